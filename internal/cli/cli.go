@@ -29,6 +29,7 @@ import (
 	"github.com/masterchef/masterchef/internal/release"
 	"github.com/masterchef/masterchef/internal/server"
 	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
 	"github.com/masterchef/masterchef/internal/testimpact"
 	"google.golang.org/grpc"
 	"gopkg.in/yaml.v3"
@@ -73,6 +74,8 @@ func Run(args []string) error {
 		return runPolicy(args[1:])
 	case "vars":
 		return runVars(args[1:])
+	case "databag":
+		return runDataBag(args[1:])
 	case "features":
 		return runFeatures(args[1:])
 	case "docs":
@@ -102,6 +105,7 @@ masterchef commands:
   dev [-state-dir .masterchef/dev] [-addr :8080] [-grpc-addr :9090] [-dry-run]
   policy [keygen|sign|verify] ...
   vars [explain] [-f vars.layers.yaml] [-format human|json] [-hard-fail]
+  databag [encrypt|decrypt] -f item.json (-secret-file path | -secret value) [-o out.json]
   features [matrix|summary|verify] [-f features.md]
   docs [verify-examples] [-format human|json]
 `))
@@ -476,6 +480,7 @@ func runApply(args []string) error {
 	}
 
 	ex := executor.New(".")
+	attachArtifactStore(ex, p)
 	run, err := ex.Apply(p)
 	if err != nil {
 		return err
@@ -531,6 +536,7 @@ func runDeploy(args []string) error {
 	}
 
 	ex := executor.New(".")
+	attachArtifactStore(ex, p)
 	run, err := ex.Apply(p)
 	if err != nil {
 		return err
@@ -838,6 +844,24 @@ type planSelectors struct {
 	SkipTags    map[string]struct{}
 }
 
+// attachArtifactStore enables output artifact upload when the plan declares
+// any, so plain applies never create an object store.
+func attachArtifactStore(ex *executor.Executor, p *planner.Plan) {
+	declared := len(p.Artifacts) > 0
+	for _, step := range p.Steps {
+		declared = declared || len(step.Resource.Artifacts) > 0
+	}
+	if !declared {
+		return
+	}
+	store, err := storage.NewObjectStoreFromEnv(".")
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: output artifacts not collected: %v\n", err)
+		return
+	}
+	ex.SetArtifactStore(store)
+}
+
 func filterPlanBySelectors(p *planner.Plan, selectors planSelectors) *planner.Plan {
 	if p == nil {
 		return &planner.Plan{}
@@ -849,9 +873,19 @@ func filterPlanBySelectors(p *planner.Plan, selectors planSelectors) *planner.Pl
 		}
 		steps = append(steps, step)
 	}
+	artifacts := make([]planner.ArtifactStep, 0, len(p.Artifacts))
+	for _, artifact := range p.Artifacts {
+		if len(selectors.Hosts) > 0 {
+			if _, ok := selectors.Hosts[artifact.Host.Name]; !ok {
+				continue
+			}
+		}
+		artifacts = append(artifacts, artifact)
+	}
 	return &planner.Plan{
 		Execution: p.Execution,
 		Steps:     steps,
+		Artifacts: artifacts,
 	}
 }
 
@@ -1159,6 +1193,63 @@ func runVars(args []string) error {
 	}
 }
 
+func runDataBag(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("databag subcommand is required: encrypt|decrypt")
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	fs := flag.NewFlagSet("databag", flag.ContinueOnError)
+	path := fs.String("f", "", "data bag item json path")
+	out := fs.String("o", "", "output path (default stdout)")
+	secret := fs.String("secret", "", "shared secret")
+	secretFile := fs.String("secret-file", "", "path to shared secret file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*path) == "" {
+		return fmt.Errorf("-f is required")
+	}
+	key := *secret
+	if strings.TrimSpace(*secretFile) != "" {
+		raw, err := os.ReadFile(*secretFile)
+		if err != nil {
+			return err
+		}
+		key = string(raw)
+	}
+	// Chef trims trailing whitespace from secret files.
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("-secret or -secret-file is required")
+	}
+	raw, err := os.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+	var item map[string]any
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return fmt.Errorf("data bag item must be a json object")
+	}
+	var result map[string]any
+	switch sub {
+	case "encrypt":
+		result, err = control.EncryptChefDataBagItem(item, key)
+	case "decrypt":
+		result, err = control.DecryptChefDataBagItem(item, key)
+	default:
+		return fmt.Errorf("unknown databag subcommand %q", sub)
+	}
+	if err != nil {
+		return err
+	}
+	b, _ := json.MarshalIndent(result, "", "  ")
+	if strings.TrimSpace(*out) == "" {
+		fmt.Println(string(b))
+		return nil
+	}
+	return os.WriteFile(*out, append(b, '\n'), 0o600)
+}
+
 func runDocs(args []string) error {
 	sub := "verify-examples"
 	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
@@ -1173,12 +1264,17 @@ func runDocs(args []string) error {
 
 	switch sub {
 	case "verify-examples":
-		report := control.VerifyActionDocExamples(control.NewActionDocCatalog().List(), nil)
+		// Without a control plane, config examples are planned and API
+		// examples are checked but not served.
+		report, err := control.NewDocsExampleVerifier(control.NewActionDocCatalog(), "").Verify(nil, nil)
+		if err != nil {
+			return err
+		}
 		if strings.EqualFold(strings.TrimSpace(*format), "json") {
 			b, _ := json.MarshalIndent(report, "", "  ")
 			fmt.Println(string(b))
 		} else {
-			fmt.Printf("checked=%d passed=%t\n", report.Checked, report.Passed)
+			fmt.Printf("checked=%d executed=%d passed=%t\n", report.Checked, report.Executed, report.Passed)
 			for _, item := range report.Failures {
 				fmt.Printf("- %s\n", item)
 			}
@@ -1285,7 +1381,8 @@ func serveRuntime(addr, grpcAddr, baseDir string) error {
 	go func() {
 		errCh <- s.ListenAndServe()
 	}()
-	fmt.Printf("server listening on %s\n", addr)
+	logger := s.Logger()
+	logger.Info("server listening", "addr", addr)
 
 	var grpcServer *grpc.Server
 	var grpcLis net.Listener
@@ -1299,15 +1396,15 @@ func serveRuntime(addr, grpcAddr, baseDir string) error {
 		go func() {
 			errCh <- g.Serve(lis)
 		}()
-		fmt.Printf("grpc listening on %s\n", grpcAddr)
+		logger.Info("grpc listening", "addr", grpcAddr)
 	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	select {
 	case sig := <-sigCh:
-		fmt.Printf("received signal %s, shutting down\n", sig.String())
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		logger.Info("shutting down", "signal", sig.String())
+		ctx, cancel := context.WithTimeout(context.Background(), s.DrainTimeout()+5*time.Second)
 		defer cancel()
 		if grpcServer != nil {
 			done := make(chan struct{})
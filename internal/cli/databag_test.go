@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDataBagEncryptDecrypt(t *testing.T) {
+	tmp := t.TempDir()
+	itemPath := filepath.Join(tmp, "item.json")
+	secretPath := filepath.Join(tmp, "secret")
+	encPath := filepath.Join(tmp, "item.enc.json")
+	decPath := filepath.Join(tmp, "item.dec.json")
+	if err := os.WriteFile(itemPath, []byte(`{"id":"db","password":"s3cr3t"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(secretPath, []byte("shared-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runDataBag([]string{"encrypt", "-f", itemPath, "-secret-file", secretPath, "-o", encPath}); err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	raw, err := os.ReadFile(encPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enc map[string]any
+	if err := json.Unmarshal(raw, &enc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := enc["password"].(map[string]any); !ok {
+		t.Fatalf("expected encrypted envelope, got %#v", enc["password"])
+	}
+	if err := runDataBag([]string{"decrypt", "-f", encPath, "-secret", "shared-secret", "-o", decPath}); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	raw, _ = os.ReadFile(decPath)
+	var dec map[string]any
+	if err := json.Unmarshal(raw, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec["password"] != "s3cr3t" {
+		t.Fatalf("unexpected decrypted item %#v", dec)
+	}
+	if err := runDataBag([]string{"decrypt", "-f", encPath, "-secret", "wrong"}); err == nil {
+		t.Fatalf("expected wrong secret to fail")
+	}
+}
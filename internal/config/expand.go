@@ -28,12 +28,15 @@ func expandResourceCollection(resources []Resource) []Resource {
 		if len(combos) == 0 {
 			combos = []map[string]string{{}}
 		}
+		factWhen := WhenReferencesFacts(in.When)
 		for _, vars := range combos {
-			if !evaluateResourceWhen(in.When, vars) {
+			if !factWhen && !evaluateResourceWhen(in.When, vars) {
 				continue
 			}
 			res := cloneResource(in)
-			res.When = ""
+			if !factWhen {
+				res.When = ""
+			}
 			res.Matrix = nil
 			res.Loop = nil
 			res.LoopVar = ""
@@ -130,6 +133,33 @@ func matrixCombinations(matrix map[string][]string) []map[string]string {
 	return combos
 }
 
+// EvaluateWhen reports whether a when expression holds for vars, using the
+// same ==, != and truthiness rules as resource conditions.
+func EvaluateWhen(when string, vars map[string]string) bool {
+	return evaluateResourceWhen(when, vars)
+}
+
+// WhenReferencesFacts reports whether a when expression reads host facts
+// ("facts.<name>"). Such conditions are kept on the resource at load time
+// and evaluated per host when the plan is built.
+func WhenReferencesFacts(when string) bool {
+	expr := strings.TrimSpace(when)
+	if expr == "" {
+		return false
+	}
+	for _, op := range []string{"==", "!="} {
+		if strings.Contains(expr, op) {
+			parts := strings.SplitN(expr, op, 2)
+			return isFactOperand(parts[0]) || isFactOperand(parts[1])
+		}
+	}
+	return isFactOperand(expr)
+}
+
+func isFactOperand(token string) bool {
+	return strings.HasPrefix(strings.TrimSpace(token), "facts.")
+}
+
 func evaluateResourceWhen(when string, vars map[string]string) bool {
 	expr := strings.TrimSpace(when)
 	if expr == "" {
@@ -172,6 +202,10 @@ func resolveWhenOperand(token string, vars map[string]string) string {
 	if v, ok := vars[token]; ok {
 		return v
 	}
+	if isFactOperand(token) {
+		// Unknown facts are empty, so a missing fact never reads as truthy.
+		return ""
+	}
 	return token
 }
 
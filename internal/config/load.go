@@ -26,6 +26,16 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// LoadRaw parses a single config file without resolving its includes,
+// imports, or overlays, so callers can inspect composition references.
+func LoadRaw(path string) (*Config, error) {
+	cfg, err := parseConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
 func loadComposedConfig(path string, stack map[string]bool) (*Config, error) {
 	resolved, err := filepath.Abs(path)
 	if err != nil {
@@ -117,6 +127,8 @@ func mergeConfig(dst *Config, src *Config) {
 	mergeExecution(&dst.Execution, src.Execution)
 	mergeResources(&dst.Resources, src.Resources)
 	mergeResources(&dst.Handlers, src.Handlers)
+	mergeCollectors(&dst.Collect, src.Collect)
+	mergeArtifacts(&dst.Artifacts, src.Artifacts)
 }
 
 func mergeInventory(dst *Inventory, src Inventory) {
@@ -176,6 +188,42 @@ func mergeResources(dst *[]Resource, src []Resource) {
 	}
 }
 
+func mergeCollectors(dst *[]Collector, src []Collector) {
+	if dst == nil {
+		return
+	}
+	seen := map[Collector]struct{}{}
+	for _, c := range *dst {
+		seen[c] = struct{}{}
+	}
+	for _, c := range src {
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		*dst = append(*dst, c)
+	}
+}
+
+func mergeArtifacts(dst *[]OutputArtifact, src []OutputArtifact) {
+	if dst == nil {
+		return
+	}
+	index := map[string]int{}
+	for i, a := range *dst {
+		index[a.Host+"/"+a.Name] = i
+	}
+	for _, a := range src {
+		key := a.Host + "/" + a.Name
+		if i, ok := index[key]; ok {
+			(*dst)[i] = a
+			continue
+		}
+		index[key] = len(*dst)
+		*dst = append(*dst, a)
+	}
+}
+
 func cloneConfig(in Config) Config {
 	out := in
 	out.Includes = append([]string{}, in.Includes...)
@@ -193,6 +241,8 @@ func cloneConfig(in Config) Config {
 	for _, handler := range in.Handlers {
 		out.Handlers = append(out.Handlers, cloneResource(handler))
 	}
+	out.Collect = append([]Collector{}, in.Collect...)
+	out.Artifacts = append([]OutputArtifact{}, in.Artifacts...)
 	return out
 }
 
@@ -201,6 +251,7 @@ func cloneHost(in Host) Host {
 	out.Capabilities = append([]string{}, in.Capabilities...)
 	out.Roles = append([]string{}, in.Roles...)
 	out.Labels = cloneStringMap(in.Labels)
+	out.Facts = cloneStringMap(in.Facts)
 	if len(in.Topology) == 0 {
 		out.Topology = map[string]string{}
 	} else {
@@ -230,6 +281,7 @@ func cloneResource(in Resource) Resource {
 		out.Matrix = map[string][]string{}
 	}
 	out.Loop = append([]string{}, in.Loop...)
+	out.Artifacts = append([]OutputArtifact{}, in.Artifacts...)
 	return out
 }
 
@@ -147,3 +147,39 @@ resources:
 		}
 	}
 }
+
+func TestLoad_FactWhenIsDeferredToPlanTime(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "facts.yaml")
+	if err := os.WriteFile(cfgPath, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+      facts:
+        os_family: debian
+resources:
+  - id: apt-{{pkg}}
+    type: command
+    host: localhost
+    when: facts.os_family == debian
+    matrix:
+      pkg: [curl, jq]
+    command: "echo {{pkg}}"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load fact-conditioned config failed: %v", err)
+	}
+	if len(cfg.Resources) != 2 || cfg.Resources[0].When != "facts.os_family == debian" {
+		t.Fatalf("expected fact condition kept on expanded resources, got %+v", cfg.Resources)
+	}
+	if cfg.Inventory.Hosts[0].Facts["os_family"] != "debian" {
+		t.Fatalf("expected static host facts loaded, got %+v", cfg.Inventory.Hosts[0])
+	}
+	if !EvaluateWhen("facts.missing == ''", nil) || EvaluateWhen("facts.missing", nil) {
+		t.Fatalf("expected unknown facts to evaluate as empty")
+	}
+}
@@ -2,14 +2,35 @@ package config
 
 // Config is the top-level desired state model for a Masterchef run.
 type Config struct {
-	Version   string     `json:"version" yaml:"version"`
-	Includes  []string   `json:"includes,omitempty" yaml:"includes,omitempty"`
-	Imports   []string   `json:"imports,omitempty" yaml:"imports,omitempty"`
-	Overlays  []string   `json:"overlays,omitempty" yaml:"overlays,omitempty"`
-	Inventory Inventory  `json:"inventory" yaml:"inventory"`
-	Execution Execution  `json:"execution,omitempty" yaml:"execution,omitempty"`
-	Resources []Resource `json:"resources" yaml:"resources"`
-	Handlers  []Resource `json:"handlers,omitempty" yaml:"handlers,omitempty"`
+	Version   string           `json:"version" yaml:"version"`
+	Includes  []string         `json:"includes,omitempty" yaml:"includes,omitempty"`
+	Imports   []string         `json:"imports,omitempty" yaml:"imports,omitempty"`
+	Overlays  []string         `json:"overlays,omitempty" yaml:"overlays,omitempty"`
+	Inventory Inventory        `json:"inventory" yaml:"inventory"`
+	Execution Execution        `json:"execution,omitempty" yaml:"execution,omitempty"`
+	Resources []Resource       `json:"resources" yaml:"resources"`
+	Handlers  []Resource       `json:"handlers,omitempty" yaml:"handlers,omitempty"`
+	Collect   []Collector      `json:"collect,omitempty" yaml:"collect,omitempty"`
+	Artifacts []OutputArtifact `json:"artifacts,omitempty" yaml:"artifacts,omitempty"`
+}
+
+// OutputArtifact is a host-side file (log, generated report) uploaded to the
+// object store after a run and linked from the run record. Resource-level
+// artifacts are collected from the resource's execution host; run-level
+// artifacts name their Host explicitly.
+type OutputArtifact struct {
+	Name     string `json:"name" yaml:"name"`
+	Path     string `json:"path" yaml:"path"`
+	Host     string `json:"host,omitempty" yaml:"host,omitempty"`
+	MaxBytes int64  `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+	Optional bool   `json:"optional,omitempty" yaml:"optional,omitempty"` // missing file is not a failure
+}
+
+// Collector realizes exported resources matching Selector (e.g.
+// "type=file and tag=monitoring") into the catalog of Host.
+type Collector struct {
+	Host     string `json:"host" yaml:"host"`
+	Selector string `json:"selector" yaml:"selector"`
 }
 
 type Inventory struct {
@@ -30,6 +51,7 @@ type Host struct {
 	Labels       map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 	Roles        []string          `json:"roles,omitempty" yaml:"roles,omitempty"`
 	Topology     map[string]string `json:"topology,omitempty" yaml:"topology,omitempty"`
+	Facts        map[string]string `json:"facts,omitempty" yaml:"facts,omitempty"` // static facts for plan-time when conditions
 }
 
 // Resource is a compact typed resource model for v0.
@@ -50,6 +72,8 @@ type Resource struct {
 	Loop           []string            `json:"loop,omitempty" yaml:"loop,omitempty"`
 	LoopVar        string              `json:"loop_var,omitempty" yaml:"loop_var,omitempty"`
 	Tags           []string            `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Export         bool                `json:"export,omitempty" yaml:"export,omitempty"` // publish for collection instead of applying on Host
+	Artifacts      []OutputArtifact    `json:"artifacts,omitempty" yaml:"artifacts,omitempty"`
 
 	// file
 	Path                 string `json:"path,omitempty" yaml:"path,omitempty"`
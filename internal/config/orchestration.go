@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OrchestrationKind marks a file as a multi-host orchestration config rather
+// than a per-host desired state config.
+const OrchestrationKind = "orchestration"
+
+// Orchestration is an ordered list of cross-host phases, each applying a
+// per-host config to the hosts its target expression selects (e.g. drain the
+// load balancer on A, upgrade B, verify, re-add A).
+type Orchestration struct {
+	Kind        string               `json:"kind" yaml:"kind"`
+	Version     string               `json:"version" yaml:"version"`
+	Name        string               `json:"name" yaml:"name"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Phases      []OrchestrationPhase `json:"phases" yaml:"phases"`
+}
+
+// OrchestrationPhase applies Config to the hosts matching Target. Config
+// paths are relative to the orchestration file.
+type OrchestrationPhase struct {
+	Name           string `json:"name" yaml:"name"`
+	Config         string `json:"config" yaml:"config"`
+	Target         string `json:"target,omitempty" yaml:"target,omitempty"`
+	FailurePolicy  string `json:"failure_policy,omitempty" yaml:"failure_policy,omitempty"` // abort, continue, rollback
+	RollbackConfig string `json:"rollback_config,omitempty" yaml:"rollback_config,omitempty"`
+	RollbackTarget string `json:"rollback_target,omitempty" yaml:"rollback_target,omitempty"` // defaults to Target
+}
+
+// LoadOrchestration parses and validates an orchestration file and resolves
+// its phase config paths against the file's directory.
+func LoadOrchestration(p string) (*Orchestration, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read orchestration: %w", err)
+	}
+	var orch Orchestration
+	if strings.ToLower(filepath.Ext(p)) == ".json" {
+		if err := json.Unmarshal(b, &orch); err != nil {
+			return nil, fmt.Errorf("parse json orchestration: %w", err)
+		}
+	} else if err := yaml.Unmarshal(b, &orch); err != nil {
+		return nil, fmt.Errorf("parse yaml orchestration: %w", err)
+	}
+	if err := ValidateOrchestration(&orch); err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Dir(p)
+	for i := range orch.Phases {
+		orch.Phases[i].Config = resolveConfigRef(baseDir, orch.Phases[i].Config)
+		orch.Phases[i].RollbackConfig = resolveConfigRef(baseDir, orch.Phases[i].RollbackConfig)
+	}
+	return &orch, nil
+}
+
+// ValidateOrchestration checks the phase list and normalizes failure policies
+// and rollback targets in place.
+func ValidateOrchestration(orch *Orchestration) error {
+	if orch == nil {
+		return fmt.Errorf("orchestration is nil")
+	}
+	if strings.ToLower(strings.TrimSpace(orch.Kind)) != OrchestrationKind {
+		return fmt.Errorf("kind must be %q", OrchestrationKind)
+	}
+	orch.Kind = OrchestrationKind
+	if orch.Version != "" && orch.Version != "v0" {
+		return fmt.Errorf("unsupported version %q", orch.Version)
+	}
+	if strings.TrimSpace(orch.Name) == "" {
+		return fmt.Errorf("orchestration name is required")
+	}
+	if len(orch.Phases) == 0 {
+		return fmt.Errorf("orchestration must include at least one phase")
+	}
+	seen := map[string]struct{}{}
+	for i := range orch.Phases {
+		ph := &orch.Phases[i]
+		ph.Name = strings.TrimSpace(ph.Name)
+		if ph.Name == "" {
+			return fmt.Errorf("phases[%d].name is required", i)
+		}
+		if _, ok := seen[ph.Name]; ok {
+			return fmt.Errorf("duplicate phase name %q", ph.Name)
+		}
+		seen[ph.Name] = struct{}{}
+		if strings.TrimSpace(ph.Config) == "" {
+			return fmt.Errorf("phase %q config is required", ph.Name)
+		}
+		if _, err := ParseTargetExpression(ph.Target); err != nil {
+			return fmt.Errorf("phase %q target: %w", ph.Name, err)
+		}
+		ph.FailurePolicy = strings.ToLower(strings.TrimSpace(ph.FailurePolicy))
+		switch ph.FailurePolicy {
+		case "":
+			ph.FailurePolicy = "abort"
+		case "abort", "continue":
+		case "rollback":
+			if strings.TrimSpace(ph.RollbackConfig) == "" {
+				return fmt.Errorf("phase %q failure_policy rollback requires rollback_config", ph.Name)
+			}
+		default:
+			return fmt.Errorf("phase %q failure_policy must be abort, continue, or rollback", ph.Name)
+		}
+		if ph.FailurePolicy != "rollback" && strings.TrimSpace(ph.RollbackConfig) != "" {
+			return fmt.Errorf("phase %q rollback_config requires failure_policy rollback", ph.Name)
+		}
+		if strings.TrimSpace(ph.RollbackTarget) == "" {
+			ph.RollbackTarget = ph.Target
+		}
+		if _, err := ParseTargetExpression(ph.RollbackTarget); err != nil {
+			return fmt.Errorf("phase %q rollback_target: %w", ph.Name, err)
+		}
+	}
+	return nil
+}
+
+// TargetExpression selects inventory hosts. It is a comma-separated list of
+// terms using the same group syntax as plan/apply filters: a host name glob
+// ("web-*"), "host:<glob>", "role:<role>", "label:<key>=<value>", or
+// "topology:<key>=<value>". A host matches when it matches any positive term
+// (or there are none) and no term prefixed with "!". An empty expression
+// selects every host.
+type TargetExpression struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+func ParseTargetExpression(raw string) (TargetExpression, error) {
+	var out TargetExpression
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return out, nil
+	}
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.ToLower(strings.TrimSpace(term))
+		negate := strings.HasPrefix(term, "!")
+		term = strings.TrimSpace(strings.TrimPrefix(term, "!"))
+		if term == "" {
+			return TargetExpression{}, fmt.Errorf("empty target term in %q", raw)
+		}
+		if _, err := path.Match(strings.TrimPrefix(term, "host:"), ""); err != nil {
+			return TargetExpression{}, fmt.Errorf("invalid target term %q: %w", term, err)
+		}
+		if negate {
+			out.Exclude = append(out.Exclude, term)
+		} else {
+			out.Include = append(out.Include, term)
+		}
+	}
+	return out, nil
+}
+
+func (e TargetExpression) Matches(host Host) bool {
+	for _, term := range e.Exclude {
+		if targetTermMatches(host, term) {
+			return false
+		}
+	}
+	if len(e.Include) == 0 {
+		return true
+	}
+	for _, term := range e.Include {
+		if targetTermMatches(host, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveTarget returns the names of cfg's inventory hosts that expr selects,
+// in inventory order.
+func ResolveTarget(cfg *Config, expr TargetExpression) []string {
+	if cfg == nil {
+		return nil
+	}
+	out := make([]string, 0, len(cfg.Inventory.Hosts))
+	for _, h := range cfg.Inventory.Hosts {
+		if expr.Matches(h) {
+			out = append(out, h.Name)
+		}
+	}
+	return out
+}
+
+// RestrictToHosts returns a copy of cfg that only applies the resources and
+// collectors of the named hosts. The full inventory and handlers are kept so
+// delegation still resolves; relationships to dropped resources are removed.
+func RestrictToHosts(cfg *Config, hosts []string) *Config {
+	out := cloneConfig(*cfg)
+	keepHost := map[string]struct{}{}
+	for _, h := range hosts {
+		keepHost[h] = struct{}{}
+	}
+	kept := map[string]struct{}{}
+	resources := make([]Resource, 0, len(out.Resources))
+	for _, r := range out.Resources {
+		if _, ok := keepHost[r.Host]; ok {
+			kept[r.ID] = struct{}{}
+			resources = append(resources, r)
+		}
+	}
+	prune := func(refs []string) []string {
+		var keep []string
+		for _, ref := range refs {
+			if _, ok := kept[ref]; ok {
+				keep = append(keep, ref)
+			}
+		}
+		return keep
+	}
+	for i := range resources {
+		resources[i].DependsOn = prune(resources[i].DependsOn)
+		resources[i].Require = prune(resources[i].Require)
+		resources[i].Before = prune(resources[i].Before)
+		resources[i].Notify = prune(resources[i].Notify)
+		resources[i].Subscribe = prune(resources[i].Subscribe)
+	}
+	out.Resources = resources
+	collect := make([]Collector, 0, len(out.Collect))
+	for _, c := range out.Collect {
+		if _, ok := keepHost[c.Host]; ok {
+			collect = append(collect, c)
+		}
+	}
+	out.Collect = collect
+	return &out
+}
+
+func targetTermMatches(host Host, term string) bool {
+	name := strings.ToLower(strings.TrimSpace(host.Name))
+	if strings.HasPrefix(term, "host:") {
+		ok, _ := path.Match(strings.TrimPrefix(term, "host:"), name)
+		return ok
+	}
+	if strings.HasPrefix(term, "role:") {
+		role := strings.TrimPrefix(term, "role:")
+		for _, r := range host.Roles {
+			if strings.ToLower(strings.TrimSpace(r)) == role {
+				return true
+			}
+		}
+		return false
+	}
+	if strings.HasPrefix(term, "label:") {
+		return matchesTargetKV(host.Labels, strings.TrimPrefix(term, "label:"))
+	}
+	if strings.HasPrefix(term, "topology:") {
+		return matchesTargetKV(host.Topology, strings.TrimPrefix(term, "topology:"))
+	}
+	ok, _ := path.Match(term, name)
+	return ok
+}
+
+func matchesTargetKV(values map[string]string, selector string) bool {
+	key, want, hasValue := strings.Cut(selector, "=")
+	key = strings.TrimSpace(key)
+	for k, v := range values {
+		if strings.ToLower(strings.TrimSpace(k)) != key {
+			continue
+		}
+		return !hasValue || strings.ToLower(strings.TrimSpace(v)) == strings.TrimSpace(want)
+	}
+	return false
+}
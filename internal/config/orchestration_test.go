@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadOrchestrationValidatesPhases(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(tmp, name)
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	orch, err := LoadOrchestration(write("rolling.yaml", `kind: orchestration
+name: rolling-web
+phases:
+  - name: drain-a
+    config: lb.yaml
+    target: web-a
+  - name: upgrade-b
+    config: upgrade.yaml
+    target: "role:web,!web-a"
+    failure_policy: rollback
+    rollback_config: lb-readd.yaml
+    rollback_target: web-a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orch.Phases[0].FailurePolicy != "abort" || orch.Phases[0].Config != filepath.Join(tmp, "lb.yaml") {
+		t.Fatalf("expected default policy and resolved config path, got %+v", orch.Phases[0])
+	}
+	if orch.Phases[1].RollbackConfig != filepath.Join(tmp, "lb-readd.yaml") || orch.Phases[1].RollbackTarget != "web-a" {
+		t.Fatalf("unexpected rollback phase %+v", orch.Phases[1])
+	}
+
+	cases := map[string]string{
+		"version: v0\nname: x\nphases: [{name: a, config: a.yaml}]\n":                                    "kind must be",
+		"kind: orchestration\nname: x\nphases: [{name: a, config: a.yaml}, {name: a, config: b.yaml}]\n": "duplicate phase",
+		"kind: orchestration\nname: x\nphases: [{name: a, config: a.yaml, failure_policy: rollback}]\n":  "requires rollback_config",
+		"kind: orchestration\nname: x\nphases: [{name: a, config: a.yaml, failure_policy: retry}]\n":     "failure_policy must be",
+		"kind: orchestration\nname: x\nphases: [{name: a, config: a.yaml, target: \"web-[\"}]\n":         "invalid target term",
+		"kind: orchestration\nname: x\nphases: [{name: a, config: a.yaml, rollback_config: b.yaml}]\n":   "requires failure_policy rollback",
+	}
+	for body, want := range cases {
+		if _, err := LoadOrchestration(write("bad.yaml", body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q for %q, got %v", want, body, err)
+		}
+	}
+}
+
+func TestTargetExpressionAndRestrictToHosts(t *testing.T) {
+	cfg := &Config{
+		Version: "v0",
+		Inventory: Inventory{Hosts: []Host{
+			{Name: "web-a", Transport: "local", Roles: []string{"web"}, Labels: map[string]string{"lb": "blue"}},
+			{Name: "web-b", Transport: "local", Roles: []string{"web"}, Topology: map[string]string{"zone": "us-east-1b"}},
+			{Name: "db-1", Transport: "local", Roles: []string{"db"}},
+		}},
+		Resources: []Resource{
+			{ID: "pkg-a", Type: "command", Host: "web-a", Command: "true"},
+			{ID: "pkg-b", Type: "command", Host: "web-b", Command: "true", DependsOn: []string{"pkg-a"}},
+			{ID: "svc-b", Type: "command", Host: "web-b", Command: "true", Require: []string{"pkg-b"}},
+			{ID: "db", Type: "command", Host: "db-1", Command: "true"},
+		},
+	}
+	resolve := func(raw string) string {
+		expr, err := ParseTargetExpression(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(ResolveTarget(cfg, expr), ",")
+	}
+	for raw, want := range map[string]string{
+		"":                              "web-a,web-b,db-1",
+		"web-*":                         "web-a,web-b",
+		"role:web,!label:lb=blue":       "web-b",
+		"topology:zone=us-east-1b,db-1": "web-b,db-1",
+		"host:db-?":                     "db-1",
+		"!role:web":                     "db-1",
+	} {
+		if got := resolve(raw); got != want {
+			t.Fatalf("target %q: expected %s, got %s", raw, want, got)
+		}
+	}
+
+	out := RestrictToHosts(cfg, []string{"web-b"})
+	if len(out.Resources) != 2 || out.Resources[0].ID != "pkg-b" || len(out.Resources[0].DependsOn) != 0 || out.Resources[1].Require[0] != "pkg-b" {
+		t.Fatalf("expected web-b resources with dropped cross-host deps, got %+v", out.Resources)
+	}
+	if len(out.Inventory.Hosts) != 3 || len(cfg.Resources[1].DependsOn) != 1 {
+		t.Fatalf("expected inventory kept and source config untouched")
+	}
+	if err := Validate(out); err != nil {
+		t.Fatalf("expected restricted config to validate: %v", err)
+	}
+}
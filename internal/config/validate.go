@@ -156,6 +156,9 @@ func Validate(cfg *Config) error {
 		if err := normalizeLoop(r, fmt.Sprintf("resource %q", r.ID)); err != nil {
 			return err
 		}
+		if err := normalizeArtifacts(r.Artifacts, fmt.Sprintf("resource %q", r.ID), nil); err != nil {
+			return err
+		}
 		r.BecomeUser = strings.TrimSpace(r.BecomeUser)
 		if r.BecomeUser != "" {
 			r.Become = true
@@ -411,8 +414,45 @@ func Validate(cfg *Config) error {
 		}
 	}
 
+	exported := map[string]struct{}{}
+	for _, r := range cfg.Resources {
+		if r.Export {
+			exported[r.ID] = struct{}{}
+		}
+	}
+	for i := range cfg.Collect {
+		c := &cfg.Collect[i]
+		c.Host = strings.TrimSpace(c.Host)
+		c.Selector = strings.TrimSpace(c.Selector)
+		if _, ok := hostSet[c.Host]; !ok {
+			return fmt.Errorf("collect[%d] references unknown host %q", i, c.Host)
+		}
+		if c.Selector == "" {
+			return fmt.Errorf("collect[%d].selector is required", i)
+		}
+	}
+
+	if err := normalizeArtifacts(cfg.Artifacts, "artifacts", hostSet); err != nil {
+		return err
+	}
+
 	notifiedBy := map[string]struct{}{}
 	for _, r := range cfg.Resources {
+		if _, ok := exported[r.ID]; ok {
+			if len(r.DependsOn) > 0 || len(r.Require) > 0 || len(r.Subscribe) > 0 || len(r.Before) > 0 || len(r.Notify) > 0 || len(r.NotifyHandlers) > 0 {
+				return fmt.Errorf("exported resource %q cannot declare relationships", r.ID)
+			}
+		}
+		refs := append([]string{}, r.DependsOn...)
+		refs = append(refs, r.Require...)
+		refs = append(refs, r.Subscribe...)
+		refs = append(refs, r.Before...)
+		refs = append(refs, r.Notify...)
+		for _, ref := range refs {
+			if _, ok := exported[ref]; ok {
+				return fmt.Errorf("resource %q references exported resource %q", r.ID, ref)
+			}
+		}
 		for _, dep := range r.DependsOn {
 			if _, ok := resSet[dep]; !ok {
 				return fmt.Errorf("resource %q depends on unknown resource %q", r.ID, dep)
@@ -528,6 +568,53 @@ func normalizeLoop(resource *Resource, owner string) error {
 	return nil
 }
 
+// normalizeArtifacts validates output artifact declarations. When hostSet is
+// non-nil every artifact must name a known host; otherwise Host must be empty
+// because the artifact follows its resource.
+func normalizeArtifacts(artifacts []OutputArtifact, owner string, hostSet map[string]struct{}) error {
+	names := map[string]struct{}{}
+	for i := range artifacts {
+		a := &artifacts[i]
+		a.Name = strings.TrimSpace(a.Name)
+		a.Path = strings.TrimSpace(a.Path)
+		a.Host = strings.TrimSpace(a.Host)
+		if a.Name == "" {
+			return fmt.Errorf("%s artifacts[%d].name is required", owner, i)
+		}
+		if strings.ContainsAny(a.Name, "/\\ ") {
+			return fmt.Errorf("%s artifact %q name must not contain slashes or spaces", owner, a.Name)
+		}
+		if _, ok := names[a.Host+"/"+a.Name]; ok {
+			return fmt.Errorf("%s has duplicate artifact %q", owner, a.Name)
+		}
+		names[a.Host+"/"+a.Name] = struct{}{}
+		if !isAbsArtifactPath(a.Path) {
+			return fmt.Errorf("%s artifact %q path must be absolute", owner, a.Name)
+		}
+		if a.MaxBytes < 0 {
+			return fmt.Errorf("%s artifact %q max_bytes must be >= 0", owner, a.Name)
+		}
+		if hostSet == nil {
+			if a.Host != "" {
+				return fmt.Errorf("%s artifact %q cannot set host; it is collected from the resource host", owner, a.Name)
+			}
+			continue
+		}
+		if _, ok := hostSet[a.Host]; !ok {
+			return fmt.Errorf("%s artifact %q references unknown host %q", owner, a.Name, a.Host)
+		}
+	}
+	return nil
+}
+
+func isAbsArtifactPath(path string) bool {
+	if strings.HasPrefix(path, "/") {
+		return true
+	}
+	// Windows drive paths such as C:\logs\run.log on winrm hosts.
+	return len(path) > 2 && path[1] == ':' && (path[2] == '\\' || path[2] == '/')
+}
+
 func isSHA256Digest(v string) bool {
 	v = strings.TrimSpace(strings.ToLower(v))
 	if !strings.HasPrefix(v, "sha256:") {
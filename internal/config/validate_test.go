@@ -639,3 +639,76 @@ func TestValidate_FileIntegrityMetadata(t *testing.T) {
 		t.Fatalf("expected integrity metadata on non-file resource to fail")
 	}
 }
+
+func TestValidate_ExportsAndCollectors(t *testing.T) {
+	cfg := &Config{
+		Version: "v0",
+		Inventory: Inventory{
+			Hosts: []Host{{Name: "web-01", Transport: "local"}, {Name: "monitor", Transport: "local"}},
+		},
+		Resources: []Resource{
+			{ID: "check-web-01", Type: "file", Host: "web-01", Path: "/tmp/check", Export: true},
+			{ID: "local", Type: "file", Host: "web-01", Path: "/tmp/local"},
+		},
+		Collect: []Collector{{Host: " monitor ", Selector: " type=file "}},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid exports config, got %v", err)
+	}
+	if cfg.Collect[0].Host != "monitor" || cfg.Collect[0].Selector != "type=file" {
+		t.Fatalf("expected normalized collector, got %#v", cfg.Collect[0])
+	}
+
+	cfg.Resources[1].DependsOn = []string{"check-web-01"}
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for dependency on exported resource")
+	}
+	cfg.Resources[1].DependsOn = nil
+
+	cfg.Collect = []Collector{{Host: "missing", Selector: "type=file"}}
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for collector on unknown host")
+	}
+	cfg.Collect = []Collector{{Host: "monitor"}}
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for collector without selector")
+	}
+}
+
+func TestValidate_OutputArtifacts(t *testing.T) {
+	cfg := &Config{
+		Version: "v0",
+		Inventory: Inventory{
+			Hosts: []Host{{Name: "web-01", Transport: "local"}, {Name: "win-01", Transport: "winrm"}},
+		},
+		Resources: []Resource{
+			{ID: "report", Type: "command", Host: "web-01", Command: "make report", Artifacts: []OutputArtifact{{Name: " report ", Path: " /var/tmp/report.html "}}},
+		},
+		Artifacts: []OutputArtifact{{Name: "iis-log", Host: "win-01", Path: `C:\inetpub\logs\u_ex.log`}},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid artifacts config, got %v", err)
+	}
+	if got := cfg.Resources[0].Artifacts[0]; got.Name != "report" || got.Path != "/var/tmp/report.html" {
+		t.Fatalf("expected normalized artifact, got %#v", got)
+	}
+
+	cfg.Resources[0].Artifacts[0].Host = "win-01"
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for resource artifact with explicit host")
+	}
+	cfg.Resources[0].Artifacts[0].Host = ""
+	cfg.Resources[0].Artifacts[0].Path = "report.html"
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for relative artifact path")
+	}
+	cfg.Resources[0].Artifacts[0].Path = "/var/tmp/report.html"
+	cfg.Artifacts = []OutputArtifact{{Name: "log", Host: "missing", Path: "/var/log/app.log"}}
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for artifact on unknown host")
+	}
+	cfg.Artifacts = []OutputArtifact{{Name: "log", Host: "web-01", Path: "/a"}, {Name: "log", Host: "web-01", Path: "/b"}}
+	if err := Validate(cfg); err == nil {
+		t.Fatalf("expected error for duplicate artifact names")
+	}
+}
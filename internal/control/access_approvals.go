@@ -8,22 +8,38 @@ import (
 	"time"
 )
 
+// ApprovalStageRule requires RequiredApprovals approvals from actors who hold
+// one of Roles or belong to one of Teams. With neither set, anyone counts.
 type ApprovalStageRule struct {
-	Name              string `json:"name"`
-	RequiredApprovals int    `json:"required_approvals"`
+	Name              string   `json:"name"`
+	RequiredApprovals int      `json:"required_approvals"`
+	Roles             []string `json:"roles,omitempty"`
+	Teams             []string `json:"teams,omitempty"`
 }
 
+// QuorumApprovalPolicy is enforced on every change of an AppliesTo kind
+// whose scope starts with one of Scopes (all scopes when empty). Requesters
+// may not approve their own changes unless AllowSelfApproval is set, and
+// approvals older than ApprovalTTLSeconds no longer count.
 type QuorumApprovalPolicy struct {
-	ID        string              `json:"id"`
-	Name      string              `json:"name"`
-	Stages    []ApprovalStageRule `json:"stages"`
-	CreatedAt time.Time           `json:"created_at"`
-	UpdatedAt time.Time           `json:"updated_at"`
+	ID                 string              `json:"id"`
+	Name               string              `json:"name"`
+	Stages             []ApprovalStageRule `json:"stages"`
+	AppliesTo          []string            `json:"applies_to,omitempty"` // change_record|break_glass|gitops_promotion
+	Scopes             []string            `json:"scopes,omitempty"`
+	AllowSelfApproval  bool                `json:"allow_self_approval,omitempty"`
+	ApprovalTTLSeconds int                 `json:"approval_ttl_seconds,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+	UpdatedAt          time.Time           `json:"updated_at"`
 }
 
 type QuorumApprovalPolicyInput struct {
-	Name   string              `json:"name"`
-	Stages []ApprovalStageRule `json:"stages"`
+	Name               string              `json:"name"`
+	Stages             []ApprovalStageRule `json:"stages"`
+	AppliesTo          []string            `json:"applies_to,omitempty"`
+	Scopes             []string            `json:"scopes,omitempty"`
+	AllowSelfApproval  bool                `json:"allow_self_approval,omitempty"`
+	ApprovalTTLSeconds int                 `json:"approval_ttl_seconds,omitempty"`
 }
 
 type BreakGlassStatus string
@@ -46,24 +62,44 @@ type BreakGlassApproval struct {
 }
 
 type BreakGlassRequest struct {
-	ID              string               `json:"id"`
-	RequestedBy     string               `json:"requested_by"`
-	Reason          string               `json:"reason"`
-	Scope           string               `json:"scope"`
-	PolicyID        string               `json:"policy_id"`
-	PolicyName      string               `json:"policy_name"`
-	Stages          []ApprovalStageRule  `json:"stages"`
-	CurrentStage    int                  `json:"current_stage"`
-	Status          BreakGlassStatus     `json:"status"`
-	Approvals       []BreakGlassApproval `json:"approvals,omitempty"`
-	TTLSeconds      int                  `json:"ttl_seconds"`
-	CreatedAt       time.Time            `json:"created_at"`
-	UpdatedAt       time.Time            `json:"updated_at"`
-	ActivatedAt     *time.Time           `json:"activated_at,omitempty"`
-	ExpiresAt       *time.Time           `json:"expires_at,omitempty"`
-	RejectedAt      *time.Time           `json:"rejected_at,omitempty"`
-	RevokedAt       *time.Time           `json:"revoked_at,omitempty"`
-	RejectionReason string               `json:"rejection_reason,omitempty"`
+	ID                 string                `json:"id"`
+	RequestedBy        string                `json:"requested_by"`
+	Reason             string                `json:"reason"`
+	Scope              string                `json:"scope"`
+	PolicyID           string                `json:"policy_id"`
+	PolicyName         string                `json:"policy_name"`
+	Stages             []ApprovalStageRule   `json:"stages"`
+	AllowSelfApproval  bool                  `json:"allow_self_approval,omitempty"`
+	ApprovalTTLSeconds int                   `json:"approval_ttl_seconds,omitempty"`
+	CurrentStage       int                   `json:"current_stage"`
+	Status             BreakGlassStatus      `json:"status"`
+	Approvals          []BreakGlassApproval  `json:"approvals,omitempty"`
+	TTLSeconds         int                   `json:"ttl_seconds"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+	ActivatedAt        *time.Time            `json:"activated_at,omitempty"`
+	ExpiresAt          *time.Time            `json:"expires_at,omitempty"`
+	RejectedAt         *time.Time            `json:"rejected_at,omitempty"`
+	RevokedAt          *time.Time            `json:"revoked_at,omitempty"`
+	RejectionReason    string                `json:"rejection_reason,omitempty"`
+	Postmortem         *BreakGlassPostmortem `json:"postmortem,omitempty"`
+}
+
+// BreakGlassPostmortemChecklist lists the items a postmortem must confirm
+// before another break-glass request can be made.
+var BreakGlassPostmortemChecklist = []string{
+	"timeline_documented",
+	"actions_reviewed",
+	"access_removal_verified",
+	"follow_ups_filed",
+}
+
+type BreakGlassPostmortem struct {
+	Author     string          `json:"author"`
+	Summary    string          `json:"summary"`
+	Checklist  map[string]bool `json:"checklist"`
+	Links      []string        `json:"links,omitempty"`
+	AttachedAt time.Time       `json:"attached_at"`
 }
 
 type BreakGlassRequestInput struct {
@@ -80,6 +116,8 @@ type AccessApprovalStore struct {
 	nextRequest int64
 	policies    map[string]*QuorumApprovalPolicy
 	requests    map[string]*BreakGlassRequest
+	expired     []string
+	resolve     func(actor string) ApproverIdentity
 }
 
 func NewAccessApprovalStore() *AccessApprovalStore {
@@ -98,12 +136,25 @@ func (s *AccessApprovalStore) CreatePolicy(in QuorumApprovalPolicyInput) (Quorum
 	if err != nil {
 		return QuorumApprovalPolicy{}, err
 	}
+	appliesTo := normalizeStringList(in.AppliesTo)
+	for _, kind := range appliesTo {
+		if !validApprovalSubjectKind(kind) {
+			return QuorumApprovalPolicy{}, errors.New("applies_to must list change_record, break_glass, or gitops_promotion")
+		}
+	}
+	if in.ApprovalTTLSeconds < 0 {
+		return QuorumApprovalPolicy{}, errors.New("approval_ttl_seconds must be >= 0")
+	}
 	now := time.Now().UTC()
 	item := QuorumApprovalPolicy{
-		Name:      name,
-		Stages:    stages,
-		CreatedAt: now,
-		UpdatedAt: now,
+		Name:               name,
+		Stages:             stages,
+		AppliesTo:          appliesTo,
+		Scopes:             dedupeStrings(in.Scopes),
+		AllowSelfApproval:  in.AllowSelfApproval,
+		ApprovalTTLSeconds: in.ApprovalTTLSeconds,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -139,8 +190,8 @@ func (s *AccessApprovalStore) CreateBreakGlassRequest(in BreakGlassRequestInput)
 	reason := strings.TrimSpace(in.Reason)
 	scope := strings.TrimSpace(in.Scope)
 	policyID := strings.TrimSpace(in.PolicyID)
-	if requestedBy == "" || reason == "" || scope == "" || policyID == "" {
-		return BreakGlassRequest{}, errors.New("requested_by, reason, scope, and policy_id are required")
+	if requestedBy == "" || reason == "" || scope == "" {
+		return BreakGlassRequest{}, errors.New("requested_by, reason, and scope are required")
 	}
 	ttl := in.TTLSeconds
 	if ttl <= 0 {
@@ -155,25 +206,30 @@ func (s *AccessApprovalStore) CreateBreakGlassRequest(in BreakGlassRequestInput)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	policy, ok := s.policies[policyID]
-	if !ok {
-		return BreakGlassRequest{}, errors.New("approval policy not found")
+	policy, err := s.breakGlassPolicyLocked(policyID, scope)
+	if err != nil {
+		return BreakGlassRequest{}, err
+	}
+	if pending := s.postmortemDueLocked(); pending != "" {
+		return BreakGlassRequest{}, errors.New("break-glass request " + pending + " needs a postmortem before new break-glass requests are accepted")
 	}
 	now := time.Now().UTC()
 	s.nextRequest++
 	req := BreakGlassRequest{
-		ID:           "breakglass-" + itoa(s.nextRequest),
-		RequestedBy:  requestedBy,
-		Reason:       reason,
-		Scope:        scope,
-		PolicyID:     policy.ID,
-		PolicyName:   policy.Name,
-		Stages:       cloneApprovalStages(policy.Stages),
-		TTLSeconds:   ttl,
-		CurrentStage: 0,
-		Status:       BreakGlassPending,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:                 "breakglass-" + itoa(s.nextRequest),
+		RequestedBy:        requestedBy,
+		Reason:             reason,
+		Scope:              scope,
+		PolicyID:           policy.ID,
+		PolicyName:         policy.Name,
+		Stages:             cloneApprovalStages(policy.Stages),
+		TTLSeconds:         ttl,
+		CurrentStage:       0,
+		Status:             BreakGlassPending,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		AllowSelfApproval:  policy.AllowSelfApproval,
+		ApprovalTTLSeconds: policy.ApprovalTTLSeconds,
 	}
 	s.requests[req.ID] = &req
 	return cloneBreakGlassRequest(req), nil
@@ -225,8 +281,14 @@ func (s *AccessApprovalStore) ApproveBreakGlassRequest(id, actor, comment string
 		return BreakGlassRequest{}, errors.New("break-glass stage index out of range")
 	}
 	stage := req.Stages[req.CurrentStage]
+	if !req.AllowSelfApproval && strings.EqualFold(actor, req.RequestedBy) {
+		return BreakGlassRequest{}, errors.New("self-approval is not allowed by approval policy " + req.PolicyID)
+	}
+	if !s.eligibleLocked(stage, actor) {
+		return BreakGlassRequest{}, errors.New("actor is not an eligible approver for stage " + stage.Name)
+	}
 	for _, existing := range req.Approvals {
-		if existing.StageIndex == req.CurrentStage && strings.EqualFold(existing.Actor, actor) {
+		if existing.StageIndex == req.CurrentStage && strings.EqualFold(existing.Actor, actor) && !approvalStale(existing.CreatedAt, req.ApprovalTTLSeconds, now) {
 			return BreakGlassRequest{}, errors.New("actor has already approved current stage")
 		}
 	}
@@ -238,7 +300,7 @@ func (s *AccessApprovalStore) ApproveBreakGlassRequest(id, actor, comment string
 		StageName:  stage.Name,
 		CreatedAt:  now,
 	})
-	if s.countApprovalsForStage(*req, req.CurrentStage) >= stage.RequiredApprovals {
+	if s.countApprovalsForStage(*req, req.CurrentStage, now) >= stage.RequiredApprovals {
 		req.CurrentStage++
 		if req.CurrentStage >= len(req.Stages) {
 			req.Status = BreakGlassActive
@@ -325,14 +387,121 @@ func (s *AccessApprovalStore) RevokeBreakGlassRequest(id, actor, reason string)
 	return cloneBreakGlassRequest(*req), nil
 }
 
-func (s *AccessApprovalStore) countApprovalsForStage(req BreakGlassRequest, stageIndex int) int {
-	total := 0
+// ActiveBreakGlassFor returns the active break-glass request that elevates
+// principal, if any.
+func (s *AccessApprovalStore) ActiveBreakGlassFor(principal string) (BreakGlassRequest, bool) {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return BreakGlassRequest{}, false
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireBreakGlassRequestsLocked(now)
+	for _, req := range s.requests {
+		if req.Status == BreakGlassActive && req.RequestedBy == principal {
+			return cloneBreakGlassRequest(*req), true
+		}
+	}
+	return BreakGlassRequest{}, false
+}
+
+// ExpireBreakGlassRequests expires active requests past their deadline and
+// returns every request that expired since the previous call, including
+// those expired lazily by reads.
+func (s *AccessApprovalStore) ExpireBreakGlassRequests() []BreakGlassRequest {
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireBreakGlassRequestsLocked(now)
+	out := make([]BreakGlassRequest, 0, len(s.expired))
+	for _, id := range s.expired {
+		if req, ok := s.requests[id]; ok {
+			out = append(out, cloneBreakGlassRequest(*req))
+		}
+	}
+	s.expired = nil
+	return out
+}
+
+// AttachBreakGlassPostmortem records the postmortem for a request whose
+// elevated mode has ended. Every checklist item must be confirmed.
+func (s *AccessApprovalStore) AttachBreakGlassPostmortem(id string, in BreakGlassPostmortem) (BreakGlassRequest, error) {
+	id = strings.TrimSpace(id)
+	in.Author = strings.TrimSpace(in.Author)
+	in.Summary = strings.TrimSpace(in.Summary)
+	if in.Author == "" || in.Summary == "" {
+		return BreakGlassRequest{}, errors.New("author and summary are required")
+	}
+	checklist := map[string]bool{}
+	for _, item := range BreakGlassPostmortemChecklist {
+		if !in.Checklist[item] {
+			return BreakGlassRequest{}, errors.New("postmortem checklist item " + item + " must be confirmed")
+		}
+		checklist[item] = true
+	}
+	links := []string{}
+	for _, link := range in.Links {
+		if link = strings.TrimSpace(link); link != "" {
+			links = append(links, link)
+		}
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireBreakGlassRequestsLocked(now)
+	req, ok := s.requests[id]
+	if !ok {
+		return BreakGlassRequest{}, errors.New("break-glass request not found")
+	}
+	if req.ActivatedAt == nil || (req.Status != BreakGlassExpired && req.Status != BreakGlassRevoked) {
+		return BreakGlassRequest{}, errors.New("postmortem can only be attached after elevated mode has ended")
+	}
+	if req.Postmortem != nil {
+		return BreakGlassRequest{}, errors.New("postmortem already attached")
+	}
+	req.Postmortem = &BreakGlassPostmortem{
+		Author:     in.Author,
+		Summary:    in.Summary,
+		Checklist:  checklist,
+		Links:      links,
+		AttachedAt: now,
+	}
+	req.UpdatedAt = now
+	return cloneBreakGlassRequest(*req), nil
+}
+
+// postmortemDueLocked returns the oldest request whose elevated mode ended
+// without a postmortem.
+func (s *AccessApprovalStore) postmortemDueLocked() string {
+	s.expireBreakGlassRequestsLocked(time.Now().UTC())
+	due := ""
+	var dueAt time.Time
+	for _, req := range s.requests {
+		if req.ActivatedAt == nil || req.Postmortem != nil {
+			continue
+		}
+		if req.Status != BreakGlassExpired && req.Status != BreakGlassRevoked {
+			continue
+		}
+		if due == "" || req.ActivatedAt.Before(dueAt) {
+			due, dueAt = req.ID, *req.ActivatedAt
+		}
+	}
+	return due
+}
+
+// countApprovalsForStage counts the distinct actors whose approval of the
+// stage has not gone stale.
+func (s *AccessApprovalStore) countApprovalsForStage(req BreakGlassRequest, stageIndex int, now time.Time) int {
+	seen := map[string]bool{}
 	for _, item := range req.Approvals {
-		if item.StageIndex == stageIndex && item.Decision == "approve" {
-			total++
+		if item.StageIndex != stageIndex || item.Decision != "approve" || approvalStale(item.CreatedAt, req.ApprovalTTLSeconds, now) {
+			continue
 		}
+		seen[strings.ToLower(item.Actor)] = true
 	}
-	return total
+	return len(seen)
 }
 
 func (s *AccessApprovalStore) expireBreakGlassRequestsLocked(now time.Time) {
@@ -343,6 +512,7 @@ func (s *AccessApprovalStore) expireBreakGlassRequestsLocked(now time.Time) {
 		if !now.Before(*req.ExpiresAt) {
 			req.Status = BreakGlassExpired
 			req.UpdatedAt = now
+			s.expired = append(s.expired, req.ID)
 		}
 	}
 }
@@ -364,6 +534,8 @@ func normalizeApprovalStages(in []ApprovalStageRule) ([]ApprovalStageRule, error
 		out = append(out, ApprovalStageRule{
 			Name:              name,
 			RequiredApprovals: required,
+			Roles:             normalizeStringList(stage.Roles),
+			Teams:             normalizeStringList(stage.Teams),
 		})
 	}
 	return out, nil
@@ -372,12 +544,18 @@ func normalizeApprovalStages(in []ApprovalStageRule) ([]ApprovalStageRule, error
 func cloneApprovalPolicy(in QuorumApprovalPolicy) QuorumApprovalPolicy {
 	out := in
 	out.Stages = cloneApprovalStages(in.Stages)
+	out.AppliesTo = append([]string(nil), in.AppliesTo...)
+	out.Scopes = append([]string(nil), in.Scopes...)
 	return out
 }
 
 func cloneApprovalStages(in []ApprovalStageRule) []ApprovalStageRule {
 	out := make([]ApprovalStageRule, len(in))
-	copy(out, in)
+	for i, stage := range in {
+		stage.Roles = append([]string(nil), stage.Roles...)
+		stage.Teams = append([]string(nil), stage.Teams...)
+		out[i] = stage
+	}
 	return out
 }
 
@@ -401,5 +579,14 @@ func cloneBreakGlassRequest(in BreakGlassRequest) BreakGlassRequest {
 		revokedAt := *in.RevokedAt
 		out.RevokedAt = &revokedAt
 	}
+	if in.Postmortem != nil {
+		pm := *in.Postmortem
+		pm.Checklist = map[string]bool{}
+		for k, v := range in.Postmortem.Checklist {
+			pm.Checklist[k] = v
+		}
+		pm.Links = append([]string{}, in.Postmortem.Links...)
+		out.Postmortem = &pm
+	}
 	return out
 }
@@ -141,3 +141,80 @@ func TestBreakGlassRejectRevokeAndExpiry(t *testing.T) {
 		t.Fatalf("expected expired status, got %+v", got)
 	}
 }
+
+func TestBreakGlassElevatedModeRequiresPostmortem(t *testing.T) {
+	store := NewAccessApprovalStore()
+	policy, err := store.CreatePolicy(QuorumApprovalPolicyInput{
+		Name:   "single",
+		Stages: []ApprovalStageRule{{Name: "security", RequiredApprovals: 1}},
+	})
+	if err != nil {
+		t.Fatalf("create policy failed: %v", err)
+	}
+	req, err := store.CreateBreakGlassRequest(BreakGlassRequestInput{
+		RequestedBy: "oncall-sre",
+		Reason:      "database failover",
+		Scope:       "db/prod",
+		PolicyID:    policy.ID,
+		TTLSeconds:  600,
+	})
+	if err != nil {
+		t.Fatalf("create request failed: %v", err)
+	}
+	if _, ok := store.ActiveBreakGlassFor("oncall-sre"); ok {
+		t.Fatalf("pending request should not grant elevated mode")
+	}
+	if _, err := store.ApproveBreakGlassRequest(req.ID, "security-1", "go"); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if active, ok := store.ActiveBreakGlassFor("oncall-sre"); !ok || active.ID != req.ID {
+		t.Fatalf("expected elevated mode for requester, got %+v", active)
+	}
+	if _, ok := store.ActiveBreakGlassFor("someone-else"); ok {
+		t.Fatalf("elevated mode should be limited to the requester")
+	}
+	if _, err := store.AttachBreakGlassPostmortem(req.ID, BreakGlassPostmortem{Author: "oncall-sre", Summary: "early"}); err == nil {
+		t.Fatalf("expected postmortem on active request to fail")
+	}
+
+	store.mu.Lock()
+	past := time.Now().UTC().Add(-time.Second)
+	store.requests[req.ID].ExpiresAt = &past
+	store.mu.Unlock()
+	expired := store.ExpireBreakGlassRequests()
+	if len(expired) != 1 || expired[0].ID != req.ID || expired[0].Status != BreakGlassExpired {
+		t.Fatalf("expected one expired request, got %+v", expired)
+	}
+	if again := store.ExpireBreakGlassRequests(); len(again) != 0 {
+		t.Fatalf("expected expiry to be reported once, got %+v", again)
+	}
+	if _, ok := store.ActiveBreakGlassFor("oncall-sre"); ok {
+		t.Fatalf("expired request should not grant elevated mode")
+	}
+
+	next := BreakGlassRequestInput{RequestedBy: "oncall-sre", Reason: "again", Scope: "db/prod", PolicyID: policy.ID}
+	if _, err := store.CreateBreakGlassRequest(next); err == nil {
+		t.Fatalf("expected new request to be blocked until postmortem")
+	}
+	partial := BreakGlassPostmortem{
+		Author:    "oncall-sre",
+		Summary:   "failover completed",
+		Checklist: map[string]bool{"timeline_documented": true},
+	}
+	if _, err := store.AttachBreakGlassPostmortem(req.ID, partial); err == nil {
+		t.Fatalf("expected incomplete checklist to fail")
+	}
+	for _, item := range BreakGlassPostmortemChecklist {
+		partial.Checklist[item] = true
+	}
+	got, err := store.AttachBreakGlassPostmortem(req.ID, partial)
+	if err != nil || got.Postmortem == nil || len(got.Postmortem.Checklist) != len(BreakGlassPostmortemChecklist) {
+		t.Fatalf("attach postmortem failed: %+v err=%v", got, err)
+	}
+	if _, err := store.AttachBreakGlassPostmortem(req.ID, partial); err == nil {
+		t.Fatalf("expected duplicate postmortem to fail")
+	}
+	if _, err := store.CreateBreakGlassRequest(next); err != nil {
+		t.Fatalf("expected new request after postmortem, got %v", err)
+	}
+}
@@ -148,3 +148,167 @@ func profileDisplayName(id string) string {
 	}
 	return strings.Join(out, " ")
 }
+
+// Resolve returns the named profile, or the active one when id is empty.
+func (s *AccessibilityStore) Resolve(id string) (AccessibilityProfile, error) {
+	if strings.TrimSpace(id) == "" {
+		return s.ActiveProfile(), nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.profiles[normalizeProfileID(id)]
+	if !ok {
+		return AccessibilityProfile{}, errors.New("profile not found")
+	}
+	return *item, nil
+}
+
+// AccessibilityVariantHints tell front-ends how to render UI metadata for a
+// profile, so clients adapt from one place instead of each keeping its own
+// copy of the accessibility rules.
+type AccessibilityVariantHints struct {
+	ProfileID    string            `json:"profile_id"`
+	Motion       string            `json:"motion"`      // full|reduced
+	TokenSet     string            `json:"token_set"`   // default|high-contrast
+	Tokens       map[string]string `json:"tokens"`      // design tokens for the token set
+	Interaction  string            `json:"interaction"` // pointer|keyboard-only
+	FocusRing    string            `json:"focus_ring"`  // auto|always-visible
+	LiveRegions  string            `json:"live_regions"`
+	ScreenReader bool              `json:"screen_reader"`
+}
+
+var accessibilityTokenSets = map[string]map[string]string{
+	"default": {
+		"color.background": "#ffffff",
+		"color.text":       "#1f2933",
+		"color.accent":     "#2f6fed",
+		"color.focus":      "#2f6fed",
+		"border.width":     "1px",
+		"focus.width":      "2px",
+		"motion.duration":  "200ms",
+	},
+	"high-contrast": {
+		"color.background": "#000000",
+		"color.text":       "#ffffff",
+		"color.accent":     "#ffd400",
+		"color.focus":      "#ffd400",
+		"border.width":     "2px",
+		"focus.width":      "3px",
+		"motion.duration":  "200ms",
+	},
+}
+
+func (p AccessibilityProfile) VariantHints() AccessibilityVariantHints {
+	out := AccessibilityVariantHints{
+		ProfileID:    p.ID,
+		Motion:       "full",
+		TokenSet:     "default",
+		Interaction:  "pointer",
+		FocusRing:    "auto",
+		LiveRegions:  "off",
+		ScreenReader: p.ScreenReaderOptimized,
+	}
+	if p.HighContrast {
+		out.TokenSet = "high-contrast"
+	}
+	out.Tokens = cloneStringMap(accessibilityTokenSets[out.TokenSet])
+	if p.ReducedMotion {
+		out.Motion = "reduced"
+		out.Tokens["motion.duration"] = "0ms"
+	}
+	if p.KeyboardOnly {
+		out.Interaction = "keyboard-only"
+		out.FocusRing = "always-visible"
+	}
+	if p.ScreenReaderOptimized {
+		out.LiveRegions = "polite"
+	}
+	return out
+}
+
+type UIShortcutVariant struct {
+	UIShortcut
+	AriaKeyShortcuts string `json:"aria_keyshortcuts"`
+	FocusOrder       int    `json:"focus_order,omitempty"` // keyboard-only profiles
+	Label            string `json:"label,omitempty"`       // screen reader profiles
+}
+
+// AdaptShortcuts annotates shortcuts for hints: every shortcut gets its
+// aria-keyshortcuts value, keyboard-only profiles get a tab order with global
+// shortcuts first, and screen reader profiles get a spoken label.
+func AdaptShortcuts(items []UIShortcut, hints AccessibilityVariantHints) []UIShortcutVariant {
+	ordered := append([]UIShortcut{}, items...)
+	if hints.Interaction == "keyboard-only" {
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Global && !ordered[j].Global })
+	}
+	out := make([]UIShortcutVariant, 0, len(ordered))
+	for i, item := range ordered {
+		variant := UIShortcutVariant{UIShortcut: item, AriaKeyShortcuts: ariaKeyShortcuts(item.Keystroke)}
+		if hints.Interaction == "keyboard-only" {
+			variant.FocusOrder = i + 1
+		}
+		if hints.ScreenReader {
+			variant.Label = item.Title + ", " + item.Keystroke
+			if item.Description != "" {
+				variant.Label += ". " + item.Description
+			}
+		}
+		out = append(out, variant)
+	}
+	return out
+}
+
+type DashboardWidgetVariant struct {
+	DashboardWidget
+	AnimateRefresh bool   `json:"animate_refresh"`
+	TokenSet       string `json:"token_set"`
+	LiveRegion     string `json:"live_region"` // off|polite
+	FocusOrder     int    `json:"focus_order,omitempty"`
+	AriaLabel      string `json:"aria_label,omitempty"`
+}
+
+// AdaptDashboardWidgets annotates widgets for hints. Keyboard-only profiles
+// get a focus order that follows the grid, row by row.
+func AdaptDashboardWidgets(items []DashboardWidget, hints AccessibilityVariantHints) []DashboardWidgetVariant {
+	order := map[string]int{}
+	if hints.Interaction == "keyboard-only" {
+		grid := append([]DashboardWidget{}, items...)
+		sort.SliceStable(grid, func(i, j int) bool {
+			if grid[i].Row != grid[j].Row {
+				return grid[i].Row < grid[j].Row
+			}
+			return grid[i].Column < grid[j].Column
+		})
+		for i, item := range grid {
+			order[item.ID] = i + 1
+		}
+	}
+	out := make([]DashboardWidgetVariant, 0, len(items))
+	for _, item := range items {
+		variant := DashboardWidgetVariant{
+			DashboardWidget: item,
+			AnimateRefresh:  hints.Motion != "reduced",
+			TokenSet:        hints.TokenSet,
+			LiveRegion:      hints.LiveRegions,
+			FocusOrder:      order[item.ID],
+		}
+		if hints.ScreenReader {
+			variant.AriaLabel = item.Title + " widget"
+			if item.Description != "" {
+				variant.AriaLabel += ": " + item.Description
+			}
+		}
+		out = append(out, variant)
+	}
+	return out
+}
+
+// ariaKeyShortcuts converts a keystroke to aria-keyshortcuts syntax,
+// expanding CmdOrCtrl into its Control and Meta alternatives.
+func ariaKeyShortcuts(keystroke string) string {
+	keystroke = strings.TrimSpace(keystroke)
+	if rest, ok := strings.CutPrefix(keystroke, "CmdOrCtrl+"); ok {
+		return "Control+" + rest + " Meta+" + rest
+	}
+	return strings.ReplaceAll(keystroke, "Ctrl+", "Control+")
+}
@@ -39,3 +39,37 @@ func TestAccessibilityStoreUpsertAndActivate(t *testing.T) {
 		t.Fatalf("expected validation error for profile without accessibility modes")
 	}
 }
+
+func TestAccessibilityVariantHintsAdaptUIMetadata(t *testing.T) {
+	store := NewAccessibilityStore()
+	profile, err := store.Resolve("High_Contrast")
+	if err != nil {
+		t.Fatalf("resolve profile: %v", err)
+	}
+	hints := profile.VariantHints()
+	if hints.TokenSet != "high-contrast" || hints.Tokens["color.background"] != "#000000" || hints.Tokens["motion.duration"] != "0ms" || hints.Interaction != "keyboard-only" || hints.LiveRegions != "polite" {
+		t.Fatalf("unexpected high contrast hints: %+v", hints)
+	}
+	if base := store.ActiveProfile().VariantHints(); base.Motion != "full" || base.Tokens["motion.duration"] != "200ms" || base.FocusRing != "auto" {
+		t.Fatalf("unexpected default hints: %+v", base)
+	}
+	if _, err := store.Resolve("missing"); err == nil {
+		t.Fatalf("expected unknown profile error")
+	}
+
+	shortcuts := AdaptShortcuts([]UIShortcut{
+		{ID: "apply", Title: "Start Apply", Keystroke: "Shift+A"},
+		{ID: "palette", Title: "Open Command Palette", Keystroke: "CmdOrCtrl+K", Description: "Search.", Global: true},
+	}, hints)
+	if shortcuts[0].ID != "palette" || shortcuts[0].FocusOrder != 1 || shortcuts[0].AriaKeyShortcuts != "Control+K Meta+K" || shortcuts[0].Label != "Open Command Palette, CmdOrCtrl+K. Search." {
+		t.Fatalf("expected global shortcut first with aria hints, got %+v", shortcuts)
+	}
+
+	widgets := AdaptDashboardWidgets([]DashboardWidget{
+		{ID: "w1", Title: "Runs", Row: 2, Column: 1},
+		{ID: "w2", Title: "Drift", Row: 1, Column: 2},
+	}, hints)
+	if widgets[0].FocusOrder != 2 || widgets[1].FocusOrder != 1 || widgets[0].AnimateRefresh || widgets[0].AriaLabel != "Runs widget" {
+		t.Fatalf("expected grid focus order and reduced motion, got %+v", widgets)
+	}
+}
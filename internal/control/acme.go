@@ -0,0 +1,399 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ACMEHTTP01Solver publishes http-01 key authorizations so the ACME server
+// can fetch them from /.well-known/acme-challenge/{token}.
+type ACMEHTTP01Solver interface {
+	Present(token, keyAuthorization string)
+	CleanUp(token string)
+}
+
+// ACMEChallengeStore is an in-memory ACMEHTTP01Solver served by the HTTP
+// server.
+type ACMEChallengeStore struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+func NewACMEChallengeStore() *ACMEChallengeStore {
+	return &ACMEChallengeStore{tokens: map[string]string{}}
+}
+
+func (s *ACMEChallengeStore) Present(token, keyAuthorization string) {
+	s.mu.Lock()
+	s.tokens[token] = keyAuthorization
+	s.mu.Unlock()
+}
+
+func (s *ACMEChallengeStore) CleanUp(token string) {
+	s.mu.Lock()
+	delete(s.tokens, token)
+	s.mu.Unlock()
+}
+
+func (s *ACMEChallengeStore) Get(token string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.tokens[token]
+	return v, ok
+}
+
+// ACMEClient is an RFC 8555 client that orders certificates using http-01
+// challenges. The account key is generated on first use and reused for the
+// life of the client.
+type ACMEClient struct {
+	DirectoryURL string
+	Email        string
+	PollInterval time.Duration
+
+	client     *http.Client
+	mu         sync.Mutex
+	accountKey *ecdsa.PrivateKey
+	accountURL string
+	directory  acmeDirectory
+	nonce      string
+}
+
+type acmeDirectory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type acmeOrder struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate,omitempty"`
+}
+
+type acmeAuthorization struct {
+	Status     string `json:"status"`
+	Identifier struct {
+		Value string `json:"value"`
+	} `json:"identifier"`
+	Challenges []acmeChallenge `json:"challenges"`
+}
+
+type acmeChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+type acmeProblem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func NewACMEClient(directoryURL, email string) *ACMEClient {
+	return &ACMEClient{
+		DirectoryURL: strings.TrimSpace(directoryURL),
+		Email:        strings.TrimSpace(email),
+		PollInterval: 2 * time.Second,
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ObtainCertificate orders a certificate for the CSR's DNS names, proving
+// control of each through solver, and returns the issued chain leaf first.
+func (c *ACMEClient) ObtainCertificate(ctx context.Context, csrDER []byte, solver ACMEHTTP01Solver) ([]*x509.Certificate, error) {
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, errors.New("csr could not be parsed: " + err.Error())
+	}
+	if len(csr.DNSNames) == 0 {
+		return nil, errors.New("csr must name at least one dns identifier")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ensureAccount(ctx); err != nil {
+		return nil, err
+	}
+	identifiers := make([]map[string]string, 0, len(csr.DNSNames))
+	for _, name := range csr.DNSNames {
+		identifiers = append(identifiers, map[string]string{"type": "dns", "value": name})
+	}
+	var order acmeOrder
+	resp, err := c.post(ctx, c.directory.NewOrder, map[string]any{"identifiers": identifiers}, &order)
+	if err != nil {
+		return nil, err
+	}
+	orderURL := resp.header.Get("Location")
+	for _, authzURL := range order.Authorizations {
+		if err := c.authorize(ctx, authzURL, solver); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := c.post(ctx, order.Finalize, map[string]string{"csr": base64.RawURLEncoding.EncodeToString(csrDER)}, &order); err != nil {
+		return nil, err
+	}
+	for order.Status != "valid" {
+		if order.Status == "invalid" {
+			return nil, errors.New("acme order became invalid")
+		}
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+		if _, err := c.post(ctx, orderURL, nil, &order); err != nil {
+			return nil, err
+		}
+	}
+	resp, err = c.post(ctx, order.Certificate, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	rest := resp.body
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.New("acme certificate could not be parsed: " + err.Error())
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("acme server returned no certificates")
+	}
+	return certs, nil
+}
+
+func (c *ACMEClient) authorize(ctx context.Context, authzURL string, solver ACMEHTTP01Solver) error {
+	var authz acmeAuthorization
+	if _, err := c.post(ctx, authzURL, nil, &authz); err != nil {
+		return err
+	}
+	if authz.Status == "valid" {
+		return nil
+	}
+	var chal *acmeChallenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == "http-01" {
+			chal = &authz.Challenges[i]
+			break
+		}
+	}
+	if chal == nil {
+		return errors.New("acme server offered no http-01 challenge for " + authz.Identifier.Value)
+	}
+	thumbprint := acmeJWKThumbprint(&c.accountKey.PublicKey)
+	solver.Present(chal.Token, chal.Token+"."+thumbprint)
+	defer solver.CleanUp(chal.Token)
+	if _, err := c.post(ctx, chal.URL, map[string]any{}, nil); err != nil {
+		return err
+	}
+	for authz.Status != "valid" {
+		if authz.Status == "invalid" {
+			return errors.New("acme authorization for " + authz.Identifier.Value + " failed")
+		}
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		if _, err := c.post(ctx, authzURL, nil, &authz); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ACMEClient) ensureAccount(ctx context.Context) error {
+	if c.directory.NewOrder == "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.DirectoryURL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return errors.New("acme directory unreachable: " + err.Error())
+		}
+		err = json.NewDecoder(resp.Body).Decode(&c.directory)
+		resp.Body.Close()
+		if err != nil || c.directory.NewOrder == "" || c.directory.NewAccount == "" || c.directory.NewNonce == "" {
+			return errors.New("acme directory is malformed")
+		}
+	}
+	if c.accountURL != "" {
+		return nil
+	}
+	if c.accountKey == nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return err
+		}
+		c.accountKey = key
+	}
+	payload := map[string]any{"termsOfServiceAgreed": true}
+	if c.Email != "" {
+		payload["contact"] = []string{"mailto:" + c.Email}
+	}
+	resp, err := c.post(ctx, c.directory.NewAccount, payload, nil)
+	if err != nil {
+		return err
+	}
+	c.accountURL = resp.header.Get("Location")
+	if c.accountURL == "" {
+		return errors.New("acme server did not return an account url")
+	}
+	return nil
+}
+
+type acmeResponse struct {
+	header http.Header
+	body   []byte
+}
+
+// post sends a JWS-signed request; a nil payload is a POST-as-GET. A
+// badNonce rejection is retried once with the fresh nonce.
+func (c *ACMEClient) post(ctx context.Context, url string, payload any, out any) (acmeResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if c.nonce == "" {
+			if err := c.fetchNonce(ctx); err != nil {
+				return acmeResponse{}, err
+			}
+		}
+		body, err := c.signJWS(url, payload)
+		if err != nil {
+			return acmeResponse{}, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return acmeResponse{}, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return acmeResponse{}, errors.New("acme request failed: " + err.Error())
+		}
+		raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		c.nonce = resp.Header.Get("Replay-Nonce")
+		if err != nil {
+			return acmeResponse{}, err
+		}
+		if resp.StatusCode >= 400 {
+			var problem acmeProblem
+			_ = json.Unmarshal(raw, &problem)
+			if problem.Type == "urn:ietf:params:acme:error:badNonce" && attempt == 0 {
+				continue
+			}
+			return acmeResponse{}, errors.New("acme error " + strconv.Itoa(resp.StatusCode) + ": " + firstNonEmpty(problem.Detail, problem.Type, string(raw)))
+		}
+		if out != nil {
+			if err := json.Unmarshal(raw, out); err != nil {
+				return acmeResponse{}, errors.New("acme response is malformed")
+			}
+		}
+		return acmeResponse{header: resp.Header, body: raw}, nil
+	}
+}
+
+func (c *ACMEClient) fetchNonce(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.directory.NewNonce, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.New("acme nonce request failed: " + err.Error())
+	}
+	resp.Body.Close()
+	c.nonce = resp.Header.Get("Replay-Nonce")
+	if c.nonce == "" {
+		return errors.New("acme server did not return a nonce")
+	}
+	return nil
+}
+
+func (c *ACMEClient) signJWS(url string, payload any) ([]byte, error) {
+	protected := map[string]any{"alg": "ES256", "nonce": c.nonce, "url": url}
+	if c.accountURL != "" {
+		protected["kid"] = c.accountURL
+	} else {
+		protected["jwk"] = acmeJWK(&c.accountKey.PublicKey)
+	}
+	header, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+	var encodedPayload string
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		encodedPayload = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+	digest := sha256.Sum256([]byte(encodedHeader + "." + encodedPayload))
+	r, s, err := ecdsa.Sign(rand.Reader, c.accountKey, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return json.Marshal(map[string]string{
+		"protected": encodedHeader,
+		"payload":   encodedPayload,
+		"signature": base64.RawURLEncoding.EncodeToString(sig),
+	})
+}
+
+func (c *ACMEClient) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(c.PollInterval):
+		return nil
+	}
+}
+
+func acmeJWK(pub *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"crv": "P-256",
+		"kty": "EC",
+		"x":   base64.RawURLEncoding.EncodeToString(padACMECoordinate(pub.X)),
+		"y":   base64.RawURLEncoding.EncodeToString(padACMECoordinate(pub.Y)),
+	}
+}
+
+// acmeJWKThumbprint is the RFC 7638 thumbprint: members in lexical order,
+// no whitespace.
+func acmeJWKThumbprint(pub *ecdsa.PublicKey) string {
+	jwk := acmeJWK(pub)
+	canonical := `{"crv":"P-256","kty":"EC","x":"` + jwk["x"] + `","y":"` + jwk["y"] + `"}`
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func padACMECoordinate(v *big.Int) []byte {
+	out := make([]byte, 32)
+	v.FillBytes(out)
+	return out
+}
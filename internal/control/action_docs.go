@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sort"
 	"strings"
+	"sync"
 )
 
 type ActionDoc struct {
@@ -12,10 +13,12 @@ type ActionDoc struct {
 	Summary     string   `json:"summary"`
 	Endpoints   []string `json:"endpoints"`
 	ExampleJSON string   `json:"example_json,omitempty"`
+	Body        string   `json:"body,omitempty"` // markdown; fenced yaml and http blocks are verified examples
 	Tags        []string `json:"tags,omitempty"`
 }
 
 type ActionDocCatalog struct {
+	mu    sync.RWMutex
 	items map[string]ActionDoc
 }
 
@@ -66,6 +69,36 @@ func NewActionDocCatalog() *ActionDocCatalog {
 			ExampleJSON: "",
 			Tags:        []string{"incident", "alerts", "observability"},
 		},
+		{
+			ID:      "preview-config-change",
+			Title:   "Preview a Config Change",
+			Summary: "Plan a config without applying it and explain every step it would take.",
+			Endpoints: []string{
+				"POST /v1/plans/explain",
+				"POST /v1/plans/risk-summary",
+			},
+			ExampleJSON: `{"config_path":"motd.yaml"}`,
+			Body: "Describe the desired state:\n\n" +
+				"```yaml file=motd.yaml\n" +
+				"version: v0\n" +
+				"inventory:\n" +
+				"  hosts:\n" +
+				"    - name: localhost\n" +
+				"      transport: local\n" +
+				"resources:\n" +
+				"  - id: motd\n" +
+				"    type: file\n" +
+				"    host: localhost\n" +
+				"    path: /etc/motd\n" +
+				"    content: \"managed by masterchef\\n\"\n" +
+				"```\n\n" +
+				"Then ask the control plane what applying it would do:\n\n" +
+				"```http\n" +
+				"POST /v1/plans/explain\n" +
+				"{\"config_path\":\"motd.yaml\"}\n" +
+				"```\n",
+			Tags: []string{"plan", "preview", "config"},
+		},
 	}
 	out := map[string]ActionDoc{}
 	for _, item := range items {
@@ -75,6 +108,8 @@ func NewActionDocCatalog() *ActionDocCatalog {
 }
 
 func (c *ActionDocCatalog) List() []ActionDoc {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	out := make([]ActionDoc, 0, len(c.items))
 	for _, item := range c.items {
 		out = append(out, cloneActionDoc(item))
@@ -84,6 +119,8 @@ func (c *ActionDocCatalog) List() []ActionDoc {
 }
 
 func (c *ActionDocCatalog) Get(id string) (ActionDoc, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	item, ok := c.items[strings.TrimSpace(id)]
 	if !ok {
 		return ActionDoc{}, errors.New("action doc not found")
@@ -91,6 +128,31 @@ func (c *ActionDocCatalog) Get(id string) (ActionDoc, error) {
 	return cloneActionDoc(item), nil
 }
 
+// Upsert registers or replaces an action doc, so modules and teams can
+// publish workflow docs whose examples are verified alongside the built-ins.
+func (c *ActionDocCatalog) Upsert(item ActionDoc) (ActionDoc, error) {
+	item.ID = strings.TrimSpace(item.ID)
+	item.Title = strings.TrimSpace(item.Title)
+	if item.ID == "" || item.Title == "" {
+		return ActionDoc{}, errors.New("id and title are required")
+	}
+	endpoints := make([]string, 0, len(item.Endpoints))
+	for _, endpoint := range item.Endpoints {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return ActionDoc{}, errors.New("at least one endpoint is required")
+	}
+	item.Endpoints = endpoints
+	item = cloneActionDoc(item)
+	c.mu.Lock()
+	c.items[item.ID] = item
+	c.mu.Unlock()
+	return cloneActionDoc(item), nil
+}
+
 func cloneActionDoc(item ActionDoc) ActionDoc {
 	out := item
 	out.Endpoints = append([]string{}, item.Endpoints...)
@@ -0,0 +1,911 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// celProgram is a compiled expression in the subset of CEL used by
+// admission policies: literals, lists and maps, field selection and
+// indexing, arithmetic, comparison, in, logical and ternary operators,
+// has(), size(), int(), string(), and the string and list methods
+// startsWith, endsWith, contains, matches, lowerAscii, exists, and all.
+type celProgram struct {
+	source string
+	root   *celNode
+}
+
+type celNode struct {
+	kind  string // lit|ident|select|index|call|method|unary|binary|cond|list|map
+	op    string
+	name  string
+	value any
+	args  []*celNode
+}
+
+const (
+	celMaxSourceBytes = 4096
+	celMaxDepth       = 64
+)
+
+func compileCEL(source string) (*celProgram, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, errors.New("expression is required")
+	}
+	if len(source) > celMaxSourceBytes {
+		return nil, errors.New("expression is too long")
+	}
+	tokens, err := lexCEL(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &celParser{tokens: tokens}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	return &celProgram{source: source, root: root}, nil
+}
+
+func (p *celProgram) evalBool(vars map[string]any) (bool, error) {
+	v, err := evalCEL(p.root, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %s, want bool", celTypeName(v))
+	}
+	return b, nil
+}
+
+type celToken struct {
+	kind string // ident|int|float|string|op|eof
+	text string
+	val  any
+	pos  int
+}
+
+func lexCEL(src string) ([]celToken, error) {
+	var out []celToken
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			out = append(out, celToken{kind: "ident", text: src[i:j], pos: i})
+			i = j
+		case unicode.IsDigit(rune(c)):
+			j := i
+			isFloat := false
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.' || src[j] == 'e' || src[j] == 'E') {
+				if src[j] == '.' || src[j] == 'e' || src[j] == 'E' {
+					isFloat = true
+				}
+				j++
+			}
+			text := src[i:j]
+			if isFloat {
+				f, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid number %q", text)
+				}
+				out = append(out, celToken{kind: "float", text: text, val: f, pos: i})
+			} else {
+				n, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid number %q", text)
+				}
+				out = append(out, celToken{kind: "int", text: text, val: n, pos: i})
+			}
+			i = j
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			closed := false
+			for j < len(src) {
+				if src[j] == c {
+					closed = true
+					j++
+					break
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					switch src[j+1] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					case '\\', '"', '\'':
+						b.WriteByte(src[j+1])
+					default:
+						return nil, fmt.Errorf("invalid escape at offset %d", j)
+					}
+					j += 2
+					continue
+				}
+				b.WriteByte(src[j])
+				j++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			out = append(out, celToken{kind: "string", text: src[i:j], val: b.String(), pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "?", ":", ".", ",", "(", ")", "[", "]", "{", "}"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			out = append(out, celToken{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(out, celToken{kind: "eof", text: "end of expression", pos: len(src)}), nil
+}
+
+type celParser struct {
+	tokens []celToken
+	pos    int
+}
+
+func (p *celParser) peek() celToken { return p.tokens[p.pos] }
+
+func (p *celParser) next() celToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != "eof" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *celParser) acceptOp(op string) bool {
+	if tok := p.peek(); tok.kind == "op" && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *celParser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		tok := p.peek()
+		return fmt.Errorf("expected %q but found %q at offset %d", op, tok.text, tok.pos)
+	}
+	return nil
+}
+
+func (p *celParser) parseExpr(depth int) (*celNode, error) {
+	if depth > celMaxDepth {
+		return nil, errors.New("expression is nested too deeply")
+	}
+	cond, err := p.parseBinary(0, depth)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptOp("?") {
+		return cond, nil
+	}
+	then, err := p.parseExpr(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectOp(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseExpr(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return &celNode{kind: "cond", args: []*celNode{cond, then, otherwise}}, nil
+}
+
+var celPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *celParser) parseBinary(level, depth int) (*celNode, error) {
+	if level == len(celPrecedence) {
+		return p.parseUnary(depth)
+	}
+	left, err := p.parseBinary(level+1, depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		matched := ""
+		for _, op := range celPrecedence[level] {
+			if (tok.kind == "op" || (tok.kind == "ident" && op == "in")) && tok.text == op {
+				matched = op
+			}
+		}
+		if matched == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level+1, depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &celNode{kind: "binary", op: matched, args: []*celNode{left, right}}
+	}
+}
+
+func (p *celParser) parseUnary(depth int) (*celNode, error) {
+	if depth > celMaxDepth {
+		return nil, errors.New("expression is nested too deeply")
+	}
+	if p.acceptOp("!") {
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &celNode{kind: "unary", op: "!", args: []*celNode{operand}}, nil
+	}
+	if p.acceptOp("-") {
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &celNode{kind: "unary", op: "-", args: []*celNode{operand}}, nil
+	}
+	return p.parseMember(depth)
+}
+
+func (p *celParser) parseMember(depth int) (*celNode, error) {
+	n, err := p.parsePrimary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.acceptOp("."):
+			tok := p.next()
+			if tok.kind != "ident" {
+				return nil, fmt.Errorf("expected field name at offset %d", tok.pos)
+			}
+			if p.acceptOp("(") {
+				args, err := p.parseArgs(")", depth)
+				if err != nil {
+					return nil, err
+				}
+				n = &celNode{kind: "method", name: tok.text, args: append([]*celNode{n}, args...)}
+			} else {
+				n = &celNode{kind: "select", name: tok.text, args: []*celNode{n}}
+			}
+		case p.acceptOp("["):
+			index, err := p.parseExpr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			n = &celNode{kind: "index", args: []*celNode{n, index}}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *celParser) parseArgs(closer string, depth int) ([]*celNode, error) {
+	var args []*celNode
+	if p.acceptOp(closer) {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.acceptOp(closer) {
+			return args, nil
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *celParser) parsePrimary(depth int) (*celNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case "int", "float", "string":
+		return &celNode{kind: "lit", value: tok.val}, nil
+	case "ident":
+		switch tok.text {
+		case "true":
+			return &celNode{kind: "lit", value: true}, nil
+		case "false":
+			return &celNode{kind: "lit", value: false}, nil
+		case "null":
+			return &celNode{kind: "lit", value: nil}, nil
+		}
+		if p.acceptOp("(") {
+			args, err := p.parseArgs(")", depth)
+			if err != nil {
+				return nil, err
+			}
+			if tok.text == "has" && (len(args) != 1 || args[0].kind != "select") {
+				return nil, errors.New("has() expects a field selection such as has(request.body.field)")
+			}
+			return &celNode{kind: "call", name: tok.text, args: args}, nil
+		}
+		return &celNode{kind: "ident", name: tok.text}, nil
+	case "op":
+		switch tok.text {
+		case "(":
+			n, err := p.parseExpr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expectOp(")")
+		case "[":
+			items, err := p.parseArgs("]", depth)
+			if err != nil {
+				return nil, err
+			}
+			return &celNode{kind: "list", args: items}, nil
+		case "{":
+			var entries []*celNode
+			if p.acceptOp("}") {
+				return &celNode{kind: "map"}, nil
+			}
+			for {
+				key, err := p.parseExpr(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectOp(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.parseExpr(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, key, value)
+				if p.acceptOp("}") {
+					return &celNode{kind: "map", args: entries}, nil
+				}
+				if err := p.expectOp(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+func evalCEL(n *celNode, vars map[string]any) (any, error) {
+	switch n.kind {
+	case "lit":
+		return n.value, nil
+	case "ident":
+		v, ok := vars[n.name]
+		if !ok {
+			return nil, fmt.Errorf("undeclared reference to %q", n.name)
+		}
+		return v, nil
+	case "select":
+		target, err := evalCEL(n.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := target.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot select field %q from %s", n.name, celTypeName(target))
+		}
+		v, ok := m[n.name]
+		if !ok {
+			return nil, fmt.Errorf("no such key: %s", n.name)
+		}
+		return v, nil
+	case "index":
+		target, err := evalCEL(n.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		key, err := evalCEL(n.args[1], vars)
+		if err != nil {
+			return nil, err
+		}
+		return celIndex(target, key)
+	case "list":
+		out := make([]any, 0, len(n.args))
+		for _, item := range n.args {
+			v, err := evalCEL(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case "map":
+		out := map[string]any{}
+		for i := 0; i < len(n.args); i += 2 {
+			k, err := evalCEL(n.args[i], vars)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, errors.New("map keys must be strings")
+			}
+			v, err := evalCEL(n.args[i+1], vars)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+		return out, nil
+	case "unary":
+		v, err := evalCEL(n.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("no such overload: !%s", celTypeName(v))
+			}
+			return !b, nil
+		}
+		switch x := v.(type) {
+		case int64:
+			return -x, nil
+		case float64:
+			return -x, nil
+		}
+		return nil, fmt.Errorf("no such overload: -%s", celTypeName(v))
+	case "cond":
+		c, err := evalCEL(n.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := c.(bool)
+		if !ok {
+			return nil, errors.New("ternary condition must be bool")
+		}
+		if b {
+			return evalCEL(n.args[1], vars)
+		}
+		return evalCEL(n.args[2], vars)
+	case "binary":
+		if n.op == "&&" || n.op == "||" {
+			return evalCELLogical(n, vars)
+		}
+		left, err := evalCEL(n.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		right, err := evalCEL(n.args[1], vars)
+		if err != nil {
+			return nil, err
+		}
+		return celBinary(n.op, left, right)
+	case "call":
+		return evalCELCall(n, vars)
+	case "method":
+		return evalCELMethod(n, vars)
+	}
+	return nil, fmt.Errorf("unsupported expression node %s", n.kind)
+}
+
+// evalCELLogical follows CEL's commutative semantics: an error on one side
+// is absorbed when the other side alone decides the result.
+func evalCELLogical(n *celNode, vars map[string]any) (any, error) {
+	decisive := n.op == "||"
+	asBool := func(node *celNode) (bool, error) {
+		v, err := evalCEL(node, vars)
+		if err != nil {
+			return false, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("no such overload: %s %s", celTypeName(v), n.op)
+		}
+		return b, nil
+	}
+	left, leftErr := asBool(n.args[0])
+	if leftErr == nil && left == decisive {
+		return decisive, nil
+	}
+	right, rightErr := asBool(n.args[1])
+	if rightErr == nil && right == decisive {
+		return decisive, nil
+	}
+	if leftErr != nil {
+		return nil, leftErr
+	}
+	if rightErr != nil {
+		return nil, rightErr
+	}
+	return !decisive, nil
+}
+
+func evalCELCall(n *celNode, vars map[string]any) (any, error) {
+	if n.name == "has" {
+		sel := n.args[0]
+		target, err := evalCEL(sel.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := target.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("has() cannot test a field of %s", celTypeName(target))
+		}
+		_, present := m[sel.name]
+		return present, nil
+	}
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := evalCEL(arg, vars)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s() expects one argument", n.name)
+	}
+	switch n.name {
+	case "size":
+		return celSize(args[0])
+	case "int":
+		switch x := args[0].(type) {
+		case int64:
+			return x, nil
+		case float64:
+			return int64(x), nil
+		case string:
+			v, err := strconv.ParseInt(x, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to int", x)
+			}
+			return v, nil
+		}
+	case "string":
+		switch x := args[0].(type) {
+		case string:
+			return x, nil
+		case int64:
+			return strconv.FormatInt(x, 10), nil
+		case float64:
+			return strconv.FormatFloat(x, 'g', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(x), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown function %s()", n.name)
+	}
+	return nil, fmt.Errorf("no such overload: %s(%s)", n.name, celTypeName(args[0]))
+}
+
+func evalCELMethod(n *celNode, vars map[string]any) (any, error) {
+	target, err := evalCEL(n.args[0], vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.name == "exists" || n.name == "all" {
+		if len(n.args) != 3 || n.args[1].kind != "ident" {
+			return nil, fmt.Errorf("%s() expects a variable name and a predicate", n.name)
+		}
+		var items []any
+		switch x := target.(type) {
+		case []any:
+			items = x
+		case map[string]any:
+			for k := range x {
+				items = append(items, k)
+			}
+		default:
+			return nil, fmt.Errorf("%s() needs a list or map, got %s", n.name, celTypeName(target))
+		}
+		scope := make(map[string]any, len(vars)+1)
+		for k, v := range vars {
+			scope[k] = v
+		}
+		want := n.name == "exists"
+		for _, item := range items {
+			scope[n.args[1].name] = item
+			v, err := evalCEL(n.args[2], scope)
+			if err != nil {
+				return nil, err
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s() predicate must return bool", n.name)
+			}
+			if b == want {
+				return want, nil
+			}
+		}
+		return !want, nil
+	}
+	args := make([]any, 0, len(n.args)-1)
+	for _, arg := range n.args[1:] {
+		v, err := evalCEL(arg, vars)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	if n.name == "size" && len(args) == 0 {
+		return celSize(target)
+	}
+	s, ok := target.(string)
+	if !ok {
+		return nil, fmt.Errorf("no such overload: %s.%s()", celTypeName(target), n.name)
+	}
+	if n.name == "lowerAscii" && len(args) == 0 {
+		return strings.ToLower(s), nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s() expects one argument", n.name)
+	}
+	arg, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects a string argument", n.name)
+	}
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(s, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(s, arg), nil
+	case "contains":
+		return strings.Contains(s, arg), nil
+	case "matches":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q", arg)
+		}
+		return re.MatchString(s), nil
+	}
+	return nil, fmt.Errorf("unknown method %s()", n.name)
+}
+
+func celIndex(target, key any) (any, error) {
+	switch x := target.(type) {
+	case map[string]any:
+		k, ok := key.(string)
+		if !ok {
+			return nil, errors.New("map index must be a string")
+		}
+		v, ok := x[k]
+		if !ok {
+			return nil, fmt.Errorf("no such key: %s", k)
+		}
+		return v, nil
+	case []any:
+		i, ok := celInt(key)
+		if !ok {
+			return nil, errors.New("list index must be an int")
+		}
+		if i < 0 || i >= int64(len(x)) {
+			return nil, fmt.Errorf("index %d out of range", i)
+		}
+		return x[i], nil
+	}
+	return nil, fmt.Errorf("cannot index %s", celTypeName(target))
+}
+
+func celSize(v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return int64(len([]rune(x))), nil
+	case []any:
+		return int64(len(x)), nil
+	case map[string]any:
+		return int64(len(x)), nil
+	}
+	return nil, fmt.Errorf("no such overload: size(%s)", celTypeName(v))
+}
+
+func celBinary(op string, left, right any) (any, error) {
+	switch op {
+	case "==":
+		return celEqual(left, right), nil
+	case "!=":
+		return !celEqual(left, right), nil
+	case "in":
+		switch c := right.(type) {
+		case []any:
+			for _, item := range c {
+				if celEqual(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]any:
+			k, ok := left.(string)
+			if !ok {
+				return false, nil
+			}
+			_, present := c[k]
+			return present, nil
+		}
+		return nil, fmt.Errorf("no such overload: in %s", celTypeName(right))
+	case "<", "<=", ">", ">=":
+		cmp, err := celCompare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	case "+":
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		case []any:
+			if r, ok := right.([]any); ok {
+				return append(append([]any{}, l...), r...), nil
+			}
+		}
+	}
+	li, lInt := left.(int64)
+	ri, rInt := right.(int64)
+	if lInt && rInt {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+	lf, lNum := celFloat(left)
+	rf, rNum := celFloat(right)
+	if lNum && rNum {
+		switch op {
+		case "+":
+			return lf + rf, nil
+		case "-":
+			return lf - rf, nil
+		case "*":
+			return lf * rf, nil
+		case "/":
+			return lf / rf, nil
+		case "%":
+			return math.Mod(lf, rf), nil
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s %s %s", celTypeName(left), op, celTypeName(right))
+}
+
+func celEqual(a, b any) bool {
+	if af, ok := celFloat(a); ok {
+		bf, ok := celFloat(b)
+		return ok && af == bf
+	}
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !celEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !celEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func celCompare(a, b any) (int, error) {
+	if af, ok := celFloat(a); ok {
+		if bf, ok := celFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, nil
+			case af > bf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			return strings.Compare(as, bs), nil
+		}
+	}
+	return 0, fmt.Errorf("no such overload: %s < %s", celTypeName(a), celTypeName(b))
+}
+
+func celInt(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case float64:
+		if x == math.Trunc(x) {
+			return int64(x), true
+		}
+	}
+	return 0, false
+}
+
+func celFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func celTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,63 @@
+package control
+
+import "testing"
+
+func TestCELEvaluation(t *testing.T) {
+	vars := map[string]any{
+		"request": map[string]any{
+			"method": "POST",
+			"body": map[string]any{
+				"environment": "prod",
+				"priority":    "low",
+				"hosts":       []any{"web-1", "db-1"},
+				"retries":     int64(3),
+			},
+		},
+	}
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`request.body.environment == "prod" && request.body.priority != "low"`, false},
+		{`request.body.priority in ["high", "normal"] || request.method == "POST"`, true},
+		{`has(request.body.change_record)`, false},
+		{`!has(request.body.change_record) || request.body.change_record.startsWith("CHG")`, true},
+		{`request.body.hosts.exists(h, h.startsWith("db-"))`, true},
+		{`request.body.hosts.all(h, h.matches("^web-[0-9]+$"))`, false},
+		{`size(request.body.hosts) == 2 && request.body.retries % 2 == 1`, true},
+		{`(request.body.retries > 5 ? "many" : "few") == "few"`, true},
+		{`request.body.missing == "x" || true`, true},
+		{`"environment" in request.body && request.body["environment"].lowerAscii() == "prod"`, true},
+	}
+	for _, tc := range cases {
+		prog, err := compileCEL(tc.expr)
+		if err != nil {
+			t.Fatalf("compile %q: %v", tc.expr, err)
+		}
+		got, err := prog.evalBool(vars)
+		if err != nil {
+			t.Fatalf("eval %q: %v", tc.expr, err)
+		}
+		if got != tc.want {
+			t.Fatalf("eval %q = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestCELErrors(t *testing.T) {
+	for _, expr := range []string{`request.body.`, `"unterminated`, `has(request)`, `a ? b`} {
+		if _, err := compileCEL(expr); err == nil {
+			t.Fatalf("expected compile error for %q", expr)
+		}
+	}
+	vars := map[string]any{"request": map[string]any{"body": map[string]any{}}}
+	for _, expr := range []string{`request.body.missing == "x"`, `request.body`, `unknown == 1`, `1 / 0 == 1`} {
+		prog, err := compileCEL(expr)
+		if err != nil {
+			t.Fatalf("compile %q: %v", expr, err)
+		}
+		if _, err := prog.evalBool(vars); err == nil {
+			t.Fatalf("expected evaluation error for %q", expr)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AdmissionLanguageCEL = "cel"
+
+	AdmissionFailurePolicyFail   = "fail"
+	AdmissionFailurePolicyIgnore = "ignore"
+)
+
+// AdmissionRequest is the view of a mutating API call that admission
+// policies see as the CEL variable "request".
+type AdmissionRequest struct {
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Principal string            `json:"principal,omitempty"`
+	Tenant    string            `json:"tenant,omitempty"`
+	Query     map[string]string `json:"query,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      any               `json:"body,omitempty"`
+}
+
+// AdmissionPolicyFixture is a sample request paired with the outcome the
+// policy must produce for it. Fixtures run whenever a policy is saved.
+type AdmissionPolicyFixture struct {
+	Name    string           `json:"name"`
+	Request AdmissionRequest `json:"request"`
+	Expect  string           `json:"expect"` // allow|deny
+}
+
+type AdmissionPolicyInput struct {
+	Name          string                   `json:"name"`
+	Description   string                   `json:"description,omitempty"`
+	Language      string                   `json:"language,omitempty"`
+	Methods       []string                 `json:"methods,omitempty"`
+	Paths         []string                 `json:"paths,omitempty"`
+	Match         string                   `json:"match,omitempty"`
+	Expression    string                   `json:"expression"`
+	Message       string                   `json:"message,omitempty"`
+	FailurePolicy string                   `json:"failure_policy,omitempty"`
+	DryRun        bool                     `json:"dry_run,omitempty"`
+	Tests         []AdmissionPolicyFixture `json:"tests,omitempty"`
+}
+
+type AdmissionPolicy struct {
+	ID            string                   `json:"id"`
+	Name          string                   `json:"name"`
+	Description   string                   `json:"description,omitempty"`
+	Language      string                   `json:"language"`
+	Methods       []string                 `json:"methods"`
+	Paths         []string                 `json:"paths,omitempty"`
+	Match         string                   `json:"match,omitempty"`
+	Expression    string                   `json:"expression"`
+	Message       string                   `json:"message"`
+	FailurePolicy string                   `json:"failure_policy"`
+	DryRun        bool                     `json:"dry_run"`
+	Tests         []AdmissionPolicyFixture `json:"tests,omitempty"`
+	Version       int64                    `json:"version"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+}
+
+type AdmissionPolicyResult struct {
+	PolicyID   string `json:"policy_id,omitempty"`
+	PolicyName string `json:"policy_name"`
+	Allowed    bool   `json:"allowed"`
+	DryRun     bool   `json:"dry_run,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AdmissionDecision combines the results of every policy that matched a
+// request. Dry-run denials are reported but never reject the request.
+type AdmissionDecision struct {
+	Allowed bool                    `json:"allowed"`
+	Message string                  `json:"message,omitempty"`
+	Results []AdmissionPolicyResult `json:"results"`
+}
+
+// DryRunDenials returns the results that would have rejected the request
+// had their policies been enforced.
+func (d AdmissionDecision) DryRunDenials() []AdmissionPolicyResult {
+	var out []AdmissionPolicyResult
+	for _, res := range d.Results {
+		if res.DryRun && !res.Allowed {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+type AdmissionFixtureResult struct {
+	Name   string `json:"name"`
+	Expect string `json:"expect"`
+	Got    string `json:"got"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+type AdmissionPolicyTestReport struct {
+	Passed  bool                     `json:"passed"`
+	Results []AdmissionFixtureResult `json:"results"`
+}
+
+type admissionPolicyEntry struct {
+	policy AdmissionPolicy
+	match  *celProgram
+	expr   *celProgram
+}
+
+type AdmissionPolicyStore struct {
+	mu       sync.RWMutex
+	nextID   int64
+	policies map[string]*admissionPolicyEntry
+}
+
+func NewAdmissionPolicyStore() *AdmissionPolicyStore {
+	return &AdmissionPolicyStore{policies: map[string]*admissionPolicyEntry{}}
+}
+
+func (s *AdmissionPolicyStore) Create(in AdmissionPolicyInput) (AdmissionPolicy, error) {
+	entry, err := compileAdmissionPolicy(in)
+	if err != nil {
+		return AdmissionPolicy{}, err
+	}
+	if report := entry.runFixtures(); !report.Passed {
+		return AdmissionPolicy{}, fixtureFailure(report)
+	}
+	now := time.Now().UTC()
+	entry.policy.Version = 1
+	entry.policy.CreatedAt = now
+	entry.policy.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	entry.policy.ID = "admission-policy-" + itoa(s.nextID)
+	s.policies[entry.policy.ID] = entry
+	return cloneAdmissionPolicy(entry.policy), nil
+}
+
+// Update recompiles a policy and bumps its version. A non-zero
+// expectedVersion must match the stored version.
+func (s *AdmissionPolicyStore) Update(id string, in AdmissionPolicyInput, expectedVersion int64) (AdmissionPolicy, error) {
+	entry, err := compileAdmissionPolicy(in)
+	if err != nil {
+		return AdmissionPolicy{}, err
+	}
+	if report := entry.runFixtures(); !report.Passed {
+		return AdmissionPolicy{}, fixtureFailure(report)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.policies[strings.TrimSpace(id)]
+	if !ok {
+		return AdmissionPolicy{}, errors.New("admission policy not found")
+	}
+	if err := checkVersion(VersionedKindAdmissionPolicy, current.policy.ID, current.policy.Version, expectedVersion); err != nil {
+		return AdmissionPolicy{}, err
+	}
+	entry.policy.ID = current.policy.ID
+	entry.policy.Version = current.policy.Version + 1
+	entry.policy.CreatedAt = current.policy.CreatedAt
+	entry.policy.UpdatedAt = time.Now().UTC()
+	s.policies[entry.policy.ID] = entry
+	return cloneAdmissionPolicy(entry.policy), nil
+}
+
+func (s *AdmissionPolicyStore) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok := s.policies[id]; !ok {
+		return false
+	}
+	delete(s.policies, id)
+	return true
+}
+
+func (s *AdmissionPolicyStore) Get(id string) (AdmissionPolicy, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.policies[strings.TrimSpace(id)]
+	if !ok {
+		return AdmissionPolicy{}, false
+	}
+	return cloneAdmissionPolicy(entry.policy), true
+}
+
+func (s *AdmissionPolicyStore) List() []AdmissionPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]AdmissionPolicy, 0, len(s.policies))
+	for _, entry := range s.policies {
+		out = append(out, cloneAdmissionPolicy(entry.policy))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Applies reports whether any policy covers the method and path, letting
+// callers skip reading the request body when nothing would inspect it.
+func (s *AdmissionPolicyStore) Applies(method, path string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, entry := range s.policies {
+		if entry.covers(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate runs every policy covering the request. The request is
+// rejected when at least one enforced policy denies it.
+func (s *AdmissionPolicyStore) Evaluate(req AdmissionRequest) AdmissionDecision {
+	s.mu.RLock()
+	entries := make([]*admissionPolicyEntry, 0, len(s.policies))
+	for _, entry := range s.policies {
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].policy.CreatedAt.Before(entries[j].policy.CreatedAt) })
+
+	decision := AdmissionDecision{Allowed: true, Results: []AdmissionPolicyResult{}}
+	vars := admissionVars(req)
+	for _, entry := range entries {
+		res, matched := entry.evaluate(req, vars)
+		if !matched {
+			continue
+		}
+		decision.Results = append(decision.Results, res)
+		if !res.Allowed && !res.DryRun && decision.Allowed {
+			decision.Allowed = false
+			decision.Message = res.Message
+		}
+	}
+	return decision
+}
+
+// Test compiles a policy without saving it and runs its fixtures, so
+// policies can be checked in CI before they are rolled out.
+func (s *AdmissionPolicyStore) Test(in AdmissionPolicyInput) (AdmissionPolicyTestReport, error) {
+	entry, err := compileAdmissionPolicy(in)
+	if err != nil {
+		return AdmissionPolicyTestReport{}, err
+	}
+	return entry.runFixtures(), nil
+}
+
+func compileAdmissionPolicy(in AdmissionPolicyInput) (*admissionPolicyEntry, error) {
+	policy := AdmissionPolicy{
+		Name:          strings.TrimSpace(in.Name),
+		Description:   strings.TrimSpace(in.Description),
+		Language:      strings.ToLower(strings.TrimSpace(in.Language)),
+		Match:         strings.TrimSpace(in.Match),
+		Expression:    strings.TrimSpace(in.Expression),
+		Message:       strings.TrimSpace(in.Message),
+		FailurePolicy: strings.ToLower(strings.TrimSpace(in.FailurePolicy)),
+		DryRun:        in.DryRun,
+	}
+	if policy.Name == "" || policy.Expression == "" {
+		return nil, errors.New("name and expression are required")
+	}
+	if policy.Language == "" {
+		policy.Language = AdmissionLanguageCEL
+	}
+	if policy.Language != AdmissionLanguageCEL {
+		return nil, errors.New("language must be cel")
+	}
+	if policy.FailurePolicy == "" {
+		policy.FailurePolicy = AdmissionFailurePolicyFail
+	}
+	if policy.FailurePolicy != AdmissionFailurePolicyFail && policy.FailurePolicy != AdmissionFailurePolicyIgnore {
+		return nil, errors.New("failure_policy must be fail or ignore")
+	}
+	if policy.Message == "" {
+		policy.Message = "denied by admission policy " + policy.Name
+	}
+	for _, method := range in.Methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		switch method {
+		case "":
+			continue
+		case "POST", "PUT", "PATCH", "DELETE":
+			policy.Methods = append(policy.Methods, method)
+		default:
+			return nil, fmt.Errorf("method %s is not a mutating method", method)
+		}
+	}
+	if len(policy.Methods) == 0 {
+		policy.Methods = []string{"POST", "PUT", "PATCH", "DELETE"}
+	}
+	for _, path := range in.Paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		policy.Paths = append(policy.Paths, "/"+strings.Trim(path, "/"))
+	}
+
+	entry := &admissionPolicyEntry{}
+	var err error
+	if policy.Match != "" {
+		if entry.match, err = compileCEL(policy.Match); err != nil {
+			return nil, fmt.Errorf("match: %w", err)
+		}
+	}
+	if entry.expr, err = compileCEL(policy.Expression); err != nil {
+		return nil, fmt.Errorf("expression: %w", err)
+	}
+	for i, fixture := range in.Tests {
+		fixture.Name = strings.TrimSpace(fixture.Name)
+		if fixture.Name == "" {
+			fixture.Name = "fixture-" + itoa(int64(i+1))
+		}
+		fixture.Expect = strings.ToLower(strings.TrimSpace(fixture.Expect))
+		if fixture.Expect != "allow" && fixture.Expect != "deny" {
+			return nil, fmt.Errorf("test %s: expect must be allow or deny", fixture.Name)
+		}
+		fixture.Request.Method = strings.ToUpper(strings.TrimSpace(fixture.Request.Method))
+		if fixture.Request.Method == "" {
+			fixture.Request.Method = policy.Methods[0]
+		}
+		policy.Tests = append(policy.Tests, fixture)
+	}
+	entry.policy = policy
+	return entry, nil
+}
+
+func (e *admissionPolicyEntry) covers(method, path string) bool {
+	methodOK := false
+	for _, m := range e.policy.Methods {
+		if m == method {
+			methodOK = true
+			break
+		}
+	}
+	if !methodOK {
+		return false
+	}
+	if len(e.policy.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range e.policy.Paths {
+		if admissionPathMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *admissionPolicyEntry) evaluate(req AdmissionRequest, vars map[string]any) (AdmissionPolicyResult, bool) {
+	if !e.covers(req.Method, req.Path) {
+		return AdmissionPolicyResult{}, false
+	}
+	res := AdmissionPolicyResult{
+		PolicyID:   e.policy.ID,
+		PolicyName: e.policy.Name,
+		Allowed:    true,
+		DryRun:     e.policy.DryRun,
+	}
+	failed := func(err error) AdmissionPolicyResult {
+		res.Error = err.Error()
+		if e.policy.FailurePolicy == AdmissionFailurePolicyFail {
+			res.Allowed = false
+			res.Message = e.policy.Message
+		}
+		return res
+	}
+	if e.match != nil {
+		ok, err := e.match.evalBool(vars)
+		if err != nil {
+			return failed(fmt.Errorf("match: %w", err)), true
+		}
+		if !ok {
+			return AdmissionPolicyResult{}, false
+		}
+	}
+	ok, err := e.expr.evalBool(vars)
+	if err != nil {
+		return failed(err), true
+	}
+	if !ok {
+		res.Allowed = false
+		res.Message = e.policy.Message
+	}
+	return res, true
+}
+
+func (e *admissionPolicyEntry) runFixtures() AdmissionPolicyTestReport {
+	report := AdmissionPolicyTestReport{Passed: true, Results: []AdmissionFixtureResult{}}
+	for _, fixture := range e.policy.Tests {
+		got := "allow"
+		res, matched := e.evaluate(fixture.Request, admissionVars(fixture.Request))
+		if matched && !res.Allowed {
+			got = "deny"
+		}
+		item := AdmissionFixtureResult{
+			Name:   fixture.Name,
+			Expect: fixture.Expect,
+			Got:    got,
+			Passed: got == fixture.Expect,
+			Error:  res.Error,
+		}
+		if !item.Passed {
+			report.Passed = false
+		}
+		report.Results = append(report.Results, item)
+	}
+	return report
+}
+
+func fixtureFailure(report AdmissionPolicyTestReport) error {
+	var failed []string
+	for _, res := range report.Results {
+		if !res.Passed {
+			failed = append(failed, fmt.Sprintf("%s (expected %s, got %s)", res.Name, res.Expect, res.Got))
+		}
+	}
+	return errors.New("policy tests failed: " + strings.Join(failed, "; "))
+}
+
+// admissionPathMatches accepts exact paths, {param} segments, and a
+// trailing /* that matches the path itself and everything below it.
+func admissionPathMatches(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := strings.TrimSuffix(pattern, "*")
+		return strings.HasPrefix(path+"/", prefix)
+	}
+	return jitRouteMatches(pattern, strings.Split(strings.Trim(path, "/"), "/"))
+}
+
+func admissionVars(req AdmissionRequest) map[string]any {
+	query := map[string]any{}
+	for k, v := range req.Query {
+		query[k] = v
+	}
+	headers := map[string]any{}
+	for k, v := range req.Headers {
+		headers[strings.ToLower(k)] = v
+	}
+	body := admissionValue(req.Body)
+	if body == nil {
+		body = map[string]any{}
+	}
+	return map[string]any{
+		"request": map[string]any{
+			"method":    strings.ToUpper(req.Method),
+			"path":      req.Path,
+			"principal": req.Principal,
+			"tenant":    req.Tenant,
+			"query":     query,
+			"headers":   headers,
+			"body":      body,
+		},
+	}
+}
+
+// admissionValue converts decoded JSON into CEL values: whole numbers
+// become ints so that integer arithmetic and comparisons behave as
+// policy authors expect.
+func admissionValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, item := range x {
+			out[k] = admissionValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, item := range x {
+			out[i] = admissionValue(item)
+		}
+		return out
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n
+		}
+		f, _ := x.Float64()
+		return f
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int64(x)
+		}
+		return x
+	case int:
+		return int64(x)
+	}
+	return v
+}
+
+func cloneAdmissionPolicy(in AdmissionPolicy) AdmissionPolicy {
+	out := in
+	out.Methods = append([]string{}, in.Methods...)
+	out.Paths = append([]string{}, in.Paths...)
+	out.Tests = append([]AdmissionPolicyFixture{}, in.Tests...)
+	return out
+}
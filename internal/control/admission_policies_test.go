@@ -0,0 +1,126 @@
+package control
+
+import (
+	"strings"
+	"testing"
+)
+
+func prodJobPolicy() AdmissionPolicyInput {
+	return AdmissionPolicyInput{
+		Name:       "prod-jobs-need-change-record",
+		Paths:      []string{"/v1/jobs"},
+		Methods:    []string{"post"},
+		Match:      `has(request.body.environment) && request.body.environment == "prod"`,
+		Expression: `has(request.body.change_record) && request.body.priority != "low"`,
+		Message:    "prod jobs require change_record and priority other than low",
+		Tests: []AdmissionPolicyFixture{
+			{Name: "prod without change record", Expect: "deny", Request: AdmissionRequest{Path: "/v1/jobs", Body: map[string]any{"environment": "prod", "priority": "high"}}},
+			{Name: "prod low priority", Expect: "deny", Request: AdmissionRequest{Path: "/v1/jobs", Body: map[string]any{"environment": "prod", "priority": "low", "change_record": "CHG-1"}}},
+			{Name: "prod with change record", Expect: "allow", Request: AdmissionRequest{Path: "/v1/jobs", Body: map[string]any{"environment": "prod", "priority": "high", "change_record": "CHG-1"}}},
+			{Name: "staging", Expect: "allow", Request: AdmissionRequest{Path: "/v1/jobs", Body: map[string]any{"environment": "staging"}}},
+		},
+	}
+}
+
+func TestAdmissionPolicyEvaluation(t *testing.T) {
+	store := NewAdmissionPolicyStore()
+	policy, err := store.Create(prodJobPolicy())
+	if err != nil {
+		t.Fatalf("create admission policy failed: %v", err)
+	}
+	if policy.Language != AdmissionLanguageCEL || policy.FailurePolicy != AdmissionFailurePolicyFail || policy.Methods[0] != "POST" {
+		t.Fatalf("unexpected policy defaults: %+v", policy)
+	}
+	if !store.Applies("POST", "/v1/jobs") || store.Applies("GET", "/v1/jobs") || store.Applies("POST", "/v1/runs") {
+		t.Fatalf("unexpected policy coverage")
+	}
+
+	denied := store.Evaluate(AdmissionRequest{Method: "POST", Path: "/v1/jobs", Body: map[string]any{"environment": "prod", "priority": "low"}})
+	if denied.Allowed || denied.Message != policy.Message || len(denied.Results) != 1 {
+		t.Fatalf("expected enforced denial: %+v", denied)
+	}
+	allowed := store.Evaluate(AdmissionRequest{Method: "POST", Path: "/v1/jobs", Body: map[string]any{"environment": "dev"}})
+	if !allowed.Allowed || len(allowed.Results) != 0 {
+		t.Fatalf("expected unmatched request to be admitted without results: %+v", allowed)
+	}
+
+	in := prodJobPolicy()
+	in.DryRun = true
+	if _, err := store.Update(policy.ID, in, policy.Version); err != nil {
+		t.Fatalf("update admission policy failed: %v", err)
+	}
+	dryRun := store.Evaluate(AdmissionRequest{Method: "POST", Path: "/v1/jobs", Body: map[string]any{"environment": "prod"}})
+	if !dryRun.Allowed || len(dryRun.DryRunDenials()) != 1 {
+		t.Fatalf("expected dry-run denial to be reported but admitted: %+v", dryRun)
+	}
+
+	if !store.Delete(policy.ID) || store.Applies("POST", "/v1/jobs") {
+		t.Fatalf("expected policy to be deleted")
+	}
+}
+
+func TestAdmissionPolicyFailurePolicyAndPaths(t *testing.T) {
+	store := NewAdmissionPolicyStore()
+	if _, err := store.Create(AdmissionPolicyInput{
+		Name:       "runbooks-need-owner",
+		Paths:      []string{"/v1/runbooks/*"},
+		Expression: `request.body.owner != ""`,
+	}); err != nil {
+		t.Fatalf("create fail-closed policy failed: %v", err)
+	}
+	if !store.Applies("POST", "/v1/runbooks") || !store.Applies("DELETE", "/v1/runbooks/rb-1/approve") || store.Applies("POST", "/v1/runbooksx") {
+		t.Fatalf("unexpected wildcard path coverage")
+	}
+	res := store.Evaluate(AdmissionRequest{Method: "POST", Path: "/v1/runbooks/rb-1", Body: map[string]any{}})
+	if res.Allowed || res.Results[0].Error == "" {
+		t.Fatalf("expected evaluation error to deny under fail policy: %+v", res)
+	}
+
+	ignore := NewAdmissionPolicyStore()
+	if _, err := ignore.Create(AdmissionPolicyInput{
+		Name:          "runbooks-need-owner",
+		Paths:         []string{"/v1/runbooks/{id}"},
+		Expression:    `request.body.owner != ""`,
+		FailurePolicy: "ignore",
+	}); err != nil {
+		t.Fatalf("create fail-open policy failed: %v", err)
+	}
+	res = ignore.Evaluate(AdmissionRequest{Method: "POST", Path: "/v1/runbooks/rb-1", Body: map[string]any{}})
+	if !res.Allowed || res.Results[0].Error == "" {
+		t.Fatalf("expected evaluation error to admit under ignore policy: %+v", res)
+	}
+}
+
+func TestAdmissionPolicyValidationAndFixtures(t *testing.T) {
+	store := NewAdmissionPolicyStore()
+	invalid := []AdmissionPolicyInput{
+		{Name: "missing-expression"},
+		{Name: "rego", Language: "rego", Expression: "allow"},
+		{Name: "read-only", Methods: []string{"GET"}, Expression: "true"},
+		{Name: "syntax", Expression: `request.body.`},
+		{Name: "bad-failure", Expression: "true", FailurePolicy: "maybe"},
+		{Name: "bad-expect", Expression: "true", Tests: []AdmissionPolicyFixture{{Expect: "pass"}}},
+	}
+	for _, in := range invalid {
+		if _, err := store.Create(in); err == nil {
+			t.Fatalf("expected policy %s to be rejected", in.Name)
+		}
+	}
+
+	in := prodJobPolicy()
+	in.Expression = `has(request.body.change_record)`
+	_, err := store.Create(in)
+	if err == nil || !strings.Contains(err.Error(), "prod low priority") {
+		t.Fatalf("expected failing fixture to block create, got %v", err)
+	}
+	report, err := store.Test(in)
+	if err != nil {
+		t.Fatalf("test admission policy failed: %v", err)
+	}
+	if report.Passed || len(report.Results) != 4 || report.Results[1].Got != "allow" {
+		t.Fatalf("unexpected fixture report: %+v", report)
+	}
+	if len(store.List()) != 0 {
+		t.Fatalf("expected no stored policies")
+	}
+}
@@ -11,10 +11,16 @@ import (
 )
 
 type AgentAttestationPolicy struct {
-	RequireBeforeCert bool      `json:"require_before_cert"`
-	AllowedProviders  []string  `json:"allowed_providers,omitempty"`
-	MaxAgeMinutes     int       `json:"max_age_minutes"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	RequireBeforeCert bool `json:"require_before_cert"`
+	// RequireVerifiedEvidence rejects claims-only submissions: evidence must
+	// be a TPM quote or platform statement checked against a registered key.
+	RequireVerifiedEvidence bool `json:"require_verified_evidence"`
+	// RequireForDispatch blocks dispatch to agents that have never attested.
+	// Agents whose latest attestation failed or expired are always blocked.
+	RequireForDispatch bool      `json:"require_for_dispatch"`
+	AllowedProviders   []string  `json:"allowed_providers,omitempty"`
+	MaxAgeMinutes      int       `json:"max_age_minutes"`
+	UpdatedAt          time.Time `json:"updated_at"`
 }
 
 type AgentAttestationInput struct {
@@ -22,6 +28,9 @@ type AgentAttestationInput struct {
 	Provider string            `json:"provider"` // tpm|aws_iid|gcp_shielded|azure_imds
 	Nonce    string            `json:"nonce"`
 	Claims   map[string]string `json:"claims,omitempty"`
+
+	Quote     *TPMQuote          `json:"quote,omitempty"`
+	Statement *PlatformStatement `json:"statement,omitempty"`
 }
 
 type AgentAttestationEvidence struct {
@@ -30,22 +39,42 @@ type AgentAttestationEvidence struct {
 	Provider     string            `json:"provider"`
 	EvidenceHash string            `json:"evidence_hash"`
 	Claims       map[string]string `json:"claims,omitempty"`
+	Method       string            `json:"method"` // claims|tpm_quote|platform_statement
+	KeyID        string            `json:"key_id,omitempty"`
+	Measurements map[string]string `json:"measurements,omitempty"`
 	Verified     bool              `json:"verified"`
 	Reason       string            `json:"reason,omitempty"`
 	CollectedAt  time.Time         `json:"collected_at"`
 	ExpiresAt    time.Time         `json:"expires_at"`
 }
 
+// AgentAttestationStatus is the cached outcome of an agent's latest
+// attestation. It outlives the evidence itself so dispatch can tell an
+// expired attestation from one that never happened.
+type AgentAttestationStatus struct {
+	AgentID       string    `json:"agent_id"`
+	Status        string    `json:"status"` // verified|failed|expired
+	AttestationID string    `json:"attestation_id"`
+	Method        string    `json:"method"`
+	Reason        string    `json:"reason,omitempty"`
+	CheckedAt     time.Time `json:"checked_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
 type AgentAttestationCheck struct {
 	Allowed bool   `json:"allowed"`
 	Reason  string `json:"reason,omitempty"`
 }
 
 type AgentAttestationStore struct {
-	mu       sync.RWMutex
-	nextID   int64
-	policy   AgentAttestationPolicy
-	evidence map[string]*AgentAttestationEvidence
+	mu         sync.RWMutex
+	nextID     int64
+	nextKeyID  int64
+	policy     AgentAttestationPolicy
+	evidence   map[string]*AgentAttestationEvidence
+	statuses   map[string]*AgentAttestationStatus
+	keys       map[string]*agentAttestationKeyRecord
+	challenges map[string]*AgentAttestationChallenge
 }
 
 func NewAgentAttestationStore() *AgentAttestationStore {
@@ -56,7 +85,10 @@ func NewAgentAttestationStore() *AgentAttestationStore {
 			MaxAgeMinutes:     60,
 			UpdatedAt:         time.Now().UTC(),
 		},
-		evidence: map[string]*AgentAttestationEvidence{},
+		evidence:   map[string]*AgentAttestationEvidence{},
+		statuses:   map[string]*AgentAttestationStatus{},
+		keys:       map[string]*agentAttestationKeyRecord{},
+		challenges: map[string]*AgentAttestationChallenge{},
 	}
 }
 
@@ -85,10 +117,12 @@ func (s *AgentAttestationStore) SetPolicy(policy AgentAttestationPolicy) AgentAt
 		maxAge = 24 * 60
 	}
 	item := AgentAttestationPolicy{
-		RequireBeforeCert: policy.RequireBeforeCert,
-		AllowedProviders:  allowed,
-		MaxAgeMinutes:     maxAge,
-		UpdatedAt:         time.Now().UTC(),
+		RequireBeforeCert:       policy.RequireBeforeCert,
+		RequireVerifiedEvidence: policy.RequireVerifiedEvidence,
+		RequireForDispatch:      policy.RequireForDispatch,
+		AllowedProviders:        allowed,
+		MaxAgeMinutes:           maxAge,
+		UpdatedAt:               time.Now().UTC(),
 	}
 	s.mu.Lock()
 	s.policy = item
@@ -111,22 +145,46 @@ func (s *AgentAttestationStore) Submit(in AgentAttestationInput) (AgentAttestati
 		}
 		claims[key] = strings.TrimSpace(v)
 	}
+	if in.Quote != nil && in.Statement != nil {
+		return AgentAttestationEvidence{}, errors.New("submit either a quote or a statement, not both")
+	}
 	now := time.Now().UTC()
 	hashInput := agentID + "|" + provider + "|" + nonce
+	method := "claims"
+	switch {
+	case in.Quote != nil:
+		method = "tpm_quote"
+		hashInput += "|" + in.Quote.Attest + "|" + in.Quote.Signature
+	case in.Statement != nil:
+		method = "platform_statement"
+		hashInput += "|" + in.Statement.Payload + "|" + in.Statement.Signature
+	}
 	sum := sha256.Sum256([]byte(hashInput))
 	evidenceHash := "sha256:" + hex.EncodeToString(sum[:])
 
 	policy := s.Policy()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var measurements map[string]string
+	var keyID string
 	verified := false
 	reason := ""
-	if providerAllowed(provider, policy.AllowedProviders) {
-		verified = true
-	} else {
+	switch {
+	case !providerAllowed(provider, policy.AllowedProviders):
 		reason = "provider not allowed by policy"
+	case method == "claims" && policy.RequireVerifiedEvidence:
+		reason = "policy requires a tpm quote or signed platform statement"
+	case method == "claims":
+		verified = true
+	default:
+		var err error
+		measurements, keyID, err = s.verifyEvidenceLocked(agentID, provider, nonce, in, now)
+		if err != nil {
+			reason = err.Error()
+		} else {
+			verified = true
+		}
 	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.nextID++
 	item := AgentAttestationEvidence{
 		ID:           "attestation-" + itoa(s.nextID),
@@ -134,15 +192,77 @@ func (s *AgentAttestationStore) Submit(in AgentAttestationInput) (AgentAttestati
 		Provider:     provider,
 		EvidenceHash: evidenceHash,
 		Claims:       claims,
+		Method:       method,
+		KeyID:        keyID,
+		Measurements: measurements,
 		Verified:     verified,
 		Reason:       reason,
 		CollectedAt:  now,
 		ExpiresAt:    now.Add(time.Duration(policy.MaxAgeMinutes) * time.Minute),
 	}
 	s.evidence[item.ID] = &item
+	status := "verified"
+	if !verified {
+		status = "failed"
+	}
+	s.statuses[agentID] = &AgentAttestationStatus{
+		AgentID:       agentID,
+		Status:        status,
+		AttestationID: item.ID,
+		Method:        method,
+		Reason:        reason,
+		CheckedAt:     now,
+		ExpiresAt:     item.ExpiresAt,
+	}
 	return cloneAttestationEvidence(item), nil
 }
 
+// Status returns the cached result of the agent's latest attestation.
+func (s *AgentAttestationStore) Status(agentID string) (AgentAttestationStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.statuses[strings.TrimSpace(agentID)]
+	if !ok {
+		return AgentAttestationStatus{}, false
+	}
+	return currentAttestationStatus(*item, time.Now().UTC()), true
+}
+
+func (s *AgentAttestationStore) Statuses() []AgentAttestationStatus {
+	now := time.Now().UTC()
+	s.mu.RLock()
+	out := make([]AgentAttestationStatus, 0, len(s.statuses))
+	for _, item := range s.statuses {
+		out = append(out, currentAttestationStatus(*item, now))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].AgentID < out[j].AgentID })
+	return out
+}
+
+// CheckForDispatch decides from the cached attestation status whether work
+// may be dispatched to the agent.
+func (s *AgentAttestationStore) CheckForDispatch(agentID string) AgentAttestationCheck {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return AgentAttestationCheck{Allowed: false, Reason: "agent_id is required"}
+	}
+	status, ok := s.Status(agentID)
+	if !ok {
+		if s.Policy().RequireForDispatch {
+			return AgentAttestationCheck{Allowed: false, Reason: "no attestation recorded for agent"}
+		}
+		return AgentAttestationCheck{Allowed: true}
+	}
+	switch status.Status {
+	case "failed":
+		return AgentAttestationCheck{Allowed: false, Reason: "latest attestation failed: " + status.Reason}
+	case "expired":
+		return AgentAttestationCheck{Allowed: false, Reason: "latest attestation expired"}
+	}
+	return AgentAttestationCheck{Allowed: true}
+}
+
 func (s *AgentAttestationStore) List() []AgentAttestationEvidence {
 	now := time.Now().UTC()
 	s.mu.Lock()
@@ -223,11 +343,24 @@ func cloneAttestationPolicy(in AgentAttestationPolicy) AgentAttestationPolicy {
 	return out
 }
 
+func currentAttestationStatus(in AgentAttestationStatus, now time.Time) AgentAttestationStatus {
+	if in.Status == "verified" && !now.Before(in.ExpiresAt) {
+		in.Status = "expired"
+	}
+	return in
+}
+
 func cloneAttestationEvidence(in AgentAttestationEvidence) AgentAttestationEvidence {
 	out := in
 	out.Claims = map[string]string{}
 	for k, v := range in.Claims {
 		out.Claims[k] = v
 	}
+	if in.Measurements != nil {
+		out.Measurements = map[string]string{}
+		for k, v := range in.Measurements {
+			out.Measurements[k] = v
+		}
+	}
 	return out
 }
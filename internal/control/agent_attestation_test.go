@@ -1,6 +1,18 @@
 package control
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -104,3 +116,155 @@ func TestAgentAttestationExpiryPruning(t *testing.T) {
 		t.Fatalf("expected evidence pruned after expiry")
 	}
 }
+
+func buildTestTPMQuote(t *testing.T, key *ecdsa.PrivateKey, nonce string, pcrs map[int][]byte) TPMQuote {
+	t.Helper()
+	extra, err := hex.DecodeString(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bitmap [3]byte
+	digest := sha256.New()
+	values := map[string]string{}
+	for idx := 0; idx < 24; idx++ {
+		raw, ok := pcrs[idx]
+		if !ok {
+			continue
+		}
+		bitmap[idx/8] |= 1 << (idx % 8)
+		digest.Write(raw)
+		values[strconv.Itoa(idx)] = hex.EncodeToString(raw)
+	}
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, uint32(0xff544347))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(0x8018))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(4))
+	buf.Write([]byte{0, 0x0b, 1, 2})
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(extra)))
+	buf.Write(extra)
+	buf.Write(make([]byte, 17+8))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(1))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(0x000b))
+	buf.WriteByte(3)
+	buf.Write(bitmap[:])
+	sum := digest.Sum(nil)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(sum)))
+	buf.Write(sum)
+	attest := buf.Bytes()
+	h := sha256.Sum256(attest)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return TPMQuote{
+		Attest:    base64.StdEncoding.EncodeToString(attest),
+		Signature: base64.StdEncoding.EncodeToString(sig),
+		PCRs:      values,
+	}
+}
+
+func testPublicKeyPEM(t *testing.T, pub any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestAgentAttestationVerifiesTPMQuote(t *testing.T) {
+	store := NewAgentAttestationStore()
+	store.SetPolicy(AgentAttestationPolicy{RequireVerifiedEvidence: true, MaxAgeMinutes: 30})
+	ak, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcr0 := bytes.Repeat([]byte{0x11}, 32)
+	pcr7 := bytes.Repeat([]byte{0x77}, 32)
+	if _, err := store.RegisterKey(AgentAttestationKeyInput{
+		AgentID:              "agent-1",
+		Provider:             "tpm",
+		PublicKey:            testPublicKeyPEM(t, &ak.PublicKey),
+		ExpectedMeasurements: map[string]string{"pcr0": hex.EncodeToString(pcr0), "7": hex.EncodeToString(pcr7)},
+	}); err != nil {
+		t.Fatalf("register key failed: %v", err)
+	}
+
+	if ev, _ := store.Submit(AgentAttestationInput{AgentID: "agent-1", Provider: "tpm", Nonce: "n-1"}); ev.Verified {
+		t.Fatalf("expected claims-only evidence rejected by policy, got %+v", ev)
+	}
+	if check := store.CheckForDispatch("agent-1"); check.Allowed {
+		t.Fatalf("expected failed attestation to block dispatch")
+	}
+
+	ch, err := store.IssueChallenge("agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote := buildTestTPMQuote(t, ak, ch.Nonce, map[int][]byte{0: pcr0, 7: pcr7})
+	ev, err := store.Submit(AgentAttestationInput{AgentID: "agent-1", Provider: "tpm", Nonce: ch.Nonce, Quote: &quote})
+	if err != nil {
+		t.Fatalf("submit quote failed: %v", err)
+	}
+	if !ev.Verified || ev.Method != "tpm_quote" || ev.Measurements["7"] != hex.EncodeToString(pcr7) {
+		t.Fatalf("expected verified tpm quote, got %+v", ev)
+	}
+	if check := store.CheckForDispatch("agent-1"); !check.Allowed {
+		t.Fatalf("expected verified attestation to allow dispatch, got %+v", check)
+	}
+
+	replay, _ := store.Submit(AgentAttestationInput{AgentID: "agent-1", Provider: "tpm", Nonce: ch.Nonce, Quote: &quote})
+	if replay.Verified || !strings.Contains(replay.Reason, "nonce") {
+		t.Fatalf("expected replayed quote rejected, got %+v", replay)
+	}
+
+	ch, _ = store.IssueChallenge("agent-1")
+	tampered := buildTestTPMQuote(t, ak, ch.Nonce, map[int][]byte{0: pcr0, 7: bytes.Repeat([]byte{0x99}, 32)})
+	bad, _ := store.Submit(AgentAttestationInput{AgentID: "agent-1", Provider: "tpm", Nonce: ch.Nonce, Quote: &tampered})
+	if bad.Verified || !strings.Contains(bad.Reason, "measurement 7") {
+		t.Fatalf("expected boot measurement mismatch, got %+v", bad)
+	}
+
+	ch, _ = store.IssueChallenge("agent-1")
+	forged := buildTestTPMQuote(t, ak, ch.Nonce, map[int][]byte{0: pcr0, 7: pcr7})
+	forged.PCRs["7"] = hex.EncodeToString(bytes.Repeat([]byte{0x98}, 32))
+	bad, _ = store.Submit(AgentAttestationInput{AgentID: "agent-1", Provider: "tpm", Nonce: ch.Nonce, Quote: &forged})
+	if bad.Verified || !strings.Contains(bad.Reason, "quoted digest") {
+		t.Fatalf("expected reported pcrs to be checked against the quote, got %+v", bad)
+	}
+	if status, ok := store.Status("agent-1"); !ok || status.Status != "failed" {
+		t.Fatalf("expected cached failed status, got %+v", status)
+	}
+}
+
+func TestAgentAttestationVerifiesPlatformStatementAndExpiry(t *testing.T) {
+	store := NewAgentAttestationStore()
+	store.SetPolicy(AgentAttestationPolicy{RequireForDispatch: true, MaxAgeMinutes: 30})
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.RegisterKey(AgentAttestationKeyInput{Provider: "gcp_shielded", PublicKey: testPublicKeyPEM(t, pub)}); err != nil {
+		t.Fatalf("register platform signer failed: %v", err)
+	}
+	if check := store.CheckForDispatch("vm-1"); check.Allowed {
+		t.Fatalf("expected unattested agent blocked when required for dispatch")
+	}
+	ch, _ := store.IssueChallenge("vm-1")
+	payload := []byte(`{"agent_id":"vm-1","provider":"gcp_shielded","nonce":"` + ch.Nonce + `","measurements":{"secure_boot":"on"}}`)
+	stmt := PlatformStatement{
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload)),
+	}
+	ev, err := store.Submit(AgentAttestationInput{AgentID: "vm-1", Provider: "gcp_shielded", Nonce: ch.Nonce, Statement: &stmt})
+	if err != nil || !ev.Verified || ev.Method != "platform_statement" {
+		t.Fatalf("expected verified platform statement, got %+v err=%v", ev, err)
+	}
+
+	store.mu.Lock()
+	store.statuses["vm-1"].ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	store.mu.Unlock()
+	if check := store.CheckForDispatch("vm-1"); check.Allowed || !strings.Contains(check.Reason, "expired") {
+		t.Fatalf("expected expired attestation to block dispatch, got %+v", check)
+	}
+}
@@ -0,0 +1,466 @@
+package control
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tpmGeneratedValue     = 0xff544347
+	tpmSTAttestQuote      = 0x8018
+	tpmAlgSHA256          = 0x000b
+	tpmAlgSHA384          = 0x000c
+	agentAttestChallenge  = 5 * time.Minute
+	agentAttestMaxPCRBank = 24
+)
+
+// TPMQuote is a TPM2_Quote over the agent's PCRs. Attest is the marshalled
+// TPMS_ATTEST structure, Signature the attestation key's signature over it
+// (RSA PKCS#1 v1.5 or PSS, ECDSA ASN.1, or Ed25519), and PCRs the quoted
+// register values keyed by index.
+type TPMQuote struct {
+	Attest    string            `json:"attest"`    // base64
+	Signature string            `json:"signature"` // base64
+	PCRs      map[string]string `json:"pcrs"`      // index -> hex
+}
+
+// PlatformStatement is a platform-signed JSON document, for providers that
+// vouch for boot state without exposing a TPM quote.
+type PlatformStatement struct {
+	Payload   string `json:"payload"`   // base64 JSON platformStatementBody
+	Signature string `json:"signature"` // base64
+}
+
+type platformStatementBody struct {
+	AgentID      string            `json:"agent_id"`
+	Provider     string            `json:"provider"`
+	Nonce        string            `json:"nonce"`
+	Measurements map[string]string `json:"measurements,omitempty"`
+}
+
+// AgentAttestationKey is a trust anchor for attestation evidence: a TPM
+// attestation key bound to one agent, or a platform signer for a provider
+// (AgentID empty). ExpectedMeasurements are golden values evidence must
+// report, PCR indices for TPM keys.
+type AgentAttestationKey struct {
+	ID                   string            `json:"id"`
+	AgentID              string            `json:"agent_id,omitempty"`
+	Provider             string            `json:"provider"`
+	Algorithm            string            `json:"algorithm"`
+	Fingerprint          string            `json:"fingerprint"`
+	ExpectedMeasurements map[string]string `json:"expected_measurements,omitempty"`
+	RegisteredAt         time.Time         `json:"registered_at"`
+}
+
+type AgentAttestationKeyInput struct {
+	AgentID              string            `json:"agent_id,omitempty"`
+	Provider             string            `json:"provider"`
+	PublicKey            string            `json:"public_key"` // PEM PKIX
+	ExpectedMeasurements map[string]string `json:"expected_measurements,omitempty"`
+}
+
+type AgentAttestationChallenge struct {
+	AgentID   string    `json:"agent_id"`
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type agentAttestationKeyRecord struct {
+	item      AgentAttestationKey
+	publicKey crypto.PublicKey
+}
+
+func (s *AgentAttestationStore) RegisterKey(in AgentAttestationKeyInput) (AgentAttestationKey, error) {
+	agentID := strings.TrimSpace(in.AgentID)
+	provider := strings.ToLower(strings.TrimSpace(in.Provider))
+	switch provider {
+	case "tpm":
+		if agentID == "" {
+			return AgentAttestationKey{}, errors.New("agent_id is required for tpm attestation keys")
+		}
+	case "aws_iid", "gcp_shielded", "azure_imds":
+	default:
+		return AgentAttestationKey{}, errors.New("provider must be tpm, aws_iid, gcp_shielded, or azure_imds")
+	}
+	block, _ := pem.Decode([]byte(strings.TrimSpace(in.PublicKey)))
+	if block == nil {
+		return AgentAttestationKey{}, errors.New("public_key must be a PEM encoded public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return AgentAttestationKey{}, errors.New("public_key could not be parsed: " + err.Error())
+	}
+	var algorithm string
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		algorithm = "rsa"
+	case *ecdsa.PublicKey:
+		algorithm = "ecdsa"
+	case ed25519.PublicKey:
+		algorithm = "ed25519"
+	default:
+		return AgentAttestationKey{}, errors.New("public_key must be rsa, ecdsa, or ed25519")
+	}
+	expected := map[string]string{}
+	for k, v := range in.ExpectedMeasurements {
+		key := strings.TrimSpace(k)
+		if key == "" {
+			continue
+		}
+		if provider == "tpm" {
+			idx, err := parsePCRIndex(key)
+			if err != nil {
+				return AgentAttestationKey{}, err
+			}
+			key = strconv.Itoa(idx)
+		}
+		expected[key] = strings.ToLower(strings.TrimSpace(v))
+	}
+	sum := sha256.Sum256(block.Bytes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, existing := range s.keys {
+		if existing.item.AgentID == agentID && existing.item.Provider == provider {
+			delete(s.keys, id)
+		}
+	}
+	s.nextKeyID++
+	item := AgentAttestationKey{
+		ID:                   "agent-attestation-key-" + itoa(s.nextKeyID),
+		AgentID:              agentID,
+		Provider:             provider,
+		Algorithm:            algorithm,
+		Fingerprint:          "sha256:" + hex.EncodeToString(sum[:]),
+		ExpectedMeasurements: expected,
+		RegisteredAt:         time.Now().UTC(),
+	}
+	s.keys[item.ID] = &agentAttestationKeyRecord{item: item, publicKey: pub}
+	return cloneAgentAttestationKey(item), nil
+}
+
+func (s *AgentAttestationStore) ListKeys() []AgentAttestationKey {
+	s.mu.RLock()
+	out := make([]AgentAttestationKey, 0, len(s.keys))
+	for _, record := range s.keys {
+		out = append(out, cloneAgentAttestationKey(record.item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// HasKey reports whether an attestation key is bound to the agent itself.
+func (s *AgentAttestationStore) HasKey(agentID string) bool {
+	agentID = strings.TrimSpace(agentID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.keys {
+		if record.item.AgentID == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueChallenge hands out a single-use nonce the agent must bind into its
+// next quote or platform statement, so captured evidence cannot be replayed.
+func (s *AgentAttestationStore) IssueChallenge(agentID string) (AgentAttestationChallenge, error) {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return AgentAttestationChallenge{}, errors.New("agent_id is required")
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return AgentAttestationChallenge{}, err
+	}
+	now := time.Now().UTC()
+	item := AgentAttestationChallenge{
+		AgentID:   agentID,
+		Nonce:     hex.EncodeToString(raw),
+		ExpiresAt: now.Add(agentAttestChallenge),
+	}
+	s.mu.Lock()
+	for nonce, ch := range s.challenges {
+		if !now.Before(ch.ExpiresAt) {
+			delete(s.challenges, nonce)
+		}
+	}
+	cp := item
+	s.challenges[item.Nonce] = &cp
+	s.mu.Unlock()
+	return item, nil
+}
+
+// verifyEvidenceLocked checks a TPM quote or platform statement against the
+// registered keys and the outstanding challenge. It returns the verified
+// measurements and the key used, or the reason verification failed.
+func (s *AgentAttestationStore) verifyEvidenceLocked(agentID, provider, nonce string, in AgentAttestationInput, now time.Time) (map[string]string, string, error) {
+	ch, ok := s.challenges[nonce]
+	delete(s.challenges, nonce)
+	if !ok || ch.AgentID != agentID || !now.Before(ch.ExpiresAt) {
+		return nil, "", errors.New("nonce was not issued to this agent or has expired")
+	}
+	if in.Quote != nil {
+		if provider != "tpm" {
+			return nil, "", errors.New("tpm quotes require provider tpm")
+		}
+		record := s.findKeyLocked(agentID, provider, false)
+		if record == nil {
+			return nil, "", errors.New("no attestation key registered for agent")
+		}
+		measurements, err := verifyTPMQuote(record, *in.Quote, nonce)
+		return measurements, record.item.ID, err
+	}
+	record := s.findKeyLocked(agentID, provider, true)
+	if record == nil {
+		return nil, "", errors.New("no platform signer registered for provider")
+	}
+	measurements, err := verifyPlatformStatement(record, *in.Statement, agentID, provider, nonce)
+	return measurements, record.item.ID, err
+}
+
+func (s *AgentAttestationStore) findKeyLocked(agentID, provider string, allowProviderWide bool) *agentAttestationKeyRecord {
+	var fallback *agentAttestationKeyRecord
+	for _, record := range s.keys {
+		if record.item.Provider != provider {
+			continue
+		}
+		if record.item.AgentID == agentID {
+			return record
+		}
+		if allowProviderWide && record.item.AgentID == "" {
+			fallback = record
+		}
+	}
+	return fallback
+}
+
+func verifyTPMQuote(record *agentAttestationKeyRecord, quote TPMQuote, nonce string) (map[string]string, error) {
+	attest, err := base64.StdEncoding.DecodeString(strings.TrimSpace(quote.Attest))
+	if err != nil || len(attest) == 0 {
+		return nil, errors.New("quote attest must be base64")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(quote.Signature))
+	if err != nil || len(sig) == 0 {
+		return nil, errors.New("quote signature must be base64")
+	}
+	if !verifyAttestationSignature(record.publicKey, attest, sig) {
+		return nil, errors.New("quote signature does not match attestation key")
+	}
+	parsed, err := parseTPMQuote(attest)
+	if err != nil {
+		return nil, err
+	}
+	want, err := hex.DecodeString(nonce)
+	if err != nil || !bytes.Equal(parsed.extraData, want) {
+		return nil, errors.New("quote is not bound to the issued nonce")
+	}
+	values := map[int][]byte{}
+	for k, v := range quote.PCRs {
+		idx, err := parsePCRIndex(k)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := hex.DecodeString(strings.TrimSpace(v))
+		if err != nil {
+			return nil, errors.New("pcr " + k + " must be hex")
+		}
+		values[idx] = raw
+	}
+	var h hash.Hash
+	switch parsed.hashAlg {
+	case tpmAlgSHA256:
+		h = sha256.New()
+	case tpmAlgSHA384:
+		h = sha512.New384()
+	default:
+		return nil, errors.New("quote pcr bank must be sha256 or sha384")
+	}
+	measurements := map[string]string{}
+	for _, idx := range parsed.pcrs {
+		raw, ok := values[idx]
+		if !ok || len(raw) != h.Size() {
+			return nil, errors.New("quoted pcr " + strconv.Itoa(idx) + " value missing or wrong size")
+		}
+		h.Write(raw)
+		measurements[strconv.Itoa(idx)] = hex.EncodeToString(raw)
+	}
+	if !bytes.Equal(h.Sum(nil), parsed.pcrDigest) {
+		return nil, errors.New("reported pcr values do not match quoted digest")
+	}
+	if err := compareMeasurements(record.item.ExpectedMeasurements, measurements); err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+func verifyPlatformStatement(record *agentAttestationKeyRecord, stmt PlatformStatement, agentID, provider, nonce string) (map[string]string, error) {
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimSpace(stmt.Payload))
+	if err != nil || len(payload) == 0 {
+		return nil, errors.New("statement payload must be base64")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(stmt.Signature))
+	if err != nil || len(sig) == 0 {
+		return nil, errors.New("statement signature must be base64")
+	}
+	if !verifyAttestationSignature(record.publicKey, payload, sig) {
+		return nil, errors.New("statement signature does not match platform signer")
+	}
+	var body platformStatementBody
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, errors.New("statement payload must be json")
+	}
+	if body.AgentID != agentID || strings.ToLower(body.Provider) != provider || body.Nonce != nonce {
+		return nil, errors.New("statement is not bound to this agent, provider, and nonce")
+	}
+	measurements := map[string]string{}
+	for k, v := range body.Measurements {
+		measurements[strings.TrimSpace(k)] = strings.ToLower(strings.TrimSpace(v))
+	}
+	if err := compareMeasurements(record.item.ExpectedMeasurements, measurements); err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+func compareMeasurements(expected, actual map[string]string) error {
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		got, ok := actual[k]
+		if !ok {
+			return errors.New("measurement " + k + " was not reported")
+		}
+		if got != expected[k] {
+			return errors.New("measurement " + k + " does not match expected value")
+		}
+	}
+	return nil
+}
+
+func verifyAttestationSignature(pub crypto.PublicKey, msg, sig []byte) bool {
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, msg, sig)
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(msg)
+		return ecdsa.VerifyASN1(key, sum[:], sig)
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(msg)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) == nil {
+			return true
+		}
+		return rsa.VerifyPSS(key, crypto.SHA256, sum[:], sig, nil) == nil
+	}
+	return false
+}
+
+type tpmQuoteInfo struct {
+	extraData []byte
+	hashAlg   uint16
+	pcrs      []int
+	pcrDigest []byte
+}
+
+// parseTPMQuote decodes the fields of a TPMS_ATTEST quote that verification
+// needs. Only a single PCR bank selection is supported.
+func parseTPMQuote(raw []byte) (tpmQuoteInfo, error) {
+	r := bytes.NewReader(raw)
+	var magic uint32
+	var typ uint16
+	if binary.Read(r, binary.BigEndian, &magic) != nil || magic != tpmGeneratedValue {
+		return tpmQuoteInfo{}, errors.New("quote attest is not a tpm generated structure")
+	}
+	if binary.Read(r, binary.BigEndian, &typ) != nil || typ != tpmSTAttestQuote {
+		return tpmQuoteInfo{}, errors.New("quote attest is not a tpm quote")
+	}
+	if _, err := readTPM2B(r); err != nil { // qualifiedSigner
+		return tpmQuoteInfo{}, err
+	}
+	extra, err := readTPM2B(r)
+	if err != nil {
+		return tpmQuoteInfo{}, err
+	}
+	// clockInfo (17 bytes) and firmwareVersion (8 bytes)
+	if _, err := r.Seek(17+8, 1); err != nil {
+		return tpmQuoteInfo{}, errors.New("quote attest is truncated")
+	}
+	var count uint32
+	if binary.Read(r, binary.BigEndian, &count) != nil || count != 1 {
+		return tpmQuoteInfo{}, errors.New("quote must select exactly one pcr bank")
+	}
+	var alg uint16
+	var size uint8
+	if binary.Read(r, binary.BigEndian, &alg) != nil || binary.Read(r, binary.BigEndian, &size) != nil || size == 0 || int(size)*8 > agentAttestMaxPCRBank {
+		return tpmQuoteInfo{}, errors.New("quote pcr selection is invalid")
+	}
+	bitmap := make([]byte, size)
+	if _, err := r.Read(bitmap); err != nil {
+		return tpmQuoteInfo{}, errors.New("quote attest is truncated")
+	}
+	var pcrs []int
+	for i, b := range bitmap {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				pcrs = append(pcrs, i*8+bit)
+			}
+		}
+	}
+	if len(pcrs) == 0 {
+		return tpmQuoteInfo{}, errors.New("quote selects no pcrs")
+	}
+	digest, err := readTPM2B(r)
+	if err != nil {
+		return tpmQuoteInfo{}, err
+	}
+	return tpmQuoteInfo{extraData: extra, hashAlg: alg, pcrs: pcrs, pcrDigest: digest}, nil
+}
+
+func readTPM2B(r *bytes.Reader) ([]byte, error) {
+	var size uint16
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil || int(size) > r.Len() {
+		return nil, errors.New("quote attest is truncated")
+	}
+	out := make([]byte, size)
+	_, _ = r.Read(out)
+	return out, nil
+}
+
+func parsePCRIndex(in string) (int, error) {
+	idx, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(in)), "pcr"))
+	if err != nil || idx < 0 || idx >= agentAttestMaxPCRBank {
+		return 0, errors.New("pcr index " + in + " must be between 0 and 23")
+	}
+	return idx, nil
+}
+
+func cloneAgentAttestationKey(in AgentAttestationKey) AgentAttestationKey {
+	out := in
+	out.ExpectedMeasurements = map[string]string{}
+	for k, v := range in.ExpectedMeasurements {
+		out.ExpectedMeasurements[k] = v
+	}
+	return out
+}
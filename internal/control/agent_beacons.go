@@ -0,0 +1,507 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BeaconKindFile    = "file"
+	BeaconKindService = "service"
+	BeaconKindDisk    = "disk"
+)
+
+// AgentBeacon is a Salt-style beacon that agents evaluate every
+// IntervalSeconds, emitting beacon.<kind>.<name> events on change: a watched
+// file is created, modified, or deleted; a service goes down or recovers; a
+// disk crosses ThresholdPercent. At most FloodLimit events are emitted per
+// node per FloodWindowSeconds; the rest are counted as suppressed.
+type AgentBeacon struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Kind               string    `json:"kind"`
+	Nodes              []string  `json:"nodes,omitempty"`
+	Path               string    `json:"path,omitempty"`
+	Service            string    `json:"service,omitempty"`
+	CheckCommand       []string  `json:"check_command,omitempty"`
+	ThresholdPercent   float64   `json:"threshold_percent,omitempty"`
+	IntervalSeconds    int       `json:"interval_seconds"`
+	FloodLimit         int       `json:"flood_limit"`
+	FloodWindowSeconds int       `json:"flood_window_seconds"`
+	Enabled            bool      `json:"enabled"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type AgentBeaconInput struct {
+	Name               string   `json:"name"`
+	Kind               string   `json:"kind"` // file|service|disk
+	Nodes              []string `json:"nodes,omitempty"`
+	Path               string   `json:"path,omitempty"`
+	Service            string   `json:"service,omitempty"`
+	CheckCommand       []string `json:"check_command,omitempty"`
+	ThresholdPercent   float64  `json:"threshold_percent,omitempty"`
+	IntervalSeconds    int      `json:"interval_seconds,omitempty"`
+	FloodLimit         int      `json:"flood_limit,omitempty"`
+	FloodWindowSeconds int      `json:"flood_window_seconds,omitempty"`
+	Disabled           bool     `json:"disabled,omitempty"`
+}
+
+// AgentBeaconState is what a node's agent last observed for one beacon.
+type AgentBeaconState struct {
+	Node        string    `json:"node"`
+	BeaconID    string    `json:"beacon_id"`
+	Observed    string    `json:"observed,omitempty"`
+	LastChecked time.Time `json:"last_checked"`
+	LastEmitted time.Time `json:"last_emitted,omitempty"`
+	Emitted     int       `json:"emitted"`
+	Suppressed  int       `json:"suppressed"`
+	Error       string    `json:"error,omitempty"`
+
+	window  []time.Time
+	pending int
+}
+
+// AgentBeaconEmit is one event an agent sends to the beacon emit endpoint.
+type AgentBeaconEmit struct {
+	Beacon  string         `json:"beacon"`
+	Host    string         `json:"host"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields"`
+}
+
+// BeaconProbe is how an agent observes its host. LocalProbe uses the local
+// filesystem, systemctl, and df.
+type BeaconProbe struct {
+	Stat    func(path string) (os.FileInfo, error)
+	Service func(ctx context.Context, beacon AgentBeacon) (bool, error)
+	Disk    func(ctx context.Context, path string) (float64, error)
+}
+
+type AgentBeaconStore struct {
+	mu      sync.Mutex
+	nextID  int64
+	beacons map[string]*AgentBeacon
+	states  map[string]*AgentBeaconState
+	guard   func(command string, args []string) error
+}
+
+func NewAgentBeaconStore() *AgentBeaconStore {
+	return &AgentBeaconStore{
+		beacons: map[string]*AgentBeacon{},
+		states:  map[string]*AgentBeaconState{},
+	}
+}
+
+// SetExecGuard installs the check every service beacon check_command must
+// pass, both when the beacon is saved and before each local run. Without a
+// guard check commands are rejected.
+func (s *AgentBeaconStore) SetExecGuard(fn func(command string, args []string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guard = fn
+}
+
+func (s *AgentBeaconStore) checkCommand(argv []string) error {
+	s.mu.Lock()
+	guard := s.guard
+	s.mu.Unlock()
+	if guard == nil {
+		return errors.New("beacon check commands are disabled")
+	}
+	return guard(argv[0], argv[1:])
+}
+
+// Upsert creates a beacon or replaces the one with the same name, resetting
+// what agents observed for it.
+func (s *AgentBeaconStore) Upsert(in AgentBeaconInput) (AgentBeacon, error) {
+	name := strings.ToLower(strings.TrimSpace(in.Name))
+	if name == "" || strings.ContainsAny(name, " ./") {
+		return AgentBeacon{}, errors.New("name is required and may not contain spaces, dots, or slashes")
+	}
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	item := AgentBeacon{
+		Name:               name,
+		Kind:               kind,
+		Nodes:              normalizeStringSlice(in.Nodes),
+		Path:               strings.TrimSpace(in.Path),
+		Service:            strings.TrimSpace(in.Service),
+		ThresholdPercent:   in.ThresholdPercent,
+		IntervalSeconds:    in.IntervalSeconds,
+		FloodLimit:         in.FloodLimit,
+		FloodWindowSeconds: in.FloodWindowSeconds,
+		Enabled:            !in.Disabled,
+	}
+	switch kind {
+	case BeaconKindFile:
+		if item.Path == "" {
+			return AgentBeacon{}, errors.New("path is required for file beacons")
+		}
+	case BeaconKindService:
+		for _, arg := range in.CheckCommand {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				item.CheckCommand = append(item.CheckCommand, arg)
+			}
+		}
+		if item.Service == "" && len(item.CheckCommand) == 0 {
+			return AgentBeacon{}, errors.New("service or check_command is required for service beacons")
+		}
+		if strings.HasPrefix(item.Service, "-") {
+			return AgentBeacon{}, errors.New("service may not start with -")
+		}
+		if len(item.CheckCommand) > 0 {
+			if err := s.checkCommand(item.CheckCommand); err != nil {
+				return AgentBeacon{}, err
+			}
+		}
+	case BeaconKindDisk:
+		if item.Path == "" {
+			item.Path = "/"
+		}
+		if item.ThresholdPercent == 0 {
+			item.ThresholdPercent = 90
+		}
+		if item.ThresholdPercent < 0 || item.ThresholdPercent > 100 {
+			return AgentBeacon{}, errors.New("threshold_percent must be between 0 and 100")
+		}
+	default:
+		return AgentBeacon{}, errors.New("kind must be file, service, or disk")
+	}
+	if item.IntervalSeconds <= 0 {
+		item.IntervalSeconds = 60
+	}
+	if item.FloodLimit <= 0 {
+		item.FloodLimit = 5
+	}
+	if item.FloodWindowSeconds <= 0 {
+		item.FloodWindowSeconds = 300
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cur := range s.beacons {
+		if cur.Name == name {
+			item.ID = cur.ID
+			item.CreatedAt = cur.CreatedAt
+		}
+	}
+	if item.ID == "" {
+		s.nextID++
+		item.ID = "beacon-" + itoa(s.nextID)
+		item.CreatedAt = now
+	}
+	item.UpdatedAt = now
+	s.beacons[item.ID] = &item
+	s.dropStatesLocked(item.ID)
+	return cloneAgentBeacon(item), nil
+}
+
+func (s *AgentBeaconStore) Get(id string) (AgentBeacon, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.beacons[strings.TrimSpace(id)]
+	if !ok {
+		return AgentBeacon{}, false
+	}
+	return cloneAgentBeacon(*item), true
+}
+
+// List returns beacons by name; with node set, only those that node runs.
+func (s *AgentBeaconStore) List(node string) []AgentBeacon {
+	node = strings.ToLower(strings.TrimSpace(node))
+	s.mu.Lock()
+	out := make([]AgentBeacon, 0, len(s.beacons))
+	for _, item := range s.beacons {
+		if node == "" || item.appliesTo(node) {
+			out = append(out, cloneAgentBeacon(*item))
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *AgentBeaconStore) Delete(id string) error {
+	id = strings.TrimSpace(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.beacons[id]; !ok {
+		return errors.New("beacon not found")
+	}
+	delete(s.beacons, id)
+	s.dropStatesLocked(id)
+	return nil
+}
+
+// States reports what agents last observed, optionally for one node.
+func (s *AgentBeaconStore) States(node string) []AgentBeaconState {
+	node = strings.ToLower(strings.TrimSpace(node))
+	s.mu.Lock()
+	out := make([]AgentBeaconState, 0, len(s.states))
+	for _, st := range s.states {
+		if node == "" || st.Node == node {
+			cp := *st
+			cp.window, cp.pending = nil, 0
+			out = append(out, cp)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Node != out[j].Node {
+			return out[i].Node < out[j].Node
+		}
+		return out[i].BeaconID < out[j].BeaconID
+	})
+	return out
+}
+
+// Evaluate runs the node's enabled beacons whose interval has elapsed and
+// returns the events to emit after flood protection.
+func (s *AgentBeaconStore) Evaluate(ctx context.Context, node string, now time.Time, probe BeaconProbe) []AgentBeaconEmit {
+	node = strings.ToLower(strings.TrimSpace(node))
+	due := make([]AgentBeacon, 0)
+	s.mu.Lock()
+	for _, item := range s.beacons {
+		if !item.Enabled || !item.appliesTo(node) {
+			continue
+		}
+		if st, ok := s.states[beaconStateKey(node, item.ID)]; ok && now.Before(st.LastChecked.Add(time.Duration(item.IntervalSeconds)*time.Second)) {
+			continue
+		}
+		due = append(due, cloneAgentBeacon(*item))
+	}
+	s.mu.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].Name < due[j].Name })
+
+	out := make([]AgentBeaconEmit, 0)
+	for _, beacon := range due {
+		observed, detail, err := observeBeacon(ctx, beacon, probe)
+		s.mu.Lock()
+		if _, ok := s.beacons[beacon.ID]; !ok {
+			s.mu.Unlock()
+			continue
+		}
+		key := beaconStateKey(node, beacon.ID)
+		st, seen := s.states[key]
+		if !seen {
+			st = &AgentBeaconState{Node: node, BeaconID: beacon.ID}
+			s.states[key] = st
+		}
+		st.LastChecked = now
+		st.Error = ""
+		if err != nil {
+			st.Error = err.Error()
+			s.mu.Unlock()
+			continue
+		}
+		change := beaconChange(beacon, seen, st.Observed, observed)
+		st.Observed = observed
+		if change != "" {
+			if suppressed, ok := st.admit(beacon, now); ok {
+				fields := map[string]any{"beacon": beacon.Name, "beacon_id": beacon.ID, "change": change}
+				for k, v := range detail {
+					fields[k] = v
+				}
+				if suppressed > 0 {
+					fields["suppressed"] = suppressed
+				}
+				out = append(out, AgentBeaconEmit{
+					Beacon:  beacon.Kind + "." + beacon.Name,
+					Host:    node,
+					Message: beacon.Kind + " beacon " + beacon.Name + " " + change,
+					Fields:  fields,
+				})
+			}
+		}
+		s.mu.Unlock()
+	}
+	return out
+}
+
+// admit applies flood protection. It returns how many events were
+// suppressed since the last emitted one.
+func (st *AgentBeaconState) admit(beacon AgentBeacon, now time.Time) (int, bool) {
+	cutoff := now.Add(-time.Duration(beacon.FloodWindowSeconds) * time.Second)
+	kept := st.window[:0]
+	for _, at := range st.window {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	st.window = kept
+	if len(st.window) >= beacon.FloodLimit {
+		st.Suppressed++
+		st.pending++
+		return 0, false
+	}
+	st.window = append(st.window, now)
+	st.Emitted++
+	st.LastEmitted = now
+	suppressed := st.pending
+	st.pending = 0
+	return suppressed, true
+}
+
+// observeBeacon reduces the host's current condition to a comparable string.
+func observeBeacon(ctx context.Context, beacon AgentBeacon, probe BeaconProbe) (string, map[string]any, error) {
+	switch beacon.Kind {
+	case BeaconKindFile:
+		info, err := probe.Stat(beacon.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return "absent", map[string]any{"path": beacon.Path}, nil
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return "present:" + info.ModTime().UTC().Format(time.RFC3339Nano) + ":" + strconv.FormatInt(info.Size(), 10),
+			map[string]any{"path": beacon.Path, "size": info.Size(), "mtime": info.ModTime().UTC()}, nil
+	case BeaconKindService:
+		up, err := probe.Service(ctx, beacon)
+		if err != nil {
+			return "", nil, err
+		}
+		if up {
+			return "up", map[string]any{"service": beacon.Service}, nil
+		}
+		return "down", map[string]any{"service": beacon.Service}, nil
+	case BeaconKindDisk:
+		used, err := probe.Disk(ctx, beacon.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		detail := map[string]any{"path": beacon.Path, "used_percent": used, "threshold_percent": beacon.ThresholdPercent}
+		if used >= beacon.ThresholdPercent {
+			return "above", detail, nil
+		}
+		return "below", detail, nil
+	default:
+		return "", nil, errors.New("unsupported beacon kind")
+	}
+}
+
+// beaconChange names the transition worth emitting, or "" for none. The
+// first file observation is only a baseline; a service already down or a
+// disk already above threshold is reported straight away.
+func beaconChange(beacon AgentBeacon, seen bool, before, after string) string {
+	if seen && before == after {
+		return ""
+	}
+	switch beacon.Kind {
+	case BeaconKindFile:
+		switch {
+		case !seen:
+			return ""
+		case after == "absent":
+			return "deleted"
+		case before == "absent":
+			return "created"
+		default:
+			return "modified"
+		}
+	case BeaconKindService:
+		if after == "down" {
+			return "down"
+		}
+		if seen {
+			return "recovered"
+		}
+	case BeaconKindDisk:
+		if after == "above" {
+			return "above_threshold"
+		}
+		if seen {
+			return "recovered"
+		}
+	}
+	return ""
+}
+
+// LocalProbe observes the host the control plane runs on. Check commands
+// pass the store's exec guard before every run.
+func (s *AgentBeaconStore) LocalProbe() BeaconProbe {
+	return BeaconProbe{
+		Stat: os.Stat,
+		Service: func(ctx context.Context, beacon AgentBeacon) (bool, error) {
+			if len(beacon.CheckCommand) > 0 {
+				if err := s.checkCommand(beacon.CheckCommand); err != nil {
+					return false, err
+				}
+			}
+			return localServiceUp(ctx, beacon)
+		},
+		Disk: localDiskUsedPercent,
+	}
+}
+
+func localServiceUp(ctx context.Context, beacon AgentBeacon) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	argv := beacon.CheckCommand
+	if len(argv) == 0 {
+		argv = []string{"systemctl", "is-active", "--quiet", "--", beacon.Service}
+	}
+	err := boundedCommand(ctx, argv[0], argv[1:]...).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// localDiskUsedPercent reads the capacity column of POSIX df output.
+func localDiskUsedPercent(ctx context.Context, path string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "df", "-P", path).Output()
+	if err != nil {
+		return 0, errors.New("df failed: " + err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(lines) < 2 || len(fields) < 5 {
+		return 0, errors.New("unexpected df output")
+	}
+	used, err := strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+	if err != nil {
+		return 0, errors.New("unexpected df output")
+	}
+	return used, nil
+}
+
+func (b *AgentBeacon) appliesTo(node string) bool {
+	if len(b.Nodes) == 0 {
+		return true
+	}
+	for _, n := range b.Nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AgentBeaconStore) dropStatesLocked(id string) {
+	for key, st := range s.states {
+		if st.BeaconID == id {
+			delete(s.states, key)
+		}
+	}
+}
+
+func beaconStateKey(node, id string) string {
+	return node + "\x00" + id
+}
+
+func cloneAgentBeacon(in AgentBeacon) AgentBeacon {
+	out := in
+	out.Nodes = append([]string{}, in.Nodes...)
+	out.CheckCommand = append([]string{}, in.CheckCommand...)
+	return out
+}
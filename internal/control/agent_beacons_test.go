@@ -0,0 +1,119 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAgentBeaconStoreEmitsOnChangeWithFloodProtection(t *testing.T) {
+	store := NewAgentBeaconStore()
+	if _, err := store.Upsert(AgentBeaconInput{Name: "conf", Kind: "file"}); err == nil {
+		t.Fatalf("expected file beacon without path to be rejected")
+	}
+	path := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(path, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := store.Upsert(AgentBeaconInput{Name: "conf", Kind: "file", Path: path, IntervalSeconds: 10, FloodLimit: 1, FloodWindowSeconds: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := true
+	disk := 50.0
+	probe := BeaconProbe{
+		Stat:    os.Stat,
+		Service: func(context.Context, AgentBeacon) (bool, error) { return up, nil },
+		Disk:    func(context.Context, string) (float64, error) { return disk, nil },
+	}
+	if _, err := store.Upsert(AgentBeaconInput{Name: "web", Kind: "service", Service: "nginx", Nodes: []string{"web-1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Upsert(AgentBeaconInput{Name: "root", Kind: "disk", ThresholdPercent: 80}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if emits := store.Evaluate(ctx, "web-1", now, probe); len(emits) != 0 {
+		t.Fatalf("expected first observation to set a baseline, got %+v", emits)
+	}
+
+	touch := func(at time.Time, body string) {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Chtimes(path, at, at)
+	}
+	touch(now.Add(time.Second), "ab")
+	if emits := store.Evaluate(ctx, "web-1", now.Add(5*time.Second), probe); len(emits) != 0 {
+		t.Fatalf("expected interval to defer evaluation, got %+v", emits)
+	}
+	up, disk = false, 85
+	emits := store.Evaluate(ctx, "web-1", now.Add(time.Minute), probe)
+	got := map[string]string{}
+	for _, e := range emits {
+		got[e.Beacon] = e.Fields["change"].(string)
+	}
+	if got["file.conf"] != "modified" || got["service.web"] != "down" || got["disk.root"] != "above_threshold" {
+		t.Fatalf("unexpected beacon emits %+v", emits)
+	}
+
+	// Flood protection: one emit per minute for the file beacon.
+	touch(now.Add(2*time.Second), "abc")
+	if emits := store.Evaluate(ctx, "web-1", now.Add(time.Minute+10*time.Second), probe); len(emits) != 0 {
+		t.Fatalf("expected flood protection to suppress emit, got %+v", emits)
+	}
+	touch(now.Add(3*time.Second), "abcd")
+	emits = store.Evaluate(ctx, "web-1", now.Add(3*time.Minute), probe)
+	if len(emits) != 1 || emits[0].Fields["suppressed"] != 1 {
+		t.Fatalf("expected emit carrying suppressed count, got %+v", emits)
+	}
+	for _, st := range store.States("web-1") {
+		if st.BeaconID == file.ID && (st.Emitted != 2 || st.Suppressed != 1) {
+			t.Fatalf("unexpected file beacon state %+v", st)
+		}
+	}
+
+	if beacons := store.List("db-1"); len(beacons) != 2 {
+		t.Fatalf("expected node-scoped beacon excluded for other nodes, got %+v", beacons)
+	}
+}
+
+func TestAgentBeaconStoreGuardsCheckCommands(t *testing.T) {
+	store := NewAgentBeaconStore()
+	if _, err := store.Upsert(AgentBeaconInput{Name: "web", Kind: "service", CheckCommand: []string{"/usr/bin/false"}}); err == nil {
+		t.Fatalf("expected check command to be rejected without a guard")
+	}
+	if _, err := store.Upsert(AgentBeaconInput{Name: "web", Kind: "service", Service: "--help"}); err == nil {
+		t.Fatalf("expected service name starting with - to be rejected")
+	}
+	allowed := true
+	store.SetExecGuard(func(command string, args []string) error {
+		if !allowed || command != "/usr/bin/false" {
+			return errors.New("not allowlisted")
+		}
+		return nil
+	})
+	if _, err := store.Upsert(AgentBeaconInput{Name: "sh", Kind: "service", CheckCommand: []string{"/bin/sh", "-c", "true"}}); err == nil {
+		t.Fatalf("expected unlisted check command to be rejected")
+	}
+	beacon, err := store.Upsert(AgentBeaconInput{Name: "web", Kind: "service", CheckCommand: []string{"/usr/bin/false"}})
+	if err != nil {
+		t.Fatalf("upsert guarded beacon failed: %v", err)
+	}
+	probe := store.LocalProbe()
+	if up, err := probe.Service(context.Background(), beacon); err != nil || up {
+		t.Fatalf("expected failing check command to report down, got up=%v err=%v", up, err)
+	}
+
+	// The guard is re-checked before each run, so tightening the allowlist
+	// stops commands that were saved earlier.
+	allowed = false
+	if _, err := probe.Service(context.Background(), beacon); err == nil {
+		t.Fatalf("expected guard to block a previously saved check command")
+	}
+}
@@ -16,12 +16,19 @@ type AgentCheckin struct {
 	AppliedSplaySec int       `json:"applied_splay_seconds"`
 	LastCheckinAt   time.Time `json:"last_checkin_at"`
 	NextCheckinAt   time.Time `json:"next_checkin_at"`
+
+	// Attestation is the verification result for evidence sent with this
+	// check-in; AttestationChallenge is the nonce to bind into the next one.
+	Attestation          *AgentAttestationEvidence  `json:"attestation,omitempty"`
+	AttestationChallenge *AgentAttestationChallenge `json:"attestation_challenge,omitempty"`
 }
 
 type AgentCheckinInput struct {
 	AgentID         string `json:"agent_id"`
 	IntervalSeconds int    `json:"interval_seconds,omitempty"`
 	MaxSplaySeconds int    `json:"max_splay_seconds,omitempty"`
+
+	Attestation *AgentAttestationInput `json:"attestation,omitempty"`
 }
 
 type AgentCheckinStore struct {
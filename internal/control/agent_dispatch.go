@@ -20,6 +20,7 @@ const (
 type AgentDispatchRequest struct {
 	ConfigPath  string `json:"config_path"`
 	Environment string `json:"environment,omitempty"`
+	Host        string `json:"host,omitempty"`
 	Priority    string `json:"priority,omitempty"`
 	Force       bool   `json:"force,omitempty"`
 }
@@ -35,11 +36,14 @@ type AgentDispatchRecord struct {
 	Mode        string    `json:"mode"`
 	Strategy    string    `json:"strategy"`
 	Environment string    `json:"environment,omitempty"`
+	Host        string    `json:"host,omitempty"`
 	ConfigPath  string    `json:"config_path"`
 	Priority    string    `json:"priority,omitempty"`
 	Force       bool      `json:"force,omitempty"`
 	Status      string    `json:"status"`
 	JobID       string    `json:"job_id,omitempty"`
+	Transport   string    `json:"transport,omitempty"`
+	MessageID   string    `json:"message_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -137,6 +141,16 @@ func (s *AgentDispatchStore) ListEnvironmentStrategies() []AgentEnvironmentDispa
 }
 
 func (s *AgentDispatchStore) Record(mode, strategy string, req AgentDispatchRequest, status, jobID string) AgentDispatchRecord {
+	return s.record(mode, strategy, req, status, jobID, AgentTransportMessage{})
+}
+
+// RecordTransport records a dispatch sent to agents over the agent
+// transports.
+func (s *AgentDispatchStore) RecordTransport(mode, strategy string, req AgentDispatchRequest, status string, msg AgentTransportMessage) AgentDispatchRecord {
+	return s.record(mode, strategy, req, status, "", msg)
+}
+
+func (s *AgentDispatchStore) record(mode, strategy string, req AgentDispatchRequest, status, jobID string, msg AgentTransportMessage) AgentDispatchRecord {
 	strategy, err := normalizeDispatchStrategy(strategy)
 	if err != nil {
 		strategy = AgentDispatchStrategyHybrid
@@ -149,11 +163,14 @@ func (s *AgentDispatchStore) Record(mode, strategy string, req AgentDispatchRequ
 		Mode:        strings.ToLower(strings.TrimSpace(mode)),
 		Strategy:    strategy,
 		Environment: strings.TrimSpace(req.Environment),
+		Host:        strings.ToLower(strings.TrimSpace(req.Host)),
 		ConfigPath:  strings.TrimSpace(req.ConfigPath),
 		Priority:    normalizePriority(req.Priority),
 		Force:       req.Force,
 		Status:      strings.TrimSpace(status),
 		JobID:       strings.TrimSpace(jobID),
+		Transport:   msg.Transport,
+		MessageID:   msg.ID,
 		CreatedAt:   time.Now().UTC(),
 	}
 	s.records = append(s.records, item)
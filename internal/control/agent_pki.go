@@ -1,7 +1,12 @@
 package control
 
 import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"math/big"
 	"sort"
 	"strings"
 	"sync"
@@ -18,7 +23,8 @@ type AgentCSR struct {
 	ID         string            `json:"id"`
 	AgentID    string            `json:"agent_id"`
 	Attributes map[string]string `json:"attributes,omitempty"`
-	Status     string            `json:"status"` // pending|approved|rejected|issued
+	CSRPEM     string            `json:"csr_pem,omitempty"`
+	Status     string            `json:"status"` // pending|approved|rejected|issued|failed
 	Reason     string            `json:"reason,omitempty"`
 	CertID     string            `json:"cert_id,omitempty"`
 	CreatedAt  time.Time         `json:"created_at"`
@@ -28,17 +34,25 @@ type AgentCSR struct {
 type AgentCSRInput struct {
 	AgentID    string            `json:"agent_id"`
 	Attributes map[string]string `json:"attributes,omitempty"`
+	// CSRPEM is the agent's PKCS#10 request; external CA backends need it
+	// since the private key never leaves the agent.
+	CSRPEM string `json:"csr_pem,omitempty"`
 }
 
 type AgentCertificate struct {
 	ID        string     `json:"id"`
 	AgentID   string     `json:"agent_id"`
 	Serial    string     `json:"serial"`
+	Backend   string     `json:"backend"`
+	Issuer    string     `json:"issuer,omitempty"`
 	Status    string     `json:"status"` // active|revoked|rotated
 	IssuedAt  time.Time  `json:"issued_at"`
 	ExpiresAt time.Time  `json:"expires_at"`
 	RevokedAt *time.Time `json:"revoked_at,omitempty"`
 	RotatedBy string     `json:"rotated_by,omitempty"`
+
+	CertificatePEM string `json:"certificate_pem,omitempty"`
+	ChainPEM       string `json:"chain_pem,omitempty"`
 }
 
 type AgentCertificateExpiryReport struct {
@@ -52,15 +66,26 @@ type AgentCertificateRenewalResult struct {
 	RequestedWithinHours int                `json:"requested_within_hours"`
 	RenewedCount         int                `json:"renewed_count"`
 	Renewed              []AgentCertificate `json:"renewed"`
+	Failed               map[string]string  `json:"failed,omitempty"`
 }
 
 type AgentPKIStore struct {
-	mu       sync.RWMutex
-	nextCSR  int64
-	nextCert int64
-	policy   AgentCertificatePolicy
-	csrs     map[string]*AgentCSR
-	certs    map[string]*AgentCertificate
+	mu            sync.RWMutex
+	nextCSR       int64
+	nextCert      int64
+	policy        AgentCertificatePolicy
+	csrs          map[string]*AgentCSR
+	certs         map[string]*AgentCertificate
+	certCSRs      map[string][]byte
+	backend       CABackend
+	backendConfig AgentCABackendConfig
+
+	caCert      *x509.Certificate
+	caKey       crypto.Signer
+	certSerials map[string]*big.Int
+	revocation  AgentRevocationConfig
+	crl         AgentCRL
+	crlDER      []byte
 }
 
 func NewAgentPKIStore() *AgentPKIStore {
@@ -69,9 +94,59 @@ func NewAgentPKIStore() *AgentPKIStore {
 			AutoApprove: false,
 			UpdatedAt:   time.Now().UTC(),
 		},
-		csrs:  map[string]*AgentCSR{},
-		certs: map[string]*AgentCertificate{},
+		csrs:          map[string]*AgentCSR{},
+		certs:         map[string]*AgentCertificate{},
+		certCSRs:      map[string][]byte{},
+		certSerials:   map[string]*big.Int{},
+		backendConfig: AgentCABackendConfig{Type: "internal", UpdatedAt: time.Now().UTC()},
+		revocation:    AgentRevocationConfig{ValidityHours: 24, UpdatedAt: time.Now().UTC()},
+	}
+}
+
+// Backend reports the configured CA backend with credentials redacted.
+func (s *AgentPKIStore) Backend() AgentCABackendConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return redactCABackendConfig(s.backendConfig)
+}
+
+// SetBackend switches where approved CSRs are issued. Existing certificates
+// are unaffected; rotations and renewals go to the new backend.
+func (s *AgentPKIStore) SetBackend(cfg AgentCABackendConfig) (AgentCABackendConfig, error) {
+	cfg.Type = strings.ToLower(strings.TrimSpace(cfg.Type))
+	var backend CABackend
+	switch cfg.Type {
+	case "", "internal":
+		cfg.Type = "internal"
+		cfg.EST = nil
+	case "est":
+		if cfg.EST == nil {
+			return AgentCABackendConfig{}, errors.New("est settings are required for the est backend")
+		}
+		est, err := NewESTBackend(*cfg.EST)
+		if err != nil {
+			return AgentCABackendConfig{}, err
+		}
+		estCfg := est.cfg
+		cfg.EST = &estCfg
+		backend = est
+	default:
+		return AgentCABackendConfig{}, errors.New("ca backend type must be internal or est")
 	}
+	cfg.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	s.backend = backend
+	s.backendConfig = cfg
+	s.mu.Unlock()
+	return redactCABackendConfig(cfg), nil
+}
+
+// UseBackend installs a CABackend directly, for backends configured in code.
+func (s *AgentPKIStore) UseBackend(backend CABackend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backend = backend
+	s.backendConfig = AgentCABackendConfig{Type: backend.Name(), UpdatedAt: time.Now().UTC()}
 }
 
 func (s *AgentPKIStore) Policy() AgentCertificatePolicy {
@@ -110,28 +185,39 @@ func (s *AgentPKIStore) SubmitCSR(in AgentCSRInput) (AgentCSR, error) {
 		}
 		attrs[key] = strings.TrimSpace(v)
 	}
+	csrPEM := strings.TrimSpace(in.CSRPEM)
+	if csrPEM != "" {
+		csr, err := parseCSRPEM(csrPEM)
+		if err != nil {
+			return AgentCSR{}, err
+		}
+		if csr.Subject.CommonName != agentID {
+			return AgentCSR{}, errors.New("csr common name must match agent_id")
+		}
+	}
 	now := time.Now().UTC()
 	item := AgentCSR{
 		AgentID:    agentID,
 		Attributes: attrs,
+		CSRPEM:     csrPEM,
 		Status:     "pending",
 		CreatedAt:  now,
 		UpdatedAt:  now,
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.nextCSR++
 	item.ID = "csr-" + itoa(s.nextCSR)
 	s.csrs[item.ID] = &item
-	if s.policy.AutoApprove && csrMatchesPolicy(item, s.policy) {
-		cert := s.issueCertificateLocked(agentID)
-		item.Status = "issued"
-		item.CertID = cert.ID
-		item.UpdatedAt = time.Now().UTC()
-		s.csrs[item.ID] = &item
+	auto := s.policy.AutoApprove && csrMatchesPolicy(item, s.policy)
+	out := cloneAgentCSR(item)
+	s.mu.Unlock()
+	if auto {
+		// An auto-approved CSR that the backend fails to issue is left in
+		// the failed state for an operator to retry.
+		out, _ = s.DecideCSR(item.ID, "approve", "")
 	}
-	return cloneAgentCSR(item), nil
+	return out, nil
 }
 
 func (s *AgentPKIStore) ListCSRs() []AgentCSR {
@@ -165,24 +251,40 @@ func (s *AgentPKIStore) DecideCSR(id, decision, reason string) (AgentCSR, error)
 		return AgentCSR{}, errors.New("decision must be approve or reject")
 	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	item, ok := s.csrs[id]
 	if !ok {
+		s.mu.Unlock()
 		return AgentCSR{}, errors.New("csr not found")
 	}
-	if item.Status != "pending" {
+	if item.Status != "pending" && item.Status != "failed" {
+		s.mu.Unlock()
 		return AgentCSR{}, errors.New("csr is not pending")
 	}
-	if decision == "approve" {
-		cert := s.issueCertificateLocked(item.AgentID)
-		item.Status = "issued"
-		item.CertID = cert.ID
-	} else {
+	if decision == "reject" {
 		item.Status = "rejected"
 		item.Reason = strings.TrimSpace(reason)
+		item.UpdatedAt = time.Now().UTC()
+		out := cloneAgentCSR(*item)
+		s.mu.Unlock()
+		return out, nil
+	}
+	item.Status = "approved"
+	item.Reason = ""
+	agentID, csrDER := item.AgentID, decodeCSRPEM(item.CSRPEM)
+	s.mu.Unlock()
+
+	cert, err := s.issueCertificate(agentID, csrDER, "")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		item.Status = "failed"
+		item.Reason = err.Error()
+	} else {
+		item.Status = "issued"
+		item.CertID = cert.ID
 	}
 	item.UpdatedAt = time.Now().UTC()
-	return cloneAgentCSR(*item), nil
+	return cloneAgentCSR(*item), err
 }
 
 func (s *AgentPKIStore) ListCertificates() []AgentCertificate {
@@ -220,8 +322,7 @@ func (s *AgentPKIStore) RotateAgentCertificate(agentID string) (AgentCertificate
 	if agentID == "" {
 		return AgentCertificate{}, errors.New("agent_id is required")
 	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
 	var latest *AgentCertificate
 	for _, cert := range s.certs {
 		if cert.AgentID != agentID || cert.Status != "active" {
@@ -231,14 +332,25 @@ func (s *AgentPKIStore) RotateAgentCertificate(agentID string) (AgentCertificate
 			latest = cert
 		}
 	}
+	var csrDER []byte
+	var previous string
 	if latest != nil {
-		latest.Status = "rotated"
+		csrDER = s.certCSRs[latest.ID]
+		previous = latest.Backend
+	}
+	s.mu.RUnlock()
+
+	newCert, err := s.issueCertificate(agentID, csrDER, previous)
+	if err != nil {
+		return AgentCertificate{}, err
 	}
-	newCert := s.issueCertificateLocked(agentID)
 	if latest != nil {
+		s.mu.Lock()
+		latest.Status = "rotated"
 		latest.RotatedBy = newCert.ID
+		s.mu.Unlock()
 	}
-	return cloneAgentCert(newCert), nil
+	return newCert, nil
 }
 
 func (s *AgentPKIStore) ExpiryReport(withinHours int) AgentCertificateExpiryReport {
@@ -273,9 +385,12 @@ func (s *AgentPKIStore) RenewExpiring(withinHours int) (AgentCertificateRenewalR
 	}
 	now := time.Now().UTC()
 	threshold := now.Add(time.Duration(withinHours) * time.Hour)
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	renewed := make([]AgentCertificate, 0)
+	type candidate struct {
+		cert   *AgentCertificate
+		csrDER []byte
+	}
+	s.mu.RLock()
+	candidates := make([]candidate, 0)
 	for _, cert := range s.certs {
 		if cert.Status != "active" {
 			continue
@@ -283,31 +398,118 @@ func (s *AgentPKIStore) RenewExpiring(withinHours int) (AgentCertificateRenewalR
 		if cert.ExpiresAt.After(threshold) {
 			continue
 		}
-		cert.Status = "rotated"
-		newCert := s.issueCertificateLocked(cert.AgentID)
-		cert.RotatedBy = newCert.ID
-		renewed = append(renewed, cloneAgentCert(newCert))
+		candidates = append(candidates, candidate{cert: cert, csrDER: s.certCSRs[cert.ID]})
 	}
-	return AgentCertificateRenewalResult{
+	s.mu.RUnlock()
+
+	renewed := make([]AgentCertificate, 0)
+	failed := map[string]string{}
+	for _, c := range candidates {
+		newCert, err := s.issueCertificate(c.cert.AgentID, c.csrDER, c.cert.Backend)
+		if err != nil {
+			failed[c.cert.ID] = err.Error()
+			continue
+		}
+		s.mu.Lock()
+		c.cert.Status = "rotated"
+		c.cert.RotatedBy = newCert.ID
+		s.mu.Unlock()
+		renewed = append(renewed, newCert)
+	}
+	result := AgentCertificateRenewalResult{
 		RequestedWithinHours: withinHours,
 		RenewedCount:         len(renewed),
 		Renewed:              renewed,
-	}, nil
+	}
+	if len(failed) > 0 {
+		result.Failed = failed
+	}
+	return result, nil
 }
 
-func (s *AgentPKIStore) issueCertificateLocked(agentID string) AgentCertificate {
+// issueCertificate issues through the configured CA backend, or the
+// built-in issuer when none is set. previousBackend names the backend that
+// issued the certificate being replaced, so a renewal at the same CA uses
+// re-enrollment. External backends are called without the store lock held.
+func (s *AgentPKIStore) issueCertificate(agentID string, csrDER []byte, previousBackend string) (AgentCertificate, error) {
+	s.mu.RLock()
+	backend := s.backend
+	s.mu.RUnlock()
+	if backend == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		item, err := s.issueCertificateLocked(agentID, csrDER)
+		if err != nil {
+			return AgentCertificate{}, err
+		}
+		if len(csrDER) > 0 {
+			s.certCSRs[item.ID] = csrDER
+		}
+		return cloneAgentCert(item), nil
+	}
+	if len(csrDER) == 0 {
+		return AgentCertificate{}, errors.New("the " + backend.Name() + " ca backend requires a csr_pem for agent " + agentID)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	chain, err := backend.Enroll(ctx, csrDER, previousBackend == backend.Name())
+	if err != nil {
+		return AgentCertificate{}, err
+	}
+	if len(chain) == 0 {
+		return AgentCertificate{}, errors.New("ca backend returned no certificate")
+	}
+	leaf := chain[0]
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.nextCert++
+	item := AgentCertificate{
+		ID:             "cert-" + itoa(s.nextCert),
+		AgentID:        agentID,
+		Serial:         strings.ToUpper(leaf.SerialNumber.Text(16)),
+		Backend:        backend.Name(),
+		Issuer:         leaf.Issuer.String(),
+		Status:         "active",
+		IssuedAt:       time.Now().UTC(),
+		ExpiresAt:      leaf.NotAfter.UTC(),
+		CertificatePEM: encodeCertificatesPEM(chain[:1]),
+		ChainPEM:       encodeCertificatesPEM(chain[1:]),
+	}
+	s.certs[item.ID] = &item
+	s.certCSRs[item.ID] = csrDER
+	return cloneAgentCert(item), nil
+}
+
+// issueCertificateLocked issues from the built-in CA. With a CSR the agent
+// gets a signed certificate carrying the CRL distribution point; without one
+// only the serial is allocated, as for agents that predate CSR submission.
+func (s *AgentPKIStore) issueCertificateLocked(agentID string, csrDER []byte) (AgentCertificate, error) {
+	n := s.nextCert + 1
 	now := time.Now().UTC()
 	item := AgentCertificate{
-		ID:        "cert-" + itoa(s.nextCert),
+		ID:        "cert-" + itoa(n),
 		AgentID:   agentID,
-		Serial:    "SERIAL-" + itoa(s.nextCert),
+		Serial:    "SERIAL-" + itoa(n),
+		Backend:   "internal",
 		Status:    "active",
 		IssuedAt:  now,
 		ExpiresAt: now.Add(90 * 24 * time.Hour),
 	}
+	serial := big.NewInt(n)
+	if len(csrDER) > 0 {
+		leaf, err := s.signAgentCertificateLocked(csrDER, serial, item.IssuedAt, item.ExpiresAt)
+		if err != nil {
+			return AgentCertificate{}, err
+		}
+		item.Serial = strings.ToUpper(leaf.SerialNumber.Text(16))
+		item.Issuer = leaf.Issuer.String()
+		item.CertificatePEM = encodeCertificatesPEM([]*x509.Certificate{leaf})
+		item.ChainPEM = encodeCertificatesPEM([]*x509.Certificate{s.caCert})
+	}
+	s.nextCert = n
 	s.certs[item.ID] = &item
-	return item
+	s.certSerials[item.ID] = serial
+	return item, nil
 }
 
 func csrMatchesPolicy(csr AgentCSR, policy AgentCertificatePolicy) bool {
@@ -337,6 +539,26 @@ func cloneAgentCSR(in AgentCSR) AgentCSR {
 	return out
 }
 
+func decodeCSRPEM(in string) []byte {
+	block, _ := pem.Decode([]byte(in))
+	if block == nil {
+		return nil
+	}
+	return block.Bytes
+}
+
+func redactCABackendConfig(in AgentCABackendConfig) AgentCABackendConfig {
+	out := in
+	if in.EST != nil {
+		est := *in.EST
+		if est.Password != "" {
+			est.Password = "redacted"
+		}
+		out.EST = &est
+	}
+	return out
+}
+
 func cloneAgentCert(in AgentCertificate) AgentCertificate {
 	out := in
 	if in.RevokedAt != nil {
@@ -1,6 +1,20 @@
 package control
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -106,3 +120,165 @@ func TestAgentPKIExpiryReportAndRenew(t *testing.T) {
 		t.Fatalf("expected one renewed cert, got %+v", renewed)
 	}
 }
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Enterprise CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return testCA{cert: cert, key: key}
+}
+
+func (ca testCA) sign(t *testing.T, csrDER []byte, serial int64) []byte {
+	t.Helper()
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(30 * 24 * time.Hour),
+	}, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func testCSRPEM(t *testing.T, cn string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+// certsOnlyPKCS7 builds the degenerate SignedData an EST server returns.
+func certsOnlyPKCS7(t *testing.T, certs ...[]byte) []byte {
+	t.Helper()
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	inner, _ := asn1.Marshal(struct{ ContentType asn1.ObjectIdentifier }{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})
+	sd, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      asn1.RawValue
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      asn1.RawValue{FullBytes: inner},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes.Join(certs, nil)},
+		SignerInfos:      emptySet,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: oidPKCS7SignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestAgentPKIESTBackendIssuesApprovedCSRs(t *testing.T) {
+	ca := newTestCA(t)
+	var paths []string
+	var serial int64 = 100
+	est := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if user, pass, _ := r.BasicAuth(); user != "enroll" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		csrDER, err := base64.StdEncoding.DecodeString(string(raw))
+		if err != nil || r.Header.Get("Content-Type") != "application/pkcs10" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		serial++
+		w.Header().Set("Content-Type", "application/pkcs7-mime; smime-type=certs-only")
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(certsOnlyPKCS7(t, ca.cert.Raw, ca.sign(t, csrDER, serial)))))
+	}))
+	defer est.Close()
+
+	store := NewAgentPKIStore()
+	cfg, err := store.SetBackend(AgentCABackendConfig{Type: "est", EST: &ESTConfig{URL: est.URL, Label: "agents", Username: "enroll", Password: "s3cret"}})
+	if err != nil {
+		t.Fatalf("set est backend failed: %v", err)
+	}
+	if cfg.EST.Password != "redacted" || store.Backend().EST.Password != "redacted" {
+		t.Fatalf("expected est password redacted, got %+v", cfg.EST)
+	}
+
+	if _, err := store.SubmitCSR(AgentCSRInput{AgentID: "agent-1", CSRPEM: testCSRPEM(t, "agent-2")}); err == nil {
+		t.Fatalf("expected csr common name mismatch to be rejected")
+	}
+	noCSR, err := store.SubmitCSR(AgentCSRInput{AgentID: "agent-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed, err := store.DecideCSR(noCSR.ID, "approve", ""); err == nil || failed.Status != "failed" {
+		t.Fatalf("expected est backend to require a csr, got %+v err=%v", failed, err)
+	}
+
+	csr, err := store.SubmitCSR(AgentCSRInput{AgentID: "agent-1", CSRPEM: testCSRPEM(t, "agent-1")})
+	if err != nil || csr.Status != "pending" {
+		t.Fatalf("expected pending csr awaiting approval, got %+v err=%v", csr, err)
+	}
+	issued, err := store.DecideCSR(csr.ID, "approve", "")
+	if err != nil || issued.Status != "issued" {
+		t.Fatalf("approve via est failed: %+v err=%v", issued, err)
+	}
+	certs := store.ListCertificates()
+	if len(certs) != 1 || certs[0].Backend != "est" || certs[0].Serial != "65" || !strings.Contains(certs[0].Issuer, "Test Enterprise CA") {
+		t.Fatalf("unexpected est certificate: %+v", certs)
+	}
+	block, _ := pem.Decode([]byte(certs[0].CertificatePEM))
+	if leaf, err := x509.ParseCertificate(block.Bytes); err != nil || leaf.Subject.CommonName != "agent-1" {
+		t.Fatalf("expected agent leaf certificate first, got %v", err)
+	}
+
+	rotated, err := store.RotateAgentCertificate("agent-1")
+	if err != nil || rotated.Backend != "est" {
+		t.Fatalf("rotate via est failed: %+v err=%v", rotated, err)
+	}
+	if paths[len(paths)-1] != "/.well-known/est/agents/simplereenroll" || paths[0] != "/.well-known/est/agents/simpleenroll" {
+		t.Fatalf("unexpected est operations: %v", paths)
+	}
+}
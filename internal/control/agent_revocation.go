@@ -0,0 +1,258 @@
+package control
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CertificateRevocationChecker answers whether a presented client
+// certificate serial has been revoked. The mTLS and relay stores consult it
+// before admitting a connection.
+type CertificateRevocationChecker interface {
+	IsRevoked(serial string) bool
+}
+
+// AgentRevocationConfig controls how the agent CRL is published and where
+// issued certificates point relying parties to fetch it.
+type AgentRevocationConfig struct {
+	DistributionURL string    `json:"distribution_url,omitempty"`
+	ValidityHours   int       `json:"validity_hours"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AgentCRL describes the most recently published certificate revocation
+// list. CRLPEM is the signed list; the DER form is served to relays.
+type AgentCRL struct {
+	Number       int64     `json:"number"`
+	Issuer       string    `json:"issuer"`
+	ThisUpdate   time.Time `json:"this_update"`
+	NextUpdate   time.Time `json:"next_update"`
+	RevokedCount int       `json:"revoked_count"`
+	CRLPEM       string    `json:"crl_pem"`
+}
+
+// AgentRevocationStatus is the per-serial answer served to relays that
+// cannot fetch and parse the full CRL.
+type AgentRevocationStatus struct {
+	Serial    string     `json:"serial"`
+	Status    string     `json:"status"` // good|revoked|unknown
+	CertID    string     `json:"cert_id,omitempty"`
+	AgentID   string     `json:"agent_id,omitempty"`
+	Backend   string     `json:"backend,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CheckedAt time.Time  `json:"checked_at"`
+}
+
+func (s *AgentPKIStore) RevocationConfig() AgentRevocationConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revocation
+}
+
+func (s *AgentPKIStore) SetRevocationConfig(cfg AgentRevocationConfig) (AgentRevocationConfig, error) {
+	cfg.DistributionURL = strings.TrimSpace(cfg.DistributionURL)
+	if cfg.DistributionURL != "" {
+		parsed, err := url.Parse(cfg.DistributionURL)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+			return AgentRevocationConfig{}, errors.New("distribution_url must be an absolute http(s) url")
+		}
+	}
+	if cfg.ValidityHours <= 0 {
+		cfg.ValidityHours = 24
+	}
+	cfg.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	s.revocation = cfg
+	s.mu.Unlock()
+	return cfg, nil
+}
+
+// CACertificatePEM returns the built-in issuer certificate, which also signs
+// the CRL.
+func (s *AgentPKIStore) CACertificatePEM() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caCert, _, err := s.internalCALocked()
+	if err != nil {
+		return "", err
+	}
+	return encodeCertificatesPEM([]*x509.Certificate{caCert}), nil
+}
+
+// PublishCRL signs a fresh CRL covering every revoked certificate the
+// built-in issuer has handed out. Certificates from external CA backends
+// are revoked at that CA; they are still reported by CheckRevocation.
+func (s *AgentPKIStore) PublishCRL() (AgentCRL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caCert, caKey, err := s.internalCALocked()
+	if err != nil {
+		return AgentCRL{}, err
+	}
+	now := time.Now().UTC()
+	entries := make([]x509.RevocationListEntry, 0)
+	for id, cert := range s.certs {
+		if cert.Status != "revoked" || cert.Backend != "internal" {
+			continue
+		}
+		serial, ok := s.certSerials[id]
+		if !ok {
+			continue
+		}
+		revokedAt := now
+		if cert.RevokedAt != nil {
+			revokedAt = *cert.RevokedAt
+		}
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: revokedAt})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SerialNumber.Cmp(entries[j].SerialNumber) < 0 })
+	validity := s.revocation.ValidityHours
+	if validity <= 0 {
+		validity = 24
+	}
+	number := s.crl.Number + 1
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(time.Duration(validity) * time.Hour),
+		RevokedCertificateEntries: entries,
+	}, caCert, caKey)
+	if err != nil {
+		return AgentCRL{}, err
+	}
+	s.crl = AgentCRL{
+		Number:       number,
+		Issuer:       caCert.Subject.String(),
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(time.Duration(validity) * time.Hour),
+		RevokedCount: len(entries),
+		CRLPEM:       string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})),
+	}
+	s.crlDER = der
+	return s.crl, nil
+}
+
+// CRL returns the last published list and its DER encoding.
+func (s *AgentPKIStore) CRL() (AgentCRL, []byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.crlDER) == 0 {
+		return AgentCRL{}, nil, false
+	}
+	return s.crl, append([]byte{}, s.crlDER...), true
+}
+
+// CheckRevocation reports the status of a certificate by serial across all
+// backends. Serials are compared as upper-case hex without separators.
+func (s *AgentPKIStore) CheckRevocation(serial string) AgentRevocationStatus {
+	serial = normalizeCertificateSerial(serial)
+	out := AgentRevocationStatus{Serial: serial, Status: "unknown", CheckedAt: time.Now().UTC()}
+	if serial == "" {
+		return out
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, cert := range s.certs {
+		if normalizeCertificateSerial(cert.Serial) != serial {
+			continue
+		}
+		out.CertID = cert.ID
+		out.AgentID = cert.AgentID
+		out.Backend = cert.Backend
+		out.Status = "good"
+		if cert.Status == "revoked" {
+			out.Status = "revoked"
+			if cert.RevokedAt != nil {
+				revokedAt := *cert.RevokedAt
+				out.RevokedAt = &revokedAt
+			}
+		}
+		return out
+	}
+	return out
+}
+
+func (s *AgentPKIStore) IsRevoked(serial string) bool {
+	return s.CheckRevocation(serial).Status == "revoked"
+}
+
+// internalCALocked lazily creates the built-in issuer's signing key.
+func (s *AgentPKIStore) internalCALocked() (*x509.Certificate, crypto.Signer, error) {
+	if s.caCert != nil {
+		return s.caCert, s.caKey, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 63))
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "masterchef agent ca", Organization: []string{"masterchef"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "masterchef agent ca", Organization: []string{"masterchef"}},
+	}, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.caCert, s.caKey = cert, key
+	return cert, key, nil
+}
+
+// signAgentCertificateLocked issues a real client certificate from the
+// built-in CA for an agent CSR, pointing at the CRL distribution URL.
+func (s *AgentPKIStore) signAgentCertificateLocked(csrDER []byte, serial *big.Int, notBefore, notAfter time.Time) (*x509.Certificate, error) {
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, err
+	}
+	caCert, caKey, err := s.internalCALocked()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      csr.Subject,
+		NotBefore:    notBefore.Add(-time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if s.revocation.DistributionURL != "" {
+		template.CRLDistributionPoints = []string{s.revocation.DistributionURL}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+func normalizeCertificateSerial(in string) string {
+	return strings.ToUpper(strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(in)))
+}
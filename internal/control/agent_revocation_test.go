@@ -0,0 +1,113 @@
+package control
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestAgentPKIPublishesCRLAndEnforcesRevocation(t *testing.T) {
+	store := NewAgentPKIStore()
+	if _, err := store.SetRevocationConfig(AgentRevocationConfig{DistributionURL: "ftp://crl"}); err == nil {
+		t.Fatalf("expected non-http distribution url to be rejected")
+	}
+	if _, err := store.SetRevocationConfig(AgentRevocationConfig{DistributionURL: "https://mc.example.com/v1/agents/pki/crl", ValidityHours: 6}); err != nil {
+		t.Fatal(err)
+	}
+	csr, err := store.SubmitCSR(AgentCSRInput{AgentID: "agent-1", CSRPEM: testCSRPEM(t, "agent-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.DecideCSR(csr.ID, "approve", ""); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	cert := store.ListCertificates()[0]
+	block, _ := pem.Decode([]byte(cert.CertificatePEM))
+	if block == nil {
+		t.Fatalf("expected built-in issuer to sign the agent csr, got %+v", cert)
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaf.CRLDistributionPoints) != 1 || leaf.CRLDistributionPoints[0] != "https://mc.example.com/v1/agents/pki/crl" {
+		t.Fatalf("expected crl distribution point on issued cert, got %v", leaf.CRLDistributionPoints)
+	}
+	caPEM, err := store.CACertificatePEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	caBlock, _ := pem.Decode([]byte(caPEM))
+	caCert, _ := x509.ParseCertificate(caBlock.Bytes)
+	if err := leaf.CheckSignatureFrom(caCert); err != nil {
+		t.Fatalf("leaf not signed by agent ca: %v", err)
+	}
+
+	mtls := NewMTLSStore()
+	mtls.SetRevocationChecker(store)
+	authority, _ := mtls.CreateAuthority(MTLSAuthorityInput{Name: "agents", CABundle: caPEM})
+	_, _ = mtls.SetPolicy(MTLSComponentPolicy{Component: "control-plane", MinTLSVersion: "1.2", RequireClientCert: true})
+	handshake := MTLSHandshakeCheckInput{Component: "control-plane", AuthorityID: authority.ID, TLSVersion: "1.3", ClientCert: true, ClientCertSerial: cert.Serial}
+	if res := mtls.CheckHandshake(handshake); !res.Allowed {
+		t.Fatalf("expected active cert to pass handshake, got %+v", res)
+	}
+	relay := NewHopRelayStore()
+	relay.SetRevocationChecker(store)
+	ep, _ := relay.UpsertEndpoint(HopRelayEndpointInput{Name: "hop", Kind: "hop", URL: "relay:443", MaxSessions: 4})
+	session, err := relay.OpenSession(HopRelaySessionInput{EndpointID: ep.ID, NodeID: "agent-1", TargetHost: "db:5432", ClientCertSerial: cert.Serial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edge := NewEdgeRelayStore()
+	edge.SetRevocationChecker(store)
+	if _, err := edge.UpsertSite(EdgeRelaySiteInput{SiteID: "branch-1", Mode: "store_and_forward", ClientCertSerial: cert.Serial}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.RevokeCertificate(cert.ID); err != nil {
+		t.Fatal(err)
+	}
+	crl, err := store.PublishCRL()
+	if err != nil || crl.Number != 1 || crl.RevokedCount != 1 {
+		t.Fatalf("unexpected crl %+v err=%v", crl, err)
+	}
+	_, der, ok := store.CRL()
+	if !ok {
+		t.Fatalf("expected published crl")
+	}
+	list, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.CheckSignatureFrom(caCert); err != nil {
+		t.Fatalf("crl not signed by agent ca: %v", err)
+	}
+	if len(list.RevokedCertificateEntries) != 1 || list.RevokedCertificateEntries[0].SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		t.Fatalf("expected revoked leaf in crl, got %+v", list.RevokedCertificateEntries)
+	}
+	if next, _ := store.PublishCRL(); next.Number != 2 {
+		t.Fatalf("expected crl number to increase, got %d", next.Number)
+	}
+
+	if status := store.CheckRevocation(cert.Serial); status.Status != "revoked" || status.AgentID != "agent-1" || status.RevokedAt == nil {
+		t.Fatalf("unexpected revocation status %+v", status)
+	}
+	if status := store.CheckRevocation("DEADBEEF"); status.Status != "unknown" {
+		t.Fatalf("expected unknown serial, got %+v", status)
+	}
+	if res := mtls.CheckHandshake(handshake); res.Allowed || res.Reason != "client certificate revoked" {
+		t.Fatalf("expected revoked cert refused at handshake, got %+v", res)
+	}
+	if _, err := relay.OpenSession(HopRelaySessionInput{EndpointID: ep.ID, NodeID: "agent-1", TargetHost: "db:5432", ClientCertSerial: cert.Serial}); err == nil {
+		t.Fatalf("expected relay to refuse revoked client certificate")
+	}
+	if ended := relay.TerminateRevokedSessions(); len(ended) != 1 || ended[0].ID != session.ID || ended[0].Status != "revoked" {
+		t.Fatalf("expected open session terminated, got %+v", ended)
+	}
+	if sites := edge.DisconnectRevoked(); len(sites) != 1 || sites[0] != "branch-1" {
+		t.Fatalf("expected edge site disconnected, got %v", sites)
+	}
+	if _, err := edge.Heartbeat("branch-1"); err == nil {
+		t.Fatalf("expected revoked edge site heartbeat refused")
+	}
+}
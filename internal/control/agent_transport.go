@@ -0,0 +1,567 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AgentTransportWebSocket = "websocket"
+	AgentTransportLongPoll  = "long_poll"
+)
+
+// AgentTransportMessage is a control-plane message for agents. Messages
+// with an AgentID go to that agent only; otherwise every agent in the
+// environment receives them once.
+type AgentTransportMessage struct {
+	ID          string         `json:"id"`
+	AgentID     string         `json:"agent_id,omitempty"`
+	Environment string         `json:"environment"`
+	Type        string         `json:"type"`
+	Payload     map[string]any `json:"payload,omitempty"`
+	Transport   string         `json:"transport,omitempty"`
+	Fallback    bool           `json:"fallback,omitempty"`
+	Status      string         `json:"status"` // queued|delivered|acked|expired|undeliverable
+	DeliveredTo []string       `json:"delivered_to,omitempty"`
+	AckedBy     []string       `json:"acked_by,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+}
+
+type AgentTransportSend struct {
+	AgentID     string         `json:"agent_id,omitempty"`
+	Environment string         `json:"environment,omitempty"`
+	Type        string         `json:"type"`
+	Payload     map[string]any `json:"payload,omitempty"`
+	TTLSeconds  int            `json:"ttl_seconds,omitempty"`
+}
+
+// AgentTransportPolicy is the ordered list of transports used to reach
+// agents in an environment; later entries are fallbacks.
+type AgentTransportPolicy struct {
+	Environment string    `json:"environment"`
+	Transports  []string  `json:"transports"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+type AgentTransportMetrics struct {
+	Transport       string  `json:"transport"`
+	Connections     int     `json:"connections"`
+	Delivered       int64   `json:"delivered"`
+	Queued          int64   `json:"queued"`
+	Acked           int64   `json:"acked"`
+	Fallbacks       int64   `json:"fallbacks"`
+	Failures        int64   `json:"failures"`
+	Expired         int64   `json:"expired"`
+	AvgAckLatencyMS float64 `json:"avg_ack_latency_ms"`
+}
+
+// AgentTransportSession is one connected agent: a websocket stream or a
+// long-poll request that is waiting for messages.
+type AgentTransportSession struct {
+	ID          string
+	AgentID     string
+	Environment string
+	Transport   string
+	ch          chan AgentTransportMessage
+	hub         *AgentTransportHub
+}
+
+// Messages is closed when the session is closed or the hub shuts down.
+func (s *AgentTransportSession) Messages() <-chan AgentTransportMessage { return s.ch }
+
+func (s *AgentTransportSession) Close() {
+	s.hub.mu.Lock()
+	s.hub.removeSessionLocked(s)
+	s.hub.mu.Unlock()
+}
+
+type agentTransportRecord struct {
+	msg        AgentTransportMessage
+	deliveries map[string]agentTransportDelivery
+	acked      map[string]bool
+	// pollable records wait in the long-poll mailbox until they expire or,
+	// for agent-addressed messages, are delivered.
+	pollable bool
+}
+
+type agentTransportDelivery struct {
+	transport string
+	at        time.Time
+}
+
+type agentTransportCounters struct {
+	delivered, queued, acked, fallbacks, failures, expired int64
+	ackLatency                                             time.Duration
+}
+
+// AgentTransportHub delivers messages to agents over websocket streams and
+// HTTPS long-poll, falling back down each environment's transport list when
+// an agent is not reachable on the preferred transport.
+type AgentTransportHub struct {
+	mu          sync.Mutex
+	nextMsg     int64
+	nextSession int64
+	policies    map[string]AgentTransportPolicy
+	sessions    map[string]*AgentTransportSession
+	records     map[string]*agentTransportRecord
+	order       []string
+	counters    map[string]*agentTransportCounters
+	closed      bool
+}
+
+func NewAgentTransportHub() *AgentTransportHub {
+	return &AgentTransportHub{
+		policies: map[string]AgentTransportPolicy{},
+		sessions: map[string]*AgentTransportSession{},
+		records:  map[string]*agentTransportRecord{},
+		counters: map[string]*agentTransportCounters{
+			AgentTransportWebSocket: {},
+			AgentTransportLongPoll:  {},
+		},
+	}
+}
+
+func (h *AgentTransportHub) SetPolicy(environment string, transports []string) (AgentTransportPolicy, error) {
+	environment = normalizeAgentEnvironment(environment)
+	seen := map[string]bool{}
+	out := make([]string, 0, len(transports))
+	for _, transport := range transports {
+		transport = strings.ToLower(strings.TrimSpace(transport))
+		switch transport {
+		case AgentTransportWebSocket, AgentTransportLongPoll:
+		default:
+			return AgentTransportPolicy{}, errors.New("transports must be websocket or long_poll")
+		}
+		if !seen[transport] {
+			seen[transport] = true
+			out = append(out, transport)
+		}
+	}
+	if len(out) == 0 {
+		return AgentTransportPolicy{}, errors.New("at least one transport is required")
+	}
+	item := AgentTransportPolicy{Environment: environment, Transports: out, UpdatedAt: time.Now().UTC()}
+	h.mu.Lock()
+	h.policies[environment] = item
+	h.mu.Unlock()
+	return clonePolicy(item), nil
+}
+
+// Policy returns the environment's policy, defaulting to websocket with
+// long-poll fallback.
+func (h *AgentTransportHub) Policy(environment string) AgentTransportPolicy {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return clonePolicy(h.policyLocked(normalizeAgentEnvironment(environment)))
+}
+
+func (h *AgentTransportHub) ListPolicies() []AgentTransportPolicy {
+	h.mu.Lock()
+	out := make([]AgentTransportPolicy, 0, len(h.policies))
+	for _, item := range h.policies {
+		out = append(out, clonePolicy(item))
+	}
+	h.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Environment < out[j].Environment })
+	return out
+}
+
+// Send routes a message over the environment's transports. Environment-wide
+// messages fan out over every transport in the policy; agent-addressed
+// messages use the first transport the agent is reachable on. Long-poll
+// stores messages until they are collected or expire.
+func (h *AgentTransportHub) Send(in AgentTransportSend) (AgentTransportMessage, error) {
+	msgType := strings.TrimSpace(in.Type)
+	if msgType == "" {
+		return AgentTransportMessage{}, errors.New("type is required")
+	}
+	ttl := time.Duration(in.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	now := time.Now().UTC()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return AgentTransportMessage{}, errors.New("agent transport hub is shut down")
+	}
+	h.expireLocked(now)
+	h.nextMsg++
+	rec := &agentTransportRecord{
+		msg: AgentTransportMessage{
+			ID:          "agent-msg-" + itoa(h.nextMsg),
+			AgentID:     strings.TrimSpace(in.AgentID),
+			Environment: normalizeAgentEnvironment(in.Environment),
+			Type:        msgType,
+			Payload:     cloneAnyMap(in.Payload),
+			Status:      "queued",
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		},
+		deliveries: map[string]agentTransportDelivery{},
+		acked:      map[string]bool{},
+	}
+	h.records[rec.msg.ID] = rec
+	h.order = append(h.order, rec.msg.ID)
+	if len(h.order) > 2000 {
+		for _, id := range h.order[:len(h.order)-2000] {
+			delete(h.records, id)
+		}
+		h.order = append([]string{}, h.order[len(h.order)-2000:]...)
+	}
+
+	for i, transport := range h.policyLocked(rec.msg.Environment).Transports {
+		reached := h.pushLocked(rec, transport, now)
+		if transport == AgentTransportLongPoll && !(rec.msg.AgentID != "" && reached > 0) {
+			rec.pollable = true
+			h.counters[transport].queued++
+			reached++
+		}
+		if reached == 0 {
+			h.counters[transport].fallbacks++
+			continue
+		}
+		if rec.msg.Transport == "" {
+			rec.msg.Transport = transport
+			rec.msg.Fallback = i > 0
+		}
+		if rec.msg.AgentID != "" {
+			break
+		}
+	}
+	if rec.msg.Transport == "" {
+		rec.msg.Status = "undeliverable"
+		return cloneAgentTransportMessage(rec), errors.New("no transport can reach the agent; enable long_poll for environment " + rec.msg.Environment)
+	}
+	return cloneAgentTransportMessage(rec), nil
+}
+
+// Connect registers a websocket session. Messages already waiting in the
+// long-poll mailbox for the agent are handed to the new session.
+func (h *AgentTransportHub) Connect(agentID, environment string) (*AgentTransportSession, error) {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return nil, errors.New("agent_id is required")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, errors.New("agent transport hub is shut down")
+	}
+	sess := h.addSessionLocked(agentID, environment, AgentTransportWebSocket, 64)
+	now := time.Now().UTC()
+	h.expireLocked(now)
+	for _, rec := range h.mailboxLocked(sess) {
+		if !h.deliverLocked(rec, sess, now) {
+			break
+		}
+	}
+	return sess, nil
+}
+
+// Poll returns messages waiting for the agent, blocking up to wait for new
+// ones when the mailbox is empty.
+func (h *AgentTransportHub) Poll(ctx context.Context, agentID, environment string, wait time.Duration) ([]AgentTransportMessage, error) {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return nil, errors.New("agent_id is required")
+	}
+	now := time.Now().UTC()
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, errors.New("agent transport hub is shut down")
+	}
+	h.expireLocked(now)
+	sess := h.addSessionLocked(agentID, environment, AgentTransportLongPoll, 64)
+	for _, rec := range h.mailboxLocked(sess) {
+		if !h.deliverLocked(rec, sess, now) {
+			break
+		}
+	}
+	h.mu.Unlock()
+
+	out := []AgentTransportMessage{}
+	if len(sess.ch) == 0 && wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case msg, ok := <-sess.ch:
+			if ok {
+				out = append(out, msg)
+			}
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+	sess.Close()
+	for msg := range sess.ch {
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// Ack records that the agent processed the message.
+func (h *AgentTransportHub) Ack(agentID, messageID string) (AgentTransportMessage, error) {
+	agentID = strings.TrimSpace(agentID)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rec, ok := h.records[strings.TrimSpace(messageID)]
+	if !ok {
+		return AgentTransportMessage{}, errors.New("message not found")
+	}
+	delivery, ok := rec.deliveries[agentID]
+	if !ok {
+		return AgentTransportMessage{}, errors.New("message was not delivered to agent")
+	}
+	if !rec.acked[agentID] {
+		rec.acked[agentID] = true
+		c := h.counters[delivery.transport]
+		c.acked++
+		c.ackLatency += time.Since(delivery.at)
+	}
+	if len(rec.acked) == len(rec.deliveries) {
+		rec.msg.Status = "acked"
+	}
+	return cloneAgentTransportMessage(rec), nil
+}
+
+// Requeue returns a message the session could not write to its agent to the
+// long-poll mailbox so the agent picks it up after reconnecting.
+func (h *AgentTransportHub) Requeue(sess *AgentTransportSession, messageID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rec, ok := h.records[strings.TrimSpace(messageID)]
+	if !ok || rec.acked[sess.AgentID] {
+		return
+	}
+	delete(rec.deliveries, sess.AgentID)
+	if len(rec.deliveries) == 0 {
+		rec.msg.Status = "queued"
+	}
+	rec.pollable = true
+	c := h.counters[sess.Transport]
+	c.failures++
+	c.delivered--
+}
+
+func (h *AgentTransportHub) Get(id string) (AgentTransportMessage, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rec, ok := h.records[strings.TrimSpace(id)]
+	if !ok {
+		return AgentTransportMessage{}, false
+	}
+	return cloneAgentTransportMessage(rec), true
+}
+
+func (h *AgentTransportHub) List(limit int) []AgentTransportMessage {
+	h.mu.Lock()
+	h.expireLocked(time.Now().UTC())
+	out := make([]AgentTransportMessage, 0, len(h.order))
+	for i := len(h.order) - 1; i >= 0; i-- {
+		out = append(out, cloneAgentTransportMessage(h.records[h.order[i]]))
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	h.mu.Unlock()
+	return out
+}
+
+func (h *AgentTransportHub) Metrics() []AgentTransportMetrics {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked(time.Now().UTC())
+	connections := map[string]int{}
+	for _, sess := range h.sessions {
+		connections[sess.Transport]++
+	}
+	out := make([]AgentTransportMetrics, 0, len(h.counters))
+	for transport, c := range h.counters {
+		item := AgentTransportMetrics{
+			Transport:   transport,
+			Connections: connections[transport],
+			Delivered:   c.delivered,
+			Queued:      c.queued,
+			Acked:       c.acked,
+			Fallbacks:   c.fallbacks,
+			Failures:    c.failures,
+			Expired:     c.expired,
+		}
+		if c.acked > 0 {
+			item.AvgAckLatencyMS = float64(c.ackLatency.Milliseconds()) / float64(c.acked)
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Transport < out[j].Transport })
+	return out
+}
+
+// Shutdown closes every session so websocket streams and pending polls
+// return.
+func (h *AgentTransportHub) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, sess := range h.sessions {
+		h.removeSessionLocked(sess)
+	}
+}
+
+func (h *AgentTransportHub) policyLocked(environment string) AgentTransportPolicy {
+	if item, ok := h.policies[environment]; ok {
+		return item
+	}
+	return AgentTransportPolicy{
+		Environment: environment,
+		Transports:  []string{AgentTransportWebSocket, AgentTransportLongPoll},
+	}
+}
+
+func (h *AgentTransportHub) addSessionLocked(agentID, environment, transport string, buffer int) *AgentTransportSession {
+	h.nextSession++
+	sess := &AgentTransportSession{
+		ID:          "agent-session-" + itoa(h.nextSession),
+		AgentID:     agentID,
+		Environment: normalizeAgentEnvironment(environment),
+		Transport:   transport,
+		ch:          make(chan AgentTransportMessage, buffer),
+		hub:         h,
+	}
+	h.sessions[sess.ID] = sess
+	return sess
+}
+
+func (h *AgentTransportHub) removeSessionLocked(sess *AgentTransportSession) {
+	if _, ok := h.sessions[sess.ID]; !ok {
+		return
+	}
+	delete(h.sessions, sess.ID)
+	close(sess.ch)
+}
+
+// pushLocked hands the message to live sessions on one transport and
+// returns how many agents it reached.
+func (h *AgentTransportHub) pushLocked(rec *agentTransportRecord, transport string, now time.Time) int {
+	ids := make([]string, 0, len(h.sessions))
+	for id := range h.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	reached := 0
+	for _, id := range ids {
+		sess := h.sessions[id]
+		if sess.Transport != transport || !recordMatchesSession(rec, sess) {
+			continue
+		}
+		if _, done := rec.deliveries[sess.AgentID]; done {
+			continue
+		}
+		if h.deliverLocked(rec, sess, now) {
+			reached++
+			if rec.msg.AgentID != "" {
+				break
+			}
+		}
+	}
+	return reached
+}
+
+// deliverLocked sends without blocking; a full session buffer counts as a
+// transport failure and the message is left for another transport.
+func (h *AgentTransportHub) deliverLocked(rec *agentTransportRecord, sess *AgentTransportSession, now time.Time) bool {
+	rec.deliveries[sess.AgentID] = agentTransportDelivery{transport: sess.Transport, at: now}
+	if rec.msg.Status == "queued" {
+		rec.msg.Status = "delivered"
+	}
+	msg := cloneAgentTransportMessage(rec)
+	msg.Transport = sess.Transport
+	select {
+	case sess.ch <- msg:
+	default:
+		delete(rec.deliveries, sess.AgentID)
+		if len(rec.deliveries) == 0 {
+			rec.msg.Status = "queued"
+		}
+		h.counters[sess.Transport].failures++
+		return false
+	}
+	h.counters[sess.Transport].delivered++
+	if rec.msg.AgentID != "" {
+		rec.pollable = false
+	}
+	return true
+}
+
+func (h *AgentTransportHub) mailboxLocked(sess *AgentTransportSession) []*agentTransportRecord {
+	out := []*agentTransportRecord{}
+	for _, id := range h.order {
+		rec := h.records[id]
+		if !rec.pollable || !recordMatchesSession(rec, sess) {
+			continue
+		}
+		if _, done := rec.deliveries[sess.AgentID]; done {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func (h *AgentTransportHub) expireLocked(now time.Time) {
+	for _, id := range h.order {
+		rec := h.records[id]
+		if !rec.pollable || now.Before(rec.msg.ExpiresAt) {
+			continue
+		}
+		rec.pollable = false
+		if len(rec.deliveries) == 0 {
+			rec.msg.Status = "expired"
+			h.counters[AgentTransportLongPoll].expired++
+		}
+	}
+}
+
+func recordMatchesSession(rec *agentTransportRecord, sess *AgentTransportSession) bool {
+	if rec.msg.AgentID != "" {
+		return rec.msg.AgentID == sess.AgentID
+	}
+	return rec.msg.Environment == sess.Environment
+}
+
+func normalizeAgentEnvironment(environment string) string {
+	environment = strings.TrimSpace(environment)
+	if environment == "" {
+		return "default"
+	}
+	return environment
+}
+
+func clonePolicy(in AgentTransportPolicy) AgentTransportPolicy {
+	out := in
+	out.Transports = append([]string{}, in.Transports...)
+	return out
+}
+
+func cloneAgentTransportMessage(rec *agentTransportRecord) AgentTransportMessage {
+	out := rec.msg
+	out.Payload = cloneAnyMap(rec.msg.Payload)
+	out.DeliveredTo = make([]string, 0, len(rec.deliveries))
+	for agent := range rec.deliveries {
+		out.DeliveredTo = append(out.DeliveredTo, agent)
+	}
+	sort.Strings(out.DeliveredTo)
+	out.AckedBy = make([]string, 0, len(rec.acked))
+	for agent := range rec.acked {
+		out.AckedBy = append(out.AckedBy, agent)
+	}
+	sort.Strings(out.AckedBy)
+	return out
+}
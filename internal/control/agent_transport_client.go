@@ -0,0 +1,253 @@
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AgentTransportClient is the agent side of the agent transports. It
+// streams messages over a websocket and falls back to HTTPS long-poll when
+// the stream cannot be opened or drops, going back to the preferred
+// transport after RetryPreferredAfter fallback polls.
+type AgentTransportClient struct {
+	BaseURL             string
+	AgentID             string
+	Environment         string
+	Transports          []string
+	PollWait            time.Duration
+	RetryPreferredAfter int
+
+	client *http.Client
+	mu     sync.Mutex
+	active string
+}
+
+func NewAgentTransportClient(baseURL, agentID, environment string) *AgentTransportClient {
+	return &AgentTransportClient{
+		BaseURL:             strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		AgentID:             strings.TrimSpace(agentID),
+		Environment:         normalizeAgentEnvironment(environment),
+		Transports:          []string{AgentTransportWebSocket, AgentTransportLongPoll},
+		PollWait:            30 * time.Second,
+		RetryPreferredAfter: 10,
+		client:              &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+// Active reports the transport currently receiving messages.
+func (c *AgentTransportClient) Active() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active
+}
+
+// Run receives messages until ctx is done. Messages are acked when handle
+// returns nil.
+func (c *AgentTransportClient) Run(ctx context.Context, handle func(AgentTransportMessage) error) error {
+	backoff := time.Second
+	for {
+		failed := 0
+		for i, transport := range c.Transports {
+			var err error
+			switch transport {
+			case AgentTransportWebSocket:
+				err = c.stream(ctx, handle)
+			case AgentTransportLongPoll:
+				err = c.poll(ctx, handle, i > 0)
+			default:
+				err = errors.New("unsupported transport " + transport)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == nil {
+				break
+			}
+			failed++
+		}
+		if failed < len(c.Transports) {
+			backoff = time.Second
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (c *AgentTransportClient) setActive(transport string) {
+	c.mu.Lock()
+	c.active = transport
+	c.mu.Unlock()
+}
+
+func (c *AgentTransportClient) query() string {
+	return "agent_id=" + url.QueryEscape(c.AgentID) + "&environment=" + url.QueryEscape(c.Environment)
+}
+
+// stream returns when the websocket closes or fails.
+func (c *AgentTransportClient) stream(ctx context.Context, handle func(AgentTransportMessage) error) error {
+	conn, br, err := c.dialWebSocket(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	c.setActive(AgentTransportWebSocket)
+	for {
+		opcode, payload, err := ReadWebSocketFrame(br)
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case WebSocketOpPing:
+			if err := WriteWebSocketFrame(conn, WebSocketOpPong, payload, true); err != nil {
+				return err
+			}
+		case WebSocketOpClose:
+			_ = WriteWebSocketFrame(conn, WebSocketOpClose, nil, true)
+			return errors.New("websocket closed by control plane")
+		case WebSocketOpText:
+			var msg AgentTransportMessage
+			if err := json.Unmarshal(payload, &msg); err != nil {
+				continue
+			}
+			if handle(msg) != nil {
+				continue
+			}
+			ack, _ := json.Marshal(map[string]string{"ack": msg.ID})
+			if err := WriteWebSocketFrame(conn, WebSocketOpText, ack, true); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *AgentTransportClient) dialWebSocket(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, nil, errors.New("base url must be http or https")
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		conn = tlsConn
+	}
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	key := base64.StdEncoding.EncodeToString(raw)
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	req := fmt.Sprintf("GET %s/v1/agents/transports/ws?%s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n",
+		u.Path, c.query(), u.Host, key)
+	if _, err := conn.Write([]byte(req)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != WebSocketAccept(key) {
+		conn.Close()
+		return nil, nil, fmt.Errorf("websocket upgrade failed with status %d", resp.StatusCode)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, br, nil
+}
+
+// poll long-polls until a request fails or, when long-poll is a fallback,
+// until it is time to retry the preferred transport.
+func (c *AgentTransportClient) poll(ctx context.Context, handle func(AgentTransportMessage) error, fallback bool) error {
+	wait := int(c.PollWait / time.Second)
+	if wait <= 0 {
+		wait = 1
+	}
+	for polls := 0; !fallback || polls < c.RetryPreferredAfter; polls++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/v1/agents/transports/poll?"+c.query()+"&wait_seconds="+strconv.Itoa(wait), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		var body struct {
+			Messages []AgentTransportMessage `json:"messages"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("long-poll returned status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return err
+		}
+		c.setActive(AgentTransportLongPoll)
+		for _, msg := range body.Messages {
+			if handle(msg) != nil {
+				continue
+			}
+			if err := c.ack(ctx, msg.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *AgentTransportClient) ack(ctx context.Context, messageID string) error {
+	payload, _ := json.Marshal(map[string]string{"agent_id": c.AgentID, "message_id": messageID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/agents/transports/ack", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ack returned status %d", resp.StatusCode)
+	}
+	return nil
+}
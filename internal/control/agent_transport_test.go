@@ -0,0 +1,191 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgentTransportHubFallbackAndAck(t *testing.T) {
+	hub := NewAgentTransportHub()
+	if _, err := hub.SetPolicy("prod", []string{"carrier-pigeon"}); err == nil {
+		t.Fatalf("expected unsupported transport error")
+	}
+	if got := hub.Policy("prod").Transports; len(got) != 2 || got[0] != AgentTransportWebSocket {
+		t.Fatalf("unexpected default policy %v", got)
+	}
+
+	// No websocket agents are connected, so the message falls back to the
+	// long-poll mailbox.
+	msg, err := hub.Send(AgentTransportSend{Environment: "prod", Type: "dispatch", Payload: map[string]any{"config_path": "c.yaml"}})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if msg.Transport != AgentTransportLongPoll || !msg.Fallback || msg.Status != "queued" {
+		t.Fatalf("expected long-poll fallback, got %+v", msg)
+	}
+	got, err := hub.Poll(context.Background(), "agent-1", "prod", 0)
+	if err != nil || len(got) != 1 || got[0].ID != msg.ID {
+		t.Fatalf("expected queued message from poll, got %+v err=%v", got, err)
+	}
+	if again, _ := hub.Poll(context.Background(), "agent-1", "prod", 0); len(again) != 0 {
+		t.Fatalf("expected message to be delivered once per agent, got %+v", again)
+	}
+	if others, _ := hub.Poll(context.Background(), "agent-2", "staging", 0); len(others) != 0 {
+		t.Fatalf("expected no messages for another environment, got %+v", others)
+	}
+	acked, err := hub.Ack("agent-1", msg.ID)
+	if err != nil || acked.Status != "acked" {
+		t.Fatalf("ack failed: %+v err=%v", acked, err)
+	}
+	if _, err := hub.Ack("agent-9", msg.ID); err == nil {
+		t.Fatalf("expected ack from agent without delivery to fail")
+	}
+
+	sess, err := hub.Connect("agent-1", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := hub.Send(AgentTransportSend{AgentID: "agent-1", Type: "run"})
+	if err != nil || direct.Transport != AgentTransportWebSocket || direct.Fallback {
+		t.Fatalf("expected websocket delivery, got %+v err=%v", direct, err)
+	}
+	select {
+	case m := <-sess.Messages():
+		if m.ID != direct.ID {
+			t.Fatalf("unexpected session message %+v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected message on websocket session")
+	}
+	sess.Close()
+
+	if _, err := hub.SetPolicy("edge", []string{AgentTransportWebSocket}); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := hub.Send(AgentTransportSend{AgentID: "agent-7", Environment: "edge", Type: "run"}); err == nil || out.Status != "undeliverable" {
+		t.Fatalf("expected undeliverable message, got %+v err=%v", out, err)
+	}
+
+	metrics := map[string]AgentTransportMetrics{}
+	for _, m := range hub.Metrics() {
+		metrics[m.Transport] = m
+	}
+	if metrics[AgentTransportLongPoll].Delivered != 1 || metrics[AgentTransportLongPoll].Acked != 1 {
+		t.Fatalf("unexpected long-poll metrics %+v", metrics[AgentTransportLongPoll])
+	}
+	if metrics[AgentTransportWebSocket].Delivered != 1 || metrics[AgentTransportWebSocket].Fallbacks != 2 {
+		t.Fatalf("unexpected websocket metrics %+v", metrics[AgentTransportWebSocket])
+	}
+}
+
+func TestAgentTransportHubPollWakesOnSend(t *testing.T) {
+	hub := NewAgentTransportHub()
+	done := make(chan []AgentTransportMessage, 1)
+	go func() {
+		msgs, _ := hub.Poll(context.Background(), "agent-1", "", 5*time.Second)
+		done <- msgs
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Metrics()[0].Connections == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	msg, err := hub.Send(AgentTransportSend{AgentID: "agent-1", Type: "run"})
+	if err != nil || msg.Transport != AgentTransportLongPoll || msg.Status != "delivered" {
+		t.Fatalf("expected delivery to waiting poller, got %+v err=%v", msg, err)
+	}
+	select {
+	case msgs := <-done:
+		if len(msgs) != 1 || msgs[0].ID != msg.ID {
+			t.Fatalf("unexpected poll result %+v", msgs)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("poll did not wake on send")
+	}
+}
+
+func TestWebSocketFrameRoundTrip(t *testing.T) {
+	for _, size := range []int{5, 300, 70000} {
+		for _, masked := range []bool{false, true} {
+			payload := []byte(strings.Repeat("x", size))
+			var buf bytes.Buffer
+			if err := WriteWebSocketFrame(&buf, WebSocketOpText, payload, masked); err != nil {
+				t.Fatal(err)
+			}
+			opcode, got, err := ReadWebSocketFrame(&buf)
+			if err != nil || opcode != WebSocketOpText || !bytes.Equal(got, payload) {
+				t.Fatalf("round trip failed for size=%d masked=%v: op=%d err=%v", size, masked, opcode, err)
+			}
+		}
+	}
+	if WebSocketAccept("dGhlIHNhbXBsZSBub25jZQ==") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key")
+	}
+}
+
+func TestAgentTransportClientFallsBackToLongPoll(t *testing.T) {
+	hub := NewAgentTransportHub()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/agents/transports/ws", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/v1/agents/transports/poll", func(w http.ResponseWriter, r *http.Request) {
+		msgs, _ := hub.Poll(r.Context(), r.URL.Query().Get("agent_id"), r.URL.Query().Get("environment"), time.Second)
+		_ = json.NewEncoder(w).Encode(map[string]any{"messages": msgs})
+	})
+	mux.HandleFunc("/v1/agents/transports/ack", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			AgentID   string `json:"agent_id"`
+			MessageID string `json:"message_id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, err := hub.Ack(req.AgentID, req.MessageID); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer hub.Shutdown()
+
+	msg, err := hub.Send(AgentTransportSend{AgentID: "agent-1", Type: "run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewAgentTransportClient(srv.URL, "agent-1", "")
+	client.PollWait = time.Second
+	received := make(chan AgentTransportMessage, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = client.Run(ctx, func(m AgentTransportMessage) error {
+			received <- m
+			return nil
+		})
+	}()
+	select {
+	case m := <-received:
+		if m.ID != msg.ID || m.Transport != AgentTransportLongPoll {
+			t.Fatalf("unexpected message %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("client did not receive message over long-poll")
+	}
+	if client.Active() != AgentTransportLongPoll {
+		t.Fatalf("expected long-poll to be active, got %q", client.Active())
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got, _ := hub.Get(msg.ID); got.Status == "acked" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("message was not acked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,108 @@
+package control
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+)
+
+// Minimal RFC 6455 framing for the agent websocket transport: single-frame
+// text, ping/pong, and close messages. Fragmented messages are rejected.
+
+const (
+	WebSocketOpText  byte = 0x1
+	WebSocketOpClose byte = 0x8
+	WebSocketOpPing  byte = 0x9
+	WebSocketOpPong  byte = 0xa
+
+	webSocketGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	webSocketMaxPayload = 1 << 20
+)
+
+// WebSocketAccept computes the Sec-WebSocket-Accept value for a client key.
+func WebSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WriteWebSocketFrame writes one final frame. Clients must mask their
+// frames; servers must not.
+func WriteWebSocketFrame(w io.Writer, opcode byte, payload []byte, masked bool) error {
+	header := []byte{0x80 | opcode, 0}
+	n := len(payload)
+	switch {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	body := payload
+	if masked {
+		header[1] |= 0x80
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, rand.Uint32())
+		header = append(header, key...)
+		body = make([]byte, n)
+		for i := range payload {
+			body[i] = payload[i] ^ key[i%4]
+		}
+	}
+	if _, err := w.Write(append(header, body...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadWebSocketFrame reads one frame and unmasks its payload.
+func ReadWebSocketFrame(r io.Reader) (byte, []byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return 0, nil, err
+	}
+	if head[0]&0x80 == 0 || head[0]&0x0f == 0 {
+		return 0, nil, errors.New("fragmented websocket messages are not supported")
+	}
+	opcode := head[0] & 0x0f
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext)
+	}
+	if n > webSocketMaxPayload {
+		return 0, nil, errors.New("websocket frame exceeds 1MiB")
+	}
+	var key []byte
+	if head[1]&0x80 != 0 {
+		key = make([]byte, 4)
+		if _, err := io.ReadFull(r, key); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if key != nil {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
@@ -0,0 +1,174 @@
+package control
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Annotation target kinds.
+const (
+	AnnotationTargetRun   = "run"
+	AnnotationTargetJob   = "job"
+	AnnotationTargetAlert = "alert"
+)
+
+const maxAnnotationBody = 16 * 1024
+
+type AnnotationLink struct {
+	Label string `json:"label,omitempty"`
+	URL   string `json:"url"`
+}
+
+// Annotation is an operator comment attached to a run, job, or alert. Body
+// is markdown and is stored as written.
+type Annotation struct {
+	ID         string           `json:"id"`
+	TargetKind string           `json:"target_kind"`
+	TargetID   string           `json:"target_id"`
+	Author     string           `json:"author"`
+	Body       string           `json:"body"`
+	Links      []AnnotationLink `json:"links,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+type AnnotationInput struct {
+	TargetKind string           `json:"target_kind"`
+	TargetID   string           `json:"target_id"`
+	Author     string           `json:"author"`
+	Body       string           `json:"body"`
+	Links      []AnnotationLink `json:"links,omitempty"`
+}
+
+type AnnotationStore struct {
+	mu          sync.RWMutex
+	nextID      int64
+	annotations map[string]*Annotation
+}
+
+func NewAnnotationStore() *AnnotationStore {
+	return &AnnotationStore{annotations: map[string]*Annotation{}}
+}
+
+func (s *AnnotationStore) Add(in AnnotationInput) (Annotation, error) {
+	kind := strings.ToLower(strings.TrimSpace(in.TargetKind))
+	switch kind {
+	case AnnotationTargetRun, AnnotationTargetJob, AnnotationTargetAlert:
+	default:
+		return Annotation{}, errors.New("target_kind must be run, job, or alert")
+	}
+	item := Annotation{
+		TargetKind: kind,
+		TargetID:   strings.TrimSpace(in.TargetID),
+		Author:     strings.TrimSpace(in.Author),
+		Body:       strings.TrimSpace(in.Body),
+	}
+	if item.TargetID == "" {
+		return Annotation{}, errors.New("target_id is required")
+	}
+	if item.Author == "" {
+		return Annotation{}, errors.New("annotation author is required")
+	}
+	if item.Body == "" {
+		return Annotation{}, errors.New("annotation body is required")
+	}
+	if len(item.Body) > maxAnnotationBody {
+		return Annotation{}, errors.New("annotation body exceeds 16KiB")
+	}
+	for _, link := range in.Links {
+		link.Label = strings.TrimSpace(link.Label)
+		link.URL = strings.TrimSpace(link.URL)
+		u, err := url.Parse(link.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Annotation{}, errors.New("annotation links must be absolute http or https urls")
+		}
+		item.Links = append(item.Links, link)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	item.ID = "ann-" + itoa(s.nextID)
+	item.CreatedAt = time.Now().UTC()
+	s.annotations[item.ID] = &item
+	return cloneAnnotation(item), nil
+}
+
+// List returns the annotations of a target, oldest first.
+func (s *AnnotationStore) List(kind, targetID string) []Annotation {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	targetID = strings.TrimSpace(targetID)
+	s.mu.RLock()
+	out := make([]Annotation, 0)
+	for _, item := range s.annotations {
+		if item.TargetKind == kind && item.TargetID == targetID {
+			out = append(out, cloneAnnotation(*item))
+		}
+	}
+	s.mu.RUnlock()
+	sortAnnotations(out)
+	return out
+}
+
+// ListForTargets returns the annotations of any of the given targets keyed
+// by kind, oldest first.
+func (s *AnnotationStore) ListForTargets(targets map[string][]string) []Annotation {
+	want := map[string]struct{}{}
+	for kind, ids := range targets {
+		for _, id := range ids {
+			want[strings.ToLower(kind)+"/"+strings.TrimSpace(id)] = struct{}{}
+		}
+	}
+	s.mu.RLock()
+	out := make([]Annotation, 0)
+	for _, item := range s.annotations {
+		if _, ok := want[item.TargetKind+"/"+item.TargetID]; ok {
+			out = append(out, cloneAnnotation(*item))
+		}
+	}
+	s.mu.RUnlock()
+	sortAnnotations(out)
+	return out
+}
+
+func (s *AnnotationStore) Get(id string) (Annotation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.annotations[strings.TrimSpace(id)]
+	if !ok {
+		return Annotation{}, errors.New("annotation not found")
+	}
+	return cloneAnnotation(*item), nil
+}
+
+func (s *AnnotationStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok := s.annotations[id]; !ok {
+		return errors.New("annotation not found")
+	}
+	delete(s.annotations, id)
+	return nil
+}
+
+func sortAnnotations(items []Annotation) {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+func cloneAnnotation(in Annotation) Annotation {
+	out := in
+	out.Links = append([]AnnotationLink{}, in.Links...)
+	if len(out.Links) == 0 {
+		out.Links = nil
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package control
+
+import "testing"
+
+func TestAnnotationStore(t *testing.T) {
+	store := NewAnnotationStore()
+	if _, err := store.Add(AnnotationInput{TargetKind: "host", TargetID: "h1", Author: "alice", Body: "x"}); err == nil {
+		t.Fatalf("expected unknown target kind to be rejected")
+	}
+	if _, err := store.Add(AnnotationInput{TargetKind: "run", TargetID: "run-1", Author: "alice", Body: " "}); err == nil {
+		t.Fatalf("expected empty body to be rejected")
+	}
+	if _, err := store.Add(AnnotationInput{TargetKind: "run", TargetID: "run-1", Author: "alice", Body: "x", Links: []AnnotationLink{{URL: "javascript:alert(1)"}}}); err == nil {
+		t.Fatalf("expected non-http link to be rejected")
+	}
+	first, err := store.Add(AnnotationInput{TargetKind: "RUN", TargetID: "run-1", Author: "alice", Body: "**root cause**: disk full", Links: []AnnotationLink{{Label: "graph", URL: "https://grafana.example.com/d/disk"}}})
+	if err != nil || first.TargetKind != AnnotationTargetRun || first.ID != "ann-1" {
+		t.Fatalf("unexpected annotation %+v err=%v", first, err)
+	}
+	if _, err := store.Add(AnnotationInput{TargetKind: "run", TargetID: "run-1", Author: "bob", Body: "rotated logs"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(AnnotationInput{TargetKind: "alert", TargetID: "alert-1", Author: "bob", Body: "paged db team"}); err != nil {
+		t.Fatal(err)
+	}
+	items := store.List("run", "run-1")
+	if len(items) != 2 || items[0].ID != first.ID || items[1].Author != "bob" {
+		t.Fatalf("unexpected run annotations %+v", items)
+	}
+	if got := store.ListForTargets(map[string][]string{"run": {"run-1"}, "alert": {"alert-1"}}); len(got) != 3 {
+		t.Fatalf("expected annotations across targets, got %+v", got)
+	}
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(first.ID); err == nil {
+		t.Fatalf("expected deleted annotation to be gone")
+	}
+}
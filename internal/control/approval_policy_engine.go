@@ -0,0 +1,274 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ApprovalSubjectChangeRecord = "change_record"
+	ApprovalSubjectBreakGlass   = "break_glass"
+	ApprovalSubjectPromotion    = "gitops_promotion"
+)
+
+// ApproverIdentity is what an approval policy knows about an actor: the
+// roles they hold and the teams they belong to, lowercased.
+type ApproverIdentity struct {
+	Roles []string `json:"roles,omitempty"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+// ApprovalSubject is a change under approval. Scope is matched against
+// policy scopes: the config path of a change record, the scope of a
+// break-glass request, or the target stage of a GitOps promotion.
+type ApprovalSubject struct {
+	Kind        string `json:"kind"`
+	ID          string `json:"id"`
+	Scope       string `json:"scope"`
+	RequestedBy string `json:"requested_by,omitempty"`
+}
+
+type ApprovalVote struct {
+	Actor     string    `json:"actor"`
+	Decision  string    `json:"decision"` // approve|reject
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ApprovalIgnoredVote struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"` // self_approval|stale|not_eligible
+}
+
+type ApprovalStageEvaluation struct {
+	Name      string   `json:"name"`
+	Required  int      `json:"required"`
+	Approvers []string `json:"approvers"`
+	Satisfied bool     `json:"satisfied"`
+}
+
+type ApprovalPolicyEvaluation struct {
+	PolicyID   string                    `json:"policy_id"`
+	PolicyName string                    `json:"policy_name"`
+	Satisfied  bool                      `json:"satisfied"`
+	Stages     []ApprovalStageEvaluation `json:"stages"`
+	Ignored    []ApprovalIgnoredVote     `json:"ignored,omitempty"`
+}
+
+// ApprovalEvaluation reports whether a subject has the approvals every
+// matching policy demands. A subject no policy covers needs no extra
+// approvals and is reported with Required false.
+type ApprovalEvaluation struct {
+	Subject   ApprovalSubject            `json:"subject"`
+	Required  bool                       `json:"required"`
+	Satisfied bool                       `json:"satisfied"`
+	Policies  []ApprovalPolicyEvaluation `json:"policies"`
+}
+
+// SetApproverResolver sets how actors map to roles and teams for stage
+// constraints. Without one, only stages without constraints can be met.
+func (s *AccessApprovalStore) SetApproverResolver(fn func(actor string) ApproverIdentity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolve = fn
+}
+
+// MatchingPolicies returns the policies enforced on changes of kind in
+// scope, oldest first.
+func (s *AccessApprovalStore) MatchingPolicies(kind, scope string) []QuorumApprovalPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.matchingPoliciesLocked(kind, scope)
+}
+
+// CheckApprover reports why actor may not approve subject under the
+// policies that cover it, or nil when the approval can be recorded.
+func (s *AccessApprovalStore) CheckApprover(subject ApprovalSubject, actor string) error {
+	actor = strings.TrimSpace(actor)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, p := range s.matchingPoliciesLocked(subject.Kind, subject.Scope) {
+		if !p.AllowSelfApproval && subject.RequestedBy != "" && strings.EqualFold(actor, subject.RequestedBy) {
+			return errors.New("self-approval is not allowed by approval policy " + p.ID)
+		}
+		eligible := false
+		for _, stage := range p.Stages {
+			if s.eligibleLocked(stage, actor) {
+				eligible = true
+				break
+			}
+		}
+		if !eligible {
+			return errors.New("actor is not an eligible approver under approval policy " + p.ID)
+		}
+	}
+	return nil
+}
+
+// EvaluateApprovals checks votes against every policy covering subject.
+// Each actor's latest vote counts once, towards the first unmet stage they
+// are eligible for; self-approvals and stale approvals are ignored.
+func (s *AccessApprovalStore) EvaluateApprovals(subject ApprovalSubject, votes []ApprovalVote, now time.Time) ApprovalEvaluation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := ApprovalEvaluation{Subject: subject, Satisfied: true, Policies: []ApprovalPolicyEvaluation{}}
+	latest := latestApprovalVotes(votes)
+	for _, p := range s.matchingPoliciesLocked(subject.Kind, subject.Scope) {
+		out.Required = true
+		eval := s.evaluatePolicyLocked(p, subject, latest, now)
+		if !eval.Satisfied {
+			out.Satisfied = false
+		}
+		out.Policies = append(out.Policies, eval)
+	}
+	return out
+}
+
+func (s *AccessApprovalStore) evaluatePolicyLocked(p QuorumApprovalPolicy, subject ApprovalSubject, votes []ApprovalVote, now time.Time) ApprovalPolicyEvaluation {
+	eval := ApprovalPolicyEvaluation{PolicyID: p.ID, PolicyName: p.Name, Stages: make([]ApprovalStageEvaluation, len(p.Stages))}
+	for i, stage := range p.Stages {
+		eval.Stages[i] = ApprovalStageEvaluation{Name: stage.Name, Required: stage.RequiredApprovals, Approvers: []string{}}
+	}
+	for _, vote := range votes {
+		if vote.Decision != "approve" {
+			continue
+		}
+		switch {
+		case !p.AllowSelfApproval && subject.RequestedBy != "" && strings.EqualFold(vote.Actor, subject.RequestedBy):
+			eval.Ignored = append(eval.Ignored, ApprovalIgnoredVote{Actor: vote.Actor, Reason: "self_approval"})
+			continue
+		case approvalStale(vote.CreatedAt, p.ApprovalTTLSeconds, now):
+			eval.Ignored = append(eval.Ignored, ApprovalIgnoredVote{Actor: vote.Actor, Reason: "stale"})
+			continue
+		}
+		eligible := false
+		for i, stage := range p.Stages {
+			if !s.eligibleLocked(stage, vote.Actor) {
+				continue
+			}
+			eligible = true
+			if len(eval.Stages[i].Approvers) < stage.RequiredApprovals {
+				eval.Stages[i].Approvers = append(eval.Stages[i].Approvers, vote.Actor)
+				break
+			}
+		}
+		if !eligible {
+			eval.Ignored = append(eval.Ignored, ApprovalIgnoredVote{Actor: vote.Actor, Reason: "not_eligible"})
+		}
+	}
+	eval.Satisfied = true
+	for i := range eval.Stages {
+		eval.Stages[i].Satisfied = len(eval.Stages[i].Approvers) >= eval.Stages[i].Required
+		if !eval.Stages[i].Satisfied {
+			eval.Satisfied = false
+		}
+	}
+	return eval
+}
+
+func (s *AccessApprovalStore) matchingPoliciesLocked(kind, scope string) []QuorumApprovalPolicy {
+	out := make([]QuorumApprovalPolicy, 0)
+	for _, p := range s.policies {
+		if !containsString(p.AppliesTo, kind) || !approvalScopeMatches(p.Scopes, scope) {
+			continue
+		}
+		out = append(out, cloneApprovalPolicy(*p))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// breakGlassPolicyLocked picks the policy a break-glass request runs under.
+// When policies are enforced on break-glass for the scope, the request must
+// use one of them; policyID may be left empty only if exactly one applies.
+func (s *AccessApprovalStore) breakGlassPolicyLocked(policyID, scope string) (*QuorumApprovalPolicy, error) {
+	enforced := s.matchingPoliciesLocked(ApprovalSubjectBreakGlass, scope)
+	if policyID == "" {
+		switch len(enforced) {
+		case 0:
+			return nil, errors.New("policy_id is required")
+		case 1:
+			return s.policies[enforced[0].ID], nil
+		default:
+			return nil, errors.New("policy_id is required: several approval policies cover scope " + scope)
+		}
+	}
+	policy, ok := s.policies[policyID]
+	if !ok {
+		return nil, errors.New("approval policy not found")
+	}
+	if len(enforced) == 0 {
+		return policy, nil
+	}
+	for _, p := range enforced {
+		if p.ID == policyID {
+			return policy, nil
+		}
+	}
+	return nil, errors.New("approval policy " + policyID + " is not enforced on break-glass for scope " + scope)
+}
+
+func (s *AccessApprovalStore) eligibleLocked(stage ApprovalStageRule, actor string) bool {
+	if len(stage.Roles) == 0 && len(stage.Teams) == 0 {
+		return true
+	}
+	if s.resolve == nil {
+		return false
+	}
+	identity := s.resolve(strings.TrimSpace(actor))
+	for _, role := range identity.Roles {
+		if containsString(stage.Roles, strings.ToLower(role)) {
+			return true
+		}
+	}
+	for _, team := range identity.Teams {
+		if containsString(stage.Teams, strings.ToLower(team)) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestApprovalVotes keeps each actor's most recent vote, in the order the
+// votes were cast.
+func latestApprovalVotes(votes []ApprovalVote) []ApprovalVote {
+	sorted := append([]ApprovalVote(nil), votes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+	last := map[string]int{}
+	for i, vote := range sorted {
+		last[strings.ToLower(strings.TrimSpace(vote.Actor))] = i
+	}
+	out := make([]ApprovalVote, 0, len(last))
+	for i, vote := range sorted {
+		if last[strings.ToLower(strings.TrimSpace(vote.Actor))] == i {
+			out = append(out, vote)
+		}
+	}
+	return out
+}
+
+func approvalScopeMatches(scopes []string, scope string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, prefix := range scopes {
+		if prefix == "*" || strings.HasPrefix(scope, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func approvalStale(at time.Time, ttlSeconds int, now time.Time) bool {
+	return ttlSeconds > 0 && now.Sub(at) > time.Duration(ttlSeconds)*time.Second
+}
+
+func validApprovalSubjectKind(kind string) bool {
+	switch kind {
+	case ApprovalSubjectChangeRecord, ApprovalSubjectBreakGlass, ApprovalSubjectPromotion:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,140 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApprovalPolicyEvaluation(t *testing.T) {
+	store := NewAccessApprovalStore()
+	store.SetApproverResolver(func(actor string) ApproverIdentity {
+		switch actor {
+		case "dba-1", "dba-2":
+			return ApproverIdentity{Roles: []string{"DBA"}}
+		case "sec-1":
+			return ApproverIdentity{Teams: []string{"security"}}
+		}
+		return ApproverIdentity{}
+	})
+	if _, err := store.CreatePolicy(QuorumApprovalPolicyInput{Name: "bad", Stages: []ApprovalStageRule{{RequiredApprovals: 1}}, AppliesTo: []string{"deploys"}}); err == nil {
+		t.Fatalf("expected unknown applies_to kind to be rejected")
+	}
+	policy, err := store.CreatePolicy(QuorumApprovalPolicyInput{
+		Name: "prod-db",
+		Stages: []ApprovalStageRule{
+			{Name: "dba", RequiredApprovals: 2, Roles: []string{"dba"}},
+			{Name: "security", RequiredApprovals: 1, Teams: []string{"Security"}},
+		},
+		AppliesTo:          []string{ApprovalSubjectChangeRecord},
+		Scopes:             []string{"prod/db"},
+		ApprovalTTLSeconds: 3600,
+	})
+	if err != nil {
+		t.Fatalf("create policy failed: %v", err)
+	}
+	if got := store.MatchingPolicies(ApprovalSubjectChangeRecord, "prod/web.yaml"); len(got) != 0 {
+		t.Fatalf("expected scope mismatch to match nothing, got %+v", got)
+	}
+	if got := store.MatchingPolicies(ApprovalSubjectPromotion, "prod/db.yaml"); len(got) != 0 {
+		t.Fatalf("expected kind mismatch to match nothing, got %+v", got)
+	}
+
+	subject := ApprovalSubject{Kind: ApprovalSubjectChangeRecord, ID: "cr-1", Scope: "prod/db.yaml", RequestedBy: "dba-1"}
+	if err := store.CheckApprover(subject, "dba-1"); err == nil {
+		t.Fatalf("expected self-approval to be refused")
+	}
+	if err := store.CheckApprover(subject, "intern"); err == nil {
+		t.Fatalf("expected ineligible approver to be refused")
+	}
+	if err := store.CheckApprover(subject, "sec-1"); err != nil {
+		t.Fatalf("expected security team member to be eligible: %v", err)
+	}
+
+	now := time.Now().UTC()
+	votes := []ApprovalVote{
+		{Actor: "dba-1", Decision: "approve", CreatedAt: now.Add(-time.Minute)},
+		{Actor: "dba-2", Decision: "approve", CreatedAt: now.Add(-2 * time.Hour)},
+		{Actor: "sec-1", Decision: "approve", CreatedAt: now.Add(-time.Minute)},
+		{Actor: "intern", Decision: "approve", CreatedAt: now.Add(-time.Minute)},
+	}
+	eval := store.EvaluateApprovals(subject, votes, now)
+	if !eval.Required || eval.Satisfied || len(eval.Policies) != 1 || eval.Policies[0].PolicyID != policy.ID {
+		t.Fatalf("expected unsatisfied evaluation, got %+v", eval)
+	}
+	reasons := map[string]string{}
+	for _, ignored := range eval.Policies[0].Ignored {
+		reasons[ignored.Actor] = ignored.Reason
+	}
+	if reasons["dba-1"] != "self_approval" || reasons["dba-2"] != "stale" || reasons["intern"] != "not_eligible" {
+		t.Fatalf("unexpected ignored votes: %+v", eval.Policies[0].Ignored)
+	}
+	if !eval.Policies[0].Stages[1].Satisfied || eval.Policies[0].Stages[0].Satisfied {
+		t.Fatalf("expected only the security stage to be met, got %+v", eval.Policies[0].Stages)
+	}
+
+	subject.RequestedBy = "someone-else"
+	votes[1].CreatedAt = now
+	votes = append(votes, ApprovalVote{Actor: "dba-2", Decision: "reject", CreatedAt: now.Add(time.Second)})
+	if eval = store.EvaluateApprovals(subject, votes, now); eval.Satisfied {
+		t.Fatalf("expected a later rejection to withdraw dba-2's approval, got %+v", eval)
+	}
+	votes = votes[:len(votes)-1]
+	if eval = store.EvaluateApprovals(subject, votes, now); !eval.Satisfied {
+		t.Fatalf("expected two dbas and security to satisfy policy, got %+v", eval)
+	}
+
+	other := ApprovalSubject{Kind: ApprovalSubjectChangeRecord, ID: "cr-2", Scope: "staging/app.yaml"}
+	if eval = store.EvaluateApprovals(other, nil, now); eval.Required || !eval.Satisfied {
+		t.Fatalf("expected uncovered subject to need nothing, got %+v", eval)
+	}
+}
+
+func TestBreakGlassApprovalConstraints(t *testing.T) {
+	store := NewAccessApprovalStore()
+	store.SetApproverResolver(func(actor string) ApproverIdentity {
+		if actor == "sec-lead" || actor == "oncall-sre" {
+			return ApproverIdentity{Roles: []string{"security-lead"}}
+		}
+		return ApproverIdentity{}
+	})
+	loose, err := store.CreatePolicy(QuorumApprovalPolicyInput{Name: "loose", Stages: []ApprovalStageRule{{RequiredApprovals: 1}}})
+	if err != nil {
+		t.Fatalf("create loose policy failed: %v", err)
+	}
+	strict, err := store.CreatePolicy(QuorumApprovalPolicyInput{
+		Name:      "prod",
+		Stages:    []ApprovalStageRule{{Name: "security", RequiredApprovals: 1, Roles: []string{"security-lead"}}},
+		AppliesTo: []string{ApprovalSubjectBreakGlass},
+		Scopes:    []string{"prod/"},
+	})
+	if err != nil {
+		t.Fatalf("create strict policy failed: %v", err)
+	}
+
+	if _, err := store.CreateBreakGlassRequest(BreakGlassRequestInput{RequestedBy: "oncall-sre", Reason: "outage", Scope: "prod/db", PolicyID: loose.ID}); err == nil {
+		t.Fatalf("expected a policy not enforced on the scope to be refused")
+	}
+	if _, err := store.CreateBreakGlassRequest(BreakGlassRequestInput{RequestedBy: "oncall-sre", Reason: "outage", Scope: "dev/db"}); err == nil {
+		t.Fatalf("expected policy_id to be required when no policy covers the scope")
+	}
+	req, err := store.CreateBreakGlassRequest(BreakGlassRequestInput{RequestedBy: "oncall-sre", Reason: "outage", Scope: "prod/db"})
+	if err != nil {
+		t.Fatalf("create request failed: %v", err)
+	}
+	if req.PolicyID != strict.ID {
+		t.Fatalf("expected enforced policy to be picked, got %s", req.PolicyID)
+	}
+	if _, err := store.ApproveBreakGlassRequest(req.ID, "oncall-sre", "self"); err == nil {
+		t.Fatalf("expected self-approval to be refused")
+	}
+	if _, err := store.ApproveBreakGlassRequest(req.ID, "random", "go"); err == nil {
+		t.Fatalf("expected approver without the stage role to be refused")
+	}
+	req, err = store.ApproveBreakGlassRequest(req.ID, "sec-lead", "go")
+	if err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if req.Status != BreakGlassActive {
+		t.Fatalf("expected request to activate, got %s", req.Status)
+	}
+}
@@ -48,6 +48,7 @@ type ArtifactDeploymentStore struct {
 	mu          sync.RWMutex
 	nextID      int64
 	deployments map[string]*ArtifactDeployment
+	admission   func(ArtifactDeployment) string
 }
 
 func NewArtifactDeploymentStore() *ArtifactDeploymentStore {
@@ -90,6 +91,14 @@ func (s *ArtifactDeploymentStore) Create(in ArtifactDeploymentInput) (ArtifactDe
 	return item, s.plan(item), nil
 }
 
+// SetAdmissionCheck registers a check run on every plan; a non-empty
+// result blocks the rollout with that reason.
+func (s *ArtifactDeploymentStore) SetAdmissionCheck(fn func(ArtifactDeployment) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.admission = fn
+}
+
 func (s *ArtifactDeploymentStore) List() []ArtifactDeployment {
 	s.mu.RLock()
 	out := make([]ArtifactDeployment, 0, len(s.deployments))
@@ -132,6 +141,16 @@ func (s *ArtifactDeploymentStore) plan(item ArtifactDeployment) ArtifactDeployme
 		plan.BlockedReason = "checksum pin is required for artifact deployment"
 		return plan
 	}
+	s.mu.RLock()
+	admission := s.admission
+	s.mu.RUnlock()
+	if admission != nil {
+		if reason := admission(item); reason != "" {
+			plan.Allowed = false
+			plan.BlockedReason = reason
+			return plan
+		}
+	}
 	stages := make([]ArtifactDeploymentStage, 0, len(item.Targets))
 	stage := 1
 	for i := 0; i < len(item.Targets); i += item.StageSize {
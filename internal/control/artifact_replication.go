@@ -0,0 +1,349 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ArtifactReplicaPending     = "pending"
+	ArtifactReplicaReplicating = "replicating"
+	ArtifactReplicaVerified    = "verified"
+	ArtifactReplicaFailed      = "failed"
+)
+
+// ArtifactReplicaTargetInput registers an object store region or relay that
+// artifacts are copied to. Root is the replica store location; the server
+// picks one under its state directory when empty.
+type ArtifactReplicaTargetInput struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind,omitempty"` // region|relay
+	Region string `json:"region,omitempty"`
+	Root   string `json:"root,omitempty"`
+}
+
+type ArtifactReplicaTarget struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Region    string    `json:"region"`
+	Root      string    `json:"root,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ArtifactReplication copies one object from the primary store to one
+// target. A copy is only verified once the digest read back from the target
+// matches the source digest.
+type ArtifactReplication struct {
+	ID          string    `json:"id"`
+	ObjectKey   string    `json:"object_key"`
+	Class       string    `json:"class"`
+	Digest      string    `json:"digest"`
+	Target      string    `json:"target"`
+	Region      string    `json:"region"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	EnqueuedAt  time.Time `json:"enqueued_at"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	LagSeconds  float64   `json:"lag_seconds,omitempty"`
+}
+
+// ArtifactReplicationLag summarises replication for one artifact class.
+// Pending lag is the age of the oldest copy not yet verified.
+type ArtifactReplicationLag struct {
+	Class             string  `json:"class"`
+	Pending           int     `json:"pending"`
+	Verified          int     `json:"verified"`
+	Failed            int     `json:"failed"`
+	OldestPendingSecs float64 `json:"oldest_pending_seconds"`
+	AvgLagSeconds     float64 `json:"avg_lag_seconds"`
+	MaxLagSeconds     float64 `json:"max_lag_seconds"`
+}
+
+type ArtifactReplicationStore struct {
+	mu          sync.Mutex
+	nextID      int64
+	maxAttempts int
+	targets     map[string]*ArtifactReplicaTarget
+	items       []*ArtifactReplication
+	limit       int
+}
+
+func NewArtifactReplicationStore() *ArtifactReplicationStore {
+	return &ArtifactReplicationStore{
+		maxAttempts: 3,
+		targets:     map[string]*ArtifactReplicaTarget{},
+		limit:       10_000,
+	}
+}
+
+func (s *ArtifactReplicationStore) UpsertTarget(in ArtifactReplicaTargetInput) (ArtifactReplicaTarget, error) {
+	name := strings.ToLower(strings.TrimSpace(in.Name))
+	if name == "" {
+		return ArtifactReplicaTarget{}, errors.New("name is required")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return ArtifactReplicaTarget{}, errors.New("name must not contain path separators")
+	}
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	if kind == "" {
+		kind = "region"
+	}
+	if kind != "region" && kind != "relay" {
+		return ArtifactReplicaTarget{}, errors.New("kind must be region or relay")
+	}
+	region := strings.ToLower(strings.TrimSpace(in.Region))
+	if region == "" {
+		region = name
+	}
+	item := ArtifactReplicaTarget{
+		Name:      name,
+		Kind:      kind,
+		Region:    region,
+		Root:      strings.TrimSpace(in.Root),
+		UpdatedAt: time.Now().UTC(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets[name] = &item
+	return item, nil
+}
+
+func (s *ArtifactReplicationStore) DeleteTarget(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.targets[name]; !ok {
+		return errors.New("replica target not found")
+	}
+	delete(s.targets, name)
+	for _, item := range s.items {
+		if item.Target == name && item.Status == ArtifactReplicaPending {
+			item.Status = ArtifactReplicaFailed
+			item.Error = "replica target removed"
+		}
+	}
+	return nil
+}
+
+func (s *ArtifactReplicationStore) Target(name string) (ArtifactReplicaTarget, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.targets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return ArtifactReplicaTarget{}, false
+	}
+	return *item, true
+}
+
+func (s *ArtifactReplicationStore) Targets() []ArtifactReplicaTarget {
+	s.mu.Lock()
+	out := make([]ArtifactReplicaTarget, 0, len(s.targets))
+	for _, item := range s.targets {
+		out = append(out, *item)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Enqueue schedules a copy of the object to every target that does not
+// already hold, or is not already copying, this digest.
+func (s *ArtifactReplicationStore) Enqueue(objectKey, class, digest string) ([]ArtifactReplication, error) {
+	objectKey = strings.TrimSpace(objectKey)
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	class = strings.ToLower(strings.TrimSpace(class))
+	if objectKey == "" {
+		return nil, errors.New("object_key is required")
+	}
+	if digest == "" {
+		return nil, errors.New("digest is required")
+	}
+	if class == "" {
+		class = "generic"
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ArtifactReplication, 0, len(s.targets))
+	for _, target := range s.sortedTargetsLocked() {
+		if s.activeLocked(objectKey, target.Name, digest) {
+			continue
+		}
+		s.nextID++
+		item := &ArtifactReplication{
+			ID:         "artifact-replication-" + itoa(s.nextID),
+			ObjectKey:  objectKey,
+			Class:      class,
+			Digest:     digest,
+			Target:     target.Name,
+			Region:     target.Region,
+			Status:     ArtifactReplicaPending,
+			EnqueuedAt: now,
+		}
+		s.items = append(s.items, item)
+		out = append(out, *item)
+	}
+	if len(s.items) > s.limit {
+		s.items = append([]*ArtifactReplication{}, s.items[len(s.items)-s.limit:]...)
+	}
+	return out, nil
+}
+
+// Next claims the oldest pending copy for a replication worker.
+func (s *ArtifactReplicationStore) Next() (ArtifactReplication, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.items {
+		if item.Status != ArtifactReplicaPending {
+			continue
+		}
+		item.Status = ArtifactReplicaReplicating
+		item.Attempts++
+		return *item, true
+	}
+	return ArtifactReplication{}, false
+}
+
+// Complete records a worker's transfer result. A copy whose read-back digest
+// differs from the source is retried until it runs out of attempts.
+func (s *ArtifactReplicationStore) Complete(id, gotDigest string, transferErr error) (ArtifactReplication, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var item *ArtifactReplication
+	for _, cur := range s.items {
+		if cur.ID == id {
+			item = cur
+			break
+		}
+	}
+	if item == nil {
+		return ArtifactReplication{}, errors.New("replication not found")
+	}
+	if item.Status != ArtifactReplicaReplicating {
+		return ArtifactReplication{}, errors.New("replication is not in progress")
+	}
+	gotDigest = strings.ToLower(strings.TrimSpace(gotDigest))
+	switch {
+	case transferErr != nil:
+		item.Error = transferErr.Error()
+	case gotDigest != item.Digest:
+		item.Error = "digest mismatch after transfer: got " + gotDigest
+	default:
+		now := time.Now().UTC()
+		item.Status = ArtifactReplicaVerified
+		item.Error = ""
+		item.CompletedAt = now
+		item.LagSeconds = now.Sub(item.EnqueuedAt).Seconds()
+		return *item, nil
+	}
+	if item.Attempts >= s.maxAttempts {
+		item.Status = ArtifactReplicaFailed
+		item.CompletedAt = time.Now().UTC()
+	} else {
+		item.Status = ArtifactReplicaPending
+	}
+	return *item, nil
+}
+
+// List returns replications newest first, filtered by object key and status.
+func (s *ArtifactReplicationStore) List(objectKey, status string) []ArtifactReplication {
+	objectKey = strings.TrimSpace(objectKey)
+	status = strings.ToLower(strings.TrimSpace(status))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ArtifactReplication, 0)
+	for i := len(s.items) - 1; i >= 0; i-- {
+		item := s.items[i]
+		if (objectKey == "" || item.ObjectKey == objectKey) && (status == "" || item.Status == status) {
+			out = append(out, *item)
+		}
+	}
+	return out
+}
+
+// LocalReplica returns a verified replica of the object in region, if any,
+// so agents there can fetch without crossing regions.
+func (s *ArtifactReplicationStore) LocalReplica(objectKey, digest, region string) (ArtifactReplicaTarget, bool) {
+	objectKey = strings.TrimSpace(objectKey)
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	region = strings.ToLower(strings.TrimSpace(region))
+	if region == "" {
+		return ArtifactReplicaTarget{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.items) - 1; i >= 0; i-- {
+		item := s.items[i]
+		if item.ObjectKey != objectKey || item.Digest != digest || item.Status != ArtifactReplicaVerified || item.Region != region {
+			continue
+		}
+		if target, ok := s.targets[item.Target]; ok && target.Region == region {
+			return *target, true
+		}
+	}
+	return ArtifactReplicaTarget{}, false
+}
+
+// Lag reports replication progress and lag per artifact class.
+func (s *ArtifactReplicationStore) Lag(now time.Time) []ArtifactReplicationLag {
+	s.mu.Lock()
+	byClass := map[string]*ArtifactReplicationLag{}
+	totals := map[string]float64{}
+	for _, item := range s.items {
+		lag, ok := byClass[item.Class]
+		if !ok {
+			lag = &ArtifactReplicationLag{Class: item.Class}
+			byClass[item.Class] = lag
+		}
+		switch item.Status {
+		case ArtifactReplicaPending, ArtifactReplicaReplicating:
+			lag.Pending++
+			if age := now.Sub(item.EnqueuedAt).Seconds(); age > lag.OldestPendingSecs {
+				lag.OldestPendingSecs = age
+			}
+		case ArtifactReplicaVerified:
+			lag.Verified++
+			totals[item.Class] += item.LagSeconds
+			if item.LagSeconds > lag.MaxLagSeconds {
+				lag.MaxLagSeconds = item.LagSeconds
+			}
+		case ArtifactReplicaFailed:
+			lag.Failed++
+		}
+	}
+	s.mu.Unlock()
+	out := make([]ArtifactReplicationLag, 0, len(byClass))
+	for class, lag := range byClass {
+		if lag.Verified > 0 {
+			lag.AvgLagSeconds = totals[class] / float64(lag.Verified)
+		}
+		out = append(out, *lag)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Class < out[j].Class })
+	return out
+}
+
+func (s *ArtifactReplicationStore) activeLocked(objectKey, target, digest string) bool {
+	for _, item := range s.items {
+		if item.ObjectKey != objectKey || item.Target != target || item.Digest != digest {
+			continue
+		}
+		if item.Status != ArtifactReplicaFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ArtifactReplicationStore) sortedTargetsLocked() []ArtifactReplicaTarget {
+	out := make([]ArtifactReplicaTarget, 0, len(s.targets))
+	for _, item := range s.targets {
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
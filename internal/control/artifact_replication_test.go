@@ -0,0 +1,58 @@
+package control
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestArtifactReplicationStoreRetriesDigestMismatch(t *testing.T) {
+	s := NewArtifactReplicationStore()
+	if _, err := s.UpsertTarget(ArtifactReplicaTargetInput{Name: "../etc"}); err == nil {
+		t.Fatalf("expected path-like target name to be rejected")
+	}
+	if _, err := s.UpsertTarget(ArtifactReplicaTargetInput{Name: "EU-West-1"}); err != nil {
+		t.Fatalf("upsert target failed: %v", err)
+	}
+	items, err := s.Enqueue("packages/a.tgz", "", "sha256:aa")
+	if err != nil || len(items) != 1 || items[0].Class != "generic" || items[0].Region != "eu-west-1" {
+		t.Fatalf("unexpected enqueue: %+v err=%v", items, err)
+	}
+	if again, _ := s.Enqueue("packages/a.tgz", "", "sha256:aa"); len(again) != 0 {
+		t.Fatalf("expected duplicate enqueue to be skipped, got %+v", again)
+	}
+
+	item, ok := s.Next()
+	if !ok {
+		t.Fatalf("expected pending replication")
+	}
+	retry, _ := s.Complete(item.ID, "sha256:bb", nil)
+	if retry.Status != ArtifactReplicaPending || retry.Error == "" {
+		t.Fatalf("expected digest mismatch to be retried, got %+v", retry)
+	}
+	if _, ok := s.LocalReplica("packages/a.tgz", "sha256:aa", "eu-west-1"); ok {
+		t.Fatalf("expected unverified copy not to be served")
+	}
+	item, _ = s.Next()
+	_, _ = s.Complete(item.ID, "", errors.New("network down"))
+	item, _ = s.Next()
+	failed, _ := s.Complete(item.ID, "sha256:bb", nil)
+	if failed.Status != ArtifactReplicaFailed || failed.Attempts != 3 {
+		t.Fatalf("expected replication to fail after three attempts, got %+v", failed)
+	}
+
+	// A failed copy can be re-enqueued and then verifies.
+	items, _ = s.Enqueue("packages/a.tgz", "package", "sha256:aa")
+	item, _ = s.Next()
+	done, _ := s.Complete(item.ID, "SHA256:AA", nil)
+	if len(items) != 1 || done.Status != ArtifactReplicaVerified {
+		t.Fatalf("expected verified replica, got %+v", done)
+	}
+	if target, ok := s.LocalReplica("packages/a.tgz", "sha256:aa", "EU-WEST-1"); !ok || target.Name != "eu-west-1" {
+		t.Fatalf("expected local replica in eu-west-1, got %+v ok=%v", target, ok)
+	}
+	lag := s.Lag(time.Now().UTC())
+	if len(lag) != 2 || lag[0].Class != "generic" || lag[0].Failed != 1 || lag[1].Class != "package" || lag[1].Verified != 1 {
+		t.Fatalf("unexpected lag: %+v", lag)
+	}
+}
@@ -0,0 +1,579 @@
+package control
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = "cyclonedx"
+
+	// SBOMInternalEcosystem marks masterchef modules and providers, which
+	// no public vulnerability database tracks.
+	SBOMInternalEcosystem = "masterchef"
+)
+
+const (
+	ScanArtifactPackage = "package"
+	ScanArtifactImage   = "image"
+)
+
+const (
+	VulnSeverityNone     = "none"
+	VulnSeverityLow      = "low"
+	VulnSeverityMedium   = "medium"
+	VulnSeverityHigh     = "high"
+	VulnSeverityCritical = "critical"
+	VulnSeverityUnknown  = "unknown"
+)
+
+const artifactScanHistoryLimit = 20
+
+type SBOMComponent struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Ecosystem string `json:"ecosystem,omitempty"` // OSV ecosystem, e.g. PyPI, npm, Go, Debian
+	PURL      string `json:"purl,omitempty"`
+}
+
+type SBOMGenerateInput struct {
+	ArtifactType string          `json:"artifact_type"` // package|image
+	ArtifactID   string          `json:"artifact_id"`   // package artifact id or image bake pipeline id
+	Format       string          `json:"format,omitempty"`
+	Version      string          `json:"version,omitempty"`    // image build ref; packages use their own version
+	Components   []SBOMComponent `json:"components,omitempty"` // vendored or installed contents
+}
+
+type ArtifactSBOM struct {
+	ID           string          `json:"id"`
+	ArtifactType string          `json:"artifact_type"`
+	ArtifactID   string          `json:"artifact_id"`
+	Format       string          `json:"format"`
+	Digest       string          `json:"digest"`
+	Components   []SBOMComponent `json:"components"`
+	Document     json.RawMessage `json:"document"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type VulnerabilityFinding struct {
+	ID            string   `json:"id"`
+	Aliases       []string `json:"aliases,omitempty"`
+	Summary       string   `json:"summary,omitempty"`
+	Severity      string   `json:"severity"`
+	Component     string   `json:"component"`
+	Version       string   `json:"version"`
+	Ecosystem     string   `json:"ecosystem,omitempty"`
+	FixedVersions []string `json:"fixed_versions,omitempty"`
+}
+
+type ArtifactScan struct {
+	ID           string                 `json:"id"`
+	ArtifactType string                 `json:"artifact_type"`
+	ArtifactID   string                 `json:"artifact_id"`
+	SBOMID       string                 `json:"sbom_id"`
+	SBOMDigest   string                 `json:"sbom_digest"`
+	Feed         string                 `json:"feed"`
+	Findings     []VulnerabilityFinding `json:"findings"`
+	Counts       map[string]int         `json:"counts"` // severity -> findings
+	MaxSeverity  string                 `json:"max_severity"`
+	ScannedAt    time.Time              `json:"scanned_at"`
+}
+
+// VulnerabilityGatePolicy caps the worst finding an artifact may carry.
+// Unknown severities are treated as medium.
+type VulnerabilityGatePolicy struct {
+	MaxCertificationSeverity string    `json:"max_certification_severity"`
+	MaxPublicationSeverity   string    `json:"max_publication_severity"`
+	RequireScan              bool      `json:"require_scan"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
+
+// ArtifactScanStore generates SBOMs for package artifacts and baked images,
+// scans them against a vulnerability feed, and keeps the findings per
+// artifact for certification and publication gates.
+type ArtifactScanStore struct {
+	mu       sync.RWMutex
+	nextSBOM int64
+	nextScan int64
+	registry *PackageRegistryStore
+	images   *ImageBakeStore
+	feed     VulnerabilityFeed
+	policy   VulnerabilityGatePolicy
+	sboms    map[string]*ArtifactSBOM   // artifact key -> latest sbom
+	scans    map[string][]*ArtifactScan // artifact key -> scans, oldest first
+}
+
+// NewArtifactScanStore wires itself into registry so certification and
+// publication checks consult the latest scan.
+func NewArtifactScanStore(registry *PackageRegistryStore, images *ImageBakeStore, feed VulnerabilityFeed) *ArtifactScanStore {
+	s := &ArtifactScanStore{
+		registry: registry,
+		images:   images,
+		feed:     feed,
+		policy: VulnerabilityGatePolicy{
+			MaxCertificationSeverity: VulnSeverityMedium,
+			MaxPublicationSeverity:   VulnSeverityMedium,
+			UpdatedAt:                time.Now().UTC(),
+		},
+		sboms: map[string]*ArtifactSBOM{},
+		scans: map[string][]*ArtifactScan{},
+	}
+	if registry != nil {
+		registry.setVulnerabilityGate(s.gate)
+	}
+	return s
+}
+
+// SetFeed replaces the vulnerability feed used by later scans.
+func (s *ArtifactScanStore) SetFeed(feed VulnerabilityFeed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feed = feed
+}
+
+func (s *ArtifactScanStore) Policy() VulnerabilityGatePolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+func (s *ArtifactScanStore) SetPolicy(policy VulnerabilityGatePolicy) (VulnerabilityGatePolicy, error) {
+	for _, field := range []*string{&policy.MaxCertificationSeverity, &policy.MaxPublicationSeverity} {
+		if strings.TrimSpace(*field) == "" {
+			*field = VulnSeverityMedium
+			continue
+		}
+		severity := normalizeVulnerabilitySeverity(*field)
+		if severity == VulnSeverityUnknown {
+			return VulnerabilityGatePolicy{}, errors.New("severity thresholds must be none, low, medium, high, or critical")
+		}
+		*field = severity
+	}
+	policy.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	s.policy = policy
+	s.mu.Unlock()
+	return policy, nil
+}
+
+// GenerateSBOM builds an SPDX or CycloneDX document for an artifact. A
+// package's SBOM lists the package, its resolved dependencies, and any
+// supplied components; an image's lists the target image and the
+// components installed by the bake. Generating a package SBOM also fills
+// the artifact's provenance SBOM digest when none was published.
+func (s *ArtifactScanStore) GenerateSBOM(in SBOMGenerateInput) (ArtifactSBOM, error) {
+	artifactType := strings.ToLower(strings.TrimSpace(in.ArtifactType))
+	artifactID := strings.TrimSpace(in.ArtifactID)
+	if artifactID == "" {
+		return ArtifactSBOM{}, errors.New("artifact_id is required")
+	}
+	format := strings.ToLower(strings.TrimSpace(in.Format))
+	if format == "" {
+		format = SBOMFormatSPDX
+	}
+	if format != SBOMFormatSPDX && format != SBOMFormatCycloneDX {
+		return ArtifactSBOM{}, errors.New("format must be spdx or cyclonedx")
+	}
+	extra, err := normalizeSBOMComponents(in.Components)
+	if err != nil {
+		return ArtifactSBOM{}, err
+	}
+
+	var root SBOMComponent
+	components := []SBOMComponent{}
+	switch artifactType {
+	case ScanArtifactPackage:
+		artifact, ok := s.registry.GetArtifact(artifactID)
+		if !ok {
+			return ArtifactSBOM{}, errors.New("package artifact not found")
+		}
+		root = SBOMComponent{Name: artifact.Name, Version: artifact.Version, Ecosystem: SBOMInternalEcosystem}
+		if len(artifact.Dependencies) > 0 {
+			resolution, err := s.registry.Resolve(PackageResolveInput{Kind: artifact.Kind, Requirements: artifact.Dependencies})
+			if err != nil {
+				return ArtifactSBOM{}, errors.New("resolve dependencies: " + err.Error())
+			}
+			for _, dep := range resolution.Packages {
+				components = append(components, SBOMComponent{Name: dep.Name, Version: dep.Version, Ecosystem: SBOMInternalEcosystem})
+			}
+		}
+	case ScanArtifactImage:
+		pipeline, ok := s.images.Get(artifactID)
+		if !ok {
+			return ArtifactSBOM{}, errors.New("image bake pipeline not found")
+		}
+		if len(extra) == 0 {
+			return ArtifactSBOM{}, errors.New("components are required for image sboms")
+		}
+		version := strings.TrimSpace(in.Version)
+		if version == "" {
+			version = "latest"
+		}
+		root = SBOMComponent{Name: pipeline.TargetImage, Version: version, PURL: "pkg:oci/" + pipeline.TargetImage + "@" + version}
+	default:
+		return ArtifactSBOM{}, errors.New("artifact_type must be package or image")
+	}
+	if root.PURL == "" {
+		root.PURL = sbomPURL(root)
+	}
+	for i := range components {
+		components[i].PURL = sbomPURL(components[i])
+	}
+	components = append(components, extra...)
+
+	now := time.Now().UTC()
+	var doc any
+	if format == SBOMFormatSPDX {
+		doc = spdxDocument(artifactType, artifactID, root, components, now)
+	} else {
+		doc = cycloneDXDocument(root, components, now)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return ArtifactSBOM{}, err
+	}
+	sum := sha256.Sum256(raw)
+	item := ArtifactSBOM{
+		ArtifactType: artifactType,
+		ArtifactID:   artifactID,
+		Format:       format,
+		Digest:       "sha256:" + hex.EncodeToString(sum[:]),
+		Components:   append([]SBOMComponent{root}, components...),
+		Document:     raw,
+		CreatedAt:    now,
+	}
+	s.mu.Lock()
+	s.nextSBOM++
+	item.ID = "sbom-" + itoa(s.nextSBOM)
+	s.sboms[scanArtifactKey(artifactType, artifactID)] = &item
+	s.mu.Unlock()
+	if artifactType == ScanArtifactPackage {
+		s.registry.attachSBOMDigest(artifactID, item.Digest)
+	}
+	return cloneArtifactSBOM(item), nil
+}
+
+func (s *ArtifactScanStore) GetSBOM(artifactType, artifactID string) (ArtifactSBOM, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.sboms[scanArtifactKey(artifactType, artifactID)]
+	if !ok {
+		return ArtifactSBOM{}, false
+	}
+	return cloneArtifactSBOM(*item), true
+}
+
+// Scan matches the artifact's latest SBOM against the feed and records the
+// findings.
+func (s *ArtifactScanStore) Scan(ctx context.Context, artifactType, artifactID string) (ArtifactScan, error) {
+	key := scanArtifactKey(artifactType, artifactID)
+	s.mu.RLock()
+	sbom, ok := s.sboms[key]
+	feed := s.feed
+	s.mu.RUnlock()
+	if !ok {
+		return ArtifactScan{}, errors.New("generate an sbom before scanning")
+	}
+	if feed == nil {
+		return ArtifactScan{}, errors.New("vulnerability feed is not configured")
+	}
+	findings, err := feed.Query(ctx, sbom.Components)
+	if err != nil {
+		return ArtifactScan{}, err
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		ri, rj := vulnerabilitySeverityRank(findings[i].Severity), vulnerabilitySeverityRank(findings[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		return findings[i].ID < findings[j].ID
+	})
+	scan := ArtifactScan{
+		ArtifactType: sbom.ArtifactType,
+		ArtifactID:   sbom.ArtifactID,
+		SBOMID:       sbom.ID,
+		SBOMDigest:   sbom.Digest,
+		Feed:         feed.Name(),
+		Findings:     findings,
+		Counts:       map[string]int{},
+		MaxSeverity:  VulnSeverityNone,
+		ScannedAt:    time.Now().UTC(),
+	}
+	for _, finding := range findings {
+		scan.Counts[finding.Severity]++
+		if vulnerabilitySeverityRank(finding.Severity) > vulnerabilitySeverityRank(scan.MaxSeverity) {
+			scan.MaxSeverity = finding.Severity
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextScan++
+	scan.ID = "vuln-scan-" + itoa(s.nextScan)
+	history := append(s.scans[key], &scan)
+	if len(history) > artifactScanHistoryLimit {
+		history = history[len(history)-artifactScanHistoryLimit:]
+	}
+	s.scans[key] = history
+	return cloneArtifactScan(scan), nil
+}
+
+// LatestScan returns the most recent scan of an artifact.
+func (s *ArtifactScanStore) LatestScan(artifactType, artifactID string) (ArtifactScan, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := s.scans[scanArtifactKey(artifactType, artifactID)]
+	if len(history) == 0 {
+		return ArtifactScan{}, false
+	}
+	return cloneArtifactScan(*history[len(history)-1]), true
+}
+
+// ListScans returns an artifact's scans, newest first.
+func (s *ArtifactScanStore) ListScans(artifactType, artifactID string) []ArtifactScan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := s.scans[scanArtifactKey(artifactType, artifactID)]
+	out := make([]ArtifactScan, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		out = append(out, cloneArtifactScan(*history[i]))
+	}
+	return out
+}
+
+// Gate explains why an artifact's latest scan fails the certification or
+// publication threshold; an empty result means it passes.
+func (s *ArtifactScanStore) Gate(artifactType, artifactID, stage string) []string {
+	_, reasons := s.gate(scanArtifactKey(artifactType, artifactID), stage)
+	return reasons
+}
+
+// gate must not call back into the package registry: the registry invokes
+// it while holding its own lock.
+func (s *ArtifactScanStore) gate(key, stage string) (ArtifactScan, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	threshold := s.policy.MaxPublicationSeverity
+	if stage == "certification" {
+		threshold = s.policy.MaxCertificationSeverity
+	}
+	history := s.scans[key]
+	if len(history) == 0 {
+		if s.policy.RequireScan {
+			return ArtifactScan{}, []string{"vulnerability scan is required for " + stage}
+		}
+		return ArtifactScan{}, nil
+	}
+	latest := history[len(history)-1]
+	if vulnerabilitySeverityRank(latest.MaxSeverity) > vulnerabilitySeverityRank(threshold) {
+		return cloneArtifactScan(*latest), []string{fmt.Sprintf("%s vulnerabilities exceed %s threshold %s", latest.MaxSeverity, stage, threshold)}
+	}
+	return cloneArtifactScan(*latest), nil
+}
+
+func scanArtifactKey(artifactType, artifactID string) string {
+	return strings.ToLower(strings.TrimSpace(artifactType)) + "/" + strings.TrimSpace(artifactID)
+}
+
+func normalizeSBOMComponents(in []SBOMComponent) ([]SBOMComponent, error) {
+	out := make([]SBOMComponent, 0, len(in))
+	seen := map[string]struct{}{}
+	for _, item := range in {
+		item.Name = strings.TrimSpace(item.Name)
+		item.Version = strings.TrimSpace(item.Version)
+		item.Ecosystem = strings.TrimSpace(item.Ecosystem)
+		item.PURL = strings.TrimSpace(item.PURL)
+		if item.Name == "" || item.Version == "" {
+			return nil, errors.New("component name and version are required")
+		}
+		key := item.Ecosystem + "/" + item.Name + "@" + item.Version
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if item.PURL == "" {
+			item.PURL = sbomPURL(item)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// sbomPURL maps OSV ecosystems onto package URL types.
+func sbomPURL(c SBOMComponent) string {
+	purlType := "generic"
+	switch strings.ToLower(c.Ecosystem) {
+	case "npm":
+		purlType = "npm"
+	case "pypi":
+		purlType = "pypi"
+	case "go":
+		purlType = "golang"
+	case "maven":
+		purlType = "maven"
+	case "crates.io":
+		purlType = "cargo"
+	case "rubygems":
+		purlType = "gem"
+	case "nuget":
+		purlType = "nuget"
+	case "debian", "ubuntu":
+		purlType = "deb"
+	case "alpine":
+		purlType = "apk"
+	}
+	return "pkg:" + purlType + "/" + c.Name + "@" + c.Version
+}
+
+func spdxDocument(artifactType, artifactID string, root SBOMComponent, components []SBOMComponent, now time.Time) map[string]any {
+	spdxPackage := func(id string, c SBOMComponent) map[string]any {
+		return map[string]any{
+			"SPDXID":           id,
+			"name":             c.Name,
+			"versionInfo":      c.Version,
+			"downloadLocation": "NOASSERTION",
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  c.PURL,
+			}},
+		}
+	}
+	packages := []map[string]any{spdxPackage("SPDXRef-Root", root)}
+	relationships := []map[string]string{{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": "SPDXRef-Root",
+	}}
+	for i, c := range components {
+		id := "SPDXRef-Component-" + itoa(int64(i+1))
+		packages = append(packages, spdxPackage(id, c))
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-Root",
+			"relationshipType":   "DEPENDS_ON",
+			"relatedSpdxElement": id,
+		})
+	}
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              root.Name + "@" + root.Version,
+		"documentNamespace": "https://masterchef.dev/spdx/" + artifactType + "/" + artifactID + "/" + now.Format("20060102T150405.000000000Z"),
+		"creationInfo": map[string]any{
+			"created":  now.Format(time.RFC3339),
+			"creators": []string{"Tool: masterchef"},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+func cycloneDXDocument(root SBOMComponent, components []SBOMComponent, now time.Time) map[string]any {
+	component := func(c SBOMComponent, kind string) map[string]any {
+		return map[string]any{
+			"type":    kind,
+			"bom-ref": c.PURL,
+			"name":    c.Name,
+			"version": c.Version,
+			"purl":    c.PURL,
+		}
+	}
+	rootKind := "library"
+	if strings.HasPrefix(root.PURL, "pkg:oci/") {
+		rootKind = "container"
+	}
+	list := make([]map[string]any, 0, len(components))
+	refs := make([]string, 0, len(components))
+	for _, c := range components {
+		list = append(list, component(c, "library"))
+		refs = append(refs, c.PURL)
+	}
+	return map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + sbomSerial(root, now),
+		"version":      1,
+		"metadata": map[string]any{
+			"timestamp": now.Format(time.RFC3339),
+			"tools":     []map[string]string{{"name": "masterchef"}},
+			"component": component(root, rootKind),
+		},
+		"components":   list,
+		"dependencies": []map[string]any{{"ref": root.PURL, "dependsOn": refs}},
+	}
+}
+
+// sbomSerial derives a version 4 style UUID from the root and timestamp.
+func sbomSerial(root SBOMComponent, now time.Time) string {
+	sum := sha256.Sum256([]byte(root.PURL + "|" + now.Format(time.RFC3339Nano)))
+	sum[6] = (sum[6] & 0x0f) | 0x40
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+func normalizeVulnerabilitySeverity(in string) string {
+	switch strings.ToLower(strings.TrimSpace(in)) {
+	case "none":
+		return VulnSeverityNone
+	case "low", "negligible":
+		return VulnSeverityLow
+	case "medium", "moderate":
+		return VulnSeverityMedium
+	case "high", "important":
+		return VulnSeverityHigh
+	case "critical":
+		return VulnSeverityCritical
+	default:
+		return VulnSeverityUnknown
+	}
+}
+
+func vulnerabilitySeverityRank(severity string) int {
+	switch severity {
+	case VulnSeverityNone:
+		return 0
+	case VulnSeverityLow:
+		return 1
+	case VulnSeverityMedium, VulnSeverityUnknown:
+		return 2
+	case VulnSeverityHigh:
+		return 3
+	case VulnSeverityCritical:
+		return 4
+	default:
+		return 2
+	}
+}
+
+func cloneArtifactSBOM(in ArtifactSBOM) ArtifactSBOM {
+	out := in
+	out.Components = append([]SBOMComponent{}, in.Components...)
+	out.Document = append(json.RawMessage{}, in.Document...)
+	return out
+}
+
+func cloneArtifactScan(in ArtifactScan) ArtifactScan {
+	out := in
+	out.Findings = make([]VulnerabilityFinding, 0, len(in.Findings))
+	for _, finding := range in.Findings {
+		finding.Aliases = append([]string{}, finding.Aliases...)
+		finding.FixedVersions = append([]string{}, finding.FixedVersions...)
+		out.Findings = append(out.Findings, finding)
+	}
+	out.Counts = map[string]int{}
+	for k, v := range in.Counts {
+		out.Counts[k] = v
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestArtifactScanStoreGatesPackageOnFindings(t *testing.T) {
+	osv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q osvQuery
+		_ = json.NewDecoder(r.Body).Decode(&q)
+		if q.Package.Ecosystem == SBOMInternalEcosystem {
+			t.Errorf("internal component %s should not be sent to osv", q.Package.Name)
+		}
+		if q.Package.Name != "requests" {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"vulns":[{"id":"GHSA-1234","aliases":["CVE-2024-1"],"summary":"leak","database_specific":{"severity":"HIGH"},
+			"affected":[{"package":{"name":"requests","ecosystem":"PyPI"},"ranges":[{"events":[{"introduced":"0"},{"fixed":"2.32.0"}]}]}]}]}`))
+	}))
+	defer osv.Close()
+
+	registry := NewPackageRegistryStore()
+	scans := NewArtifactScanStore(registry, NewImageBakeStore(), NewOSVFeed(osv.URL, osv.Client()))
+	dep, err := registry.Publish(PackageArtifactInput{Kind: "module", Name: "core/base", Version: "1.0.0", Digest: "sha256:" + strings.Repeat("a", 64), Signed: true, KeyID: "k", Signature: "s"})
+	if err != nil {
+		t.Fatalf("publish dependency failed: %v", err)
+	}
+	artifact, err := registry.Publish(PackageArtifactInput{
+		Kind: "module", Name: "core/app", Version: "2.0.0", Digest: "sha256:" + strings.Repeat("b", 64),
+		Visibility: "public", Signed: true, KeyID: "k", Signature: "s",
+		Dependencies: map[string]string{"core/base": ">= 1.0.0"},
+		Provenance:   PackageProvenance{AttestationDigest: "sha256:att"},
+	})
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	if _, err := scans.Scan(context.Background(), ScanArtifactPackage, artifact.ID); err == nil {
+		t.Fatalf("expected scan without sbom to fail")
+	}
+	sbom, err := scans.GenerateSBOM(SBOMGenerateInput{
+		ArtifactType: ScanArtifactPackage,
+		ArtifactID:   artifact.ID,
+		Format:       SBOMFormatCycloneDX,
+		Components:   []SBOMComponent{{Name: "requests", Version: "2.31.0", Ecosystem: "PyPI"}},
+	})
+	if err != nil {
+		t.Fatalf("generate sbom failed: %v", err)
+	}
+	if len(sbom.Components) != 3 || sbom.Components[1].Name != dep.Name || sbom.Components[2].PURL != "pkg:pypi/requests@2.31.0" {
+		t.Fatalf("unexpected sbom components %+v", sbom.Components)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(sbom.Document, &doc); err != nil || doc["bomFormat"] != "CycloneDX" {
+		t.Fatalf("expected cyclonedx document, got %s", sbom.Document)
+	}
+	if got, _ := registry.GetArtifact(artifact.ID); got.Provenance.SBOMDigest != sbom.Digest {
+		t.Fatalf("expected sbom digest to be attached to provenance, got %q", got.Provenance.SBOMDigest)
+	}
+
+	scan, err := scans.Scan(context.Background(), ScanArtifactPackage, artifact.ID)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if scan.MaxSeverity != VulnSeverityHigh || scan.Counts[VulnSeverityHigh] != 1 || len(scan.Findings) != 1 {
+		t.Fatalf("unexpected scan %+v", scan)
+	}
+	if fixed := scan.Findings[0].FixedVersions; len(fixed) != 1 || fixed[0] != "2.32.0" {
+		t.Fatalf("expected fixed version 2.32.0, got %+v", fixed)
+	}
+
+	report, err := registry.Certify(PackageCertificationInput{ArtifactID: artifact.ID, ConformancePassed: true, TestPassRate: 1, MaintainerScore: 100})
+	if err != nil {
+		t.Fatalf("certify failed: %v", err)
+	}
+	if report.Certified || report.HighVulnerabilities != 1 {
+		t.Fatalf("expected scan findings to block certification, got %+v", report)
+	}
+	check := registry.PublicationGateCheck(PackagePublicationCheckInput{ArtifactID: artifact.ID, Target: "public"})
+	if check.Allowed || !containsString(check.Reasons, "high vulnerabilities exceed publication threshold medium") {
+		t.Fatalf("expected publication to be gated on severity, got %+v", check.Reasons)
+	}
+
+	if _, err := scans.SetPolicy(VulnerabilityGatePolicy{MaxCertificationSeverity: "high", MaxPublicationSeverity: "high"}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if _, err := registry.SetCertificationPolicy(PackageCertificationPolicy{RequireConformance: true, MaxHighVulns: 1, RequireSigned: true}); err != nil {
+		t.Fatalf("set certification policy failed: %v", err)
+	}
+	report, err = registry.Certify(PackageCertificationInput{ArtifactID: artifact.ID, ConformancePassed: true, TestPassRate: 1, MaintainerScore: 100})
+	if err != nil || !report.Certified {
+		t.Fatalf("expected certification under relaxed thresholds, got %+v err=%v", report, err)
+	}
+	if check := registry.PublicationGateCheck(PackagePublicationCheckInput{ArtifactID: artifact.ID, Target: "public"}); !check.Allowed {
+		t.Fatalf("expected publication to pass, got %+v", check.Reasons)
+	}
+}
+
+func TestArtifactScanStoreImagesAndRequiredScans(t *testing.T) {
+	images := NewImageBakeStore()
+	pipeline, err := images.Create(ImageBakePipelineInput{Environment: "prod", Name: "web", Builder: "packer", BaseImage: "ubuntu:24.04", TargetImage: "registry.local/web"})
+	if err != nil {
+		t.Fatalf("create pipeline failed: %v", err)
+	}
+	scans := NewArtifactScanStore(NewPackageRegistryStore(), images, nil)
+	if _, err := scans.GenerateSBOM(SBOMGenerateInput{ArtifactType: ScanArtifactImage, ArtifactID: pipeline.ID}); err == nil {
+		t.Fatalf("expected image sbom without components to fail")
+	}
+	sbom, err := scans.GenerateSBOM(SBOMGenerateInput{
+		ArtifactType: ScanArtifactImage,
+		ArtifactID:   pipeline.ID,
+		Version:      "build-42",
+		Components:   []SBOMComponent{{Name: "openssl", Version: "3.0.2-0ubuntu1", Ecosystem: "Ubuntu"}},
+	})
+	if err != nil {
+		t.Fatalf("generate image sbom failed: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(sbom.Document, &doc); err != nil || doc["spdxVersion"] != "SPDX-2.3" {
+		t.Fatalf("expected spdx document by default, got %s", sbom.Document)
+	}
+	if _, err := scans.Scan(context.Background(), ScanArtifactImage, pipeline.ID); err == nil {
+		t.Fatalf("expected scan without feed to fail")
+	}
+
+	if reasons := scans.Gate(ScanArtifactImage, pipeline.ID, "publication"); len(reasons) != 0 {
+		t.Fatalf("expected unscanned image to pass when scans are optional, got %v", reasons)
+	}
+	if _, err := scans.SetPolicy(VulnerabilityGatePolicy{RequireScan: true}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if reasons := scans.Gate(ScanArtifactImage, pipeline.ID, "publication"); len(reasons) != 1 {
+		t.Fatalf("expected missing scan to block, got %v", reasons)
+	}
+	if _, err := scans.SetPolicy(VulnerabilityGatePolicy{MaxPublicationSeverity: "severe"}); err == nil {
+		t.Fatalf("expected invalid severity to fail")
+	}
+}
@@ -9,19 +9,21 @@ import (
 )
 
 type Association struct {
-	ID         string        `json:"id"`
-	ConfigPath string        `json:"config_path"`
-	TargetKind string        `json:"target_kind"`
-	TargetName string        `json:"target_name"`
-	Priority   string        `json:"priority"`
-	Backend    string        `json:"scheduler_backend,omitempty"`
-	Interval   time.Duration `json:"interval"`
-	Jitter     time.Duration `json:"jitter"`
-	Enabled    bool          `json:"enabled"`
-	ScheduleID string        `json:"schedule_id"`
-	Revision   int           `json:"revision"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
+	ID                  string        `json:"id"`
+	ConfigPath          string        `json:"config_path"`
+	TargetKind          string        `json:"target_kind"`
+	TargetName          string        `json:"target_name"`
+	Priority            string        `json:"priority"`
+	Backend             string        `json:"scheduler_backend,omitempty"`
+	Interval            time.Duration `json:"interval"`
+	Jitter              time.Duration `json:"jitter"`
+	Enabled             bool          `json:"enabled"`
+	ScheduleID          string        `json:"schedule_id"`
+	PolicyBundleID      string        `json:"policy_bundle_id,omitempty"`
+	PolicyBundleVersion string        `json:"policy_bundle_version,omitempty"`
+	Revision            int           `json:"revision"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
 }
 
 type AssociationRevision struct {
@@ -32,14 +34,16 @@ type AssociationRevision struct {
 }
 
 type AssociationCreate struct {
-	ConfigPath string
-	TargetKind string
-	TargetName string
-	Priority   string
-	Backend    string
-	Interval   time.Duration
-	Jitter     time.Duration
-	Enabled    bool
+	ConfigPath          string
+	TargetKind          string
+	TargetName          string
+	Priority            string
+	Backend             string
+	Interval            time.Duration
+	Jitter              time.Duration
+	Enabled             bool
+	PolicyBundleID      string
+	PolicyBundleVersion string
 }
 
 type AssociationStore struct {
@@ -91,19 +95,21 @@ func (s *AssociationStore) Create(in AssociationCreate) (Association, error) {
 	s.nextID++
 	id := "assoc-" + itoa(s.nextID)
 	assoc := &Association{
-		ID:         id,
-		ConfigPath: in.ConfigPath,
-		TargetKind: kind,
-		TargetName: in.TargetName,
-		Priority:   normalizePriority(in.Priority),
-		Backend:    strings.ToLower(strings.TrimSpace(in.Backend)),
-		Interval:   in.Interval,
-		Jitter:     in.Jitter,
-		Enabled:    in.Enabled,
-		ScheduleID: sc.ID,
-		Revision:   1,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:                  id,
+		ConfigPath:          in.ConfigPath,
+		TargetKind:          kind,
+		TargetName:          in.TargetName,
+		Priority:            normalizePriority(in.Priority),
+		Backend:             strings.ToLower(strings.TrimSpace(in.Backend)),
+		Interval:            in.Interval,
+		Jitter:              in.Jitter,
+		Enabled:             in.Enabled,
+		ScheduleID:          sc.ID,
+		PolicyBundleID:      strings.TrimSpace(in.PolicyBundleID),
+		PolicyBundleVersion: strings.TrimSpace(in.PolicyBundleVersion),
+		Revision:            1,
+		CreatedAt:           now,
+		UpdatedAt:           now,
 	}
 	s.items[id] = assoc
 	s.history[id] = append(s.history[id], AssociationRevision{
@@ -0,0 +1,375 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BackupSetAvailable = "available"
+	BackupSetPruned    = "pruned"
+
+	BackupOffsiteSkipped  = "skipped"
+	BackupOffsiteVerified = "verified"
+	BackupOffsiteFailed   = "failed"
+)
+
+type BackupScheduleInput struct {
+	Name                string `json:"name"`
+	Tenant              string `json:"tenant"`
+	IntervalMinutes     int    `json:"interval_minutes"`
+	IncludeRuns         bool   `json:"include_runs"`
+	IncludeEvents       bool   `json:"include_events"`
+	IncludeControlPlane bool   `json:"include_control_plane"`
+	Prefix              string `json:"prefix,omitempty"`
+	RetainCount         int    `json:"retain_count,omitempty"`
+	RetainDays          int    `json:"retain_days,omitempty"`
+	Offsite             bool   `json:"offsite,omitempty"`
+	// VerifyEvery runs a restore-verification drill on every Nth backup.
+	VerifyEvery      int `json:"verify_every,omitempty"`
+	TargetRTOSeconds int `json:"target_rto_seconds,omitempty"`
+}
+
+// BackupSchedule takes an encrypted backup of the control plane every
+// interval, keeps the newest RetainCount sets (and none older than
+// RetainDays), and optionally copies each set offsite.
+type BackupSchedule struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Tenant              string    `json:"tenant"`
+	IntervalMinutes     int       `json:"interval_minutes"`
+	IncludeRuns         bool      `json:"include_runs"`
+	IncludeEvents       bool      `json:"include_events"`
+	IncludeControlPlane bool      `json:"include_control_plane"`
+	Prefix              string    `json:"prefix"`
+	RetainCount         int       `json:"retain_count"`
+	RetainDays          int       `json:"retain_days,omitempty"`
+	Offsite             bool      `json:"offsite"`
+	VerifyEvery         int       `json:"verify_every"`
+	TargetRTOSeconds    int       `json:"target_rto_seconds"`
+	Enabled             bool      `json:"enabled"`
+	Runs                int       `json:"runs"`
+	LastRunAt           time.Time `json:"last_run_at,omitempty"`
+	LastStatus          string    `json:"last_status,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	NextRunAt           time.Time `json:"next_run_at"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// BackupVerification is the result of restoring a backup set into memory
+// and comparing it with what was captured.
+type BackupVerification struct {
+	Passed     bool      `json:"passed"`
+	Source     string    `json:"source"` // primary|offsite
+	Runs       int       `json:"runs"`
+	Events     int       `json:"events"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	DrillID    string    `json:"drill_id,omitempty"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+type BackupSet struct {
+	ID            string              `json:"id"`
+	ScheduleID    string              `json:"schedule_id"`
+	Tenant        string              `json:"tenant"`
+	KeyID         string              `json:"key_id"`
+	ObjectKey     string              `json:"object_key"`
+	Digest        string              `json:"digest"`
+	SizeBytes     int64               `json:"size_bytes"`
+	Runs          int                 `json:"runs"`
+	Events        int                 `json:"events"`
+	OffsiteStatus string              `json:"offsite_status"`
+	OffsiteKey    string              `json:"offsite_key,omitempty"`
+	OffsiteError  string              `json:"offsite_error,omitempty"`
+	Verification  *BackupVerification `json:"verification,omitempty"`
+	Status        string              `json:"status"`
+	CreatedAt     time.Time           `json:"created_at"`
+	PrunedAt      time.Time           `json:"pruned_at,omitempty"`
+}
+
+type BackupScheduleStore struct {
+	mu           sync.Mutex
+	nextSchedule int64
+	nextSet      int64
+	schedules    map[string]*BackupSchedule
+	sets         []*BackupSet
+}
+
+func NewBackupScheduleStore() *BackupScheduleStore {
+	return &BackupScheduleStore{schedules: map[string]*BackupSchedule{}}
+}
+
+func (s *BackupScheduleStore) Create(in BackupScheduleInput) (BackupSchedule, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return BackupSchedule{}, errors.New("name is required")
+	}
+	tenant := strings.ToLower(strings.TrimSpace(in.Tenant))
+	if tenant == "" {
+		return BackupSchedule{}, errors.New("tenant is required to select the backup encryption key")
+	}
+	if in.IntervalMinutes <= 0 {
+		return BackupSchedule{}, errors.New("interval_minutes must be greater than zero")
+	}
+	if in.RetainCount < 0 || in.RetainDays < 0 || in.VerifyEvery < 0 {
+		return BackupSchedule{}, errors.New("retention and verify_every must not be negative")
+	}
+	if !in.IncludeRuns && !in.IncludeEvents && !in.IncludeControlPlane {
+		in.IncludeRuns, in.IncludeEvents, in.IncludeControlPlane = true, true, true
+	}
+	retain := in.RetainCount
+	if retain == 0 {
+		retain = 7
+	}
+	verifyEvery := in.VerifyEvery
+	if verifyEvery == 0 {
+		verifyEvery = 1
+	}
+	rto := in.TargetRTOSeconds
+	if rto <= 0 {
+		rto = 300
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSchedule++
+	item := &BackupSchedule{
+		ID:                  "backup-schedule-" + itoa(s.nextSchedule),
+		Name:                name,
+		Tenant:              tenant,
+		IntervalMinutes:     in.IntervalMinutes,
+		IncludeRuns:         in.IncludeRuns,
+		IncludeEvents:       in.IncludeEvents,
+		IncludeControlPlane: in.IncludeControlPlane,
+		Prefix:              strings.Trim(strings.TrimSpace(in.Prefix), "/"),
+		RetainCount:         retain,
+		RetainDays:          in.RetainDays,
+		Offsite:             in.Offsite,
+		VerifyEvery:         verifyEvery,
+		TargetRTOSeconds:    rto,
+		Enabled:             true,
+		NextRunAt:           now,
+		CreatedAt:           now,
+	}
+	if item.Prefix == "" {
+		item.Prefix = "backups/scheduled/" + item.ID
+	}
+	s.schedules[item.ID] = item
+	return *item, nil
+}
+
+func (s *BackupScheduleStore) Get(id string) (BackupSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.schedules[strings.TrimSpace(id)]
+	if !ok {
+		return BackupSchedule{}, errors.New("backup schedule not found")
+	}
+	return *item, nil
+}
+
+func (s *BackupScheduleStore) List() []BackupSchedule {
+	s.mu.Lock()
+	out := make([]BackupSchedule, 0, len(s.schedules))
+	for _, item := range s.schedules {
+		out = append(out, *item)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *BackupScheduleStore) SetEnabled(id string, enabled bool) (BackupSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.schedules[strings.TrimSpace(id)]
+	if !ok {
+		return BackupSchedule{}, errors.New("backup schedule not found")
+	}
+	item.Enabled = enabled
+	if enabled && item.NextRunAt.Before(time.Now().UTC()) {
+		item.NextRunAt = time.Now().UTC()
+	}
+	return *item, nil
+}
+
+func (s *BackupScheduleStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[strings.TrimSpace(id)]; !ok {
+		return errors.New("backup schedule not found")
+	}
+	delete(s.schedules, strings.TrimSpace(id))
+	return nil
+}
+
+// Due returns enabled schedules whose next run has passed and advances them
+// by one interval so a slow backup is not started twice.
+func (s *BackupScheduleStore) Due(now time.Time) []BackupSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]BackupSchedule, 0)
+	for _, item := range s.schedules {
+		if !item.Enabled || item.NextRunAt.After(now) {
+			continue
+		}
+		item.NextRunAt = now.Add(time.Duration(item.IntervalMinutes) * time.Minute)
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// RecordRun notes the outcome of one scheduled backup and reports whether
+// this run is due a restore-verification drill.
+func (s *BackupScheduleStore) RecordRun(id string, runErr error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.schedules[strings.TrimSpace(id)]
+	if !ok {
+		return false, errors.New("backup schedule not found")
+	}
+	item.LastRunAt = time.Now().UTC()
+	if runErr != nil {
+		item.LastStatus = "failed"
+		item.LastError = runErr.Error()
+		return false, nil
+	}
+	item.Runs++
+	item.LastStatus = "succeeded"
+	item.LastError = ""
+	return item.Runs%item.VerifyEvery == 0, nil
+}
+
+func (s *BackupScheduleStore) AddSet(set BackupSet) BackupSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSet++
+	set.ID = "backup-set-" + itoa(s.nextSet)
+	set.Status = BackupSetAvailable
+	if set.OffsiteStatus == "" {
+		set.OffsiteStatus = BackupOffsiteSkipped
+	}
+	if set.CreatedAt.IsZero() {
+		set.CreatedAt = time.Now().UTC()
+	}
+	s.sets = append(s.sets, &set)
+	return set
+}
+
+func (s *BackupScheduleStore) GetSet(id string) (BackupSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, set := range s.sets {
+		if set.ID == strings.TrimSpace(id) {
+			return cloneBackupSet(set), nil
+		}
+	}
+	return BackupSet{}, errors.New("backup set not found")
+}
+
+// Sets lists backup sets newest first, optionally for one schedule.
+func (s *BackupScheduleStore) Sets(scheduleID string) []BackupSet {
+	scheduleID = strings.TrimSpace(scheduleID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]BackupSet, 0)
+	for i := len(s.sets) - 1; i >= 0; i-- {
+		if scheduleID == "" || s.sets[i].ScheduleID == scheduleID {
+			out = append(out, cloneBackupSet(s.sets[i]))
+		}
+	}
+	return out
+}
+
+func (s *BackupScheduleStore) MarkOffsite(id, key string, copyErr error) (BackupSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, set := range s.sets {
+		if set.ID != id {
+			continue
+		}
+		set.OffsiteKey = key
+		if copyErr != nil {
+			set.OffsiteStatus = BackupOffsiteFailed
+			set.OffsiteError = copyErr.Error()
+		} else {
+			set.OffsiteStatus = BackupOffsiteVerified
+			set.OffsiteError = ""
+		}
+		return cloneBackupSet(set), nil
+	}
+	return BackupSet{}, errors.New("backup set not found")
+}
+
+func (s *BackupScheduleStore) MarkVerified(id string, v BackupVerification) (BackupSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, set := range s.sets {
+		if set.ID != id {
+			continue
+		}
+		if v.VerifiedAt.IsZero() {
+			v.VerifiedAt = time.Now().UTC()
+		}
+		set.Verification = &v
+		return cloneBackupSet(set), nil
+	}
+	return BackupSet{}, errors.New("backup set not found")
+}
+
+// Prune applies the schedule's retention, marking sets beyond RetainCount or
+// older than RetainDays as pruned, and returns them so their objects can be
+// deleted. The newest verified set is always kept.
+func (s *BackupScheduleStore) Prune(scheduleID string, now time.Time) []BackupSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[strings.TrimSpace(scheduleID)]
+	if !ok {
+		return nil
+	}
+	available := make([]*BackupSet, 0)
+	for i := len(s.sets) - 1; i >= 0; i-- {
+		set := s.sets[i]
+		if set.ScheduleID == schedule.ID && set.Status == BackupSetAvailable {
+			available = append(available, set)
+		}
+	}
+	var keep *BackupSet
+	for _, set := range available {
+		if set.Verification != nil && set.Verification.Passed {
+			keep = set
+			break
+		}
+	}
+	cutoff := time.Time{}
+	if schedule.RetainDays > 0 {
+		cutoff = now.Add(-time.Duration(schedule.RetainDays) * 24 * time.Hour)
+	}
+	out := make([]BackupSet, 0)
+	for i, set := range available {
+		if set == keep {
+			continue
+		}
+		if i < schedule.RetainCount && (cutoff.IsZero() || !set.CreatedAt.Before(cutoff)) {
+			continue
+		}
+		set.Status = BackupSetPruned
+		set.PrunedAt = now
+		out = append(out, cloneBackupSet(set))
+	}
+	return out
+}
+
+func cloneBackupSet(in *BackupSet) BackupSet {
+	out := *in
+	if in.Verification != nil {
+		v := *in.Verification
+		out.Verification = &v
+	}
+	return out
+}
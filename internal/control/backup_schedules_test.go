@@ -0,0 +1,47 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackupScheduleStoreDueVerifyCadenceAndRetention(t *testing.T) {
+	s := NewBackupScheduleStore()
+	schedule, err := s.Create(BackupScheduleInput{Name: "hourly", Tenant: "Acme", IntervalMinutes: 60, RetainCount: 2, VerifyEvery: 2})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if schedule.Prefix != "backups/scheduled/"+schedule.ID || !schedule.IncludeRuns || schedule.Tenant != "acme" {
+		t.Fatalf("unexpected defaults: %+v", schedule)
+	}
+	now := time.Now().UTC()
+	if due := s.Due(now); len(due) != 1 {
+		t.Fatalf("expected new schedule to be due, got %+v", due)
+	}
+	if due := s.Due(now); len(due) != 0 {
+		t.Fatalf("expected schedule advanced past now, got %+v", due)
+	}
+
+	if drill, _ := s.RecordRun(schedule.ID, nil); drill {
+		t.Fatalf("expected no drill on first run with verify_every=2")
+	}
+	if drill, _ := s.RecordRun(schedule.ID, nil); !drill {
+		t.Fatalf("expected drill on second run")
+	}
+
+	base := now.Add(-time.Hour)
+	ids := []string{}
+	for i := 0; i < 4; i++ {
+		set := s.AddSet(BackupSet{ScheduleID: schedule.ID, ObjectKey: "k" + itoa(int64(i)), CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+		ids = append(ids, set.ID)
+	}
+	// The oldest set is the only verified one, so it survives retention.
+	_, _ = s.MarkVerified(ids[0], BackupVerification{Passed: true})
+	pruned := s.Prune(schedule.ID, now)
+	if len(pruned) != 1 || pruned[0].ID != ids[1] {
+		t.Fatalf("expected only the second set pruned, got %+v", pruned)
+	}
+	if sets := s.Sets(schedule.ID); len(sets) != 4 || sets[0].ID != ids[3] {
+		t.Fatalf("expected sets newest first, got %+v", sets)
+	}
+}
@@ -0,0 +1,191 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CABackend issues certificates for approved CSRs from an external
+// certificate authority. The store falls back to its built-in issuer when no
+// backend is configured.
+type CABackend interface {
+	Name() string
+	Enroll(ctx context.Context, csrDER []byte, renew bool) ([]*x509.Certificate, error)
+}
+
+// AgentCABackendConfig selects where agent certificates are issued from.
+type AgentCABackendConfig struct {
+	Type      string     `json:"type"` // internal|est
+	EST       *ESTConfig `json:"est,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ESTConfig points at an RFC 7030 Enrollment over Secure Transport server,
+// the enrollment protocol most enterprise CAs (and SCEP gateways) expose.
+type ESTConfig struct {
+	URL      string `json:"url"`
+	Label    string `json:"label,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	CAPEM    string `json:"ca_pem,omitempty"`
+}
+
+// ESTBackend enrolls through the EST simpleenroll/simplereenroll endpoints.
+type ESTBackend struct {
+	cfg    ESTConfig
+	client *http.Client
+}
+
+func NewESTBackend(cfg ESTConfig) (*ESTBackend, error) {
+	cfg.URL = strings.TrimRight(strings.TrimSpace(cfg.URL), "/")
+	cfg.Label = strings.Trim(strings.TrimSpace(cfg.Label), "/")
+	parsed, err := url.Parse(cfg.URL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return nil, errors.New("est url must be an absolute http(s) url")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if strings.TrimSpace(cfg.CAPEM) != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.CAPEM)) {
+			return nil, errors.New("est ca_pem contains no certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &ESTBackend{cfg: cfg, client: &http.Client{Timeout: 60 * time.Second, Transport: transport}}, nil
+}
+
+func (b *ESTBackend) Name() string { return "est" }
+
+func (b *ESTBackend) Enroll(ctx context.Context, csrDER []byte, renew bool) ([]*x509.Certificate, error) {
+	op := "simpleenroll"
+	if renew {
+		op = "simplereenroll"
+	}
+	endpoint := b.cfg.URL + "/.well-known/est/"
+	if b.cfg.Label != "" {
+		endpoint += b.cfg.Label + "/"
+	}
+	endpoint += op
+	body := base64.StdEncoding.EncodeToString(csrDER)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/pkcs10")
+	req.Header.Set("Content-Transfer-Encoding", "base64")
+	if b.cfg.Username != "" {
+		req.SetBasicAuth(b.cfg.Username, b.cfg.Password)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, errors.New("est enrollment failed: " + err.Error())
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		return nil, errors.New("est enrollment is pending manual approval at the ca; retry after " + firstNonEmpty(resp.Header.Get("Retry-After"), "a delay"))
+	case resp.StatusCode != http.StatusOK:
+		return nil, errors.New("est enrollment rejected: " + resp.Status + " " + strings.TrimSpace(string(raw)))
+	}
+	der, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(raw), nil)))
+	if err != nil {
+		return nil, errors.New("est response is not base64 encoded")
+	}
+	certs, err := parsePKCS7Certificates(der)
+	if err != nil {
+		return nil, err
+	}
+	return orderLeafFirst(certs, csrDER), nil
+}
+
+// pkcs7ContentInfo and pkcs7SignedData cover the "certs-only" degenerate
+// SignedData EST returns; signer infos are never populated there.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+var oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+func parsePKCS7Certificates(der []byte) ([]*x509.Certificate, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, errors.New("est response is not a pkcs7 structure")
+	}
+	if !info.ContentType.Equal(oidPKCS7SignedData) {
+		return nil, errors.New("est response is not pkcs7 signed data")
+	}
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return nil, errors.New("est response signed data is malformed")
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certs) == 0 {
+		return nil, errors.New("est response contains no certificates")
+	}
+	return certs, nil
+}
+
+// orderLeafFirst moves the certificate issued for the CSR's key to the front
+// of the chain; PKCS#7 certificate sets are unordered.
+func orderLeafFirst(certs []*x509.Certificate, csrDER []byte) []*x509.Certificate {
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return certs
+	}
+	for i, cert := range certs {
+		if bytes.Equal(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo) {
+			out := append([]*x509.Certificate{cert}, certs[:i]...)
+			return append(out, certs[i+1:]...)
+		}
+	}
+	return certs
+}
+
+// parseCSRPEM decodes and checks the self-signature of a PEM CSR.
+func parseCSRPEM(in string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(in)))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("csr_pem must be a PEM encoded CERTIFICATE REQUEST")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, errors.New("csr_pem could not be parsed: " + err.Error())
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, errors.New("csr_pem signature is invalid")
+	}
+	return csr, nil
+}
+
+func encodeCertificatesPEM(certs []*x509.Certificate) string {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.String()
+}
@@ -0,0 +1,333 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CanaryAnalysisSample is one run observed on either side of a canary
+// comparison.
+type CanaryAnalysisSample struct {
+	RunID            string  `json:"run_id,omitempty"`
+	DurationMS       float64 `json:"duration_ms"`
+	Failed           bool    `json:"failed"`
+	ChangedResources int     `json:"changed_resources"`
+}
+
+type CanaryAnalysisInput struct {
+	Name                      string                 `json:"name"`
+	Component                 string                 `json:"component,omitempty"`
+	Baseline                  []CanaryAnalysisSample `json:"baseline"`
+	Canary                    []CanaryAnalysisSample `json:"canary"`
+	MaxLatencyIncreasePercent float64                `json:"max_latency_increase_percent,omitempty"`
+	MaxErrorRateIncrease      float64                `json:"max_error_rate_increase,omitempty"` // percentage points
+	MaxChangedIncreasePercent float64                `json:"max_changed_increase_percent,omitempty"`
+	Significance              float64                `json:"significance,omitempty"`
+	PassScore                 int                    `json:"pass_score,omitempty"`
+	MarginalScore             int                    `json:"marginal_score,omitempty"`
+}
+
+// CanaryMetricComparison reports one metric. Significant means the canary is
+// worse than baseline with a p-value below the configured significance.
+type CanaryMetricComparison struct {
+	Metric       string  `json:"metric"` // latency|error_rate|changed_resources
+	BaselineMean float64 `json:"baseline_mean"`
+	CanaryMean   float64 `json:"canary_mean"`
+	Delta        float64 `json:"delta"`
+	Tolerance    float64 `json:"tolerance"`
+	PValue       float64 `json:"p_value"`
+	Significant  bool    `json:"significant"`
+	Verdict      string  `json:"verdict"` // pass|marginal|fail
+	Reason       string  `json:"reason"`
+}
+
+type CanaryAnalysis struct {
+	ID             string                   `json:"id"`
+	Name           string                   `json:"name"`
+	Component      string                   `json:"component,omitempty"`
+	BaselineRuns   int                      `json:"baseline_runs"`
+	CanaryRuns     int                      `json:"canary_runs"`
+	Metrics        []CanaryMetricComparison `json:"metrics"`
+	Score          int                      `json:"score"`
+	Verdict        string                   `json:"verdict"` // pass|marginal|fail
+	Recommendation string                   `json:"recommendation"`
+	Significance   float64                  `json:"significance"`
+	PassScore      int                      `json:"pass_score"`
+	MarginalScore  int                      `json:"marginal_score"`
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+type CanaryAnalysisStore struct {
+	mu       sync.RWMutex
+	nextID   int64
+	analyses map[string]*CanaryAnalysis
+}
+
+func NewCanaryAnalysisStore() *CanaryAnalysisStore {
+	return &CanaryAnalysisStore{analyses: map[string]*CanaryAnalysis{}}
+}
+
+// Analyze compares canary samples with baseline samples and stores the
+// verdict.
+func (s *CanaryAnalysisStore) Analyze(in CanaryAnalysisInput) (CanaryAnalysis, error) {
+	item, err := AnalyzeCanary(in)
+	if err != nil {
+		return CanaryAnalysis{}, err
+	}
+	s.mu.Lock()
+	s.nextID++
+	item.ID = "canary-analysis-" + itoa(s.nextID)
+	s.analyses[item.ID] = &item
+	s.mu.Unlock()
+	return cloneCanaryAnalysis(item), nil
+}
+
+func (s *CanaryAnalysisStore) List(limit int) []CanaryAnalysis {
+	s.mu.RLock()
+	out := make([]CanaryAnalysis, 0, len(s.analyses))
+	for _, item := range s.analyses {
+		out = append(out, cloneCanaryAnalysis(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (s *CanaryAnalysisStore) Get(id string) (CanaryAnalysis, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.analyses[strings.TrimSpace(id)]
+	if !ok {
+		return CanaryAnalysis{}, errors.New("canary analysis not found")
+	}
+	return cloneCanaryAnalysis(*item), nil
+}
+
+// AnalyzeCanary scores a canary against its baseline. Latency and changed
+// resource counts use Welch's t statistic and error rate a two-proportion z
+// statistic, both with a normal approximation for the p-value.
+// A metric fails when the canary is worse by more than its tolerance and the
+// difference is significant; worse beyond tolerance without significance,
+// or significant within tolerance, is marginal. Passing metrics score 100,
+// marginal 50, and failing 0; the score is their average. Any failing
+// metric fails the analysis regardless of score.
+func AnalyzeCanary(in CanaryAnalysisInput) (CanaryAnalysis, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return CanaryAnalysis{}, errors.New("name is required")
+	}
+	if len(in.Baseline) == 0 || len(in.Canary) == 0 {
+		return CanaryAnalysis{}, errors.New("baseline and canary samples are required")
+	}
+	if in.MaxLatencyIncreasePercent <= 0 {
+		in.MaxLatencyIncreasePercent = 20
+	}
+	if in.MaxErrorRateIncrease <= 0 {
+		in.MaxErrorRateIncrease = 5
+	}
+	if in.MaxChangedIncreasePercent <= 0 {
+		in.MaxChangedIncreasePercent = 50
+	}
+	if in.Significance <= 0 || in.Significance >= 1 {
+		in.Significance = 0.05
+	}
+	if in.PassScore <= 0 || in.PassScore > 100 {
+		in.PassScore = 80
+	}
+	if in.MarginalScore <= 0 || in.MarginalScore > in.PassScore {
+		in.MarginalScore = in.PassScore * 3 / 4
+	}
+
+	baseDur, canDur := make([]float64, 0, len(in.Baseline)), make([]float64, 0, len(in.Canary))
+	baseChg, canChg := make([]float64, 0, len(in.Baseline)), make([]float64, 0, len(in.Canary))
+	baseFail, canFail := 0, 0
+	for _, sample := range in.Baseline {
+		if sample.DurationMS < 0 || sample.ChangedResources < 0 {
+			return CanaryAnalysis{}, errors.New("sample durations and changed resource counts must be >= 0")
+		}
+		baseDur = append(baseDur, sample.DurationMS)
+		baseChg = append(baseChg, float64(sample.ChangedResources))
+		if sample.Failed {
+			baseFail++
+		}
+	}
+	for _, sample := range in.Canary {
+		if sample.DurationMS < 0 || sample.ChangedResources < 0 {
+			return CanaryAnalysis{}, errors.New("sample durations and changed resource counts must be >= 0")
+		}
+		canDur = append(canDur, sample.DurationMS)
+		canChg = append(canChg, float64(sample.ChangedResources))
+		if sample.Failed {
+			canFail++
+		}
+	}
+
+	metrics := []CanaryMetricComparison{
+		compareCanaryMeans("latency", baseDur, canDur, in.MaxLatencyIncreasePercent, in.Significance),
+		compareCanaryErrorRates(baseFail, len(in.Baseline), canFail, len(in.Canary), in.MaxErrorRateIncrease, in.Significance),
+		compareCanaryMeans("changed_resources", baseChg, canChg, in.MaxChangedIncreasePercent, in.Significance),
+	}
+	total, failed := 0, false
+	for _, m := range metrics {
+		switch m.Verdict {
+		case "pass":
+			total += 100
+		case "marginal":
+			total += 50
+		default:
+			failed = true
+		}
+	}
+	out := CanaryAnalysis{
+		Name:          name,
+		Component:     strings.ToLower(strings.TrimSpace(in.Component)),
+		BaselineRuns:  len(in.Baseline),
+		CanaryRuns:    len(in.Canary),
+		Metrics:       metrics,
+		Score:         total / len(metrics),
+		Significance:  in.Significance,
+		PassScore:     in.PassScore,
+		MarginalScore: in.MarginalScore,
+		CreatedAt:     time.Now().UTC(),
+	}
+	switch {
+	case failed:
+		out.Verdict, out.Recommendation = "fail", "rollback"
+	case out.Score >= in.PassScore:
+		out.Verdict, out.Recommendation = "pass", "promote"
+	case out.Score >= in.MarginalScore:
+		out.Verdict, out.Recommendation = "marginal", "hold"
+	default:
+		out.Verdict, out.Recommendation = "fail", "rollback"
+	}
+	return out, nil
+}
+
+// compareCanaryMeans compares sample means; tolerancePercent is the allowed
+// relative increase of the canary mean over the baseline mean.
+func compareCanaryMeans(metric string, baseline, canary []float64, tolerancePercent, alpha float64) CanaryMetricComparison {
+	bm, bv := meanVariance(baseline)
+	cm, cv := meanVariance(canary)
+	out := CanaryMetricComparison{
+		Metric:       metric,
+		BaselineMean: roundCanary(bm),
+		CanaryMean:   roundCanary(cm),
+		Tolerance:    tolerancePercent,
+		PValue:       1,
+	}
+	switch {
+	case bm > 0:
+		out.Delta = roundCanary((cm - bm) / bm * 100)
+	case cm > 0:
+		out.Delta = 100
+	}
+	se := math.Sqrt(bv/float64(len(baseline)) + cv/float64(len(canary)))
+	switch {
+	case se > 0:
+		out.PValue = roundCanary(oneSidedPValue((cm - bm) / se))
+	case cm > bm:
+		// No variance on either side: any increase is a certain difference.
+		out.PValue = 0
+	}
+	out.Significant = cm > bm && out.PValue < alpha
+	out.Verdict, out.Reason = canaryMetricVerdict(metric, out.Delta > tolerancePercent, out.Significant)
+	return out
+}
+
+// compareCanaryErrorRates compares failure proportions in percent;
+// tolerance is the allowed increase in percentage points.
+func compareCanaryErrorRates(baseFailed, baseTotal, canFailed, canTotal int, tolerance, alpha float64) CanaryMetricComparison {
+	bp := float64(baseFailed) / float64(baseTotal)
+	cp := float64(canFailed) / float64(canTotal)
+	out := CanaryMetricComparison{
+		Metric:       "error_rate",
+		BaselineMean: roundCanary(bp * 100),
+		CanaryMean:   roundCanary(cp * 100),
+		Delta:        roundCanary((cp - bp) * 100),
+		Tolerance:    tolerance,
+		PValue:       1,
+	}
+	pooled := float64(baseFailed+canFailed) / float64(baseTotal+canTotal)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(baseTotal) + 1/float64(canTotal)))
+	if se > 0 {
+		out.PValue = roundCanary(oneSidedPValue((cp - bp) / se))
+	}
+	out.Significant = cp > bp && out.PValue < alpha
+	out.Verdict, out.Reason = canaryMetricVerdict("error_rate", out.Delta > tolerance, out.Significant)
+	return out
+}
+
+func canaryMetricVerdict(metric string, exceeds, significant bool) (string, string) {
+	switch {
+	case exceeds && significant:
+		return "fail", metric + " regression exceeds tolerance and is statistically significant"
+	case exceeds:
+		return "marginal", metric + " exceeds tolerance but the difference is not significant"
+	case significant:
+		return "marginal", metric + " increase is significant but within tolerance"
+	default:
+		return "pass", metric + " within tolerance"
+	}
+}
+
+// ApplyCanaryAnalysis gates a rollout plan on a canary verdict. A failed
+// analysis blocks the plan; a marginal one keeps it allowed but marks the
+// promote wave as held for review.
+func ApplyCanaryAnalysis(plan RolloutPlan, analysis CanaryAnalysis) RolloutPlan {
+	plan.CanaryAnalysisID = analysis.ID
+	plan.CanaryVerdict = analysis.Verdict
+	plan.CanaryScore = analysis.Score
+	switch analysis.Verdict {
+	case "fail":
+		plan.Allowed = false
+		plan.BlockedReason = "canary analysis " + analysis.ID + " failed with score " + itoa(int64(analysis.Score))
+	case "marginal":
+		for i := range plan.Waves {
+			if plan.Waves[i].Phase == "promote" {
+				plan.Waves[i].Reason = "hold promotion for review: canary analysis is marginal"
+			}
+		}
+	}
+	return plan
+}
+
+func meanVariance(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	sq := 0.0
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, sq / float64(len(values)-1)
+}
+
+// oneSidedPValue returns P(Z >= z) under the standard normal distribution.
+func oneSidedPValue(z float64) float64 {
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+func roundCanary(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func cloneCanaryAnalysis(in CanaryAnalysis) CanaryAnalysis {
+	out := in
+	out.Metrics = append([]CanaryMetricComparison{}, in.Metrics...)
+	return out
+}
@@ -0,0 +1,87 @@
+package control
+
+import "testing"
+
+func canarySamples(durations []float64, failed int) []CanaryAnalysisSample {
+	out := make([]CanaryAnalysisSample, 0, len(durations))
+	for i, d := range durations {
+		out = append(out, CanaryAnalysisSample{DurationMS: d, Failed: i < failed, ChangedResources: 2})
+	}
+	return out
+}
+
+func TestAnalyzeCanaryVerdicts(t *testing.T) {
+	baseline := canarySamples([]float64{100, 104, 98, 101, 99, 102, 97, 103, 100, 101}, 0)
+
+	pass, err := AnalyzeCanary(CanaryAnalysisInput{
+		Name:     "steady",
+		Baseline: baseline,
+		Canary:   canarySamples([]float64{101, 99, 103, 100, 98, 102, 100, 101}, 0),
+	})
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if pass.Verdict != "pass" || pass.Score != 100 || pass.Recommendation != "promote" {
+		t.Fatalf("expected passing canary, got %+v", pass)
+	}
+
+	slow, err := AnalyzeCanary(CanaryAnalysisInput{
+		Name:     "slow",
+		Baseline: baseline,
+		Canary:   canarySamples([]float64{160, 158, 170, 155, 162, 166, 159, 161}, 0),
+	})
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if slow.Verdict != "fail" || slow.Metrics[0].Metric != "latency" || slow.Metrics[0].Verdict != "fail" || !slow.Metrics[0].Significant {
+		t.Fatalf("expected latency regression to fail, got %+v", slow)
+	}
+
+	failing, err := AnalyzeCanary(CanaryAnalysisInput{
+		Name:     "errors",
+		Baseline: baseline,
+		Canary:   canarySamples([]float64{100, 101, 99, 100, 102, 98, 100, 101}, 4),
+	})
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if failing.Metrics[1].Metric != "error_rate" || failing.Metrics[1].Verdict != "fail" || failing.Verdict != "fail" {
+		t.Fatalf("expected error rate regression to fail, got %+v", failing)
+	}
+
+	noisy, err := AnalyzeCanary(CanaryAnalysisInput{
+		Name:     "noisy",
+		Baseline: canarySamples([]float64{100, 100}, 0),
+		Canary:   canarySamples([]float64{60, 240}, 0),
+	})
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if noisy.Metrics[0].Verdict != "marginal" || noisy.Verdict != "pass" {
+		t.Fatalf("expected insignificant latency increase to be marginal, got %+v", noisy)
+	}
+}
+
+func TestCanaryAnalysisStore(t *testing.T) {
+	store := NewCanaryAnalysisStore()
+	if _, err := store.Analyze(CanaryAnalysisInput{Name: "empty"}); err == nil {
+		t.Fatalf("expected samples to be required")
+	}
+	item, err := store.Analyze(CanaryAnalysisInput{
+		Name:     "steady",
+		Baseline: canarySamples([]float64{100, 101}, 0),
+		Canary:   canarySamples([]float64{100, 101}, 0),
+	})
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if item.ID != "canary-analysis-1" {
+		t.Fatalf("unexpected id %q", item.ID)
+	}
+	if got, err := store.Get(item.ID); err != nil || got.Verdict != item.Verdict {
+		t.Fatalf("get failed: %+v %v", got, err)
+	}
+	if len(store.List(10)) != 1 {
+		t.Fatalf("expected one analysis")
+	}
+}
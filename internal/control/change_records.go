@@ -46,6 +46,7 @@ type ChangeRecordStore struct {
 	mu      sync.RWMutex
 	nextID  int64
 	records map[string]*ChangeRecord
+	gate    func(rec ChangeRecord, actor string) (bool, error)
 }
 
 func NewChangeRecordStore() *ChangeRecordStore {
@@ -93,6 +94,16 @@ func (s *ChangeRecordStore) Get(id string) (ChangeRecord, error) {
 	return cloneChangeRecord(*rec), nil
 }
 
+// SetApprovalGate makes approvals subject to fn. fn sees the record with the
+// new approval appended (actor is empty on a recheck) and reports whether
+// the record now has every approval it needs; an error refuses the
+// approval.
+func (s *ChangeRecordStore) SetApprovalGate(fn func(rec ChangeRecord, actor string) (bool, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gate = fn
+}
+
 func (s *ChangeRecordStore) Approve(id, actor, comment string) (ChangeRecord, error) {
 	return s.recordDecision(id, actor, "approve", comment)
 }
@@ -120,16 +131,45 @@ func (s *ChangeRecordStore) recordDecision(id, actor, decision, comment string)
 		Comment:   strings.TrimSpace(comment),
 		CreatedAt: now,
 	}
+	approved := true
+	if decision == "approve" && s.gate != nil {
+		candidate := cloneChangeRecord(*rec)
+		candidate.Approvals = append(candidate.Approvals, approval)
+		ok, err := s.gate(candidate, actor)
+		if err != nil {
+			return ChangeRecord{}, err
+		}
+		approved = ok
+	}
 	rec.Approvals = append(rec.Approvals, approval)
-	if decision == "approve" {
-		rec.Status = ChangeRecordApproved
-	} else {
+	switch {
+	case decision != "approve":
 		rec.Status = ChangeRecordRejected
+	case approved:
+		rec.Status = ChangeRecordApproved
 	}
 	rec.UpdatedAt = now
 	return cloneChangeRecord(*rec), nil
 }
 
+// RecheckApproval returns an approved record to proposed once the approvals
+// it was granted on have gone stale.
+func (s *ChangeRecordStore) RecheckApproval(id string) (ChangeRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[strings.TrimSpace(id)]
+	if !ok {
+		return ChangeRecord{}, errors.New("change record not found")
+	}
+	if rec.Status == ChangeRecordApproved && s.gate != nil {
+		if ok, err := s.gate(cloneChangeRecord(*rec), ""); err == nil && !ok {
+			rec.Status = ChangeRecordProposed
+			rec.UpdatedAt = time.Now().UTC()
+		}
+	}
+	return cloneChangeRecord(*rec), nil
+}
+
 func (s *ChangeRecordStore) AttachJob(id, jobID string) (ChangeRecord, error) {
 	id = strings.TrimSpace(id)
 	jobID = strings.TrimSpace(jobID)
@@ -148,6 +188,21 @@ func (s *ChangeRecordStore) AttachJob(id, jobID string) (ChangeRecord, error) {
 	return cloneChangeRecord(*rec), nil
 }
 
+func (s *ChangeRecordStore) SetTicket(id, system, ticketID, ticketURL string) (ChangeRecord, error) {
+	id = strings.TrimSpace(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[id]
+	if !ok {
+		return ChangeRecord{}, errors.New("change record not found")
+	}
+	rec.TicketSystem = strings.TrimSpace(system)
+	rec.TicketID = strings.TrimSpace(ticketID)
+	rec.TicketURL = strings.TrimSpace(ticketURL)
+	rec.UpdatedAt = time.Now().UTC()
+	return cloneChangeRecord(*rec), nil
+}
+
 func (s *ChangeRecordStore) MarkCompleted(id string) (ChangeRecord, error) {
 	return s.setTerminalStatus(id, ChangeRecordCompleted, "")
 }
@@ -18,24 +18,29 @@ type ChaosExperimentInput struct {
 	Async         bool   `json:"async"`
 	TriggeredBy   string `json:"triggered_by,omitempty"`
 	ApprovalToken string `json:"approval_token,omitempty"`
+	MaxFailedJobs int    `json:"max_failed_jobs,omitempty"`
 }
 
 type ChaosExperiment struct {
-	ID                    string    `json:"id"`
-	Name                  string    `json:"name"`
-	Target                string    `json:"target"`
-	FaultType             string    `json:"fault_type"`
-	Intensity             int       `json:"intensity"`
-	DurationSec           int       `json:"duration_sec"`
-	Async                 bool      `json:"async"`
-	Status                string    `json:"status"` // running|completed|aborted|blocked
-	TriggeredBy           string    `json:"triggered_by,omitempty"`
-	ImpactScore           int       `json:"impact_score"`
-	ServiceDisruptions    int       `json:"service_disruptions"`
-	AutoRollbackTriggered bool      `json:"auto_rollback_triggered"`
-	Findings              []string  `json:"findings,omitempty"`
-	StartedAt             time.Time `json:"started_at"`
-	CompletedAt           time.Time `json:"completed_at,omitempty"`
+	ID                    string     `json:"id"`
+	Name                  string     `json:"name"`
+	Target                string     `json:"target"`
+	FaultType             string     `json:"fault_type"`
+	Intensity             int        `json:"intensity"`
+	DurationSec           int        `json:"duration_sec"`
+	Async                 bool       `json:"async"`
+	Status                string     `json:"status"` // running|completed|aborted|blocked
+	TriggeredBy           string     `json:"triggered_by,omitempty"`
+	ImpactScore           int        `json:"impact_score"`
+	ServiceDisruptions    int        `json:"service_disruptions"`
+	AutoRollbackTriggered bool       `json:"auto_rollback_triggered"`
+	Injected              bool       `json:"injected"`
+	MaxFailedJobs         int        `json:"max_failed_jobs,omitempty"`
+	AbortReason           string     `json:"abort_reason,omitempty"`
+	Observations          ChaosStats `json:"observations"`
+	Findings              []string   `json:"findings,omitempty"`
+	StartedAt             time.Time  `json:"started_at"`
+	CompletedAt           time.Time  `json:"completed_at,omitempty"`
 }
 
 type ChaosExperimentStore struct {
@@ -62,6 +67,11 @@ func (s *ChaosExperimentStore) Create(in ChaosExperimentInput) (ChaosExperiment,
 	if in.Intensity <= 0 || in.Intensity > 100 {
 		return ChaosExperiment{}, errors.New("intensity must be between 1 and 100")
 	}
+	if fault == ChaosFaultWorkerKill {
+		if _, ok := parseChaosJobTarget(target); !ok {
+			return ChaosExperiment{}, errors.New("worker-kill target must be * or comma-separated key=value job selectors")
+		}
+	}
 	duration := in.DurationSec
 	if duration <= 0 {
 		duration = 60
@@ -80,8 +90,15 @@ func (s *ChaosExperimentStore) Create(in ChaosExperimentInput) (ChaosExperiment,
 		Async:       in.Async,
 		Status:      "running",
 		TriggeredBy: strings.TrimSpace(in.TriggeredBy),
+		Injected:    IsInjectableChaosFault(fault),
 		StartedAt:   now,
 	}
+	if item.Injected {
+		item.MaxFailedJobs = in.MaxFailedJobs
+		if item.MaxFailedJobs <= 0 {
+			item.MaxFailedJobs = 3
+		}
+	}
 	if in.Intensity >= 85 && strings.Contains(strings.ToLower(target), "prod") && strings.TrimSpace(in.ApprovalToken) == "" {
 		item.Status = "blocked"
 		item.Findings = []string{"high-intensity production experiment requires approval_token"}
@@ -90,7 +107,8 @@ func (s *ChaosExperimentStore) Create(in ChaosExperimentInput) (ChaosExperiment,
 		s.ordered = append(s.ordered, item.ID)
 		return cloneChaosExperiment(item), nil
 	}
-	if !in.Async {
+	// Injected faults stay running until a ChaosInjector finishes them.
+	if !in.Async && !item.Injected {
 		s.completeLocked(&item, now)
 	}
 	s.items[item.ID] = &item
@@ -189,9 +207,43 @@ func (s *ChaosExperimentStore) Complete(id string) (ChaosExperiment, error) {
 	return cloneChaosExperiment(*item), nil
 }
 
+// Finish records the outcome of an injected experiment. Only running
+// experiments can be finished.
+func (s *ChaosExperimentStore) Finish(id, status, abortReason string, stats ChaosStats, findings []string) (ChaosExperiment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[strings.TrimSpace(id)]
+	if !ok {
+		return ChaosExperiment{}, errors.New("experiment not found")
+	}
+	if item.Status != "running" {
+		return ChaosExperiment{}, errors.New("only running experiments can be finished")
+	}
+	item.Status = status
+	item.AbortReason = strings.TrimSpace(abortReason)
+	item.Observations = stats
+	item.ServiceDisruptions = stats.JobsKilled + stats.FailedJobs + stats.WebhooksDropped
+	item.AutoRollbackTriggered = status == "aborted" && item.AbortReason != ""
+	item.Findings = append(item.Findings, findings...)
+	item.CompletedAt = time.Now().UTC()
+	return cloneChaosExperiment(*item), nil
+}
+
+// IsInjectableChaosFault reports whether a fault type is executed against
+// the running control plane rather than simulated.
+func IsInjectableChaosFault(fault string) bool {
+	switch fault {
+	case ChaosFaultWorkerKill, ChaosFaultTransportDelay, ChaosFaultWebhookDrop, ChaosFaultQueueSaturation:
+		return true
+	default:
+		return false
+	}
+}
+
 func normalizeChaosFaultType(v string) string {
 	switch strings.ToLower(strings.TrimSpace(v)) {
-	case "network-latency", "packet-loss", "process-crash", "cpu-stress", "queue-delay":
+	case "network-latency", "packet-loss", "process-crash", "cpu-stress", "queue-delay",
+		ChaosFaultWorkerKill, ChaosFaultTransportDelay, ChaosFaultWebhookDrop, ChaosFaultQueueSaturation:
 		return strings.ToLower(strings.TrimSpace(v))
 	default:
 		return ""
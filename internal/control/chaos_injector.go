@@ -0,0 +1,484 @@
+package control
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault types that ChaosInjector executes against the running control
+// plane. Other fault types are simulated by ChaosExperimentStore.
+const (
+	ChaosFaultWorkerKill      = "worker-kill"
+	ChaosFaultTransportDelay  = "transport-delay"
+	ChaosFaultWebhookDrop     = "webhook-drop"
+	ChaosFaultQueueSaturation = "queue-saturation"
+)
+
+// ChaosSyntheticJobPrefix marks config paths of jobs enqueued to saturate
+// the queue. The wrapped executor completes them without applying anything.
+const ChaosSyntheticJobPrefix = "chaos://saturation/"
+
+// ChaosStats counts what an injected experiment did and observed.
+type ChaosStats struct {
+	JobsKilled        int   `json:"jobs_killed,omitempty"`
+	DelaysInjected    int   `json:"delays_injected,omitempty"`
+	DelayMS           int64 `json:"delay_ms,omitempty"`
+	WebhooksDropped   int   `json:"webhooks_dropped,omitempty"`
+	SyntheticJobs     int   `json:"synthetic_jobs,omitempty"`
+	FailedJobs        int   `json:"failed_jobs,omitempty"`
+	MaxPending        int   `json:"max_pending,omitempty"`
+	GuardChecks       int   `json:"guard_checks,omitempty"`
+	SyntheticCanceled int   `json:"synthetic_canceled,omitempty"`
+}
+
+// ChaosGuard reports an SLO breach for a running experiment; an empty
+// string means the experiment may continue.
+type ChaosGuard func(ChaosExperiment) string
+
+// ChaosInjector runs injectable chaos experiments. Experiments only start
+// while emergency stop and change freeze are clear and the guard reports no
+// breach, and are aborted automatically when the guard trips or too many
+// real jobs fail during the window.
+type ChaosInjector struct {
+	mu            sync.Mutex
+	store         *ChaosExperimentStore
+	queue         *Queue
+	guard         ChaosGuard
+	onFinish      func(ChaosExperiment)
+	guardInterval time.Duration
+	syntheticCost time.Duration
+	rng           *rand.Rand
+	active        map[string]*activeChaosFault
+	killed        map[string]string // job id -> experiment id
+}
+
+type activeChaosFault struct {
+	experiment ChaosExperiment
+	stats      ChaosStats
+	synthetic  []string
+	stop       chan chaosStop
+	done       chan struct{}
+}
+
+type chaosStop struct {
+	status string
+	reason string
+}
+
+func NewChaosInjector(store *ChaosExperimentStore, queue *Queue) *ChaosInjector {
+	c := &ChaosInjector{
+		store:         store,
+		queue:         queue,
+		guardInterval: time.Second,
+		syntheticCost: 50 * time.Millisecond,
+		rng:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		active:        map[string]*activeChaosFault{},
+		killed:        map[string]string{},
+	}
+	if queue != nil {
+		queue.Subscribe(c.onJob)
+	}
+	return c
+}
+
+// SetGuard installs the SLO guard consulted before and during experiments.
+func (c *ChaosInjector) SetGuard(fn ChaosGuard) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.guard = fn
+}
+
+// OnFinish registers a callback invoked when an injected experiment ends.
+func (c *ChaosInjector) OnFinish(fn func(ChaosExperiment)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onFinish = fn
+}
+
+// Start begins injecting a running experiment's fault. An experiment that
+// fails the safety checks is finished as blocked.
+func (c *ChaosInjector) Start(exp ChaosExperiment) (ChaosExperiment, error) {
+	if !exp.Injected || exp.Status != "running" {
+		return ChaosExperiment{}, errors.New("experiment is not a running injectable experiment")
+	}
+	if reason := c.preflight(exp); reason != "" {
+		return c.store.Finish(exp.ID, "blocked", "", ChaosStats{}, []string{reason})
+	}
+	fault := &activeChaosFault{
+		experiment: exp,
+		stop:       make(chan chaosStop, 1),
+		done:       make(chan struct{}),
+	}
+	c.mu.Lock()
+	if _, ok := c.active[exp.ID]; ok {
+		c.mu.Unlock()
+		return ChaosExperiment{}, errors.New("experiment is already injecting")
+	}
+	c.active[exp.ID] = fault
+	c.mu.Unlock()
+
+	if exp.FaultType == ChaosFaultQueueSaturation && c.queue != nil {
+		c.saturate(fault)
+	}
+	go c.run(fault)
+	return exp, nil
+}
+
+func (c *ChaosInjector) preflight(exp ChaosExperiment) string {
+	if reason := c.controlHalted(); reason != "" {
+		return "blocked: " + reason
+	}
+	c.mu.Lock()
+	guard := c.guard
+	c.mu.Unlock()
+	if guard != nil {
+		if reason := guard(exp); reason != "" {
+			return "blocked: " + reason
+		}
+	}
+	return ""
+}
+
+// controlHalted reports an active emergency stop or change freeze, during
+// which no faults may be injected.
+func (c *ChaosInjector) controlHalted() string {
+	if c.queue == nil {
+		return ""
+	}
+	if st := c.queue.EmergencyStatus(); st.Active {
+		return "emergency stop is active"
+	}
+	if st := c.queue.FreezeStatus(); st.Active {
+		return "change freeze is active"
+	}
+	return ""
+}
+
+// Stop ends an injecting experiment early with status completed or aborted
+// and waits for its fault to be removed.
+func (c *ChaosInjector) Stop(id, status, reason string) (ChaosExperiment, error) {
+	c.mu.Lock()
+	fault, ok := c.active[strings.TrimSpace(id)]
+	c.mu.Unlock()
+	if !ok {
+		return ChaosExperiment{}, errors.New("experiment is not injecting")
+	}
+	select {
+	case fault.stop <- chaosStop{status: status, reason: reason}:
+	default:
+	}
+	<-fault.done
+	return c.store.Get(id)
+}
+
+// Active reports whether an experiment is currently injecting.
+func (c *ChaosInjector) Active(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.active[strings.TrimSpace(id)]
+	return ok
+}
+
+// Shutdown aborts every injecting experiment.
+func (c *ChaosInjector) Shutdown() {
+	c.mu.Lock()
+	ids := make([]string, 0, len(c.active))
+	for id := range c.active {
+		ids = append(ids, id)
+	}
+	c.mu.Unlock()
+	for _, id := range ids {
+		_, _ = c.Stop(id, "aborted", "control plane shutting down")
+	}
+}
+
+func (c *ChaosInjector) run(fault *activeChaosFault) {
+	exp := fault.experiment
+	deadline := time.NewTimer(time.Duration(exp.DurationSec) * time.Second)
+	defer deadline.Stop()
+	ticker := time.NewTicker(c.guardInterval)
+	defer ticker.Stop()
+
+	status, reason := "completed", ""
+	operatorStop := false
+loop:
+	for {
+		select {
+		case <-deadline.C:
+			break loop
+		case stop := <-fault.stop:
+			status, reason, operatorStop = stop.status, stop.reason, true
+			break loop
+		case <-ticker.C:
+			if breach := c.checkGuard(fault); breach != "" {
+				status, reason = "aborted", breach
+				break loop
+			}
+		}
+	}
+
+	c.mu.Lock()
+	delete(c.active, exp.ID)
+	stats := fault.stats
+	synthetic := append([]string{}, fault.synthetic...)
+	onFinish := c.onFinish
+	c.mu.Unlock()
+
+	for _, id := range synthetic {
+		if job, ok := c.queue.Get(id); ok && job.Status == JobPending {
+			// Leftover saturation load is removed so it cannot outlive the
+			// experiment.
+			_ = c.queue.Cancel(id)
+			stats.SyntheticCanceled++
+		}
+	}
+	findings := chaosFindings(exp, stats)
+	abortReason := ""
+	switch {
+	case status == "aborted" && !operatorStop:
+		abortReason = reason
+		findings = append(findings, "auto-aborted on SLO breach: "+reason)
+	case status == "aborted":
+		if reason == "" {
+			reason = "aborted by operator"
+		}
+		findings = append(findings, reason)
+	}
+	item, err := c.store.Finish(exp.ID, status, abortReason, stats, findings)
+	close(fault.done)
+	if err == nil && onFinish != nil {
+		onFinish(item)
+	}
+}
+
+// checkGuard samples the queue and evaluates the abort conditions.
+func (c *ChaosInjector) checkGuard(fault *activeChaosFault) string {
+	if reason := c.controlHalted(); reason != "" {
+		return reason
+	}
+	pending := 0
+	if c.queue != nil {
+		pending = c.queue.ControlStatus().Pending
+	}
+	c.mu.Lock()
+	fault.stats.GuardChecks++
+	if pending > fault.stats.MaxPending {
+		fault.stats.MaxPending = pending
+	}
+	failed := fault.stats.FailedJobs
+	guard := c.guard
+	exp := fault.experiment
+	c.mu.Unlock()
+	if exp.MaxFailedJobs > 0 && failed >= exp.MaxFailedJobs {
+		return strconv.Itoa(failed) + " jobs failed during the experiment (limit " + strconv.Itoa(exp.MaxFailedJobs) + ")"
+	}
+	if guard != nil {
+		return guard(exp)
+	}
+	return ""
+}
+
+func (c *ChaosInjector) saturate(fault *activeChaosFault) {
+	// Intensity is the number of synthetic jobs, so saturation is bounded.
+	for i := 0; i < fault.experiment.Intensity; i++ {
+		job, err := c.queue.Enqueue(ChaosSyntheticJobPrefix+fault.experiment.ID+"/"+strconv.Itoa(i), "", false, "low")
+		if err != nil {
+			break
+		}
+		c.mu.Lock()
+		fault.synthetic = append(fault.synthetic, job.ID)
+		fault.stats.SyntheticJobs++
+		c.mu.Unlock()
+	}
+}
+
+// onJob kills starting jobs for worker-kill experiments and counts real job
+// failures for the SLO guard.
+func (c *ChaosInjector) onJob(job Job) {
+	if strings.HasPrefix(job.ConfigPath, ChaosSyntheticJobPrefix) {
+		return
+	}
+	c.mu.Lock()
+	switch job.Status {
+	case JobRunning:
+		var killer *activeChaosFault
+		for _, fault := range c.active {
+			if fault.experiment.FaultType != ChaosFaultWorkerKill || !chaosTargetsJob(fault.experiment.Target, job) {
+				continue
+			}
+			if c.rng.Intn(100) < fault.experiment.Intensity {
+				killer = fault
+				break
+			}
+		}
+		if killer == nil {
+			c.mu.Unlock()
+			return
+		}
+		killer.stats.JobsKilled++
+		c.killed[job.ID] = killer.experiment.ID
+		expID := killer.experiment.ID
+		c.mu.Unlock()
+		// Interrupt asynchronously so the worker is mid-apply, not still
+		// publishing the running transition.
+		go func() {
+			_, _ = c.queue.InterruptJob(job.ID, "worker killed by chaos experiment "+expID)
+		}()
+		return
+	case JobFailed, JobSucceeded, JobCanceled:
+		// A killed job may end in any terminal state, e.g. when it finishes
+		// before the interrupt lands or after its experiment has stopped.
+		if _, ok := c.killed[job.ID]; ok {
+			delete(c.killed, job.ID)
+			c.mu.Unlock()
+			return
+		}
+		if job.Status == JobFailed {
+			for _, fault := range c.active {
+				fault.stats.FailedJobs++
+			}
+		}
+	}
+	c.mu.Unlock()
+}
+
+// parseChaosJobTarget parses a worker-kill target: "*" for every job, or
+// comma-separated key=value selectors on environment, tenant, region,
+// partition, worker, execution_env, or a job label.
+func parseChaosJobTarget(target string) (map[string]string, bool) {
+	target = strings.TrimSpace(target)
+	if target == "*" {
+		return map[string]string{}, true
+	}
+	selectors := map[string]string{}
+	for _, part := range strings.Split(target, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, false
+		}
+		selectors[key] = value
+	}
+	return selectors, len(selectors) > 0
+}
+
+func chaosTargetsJob(target string, job Job) bool {
+	selectors, ok := parseChaosJobTarget(target)
+	if !ok {
+		return false
+	}
+	for key, want := range selectors {
+		var got string
+		switch key {
+		case "environment":
+			got = job.Environment
+		case "tenant":
+			got = job.Tenant
+		case "region":
+			got = job.Region
+		case "partition":
+			got = job.Partition
+		case "worker":
+			got = job.Worker
+		case "execution_env":
+			got = job.ExecutionEnv
+		default:
+			got = job.Labels[key]
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// TransportDelay returns the latency to inject before an apply, scaled by
+// intensity up to two seconds.
+func (c *ChaosInjector) TransportDelay() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var delay time.Duration
+	var target *activeChaosFault
+	for _, fault := range c.active {
+		if fault.experiment.FaultType != ChaosFaultTransportDelay {
+			continue
+		}
+		if d := time.Duration(fault.experiment.Intensity) * 20 * time.Millisecond; d > delay {
+			delay, target = d, fault
+		}
+	}
+	if target != nil {
+		target.stats.DelaysInjected++
+		target.stats.DelayMS += delay.Milliseconds()
+	}
+	return delay
+}
+
+// DropWebhook decides whether to drop a webhook delivery, returning the
+// responsible experiment ID.
+func (c *ChaosInjector) DropWebhook() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fault := range c.active {
+		if fault.experiment.FaultType == ChaosFaultWebhookDrop && c.rng.Intn(100) < fault.experiment.Intensity {
+			fault.stats.WebhooksDropped++
+			return fault.experiment.ID, true
+		}
+	}
+	return "", false
+}
+
+// WrapExecutor injects transport delay into applies and completes
+// synthetic saturation jobs without touching any host.
+func (c *ChaosInjector) WrapExecutor(exec Executor) Executor {
+	return chaosExecutor{inner: exec, chaos: c}
+}
+
+type chaosExecutor struct {
+	inner Executor
+	chaos *ChaosInjector
+}
+
+func (e chaosExecutor) ApplyPath(configPath string) error {
+	if strings.HasPrefix(configPath, ChaosSyntheticJobPrefix) {
+		time.Sleep(e.chaos.syntheticCost)
+		return nil
+	}
+	if delay := e.chaos.TransportDelay(); delay > 0 {
+		time.Sleep(delay)
+	}
+	return e.inner.ApplyPath(configPath)
+}
+
+func (e chaosExecutor) ApplyJob(job Job) error {
+	jobExec, ok := e.inner.(JobExecutor)
+	if !ok || strings.HasPrefix(job.ConfigPath, ChaosSyntheticJobPrefix) {
+		return e.ApplyPath(job.ConfigPath)
+	}
+	if delay := e.chaos.TransportDelay(); delay > 0 {
+		time.Sleep(delay)
+	}
+	return jobExec.ApplyJob(job)
+}
+
+func chaosFindings(exp ChaosExperiment, stats ChaosStats) []string {
+	out := []string{}
+	switch exp.FaultType {
+	case ChaosFaultWorkerKill:
+		out = append(out, strconv.Itoa(stats.JobsKilled)+" jobs interrupted by simulated worker loss")
+	case ChaosFaultTransportDelay:
+		out = append(out, strconv.Itoa(stats.DelaysInjected)+" applies delayed by "+strconv.FormatInt(stats.DelayMS, 10)+"ms in total")
+	case ChaosFaultWebhookDrop:
+		out = append(out, strconv.Itoa(stats.WebhooksDropped)+" webhook deliveries dropped")
+	case ChaosFaultQueueSaturation:
+		out = append(out, strconv.Itoa(stats.SyntheticJobs)+" synthetic jobs enqueued; peak backlog "+strconv.Itoa(stats.MaxPending))
+	}
+	if stats.FailedJobs > 0 {
+		out = append(out, strconv.Itoa(stats.FailedJobs)+" real jobs failed during the experiment window")
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package control
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitForJobStatus(t *testing.T, q *Queue, id string, want JobStatus) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, ok := q.Get(id); ok && job.Status == want {
+			return *job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, _ := q.Get(id)
+	t.Fatalf("job %s did not reach %s: %+v", id, want, job)
+	return Job{}
+}
+
+func TestChaosInjectorWorkerKill(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exec := &blockingExecutor{started: make(chan string, 1), release: make(chan struct{})}
+	q := NewQueue(16)
+	store := NewChaosExperimentStore()
+	injector := NewChaosInjector(store, q)
+	q.StartWorker(ctx, injector.WrapExecutor(exec))
+
+	exp, err := store.Create(ChaosExperimentInput{Name: "kill", Target: "environment=staging", FaultType: ChaosFaultWorkerKill, Intensity: 100, DurationSec: 60})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if !exp.Injected || exp.Status != "running" {
+		t.Fatalf("expected running injected experiment, got %+v", exp)
+	}
+	if _, err := injector.Start(exp); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	job, err := q.EnqueuePlaced(JobPlacement{Environment: "staging"}, "site.yaml", "", false, "")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	<-exec.started
+	killed := waitForJobStatus(t, q, job.ID, JobFailed)
+	close(exec.release)
+	other, err := q.EnqueuePlaced(JobPlacement{Environment: "prod"}, "site.yaml", "", false, "")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	<-exec.started
+	waitForJobStatus(t, q, other.ID, JobSucceeded)
+	if !strings.Contains(killed.Error, exp.ID) {
+		t.Fatalf("expected kill reason to name experiment: %+v", killed)
+	}
+
+	done, err := injector.Stop(exp.ID, "completed", "")
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if done.Status != "completed" || done.Observations.JobsKilled != 1 || done.Observations.FailedJobs != 0 || len(done.Findings) == 0 {
+		t.Fatalf("unexpected finished experiment %+v", done)
+	}
+	if injector.Active(exp.ID) {
+		t.Fatalf("expected experiment to stop injecting")
+	}
+
+	// A kill that loses the race with completion must not leak.
+	injector.mu.Lock()
+	injector.killed["job-late"] = exp.ID
+	injector.mu.Unlock()
+	injector.onJob(Job{ID: "job-late", ConfigPath: "site.yaml", Status: JobSucceeded})
+	injector.mu.Lock()
+	leaked := len(injector.killed)
+	injector.mu.Unlock()
+	if leaked != 0 {
+		t.Fatalf("expected killed jobs to be cleared on any terminal status, %d left", leaked)
+	}
+	if _, err := store.Create(ChaosExperimentInput{Name: "vague", Target: "workers", FaultType: ChaosFaultWorkerKill, Intensity: 10}); err == nil {
+		t.Fatalf("expected worker-kill target without job selectors to be rejected")
+	}
+}
+
+func TestChaosInjectorSafetyGuards(t *testing.T) {
+	q := NewQueue(16)
+	store := NewChaosExperimentStore()
+	injector := NewChaosInjector(store, q)
+	injector.guardInterval = 10 * time.Millisecond
+
+	q.SetFreezeUntil(time.Now().Add(time.Hour), "release window")
+	frozen, _ := store.Create(ChaosExperimentInput{Name: "frozen", Target: "queue", FaultType: ChaosFaultQueueSaturation, Intensity: 5})
+	blocked, err := injector.Start(frozen)
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if blocked.Status != "blocked" || !strings.Contains(strings.Join(blocked.Findings, ";"), "change freeze") {
+		t.Fatalf("expected freeze to block experiment, got %+v", blocked)
+	}
+	q.ClearFreeze()
+
+	breach := make(chan string, 1)
+	injector.SetGuard(func(ChaosExperiment) string {
+		select {
+		case reason := <-breach:
+			return reason
+		default:
+			return ""
+		}
+	})
+	finished := make(chan ChaosExperiment, 1)
+	injector.OnFinish(func(exp ChaosExperiment) { finished <- exp })
+
+	// The queue has no worker, so saturation jobs stay pending.
+	exp, _ := store.Create(ChaosExperimentInput{Name: "saturate", Target: "queue", FaultType: ChaosFaultQueueSaturation, Intensity: 5, DurationSec: 60})
+	if _, err := injector.Start(exp); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if pending := q.ControlStatus().Pending; pending != 5 {
+		t.Fatalf("expected five synthetic jobs, got %d", pending)
+	}
+	breach <- "error budget exhausted"
+	var done ChaosExperiment
+	select {
+	case done = <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("experiment was not auto-aborted")
+	}
+	if done.Status != "aborted" || done.AbortReason != "error budget exhausted" || !done.AutoRollbackTriggered {
+		t.Fatalf("expected auto-abort, got %+v", done)
+	}
+	if done.Observations.SyntheticJobs != 5 || done.Observations.SyntheticCanceled != 5 || done.Observations.MaxPending != 5 {
+		t.Fatalf("unexpected observations %+v", done.Observations)
+	}
+	for _, job := range q.List() {
+		if job.Status != JobCanceled {
+			t.Fatalf("expected synthetic job to be canceled: %+v", job)
+		}
+	}
+}
+
+func TestChaosInjectorTransportDelayAndWebhookDrop(t *testing.T) {
+	store := NewChaosExperimentStore()
+	injector := NewChaosInjector(store, NewQueue(4))
+	if injector.TransportDelay() != 0 {
+		t.Fatalf("expected no delay without experiments")
+	}
+	delay, _ := store.Create(ChaosExperimentInput{Name: "slow", Target: "ssh", FaultType: ChaosFaultTransportDelay, Intensity: 10, DurationSec: 60})
+	drop, _ := store.Create(ChaosExperimentInput{Name: "drop", Target: "webhooks", FaultType: ChaosFaultWebhookDrop, Intensity: 100, DurationSec: 60})
+	for _, exp := range []ChaosExperiment{delay, drop} {
+		if _, err := injector.Start(exp); err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+	}
+	defer injector.Shutdown()
+	if got := injector.TransportDelay(); got != 200*time.Millisecond {
+		t.Fatalf("expected 200ms delay, got %s", got)
+	}
+	dispatcher := NewWebhookDispatcher(10)
+	dispatcher.SetChaosInjector(injector)
+	if _, err := dispatcher.Register(WebhookSubscription{Name: "ops", URL: "http://127.0.0.1:1/hook", EventPrefix: "job.", Enabled: true}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	deliveries := dispatcher.Dispatch(Event{Type: "job.failed"})
+	if len(deliveries) != 1 || !strings.Contains(deliveries[0].Error, drop.ID) {
+		t.Fatalf("expected dropped delivery, got %+v", deliveries)
+	}
+	injector.Shutdown()
+	got, _ := store.Get(drop.ID)
+	if got.Status != "aborted" || got.Observations.WebhooksDropped != 1 || got.AutoRollbackTriggered {
+		t.Fatalf("expected operator-style abort on shutdown, got %+v", got)
+	}
+}
@@ -0,0 +1,638 @@
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CloudCredentialAWSSTS = "aws_sts"
+	CloudCredentialGCPSTS = "gcp_sts"
+)
+
+// awsRegionPattern matches AWS region names such as us-east-1 or
+// us-gov-west-1. The region is spliced into the STS hostname, so anything
+// else could point the exchange at a host the operator did not choose.
+var awsRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d$`)
+
+// CloudCredentialBinding describes how to mint a cloud credential for a
+// job. The control plane's own federated identity token (SubjectTokenFile
+// or SubjectTokenEnv, e.g. a projected service account token) is first
+// authorized against an OIDC workload provider and then exchanged with the
+// cloud's STS: AssumeRoleWithWebIdentity for AWS, token exchange (and
+// optional service account impersonation) for GCP.
+type CloudCredentialBinding struct {
+	ID                        string    `json:"id"`
+	Name                      string    `json:"name"`
+	Provider                  string    `json:"provider"` // aws_sts|gcp_sts
+	OIDCProviderID            string    `json:"oidc_provider_id"`
+	ServiceAccount            string    `json:"service_account"`
+	SubjectTokenFile          string    `json:"subject_token_file,omitempty"`
+	SubjectTokenEnv           string    `json:"subject_token_env,omitempty"`
+	RoleARN                   string    `json:"role_arn,omitempty"`
+	Region                    string    `json:"region,omitempty"`
+	Audience                  string    `json:"audience,omitempty"`
+	ImpersonateServiceAccount string    `json:"impersonate_service_account,omitempty"`
+	Scopes                    []string  `json:"scopes,omitempty"`
+	DurationSeconds           int       `json:"duration_seconds"`
+	EnvPrefix                 string    `json:"env_prefix,omitempty"`
+	CreatedAt                 time.Time `json:"created_at"`
+}
+
+type CloudCredentialBindingInput struct {
+	Name                      string   `json:"name"`
+	Provider                  string   `json:"provider"`
+	OIDCProviderID            string   `json:"oidc_provider_id"`
+	ServiceAccount            string   `json:"service_account"`
+	SubjectTokenFile          string   `json:"subject_token_file,omitempty"`
+	SubjectTokenEnv           string   `json:"subject_token_env,omitempty"`
+	RoleARN                   string   `json:"role_arn,omitempty"`
+	Region                    string   `json:"region,omitempty"`
+	Audience                  string   `json:"audience,omitempty"`
+	ImpersonateServiceAccount string   `json:"impersonate_service_account,omitempty"`
+	Scopes                    []string `json:"scopes,omitempty"`
+	DurationSeconds           int      `json:"duration_seconds,omitempty"`
+	EnvPrefix                 string   `json:"env_prefix,omitempty"`
+}
+
+// CloudCredentialLease records one minted credential. Secret material is
+// never part of the lease.
+type CloudCredentialLease struct {
+	ID                    string     `json:"id"`
+	BindingID             string     `json:"binding_id"`
+	Provider              string     `json:"provider"`
+	JobID                 string     `json:"job_id"`
+	ExecutionCredentialID string     `json:"execution_credential_id,omitempty"`
+	OIDCCredentialID      string     `json:"oidc_credential_id,omitempty"`
+	AccessKeyID           string     `json:"access_key_id,omitempty"`
+	Principal             string     `json:"principal"`
+	Status                string     `json:"status"` // active|revoked|released|expired
+	IssuedAt              time.Time  `json:"issued_at"`
+	ExpiresAt             time.Time  `json:"expires_at"`
+	EndedAt               *time.Time `json:"ended_at,omitempty"`
+	Error                 string     `json:"error,omitempty"`
+}
+
+// CloudCredentialBroker mints short-lived cloud credentials for job runs
+// and ends them when the run finishes. GCP access tokens are revoked; AWS
+// session credentials cannot be revoked, so their lease is released and
+// they lapse at ExpiresAt (the 900s STS minimum by default).
+type CloudCredentialBroker struct {
+	mu          sync.RWMutex
+	nextBinding int64
+	nextLease   int64
+	bindings    map[string]*CloudCredentialBinding
+	leases      map[string]*CloudCredentialLease
+	leaseOrder  []string
+	revokeToken map[string]string
+
+	oidc     *OIDCWorkloadStore
+	traces   *SecretsIntegrationStore
+	client   *http.Client
+	readFile func(string) ([]byte, error)
+	getenv   func(string) string
+
+	// subjectFiles and subjectEnvs are the only token sources bindings may
+	// name, so a binding cannot read arbitrary control-plane files or
+	// environment variables and send them to an STS.
+	subjectFiles map[string]bool
+	subjectEnvs  map[string]bool
+
+	awsSTSURL    func(region string) string
+	gcpSTSURL    string
+	gcpIAMURL    string
+	gcpRevokeURL string
+}
+
+// NewCloudCredentialBroker allows the subject token files and environment
+// variables listed, comma-separated, in MC_CLOUD_SUBJECT_TOKEN_FILES and
+// MC_CLOUD_SUBJECT_TOKEN_ENVS.
+func NewCloudCredentialBroker(oidc *OIDCWorkloadStore, traces *SecretsIntegrationStore) *CloudCredentialBroker {
+	b := &CloudCredentialBroker{
+		bindings:    map[string]*CloudCredentialBinding{},
+		leases:      map[string]*CloudCredentialLease{},
+		revokeToken: map[string]string{},
+		oidc:        oidc,
+		traces:      traces,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		readFile:    os.ReadFile,
+		getenv:      os.Getenv,
+		awsSTSURL: func(region string) string {
+			if region == "" || !awsRegionPattern.MatchString(region) {
+				return "https://sts.amazonaws.com/"
+			}
+			return "https://sts." + region + ".amazonaws.com/"
+		},
+		gcpSTSURL:    "https://sts.googleapis.com/v1/token",
+		gcpIAMURL:    "https://iamcredentials.googleapis.com/v1",
+		gcpRevokeURL: "https://oauth2.googleapis.com/revoke",
+	}
+	b.SetSubjectTokenSources(strings.Split(os.Getenv("MC_CLOUD_SUBJECT_TOKEN_FILES"), ","), strings.Split(os.Getenv("MC_CLOUD_SUBJECT_TOKEN_ENVS"), ","))
+	return b
+}
+
+// SetSubjectTokenSources replaces the subject token files (absolute paths)
+// and environment variables bindings may use.
+func (b *CloudCredentialBroker) SetSubjectTokenSources(files, envs []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subjectFiles = map[string]bool{}
+	b.subjectEnvs = map[string]bool{}
+	for _, file := range files {
+		if file = strings.TrimSpace(file); file != "" && filepath.IsAbs(file) {
+			b.subjectFiles[filepath.Clean(file)] = true
+		}
+	}
+	for _, name := range envs {
+		if name = strings.TrimSpace(name); name != "" {
+			b.subjectEnvs[name] = true
+		}
+	}
+}
+
+func (b *CloudCredentialBroker) checkSubjectSource(binding CloudCredentialBinding) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if binding.SubjectTokenEnv != "" && !b.subjectEnvs[binding.SubjectTokenEnv] {
+		return errors.New("subject_token_env " + binding.SubjectTokenEnv + " is not listed in MC_CLOUD_SUBJECT_TOKEN_ENVS")
+	}
+	if binding.SubjectTokenFile != "" && !b.subjectFiles[binding.SubjectTokenFile] {
+		return errors.New("subject_token_file " + binding.SubjectTokenFile + " is not listed in MC_CLOUD_SUBJECT_TOKEN_FILES")
+	}
+	return nil
+}
+
+func (b *CloudCredentialBroker) CreateBinding(in CloudCredentialBindingInput) (CloudCredentialBinding, error) {
+	item := CloudCredentialBinding{
+		Name:                      strings.TrimSpace(in.Name),
+		Provider:                  strings.ToLower(strings.TrimSpace(in.Provider)),
+		OIDCProviderID:            strings.TrimSpace(in.OIDCProviderID),
+		ServiceAccount:            strings.TrimSpace(in.ServiceAccount),
+		SubjectTokenFile:          strings.TrimSpace(in.SubjectTokenFile),
+		SubjectTokenEnv:           strings.TrimSpace(in.SubjectTokenEnv),
+		RoleARN:                   strings.TrimSpace(in.RoleARN),
+		Region:                    strings.TrimSpace(in.Region),
+		Audience:                  strings.TrimSpace(in.Audience),
+		ImpersonateServiceAccount: strings.TrimSpace(in.ImpersonateServiceAccount),
+		DurationSeconds:           in.DurationSeconds,
+		EnvPrefix:                 strings.ToUpper(strings.TrimSpace(in.EnvPrefix)),
+	}
+	for _, scope := range in.Scopes {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			item.Scopes = append(item.Scopes, scope)
+		}
+	}
+	if item.Name == "" || item.OIDCProviderID == "" || item.ServiceAccount == "" {
+		return CloudCredentialBinding{}, errors.New("name, oidc_provider_id, and service_account are required")
+	}
+	if (item.SubjectTokenFile == "") == (item.SubjectTokenEnv == "") {
+		return CloudCredentialBinding{}, errors.New("exactly one of subject_token_file or subject_token_env is required")
+	}
+	if item.SubjectTokenFile != "" {
+		if !filepath.IsAbs(item.SubjectTokenFile) {
+			return CloudCredentialBinding{}, errors.New("subject_token_file must be an absolute path")
+		}
+		item.SubjectTokenFile = filepath.Clean(item.SubjectTokenFile)
+	}
+	if err := b.checkSubjectSource(item); err != nil {
+		return CloudCredentialBinding{}, err
+	}
+	if _, ok := b.oidc.GetProvider(item.OIDCProviderID); !ok {
+		return CloudCredentialBinding{}, errors.New("oidc workload provider not found")
+	}
+	if strings.ContainsAny(item.EnvPrefix, "= ") {
+		return CloudCredentialBinding{}, errors.New("env_prefix must not contain '=' or spaces")
+	}
+	if item.DurationSeconds < 0 {
+		return CloudCredentialBinding{}, errors.New("duration_seconds must be positive")
+	}
+	switch item.Provider {
+	case CloudCredentialAWSSTS:
+		if !strings.HasPrefix(item.RoleARN, "arn:") {
+			return CloudCredentialBinding{}, errors.New("role_arn is required for aws_sts")
+		}
+		if item.Region != "" && !awsRegionPattern.MatchString(item.Region) {
+			return CloudCredentialBinding{}, errors.New("region must be an AWS region name such as us-east-1")
+		}
+		if item.DurationSeconds == 0 {
+			item.DurationSeconds = 900
+		}
+		if item.DurationSeconds < 900 || item.DurationSeconds > 43200 {
+			return CloudCredentialBinding{}, errors.New("duration_seconds must be between 900 and 43200 for aws_sts")
+		}
+	case CloudCredentialGCPSTS:
+		if !strings.HasPrefix(item.Audience, "//iam.googleapis.com/") {
+			return CloudCredentialBinding{}, errors.New("audience must be a workload identity provider (//iam.googleapis.com/...) for gcp_sts")
+		}
+		if len(item.Scopes) == 0 {
+			item.Scopes = []string{"https://www.googleapis.com/auth/cloud-platform"}
+		}
+		if item.DurationSeconds == 0 {
+			item.DurationSeconds = 900
+		}
+		if item.DurationSeconds > 3600 {
+			return CloudCredentialBinding{}, errors.New("duration_seconds must be at most 3600 for gcp_sts")
+		}
+	default:
+		return CloudCredentialBinding{}, errors.New("provider must be aws_sts or gcp_sts")
+	}
+	item.CreatedAt = time.Now().UTC()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, existing := range b.bindings {
+		if strings.EqualFold(existing.Name, item.Name) {
+			return CloudCredentialBinding{}, errors.New("cloud credential binding name already exists")
+		}
+	}
+	b.nextBinding++
+	item.ID = "cloud-cred-binding-" + itoa(b.nextBinding)
+	b.bindings[item.ID] = &item
+	return cloneCloudCredentialBinding(item), nil
+}
+
+func (b *CloudCredentialBroker) ListBindings() []CloudCredentialBinding {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := make([]CloudCredentialBinding, 0, len(b.bindings))
+	for _, item := range b.bindings {
+		out = append(out, cloneCloudCredentialBinding(*item))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// GetBinding looks a binding up by ID or name.
+func (b *CloudCredentialBroker) GetBinding(ref string) (CloudCredentialBinding, bool) {
+	ref = strings.TrimSpace(ref)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if item, ok := b.bindings[ref]; ok {
+		return cloneCloudCredentialBinding(*item), true
+	}
+	for _, item := range b.bindings {
+		if strings.EqualFold(item.Name, ref) {
+			return cloneCloudCredentialBinding(*item), true
+		}
+	}
+	return CloudCredentialBinding{}, false
+}
+
+// Mint exchanges the binding's subject token for a cloud credential and
+// returns the environment variables that carry it into the run.
+func (b *CloudCredentialBroker) Mint(ref, jobID, executionCredentialID string) (CloudCredentialLease, map[string]string, error) {
+	binding, ok := b.GetBinding(ref)
+	if !ok {
+		return CloudCredentialLease{}, nil, errors.New("cloud credential binding " + strings.TrimSpace(ref) + " not found")
+	}
+	subject, err := b.subjectToken(binding)
+	if err != nil {
+		return CloudCredentialLease{}, nil, err
+	}
+	workload, err := b.oidc.Exchange(OIDCWorkloadExchangeInput{
+		ProviderID:     binding.OIDCProviderID,
+		SubjectToken:   subject,
+		ServiceAccount: binding.ServiceAccount,
+		Workload:       "job:" + jobID,
+	})
+	if err != nil {
+		return CloudCredentialLease{}, nil, errors.New("oidc workload exchange: " + err.Error())
+	}
+	lease := CloudCredentialLease{
+		BindingID:             binding.ID,
+		Provider:              binding.Provider,
+		JobID:                 jobID,
+		ExecutionCredentialID: executionCredentialID,
+		OIDCCredentialID:      workload.ID,
+		Status:                "active",
+		IssuedAt:              time.Now().UTC(),
+	}
+	env := map[string]string{}
+	revoke := ""
+	switch binding.Provider {
+	case CloudCredentialAWSSTS:
+		creds, err := b.assumeRoleWithWebIdentity(binding, subject, jobID)
+		if err != nil {
+			return CloudCredentialLease{}, nil, err
+		}
+		lease.Principal = binding.RoleARN
+		lease.AccessKeyID = creds.AccessKeyID
+		lease.ExpiresAt = creds.Expiration.UTC()
+		env["AWS_ACCESS_KEY_ID"] = creds.AccessKeyID
+		env["AWS_SECRET_ACCESS_KEY"] = creds.SecretAccessKey
+		env["AWS_SESSION_TOKEN"] = creds.SessionToken
+		if binding.Region != "" {
+			env["AWS_REGION"] = binding.Region
+			env["AWS_DEFAULT_REGION"] = binding.Region
+		}
+	case CloudCredentialGCPSTS:
+		token, expires, err := b.gcpAccessToken(binding, subject)
+		if err != nil {
+			return CloudCredentialLease{}, nil, err
+		}
+		lease.Principal = binding.Audience
+		if binding.ImpersonateServiceAccount != "" {
+			lease.Principal = binding.ImpersonateServiceAccount
+		}
+		lease.ExpiresAt = expires
+		revoke = token
+		env["CLOUDSDK_AUTH_ACCESS_TOKEN"] = token
+		env["GOOGLE_OAUTH_ACCESS_TOKEN"] = token
+	}
+	if binding.EnvPrefix != "" {
+		prefixed := make(map[string]string, len(env))
+		for k, v := range env {
+			prefixed[binding.EnvPrefix+k] = v
+		}
+		env = prefixed
+	}
+
+	b.mu.Lock()
+	b.nextLease++
+	lease.ID = "cloud-cred-lease-" + itoa(b.nextLease)
+	b.leases[lease.ID] = &lease
+	b.leaseOrder = append(b.leaseOrder, lease.ID)
+	if len(b.leaseOrder) > 2000 {
+		for _, id := range b.leaseOrder[:len(b.leaseOrder)-2000] {
+			delete(b.leases, id)
+			delete(b.revokeToken, id)
+		}
+		b.leaseOrder = append([]string{}, b.leaseOrder[len(b.leaseOrder)-2000:]...)
+	}
+	if revoke != "" {
+		b.revokeToken[lease.ID] = revoke
+	}
+	out := lease
+	b.mu.Unlock()
+
+	if b.traces != nil {
+		b.traces.RecordUsage(SecretUsageTrace{
+			IntegrationID: binding.ID,
+			Path:          binding.Provider + ":" + lease.Principal,
+			Version:       lease.ID,
+			UsedBy:        "job:" + jobID,
+			ResolvedAt:    lease.IssuedAt,
+		})
+	}
+	return out, env, nil
+}
+
+// Release ends a lease once its run is over.
+func (b *CloudCredentialBroker) Release(leaseID string) (CloudCredentialLease, error) {
+	leaseID = strings.TrimSpace(leaseID)
+	b.mu.Lock()
+	lease, ok := b.leases[leaseID]
+	if !ok {
+		b.mu.Unlock()
+		return CloudCredentialLease{}, errors.New("cloud credential lease not found")
+	}
+	if lease.Status != "active" {
+		out := *lease
+		b.mu.Unlock()
+		return out, nil
+	}
+	token := b.revokeToken[leaseID]
+	delete(b.revokeToken, leaseID)
+	b.mu.Unlock()
+
+	var revokeErr error
+	if token != "" {
+		revokeErr = b.revokeGCPToken(token)
+	}
+	now := time.Now().UTC()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lease.EndedAt = &now
+	switch {
+	case lease.Provider == CloudCredentialAWSSTS:
+		lease.Status = "released"
+	case revokeErr != nil:
+		// The token still lapses at ExpiresAt.
+		lease.Status = "released"
+		lease.Error = "revoke failed: " + revokeErr.Error()
+	default:
+		lease.Status = "revoked"
+	}
+	return *lease, nil
+}
+
+func (b *CloudCredentialBroker) GetLease(id string) (CloudCredentialLease, bool) {
+	now := time.Now().UTC()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lease, ok := b.leases[strings.TrimSpace(id)]
+	if !ok {
+		return CloudCredentialLease{}, false
+	}
+	b.expireLeaseLocked(lease, now)
+	return *lease, true
+}
+
+func (b *CloudCredentialBroker) ListLeases(jobID string, limit int) []CloudCredentialLease {
+	jobID = strings.TrimSpace(jobID)
+	now := time.Now().UTC()
+	b.mu.Lock()
+	out := make([]CloudCredentialLease, 0, len(b.leases))
+	for _, lease := range b.leases {
+		if jobID != "" && lease.JobID != jobID {
+			continue
+		}
+		b.expireLeaseLocked(lease, now)
+		out = append(out, *lease)
+	}
+	b.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].IssuedAt.After(out[j].IssuedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (b *CloudCredentialBroker) expireLeaseLocked(lease *CloudCredentialLease, now time.Time) {
+	if lease.Status == "active" && !now.Before(lease.ExpiresAt) {
+		lease.Status = "expired"
+		delete(b.revokeToken, lease.ID)
+	}
+}
+
+func (b *CloudCredentialBroker) subjectToken(binding CloudCredentialBinding) (string, error) {
+	if err := b.checkSubjectSource(binding); err != nil {
+		return "", err
+	}
+	if binding.SubjectTokenEnv != "" {
+		token := strings.TrimSpace(b.getenv(binding.SubjectTokenEnv))
+		if token == "" {
+			return "", errors.New("subject token env " + binding.SubjectTokenEnv + " is empty")
+		}
+		return token, nil
+	}
+	raw, err := b.readFile(binding.SubjectTokenFile)
+	if err != nil {
+		return "", errors.New("read subject token: " + err.Error())
+	}
+	token := strings.TrimSpace(string(raw))
+	if token == "" {
+		return "", errors.New("subject token file " + binding.SubjectTokenFile + " is empty")
+	}
+	return token, nil
+}
+
+type awsSessionCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+// assumeRoleWithWebIdentity calls STS without request signing; the web
+// identity token is the proof of identity.
+func (b *CloudCredentialBroker) assumeRoleWithWebIdentity(binding CloudCredentialBinding, subject, jobID string) (awsSessionCredentials, error) {
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {binding.RoleARN},
+		"RoleSessionName":  {awsRoleSessionName("masterchef-" + jobID)},
+		"WebIdentityToken": {subject},
+		"DurationSeconds":  {strconv.Itoa(binding.DurationSeconds)},
+	}
+	resp, err := b.client.PostForm(b.awsSTSURL(binding.Region), form)
+	if err != nil {
+		return awsSessionCredentials{}, errors.New("aws sts: " + err.Error())
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		if xml.Unmarshal(body, &failure) == nil && failure.Code != "" {
+			return awsSessionCredentials{}, errors.New("aws sts: " + failure.Code + ": " + failure.Message)
+		}
+		return awsSessionCredentials{}, errors.New("aws sts returned status " + strconv.Itoa(resp.StatusCode))
+	}
+	var parsed struct {
+		Credentials awsSessionCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return awsSessionCredentials{}, errors.New("aws sts: invalid response: " + err.Error())
+	}
+	if parsed.Credentials.AccessKeyID == "" || parsed.Credentials.SessionToken == "" {
+		return awsSessionCredentials{}, errors.New("aws sts: response has no credentials")
+	}
+	return parsed.Credentials, nil
+}
+
+func (b *CloudCredentialBroker) gcpAccessToken(binding CloudCredentialBinding, subject string) (string, time.Time, error) {
+	var federated struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err := b.postJSON(b.gcpSTSURL, "", map[string]any{
+		"grantType":          "urn:ietf:params:oauth:grant-type:token-exchange",
+		"audience":           binding.Audience,
+		"scope":              strings.Join(binding.Scopes, " "),
+		"requestedTokenType": "urn:ietf:params:oauth:token-type:access_token",
+		"subjectTokenType":   "urn:ietf:params:oauth:token-type:jwt",
+		"subjectToken":       subject,
+	}, &federated)
+	if err != nil {
+		return "", time.Time{}, errors.New("gcp sts: " + err.Error())
+	}
+	if federated.AccessToken == "" {
+		return "", time.Time{}, errors.New("gcp sts: response has no access_token")
+	}
+	if binding.ImpersonateServiceAccount == "" {
+		return federated.AccessToken, time.Now().UTC().Add(time.Duration(federated.ExpiresIn) * time.Second), nil
+	}
+	var impersonated struct {
+		AccessToken string    `json:"accessToken"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}
+	err = b.postJSON(b.gcpIAMURL+"/projects/-/serviceAccounts/"+url.PathEscape(binding.ImpersonateServiceAccount)+":generateAccessToken", federated.AccessToken, map[string]any{
+		"scope":    binding.Scopes,
+		"lifetime": strconv.Itoa(binding.DurationSeconds) + "s",
+	}, &impersonated)
+	if err != nil {
+		return "", time.Time{}, errors.New("gcp service account impersonation: " + err.Error())
+	}
+	if impersonated.AccessToken == "" {
+		return "", time.Time{}, errors.New("gcp service account impersonation: response has no accessToken")
+	}
+	return impersonated.AccessToken, impersonated.ExpireTime.UTC(), nil
+}
+
+func (b *CloudCredentialBroker) revokeGCPToken(token string) error {
+	resp, err := b.client.PostForm(b.gcpRevokeURL, url.Values{"token": {token}})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+func (b *CloudCredentialBroker) postJSON(endpoint, bearer string, payload any, out any) error {
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error            any    `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		_ = json.Unmarshal(raw, &failure)
+		if failure.ErrorDescription != "" {
+			return errors.New(failure.ErrorDescription)
+		}
+		return errors.New("status " + strconv.Itoa(resp.StatusCode))
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// awsRoleSessionName keeps the characters STS accepts ([\w+=,.@-]) and
+// its 64 character limit.
+func awsRoleSessionName(in string) string {
+	out := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_+=,.@-", r) {
+			return r
+		}
+		return '-'
+	}, in)
+	if len(out) > 64 {
+		out = out[:64]
+	}
+	return out
+}
+
+func cloneCloudCredentialBinding(in CloudCredentialBinding) CloudCredentialBinding {
+	out := in
+	out.Scopes = append([]string{}, in.Scopes...)
+	return out
+}
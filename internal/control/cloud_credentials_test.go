@@ -0,0 +1,216 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCloudCredentialTestBroker(t *testing.T) (*CloudCredentialBroker, *SecretsIntegrationStore, string, string) {
+	t.Helper()
+	oidc := NewOIDCWorkloadStore()
+	provider, err := oidc.CreateProvider(OIDCWorkloadProviderInput{
+		Name:      "control-plane",
+		IssuerURL: "https://issuer.example.com",
+		Audience:  "sts.amazonaws.com",
+		JWKSURL:   "https://issuer.example.com/jwks",
+		AllowedSA: []string{"masterchef-runner"},
+	})
+	if err != nil {
+		t.Fatalf("create oidc provider failed: %v", err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("subject-jwt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	traces := NewSecretsIntegrationStore()
+	b := NewCloudCredentialBroker(oidc, traces)
+	b.SetSubjectTokenSources([]string{tokenFile}, []string{"MC_TEST_SUBJECT"})
+	return b, traces, provider.ID, tokenFile
+}
+
+func TestCloudCredentialBrokerAWSSTS(t *testing.T) {
+	b, traces, providerID, tokenFile := newCloudCredentialTestBroker(t)
+	expires := time.Now().UTC().Add(15 * time.Minute).Truncate(time.Second)
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("WebIdentityToken") != "subject-jwt" ||
+			r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/deploy" || r.Form.Get("DurationSeconds") != "900" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<ErrorResponse><Error><Code>InvalidParameter</Code><Message>bad request</Message></Error></ErrorResponse>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIATEST</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>` + expires.Format(time.RFC3339) + `</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+	b.awsSTSURL = func(string) string { return sts.URL }
+
+	if _, err := b.CreateBinding(CloudCredentialBindingInput{Name: "x", Provider: "aws_sts", OIDCProviderID: providerID, ServiceAccount: "masterchef-runner", SubjectTokenFile: tokenFile}); err == nil {
+		t.Fatalf("expected aws binding without role_arn to fail")
+	}
+	for _, in := range []CloudCredentialBindingInput{
+		{Name: "x", Provider: "aws_sts", OIDCProviderID: providerID, ServiceAccount: "masterchef-runner", SubjectTokenFile: tokenFile, RoleARN: "arn:aws:iam::123456789012:role/deploy", Region: "evil.example.com/"},
+		{Name: "x", Provider: "aws_sts", OIDCProviderID: providerID, ServiceAccount: "masterchef-runner", SubjectTokenFile: "/etc/shadow", RoleARN: "arn:aws:iam::123456789012:role/deploy"},
+		{Name: "x", Provider: "aws_sts", OIDCProviderID: providerID, ServiceAccount: "masterchef-runner", SubjectTokenEnv: "AWS_SECRET_ACCESS_KEY", RoleARN: "arn:aws:iam::123456789012:role/deploy"},
+	} {
+		if _, err := b.CreateBinding(in); err == nil {
+			t.Fatalf("expected binding %+v to be rejected", in)
+		}
+	}
+	binding, err := b.CreateBinding(CloudCredentialBindingInput{
+		Name:             "deploy-aws",
+		Provider:         "aws_sts",
+		OIDCProviderID:   providerID,
+		ServiceAccount:   "masterchef-runner",
+		SubjectTokenFile: tokenFile,
+		RoleARN:          "arn:aws:iam::123456789012:role/deploy",
+		Region:           "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("create binding failed: %v", err)
+	}
+	lease, env, err := b.Mint("deploy-aws", "job-1", "exec-cred-1")
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	if env["AWS_ACCESS_KEY_ID"] != "ASIATEST" || env["AWS_SECRET_ACCESS_KEY"] != "secret" || env["AWS_SESSION_TOKEN"] != "session" || env["AWS_REGION"] != "us-east-1" {
+		t.Fatalf("unexpected aws env: %+v", env)
+	}
+	if lease.BindingID != binding.ID || lease.Status != "active" || !lease.ExpiresAt.Equal(expires) || lease.OIDCCredentialID == "" || lease.ExecutionCredentialID != "exec-cred-1" {
+		t.Fatalf("unexpected lease: %+v", lease)
+	}
+	trace := traces.ListUsageTraces(10)
+	if len(trace) != 1 || trace[0].IntegrationID != binding.ID || trace[0].UsedBy != "job:job-1" || trace[0].RedactedValue != "<redacted>" {
+		t.Fatalf("expected usage trace for minted credential: %+v", trace)
+	}
+	released, err := b.Release(lease.ID)
+	if err != nil || released.Status != "released" || released.EndedAt == nil {
+		t.Fatalf("expected aws lease to be released: %+v err=%v", released, err)
+	}
+}
+
+func TestCloudCredentialBrokerGCPImpersonationAndRevoke(t *testing.T) {
+	b, _, providerID, _ := newCloudCredentialTestBroker(t)
+	var revoked atomic.Int32
+	gcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/token":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["subjectToken"] != "subject-jwt" || !strings.HasPrefix(body["audience"], "//iam.googleapis.com/") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"bad subject"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"federated","expires_in":3600}`))
+		case strings.HasSuffix(r.URL.Path, ":generateAccessToken"):
+			if r.Header.Get("Authorization") != "Bearer federated" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"accessToken":"impersonated","expireTime":"` + time.Now().UTC().Add(10*time.Minute).Format(time.RFC3339) + `"}`))
+		case r.URL.Path == "/revoke":
+			_ = r.ParseForm()
+			if r.Form.Get("token") == "impersonated" {
+				revoked.Add(1)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gcp.Close()
+	b.gcpSTSURL = gcp.URL + "/v1/token"
+	b.gcpIAMURL = gcp.URL + "/v1"
+	b.gcpRevokeURL = gcp.URL + "/revoke"
+	b.getenv = func(name string) string {
+		if name == "MC_TEST_SUBJECT" {
+			return "subject-jwt"
+		}
+		return ""
+	}
+
+	if _, err := b.CreateBinding(CloudCredentialBindingInput{
+		Name:                      "deploy-gcp",
+		Provider:                  "gcp_sts",
+		OIDCProviderID:            providerID,
+		ServiceAccount:            "masterchef-runner",
+		SubjectTokenEnv:           "MC_TEST_SUBJECT",
+		Audience:                  "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/mc",
+		ImpersonateServiceAccount: "deployer@proj.iam.gserviceaccount.com",
+		EnvPrefix:                 "prod_",
+	}); err != nil {
+		t.Fatalf("create gcp binding failed: %v", err)
+	}
+	lease, env, err := b.Mint("deploy-gcp", "job-2", "")
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	if env["PROD_CLOUDSDK_AUTH_ACCESS_TOKEN"] != "impersonated" || lease.Principal != "deployer@proj.iam.gserviceaccount.com" {
+		t.Fatalf("unexpected gcp env or lease: %+v %+v", env, lease)
+	}
+	released, err := b.Release(lease.ID)
+	if err != nil || released.Status != "revoked" || revoked.Load() != 1 {
+		t.Fatalf("expected gcp token revocation: %+v err=%v revoked=%d", released, err, revoked.Load())
+	}
+	if leases := b.ListLeases("job-2", 10); len(leases) != 1 || leases[0].Status != "revoked" {
+		t.Fatalf("unexpected leases: %+v", leases)
+	}
+}
+
+func TestIsolatedRunnerInjectsCloudCredentials(t *testing.T) {
+	r, _, env, cfg := newIsolatedTestRunner(t, ExecutionIsolation{Mode: "container", Image: "registry.local/runner"})
+	b, _, providerID, tokenFile := newCloudCredentialTestBroker(t)
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIARUN</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>` + time.Now().UTC().Add(15*time.Minute).Format(time.RFC3339) + `</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+	b.awsSTSURL = func(string) string { return sts.URL }
+	if _, err := b.CreateBinding(CloudCredentialBindingInput{
+		Name:             "deploy-aws",
+		Provider:         "aws_sts",
+		OIDCProviderID:   providerID,
+		ServiceAccount:   "masterchef-runner",
+		SubjectTokenFile: tokenFile,
+		RoleARN:          "arn:aws:iam::123456789012:role/deploy",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r.SetCloudCredentialBroker(b)
+	var envFileData string
+	r.command = func(ctx context.Context, cmd isolationCommand) (string, int, error) {
+		for i, arg := range cmd.args {
+			if arg == "--env-file" {
+				data, _ := os.ReadFile(cmd.args[i+1])
+				envFileData = string(data)
+			}
+		}
+		return "", 0, nil
+	}
+	if err := r.ApplyJob(Job{ID: "job-7", ConfigPath: cfg, ExecutionEnv: env.ID, CloudCredentials: []string{"deploy-aws"}}); err != nil {
+		t.Fatalf("apply job failed: %v", err)
+	}
+	if !strings.Contains(envFileData, "AWS_SESSION_TOKEN=") || !strings.Contains(envFileData, "AWS_ACCESS_KEY_ID=ASIARUN") {
+		t.Fatalf("expected aws credentials in run env file: %q", envFileData)
+	}
+	run, _ := r.Get("job-7")
+	if len(run.CloudLeases) != 1 {
+		t.Fatalf("expected lease on run record: %+v", run)
+	}
+	if lease, _ := b.GetLease(run.CloudLeases[0]); lease.Status != "released" {
+		t.Fatalf("expected lease released after run: %+v", lease)
+	}
+	if err := r.ApplyJob(Job{ID: "job-8", ConfigPath: cfg, CloudCredentials: []string{"deploy-aws"}}); err == nil {
+		t.Fatalf("expected cloud credentials without isolation to fail")
+	}
+}
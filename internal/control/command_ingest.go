@@ -3,6 +3,7 @@ package control
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"sort"
 	"strings"
 	"sync"
@@ -18,6 +19,12 @@ type CommandEnvelope struct {
 	Checksum       string    `json:"checksum,omitempty"`
 	Status         string    `json:"status"`
 	Reason         string    `json:"reason,omitempty"`
+	FailureClass   string    `json:"failure_class,omitempty"`
+	Attempts       int       `json:"attempts,omitempty"`
+	NextRetryAt    time.Time `json:"next_retry_at,omitempty"`
+	ReplayedAs     string    `json:"replayed_as,omitempty"`
+	ReplayJobID    string    `json:"replay_job_id,omitempty"`
+	ReplayedAt     time.Time `json:"replayed_at,omitempty"`
 	ReceivedAt     time.Time `json:"received_at"`
 }
 
@@ -28,6 +35,22 @@ type CommandIngestStore struct {
 	deadLetters   []CommandEnvelope
 	byIdempotency map[string]string
 	deadLimit     int
+	batch         CommandBatchPolicy
+	retry         CommandRetryPolicy
+}
+
+const (
+	CommandBatchIndividual = "individual"
+	CommandBatchAtomic     = "atomic"
+)
+
+// CommandBatchPolicy bounds batch ingest: how many envelopes one request
+// may carry and whether a batch is accepted per item or all-or-nothing
+// when the request does not say.
+type CommandBatchPolicy struct {
+	MaxItems    int       `json:"max_items"`
+	DefaultMode string    `json:"default_mode"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
 }
 
 func NewCommandIngestStore(deadLetterLimit int) *CommandIngestStore {
@@ -39,7 +62,44 @@ func NewCommandIngestStore(deadLetterLimit int) *CommandIngestStore {
 		deadLetters:   make([]CommandEnvelope, 0, deadLetterLimit),
 		byIdempotency: map[string]string{},
 		deadLimit:     deadLetterLimit,
+		batch:         CommandBatchPolicy{MaxItems: 500, DefaultMode: CommandBatchIndividual},
+		retry:         defaultCommandRetryPolicy(),
+	}
+}
+
+// NormalizeCommandBatchMode resolves a batch mode, falling back to def when
+// mode is empty.
+func NormalizeCommandBatchMode(mode, def string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = def
+	}
+	switch mode {
+	case CommandBatchIndividual, CommandBatchAtomic:
+		return mode, nil
+	default:
+		return "", errors.New("batch mode must be individual or atomic")
+	}
+}
+
+func (s *CommandIngestStore) BatchPolicy() CommandBatchPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.batch
+}
+
+func (s *CommandIngestStore) SetBatchPolicy(in CommandBatchPolicy) (CommandBatchPolicy, error) {
+	if in.MaxItems <= 0 || in.MaxItems > 10000 {
+		return CommandBatchPolicy{}, errors.New("max_items must be between 1 and 10000")
+	}
+	mode, err := NormalizeCommandBatchMode(in.DefaultMode, CommandBatchIndividual)
+	if err != nil {
+		return CommandBatchPolicy{}, err
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batch = CommandBatchPolicy{MaxItems: in.MaxItems, DefaultMode: mode, UpdatedAt: time.Now().UTC()}
+	return s.batch, nil
 }
 
 func ComputeCommandChecksum(action, configPath, priority, idempotencyKey string) string {
@@ -83,7 +143,10 @@ func (s *CommandIngestStore) RecordDeadLetter(input CommandEnvelope, reason stri
 	env.ID = "dlq-" + itoa(s.nextID)
 	env.Status = "dead_letter"
 	env.Reason = strings.TrimSpace(reason)
+	env.FailureClass = ClassifyCommandFailure(env.Reason)
+	env.Attempts = 1
 	env.ReceivedAt = time.Now().UTC()
+	env.NextRetryAt = s.nextRetryLocked(env, env.ReceivedAt)
 
 	if len(s.deadLetters) >= s.deadLimit {
 		copy(s.deadLetters[0:], s.deadLetters[1:])
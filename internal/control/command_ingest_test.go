@@ -1,6 +1,9 @@
 package control
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestComputeCommandChecksumDeterministic(t *testing.T) {
 	a := ComputeCommandChecksum("apply", "cfg.yaml", "HIGH", "k1")
@@ -27,3 +30,64 @@ func TestCommandIngestStore_AcceptedAndDeadLetters(t *testing.T) {
 		t.Fatalf("expected dead letter limit to apply, got %d", len(dead))
 	}
 }
+
+func TestCommandIngestStore_BatchPolicy(t *testing.T) {
+	s := NewCommandIngestStore(10)
+	if p := s.BatchPolicy(); p.MaxItems != 500 || p.DefaultMode != CommandBatchIndividual {
+		t.Fatalf("unexpected default batch policy: %+v", p)
+	}
+	if _, err := s.SetBatchPolicy(CommandBatchPolicy{MaxItems: 0}); err == nil {
+		t.Fatalf("expected non-positive max_items rejected")
+	}
+	if _, err := s.SetBatchPolicy(CommandBatchPolicy{MaxItems: 10, DefaultMode: "some"}); err == nil {
+		t.Fatalf("expected unknown mode rejected")
+	}
+	p, err := s.SetBatchPolicy(CommandBatchPolicy{MaxItems: 10, DefaultMode: "ATOMIC"})
+	if err != nil || p.DefaultMode != CommandBatchAtomic {
+		t.Fatalf("unexpected policy update: %+v err=%v", p, err)
+	}
+	if mode, _ := NormalizeCommandBatchMode("", p.DefaultMode); mode != CommandBatchAtomic {
+		t.Fatalf("expected empty mode to use the policy default, got %q", mode)
+	}
+}
+
+func TestCommandIngestStore_RetryPolicyAndReplay(t *testing.T) {
+	s := NewCommandIngestStore(10)
+	if got := ClassifyCommandFailure("change freeze active until 2026-01-01T00:00:00Z"); got != CommandFailureFreeze {
+		t.Fatalf("unexpected failure class %q", got)
+	}
+	if _, err := s.SetRetryPolicy(CommandRetryPolicy{Enabled: true, MaxAttempts: 3, BackoffSeconds: 10, Classes: []string{"validation"}}); err == nil {
+		t.Fatalf("expected validation class rejected for auto-retry")
+	}
+	if _, err := s.SetRetryPolicy(CommandRetryPolicy{Enabled: true, MaxAttempts: 2, BackoffSeconds: 10, MaxBackoffSeconds: 60, Classes: []string{"capacity"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := s.RecordDeadLetter(CommandEnvelope{Action: "apply"}, "checksum mismatch")
+	if invalid.FailureClass != CommandFailureValidation || !invalid.NextRetryAt.IsZero() {
+		t.Fatalf("expected validation failure not scheduled for retry: %+v", invalid)
+	}
+	full := s.RecordDeadLetter(CommandEnvelope{Action: "apply"}, "pending queue full for priority class: low")
+	if full.FailureClass != CommandFailureCapacity || full.NextRetryAt.Sub(full.ReceivedAt) != 10*time.Second {
+		t.Fatalf("expected capacity failure retried after backoff: %+v", full)
+	}
+	due := s.DueRetries(full.NextRetryAt)
+	if len(due) != 1 || due[0].ID != full.ID {
+		t.Fatalf("expected one due retry, got %+v", due)
+	}
+	again, err := s.RecordReplayFailure(full.ID, "pending queue full for priority class: low")
+	if err != nil || again.Attempts != 2 || !again.NextRetryAt.IsZero() {
+		t.Fatalf("expected retries exhausted after max attempts: %+v err=%v", again, err)
+	}
+
+	replayed, err := s.MarkReplayed(invalid.ID, "cmd-9", "job-9")
+	if err != nil || replayed.Status != "replayed" || replayed.ReplayJobID != "job-9" {
+		t.Fatalf("unexpected replay marking: %+v err=%v", replayed, err)
+	}
+	if got := s.FilterDeadLetters(CommandDeadLetterFilter{Status: "dead_letter"}); len(got) != 1 || got[0].ID != full.ID {
+		t.Fatalf("expected only unreplayed dead letters, got %+v", got)
+	}
+	if _, err := s.GetDeadLetter("dlq-missing"); err == nil {
+		t.Fatalf("expected missing dead letter error")
+	}
+}
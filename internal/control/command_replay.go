@@ -0,0 +1,244 @@
+package control
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Failure classes assigned to dead-lettered commands.
+const (
+	CommandFailureValidation    = "validation"
+	CommandFailureCapacity      = "capacity"
+	CommandFailureFreeze        = "freeze"
+	CommandFailureEmergencyStop = "emergency_stop"
+	CommandFailureDraining      = "draining"
+	CommandFailureOther         = "other"
+)
+
+// CommandRetryPolicy retries dead letters of transient failure classes
+// with exponential backoff until MaxAttempts deliveries have failed.
+type CommandRetryPolicy struct {
+	Enabled           bool      `json:"enabled"`
+	MaxAttempts       int       `json:"max_attempts"`
+	BackoffSeconds    int       `json:"backoff_seconds"`
+	MaxBackoffSeconds int       `json:"max_backoff_seconds"`
+	Classes           []string  `json:"classes"`
+	UpdatedAt         time.Time `json:"updated_at,omitempty"`
+}
+
+// CommandDeadLetterFilter selects dead letters for listing and bulk replay.
+// Empty fields match everything.
+type CommandDeadLetterFilter struct {
+	Status       string    `json:"status,omitempty"`
+	FailureClass string    `json:"failure_class,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Action       string    `json:"action,omitempty"`
+	ConfigPath   string    `json:"config_path,omitempty"`
+	Since        time.Time `json:"since,omitempty"`
+	Limit        int       `json:"limit,omitempty"`
+}
+
+func defaultCommandRetryPolicy() CommandRetryPolicy {
+	return CommandRetryPolicy{
+		MaxAttempts:       5,
+		BackoffSeconds:    30,
+		MaxBackoffSeconds: 900,
+		Classes:           []string{CommandFailureCapacity, CommandFailureFreeze, CommandFailureDraining},
+	}
+}
+
+// ClassifyCommandFailure maps a dead-letter reason to its failure class.
+func ClassifyCommandFailure(reason string) string {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	switch {
+	case strings.HasPrefix(reason, "checksum"), reason == "unsupported action", strings.HasPrefix(reason, "config_path"):
+		return CommandFailureValidation
+	case strings.HasPrefix(reason, "pending queue full"):
+		return CommandFailureCapacity
+	case strings.HasPrefix(reason, "change freeze"):
+		return CommandFailureFreeze
+	case strings.HasPrefix(reason, "emergency stop"):
+		return CommandFailureEmergencyStop
+	case strings.HasPrefix(reason, "queue is draining"):
+		return CommandFailureDraining
+	default:
+		return CommandFailureOther
+	}
+}
+
+func (s *CommandIngestStore) RetryPolicy() CommandRetryPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := s.retry
+	out.Classes = append([]string{}, s.retry.Classes...)
+	return out
+}
+
+// SetRetryPolicy replaces the auto-retry policy and reschedules pending
+// dead letters under it.
+func (s *CommandIngestStore) SetRetryPolicy(in CommandRetryPolicy) (CommandRetryPolicy, error) {
+	if in.MaxAttempts <= 0 || in.MaxAttempts > 100 {
+		return CommandRetryPolicy{}, errors.New("max_attempts must be between 1 and 100")
+	}
+	if in.BackoffSeconds <= 0 {
+		return CommandRetryPolicy{}, errors.New("backoff_seconds must be positive")
+	}
+	if in.MaxBackoffSeconds == 0 {
+		in.MaxBackoffSeconds = in.BackoffSeconds
+	}
+	if in.MaxBackoffSeconds < in.BackoffSeconds {
+		return CommandRetryPolicy{}, errors.New("max_backoff_seconds must be at least backoff_seconds")
+	}
+	classes := make([]string, 0, len(in.Classes))
+	seen := map[string]bool{}
+	for _, class := range in.Classes {
+		class = strings.ToLower(strings.TrimSpace(class))
+		switch class {
+		case CommandFailureCapacity, CommandFailureFreeze, CommandFailureEmergencyStop, CommandFailureDraining, CommandFailureOther:
+		case CommandFailureValidation:
+			return CommandRetryPolicy{}, errors.New("validation failures cannot be retried automatically; replay them after fixing the command")
+		default:
+			return CommandRetryPolicy{}, errors.New("unknown failure class: " + class)
+		}
+		if !seen[class] {
+			seen[class] = true
+			classes = append(classes, class)
+		}
+	}
+	if in.Enabled && len(classes) == 0 {
+		return CommandRetryPolicy{}, errors.New("classes are required when auto-retry is enabled")
+	}
+	in.Classes = classes
+	in.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retry = in
+	for i := range s.deadLetters {
+		if s.deadLetters[i].Status == "dead_letter" {
+			s.deadLetters[i].NextRetryAt = s.nextRetryLocked(s.deadLetters[i], in.UpdatedAt)
+		}
+	}
+	out := in
+	out.Classes = append([]string{}, in.Classes...)
+	return out, nil
+}
+
+// nextRetryLocked returns when env is next due for automatic replay, or the
+// zero time when policy does not retry it.
+func (s *CommandIngestStore) nextRetryLocked(env CommandEnvelope, from time.Time) time.Time {
+	p := s.retry
+	if !p.Enabled || env.Attempts >= p.MaxAttempts {
+		return time.Time{}
+	}
+	retryable := false
+	for _, class := range p.Classes {
+		if class == env.FailureClass {
+			retryable = true
+			break
+		}
+	}
+	if !retryable {
+		return time.Time{}
+	}
+	delay := time.Duration(p.BackoffSeconds) * time.Second
+	ceiling := time.Duration(p.MaxBackoffSeconds) * time.Second
+	for i := 1; i < env.Attempts && delay < ceiling; i++ {
+		delay *= 2
+	}
+	if delay > ceiling {
+		delay = ceiling
+	}
+	return from.Add(delay)
+}
+
+func (s *CommandIngestStore) deadLetterIndexLocked(id string) (int, error) {
+	id = strings.TrimSpace(id)
+	for i := range s.deadLetters {
+		if s.deadLetters[i].ID == id {
+			return i, nil
+		}
+	}
+	return -1, errors.New("dead letter not found")
+}
+
+func (s *CommandIngestStore) GetDeadLetter(id string) (CommandEnvelope, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, err := s.deadLetterIndexLocked(id)
+	if err != nil {
+		return CommandEnvelope{}, err
+	}
+	return s.deadLetters[i], nil
+}
+
+// FilterDeadLetters returns dead letters matching filter, oldest first.
+func (s *CommandIngestStore) FilterDeadLetters(filter CommandDeadLetterFilter) []CommandEnvelope {
+	reason := strings.ToLower(strings.TrimSpace(filter.Reason))
+	out := make([]CommandEnvelope, 0)
+	for _, env := range s.DeadLetters() {
+		switch {
+		case filter.Status != "" && env.Status != filter.Status,
+			filter.FailureClass != "" && env.FailureClass != filter.FailureClass,
+			reason != "" && !strings.Contains(strings.ToLower(env.Reason), reason),
+			filter.Action != "" && !strings.EqualFold(env.Action, filter.Action),
+			filter.ConfigPath != "" && env.ConfigPath != filter.ConfigPath,
+			!filter.Since.IsZero() && env.ReceivedAt.Before(filter.Since):
+			continue
+		}
+		out = append(out, env)
+		if filter.Limit > 0 && len(out) >= filter.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// DueRetries returns dead letters whose automatic retry is due at now.
+func (s *CommandIngestStore) DueRetries(now time.Time) []CommandEnvelope {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]CommandEnvelope, 0)
+	for _, env := range s.deadLetters {
+		if env.Status == "dead_letter" && !env.NextRetryAt.IsZero() && !env.NextRetryAt.After(now) {
+			out = append(out, env)
+		}
+	}
+	return out
+}
+
+// MarkReplayed records that a dead letter was delivered as command
+// commandID, queued as jobID.
+func (s *CommandIngestStore) MarkReplayed(id, commandID, jobID string) (CommandEnvelope, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, err := s.deadLetterIndexLocked(id)
+	if err != nil {
+		return CommandEnvelope{}, err
+	}
+	env := &s.deadLetters[i]
+	env.Status = "replayed"
+	env.ReplayedAs = commandID
+	env.ReplayJobID = jobID
+	env.ReplayedAt = time.Now().UTC()
+	env.NextRetryAt = time.Time{}
+	return *env, nil
+}
+
+// RecordReplayFailure counts a failed replay against the dead letter and
+// schedules its next automatic retry, if policy allows one.
+func (s *CommandIngestStore) RecordReplayFailure(id, reason string) (CommandEnvelope, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, err := s.deadLetterIndexLocked(id)
+	if err != nil {
+		return CommandEnvelope{}, err
+	}
+	env := &s.deadLetters[i]
+	env.Attempts++
+	env.Reason = strings.TrimSpace(reason)
+	env.FailureClass = ClassifyCommandFailure(env.Reason)
+	env.NextRetryAt = s.nextRetryLocked(*env, time.Now().UTC())
+	return *env, nil
+}
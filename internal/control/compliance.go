@@ -382,6 +382,23 @@ func (s *ComplianceStore) RunScan(in ComplianceScanInput) (ComplianceScan, error
 	return cloneComplianceScan(scan), nil
 }
 
+// RecordScan stores a scan produced outside of profile evaluation, such as
+// secret scanning at content intake, so it counts toward the scorecards.
+func (s *ComplianceStore) RecordScan(scan ComplianceScan) ComplianceScan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextScanID++
+	scan.ID = "compliance-scan-" + itoa(s.nextScanID)
+	if scan.StartedAt.IsZero() {
+		scan.StartedAt = time.Now().UTC()
+	}
+	if scan.EndedAt.IsZero() {
+		scan.EndedAt = scan.StartedAt
+	}
+	s.scans[scan.ID] = &scan
+	return cloneComplianceScan(scan)
+}
+
 func (s *ComplianceStore) ListScans() []ComplianceScan {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -0,0 +1,497 @@
+package control
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of control-plane entities mirrored to disk, one directory each.
+const (
+	ConfigAsCodeTemplates = "templates"
+	ConfigAsCodeRunbooks  = "runbooks"
+	ConfigAsCodeRules     = "rules"
+	ConfigAsCodeSchedules = "schedules"
+)
+
+var configAsCodeKinds = []string{ConfigAsCodeTemplates, ConfigAsCodeRunbooks, ConfigAsCodeRules, ConfigAsCodeSchedules}
+
+// Entity IDs become file names, so they are restricted to a safe alphabet.
+var configAsCodeIDPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// Runtime counters are left out of files so converges and rule firings do
+// not rewrite them or show up as drift.
+var configAsCodeVolatileFields = map[string][]string{
+	ConfigAsCodeRules:     {"last_triggered_at", "trigger_count"},
+	ConfigAsCodeSchedules: {"last_run_at", "next_run_at"},
+}
+
+type ConfigAsCodeSettings struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir,omitempty"`
+	Git     bool   `json:"git,omitempty"`
+}
+
+type ConfigAsCodeStatus struct {
+	ConfigAsCodeSettings
+	LastSync *ConfigAsCodeSyncResult `json:"last_sync,omitempty"`
+}
+
+type ConfigAsCodeSnapshot struct {
+	Templates []Template `json:"templates"`
+	Runbooks  []Runbook  `json:"runbooks"`
+	Rules     []Rule     `json:"rules"`
+	Schedules []Schedule `json:"schedules"`
+}
+
+type ConfigAsCodeSyncResult struct {
+	Written   []string  `json:"written"`
+	Removed   []string  `json:"removed"`
+	Committed bool      `json:"committed"`
+	Commit    string    `json:"commit,omitempty"`
+	SyncedAt  time.Time `json:"synced_at"`
+}
+
+type ConfigAsCodeDrift struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Status string `json:"status"` // live_only|file_only|changed
+	Live   string `json:"live,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+type ConfigAsCodeDiff struct {
+	Dir       string              `json:"dir"`
+	InSync    bool                `json:"in_sync"`
+	Drift     []ConfigAsCodeDrift `json:"drift"`
+	CheckedAt time.Time           `json:"checked_at"`
+}
+
+type ConfigAsCodeStore struct {
+	mu       sync.Mutex
+	settings ConfigAsCodeSettings
+	lastSync *ConfigAsCodeSyncResult
+	// synced fingerprints the documents last mirrored to disk so SyncChanged
+	// can skip requests that did not touch a mirrored store.
+	synced string
+}
+
+func NewConfigAsCodeStore() *ConfigAsCodeStore {
+	return &ConfigAsCodeStore{}
+}
+
+func (s *ConfigAsCodeStore) Configure(in ConfigAsCodeSettings) (ConfigAsCodeSettings, error) {
+	in.Dir = strings.TrimSpace(in.Dir)
+	if in.Enabled {
+		if in.Dir == "" {
+			return ConfigAsCodeSettings{}, errors.New("dir is required when configuration as code is enabled")
+		}
+		if err := os.MkdirAll(in.Dir, 0o755); err != nil {
+			return ConfigAsCodeSettings{}, err
+		}
+	}
+	s.mu.Lock()
+	s.settings = in
+	s.synced = ""
+	s.mu.Unlock()
+	return in, nil
+}
+
+func (s *ConfigAsCodeStore) Status() ConfigAsCodeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := ConfigAsCodeStatus{ConfigAsCodeSettings: s.settings}
+	if s.lastSync != nil {
+		last := cloneConfigAsCodeSyncResult(*s.lastSync)
+		out.LastSync = &last
+	}
+	return out
+}
+
+func (s *ConfigAsCodeStore) Enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings.Enabled
+}
+
+// Sync writes every entity in snap to <dir>/<kind>/<id>.yaml, rewriting only
+// files whose content changed and removing files for deleted entities. In git
+// mode the directory is initialized as a repository if needed and changes are
+// committed.
+func (s *ConfigAsCodeStore) Sync(snap ConfigAsCodeSnapshot, message string) (ConfigAsCodeSyncResult, error) {
+	live, err := configAsCodeDocuments(snap)
+	if err != nil {
+		return ConfigAsCodeSyncResult{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncLocked(live, message)
+}
+
+// SyncChanged is Sync for callers that run after every mutating request: it
+// returns false without touching the directory when the mirrored entities
+// are unchanged since the last sync.
+func (s *ConfigAsCodeStore) SyncChanged(snap ConfigAsCodeSnapshot, message string) (ConfigAsCodeSyncResult, bool, error) {
+	live, err := configAsCodeDocuments(snap)
+	if err != nil {
+		return ConfigAsCodeSyncResult{}, false, err
+	}
+	fingerprint := configAsCodeFingerprint(live)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.settings.Enabled && s.synced == fingerprint {
+		return ConfigAsCodeSyncResult{}, false, nil
+	}
+	out, err := s.syncLocked(live, message)
+	return out, err == nil, err
+}
+
+func (s *ConfigAsCodeStore) syncLocked(live map[string]map[string][]byte, message string) (ConfigAsCodeSyncResult, error) {
+	if !s.settings.Enabled {
+		return ConfigAsCodeSyncResult{}, errors.New("configuration as code is not enabled")
+	}
+	files, err := readConfigAsCodeDocuments(s.settings.Dir)
+	if err != nil {
+		return ConfigAsCodeSyncResult{}, err
+	}
+	out := ConfigAsCodeSyncResult{Written: []string{}, Removed: []string{}, SyncedAt: time.Now().UTC()}
+	for _, kind := range configAsCodeKinds {
+		dir := filepath.Join(s.settings.Dir, kind)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return ConfigAsCodeSyncResult{}, err
+		}
+		for _, id := range sortedConfigAsCodeIDs(live[kind]) {
+			if bytes.Equal(files[kind][id], live[kind][id]) {
+				continue
+			}
+			path, err := configAsCodePath(dir, id)
+			if err != nil {
+				return ConfigAsCodeSyncResult{}, err
+			}
+			if err := os.WriteFile(path, live[kind][id], 0o644); err != nil {
+				return ConfigAsCodeSyncResult{}, err
+			}
+			out.Written = append(out.Written, kind+"/"+id+".yaml")
+		}
+		for _, id := range sortedConfigAsCodeIDs(files[kind]) {
+			if _, ok := live[kind][id]; ok {
+				continue
+			}
+			path, err := configAsCodePath(dir, id)
+			if err != nil {
+				return ConfigAsCodeSyncResult{}, err
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return ConfigAsCodeSyncResult{}, err
+			}
+			out.Removed = append(out.Removed, kind+"/"+id+".yaml")
+		}
+	}
+	if s.settings.Git && (len(out.Written) > 0 || len(out.Removed) > 0) {
+		if strings.TrimSpace(message) == "" {
+			message = "masterchef: sync control-plane configuration"
+		}
+		commit, err := commitConfigAsCode(s.settings.Dir, message)
+		if err != nil {
+			return ConfigAsCodeSyncResult{}, err
+		}
+		out.Committed = commit != ""
+		out.Commit = commit
+	}
+	last := cloneConfigAsCodeSyncResult(out)
+	s.lastSync = &last
+	s.synced = configAsCodeFingerprint(live)
+	return out, nil
+}
+
+// Load parses the entity files in the configured directory so they can be
+// restored into the live stores, typically at startup.
+func (s *ConfigAsCodeStore) Load() (ConfigAsCodeSnapshot, error) {
+	s.mu.Lock()
+	dir := s.settings.Dir
+	enabled := s.settings.Enabled
+	s.mu.Unlock()
+	if !enabled {
+		return ConfigAsCodeSnapshot{}, errors.New("configuration as code is not enabled")
+	}
+	files, err := readConfigAsCodeDocuments(dir)
+	if err != nil {
+		return ConfigAsCodeSnapshot{}, err
+	}
+	out := ConfigAsCodeSnapshot{Templates: []Template{}, Runbooks: []Runbook{}, Rules: []Rule{}, Schedules: []Schedule{}}
+	for _, kind := range configAsCodeKinds {
+		for _, id := range sortedConfigAsCodeIDs(files[kind]) {
+			var target any
+			switch kind {
+			case ConfigAsCodeTemplates:
+				out.Templates = append(out.Templates, Template{})
+				target = &out.Templates[len(out.Templates)-1]
+			case ConfigAsCodeRunbooks:
+				out.Runbooks = append(out.Runbooks, Runbook{})
+				target = &out.Runbooks[len(out.Runbooks)-1]
+			case ConfigAsCodeRules:
+				out.Rules = append(out.Rules, Rule{})
+				target = &out.Rules[len(out.Rules)-1]
+			case ConfigAsCodeSchedules:
+				out.Schedules = append(out.Schedules, Schedule{})
+				target = &out.Schedules[len(out.Schedules)-1]
+			}
+			if err := decodeConfigAsCodeDocument(files[kind][id], target); err != nil {
+				return ConfigAsCodeSnapshot{}, errors.New(kind + "/" + id + ".yaml: " + err.Error())
+			}
+		}
+	}
+	return out, nil
+}
+
+// Diff compares the live stores against the files on disk.
+func (s *ConfigAsCodeStore) Diff(snap ConfigAsCodeSnapshot) (ConfigAsCodeDiff, error) {
+	s.mu.Lock()
+	dir := s.settings.Dir
+	enabled := s.settings.Enabled
+	s.mu.Unlock()
+	if !enabled {
+		return ConfigAsCodeDiff{}, errors.New("configuration as code is not enabled")
+	}
+	live, err := configAsCodeDocuments(snap)
+	if err != nil {
+		return ConfigAsCodeDiff{}, err
+	}
+	files, err := readConfigAsCodeDocuments(dir)
+	if err != nil {
+		return ConfigAsCodeDiff{}, err
+	}
+	out := ConfigAsCodeDiff{Dir: dir, Drift: []ConfigAsCodeDrift{}, CheckedAt: time.Now().UTC()}
+	for _, kind := range configAsCodeKinds {
+		ids := map[string]struct{}{}
+		for id := range live[kind] {
+			ids[id] = struct{}{}
+		}
+		for id := range files[kind] {
+			ids[id] = struct{}{}
+		}
+		sorted := make([]string, 0, len(ids))
+		for id := range ids {
+			sorted = append(sorted, id)
+		}
+		sort.Strings(sorted)
+		for _, id := range sorted {
+			liveDoc, inLive := live[kind][id]
+			fileDoc, inFile := files[kind][id]
+			item := ConfigAsCodeDrift{Kind: kind, ID: id}
+			switch {
+			case !inFile:
+				item.Status = "live_only"
+				item.Live = string(liveDoc)
+			case !inLive:
+				item.Status = "file_only"
+				item.File = string(fileDoc)
+			case !bytes.Equal(liveDoc, fileDoc):
+				item.Status = "changed"
+				item.Live = string(liveDoc)
+				item.File = string(fileDoc)
+			default:
+				continue
+			}
+			out.Drift = append(out.Drift, item)
+		}
+	}
+	out.InSync = len(out.Drift) == 0
+	return out, nil
+}
+
+func configAsCodeDocuments(snap ConfigAsCodeSnapshot) (map[string]map[string][]byte, error) {
+	out := map[string]map[string][]byte{}
+	add := func(kind, id string, item any) error {
+		if err := ValidateConfigAsCodeID(id); err != nil {
+			return errors.New(kind + ": " + err.Error())
+		}
+		doc, err := encodeConfigAsCodeDocument(kind, item)
+		if err != nil {
+			return err
+		}
+		if out[kind] == nil {
+			out[kind] = map[string][]byte{}
+		}
+		out[kind][id] = doc
+		return nil
+	}
+	for _, item := range snap.Templates {
+		if err := add(ConfigAsCodeTemplates, item.ID, item); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range snap.Runbooks {
+		if err := add(ConfigAsCodeRunbooks, item.ID, item); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range snap.Rules {
+		if err := add(ConfigAsCodeRules, item.ID, item); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range snap.Schedules {
+		if err := add(ConfigAsCodeSchedules, item.ID, item); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// encodeConfigAsCodeDocument renders item as YAML using its json field
+// names, so files match the API payloads.
+func encodeConfigAsCodeDocument(kind string, item any) ([]byte, error) {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]any{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	for _, field := range configAsCodeVolatileFields[kind] {
+		delete(doc, field)
+	}
+	return yaml.Marshal(doc)
+}
+
+func decodeConfigAsCodeDocument(data []byte, target any) error {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func readConfigAsCodeDocuments(dir string) (map[string]map[string][]byte, error) {
+	out := map[string]map[string][]byte{}
+	for _, kind := range configAsCodeKinds {
+		entries, err := os.ReadDir(filepath.Join(dir, kind))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".yaml") || ValidateConfigAsCodeID(strings.TrimSuffix(name, ".yaml")) != nil {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, kind, name))
+			if err != nil {
+				return nil, err
+			}
+			if out[kind] == nil {
+				out[kind] = map[string][]byte{}
+			}
+			out[kind][strings.TrimSuffix(name, ".yaml")] = data
+		}
+	}
+	return out, nil
+}
+
+func commitConfigAsCode(dir, message string) (string, error) {
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", errors.New("git " + args[0] + ": " + strings.TrimSpace(string(out)))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if _, err := git("init", "-q"); err != nil {
+			return "", err
+		}
+	}
+	if _, err := git("add", "-A"); err != nil {
+		return "", err
+	}
+	if status, err := git("status", "--porcelain"); err != nil || status == "" {
+		return "", err
+	}
+	if _, err := git("-c", "user.name=masterchef", "-c", "user.email=masterchef@localhost", "commit", "-q", "-m", message); err != nil {
+		return "", err
+	}
+	return git("rev-parse", "HEAD")
+}
+
+// ValidateConfigAsCodeID rejects entity IDs that cannot be used as file
+// names under the configuration-as-code directory.
+func ValidateConfigAsCodeID(id string) error {
+	if !configAsCodeIDPattern.MatchString(id) {
+		return errors.New("invalid id " + strconv.Quote(id) + ": must match " + configAsCodeIDPattern.String())
+	}
+	return nil
+}
+
+// configAsCodePath returns <dir>/<id>.yaml, refusing IDs that would resolve
+// outside dir.
+func configAsCodePath(dir, id string) (string, error) {
+	if err := ValidateConfigAsCodeID(id); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, id+".yaml")
+	if rel, err := filepath.Rel(dir, path); err != nil || rel != filepath.Base(path) {
+		return "", errors.New("path for id " + strconv.Quote(id) + " escapes " + dir)
+	}
+	return path, nil
+}
+
+func configAsCodeFingerprint(docs map[string]map[string][]byte) string {
+	h := sha256.New()
+	for _, kind := range configAsCodeKinds {
+		for _, id := range sortedConfigAsCodeIDs(docs[kind]) {
+			h.Write([]byte(kind + "/" + id + "\n"))
+			h.Write(docs[kind][id])
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sortedConfigAsCodeIDs(docs map[string][]byte) []string {
+	out := make([]string, 0, len(docs))
+	for id := range docs {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func cloneConfigAsCodeSyncResult(in ConfigAsCodeSyncResult) ConfigAsCodeSyncResult {
+	out := in
+	out.Written = append([]string{}, in.Written...)
+	out.Removed = append([]string{}, in.Removed...)
+	return out
+}
+
+// advanceRestoredID keeps a store's ID counter ahead of a restored
+// "<prefix><n>" ID so newly created entities do not collide with it.
+func advanceRestoredID(next int64, id, prefix string) int64 {
+	n, err := strconv.ParseInt(strings.TrimPrefix(id, prefix), 10, 64)
+	if err != nil || !strings.HasPrefix(id, prefix) || n <= next {
+		return next
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package control
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigAsCodeSyncLoadAndDiff(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cac")
+	store := NewConfigAsCodeStore()
+	if _, err := store.Configure(ConfigAsCodeSettings{Enabled: true}); err == nil {
+		t.Fatalf("expected dir to be required")
+	}
+	if _, err := store.Configure(ConfigAsCodeSettings{Enabled: true, Dir: dir}); err != nil {
+		t.Fatalf("configure failed: %v", err)
+	}
+
+	templates := NewTemplateStore()
+	tpl := templates.Create(Template{Name: "deploy", ConfigPath: "deploy.yaml", Defaults: map[string]string{"env": "prod"}})
+	rules := NewRuleEngine()
+	rule, err := rules.Create(Rule{Name: "on-drift", SourcePrefix: "drift.", Actions: []RuleAction{{Type: "enqueue_apply", ConfigPath: "fix.yaml"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := ConfigAsCodeSnapshot{Templates: templates.List(), Rules: rules.List()}
+	out, err := store.Sync(snap, "")
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if len(out.Written) != 2 {
+		t.Fatalf("expected two files written, got %#v", out)
+	}
+	body, err := os.ReadFile(filepath.Join(dir, "templates", tpl.ID+".yaml"))
+	if err != nil || !strings.Contains(string(body), "config_path: deploy.yaml") {
+		t.Fatalf("expected template yaml with json field names, got %q err=%v", body, err)
+	}
+
+	// Runtime counters are not persisted, so firing a rule is not drift.
+	snap.Rules[0].TriggerCount = 5
+	snap.Rules[0].LastTriggeredAt = time.Now().UTC()
+	diff, err := store.Diff(snap)
+	if err != nil || !diff.InSync {
+		t.Fatalf("expected in sync after volatile change, got %#v err=%v", diff, err)
+	}
+	if out, err := store.Sync(snap, ""); err != nil || len(out.Written) != 0 {
+		t.Fatalf("expected no rewrite for unchanged state, got %#v err=%v", out, err)
+	}
+	if _, synced, err := store.SyncChanged(snap, ""); err != nil || synced {
+		t.Fatalf("expected unchanged state to skip sync, synced=%v err=%v", synced, err)
+	}
+
+	snap.Templates[0].Description = "edited live"
+	snap.Rules = nil
+	diff, err = store.Diff(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.InSync || len(diff.Drift) != 2 || diff.Drift[0].Status != "changed" || diff.Drift[1].Status != "file_only" || diff.Drift[1].ID != rule.ID {
+		t.Fatalf("unexpected drift %#v", diff.Drift)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded.Templates) != 1 || loaded.Templates[0].Defaults["env"] != "prod" || len(loaded.Rules) != 1 {
+		t.Fatalf("unexpected loaded snapshot %#v", loaded)
+	}
+	restored := NewTemplateStore()
+	if _, err := restored.Restore(loaded.Templates[0]); err != nil {
+		t.Fatal(err)
+	}
+	if next := restored.Create(Template{Name: "next"}); next.ID == tpl.ID {
+		t.Fatalf("expected restored id to advance counter, got %s", next.ID)
+	}
+
+	out, synced, err := store.SyncChanged(snap, "")
+	if err != nil || !synced || len(out.Removed) != 1 || len(out.Written) != 1 {
+		t.Fatalf("expected one removal and one rewrite, got %#v synced=%v err=%v", out, synced, err)
+	}
+
+	for _, id := range []string{"../escape", "tpl/1", "TPL-1", ""} {
+		if _, err := restored.Restore(Template{ID: id, Name: "bad"}); err == nil {
+			t.Fatalf("expected restore of id %q to fail", id)
+		}
+		bad := ConfigAsCodeSnapshot{Templates: []Template{{ID: id, Name: "bad"}}}
+		if _, err := store.Sync(bad, ""); err == nil {
+			t.Fatalf("expected sync of id %q to fail", id)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file written outside dir, err=%v", err)
+	}
+}
+
+func TestConfigAsCodeGitCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	store := NewConfigAsCodeStore()
+	if _, err := store.Configure(ConfigAsCodeSettings{Enabled: true, Dir: dir, Git: true}); err != nil {
+		t.Fatal(err)
+	}
+	runbooks := NewRunbookStore()
+	if _, err := runbooks.Create(Runbook{Name: "restart", TargetType: RunbookTargetConfig, ConfigPath: "restart.yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := store.Sync(ConfigAsCodeSnapshot{Runbooks: runbooks.List()}, "add runbook")
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if !out.Committed || out.Commit == "" {
+		t.Fatalf("expected git commit, got %#v", out)
+	}
+	if status := store.Status(); status.LastSync == nil || status.LastSync.Commit != out.Commit {
+		t.Fatalf("expected last sync recorded, got %#v", status)
+	}
+	cmd := exec.Command("git", "log", "--format=%s")
+	cmd.Dir = dir
+	logOut, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(logOut)) != "add runbook" {
+		t.Fatalf("unexpected git log %q err=%v", logOut, err)
+	}
+}
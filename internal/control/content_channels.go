@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"path"
 	"sort"
 	"strings"
 	"sync"
@@ -33,6 +34,16 @@ type OrgSyncRemote struct {
 	TokenConfigured bool      `json:"token_configured"`
 	Enabled         bool      `json:"enabled"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Mirroring: Packages selects "kind/name" globs to pull (empty means
+	// everything the channel policy allows).
+	Packages           []string  `json:"packages,omitempty"`
+	BandwidthLimitKBps int       `json:"bandwidth_limit_kbps,omitempty"`
+	IntervalSeconds    int       `json:"interval_seconds,omitempty"` // 0 syncs only on demand
+	LastSyncAt         time.Time `json:"last_sync_at,omitempty"`
+	LastSuccessAt      time.Time `json:"last_success_at,omitempty"`
+	LastSyncStatus     string    `json:"last_sync_status,omitempty"`
+	SyncLagSeconds     int64     `json:"sync_lag_seconds"` // since last success; -1 if never synced
 }
 
 type OrgSyncRemoteInput struct {
@@ -42,11 +53,18 @@ type OrgSyncRemoteInput struct {
 	URL          string `json:"url"`
 	APIToken     string `json:"api_token"`
 	Enabled      bool   `json:"enabled"`
+
+	Packages           []string `json:"packages,omitempty"`
+	BandwidthLimitKBps int      `json:"bandwidth_limit_kbps,omitempty"`
+	IntervalSeconds    int      `json:"interval_seconds,omitempty"`
 }
 
+// contentRemoteRecord keeps the raw token alongside its hash because sync
+// jobs must present it to the upstream registry.
 type contentRemoteRecord struct {
 	OrgSyncRemote
 	TokenHash string
+	token     string
 }
 
 type ContentChannelStore struct {
@@ -155,6 +173,18 @@ func (s *ContentChannelStore) UpsertRemote(in OrgSyncRemoteInput) (OrgSyncRemote
 		return OrgSyncRemote{}, errors.New("api_token is required")
 	}
 	tokenHash := hashToken(in.APIToken)
+	if in.BandwidthLimitKBps < 0 {
+		return OrgSyncRemote{}, errors.New("bandwidth_limit_kbps cannot be negative")
+	}
+	if in.IntervalSeconds < 0 || (in.IntervalSeconds > 0 && in.IntervalSeconds < 60) {
+		return OrgSyncRemote{}, errors.New("interval_seconds must be 0 or at least 60")
+	}
+	packages := normalizeStringSlice(in.Packages)
+	for _, pattern := range packages {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return OrgSyncRemote{}, errors.New("invalid package pattern " + pattern)
+		}
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -167,10 +197,14 @@ func (s *ContentChannelStore) UpsertRemote(in OrgSyncRemoteInput) (OrgSyncRemote
 		if existing.Organization == org && existing.Channel == channel && existing.Name == name {
 			existing.URL = url
 			existing.TokenHash = tokenHash
+			existing.token = in.APIToken
 			existing.TokenConfigured = true
 			existing.Enabled = in.Enabled
+			existing.Packages = packages
+			existing.BandwidthLimitKBps = in.BandwidthLimitKBps
+			existing.IntervalSeconds = in.IntervalSeconds
 			existing.UpdatedAt = now
-			return existing.OrgSyncRemote, nil
+			return existing.view(now), nil
 		}
 	}
 	s.nextID++
@@ -183,17 +217,24 @@ func (s *ContentChannelStore) UpsertRemote(in OrgSyncRemoteInput) (OrgSyncRemote
 		TokenConfigured: true,
 		Enabled:         in.Enabled,
 		UpdatedAt:       now,
+
+		Packages:           packages,
+		BandwidthLimitKBps: in.BandwidthLimitKBps,
+		IntervalSeconds:    in.IntervalSeconds,
 	}
-	s.remotes[item.ID] = &contentRemoteRecord{
+	record := &contentRemoteRecord{
 		OrgSyncRemote: item,
 		TokenHash:     tokenHash,
+		token:         in.APIToken,
 	}
-	return item, nil
+	s.remotes[item.ID] = record
+	return record.view(now), nil
 }
 
 func (s *ContentChannelStore) ListRemotes(organization, channel string) []OrgSyncRemote {
 	org := strings.ToLower(strings.TrimSpace(organization))
 	channel = normalizeChannelName(channel)
+	now := time.Now().UTC()
 	s.mu.RLock()
 	out := make([]OrgSyncRemote, 0, len(s.remotes))
 	for _, item := range s.remotes {
@@ -203,7 +244,7 @@ func (s *ContentChannelStore) ListRemotes(organization, channel string) []OrgSyn
 		if channel != "" && item.Channel != channel {
 			continue
 		}
-		out = append(out, item.OrgSyncRemote)
+		out = append(out, item.view(now))
 	}
 	s.mu.RUnlock()
 	sort.Slice(out, func(i, j int) bool {
@@ -229,7 +270,7 @@ func (s *ContentChannelStore) GetRemote(id string) (OrgSyncRemote, error) {
 	if !ok {
 		return OrgSyncRemote{}, errors.New("sync remote not found")
 	}
-	return item.OrgSyncRemote, nil
+	return item.view(time.Now().UTC()), nil
 }
 
 func (s *ContentChannelStore) RotateRemoteToken(id, newToken string) (OrgSyncRemote, error) {
@@ -245,9 +286,45 @@ func (s *ContentChannelStore) RotateRemoteToken(id, newToken string) (OrgSyncRem
 		return OrgSyncRemote{}, errors.New("sync remote not found")
 	}
 	item.TokenHash = hashToken(newToken)
+	item.token = newToken
 	item.TokenConfigured = true
 	item.UpdatedAt = time.Now().UTC()
-	return item.OrgSyncRemote, nil
+	return item.view(item.UpdatedAt), nil
+}
+
+// remoteForSync returns a remote with its raw token for a sync job.
+func (s *ContentChannelStore) remoteForSync(id string) (OrgSyncRemote, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.remotes[strings.TrimSpace(id)]
+	if !ok {
+		return OrgSyncRemote{}, "", errors.New("sync remote not found")
+	}
+	return item.view(time.Now().UTC()), item.token, nil
+}
+
+func (s *ContentChannelStore) recordRemoteSync(id, status string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.remotes[id]
+	if !ok {
+		return
+	}
+	item.LastSyncAt = at
+	item.LastSyncStatus = status
+	if status == ContentSyncSucceeded {
+		item.LastSuccessAt = at
+	}
+}
+
+func (r *contentRemoteRecord) view(now time.Time) OrgSyncRemote {
+	out := r.OrgSyncRemote
+	out.Packages = append([]string{}, r.Packages...)
+	out.SyncLagSeconds = -1
+	if !r.LastSuccessAt.IsZero() {
+		out.SyncLagSeconds = int64(now.Sub(r.LastSuccessAt) / time.Second)
+	}
+	return out
 }
 
 func normalizeChannelName(name string) string {
@@ -0,0 +1,447 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ContentSyncRunning   = "running"
+	ContentSyncSucceeded = "succeeded"
+	ContentSyncPartial   = "partial"
+	ContentSyncFailed    = "failed"
+)
+
+// Per-package outcomes of a sync run.
+const (
+	ContentSyncMirrored  = "mirrored"
+	ContentSyncUnchanged = "unchanged"
+	ContentSyncFiltered  = "filtered"
+	ContentSyncRejected  = "rejected"
+	ContentSyncError     = "error"
+)
+
+const (
+	contentSyncHistoryLimit = 50
+	contentSyncMaxTarball   = 256 << 20
+)
+
+type ContentSyncItem struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Digest     string `json:"digest"`
+	Outcome    string `json:"outcome"`
+	Reason     string `json:"reason,omitempty"`
+	ArtifactID string `json:"artifact_id,omitempty"` // local artifact when mirrored or unchanged
+	Bytes      int64  `json:"bytes,omitempty"`
+}
+
+type ContentSyncRun struct {
+	ID               string            `json:"id"`
+	RemoteID         string            `json:"remote_id"`
+	Channel          string            `json:"channel"`
+	Trigger          string            `json:"trigger"` // api|schedule
+	Status           string            `json:"status"`
+	Error            string            `json:"error,omitempty"`
+	Discovered       int               `json:"discovered"`
+	Counts           map[string]int    `json:"counts"` // outcome -> packages
+	BytesTransferred int64             `json:"bytes_transferred"`
+	Items            []ContentSyncItem `json:"items"`
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       time.Time         `json:"finished_at,omitempty"`
+}
+
+// ContentMirror runs sync jobs that pull packages from a remote's upstream
+// registry into the local package registry. Upstreams speak the same
+// /v1/packages/artifacts API as this control plane. Each package passes the
+// channel policy, the remote's package selection, and the local signing
+// policy before its tarball is downloaded, throttled to the remote's
+// bandwidth limit, and checked against the advertised digest. Versions
+// already mirrored with the same digest are skipped.
+type ContentMirror struct {
+	mu       sync.RWMutex
+	nextID   int64
+	channels *ContentChannelStore
+	registry *PackageRegistryStore
+	store    func(data []byte) (objectKey string, err error)
+	client   *http.Client
+	runs     map[string][]*ContentSyncRun // remote id -> runs, oldest first
+	onFinish func(ContentSyncRun)
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewContentMirror mirrors into registry; store persists downloaded
+// tarballs and returns their object key.
+func NewContentMirror(channels *ContentChannelStore, registry *PackageRegistryStore, store func([]byte) (string, error)) *ContentMirror {
+	return &ContentMirror{
+		channels: channels,
+		registry: registry,
+		store:    store,
+		client: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+		runs: map[string][]*ContentSyncRun{},
+	}
+}
+
+// SetClient replaces the HTTP client used to reach upstream registries.
+func (m *ContentMirror) SetClient(client *http.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.client = client
+}
+
+// OnFinish registers a callback for completed sync runs.
+func (m *ContentMirror) OnFinish(fn func(ContentSyncRun)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onFinish = fn
+}
+
+// Start checks on interval for enabled remotes whose interval_seconds has
+// elapsed since their last sync.
+func (m *ContentMirror) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				m.syncDue(ctx)
+			}
+		}
+	}(m.done)
+}
+
+func (m *ContentMirror) Shutdown() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (m *ContentMirror) syncDue(ctx context.Context) {
+	now := time.Now().UTC()
+	for _, remote := range m.channels.ListRemotes("", "") {
+		if !remote.Enabled || remote.IntervalSeconds <= 0 {
+			continue
+		}
+		if !remote.LastSyncAt.IsZero() && now.Sub(remote.LastSyncAt) < time.Duration(remote.IntervalSeconds)*time.Second {
+			continue
+		}
+		run, err := m.begin(remote.ID, "schedule")
+		if err != nil {
+			continue
+		}
+		m.execute(ctx, run)
+	}
+}
+
+// Trigger starts a sync of remoteID in the background and returns the
+// running record. Only one run per remote may be active.
+func (m *ContentMirror) Trigger(remoteID string) (ContentSyncRun, error) {
+	run, err := m.begin(remoteID, "api")
+	if err != nil {
+		return ContentSyncRun{}, err
+	}
+	out := cloneContentSyncRun(*run)
+	go m.execute(context.Background(), run)
+	return out, nil
+}
+
+func (m *ContentMirror) begin(remoteID, trigger string) (*ContentSyncRun, error) {
+	remote, _, err := m.channels.remoteForSync(remoteID)
+	if err != nil {
+		return nil, err
+	}
+	if !remote.Enabled {
+		return nil, errors.New("sync remote is disabled")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := m.runs[remote.ID]
+	if len(runs) > 0 && runs[len(runs)-1].Status == ContentSyncRunning {
+		return nil, errors.New("sync already running for remote")
+	}
+	m.nextID++
+	run := &ContentSyncRun{
+		ID:        "content-sync-" + itoa(m.nextID),
+		RemoteID:  remote.ID,
+		Channel:   remote.Channel,
+		Trigger:   trigger,
+		Status:    ContentSyncRunning,
+		Counts:    map[string]int{},
+		Items:     []ContentSyncItem{},
+		StartedAt: time.Now().UTC(),
+	}
+	runs = append(runs, run)
+	if len(runs) > contentSyncHistoryLimit {
+		runs = runs[len(runs)-contentSyncHistoryLimit:]
+	}
+	m.runs[remote.ID] = runs
+	return run, nil
+}
+
+// History returns a remote's sync runs, newest first.
+func (m *ContentMirror) History(remoteID string) []ContentSyncRun {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	runs := m.runs[strings.TrimSpace(remoteID)]
+	out := make([]ContentSyncRun, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		out = append(out, cloneContentSyncRun(*runs[i]))
+	}
+	return out
+}
+
+func (m *ContentMirror) execute(ctx context.Context, run *ContentSyncRun) {
+	result := m.sync(ctx, run.RemoteID)
+	finished := time.Now().UTC()
+
+	m.mu.Lock()
+	run.Status = result.Status
+	run.Error = result.Error
+	run.Discovered = result.Discovered
+	run.Counts = result.Counts
+	run.BytesTransferred = result.BytesTransferred
+	run.Items = result.Items
+	run.FinishedAt = finished
+	out := cloneContentSyncRun(*run)
+	onFinish := m.onFinish
+	m.mu.Unlock()
+
+	m.channels.recordRemoteSync(run.RemoteID, out.Status, finished)
+	if onFinish != nil {
+		onFinish(out)
+	}
+}
+
+func (m *ContentMirror) sync(ctx context.Context, remoteID string) ContentSyncRun {
+	out := ContentSyncRun{Counts: map[string]int{}, Items: []ContentSyncItem{}}
+	remote, token, err := m.channels.remoteForSync(remoteID)
+	if err != nil {
+		out.Status, out.Error = ContentSyncFailed, err.Error()
+		return out
+	}
+	policy, _ := m.channels.GetPolicy(remote.Channel)
+
+	var upstream []PackageArtifact
+	body, err := m.fetch(ctx, remote, token, "/v1/packages/artifacts", 0)
+	if err == nil {
+		err = json.Unmarshal(body, &upstream)
+	}
+	if err != nil {
+		out.Status, out.Error = ContentSyncFailed, "list upstream packages: "+err.Error()
+		return out
+	}
+	sort.Slice(upstream, func(i, j int) bool {
+		if upstream[i].Name != upstream[j].Name {
+			return upstream[i].Name < upstream[j].Name
+		}
+		return compareVersions(upstream[i].Version, upstream[j].Version) < 0
+	})
+	out.Discovered = len(upstream)
+	signing := m.registry.Policy()
+	for _, artifact := range upstream {
+		item := m.mirrorArtifact(ctx, remote, token, policy, signing, artifact)
+		out.Counts[item.Outcome]++
+		out.BytesTransferred += item.Bytes
+		out.Items = append(out.Items, item)
+	}
+	out.Status = ContentSyncSucceeded
+	if out.Counts[ContentSyncError] > 0 {
+		out.Status = ContentSyncPartial
+		if out.Counts[ContentSyncError] == len(upstream) {
+			out.Status = ContentSyncFailed
+		}
+	}
+	return out
+}
+
+func (m *ContentMirror) mirrorArtifact(ctx context.Context, remote OrgSyncRemote, token string, policy ChannelSyncPolicy, signing PackageSigningPolicy, artifact PackageArtifact) ContentSyncItem {
+	kind := strings.ToLower(strings.TrimSpace(artifact.Kind))
+	item := ContentSyncItem{
+		Kind:    kind,
+		Name:    artifact.Name,
+		Version: artifact.Version,
+		Digest:  artifact.Digest,
+	}
+	reject := func(outcome, reason string) ContentSyncItem {
+		item.Outcome = outcome
+		item.Reason = reason
+		return item
+	}
+	ref := strings.ToLower(kind + "/" + artifact.Name)
+	switch {
+	case contentPatternMatches(policy.Blocklist, ref):
+		return reject(ContentSyncFiltered, "blocked by "+remote.Channel+" channel policy")
+	case len(policy.Allowlist) > 0 && !contentPatternMatches(policy.Allowlist, ref):
+		return reject(ContentSyncFiltered, "not in "+remote.Channel+" channel allowlist")
+	case len(remote.Packages) > 0 && !contentPatternMatches(remote.Packages, ref):
+		return reject(ContentSyncFiltered, "not selected by remote")
+	}
+	if local, ok := m.registry.findVersion(kind, artifact.Name, artifact.Version); ok {
+		item.ArtifactID = local.ID
+		if local.Digest == strings.ToLower(artifact.Digest) {
+			return reject(ContentSyncUnchanged, "")
+		}
+		return reject(ContentSyncRejected, "upstream digest differs from local "+local.Digest+"; versions are immutable")
+	}
+	if reason := packageSignatureProblem(artifact, signing); reason != "" {
+		return reject(ContentSyncRejected, reason)
+	}
+
+	input := PackageArtifactInput{
+		Kind:         kind,
+		Name:         artifact.Name,
+		Version:      artifact.Version,
+		Digest:       artifact.Digest,
+		Visibility:   artifact.Visibility,
+		Signed:       artifact.Signed,
+		KeyID:        artifact.KeyID,
+		Signature:    artifact.Signature,
+		Metadata:     map[string]string{},
+		Provenance:   artifact.Provenance,
+		Dependencies: artifact.Dependencies,
+	}
+	for k, v := range artifact.Metadata {
+		input.Metadata[k] = v
+	}
+	input.Metadata["mirrored_from"] = remote.ID
+	input.Metadata["upstream_url"] = remote.URL
+	input.Metadata["upstream_artifact_id"] = artifact.ID
+	input.Metadata["channel"] = remote.Channel
+
+	if artifact.ObjectKey != "" {
+		data, err := m.fetch(ctx, remote, token, "/v1/packages/artifacts/"+url.PathEscape(artifact.ID)+"/download", remote.BandwidthLimitKBps)
+		if err != nil {
+			return reject(ContentSyncError, "download: "+err.Error())
+		}
+		item.Bytes = int64(len(data))
+		sum := sha256.Sum256(data)
+		if "sha256:"+hex.EncodeToString(sum[:]) != strings.ToLower(artifact.Digest) {
+			return reject(ContentSyncRejected, "downloaded tarball does not match advertised digest")
+		}
+		if m.store == nil {
+			return reject(ContentSyncError, "no tarball store configured")
+		}
+		key, err := m.store(data)
+		if err != nil {
+			return reject(ContentSyncError, "store tarball: "+err.Error())
+		}
+		input.ObjectKey = key
+		input.SizeBytes = item.Bytes
+	}
+	local, err := m.registry.Publish(input)
+	if err != nil {
+		return reject(ContentSyncError, err.Error())
+	}
+	item.ArtifactID = local.ID
+	item.Outcome = ContentSyncMirrored
+	return item
+}
+
+func (m *ContentMirror) fetch(ctx context.Context, remote OrgSyncRemote, token, endpoint string, limitKBps int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(remote.URL, "/")+endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	m.mu.RLock()
+	client := m.client
+	m.mu.RUnlock()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("upstream returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return readThrottled(ctx, io.LimitReader(resp.Body, contentSyncMaxTarball), limitKBps)
+}
+
+// readThrottled reads r in 100ms-sized chunks, sleeping as needed to stay
+// under limitKBps. A limit of 0 reads at full speed.
+func readThrottled(ctx context.Context, r io.Reader, limitKBps int) ([]byte, error) {
+	if limitKBps <= 0 {
+		return io.ReadAll(r)
+	}
+	rate := float64(limitKBps * 1024)
+	chunk := int64(rate / 10)
+	if chunk < 1 {
+		chunk = 1
+	}
+	var buf bytes.Buffer
+	start := time.Now()
+	for {
+		_, err := io.CopyN(&buf, r, chunk)
+		expected := time.Duration(float64(buf.Len()) / rate * float64(time.Second))
+		if wait := expected - time.Since(start); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func contentPatternMatches(patterns []string, ref string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, ref); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneContentSyncRun(in ContentSyncRun) ContentSyncRun {
+	out := in
+	out.Counts = make(map[string]int, len(in.Counts))
+	for k, v := range in.Counts {
+		out.Counts[k] = v
+	}
+	out.Items = append([]ContentSyncItem{}, in.Items...)
+	return out
+}
@@ -0,0 +1,141 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContentMirrorSyncsSelectedPackages(t *testing.T) {
+	tarball := []byte("nginx module tarball")
+	sum := sha256.Sum256(tarball)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	other := "sha256:" + strings.Repeat("b", 64)
+	upstream := []PackageArtifact{
+		{ID: "up-1", Kind: "module", Name: "core/nginx", Version: "1.2.0", Digest: digest, Signed: true, KeyID: "k1", Signature: "sig", ObjectKey: "blob-1"},
+		{ID: "up-2", Kind: "module", Name: "core/tampered", Version: "1.0.0", Digest: other, Signed: true, KeyID: "k1", Signature: "sig", ObjectKey: "blob-2"},
+		{ID: "up-3", Kind: "module", Name: "core/unsigned", Version: "1.0.0", Digest: other},
+		{ID: "up-4", Kind: "module", Name: "community/redis", Version: "2.0.0", Digest: other, Signed: true, KeyID: "k1", Signature: "sig"},
+		{ID: "up-5", Kind: "provider", Name: "core/aws", Version: "3.0.0", Digest: other, Signed: true, KeyID: "k1", Signature: "sig"},
+	}
+	var downloads int
+	upstreamServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/packages/artifacts":
+			_ = json.NewEncoder(w).Encode(upstream)
+		case "/v1/packages/artifacts/up-1/download", "/v1/packages/artifacts/up-2/download":
+			downloads++
+			_, _ = w.Write(tarball)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstreamServer.Close()
+
+	channels := NewContentChannelStore()
+	if _, err := channels.SetPolicy(ChannelSyncPolicy{Channel: "validated", Blocklist: []string{"module/community/*"}}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	remote, err := channels.UpsertRemote(OrgSyncRemoteInput{
+		Organization:       "acme",
+		Channel:            "validated",
+		Name:               "central",
+		URL:                upstreamServer.URL,
+		APIToken:           "secret-token",
+		Packages:           []string{"module/core/*"},
+		BandwidthLimitKBps: 1024,
+		Enabled:            true,
+	})
+	if err != nil {
+		t.Fatalf("upsert remote failed: %v", err)
+	}
+	if remote.SyncLagSeconds != -1 {
+		t.Fatalf("expected no sync lag before first sync, got %+v", remote)
+	}
+
+	registry := NewPackageRegistryStore()
+	stored := map[string][]byte{}
+	mirror := NewContentMirror(channels, registry, func(data []byte) (string, error) {
+		key := "blobs/" + itoa(int64(len(stored)+1))
+		stored[key] = data
+		return key, nil
+	})
+	mirror.SetClient(upstreamServer.Client())
+	finished := make(chan ContentSyncRun, 1)
+	mirror.OnFinish(func(run ContentSyncRun) { finished <- run })
+	wait := func() ContentSyncRun {
+		select {
+		case run := <-finished:
+			return run
+		case <-time.After(5 * time.Second):
+			t.Fatalf("sync did not finish")
+		}
+		return ContentSyncRun{}
+	}
+
+	started, err := mirror.Trigger(remote.ID)
+	if err != nil {
+		t.Fatalf("trigger failed: %v", err)
+	}
+	if started.Status != ContentSyncRunning || started.Trigger != "api" {
+		t.Fatalf("unexpected started run: %+v", started)
+	}
+	run := wait()
+	if run.Status != ContentSyncSucceeded || run.Discovered != 5 {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	if run.Counts[ContentSyncMirrored] != 1 || run.Counts[ContentSyncRejected] != 2 || run.Counts[ContentSyncFiltered] != 2 {
+		t.Fatalf("unexpected outcome counts: %+v", run.Counts)
+	}
+	if run.BytesTransferred != int64(2*len(tarball)) || downloads != 2 {
+		t.Fatalf("expected two downloads, got bytes=%d downloads=%d", run.BytesTransferred, downloads)
+	}
+	mirrored, ok := registry.findVersion("module", "core/nginx", "1.2.0")
+	if !ok || mirrored.Metadata["mirrored_from"] != remote.ID || mirrored.ObjectKey == "" || string(stored[mirrored.ObjectKey]) != string(tarball) {
+		t.Fatalf("expected nginx mirrored with tarball, got %+v", mirrored)
+	}
+	if _, ok := registry.findVersion("module", "core/tampered", "1.0.0"); ok {
+		t.Fatalf("expected tampered tarball to be rejected")
+	}
+
+	synced, err := channels.GetRemote(remote.ID)
+	if err != nil || synced.LastSyncStatus != ContentSyncSucceeded || synced.LastSuccessAt.IsZero() || synced.SyncLagSeconds < 0 {
+		t.Fatalf("expected remote sync recorded, got %+v err=%v", synced, err)
+	}
+
+	if _, err := mirror.Trigger(remote.ID); err != nil {
+		t.Fatalf("second trigger failed: %v", err)
+	}
+	run = wait()
+	if run.Counts[ContentSyncUnchanged] != 1 || run.Counts[ContentSyncMirrored] != 0 || downloads != 3 {
+		t.Fatalf("expected delta sync to skip unchanged nginx, got %+v downloads=%d", run.Counts, downloads)
+	}
+
+	history := mirror.History(remote.ID)
+	if len(history) != 2 || history[0].ID != run.ID {
+		t.Fatalf("expected newest-first history of two runs, got %+v", history)
+	}
+
+	if _, err := channels.UpsertRemote(OrgSyncRemoteInput{
+		Organization: "acme",
+		Channel:      "validated",
+		Name:         "central",
+		URL:          upstreamServer.URL,
+		APIToken:     "secret-token",
+		Enabled:      false,
+	}); err != nil {
+		t.Fatalf("disable remote failed: %v", err)
+	}
+	if _, err := mirror.Trigger(remote.ID); err == nil {
+		t.Fatalf("expected disabled remote to refuse sync")
+	}
+}
@@ -0,0 +1,409 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Profile capture triggers.
+const (
+	ProfileTriggerManual            = "manual"
+	ProfileTriggerBacklogSaturation = "backlog_saturation"
+	ProfileTriggerHighGC            = "high_gc"
+)
+
+const maxProfileCaptures = 200
+
+// ContinuousProfilerConfig controls when the profiler captures CPU and heap
+// profiles on its own. Manual captures ignore Enabled and the cooldown.
+type ContinuousProfilerConfig struct {
+	Enabled             bool      `json:"enabled"`
+	OnBacklogSaturation bool      `json:"on_backlog_saturation"`
+	OnHighGC            bool      `json:"on_high_gc"`
+	CPUSeconds          int       `json:"cpu_seconds"`
+	CooldownSeconds     int       `json:"cooldown_seconds"`
+	SampleSeconds       int       `json:"sample_seconds"`
+	GCPausePercent      float64   `json:"gc_pause_percent"`
+	GCCyclesPerMinute   int       `json:"gc_cycles_per_minute"`
+	Retain              int       `json:"retain"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// ProfileArtifact is one profile of a capture written to the object store.
+type ProfileArtifact struct {
+	Kind      string `json:"kind"`
+	ObjectKey string `json:"object_key"`
+	SizeBytes int    `json:"size_bytes"`
+}
+
+type ProfileCapture struct {
+	ID          string            `json:"id"`
+	Trigger     string            `json:"trigger"`
+	Reason      string            `json:"reason,omitempty"`
+	Status      string            `json:"status"`
+	CPUSeconds  int               `json:"cpu_seconds"`
+	Artifacts   []ProfileArtifact `json:"artifacts,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	StartedAt   time.Time         `json:"started_at"`
+	CompletedAt time.Time         `json:"completed_at,omitempty"`
+}
+
+// ProfileSink persists a captured profile under key.
+type ProfileSink func(key string, data []byte) error
+
+// ContinuousProfiler captures CPU and heap profiles when triggered by
+// backlog saturation, sustained GC pressure, or an operator, and hands them
+// to a sink for storage. Only one capture runs at a time because the
+// runtime allows a single CPU profile.
+type ContinuousProfiler struct {
+	mu        sync.RWMutex
+	nextID    int64
+	config    ContinuousProfilerConfig
+	sink      ProfileSink
+	onCapture func(ProfileCapture)
+	captures  map[string]*ProfileCapture
+	capturing string
+	last      time.Time
+	lastGC    runtime.MemStats
+	lastGCAt  time.Time
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewContinuousProfiler(sink ProfileSink) *ContinuousProfiler {
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg, _ := normalizeContinuousProfilerConfig(ContinuousProfilerConfig{
+		OnBacklogSaturation: true,
+		OnHighGC:            true,
+	})
+	return &ContinuousProfiler{
+		config:   cfg,
+		sink:     sink,
+		captures: map[string]*ProfileCapture{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func normalizeContinuousProfilerConfig(in ContinuousProfilerConfig) (ContinuousProfilerConfig, error) {
+	if in.CPUSeconds == 0 {
+		in.CPUSeconds = 10
+	}
+	if in.CPUSeconds < 1 || in.CPUSeconds > 60 {
+		return ContinuousProfilerConfig{}, errors.New("cpu_seconds must be between 1 and 60")
+	}
+	if in.CooldownSeconds == 0 {
+		in.CooldownSeconds = 300
+	}
+	if in.CooldownSeconds < 0 {
+		return ContinuousProfilerConfig{}, errors.New("cooldown_seconds must be zero or greater")
+	}
+	if in.SampleSeconds == 0 {
+		in.SampleSeconds = 15
+	}
+	if in.SampleSeconds < 1 {
+		return ContinuousProfilerConfig{}, errors.New("sample_seconds must be at least 1")
+	}
+	if in.GCPausePercent == 0 {
+		in.GCPausePercent = 5
+	}
+	if in.GCPausePercent < 0 || in.GCPausePercent > 100 {
+		return ContinuousProfilerConfig{}, errors.New("gc_pause_percent must be between 0 and 100")
+	}
+	if in.GCCyclesPerMinute == 0 {
+		in.GCCyclesPerMinute = 120
+	}
+	if in.GCCyclesPerMinute < 0 {
+		return ContinuousProfilerConfig{}, errors.New("gc_cycles_per_minute must be zero or greater")
+	}
+	if in.Retain == 0 {
+		in.Retain = 50
+	}
+	if in.Retain < 1 || in.Retain > maxProfileCaptures {
+		return ContinuousProfilerConfig{}, errors.New("retain must be between 1 and " + strconv.Itoa(maxProfileCaptures))
+	}
+	in.UpdatedAt = time.Now().UTC()
+	return in, nil
+}
+
+func (p *ContinuousProfiler) Config() ContinuousProfilerConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config
+}
+
+func (p *ContinuousProfiler) SetConfig(in ContinuousProfilerConfig) (ContinuousProfilerConfig, error) {
+	cfg, err := normalizeContinuousProfilerConfig(in)
+	if err != nil {
+		return ContinuousProfilerConfig{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = cfg
+	p.pruneLocked()
+	return cfg, nil
+}
+
+// OnCapture registers a callback invoked when a capture finishes.
+func (p *ContinuousProfiler) OnCapture(fn func(ProfileCapture)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onCapture = fn
+}
+
+// Start launches the GC pressure sampler.
+func (p *ContinuousProfiler) Start() {
+	p.wg.Add(1)
+	go p.sampleGC()
+}
+
+// Shutdown stops the sampler and cuts any running CPU profile short.
+func (p *ContinuousProfiler) Shutdown() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+// Trigger starts a capture in the background and returns it in capturing
+// state. Automatic triggers are skipped (ok=false) while the profiler or
+// the trigger is disabled, during the cooldown, or while another capture
+// runs; manual captures only wait for a running capture.
+func (p *ContinuousProfiler) Trigger(trigger, reason string) (ProfileCapture, bool, error) {
+	trigger = strings.ToLower(strings.TrimSpace(trigger))
+	now := time.Now().UTC()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx.Err() != nil {
+		return ProfileCapture{}, false, errors.New("profiler is shut down")
+	}
+	cfg := p.config
+	switch trigger {
+	case ProfileTriggerManual:
+	case ProfileTriggerBacklogSaturation, ProfileTriggerHighGC:
+		if !cfg.Enabled {
+			return ProfileCapture{}, false, nil
+		}
+		if (trigger == ProfileTriggerBacklogSaturation && !cfg.OnBacklogSaturation) || (trigger == ProfileTriggerHighGC && !cfg.OnHighGC) {
+			return ProfileCapture{}, false, nil
+		}
+		if !p.last.IsZero() && now.Sub(p.last) < time.Duration(cfg.CooldownSeconds)*time.Second {
+			return ProfileCapture{}, false, nil
+		}
+	default:
+		return ProfileCapture{}, false, errors.New("unsupported profile trigger")
+	}
+	if p.capturing != "" {
+		if trigger == ProfileTriggerManual {
+			return ProfileCapture{}, false, errors.New("profile capture " + p.capturing + " is already running")
+		}
+		return ProfileCapture{}, false, nil
+	}
+	p.nextID++
+	item := &ProfileCapture{
+		ID:         "profile-" + itoa(p.nextID),
+		Trigger:    trigger,
+		Reason:     strings.TrimSpace(reason),
+		Status:     "capturing",
+		CPUSeconds: cfg.CPUSeconds,
+		StartedAt:  now,
+	}
+	p.captures[item.ID] = item
+	p.capturing = item.ID
+	p.last = now
+	p.pruneLocked()
+	p.wg.Add(1)
+	go p.capture(*item)
+	return cloneProfileCapture(*item), true, nil
+}
+
+func (p *ContinuousProfiler) capture(item ProfileCapture) {
+	defer p.wg.Done()
+	var artifacts []ProfileArtifact
+	var errs []string
+	cpu, err := captureCPUProfile(p.ctx, time.Duration(item.CPUSeconds)*time.Second)
+	if err != nil {
+		errs = append(errs, "cpu: "+err.Error())
+	} else if artifact, err := p.store(item.ID, "cpu", cpu); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		artifacts = append(artifacts, artifact)
+	}
+	var heap bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		errs = append(errs, "heap: "+err.Error())
+	} else if artifact, err := p.store(item.ID, "heap", heap.Bytes()); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		artifacts = append(artifacts, artifact)
+	}
+
+	p.mu.Lock()
+	stored, ok := p.captures[item.ID]
+	if !ok {
+		// Pruned while capturing; keep the record so the result is visible.
+		stored = &item
+		p.captures[item.ID] = stored
+	}
+	stored.Artifacts = artifacts
+	stored.Status = "completed"
+	if len(artifacts) == 0 {
+		stored.Status = "failed"
+	}
+	stored.Error = strings.Join(errs, "; ")
+	stored.CompletedAt = time.Now().UTC()
+	p.capturing = ""
+	out := cloneProfileCapture(*stored)
+	onCapture := p.onCapture
+	p.mu.Unlock()
+	if onCapture != nil {
+		onCapture(out)
+	}
+}
+
+func (p *ContinuousProfiler) store(id, kind string, data []byte) (ProfileArtifact, error) {
+	if p.sink == nil {
+		return ProfileArtifact{}, errors.New(kind + ": no profile sink configured")
+	}
+	key := "profiles/" + id + "/" + kind + ".pb.gz"
+	if err := p.sink(key, data); err != nil {
+		return ProfileArtifact{}, errors.New(kind + ": " + err.Error())
+	}
+	return ProfileArtifact{Kind: kind, ObjectKey: key, SizeBytes: len(data)}, nil
+}
+
+// captureCPUProfile records a CPU profile for d, or until ctx ends.
+func captureCPUProfile(ctx context.Context, d time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, err
+	}
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	t.Stop()
+	pprof.StopCPUProfile()
+	return buf.Bytes(), nil
+}
+
+func (p *ContinuousProfiler) sampleGC() {
+	defer p.wg.Done()
+	for {
+		interval := time.Duration(p.Config().SampleSeconds) * time.Second
+		t := time.NewTimer(interval)
+		select {
+		case <-p.ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if reason := p.ObserveGC(time.Now().UTC()); reason != "" {
+			_, _, _ = p.Trigger(ProfileTriggerHighGC, reason)
+		}
+	}
+}
+
+// ObserveGC samples runtime GC statistics and reports GC pressure since the
+// previous sample, or "" when within limits.
+func (p *ContinuousProfiler) ObserveGC(now time.Time) string {
+	var cur runtime.MemStats
+	runtime.ReadMemStats(&cur)
+	p.mu.Lock()
+	prev, prevAt := p.lastGC, p.lastGCAt
+	p.lastGC, p.lastGCAt = cur, now
+	cfg := p.config
+	p.mu.Unlock()
+	if prevAt.IsZero() {
+		return ""
+	}
+	return gcPressure(cfg, prev, cur, now.Sub(prevAt))
+}
+
+// gcPressure compares two MemStats samples taken elapsed apart against the
+// configured pause share and cycle rate.
+func gcPressure(cfg ContinuousProfilerConfig, prev, cur runtime.MemStats, elapsed time.Duration) string {
+	if elapsed <= 0 || cur.NumGC < prev.NumGC {
+		return ""
+	}
+	pausePercent := float64(cur.PauseTotalNs-prev.PauseTotalNs) / float64(elapsed.Nanoseconds()) * 100
+	if cfg.GCPausePercent > 0 && pausePercent >= cfg.GCPausePercent {
+		return "gc pauses took " + strconv.FormatFloat(pausePercent, 'f', 1, 64) + "% of wall time (limit " + strconv.FormatFloat(cfg.GCPausePercent, 'f', 1, 64) + "%)"
+	}
+	perMinute := float64(cur.NumGC-prev.NumGC) / elapsed.Minutes()
+	if cfg.GCCyclesPerMinute > 0 && perMinute >= float64(cfg.GCCyclesPerMinute) {
+		return strconv.Itoa(int(perMinute)) + " gc cycles per minute (limit " + strconv.Itoa(cfg.GCCyclesPerMinute) + ")"
+	}
+	return ""
+}
+
+// List returns captures newest first.
+func (p *ContinuousProfiler) List(limit int) []ProfileCapture {
+	p.mu.RLock()
+	out := make([]ProfileCapture, 0, len(p.captures))
+	for _, item := range p.captures {
+		out = append(out, cloneProfileCapture(*item))
+	}
+	p.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].StartedAt.Equal(out[j].StartedAt) {
+			return out[i].ID > out[j].ID
+		}
+		return out[i].StartedAt.After(out[j].StartedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (p *ContinuousProfiler) Get(id string) (ProfileCapture, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	item, ok := p.captures[strings.TrimSpace(id)]
+	if !ok {
+		return ProfileCapture{}, errors.New("profile capture not found")
+	}
+	return cloneProfileCapture(*item), nil
+}
+
+// Capturing returns the ID of the running capture, if any.
+func (p *ContinuousProfiler) Capturing() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.capturing
+}
+
+// pruneLocked drops the oldest finished captures beyond the retain limit.
+func (p *ContinuousProfiler) pruneLocked() {
+	if len(p.captures) <= p.config.Retain {
+		return
+	}
+	ids := make([]*ProfileCapture, 0, len(p.captures))
+	for _, item := range p.captures {
+		if item.ID != p.capturing {
+			ids = append(ids, item)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].StartedAt.Before(ids[j].StartedAt) })
+	for _, item := range ids {
+		if len(p.captures) <= p.config.Retain {
+			break
+		}
+		delete(p.captures, item.ID)
+	}
+}
+
+func cloneProfileCapture(in ProfileCapture) ProfileCapture {
+	out := in
+	out.Artifacts = append([]ProfileArtifact{}, in.Artifacts...)
+	return out
+}
@@ -0,0 +1,88 @@
+package control
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContinuousProfilerTriggers(t *testing.T) {
+	var mu sync.Mutex
+	stored := map[string]int{}
+	p := NewContinuousProfiler(func(key string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stored[key] = len(data)
+		return nil
+	})
+	t.Cleanup(p.Shutdown)
+	done := make(chan ProfileCapture, 1)
+	p.OnCapture(func(c ProfileCapture) { done <- c })
+
+	if _, ok, err := p.Trigger(ProfileTriggerBacklogSaturation, "pending 500"); err != nil || ok {
+		t.Fatalf("expected automatic trigger to be skipped while disabled, ok=%t err=%v", ok, err)
+	}
+	if _, err := p.SetConfig(ContinuousProfilerConfig{Enabled: true, OnBacklogSaturation: true, CPUSeconds: 1}); err != nil {
+		t.Fatalf("set config failed: %v", err)
+	}
+	if _, ok, _ := p.Trigger(ProfileTriggerHighGC, "gc"); ok {
+		t.Fatalf("expected high_gc trigger to be skipped when not configured")
+	}
+	capture, ok, err := p.Trigger(ProfileTriggerBacklogSaturation, "pending 500")
+	if err != nil || !ok || capture.Status != "capturing" {
+		t.Fatalf("expected saturation capture to start, got %+v ok=%t err=%v", capture, ok, err)
+	}
+	if _, _, err := p.Trigger(ProfileTriggerManual, ""); err == nil {
+		t.Fatalf("expected manual capture to be rejected while another capture runs")
+	}
+
+	select {
+	case finished := <-done:
+		if finished.Status != "completed" || len(finished.Artifacts) != 2 {
+			t.Fatalf("unexpected finished capture: %+v", finished)
+		}
+		mu.Lock()
+		for _, artifact := range finished.Artifacts {
+			if stored[artifact.ObjectKey] == 0 || !strings.HasPrefix(artifact.ObjectKey, "profiles/"+finished.ID+"/") {
+				t.Fatalf("artifact not stored: %+v", artifact)
+			}
+		}
+		mu.Unlock()
+	case <-time.After(10 * time.Second):
+		t.Fatalf("capture did not finish")
+	}
+	if p.Capturing() != "" {
+		t.Fatalf("expected no running capture")
+	}
+	if _, ok, _ := p.Trigger(ProfileTriggerBacklogSaturation, "again"); ok {
+		t.Fatalf("expected automatic trigger to respect the cooldown")
+	}
+	if items := p.List(10); len(items) != 1 || items[0].ID != capture.ID {
+		t.Fatalf("unexpected capture list: %+v", items)
+	}
+}
+
+func TestGCPressure(t *testing.T) {
+	cfg, err := normalizeContinuousProfilerConfig(ContinuousProfilerConfig{GCPausePercent: 5, GCCyclesPerMinute: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := runtime.MemStats{NumGC: 10, PauseTotalNs: 0}
+	quiet := runtime.MemStats{NumGC: 12, PauseTotalNs: uint64(10 * time.Millisecond)}
+	if reason := gcPressure(cfg, prev, quiet, time.Minute); reason != "" {
+		t.Fatalf("expected no gc pressure, got %q", reason)
+	}
+	pauses := runtime.MemStats{NumGC: 12, PauseTotalNs: uint64(6 * time.Second)}
+	if reason := gcPressure(cfg, prev, pauses, time.Minute); !strings.Contains(reason, "wall time") {
+		t.Fatalf("expected pause pressure, got %q", reason)
+	}
+	cycles := runtime.MemStats{NumGC: 100, PauseTotalNs: uint64(10 * time.Millisecond)}
+	if reason := gcPressure(cfg, prev, cycles, time.Minute); !strings.Contains(reason, "cycles per minute") {
+		t.Fatalf("expected cycle pressure, got %q", reason)
+	}
+	if _, err := normalizeContinuousProfilerConfig(ContinuousProfilerConfig{CPUSeconds: 120}); err == nil {
+		t.Fatalf("expected cpu_seconds validation error")
+	}
+}
@@ -8,37 +8,113 @@ import (
 	"time"
 )
 
+// Widget data providers resolved server-side at
+// /v1/ui/dashboard/widgets/{id}/data.
+const (
+	WidgetProviderQuery        = "query"         // saved view results
+	WidgetProviderMetricSeries = "metric_series" // event counts per time bucket
+	WidgetProviderEventFeed    = "event_feed"    // latest matching events
+	WidgetProviderScorecard    = "scorecard"     // latest readiness scorecards
+)
+
+const (
+	defaultWidgetRefreshSeconds = 60
+	minWidgetRefreshSeconds     = 5
+	maxWidgetRefreshSeconds     = 86400
+)
+
 type DashboardWidget struct {
-	ID              string    `json:"id"`
-	ViewID          string    `json:"view_id"`
-	Title           string    `json:"title"`
-	Description     string    `json:"description,omitempty"`
-	Width           int       `json:"width"`
-	Height          int       `json:"height"`
-	Column          int       `json:"column"`
-	Row             int       `json:"row"`
-	Pinned          bool      `json:"pinned"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	LastRefreshedAt time.Time `json:"last_refreshed_at,omitempty"`
+	ID                     string    `json:"id"`
+	ViewID                 string    `json:"view_id,omitempty"`
+	Provider               string    `json:"provider"`
+	EventType              string    `json:"event_type,omitempty"`     // metric_series and event_feed type prefix
+	Environment            string    `json:"environment,omitempty"`    // scorecard filter
+	Service                string    `json:"service,omitempty"`        // scorecard filter
+	WindowSeconds          int       `json:"window_seconds,omitempty"` // metric_series, default 3600
+	BucketSeconds          int       `json:"bucket_seconds,omitempty"` // metric_series, default 300
+	Limit                  int       `json:"limit,omitempty"`          // event_feed and scorecard, default 20
+	RefreshIntervalSeconds int       `json:"refresh_interval_seconds"`
+	Title                  string    `json:"title"`
+	Description            string    `json:"description,omitempty"`
+	Width                  int       `json:"width"`
+	Height                 int       `json:"height"`
+	Column                 int       `json:"column"`
+	Row                    int       `json:"row"`
+	Pinned                 bool      `json:"pinned"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+	LastRefreshedAt        time.Time `json:"last_refreshed_at,omitempty"`
+}
+
+// DashboardWidgetData is the resolved payload of a widget, cached until its
+// refresh interval elapses.
+type DashboardWidgetData struct {
+	WidgetID   string    `json:"widget_id"`
+	Provider   string    `json:"provider"`
+	Data       any       `json:"data"`
+	Cached     bool      `json:"cached"`
+	ResolvedAt time.Time `json:"resolved_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type DashboardSeriesPoint struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
 }
 
 type DashboardWidgetStore struct {
 	mu      sync.RWMutex
 	nextID  int64
 	widgets map[string]*DashboardWidget
+	data    map[string]DashboardWidgetData
 }
 
 func NewDashboardWidgetStore() *DashboardWidgetStore {
 	return &DashboardWidgetStore{
 		widgets: map[string]*DashboardWidget{},
+		data:    map[string]DashboardWidgetData{},
 	}
 }
 
 func (s *DashboardWidgetStore) Create(in DashboardWidget) (DashboardWidget, error) {
+	provider := strings.ToLower(strings.TrimSpace(in.Provider))
+	if provider == "" {
+		provider = WidgetProviderQuery
+	}
 	viewID := strings.TrimSpace(in.ViewID)
-	if viewID == "" {
-		return DashboardWidget{}, errors.New("view_id is required")
+	switch provider {
+	case WidgetProviderQuery:
+		if viewID == "" {
+			return DashboardWidget{}, errors.New("view_id is required")
+		}
+	case WidgetProviderMetricSeries:
+		if strings.TrimSpace(in.EventType) == "" {
+			return DashboardWidget{}, errors.New("event_type is required for metric_series widgets")
+		}
+		if in.WindowSeconds <= 0 {
+			in.WindowSeconds = 3600
+		}
+		if in.BucketSeconds <= 0 {
+			in.BucketSeconds = 300
+		}
+		if in.BucketSeconds > in.WindowSeconds || in.WindowSeconds/in.BucketSeconds > 1000 {
+			return DashboardWidget{}, errors.New("window_seconds must hold between 1 and 1000 buckets")
+		}
+	case WidgetProviderEventFeed, WidgetProviderScorecard:
+		if in.Limit <= 0 {
+			in.Limit = 20
+		}
+		if in.Limit > 500 {
+			return DashboardWidget{}, errors.New("limit must be <= 500")
+		}
+	default:
+		return DashboardWidget{}, errors.New("provider must be query, metric_series, event_feed, or scorecard")
+	}
+	if in.RefreshIntervalSeconds == 0 {
+		in.RefreshIntervalSeconds = defaultWidgetRefreshSeconds
+	}
+	if in.RefreshIntervalSeconds < minWidgetRefreshSeconds || in.RefreshIntervalSeconds > maxWidgetRefreshSeconds {
+		return DashboardWidget{}, errors.New("refresh_interval_seconds must be between 5 and 86400")
 	}
 	title := strings.TrimSpace(in.Title)
 	if title == "" {
@@ -68,17 +144,25 @@ func (s *DashboardWidgetStore) Create(in DashboardWidget) (DashboardWidget, erro
 	defer s.mu.Unlock()
 	s.nextID++
 	item := DashboardWidget{
-		ID:          "widget-" + itoa(s.nextID),
-		ViewID:      viewID,
-		Title:       title,
-		Description: strings.TrimSpace(in.Description),
-		Width:       in.Width,
-		Height:      in.Height,
-		Column:      in.Column,
-		Row:         in.Row,
-		Pinned:      in.Pinned,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:                     "widget-" + itoa(s.nextID),
+		ViewID:                 viewID,
+		Provider:               provider,
+		EventType:              strings.TrimSpace(in.EventType),
+		Environment:            strings.TrimSpace(in.Environment),
+		Service:                strings.TrimSpace(in.Service),
+		WindowSeconds:          in.WindowSeconds,
+		BucketSeconds:          in.BucketSeconds,
+		Limit:                  in.Limit,
+		RefreshIntervalSeconds: in.RefreshIntervalSeconds,
+		Title:                  title,
+		Description:            strings.TrimSpace(in.Description),
+		Width:                  in.Width,
+		Height:                 in.Height,
+		Column:                 in.Column,
+		Row:                    in.Row,
+		Pinned:                 in.Pinned,
+		CreatedAt:              now,
+		UpdatedAt:              now,
 	}
 	s.widgets[item.ID] = &item
 	return item, nil
@@ -124,6 +208,7 @@ func (s *DashboardWidgetStore) Delete(id string) error {
 		return errors.New("widget not found")
 	}
 	delete(s.widgets, id)
+	delete(s.data, id)
 	return nil
 }
 
@@ -148,9 +233,73 @@ func (s *DashboardWidgetStore) Refresh(id string) (DashboardWidget, error) {
 	}
 	item.LastRefreshedAt = time.Now().UTC()
 	item.UpdatedAt = item.LastRefreshedAt
+	delete(s.data, item.ID) // the next data request resolves fresh
 	return *cloneDashboardWidget(item), nil
 }
 
+// CachedData returns the widget's resolved data while its refresh interval
+// has not elapsed.
+func (s *DashboardWidgetStore) CachedData(id string, now time.Time) (DashboardWidgetData, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.data[strings.TrimSpace(id)]
+	if !ok || !now.Before(item.ExpiresAt) {
+		return DashboardWidgetData{}, false
+	}
+	item.Cached = true
+	return item, true
+}
+
+// StoreData caches freshly resolved data for the widget's refresh interval
+// and marks the widget refreshed.
+func (s *DashboardWidgetStore) StoreData(id string, data any, now time.Time) (DashboardWidgetData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.widgets[strings.TrimSpace(id)]
+	if !ok {
+		return DashboardWidgetData{}, errors.New("widget not found")
+	}
+	interval := item.RefreshIntervalSeconds
+	if interval <= 0 {
+		interval = defaultWidgetRefreshSeconds
+	}
+	out := DashboardWidgetData{
+		WidgetID:   item.ID,
+		Provider:   item.Provider,
+		Data:       data,
+		ResolvedAt: now,
+		ExpiresAt:  now.Add(time.Duration(interval) * time.Second),
+	}
+	s.data[item.ID] = out
+	item.LastRefreshedAt = now
+	return out, nil
+}
+
+// EventCountSeries buckets events whose type starts with typePrefix into
+// bucket-sized counts over the window ending at now, oldest first.
+func EventCountSeries(events []Event, typePrefix string, window, bucket time.Duration, now time.Time) []DashboardSeriesPoint {
+	if bucket <= 0 || window < bucket {
+		return []DashboardSeriesPoint{}
+	}
+	end := now.Truncate(bucket).Add(bucket)
+	start := end.Add(-window)
+	n := int(window / bucket)
+	out := make([]DashboardSeriesPoint, n)
+	for i := range out {
+		out[i].Start = start.Add(time.Duration(i) * bucket)
+	}
+	typePrefix = strings.ToLower(strings.TrimSpace(typePrefix))
+	for _, e := range events {
+		if !strings.HasPrefix(strings.ToLower(e.Type), typePrefix) || e.Time.Before(start) || !e.Time.Before(end) {
+			continue
+		}
+		if idx := int(e.Time.Sub(start) / bucket); idx >= 0 && idx < n {
+			out[idx].Count++
+		}
+	}
+	return out
+}
+
 func cloneDashboardWidget(in *DashboardWidget) *DashboardWidget {
 	if in == nil {
 		return nil
@@ -1,6 +1,9 @@
 package control
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestDashboardWidgetStoreLifecycle(t *testing.T) {
 	store := NewDashboardWidgetStore()
@@ -51,3 +54,62 @@ func TestDashboardWidgetStoreLifecycle(t *testing.T) {
 		t.Fatalf("expected deleted widget lookup to fail")
 	}
 }
+
+func TestDashboardWidgetDataCacheAndProviders(t *testing.T) {
+	store := NewDashboardWidgetStore()
+	if _, err := store.Create(DashboardWidget{Title: "Applies", Provider: WidgetProviderMetricSeries}); err == nil {
+		t.Fatalf("expected metric_series widget without event_type to fail")
+	}
+	if _, err := store.Create(DashboardWidget{Title: "Bad", Provider: "chart"}); err == nil {
+		t.Fatalf("expected unknown provider to fail")
+	}
+	if _, err := store.Create(DashboardWidget{ViewID: "view-1", Title: "Fast", RefreshIntervalSeconds: 1}); err == nil {
+		t.Fatalf("expected refresh interval below minimum to fail")
+	}
+	item, err := store.Create(DashboardWidget{Title: "Applies", Provider: WidgetProviderMetricSeries, EventType: "apply."})
+	if err != nil {
+		t.Fatalf("create metric widget failed: %v", err)
+	}
+	if item.WindowSeconds != 3600 || item.BucketSeconds != 300 || item.RefreshIntervalSeconds != 60 {
+		t.Fatalf("expected provider defaults, got %+v", item)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if _, ok := store.CachedData(item.ID, now); ok {
+		t.Fatalf("expected no cached data before first resolve")
+	}
+	stored, err := store.StoreData(item.ID, map[string]any{"total": 3}, now)
+	if err != nil {
+		t.Fatalf("store data failed: %v", err)
+	}
+	if !stored.ExpiresAt.Equal(now.Add(time.Minute)) || stored.Cached {
+		t.Fatalf("unexpected stored data: %+v", stored)
+	}
+	cached, ok := store.CachedData(item.ID, now.Add(30*time.Second))
+	if !ok || !cached.Cached {
+		t.Fatalf("expected cached data within refresh interval")
+	}
+	if _, ok := store.CachedData(item.ID, now.Add(time.Minute)); ok {
+		t.Fatalf("expected cached data to expire after refresh interval")
+	}
+	if _, err := store.Refresh(item.ID); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if _, ok := store.CachedData(item.ID, now.Add(time.Second)); ok {
+		t.Fatalf("expected refresh to drop cached data")
+	}
+
+	events := []Event{
+		{Type: "apply.completed", Time: now.Add(-2 * time.Minute)},
+		{Type: "Apply.failed", Time: now.Add(-7 * time.Minute)},
+		{Type: "plan.completed", Time: now.Add(-time.Minute)},
+		{Type: "apply.completed", Time: now.Add(-2 * time.Hour)},
+	}
+	points := EventCountSeries(events, "apply.", time.Hour, 5*time.Minute, now)
+	if len(points) != 12 {
+		t.Fatalf("expected 12 buckets, got %d", len(points))
+	}
+	if points[11].Count != 0 || points[10].Count != 1 || points[9].Count != 1 {
+		t.Fatalf("unexpected bucket counts: %+v", points)
+	}
+}
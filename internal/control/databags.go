@@ -19,6 +19,7 @@ type DataBagItem struct {
 	Bag        string         `json:"bag"`
 	Item       string         `json:"item"`
 	Encrypted  bool           `json:"encrypted"`
+	Format     string         `json:"format,omitempty"`
 	Data       map[string]any `json:"data,omitempty"`
 	Ciphertext string         `json:"ciphertext,omitempty"`
 	Nonce      string         `json:"nonce,omitempty"`
@@ -30,6 +31,7 @@ type DataBagItemSummary struct {
 	Bag       string         `json:"bag"`
 	Item      string         `json:"item"`
 	Encrypted bool           `json:"encrypted"`
+	Format    string         `json:"format,omitempty"`
 	Data      map[string]any `json:"data,omitempty"`
 	Tags      []string       `json:"tags,omitempty"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -47,11 +49,13 @@ type DataBagSearchRequest struct {
 type DataBagStore struct {
 	mu   sync.RWMutex
 	bags map[string]map[string]*DataBagItem
+	keys map[string]*DataBagKey
 }
 
 func NewDataBagStore() *DataBagStore {
 	return &DataBagStore{
 		bags: map[string]map[string]*DataBagItem{},
+		keys: map[string]*DataBagKey{},
 	}
 }
 
@@ -71,22 +75,37 @@ func (s *DataBagStore) Upsert(bag, item string, data map[string]any, encrypted b
 		Tags:      normalizeTags(tags),
 		UpdatedAt: time.Now().UTC(),
 	}
-	if encrypted {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case IsChefEncryptedDataBagItem(data):
+		// Items encrypted client-side (knife or `masterchef databag encrypt`)
+		// are stored as-is in the Chef envelope format.
+		entry.Encrypted = true
+		entry.Format = DataBagFormatChef
+		entry.Data = cloneMap(data)
+	case encrypted && strings.TrimSpace(passphrase) == "" && s.keys[bag] != nil:
+		enc, err := EncryptChefDataBagItem(data, s.keys[bag].Secret)
+		if err != nil {
+			return DataBagItem{}, err
+		}
+		entry.Format = DataBagFormatChef
+		entry.Data = enc
+	case encrypted:
 		if strings.TrimSpace(passphrase) == "" {
-			return DataBagItem{}, errors.New("passphrase is required for encrypted items")
+			return DataBagItem{}, errors.New("passphrase or bag key is required for encrypted items")
 		}
 		ciphertext, nonce, err := encryptDataBagData(data, passphrase)
 		if err != nil {
 			return DataBagItem{}, err
 		}
+		entry.Format = DataBagFormatPassphrase
 		entry.Ciphertext = ciphertext
 		entry.Nonce = nonce
-	} else {
+	default:
 		entry.Data = cloneMap(data)
 	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	if s.bags[bag] == nil {
 		s.bags[bag] = map[string]*DataBagItem{}
 	}
@@ -110,7 +129,7 @@ func (s *DataBagStore) Get(bag, item, passphrase string) (DataBagItem, error) {
 		if strings.TrimSpace(passphrase) == "" {
 			return DataBagItem{}, errors.New("passphrase is required for encrypted item retrieval")
 		}
-		plain, err := decryptDataBagData(out.Ciphertext, out.Nonce, passphrase)
+		plain, err := decryptDataBagEntry(out, passphrase)
 		if err != nil {
 			return DataBagItem{}, err
 		}
@@ -159,6 +178,7 @@ func (s *DataBagStore) ListSummaries() []DataBagItemSummary {
 				Bag:       bag,
 				Item:      item,
 				Encrypted: entry.Encrypted,
+				Format:    entry.Format,
 				Tags:      append([]string{}, entry.Tags...),
 				UpdatedAt: entry.UpdatedAt,
 			}
@@ -202,7 +222,7 @@ func (s *DataBagStore) Search(req DataBagSearchRequest) ([]DataBagItemSummary, e
 				if passphrase == "" {
 					continue
 				}
-				plain, err := decryptDataBagData(entry.Ciphertext, entry.Nonce, passphrase)
+				plain, err := decryptDataBagEntry(*entry, passphrase)
 				if err != nil {
 					return nil, err
 				}
@@ -217,6 +237,7 @@ func (s *DataBagStore) Search(req DataBagSearchRequest) ([]DataBagItemSummary, e
 				Bag:       bag,
 				Item:      itemName,
 				Encrypted: entry.Encrypted,
+				Format:    entry.Format,
 				Data:      data,
 				Tags:      append([]string{}, entry.Tags...),
 				UpdatedAt: entry.UpdatedAt,
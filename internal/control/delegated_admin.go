@@ -98,6 +98,18 @@ func (s *DelegatedAdminStore) List() []DelegatedAdminGrant {
 	return out
 }
 
+func (s *DelegatedAdminStore) Get(id string) (DelegatedAdminGrant, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.grants[strings.TrimSpace(id)]
+	if !ok {
+		return DelegatedAdminGrant{}, false
+	}
+	out := *item
+	out.Scopes = append([]string{}, item.Scopes...)
+	return out, true
+}
+
 func (s *DelegatedAdminStore) Authorize(in DelegatedAdminAuthorizeInput) DelegatedAdminAuthorizeDecision {
 	tenant := strings.ToLower(strings.TrimSpace(in.Tenant))
 	environment := strings.ToLower(strings.TrimSpace(in.Environment))
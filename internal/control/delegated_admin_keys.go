@@ -0,0 +1,408 @@
+package control
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DelegatedAPIKey is a bearer credential minted under a delegated admin
+// grant. It can only call its allowed routes in its target environments,
+// and everything it does is attributed to both the key and the admin who
+// delegated the grant.
+type DelegatedAPIKey struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	GrantID      string     `json:"grant_id"`
+	Tenant       string     `json:"tenant"`
+	Principal    string     `json:"principal"`
+	Delegator    string     `json:"delegator,omitempty"`
+	Routes       []string   `json:"routes"`
+	Environments []string   `json:"environments"`
+	CreatedBy    string     `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RotatedFrom  string     `json:"rotated_from,omitempty"`
+	RotatedTo    string     `json:"rotated_to,omitempty"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+type DelegatedAPIKeyInput struct {
+	GrantID      string   `json:"grant_id"`
+	Name         string   `json:"name"`
+	Routes       []string `json:"routes"`
+	Environments []string `json:"environments,omitempty"`
+	TTLSeconds   int      `json:"ttl_seconds,omitempty"`
+	CreatedBy    string   `json:"created_by,omitempty"`
+}
+
+type IssuedDelegatedAPIKey struct {
+	Key   DelegatedAPIKey `json:"key"`
+	Token string          `json:"token"`
+}
+
+type DelegatedAPIKeyRequest struct {
+	Token       string `json:"token"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Environment string `json:"environment,omitempty"`
+}
+
+type DelegatedAPIKeyDecision struct {
+	Allowed      bool   `json:"allowed"`
+	Reason       string `json:"reason,omitempty"`
+	KeyID        string `json:"key_id,omitempty"`
+	GrantID      string `json:"grant_id,omitempty"`
+	Tenant       string `json:"tenant,omitempty"`
+	Principal    string `json:"principal,omitempty"`
+	Delegator    string `json:"delegator,omitempty"`
+	MatchedRoute string `json:"matched_route,omitempty"`
+}
+
+type delegatedKeyRecord struct {
+	key       DelegatedAPIKey
+	tokenHash string
+}
+
+type DelegatedAPIKeyStore struct {
+	mu         sync.Mutex
+	nextID     int64
+	grants     *DelegatedAdminStore
+	keys       map[string]*delegatedKeyRecord
+	tokenIndex map[string]string
+}
+
+func NewDelegatedAPIKeyStore(grants *DelegatedAdminStore) *DelegatedAPIKeyStore {
+	return &DelegatedAPIKeyStore{
+		grants:     grants,
+		keys:       map[string]*delegatedKeyRecord{},
+		tokenIndex: map[string]string{},
+	}
+}
+
+// Mint issues a key bound to a grant. Routes must fall inside the grant's
+// scopes, and environments default to the grant's environment and must stay
+// inside it; the token is only returned here. The key's delegator is always
+// the grant's.
+func (s *DelegatedAPIKeyStore) Mint(in DelegatedAPIKeyInput) (IssuedDelegatedAPIKey, error) {
+	grant, ok := s.grants.Get(in.GrantID)
+	if !ok {
+		return IssuedDelegatedAPIKey{}, errors.New("delegated admin grant not found")
+	}
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return IssuedDelegatedAPIKey{}, errors.New("name is required")
+	}
+	routes, err := normalizeDelegatedRoutes(in.Routes)
+	if err != nil {
+		return IssuedDelegatedAPIKey{}, err
+	}
+	for _, route := range routes {
+		if !delegatedRouteInScopes(route, grant.Scopes) {
+			return IssuedDelegatedAPIKey{}, errors.New("route " + route + " is outside the grant's scopes " + strings.Join(grant.Scopes, ", "))
+		}
+	}
+	envs := normalizeScopes(in.Environments)
+	if len(envs) == 0 {
+		envs = []string{grant.Environment}
+	}
+	for _, env := range envs {
+		if grant.Environment != "*" && env != grant.Environment {
+			return IssuedDelegatedAPIKey{}, errors.New("environment " + env + " is outside the grant's environment " + grant.Environment)
+		}
+	}
+	ttl := in.TTLSeconds
+	if ttl <= 0 {
+		ttl = 30 * 24 * 3600
+	}
+	if ttl < 60 {
+		return IssuedDelegatedAPIKey{}, errors.New("ttl_seconds must be >= 60")
+	}
+	if ttl > 365*24*3600 {
+		return IssuedDelegatedAPIKey{}, errors.New("ttl_seconds must be <= 31536000")
+	}
+	createdBy := strings.TrimSpace(in.CreatedBy)
+	now := time.Now().UTC()
+	key := DelegatedAPIKey{
+		Name:         name,
+		GrantID:      grant.ID,
+		Tenant:       grant.Tenant,
+		Principal:    grant.Principal,
+		Delegator:    grant.Delegator,
+		Routes:       routes,
+		Environments: envs,
+		CreatedBy:    createdBy,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(time.Duration(ttl) * time.Second),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueLocked(key)
+}
+
+func (s *DelegatedAPIKeyStore) issueLocked(key DelegatedAPIKey) (IssuedDelegatedAPIKey, error) {
+	token, err := generateDelegatedAPIKeyToken()
+	if err != nil {
+		return IssuedDelegatedAPIKey{}, err
+	}
+	s.nextID++
+	key.ID = "delegated-key-" + itoa(s.nextID)
+	hash := hashJITAccessGrantToken(token)
+	s.keys[key.ID] = &delegatedKeyRecord{key: key, tokenHash: hash}
+	s.tokenIndex[hash] = key.ID
+	return IssuedDelegatedAPIKey{Key: cloneDelegatedAPIKey(key), Token: token}, nil
+}
+
+func (s *DelegatedAPIKeyStore) List(grantID string) []DelegatedAPIKey {
+	grantID = strings.TrimSpace(grantID)
+	s.mu.Lock()
+	out := make([]DelegatedAPIKey, 0, len(s.keys))
+	for _, item := range s.keys {
+		if grantID != "" && item.key.GrantID != grantID {
+			continue
+		}
+		out = append(out, cloneDelegatedAPIKey(item.key))
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt) || (out[i].CreatedAt.Equal(out[j].CreatedAt) && out[i].ID < out[j].ID)
+	})
+	return out
+}
+
+func (s *DelegatedAPIKeyStore) Get(id string) (DelegatedAPIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.keys[strings.TrimSpace(id)]
+	if !ok {
+		return DelegatedAPIKey{}, errors.New("delegated api key not found")
+	}
+	return cloneDelegatedAPIKey(item.key), nil
+}
+
+// Rotate issues a replacement with the same scope and expiry window. The old
+// key stays valid for graceSeconds so callers can swap tokens without an
+// outage; a zero grace revokes it immediately.
+func (s *DelegatedAPIKeyStore) Rotate(id string, graceSeconds int) (IssuedDelegatedAPIKey, error) {
+	if graceSeconds < 0 {
+		return IssuedDelegatedAPIKey{}, errors.New("grace_seconds must be >= 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.keys[strings.TrimSpace(id)]
+	if !ok {
+		return IssuedDelegatedAPIKey{}, errors.New("delegated api key not found")
+	}
+	now := time.Now().UTC()
+	if item.key.RevokedAt != nil || !now.Before(item.key.ExpiresAt) {
+		return IssuedDelegatedAPIKey{}, errors.New("delegated api key is no longer active")
+	}
+	if item.key.RotatedTo != "" {
+		return IssuedDelegatedAPIKey{}, errors.New("delegated api key was already rotated to " + item.key.RotatedTo)
+	}
+	next := cloneDelegatedAPIKey(item.key)
+	next.CreatedAt = now
+	next.ExpiresAt = now.Add(item.key.ExpiresAt.Sub(item.key.CreatedAt))
+	next.RevokedAt, next.LastUsedAt, next.RotatedTo = nil, nil, ""
+	next.RotatedFrom = item.key.ID
+	issued, err := s.issueLocked(next)
+	if err != nil {
+		return IssuedDelegatedAPIKey{}, err
+	}
+	item.key.RotatedTo = issued.Key.ID
+	if graceSeconds == 0 {
+		item.key.RevokedAt = &now
+	} else if cutoff := now.Add(time.Duration(graceSeconds) * time.Second); cutoff.Before(item.key.ExpiresAt) {
+		item.key.ExpiresAt = cutoff
+	}
+	return issued, nil
+}
+
+func (s *DelegatedAPIKeyStore) Revoke(id string) (DelegatedAPIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.keys[strings.TrimSpace(id)]
+	if !ok {
+		return DelegatedAPIKey{}, errors.New("delegated api key not found")
+	}
+	if item.key.RevokedAt == nil {
+		now := time.Now().UTC()
+		item.key.RevokedAt = &now
+	}
+	return cloneDelegatedAPIKey(item.key), nil
+}
+
+// Authorize checks a presented token against the request. Known tokens
+// always report the key and delegator, even when denied, so audit entries
+// can name them.
+func (s *DelegatedAPIKeyStore) Authorize(in DelegatedAPIKeyRequest) DelegatedAPIKeyDecision {
+	return s.authorizeAt(in, time.Now().UTC())
+}
+
+func (s *DelegatedAPIKeyStore) authorizeAt(in DelegatedAPIKeyRequest, now time.Time) DelegatedAPIKeyDecision {
+	token := strings.TrimSpace(in.Token)
+	if token == "" {
+		return DelegatedAPIKeyDecision{Reason: "api key is required"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.tokenIndex[hashJITAccessGrantToken(token)]
+	if !ok {
+		return DelegatedAPIKeyDecision{Reason: "api key not recognized"}
+	}
+	item := s.keys[id]
+	out := DelegatedAPIKeyDecision{
+		KeyID:     item.key.ID,
+		GrantID:   item.key.GrantID,
+		Tenant:    item.key.Tenant,
+		Principal: item.key.Principal,
+		Delegator: item.key.Delegator,
+	}
+	switch {
+	case item.key.RevokedAt != nil:
+		out.Reason = "api key revoked"
+		return out
+	case !now.Before(item.key.ExpiresAt):
+		out.Reason = "api key expired"
+		return out
+	}
+	grant, ok := s.grants.Get(item.key.GrantID)
+	if !ok {
+		out.Reason = "delegating grant no longer exists"
+		return out
+	}
+	env := strings.ToLower(strings.TrimSpace(in.Environment))
+	if !delegatedEnvironmentAllowed(item.key.Environments, env) {
+		out.Reason = "environment is outside the api key's scope"
+		return out
+	}
+	out.Reason = "route is outside the api key's scope"
+	for _, route := range item.key.Routes {
+		if delegatedRouteMatches(route, in.Method, in.Path) {
+			// The grant's scopes may have narrowed since the key was minted.
+			if !delegatedRouteInScopes(route, grant.Scopes) {
+				out.Reason = "route is outside the delegating grant's scopes"
+				continue
+			}
+			out.Allowed = true
+			out.Reason = ""
+			out.MatchedRoute = route
+			used := now
+			item.key.LastUsedAt = &used
+			return out
+		}
+	}
+	return out
+}
+
+func delegatedEnvironmentAllowed(allowed []string, env string) bool {
+	for _, item := range allowed {
+		if item == "*" || (env != "" && item == env) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDelegatedRoutes accepts "METHOD /path" entries. The method may be
+// "*", {name} segments match any single segment, and a trailing "/*" matches
+// any deeper path.
+func normalizeDelegatedRoutes(in []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(in))
+	for _, raw := range in {
+		fields := strings.Fields(raw)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+			return nil, errors.New("route " + `"` + strings.TrimSpace(raw) + `"` + " must look like \"GET /v1/runs\"")
+		}
+		route := strings.ToUpper(fields[0]) + " /" + strings.Trim(fields[1], "/")
+		if !seen[route] {
+			seen[route] = true
+			out = append(out, route)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("at least one route is required")
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// delegatedRouteAction maps a route to the grant action it exercises:
+// "<resource>.<verb>", where resource is the first path segment after /v1
+// and verb is the last literal segment below it, or the method when there is
+// none ("*" for any method). "POST /v1/runs/{id}/cancel" is runs.cancel and
+// "GET /v1/runs" is runs.get. Routes whose resource is itself a wildcard map
+// to "" and only fit a "*" scope.
+func delegatedRouteAction(route string) string {
+	method, pattern, _ := strings.Cut(route, " ")
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(segments) > 0 && segments[0] == "v1" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || segments[0] == "" || segments[0] == "*" || strings.HasPrefix(segments[0], "{") {
+		return ""
+	}
+	resource := strings.ToLower(segments[0])
+	verb := strings.ToLower(method)
+	for _, segment := range segments[1:] {
+		if segment != "*" && !strings.HasPrefix(segment, "{") {
+			verb = strings.ToLower(segment)
+		}
+	}
+	return resource + "." + verb
+}
+
+func delegatedRouteInScopes(route string, scopes []string) bool {
+	action := delegatedRouteAction(route)
+	for _, scope := range scopes {
+		if scope == "*" || (action != "" && matchesScope(scope, action)) {
+			return true
+		}
+	}
+	return false
+}
+
+func delegatedRouteMatches(route, method, path string) bool {
+	routeMethod, pattern, _ := strings.Cut(route, " ")
+	if routeMethod != "*" && routeMethod != strings.ToUpper(method) {
+		return false
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		parts := strings.Split(strings.Trim(prefix, "/"), "/")
+		if len(segments) <= len(parts) {
+			return false
+		}
+		return jitRouteMatches(prefix, segments[:len(parts)])
+	}
+	return jitRouteMatches(pattern, segments)
+}
+
+func cloneDelegatedAPIKey(in DelegatedAPIKey) DelegatedAPIKey {
+	out := in
+	out.Routes = append([]string{}, in.Routes...)
+	out.Environments = append([]string{}, in.Environments...)
+	if in.RevokedAt != nil {
+		t := *in.RevokedAt
+		out.RevokedAt = &t
+	}
+	if in.LastUsedAt != nil {
+		t := *in.LastUsedAt
+		out.LastUsedAt = &t
+	}
+	return out
+}
+
+func generateDelegatedAPIKeyToken() (string, error) {
+	entropy := make([]byte, 32)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return "mcdak_" + hex.EncodeToString(entropy), nil
+}
@@ -0,0 +1,117 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelegatedAPIKeyScopeRotationAndRevocation(t *testing.T) {
+	grants := NewDelegatedAdminStore()
+	grant, err := grants.Create(DelegatedAdminGrantInput{Tenant: "acme", Environment: "prod", Principal: "ops-bot", Scopes: []string{"runs.*"}, Delegator: "alice"})
+	if err != nil {
+		t.Fatalf("create grant failed: %v", err)
+	}
+	keys := NewDelegatedAPIKeyStore(grants)
+	if _, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "dash", Routes: []string{"GET /v1/runs"}, Environments: []string{"staging"}}); err == nil {
+		t.Fatalf("expected environment outside the grant to be rejected")
+	}
+	if _, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "dash", Routes: []string{"/v1/runs"}}); err == nil {
+		t.Fatalf("expected route without method to be rejected")
+	}
+	for _, route := range []string{"GET /v1/jobs", "* /v1/*", "POST /v1/{kind}/x"} {
+		if _, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "dash", Routes: []string{route}}); err == nil {
+			t.Fatalf("expected route %q outside the grant's runs.* scope to be rejected", route)
+		}
+	}
+	issued, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "dash", Routes: []string{"get /v1/runs/", "POST /v1/runs/{id}/*"}, CreatedBy: "mallory"})
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	if issued.Key.Delegator != "alice" || issued.Key.Principal != "ops-bot" || len(issued.Key.Environments) != 1 || issued.Key.Environments[0] != "prod" {
+		t.Fatalf("unexpected minted key %+v", issued.Key)
+	}
+
+	check := func(token, method, path, env string) DelegatedAPIKeyDecision {
+		return keys.Authorize(DelegatedAPIKeyRequest{Token: token, Method: method, Path: path, Environment: env})
+	}
+	if d := check(issued.Token, "GET", "/v1/runs", "prod"); !d.Allowed || d.Delegator != "alice" || d.MatchedRoute != "GET /v1/runs" {
+		t.Fatalf("expected allowed read, got %+v", d)
+	}
+	if d := check(issued.Token, "POST", "/v1/runs/run-1/rollback", "prod"); !d.Allowed {
+		t.Fatalf("expected wildcard route allowed, got %+v", d)
+	}
+	if d := check(issued.Token, "POST", "/v1/runs/run-1", "prod"); d.Allowed {
+		t.Fatalf("expected trailing wildcard to require a deeper path")
+	}
+	if d := check(issued.Token, "DELETE", "/v1/runs", "prod"); d.Allowed || d.KeyID != issued.Key.ID {
+		t.Fatalf("expected out-of-scope method denied with key attribution, got %+v", d)
+	}
+	if d := check(issued.Token, "GET", "/v1/runs", "staging"); d.Allowed {
+		t.Fatalf("expected environment outside key scope denied")
+	}
+	if d := check("mcdak_unknown", "GET", "/v1/runs", "prod"); d.Allowed || d.KeyID != "" {
+		t.Fatalf("expected unknown token rejected, got %+v", d)
+	}
+
+	rotated, err := keys.Rotate(issued.Key.ID, 60)
+	if err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	if rotated.Key.RotatedFrom != issued.Key.ID || rotated.Token == issued.Token {
+		t.Fatalf("unexpected rotated key %+v", rotated.Key)
+	}
+	if d := check(issued.Token, "GET", "/v1/runs", "prod"); !d.Allowed {
+		t.Fatalf("expected old key valid during grace period, got %+v", d)
+	}
+	if d := keys.authorizeAt(DelegatedAPIKeyRequest{Token: issued.Token, Method: "GET", Path: "/v1/runs", Environment: "prod"}, time.Now().Add(2*time.Minute)); d.Allowed || d.Reason != "api key expired" {
+		t.Fatalf("expected old key expired after grace period, got %+v", d)
+	}
+	if _, err := keys.Rotate(issued.Key.ID, 0); err == nil {
+		t.Fatalf("expected double rotation to be rejected")
+	}
+	if _, err := keys.Revoke(rotated.Key.ID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if d := check(rotated.Token, "GET", "/v1/runs", "prod"); d.Allowed || d.Reason != "api key revoked" {
+		t.Fatalf("expected revoked key denied, got %+v", d)
+	}
+	if got := keys.List(grant.ID); len(got) != 2 {
+		t.Fatalf("expected two keys for grant, got %d", len(got))
+	}
+}
+
+func TestDelegatedAPIKeyRoutesStayInsideGrantScopes(t *testing.T) {
+	grants := NewDelegatedAdminStore()
+	grant, err := grants.Create(DelegatedAdminGrantInput{Tenant: "acme", Environment: "prod", Principal: "ops-bot", Scopes: []string{"runs.cancel", "workflows.*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewDelegatedAPIKeyStore(grants)
+	issued, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "ops", Routes: []string{"POST /v1/runs/{id}/cancel", "GET /v1/workflows"}, CreatedBy: "mallory"})
+	if err != nil {
+		t.Fatalf("mint failed: %v", err)
+	}
+	if issued.Key.Delegator != "" || issued.Key.CreatedBy != "mallory" {
+		t.Fatalf("expected delegator taken only from the grant, got %+v", issued.Key)
+	}
+	if _, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "ops", Routes: []string{"POST /v1/runs/{id}/rollback"}}); err == nil {
+		t.Fatalf("expected runs.rollback route outside runs.cancel scope to be rejected")
+	}
+	if _, err := keys.Mint(DelegatedAPIKeyInput{GrantID: grant.ID, Name: "ops", Routes: []string{"* /v1/runs/{id}/cancel"}}); err != nil {
+		t.Fatalf("expected any-method cancel route inside runs.cancel scope, got %v", err)
+	}
+
+	// A key that somehow carries a route outside its grant is still denied.
+	keys.mu.Lock()
+	stray, err := keys.issueLocked(DelegatedAPIKey{GrantID: grant.ID, Principal: "ops-bot", Routes: []string{"GET /v1/jobs"}, Environments: []string{"prod"}, ExpiresAt: time.Now().Add(time.Hour)})
+	keys.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := keys.Authorize(DelegatedAPIKeyRequest{Token: stray.Token, Method: "GET", Path: "/v1/jobs", Environment: "prod"}); d.Allowed || d.Reason != "route is outside the delegating grant's scopes" {
+		t.Fatalf("expected route outside grant scopes denied, got %+v", d)
+	}
+	if d := keys.Authorize(DelegatedAPIKeyRequest{Token: issued.Token, Method: "POST", Path: "/v1/runs/run-1/cancel", Environment: "prod"}); !d.Allowed {
+		t.Fatalf("expected scoped cancel allowed, got %+v", d)
+	}
+}
@@ -0,0 +1,227 @@
+package control
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DependencyPackageFetcher returns the stored tarball of a registry package.
+type DependencyPackageFetcher func(artifact PackageArtifact) ([]byte, error)
+
+type DependencyUpdateExecuteInput struct {
+	Kind           string `json:"kind,omitempty"` // module|provider, default module
+	Package        string `json:"package"`
+	CurrentVersion string `json:"current_version"`
+	TargetVersion  string `json:"target_version,omitempty"` // default newest published version
+	Reason         string `json:"reason,omitempty"`
+	TriggeredBy    string `json:"triggered_by,omitempty"`
+}
+
+// DependencyUpdateExecutor is the executing side of the dependency update
+// bot. It fetches the target version of a registry package into an
+// ephemeral directory, runs every scenario whose config reads the module
+// against it, and records the runs as evidence on a proposal, which ends up
+// auto-mergeable or blocked.
+type DependencyUpdateExecutor struct {
+	updates   *DependencyUpdateStore
+	packages  *PackageRegistryStore
+	scenarios *ScenarioTestStore
+	runner    *ScenarioExecutor
+	fetch     DependencyPackageFetcher
+	workRoot  string
+}
+
+// NewDependencyUpdateExecutor extracts packages under workRoot, or the
+// system temp directory when it is empty.
+func NewDependencyUpdateExecutor(updates *DependencyUpdateStore, packages *PackageRegistryStore, scenarios *ScenarioTestStore, runner *ScenarioExecutor, fetch DependencyPackageFetcher, workRoot string) *DependencyUpdateExecutor {
+	return &DependencyUpdateExecutor{
+		updates:   updates,
+		packages:  packages,
+		scenarios: scenarios,
+		runner:    runner,
+		fetch:     fetch,
+		workRoot:  workRoot,
+	}
+}
+
+func (e *DependencyUpdateExecutor) Execute(in DependencyUpdateExecuteInput) (DependencyUpdateProposal, error) {
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	if kind == "" {
+		kind = "module"
+	}
+	name := strings.TrimSpace(in.Package)
+	current := strings.TrimSpace(in.CurrentVersion)
+	if name == "" || current == "" {
+		return DependencyUpdateProposal{}, errors.New("package and current_version are required")
+	}
+	versions := e.packages.ListVersions(kind, name)
+	if len(versions) == 0 {
+		return DependencyUpdateProposal{}, errors.New("package is not registered")
+	}
+	var target PackageArtifact
+	if want := strings.TrimSpace(in.TargetVersion); want != "" {
+		found := false
+		for _, item := range versions {
+			if item.Version == want {
+				target, found = item, true
+				break
+			}
+		}
+		if !found {
+			return DependencyUpdateProposal{}, errors.New("target version is not published")
+		}
+	} else {
+		target = versions[0]
+	}
+	if compareVersions(target.Version, current) <= 0 {
+		return DependencyUpdateProposal{}, errors.New("no newer version than " + current)
+	}
+
+	dir, err := e.fetchPackage(target)
+	if err != nil {
+		return DependencyUpdateProposal{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	reason := strings.TrimSpace(in.Reason)
+	if reason == "" {
+		reason = "newer " + kind + " version published"
+	}
+	proposal, err := e.updates.Propose(DependencyUpdateInput{
+		Ecosystem:      DependencyEcosystemMasterchef,
+		Package:        target.Name,
+		CurrentVersion: current,
+		TargetVersion:  target.Version,
+		Reason:         reason,
+	})
+	if err != nil {
+		return DependencyUpdateProposal{}, err
+	}
+
+	baselines := map[string]ScenarioBaseline{}
+	for _, baseline := range e.scenarios.ListBaselines() {
+		if _, ok := baselines[baseline.ScenarioID]; !ok {
+			baselines[baseline.ScenarioID] = baseline // newest first
+		}
+	}
+	triggeredBy := strings.TrimSpace(in.TriggeredBy)
+	if triggeredBy == "" {
+		triggeredBy = "dependency-bot"
+	}
+	evidence := []DependencyUpdateEvidence{}
+	for _, scenario := range e.scenarios.ListScenarios() {
+		if !scenario.ReferencesModule(target.Name) {
+			continue
+		}
+		ev := DependencyUpdateEvidence{ScenarioID: scenario.ID}
+		baseline, hasBaseline := baselines[scenario.ID]
+		if hasBaseline {
+			ev.BaselineID = baseline.ID
+		}
+		run, err := e.runner.Execute(ScenarioExecuteInput{
+			ScenarioID:  scenario.ID,
+			TriggeredBy: triggeredBy + ":" + proposal.ID,
+			BaselineID:  ev.BaselineID,
+			Modules:     map[string]string{target.Name: dir},
+		})
+		if err != nil {
+			ev.Status = "error"
+			ev.Error = err.Error()
+			evidence = append(evidence, ev)
+			continue
+		}
+		ev.RunID = run.ID
+		ev.Status = run.Status
+		ev.RegressionReasons = cloneStringSlice(run.RegressionReasons)
+		if hasBaseline && baseline.MeanApplyLatencyMS > 0 {
+			ev.LatencyDeltaPct = float64(run.MeanApplyLatencyMS-baseline.MeanApplyLatencyMS) / float64(baseline.MeanApplyLatencyMS) * 100
+		}
+		evidence = append(evidence, ev)
+	}
+	return e.updates.recordExecution(proposal.ID, evidence)
+}
+
+// fetchPackage verifies the artifact tarball against its digest and
+// extracts it into a fresh directory.
+func (e *DependencyUpdateExecutor) fetchPackage(artifact PackageArtifact) (string, error) {
+	if artifact.ObjectKey == "" || e.fetch == nil {
+		return "", errors.New("package " + artifact.Name + "@" + artifact.Version + " has no stored tarball")
+	}
+	data, err := e.fetch(artifact)
+	if err != nil {
+		return "", fmt.Errorf("fetch %s@%s: %w", artifact.Name, artifact.Version, err)
+	}
+	sum := sha256.Sum256(data)
+	if "sha256:"+hex.EncodeToString(sum[:]) != artifact.Digest {
+		return "", errors.New("package tarball failed checksum verification")
+	}
+	if e.workRoot != "" {
+		if err := os.MkdirAll(e.workRoot, 0o755); err != nil {
+			return "", err
+		}
+	}
+	dir, err := os.MkdirTemp(e.workRoot, "dep-update-")
+	if err != nil {
+		return "", err
+	}
+	if err := extractPackageTarball(data, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// extractPackageTarball unpacks regular files and directories from a gzipped
+// tarball, refusing entries that would land outside dir.
+func extractPackageTarball(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("open package tarball: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read package tarball: %w", err)
+		}
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return errors.New("package tarball entry escapes extraction directory: " + hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
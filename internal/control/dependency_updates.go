@@ -8,6 +8,10 @@ import (
 	"time"
 )
 
+// DependencyEcosystemMasterchef is the ecosystem of modules and providers
+// published to the package registry.
+const DependencyEcosystemMasterchef = "masterchef"
+
 type DependencyUpdatePolicy struct {
 	Enabled                   bool      `json:"enabled"`
 	MaxUpdatesPerDay          int       `json:"max_updates_per_day"`
@@ -41,6 +45,26 @@ type DependencyUpdateProposal struct {
 	BlockedReasons        []string  `json:"blocked_reasons,omitempty"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
+
+	Mode          string                     `json:"mode,omitempty"`           // manual|executed
+	AutoMergeable bool                       `json:"auto_mergeable,omitempty"` // ready for merge on executed evidence alone
+	Evidence      []DependencyUpdateEvidence `json:"evidence,omitempty"`
+}
+
+// DependencyUpdateEvidence is the outcome of one impacted scenario test run
+// against the target version.
+type DependencyUpdateEvidence struct {
+	ScenarioID        string   `json:"scenario_id"`
+	RunID             string   `json:"run_id,omitempty"`
+	BaselineID        string   `json:"baseline_id,omitempty"`
+	Status            string   `json:"status"` // passed|degraded|failed|error
+	LatencyDeltaPct   float64  `json:"latency_delta_pct,omitempty"`
+	RegressionReasons []string `json:"regression_reasons,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+func (e DependencyUpdateEvidence) failing() bool {
+	return e.Status != "passed" || len(e.RegressionReasons) > 0
 }
 
 type DependencyUpdateEvaluationInput struct {
@@ -65,7 +89,7 @@ func NewDependencyUpdateStore() *DependencyUpdateStore {
 			MaxUpdatesPerDay:          20,
 			RequireCompatibilityCheck: true,
 			RequirePerformanceCheck:   true,
-			AllowedEcosystems:         []string{"go", "npm", "pypi", "maven"},
+			AllowedEcosystems:         []string{"go", "npm", "pypi", "maven", DependencyEcosystemMasterchef},
 			UpdatedAt:                 time.Now().UTC(),
 		},
 		updates: map[string]*DependencyUpdateProposal{},
@@ -117,6 +141,7 @@ func (s *DependencyUpdateStore) Propose(in DependencyUpdateInput) (DependencyUpd
 	s.nextID++
 	item := DependencyUpdateProposal{
 		ID:             "dep-update-" + itoa(s.nextID),
+		Mode:           "manual",
 		Ecosystem:      eco,
 		Package:        pkg,
 		CurrentVersion: current,
@@ -146,6 +171,48 @@ func (s *DependencyUpdateStore) Evaluate(in DependencyUpdateEvaluationInput) (De
 	item.PerformanceChecked = in.PerformanceChecked
 	item.PerformanceDeltaPct = in.PerformanceDeltaPct
 	item.PerformanceRegression = in.PerformanceChecked && in.PerformanceDeltaPct > 5
+	item.Mode = "manual"
+	item.UpdatedAt = time.Now().UTC()
+	*item = finalizeDependencyUpdateProposal(*item, s.policy)
+	return cloneDependencyUpdateProposal(*item), nil
+}
+
+// recordExecution attaches executed scenario evidence to a proposal. The
+// update is compatible when every impacted scenario passed without
+// regressions, and performance was checked when every run had a baseline to
+// compare latency against.
+func (s *DependencyUpdateStore) recordExecution(id string, evidence []DependencyUpdateEvidence) (DependencyUpdateProposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.updates[id]
+	if !ok {
+		return DependencyUpdateProposal{}, errors.New("dependency update proposal not found")
+	}
+	item.Mode = "executed"
+	item.Evidence = append([]DependencyUpdateEvidence{}, evidence...)
+	item.CompatibilityChecked = len(evidence) > 0
+	item.CompatibilityPassed = len(evidence) > 0
+	item.PerformanceChecked = len(evidence) > 0
+	item.PerformanceDeltaPct = 0
+	item.PerformanceRegression = false
+	for _, ev := range evidence {
+		if ev.Status != "passed" {
+			item.CompatibilityPassed = false
+		}
+		if ev.BaselineID == "" {
+			item.PerformanceChecked = false
+		}
+		if ev.LatencyDeltaPct > item.PerformanceDeltaPct {
+			item.PerformanceDeltaPct = ev.LatencyDeltaPct
+		}
+		for _, reason := range ev.RegressionReasons {
+			if reason == scenarioLatencyRegression {
+				item.PerformanceRegression = true
+			} else {
+				item.CompatibilityPassed = false
+			}
+		}
+	}
 	item.UpdatedAt = time.Now().UTC()
 	*item = finalizeDependencyUpdateProposal(*item, s.policy)
 	return cloneDependencyUpdateProposal(*item), nil
@@ -207,8 +274,29 @@ func finalizeDependencyUpdateProposal(item DependencyUpdateProposal, policy Depe
 			reasons = append(reasons, "performance regression exceeds threshold")
 		}
 	}
+	if item.Mode == "executed" && len(item.Evidence) == 0 {
+		reasons = append(reasons, "no scenario tests exercise the package")
+	}
+	for _, ev := range item.Evidence {
+		if !ev.failing() {
+			continue
+		}
+		detail := ev.Error
+		if detail == "" {
+			detail = strings.Join(ev.RegressionReasons, "; ")
+		}
+		reason := "scenario " + ev.ScenarioID + " " + ev.Status
+		if ev.RunID != "" {
+			reason += " (run " + ev.RunID + ")"
+		}
+		if detail != "" {
+			reason += ": " + detail
+		}
+		reasons = append(reasons, reason)
+	}
 	item.BlockedReasons = reasons
 	item.ReadyForMerge = len(reasons) == 0
+	item.AutoMergeable = item.ReadyForMerge && item.Mode == "executed"
 	return item
 }
 
@@ -221,5 +309,12 @@ func cloneDependencyUpdatePolicy(in DependencyUpdatePolicy) DependencyUpdatePoli
 func cloneDependencyUpdateProposal(in DependencyUpdateProposal) DependencyUpdateProposal {
 	out := in
 	out.BlockedReasons = append([]string{}, in.BlockedReasons...)
+	if len(in.Evidence) > 0 {
+		out.Evidence = make([]DependencyUpdateEvidence, 0, len(in.Evidence))
+		for _, ev := range in.Evidence {
+			ev.RegressionReasons = cloneStringSlice(ev.RegressionReasons)
+			out.Evidence = append(out.Evidence, ev)
+		}
+	}
 	return out
 }
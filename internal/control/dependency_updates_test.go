@@ -1,6 +1,14 @@
 package control
 
-import "testing"
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
 
 func TestDependencyUpdateStoreFlow(t *testing.T) {
 	store := NewDependencyUpdateStore()
@@ -52,3 +60,114 @@ func TestDependencyUpdateStorePolicyGuards(t *testing.T) {
 		t.Fatalf("expected disallowed ecosystem to fail")
 	}
 }
+
+func TestDependencyUpdateExecutorRunsImpactedScenarios(t *testing.T) {
+	tarball := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for name, body := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	blobs := map[string][]byte{}
+	packages := NewPackageRegistryStore()
+	publish := func(version string, data []byte) {
+		sum := sha256.Sum256(data)
+		key := "packages/" + version + ".tgz"
+		blobs[key] = data
+		if _, err := packages.Publish(PackageArtifactInput{Kind: "module", Name: "acme/motd", Version: version, Digest: "sha256:" + hex.EncodeToString(sum[:]), ObjectKey: key}); err != nil {
+			t.Fatalf("publish %s: %v", version, err)
+		}
+	}
+	module := func(command string) string {
+		return "version: v0\nresources:\n  - id: motd-check\n    type: command\n    host: localhost\n    command: \"" + command + "\"\n    creates: \"/\"\n"
+	}
+	publish("1.0.0", tarball(map[string]string{"main.yaml": module("exit 0")}))
+	publish("1.1.0", tarball(map[string]string{"main.yaml": module("exit 0"), "README.md": "docs\n"}))
+	publish("2.0.0", tarball(map[string]string{"main.yaml": "version: v0\nresources:\n  - id: motd-check\n    type: command\n    host: localhost\n    command: \"exit 3\"\n"}))
+	publish("2.1.0", tarball(map[string]string{"../escape.yaml": "x"}))
+
+	scenarios := NewScenarioTestStore()
+	config := `version: v0
+includes:
+  - "{{module.acme/motd}}/main.yaml"
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: localhost
+    path: "{{workspace}}/marker"
+    content: "x\n"
+`
+	if _, err := scenarios.UpsertScenario(ScenarioDefinition{ID: "motd", Name: "MOTD", Config: config}); err != nil {
+		t.Fatalf("upsert scenario: %v", err)
+	}
+	if _, err := scenarios.UpsertScenario(ScenarioDefinition{ID: "unrelated", Name: "Unrelated", FleetSize: 2, Services: 1}); err != nil {
+		t.Fatalf("upsert scenario: %v", err)
+	}
+	runner := NewScenarioExecutor(scenarios, t.TempDir())
+	updates := NewDependencyUpdateStore()
+	exec := NewDependencyUpdateExecutor(updates, packages, scenarios, runner, func(artifact PackageArtifact) ([]byte, error) {
+		return blobs[artifact.ObjectKey], nil
+	}, t.TempDir())
+
+	// Golden baseline from the version currently in use.
+	first, err := exec.Execute(DependencyUpdateExecuteInput{Package: "acme/motd", CurrentVersion: "0.9.0", TargetVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("execute 1.0.0: %v", err)
+	}
+	if first.Mode != "executed" || len(first.Evidence) != 1 || first.Evidence[0].Status != "passed" || first.AutoMergeable {
+		t.Fatalf("expected passing run blocked only on missing baseline, got %+v", first)
+	}
+	if len(first.BlockedReasons) != 1 || first.BlockedReasons[0] != "performance verification is required" {
+		t.Fatalf("expected missing baseline to leave performance unchecked, got %+v", first.BlockedReasons)
+	}
+	if _, err := scenarios.CreateBaseline(ScenarioBaselineInput{Name: "motd-1.0.0", RunID: first.Evidence[0].RunID}); err != nil {
+		t.Fatalf("create baseline: %v", err)
+	}
+
+	minor, err := exec.Execute(DependencyUpdateExecuteInput{Package: "acme/motd", CurrentVersion: "1.0.0", TargetVersion: "1.1.0"})
+	if err != nil {
+		t.Fatalf("execute 1.1.0: %v", err)
+	}
+	if !minor.AutoMergeable || !minor.ReadyForMerge || minor.Ecosystem != DependencyEcosystemMasterchef || minor.Evidence[0].BaselineID == "" {
+		t.Fatalf("expected compatible bump to be auto-mergeable, got %+v", minor)
+	}
+
+	major, err := exec.Execute(DependencyUpdateExecuteInput{Package: "acme/motd", CurrentVersion: "1.1.0", TargetVersion: "2.0.0"})
+	if err != nil {
+		t.Fatalf("execute 2.0.0: %v", err)
+	}
+	if major.AutoMergeable || major.CompatibilityPassed || major.Evidence[0].Status != "failed" || major.Evidence[0].RunID == "" {
+		t.Fatalf("expected failing bump to be blocked with evidence, got %+v", major)
+	}
+	if !strings.Contains(strings.Join(major.BlockedReasons, "\n"), "scenario motd failed (run "+major.Evidence[0].RunID+")") {
+		t.Fatalf("expected failing evidence in blocked reasons, got %+v", major.BlockedReasons)
+	}
+
+	if _, err := exec.Execute(DependencyUpdateExecuteInput{Package: "acme/motd", CurrentVersion: "2.0.0"}); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected newest tarball with escaping entry to be rejected, got %v", err)
+	}
+	if _, err := exec.Execute(DependencyUpdateExecuteInput{Package: "acme/motd", CurrentVersion: "2.1.0"}); err == nil {
+		t.Fatalf("expected no newer version error")
+	}
+	if _, err := exec.Execute(DependencyUpdateExecuteInput{Package: "acme/other", CurrentVersion: "1.0.0"}); err == nil {
+		t.Fatalf("expected unregistered package error")
+	}
+}
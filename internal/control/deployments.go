@@ -15,26 +15,30 @@ const (
 )
 
 type DeploymentTrigger struct {
-	ID          string           `json:"id"`
-	Environment string           `json:"environment"`
-	Branch      string           `json:"branch"`
-	ConfigPath  string           `json:"config_path"`
-	Source      string           `json:"source"`
-	Priority    string           `json:"priority,omitempty"`
-	Force       bool             `json:"force,omitempty"`
-	Status      DeploymentStatus `json:"status"`
-	JobID       string           `json:"job_id,omitempty"`
-	CreatedAt   time.Time        `json:"created_at"`
+	ID                  string           `json:"id"`
+	Environment         string           `json:"environment"`
+	Branch              string           `json:"branch"`
+	ConfigPath          string           `json:"config_path"`
+	Source              string           `json:"source"`
+	Priority            string           `json:"priority,omitempty"`
+	Force               bool             `json:"force,omitempty"`
+	Status              DeploymentStatus `json:"status"`
+	JobID               string           `json:"job_id,omitempty"`
+	PolicyBundleID      string           `json:"policy_bundle_id,omitempty"`
+	PolicyBundleVersion string           `json:"policy_bundle_version,omitempty"`
+	CreatedAt           time.Time        `json:"created_at"`
 }
 
 type DeploymentTriggerInput struct {
-	Environment string `json:"environment"`
-	Branch      string `json:"branch"`
-	ConfigPath  string `json:"config_path"`
-	Source      string `json:"source"`
-	Priority    string `json:"priority,omitempty"`
-	Force       bool   `json:"force,omitempty"`
-	JobID       string `json:"job_id,omitempty"`
+	Environment         string `json:"environment"`
+	Branch              string `json:"branch"`
+	ConfigPath          string `json:"config_path"`
+	Source              string `json:"source"`
+	Priority            string `json:"priority,omitempty"`
+	Force               bool   `json:"force,omitempty"`
+	JobID               string `json:"job_id,omitempty"`
+	PolicyBundleID      string `json:"policy_bundle_id,omitempty"`
+	PolicyBundleVersion string `json:"policy_bundle_version,omitempty"`
 }
 
 type DeploymentStore struct {
@@ -67,16 +71,18 @@ func (s *DeploymentStore) Create(in DeploymentTriggerInput) (DeploymentTrigger,
 	defer s.mu.Unlock()
 	s.nextID++
 	item := &DeploymentTrigger{
-		ID:          "deploy-" + itoa(s.nextID),
-		Environment: env,
-		Branch:      branch,
-		ConfigPath:  configPath,
-		Source:      source,
-		Priority:    normalizePriority(in.Priority),
-		Force:       in.Force,
-		Status:      DeploymentQueued,
-		JobID:       strings.TrimSpace(in.JobID),
-		CreatedAt:   now,
+		ID:                  "deploy-" + itoa(s.nextID),
+		Environment:         env,
+		Branch:              branch,
+		ConfigPath:          configPath,
+		Source:              source,
+		Priority:            normalizePriority(in.Priority),
+		Force:               in.Force,
+		Status:              DeploymentQueued,
+		JobID:               strings.TrimSpace(in.JobID),
+		PolicyBundleID:      strings.TrimSpace(in.PolicyBundleID),
+		PolicyBundleVersion: strings.TrimSpace(in.PolicyBundleVersion),
+		CreatedAt:           now,
 	}
 	s.records[item.ID] = item
 	return cloneDeployment(*item), nil
@@ -14,6 +14,7 @@ type DisruptionBudget struct {
 	Scope          string    `json:"scope,omitempty"`
 	MaxUnavailable int       `json:"max_unavailable"`
 	MinHealthyPct  int       `json:"min_healthy_pct"`
+	TotalHosts     int       `json:"total_hosts,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -23,6 +24,7 @@ type DisruptionBudgetInput struct {
 	Scope          string `json:"scope,omitempty"`
 	MaxUnavailable int    `json:"max_unavailable,omitempty"`
 	MinHealthyPct  int    `json:"min_healthy_pct,omitempty"`
+	TotalHosts     int    `json:"total_hosts,omitempty"`
 }
 
 type DisruptionBudgetEvaluation struct {
@@ -35,10 +37,56 @@ type DisruptionBudgetEvaluation struct {
 	HealthyPctAfter       int    `json:"healthy_pct_after"`
 }
 
+const (
+	DisruptionClaimActive   = "active"
+	DisruptionClaimReleased = "released"
+	DisruptionClaimExpired  = "expired"
+)
+
+// DisruptionClaim reserves hosts of a service as unavailable for one
+// disruptive operation. Active claims for the same service add up, so
+// rollouts, patching, reboots, and bulk executes share one budget.
+type DisruptionClaim struct {
+	ID         string    `json:"id"`
+	Service    string    `json:"service"`
+	Source     string    `json:"source"`
+	Ref        string    `json:"ref,omitempty"`
+	Hosts      []string  `json:"hosts"`
+	TotalHosts int       `json:"total_hosts,omitempty"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ReleasedAt time.Time `json:"released_at,omitempty"`
+}
+
+type DisruptionClaimInput struct {
+	Service    string   `json:"service"`
+	Source     string   `json:"source"`
+	Ref        string   `json:"ref,omitempty"`
+	Hosts      []string `json:"hosts"`
+	TotalHosts int      `json:"total_hosts,omitempty"`
+	TTLSeconds int      `json:"ttl_seconds,omitempty"`
+}
+
+// DisruptionClaimDecision is the outcome of Acquire: the claim when granted,
+// otherwise the budget that blocked it. Evaluations cover every budget
+// scoped to the service, counting hosts already held by active claims.
+type DisruptionClaimDecision struct {
+	Granted     bool                         `json:"granted"`
+	Service     string                       `json:"service"`
+	Unavailable int                          `json:"unavailable"`
+	Claim       *DisruptionClaim             `json:"claim,omitempty"`
+	BlockedBy   string                       `json:"blocked_by,omitempty"`
+	Reason      string                       `json:"reason,omitempty"`
+	Evaluations []DisruptionBudgetEvaluation `json:"evaluations"`
+}
+
 type DisruptionBudgetStore struct {
-	mu      sync.RWMutex
-	nextID  int64
-	budgets map[string]*DisruptionBudget
+	mu          sync.RWMutex
+	nextID      int64
+	nextClaimID int64
+	budgets     map[string]*DisruptionBudget
+	claims      []*DisruptionClaim
 }
 
 func NewDisruptionBudgetStore() *DisruptionBudgetStore {
@@ -59,6 +107,9 @@ func (s *DisruptionBudgetStore) Create(in DisruptionBudgetInput) (DisruptionBudg
 	if in.MinHealthyPct > 100 {
 		in.MinHealthyPct = 100
 	}
+	if in.TotalHosts < 0 {
+		in.TotalHosts = 0
+	}
 	now := time.Now().UTC()
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -69,6 +120,7 @@ func (s *DisruptionBudgetStore) Create(in DisruptionBudgetInput) (DisruptionBudg
 		Scope:          strings.TrimSpace(in.Scope),
 		MaxUnavailable: in.MaxUnavailable,
 		MinHealthyPct:  in.MinHealthyPct,
+		TotalHosts:     in.TotalHosts,
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
@@ -97,6 +149,167 @@ func (s *DisruptionBudgetStore) Get(id string) (DisruptionBudget, bool) {
 	return cloneDisruptionBudget(*item), true
 }
 
+// Acquire claims hosts for a disruptive operation. The hosts already held by
+// active claims for the service plus the new ones must fit every budget
+// scoped to the service; a service without budgets is always granted.
+func (s *DisruptionBudgetStore) Acquire(in DisruptionClaimInput) (DisruptionClaimDecision, error) {
+	service := strings.ToLower(strings.TrimSpace(in.Service))
+	if service == "" {
+		return DisruptionClaimDecision{}, errors.New("service is required")
+	}
+	hosts := normalizeDisruptionHosts(in.Hosts)
+	if len(hosts) == 0 {
+		return DisruptionClaimDecision{}, errors.New("hosts are required")
+	}
+	if in.TotalHosts < 0 {
+		in.TotalHosts = 0
+	}
+	ttl := time.Duration(in.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireClaimsLocked(now)
+	unavailable := map[string]struct{}{}
+	for _, claim := range s.claims {
+		if claim.Status == DisruptionClaimActive && claim.Service == service {
+			for _, host := range claim.Hosts {
+				unavailable[host] = struct{}{}
+			}
+		}
+	}
+	for _, host := range hosts {
+		unavailable[host] = struct{}{}
+	}
+	decision := DisruptionClaimDecision{
+		Granted:     true,
+		Service:     service,
+		Unavailable: len(unavailable),
+		Evaluations: []DisruptionBudgetEvaluation{},
+	}
+	budgets := make([]*DisruptionBudget, 0)
+	for _, budget := range s.budgets {
+		if strings.EqualFold(strings.TrimSpace(budget.Scope), service) {
+			budgets = append(budgets, budget)
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].ID < budgets[j].ID })
+	for _, budget := range budgets {
+		total := budget.TotalHosts
+		if total <= 0 {
+			total = in.TotalHosts
+		}
+		if total < len(unavailable) {
+			total = len(unavailable)
+		}
+		eval := EvaluateDisruptionBudget(*budget, total, len(unavailable))
+		decision.Evaluations = append(decision.Evaluations, eval)
+		if !eval.Allowed && decision.Granted {
+			decision.Granted = false
+			decision.BlockedBy = budget.ID
+			decision.Reason = eval.Reason
+		}
+	}
+	if !decision.Granted {
+		return decision, nil
+	}
+	s.nextClaimID++
+	claim := &DisruptionClaim{
+		ID:         "disruption-claim-" + itoa(s.nextClaimID),
+		Service:    service,
+		Source:     strings.ToLower(strings.TrimSpace(in.Source)),
+		Ref:        strings.TrimSpace(in.Ref),
+		Hosts:      hosts,
+		TotalHosts: in.TotalHosts,
+		Status:     DisruptionClaimActive,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	s.claims = append(s.claims, claim)
+	if len(s.claims) > 2000 {
+		s.claims = append([]*DisruptionClaim{}, s.claims[len(s.claims)-2000:]...)
+	}
+	out := cloneDisruptionClaim(*claim)
+	decision.Claim = &out
+	return decision, nil
+}
+
+// Release returns a claim's hosts to the budget.
+func (s *DisruptionBudgetStore) Release(id string) (DisruptionClaim, error) {
+	id = strings.TrimSpace(id)
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireClaimsLocked(now)
+	for _, claim := range s.claims {
+		if claim.ID != id {
+			continue
+		}
+		if claim.Status != DisruptionClaimActive {
+			return DisruptionClaim{}, errors.New("disruption claim already " + claim.Status)
+		}
+		claim.Status = DisruptionClaimReleased
+		claim.ReleasedAt = now
+		return cloneDisruptionClaim(*claim), nil
+	}
+	return DisruptionClaim{}, errors.New("disruption claim not found")
+}
+
+// Claims lists claims newest first, optionally for one service and only
+// those still active.
+func (s *DisruptionBudgetStore) Claims(service string, activeOnly bool) []DisruptionClaim {
+	service = strings.ToLower(strings.TrimSpace(service))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireClaimsLocked(time.Now().UTC())
+	out := make([]DisruptionClaim, 0)
+	for i := len(s.claims) - 1; i >= 0; i-- {
+		claim := s.claims[i]
+		if service != "" && claim.Service != service {
+			continue
+		}
+		if activeOnly && claim.Status != DisruptionClaimActive {
+			continue
+		}
+		out = append(out, cloneDisruptionClaim(*claim))
+	}
+	return out
+}
+
+func (s *DisruptionBudgetStore) expireClaimsLocked(now time.Time) {
+	for _, claim := range s.claims {
+		if claim.Status == DisruptionClaimActive && !claim.ExpiresAt.After(now) {
+			claim.Status = DisruptionClaimExpired
+		}
+	}
+}
+
+func normalizeDisruptionHosts(in []string) []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(in))
+	for _, host := range in {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if _, ok := seen[host]; ok {
+			continue
+		}
+		seen[host] = struct{}{}
+		out = append(out, host)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func cloneDisruptionClaim(in DisruptionClaim) DisruptionClaim {
+	in.Hosts = append([]string{}, in.Hosts...)
+	return in
+}
+
 func EvaluateDisruptionBudget(budget DisruptionBudget, totalTargets, requestedDisruptions int) DisruptionBudgetEvaluation {
 	if totalTargets < 0 {
 		totalTargets = 0
@@ -21,3 +21,52 @@ func TestDisruptionBudgetEvaluation(t *testing.T) {
 		t.Fatalf("expected budget evaluation to block request: %+v", blockEval)
 	}
 }
+
+func TestDisruptionBudgetClaimsShareBudget(t *testing.T) {
+	store := NewDisruptionBudgetStore()
+	budget, err := store.Create(DisruptionBudgetInput{Name: "api", Scope: "API", MaxUnavailable: 3, MinHealthyPct: 70, TotalHosts: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := store.Acquire(DisruptionClaimInput{Service: "api", Source: "rollout", Hosts: []string{"a", "b", "b"}})
+	if err != nil || !first.Granted || len(first.Claim.Hosts) != 2 {
+		t.Fatalf("expected first claim granted: %+v %v", first, err)
+	}
+	// Overlapping hosts count once.
+	second, err := store.Acquire(DisruptionClaimInput{Service: "api", Source: "patch", Hosts: []string{"B", "c"}})
+	if err != nil || !second.Granted || second.Unavailable != 3 {
+		t.Fatalf("expected overlapping claim granted at three unavailable: %+v %v", second, err)
+	}
+	denied, err := store.Acquire(DisruptionClaimInput{Service: "api", Source: "reboot", Hosts: []string{"d"}})
+	if err != nil || denied.Granted || denied.BlockedBy != budget.ID || denied.Claim != nil {
+		t.Fatalf("expected fourth host denied: %+v %v", denied, err)
+	}
+	if other, err := store.Acquire(DisruptionClaimInput{Service: "search", Hosts: []string{"d", "e", "f", "g"}}); err != nil || !other.Granted {
+		t.Fatalf("expected unbudgeted service granted: %+v %v", other, err)
+	}
+	if _, err := store.Acquire(DisruptionClaimInput{Service: "api"}); err == nil {
+		t.Fatal("expected hosts to be required")
+	}
+
+	if _, err := store.Release(first.Claim.ID); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := store.Acquire(DisruptionClaimInput{Service: "api", Hosts: []string{"d"}}); err != nil || !again.Granted {
+		t.Fatalf("expected claim after release: %+v %v", again, err)
+	}
+	if active := store.Claims("api", true); len(active) != 2 {
+		t.Fatalf("expected two active api claims, got %d", len(active))
+	}
+
+	short, _ := store.Acquire(DisruptionClaimInput{Service: "search", Hosts: []string{"z"}, TTLSeconds: 1})
+	store.mu.Lock()
+	for _, claim := range store.claims {
+		if claim.ID == short.Claim.ID {
+			claim.ExpiresAt = claim.CreatedAt
+		}
+	}
+	store.mu.Unlock()
+	if _, err := store.Release(short.Claim.ID); err == nil {
+		t.Fatal("expected expired claim release to fail")
+	}
+}
@@ -0,0 +1,413 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DocumentationBundleSink stores one bundle file under key.
+type DocumentationBundleSink func(key string, data []byte, contentType string) error
+
+// DocumentationSources is the registered state a bundle documents.
+type DocumentationSources struct {
+	ActionDocs   []ActionDoc
+	ObjectModel  []ObjectModelEntry
+	Dependencies ObjectDependencyGraph
+	API          APISpec
+	Providers    []ProviderProfile
+	Packages     []PackageArtifact
+}
+
+type DocumentationBundleFile struct {
+	Path        string `json:"path"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+	SizeBytes   int    `json:"size_bytes"`
+}
+
+type DocumentationBundle struct {
+	Channel     string                    `json:"channel"`
+	Version     string                    `json:"version"` // API version plus content digest prefix
+	Digest      string                    `json:"digest"`
+	Prefix      string                    `json:"prefix"`
+	Files       []DocumentationBundleFile `json:"files"`
+	Counts      map[string]int            `json:"counts"`
+	Unchanged   bool                      `json:"unchanged,omitempty"` // content matched the channel's latest bundle, nothing was written
+	GeneratedAt time.Time                 `json:"generated_at"`
+}
+
+// DocumentationBundleStore publishes static documentation sites into the
+// object store, one versioned tree per release channel, and remembers the
+// bundles it wrote so the latest docs for a channel can be served.
+type DocumentationBundleStore struct {
+	mu      sync.RWMutex
+	sink    DocumentationBundleSink
+	bundles map[string][]DocumentationBundle // channel -> bundles, oldest first
+}
+
+func NewDocumentationBundleStore(sink DocumentationBundleSink) *DocumentationBundleStore {
+	return &DocumentationBundleStore{sink: sink, bundles: map[string][]DocumentationBundle{}}
+}
+
+// Publish renders every page as Markdown and HTML under
+// docs/<channel>/<version>/ plus a manifest.json, and records the bundle as
+// the channel's latest. Content identical to the channel's latest bundle is
+// not written again.
+func (s *DocumentationBundleStore) Publish(channel string, src DocumentationSources) (DocumentationBundle, error) {
+	channel = normalizeChannel(channel)
+	if channel == "" {
+		return DocumentationBundle{}, errors.New("channel must be stable, candidate, edge, or lts")
+	}
+	if s.sink == nil {
+		return DocumentationBundle{}, errors.New("documentation bundle sink is not configured")
+	}
+	pages := buildDocumentationPages(src)
+	type rendered struct {
+		path, contentType string
+		data              []byte
+	}
+	files := make([]rendered, 0, len(pages)*2)
+	sum := sha256.New()
+	for _, page := range pages {
+		md := []byte(page.markdown())
+		htm := []byte(page.html(pages))
+		files = append(files,
+			rendered{path: page.slug + ".md", contentType: "text/markdown; charset=utf-8", data: md},
+			rendered{path: page.slug + ".html", contentType: "text/html; charset=utf-8", data: htm},
+		)
+		sum.Write(md)
+	}
+	digest := hex.EncodeToString(sum.Sum(nil))
+	apiVersion := strings.TrimSpace(src.API.Version)
+	if apiVersion == "" {
+		apiVersion = "v0"
+	}
+	version := apiVersion + "-" + digest[:12]
+
+	s.mu.RLock()
+	history := s.bundles[channel]
+	if n := len(history); n > 0 && history[n-1].Version == version {
+		latest := cloneDocumentationBundle(history[n-1])
+		s.mu.RUnlock()
+		latest.Unchanged = true
+		return latest, nil
+	}
+	s.mu.RUnlock()
+
+	prefix := "docs/" + channel + "/" + version + "/"
+	bundle := DocumentationBundle{
+		Channel: channel,
+		Version: version,
+		Digest:  "sha256:" + digest,
+		Prefix:  prefix,
+		Files:   make([]DocumentationBundleFile, 0, len(files)+1),
+		Counts: map[string]int{
+			"action_docs":      len(src.ActionDocs),
+			"object_model":     len(src.ObjectModel),
+			"endpoints":        len(src.API.Endpoints),
+			"providers":        len(src.Providers),
+			"packages":         len(src.Packages),
+			"examples":         countActionDocExamples(src.ActionDocs),
+			"dependency_edges": len(src.Dependencies.Edges),
+		},
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, f := range files {
+		fileSum := sha256.Sum256(f.data)
+		if err := s.sink(prefix+f.path, f.data, f.contentType); err != nil {
+			return DocumentationBundle{}, fmt.Errorf("store %s: %w", f.path, err)
+		}
+		bundle.Files = append(bundle.Files, DocumentationBundleFile{
+			Path:        f.path,
+			Key:         prefix + f.path,
+			ContentType: f.contentType,
+			SHA256:      hex.EncodeToString(fileSum[:]),
+			SizeBytes:   len(f.data),
+		})
+	}
+	manifest, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return DocumentationBundle{}, err
+	}
+	if err := s.sink(prefix+"manifest.json", manifest, "application/json"); err != nil {
+		return DocumentationBundle{}, fmt.Errorf("store manifest: %w", err)
+	}
+
+	s.mu.Lock()
+	s.bundles[channel] = append(s.bundles[channel], bundle)
+	s.mu.Unlock()
+	return cloneDocumentationBundle(bundle), nil
+}
+
+// List returns bundles newest first, optionally for one channel.
+func (s *DocumentationBundleStore) List(channel string) []DocumentationBundle {
+	channel = strings.TrimSpace(channel)
+	s.mu.RLock()
+	out := []DocumentationBundle{}
+	for ch, history := range s.bundles {
+		if channel != "" && ch != normalizeChannel(channel) {
+			continue
+		}
+		for _, item := range history {
+			out = append(out, cloneDocumentationBundle(item))
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].GeneratedAt.Equal(out[j].GeneratedAt) {
+			return out[i].GeneratedAt.After(out[j].GeneratedAt)
+		}
+		return out[i].Channel < out[j].Channel
+	})
+	return out
+}
+
+// Lookup resolves a channel and version, where version "latest" names the
+// channel's most recent bundle.
+func (s *DocumentationBundleStore) Lookup(channel, version string) (DocumentationBundle, bool) {
+	channel = normalizeChannel(channel)
+	version = strings.TrimSpace(version)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := s.bundles[channel]
+	if len(history) == 0 {
+		return DocumentationBundle{}, false
+	}
+	if version == "latest" {
+		return cloneDocumentationBundle(history[len(history)-1]), true
+	}
+	for _, item := range history {
+		if item.Version == version {
+			return cloneDocumentationBundle(item), true
+		}
+	}
+	return DocumentationBundle{}, false
+}
+
+func cloneDocumentationBundle(in DocumentationBundle) DocumentationBundle {
+	out := in
+	out.Files = append([]DocumentationBundleFile{}, in.Files...)
+	out.Counts = map[string]int{}
+	for k, v := range in.Counts {
+		out.Counts[k] = v
+	}
+	return out
+}
+
+type docPage struct {
+	slug     string
+	title    string
+	sections []docSection
+}
+
+type docSection struct {
+	heading string
+	items   []string
+	code    []string // JSON code blocks
+}
+
+func buildDocumentationPages(src DocumentationSources) []docPage {
+	actions := docPage{slug: "actions", title: "Actions"}
+	examples := docPage{slug: "examples", title: "Examples"}
+	for _, doc := range src.ActionDocs {
+		items := []string{doc.Summary}
+		for _, endpoint := range doc.Endpoints {
+			items = append(items, "`"+endpoint+"`")
+		}
+		if len(doc.Tags) > 0 {
+			items = append(items, "Tags: "+strings.Join(doc.Tags, ", "))
+		}
+		actions.sections = append(actions.sections, docSection{heading: doc.Title, items: items})
+		if strings.TrimSpace(doc.ExampleJSON) != "" {
+			example := docSection{heading: doc.Title, code: []string{prettyDocJSON(doc.ExampleJSON)}}
+			if len(doc.Endpoints) > 0 {
+				example.items = []string{"Request body for `" + doc.Endpoints[len(doc.Endpoints)-1] + "`"}
+			}
+			examples.sections = append(examples.sections, example)
+		}
+	}
+
+	model := docPage{slug: "object-model", title: "Object Model"}
+	for _, entry := range src.ObjectModel {
+		items := []string{
+			entry.Description,
+			"CLI: `" + entry.CLI + "`, API: `" + entry.API + "`, UI: " + entry.UI,
+		}
+		if len(entry.Aliases) > 0 {
+			items = append(items, "Aliases: "+strings.Join(entry.Aliases, ", "))
+		}
+		model.sections = append(model.sections, docSection{heading: entry.Canonical, items: items})
+	}
+	if len(src.Dependencies.Edges) > 0 {
+		edges := make([]string, 0, len(src.Dependencies.Edges))
+		for _, edge := range src.Dependencies.Edges {
+			edges = append(edges, edge.From.Kind+" `"+edge.From.Name+"` "+edge.Relation+" "+edge.To.Kind+" `"+edge.To.Name+"`")
+		}
+		model.sections = append(model.sections, docSection{heading: "Dependency graph", items: edges})
+	}
+
+	api := docPage{slug: "api", title: "API Reference (" + src.API.Version + ")"}
+	groups := map[string][]string{}
+	for _, endpoint := range src.API.Endpoints {
+		groups[apiDocGroup(endpoint)] = append(groups[apiDocGroup(endpoint)], "`"+endpoint+"`")
+	}
+	for _, group := range sortedPackageKeys(groups) {
+		api.sections = append(api.sections, docSection{heading: group, items: groups[group]})
+	}
+	if len(src.API.Deprecations) > 0 {
+		items := make([]string, 0, len(src.API.Deprecations))
+		for _, dep := range src.API.Deprecations {
+			raw, _ := json.Marshal(dep)
+			items = append(items, string(raw))
+		}
+		api.sections = append(api.sections, docSection{heading: "Deprecations", items: items})
+	}
+
+	providers := docPage{slug: "providers", title: "Provider Catalog"}
+	for _, profile := range src.Providers {
+		items := []string{
+			"Category: " + profile.Category,
+			"Purity: " + profile.Purity,
+			"Capabilities: " + strings.Join(profile.Capabilities, ", "),
+		}
+		if len(profile.SideEffects) > 0 {
+			items = append(items, "Side effects: "+strings.Join(profile.SideEffects, ", "))
+		}
+		if profile.PurityDescription != "" {
+			items = append(items, profile.PurityDescription)
+		}
+		providers.sections = append(providers.sections, docSection{heading: profile.ID, items: items})
+	}
+	if lines := packageDocLines(src.Packages); len(lines) > 0 {
+		providers.sections = append(providers.sections, docSection{heading: "Published modules and providers", items: lines})
+	}
+
+	index := docPage{slug: "index", title: "Masterchef Documentation"}
+	contents := docSection{heading: "Contents"}
+	for _, page := range []docPage{actions, model, api, providers, examples} {
+		contents.items = append(contents.items, "["+page.title+"]("+page.slug+") ("+strconv.Itoa(len(page.sections))+" sections)")
+	}
+	index.sections = append(index.sections, contents)
+	return []docPage{index, actions, model, api, providers, examples}
+}
+
+// apiDocGroup groups endpoints by their first path segment after /v1.
+func apiDocGroup(endpoint string) string {
+	_, path, _ := strings.Cut(endpoint, " ")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "v1" {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+func prettyDocJSON(raw string) string {
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return raw
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return raw
+	}
+	return string(b)
+}
+
+func countActionDocExamples(docs []ActionDoc) int {
+	n := 0
+	for _, doc := range docs {
+		if strings.TrimSpace(doc.ExampleJSON) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// markdown renders links between pages as relative .md files.
+func (p docPage) markdown() string {
+	var b strings.Builder
+	b.WriteString("# " + p.title + "\n")
+	for _, section := range p.sections {
+		b.WriteString("\n## " + section.heading + "\n\n")
+		for _, item := range section.items {
+			b.WriteString("- " + docLinkTarget(item, ".md") + "\n")
+		}
+		for _, code := range section.code {
+			b.WriteString("\n```json\n" + code + "\n```\n")
+		}
+	}
+	return b.String()
+}
+
+// html renders a standalone page with navigation to the other pages.
+func (p docPage) html(pages []docPage) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(p.title) + "</title></head><body>\n<nav>")
+	for i, page := range pages {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		b.WriteString(`<a href="` + page.slug + `.html">` + html.EscapeString(page.title) + "</a>")
+	}
+	b.WriteString("</nav>\n<h1>" + html.EscapeString(p.title) + "</h1>\n")
+	for _, section := range p.sections {
+		b.WriteString("<h2>" + html.EscapeString(section.heading) + "</h2>\n<ul>\n")
+		for _, item := range section.items {
+			b.WriteString("<li>" + docInlineHTML(item) + "</li>\n")
+		}
+		b.WriteString("</ul>\n")
+		for _, code := range section.code {
+			b.WriteString("<pre><code>" + html.EscapeString(code) + "</code></pre>\n")
+		}
+	}
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+// docLinkTarget appends ext to a leading "[title](slug)" link.
+func docLinkTarget(item, ext string) string {
+	if strings.HasPrefix(item, "[") {
+		if idx := strings.Index(item, "]("); idx > 0 {
+			if end := strings.Index(item[idx:], ")"); end > 0 {
+				return item[:idx+end] + ext + item[idx+end:]
+			}
+		}
+	}
+	return item
+}
+
+// docInlineHTML escapes an item and renders its `code` spans and leading
+// page link.
+func docInlineHTML(item string) string {
+	if strings.HasPrefix(item, "[") {
+		if idx := strings.Index(item, "]("); idx > 0 {
+			if end := strings.Index(item[idx:], ")"); end > 0 {
+				title, slug := item[1:idx], item[idx+2:idx+end]
+				return `<a href="` + html.EscapeString(slug) + `.html">` + html.EscapeString(title) + "</a>" + docInlineHTML(item[idx+end+1:])
+			}
+		}
+	}
+	parts := strings.Split(item, "`")
+	var b strings.Builder
+	for i, part := range parts {
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+		b.WriteString(html.EscapeString(part))
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package control
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocumentationBundleStorePublishesVersionedSite(t *testing.T) {
+	written := map[string]string{}
+	store := NewDocumentationBundleStore(func(key string, data []byte, contentType string) error {
+		written[key] = string(data)
+		return nil
+	})
+	src := DocumentationSources{
+		ActionDocs:  NewActionDocCatalog().List(),
+		ObjectModel: NewObjectModelRegistry().List(),
+		API:         APISpec{Version: "v1", Endpoints: []string{"GET /v1/docs/generate", "POST /v1/runs/{id}/retry"}},
+		Providers:   NewProviderCatalog().List(),
+		Packages:    []PackageArtifact{{Kind: "module", Name: "core/<net>", Version: "1.0.0", Digest: "sha256:abc"}},
+	}
+
+	bundle, err := store.Publish("candidate", src)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if !strings.HasPrefix(bundle.Version, "v1-") || bundle.Prefix != "docs/candidate/"+bundle.Version+"/" || len(bundle.Files) != 12 {
+		t.Fatalf("unexpected bundle: %+v", bundle)
+	}
+	for _, page := range []string{"index", "actions", "object-model", "api", "providers", "examples"} {
+		if _, ok := written[bundle.Prefix+page+".md"]; !ok {
+			t.Fatalf("expected %s.md in bundle", page)
+		}
+		if _, ok := written[bundle.Prefix+page+".html"]; !ok {
+			t.Fatalf("expected %s.html in bundle", page)
+		}
+	}
+	if _, ok := written[bundle.Prefix+"manifest.json"]; !ok {
+		t.Fatalf("expected manifest in bundle")
+	}
+	if !strings.Contains(written[bundle.Prefix+"index.md"], "[API Reference (v1)](api.md)") {
+		t.Fatalf("expected markdown index to link pages, got %s", written[bundle.Prefix+"index.md"])
+	}
+	providers := written[bundle.Prefix+"providers.html"]
+	if !strings.Contains(providers, "core/&lt;net&gt;@1.0.0") || strings.Contains(providers, "core/<net>") {
+		t.Fatalf("expected escaped package names in html, got %s", providers)
+	}
+	if !strings.Contains(written[bundle.Prefix+"api.html"], "<h2>runs</h2>") || !strings.Contains(written[bundle.Prefix+"examples.md"], "```json") {
+		t.Fatalf("expected grouped api reference and example code blocks")
+	}
+
+	again, err := store.Publish("candidate", src)
+	if err != nil || !again.Unchanged || again.Version != bundle.Version {
+		t.Fatalf("expected identical content to reuse bundle, got %+v err=%v", again, err)
+	}
+	src.API.Endpoints = append(src.API.Endpoints, "GET /v1/docs/bundles")
+	next, err := store.Publish("candidate", src)
+	if err != nil || next.Unchanged || next.Version == bundle.Version {
+		t.Fatalf("expected new version after api change, got %+v err=%v", next, err)
+	}
+	if latest, ok := store.Lookup("candidate", "latest"); !ok || latest.Version != next.Version {
+		t.Fatalf("expected latest lookup to return newest bundle, got %+v", latest)
+	}
+	if _, ok := store.Lookup("candidate", bundle.Version); !ok {
+		t.Fatalf("expected older bundle version to stay addressable")
+	}
+	if len(store.List("candidate")) != 2 || len(store.List("stable")) != 0 {
+		t.Fatalf("expected bundles to be versioned per channel")
+	}
+	if _, err := store.Publish("nightly", src); err == nil {
+		t.Fatalf("expected unknown channel to be rejected")
+	}
+}
@@ -2,16 +2,62 @@ package control
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+	"github.com/masterchef/masterchef/internal/planner"
+)
+
+const (
+	DocsExampleConfig = "config"
+	DocsExampleAPI    = "api"
 )
 
 type DocsExampleVerificationReport struct {
-	Checked  int      `json:"checked"`
-	Passed   bool     `json:"passed"`
-	Failures []string `json:"failures,omitempty"`
+	ID          string              `json:"id,omitempty"`
+	Checked     int                 `json:"checked"`
+	Passed      bool                `json:"passed"`
+	Failures    []string            `json:"failures,omitempty"`
+	Executed    int                 `json:"executed"`
+	Examples    []DocsExampleResult `json:"examples,omitempty"`
+	Broken      []string            `json:"broken,omitempty"`      // example ids that failed this run
+	Regressions []string            `json:"regressions,omitempty"` // broken examples that passed in an earlier run
+	VerifiedAt  time.Time           `json:"verified_at,omitempty"`
+}
+
+// DocsExample is a fenced block extracted from an action doc body. Config
+// examples are ```yaml blocks, optionally named with file=<name>; API
+// examples are ```http blocks holding a request line and an optional JSON
+// body.
+type DocsExample struct {
+	ID       string `json:"id"` // <doc id>#<n>
+	DocID    string `json:"doc_id"`
+	Kind     string `json:"kind"`
+	File     string `json:"file,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Source   string `json:"-"`
+	Language string `json:"language"`
+}
+
+type DocsExampleResult struct {
+	DocsExample
+	Status string `json:"status"` // passed|failed|skipped
+	Detail string `json:"detail,omitempty"`
 }
 
+// DocsExampleRequester serves an API example in-process and returns the
+// response status and body.
+type DocsExampleRequester func(method, path string, body []byte) (int, []byte)
+
 func VerifyActionDocExamples(items []ActionDoc, knownEndpoints []string) DocsExampleVerificationReport {
 	report := DocsExampleVerificationReport{
 		Checked:  len(items),
@@ -54,3 +100,305 @@ func VerifyActionDocExamples(items []ActionDoc, knownEndpoints []string) DocsExa
 	}
 	return report
 }
+
+// ExtractActionDocExamples returns the config and API examples fenced in the
+// body of doc, in document order. Fences in other languages are ignored.
+func ExtractActionDocExamples(doc ActionDoc) []DocsExample {
+	out := []DocsExample{}
+	lines := strings.Split(strings.ReplaceAll(doc.Body, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		info, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "```")
+		if !ok {
+			continue
+		}
+		block := []string{}
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+			block = append(block, lines[i])
+		}
+		fields := strings.Fields(info)
+		if len(fields) == 0 {
+			continue
+		}
+		ex := DocsExample{
+			DocID:    doc.ID,
+			Language: strings.ToLower(fields[0]),
+			Source:   strings.Join(block, "\n") + "\n",
+		}
+		for _, attr := range fields[1:] {
+			if name, ok := strings.CutPrefix(attr, "file="); ok {
+				ex.File = name
+			}
+		}
+		switch ex.Language {
+		case "yaml", "yml":
+			ex.Kind = DocsExampleConfig
+		case "json":
+			if ex.File == "" {
+				continue // a bare JSON block is a response or body sample, not a config
+			}
+			ex.Kind = DocsExampleConfig
+		case "http":
+			ex.Kind = DocsExampleAPI
+			ex.File = ""
+			request := strings.TrimSpace(ex.Source)
+			line, body, _ := strings.Cut(request, "\n")
+			parts := strings.Fields(line)
+			if len(parts) == 2 {
+				ex.Method, ex.Path = strings.ToUpper(parts[0]), parts[1]
+			}
+			ex.Source = strings.TrimSpace(body)
+		default:
+			continue
+		}
+		out = append(out, ex)
+		out[len(out)-1].ID = doc.ID + "#" + strconv.Itoa(len(out))
+	}
+	for i := range out {
+		if out[i].Kind == DocsExampleConfig && out[i].File == "" {
+			out[i].File = "example-" + strconv.Itoa(i+1) + ".yaml"
+		}
+	}
+	return out
+}
+
+// DocsExampleVerifier executes the examples in action docs against a
+// throwaway sandbox workspace. Config examples are loaded and planned, never
+// applied; API examples are served in-process only when they are plan-only
+// (GET requests and /v1/plans/ endpoints), with config_path resolved inside
+// the sandbox. Examples that passed once and later break are regressions,
+// which block release readiness until they pass again.
+type DocsExampleVerifier struct {
+	mu       sync.RWMutex
+	docs     *ActionDocCatalog
+	workRoot string
+	nextID   int64
+	reports  []DocsExampleVerificationReport
+	verified map[string]bool
+}
+
+// NewDocsExampleVerifier creates sandboxes under workRoot, or the system
+// temp directory when it is empty.
+func NewDocsExampleVerifier(docs *ActionDocCatalog, workRoot string) *DocsExampleVerifier {
+	return &DocsExampleVerifier{
+		docs:     docs,
+		workRoot: workRoot,
+		reports:  []DocsExampleVerificationReport{},
+		verified: map[string]bool{},
+	}
+}
+
+func (v *DocsExampleVerifier) Verify(knownEndpoints []string, request DocsExampleRequester) (DocsExampleVerificationReport, error) {
+	items := v.docs.List()
+	report := VerifyActionDocExamples(items, knownEndpoints)
+	report.Examples = []DocsExampleResult{}
+	if v.workRoot != "" {
+		if err := os.MkdirAll(v.workRoot, 0o755); err != nil {
+			return DocsExampleVerificationReport{}, err
+		}
+	}
+	sandbox, err := os.MkdirTemp(v.workRoot, "docs-examples-")
+	if err != nil {
+		return DocsExampleVerificationReport{}, err
+	}
+	defer os.RemoveAll(sandbox)
+
+	for i, doc := range items {
+		examples := ExtractActionDocExamples(doc)
+		if len(examples) == 0 {
+			continue
+		}
+		// Each doc gets its own workspace so file names only need to be
+		// unique within a doc.
+		dir := filepath.Join(sandbox, strconv.Itoa(i))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return DocsExampleVerificationReport{}, err
+		}
+		written := map[string]error{}
+		for _, ex := range examples {
+			if ex.Kind == DocsExampleConfig {
+				written[ex.File] = writeDocsExampleFile(dir, ex)
+			}
+		}
+		for _, ex := range examples {
+			result := DocsExampleResult{DocsExample: ex, Status: "passed"}
+			var err error
+			switch ex.Kind {
+			case DocsExampleConfig:
+				if err = written[ex.File]; err == nil {
+					err = planDocsExample(filepath.Join(dir, ex.File))
+				}
+				report.Executed++
+			case DocsExampleAPI:
+				var executed bool
+				executed, err = runDocsAPIExample(ex, dir, knownEndpoints, request)
+				if executed {
+					report.Executed++
+				} else if err == nil {
+					result.Status = "skipped"
+					result.Detail = "not plan-only; endpoint and body checked without executing"
+				}
+			}
+			if err != nil {
+				result.Status = "failed"
+				result.Detail = err.Error()
+				report.Broken = append(report.Broken, ex.ID)
+				report.Failures = append(report.Failures, ex.ID+": "+err.Error())
+			}
+			report.Examples = append(report.Examples, result)
+		}
+	}
+	sort.Strings(report.Failures)
+	report.Passed = len(report.Failures) == 0
+	if report.Passed {
+		report.Failures = nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, result := range report.Examples {
+		switch result.Status {
+		case "passed":
+			v.verified[result.ID] = true
+		case "failed":
+			if v.verified[result.ID] {
+				report.Regressions = append(report.Regressions, result.ID)
+			}
+		}
+	}
+	v.nextID++
+	report.ID = "docs-verify-" + itoa(v.nextID)
+	report.VerifiedAt = time.Now().UTC()
+	v.reports = append(v.reports, report)
+	return cloneDocsExampleReport(report), nil
+}
+
+// List returns verification reports, newest first.
+func (v *DocsExampleVerifier) List() []DocsExampleVerificationReport {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	out := make([]DocsExampleVerificationReport, 0, len(v.reports))
+	for i := len(v.reports) - 1; i >= 0; i-- {
+		out = append(out, cloneDocsExampleReport(v.reports[i]))
+	}
+	return out
+}
+
+// ReadinessBlockers reports the regressions of the latest verification.
+func (v *DocsExampleVerifier) ReadinessBlockers() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	out := make([]string, 0)
+	if len(v.reports) == 0 {
+		return out
+	}
+	latest := v.reports[len(v.reports)-1]
+	for _, id := range latest.Regressions {
+		for _, result := range latest.Examples {
+			if result.ID == id {
+				out = append(out, "docs example "+id+" regressed: "+result.Detail)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func writeDocsExampleFile(dir string, ex DocsExample) error {
+	name := filepath.Clean(filepath.FromSlash(ex.File))
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return errors.New("example file " + ex.File + " escapes the sandbox")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(ex.Source), 0o644)
+}
+
+func planDocsExample(path string) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return errors.New("config does not load: " + err.Error())
+	}
+	if _, err := planner.Build(cfg); err != nil {
+		return errors.New("config does not plan: " + err.Error())
+	}
+	return nil
+}
+
+// runDocsAPIExample checks an API example and serves it when it is
+// plan-only, reporting whether it was executed.
+func runDocsAPIExample(ex DocsExample, dir string, knownEndpoints []string, request DocsExampleRequester) (bool, error) {
+	if ex.Method == "" || !strings.HasPrefix(ex.Path, "/") {
+		return false, errors.New("request line must be METHOD /path")
+	}
+	if !docsEndpointKnown(ex.Method, ex.Path, knownEndpoints) {
+		return false, errors.New("unknown endpoint " + ex.Method + " " + ex.Path)
+	}
+	var body []byte
+	if ex.Source != "" {
+		var payload any
+		if err := json.Unmarshal([]byte(ex.Source), &payload); err != nil {
+			return false, errors.New("request body is invalid json")
+		}
+		if obj, ok := payload.(map[string]any); ok {
+			if path, ok := obj["config_path"].(string); ok && path != "" && !filepath.IsAbs(path) {
+				obj["config_path"] = filepath.Join(dir, path)
+			}
+		}
+		body, _ = json.Marshal(payload)
+	}
+	planOnly := ex.Method == http.MethodGet || strings.HasPrefix(ex.Path, "/v1/plans/")
+	if !planOnly || request == nil {
+		return false, nil
+	}
+	code, resp := request(ex.Method, ex.Path, body)
+	if code >= http.StatusBadRequest {
+		detail := strings.TrimSpace(string(resp))
+		if len(detail) > 200 {
+			detail = detail[:200]
+		}
+		return true, errors.New("status " + strconv.Itoa(code) + ": " + detail)
+	}
+	return true, nil
+}
+
+// docsEndpointKnown matches a concrete request against API spec entries,
+// where {param} segments match any value.
+func docsEndpointKnown(method, path string, knownEndpoints []string) bool {
+	if len(knownEndpoints) == 0 {
+		return true
+	}
+	path, _, _ = strings.Cut(path, "?")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	for _, endpoint := range knownEndpoints {
+		m, p, ok := strings.Cut(strings.TrimSpace(endpoint), " ")
+		if !ok || m != method {
+			continue
+		}
+		want := strings.Split(strings.Trim(p, "/"), "/")
+		if len(want) != len(got) {
+			continue
+		}
+		match := true
+		for i := range want {
+			if !strings.HasPrefix(want[i], "{") && want[i] != got[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneDocsExampleReport(in DocsExampleVerificationReport) DocsExampleVerificationReport {
+	out := in
+	out.Failures = cloneStringSlice(in.Failures)
+	out.Broken = cloneStringSlice(in.Broken)
+	out.Regressions = cloneStringSlice(in.Regressions)
+	out.Examples = append([]DocsExampleResult{}, in.Examples...)
+	return out
+}
@@ -1,6 +1,10 @@
 package control
 
-import "testing"
+import (
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestVerifyActionDocExamples(t *testing.T) {
 	items := []ActionDoc{
@@ -37,3 +41,71 @@ func TestVerifyActionDocExamplesPass(t *testing.T) {
 		t.Fatalf("expected verification to pass: %+v", report)
 	}
 }
+
+func TestDocsExampleVerifierExecutesExamplesAndTracksRegressions(t *testing.T) {
+	cfg := "```yaml file=motd.yaml\nversion: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources:\n  - id: motd\n    type: file\n    host: localhost\n    path: /etc/motd\n    content: hi\n```\n"
+	docs := NewActionDocCatalog()
+	if _, err := docs.Upsert(ActionDoc{
+		ID:        "motd",
+		Title:     "Manage motd",
+		Endpoints: []string{"POST /v1/plans/explain"},
+		Body: "Config:\n\n" + cfg + "\n```http\nPOST /v1/plans/explain\n{\"config_path\":\"motd.yaml\"}\n```\n\n" +
+			"```http\nPOST /v1/runs/run-1/retry\n```\n\n```json\n{\"response\":true}\n```\n",
+	}); err != nil {
+		t.Fatalf("upsert doc: %v", err)
+	}
+	var served []string
+	request := func(method, path string, body []byte) (int, []byte) {
+		served = append(served, method+" "+path+" "+string(body))
+		if _, err := os.Stat(strings.TrimSuffix(strings.TrimPrefix(string(body), `{"config_path":"`), `"}`)); err != nil {
+			return 400, []byte(`{"error":"config_path not found"}`)
+		}
+		return 200, []byte(`{}`)
+	}
+	verifier := NewDocsExampleVerifier(docs, t.TempDir())
+	endpoints := []string{"POST /v1/plans/explain", "POST /v1/runs/{id}/retry"}
+	for _, doc := range docs.List() {
+		endpoints = append(endpoints, doc.Endpoints...)
+	}
+
+	report, err := verifier.Verify(endpoints, request)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !report.Passed || report.Executed != 4 || len(served) != 2 {
+		t.Fatalf("expected seeded and motd examples to pass, got %+v served=%v", report, served)
+	}
+	status := map[string]string{}
+	for _, result := range report.Examples {
+		status[result.ID] = result.Status
+	}
+	if status["motd#1"] != "passed" || status["motd#2"] != "passed" || status["motd#3"] != "skipped" || len(status) != 5 {
+		t.Fatalf("unexpected example statuses: %+v", status)
+	}
+	if blockers := verifier.ReadinessBlockers(); len(blockers) != 0 {
+		t.Fatalf("expected no blockers, got %v", blockers)
+	}
+
+	if _, err := docs.Upsert(ActionDoc{
+		ID:        "motd",
+		Title:     "Manage motd",
+		Endpoints: []string{"POST /v1/plans/explain"},
+		Body:      strings.Replace(cfg, "type: file", "type: nonsense", 1) + "```http\nPOST /v1/plans/explain\n{\"config_path\":\"motd.yaml\"}\n```\n```http\nPOST /v1/plans/unknown\n```\n",
+	}); err != nil {
+		t.Fatalf("upsert doc: %v", err)
+	}
+	report, err = verifier.Verify(endpoints, request)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if report.Passed || len(report.Broken) != 2 || len(report.Regressions) != 1 || report.Regressions[0] != "motd#1" {
+		t.Fatalf("expected broken config to regress and new unknown endpoint to just fail, got %+v", report)
+	}
+	blockers := verifier.ReadinessBlockers()
+	if len(blockers) != 1 || !strings.Contains(blockers[0], "motd#1 regressed") {
+		t.Fatalf("expected regression blocker, got %v", blockers)
+	}
+	if history := verifier.List(); len(history) != 2 || history[0].ID != report.ID {
+		t.Fatalf("expected newest-first history, got %+v", history)
+	}
+}
@@ -11,6 +11,8 @@ type DocumentationGenerateInput struct {
 	Format           string `json:"format,omitempty"` // markdown|json
 	IncludePackages  bool   `json:"include_packages"`
 	IncludePolicyAPI bool   `json:"include_policy_api"`
+	Bundle           bool   `json:"bundle,omitempty"`  // publish the full static site bundle instead
+	Channel          string `json:"channel,omitempty"` // bundle release channel, default the control plane's
 }
 
 type DocumentationArtifact struct {
@@ -18,6 +20,7 @@ type DocumentationArtifact struct {
 	Content     string         `json:"content"`
 	Sections    []string       `json:"sections"`
 	Counts      map[string]int `json:"counts"`
+	Locale      string         `json:"locale,omitempty"`
 	GeneratedAt time.Time      `json:"generated_at"`
 }
 
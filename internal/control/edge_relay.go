@@ -16,6 +16,8 @@ type EdgeRelaySiteInput struct {
 	Mode              string `json:"mode"`
 	MaxQueueDepth     int    `json:"max_queue_depth,omitempty"`
 	HeartbeatInterval int    `json:"heartbeat_interval_seconds,omitempty"`
+	// ClientCertSerial is the serial of the relay's mTLS client certificate.
+	ClientCertSerial string `json:"client_cert_serial,omitempty"`
 }
 
 type EdgeRelaySite struct {
@@ -24,7 +26,9 @@ type EdgeRelaySite struct {
 	Mode              string    `json:"mode"`
 	MaxQueueDepth     int       `json:"max_queue_depth"`
 	HeartbeatInterval int       `json:"heartbeat_interval_seconds"`
+	ClientCertSerial  string    `json:"client_cert_serial,omitempty"`
 	Connected         bool      `json:"connected"`
+	Revoked           bool      `json:"revoked,omitempty"`
 	QueueDepth        int       `json:"queue_depth"`
 	LastSeenAt        time.Time `json:"last_seen_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
@@ -61,6 +65,7 @@ type EdgeRelayStore struct {
 	nextID   int64
 	sites    map[string]*EdgeRelaySite
 	messages map[string]*EdgeRelayMessage
+	revoked  CertificateRevocationChecker
 }
 
 func NewEdgeRelayStore() *EdgeRelayStore {
@@ -70,6 +75,33 @@ func NewEdgeRelayStore() *EdgeRelayStore {
 	}
 }
 
+// SetRevocationChecker makes sites presenting a revoked client certificate
+// unable to heartbeat or drain their queue.
+func (s *EdgeRelayStore) SetRevocationChecker(checker CertificateRevocationChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = checker
+}
+
+// DisconnectRevoked marks every site whose certificate has since been
+// revoked as disconnected and returns their ids.
+func (s *EdgeRelayStore) DisconnectRevoked() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, 0)
+	for _, site := range s.sites {
+		if site.Revoked || !s.siteRevokedLocked(site) {
+			continue
+		}
+		site.Revoked = true
+		site.Connected = false
+		site.UpdatedAt = time.Now().UTC()
+		out = append(out, site.SiteID)
+	}
+	sort.Strings(out)
+	return out
+}
+
 func (s *EdgeRelayStore) UpsertSite(in EdgeRelaySiteInput) (EdgeRelaySite, error) {
 	siteID := strings.TrimSpace(in.SiteID)
 	if siteID == "" {
@@ -100,6 +132,11 @@ func (s *EdgeRelayStore) UpsertSite(in EdgeRelaySiteInput) (EdgeRelaySite, error
 	item.Mode = mode
 	item.MaxQueueDepth = maxQueue
 	item.HeartbeatInterval = hb
+	item.ClientCertSerial = strings.TrimSpace(in.ClientCertSerial)
+	item.Revoked = s.siteRevokedLocked(item)
+	if item.Revoked {
+		item.Connected = false
+	}
 	item.UpdatedAt = now
 	if item.Region == "" {
 		item.Region = "global"
@@ -120,6 +157,11 @@ func (s *EdgeRelayStore) Heartbeat(siteID string) (EdgeRelaySite, error) {
 	if !ok {
 		return EdgeRelaySite{}, errors.New("site not found")
 	}
+	if s.siteRevokedLocked(item) {
+		item.Revoked = true
+		item.Connected = false
+		return EdgeRelaySite{}, errors.New("site client certificate revoked")
+	}
 	item.Connected = true
 	item.LastSeenAt = now
 	item.UpdatedAt = now
@@ -216,6 +258,11 @@ func (s *EdgeRelayStore) Deliver(siteID string, limit int) (EdgeRelayDeliveryRes
 	if !ok {
 		return EdgeRelayDeliveryResult{}, errors.New("site not found")
 	}
+	if s.siteRevokedLocked(site) {
+		site.Revoked = true
+		site.Connected = false
+		return EdgeRelayDeliveryResult{}, errors.New("site client certificate revoked")
+	}
 	s.expireLocked(now)
 	delivered := 0
 	for _, msg := range s.messages {
@@ -278,6 +325,10 @@ func (s *EdgeRelayStore) expireLocked(now time.Time) {
 	}
 }
 
+func (s *EdgeRelayStore) siteRevokedLocked(site *EdgeRelaySite) bool {
+	return site.ClientCertSerial != "" && s.revoked != nil && s.revoked.IsRevoked(site.ClientCertSerial)
+}
+
 func normalizeRelayMode(mode string) string {
 	mode = strings.ToLower(strings.TrimSpace(mode))
 	switch mode {
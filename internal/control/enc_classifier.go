@@ -2,6 +2,7 @@ package control
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -10,99 +11,184 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ENCProviderTypeHTTP = "http"
+	ENCProviderTypeExec = "exec"
 )
 
 type ENCProvider struct {
-	ID             string            `json:"id"`
-	Name           string            `json:"name"`
-	Endpoint       string            `json:"endpoint"`
-	Headers        map[string]string `json:"headers,omitempty"`
-	TimeoutSeconds int               `json:"timeout_seconds"`
-	Enabled        bool              `json:"enabled"`
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Endpoint        string            `json:"endpoint,omitempty"`
+	Command         string            `json:"command,omitempty"`
+	Args            []string          `json:"args,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	TimeoutSeconds  int               `json:"timeout_seconds"`
+	CacheTTLSeconds int               `json:"cache_ttl_seconds,omitempty"`
+	Priority        int               `json:"priority"`
+	Enabled         bool              `json:"enabled"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 type ENCProviderInput struct {
-	Name           string            `json:"name"`
-	Endpoint       string            `json:"endpoint"`
-	Headers        map[string]string `json:"headers,omitempty"`
-	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
-	Enabled        bool              `json:"enabled"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type,omitempty"` // http|exec
+	Endpoint        string            `json:"endpoint,omitempty"`
+	Command         string            `json:"command,omitempty"`
+	Args            []string          `json:"args,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	TimeoutSeconds  int               `json:"timeout_seconds,omitempty"`
+	CacheTTLSeconds int               `json:"cache_ttl_seconds,omitempty"`
+	Priority        int               `json:"priority,omitempty"`
+	Enabled         bool              `json:"enabled"`
 }
 
 type ENCClassifyInput struct {
-	ProviderID string         `json:"provider_id"`
+	ProviderID string         `json:"provider_id,omitempty"` // empty tries enabled providers in priority order
 	Node       string         `json:"node"`
 	Facts      map[string]any `json:"facts,omitempty"`
 	Labels     map[string]any `json:"labels,omitempty"`
+	NoCache    bool           `json:"no_cache,omitempty"`
+}
+
+type ENCProviderAttempt struct {
+	ProviderID string `json:"provider_id"`
+	Name       string `json:"name"`
+	Error      string `json:"error,omitempty"`
 }
 
 type ENCClassifyOutput struct {
-	ProviderID  string         `json:"provider_id"`
-	Node        string         `json:"node"`
-	Classes     []string       `json:"classes,omitempty"`
-	RunList     []string       `json:"run_list,omitempty"`
-	Environment string         `json:"environment,omitempty"`
-	Attributes  map[string]any `json:"attributes,omitempty"`
-	Source      string         `json:"source"`
-	ReceivedAt  time.Time      `json:"received_at"`
+	ProviderID      string               `json:"provider_id"`
+	Node            string               `json:"node"`
+	Classes         []string             `json:"classes,omitempty"`
+	ClassParameters map[string]any       `json:"class_parameters,omitempty"`
+	RunList         []string             `json:"run_list,omitempty"`
+	Environment     string               `json:"environment,omitempty"`
+	Parameters      map[string]any       `json:"parameters,omitempty"`
+	Attributes      map[string]any       `json:"attributes,omitempty"`
+	Source          string               `json:"source"`
+	Cached          bool                 `json:"cached,omitempty"`
+	Attempts        []ENCProviderAttempt `json:"attempts,omitempty"`
+	ReceivedAt      time.Time            `json:"received_at"`
 }
 
-type encRemoteResponse struct {
-	Classes     []string       `json:"classes"`
-	RunList     []string       `json:"run_list"`
-	Environment string         `json:"environment"`
-	Attributes  map[string]any `json:"attributes"`
+type encCacheEntry struct {
+	out       ENCClassifyOutput
+	expiresAt time.Time
 }
 
 type ENCProviderStore struct {
 	mu     sync.RWMutex
 	nextID int64
 	items  map[string]*ENCProvider
+	cache  map[string]encCacheEntry
 	client *http.Client
+	guard  func(command string, args []string) error
 }
 
 func NewENCProviderStore() *ENCProviderStore {
 	return &ENCProviderStore{
 		items:  map[string]*ENCProvider{},
+		cache:  map[string]encCacheEntry{},
 		client: &http.Client{},
 	}
 }
 
+// SetExecGuard makes exec providers subject to fn, both when they are
+// registered and before each run; an error refuses the command. Without a
+// guard exec providers are rejected.
+func (s *ENCProviderStore) SetExecGuard(fn func(command string, args []string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guard = fn
+}
+
+func (s *ENCProviderStore) checkExec(command string, args []string) error {
+	s.mu.RLock()
+	guard := s.guard
+	s.mu.RUnlock()
+	if guard == nil {
+		return errors.New("exec providers are disabled")
+	}
+	return guard(command, args)
+}
+
 func (s *ENCProviderStore) Upsert(in ENCProviderInput) (ENCProvider, error) {
 	name := strings.TrimSpace(in.Name)
+	kind := strings.ToLower(strings.TrimSpace(in.Type))
+	if kind == "" {
+		kind = ENCProviderTypeHTTP
+	}
 	endpoint := strings.TrimSpace(in.Endpoint)
-	if name == "" || endpoint == "" {
-		return ENCProvider{}, errors.New("name and endpoint are required")
+	command := strings.TrimSpace(in.Command)
+	if name == "" {
+		return ENCProvider{}, errors.New("name is required")
+	}
+	switch kind {
+	case ENCProviderTypeHTTP:
+		if endpoint == "" {
+			return ENCProvider{}, errors.New("endpoint is required for http providers")
+		}
+		command = ""
+	case ENCProviderTypeExec:
+		if command == "" {
+			return ENCProvider{}, errors.New("command is required for exec providers")
+		}
+		if err := s.checkExec(command, in.Args); err != nil {
+			return ENCProvider{}, err
+		}
+		endpoint = ""
+	default:
+		return ENCProvider{}, errors.New("type must be http or exec")
 	}
 	timeout := in.TimeoutSeconds
 	if timeout <= 0 {
 		timeout = 5
 	}
+	cacheTTL := in.CacheTTLSeconds
+	if cacheTTL < 0 {
+		cacheTTL = 0
+	}
 	now := time.Now().UTC()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, item := range s.items {
 		if strings.EqualFold(item.Name, name) {
+			item.Type = kind
 			item.Endpoint = endpoint
+			item.Command = command
+			item.Args = append([]string{}, in.Args...)
 			item.Headers = cloneStringMap(in.Headers)
 			item.TimeoutSeconds = timeout
+			item.CacheTTLSeconds = cacheTTL
+			item.Priority = in.Priority
 			item.Enabled = in.Enabled
 			item.UpdatedAt = now
+			s.invalidateCacheLocked(item.ID)
 			return cloneENCProvider(*item), nil
 		}
 	}
 	s.nextID++
 	item := &ENCProvider{
-		ID:             "enc-provider-" + itoa(s.nextID),
-		Name:           name,
-		Endpoint:       endpoint,
-		Headers:        cloneStringMap(in.Headers),
-		TimeoutSeconds: timeout,
-		Enabled:        in.Enabled,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		ID:              "enc-provider-" + itoa(s.nextID),
+		Name:            name,
+		Type:            kind,
+		Endpoint:        endpoint,
+		Command:         command,
+		Args:            append([]string{}, in.Args...),
+		Headers:         cloneStringMap(in.Headers),
+		TimeoutSeconds:  timeout,
+		CacheTTLSeconds: cacheTTL,
+		Priority:        in.Priority,
+		Enabled:         in.Enabled,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 	s.items[item.ID] = item
 	return cloneENCProvider(*item), nil
@@ -138,36 +224,113 @@ func (s *ENCProviderStore) SetEnabled(id string, enabled bool) (ENCProvider, err
 	}
 	item.Enabled = enabled
 	item.UpdatedAt = time.Now().UTC()
+	s.invalidateCacheLocked(item.ID)
 	return cloneENCProvider(*item), nil
 }
 
+// Classify runs a single provider when ProviderID is set. Otherwise enabled
+// providers are tried in priority order (lowest first) and the first
+// successful classification wins; failed attempts are reported.
 func (s *ENCProviderStore) Classify(in ENCClassifyInput) (ENCClassifyOutput, error) {
 	providerID := strings.TrimSpace(in.ProviderID)
 	node := strings.TrimSpace(in.Node)
-	if providerID == "" || node == "" {
-		return ENCClassifyOutput{}, errors.New("provider_id and node are required")
+	if node == "" {
+		return ENCClassifyOutput{}, errors.New("node is required")
 	}
-	provider, ok := s.Get(providerID)
-	if !ok {
-		return ENCClassifyOutput{}, errors.New("enc provider not found")
+	in.Node = node
+	if providerID != "" {
+		provider, ok := s.Get(providerID)
+		if !ok {
+			return ENCClassifyOutput{}, errors.New("enc provider not found")
+		}
+		if !provider.Enabled {
+			return ENCClassifyOutput{}, errors.New("enc provider is disabled")
+		}
+		return s.classifyWith(provider, in)
+	}
+
+	providers := make([]ENCProvider, 0)
+	for _, provider := range s.List() {
+		if provider.Enabled {
+			providers = append(providers, provider)
+		}
 	}
-	if !provider.Enabled {
-		return ENCClassifyOutput{}, errors.New("enc provider is disabled")
+	if len(providers) == 0 {
+		return ENCClassifyOutput{}, errors.New("no enabled enc providers")
 	}
+	sort.SliceStable(providers, func(i, j int) bool { return providers[i].Priority < providers[j].Priority })
+	attempts := make([]ENCProviderAttempt, 0, len(providers))
+	for _, provider := range providers {
+		out, err := s.classifyWith(provider, in)
+		if err != nil {
+			attempts = append(attempts, ENCProviderAttempt{ProviderID: provider.ID, Name: provider.Name, Error: err.Error()})
+			continue
+		}
+		out.Attempts = append(attempts, ENCProviderAttempt{ProviderID: provider.ID, Name: provider.Name})
+		return out, nil
+	}
+	msgs := make([]string, 0, len(attempts))
+	for _, attempt := range attempts {
+		msgs = append(msgs, attempt.Name+": "+attempt.Error)
+	}
+	return ENCClassifyOutput{}, errors.New("all enc providers failed: " + strings.Join(msgs, "; "))
+}
 
-	payload := map[string]any{
-		"node":   node,
-		"facts":  cloneAnyMap(in.Facts),
-		"labels": cloneAnyMap(in.Labels),
+func (s *ENCProviderStore) classifyWith(provider ENCProvider, in ENCClassifyInput) (ENCClassifyOutput, error) {
+	cacheKey := provider.ID + "|" + in.Node
+	if provider.CacheTTLSeconds > 0 && !in.NoCache {
+		s.mu.RLock()
+		entry, ok := s.cache[cacheKey]
+		s.mu.RUnlock()
+		if ok && time.Now().Before(entry.expiresAt) {
+			out := cloneENCClassifyOutput(entry.out)
+			out.Cached = true
+			return out, nil
+		}
+	}
+
+	var (
+		raw []byte
+		err error
+	)
+	switch provider.Type {
+	case ENCProviderTypeExec:
+		raw, err = s.runExecProvider(provider, in)
+	default:
+		raw, err = s.runHTTPProvider(provider, in)
 	}
-	body, err := json.Marshal(payload)
 	if err != nil {
 		return ENCClassifyOutput{}, err
 	}
-	req, err := http.NewRequest(http.MethodPost, provider.Endpoint, bytes.NewReader(body))
+	out, err := parseENCOutput(raw)
 	if err != nil {
 		return ENCClassifyOutput{}, err
 	}
+	out.ProviderID = provider.ID
+	out.Node = in.Node
+	out.Source = provider.Name
+	out.ReceivedAt = time.Now().UTC()
+
+	if provider.CacheTTLSeconds > 0 {
+		s.mu.Lock()
+		s.cache[cacheKey] = encCacheEntry{
+			out:       cloneENCClassifyOutput(out),
+			expiresAt: time.Now().Add(time.Duration(provider.CacheTTLSeconds) * time.Second),
+		}
+		s.mu.Unlock()
+	}
+	return out, nil
+}
+
+func (s *ENCProviderStore) runHTTPProvider(provider ENCProvider, in ENCClassifyInput) ([]byte, error) {
+	body, err := encRequestPayload(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, provider.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/json")
 	for k, v := range provider.Headers {
 		req.Header.Set(k, v)
@@ -177,38 +340,136 @@ func (s *ENCProviderStore) Classify(in ENCClassifyInput) (ENCClassifyOutput, err
 	client.Timeout = time.Duration(provider.TimeoutSeconds) * time.Second
 	resp, err := client.Do(req)
 	if err != nil {
-		return ENCClassifyOutput{}, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
 		if len(msg) == 0 {
-			return ENCClassifyOutput{}, errors.New("enc provider request failed")
+			return nil, errors.New("enc provider request failed")
 		}
-		return ENCClassifyOutput{}, errors.New("enc provider request failed: " + strings.TrimSpace(string(msg)))
+		return nil, errors.New("enc provider request failed: " + strings.TrimSpace(string(msg)))
 	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
 
-	var remote encRemoteResponse
-	if err := json.NewDecoder(resp.Body).Decode(&remote); err != nil {
-		return ENCClassifyOutput{}, err
+// runExecProvider follows the Puppet ENC convention: the node name is the
+// last argument and classification YAML/JSON is read from stdout. Facts and
+// labels are also provided as JSON on stdin.
+func (s *ENCProviderStore) runExecProvider(provider ENCProvider, in ENCClassifyInput) ([]byte, error) {
+	if err := s.checkExec(provider.Command, provider.Args); err != nil {
+		return nil, err
+	}
+	body, err := encRequestPayload(in)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(provider.TimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	args := append(append([]string{}, provider.Args...), in.Node)
+	cmd := boundedCommand(ctx, provider.Command, args...)
+	cmd.Stdin = bytes.NewReader(body)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, errors.New("enc provider timed out after " + timeout.String())
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, errors.New("enc provider command failed: " + err.Error())
+		}
+		return nil, errors.New("enc provider command failed: " + msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+func encRequestPayload(in ENCClassifyInput) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"node":   in.Node,
+		"facts":  cloneAnyMap(in.Facts),
+		"labels": cloneAnyMap(in.Labels),
+	})
+}
+
+// parseENCOutput accepts JSON or YAML. classes may be a list or, as in
+// Puppet, a map of class name to class parameters.
+func parseENCOutput(raw []byte) (ENCClassifyOutput, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return ENCClassifyOutput{}, errors.New("enc provider returned invalid yaml/json: " + err.Error())
 	}
-	classes := normalizeStringSlice(remote.Classes)
-	runList := normalizeStringSlice(remote.RunList)
-	out := ENCClassifyOutput{
-		ProviderID:  provider.ID,
-		Node:        node,
-		Classes:     classes,
-		RunList:     runList,
-		Environment: strings.TrimSpace(remote.Environment),
-		Attributes:  cloneAnyMap(remote.Attributes),
-		Source:      provider.Name,
-		ReceivedAt:  time.Now().UTC(),
+	if doc == nil {
+		return ENCClassifyOutput{}, errors.New("enc provider returned empty classification")
+	}
+	out := ENCClassifyOutput{}
+	switch classes := doc["classes"].(type) {
+	case []any:
+		out.Classes = normalizeStringSlice(encStringList(classes))
+	case map[string]any:
+		names := make([]string, 0, len(classes))
+		params := map[string]any{}
+		for name, value := range classes {
+			names = append(names, name)
+			if value != nil {
+				params[name] = value
+			}
+		}
+		out.Classes = normalizeStringSlice(names)
+		if len(params) > 0 {
+			out.ClassParameters = cloneAnyMap(params)
+		}
+	}
+	if list, ok := doc["run_list"].([]any); ok {
+		out.RunList = normalizeStringSlice(encStringList(list))
+	}
+	if env, ok := doc["environment"].(string); ok {
+		out.Environment = strings.TrimSpace(env)
+	}
+	if params, ok := doc["parameters"].(map[string]any); ok {
+		out.Parameters = cloneAnyMap(params)
+	}
+	if attrs, ok := doc["attributes"].(map[string]any); ok {
+		out.Attributes = cloneAnyMap(attrs)
 	}
 	return out, nil
 }
 
+func encStringList(in []any) []string {
+	out := make([]string, 0, len(in))
+	for _, item := range in {
+		if str, ok := item.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
+}
+
+func (s *ENCProviderStore) invalidateCacheLocked(providerID string) {
+	prefix := providerID + "|"
+	for key := range s.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.cache, key)
+		}
+	}
+}
+
+func cloneENCClassifyOutput(in ENCClassifyOutput) ENCClassifyOutput {
+	out := in
+	out.Classes = append([]string{}, in.Classes...)
+	out.RunList = append([]string{}, in.RunList...)
+	out.ClassParameters = cloneAnyMap(in.ClassParameters)
+	out.Parameters = cloneAnyMap(in.Parameters)
+	out.Attributes = cloneAnyMap(in.Attributes)
+	out.Attempts = nil
+	return out
+}
+
 func cloneENCProvider(in ENCProvider) ENCProvider {
 	out := in
+	out.Args = append([]string{}, in.Args...)
 	out.Headers = cloneStringMap(in.Headers)
 	return out
 }
@@ -2,8 +2,12 @@ package control
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -60,3 +64,108 @@ func TestENCProviderDisabled(t *testing.T) {
 		t.Fatalf("expected classify with disabled provider to fail")
 	}
 }
+
+func TestENCProviderExecYAMLCacheAndFallback(t *testing.T) {
+	tmp := t.TempDir()
+	counter := filepath.Join(tmp, "calls")
+	script := filepath.Join(tmp, "enc.sh")
+	if err := os.WriteFile(script, []byte(`#!/bin/sh
+echo x >> "`+counter+`"
+cat <<YAML
+classes:
+  base:
+  nginx:
+    port: 8080
+environment: prod
+parameters:
+  node_name: $1
+YAML
+`), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewENCProviderStore()
+	if _, err := store.Upsert(ENCProviderInput{Name: "unguarded", Type: "exec", Command: "/bin/sh"}); err == nil {
+		t.Fatalf("expected exec provider without a guard to fail")
+	}
+	store.SetExecGuard(func(command string, args []string) error {
+		if command == "/bin/false" {
+			return errors.New("not allowed")
+		}
+		return nil
+	})
+	if _, err := store.Upsert(ENCProviderInput{Name: "bad-exec", Type: "exec"}); err == nil {
+		t.Fatalf("expected exec provider without command to fail")
+	}
+	if _, err := store.Upsert(ENCProviderInput{Name: "refused", Type: "exec", Command: "/bin/false"}); err == nil {
+		t.Fatalf("expected guard to refuse exec provider")
+	}
+	if _, err := store.Upsert(ENCProviderInput{
+		Name:     "broken",
+		Type:     "exec",
+		Command:  "/bin/sh",
+		Args:     []string{"-c", "echo boom >&2; exit 3", "enc"},
+		Priority: 1,
+		Enabled:  true,
+	}); err != nil {
+		t.Fatalf("upsert broken provider: %v", err)
+	}
+	slow, err := store.Upsert(ENCProviderInput{
+		Name:           "slow",
+		Type:           "exec",
+		Command:        "/bin/sh",
+		Args:           []string{"-c", "exec sleep 5", "enc"},
+		TimeoutSeconds: 1,
+		Priority:       2,
+		Enabled:        true,
+	})
+	if err != nil {
+		t.Fatalf("upsert slow provider: %v", err)
+	}
+	if _, err := store.Upsert(ENCProviderInput{
+		Name:            "script",
+		Type:            "exec",
+		Command:         script,
+		CacheTTLSeconds: 60,
+		Priority:        3,
+		Enabled:         true,
+	}); err != nil {
+		t.Fatalf("upsert script provider: %v", err)
+	}
+
+	if _, err := store.Classify(ENCClassifyInput{ProviderID: slow.ID, Node: "web-01"}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if _, err := store.SetEnabled(slow.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := store.Classify(ENCClassifyInput{Node: "web-01"})
+	if err != nil {
+		t.Fatalf("fallback classify failed: %v", err)
+	}
+	if out.Source != "script" || out.Environment != "prod" || len(out.Classes) != 2 {
+		t.Fatalf("unexpected classification %+v", out)
+	}
+	if out.Parameters["node_name"] != "web-01" {
+		t.Fatalf("expected node name passed as argument, got %#v", out.Parameters)
+	}
+	if params, ok := out.ClassParameters["nginx"].(map[string]any); !ok || params["port"] != 8080 {
+		t.Fatalf("expected nginx class parameters, got %#v", out.ClassParameters)
+	}
+	if len(out.Attempts) != 2 || out.Attempts[0].Name != "broken" || !strings.Contains(out.Attempts[0].Error, "boom") || out.Attempts[1].Error != "" {
+		t.Fatalf("unexpected attempts %+v", out.Attempts)
+	}
+
+	cached, err := store.Classify(ENCClassifyInput{Node: "web-01"})
+	if err != nil || !cached.Cached {
+		t.Fatalf("expected cached classification, got %+v err=%v", cached, err)
+	}
+	if _, err := store.Classify(ENCClassifyInput{Node: "web-01", NoCache: true}); err != nil {
+		t.Fatalf("no-cache classify failed: %v", err)
+	}
+	calls, _ := os.ReadFile(counter)
+	if got := strings.Count(string(calls), "x"); got != 2 {
+		t.Fatalf("expected script to run twice, ran %d times", got)
+	}
+}
@@ -0,0 +1,405 @@
+package control
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DataBagFormatPassphrase = "masterchef"
+	DataBagFormatChef       = "chef"
+
+	chefEncryptedDataBagVersion = 3
+	chefEncryptedDataBagCipher  = "aes-256-gcm"
+)
+
+// ChefEncryptedValue is the per-field envelope used by Chef encrypted data
+// bag items (format versions 1-3).
+type ChefEncryptedValue struct {
+	EncryptedData string `json:"encrypted_data"`
+	IV            string `json:"iv"`
+	AuthTag       string `json:"auth_tag,omitempty"`
+	HMAC          string `json:"hmac,omitempty"`
+	Version       int    `json:"version"`
+	Cipher        string `json:"cipher"`
+}
+
+type DataBagKeyInput struct {
+	Bag                  string   `json:"bag"`
+	Secret               string   `json:"secret,omitempty"`
+	AuthorizedPrincipals []string `json:"authorized_principals,omitempty"`
+}
+
+type DataBagKey struct {
+	Bag                  string    `json:"bag"`
+	Secret               string    `json:"-"`
+	Fingerprint          string    `json:"fingerprint"`
+	Version              int       `json:"version"`
+	AuthorizedPrincipals []string  `json:"authorized_principals,omitempty"`
+	ReencryptedItems     int       `json:"reencrypted_items,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	RotatedAt            time.Time `json:"rotated_at,omitempty"`
+}
+
+// SetBagKey creates or rotates the shared secret for a bag. Chef-format items
+// already in the bag are re-encrypted with the new secret. The generated (or
+// supplied) secret is returned only from this call.
+func (s *DataBagStore) SetBagKey(in DataBagKeyInput) (DataBagKey, string, error) {
+	bag := normalizeDataBagName(in.Bag)
+	if bag == "" {
+		return DataBagKey{}, "", errors.New("bag is required")
+	}
+	secret := strings.TrimSpace(in.Secret)
+	if secret == "" {
+		raw := make([]byte, 48)
+		if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+			return DataBagKey{}, "", err
+		}
+		secret = base64.StdEncoding.EncodeToString(raw)
+	}
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		s.keys = map[string]*DataBagKey{}
+	}
+	key, exists := s.keys[bag]
+	if !exists {
+		key = &DataBagKey{Bag: bag, CreatedAt: now}
+	}
+	previous := key.Secret
+	reencrypted := 0
+	if exists && previous != secret {
+		// Decrypt everything first so a bad item leaves the bag untouched.
+		staged := map[string]map[string]any{}
+		for name, item := range s.bags[bag] {
+			if !item.Encrypted || item.Format != DataBagFormatChef {
+				continue
+			}
+			plain, err := DecryptChefDataBagItem(item.Data, previous)
+			if err != nil {
+				return DataBagKey{}, "", errors.New("rotate key: decrypt " + name + ": " + err.Error())
+			}
+			enc, err := EncryptChefDataBagItem(plain, secret)
+			if err != nil {
+				return DataBagKey{}, "", err
+			}
+			staged[name] = enc
+		}
+		for name, enc := range staged {
+			s.bags[bag][name].Data = enc
+			s.bags[bag][name].UpdatedAt = now
+		}
+		reencrypted = len(staged)
+		key.RotatedAt = now
+	}
+	if !exists || previous != secret {
+		key.Version++
+	}
+	key.Secret = secret
+	key.Fingerprint = dataBagKeyFingerprint(secret)
+	key.AuthorizedPrincipals = normalizeStringList(in.AuthorizedPrincipals)
+	key.ReencryptedItems = reencrypted
+	s.keys[bag] = key
+	return cloneDataBagKey(*key), secret, nil
+}
+
+func (s *DataBagStore) GetBagKey(bag string) (DataBagKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[normalizeDataBagName(bag)]
+	if !ok {
+		return DataBagKey{}, false
+	}
+	return cloneDataBagKey(*key), true
+}
+
+func (s *DataBagStore) ListBagKeys() []DataBagKey {
+	s.mu.RLock()
+	out := make([]DataBagKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		out = append(out, cloneDataBagKey(*key))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Bag < out[j].Bag })
+	return out
+}
+
+// PrincipalAuthorized reports whether principal is listed on the bag key.
+func (k DataBagKey) PrincipalAuthorized(principal string) bool {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return false
+	}
+	for _, allowed := range k.AuthorizedPrincipals {
+		if allowed == "*" || strings.EqualFold(allowed, principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalListed reports whether principal is named explicitly on the bag
+// key. Unlike PrincipalAuthorized, a "*" entry does not count, so a bag open
+// to every reader does not let every reader rotate its secret.
+func (k DataBagKey) PrincipalListed(principal string) bool {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return false
+	}
+	for _, allowed := range k.AuthorizedPrincipals {
+		if allowed != "*" && strings.EqualFold(allowed, principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// DecryptWithBagKey returns the item with plaintext data using the bag's
+// shared secret. Callers are responsible for authorizing the principal.
+func (s *DataBagStore) DecryptWithBagKey(bag, item string) (DataBagItem, error) {
+	bag = normalizeDataBagName(bag)
+	item = normalizeDataBagName(item)
+	s.mu.RLock()
+	entry := s.bags[bag][item]
+	key := s.keys[bag]
+	s.mu.RUnlock()
+	if entry == nil {
+		return DataBagItem{}, errors.New("data bag item not found")
+	}
+	out := cloneDataBagItem(*entry)
+	if !out.Encrypted {
+		return out, nil
+	}
+	if key == nil {
+		return DataBagItem{}, errors.New("data bag key not found for bag " + bag)
+	}
+	plain, err := decryptDataBagEntry(out, key.Secret)
+	if err != nil {
+		return DataBagItem{}, err
+	}
+	out.Data = plain
+	return out, nil
+}
+
+// EncryptChefDataBagItem encrypts every top-level field except "id" using
+// the Chef version 3 (aes-256-gcm) envelope.
+func EncryptChefDataBagItem(data map[string]any, secret string) (map[string]any, error) {
+	if strings.TrimSpace(secret) == "" {
+		return nil, errors.New("secret is required")
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{}
+	for field, value := range data {
+		if field == "id" {
+			out[field] = value
+			continue
+		}
+		plain, err := json.Marshal(map[string]any{"json_wrapper": value})
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, gcm.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+			return nil, err
+		}
+		sealed := gcm.Seal(nil, iv, plain, nil)
+		tagStart := len(sealed) - gcm.Overhead()
+		env := ChefEncryptedValue{
+			EncryptedData: base64.StdEncoding.EncodeToString(sealed[:tagStart]),
+			IV:            base64.StdEncoding.EncodeToString(iv),
+			AuthTag:       base64.StdEncoding.EncodeToString(sealed[tagStart:]),
+			Version:       chefEncryptedDataBagVersion,
+			Cipher:        chefEncryptedDataBagCipher,
+		}
+		out[field] = chefEnvelopeToMap(env)
+	}
+	return out, nil
+}
+
+// DecryptChefDataBagItem decrypts a Chef encrypted data bag item. Fields that
+// are not encrypted envelopes (such as "id") are returned unchanged.
+func DecryptChefDataBagItem(data map[string]any, secret string) (map[string]any, error) {
+	if strings.TrimSpace(secret) == "" {
+		return nil, errors.New("secret is required")
+	}
+	out := map[string]any{}
+	for field, value := range data {
+		env, ok := chefEnvelopeFromValue(value)
+		if !ok {
+			out[field] = value
+			continue
+		}
+		plain, err := decryptChefValue(env, secret)
+		if err != nil {
+			return nil, errors.New("decrypt field " + field + ": " + err.Error())
+		}
+		out[field] = plain
+	}
+	return out, nil
+}
+
+// IsChefEncryptedDataBagItem reports whether every non-id field is a Chef
+// encrypted envelope, meaning the item was encrypted client-side.
+func IsChefEncryptedDataBagItem(data map[string]any) bool {
+	found := false
+	for field, value := range data {
+		if field == "id" {
+			continue
+		}
+		if _, ok := chefEnvelopeFromValue(value); !ok {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func decryptChefValue(env ChefEncryptedValue, secret string) (any, error) {
+	key := sha256.Sum256([]byte(secret))
+	cipherBytes, err := decodeChefBase64(env.EncryptedData)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := decodeChefBase64(env.IV)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	var plain []byte
+	switch env.Version {
+	case 3:
+		tag, err := decodeChefBase64(env.AuthTag)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+		if err != nil {
+			return nil, err
+		}
+		plain, err = gcm.Open(nil, iv, append(cipherBytes, tag...), nil)
+		if err != nil {
+			return nil, errors.New("invalid secret or corrupted data")
+		}
+	case 1, 2:
+		if env.Version == 2 {
+			mac := hmac.New(sha256.New, []byte(secret))
+			_, _ = mac.Write([]byte(env.EncryptedData))
+			expected, err := decodeChefBase64(env.HMAC)
+			if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+				return nil, errors.New("hmac verification failed")
+			}
+		}
+		if len(iv) != aes.BlockSize || len(cipherBytes) == 0 || len(cipherBytes)%aes.BlockSize != 0 {
+			return nil, errors.New("invalid cbc payload")
+		}
+		plain = make([]byte, len(cipherBytes))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, cipherBytes)
+		plain, err = pkcs7Unpad(plain)
+		if err != nil {
+			return nil, errors.New("invalid secret or corrupted data")
+		}
+	default:
+		return nil, errors.New("unsupported encrypted data bag version")
+	}
+	var wrapper struct {
+		JSONWrapper any `json:"json_wrapper"`
+	}
+	if err := json.Unmarshal(plain, &wrapper); err != nil {
+		return nil, errors.New("invalid decrypted payload")
+	}
+	return wrapper.JSONWrapper, nil
+}
+
+func decryptDataBagEntry(entry DataBagItem, secret string) (map[string]any, error) {
+	if entry.Format == DataBagFormatChef {
+		return DecryptChefDataBagItem(entry.Data, secret)
+	}
+	return decryptDataBagData(entry.Ciphertext, entry.Nonce, secret)
+}
+
+func chefEnvelopeFromValue(v any) (ChefEncryptedValue, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return ChefEncryptedValue{}, false
+	}
+	if _, ok := m["encrypted_data"].(string); !ok {
+		return ChefEncryptedValue{}, false
+	}
+	if _, ok := m["iv"].(string); !ok {
+		return ChefEncryptedValue{}, false
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return ChefEncryptedValue{}, false
+	}
+	var env ChefEncryptedValue
+	if err := json.Unmarshal(b, &env); err != nil {
+		return ChefEncryptedValue{}, false
+	}
+	if env.Version == 0 {
+		env.Version = 1
+	}
+	return env, true
+}
+
+func chefEnvelopeToMap(env ChefEncryptedValue) map[string]any {
+	b, _ := json.Marshal(env)
+	var out map[string]any
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+func decodeChefBase64(raw string) ([]byte, error) {
+	// Ruby's Base64.encode64 wraps lines, so strip whitespace before decoding.
+	cleaned := strings.Join(strings.Fields(raw), "")
+	return base64.StdEncoding.DecodeString(cleaned)
+}
+
+func pkcs7Unpad(in []byte) ([]byte, error) {
+	if len(in) == 0 {
+		return nil, errors.New("empty payload")
+	}
+	pad := int(in[len(in)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(in) {
+		return nil, errors.New("invalid padding")
+	}
+	if !bytes.Equal(in[len(in)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, errors.New("invalid padding")
+	}
+	return in[:len(in)-pad], nil
+}
+
+func dataBagKeyFingerprint(secret string) string {
+	sum := sha256.Sum256([]byte("masterchef-data-bag-key:" + secret))
+	return "sha256:" + base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func cloneDataBagKey(in DataBagKey) DataBagKey {
+	out := in
+	out.AuthorizedPrincipals = append([]string{}, in.AuthorizedPrincipals...)
+	return out
+}
@@ -0,0 +1,146 @@
+package control
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestChefDataBagItemRoundTrip(t *testing.T) {
+	plain := map[string]any{
+		"id":       "db",
+		"password": "s3cr3t",
+		"nested":   map[string]any{"port": float64(5432)},
+	}
+	enc, err := EncryptChefDataBagItem(plain, "shared-secret")
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if enc["id"] != "db" {
+		t.Fatalf("expected id to remain plaintext, got %#v", enc["id"])
+	}
+	env, ok := enc["password"].(map[string]any)
+	if !ok || env["cipher"] != "aes-256-gcm" || env["version"] != float64(3) || env["auth_tag"] == "" {
+		t.Fatalf("expected chef v3 envelope, got %#v", enc["password"])
+	}
+	if !IsChefEncryptedDataBagItem(enc) {
+		t.Fatalf("expected encrypted item to be detected")
+	}
+	if IsChefEncryptedDataBagItem(plain) {
+		t.Fatalf("plaintext item must not be detected as encrypted")
+	}
+
+	out, err := DecryptChefDataBagItem(enc, "shared-secret")
+	if err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if out["password"] != "s3cr3t" || out["nested"].(map[string]any)["port"] != float64(5432) {
+		t.Fatalf("unexpected decrypted item %#v", out)
+	}
+	if _, err := DecryptChefDataBagItem(enc, "wrong"); err == nil {
+		t.Fatalf("expected wrong secret to fail")
+	}
+}
+
+func TestDecryptChefDataBagItemVersion2(t *testing.T) {
+	secret := "legacy-secret"
+	key := sha256.Sum256([]byte(secret))
+	iv := bytes.Repeat([]byte{7}, aes.BlockSize)
+	plain, _ := json.Marshal(map[string]any{"json_wrapper": "hunter2"})
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+	block, _ := aes.NewCipher(key[:])
+	ciphertext := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plain)
+	// Ruby's Base64.encode64 emits trailing newlines, which are covered by the HMAC.
+	encoded := base64.StdEncoding.EncodeToString(ciphertext) + "\n"
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(encoded))
+
+	item := map[string]any{
+		"id": "legacy",
+		"password": map[string]any{
+			"encrypted_data": encoded,
+			"iv":             base64.StdEncoding.EncodeToString(iv) + "\n",
+			"hmac":           base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+			"version":        float64(2),
+			"cipher":         "aes-256-cbc",
+		},
+	}
+	out, err := DecryptChefDataBagItem(item, secret)
+	if err != nil {
+		t.Fatalf("decrypt v2 failed: %v", err)
+	}
+	if out["password"] != "hunter2" {
+		t.Fatalf("unexpected v2 plaintext %#v", out["password"])
+	}
+
+	item["password"].(map[string]any)["hmac"] = base64.StdEncoding.EncodeToString([]byte("bad"))
+	if _, err := DecryptChefDataBagItem(item, secret); err == nil || !strings.Contains(err.Error(), "hmac") {
+		t.Fatalf("expected hmac failure, got %v", err)
+	}
+}
+
+func TestDataBagStoreBagKeys(t *testing.T) {
+	store := NewDataBagStore()
+	if _, _, err := store.SetBagKey(DataBagKeyInput{}); err == nil {
+		t.Fatalf("expected bag to be required")
+	}
+	key, secret, err := store.SetBagKey(DataBagKeyInput{Bag: "secrets", AuthorizedPrincipals: []string{"node-a", "node-a"}})
+	if err != nil {
+		t.Fatalf("set key failed: %v", err)
+	}
+	if secret == "" || key.Version != 1 || len(key.AuthorizedPrincipals) != 1 {
+		t.Fatalf("unexpected key %#v", key)
+	}
+	if !key.PrincipalAuthorized("NODE-A") || key.PrincipalAuthorized("node-b") {
+		t.Fatalf("unexpected principal authorization for %#v", key.AuthorizedPrincipals)
+	}
+
+	item, err := store.Upsert("secrets", "db", map[string]any{"id": "db", "password": "p1"}, true, "", nil)
+	if err != nil {
+		t.Fatalf("upsert with bag key failed: %v", err)
+	}
+	if item.Format != DataBagFormatChef || !IsChefEncryptedDataBagItem(item.Data) {
+		t.Fatalf("expected chef-format encrypted item, got %#v", item)
+	}
+	got, err := store.Get("secrets", "db", secret)
+	if err != nil || got.Data["password"] != "p1" {
+		t.Fatalf("expected shared secret to decrypt item, got %#v err=%v", got.Data, err)
+	}
+
+	rotated, newSecret, err := store.SetBagKey(DataBagKeyInput{Bag: "secrets", Secret: "rotated-secret"})
+	if err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	if newSecret != "rotated-secret" || rotated.Version != 2 || rotated.ReencryptedItems != 1 || rotated.Fingerprint == key.Fingerprint {
+		t.Fatalf("unexpected rotated key %#v", rotated)
+	}
+	if _, err := store.Get("secrets", "db", secret); err == nil {
+		t.Fatalf("expected old secret to stop working after rotation")
+	}
+	dec, err := store.DecryptWithBagKey("secrets", "db")
+	if err != nil || dec.Data["password"] != "p1" {
+		t.Fatalf("expected bag key decrypt after rotation, got %#v err=%v", dec.Data, err)
+	}
+
+	// Items encrypted client-side are stored untouched.
+	pre, _ := EncryptChefDataBagItem(map[string]any{"id": "api", "token": "t"}, "rotated-secret")
+	imported, err := store.Upsert("secrets", "api", pre, false, "", nil)
+	if err != nil || !imported.Encrypted || imported.Format != DataBagFormatChef {
+		t.Fatalf("expected imported chef item, got %#v err=%v", imported, err)
+	}
+	results, err := store.Search(DataBagSearchRequest{Bag: "secrets", Field: "token", Equals: "t", Passphrase: "rotated-secret"})
+	if err != nil || len(results) != 1 || results[0].Item != "api" {
+		t.Fatalf("expected search over chef item, got %#v err=%v", results, err)
+	}
+	if len(store.ListBagKeys()) != 1 {
+		t.Fatalf("expected one bag key")
+	}
+}
@@ -0,0 +1,151 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entity kinds whose versions are tracked for optimistic concurrency.
+const (
+	VersionedKindTemplate        = "template"
+	VersionedKindRule            = "rule"
+	VersionedKindAdmissionPolicy = "admission_policy"
+)
+
+// VersionConflictError reports an update whose expected version no longer
+// matches the stored entity.
+type VersionConflictError struct {
+	Kind     string
+	ID       string
+	Expected int64
+	Current  int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return "version conflict: " + e.Kind + " " + e.ID + " is at version " + itoa(e.Current) + ", not " + itoa(e.Expected)
+}
+
+// checkVersion returns a conflict when expected is set and differs from
+// current. An expected version of 0 skips the check.
+func checkVersion(kind, id string, current, expected int64) error {
+	if expected > 0 && expected != current {
+		return &VersionConflictError{Kind: kind, ID: id, Expected: expected, Current: current}
+	}
+	return nil
+}
+
+// EntityETag formats a version as a strong entity tag.
+func EntityETag(version int64) string {
+	return `"v` + itoa(version) + `"`
+}
+
+// ParseIfMatch extracts the expected version from an If-Match header value.
+// It accepts tags produced by EntityETag, their weak form, and bare version
+// numbers. A "*" matches any version and yields 0.
+func ParseIfMatch(header string) (int64, error) {
+	v := strings.TrimSpace(header)
+	if v == "*" {
+		return 0, nil
+	}
+	v = strings.TrimPrefix(v, "W/")
+	v = strings.Trim(v, `"`)
+	v = strings.TrimPrefix(v, "v")
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.New("If-Match must be an entity tag such as \"v3\"")
+	}
+	return n, nil
+}
+
+// EntityVersion is one recorded revision of a versioned entity.
+type EntityVersion struct {
+	Kind       string          `json:"kind"`
+	EntityID   string          `json:"entity_id"`
+	Version    int64           `json:"version"`
+	Action     string          `json:"action"`
+	Actor      string          `json:"actor,omitempty"`
+	Entity     json.RawMessage `json:"entity"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// EntityVersionHistory keeps an audit trail of entity revisions, bounded
+// per entity.
+type EntityVersionHistory struct {
+	mu        sync.RWMutex
+	limit     int
+	revisions map[string][]EntityVersion
+}
+
+func NewEntityVersionHistory(limit int) *EntityVersionHistory {
+	if limit <= 0 {
+		limit = 100
+	}
+	return &EntityVersionHistory{limit: limit, revisions: map[string][]EntityVersion{}}
+}
+
+// Record snapshots entity at version.
+func (h *EntityVersionHistory) Record(kind, id string, version int64, action, actor string, entity any) error {
+	kind = strings.TrimSpace(kind)
+	id = strings.TrimSpace(id)
+	if kind == "" || id == "" {
+		return errors.New("kind and entity id are required")
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := kind + "/" + id
+	items := append(h.revisions[key], EntityVersion{
+		Kind:       kind,
+		EntityID:   id,
+		Version:    version,
+		Action:     strings.TrimSpace(action),
+		Actor:      strings.TrimSpace(actor),
+		Entity:     raw,
+		RecordedAt: time.Now().UTC(),
+	})
+	if len(items) > h.limit {
+		items = append([]EntityVersion{}, items[len(items)-h.limit:]...)
+	}
+	h.revisions[key] = items
+	return nil
+}
+
+// History returns recorded revisions of an entity, newest first.
+func (h *EntityVersionHistory) History(kind, id string) []EntityVersion {
+	h.mu.RLock()
+	items := h.revisions[strings.TrimSpace(kind)+"/"+strings.TrimSpace(id)]
+	out := make([]EntityVersion, 0, len(items))
+	for _, item := range items {
+		out = append(out, cloneEntityVersion(item))
+	}
+	h.mu.RUnlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Version > out[j].Version })
+	return out
+}
+
+// Get returns one recorded revision of an entity.
+func (h *EntityVersionHistory) Get(kind, id string, version int64) (EntityVersion, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	items := h.revisions[strings.TrimSpace(kind)+"/"+strings.TrimSpace(id)]
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Version == version {
+			return cloneEntityVersion(items[i]), nil
+		}
+	}
+	return EntityVersion{}, errors.New("entity version not found")
+}
+
+func cloneEntityVersion(in EntityVersion) EntityVersion {
+	out := in
+	out.Entity = append(json.RawMessage{}, in.Entity...)
+	return out
+}
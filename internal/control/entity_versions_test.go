@@ -0,0 +1,75 @@
+package control
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for header, want := range map[string]int64{`"v3"`: 3, `W/"v3"`: 3, "7": 7, "*": 0} {
+		got, err := ParseIfMatch(header)
+		if err != nil || got != want {
+			t.Fatalf("ParseIfMatch(%q) = %d, %v; want %d", header, got, err, want)
+		}
+	}
+	for _, header := range []string{"", `"abc"`, `"v0"`} {
+		if _, err := ParseIfMatch(header); err == nil {
+			t.Fatalf("expected ParseIfMatch(%q) to fail", header)
+		}
+	}
+	if EntityETag(4) != `"v4"` {
+		t.Fatalf("unexpected etag %s", EntityETag(4))
+	}
+}
+
+func TestVersionedUpdatesRejectStaleVersions(t *testing.T) {
+	templates := NewTemplateStore()
+	tpl := templates.Create(Template{Name: "web", ConfigPath: "c.yaml"})
+	if tpl.Version != 1 {
+		t.Fatalf("expected new template at version 1, got %d", tpl.Version)
+	}
+	updated, err := templates.Update(tpl.ID, Template{Name: "web-v2", ConfigPath: "c.yaml"}, 1)
+	if err != nil || updated.Version != 2 || updated.Name != "web-v2" || !updated.CreatedAt.Equal(tpl.CreatedAt) {
+		t.Fatalf("unexpected template update %+v err=%v", updated, err)
+	}
+	_, err = templates.Update(tpl.ID, Template{Name: "web-v3", ConfigPath: "c.yaml"}, 1)
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Current != 2 || conflict.Expected != 1 {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+
+	rules := NewRuleEngine()
+	rule, err := rules.Create(Rule{Name: "r", SourcePrefix: "drift.", Actions: []RuleAction{{Type: "enqueue_apply", ConfigPath: "c.yaml"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule, err = rules.SetEnabledVersion(rule.ID, false, 1); err != nil || rule.Version != 2 || rule.Enabled {
+		t.Fatalf("unexpected toggle %+v err=%v", rule, err)
+	}
+	if _, err := rules.Update(rule.ID, Rule{Name: "r2", SourcePrefix: "drift.", Actions: rule.Actions}, 1); !errors.As(err, &conflict) {
+		t.Fatalf("expected rule version conflict, got %v", err)
+	}
+	if rule, err = rules.Update(rule.ID, Rule{Name: "r2", SourcePrefix: "drift.", Actions: rule.Actions}, 0); err != nil || rule.Version != 3 || rule.Name != "r2" {
+		t.Fatalf("unexpected unconditional rule update %+v err=%v", rule, err)
+	}
+}
+
+func TestEntityVersionHistory(t *testing.T) {
+	history := NewEntityVersionHistory(2)
+	for v := int64(1); v <= 3; v++ {
+		if err := history.Record(VersionedKindRule, "rule-1", v, "updated", "alice", map[string]int64{"version": v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items := history.History(VersionedKindRule, "rule-1")
+	if len(items) != 2 || items[0].Version != 3 || items[1].Version != 2 || items[0].Actor != "alice" {
+		t.Fatalf("unexpected history %+v", items)
+	}
+	if _, err := history.Get(VersionedKindRule, "rule-1", 1); err == nil {
+		t.Fatalf("expected trimmed version to be gone")
+	}
+	item, err := history.Get(VersionedKindRule, "rule-1", 3)
+	if err != nil || string(item.Entity) != `{"version":3}` {
+		t.Fatalf("unexpected version %+v err=%v", item, err)
+	}
+}
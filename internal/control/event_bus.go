@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,15 +34,16 @@ type EventBusTarget struct {
 }
 
 type EventBusDelivery struct {
-	ID        string       `json:"id"`
-	Time      time.Time    `json:"time"`
-	TargetID  string       `json:"target_id"`
-	Target    string       `json:"target"`
-	Kind      EventBusKind `json:"kind"`
-	EventType string       `json:"event_type,omitempty"`
-	Status    string       `json:"status"` // delivered|queued|failed
-	Code      int          `json:"code,omitempty"`
-	Error     string       `json:"error,omitempty"`
+	ID        string          `json:"id"`
+	Time      time.Time       `json:"time"`
+	TargetID  string          `json:"target_id"`
+	Target    string          `json:"target"`
+	Kind      EventBusKind    `json:"kind"`
+	EventType string          `json:"event_type,omitempty"`
+	Schema    *EventSchemaRef `json:"schema,omitempty"`
+	Status    string          `json:"status"` // delivered|queued|failed
+	Code      int             `json:"code,omitempty"`
+	Error     string          `json:"error,omitempty"`
 }
 
 type EventBus struct {
@@ -51,6 +53,7 @@ type EventBus struct {
 	targets    map[string]EventBusTarget
 	deliveries []EventBusDelivery
 	client     *http.Client
+	schemaOf   func(eventType string) (EventSchemaRef, bool)
 }
 
 func NewEventBus() *EventBus {
@@ -62,6 +65,14 @@ func NewEventBus() *EventBus {
 	}
 }
 
+// SetSchemaLookup attaches the registered schema version of each event to
+// its deliveries so consumers know which contract the payload follows.
+func (b *EventBus) SetSchemaLookup(fn func(eventType string) (EventSchemaRef, bool)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.schemaOf = fn
+}
+
 func (b *EventBus) Register(in EventBusTarget) (EventBusTarget, error) {
 	name := strings.TrimSpace(in.Name)
 	if name == "" {
@@ -153,32 +164,44 @@ func (b *EventBus) Publish(event Event) []EventBusDelivery {
 			targets = append(targets, t)
 		}
 	}
+	schemaOf := b.schemaOf
 	b.mu.RUnlock()
 
+	var schema *EventSchemaRef
+	if schemaOf != nil {
+		if ref, ok := schemaOf(event.Type); ok {
+			schema = &ref
+		}
+	}
 	deliveries := make([]EventBusDelivery, 0, len(targets))
 	for _, target := range targets {
-		d := b.dispatch(target, event)
+		d := b.dispatch(target, event, schema)
 		deliveries = append(deliveries, d)
 		b.recordDelivery(d)
 	}
 	return deliveries
 }
 
-func (b *EventBus) dispatch(target EventBusTarget, event Event) EventBusDelivery {
+func (b *EventBus) dispatch(target EventBusTarget, event Event, schema *EventSchemaRef) EventBusDelivery {
 	base := EventBusDelivery{
 		Time:      time.Now().UTC(),
 		TargetID:  target.ID,
 		Target:    target.Name,
 		Kind:      target.Kind,
 		EventType: event.Type,
+		Schema:    schema,
 	}
 
+	meta := map[string]any{
+		"target_kind": target.Kind,
+		"topic":       target.Topic,
+	}
+	if schema != nil {
+		meta["schema"] = schema
+	}
 	payload := map[string]any{
 		"event": event,
-		"meta": map[string]any{
-			"target_kind": target.Kind,
-			"topic":       target.Topic,
-		},
+		"meta":  meta,
 	}
 	body, _ := json.Marshal(payload)
 	if strings.TrimSpace(target.URL) == "" {
@@ -200,6 +223,9 @@ func (b *EventBus) dispatch(target EventBusTarget, event Event) EventBusDelivery
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Masterchef-EventBus-Kind", string(target.Kind))
 	req.Header.Set("X-Masterchef-Event-Type", event.Type)
+	if schema != nil {
+		req.Header.Set("X-Masterchef-Event-Schema-Version", strconv.Itoa(schema.Version))
+	}
 	for k, v := range target.Headers {
 		req.Header.Set(k, v)
 	}
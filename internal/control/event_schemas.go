@@ -0,0 +1,687 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventSchemaWarn    = "warn"
+	EventSchemaEnforce = "enforce"
+
+	EventSchemaCompatBackward = "backward"
+	EventSchemaCompatNone     = "none"
+)
+
+// EventSchema is one version of the JSON schema for an event type's Fields.
+// The supported keywords are type, properties, required,
+// additionalProperties, enum, items, minimum, maximum, minLength,
+// maxLength, and pattern.
+type EventSchema struct {
+	EventType     string         `json:"event_type"`
+	Version       int            `json:"version"`
+	Schema        map[string]any `json:"schema"`
+	Mode          string         `json:"mode"`
+	Compatibility string         `json:"compatibility"`
+	Description   string         `json:"description,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// EventSchemaRef identifies the schema version an event was checked against.
+type EventSchemaRef struct {
+	EventType string `json:"event_type"`
+	Version   int    `json:"version"`
+}
+
+type EventSchemaValidation struct {
+	EventType string   `json:"event_type"`
+	Version   int      `json:"version,omitempty"`
+	Mode      string   `json:"mode,omitempty"`
+	Valid     bool     `json:"valid"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+type EventSchemaViolation struct {
+	EventType  string    `json:"event_type"`
+	Version    int       `json:"version"`
+	EventIndex int64     `json:"event_index,omitempty"`
+	Errors     []string  `json:"errors"`
+	Time       time.Time `json:"time"`
+}
+
+// EventSchemaIncompatibleError lists why a new schema version would break
+// events that satisfy the current one.
+type EventSchemaIncompatibleError struct {
+	Problems []string
+}
+
+func (e *EventSchemaIncompatibleError) Error() string {
+	return "schema is not backward compatible: " + strings.Join(e.Problems, "; ")
+}
+
+type EventSchemaRegistry struct {
+	mu         sync.RWMutex
+	versions   map[string][]EventSchema
+	violations []EventSchemaViolation
+	counts     map[string]int
+	limit      int
+}
+
+func NewEventSchemaRegistry(violationLimit int) *EventSchemaRegistry {
+	if violationLimit <= 0 {
+		violationLimit = 1000
+	}
+	return &EventSchemaRegistry{
+		versions: map[string][]EventSchema{},
+		counts:   map[string]int{},
+		limit:    violationLimit,
+	}
+}
+
+// Register adds the next version of an event type's schema. Unless the new
+// version sets compatibility to none, it must accept every payload the
+// current version accepts.
+func (r *EventSchemaRegistry) Register(in EventSchema) (EventSchema, error) {
+	item, err := normalizeEventSchema(in)
+	if err != nil {
+		return EventSchema{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history := r.versions[item.EventType]
+	if len(history) > 0 && item.Compatibility == EventSchemaCompatBackward {
+		if problems := eventSchemaCompatibility(history[len(history)-1].Schema, item.Schema, ""); len(problems) > 0 {
+			return EventSchema{}, &EventSchemaIncompatibleError{Problems: problems}
+		}
+	}
+	item.Version = len(history) + 1
+	item.CreatedAt = time.Now().UTC()
+	r.versions[item.EventType] = append(history, item)
+	return cloneEventSchema(item), nil
+}
+
+// CheckCompatibility reports what would make schema incompatible with the
+// event type's current version, without registering it.
+func (r *EventSchemaRegistry) CheckCompatibility(eventType string, schema map[string]any) ([]string, error) {
+	if err := checkEventSchemaDocument(schema, ""); err != nil {
+		return nil, err
+	}
+	current, ok := r.Latest(eventType)
+	if !ok {
+		return []string{}, nil
+	}
+	return eventSchemaCompatibility(current.Schema, schema, ""), nil
+}
+
+// List returns the latest version of every registered event type.
+func (r *EventSchemaRegistry) List() []EventSchema {
+	r.mu.RLock()
+	out := make([]EventSchema, 0, len(r.versions))
+	for _, history := range r.versions {
+		out = append(out, cloneEventSchema(history[len(history)-1]))
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].EventType < out[j].EventType })
+	return out
+}
+
+func (r *EventSchemaRegistry) Versions(eventType string) ([]EventSchema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	history, ok := r.versions[strings.TrimSpace(eventType)]
+	if !ok {
+		return nil, errors.New("event schema not found")
+	}
+	out := make([]EventSchema, 0, len(history))
+	for _, item := range history {
+		out = append(out, cloneEventSchema(item))
+	}
+	return out, nil
+}
+
+func (r *EventSchemaRegistry) Latest(eventType string) (EventSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	history, ok := r.versions[strings.TrimSpace(eventType)]
+	if !ok {
+		return EventSchema{}, false
+	}
+	return cloneEventSchema(history[len(history)-1]), true
+}
+
+// SchemaRef returns the current schema version for an event type, for
+// annotating deliveries.
+func (r *EventSchemaRegistry) SchemaRef(eventType string) (EventSchemaRef, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	history, ok := r.versions[strings.TrimSpace(eventType)]
+	if !ok {
+		return EventSchemaRef{}, false
+	}
+	return EventSchemaRef{EventType: eventType, Version: history[len(history)-1].Version}, true
+}
+
+// Validate checks an event's Fields against the latest schema for its
+// type. Events of unregistered types are valid.
+func (r *EventSchemaRegistry) Validate(e Event) EventSchemaValidation {
+	out := EventSchemaValidation{EventType: e.Type, Valid: true}
+	schema, ok := r.Latest(e.Type)
+	if !ok {
+		return out
+	}
+	out.Version = schema.Version
+	out.Mode = schema.Mode
+	// Round-trip through JSON so internally emitted Go values ([]string,
+	// structs, typed ints) are checked the way consumers will decode them.
+	fields := map[string]any{}
+	if raw, err := json.Marshal(e.Fields); err == nil {
+		_ = json.Unmarshal(raw, &fields)
+	}
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	out.Errors = validateEventSchemaValue(schema.Schema, fields, "fields")
+	out.Valid = len(out.Errors) == 0
+	return out
+}
+
+// Observe validates an event that has already been recorded and keeps a
+// violation when it does not match its schema.
+func (r *EventSchemaRegistry) Observe(e Event) EventSchemaValidation {
+	res := r.Validate(e)
+	if res.Valid {
+		return res
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[e.Type]++
+	r.violations = append(r.violations, EventSchemaViolation{
+		EventType:  e.Type,
+		Version:    res.Version,
+		EventIndex: e.Index,
+		Errors:     append([]string{}, res.Errors...),
+		Time:       time.Now().UTC(),
+	})
+	if len(r.violations) > r.limit {
+		r.violations = append([]EventSchemaViolation{}, r.violations[len(r.violations)-r.limit:]...)
+	}
+	return res
+}
+
+// Violations returns recent violations, newest first, with per-type totals.
+func (r *EventSchemaRegistry) Violations(limit int) ([]EventSchemaViolation, map[string]int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if limit <= 0 || limit > len(r.violations) {
+		limit = len(r.violations)
+	}
+	out := make([]EventSchemaViolation, 0, limit)
+	for i := len(r.violations) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, r.violations[i])
+	}
+	counts := make(map[string]int, len(r.counts))
+	for k, v := range r.counts {
+		counts[k] = v
+	}
+	return out, counts
+}
+
+func normalizeEventSchema(in EventSchema) (EventSchema, error) {
+	in.EventType = strings.TrimSpace(in.EventType)
+	if in.EventType == "" {
+		return EventSchema{}, errors.New("event_type is required")
+	}
+	if len(in.Schema) == 0 {
+		return EventSchema{}, errors.New("schema is required")
+	}
+	if err := checkEventSchemaDocument(in.Schema, ""); err != nil {
+		return EventSchema{}, err
+	}
+	switch in.Mode = strings.ToLower(strings.TrimSpace(in.Mode)); in.Mode {
+	case "":
+		in.Mode = EventSchemaWarn
+	case EventSchemaWarn, EventSchemaEnforce:
+	default:
+		return EventSchema{}, errors.New("mode must be warn or enforce")
+	}
+	switch in.Compatibility = strings.ToLower(strings.TrimSpace(in.Compatibility)); in.Compatibility {
+	case "":
+		in.Compatibility = EventSchemaCompatBackward
+	case EventSchemaCompatBackward, EventSchemaCompatNone:
+	default:
+		return EventSchema{}, errors.New("compatibility must be backward or none")
+	}
+	in.Description = strings.TrimSpace(in.Description)
+	in.Schema = cloneSchemaMap(in.Schema)
+	return in, nil
+}
+
+// checkEventSchemaDocument rejects keywords with values of the wrong shape
+// so validation never has to guess.
+func checkEventSchemaDocument(schema map[string]any, path string) error {
+	where := func() string {
+		if path == "" {
+			return "schema"
+		}
+		return "schema." + path
+	}
+	for _, t := range schemaTypes(schema) {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return errors.New(where() + ": unsupported type " + t)
+		}
+	}
+	if raw, ok := schema["type"]; ok {
+		if _, isString := raw.(string); !isString {
+			if _, isList := raw.([]any); !isList {
+				return errors.New(where() + ": type must be a string or list")
+			}
+		}
+	}
+	if raw, ok := schema["properties"]; ok {
+		props, ok := raw.(map[string]any)
+		if !ok {
+			return errors.New(where() + ": properties must be an object")
+		}
+		for name, sub := range props {
+			subSchema, ok := sub.(map[string]any)
+			if !ok {
+				return errors.New(where() + ": property " + name + " must be a schema object")
+			}
+			if err := checkEventSchemaDocument(subSchema, joinSchemaPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+	if raw, ok := schema["required"]; ok {
+		list, ok := raw.([]any)
+		if !ok {
+			return errors.New(where() + ": required must be a list of names")
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return errors.New(where() + ": required must be a list of names")
+			}
+		}
+	}
+	if raw, ok := schema["items"]; ok {
+		sub, ok := raw.(map[string]any)
+		if !ok {
+			return errors.New(where() + ": items must be a schema object")
+		}
+		if err := checkEventSchemaDocument(sub, joinSchemaPath(path, "[]")); err != nil {
+			return err
+		}
+	}
+	if raw, ok := schema["additionalProperties"]; ok {
+		if _, ok := raw.(bool); !ok {
+			return errors.New(where() + ": additionalProperties must be a boolean")
+		}
+	}
+	if raw, ok := schema["enum"]; ok {
+		if _, ok := raw.([]any); !ok {
+			return errors.New(where() + ": enum must be a list")
+		}
+	}
+	for _, key := range []string{"minimum", "maximum", "minLength", "maxLength"} {
+		if raw, ok := schema[key]; ok {
+			if _, ok := raw.(float64); !ok {
+				return errors.New(where() + ": " + key + " must be a number")
+			}
+		}
+	}
+	if raw, ok := schema["pattern"]; ok {
+		pattern, ok := raw.(string)
+		if !ok {
+			return errors.New(where() + ": pattern must be a string")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.New(where() + ": invalid pattern: " + err.Error())
+		}
+	}
+	return nil
+}
+
+func validateEventSchemaValue(schema map[string]any, value any, path string) []string {
+	errs := make([]string, 0)
+	if types := schemaTypes(schema); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if jsonValueIsType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return append(errs, path+": expected "+strings.Join(types, " or ")+", got "+jsonTypeName(value))
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if jsonValuesEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, path+": value is not one of the allowed enum values")
+		}
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, raw := range required {
+			name, _ := raw.(string)
+			if _, ok := v[name]; !ok {
+				errs = append(errs, joinSchemaPath(path, name)+": required field is missing")
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		closed, _ := schema["additionalProperties"].(bool)
+		closed = !closed && schema["additionalProperties"] != nil
+		for _, name := range names {
+			sub, ok := props[name].(map[string]any)
+			if !ok {
+				if closed {
+					errs = append(errs, joinSchemaPath(path, name)+": field is not allowed")
+				}
+				continue
+			}
+			errs = append(errs, validateEventSchemaValue(sub, v[name], joinSchemaPath(path, name))...)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateEventSchemaValue(items, item, path+"["+strconv.Itoa(i)+"]")...)
+			}
+		}
+	case string:
+		if n, ok := schema["minLength"].(float64); ok && float64(len([]rune(v))) < n {
+			errs = append(errs, path+": shorter than minLength")
+		}
+		if n, ok := schema["maxLength"].(float64); ok && float64(len([]rune(v))) > n {
+			errs = append(errs, path+": longer than maxLength")
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				errs = append(errs, path+": does not match pattern "+pattern)
+			}
+		}
+	default:
+		if n, ok := jsonNumber(value); ok {
+			if min, ok := schema["minimum"].(float64); ok && n < min {
+				errs = append(errs, path+": below minimum")
+			}
+			if max, ok := schema["maximum"].(float64); ok && n > max {
+				errs = append(errs, path+": above maximum")
+			}
+		}
+	}
+	return errs
+}
+
+// eventSchemaCompatibility lists changes in next that would reject payloads
+// prev accepts: new required fields, narrowed types, removed enum values,
+// tightened bounds, and properties closed off or dropped from a closed object.
+func eventSchemaCompatibility(prev, next map[string]any, path string) []string {
+	where := path
+	if where == "" {
+		where = "fields"
+	}
+	problems := make([]string, 0)
+	prevTypes, nextTypes := schemaTypes(prev), schemaTypes(next)
+	if len(nextTypes) > 0 {
+		if len(prevTypes) == 0 {
+			problems = append(problems, where+": type restricted to "+strings.Join(nextTypes, " or "))
+		}
+		for _, t := range prevTypes {
+			if !schemaTypeAllows(nextTypes, t) {
+				problems = append(problems, where+": type "+t+" no longer allowed")
+			}
+		}
+	}
+	prevRequired := map[string]bool{}
+	for _, name := range schemaRequired(prev) {
+		prevRequired[name] = true
+	}
+	for _, name := range schemaRequired(next) {
+		if !prevRequired[name] {
+			problems = append(problems, joinSchemaPath(where, name)+": newly required")
+		}
+	}
+	if nextEnum, ok := next["enum"].([]any); ok {
+		prevEnum, hadEnum := prev["enum"].([]any)
+		if !hadEnum {
+			problems = append(problems, where+": enum added")
+		}
+		for _, old := range prevEnum {
+			kept := false
+			for _, v := range nextEnum {
+				if jsonValuesEqual(old, v) {
+					kept = true
+					break
+				}
+			}
+			if !kept {
+				problems = append(problems, where+": enum value "+stringValue(old)+" removed")
+			}
+		}
+	}
+	for _, key := range []string{"minimum", "minLength"} {
+		if n, ok := next[key].(float64); ok {
+			if p, had := prev[key].(float64); !had || n > p {
+				problems = append(problems, where+": "+key+" tightened")
+			}
+		}
+	}
+	for _, key := range []string{"maximum", "maxLength"} {
+		if n, ok := next[key].(float64); ok {
+			if p, had := prev[key].(float64); !had || n < p {
+				problems = append(problems, where+": "+key+" tightened")
+			}
+		}
+	}
+	if pattern, ok := next["pattern"].(string); ok && pattern != prev["pattern"] {
+		problems = append(problems, where+": pattern changed")
+	}
+	prevOpen := prev["additionalProperties"] != false
+	nextOpen := next["additionalProperties"] != false
+	if prevOpen && !nextOpen {
+		problems = append(problems, where+": additional properties no longer allowed")
+	}
+	prevProps, _ := prev["properties"].(map[string]any)
+	nextProps, _ := next["properties"].(map[string]any)
+	for _, name := range sortedAnyKeys(prevProps) {
+		prevSub, _ := prevProps[name].(map[string]any)
+		nextSub, ok := nextProps[name].(map[string]any)
+		if !ok {
+			if !nextOpen {
+				problems = append(problems, joinSchemaPath(where, name)+": property removed from a closed object")
+			}
+			continue
+		}
+		problems = append(problems, eventSchemaCompatibility(prevSub, nextSub, joinSchemaPath(where, name))...)
+	}
+	if nextItems, ok := next["items"].(map[string]any); ok {
+		prevItems, _ := prev["items"].(map[string]any)
+		problems = append(problems, eventSchemaCompatibility(prevItems, nextItems, where+"[]")...)
+	}
+	return problems
+}
+
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// schemaTypeAllows reports whether allowed accepts every value of type t;
+// number covers integer.
+func schemaTypeAllows(allowed []string, t string) bool {
+	for _, a := range allowed {
+		if a == t || (a == "number" && t == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaRequired(schema map[string]any) []string {
+	list, _ := schema["required"].([]any)
+	out := make([]string, 0, len(list))
+	for _, raw := range list {
+		if name, ok := raw.(string); ok {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func jsonValueIsType(value any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := jsonNumber(value)
+		return ok
+	case "integer":
+		n, ok := jsonNumber(value)
+		return ok && n == math.Trunc(n)
+	default:
+		return false
+	}
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := jsonNumber(value); ok {
+		return "number"
+	}
+	return "unknown"
+}
+
+// jsonNumber accepts decoded JSON numbers and Go numeric types.
+func jsonNumber(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func jsonValuesEqual(a, b any) bool {
+	if an, ok := jsonNumber(a); ok {
+		bn, ok := jsonNumber(b)
+		return ok && an == bn
+	}
+	return stringValue(a) == stringValue(b) && jsonTypeName(a) == jsonTypeName(b)
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedAnyKeys(in map[string]any) []string {
+	out := make([]string, 0, len(in))
+	for k := range in {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func cloneSchemaMap(in map[string]any) map[string]any {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		out[k] = cloneSchemaValue(v)
+	}
+	return out
+}
+
+func cloneSchemaValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return cloneSchemaMap(t)
+	case []any:
+		out := make([]any, len(t))
+		for i, item := range t {
+			out[i] = cloneSchemaValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func cloneEventSchema(in EventSchema) EventSchema {
+	out := in
+	out.Schema = cloneSchemaMap(in.Schema)
+	return out
+}
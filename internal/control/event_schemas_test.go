@@ -0,0 +1,104 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeSchema(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	out := map[string]any{}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestEventSchemaRegistryValidatesAndChecksCompatibility(t *testing.T) {
+	r := NewEventSchemaRegistry(10)
+	if _, err := r.Register(EventSchema{EventType: "deploy.finished", Schema: decodeSchema(t, `{"type":"object","properties":{"status":{"type":"bogus"}}}`)}); err == nil {
+		t.Fatalf("expected unsupported type rejected")
+	}
+	v1, err := r.Register(EventSchema{
+		EventType: "deploy.finished",
+		Mode:      "enforce",
+		Schema: decodeSchema(t, `{"type":"object","required":["status"],"properties":{
+			"status":{"type":"string","enum":["ok","failed"]},
+			"hosts":{"type":"array","items":{"type":"string"}},
+			"duration_ms":{"type":"integer","minimum":0}}}`),
+	})
+	if err != nil || v1.Version != 1 || v1.Compatibility != EventSchemaCompatBackward {
+		t.Fatalf("unexpected first version: %+v err=%v", v1, err)
+	}
+
+	ok := r.Validate(Event{Type: "deploy.finished", Fields: map[string]any{"status": "ok", "hosts": []string{"a"}, "duration_ms": 12}})
+	if !ok.Valid || ok.Version != 1 {
+		t.Fatalf("expected Go-typed fields to validate: %+v", ok)
+	}
+	bad := r.Validate(Event{Type: "deploy.finished", Fields: map[string]any{"status": "maybe", "duration_ms": 1.5}})
+	if bad.Valid || len(bad.Errors) != 2 || bad.Mode != EventSchemaEnforce {
+		t.Fatalf("expected enum and integer violations: %+v", bad)
+	}
+	if res := r.Validate(Event{Type: "unregistered"}); !res.Valid {
+		t.Fatalf("expected unregistered types to pass")
+	}
+
+	_, err = r.Register(EventSchema{
+		EventType: "deploy.finished",
+		Schema:    decodeSchema(t, `{"type":"object","required":["status","region"],"properties":{"status":{"type":"string","enum":["ok"]}}}`),
+	})
+	var incompatible *EventSchemaIncompatibleError
+	if !errors.As(err, &incompatible) || len(incompatible.Problems) != 2 {
+		t.Fatalf("expected new required field and removed enum value reported, got %v", err)
+	}
+	problems, err := r.CheckCompatibility("deploy.finished", decodeSchema(t, `{"type":"object","required":["status"],"properties":{"status":{"type":"string","enum":["ok","failed","skipped"]},"region":{"type":"string"}}}`))
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("expected additive change compatible, got %v err=%v", problems, err)
+	}
+	v2, err := r.Register(EventSchema{EventType: "deploy.finished", Compatibility: "none", Schema: decodeSchema(t, `{"type":"object","required":["region"]}`)})
+	if err != nil || v2.Version != 2 {
+		t.Fatalf("expected compatibility none to allow breaking change: %+v err=%v", v2, err)
+	}
+
+	r.Observe(Event{Index: 7, Type: "deploy.finished", Fields: map[string]any{}})
+	items, counts := r.Violations(0)
+	if len(items) != 1 || items[0].EventIndex != 7 || counts["deploy.finished"] != 1 {
+		t.Fatalf("unexpected violations: %+v %+v", items, counts)
+	}
+}
+
+func TestEventBusDeliveriesCarrySchemaVersion(t *testing.T) {
+	var header string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Masterchef-Event-Schema-Version")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	registry := NewEventSchemaRegistry(10)
+	if _, err := registry.Register(EventSchema{EventType: "job.done", Schema: map[string]any{"type": "object"}}); err != nil {
+		t.Fatal(err)
+	}
+	bus := NewEventBus()
+	bus.SetSchemaLookup(registry.SchemaRef)
+	if _, err := bus.Register(EventBusTarget{Name: "hook", Kind: EventBusWebhook, URL: srv.URL, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	d := bus.Publish(Event{Type: "job.done"})
+	if len(d) != 1 || d[0].Schema == nil || d[0].Schema.Version != 1 || header != "1" {
+		t.Fatalf("expected schema version on delivery and header, got %+v header=%q", d, header)
+	}
+	meta, _ := body["meta"].(map[string]any)
+	if schema, _ := meta["schema"].(map[string]any); schema["event_type"] != "job.done" {
+		t.Fatalf("expected schema in delivery meta: %+v", body)
+	}
+	if d := bus.Publish(Event{Type: "other"}); d[0].Schema != nil || strings.TrimSpace(header) != "" {
+		t.Fatalf("expected no schema for unregistered type, got %+v header=%q", d[0], header)
+	}
+}
@@ -0,0 +1,404 @@
+package control
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event shard keys.
+const (
+	EventShardNone   = "none"
+	EventShardTenant = "tenant"
+	EventShardType   = "type"
+)
+
+// maxEventSpillBytes is the size at which a shard's spill file is rotated
+// to a single .1 backup.
+const maxEventSpillBytes = 64 << 20
+
+// EventShardPolicy partitions event retention by tenant (the "tenant"
+// field) or by event type family (the type up to its first dot). Each shard
+// keeps at most its capacity, and when the store as a whole is full the
+// largest shard gives up its oldest event, so a busy shard cannot evict the
+// history of quieter ones. With Spill set, evicted events are appended to a
+// per-shard file instead of being dropped.
+type EventShardPolicy struct {
+	ShardBy         string         `json:"shard_by"`
+	DefaultCapacity int            `json:"default_capacity"`
+	Capacities      map[string]int `json:"capacities,omitempty"`
+	Spill           bool           `json:"spill"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type EventShardStats struct {
+	Shard       string `json:"shard"`
+	Capacity    int    `json:"capacity"`
+	Retained    int    `json:"retained"`
+	Appended    int64  `json:"appended"`
+	Evicted     int64  `json:"evicted"`
+	Spilled     int64  `json:"spilled"`
+	SpillBytes  int64  `json:"spill_bytes"`
+	SpillError  string `json:"spill_error,omitempty"`
+	OldestIndex int64  `json:"oldest_index,omitempty"`
+	NewestIndex int64  `json:"newest_index,omitempty"`
+}
+
+type eventShard struct {
+	retained   int
+	appended   int64
+	evicted    int64
+	spilled    int64
+	spillBytes int64
+	spillErr   string
+	spill      *os.File
+	oldest     int64
+	newest     int64
+}
+
+// SetSpillDir sets the directory that receives evicted events when the
+// shard policy enables spilling.
+func (s *EventStore) SetSpillDir(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeSpillsLocked()
+	s.spillDir = strings.TrimSpace(dir)
+}
+
+// Close releases open spill files.
+func (s *EventStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeSpillsLocked()
+}
+
+func (s *EventStore) ShardPolicy() EventShardPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneEventShardPolicy(s.shardPolicy)
+}
+
+// SetShardPolicy repartitions retained events under the new policy and
+// evicts from any shard that is now over capacity.
+func (s *EventStore) SetShardPolicy(in EventShardPolicy) (EventShardPolicy, error) {
+	in.ShardBy = strings.ToLower(strings.TrimSpace(in.ShardBy))
+	switch in.ShardBy {
+	case "":
+		in.ShardBy = EventShardNone
+	case EventShardNone, EventShardTenant, EventShardType:
+	default:
+		return EventShardPolicy{}, errors.New("shard_by must be none, tenant, or type")
+	}
+	if in.DefaultCapacity < 0 {
+		return EventShardPolicy{}, errors.New("default_capacity must be non-negative")
+	}
+	capacities := map[string]int{}
+	for shard, capacity := range in.Capacities {
+		shard = strings.TrimSpace(shard)
+		if shard == "" {
+			continue
+		}
+		if capacity <= 0 {
+			return EventShardPolicy{}, errors.New("capacity for shard " + shard + " must be greater than zero")
+		}
+		capacities[shard] = capacity
+	}
+	in.Capacities = capacities
+	in.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if in.DefaultCapacity == 0 || in.DefaultCapacity > s.limit {
+		in.DefaultCapacity = s.limit
+	}
+	if in.Spill && s.spillDir == "" {
+		return EventShardPolicy{}, errors.New("event spill directory is not configured")
+	}
+	s.shardPolicy = in
+	retained := append([]Event{}, s.events...)
+	s.events = s.events[:0]
+	evicted := s.evicted
+	s.resetShardsLocked()
+	for _, event := range retained {
+		s.storeLocked(event)
+	}
+	s.evicted += evicted
+	return cloneEventShardPolicy(in), nil
+}
+
+// ShardStats returns per-shard retention metrics sorted by shard name.
+func (s *EventStore) ShardStats() []EventShardStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]EventShardStats, 0, len(s.shards))
+	for name, sh := range s.shards {
+		out = append(out, EventShardStats{
+			Shard:       name,
+			Capacity:    s.shardCapacityLocked(name),
+			Retained:    sh.retained,
+			Appended:    sh.appended,
+			Evicted:     sh.evicted,
+			Spilled:     sh.spilled,
+			SpillBytes:  sh.spillBytes,
+			SpillError:  sh.spillErr,
+			OldestIndex: sh.oldest,
+			NewestIndex: sh.newest,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Shard < out[j].Shard })
+	return out
+}
+
+// Spilled returns up to limit of the most recently spilled events of a
+// shard, oldest first.
+func (s *EventStore) Spilled(shard string, limit int) ([]Event, error) {
+	s.mu.RLock()
+	dir := s.spillDir
+	s.mu.RUnlock()
+	if dir == "" {
+		return nil, errors.New("event spill directory is not configured")
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	path := filepath.Join(dir, eventSpillFileName(strings.TrimSpace(shard)))
+	out := []Event{}
+	for _, name := range []string{path + ".1", path} {
+		events, err := readEventSpill(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, events...)
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+func readEventSpill(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	out := []Event{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 8<<20)
+	for sc.Scan() {
+		var event Event
+		// A line cut short by a concurrent write is skipped.
+		if err := json.Unmarshal(sc.Bytes(), &event); err == nil {
+			out = append(out, event)
+		}
+	}
+	return out, sc.Err()
+}
+
+func (s *EventStore) shardKey(e Event) string {
+	switch s.shardPolicy.ShardBy {
+	case EventShardTenant:
+		if tenant, ok := e.Fields["tenant"].(string); ok && strings.TrimSpace(tenant) != "" {
+			return strings.TrimSpace(tenant)
+		}
+	case EventShardType:
+		family := strings.ToLower(strings.TrimSpace(e.Type))
+		if i := strings.Index(family, "."); i >= 0 {
+			family = family[:i]
+		}
+		if family != "" {
+			return family
+		}
+	}
+	return DefaultShard
+}
+
+func (s *EventStore) shardCapacityLocked(name string) int {
+	if capacity, ok := s.shardPolicy.Capacities[name]; ok {
+		return capacity
+	}
+	if s.shardPolicy.DefaultCapacity > 0 {
+		return s.shardPolicy.DefaultCapacity
+	}
+	return s.limit
+}
+
+func (s *EventStore) resetShardsLocked() {
+	s.closeSpillsLocked()
+	s.shards = map[string]*eventShard{}
+}
+
+// storeLocked appends a sealed event and enforces shard and store limits.
+func (s *EventStore) storeLocked(e Event) {
+	name := s.shardKey(e)
+	sh, ok := s.shards[name]
+	if !ok {
+		sh = &eventShard{}
+		s.shards[name] = sh
+	}
+	s.events = append(s.events, e)
+	sh.retained++
+	sh.appended++
+	sh.newest = e.Index
+	if sh.oldest == 0 {
+		sh.oldest = e.Index
+	}
+	if sh.retained > s.shardCapacityLocked(name) {
+		s.evictLocked(name)
+	}
+	for len(s.events) > s.limit {
+		if !s.evictLocked(s.largestShardLocked()) {
+			break
+		}
+	}
+}
+
+func (s *EventStore) largestShardLocked() string {
+	largest, size := "", -1
+	for name, sh := range s.shards {
+		if sh.retained > size || (sh.retained == size && name < largest) {
+			largest, size = name, sh.retained
+		}
+	}
+	return largest
+}
+
+// evictLocked removes the oldest retained event of a shard.
+func (s *EventStore) evictLocked(name string) bool {
+	sh := s.shards[name]
+	at := -1
+	for i := range s.events {
+		if s.shardKey(s.events[i]) == name {
+			at = i
+			break
+		}
+	}
+	if sh == nil || at < 0 {
+		return false
+	}
+	evicted := s.events[at]
+	copy(s.events[at:], s.events[at+1:])
+	s.events[len(s.events)-1] = Event{}
+	s.events = s.events[:len(s.events)-1]
+	sh.retained--
+	sh.evicted++
+	s.evicted++
+	s.recordGapLocked(evicted)
+	sh.oldest = 0
+	for i := at; i < len(s.events); i++ {
+		if s.shardKey(s.events[i]) == name {
+			sh.oldest = s.events[i].Index
+			break
+		}
+	}
+	if sh.retained == 0 {
+		sh.newest = 0
+	}
+	if s.shardPolicy.Spill && s.spillDir != "" {
+		s.spillLocked(name, sh, evicted)
+	}
+	return true
+}
+
+// recordGapLocked merges an evicted event into the run of evicted events
+// around it.
+func (s *EventStore) recordGapLocked(e Event) {
+	gap := eventGap{Start: e.Index, End: e.Index, PrevHash: strings.TrimSpace(e.PrevHash), Hash: strings.TrimSpace(e.Hash)}
+	if start, ok := s.gapEnds[e.Index-1]; ok {
+		left := s.gaps[start]
+		delete(s.gaps, start)
+		delete(s.gapEnds, left.End)
+		gap.Start, gap.PrevHash = left.Start, left.PrevHash
+	}
+	if right, ok := s.gaps[e.Index+1]; ok {
+		delete(s.gaps, right.Start)
+		delete(s.gapEnds, right.End)
+		gap.End, gap.Hash = right.End, right.Hash
+	}
+	s.gaps[gap.Start] = gap
+	s.gapEnds[gap.End] = gap.Start
+}
+
+func (s *EventStore) spillLocked(name string, sh *eventShard, e Event) {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		sh.spillErr = err.Error()
+		return
+	}
+	path := filepath.Join(s.spillDir, eventSpillFileName(name))
+	if sh.spill != nil && sh.spillBytes >= maxEventSpillBytes {
+		_ = sh.spill.Close()
+		sh.spill = nil
+		if err := os.Rename(path, path+".1"); err != nil {
+			sh.spillErr = err.Error()
+		}
+		sh.spillBytes = 0
+	}
+	if sh.spill == nil {
+		if err := os.MkdirAll(s.spillDir, 0o755); err != nil {
+			sh.spillErr = err.Error()
+			return
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			sh.spillErr = err.Error()
+			return
+		}
+		if st, err := f.Stat(); err == nil {
+			sh.spillBytes = st.Size()
+		}
+		sh.spill = f
+	}
+	n, err := sh.spill.Write(append(raw, '\n'))
+	sh.spillBytes += int64(n)
+	if err != nil {
+		sh.spillErr = err.Error()
+		return
+	}
+	sh.spilled++
+	sh.spillErr = ""
+}
+
+func (s *EventStore) closeSpillsLocked() {
+	for _, sh := range s.shards {
+		if sh.spill != nil {
+			_ = sh.spill.Close()
+			sh.spill = nil
+		}
+	}
+}
+
+// eventSpillFileName keeps shard names that are not safe path components
+// apart by suffixing a hash of the original name.
+func eventSpillFileName(shard string) string {
+	var b strings.Builder
+	for _, r := range shard {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	sum := sha256.Sum256([]byte(shard))
+	return "events-" + b.String() + "-" + hex.EncodeToString(sum[:4]) + ".jsonl"
+}
+
+func cloneEventShardPolicy(in EventShardPolicy) EventShardPolicy {
+	out := in
+	out.Capacities = make(map[string]int, len(in.Capacities))
+	for k, v := range in.Capacities {
+		out.Capacities[k] = v
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package control
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestEventShardsProtectQuietTenantHistory(t *testing.T) {
+	s := NewEventStore(10)
+	s.SetSpillDir(t.TempDir())
+	t.Cleanup(s.Close)
+	if _, err := s.SetShardPolicy(EventShardPolicy{ShardBy: EventShardTenant, Spill: true}); err != nil {
+		t.Fatalf("set shard policy failed: %v", err)
+	}
+	s.Append(Event{Type: "run.completed", Fields: map[string]any{"tenant": "quiet"}})
+	s.Append(Event{Type: "run.completed", Fields: map[string]any{"tenant": "quiet"}})
+	for i := 0; i < 50; i++ {
+		s.Append(Event{Type: "http.request", Message: strconv.Itoa(i), Fields: map[string]any{"tenant": "busy"}})
+	}
+
+	if got := s.Len(); got != 10 {
+		t.Fatalf("expected store limit to hold, got %d events", got)
+	}
+	stats := map[string]EventShardStats{}
+	for _, st := range s.ShardStats() {
+		stats[st.Shard] = st
+	}
+	if stats["quiet"].Retained != 2 || stats["quiet"].Evicted != 0 {
+		t.Fatalf("expected quiet tenant history to survive, got %+v", stats["quiet"])
+	}
+	if stats["busy"].Retained != 8 || stats["busy"].Evicted != 42 || stats["busy"].Spilled != 42 {
+		t.Fatalf("unexpected busy shard stats: %+v", stats["busy"])
+	}
+
+	spilled, err := s.Spilled("busy", 5)
+	if err != nil {
+		t.Fatalf("read spilled events failed: %v", err)
+	}
+	if len(spilled) != 5 || spilled[4].Message != "41" {
+		t.Fatalf("unexpected spilled events: %+v", spilled)
+	}
+
+	report := s.VerifyIntegrity()
+	if !report.Valid || report.Gaps == 0 {
+		t.Fatalf("expected valid chain with eviction gaps, got %+v", report)
+	}
+
+	// Deleting a retained event is not mistaken for an eviction gap.
+	s.mu.Lock()
+	s.events = append(s.events[:5:5], s.events[6:]...)
+	s.mu.Unlock()
+	if report := s.VerifyIntegrity(); report.Valid {
+		t.Fatalf("expected mid-chain deletion to fail verification, got %+v", report)
+	}
+}
+
+func TestEventShardsByTypeCapacities(t *testing.T) {
+	s := NewEventStore(100)
+	for i := 0; i < 5; i++ {
+		s.Append(Event{Type: "http.request"})
+		s.Append(Event{Type: "queue.saturation"})
+	}
+	if _, err := s.SetShardPolicy(EventShardPolicy{ShardBy: EventShardType, Capacities: map[string]int{"http": 2}}); err != nil {
+		t.Fatalf("set shard policy failed: %v", err)
+	}
+	if got := s.Len(); got != 7 {
+		t.Fatalf("expected repartition to trim http shard to 2, got %d events", got)
+	}
+	if _, err := s.SetShardPolicy(EventShardPolicy{ShardBy: "host"}); err == nil {
+		t.Fatalf("expected unsupported shard_by to fail")
+	}
+	if _, err := s.SetShardPolicy(EventShardPolicy{ShardBy: EventShardType, Spill: true}); err == nil {
+		t.Fatalf("expected spill without a spill directory to fail")
+	}
+}
@@ -29,6 +29,7 @@ type EventIntegrityViolation struct {
 type EventIntegrityReport struct {
 	Valid      bool                      `json:"valid"`
 	Checked    int                       `json:"checked"`
+	Gaps       int                       `json:"gaps,omitempty"`
 	LastHash   string                    `json:"last_hash,omitempty"`
 	Violations []EventIntegrityViolation `json:"violations,omitempty"`
 }
@@ -41,6 +42,23 @@ type EventStore struct {
 	lastHash         string
 	nextSubscriberID int64
 	subscribers      map[int64]chan Event
+	shardPolicy      EventShardPolicy
+	shards           map[string]*eventShard
+	spillDir         string
+	evicted          int64
+	gaps             map[int64]eventGap
+	gapEnds          map[int64]int64
+	onAppend         func(Event)
+}
+
+// eventGap records a run of contiguous evicted events by the hashes that
+// link it to its retained neighbours, so VerifyIntegrity can tell eviction
+// gaps from deleted events.
+type eventGap struct {
+	Start    int64
+	End      int64
+	PrevHash string
+	Hash     string
 }
 
 type EventQuery struct {
@@ -60,23 +78,26 @@ func NewEventStore(limit int) *EventStore {
 		events:      make([]Event, 0, limit),
 		limit:       limit,
 		subscribers: map[int64]chan Event{},
+		shardPolicy: EventShardPolicy{ShardBy: EventShardNone, DefaultCapacity: limit, Capacities: map[string]int{}, UpdatedAt: time.Now().UTC()},
+		shards:      map[string]*eventShard{},
+		gaps:        map[int64]eventGap{},
+		gapEnds:     map[int64]int64{},
 	}
 }
 
 func (s *EventStore) Append(e Event) {
 	s.mu.Lock()
 	sealed := s.sealEventLocked(e)
-	if len(s.events) >= s.limit {
-		copy(s.events[0:], s.events[1:])
-		s.events[len(s.events)-1] = sealed
-	} else {
-		s.events = append(s.events, sealed)
-	}
+	s.storeLocked(sealed)
 	subs := make([]chan Event, 0, len(s.subscribers))
 	for _, ch := range s.subscribers {
 		subs = append(subs, ch)
 	}
+	onAppend := s.onAppend
 	s.mu.Unlock()
+	if onAppend != nil {
+		onAppend(sealed)
+	}
 	for _, ch := range subs {
 		select {
 		case ch <- sealed:
@@ -85,6 +106,14 @@ func (s *EventStore) Append(e Event) {
 	}
 }
 
+// OnAppend registers a callback invoked with each event after it is stored.
+// The callback must not append events itself.
+func (s *EventStore) OnAppend(fn func(Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onAppend = fn
+}
+
 func (s *EventStore) List() []Event {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -93,23 +122,31 @@ func (s *EventStore) List() []Event {
 	return out
 }
 
+// Len returns the number of retained events.
+func (s *EventStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.events)
+}
+
 func (s *EventStore) Replace(items []Event) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if items == nil {
-		s.events = s.events[:0]
-		s.lastHash = ""
-		s.nextIndex = 0
-		return
-	}
 	s.events = s.events[:0]
 	s.lastHash = ""
 	s.nextIndex = 0
+	s.evicted = 0
+	s.gaps = map[int64]eventGap{}
+	s.gapEnds = map[int64]int64{}
+	s.resetShardsLocked()
+	if items == nil {
+		return
+	}
 	if len(items) > s.limit {
 		items = items[len(items)-s.limit:]
 	}
 	for _, item := range items {
-		s.events = append(s.events, s.sealEventLocked(item))
+		s.storeLocked(s.sealEventLocked(item))
 	}
 }
 
@@ -169,8 +206,20 @@ func (s *EventStore) VerifyIntegrity() EventIntegrityReport {
 		Violations: make([]EventIntegrityViolation, 0),
 	}
 	var prevHash string
-	for i, event := range s.events {
-		expectedIndex := int64(i + 1)
+	var prevIndex int64
+	for _, event := range s.events {
+		if event.Index != prevIndex+1 {
+			// A gap is only accepted when it is exactly one recorded run of
+			// evicted events chained to the events on either side of it.
+			gap, ok := s.gaps[prevIndex+1]
+			if ok && gap.End == event.Index-1 && gap.PrevHash == strings.TrimSpace(prevHash) {
+				report.Gaps++
+				prevHash = gap.Hash
+				prevIndex = gap.End
+			}
+		}
+		expectedIndex := prevIndex + 1
+		prevIndex = event.Index
 		if event.Index != expectedIndex {
 			report.Valid = false
 			report.Violations = append(report.Violations, EventIntegrityViolation{
@@ -0,0 +1,16 @@
+package control
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// boundedCommand is exec.CommandContext for commands run under a timeout.
+// Once ctx kills the process, Wait gives up on grandchildren still holding
+// stdout or stderr open after a second instead of blocking until they exit.
+func boundedCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = time.Second
+	return cmd
+}
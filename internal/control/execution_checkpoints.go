@@ -63,6 +63,27 @@ func (s *ExecutionCheckpointStore) Record(in ExecutionCheckpointInput) (Executio
 	return cloneExecutionCheckpoint(*item), nil
 }
 
+// Restore inserts a checkpoint under its original ID, replacing any existing
+// checkpoint with that ID.
+func (s *ExecutionCheckpointStore) Restore(in ExecutionCheckpoint) (ExecutionCheckpoint, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		return ExecutionCheckpoint{}, errors.New("checkpoint id is required")
+	}
+	if strings.TrimSpace(in.ConfigPath) == "" {
+		return ExecutionCheckpoint{}, errors.New("config_path is required")
+	}
+	item := cloneExecutionCheckpoint(in)
+	if item.RecordedAt.IsZero() {
+		item.RecordedAt = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[item.ID] = &item
+	s.nextID = advanceRestoredID(s.nextID, item.ID, "checkpoint-")
+	return cloneExecutionCheckpoint(item), nil
+}
+
 func (s *ExecutionCheckpointStore) Get(id string) (ExecutionCheckpoint, bool) {
 	s.mu.RLock()
 	item, ok := s.items[strings.TrimSpace(id)]
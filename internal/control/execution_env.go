@@ -2,6 +2,7 @@ package control
 
 import (
 	"errors"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -10,22 +11,46 @@ import (
 )
 
 type ExecutionEnvironment struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	ImageDigest  string    `json:"image_digest"`
-	Dependencies []string  `json:"dependencies,omitempty"`
-	Signed       bool      `json:"signed"`
-	SignatureRef string    `json:"signature_ref,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	ImageDigest  string             `json:"image_digest"`
+	Dependencies []string           `json:"dependencies,omitempty"`
+	Signed       bool               `json:"signed"`
+	SignatureRef string             `json:"signature_ref,omitempty"`
+	Isolation    ExecutionIsolation `json:"isolation"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
 }
 
 type ExecutionEnvironmentInput struct {
-	Name         string   `json:"name"`
-	ImageDigest  string   `json:"image_digest"`
-	Dependencies []string `json:"dependencies,omitempty"`
-	Signed       bool     `json:"signed"`
-	SignatureRef string   `json:"signature_ref,omitempty"`
+	Name         string             `json:"name"`
+	ImageDigest  string             `json:"image_digest"`
+	Dependencies []string           `json:"dependencies,omitempty"`
+	Signed       bool               `json:"signed"`
+	SignatureRef string             `json:"signature_ref,omitempty"`
+	Isolation    ExecutionIsolation `json:"isolation"`
+}
+
+// ExecutionIsolation controls how jobs that select the environment run.
+// Container mode runs Image@ImageDigest under podman or docker; chroot mode
+// runs inside ChrootPath. Env is injected into the run alongside a
+// short-lived execution credential scoped to CredentialScopes, and files
+// matching ArtifactPaths are copied out of the workspace afterwards.
+type ExecutionIsolation struct {
+	Mode             string                  `json:"mode,omitempty"`    // none|container|chroot
+	Runtime          string                  `json:"runtime,omitempty"` // podman|docker; detected when empty
+	Image            string                  `json:"image,omitempty"`
+	ChrootPath       string                  `json:"chroot_path,omitempty"`
+	Limits           ExecutionResourceLimits `json:"limits"`
+	Env              map[string]string       `json:"env,omitempty"`
+	CredentialScopes []string                `json:"credential_scopes,omitempty"`
+	ArtifactPaths    []string                `json:"artifact_paths,omitempty"`
+}
+
+type ExecutionResourceLimits struct {
+	CPUs           float64 `json:"cpus,omitempty"`
+	MemoryMB       int     `json:"memory_mb,omitempty"`
+	TimeoutSeconds int     `json:"timeout_seconds,omitempty"`
 }
 
 type ExecutionAdmissionPolicy struct {
@@ -70,6 +95,10 @@ func (s *ExecutionEnvironmentStore) Create(in ExecutionEnvironmentInput) (Execut
 	if !in.Signed {
 		in.SignatureRef = ""
 	}
+	isolation, err := normalizeExecutionIsolation(in.Isolation)
+	if err != nil {
+		return ExecutionEnvironment{}, err
+	}
 	now := time.Now().UTC()
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -81,6 +110,7 @@ func (s *ExecutionEnvironmentStore) Create(in ExecutionEnvironmentInput) (Execut
 		Dependencies: normalizeStringSlice(in.Dependencies),
 		Signed:       in.Signed,
 		SignatureRef: strings.TrimSpace(in.SignatureRef),
+		Isolation:    isolation,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -144,9 +174,77 @@ func (s *ExecutionEnvironmentStore) EvaluateAdmission(env ExecutionEnvironment)
 	return ExecutionAdmissionResult{Allowed: true}
 }
 
+func normalizeExecutionIsolation(in ExecutionIsolation) (ExecutionIsolation, error) {
+	out := ExecutionIsolation{
+		Mode:             strings.ToLower(strings.TrimSpace(in.Mode)),
+		Runtime:          strings.ToLower(strings.TrimSpace(in.Runtime)),
+		Image:            strings.TrimSpace(in.Image),
+		ChrootPath:       strings.TrimSpace(in.ChrootPath),
+		Limits:           in.Limits,
+		Env:              map[string]string{},
+		CredentialScopes: normalizeStringSlice(in.CredentialScopes),
+	}
+	switch out.Mode {
+	case "", "none":
+		out.Mode = "none"
+	case "container":
+		if out.Image == "" {
+			return ExecutionIsolation{}, errors.New("image is required for container isolation")
+		}
+		if strings.Contains(out.Image, "@") {
+			return ExecutionIsolation{}, errors.New("image must not include a digest; image_digest is used")
+		}
+		if strings.HasPrefix(out.Image, "-") || strings.ContainsAny(out.Image, " \t\r\n") {
+			return ExecutionIsolation{}, errors.New("invalid image name")
+		}
+	case "chroot":
+		if !strings.HasPrefix(out.ChrootPath, "/") {
+			return ExecutionIsolation{}, errors.New("chroot_path must be an absolute path for chroot isolation")
+		}
+		out.ChrootPath = filepath.Clean(out.ChrootPath)
+		if out.ChrootPath == "/" {
+			return ExecutionIsolation{}, errors.New("chroot_path must be a dedicated root, not /")
+		}
+	default:
+		return ExecutionIsolation{}, errors.New("isolation mode must be none, container, or chroot")
+	}
+	switch out.Runtime {
+	case "", "podman", "docker":
+	default:
+		return ExecutionIsolation{}, errors.New("runtime must be podman or docker")
+	}
+	if out.Limits.CPUs < 0 || out.Limits.MemoryMB < 0 || out.Limits.TimeoutSeconds < 0 {
+		return ExecutionIsolation{}, errors.New("limits must not be negative")
+	}
+	for k, v := range in.Env {
+		key := strings.TrimSpace(k)
+		if key == "" || strings.ContainsAny(key, "= ") {
+			return ExecutionIsolation{}, errors.New("invalid env variable name " + k)
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return ExecutionIsolation{}, errors.New("env variable " + key + " must not contain newlines")
+		}
+		out.Env[key] = v
+	}
+	for _, pattern := range in.ArtifactPaths {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "..") {
+			return ExecutionIsolation{}, errors.New("artifact_paths must be relative to the job workspace")
+		}
+		out.ArtifactPaths = append(out.ArtifactPaths, pattern)
+	}
+	return out, nil
+}
+
 func cloneExecutionEnvironment(in ExecutionEnvironment) ExecutionEnvironment {
 	out := in
 	out.Dependencies = append([]string{}, in.Dependencies...)
+	out.Isolation.Env = cloneStringMap(in.Isolation.Env)
+	out.Isolation.CredentialScopes = append([]string{}, in.Isolation.CredentialScopes...)
+	out.Isolation.ArtifactPaths = append([]string{}, in.Isolation.ArtifactPaths...)
 	return out
 }
 
@@ -8,183 +8,488 @@ import (
 	"time"
 )
 
+const (
+	ExecutionLockExclusive = "exclusive"
+	ExecutionLockShared    = "shared"
+)
+
+// ExecutionLock is a lock on a hierarchical key such as
+// "cluster/service/host". A lock covers its key and everything below it, so
+// a lock on "prod" overlaps locks on "prod/api" and "prod/api/host-1".
+// Overlapping locks conflict unless both are shared.
 type ExecutionLock struct {
 	ID         string    `json:"id"`
 	Key        string    `json:"key"`
+	Mode       string    `json:"mode"` // exclusive|shared
 	Holder     string    `json:"holder"`
 	JobID      string    `json:"job_id,omitempty"`
-	AcquiredAt time.Time `json:"acquired_at"`
-	ExpiresAt  time.Time `json:"expires_at"`
+	QueuedAt   time.Time `json:"queued_at,omitempty"`
+	WaitingFor []string  `json:"waiting_for,omitempty"` // lock ids a waiting request is queued behind
+	AcquiredAt time.Time `json:"acquired_at,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
 	ReleasedAt time.Time `json:"released_at,omitempty"`
-	Status     string    `json:"status"` // active|released|expired
+	Status     string    `json:"status"` // waiting|active|released|expired|canceled
+
+	ttl time.Duration
 }
 
+// ExecutionLockAcquireInput requests a lock. With Wait, a conflicting
+// request joins the key's wait queue instead of failing.
 type ExecutionLockAcquireInput struct {
 	Key        string `json:"key"`
 	Holder     string `json:"holder"`
+	Mode       string `json:"mode,omitempty"`
 	TTLSeconds int    `json:"ttl_seconds,omitempty"`
+	Wait       bool   `json:"wait,omitempty"`
 }
 
+// ExecutionLockReleaseInput picks the lock to release by id, by bound job,
+// or by key (narrowed by holder when several shared locks hold the key).
+// Releasing a waiting request cancels it.
 type ExecutionLockReleaseInput struct {
-	Key   string `json:"key,omitempty"`
-	JobID string `json:"job_id,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Holder string `json:"holder,omitempty"`
+	JobID  string `json:"job_id,omitempty"`
+}
+
+// ExecutionLockWaitEdge is an edge of the wait-for graph: Waiter's request
+// for WaiterLockID is queued behind Holder's BlockingLockID.
+type ExecutionLockWaitEdge struct {
+	Waiter         string `json:"waiter"`
+	WaiterLockID   string `json:"waiter_lock_id"`
+	Holder         string `json:"holder"`
+	BlockingLockID string `json:"blocking_lock_id"`
+	Key            string `json:"key"`
 }
 
 type ExecutionLockStore struct {
 	mu      sync.RWMutex
 	nextID  int64
-	byKey   map[string]*ExecutionLock
+	active  map[string]*ExecutionLock
+	waiters []*ExecutionLock
 	byJob   map[string]string
 	history []ExecutionLock
+	onGrant []func(ExecutionLock)
 }
 
 func NewExecutionLockStore() *ExecutionLockStore {
 	return &ExecutionLockStore{
-		byKey:   map[string]*ExecutionLock{},
+		active:  map[string]*ExecutionLock{},
 		byJob:   map[string]string{},
 		history: make([]ExecutionLock, 0, 2000),
 	}
 }
 
+// OnGrant registers fn to be called when a waiting request is granted.
+func (s *ExecutionLockStore) OnGrant(fn func(ExecutionLock)) {
+	if fn == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onGrant = append(s.onGrant, fn)
+}
+
+// Acquire takes a lock, or with Wait queues the request when it conflicts.
+// Requests are granted in arrival order: a request may not overtake a
+// queued request it conflicts with, so writers are not starved by a stream
+// of readers. A request that would wait on itself, directly or through
+// other waiters, is refused as a deadlock.
 func (s *ExecutionLockStore) Acquire(in ExecutionLockAcquireInput) (ExecutionLock, error) {
 	key := normalizeLockKey(in.Key)
 	holder := strings.TrimSpace(in.Holder)
 	if key == "" {
 		return ExecutionLock{}, errors.New("key is required")
 	}
-	if holder == "" {
-		holder = "control-plane"
+	mode := strings.ToLower(strings.TrimSpace(in.Mode))
+	if mode == "" {
+		mode = ExecutionLockExclusive
+	}
+	if mode != ExecutionLockExclusive && mode != ExecutionLockShared {
+		return ExecutionLock{}, errors.New("mode must be exclusive or shared")
 	}
 	ttl := in.TTLSeconds
 	if ttl <= 0 {
 		ttl = 600
 	}
 	now := time.Now().UTC()
-	expires := now.Add(time.Duration(ttl) * time.Second)
+	req := &ExecutionLock{Key: key, Mode: mode, Holder: holder, ttl: time.Duration(ttl) * time.Second}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if current := s.byKey[key]; current != nil {
-		if current.Status == "active" && now.Before(current.ExpiresAt) {
-			return ExecutionLock{}, errors.New("execution lock already held for key")
-		}
-		if current.Status == "active" && !now.Before(current.ExpiresAt) {
-			current.Status = "expired"
-			current.ReleasedAt = now
-			s.history = append(s.history, cloneExecutionLock(*current))
+	granted := s.expireLocked(now)
+	blockers := s.blockersLocked(req, len(s.waiters))
+	if len(blockers) == 0 {
+		s.nextID++
+		req.ID = "exec-lock-" + itoa(s.nextID)
+		s.grantLocked(req, now)
+		out := cloneExecutionLock(*req)
+		s.mu.Unlock()
+		s.notifyGrants(granted)
+		return out, nil
+	}
+	if !in.Wait {
+		b := blockers[0]
+		s.mu.Unlock()
+		s.notifyGrants(granted)
+		if b.Status == "waiting" {
+			return ExecutionLock{}, errors.New("execution lock queued for key " + b.Key + " by " + lockHolderLabel(b.Holder) + "; retry with wait to join the queue")
 		}
+		return ExecutionLock{}, errors.New("execution lock already held for key " + b.Key + " by " + lockHolderLabel(b.Holder))
 	}
-	s.nextID++
-	item := &ExecutionLock{
-		ID:         "exec-lock-" + itoa(s.nextID),
-		Key:        key,
-		Holder:     holder,
-		AcquiredAt: now,
-		ExpiresAt:  expires,
-		Status:     "active",
+	if cycle := s.deadlockLocked(holder, blockers); len(cycle) > 0 {
+		s.mu.Unlock()
+		s.notifyGrants(granted)
+		return ExecutionLock{}, errors.New("deadlock detected: " + strings.Join(cycle, " -> "))
 	}
-	s.byKey[key] = item
-	return cloneExecutionLock(*item), nil
+	s.nextID++
+	req.ID = "exec-lock-" + itoa(s.nextID)
+	req.Status = "waiting"
+	req.QueuedAt = now
+	req.WaitingFor = lockIDs(blockers)
+	s.waiters = append(s.waiters, req)
+	out := cloneExecutionLock(*req)
+	s.mu.Unlock()
+	s.notifyGrants(granted)
+	return out, nil
 }
 
-func (s *ExecutionLockStore) BindJob(key, jobID string) (ExecutionLock, error) {
-	key = normalizeLockKey(key)
+// BindJob ties an active lock to a job so the lock is released when the job
+// finishes.
+func (s *ExecutionLockStore) BindJob(id, jobID string) (ExecutionLock, error) {
+	id = strings.TrimSpace(id)
 	jobID = strings.TrimSpace(jobID)
-	if key == "" || jobID == "" {
-		return ExecutionLock{}, errors.New("key and job_id are required")
+	if id == "" || jobID == "" {
+		return ExecutionLock{}, errors.New("lock id and job_id are required")
 	}
 	now := time.Now().UTC()
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	item := s.byKey[key]
-	if item == nil || item.Status != "active" {
+	item := s.active[id]
+	if item == nil {
+		s.mu.Unlock()
 		return ExecutionLock{}, errors.New("execution lock not active")
 	}
 	if !now.Before(item.ExpiresAt) {
-		item.Status = "expired"
-		item.ReleasedAt = now
-		s.history = append(s.history, cloneExecutionLock(*item))
+		granted := s.expireLocked(now)
+		s.mu.Unlock()
+		s.notifyGrants(granted)
 		return ExecutionLock{}, errors.New("execution lock expired")
 	}
 	item.JobID = jobID
-	s.byJob[jobID] = key
-	return cloneExecutionLock(*item), nil
+	s.byJob[jobID] = item.ID
+	out := cloneExecutionLock(*item)
+	s.mu.Unlock()
+	return out, nil
 }
 
 func (s *ExecutionLockStore) Release(in ExecutionLockReleaseInput) (ExecutionLock, bool) {
+	id := strings.TrimSpace(in.ID)
 	key := normalizeLockKey(in.Key)
 	jobID := strings.TrimSpace(in.JobID)
 	now := time.Now().UTC()
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if key == "" && jobID != "" {
-		key = s.byJob[jobID]
+	if id == "" && key == "" && jobID != "" {
+		id = s.byJob[jobID]
 	}
-	if key == "" {
-		return ExecutionLock{}, false
+	var item *ExecutionLock
+	switch {
+	case id != "":
+		item = s.active[id]
+		if item == nil {
+			canceled, ok := s.cancelWaiterLocked(id, now)
+			var granted []ExecutionLock
+			if ok {
+				granted = s.grantWaitersLocked(now)
+			}
+			s.mu.Unlock()
+			s.notifyGrants(granted)
+			return canceled, ok
+		}
+	case key != "":
+		item = s.activeByKeyLocked(key, strings.TrimSpace(in.Holder))
 	}
-	item := s.byKey[key]
 	if item == nil {
+		s.mu.Unlock()
 		return ExecutionLock{}, false
 	}
-	if item.Status == "active" {
-		item.Status = "released"
-		item.ReleasedAt = now
-	}
-	if item.JobID != "" {
-		delete(s.byJob, item.JobID)
-	}
-	released := cloneExecutionLock(*item)
-	s.history = append(s.history, released)
-	delete(s.byKey, key)
+	item.Status = "released"
+	item.ReleasedAt = now
+	released := s.retireLocked(item)
+	granted := s.grantWaitersLocked(now)
+	s.mu.Unlock()
+	s.notifyGrants(granted)
 	return released, true
 }
 
+func (s *ExecutionLockStore) cancelWaiterLocked(id string, now time.Time) (ExecutionLock, bool) {
+	for i, w := range s.waiters {
+		if w.ID != id {
+			continue
+		}
+		s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+		w.Status = "canceled"
+		w.ReleasedAt = now
+		w.WaitingFor = nil
+		canceled := cloneExecutionLock(*w)
+		s.history = append(s.history, canceled)
+		return canceled, true
+	}
+	return ExecutionLock{}, false
+}
+
 func (s *ExecutionLockStore) CleanupExpired() []ExecutionLock {
 	now := time.Now().UTC()
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	expired := make([]ExecutionLock, 0)
-	for key, item := range s.byKey {
-		if item.Status != "active" {
+	before := len(s.history)
+	granted := s.expireLocked(now)
+	expired := append([]ExecutionLock{}, s.history[before:]...)
+	s.mu.Unlock()
+	s.notifyGrants(granted)
+	sort.Slice(expired, func(i, j int) bool { return expired[i].Key < expired[j].Key })
+	return expired
+}
+
+// List returns active locks by key, then waiting requests in queue order,
+// then, with includeHistory, finished locks newest first.
+func (s *ExecutionLockStore) List(includeHistory bool) []ExecutionLock {
+	s.mu.RLock()
+	active := make([]ExecutionLock, 0, len(s.active))
+	for _, item := range s.active {
+		active = append(active, cloneExecutionLock(*item))
+	}
+	waiting := make([]ExecutionLock, 0, len(s.waiters))
+	for _, item := range s.waiters {
+		waiting = append(waiting, cloneExecutionLock(*item))
+	}
+	history := append([]ExecutionLock{}, s.history...)
+	s.mu.RUnlock()
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Key != active[j].Key {
+			return active[i].Key < active[j].Key
+		}
+		return active[i].AcquiredAt.Before(active[j].AcquiredAt)
+	})
+	out := append(active, waiting...)
+	if !includeHistory {
+		return out
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].ReleasedAt.After(history[j].ReleasedAt) })
+	return append(out, history...)
+}
+
+// WaitForGraph returns who each waiting request is queued behind.
+func (s *ExecutionLockStore) WaitForGraph() []ExecutionLockWaitEdge {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]ExecutionLockWaitEdge, 0)
+	for i, w := range s.waiters {
+		for _, b := range s.blockersLocked(w, i) {
+			out = append(out, ExecutionLockWaitEdge{
+				Waiter:         w.Holder,
+				WaiterLockID:   w.ID,
+				Holder:         b.Holder,
+				BlockingLockID: b.ID,
+				Key:            b.Key,
+			})
+		}
+	}
+	return out
+}
+
+// blockersLocked returns the active locks and the first ahead queued
+// requests that conflict with req.
+func (s *ExecutionLockStore) blockersLocked(req *ExecutionLock, ahead int) []*ExecutionLock {
+	out := make([]*ExecutionLock, 0)
+	for _, item := range s.active {
+		if locksConflict(req, item) {
+			out = append(out, item)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AcquiredAt.Before(out[j].AcquiredAt) })
+	for _, w := range s.waiters[:ahead] {
+		if w != req && locksConflict(req, w) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// deadlockLocked reports the cycle that would close if holder waited on
+// blockers, following the holders that waiting requests are queued behind.
+// Requests without a holder are unknown parties that cannot be told apart,
+// so they never take part in a cycle.
+func (s *ExecutionLockStore) deadlockLocked(holder string, blockers []*ExecutionLock) []string {
+	if holder == "" {
+		return nil
+	}
+	edges := map[string][]string{}
+	for i, w := range s.waiters {
+		if w.Holder == "" {
+			continue
+		}
+		for _, b := range s.blockersLocked(w, i) {
+			if b.Holder != "" {
+				edges[w.Holder] = append(edges[w.Holder], b.Holder)
+			}
+		}
+	}
+	visited := map[string]bool{}
+	var walk func(node string, path []string) []string
+	walk = func(node string, path []string) []string {
+		path = append(path, node)
+		if node == holder {
+			return path
+		}
+		if visited[node] {
+			return nil
+		}
+		visited[node] = true
+		for _, next := range edges[node] {
+			if cycle := walk(next, path); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	for _, b := range blockers {
+		if b.Holder == "" {
+			continue
+		}
+		if cycle := walk(b.Holder, []string{holder}); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func lockHolderLabel(holder string) string {
+	if holder == "" {
+		return "an unknown holder"
+	}
+	return holder
+}
+
+// grantWaitersLocked grants queued requests in arrival order once nothing
+// active or queued ahead of them conflicts.
+func (s *ExecutionLockStore) grantWaitersLocked(now time.Time) []ExecutionLock {
+	var granted []ExecutionLock
+	for i := 0; i < len(s.waiters); {
+		w := s.waiters[i]
+		blockers := s.blockersLocked(w, i)
+		if len(blockers) > 0 {
+			w.WaitingFor = lockIDs(blockers)
+			i++
 			continue
 		}
+		s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+		w.WaitingFor = nil
+		s.grantLocked(w, now)
+		granted = append(granted, cloneExecutionLock(*w))
+	}
+	return granted
+}
+
+func (s *ExecutionLockStore) grantLocked(item *ExecutionLock, now time.Time) {
+	item.Status = "active"
+	item.AcquiredAt = now
+	item.ExpiresAt = now.Add(item.ttl)
+	s.active[item.ID] = item
+}
+
+// expireLocked retires active locks past their expiry and grants the
+// requests they were holding up.
+func (s *ExecutionLockStore) expireLocked(now time.Time) []ExecutionLock {
+	expired := false
+	for _, item := range s.active {
 		if now.Before(item.ExpiresAt) {
 			continue
 		}
 		item.Status = "expired"
 		item.ReleasedAt = now
-		expired = append(expired, cloneExecutionLock(*item))
-		s.history = append(s.history, cloneExecutionLock(*item))
-		if item.JobID != "" {
-			delete(s.byJob, item.JobID)
+		s.retireLocked(item)
+		expired = true
+	}
+	if !expired {
+		return nil
+	}
+	return s.grantWaitersLocked(now)
+}
+
+func (s *ExecutionLockStore) retireLocked(item *ExecutionLock) ExecutionLock {
+	if item.JobID != "" {
+		delete(s.byJob, item.JobID)
+	}
+	delete(s.active, item.ID)
+	out := cloneExecutionLock(*item)
+	s.history = append(s.history, out)
+	return out
+}
+
+// activeByKeyLocked returns the oldest active lock on exactly key, held by
+// holder when one is given.
+func (s *ExecutionLockStore) activeByKeyLocked(key, holder string) *ExecutionLock {
+	var out *ExecutionLock
+	for _, item := range s.active {
+		if item.Key != key || (holder != "" && item.Holder != holder) {
+			continue
+		}
+		if out == nil || item.AcquiredAt.Before(out.AcquiredAt) || (item.AcquiredAt.Equal(out.AcquiredAt) && item.ID < out.ID) {
+			out = item
 		}
-		delete(s.byKey, key)
 	}
-	sort.Slice(expired, func(i, j int) bool { return expired[i].Key < expired[j].Key })
-	return expired
+	return out
 }
 
-func (s *ExecutionLockStore) List(includeHistory bool) []ExecutionLock {
-	s.mu.RLock()
-	active := make([]ExecutionLock, 0, len(s.byKey))
-	for _, item := range s.byKey {
-		active = append(active, cloneExecutionLock(*item))
+func (s *ExecutionLockStore) notifyGrants(granted []ExecutionLock) {
+	if len(granted) == 0 {
+		return
 	}
-	history := append([]ExecutionLock{}, s.history...)
+	s.mu.RLock()
+	fns := append([]func(ExecutionLock){}, s.onGrant...)
 	s.mu.RUnlock()
-	sort.Slice(active, func(i, j int) bool { return active[i].Key < active[j].Key })
-	if !includeHistory {
-		return active
+	for _, lock := range granted {
+		for _, fn := range fns {
+			fn(lock)
+		}
 	}
-	sort.Slice(history, func(i, j int) bool { return history[i].ReleasedAt.After(history[j].ReleasedAt) })
-	return append(active, history...)
 }
 
+func locksConflict(a, b *ExecutionLock) bool {
+	if a.Mode == ExecutionLockShared && b.Mode == ExecutionLockShared {
+		return false
+	}
+	return lockKeysOverlap(a.Key, b.Key)
+}
+
+// lockKeysOverlap reports whether one key is the other or one of its
+// ancestors.
+func lockKeysOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+func lockIDs(items []*ExecutionLock) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.ID)
+	}
+	return out
+}
+
+// normalizeLockKey lowercases a key and drops empty path segments, so
+// "/Prod//api/" and "prod/api" name the same lock.
 func normalizeLockKey(in string) string {
-	return strings.ToLower(strings.TrimSpace(in))
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(in)), "/")
+	out := parts[:0]
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return strings.Join(out, "/")
 }
 
 func cloneExecutionLock(in ExecutionLock) ExecutionLock {
-	return in
+	out := in
+	out.WaitingFor = append([]string(nil), in.WaitingFor...)
+	return out
 }
@@ -1,6 +1,7 @@
 package control
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -29,7 +30,7 @@ func TestExecutionLockBindAndCleanupExpired(t *testing.T) {
 	if err != nil {
 		t.Fatalf("acquire lock: %v", err)
 	}
-	if _, err := store.BindJob(lock.Key, "job-123"); err != nil {
+	if _, err := store.BindJob(lock.ID, "job-123"); err != nil {
 		t.Fatalf("bind job: %v", err)
 	}
 	if _, ok := store.Release(ExecutionLockReleaseInput{JobID: "job-123"}); !ok {
@@ -46,3 +47,105 @@ func TestExecutionLockBindAndCleanupExpired(t *testing.T) {
 		t.Fatalf("expected expired lock cleanup")
 	}
 }
+
+func TestExecutionLockHierarchyAndModes(t *testing.T) {
+	store := NewExecutionLockStore()
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod", Holder: "a", Mode: "read"}); err == nil {
+		t.Fatalf("expected unknown mode to be rejected")
+	}
+	reader, err := store.Acquire(ExecutionLockAcquireInput{Key: "/Prod//api/", Holder: "a", Mode: "shared"})
+	if err != nil || reader.Key != "prod/api" {
+		t.Fatalf("expected normalized shared lock, got %+v err=%v", reader, err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod", Holder: "b", Mode: "shared"}); err != nil {
+		t.Fatalf("expected shared locks on overlapping keys to coexist: %v", err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api/host-1", Holder: "c"}); err == nil {
+		t.Fatalf("expected exclusive lock below a shared ancestor to conflict")
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/db", Holder: "c", Mode: "shared"}); err != nil {
+		t.Fatalf("expected shared lock on a sibling to be granted: %v", err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "staging/api", Holder: "c"}); err != nil {
+		t.Fatalf("expected exclusive lock on another cluster to be granted: %v", err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prodx", Holder: "c"}); err != nil {
+		t.Fatalf("expected key sharing only a prefix to be independent: %v", err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "staging", Holder: "d", Mode: "shared"}); err == nil {
+		t.Fatalf("expected shared ancestor of an exclusive lock to conflict")
+	}
+	if _, ok := store.Release(ExecutionLockReleaseInput{Key: "prod/api", Holder: "b"}); ok {
+		t.Fatalf("expected release by key to respect holder")
+	}
+	if released, ok := store.Release(ExecutionLockReleaseInput{Key: "prod/api", Holder: "a"}); !ok || released.ID != reader.ID {
+		t.Fatalf("expected holder's lock to be released, got %+v", released)
+	}
+}
+
+func TestExecutionLockQueueFairnessAndDeadlock(t *testing.T) {
+	store := NewExecutionLockStore()
+	var granted []string
+	store.OnGrant(func(lock ExecutionLock) { granted = append(granted, lock.Holder) })
+
+	r1, _ := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api", Holder: "reader-1", Mode: "shared"})
+	writer, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod", Holder: "writer", Wait: true})
+	if err != nil || writer.Status != "waiting" || len(writer.WaitingFor) != 1 || writer.WaitingFor[0] != r1.ID {
+		t.Fatalf("expected writer to queue behind reader-1, got %+v err=%v", writer, err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api", Holder: "reader-2", Mode: "shared"}); err == nil {
+		t.Fatalf("expected a new reader not to overtake the queued writer")
+	}
+	r2, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api", Holder: "reader-2", Mode: "shared", Wait: true})
+	if err != nil || r2.Status != "waiting" {
+		t.Fatalf("expected reader-2 to queue, got %+v err=%v", r2, err)
+	}
+	if edges := store.WaitForGraph(); len(edges) != 2 || edges[0].Waiter != "writer" || edges[1].Holder != "writer" {
+		t.Fatalf("unexpected wait-for graph: %+v", edges)
+	}
+
+	// reader-1 asking for what writer waits on would close a cycle.
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/db", Holder: "reader-1", Wait: true}); err == nil || !strings.Contains(err.Error(), "deadlock detected: reader-1 -> writer -> reader-1") {
+		t.Fatalf("expected deadlock to be detected, got %v", err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api", Holder: "reader-1", Wait: true}); err == nil {
+		t.Fatalf("expected waiting on its own lock to be a deadlock")
+	}
+
+	store.Release(ExecutionLockReleaseInput{ID: r1.ID})
+	if len(granted) != 1 || granted[0] != "writer" {
+		t.Fatalf("expected writer to be granted first, got %+v", granted)
+	}
+	store.Release(ExecutionLockReleaseInput{Key: "prod"})
+	if len(granted) != 2 || granted[1] != "reader-2" {
+		t.Fatalf("expected reader-2 to be granted after the writer, got %+v", granted)
+	}
+
+	blocked, _ := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api/host-1", Holder: "writer-2", Wait: true})
+	canceled, ok := store.Release(ExecutionLockReleaseInput{ID: blocked.ID})
+	if !ok || canceled.Status != "canceled" || len(store.WaitForGraph()) != 0 {
+		t.Fatalf("expected queued request to be canceled, got %+v", canceled)
+	}
+}
+
+func TestExecutionLockAnonymousHoldersAreNotDeadlocks(t *testing.T) {
+	store := NewExecutionLockStore()
+	first, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api"})
+	if err != nil || first.Holder != "" {
+		t.Fatalf("expected anonymous lock to keep an empty holder, got %+v err=%v", first, err)
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/db"}); err != nil {
+		t.Fatalf("second anonymous lock failed: %v", err)
+	}
+	// Two unrelated anonymous callers waiting on each other's keys must not
+	// be mistaken for one holder waiting on itself.
+	for _, key := range []string{"prod/db", "prod/api"} {
+		waiting, err := store.Acquire(ExecutionLockAcquireInput{Key: key, Wait: true})
+		if err != nil || waiting.Status != "waiting" {
+			t.Fatalf("expected anonymous request for %s to queue, got %+v err=%v", key, waiting, err)
+		}
+	}
+	if _, err := store.Acquire(ExecutionLockAcquireInput{Key: "prod/api"}); err == nil || !strings.Contains(err.Error(), "an unknown holder") {
+		t.Fatalf("expected conflict to name an unknown holder, got %v", err)
+	}
+}
@@ -36,6 +36,9 @@ type CollectorResult struct {
 	Terms    []CollectorTerm    `json:"terms"`
 	Count    int                `json:"count"`
 	Items    []ExportedResource `json:"items"`
+	// BypassedHosts are exporting hosts left out because they are excluded,
+	// e.g. in maintenance, so collectors route to the remaining exporters.
+	BypassedHosts []string `json:"bypassed_hosts,omitempty"`
 }
 
 type ExportedResourceStore struct {
@@ -44,6 +47,7 @@ type ExportedResourceStore struct {
 	max     int
 	items   map[string]ExportedResource
 	ordered []string
+	exclude func(host string) bool
 }
 
 func NewExportedResourceStore(max int) *ExportedResourceStore {
@@ -79,6 +83,54 @@ func (s *ExportedResourceStore) Add(in ExportedResourceInput) (ExportedResource,
 	return item, nil
 }
 
+// ReplaceHostExports swaps every export previously recorded for host from
+// source with inputs, so a node's latest run is the source of truth for
+// what it exports.
+func (s *ExportedResourceStore) ReplaceHostExports(host, source string, inputs []ExportedResourceInput) ([]ExportedResource, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	source = strings.ToLower(strings.TrimSpace(source))
+	if host == "" {
+		return nil, errors.New("host is required")
+	}
+	now := time.Now().UTC()
+	items := make([]ExportedResource, 0, len(inputs))
+	for _, in := range inputs {
+		typ := strings.ToLower(strings.TrimSpace(in.Type))
+		if typ == "" {
+			return nil, errors.New("type is required")
+		}
+		items = append(items, ExportedResource{
+			Type:       typ,
+			Host:       host,
+			ResourceID: strings.ToLower(strings.TrimSpace(in.ResourceID)),
+			Source:     source,
+			Attributes: normalizeStringMap(in.Attributes),
+			CreatedAt:  now,
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.ordered[:0]
+	for _, id := range s.ordered {
+		item := s.items[id]
+		if item.Host == host && item.Source == source {
+			delete(s.items, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.ordered = kept
+	for i := range items {
+		s.nextID++
+		items[i].ID = "xres-" + itoa(s.nextID)
+		s.items[items[i].ID] = cloneExportedResource(items[i])
+		s.ordered = append(s.ordered, items[i].ID)
+	}
+	s.trimLocked()
+	return items, nil
+}
+
 func (s *ExportedResourceStore) List(limit int) []ExportedResource {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -92,6 +144,14 @@ func (s *ExportedResourceStore) List(limit int) []ExportedResource {
 	return out
 }
 
+// SetHostExclusion sets a predicate for exporting hosts whose resources
+// collectors should skip.
+func (s *ExportedResourceStore) SetHostExclusion(fn func(host string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exclude = fn
+}
+
 func (s *ExportedResourceStore) Collect(selector string, limit int) (CollectorResult, error) {
 	terms, err := parseCollectorSelector(selector)
 	if err != nil {
@@ -102,24 +162,35 @@ func (s *ExportedResourceStore) Collect(selector string, limit int) (CollectorRe
 	for _, id := range s.ordered {
 		items = append(items, cloneExportedResource(s.items[id]))
 	}
+	exclude := s.exclude
 	s.mu.RUnlock()
 	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) })
 
 	out := make([]ExportedResource, 0)
+	bypassed := map[string]struct{}{}
 	for _, item := range items {
 		if matchesCollector(item, terms) {
+			if exclude != nil && item.Host != "" && exclude(item.Host) {
+				bypassed[item.Host] = struct{}{}
+				continue
+			}
 			out = append(out, item)
 		}
 		if limit > 0 && len(out) >= limit {
 			break
 		}
 	}
-	return CollectorResult{
+	result := CollectorResult{
 		Selector: strings.TrimSpace(selector),
 		Terms:    terms,
 		Count:    len(out),
 		Items:    out,
-	}, nil
+	}
+	for host := range bypassed {
+		result.BypassedHosts = append(result.BypassedHosts, host)
+	}
+	sort.Strings(result.BypassedHosts)
+	return result, nil
 }
 
 func parseCollectorSelector(selector string) ([]CollectorTerm, error) {
@@ -173,6 +244,10 @@ func matchesCollector(item ExportedResource, terms []CollectorTerm) bool {
 			if item.Source != val {
 				return false
 			}
+		case key == "tag":
+			if !exportedResourceTagged(item, val) {
+				return false
+			}
 		case strings.HasPrefix(key, "attrs."):
 			attr := strings.TrimPrefix(key, "attrs.")
 			if strings.TrimSpace(item.Attributes[attr]) != val {
@@ -192,6 +267,15 @@ func matchesCollector(item ExportedResource, terms []CollectorTerm) bool {
 	return true
 }
 
+func exportedResourceTagged(item ExportedResource, tag string) bool {
+	for _, t := range strings.Split(item.Attributes["tags"], ",") {
+		if strings.ToLower(strings.TrimSpace(t)) == tag {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *ExportedResourceStore) trimLocked() {
 	if s.max <= 0 || len(s.ordered) <= s.max {
 		return
@@ -0,0 +1,201 @@
+package control
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// ExportedRunSource tags exports published by converges so a node's next run
+// replaces them without touching exports recorded through the API.
+const ExportedRunSource = "run"
+
+type CollectedResource struct {
+	ExportID   string `json:"export_id"`
+	Exporter   string `json:"exporter"`
+	Collector  string `json:"collector"`
+	ResourceID string `json:"resource_id"`
+	Selector   string `json:"selector"`
+}
+
+type ExportRealization struct {
+	Published []ExportedResource  `json:"published"`
+	Collected []CollectedResource `json:"collected"`
+	Skipped   []string            `json:"skipped,omitempty"`
+}
+
+// RealizeExportedResources implements Puppet-style exported resources for a
+// run: resources marked export are published for their host and removed from
+// the local catalog, then each collector pulls matching exports from every
+// node into the catalog of its own host. cfg is modified in place.
+func RealizeExportedResources(cfg *config.Config, store *ExportedResourceStore) (ExportRealization, error) {
+	out := ExportRealization{Published: []ExportedResource{}, Collected: []CollectedResource{}}
+	if cfg == nil {
+		return out, errors.New("config is required")
+	}
+	if store == nil {
+		return out, errors.New("exported resource store is required")
+	}
+
+	exports := map[string][]ExportedResourceInput{}
+	local := make([]config.Resource, 0, len(cfg.Resources))
+	for _, res := range cfg.Resources {
+		if !res.Export {
+			local = append(local, res)
+			continue
+		}
+		host := strings.ToLower(strings.TrimSpace(res.Host))
+		exports[host] = append(exports[host], ExportedResourceInput{
+			Type:       res.Type,
+			Host:       host,
+			ResourceID: res.ID,
+			Source:     ExportedRunSource,
+			Attributes: exportedResourceAttributes(res),
+		})
+	}
+	cfg.Resources = local
+
+	// Every host converging in this run republishes, so exports dropped from
+	// its catalog disappear from collectors on their next run.
+	hosts := make([]string, 0, len(cfg.Inventory.Hosts))
+	for _, h := range cfg.Inventory.Hosts {
+		hosts = append(hosts, strings.ToLower(strings.TrimSpace(h.Name)))
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		items, err := store.ReplaceHostExports(host, ExportedRunSource, exports[host])
+		if err != nil {
+			return out, err
+		}
+		out.Published = append(out.Published, items...)
+	}
+
+	ids := map[string]struct{}{}
+	for _, res := range cfg.Resources {
+		ids[res.ID] = struct{}{}
+	}
+	for _, collector := range cfg.Collect {
+		result, err := store.Collect(collector.Selector, 0)
+		if err != nil {
+			return out, errors.New("collect " + strconv.Quote(collector.Selector) + ": " + err.Error())
+		}
+		seen := map[string]struct{}{}
+		for _, item := range result.Items {
+			key := item.Type + "|" + item.Host + "|" + item.ResourceID
+			if item.ResourceID == "" {
+				key = item.ID
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			res := realizeExportedResource(item, collector.Host)
+			if _, ok := ids[res.ID]; ok {
+				out.Skipped = append(out.Skipped, res.ID)
+				continue
+			}
+			ids[res.ID] = struct{}{}
+			cfg.Resources = append(cfg.Resources, res)
+			out.Collected = append(out.Collected, CollectedResource{
+				ExportID:   item.ID,
+				Exporter:   item.Host,
+				Collector:  collector.Host,
+				ResourceID: res.ID,
+				Selector:   collector.Selector,
+			})
+		}
+	}
+	return out, nil
+}
+
+// exportedResourceFields maps the json names of scalar config.Resource fields
+// that travel with an export; identity and relationship fields do not.
+func exportedResourceFields() map[string]int {
+	skip := map[string]struct{}{
+		"id": {}, "type": {}, "host": {}, "export": {}, "delegate_to": {},
+		"depends_on": {}, "require": {}, "before": {}, "notify": {}, "subscribe": {},
+		"notify_handlers": {}, "matrix": {}, "loop": {}, "loop_var": {},
+	}
+	out := map[string]int{}
+	t := reflect.TypeOf(config.Resource{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if _, ok := skip[name]; ok {
+			continue
+		}
+		out[name] = i
+	}
+	return out
+}
+
+func exportedResourceAttributes(res config.Resource) map[string]string {
+	out := map[string]string{}
+	v := reflect.ValueOf(res)
+	for name, idx := range exportedResourceFields() {
+		f := v.Field(idx)
+		switch f.Kind() {
+		case reflect.String:
+			if f.String() != "" {
+				out[name] = f.String()
+			}
+		case reflect.Bool:
+			if f.Bool() {
+				out[name] = "true"
+			}
+		case reflect.Int:
+			if f.Int() != 0 {
+				out[name] = strconv.FormatInt(f.Int(), 10)
+			}
+		case reflect.Slice:
+			if f.Type().Elem().Kind() == reflect.String && f.Len() > 0 {
+				out[name] = strings.Join(f.Interface().([]string), ",")
+			}
+		}
+	}
+	return out
+}
+
+func realizeExportedResource(item ExportedResource, host string) config.Resource {
+	id := item.ResourceID
+	if id == "" {
+		id = item.ID
+	}
+	if item.Host != "" {
+		id = item.Host + "/" + id
+	}
+	res := config.Resource{ID: "exported:" + id, Type: item.Type, Host: host}
+	v := reflect.ValueOf(&res).Elem()
+	for name, idx := range exportedResourceFields() {
+		raw, ok := item.Attributes[name]
+		if !ok {
+			continue
+		}
+		f := v.Field(idx)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(raw)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err == nil {
+				f.SetBool(b)
+			}
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err == nil {
+				f.SetInt(int64(n))
+			}
+		case reflect.Slice:
+			if f.Type().Elem().Kind() == reflect.String {
+				f.Set(reflect.ValueOf(normalizeStringSlice(strings.Split(raw, ","))))
+			}
+		}
+	}
+	return res
+}
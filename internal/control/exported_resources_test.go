@@ -1,6 +1,10 @@
 package control
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
 
 func TestExportedResourceStoreCollect(t *testing.T) {
 	store := NewExportedResourceStore(100)
@@ -47,3 +51,47 @@ func TestExportedResourceStoreCollectRejectsInvalidSelector(t *testing.T) {
 		t.Fatalf("expected invalid selector to fail")
 	}
 }
+
+func TestRealizeExportedResources(t *testing.T) {
+	store := NewExportedResourceStore(100)
+	if _, err := store.Add(ExportedResourceInput{
+		Type:       "file",
+		Host:       "db-01",
+		ResourceID: "backup-target",
+		Source:     "api",
+		Attributes: map[string]string{"path": "/etc/backup/db-01", "tags": "backup", "mode": "0640"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Version:   "v0",
+		Inventory: config.Inventory{Hosts: []config.Host{{Name: "web-01"}, {Name: "backup"}}},
+		Resources: []config.Resource{
+			{ID: "check", Type: "command", Host: "web-01", Command: "true", Export: true, Tags: []string{"monitoring"}},
+			{ID: "app", Type: "file", Host: "web-01", Path: "/tmp/app"},
+		},
+		Collect: []config.Collector{{Host: "backup", Selector: "tag=backup"}},
+	}
+	out, err := RealizeExportedResources(cfg, store)
+	if err != nil {
+		t.Fatalf("realize failed: %v", err)
+	}
+	if len(out.Published) != 1 || out.Published[0].Attributes["command"] != "true" || out.Published[0].Attributes["tags"] != "monitoring" {
+		t.Fatalf("unexpected published exports %#v", out.Published)
+	}
+	if len(out.Collected) != 1 || out.Collected[0].Exporter != "db-01" {
+		t.Fatalf("unexpected collected resources %#v", out.Collected)
+	}
+	if len(cfg.Resources) != 2 || cfg.Resources[0].ID != "app" {
+		t.Fatalf("expected export removed and collected resource appended, got %#v", cfg.Resources)
+	}
+	got := cfg.Resources[1]
+	if got.ID != "exported:db-01/backup-target" || got.Host != "backup" || got.Path != "/etc/backup/db-01" || got.Mode != "0640" {
+		t.Fatalf("unexpected realized resource %#v", got)
+	}
+
+	monitoring, err := store.Collect("tag=monitoring", 0)
+	if err != nil || monitoring.Count != 1 {
+		t.Fatalf("expected tag selector to match published export, got %#v err=%v", monitoring, err)
+	}
+}
@@ -0,0 +1,206 @@
+package control
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FactMineFunction declares a value agents publish to the mine. Fact is the
+// dot-path extracted from published facts when an explicit value is absent.
+type FactMineFunction struct {
+	Name            string    `json:"name"`
+	Fact            string    `json:"fact,omitempty"`
+	IntervalSeconds int       `json:"interval_seconds"`
+	TTLSeconds      int       `json:"ttl_seconds"`
+	Targets         string    `json:"targets,omitempty"` // node glob; empty means all nodes
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type FactMinePublishInput struct {
+	Node   string         `json:"node"`
+	Values map[string]any `json:"values,omitempty"`
+	Facts  map[string]any `json:"facts,omitempty"`
+}
+
+type FactMineEntry struct {
+	Node      string    `json:"node"`
+	Function  string    `json:"function"`
+	Value     any       `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type FactMinePublishResult struct {
+	Node     string          `json:"node"`
+	Accepted []FactMineEntry `json:"accepted"`
+	Ignored  []string        `json:"ignored,omitempty"`
+}
+
+type FactMineStore struct {
+	mu        sync.RWMutex
+	functions map[string]FactMineFunction
+	entries   map[string]map[string]FactMineEntry // function -> node -> entry
+}
+
+func NewFactMineStore() *FactMineStore {
+	return &FactMineStore{
+		functions: map[string]FactMineFunction{},
+		entries:   map[string]map[string]FactMineEntry{},
+	}
+}
+
+func (s *FactMineStore) UpsertFunction(in FactMineFunction) (FactMineFunction, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return FactMineFunction{}, errors.New("name is required")
+	}
+	targets := strings.TrimSpace(in.Targets)
+	if targets != "" {
+		if _, err := path.Match(targets, ""); err != nil {
+			return FactMineFunction{}, errors.New("targets must be a valid node glob")
+		}
+	}
+	if in.IntervalSeconds <= 0 {
+		in.IntervalSeconds = 60
+	}
+	if in.TTLSeconds <= 0 {
+		in.TTLSeconds = in.IntervalSeconds * 3
+	}
+	item := FactMineFunction{
+		Name:            name,
+		Fact:            strings.TrimSpace(in.Fact),
+		IntervalSeconds: in.IntervalSeconds,
+		TTLSeconds:      in.TTLSeconds,
+		Targets:         targets,
+		UpdatedAt:       time.Now().UTC(),
+	}
+	s.mu.Lock()
+	s.functions[name] = item
+	s.mu.Unlock()
+	return item, nil
+}
+
+func (s *FactMineStore) DeleteFunction(name string) bool {
+	name = strings.TrimSpace(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.functions[name]; !ok {
+		return false
+	}
+	delete(s.functions, name)
+	delete(s.entries, name)
+	return true
+}
+
+// Functions lists mine functions, limited to those targeting node when node
+// is non-empty, so an agent can fetch exactly what it should publish.
+func (s *FactMineStore) Functions(node string) []FactMineFunction {
+	node = normalizeFactNode(node)
+	s.mu.RLock()
+	out := make([]FactMineFunction, 0, len(s.functions))
+	for _, fn := range s.functions {
+		if node != "" && !factMineTargets(fn.Targets, node) {
+			continue
+		}
+		out = append(out, fn)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Publish stores mine values for a node. Values keyed by function name take
+// precedence; otherwise the function's fact path is read from Facts.
+// Unknown functions and functions not targeting the node are ignored.
+func (s *FactMineStore) Publish(in FactMinePublishInput) (FactMinePublishResult, error) {
+	node := normalizeFactNode(in.Node)
+	if node == "" {
+		return FactMinePublishResult{}, errors.New("node is required")
+	}
+	now := time.Now().UTC()
+	out := FactMinePublishResult{Node: node, Accepted: []FactMineEntry{}}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range in.Values {
+		if _, ok := s.functions[name]; !ok {
+			out.Ignored = append(out.Ignored, name)
+		}
+	}
+	for name, fn := range s.functions {
+		if !factMineTargets(fn.Targets, node) {
+			continue
+		}
+		value, ok := in.Values[name]
+		if !ok && fn.Fact != "" && in.Facts != nil {
+			value, ok = lookupFactField(in.Facts, fn.Fact)
+		}
+		if !ok {
+			continue
+		}
+		entry := FactMineEntry{
+			Node:      node,
+			Function:  name,
+			Value:     cloneRoleEnvAny(value),
+			UpdatedAt: now,
+			ExpiresAt: now.Add(time.Duration(fn.TTLSeconds) * time.Second),
+		}
+		if s.entries[name] == nil {
+			s.entries[name] = map[string]FactMineEntry{}
+		}
+		s.entries[name][node] = entry
+		out.Accepted = append(out.Accepted, entry)
+	}
+	sort.Strings(out.Ignored)
+	sort.Slice(out.Accepted, func(i, j int) bool { return out.Accepted[i].Function < out.Accepted[j].Function })
+	return out, nil
+}
+
+// Get returns unexpired mine entries for function from nodes matching the
+// target glob (empty target matches every node), ordered by node name.
+func (s *FactMineStore) Get(function, target string) []FactMineEntry {
+	function = strings.TrimSpace(function)
+	target = strings.ToLower(strings.TrimSpace(target))
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]FactMineEntry, 0)
+	for node, entry := range s.entries[function] {
+		if entry.ExpiresAt.Before(now) {
+			delete(s.entries[function], node)
+			continue
+		}
+		if !factMineTargets(target, node) {
+			continue
+		}
+		entry.Value = cloneRoleEnvAny(entry.Value)
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Node < out[j].Node })
+	return out
+}
+
+// TemplateLookup adapts the mine for template rendering ({{ mine "fn" "web-*" }}).
+func (s *FactMineStore) TemplateLookup() TemplateMineLookup {
+	return func(function, target string) []string {
+		entries := s.Get(function, target)
+		out := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, factValueString(entry.Value))
+		}
+		return out
+	}
+}
+
+func factMineTargets(glob, node string) bool {
+	glob = strings.ToLower(strings.TrimSpace(glob))
+	if glob == "" || glob == "*" {
+		return true
+	}
+	ok, err := path.Match(glob, node)
+	return err == nil && ok
+}
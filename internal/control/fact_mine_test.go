@@ -0,0 +1,74 @@
+package control
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFactMinePublishAndGet(t *testing.T) {
+	store := NewFactMineStore()
+	if _, err := store.UpsertFunction(FactMineFunction{Name: "network.ip", Fact: "network.primary_ip", IntervalSeconds: 30}); err != nil {
+		t.Fatalf("upsert function failed: %v", err)
+	}
+	fn, err := store.UpsertFunction(FactMineFunction{Name: "nginx.version", Targets: "web-*"})
+	if err != nil {
+		t.Fatalf("upsert targeted function failed: %v", err)
+	}
+	if fn.IntervalSeconds != 60 || fn.TTLSeconds != 180 {
+		t.Fatalf("unexpected defaults %+v", fn)
+	}
+	if _, err := store.UpsertFunction(FactMineFunction{Name: "bad", Targets: "["}); err == nil {
+		t.Fatalf("expected invalid glob to be rejected")
+	}
+	if got := store.Functions("db-01"); len(got) != 1 || got[0].Name != "network.ip" {
+		t.Fatalf("expected only untargeted function for db-01, got %+v", got)
+	}
+
+	res, err := store.Publish(FactMinePublishInput{
+		Node:   "WEB-01",
+		Values: map[string]any{"nginx.version": "1.25", "unknown": 1},
+		Facts:  map[string]any{"network": map[string]any{"primary_ip": "10.0.0.1"}},
+	})
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if len(res.Accepted) != 2 || len(res.Ignored) != 1 || res.Ignored[0] != "unknown" {
+		t.Fatalf("unexpected publish result %+v", res)
+	}
+	if _, err := store.Publish(FactMinePublishInput{Node: "web-02", Facts: map[string]any{"network": map[string]any{"primary_ip": "10.0.0.2"}}}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = store.Publish(FactMinePublishInput{Node: "db-01", Values: map[string]any{"nginx.version": "1.0", "network.ip": "10.0.1.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Accepted) != 1 {
+		t.Fatalf("expected untargeted node to skip nginx.version, got %+v", res)
+	}
+
+	web := store.Get("network.ip", "web-*")
+	if len(web) != 2 || web[0].Node != "web-01" || web[1].Value != "10.0.0.2" {
+		t.Fatalf("unexpected web mine entries %+v", web)
+	}
+	if all := store.Get("network.ip", ""); len(all) != 3 {
+		t.Fatalf("expected all nodes for empty target, got %+v", all)
+	}
+
+	store.mu.Lock()
+	entry := store.entries["network.ip"]["web-02"]
+	entry.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	store.entries["network.ip"]["web-02"] = entry
+	store.mu.Unlock()
+	if web := store.Get("network.ip", "web-*"); len(web) != 1 {
+		t.Fatalf("expected expired entry to be dropped, got %+v", web)
+	}
+
+	rendered, missing := RenderTemplateTextWithMine(`upstreams={{ mine "network.ip" "*" " " }} missing={{ mine "nope" "web-*" }}`, nil, false, store.TemplateLookup())
+	if !strings.HasPrefix(rendered, "upstreams=10.0.1.1 10.0.0.1 missing=") {
+		t.Fatalf("unexpected rendered template %q", rendered)
+	}
+	if len(missing) != 1 || missing[0] != "mine:nope:web-*" {
+		t.Fatalf("expected missing mine reference, got %v", missing)
+	}
+}
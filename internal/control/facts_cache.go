@@ -138,6 +138,32 @@ func (c *FactCache) Query(q FactCacheQuery) []FactRecord {
 	return out
 }
 
+// PlanFacts returns the unexpired facts of each cached node flattened to
+// dotted keys, the shape planner.BuildWithFacts evaluates when conditions on.
+func (c *FactCache) PlanFacts() map[string]map[string]string {
+	out := map[string]map[string]string{}
+	for _, item := range c.List() {
+		flat := map[string]string{}
+		flattenFacts("", item.Facts, flat)
+		out[item.Node] = flat
+	}
+	return out
+}
+
+func flattenFacts(prefix string, data map[string]any, out map[string]string) {
+	for key, value := range data {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flattenFacts(path, nested, out)
+			continue
+		}
+		out[path] = factValueString(value)
+	}
+}
+
 func normalizeFactNode(raw string) string {
 	return strings.ToLower(strings.TrimSpace(raw))
 }
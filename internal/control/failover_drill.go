@@ -9,24 +9,32 @@ import (
 )
 
 type RegionalFailoverDrillInput struct {
+	// Kind is "failover" for region failover drills and "backup-restore" for
+	// restore-verification drills of scheduled backups.
+	Kind                string `json:"kind,omitempty"`
 	Region              string `json:"region"`
 	TargetRTOSeconds    int    `json:"target_rto_seconds"`
 	SimulatedRecoveryMs int64  `json:"simulated_recovery_ms"`
 	Notes               string `json:"notes,omitempty"`
+	// Failure marks a drill that could not recover at all.
+	Failure string `json:"failure,omitempty"`
 }
 
 type RegionalFailoverDrillRun struct {
 	ID             string    `json:"id"`
+	Kind           string    `json:"kind"`
 	Region         string    `json:"region"`
 	TargetRTOMs    int64     `json:"target_rto_ms"`
 	RecoveryTimeMs int64     `json:"recovery_time_ms"`
 	Pass           bool      `json:"pass"`
 	Notes          string    `json:"notes,omitempty"`
+	Failure        string    `json:"failure,omitempty"`
 	StartedAt      time.Time `json:"started_at"`
 	CompletedAt    time.Time `json:"completed_at"`
 }
 
 type RegionalFailoverScorecard struct {
+	Kind              string    `json:"kind"`
 	Region            string    `json:"region"`
 	WindowHours       int       `json:"window_hours"`
 	DrillCount        int       `json:"drill_count"`
@@ -52,6 +60,10 @@ func (s *RegionalFailoverDrillStore) Run(in RegionalFailoverDrillInput) (Regiona
 	if region == "" {
 		return RegionalFailoverDrillRun{}, errors.New("region is required")
 	}
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	if kind == "" {
+		kind = "failover"
+	}
 	target := int64(in.TargetRTOSeconds) * 1000
 	if target <= 0 {
 		target = 300000
@@ -64,12 +76,15 @@ func (s *RegionalFailoverDrillStore) Run(in RegionalFailoverDrillInput) (Regiona
 		}
 	}
 	start := time.Now().UTC()
+	failure := strings.TrimSpace(in.Failure)
 	item := RegionalFailoverDrillRun{
+		Kind:           kind,
 		Region:         region,
 		TargetRTOMs:    target,
 		RecoveryTimeMs: recovery,
-		Pass:           recovery <= target,
+		Pass:           failure == "" && recovery <= target,
 		Notes:          strings.TrimSpace(in.Notes),
+		Failure:        failure,
 		StartedAt:      start,
 		CompletedAt:    start.Add(time.Duration(recovery) * time.Millisecond),
 	}
@@ -105,10 +120,11 @@ func (s *RegionalFailoverDrillStore) Scorecards(windowHours int) []RegionalFailo
 		if run.CompletedAt.Before(cutoff) {
 			continue
 		}
-		groups[run.Region] = append(groups[run.Region], run)
+		key := run.Kind + "|" + run.Region
+		groups[key] = append(groups[key], run)
 	}
 	cards := make([]RegionalFailoverScorecard, 0, len(groups))
-	for region, runs := range groups {
+	for _, runs := range groups {
 		if len(runs) == 0 {
 			continue
 		}
@@ -129,7 +145,8 @@ func (s *RegionalFailoverDrillStore) Scorecards(windowHours int) []RegionalFailo
 			}
 		}
 		cards = append(cards, RegionalFailoverScorecard{
-			Region:            region,
+			Kind:              runs[0].Kind,
+			Region:            runs[0].Region,
 			WindowHours:       windowHours,
 			DrillCount:        len(runs),
 			PassCount:         pass,
@@ -139,6 +156,11 @@ func (s *RegionalFailoverDrillStore) Scorecards(windowHours int) []RegionalFailo
 			LatestCompletedAt: latest,
 		})
 	}
-	sort.Slice(cards, func(i, j int) bool { return cards[i].Region < cards[j].Region })
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].Kind != cards[j].Kind {
+			return cards[i].Kind < cards[j].Kind
+		}
+		return cards[i].Region < cards[j].Region
+	})
 	return cards
 }
@@ -13,6 +13,7 @@ type FederationPeerInput struct {
 	Endpoint string `json:"endpoint"`
 	Mode     string `json:"mode"`
 	Weight   int    `json:"weight,omitempty"`
+	Token    string `json:"token,omitempty"`
 }
 
 type FederationPeer struct {
@@ -21,6 +22,8 @@ type FederationPeer struct {
 	Endpoint  string    `json:"endpoint"`
 	Mode      string    `json:"mode"`
 	Weight    int       `json:"weight"`
+	Token     string    `json:"-"`
+	HasToken  bool      `json:"has_token"`
 	Healthy   bool      `json:"healthy"`
 	LatencyMs int       `json:"latency_ms"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -65,10 +68,12 @@ func (s *FederationStore) UpsertPeer(in FederationPeerInput) (FederationPeer, er
 		Endpoint:  endpoint,
 		Mode:      mode,
 		Weight:    weight,
+		Token:     strings.TrimSpace(in.Token),
 		Healthy:   true,
 		LatencyMs: 25,
 		UpdatedAt: time.Now().UTC(),
 	}
+	item.HasToken = item.Token != ""
 	s.mu.Lock()
 	s.next++
 	item.ID = "federation-peer-" + itoa(s.next)
@@ -98,6 +103,38 @@ func (s *FederationStore) ListPeers() []FederationPeer {
 	return out
 }
 
+// PeerForRegion picks the peer jobs for region are forwarded to: a healthy
+// peer first, then the highest weight, then the lowest latency. ok is false
+// when no peer serves the region; an error means none of them is healthy.
+func (s *FederationStore) PeerForRegion(region string) (FederationPeer, bool, error) {
+	region = strings.ToLower(strings.TrimSpace(region))
+	candidates := make([]FederationPeer, 0)
+	for _, peer := range s.ListPeers() {
+		if peer.Region == region {
+			candidates = append(candidates, peer)
+		}
+	}
+	if len(candidates) == 0 {
+		return FederationPeer{}, false, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Healthy != candidates[j].Healthy {
+			return candidates[i].Healthy
+		}
+		if candidates[i].Weight != candidates[j].Weight {
+			return candidates[i].Weight > candidates[j].Weight
+		}
+		if candidates[i].LatencyMs != candidates[j].LatencyMs {
+			return candidates[i].LatencyMs < candidates[j].LatencyMs
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+	if !candidates[0].Healthy {
+		return FederationPeer{}, true, errors.New("no healthy federation peer for region " + region)
+	}
+	return candidates[0], true, nil
+}
+
 func (s *FederationStore) SetPeerHealth(id string, healthy bool, latencyMs int) (FederationPeer, error) {
 	if latencyMs < 0 {
 		latencyMs = 0
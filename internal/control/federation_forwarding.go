@@ -0,0 +1,382 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FederationRegionHeader names the region of the control plane that sent a
+// federated request.
+const FederationRegionHeader = "X-Masterchef-Federation-Region"
+
+// FederationJobRequest is the body a control plane posts to a peer's
+// /v1/control/federation/jobs to run a job there.
+type FederationJobRequest struct {
+	ConfigPath     string `json:"config_path"`
+	Priority       string `json:"priority,omitempty"`
+	Tenant         string `json:"tenant,omitempty"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	OriginRegion   string `json:"origin_region,omitempty"`
+	ForwardID      string `json:"forward_id,omitempty"`
+}
+
+// ForwardedJob is the local record of a job running on a peer. Status
+// mirrors the remote job until it finishes.
+type ForwardedJob struct {
+	ID           string    `json:"id"`
+	PeerID       string    `json:"peer_id"`
+	Region       string    `json:"region"`
+	Endpoint     string    `json:"endpoint"`
+	RemoteJobID  string    `json:"remote_job_id,omitempty"`
+	ConfigPath   string    `json:"config_path"`
+	Priority     string    `json:"priority,omitempty"`
+	Tenant       string    `json:"tenant,omitempty"`
+	Status       JobStatus `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	SyncError    string    `json:"sync_error,omitempty"`
+	ForwardedAt  time.Time `json:"forwarded_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	LastSyncedAt time.Time `json:"last_synced_at,omitempty"`
+}
+
+// FederationRegionRuns is one region's slice of the global run view.
+type FederationRegionRuns struct {
+	Region string `json:"region"`
+	PeerID string `json:"peer_id,omitempty"`
+	Local  bool   `json:"local"`
+	Jobs   []Job  `json:"jobs"`
+	Error  string `json:"error,omitempty"`
+}
+
+type FederationRunsView struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	Regions     []FederationRegionRuns `json:"regions"`
+	Totals      map[JobStatus]int      `json:"totals"`
+}
+
+// FederationForwarder sends jobs to peer control planes over HTTP with the
+// peer's bearer token and mirrors their status back by polling.
+type FederationForwarder struct {
+	mu       sync.RWMutex
+	next     int64
+	peers    *FederationStore
+	region   string
+	client   *http.Client
+	jobs     map[string]*ForwardedJob
+	onUpdate func(ForwardedJob)
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewFederationForwarder(peers *FederationStore, localRegion string) *FederationForwarder {
+	return &FederationForwarder{
+		peers:  peers,
+		region: strings.ToLower(strings.TrimSpace(localRegion)),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		jobs: map[string]*ForwardedJob{},
+	}
+}
+
+// LocalRegion is the region this control plane serves itself.
+func (f *FederationForwarder) LocalRegion() string {
+	return f.region
+}
+
+// OnUpdate registers a callback for forwarded jobs whose mirrored status
+// changed.
+func (f *FederationForwarder) OnUpdate(fn func(ForwardedJob)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onUpdate = fn
+}
+
+// Start polls unfinished forwarded jobs on interval.
+func (f *FederationForwarder) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				f.Sync(ctx)
+			}
+		}
+	}(f.done)
+}
+
+func (f *FederationForwarder) Shutdown() {
+	f.mu.Lock()
+	cancel, done := f.cancel, f.done
+	f.cancel, f.done = nil, nil
+	f.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Route reports whether a job for region belongs to a peer. ok is false for
+// the local region and for regions no peer serves.
+func (f *FederationForwarder) Route(region string) (FederationPeer, bool, error) {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if region == "" || region == f.region {
+		return FederationPeer{}, false, nil
+	}
+	return f.peers.PeerForRegion(region)
+}
+
+// Forward submits a job to peer and records it for status mirroring.
+func (f *FederationForwarder) Forward(ctx context.Context, peer FederationPeer, in FederationJobRequest) (ForwardedJob, error) {
+	if strings.TrimSpace(in.ConfigPath) == "" {
+		return ForwardedJob{}, errors.New("config_path is required")
+	}
+	f.mu.Lock()
+	f.next++
+	id := "forward-" + itoa(f.next)
+	f.mu.Unlock()
+
+	in.OriginRegion = f.region
+	in.ForwardID = id
+	if strings.TrimSpace(in.IdempotencyKey) == "" {
+		// Retries of this forward must not start a second remote job.
+		in.IdempotencyKey = "federation:" + f.region + ":" + id
+	}
+	var remote Job
+	latency, err := f.do(ctx, peer, http.MethodPost, "/v1/control/federation/jobs", in, &remote)
+	if err != nil {
+		_, _ = f.peers.SetPeerHealth(peer.ID, false, latency)
+		return ForwardedJob{}, err
+	}
+	_, _ = f.peers.SetPeerHealth(peer.ID, true, latency)
+	now := time.Now().UTC()
+	item := &ForwardedJob{
+		ID:          id,
+		PeerID:      peer.ID,
+		Region:      peer.Region,
+		Endpoint:    peer.Endpoint,
+		RemoteJobID: remote.ID,
+		ConfigPath:  in.ConfigPath,
+		Priority:    remote.Priority,
+		Tenant:      in.Tenant,
+		Status:      remote.Status,
+		Error:       remote.Error,
+		ForwardedAt: now,
+		UpdatedAt:   now,
+	}
+	f.mu.Lock()
+	f.jobs[id] = item
+	out := *item
+	f.mu.Unlock()
+	return out, nil
+}
+
+func (f *FederationForwarder) List() []ForwardedJob {
+	f.mu.RLock()
+	out := make([]ForwardedJob, 0, len(f.jobs))
+	for _, item := range f.jobs {
+		out = append(out, *item)
+	}
+	f.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ForwardedAt.After(out[j].ForwardedAt) })
+	return out
+}
+
+func (f *FederationForwarder) Get(id string) (ForwardedJob, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	item, ok := f.jobs[strings.TrimSpace(id)]
+	if !ok {
+		return ForwardedJob{}, false
+	}
+	return *item, true
+}
+
+// Sync fetches the remote status of every unfinished forwarded job and
+// returns the jobs whose status changed.
+func (f *FederationForwarder) Sync(ctx context.Context) []ForwardedJob {
+	f.mu.RLock()
+	pending := make([]ForwardedJob, 0)
+	for _, item := range f.jobs {
+		if !jobStatusFinished(item.Status) {
+			pending = append(pending, *item)
+		}
+	}
+	onUpdate := f.onUpdate
+	f.mu.RUnlock()
+
+	changed := make([]ForwardedJob, 0)
+	for _, item := range pending {
+		peer, ok := f.peers.GetPeer(item.PeerID)
+		if !ok {
+			f.recordSync(item.ID, nil, "federation peer no longer configured")
+			continue
+		}
+		var remote Job
+		latency, err := f.do(ctx, peer, http.MethodGet, "/v1/control/federation/jobs/"+url.PathEscape(item.RemoteJobID), nil, &remote)
+		_, _ = f.peers.SetPeerHealth(peer.ID, err == nil, latency)
+		if err != nil {
+			f.recordSync(item.ID, nil, err.Error())
+			continue
+		}
+		if updated, ok := f.recordSync(item.ID, &remote, ""); ok {
+			changed = append(changed, updated)
+		}
+	}
+	if onUpdate != nil {
+		for _, item := range changed {
+			onUpdate(item)
+		}
+	}
+	return changed
+}
+
+func (f *FederationForwarder) recordSync(id string, remote *Job, syncErr string) (ForwardedJob, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.jobs[id]
+	if !ok {
+		return ForwardedJob{}, false
+	}
+	item.SyncError = syncErr
+	if remote == nil {
+		return *item, false
+	}
+	now := time.Now().UTC()
+	item.LastSyncedAt = now
+	if remote.Status == item.Status {
+		return *item, false
+	}
+	item.Status = remote.Status
+	item.Error = remote.Error
+	item.UpdatedAt = now
+	return *item, true
+}
+
+// GlobalRuns lists local jobs alongside the jobs of every peer. A peer that
+// cannot be reached is reported with its error rather than failing the view.
+func (f *FederationForwarder) GlobalRuns(ctx context.Context, local []Job, limit int) FederationRunsView {
+	if limit <= 0 {
+		limit = 100
+	}
+	view := FederationRunsView{
+		GeneratedAt: time.Now().UTC(),
+		Totals:      map[JobStatus]int{},
+	}
+	view.Regions = append(view.Regions, FederationRegionRuns{
+		Region: f.region,
+		Local:  true,
+		Jobs:   latestJobs(local, limit),
+	})
+	for _, peer := range f.peers.ListPeers() {
+		row := FederationRegionRuns{Region: peer.Region, PeerID: peer.ID, Jobs: []Job{}}
+		var remote []Job
+		latency, err := f.do(ctx, peer, http.MethodGet, "/v1/control/federation/jobs?limit="+itoa(int64(limit)), nil, &remote)
+		_, _ = f.peers.SetPeerHealth(peer.ID, err == nil, latency)
+		if err != nil {
+			row.Error = err.Error()
+		} else {
+			row.Jobs = latestJobs(remote, limit)
+		}
+		view.Regions = append(view.Regions, row)
+	}
+	for _, row := range view.Regions {
+		for _, j := range row.Jobs {
+			view.Totals[j.Status]++
+		}
+	}
+	return view
+}
+
+// do sends an authenticated request to a peer and decodes its JSON reply.
+// It returns the round-trip latency in milliseconds.
+func (f *FederationForwarder) do(ctx context.Context, peer FederationPeer, method, path string, body any, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(peer.Endpoint, "/")+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if peer.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+peer.Token)
+	}
+	if f.region != "" {
+		req.Header.Set(FederationRegionHeader, f.region)
+	}
+	start := time.Now()
+	resp, err := f.client.Do(req)
+	latency := int(time.Since(start).Milliseconds())
+	if err != nil {
+		return latency, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return latency, fmt.Errorf("federation peer %s returned status %d: %s", peer.Region, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if out == nil {
+		return latency, nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return latency, errors.New("invalid federation peer response: " + err.Error())
+	}
+	return latency, nil
+}
+
+func jobStatusFinished(status JobStatus) bool {
+	switch status {
+	case JobSucceeded, JobFailed, JobCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+func latestJobs(jobs []Job, limit int) []Job {
+	out := append([]Job{}, jobs...)
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
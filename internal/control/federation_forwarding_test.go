@@ -0,0 +1,82 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFederationForwarderForwardsAndMirrorsStatus(t *testing.T) {
+	var mu sync.Mutex
+	status := JobPending
+	var forwarded FederationJobRequest
+	peerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get(FederationRegionHeader) != "us-east-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/control/federation/jobs":
+			_ = json.NewDecoder(r.Body).Decode(&forwarded)
+			_ = json.NewEncoder(w).Encode(Job{ID: "job-remote-1", ConfigPath: forwarded.ConfigPath, Status: status})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/control/federation/jobs/job-remote-1":
+			_ = json.NewEncoder(w).Encode(Job{ID: "job-remote-1", Status: status})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/control/federation/jobs":
+			_ = json.NewEncoder(w).Encode([]Job{{ID: "job-remote-1", Status: status}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer peerServer.Close()
+
+	peers := NewFederationStore()
+	peer, _ := peers.UpsertPeer(FederationPeerInput{Region: "eu-west-1", Endpoint: peerServer.URL, Mode: "active_active", Token: "secret"})
+	f := NewFederationForwarder(peers, "us-east-1")
+	var updates []ForwardedJob
+	f.OnUpdate(func(item ForwardedJob) { updates = append(updates, item) })
+
+	if _, ok, _ := f.Route("us-east-1"); ok {
+		t.Fatalf("expected local region not to be forwarded")
+	}
+	if _, ok, _ := f.Route("ap-south-1"); ok {
+		t.Fatalf("expected region without a peer not to be forwarded")
+	}
+	routed, ok, err := f.Route("EU-WEST-1")
+	if !ok || err != nil || routed.ID != peer.ID {
+		t.Fatalf("expected eu-west-1 to route to peer, got ok=%v err=%v peer=%+v", ok, err, routed)
+	}
+
+	item, err := f.Forward(context.Background(), routed, FederationJobRequest{ConfigPath: "c.yaml", Tenant: "payments"})
+	if err != nil {
+		t.Fatalf("forward failed: %v", err)
+	}
+	if item.RemoteJobID != "job-remote-1" || item.Status != JobPending || !strings.HasPrefix(forwarded.IdempotencyKey, "federation:us-east-1:") {
+		t.Fatalf("unexpected forward: %+v request=%+v", item, forwarded)
+	}
+
+	mu.Lock()
+	status = JobSucceeded
+	mu.Unlock()
+	if changed := f.Sync(context.Background()); len(changed) != 1 || changed[0].Status != JobSucceeded || len(updates) != 1 {
+		t.Fatalf("expected mirrored success, got %+v", changed)
+	}
+	if changed := f.Sync(context.Background()); len(changed) != 0 {
+		t.Fatalf("expected finished jobs to stop syncing, got %+v", changed)
+	}
+
+	view := f.GlobalRuns(context.Background(), []Job{{ID: "job-local-1", Status: JobFailed}}, 10)
+	if len(view.Regions) != 2 || view.Totals[JobSucceeded] != 1 || view.Totals[JobFailed] != 1 {
+		t.Fatalf("unexpected global view: %+v", view)
+	}
+
+	peers.SetPeerHealth(peer.ID, false, 0)
+	if _, ok, err := f.Route("eu-west-1"); !ok || err == nil {
+		t.Fatalf("expected unhealthy peer to fail routing, got ok=%v err=%v", ok, err)
+	}
+}
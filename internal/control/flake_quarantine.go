@@ -2,6 +2,7 @@ package control
 
 import (
 	"errors"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -13,29 +14,40 @@ type FlakePolicy struct {
 	MinSamples                  int       `json:"min_samples"`
 	FlakeRateThreshold          float64   `json:"flake_rate_threshold"`
 	ConsecutiveFailureThreshold int       `json:"consecutive_failure_threshold"`
+	HistoryWindow               int       `json:"history_window"`        // recent outcomes the flake score is computed over
+	FlakeScoreThreshold         float64   `json:"flake_score_threshold"` // auto-quarantine when the flake score reaches this
+	ReleaseAfterPasses          int       `json:"release_after_passes"`  // consecutive passes that release an auto-quarantined case
 	UpdatedAt                   time.Time `json:"updated_at"`
 }
 
 type FlakeObservation struct {
 	Suite  string `json:"suite"`
 	Test   string `json:"test"`
-	Status string `json:"status"` // pass|fail|flaky
+	Status string `json:"status"`           // pass|fail|flaky
+	Source string `json:"source,omitempty"` // scenario|harness|conformance for automatic observations
+	RunID  string `json:"run_id,omitempty"`
 }
 
 type FlakeCase struct {
-	ID                  string    `json:"id"`
-	Suite               string    `json:"suite"`
-	Test                string    `json:"test"`
-	Passes              int       `json:"passes"`
-	Failures            int       `json:"failures"`
-	ConsecutiveFailures int       `json:"consecutive_failures"`
-	FlakeRate           float64   `json:"flake_rate"`
-	Quarantined         bool      `json:"quarantined"`
-	QuarantineReason    string    `json:"quarantine_reason,omitempty"`
-	QuarantinedAt       time.Time `json:"quarantined_at,omitempty"`
-	LastObservedStatus  string    `json:"last_observed_status,omitempty"`
-	LastSeenAt          time.Time `json:"last_seen_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	ID                    string    `json:"id"`
+	Suite                 string    `json:"suite"`
+	Test                  string    `json:"test"`
+	Passes                int       `json:"passes"`
+	Failures              int       `json:"failures"`
+	ConsecutiveFailures   int       `json:"consecutive_failures"`
+	FlakeRate             float64   `json:"flake_rate"`
+	FlakeScore            float64   `json:"flake_score"`
+	History               []string  `json:"history,omitempty"` // oldest first, bounded by the policy window
+	Source                string    `json:"source,omitempty"`
+	LastRunID             string    `json:"last_run_id,omitempty"`
+	Quarantined           bool      `json:"quarantined"`
+	AutoQuarantined       bool      `json:"auto_quarantined,omitempty"`
+	PassesSinceQuarantine int       `json:"passes_since_quarantine,omitempty"`
+	QuarantineReason      string    `json:"quarantine_reason,omitempty"`
+	QuarantinedAt         time.Time `json:"quarantined_at,omitempty"`
+	LastObservedStatus    string    `json:"last_observed_status,omitempty"`
+	LastSeenAt            time.Time `json:"last_seen_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
 }
 
 type FlakeObservationResult struct {
@@ -43,6 +55,11 @@ type FlakeObservationResult struct {
 	Action string    `json:"action"` // observed|auto-quarantined
 }
 
+type FlakeReleaseResult struct {
+	Released  []FlakeCase `json:"released"`
+	Evaluated int         `json:"evaluated"`
+}
+
 type FlakeSummary struct {
 	Total           int `json:"total"`
 	Quarantined     int `json:"quarantined"`
@@ -65,6 +82,9 @@ func NewFlakeQuarantineStore() *FlakeQuarantineStore {
 			MinSamples:                  10,
 			FlakeRateThreshold:          0.05,
 			ConsecutiveFailureThreshold: 3,
+			HistoryWindow:               20,
+			FlakeScoreThreshold:         0.10,
+			ReleaseAfterPasses:          5,
 			UpdatedAt:                   time.Now().UTC(),
 		},
 		cases:           map[string]*FlakeCase{},
@@ -88,6 +108,21 @@ func (s *FlakeQuarantineStore) SetPolicy(in FlakePolicy) (FlakePolicy, error) {
 	if in.ConsecutiveFailureThreshold <= 0 {
 		in.ConsecutiveFailureThreshold = 3
 	}
+	if in.HistoryWindow <= 0 {
+		in.HistoryWindow = 20
+	}
+	if in.HistoryWindow < in.MinSamples {
+		in.HistoryWindow = in.MinSamples
+	}
+	if in.FlakeScoreThreshold < 0 || in.FlakeScoreThreshold > 1 {
+		return FlakePolicy{}, errors.New("flake_score_threshold must be between 0 and 1")
+	}
+	if in.FlakeScoreThreshold == 0 {
+		in.FlakeScoreThreshold = 0.10
+	}
+	if in.ReleaseAfterPasses <= 0 {
+		in.ReleaseAfterPasses = 5
+	}
 	in.UpdatedAt = time.Now().UTC()
 	s.mu.Lock()
 	s.policy = in
@@ -129,30 +164,55 @@ func (s *FlakeQuarantineStore) Observe(in FlakeObservation) (FlakeObservationRes
 		item = s.cases[id]
 	}
 
+	policy := s.policy
 	switch status {
 	case "pass":
 		item.Passes++
 		item.ConsecutiveFailures = 0
+		if item.Quarantined {
+			item.PassesSinceQuarantine++
+		}
 	case "fail":
 		item.Failures++
 		item.ConsecutiveFailures++
+		item.PassesSinceQuarantine = 0
 	case "flaky":
 		item.Failures++
 		item.ConsecutiveFailures++
+		item.PassesSinceQuarantine = 0
 	}
-	total := item.Passes + item.Failures
-	if total > 0 {
-		item.FlakeRate = float64(item.Failures) / float64(total)
+	item.History = append(item.History, status)
+	if len(item.History) > policy.HistoryWindow {
+		item.History = append([]string(nil), item.History[len(item.History)-policy.HistoryWindow:]...)
+	}
+	// Rates are computed over the rolling window so released cases are
+	// judged on their behaviour since release, not their whole lifetime.
+	total := len(item.History)
+	failures := 0
+	for _, outcome := range item.History {
+		if outcome != "pass" {
+			failures++
+		}
+	}
+	item.FlakeRate = float64(failures) / float64(total)
+	item.FlakeScore = flakeScore(item.History)
+	if src := strings.TrimSpace(in.Source); src != "" {
+		item.Source = src
+	}
+	if runID := strings.TrimSpace(in.RunID); runID != "" {
+		item.LastRunID = runID
 	}
 	item.LastObservedStatus = status
 	item.LastSeenAt = now
 	item.UpdatedAt = now
 
 	action := "observed"
-	policy := s.policy
 	if policy.AutoQuarantine && !item.Quarantined && total >= policy.MinSamples &&
-		(item.FlakeRate >= policy.FlakeRateThreshold || item.ConsecutiveFailures >= policy.ConsecutiveFailureThreshold) {
+		(item.FlakeRate >= policy.FlakeRateThreshold || item.ConsecutiveFailures >= policy.ConsecutiveFailureThreshold ||
+			(policy.FlakeScoreThreshold > 0 && item.FlakeScore >= policy.FlakeScoreThreshold)) {
 		item.Quarantined = true
+		item.AutoQuarantined = true
+		item.PassesSinceQuarantine = 0
 		item.QuarantineReason = "auto quarantine by flake policy"
 		item.QuarantinedAt = now
 		s.autoQuarantined++
@@ -221,6 +281,8 @@ func (s *FlakeQuarantineStore) Quarantine(id, reason string) (FlakeCase, error)
 		return FlakeCase{}, errors.New("flake case not found")
 	}
 	item.Quarantined = true
+	item.AutoQuarantined = false
+	item.PassesSinceQuarantine = 0
 	item.QuarantineReason = reason
 	item.QuarantinedAt = time.Now().UTC()
 	item.UpdatedAt = item.QuarantinedAt
@@ -238,12 +300,82 @@ func (s *FlakeQuarantineStore) Unquarantine(id, reason string) (FlakeCase, error
 	if !ok {
 		return FlakeCase{}, errors.New("flake case not found")
 	}
+	releaseFlakeCase(item, strings.TrimSpace(reason), time.Now().UTC())
+	return cloneFlakeCase(*item), nil
+}
+
+// ReevaluateQuarantined releases auto-quarantined cases that have passed
+// release_after_passes times in a row since they were quarantined. Manual
+// quarantines are left for a person to lift.
+func (s *FlakeQuarantineStore) ReevaluateQuarantined() FlakeReleaseResult {
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := FlakeReleaseResult{Released: make([]FlakeCase, 0)}
+	for _, item := range s.cases {
+		if !item.Quarantined || !item.AutoQuarantined {
+			continue
+		}
+		out.Evaluated++
+		if item.PassesSinceQuarantine < s.policy.ReleaseAfterPasses {
+			continue
+		}
+		releaseFlakeCase(item, "auto release after "+itoa(int64(item.PassesSinceQuarantine))+" consecutive passes", now)
+		out.Released = append(out.Released, cloneFlakeCase(*item))
+	}
+	sort.Slice(out.Released, func(i, j int) bool { return out.Released[i].ID < out.Released[j].ID })
+	return out
+}
+
+func releaseFlakeCase(item *FlakeCase, reason string, now time.Time) {
 	item.Quarantined = false
-	item.QuarantineReason = strings.TrimSpace(reason)
+	item.AutoQuarantined = false
+	item.PassesSinceQuarantine = 0
+	item.QuarantineReason = reason
 	item.QuarantinedAt = time.Time{}
 	item.ConsecutiveFailures = 0
-	item.UpdatedAt = time.Now().UTC()
-	return cloneFlakeCase(*item), nil
+	// Start a fresh window so the outcomes that caused the quarantine do
+	// not immediately re-quarantine the case.
+	item.History = nil
+	item.FlakeRate = 0
+	item.FlakeScore = 0
+	item.UpdatedAt = now
+}
+
+// flakeScore estimates how flaky a window of outcomes is. A case that
+// always fails or always passes is not flaky, so the score is zero unless
+// outcomes alternate at least twice. Otherwise it is the 95% Wilson lower
+// bound of the failure rate, which stays low until enough failures are
+// seen to rule out a one-off.
+func flakeScore(history []string) float64 {
+	n := len(history)
+	if n < 2 {
+		return 0
+	}
+	failures := 0
+	flips := 0
+	for i, status := range history {
+		if status != "pass" {
+			failures++
+		}
+		if i > 0 && (status == "pass") != (history[i-1] == "pass") {
+			flips++
+		}
+	}
+	if failures == 0 || failures == n || flips < 2 {
+		return 0
+	}
+	const z = 1.96
+	p := float64(failures) / float64(n)
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := p + z*z/(2*nf)
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+	lower := (center - margin) / denom
+	if lower < 0 {
+		return 0
+	}
+	return lower
 }
 
 func (s *FlakeQuarantineStore) Summary() FlakeSummary {
@@ -262,5 +394,6 @@ func (s *FlakeQuarantineStore) Summary() FlakeSummary {
 }
 
 func cloneFlakeCase(in FlakeCase) FlakeCase {
+	in.History = cloneStringSlice(in.History)
 	return in
 }
@@ -60,3 +60,70 @@ func TestFlakeQuarantineManualOverrides(t *testing.T) {
 		t.Fatalf("expected unquarantined test case")
 	}
 }
+
+func TestFlakeQuarantineScoreAndRelease(t *testing.T) {
+	store := NewFlakeQuarantineStore()
+	if _, err := store.SetPolicy(FlakePolicy{
+		AutoQuarantine:              true,
+		MinSamples:                  6,
+		FlakeRateThreshold:          0.9,
+		ConsecutiveFailureThreshold: 10,
+		FlakeScoreThreshold:         0.05,
+		ReleaseAfterPasses:          3,
+	}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+
+	// A consistently failing case is broken, not flaky.
+	for i := 0; i < 5; i++ {
+		result, err := store.Observe(FlakeObservation{Suite: "scenario", Test: "broken", Status: "fail", Source: "scenario"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Case.FlakeScore != 0 {
+			t.Fatalf("expected zero flake score for consistent failures, got %+v", result.Case)
+		}
+	}
+
+	var result FlakeObservationResult
+	for _, status := range []string{"pass", "fail", "pass", "fail", "pass", "fail"} {
+		var err error
+		result, err = store.Observe(FlakeObservation{Suite: "harness/policy", Test: "intermittent", Status: status, Source: "harness", RunID: "harness-run-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if result.Action != "auto-quarantined" || result.Case.FlakeScore < 0.05 || result.Case.Source != "harness" {
+		t.Fatalf("expected alternating outcomes to be quarantined by flake score, got %+v", result)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.Observe(FlakeObservation{Suite: "harness/policy", Test: "intermittent", Status: "pass"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if released := store.ReevaluateQuarantined(); len(released.Released) != 0 || released.Evaluated != 1 {
+		t.Fatalf("expected case to stay quarantined before release threshold, got %+v", released)
+	}
+	if _, err := store.Observe(FlakeObservation{Suite: "harness/policy", Test: "intermittent", Status: "pass"}); err != nil {
+		t.Fatal(err)
+	}
+	released := store.ReevaluateQuarantined()
+	if len(released.Released) != 1 || released.Released[0].Quarantined || len(released.Released[0].History) != 0 {
+		t.Fatalf("expected case release with fresh history, got %+v", released)
+	}
+
+	manual, err := store.Observe(FlakeObservation{Suite: "unit", Test: "manual", Status: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Quarantine(manual.Case.ID, "investigating"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = store.Observe(FlakeObservation{Suite: "unit", Test: "manual", Status: "pass"})
+	}
+	if released := store.ReevaluateQuarantined(); len(released.Released) != 0 {
+		t.Fatalf("expected manual quarantine to be left alone, got %+v", released)
+	}
+}
@@ -0,0 +1,372 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fleet health score components.
+const (
+	FleetHealthCheckin    = "check_in_freshness"
+	FleetHealthDrift      = "drift"
+	FleetHealthFailedRuns = "failed_runs"
+	FleetHealthCertExpiry = "cert_expiry"
+	FleetHealthCompliance = "compliance"
+)
+
+// FleetHealthWeights sets how much each component contributes to a host's
+// score. Weights are relative; a component with no data for a host is left
+// out and the remaining weights are renormalized.
+type FleetHealthWeights struct {
+	CheckinFreshness float64 `json:"check_in_freshness"`
+	Drift            float64 `json:"drift"`
+	FailedRuns       float64 `json:"failed_runs"`
+	CertExpiry       float64 `json:"cert_expiry"`
+	Compliance       float64 `json:"compliance"`
+}
+
+// FleetHealthScoringPolicy is the scoring model for one environment; the
+// "*" environment applies wherever no specific policy exists.
+type FleetHealthScoringPolicy struct {
+	Environment      string             `json:"environment"`
+	Weights          FleetHealthWeights `json:"weights"`
+	StaleCheckinMins int                `json:"stale_checkin_minutes"`
+	DriftTolerance   int                `json:"drift_tolerance"`
+	FailureTolerance int                `json:"failure_tolerance"`
+	CertWarningDays  int                `json:"cert_warning_days"`
+	AlertBelowScore  float64            `json:"alert_below_score"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// FleetHostSignals are the raw inputs scored for one host. A zero
+// CertExpiresAt or negative ComplianceScore means no data.
+type FleetHostSignals struct {
+	Host            string    `json:"host"`
+	Environment     string    `json:"environment"`
+	LastSeenAt      time.Time `json:"last_seen_at,omitempty"`
+	DriftCount      int       `json:"drift_count"`
+	FailedRuns      int       `json:"failed_runs"`
+	CertExpiresAt   time.Time `json:"cert_expires_at,omitempty"`
+	ComplianceScore int       `json:"compliance_score"`
+}
+
+type FleetHostHealth struct {
+	Host           string             `json:"host"`
+	Environment    string             `json:"environment"`
+	Score          float64            `json:"score"`
+	Grade          string             `json:"grade"`
+	Components     map[string]float64 `json:"components"`
+	Signals        FleetHostSignals   `json:"signals"`
+	BelowThreshold bool               `json:"below_threshold"`
+}
+
+type FleetGroupHealth struct {
+	Environment    string         `json:"environment"`
+	Hosts          int            `json:"hosts"`
+	Score          float64        `json:"score"`
+	Grade          string         `json:"grade"`
+	Grades         map[string]int `json:"grades"`
+	BelowThreshold bool           `json:"below_threshold"`
+}
+
+type FleetHealthScoreReport struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Hosts       []FleetHostHealth  `json:"hosts"`
+	Groups      []FleetGroupHealth `json:"groups"`
+}
+
+// FleetHealthPoint is one recorded score in a host or group trend.
+type FleetHealthPoint struct {
+	Score float64   `json:"score"`
+	Grade string    `json:"grade"`
+	At    time.Time `json:"at"`
+}
+
+// FleetHealthCrossing reports a host or group whose score fell below its
+// environment's alert threshold since the previous evaluation.
+type FleetHealthCrossing struct {
+	Scope       string  `json:"scope"` // host|group
+	Name        string  `json:"name"`
+	Environment string  `json:"environment"`
+	Score       float64 `json:"score"`
+	Threshold   float64 `json:"threshold"`
+}
+
+// FleetHealthScoringStore keeps per-environment scoring policies and the
+// score trend of every host and group.
+type FleetHealthScoringStore struct {
+	mu           sync.RWMutex
+	policies     map[string]FleetHealthScoringPolicy
+	history      map[string][]FleetHealthPoint
+	historyLimit int
+}
+
+func NewFleetHealthScoringStore(historyLimit int) *FleetHealthScoringStore {
+	if historyLimit <= 0 {
+		historyLimit = 288
+	}
+	return &FleetHealthScoringStore{
+		policies:     map[string]FleetHealthScoringPolicy{},
+		history:      map[string][]FleetHealthPoint{},
+		historyLimit: historyLimit,
+	}
+}
+
+// DefaultFleetHealthScoringPolicy weights failed runs and drift most, with
+// a one-hour check-in window and an alert below a D grade.
+func DefaultFleetHealthScoringPolicy() FleetHealthScoringPolicy {
+	return FleetHealthScoringPolicy{
+		Environment: "*",
+		Weights: FleetHealthWeights{
+			CheckinFreshness: 2,
+			Drift:            2,
+			FailedRuns:       3,
+			CertExpiry:       1,
+			Compliance:       2,
+		},
+		StaleCheckinMins: 60,
+		DriftTolerance:   10,
+		FailureTolerance: 3,
+		CertWarningDays:  14,
+		AlertBelowScore:  60,
+	}
+}
+
+func (s *FleetHealthScoringStore) SetPolicy(in FleetHealthScoringPolicy) (FleetHealthScoringPolicy, error) {
+	in.Environment = strings.ToLower(strings.TrimSpace(in.Environment))
+	if in.Environment == "" {
+		in.Environment = "*"
+	}
+	w := in.Weights
+	for _, v := range []float64{w.CheckinFreshness, w.Drift, w.FailedRuns, w.CertExpiry, w.Compliance} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return FleetHealthScoringPolicy{}, errors.New("weights must be non-negative numbers")
+		}
+	}
+	if w.CheckinFreshness+w.Drift+w.FailedRuns+w.CertExpiry+w.Compliance == 0 {
+		return FleetHealthScoringPolicy{}, errors.New("at least one weight must be positive")
+	}
+	if in.AlertBelowScore < 0 || in.AlertBelowScore > 100 {
+		return FleetHealthScoringPolicy{}, errors.New("alert_below_score must be between 0 and 100")
+	}
+	def := DefaultFleetHealthScoringPolicy()
+	if in.StaleCheckinMins <= 0 {
+		in.StaleCheckinMins = def.StaleCheckinMins
+	}
+	if in.DriftTolerance <= 0 {
+		in.DriftTolerance = def.DriftTolerance
+	}
+	if in.FailureTolerance <= 0 {
+		in.FailureTolerance = def.FailureTolerance
+	}
+	if in.CertWarningDays <= 0 {
+		in.CertWarningDays = def.CertWarningDays
+	}
+	in.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[in.Environment] = in
+	return in, nil
+}
+
+func (s *FleetHealthScoringStore) DeletePolicy(environment string) error {
+	environment = strings.ToLower(strings.TrimSpace(environment))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.policies[environment]; !ok {
+		return errors.New("fleet health scoring policy not found")
+	}
+	delete(s.policies, environment)
+	return nil
+}
+
+func (s *FleetHealthScoringStore) Policies() []FleetHealthScoringPolicy {
+	s.mu.RLock()
+	out := make([]FleetHealthScoringPolicy, 0, len(s.policies))
+	for _, p := range s.policies {
+		out = append(out, p)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Environment < out[j].Environment })
+	return out
+}
+
+// PolicyFor returns the policy scoring an environment: its own, else the
+// "*" policy, else the default.
+func (s *FleetHealthScoringStore) PolicyFor(environment string) FleetHealthScoringPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if p, ok := s.policies[strings.ToLower(strings.TrimSpace(environment))]; ok {
+		return p
+	}
+	if p, ok := s.policies["*"]; ok {
+		return p
+	}
+	return DefaultFleetHealthScoringPolicy()
+}
+
+// Score grades every host and environment group.
+func (s *FleetHealthScoringStore) Score(signals []FleetHostSignals, now time.Time) FleetHealthScoreReport {
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	report := FleetHealthScoreReport{GeneratedAt: now, Hosts: make([]FleetHostHealth, 0, len(signals)), Groups: []FleetGroupHealth{}}
+	groups := map[string]*FleetGroupHealth{}
+	for _, sig := range signals {
+		sig.Environment = strings.ToLower(strings.TrimSpace(sig.Environment))
+		if sig.Environment == "" {
+			sig.Environment = "default"
+		}
+		policy := s.PolicyFor(sig.Environment)
+		components := scoreFleetHostComponents(sig, policy, now)
+		score := weightedFleetHealthScore(components, policy.Weights)
+		host := FleetHostHealth{
+			Host:           sig.Host,
+			Environment:    sig.Environment,
+			Score:          score,
+			Grade:          FleetHealthGrade(score),
+			Components:     components,
+			Signals:        sig,
+			BelowThreshold: score < policy.AlertBelowScore,
+		}
+		report.Hosts = append(report.Hosts, host)
+		g := groups[sig.Environment]
+		if g == nil {
+			g = &FleetGroupHealth{Environment: sig.Environment, Grades: map[string]int{}}
+			groups[sig.Environment] = g
+		}
+		g.Hosts++
+		g.Score += score
+		g.Grades[host.Grade]++
+	}
+	for env, g := range groups {
+		g.Score = math.Round(g.Score/float64(g.Hosts)*10) / 10
+		g.Grade = FleetHealthGrade(g.Score)
+		g.BelowThreshold = g.Score < s.PolicyFor(env).AlertBelowScore
+		report.Groups = append(report.Groups, *g)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		if report.Hosts[i].Score != report.Hosts[j].Score {
+			return report.Hosts[i].Score < report.Hosts[j].Score
+		}
+		return report.Hosts[i].Host < report.Hosts[j].Host
+	})
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Environment < report.Groups[j].Environment })
+	return report
+}
+
+// Record appends the report to every host and group trend and returns the
+// hosts and groups that crossed below their alert threshold.
+func (s *FleetHealthScoringStore) Record(report FleetHealthScoreReport) []FleetHealthCrossing {
+	crossings := []FleetHealthCrossing{}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := func(scope, name, env string, score float64, grade string, below bool) {
+		key := scope + "/" + name
+		items := s.history[key]
+		wasBelow := false
+		if n := len(items); n > 0 {
+			wasBelow = items[n-1].Score < s.policyForLocked(env).AlertBelowScore
+		}
+		items = append(items, FleetHealthPoint{Score: score, Grade: grade, At: report.GeneratedAt})
+		if len(items) > s.historyLimit {
+			items = append([]FleetHealthPoint{}, items[len(items)-s.historyLimit:]...)
+		}
+		s.history[key] = items
+		if below && !wasBelow {
+			crossings = append(crossings, FleetHealthCrossing{Scope: scope, Name: name, Environment: env, Score: score, Threshold: s.policyForLocked(env).AlertBelowScore})
+		}
+	}
+	for _, h := range report.Hosts {
+		record("host", h.Host, h.Environment, h.Score, h.Grade, h.BelowThreshold)
+	}
+	for _, g := range report.Groups {
+		record("group", g.Environment, g.Environment, g.Score, g.Grade, g.BelowThreshold)
+	}
+	return crossings
+}
+
+// History returns the score trend of a host or group, oldest first.
+func (s *FleetHealthScoringStore) History(scope, name string) []FleetHealthPoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := s.history[scope+"/"+strings.TrimSpace(name)]
+	return append([]FleetHealthPoint{}, items...)
+}
+
+func (s *FleetHealthScoringStore) policyForLocked(environment string) FleetHealthScoringPolicy {
+	if p, ok := s.policies[environment]; ok {
+		return p
+	}
+	if p, ok := s.policies["*"]; ok {
+		return p
+	}
+	return DefaultFleetHealthScoringPolicy()
+}
+
+func scoreFleetHostComponents(sig FleetHostSignals, policy FleetHealthScoringPolicy, now time.Time) map[string]float64 {
+	out := map[string]float64{}
+	stale := time.Duration(policy.StaleCheckinMins) * time.Minute
+	switch age := now.Sub(sig.LastSeenAt); {
+	case sig.LastSeenAt.IsZero():
+		out[FleetHealthCheckin] = 0
+	case age <= stale:
+		out[FleetHealthCheckin] = 100
+	default:
+		// Decays to zero at twice the stale window.
+		out[FleetHealthCheckin] = clampFleetScore(100 * (1 - float64(age-stale)/float64(stale)))
+	}
+	out[FleetHealthDrift] = clampFleetScore(100 * (1 - float64(sig.DriftCount)/float64(policy.DriftTolerance)))
+	out[FleetHealthFailedRuns] = clampFleetScore(100 * (1 - float64(sig.FailedRuns)/float64(policy.FailureTolerance)))
+	if !sig.CertExpiresAt.IsZero() {
+		warn := time.Duration(policy.CertWarningDays) * 24 * time.Hour
+		out[FleetHealthCertExpiry] = clampFleetScore(100 * float64(sig.CertExpiresAt.Sub(now)) / float64(warn))
+	}
+	if sig.ComplianceScore >= 0 {
+		out[FleetHealthCompliance] = clampFleetScore(float64(sig.ComplianceScore))
+	}
+	return out
+}
+
+func weightedFleetHealthScore(components map[string]float64, w FleetHealthWeights) float64 {
+	weights := map[string]float64{
+		FleetHealthCheckin:    w.CheckinFreshness,
+		FleetHealthDrift:      w.Drift,
+		FleetHealthFailedRuns: w.FailedRuns,
+		FleetHealthCertExpiry: w.CertExpiry,
+		FleetHealthCompliance: w.Compliance,
+	}
+	total, sum := 0.0, 0.0
+	for name, value := range components {
+		total += weights[name]
+		sum += weights[name] * value
+	}
+	if total == 0 {
+		return 100
+	}
+	return math.Round(sum/total*10) / 10
+}
+
+func clampFleetScore(v float64) float64 {
+	return math.Round(math.Max(0, math.Min(100, v))*10) / 10
+}
+
+// FleetHealthGrade maps a 0-100 score to a letter grade.
+func FleetHealthGrade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
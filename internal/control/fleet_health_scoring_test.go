@@ -0,0 +1,64 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFleetHealthScoringWeightsAndGrades(t *testing.T) {
+	store := NewFleetHealthScoringStore(3)
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	if _, err := store.SetPolicy(FleetHealthScoringPolicy{Environment: "prod", Weights: FleetHealthWeights{Drift: 1, Compliance: 3}, AlertBelowScore: 70}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetPolicy(FleetHealthScoringPolicy{Environment: "dev"}); err == nil {
+		t.Fatal("expected all-zero weights to be rejected")
+	}
+	if p := store.PolicyFor("staging"); p.Environment != "*" || p.StaleCheckinMins != 60 {
+		t.Fatalf("expected default policy fallback, got %+v", p)
+	}
+
+	report := store.Score([]FleetHostSignals{
+		{Host: "db-1", Environment: "prod", LastSeenAt: now, DriftCount: 5, ComplianceScore: 40},
+		{Host: "db-2", Environment: "PROD", LastSeenAt: now, ComplianceScore: -1},
+		{Host: "app-1", LastSeenAt: now.Add(-90 * time.Minute), CertExpiresAt: now.Add(7 * 24 * time.Hour), ComplianceScore: -1},
+	}, now)
+	byHost := map[string]FleetHostHealth{}
+	for _, h := range report.Hosts {
+		byHost[h.Host] = h
+	}
+	// drift 50 (weight 1) and compliance 40 (weight 3).
+	if h := byHost["db-1"]; h.Score != 42.5 || h.Grade != "F" || !h.BelowThreshold {
+		t.Fatalf("unexpected db-1 score: %+v", h)
+	}
+	// Unknown compliance is excluded, leaving a perfect drift score.
+	if h := byHost["db-2"]; h.Score != 100 || h.Environment != "prod" {
+		t.Fatalf("unexpected db-2 score: %+v", h)
+	}
+	if _, ok := byHost["db-2"].Components[FleetHealthCompliance]; ok {
+		t.Fatal("expected unknown compliance to be omitted")
+	}
+	app := byHost["app-1"]
+	if app.Environment != "default" || app.Components[FleetHealthCheckin] != 50 || app.Components[FleetHealthCertExpiry] != 50 {
+		t.Fatalf("unexpected app-1 components: %+v", app)
+	}
+	if len(report.Groups) != 2 || report.Groups[1].Environment != "prod" || report.Groups[1].Score != 71.3 || report.Groups[1].Grade != "C" {
+		t.Fatalf("unexpected groups: %+v", report.Groups)
+	}
+
+	crossings := store.Record(report)
+	if len(crossings) != 1 || crossings[0].Name != "db-1" || crossings[0].Threshold != 70 {
+		t.Fatalf("expected one crossing for db-1, got %+v", crossings)
+	}
+	for i := 0; i < 4; i++ {
+		if again := store.Record(report); len(again) != 0 {
+			t.Fatalf("expected no repeat crossing, got %+v", again)
+		}
+	}
+	if points := store.History("host", "db-1"); len(points) != 3 || points[0].Grade != "F" {
+		t.Fatalf("expected history capped at three points, got %+v", points)
+	}
+	if points := store.History("group", "prod"); len(points) != 3 {
+		t.Fatalf("expected group history, got %d", len(points))
+	}
+}
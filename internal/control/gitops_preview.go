@@ -23,6 +23,7 @@ type GitOpsPreview struct {
 	ConfigPath     string    `json:"config_path,omitempty"`
 	ArtifactDigest string    `json:"artifact_digest,omitempty"`
 	LastJobID      string    `json:"last_job_id,omitempty"`
+	ChangeScope    []string  `json:"change_scope,omitempty"` // configs affected by the previewed change set
 	Status         string    `json:"status"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
@@ -30,11 +31,12 @@ type GitOpsPreview struct {
 }
 
 type GitOpsPreviewInput struct {
-	Branch         string `json:"branch"`
-	Environment    string `json:"environment,omitempty"`
-	ConfigPath     string `json:"config_path,omitempty"`
-	ArtifactDigest string `json:"artifact_digest,omitempty"`
-	TTLSeconds     int    `json:"ttl_seconds,omitempty"`
+	Branch         string   `json:"branch"`
+	Environment    string   `json:"environment,omitempty"`
+	ConfigPath     string   `json:"config_path,omitempty"`
+	ArtifactDigest string   `json:"artifact_digest,omitempty"`
+	ChangeScope    []string `json:"change_scope,omitempty"`
+	TTLSeconds     int      `json:"ttl_seconds,omitempty"`
 }
 
 type GitOpsPreviewStore struct {
@@ -80,6 +82,7 @@ func (s *GitOpsPreviewStore) Create(in GitOpsPreviewInput) (GitOpsPreview, error
 		Environment:    env,
 		ConfigPath:     strings.TrimSpace(in.ConfigPath),
 		ArtifactDigest: digest,
+		ChangeScope:    append([]string{}, in.ChangeScope...),
 		Status:         PreviewStatusActive,
 		CreatedAt:      now,
 		UpdatedAt:      now,
@@ -149,7 +152,9 @@ func (s *GitOpsPreviewStore) AttachJob(id, jobID string) (GitOpsPreview, error)
 }
 
 func clonePreview(in GitOpsPreview) GitOpsPreview {
-	return in
+	out := in
+	out.ChangeScope = append([]string(nil), in.ChangeScope...)
+	return out
 }
 
 var sha256DigestRe = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
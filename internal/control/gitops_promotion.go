@@ -20,6 +20,15 @@ type PromotionStageRecord struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// PromotionApproval approves (or rejects) promoting into Stage.
+type PromotionApproval struct {
+	Actor     string    `json:"actor"`
+	Stage     string    `json:"stage"`
+	Decision  string    `json:"decision"` // approve|reject
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type GitOpsPromotion struct {
 	ID             string                 `json:"id"`
 	Name           string                 `json:"name"`
@@ -28,7 +37,9 @@ type GitOpsPromotion struct {
 	CurrentStage   string                 `json:"current_stage"`
 	CurrentIndex   int                    `json:"current_index"`
 	Status         string                 `json:"status"`
+	RequestedBy    string                 `json:"requested_by,omitempty"`
 	History        []PromotionStageRecord `json:"history"`
+	Approvals      []PromotionApproval    `json:"approvals,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
 }
@@ -44,6 +55,7 @@ type GitOpsPromotionStore struct {
 	mu         sync.RWMutex
 	nextID     int64
 	promotions map[string]*GitOpsPromotion
+	gate       func(p GitOpsPromotion, stage, actor string) (bool, error)
 }
 
 func NewGitOpsPromotionStore() *GitOpsPromotionStore {
@@ -78,6 +90,7 @@ func (s *GitOpsPromotionStore) Create(in GitOpsPromotionInput) (GitOpsPromotion,
 		CurrentStage:   stages[0],
 		CurrentIndex:   0,
 		Status:         PromotionStatusInProgress,
+		RequestedBy:    strings.TrimSpace(in.Actor),
 		History: []PromotionStageRecord{
 			{
 				Stage:     stages[0],
@@ -114,6 +127,51 @@ func (s *GitOpsPromotionStore) List() []GitOpsPromotion {
 	return out
 }
 
+// SetApprovalGate makes advancing into a stage subject to fn, which reports
+// whether the promotion holds the approvals that stage needs. When actor is
+// set, fn is vetting a new approval and an error refuses it.
+func (s *GitOpsPromotionStore) SetApprovalGate(fn func(p GitOpsPromotion, stage, actor string) (bool, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gate = fn
+}
+
+// Approve records actor's decision on promoting into the next stage.
+func (s *GitOpsPromotionStore) Approve(id, actor, decision, comment string) (GitOpsPromotion, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return GitOpsPromotion{}, errors.New("actor is required")
+	}
+	if decision != "approve" && decision != "reject" {
+		return GitOpsPromotion{}, errors.New("decision must be approve or reject")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.promotions[strings.TrimSpace(id)]
+	if !ok {
+		return GitOpsPromotion{}, errors.New("promotion pipeline not found")
+	}
+	if item.Status == PromotionStatusCompleted || item.CurrentIndex+1 >= len(item.Stages) {
+		return GitOpsPromotion{}, errors.New("promotion pipeline already completed")
+	}
+	stage := item.Stages[item.CurrentIndex+1]
+	if decision == "approve" && s.gate != nil {
+		if _, err := s.gate(clonePromotion(*item), stage, actor); err != nil {
+			return GitOpsPromotion{}, err
+		}
+	}
+	now := time.Now().UTC()
+	item.Approvals = append(item.Approvals, PromotionApproval{
+		Actor:     actor,
+		Stage:     stage,
+		Decision:  decision,
+		Comment:   strings.TrimSpace(comment),
+		CreatedAt: now,
+	})
+	item.UpdatedAt = now
+	return clonePromotion(*item), nil
+}
+
 func (s *GitOpsPromotionStore) Advance(id, artifactDigest, actor, note string) (GitOpsPromotion, error) {
 	id = strings.TrimSpace(id)
 	artifactDigest = strings.ToLower(strings.TrimSpace(artifactDigest))
@@ -139,6 +197,15 @@ func (s *GitOpsPromotionStore) Advance(id, artifactDigest, actor, note string) (
 		item.UpdatedAt = time.Now().UTC()
 		return clonePromotion(*item), nil
 	}
+	if s.gate != nil {
+		approved, err := s.gate(clonePromotion(*item), item.Stages[next], "")
+		if err != nil {
+			return GitOpsPromotion{}, err
+		}
+		if !approved {
+			return GitOpsPromotion{}, errors.New("promotion to stage " + item.Stages[next] + " is awaiting approval")
+		}
+	}
 	item.CurrentIndex = next
 	item.CurrentStage = item.Stages[next]
 	if next == len(item.Stages)-1 {
@@ -181,5 +248,6 @@ func clonePromotion(in GitOpsPromotion) GitOpsPromotion {
 	out := in
 	out.Stages = append([]string{}, in.Stages...)
 	out.History = append([]PromotionStageRecord{}, in.History...)
+	out.Approvals = append([]PromotionApproval(nil), in.Approvals...)
 	return out
 }
@@ -0,0 +1,455 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GrainModule is a Salt-style custom grain module: a script shipped to agents
+// by a file-sync pipeline whose stdout, a JSON object, is merged into the
+// node's grains. Agents run it sandboxed and cache the result for
+// CacheTTLSeconds.
+type GrainModule struct {
+	Name            string    `json:"name"`
+	Pipeline        string    `json:"pipeline"`
+	Path            string    `json:"path"`
+	Interpreter     string    `json:"interpreter,omitempty"`
+	TimeoutSeconds  int       `json:"timeout_seconds"`
+	CacheTTLSeconds int       `json:"cache_ttl_seconds"`
+	MaxOutputBytes  int       `json:"max_output_bytes"`
+	AllowEnv        []string  `json:"allow_env,omitempty"`
+	Enabled         bool      `json:"enabled"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type GrainModuleInput struct {
+	Name            string   `json:"name"`
+	Pipeline        string   `json:"pipeline"`
+	Path            string   `json:"path"`
+	Interpreter     string   `json:"interpreter,omitempty"`
+	TimeoutSeconds  int      `json:"timeout_seconds,omitempty"`
+	CacheTTLSeconds int      `json:"cache_ttl_seconds,omitempty"`
+	MaxOutputBytes  int      `json:"max_output_bytes,omitempty"`
+	AllowEnv        []string `json:"allow_env,omitempty"`
+	Disabled        bool     `json:"disabled,omitempty"`
+}
+
+// GrainModuleResult is one module run on one node. A failed run carries the
+// previous run's grains forward so targeting does not flap while it retries.
+type GrainModuleResult struct {
+	Node       string         `json:"node"`
+	Module     string         `json:"module"`
+	Grains     map[string]any `json:"grains,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	RanAt      time.Time      `json:"ran_at"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
+
+type GrainModuleStore struct {
+	mu      sync.RWMutex
+	modules map[string]*GrainModule
+	results map[string]*GrainModuleResult
+	guard   func(command string, args []string) error
+}
+
+// grainModuleEnv is the fixed set of control-plane environment variables a
+// module may ask for through allow_env; everything else, credentials
+// included, stays out of module scripts.
+var grainModuleEnv = map[string]bool{
+	"LANG":        true,
+	"LC_ALL":      true,
+	"TZ":          true,
+	"HTTP_PROXY":  true,
+	"HTTPS_PROXY": true,
+	"NO_PROXY":    true,
+}
+
+func NewGrainModuleStore() *GrainModuleStore {
+	return &GrainModuleStore{
+		modules: map[string]*GrainModule{},
+		results: map[string]*GrainModuleResult{},
+	}
+}
+
+// SetExecGuard installs the check a module interpreter must pass, both when
+// the module is saved and before each local run. Without a guard modules
+// with an interpreter are rejected.
+func (s *GrainModuleStore) SetExecGuard(fn func(command string, args []string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guard = fn
+}
+
+func (s *GrainModuleStore) checkInterpreter(interpreter string) error {
+	s.mu.RLock()
+	guard := s.guard
+	s.mu.RUnlock()
+	if guard == nil {
+		return errors.New("grain module interpreters are disabled")
+	}
+	return guard(interpreter, nil)
+}
+
+func (s *GrainModuleStore) Upsert(in GrainModuleInput) (GrainModule, error) {
+	name := strings.ToLower(strings.TrimSpace(in.Name))
+	if name == "" {
+		return GrainModule{}, errors.New("name is required")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return GrainModule{}, errors.New("name may only contain letters, digits, '_' and '-'")
+		}
+	}
+	pipeline := strings.TrimSpace(in.Pipeline)
+	if pipeline == "" {
+		return GrainModule{}, errors.New("pipeline is required")
+	}
+	path := filepath.ToSlash(filepath.Clean(strings.TrimSpace(in.Path)))
+	if strings.TrimSpace(in.Path) == "" || filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, "../") {
+		return GrainModule{}, errors.New("path must be relative to the pipeline live path")
+	}
+	timeout := in.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = 10
+	}
+	if timeout > 300 {
+		timeout = 300
+	}
+	ttl := in.CacheTTLSeconds
+	if ttl <= 0 {
+		ttl = 3600
+	}
+	interpreter := strings.TrimSpace(in.Interpreter)
+	if interpreter != "" {
+		if err := s.checkInterpreter(interpreter); err != nil {
+			return GrainModule{}, err
+		}
+	}
+	allowEnv := make([]string, 0, len(in.AllowEnv))
+	for _, key := range in.AllowEnv {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !grainModuleEnv[key] {
+			return GrainModule{}, errors.New("allow_env only permits LANG, LC_ALL, TZ, HTTP_PROXY, HTTPS_PROXY, and NO_PROXY")
+		}
+		allowEnv = append(allowEnv, key)
+	}
+	maxOutput := in.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = 64 << 10
+	}
+	if maxOutput > 1<<20 {
+		maxOutput = 1 << 20
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.modules[name]
+	if !ok {
+		item = &GrainModule{Name: name, CreatedAt: now}
+		s.modules[name] = item
+	}
+	item.Pipeline = pipeline
+	item.Path = path
+	item.Interpreter = interpreter
+	item.TimeoutSeconds = timeout
+	item.CacheTTLSeconds = ttl
+	item.MaxOutputBytes = maxOutput
+	item.AllowEnv = allowEnv
+	item.Enabled = !in.Disabled
+	item.UpdatedAt = now
+	// A changed module invalidates what agents cached from the old one.
+	s.dropResultsLocked(name)
+	return cloneGrainModule(*item), nil
+}
+
+func (s *GrainModuleStore) Get(name string) (GrainModule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.modules[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return GrainModule{}, false
+	}
+	return cloneGrainModule(*item), true
+}
+
+func (s *GrainModuleStore) List() []GrainModule {
+	s.mu.RLock()
+	out := make([]GrainModule, 0, len(s.modules))
+	for _, item := range s.modules {
+		out = append(out, cloneGrainModule(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *GrainModuleStore) Delete(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.modules[name]; !ok {
+		return errors.New("grain module not found")
+	}
+	delete(s.modules, name)
+	s.dropResultsLocked(name)
+	return nil
+}
+
+// Due returns the enabled modules whose cached result for node is missing or
+// expired, i.e. the ones the node's agent should run now.
+func (s *GrainModuleStore) Due(node string, now time.Time) []GrainModule {
+	node = normalizeFactNode(node)
+	s.mu.RLock()
+	out := make([]GrainModule, 0, len(s.modules))
+	for _, item := range s.modules {
+		if !item.Enabled {
+			continue
+		}
+		if res, ok := s.results[grainResultKey(node, item.Name)]; ok && res.ExpiresAt.After(now) {
+			continue
+		}
+		out = append(out, cloneGrainModule(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Record caches an agent's module result. Failures are retried after a
+// minute instead of waiting out the full TTL.
+func (s *GrainModuleStore) Record(in GrainModuleResult) (GrainModuleResult, error) {
+	node := normalizeFactNode(in.Node)
+	if node == "" {
+		return GrainModuleResult{}, errors.New("node is required")
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	module, ok := s.modules[strings.ToLower(strings.TrimSpace(in.Module))]
+	if !ok {
+		return GrainModuleResult{}, errors.New("grain module not found")
+	}
+	key := grainResultKey(node, module.Name)
+	item := GrainModuleResult{
+		Node:       node,
+		Module:     module.Name,
+		Grains:     cloneFactMap(in.Grains),
+		Error:      strings.TrimSpace(in.Error),
+		DurationMS: in.DurationMS,
+		RanAt:      now,
+		ExpiresAt:  now.Add(time.Duration(module.CacheTTLSeconds) * time.Second),
+	}
+	if item.Error != "" {
+		item.Grains = map[string]any{}
+		if prev, ok := s.results[key]; ok && prev.ExpiresAt.After(now) {
+			item.Grains = cloneFactMap(prev.Grains)
+		}
+		if retry := now.Add(time.Minute); retry.Before(item.ExpiresAt) {
+			item.ExpiresAt = retry
+		}
+	}
+	s.results[key] = &item
+	return cloneGrainModuleResult(item), nil
+}
+
+// Results returns the cached module results, optionally for one node.
+func (s *GrainModuleStore) Results(node string) []GrainModuleResult {
+	node = normalizeFactNode(node)
+	s.mu.RLock()
+	out := make([]GrainModuleResult, 0, len(s.results))
+	for _, item := range s.results {
+		if node == "" || item.Node == node {
+			out = append(out, cloneGrainModuleResult(*item))
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Node != out[j].Node {
+			return out[i].Node < out[j].Node
+		}
+		return out[i].Module < out[j].Module
+	})
+	return out
+}
+
+// Grains merges the unexpired custom grains of every node, applying modules
+// in name order so later modules win on key collisions.
+func (s *GrainModuleStore) Grains(now time.Time) map[string]map[string]any {
+	out := map[string]map[string]any{}
+	for _, item := range s.Results("") {
+		if !item.ExpiresAt.After(now) || len(item.Grains) == 0 {
+			continue
+		}
+		if out[item.Node] == nil {
+			out[item.Node] = map[string]any{}
+		}
+		for k, v := range item.Grains {
+			out[item.Node][k] = v
+		}
+	}
+	return out
+}
+
+func (s *GrainModuleStore) dropResultsLocked(module string) {
+	for key, item := range s.results {
+		if item.Module == module {
+			delete(s.results, key)
+		}
+	}
+}
+
+// Run runs a module script from root, the pipeline's live path, the way an
+// agent does: in an empty scratch directory, with only PATH, MASTERCHEF_NODE,
+// and the module's allowed variables in the environment, no stdin, a
+// timeout, and a cap on stdout. The interpreter is re-checked against the
+// exec guard before every run.
+func (s *GrainModuleStore) Run(ctx context.Context, module GrainModule, root, node string) GrainModuleResult {
+	start := time.Now()
+	out := GrainModuleResult{Node: normalizeFactNode(node), Module: module.Name}
+	var grains map[string]any
+	var err error
+	if module.Interpreter != "" {
+		err = s.checkInterpreter(module.Interpreter)
+	}
+	if err == nil {
+		grains, err = runGrainModuleScript(ctx, module, root, out.Node)
+	}
+	out.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Grains = grains
+	return out
+}
+
+func runGrainModuleScript(ctx context.Context, module GrainModule, root, node string) (map[string]any, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	script := filepath.Join(root, filepath.FromSlash(module.Path))
+	if rel, err := filepath.Rel(root, script); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, errors.New("grain module path escapes the pipeline live path")
+	}
+	info, err := os.Stat(script)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, errors.New("grain module script not found: " + module.Path)
+	}
+	// File sync does not carry mode bits, so unexecutable scripts need an
+	// interpreter.
+	if module.Interpreter == "" && info.Mode().Perm()&0o111 == 0 {
+		return nil, errors.New("grain module script is not executable; set interpreter")
+	}
+	scratch, err := os.MkdirTemp("", "masterchef-grain-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	timeout := time.Duration(module.TimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	name, args := script, []string{}
+	if module.Interpreter != "" {
+		name, args = module.Interpreter, []string{script}
+	}
+	cmd := boundedCommand(ctx, name, args...)
+	cmd.Dir = scratch
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=" + scratch, "TMPDIR=" + scratch, "MASTERCHEF_NODE=" + node}
+	for _, key := range module.AllowEnv {
+		if !grainModuleEnv[key] {
+			continue
+		}
+		if v, ok := os.LookupEnv(key); ok {
+			cmd.Env = append(cmd.Env, key+"="+v)
+		}
+	}
+	stdout := &cappedBuffer{limit: module.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: 4 << 10, truncate: true}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	switch {
+	case stdout.overflow:
+		return nil, errors.New("grain module output exceeds " + itoa(int64(module.MaxOutputBytes)) + " bytes")
+	case ctx.Err() == context.DeadlineExceeded:
+		return nil, errors.New("grain module timed out after " + timeout.String())
+	case err != nil:
+		msg := strings.TrimSpace(stderr.buf.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, errors.New("grain module failed: " + msg)
+	}
+	var grains map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.buf.Bytes()), &grains); err != nil || grains == nil {
+		return nil, errors.New("grain module output must be a JSON object")
+	}
+	return grains, nil
+}
+
+// cappedBuffer keeps at most limit bytes. Past the limit it either drops the
+// rest (truncate) or fails the write, which stops a runaway script.
+type cappedBuffer struct {
+	limit    int
+	truncate bool
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) <= b.limit {
+		return b.buf.Write(p)
+	}
+	b.overflow = true
+	if !b.truncate {
+		return 0, errors.New("output limit exceeded")
+	}
+	b.buf.Write(p[:b.limit-b.buf.Len()])
+	return len(p), nil
+}
+
+// MergeCustomGrains overlays custom grains on a grain record. The id grain
+// always stays the node name.
+func MergeCustomGrains(rec GrainRecord, custom map[string]any) GrainRecord {
+	if rec.Grains == nil {
+		rec.Grains = map[string]any{}
+	}
+	for k, v := range custom {
+		if k == "id" {
+			continue
+		}
+		rec.Grains[k] = v
+	}
+	return rec
+}
+
+func grainResultKey(node, module string) string {
+	return node + "\x00" + module
+}
+
+func cloneGrainModule(in GrainModule) GrainModule {
+	out := in
+	out.AllowEnv = append([]string{}, in.AllowEnv...)
+	return out
+}
+
+func cloneGrainModuleResult(in GrainModuleResult) GrainModuleResult {
+	out := in
+	out.Grains = cloneFactMap(in.Grains)
+	return out
+}
@@ -0,0 +1,138 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrainModuleStoreDueRecordAndGrains(t *testing.T) {
+	store := NewGrainModuleStore()
+	if _, err := store.Upsert(GrainModuleInput{Name: "rack", Pipeline: "filesync-1", Path: "../etc/passwd"}); err == nil {
+		t.Fatalf("expected path outside the live path to be rejected")
+	}
+	mod, err := store.Upsert(GrainModuleInput{Name: "Rack", Pipeline: "filesync-1", Path: "grains/rack.sh", CacheTTLSeconds: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod.Name != "rack" || !mod.Enabled || mod.TimeoutSeconds != 10 {
+		t.Fatalf("unexpected module defaults %+v", mod)
+	}
+
+	now := time.Now().UTC()
+	if due := store.Due("web-1", now); len(due) != 1 {
+		t.Fatalf("expected module due before first run, got %+v", due)
+	}
+	if _, err := store.Record(GrainModuleResult{Node: "web-1", Module: "rack", Grains: map[string]any{"rack": "r12"}}); err != nil {
+		t.Fatal(err)
+	}
+	if due := store.Due("web-1", now); len(due) != 0 {
+		t.Fatalf("expected cached result to suppress rerun, got %+v", due)
+	}
+	if due := store.Due("web-1", now.Add(2*time.Minute)); len(due) != 1 {
+		t.Fatalf("expected module due again after ttl, got %+v", due)
+	}
+
+	// A failing rerun keeps the previous grains.
+	if _, err := store.Record(GrainModuleResult{Node: "web-1", Module: "rack", Error: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	if grains := store.Grains(time.Now().UTC())["web-1"]; grains["rack"] != "r12" {
+		t.Fatalf("expected previous grains kept after failure, got %+v", grains)
+	}
+	if grains := store.Grains(now.Add(2 * time.Minute)); len(grains) != 0 {
+		t.Fatalf("expected expired grains dropped, got %+v", grains)
+	}
+
+	if _, err := store.Upsert(GrainModuleInput{Name: "rack", Pipeline: "filesync-1", Path: "grains/rack.sh"}); err != nil {
+		t.Fatal(err)
+	}
+	if results := store.Results("web-1"); len(results) != 0 {
+		t.Fatalf("expected module update to invalidate cached results, got %+v", results)
+	}
+}
+
+func TestRunGrainModuleSandbox(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ok.sh", "#!/bin/sh\nprintf '{\"node\":\"%s\",\"cwd\":\"%s\",\"secret\":\"%s\",\"tz\":\"%s\"}' \"$MASTERCHEF_NODE\" \"$(pwd)\" \"$GRAIN_TEST_SECRET\" \"$TZ\"\n")
+	write("big.sh", "#!/bin/sh\nhead -c 4096 /dev/zero | tr '\\0' a\n")
+	write("slow.sh", "#!/bin/sh\nsleep 5\n")
+	write("text.sh", "#!/bin/sh\necho not-json\n")
+	t.Setenv("GRAIN_TEST_SECRET", "hidden")
+	t.Setenv("TZ", "UTC")
+	store := NewGrainModuleStore()
+
+	res := store.Run(context.Background(), GrainModule{Name: "ok", Path: "ok.sh", TimeoutSeconds: 5, MaxOutputBytes: 1024}, root, "Web-1")
+	if res.Error != "" || res.Grains["node"] != "web-1" {
+		t.Fatalf("expected grains from module, got %+v", res)
+	}
+	if res.Grains["secret"] != "" {
+		t.Fatalf("expected environment not listed in allow_env to be withheld, got %+v", res.Grains)
+	}
+	if cwd, _ := res.Grains["cwd"].(string); strings.HasPrefix(cwd, root) {
+		t.Fatalf("expected module to run outside the live path, got %s", cwd)
+	}
+	res = store.Run(context.Background(), GrainModule{Name: "ok", Path: "ok.sh", TimeoutSeconds: 5, MaxOutputBytes: 1024, AllowEnv: []string{"TZ", "GRAIN_TEST_SECRET"}}, root, "web-1")
+	if res.Grains["tz"] != "UTC" || res.Grains["secret"] != "" {
+		t.Fatalf("expected only fixed allow_env variables passed through, got %+v", res)
+	}
+
+	if res := store.Run(context.Background(), GrainModule{Name: "big", Path: "big.sh", TimeoutSeconds: 5, MaxOutputBytes: 1024}, root, "web-1"); !strings.Contains(res.Error, "exceeds") {
+		t.Fatalf("expected output cap error, got %+v", res)
+	}
+	if res := store.Run(context.Background(), GrainModule{Name: "slow", Path: "slow.sh", TimeoutSeconds: 1, MaxOutputBytes: 1024}, root, "web-1"); !strings.Contains(res.Error, "timed out") {
+		t.Fatalf("expected timeout error, got %+v", res)
+	}
+	if res := store.Run(context.Background(), GrainModule{Name: "text", Path: "text.sh", TimeoutSeconds: 5, MaxOutputBytes: 1024}, root, "web-1"); !strings.Contains(res.Error, "JSON object") {
+		t.Fatalf("expected non-JSON output error, got %+v", res)
+	}
+	if res := store.Run(context.Background(), GrainModule{Name: "missing", Path: "missing.sh", TimeoutSeconds: 5, MaxOutputBytes: 1024}, root, "web-1"); !strings.Contains(res.Error, "not found") {
+		t.Fatalf("expected missing script error, got %+v", res)
+	}
+}
+
+func TestGrainModuleStoreGuardsInterpreterAndEnv(t *testing.T) {
+	store := NewGrainModuleStore()
+	in := GrainModuleInput{Name: "rack", Pipeline: "filesync-1", Path: "rack.sh", Interpreter: "/bin/sh"}
+	if _, err := store.Upsert(in); err == nil {
+		t.Fatalf("expected interpreter to be rejected without a guard")
+	}
+	allowed := true
+	store.SetExecGuard(func(command string, args []string) error {
+		if !allowed || command != "/bin/sh" {
+			return errors.New("not allowlisted")
+		}
+		return nil
+	})
+	if _, err := store.Upsert(GrainModuleInput{Name: "rack", Pipeline: "filesync-1", Path: "rack.sh", Interpreter: "python3"}); err == nil {
+		t.Fatalf("expected unlisted interpreter to be rejected")
+	}
+	if _, err := store.Upsert(GrainModuleInput{Name: "rack", Pipeline: "filesync-1", Path: "rack.sh", AllowEnv: []string{"AWS_SECRET_ACCESS_KEY"}}); err == nil {
+		t.Fatalf("expected allow_env outside the fixed list to be rejected")
+	}
+	mod, err := store.Upsert(in)
+	if err != nil {
+		t.Fatalf("upsert guarded module failed: %v", err)
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "rack.sh"), []byte("printf '{\"rack\":\"r1\"}'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res := store.Run(context.Background(), mod, root, "web-1"); res.Error != "" || res.Grains["rack"] != "r1" {
+		t.Fatalf("expected guarded interpreter to run, got %+v", res)
+	}
+	allowed = false
+	if res := store.Run(context.Background(), mod, root, "web-1"); res.Error == "" {
+		t.Fatalf("expected guard to block a previously saved interpreter")
+	}
+}
@@ -40,6 +40,42 @@ func GrainQueryToFactQuery(in GrainQueryInput) FactCacheQuery {
 	}
 }
 
+// FilterGrainRecords applies a grain query to records directly, so grains
+// that only exist outside the fact cache (custom grain modules) are
+// targetable too.
+func FilterGrainRecords(items []GrainRecord, in GrainQueryInput) []GrainRecord {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	field := strings.TrimSpace(in.Grain)
+	equals := strings.TrimSpace(in.Equals)
+	contains := strings.ToLower(strings.TrimSpace(in.Contains))
+	out := make([]GrainRecord, 0, minInt(limit, len(items)))
+	for _, item := range items {
+		if len(out) >= limit {
+			break
+		}
+		if field == "" && equals == "" && contains == "" {
+			out = append(out, item)
+			continue
+		}
+		val, ok := lookupFactField(item.Grains, field)
+		if !ok {
+			continue
+		}
+		value := strings.TrimSpace(factValueString(val))
+		if equals != "" && value != equals {
+			continue
+		}
+		if contains != "" && !strings.Contains(strings.ToLower(value), contains) {
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
 func normalizeGrainField(field string) string {
 	field = strings.TrimSpace(field)
 	switch strings.ToLower(field) {
@@ -0,0 +1,245 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthProbeRunner executes http, tcp, and command probes on their
+// configured intervals and records the results in a HealthProbeStore.
+// OnCheck is called after every recorded check.
+type HealthProbeRunner struct {
+	mu      sync.Mutex
+	store   *HealthProbeStore
+	client  *http.Client
+	onCheck func(HealthProbeTarget, HealthProbeCheck)
+	loops   map[string]healthProbeLoop
+}
+
+type healthProbeLoop struct {
+	signature string
+	cancel    context.CancelFunc
+}
+
+func NewHealthProbeRunner(store *HealthProbeStore, onCheck func(HealthProbeTarget, HealthProbeCheck)) *HealthProbeRunner {
+	return &HealthProbeRunner{
+		store: store,
+		client: &http.Client{
+			// Redirects are reported as the probe result rather than followed.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		onCheck: onCheck,
+		loops:   map[string]healthProbeLoop{},
+	}
+}
+
+// Sync starts loops for enabled executable targets, restarts loops whose
+// settings changed, and stops loops for targets that were disabled.
+func (r *HealthProbeRunner) Sync() {
+	want := map[string]HealthProbeTarget{}
+	for _, target := range r.store.ListTargets() {
+		if target.Enabled && target.Kind != "manual" {
+			want[target.ID] = target
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, loop := range r.loops {
+		target, ok := want[id]
+		if !ok || healthProbeSignature(target) != loop.signature {
+			loop.cancel()
+			delete(r.loops, id)
+		}
+	}
+	for id, target := range want {
+		if _, ok := r.loops[id]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		r.loops[id] = healthProbeLoop{signature: healthProbeSignature(target), cancel: cancel}
+		go r.loop(ctx, id, time.Duration(target.IntervalSeconds)*time.Second)
+	}
+}
+
+// Running returns how many targets have an active probe loop.
+func (r *HealthProbeRunner) Running() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.loops)
+}
+
+func (r *HealthProbeRunner) Shutdown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, loop := range r.loops {
+		loop.cancel()
+		delete(r.loops, id)
+	}
+}
+
+func (r *HealthProbeRunner) loop(ctx context.Context, targetID string, interval time.Duration) {
+	for {
+		_, _ = r.RunNow(ctx, targetID)
+		t := time.NewTimer(interval + randomJitter(interval/10))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunNow executes a target's probe once and records the result.
+func (r *HealthProbeRunner) RunNow(ctx context.Context, targetID string) (HealthProbeCheck, error) {
+	target, err := r.store.GetTarget(targetID)
+	if err != nil {
+		return HealthProbeCheck{}, err
+	}
+	if target.Kind == "manual" {
+		return HealthProbeCheck{}, errors.New("manual probe targets cannot be executed")
+	}
+	in := r.Execute(ctx, target)
+	if ctx.Err() != nil {
+		// Stopped mid-probe; the result reflects the cancellation, not the target.
+		return HealthProbeCheck{}, ctx.Err()
+	}
+	check, err := r.store.RecordCheck(in)
+	if err != nil {
+		return HealthProbeCheck{}, err
+	}
+	if r.onCheck != nil {
+		r.onCheck(target, check)
+	}
+	return check, nil
+}
+
+// Execute runs a target's probe without recording it.
+func (r *HealthProbeRunner) Execute(ctx context.Context, target HealthProbeTarget) HealthProbeCheckInput {
+	timeout := time.Duration(target.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	var status, message string
+	switch target.Kind {
+	case "http":
+		status, message = r.probeHTTP(ctx, target)
+	case "tcp":
+		status, message = probeTCP(ctx, target)
+	case "command":
+		status, message = r.probeCommand(ctx, target)
+	default:
+		status, message = "unhealthy", "unsupported probe kind "+target.Kind
+	}
+	latency := int(time.Since(start).Milliseconds())
+	if status == "healthy" && target.DegradedLatencyMS > 0 && latency > target.DegradedLatencyMS {
+		status = "degraded"
+		message = "latency " + strconv.Itoa(latency) + "ms exceeds " + strconv.Itoa(target.DegradedLatencyMS) + "ms"
+	}
+	return HealthProbeCheckInput{
+		TargetID:   target.ID,
+		Status:     status,
+		Source:     "probe",
+		LatencyMS:  latency,
+		Message:    message,
+		ObservedAt: time.Now().UTC(),
+	}
+}
+
+// probeHTTP treats 2xx/3xx as healthy unless expect_status pins an exact
+// code.
+func (r *HealthProbeRunner) probeHTTP(ctx context.Context, target HealthProbeTarget) (string, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.Endpoint, nil)
+	if err != nil {
+		return "unhealthy", err.Error()
+	}
+	req.Header.Set("User-Agent", "masterchef-health-probe")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "unhealthy", "http probe timed out"
+		}
+		return "unhealthy", err.Error()
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	message := "http status " + strconv.Itoa(resp.StatusCode)
+	if target.ExpectStatus > 0 {
+		if resp.StatusCode != target.ExpectStatus {
+			return "unhealthy", message + ", expected " + strconv.Itoa(target.ExpectStatus)
+		}
+		return "healthy", message
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return "healthy", message
+	}
+	return "unhealthy", message
+}
+
+func probeTCP(ctx context.Context, target HealthProbeTarget) (string, string) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target.Endpoint)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "unhealthy", "tcp connect timed out"
+		}
+		return "unhealthy", err.Error()
+	}
+	_ = conn.Close()
+	return "healthy", "tcp connect ok"
+}
+
+// probeCommand follows the Nagios plugin convention: exit 0 is healthy,
+// exit 1 is degraded, and anything else is unhealthy. The command must pass
+// the store's exec guard. The target's host and endpoint are exported as
+// MC_PROBE_HOST and MC_PROBE_ENDPOINT.
+func (r *HealthProbeRunner) probeCommand(ctx context.Context, target HealthProbeTarget) (string, string) {
+	name, args, err := r.store.checkCommand(target.Command)
+	if err != nil {
+		return "unhealthy", err.Error()
+	}
+	cmd := boundedCommand(ctx, name, args...)
+	cmd.Env = append(cmd.Environ(), "MC_PROBE_HOST="+target.Host, "MC_PROBE_ENDPOINT="+target.Endpoint)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	message := strings.TrimSpace(out.String())
+	if len(message) > 512 {
+		message = message[:512]
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return "unhealthy", "command probe timed out"
+	}
+	if err == nil {
+		return "healthy", message
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "degraded", message
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return "unhealthy", message
+}
+
+func healthProbeSignature(t HealthProbeTarget) string {
+	return strings.Join([]string{
+		t.Kind, t.Endpoint, t.Command, t.Host,
+		strconv.Itoa(t.ExpectStatus), strconv.Itoa(t.IntervalSeconds),
+		strconv.Itoa(t.TimeoutSeconds), strconv.Itoa(t.DegradedLatencyMS),
+	}, "|")
+}
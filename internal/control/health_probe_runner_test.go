@@ -0,0 +1,129 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthProbeRunnerExecutesProbes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	script := filepath.Join(t.TempDir(), "check.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$2\"\nexit \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	store := NewHealthProbeStore()
+	if _, err := store.UpsertTarget(HealthProbeTargetInput{Name: "cmd-unguarded", Kind: "command", Command: script + " 0"}); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("expected command probes to be disabled without a guard, got %v", err)
+	}
+	var guarded []string
+	store.SetExecGuard(func(command string, args []string) error {
+		if command == "/bin/rm" {
+			return errors.New("command not allowed")
+		}
+		guarded = append(guarded, command)
+		return nil
+	})
+	if _, err := store.UpsertTarget(HealthProbeTargetInput{Name: "cmd-denied", Kind: "command", Command: "/bin/rm -rf /tmp/x"}); err == nil {
+		t.Fatalf("expected guard to reject command probe")
+	}
+	runner := NewHealthProbeRunner(store, nil)
+	cases := []struct {
+		in   HealthProbeTargetInput
+		want string
+	}{
+		{HealthProbeTargetInput{Name: "http-up", Kind: "http", Endpoint: srv.URL + "/healthz"}, "healthy"},
+		{HealthProbeTargetInput{Name: "http-down", Kind: "http", Endpoint: srv.URL + "/down"}, "unhealthy"},
+		{HealthProbeTargetInput{Name: "http-expect", Kind: "http", Endpoint: srv.URL + "/down", ExpectStatus: 503}, "healthy"},
+		{HealthProbeTargetInput{Name: "tcp-up", Kind: "tcp", Endpoint: ln.Addr().String()}, "healthy"},
+		{HealthProbeTargetInput{Name: "cmd-ok", Kind: "command", Command: script + " 0"}, "healthy"},
+		{HealthProbeTargetInput{Name: "cmd-warn", Kind: "command", Command: script + " 1 warn"}, "degraded"},
+		{HealthProbeTargetInput{Name: "cmd-crit", Kind: "command", Command: script + " 2"}, "unhealthy"},
+		{HealthProbeTargetInput{Name: "cmd-slow", Kind: "command", Command: sleep + " 5", TimeoutSeconds: 1}, "unhealthy"},
+		{HealthProbeTargetInput{Name: "cmd-shell", Kind: "command", Command: script + " 0; " + script + " 2"}, "unhealthy"},
+	}
+	for _, tc := range cases {
+		target, err := store.UpsertTarget(tc.in)
+		if err != nil {
+			t.Fatalf("upsert %s failed: %v", tc.in.Name, err)
+		}
+		check, err := runner.RunNow(context.Background(), target.ID)
+		if err != nil {
+			t.Fatalf("run %s failed: %v", tc.in.Name, err)
+		}
+		if check.Status != tc.want || check.Source != "probe" {
+			t.Fatalf("%s: expected %s probe check, got %+v", tc.in.Name, tc.want, check)
+		}
+	}
+	// Each command probe passes the guard when saved and again when run.
+	if len(guarded) != 10 {
+		t.Fatalf("expected 10 guard checks, got %d", len(guarded))
+	}
+	manual, _ := store.UpsertTarget(HealthProbeTargetInput{Name: "manual"})
+	if _, err := runner.RunNow(context.Background(), manual.ID); err == nil {
+		t.Fatalf("expected manual target to be rejected")
+	}
+}
+
+func TestHealthProbeRunnerSyncSchedulesEnabledTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	store := NewHealthProbeStore()
+	checks := make(chan HealthProbeCheck, 10)
+	runner := NewHealthProbeRunner(store, func(_ HealthProbeTarget, check HealthProbeCheck) {
+		checks <- check
+	})
+	defer runner.Shutdown()
+	target, err := store.UpsertTarget(HealthProbeTargetInput{Name: "api", Kind: "http", Endpoint: srv.URL, IntervalSeconds: 60, Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpsertTarget(HealthProbeTargetInput{Name: "disabled", Kind: "http", Endpoint: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	runner.Sync()
+	if runner.Running() != 1 {
+		t.Fatalf("expected one probe loop, got %d", runner.Running())
+	}
+	select {
+	case check := <-checks:
+		if check.TargetID != target.ID || check.Status != "healthy" {
+			t.Fatalf("unexpected scheduled check %+v", check)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("scheduled probe did not run")
+	}
+	if _, err := store.UpsertTarget(HealthProbeTargetInput{Name: "api", Kind: "http", Endpoint: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	runner.Sync()
+	if runner.Running() != 0 {
+		t.Fatalf("expected disabled target loop to stop, got %d", runner.Running())
+	}
+}
@@ -2,45 +2,90 @@ package control
 
 import (
 	"errors"
+	"net"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// HealthProbeTarget describes something to check. Manual targets only
+// receive externally reported checks; http, tcp, and command targets are
+// executed periodically by a HealthProbeRunner. For http probes Endpoint is
+// a URL, for tcp probes a host:port.
 type HealthProbeTarget struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Service   string    `json:"service,omitempty"`
-	Endpoint  string    `json:"endpoint,omitempty"`
-	Enabled   bool      `json:"enabled"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	Service           string    `json:"service,omitempty"`
+	Host              string    `json:"host,omitempty"`
+	Kind              string    `json:"kind"` // manual|http|tcp|command
+	Endpoint          string    `json:"endpoint,omitempty"`
+	Command           string    `json:"command,omitempty"`
+	ExpectStatus      int       `json:"expect_status,omitempty"`
+	IntervalSeconds   int       `json:"interval_seconds,omitempty"`
+	TimeoutSeconds    int       `json:"timeout_seconds,omitempty"`
+	DegradedLatencyMS int       `json:"degraded_latency_ms,omitempty"`
+	FlapWindow        int       `json:"flap_window,omitempty"`
+	FlapThreshold     int       `json:"flap_threshold,omitempty"`
+	Enabled           bool      `json:"enabled"`
+	Status            string    `json:"status,omitempty"`
+	StatusChangedAt   time.Time `json:"status_changed_at,omitempty"`
+	Flapping          bool      `json:"flapping"`
+	LastCheckedAt     time.Time `json:"last_checked_at,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type HealthProbeTargetInput struct {
-	Name     string `json:"name"`
-	Service  string `json:"service,omitempty"`
-	Endpoint string `json:"endpoint,omitempty"`
-	Enabled  bool   `json:"enabled"`
+	Name              string `json:"name"`
+	Service           string `json:"service,omitempty"`
+	Host              string `json:"host,omitempty"`
+	Kind              string `json:"kind,omitempty"`
+	Endpoint          string `json:"endpoint,omitempty"`
+	Command           string `json:"command,omitempty"`
+	ExpectStatus      int    `json:"expect_status,omitempty"`
+	IntervalSeconds   int    `json:"interval_seconds,omitempty"`
+	TimeoutSeconds    int    `json:"timeout_seconds,omitempty"`
+	DegradedLatencyMS int    `json:"degraded_latency_ms,omitempty"`
+	FlapWindow        int    `json:"flap_window,omitempty"`
+	FlapThreshold     int    `json:"flap_threshold,omitempty"`
+	Enabled           bool   `json:"enabled"`
 }
 
 type HealthProbeCheck struct {
-	ID         string    `json:"id"`
-	TargetID   string    `json:"target_id"`
-	Status     string    `json:"status"` // healthy|degraded|unhealthy
-	LatencyMS  int       `json:"latency_ms,omitempty"`
-	Message    string    `json:"message,omitempty"`
-	ObservedAt time.Time `json:"observed_at"`
+	ID             string    `json:"id"`
+	TargetID       string    `json:"target_id"`
+	Status         string    `json:"status"` // healthy|degraded|unhealthy
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	Changed        bool      `json:"changed"`
+	Flapping       bool      `json:"flapping"`
+	FlapChanged    bool      `json:"flap_changed,omitempty"`
+	Source         string    `json:"source"` // manual|probe
+	LatencyMS      int       `json:"latency_ms,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	ObservedAt     time.Time `json:"observed_at"`
 }
 
 type HealthProbeCheckInput struct {
 	TargetID   string    `json:"target_id"`
 	Status     string    `json:"status"`
+	Source     string    `json:"source,omitempty"`
 	LatencyMS  int       `json:"latency_ms,omitempty"`
 	Message    string    `json:"message,omitempty"`
 	ObservedAt time.Time `json:"observed_at,omitempty"`
 }
 
+// HealthProbeSummary rolls the latest probe status of every enabled target
+// up for fleet health views.
+type HealthProbeSummary struct {
+	Targets   int      `json:"targets"`
+	Healthy   int      `json:"healthy"`
+	Degraded  int      `json:"degraded"`
+	Unhealthy int      `json:"unhealthy"`
+	Unknown   int      `json:"unknown"`
+	Flapping  int      `json:"flapping"`
+	Failing   []string `json:"failing,omitempty"`
+}
+
 type HealthProbeGateRequest struct {
 	TargetIDs         []string `json:"target_ids,omitempty"`
 	MinHealthyPercent int      `json:"min_healthy_percent,omitempty"`
@@ -52,6 +97,7 @@ type HealthProbeGateResult struct {
 	Reason            string    `json:"reason"`
 	CheckedTargets    int       `json:"checked_targets"`
 	HealthyTargets    int       `json:"healthy_targets"`
+	FlappingTargets   int       `json:"flapping_targets,omitempty"`
 	HealthyPercent    int       `json:"healthy_percent"`
 	RecommendedAction string    `json:"recommended_action,omitempty"` // continue|hold|rollback
 	GeneratedAt       time.Time `json:"generated_at"`
@@ -64,14 +110,47 @@ type HealthProbeStore struct {
 	targets      map[string]HealthProbeTarget
 	checks       map[string]HealthProbeCheck
 	lastByTarget map[string]HealthProbeCheck
+	history      map[string][]HealthProbeCheck
+	guard        func(command string, args []string) error
 }
 
+const maxHealthProbeHistory = 50
+
 func NewHealthProbeStore() *HealthProbeStore {
 	return &HealthProbeStore{
 		targets:      map[string]HealthProbeTarget{},
 		checks:       map[string]HealthProbeCheck{},
 		lastByTarget: map[string]HealthProbeCheck{},
+		history:      map[string][]HealthProbeCheck{},
+	}
+}
+
+// SetExecGuard installs the check every command probe must pass, both when
+// the target is saved and before each run. Without a guard command probes
+// are rejected.
+func (s *HealthProbeStore) SetExecGuard(fn func(command string, args []string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guard = fn
+}
+
+// checkCommand splits a probe command into an executable and arguments and
+// runs them past the exec guard. Probes are not run through a shell.
+func (s *HealthProbeStore) checkCommand(command string) (string, []string, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", nil, errors.New("command probes require a command")
 	}
+	s.mu.RLock()
+	guard := s.guard
+	s.mu.RUnlock()
+	if guard == nil {
+		return "", nil, errors.New("command probes are disabled")
+	}
+	if err := guard(fields[0], fields[1:]); err != nil {
+		return "", nil, err
+	}
+	return fields[0], fields[1:], nil
 }
 
 func (s *HealthProbeStore) UpsertTarget(in HealthProbeTargetInput) (HealthProbeTarget, error) {
@@ -79,35 +158,108 @@ func (s *HealthProbeStore) UpsertTarget(in HealthProbeTargetInput) (HealthProbeT
 	if name == "" {
 		return HealthProbeTarget{}, errors.New("name is required")
 	}
-	svc := strings.TrimSpace(in.Service)
-	endpoint := strings.TrimSpace(in.Endpoint)
-	enabled := in.Enabled
-	if !in.Enabled {
-		enabled = false
+	cfg, err := normalizeHealthProbeConfig(in)
+	if err != nil {
+		return HealthProbeTarget{}, err
+	}
+	if cfg.Kind == "command" {
+		if _, _, err := s.checkCommand(cfg.Command); err != nil {
+			return HealthProbeTarget{}, err
+		}
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, existing := range s.targets {
 		if strings.EqualFold(existing.Name, name) {
-			existing.Service = svc
-			existing.Endpoint = endpoint
-			existing.Enabled = enabled
-			existing.UpdatedAt = time.Now().UTC()
-			s.targets[existing.ID] = existing
-			return existing, nil
+			cfg.ID = existing.ID
+			cfg.Name = existing.Name
+			cfg.Status = existing.Status
+			cfg.StatusChangedAt = existing.StatusChangedAt
+			cfg.Flapping = existing.Flapping
+			cfg.LastCheckedAt = existing.LastCheckedAt
+			cfg.UpdatedAt = time.Now().UTC()
+			s.targets[existing.ID] = cfg
+			return cfg, nil
 		}
 	}
 	s.nextTarget++
-	item := HealthProbeTarget{
-		ID:        "probe-" + itoa(s.nextTarget),
-		Name:      name,
-		Service:   svc,
-		Endpoint:  endpoint,
-		Enabled:   enabled,
-		UpdatedAt: time.Now().UTC(),
-	}
-	s.targets[item.ID] = item
+	cfg.ID = "probe-" + itoa(s.nextTarget)
+	cfg.Name = name
+	cfg.UpdatedAt = time.Now().UTC()
+	s.targets[cfg.ID] = cfg
+	return cfg, nil
+}
+
+// normalizeHealthProbeConfig validates the execution settings of a target
+// and fills in defaults. Kind defaults to manual so existing targets that
+// only document an endpoint are not probed unexpectedly.
+func normalizeHealthProbeConfig(in HealthProbeTargetInput) (HealthProbeTarget, error) {
+	out := HealthProbeTarget{
+		Service:           strings.TrimSpace(in.Service),
+		Host:              strings.TrimSpace(in.Host),
+		Kind:              strings.ToLower(strings.TrimSpace(in.Kind)),
+		Endpoint:          strings.TrimSpace(in.Endpoint),
+		Command:           strings.TrimSpace(in.Command),
+		ExpectStatus:      in.ExpectStatus,
+		IntervalSeconds:   in.IntervalSeconds,
+		TimeoutSeconds:    in.TimeoutSeconds,
+		DegradedLatencyMS: in.DegradedLatencyMS,
+		FlapWindow:        in.FlapWindow,
+		FlapThreshold:     in.FlapThreshold,
+		Enabled:           in.Enabled,
+	}
+	switch out.Kind {
+	case "", "manual":
+		out.Kind = "manual"
+	case "http":
+		if !strings.HasPrefix(out.Endpoint, "http://") && !strings.HasPrefix(out.Endpoint, "https://") {
+			return HealthProbeTarget{}, errors.New("http probes require an http:// or https:// endpoint")
+		}
+	case "tcp":
+		if _, _, err := net.SplitHostPort(out.Endpoint); err != nil {
+			return HealthProbeTarget{}, errors.New("tcp probes require a host:port endpoint")
+		}
+	case "command":
+		if out.Command == "" {
+			return HealthProbeTarget{}, errors.New("command probes require a command")
+		}
+	default:
+		return HealthProbeTarget{}, errors.New("kind must be one of manual, http, tcp, command")
+	}
+	if out.ExpectStatus != 0 && (out.ExpectStatus < 100 || out.ExpectStatus > 599) {
+		return HealthProbeTarget{}, errors.New("expect_status must be a valid http status code")
+	}
+	if out.IntervalSeconds < 0 || out.TimeoutSeconds < 0 || out.DegradedLatencyMS < 0 || out.FlapWindow < 0 || out.FlapThreshold < 0 {
+		return HealthProbeTarget{}, errors.New("probe intervals, timeouts, and flap settings must be >= 0")
+	}
+	if out.Kind != "manual" {
+		if out.IntervalSeconds == 0 {
+			out.IntervalSeconds = 30
+		}
+		if out.TimeoutSeconds == 0 {
+			out.TimeoutSeconds = 5
+		}
+	}
+	if out.FlapWindow == 0 {
+		out.FlapWindow = 10
+	}
+	if out.FlapWindow > maxHealthProbeHistory {
+		out.FlapWindow = maxHealthProbeHistory
+	}
+	if out.FlapThreshold == 0 {
+		out.FlapThreshold = 4
+	}
+	return out, nil
+}
+
+func (s *HealthProbeStore) GetTarget(id string) (HealthProbeTarget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.targets[strings.TrimSpace(id)]
+	if !ok {
+		return HealthProbeTarget{}, errors.New("target not found")
+	}
 	return item, nil
 }
 
@@ -122,6 +274,9 @@ func (s *HealthProbeStore) ListTargets() []HealthProbeTarget {
 	return out
 }
 
+// RecordCheck stores a check result and updates the target's current status
+// and flap state. The returned check reports whether the status or flap
+// state changed so callers can raise events only on transitions.
 func (s *HealthProbeStore) RecordCheck(in HealthProbeCheckInput) (HealthProbeCheck, error) {
 	targetID := strings.TrimSpace(in.TargetID)
 	status := normalizeProbeStatus(in.Status)
@@ -134,9 +289,14 @@ func (s *HealthProbeStore) RecordCheck(in HealthProbeCheckInput) (HealthProbeChe
 	if in.LatencyMS < 0 {
 		return HealthProbeCheck{}, errors.New("latency_ms must be >= 0")
 	}
+	source := strings.ToLower(strings.TrimSpace(in.Source))
+	if source != "probe" {
+		source = "manual"
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if _, ok := s.targets[targetID]; !ok {
+	target, ok := s.targets[targetID]
+	if !ok {
 		return HealthProbeCheck{}, errors.New("target not found")
 	}
 	observedAt := in.ObservedAt.UTC()
@@ -145,18 +305,100 @@ func (s *HealthProbeStore) RecordCheck(in HealthProbeCheckInput) (HealthProbeChe
 	}
 	s.nextCheck++
 	item := HealthProbeCheck{
-		ID:         "probe-check-" + itoa(s.nextCheck),
-		TargetID:   targetID,
-		Status:     status,
-		LatencyMS:  in.LatencyMS,
-		Message:    strings.TrimSpace(in.Message),
-		ObservedAt: observedAt,
+		ID:             "probe-check-" + itoa(s.nextCheck),
+		TargetID:       targetID,
+		Status:         status,
+		PreviousStatus: target.Status,
+		Changed:        target.Status != "" && target.Status != status,
+		Source:         source,
+		LatencyMS:      in.LatencyMS,
+		Message:        strings.TrimSpace(in.Message),
+		ObservedAt:     observedAt,
 	}
+	history := append(s.history[targetID], item)
+	if len(history) > maxHealthProbeHistory {
+		history = history[len(history)-maxHealthProbeHistory:]
+	}
+	item.Flapping = healthProbeFlapping(history, target.FlapWindow, target.FlapThreshold)
+	item.FlapChanged = item.Flapping != target.Flapping
+	history[len(history)-1] = item
+	s.history[targetID] = history
+
+	if target.Status != status {
+		target.StatusChangedAt = observedAt
+	}
+	target.Status = status
+	target.Flapping = item.Flapping
+	target.LastCheckedAt = observedAt
+	s.targets[targetID] = target
 	s.checks[item.ID] = item
 	s.lastByTarget[targetID] = item
 	return item, nil
 }
 
+// healthProbeFlapping reports whether the status changed at least threshold
+// times across the last window checks.
+func healthProbeFlapping(history []HealthProbeCheck, window, threshold int) bool {
+	if window <= 1 || threshold <= 0 {
+		return false
+	}
+	if len(history) > window {
+		history = history[len(history)-window:]
+	}
+	changes := 0
+	for i := 1; i < len(history); i++ {
+		if history[i].Status != history[i-1].Status {
+			changes++
+		}
+	}
+	return changes >= threshold
+}
+
+// ListChecks returns the most recent checks for a target, newest first.
+func (s *HealthProbeStore) ListChecks(targetID string, limit int) []HealthProbeCheck {
+	s.mu.RLock()
+	history := s.history[strings.TrimSpace(targetID)]
+	out := make([]HealthProbeCheck, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		out = append(out, history[i])
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	s.mu.RUnlock()
+	return out
+}
+
+func (s *HealthProbeStore) Summary() HealthProbeSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := HealthProbeSummary{}
+	for _, target := range s.targets {
+		if !target.Enabled {
+			continue
+		}
+		out.Targets++
+		switch target.Status {
+		case "healthy":
+			out.Healthy++
+		case "degraded":
+			out.Degraded++
+		case "unhealthy":
+			out.Unhealthy++
+		default:
+			out.Unknown++
+		}
+		if target.Flapping {
+			out.Flapping++
+		}
+		if target.Status == "unhealthy" || target.Flapping {
+			out.Failing = append(out.Failing, target.Name)
+		}
+	}
+	sort.Strings(out.Failing)
+	return out
+}
+
 func (s *HealthProbeStore) EvaluateGate(req HealthProbeGateRequest) HealthProbeGateResult {
 	minHealthy := req.MinHealthyPercent
 	if minHealthy <= 0 {
@@ -177,6 +419,7 @@ func (s *HealthProbeStore) EvaluateGate(req HealthProbeGateRequest) HealthProbeG
 	s.mu.RLock()
 	checked := 0
 	healthy := 0
+	flapping := 0
 	for id, target := range s.targets {
 		if len(targetFilter) > 0 {
 			if _, ok := targetFilter[id]; !ok {
@@ -191,6 +434,12 @@ func (s *HealthProbeStore) EvaluateGate(req HealthProbeGateRequest) HealthProbeG
 			continue
 		}
 		checked++
+		// A flapping target is not trusted as healthy even when its
+		// latest check passed.
+		if target.Flapping {
+			flapping++
+			continue
+		}
 		if check.Status == "healthy" {
 			healthy++
 		}
@@ -202,6 +451,7 @@ func (s *HealthProbeStore) EvaluateGate(req HealthProbeGateRequest) HealthProbeG
 		Reason:            "health probes satisfy gate threshold",
 		CheckedTargets:    checked,
 		HealthyTargets:    healthy,
+		FlappingTargets:   flapping,
 		HealthyPercent:    100,
 		RecommendedAction: "continue",
 		GeneratedAt:       time.Now().UTC(),
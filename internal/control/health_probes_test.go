@@ -48,3 +48,60 @@ func TestHealthProbeStoreValidation(t *testing.T) {
 		t.Fatalf("expected status validation error")
 	}
 }
+
+func TestHealthProbeStoreFlapDetection(t *testing.T) {
+	store := NewHealthProbeStore()
+	target, err := store.UpsertTarget(HealthProbeTargetInput{Name: "edge", Enabled: true, FlapWindow: 5, FlapThreshold: 3})
+	if err != nil {
+		t.Fatalf("upsert target failed: %v", err)
+	}
+	var last HealthProbeCheck
+	for i, status := range []string{"healthy", "unhealthy", "healthy", "unhealthy"} {
+		last, err = store.RecordCheck(HealthProbeCheckInput{TargetID: target.ID, Status: status})
+		if err != nil {
+			t.Fatalf("record check %d failed: %v", i, err)
+		}
+		if i > 0 && !last.Changed {
+			t.Fatalf("expected check %d to report a status change: %+v", i, last)
+		}
+	}
+	if !last.Flapping || !last.FlapChanged {
+		t.Fatalf("expected target to start flapping: %+v", last)
+	}
+	if _, err := store.RecordCheck(HealthProbeCheckInput{TargetID: target.ID, Status: "healthy"}); err != nil {
+		t.Fatalf("record check failed: %v", err)
+	}
+	gate := store.EvaluateGate(HealthProbeGateRequest{TargetIDs: []string{target.ID}})
+	if gate.Decision != "block" || gate.FlappingTargets != 1 {
+		t.Fatalf("expected flapping target to block gate, got %+v", gate)
+	}
+	summary := store.Summary()
+	if summary.Flapping != 1 || len(summary.Failing) != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if got := store.ListChecks(target.ID, 2); len(got) != 2 || got[0].Status != "healthy" {
+		t.Fatalf("unexpected recent checks %+v", got)
+	}
+}
+
+func TestHealthProbeTargetValidation(t *testing.T) {
+	store := NewHealthProbeStore()
+	cases := []HealthProbeTargetInput{
+		{Name: "a", Kind: "http", Endpoint: "payments:80"},
+		{Name: "b", Kind: "tcp", Endpoint: "payments"},
+		{Name: "c", Kind: "command"},
+		{Name: "d", Kind: "icmp"},
+	}
+	for _, in := range cases {
+		if _, err := store.UpsertTarget(in); err == nil {
+			t.Fatalf("expected validation error for %+v", in)
+		}
+	}
+	target, err := store.UpsertTarget(HealthProbeTargetInput{Name: "e", Kind: "tcp", Endpoint: "127.0.0.1:1", Enabled: true})
+	if err != nil {
+		t.Fatalf("upsert tcp target failed: %v", err)
+	}
+	if target.IntervalSeconds != 30 || target.TimeoutSeconds != 5 {
+		t.Fatalf("expected probe defaults, got %+v", target)
+	}
+}
@@ -0,0 +1,377 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default filters a principal may set on their persona home view. They fill
+// in the matching query parameters when a request leaves them out.
+var homeFilterKeys = map[string]bool{"owner": true, "hours": true}
+
+// HomePreference is one principal's customization of a persona home view.
+// Cards not mentioned keep their default position after the ordered ones.
+type HomePreference struct {
+	Principal      string            `json:"principal"`
+	Persona        string            `json:"persona"`
+	PinnedCards    []string          `json:"pinned_cards"`
+	CardOrder      []string          `json:"card_order"`
+	HiddenCards    []string          `json:"hidden_cards"`
+	DefaultFilters map[string]string `json:"default_filters"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// HomeMandatoryCards are the cards an admin requires on every home view of a
+// persona. They are always shown, first, and cannot be hidden.
+type HomeMandatoryCards struct {
+	Persona   string    `json:"persona"`
+	CardIDs   []string  `json:"card_ids"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type HomeCardPlacement struct {
+	ID        string `json:"id"`
+	Position  int    `json:"position"`
+	Pinned    bool   `json:"pinned"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+type HomeCardActionInput struct {
+	Action   string `json:"action"` // pin|unpin|hide|show|move
+	CardID   string `json:"card_id"`
+	Position int    `json:"position,omitempty"` // move, zero-based
+}
+
+// HomePreferenceStore persists per-principal home view customizations and
+// admin-managed mandatory cards under .masterchef/ui.
+type HomePreferenceStore struct {
+	mu          sync.RWMutex
+	path        string
+	preferences map[string]*HomePreference
+	mandatory   map[string]*HomeMandatoryCards
+}
+
+type homePreferenceFile struct {
+	Preferences []HomePreference     `json:"preferences"`
+	Mandatory   []HomeMandatoryCards `json:"mandatory"`
+}
+
+func NewHomePreferenceStore(baseDir string) *HomePreferenceStore {
+	s := &HomePreferenceStore{
+		path:        filepath.Join(baseDir, ".masterchef", "ui", "home-preferences.json"),
+		preferences: map[string]*HomePreference{},
+		mandatory:   map[string]*HomeMandatoryCards{},
+	}
+	s.load()
+	return s
+}
+
+// Get returns the principal's preference for persona, or an empty default
+// when none was saved.
+func (s *HomePreferenceStore) Get(principal, persona string) (HomePreference, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	principal, persona = strings.TrimSpace(principal), strings.TrimSpace(persona)
+	if item, ok := s.preferences[homePreferenceKey(principal, persona)]; ok {
+		return cloneHomePreference(*item), true
+	}
+	return HomePreference{
+		Principal:      principal,
+		Persona:        persona,
+		PinnedCards:    []string{},
+		CardOrder:      []string{},
+		HiddenCards:    []string{},
+		DefaultFilters: map[string]string{},
+	}, false
+}
+
+// Save replaces the principal's preference for the persona.
+func (s *HomePreferenceStore) Save(in HomePreference) (HomePreference, error) {
+	in.Principal = strings.TrimSpace(in.Principal)
+	in.Persona = strings.TrimSpace(in.Persona)
+	if in.Principal == "" {
+		return HomePreference{}, errors.New("principal is required")
+	}
+	if in.Persona == "" {
+		return HomePreference{}, errors.New("persona is required")
+	}
+	in.PinnedCards = normalizeHomeCardIDs(in.PinnedCards)
+	in.CardOrder = normalizeHomeCardIDs(in.CardOrder)
+	in.HiddenCards = normalizeHomeCardIDs(in.HiddenCards)
+	filters := map[string]string{}
+	for k, v := range in.DefaultFilters {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if !homeFilterKeys[k] {
+			return HomePreference{}, errors.New("default filter " + k + " is not supported; use owner or hours")
+		}
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if k == "hours" {
+			if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+				return HomePreference{}, errors.New("default filter hours must be a positive integer")
+			}
+		}
+		filters[k] = v
+	}
+	in.DefaultFilters = filters
+	for _, id := range in.PinnedCards {
+		if containsString(in.HiddenCards, id) {
+			return HomePreference{}, errors.New("card " + id + " cannot be both pinned and hidden")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if mandatory, ok := s.mandatory[in.Persona]; ok {
+		for _, id := range in.HiddenCards {
+			if containsString(mandatory.CardIDs, id) {
+				return HomePreference{}, errors.New("card " + id + " is mandatory for persona " + in.Persona)
+			}
+		}
+	}
+	in.UpdatedAt = time.Now().UTC()
+	item := cloneHomePreference(in)
+	s.preferences[homePreferenceKey(in.Principal, in.Persona)] = &item
+	if err := s.persistLocked(); err != nil {
+		return HomePreference{}, err
+	}
+	return cloneHomePreference(item), nil
+}
+
+// ApplyAction pins, unpins, hides, shows, or moves one card. Moving a card
+// places it at position among the ordered cards.
+func (s *HomePreferenceStore) ApplyAction(principal, persona string, in HomeCardActionInput) (HomePreference, error) {
+	id := strings.TrimSpace(in.CardID)
+	if id == "" {
+		return HomePreference{}, errors.New("card_id is required")
+	}
+	pref, _ := s.Get(principal, persona)
+	// Cards made mandatory after the principal hid them are shown anyway;
+	// drop them so the saved preference stays valid.
+	for _, m := range s.Mandatory(persona) {
+		pref.HiddenCards = removeString(pref.HiddenCards, m)
+	}
+	switch strings.ToLower(strings.TrimSpace(in.Action)) {
+	case "pin":
+		pref.HiddenCards = removeString(pref.HiddenCards, id)
+		if !containsString(pref.PinnedCards, id) {
+			pref.PinnedCards = append(pref.PinnedCards, id)
+		}
+	case "unpin":
+		pref.PinnedCards = removeString(pref.PinnedCards, id)
+	case "hide":
+		pref.PinnedCards = removeString(pref.PinnedCards, id)
+		if !containsString(pref.HiddenCards, id) {
+			pref.HiddenCards = append(pref.HiddenCards, id)
+		}
+	case "show":
+		pref.HiddenCards = removeString(pref.HiddenCards, id)
+	case "move":
+		if in.Position < 0 {
+			return HomePreference{}, errors.New("position must be >= 0")
+		}
+		order := removeString(pref.CardOrder, id)
+		pos := in.Position
+		if pos > len(order) {
+			pos = len(order)
+		}
+		order = append(order[:pos], append([]string{id}, order[pos:]...)...)
+		pref.CardOrder = order
+	default:
+		return HomePreference{}, errors.New("action must be pin, unpin, hide, show, or move")
+	}
+	return s.Save(pref)
+}
+
+func (s *HomePreferenceStore) Delete(principal, persona string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := homePreferenceKey(strings.TrimSpace(principal), strings.TrimSpace(persona))
+	if _, ok := s.preferences[key]; !ok {
+		return errors.New("home preference not found")
+	}
+	delete(s.preferences, key)
+	return s.persistLocked()
+}
+
+// SetMandatory replaces the mandatory cards of a persona. Principals who
+// hid one of them see it again; their stored preference is left as is.
+func (s *HomePreferenceStore) SetMandatory(persona string, cardIDs []string, updatedBy string) (HomeMandatoryCards, error) {
+	persona = strings.TrimSpace(persona)
+	if persona == "" {
+		return HomeMandatoryCards{}, errors.New("persona is required")
+	}
+	item := HomeMandatoryCards{
+		Persona:   persona,
+		CardIDs:   normalizeHomeCardIDs(cardIDs),
+		UpdatedBy: strings.TrimSpace(updatedBy),
+		UpdatedAt: time.Now().UTC(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(item.CardIDs) == 0 {
+		delete(s.mandatory, persona)
+	} else {
+		stored := item
+		stored.CardIDs = append([]string{}, item.CardIDs...)
+		s.mandatory[persona] = &stored
+	}
+	if err := s.persistLocked(); err != nil {
+		return HomeMandatoryCards{}, err
+	}
+	return item, nil
+}
+
+func (s *HomePreferenceStore) Mandatory(persona string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if item, ok := s.mandatory[strings.TrimSpace(persona)]; ok {
+		return append([]string{}, item.CardIDs...)
+	}
+	return []string{}
+}
+
+func (s *HomePreferenceStore) ListMandatory() []HomeMandatoryCards {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]HomeMandatoryCards, 0, len(s.mandatory))
+	for _, item := range s.mandatory {
+		cp := *item
+		cp.CardIDs = append([]string{}, item.CardIDs...)
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Persona < out[j].Persona })
+	return out
+}
+
+// Arrange lays out the persona's default cards for a principal: mandatory
+// cards first in admin order, then pinned cards in pin order, then the rest
+// following card_order and finally the default order. Hidden cards are
+// dropped unless mandatory, and unknown card IDs are ignored.
+func (s *HomePreferenceStore) Arrange(principal, persona string, defaultIDs []string) []HomeCardPlacement {
+	pref, _ := s.Get(principal, persona)
+	mandatory := s.Mandatory(persona)
+	known := map[string]bool{}
+	for _, id := range defaultIDs {
+		known[id] = true
+	}
+	placed := map[string]bool{}
+	out := make([]HomeCardPlacement, 0, len(defaultIDs))
+	place := func(ids []string) {
+		for _, id := range ids {
+			if !known[id] || placed[id] {
+				continue
+			}
+			isMandatory := containsString(mandatory, id)
+			if !isMandatory && containsString(pref.HiddenCards, id) {
+				continue
+			}
+			placed[id] = true
+			out = append(out, HomeCardPlacement{
+				ID:        id,
+				Position:  len(out),
+				Pinned:    isMandatory || containsString(pref.PinnedCards, id),
+				Mandatory: isMandatory,
+			})
+		}
+	}
+	place(mandatory)
+	place(pref.PinnedCards)
+	place(pref.CardOrder)
+	place(defaultIDs)
+	return out
+}
+
+func (s *HomePreferenceStore) load() {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var file homePreferenceFile
+	if json.Unmarshal(raw, &file) != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range file.Preferences {
+		cp := cloneHomePreference(item)
+		s.preferences[homePreferenceKey(cp.Principal, cp.Persona)] = &cp
+	}
+	for _, item := range file.Mandatory {
+		cp := item
+		cp.CardIDs = cloneStringSlice(item.CardIDs)
+		s.mandatory[cp.Persona] = &cp
+	}
+}
+
+func (s *HomePreferenceStore) persistLocked() error {
+	file := homePreferenceFile{
+		Preferences: make([]HomePreference, 0, len(s.preferences)),
+		Mandatory:   make([]HomeMandatoryCards, 0, len(s.mandatory)),
+	}
+	for _, item := range s.preferences {
+		file.Preferences = append(file.Preferences, *item)
+	}
+	sort.Slice(file.Preferences, func(i, j int) bool {
+		return homePreferenceKey(file.Preferences[i].Principal, file.Preferences[i].Persona) < homePreferenceKey(file.Preferences[j].Principal, file.Preferences[j].Persona)
+	})
+	for _, item := range s.mandatory {
+		file.Mandatory = append(file.Mandatory, *item)
+	}
+	sort.Slice(file.Mandatory, func(i, j int) bool { return file.Mandatory[i].Persona < file.Mandatory[j].Persona })
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(raw, '\n'), 0o600)
+}
+
+func homePreferenceKey(principal, persona string) string {
+	return strings.ToLower(principal) + "|" + persona
+}
+
+func normalizeHomeCardIDs(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, id := range in {
+		id = strings.TrimSpace(id)
+		if id != "" && !containsString(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func cloneHomePreference(in HomePreference) HomePreference {
+	out := in
+	out.PinnedCards = append([]string{}, in.PinnedCards...)
+	out.CardOrder = append([]string{}, in.CardOrder...)
+	out.HiddenCards = append([]string{}, in.HiddenCards...)
+	out.DefaultFilters = map[string]string{}
+	for k, v := range in.DefaultFilters {
+		out.DefaultFilters[k] = v
+	}
+	return out
+}
+
+func removeString(items []string, needle string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != needle {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package control
+
+import "testing"
+
+func TestHomePreferenceStoreArrangeAndPersist(t *testing.T) {
+	dir := t.TempDir()
+	store := NewHomePreferenceStore(dir)
+	defaults := []string{"alert-inbox", "queue-pressure", "top-workloads"}
+
+	if _, err := store.Save(HomePreference{Principal: "alice", Persona: "sre", DefaultFilters: map[string]string{"region": "us"}}); err == nil {
+		t.Fatalf("expected unsupported default filter to fail")
+	}
+	if _, err := store.Save(HomePreference{Principal: "alice", Persona: "sre", PinnedCards: []string{"alert-inbox"}, HiddenCards: []string{"alert-inbox"}}); err == nil {
+		t.Fatalf("expected card both pinned and hidden to fail")
+	}
+	if _, err := store.ApplyAction("alice", "sre", HomeCardActionInput{Action: "pin", CardID: "top-workloads"}); err != nil {
+		t.Fatalf("pin failed: %v", err)
+	}
+	if _, err := store.ApplyAction("alice", "sre", HomeCardActionInput{Action: "hide", CardID: "queue-pressure"}); err != nil {
+		t.Fatalf("hide failed: %v", err)
+	}
+	got := store.Arrange("alice", "sre", defaults)
+	if len(got) != 2 || got[0].ID != "top-workloads" || !got[0].Pinned || got[1].ID != "alert-inbox" {
+		t.Fatalf("unexpected arrangement: %+v", got)
+	}
+	if other := store.Arrange("bob", "sre", defaults); len(other) != 3 || other[0].ID != "alert-inbox" {
+		t.Fatalf("expected defaults for another principal: %+v", other)
+	}
+
+	if _, err := store.SetMandatory("sre", []string{"queue-pressure"}, "admin"); err != nil {
+		t.Fatalf("set mandatory failed: %v", err)
+	}
+	got = store.Arrange("alice", "sre", defaults)
+	if len(got) != 3 || got[0].ID != "queue-pressure" || !got[0].Mandatory || got[1].ID != "top-workloads" {
+		t.Fatalf("expected mandatory card first despite being hidden: %+v", got)
+	}
+	if _, err := store.ApplyAction("alice", "sre", HomeCardActionInput{Action: "hide", CardID: "queue-pressure"}); err == nil {
+		t.Fatalf("expected hiding a mandatory card to fail")
+	}
+
+	reloaded := NewHomePreferenceStore(dir)
+	pref, ok := reloaded.Get("alice", "sre")
+	if !ok || len(pref.PinnedCards) != 1 || pref.PinnedCards[0] != "top-workloads" {
+		t.Fatalf("expected preference to persist: ok=%v pref=%+v", ok, pref)
+	}
+	if mandatory := reloaded.Mandatory("sre"); len(mandatory) != 1 || mandatory[0] != "queue-pressure" {
+		t.Fatalf("expected mandatory cards to persist: %+v", mandatory)
+	}
+	if err := reloaded.Delete("alice", "sre"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, ok := reloaded.Get("alice", "sre"); ok {
+		t.Fatalf("expected preference to be reset")
+	}
+}
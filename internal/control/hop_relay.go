@@ -30,16 +30,19 @@ type HopRelaySessionInput struct {
 	EndpointID string `json:"endpoint_id"`
 	NodeID     string `json:"node_id"`
 	TargetHost string `json:"target_host"`
+	// ClientCertSerial is the serial of the node's mTLS client certificate.
+	ClientCertSerial string `json:"client_cert_serial,omitempty"`
 }
 
 type HopRelaySession struct {
-	ID         string    `json:"id"`
-	EndpointID string    `json:"endpoint_id"`
-	NodeID     string    `json:"node_id"`
-	TargetHost string    `json:"target_host"`
-	EgressOnly bool      `json:"egress_only"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID               string    `json:"id"`
+	EndpointID       string    `json:"endpoint_id"`
+	NodeID           string    `json:"node_id"`
+	TargetHost       string    `json:"target_host"`
+	ClientCertSerial string    `json:"client_cert_serial,omitempty"`
+	EgressOnly       bool      `json:"egress_only"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 type HopRelayStore struct {
@@ -48,6 +51,7 @@ type HopRelayStore struct {
 	nextSes   int64
 	endpoints map[string]*HopRelayEndpoint
 	sessions  map[string]*HopRelaySession
+	revoked   CertificateRevocationChecker
 }
 
 func NewHopRelayStore() *HopRelayStore {
@@ -109,6 +113,34 @@ func (s *HopRelayStore) ListEndpoints() []HopRelayEndpoint {
 	return out
 }
 
+// SetRevocationChecker makes the relay refuse, and later terminate, sessions
+// for nodes whose client certificate has been revoked.
+func (s *HopRelayStore) SetRevocationChecker(checker CertificateRevocationChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = checker
+}
+
+// TerminateRevokedSessions ends active sessions whose client certificate
+// has been revoked since they were opened.
+func (s *HopRelayStore) TerminateRevokedSessions() []HopRelaySession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]HopRelaySession, 0)
+	if s.revoked == nil {
+		return out
+	}
+	for _, item := range s.sessions {
+		if item.Status != "active" || item.ClientCertSerial == "" || !s.revoked.IsRevoked(item.ClientCertSerial) {
+			continue
+		}
+		item.Status = "revoked"
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
 func (s *HopRelayStore) OpenSession(in HopRelaySessionInput) (HopRelaySession, error) {
 	endpointID := strings.TrimSpace(in.EndpointID)
 	nodeID := strings.TrimSpace(in.NodeID)
@@ -116,12 +148,16 @@ func (s *HopRelayStore) OpenSession(in HopRelaySessionInput) (HopRelaySession, e
 	if endpointID == "" || nodeID == "" || target == "" {
 		return HopRelaySession{}, errors.New("endpoint_id, node_id, and target_host are required")
 	}
+	serial := strings.TrimSpace(in.ClientCertSerial)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	ep, ok := s.endpoints[endpointID]
 	if !ok {
 		return HopRelaySession{}, errors.New("relay endpoint not found")
 	}
+	if serial != "" && s.revoked != nil && s.revoked.IsRevoked(serial) {
+		return HopRelaySession{}, errors.New("client certificate revoked")
+	}
 	active := 0
 	for _, item := range s.sessions {
 		if item.EndpointID == endpointID && item.Status == "active" {
@@ -133,13 +169,14 @@ func (s *HopRelayStore) OpenSession(in HopRelaySessionInput) (HopRelaySession, e
 	}
 	s.nextSes++
 	item := HopRelaySession{
-		ID:         "relay-session-" + itoa(s.nextSes),
-		EndpointID: endpointID,
-		NodeID:     nodeID,
-		TargetHost: target,
-		EgressOnly: true,
-		Status:     "active",
-		CreatedAt:  time.Now().UTC(),
+		ID:               "relay-session-" + itoa(s.nextSes),
+		EndpointID:       endpointID,
+		NodeID:           nodeID,
+		TargetHost:       target,
+		ClientCertSerial: serial,
+		EgressOnly:       true,
+		Status:           "active",
+		CreatedAt:        time.Now().UTC(),
 	}
 	s.sessions[item.ID] = &item
 	return item, nil
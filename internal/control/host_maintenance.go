@@ -0,0 +1,320 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HostMaintenanceScheduled = "scheduled"
+	HostMaintenanceActive    = "active"
+	HostMaintenanceCompleted = "completed"
+	HostMaintenanceCancelled = "cancelled"
+
+	DeferredWorkPending     = "pending"
+	DeferredWorkRescheduled = "rescheduled"
+	DeferredWorkFailed      = "failed"
+)
+
+// HostMaintenanceWindow is one entry in a host's maintenance calendar.
+// While a window is active the host receives no scheduled, association, or
+// agent-dispatched work and its exports are left out of collection.
+type HostMaintenanceWindow struct {
+	ID        string    `json:"id"`
+	Host      string    `json:"host"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+}
+
+type HostMaintenanceWindowInput struct {
+	Host            string    `json:"host"`
+	Reason          string    `json:"reason,omitempty"`
+	CreatedBy       string    `json:"created_by,omitempty"`
+	StartAt         time.Time `json:"start_at,omitempty"`
+	EndAt           time.Time `json:"end_at,omitempty"`
+	DurationMinutes int       `json:"duration_minutes,omitempty"`
+}
+
+// DeferredHostWork is work skipped because its host was in maintenance.
+// Repeat skips of the same schedule, association, or dispatch collapse into
+// one entry so the host converges once when maintenance ends.
+type DeferredHostWork struct {
+	ID             string    `json:"id"`
+	Host           string    `json:"host"`
+	Kind           string    `json:"kind"` // schedule|association|dispatch
+	Ref            string    `json:"ref"`
+	ConfigPath     string    `json:"config_path"`
+	Priority       string    `json:"priority,omitempty"`
+	Force          bool      `json:"force,omitempty"`
+	Skipped        int       `json:"skipped"`
+	Status         string    `json:"status"`
+	JobID          string    `json:"job_id,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	FirstDeferred  time.Time `json:"first_deferred_at"`
+	LastDeferred   time.Time `json:"last_deferred_at"`
+	RescheduledAt  time.Time `json:"rescheduled_at,omitempty"`
+	MaintenanceRef string    `json:"maintenance_window_id,omitempty"`
+}
+
+// HostMaintenanceTransition reports a window that started or ended while
+// advancing the calendar.
+type HostMaintenanceTransition struct {
+	Window  HostMaintenanceWindow `json:"window"`
+	Started bool                  `json:"started"`
+}
+
+type HostMaintenanceStore struct {
+	mu         sync.RWMutex
+	nextWindow int64
+	nextWork   int64
+	windows    map[string]*HostMaintenanceWindow
+	work       []*DeferredHostWork
+	workLimit  int
+}
+
+func NewHostMaintenanceStore() *HostMaintenanceStore {
+	return &HostMaintenanceStore{
+		windows:   map[string]*HostMaintenanceWindow{},
+		workLimit: 5000,
+	}
+}
+
+// Schedule adds a window to a host's calendar. A start in the past or now
+// opens it immediately on the next Advance.
+func (s *HostMaintenanceStore) Schedule(in HostMaintenanceWindowInput) (HostMaintenanceWindow, error) {
+	host := strings.ToLower(strings.TrimSpace(in.Host))
+	if host == "" {
+		return HostMaintenanceWindow{}, errors.New("host is required")
+	}
+	now := time.Now().UTC()
+	start := in.StartAt.UTC()
+	if in.StartAt.IsZero() {
+		start = now
+	}
+	end := in.EndAt.UTC()
+	if in.EndAt.IsZero() {
+		if in.DurationMinutes <= 0 {
+			return HostMaintenanceWindow{}, errors.New("end_at or duration_minutes is required")
+		}
+		end = start.Add(time.Duration(in.DurationMinutes) * time.Minute)
+	}
+	if !end.After(start) {
+		return HostMaintenanceWindow{}, errors.New("end_at must be after start_at")
+	}
+	if !end.After(now) {
+		return HostMaintenanceWindow{}, errors.New("maintenance window has already ended")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.windows {
+		if w.Host != host || (w.Status != HostMaintenanceScheduled && w.Status != HostMaintenanceActive) {
+			continue
+		}
+		if start.Before(w.EndAt) && w.StartAt.Before(end) {
+			return HostMaintenanceWindow{}, errors.New("maintenance window overlaps " + w.ID)
+		}
+	}
+	s.nextWindow++
+	w := &HostMaintenanceWindow{
+		ID:        "hmw-" + itoa(s.nextWindow),
+		Host:      host,
+		Reason:    strings.TrimSpace(in.Reason),
+		CreatedBy: strings.TrimSpace(in.CreatedBy),
+		StartAt:   start,
+		EndAt:     end,
+		Status:    HostMaintenanceScheduled,
+		CreatedAt: now,
+	}
+	s.windows[w.ID] = w
+	return *w, nil
+}
+
+func (s *HostMaintenanceStore) Get(id string) (HostMaintenanceWindow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.windows[strings.TrimSpace(id)]
+	if !ok {
+		return HostMaintenanceWindow{}, errors.New("maintenance window not found")
+	}
+	return *w, nil
+}
+
+// End closes a window early: a scheduled window is cancelled and an active
+// one completes now. started reports whether the host was in maintenance.
+func (s *HostMaintenanceStore) End(id string) (HostMaintenanceWindow, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.windows[strings.TrimSpace(id)]
+	if !ok {
+		return HostMaintenanceWindow{}, false, errors.New("maintenance window not found")
+	}
+	now := time.Now().UTC()
+	switch w.Status {
+	case HostMaintenanceScheduled:
+		w.Status = HostMaintenanceCancelled
+		w.EndedAt = now
+		return *w, false, nil
+	case HostMaintenanceActive:
+		w.Status = HostMaintenanceCompleted
+		w.EndedAt = now
+		return *w, true, nil
+	default:
+		return HostMaintenanceWindow{}, false, errors.New("maintenance window already " + w.Status)
+	}
+}
+
+// Calendar lists a host's windows, or every host's when host is empty,
+// ordered by start time.
+func (s *HostMaintenanceStore) Calendar(host string) []HostMaintenanceWindow {
+	host = strings.ToLower(strings.TrimSpace(host))
+	s.mu.RLock()
+	out := make([]HostMaintenanceWindow, 0, len(s.windows))
+	for _, w := range s.windows {
+		if host == "" || w.Host == host {
+			out = append(out, *w)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].StartAt.Equal(out[j].StartAt) {
+			return out[i].StartAt.Before(out[j].StartAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Advance opens windows whose start has passed and completes those whose
+// end has passed.
+func (s *HostMaintenanceStore) Advance(now time.Time) []HostMaintenanceTransition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []HostMaintenanceTransition{}
+	for _, w := range s.windows {
+		if w.Status == HostMaintenanceScheduled && !w.StartAt.After(now) {
+			w.Status = HostMaintenanceActive
+			out = append(out, HostMaintenanceTransition{Window: *w, Started: true})
+		}
+		if w.Status == HostMaintenanceActive && !w.EndAt.After(now) {
+			w.Status = HostMaintenanceCompleted
+			w.EndedAt = now
+			out = append(out, HostMaintenanceTransition{Window: *w})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Window.ID < out[j].Window.ID })
+	return out
+}
+
+// ActiveWindow returns the host's open window, if any.
+func (s *HostMaintenanceStore) ActiveWindow(host string) (HostMaintenanceWindow, bool) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return HostMaintenanceWindow{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, w := range s.windows {
+		if w.Host == host && w.Status == HostMaintenanceActive {
+			return *w, true
+		}
+	}
+	return HostMaintenanceWindow{}, false
+}
+
+func (s *HostMaintenanceStore) InMaintenance(host string) bool {
+	_, ok := s.ActiveWindow(host)
+	return ok
+}
+
+// Defer records work skipped for a host in maintenance.
+func (s *HostMaintenanceStore) Defer(item DeferredHostWork) DeferredHostWork {
+	item.Host = strings.ToLower(strings.TrimSpace(item.Host))
+	item.Kind = strings.ToLower(strings.TrimSpace(item.Kind))
+	item.Ref = strings.TrimSpace(item.Ref)
+	now := time.Now().UTC()
+	window, _ := s.ActiveWindow(item.Host)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item.Ref != "" {
+		for _, cur := range s.work {
+			if cur.Status == DeferredWorkPending && cur.Host == item.Host && cur.Kind == item.Kind && cur.Ref == item.Ref {
+				cur.Skipped++
+				cur.LastDeferred = now
+				cur.Force = cur.Force || item.Force
+				return *cur
+			}
+		}
+	}
+	s.nextWork++
+	item.ID = "deferred-" + itoa(s.nextWork)
+	item.Skipped = 1
+	item.Status = DeferredWorkPending
+	item.FirstDeferred = now
+	item.LastDeferred = now
+	item.MaintenanceRef = window.ID
+	s.work = append(s.work, &item)
+	if len(s.work) > s.workLimit {
+		s.work = append([]*DeferredHostWork{}, s.work[len(s.work)-s.workLimit:]...)
+	}
+	return item
+}
+
+// Deferred lists deferred work, newest first, filtered by host and status.
+func (s *HostMaintenanceStore) Deferred(host, status string) []DeferredHostWork {
+	host = strings.ToLower(strings.TrimSpace(host))
+	status = strings.ToLower(strings.TrimSpace(status))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]DeferredHostWork, 0)
+	for i := len(s.work) - 1; i >= 0; i-- {
+		item := s.work[i]
+		if (host == "" || item.Host == host) && (status == "" || item.Status == status) {
+			out = append(out, *item)
+		}
+	}
+	return out
+}
+
+// TakePending hands back a host's pending work for rescheduling, oldest
+// first; the caller reports each outcome with MarkRescheduled.
+func (s *HostMaintenanceStore) TakePending(host string) []DeferredHostWork {
+	host = strings.ToLower(strings.TrimSpace(host))
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]DeferredHostWork, 0)
+	for _, item := range s.work {
+		if item.Host == host && item.Status == DeferredWorkPending {
+			item.Status = DeferredWorkRescheduled
+			item.RescheduledAt = now
+			out = append(out, *item)
+		}
+	}
+	return out
+}
+
+func (s *HostMaintenanceStore) MarkRescheduled(id, jobID string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.work {
+		if item.ID != id {
+			continue
+		}
+		item.JobID = jobID
+		if err != nil {
+			item.Status = DeferredWorkFailed
+			item.Error = err.Error()
+		}
+		return
+	}
+}
@@ -0,0 +1,71 @@
+package control
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostMaintenanceCalendarAndDeferral(t *testing.T) {
+	store := NewHostMaintenanceStore()
+	now := time.Now().UTC()
+	later, err := store.Schedule(HostMaintenanceWindowInput{Host: "db-1", StartAt: now.Add(2 * time.Hour), EndAt: now.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := store.Schedule(HostMaintenanceWindowInput{Host: "db-1", StartAt: now.Add(-time.Minute), DurationMinutes: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Schedule(HostMaintenanceWindowInput{Host: "db-1", StartAt: now.Add(150 * time.Minute), DurationMinutes: 60}); err == nil {
+		t.Fatal("expected overlapping window to be rejected")
+	}
+	if _, err := store.Schedule(HostMaintenanceWindowInput{Host: "db-1", StartAt: now.Add(-2 * time.Hour), EndAt: now.Add(-time.Hour)}); err == nil {
+		t.Fatal("expected past window to be rejected")
+	}
+	if cal := store.Calendar("DB-1"); len(cal) != 2 || cal[0].ID != current.ID || cal[1].ID != later.ID {
+		t.Fatalf("expected calendar ordered by start, got %+v", cal)
+	}
+
+	if store.InMaintenance("db-1") {
+		t.Fatal("window must not open before Advance")
+	}
+	transitions := store.Advance(now)
+	if len(transitions) != 1 || !transitions[0].Started || transitions[0].Window.ID != current.ID {
+		t.Fatalf("expected current window to start, got %+v", transitions)
+	}
+	if !store.InMaintenance("db-1") {
+		t.Fatal("expected db-1 in maintenance")
+	}
+
+	first := store.Defer(DeferredHostWork{Host: "db-1", Kind: "schedule", Ref: "sched-1", ConfigPath: "site.yaml"})
+	again := store.Defer(DeferredHostWork{Host: "db-1", Kind: "schedule", Ref: "sched-1", ConfigPath: "site.yaml"})
+	if again.ID != first.ID || again.Skipped != 2 || first.MaintenanceRef != current.ID {
+		t.Fatalf("expected repeat skips to collapse, got %+v then %+v", first, again)
+	}
+	store.Defer(DeferredHostWork{Host: "db-1", Kind: "dispatch", Ref: "site.yaml", ConfigPath: "site.yaml"})
+
+	transitions = store.Advance(now.Add(61 * time.Minute))
+	if len(transitions) != 1 || transitions[0].Started || transitions[0].Window.Status != HostMaintenanceCompleted {
+		t.Fatalf("expected current window to complete, got %+v", transitions)
+	}
+	pending := store.TakePending("db-1")
+	if len(pending) != 2 || pending[0].Ref != "sched-1" {
+		t.Fatalf("expected two pending items oldest first, got %+v", pending)
+	}
+	store.MarkRescheduled(pending[0].ID, "job-1", nil)
+	store.MarkRescheduled(pending[1].ID, "", errors.New("queue full"))
+	if got := store.Deferred("db-1", DeferredWorkFailed); len(got) != 1 || got[0].Error != "queue full" {
+		t.Fatalf("expected one failed reschedule, got %+v", got)
+	}
+	if got := store.TakePending("db-1"); len(got) != 0 {
+		t.Fatalf("expected nothing left pending, got %+v", got)
+	}
+
+	if w, started, err := store.End(later.ID); err != nil || started || w.Status != HostMaintenanceCancelled {
+		t.Fatalf("expected scheduled window cancelled: %+v %v %v", w, started, err)
+	}
+	if _, _, err := store.End(later.ID); err == nil {
+		t.Fatal("expected ending a cancelled window to fail")
+	}
+}
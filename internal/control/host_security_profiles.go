@@ -17,18 +17,21 @@ type HostSecurityProfileInput struct {
 	Contexts    []string `json:"contexts,omitempty"`
 	Profiles    []string `json:"profiles,omitempty"`
 	Description string   `json:"description,omitempty"`
+	// Controls are baseline host settings checked by CheckControls.
+	Controls []HostSecurityControl `json:"controls,omitempty"`
 }
 
 type HostSecurityProfile struct {
-	ID          string    `json:"id"`
-	Mode        string    `json:"mode"`
-	TargetKind  string    `json:"target_kind"`
-	Target      string    `json:"target"`
-	State       string    `json:"state"`
-	Contexts    []string  `json:"contexts,omitempty"`
-	Profiles    []string  `json:"profiles,omitempty"`
-	Description string    `json:"description,omitempty"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string                `json:"id"`
+	Mode        string                `json:"mode"`
+	TargetKind  string                `json:"target_kind"`
+	Target      string                `json:"target"`
+	State       string                `json:"state"`
+	Contexts    []string              `json:"contexts,omitempty"`
+	Profiles    []string              `json:"profiles,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Controls    []HostSecurityControl `json:"controls,omitempty"`
+	UpdatedAt   time.Time             `json:"updated_at"`
 }
 
 type HostSecurityEvaluateInput struct {
@@ -49,13 +52,18 @@ type HostSecurityDecision struct {
 }
 
 type HostSecurityProfileStore struct {
-	mu       sync.RWMutex
-	nextID   int64
-	profiles map[string]*HostSecurityProfile
+	mu          sync.RWMutex
+	nextID      int64
+	nextEvalID  int64
+	profiles    map[string]*HostSecurityProfile
+	evaluations map[string]*HostSecurityEvaluation
 }
 
 func NewHostSecurityProfileStore() *HostSecurityProfileStore {
-	return &HostSecurityProfileStore{profiles: map[string]*HostSecurityProfile{}}
+	return &HostSecurityProfileStore{
+		profiles:    map[string]*HostSecurityProfile{},
+		evaluations: map[string]*HostSecurityEvaluation{},
+	}
 }
 
 func (s *HostSecurityProfileStore) Upsert(in HostSecurityProfileInput) (HostSecurityProfile, error) {
@@ -72,6 +80,10 @@ func (s *HostSecurityProfileStore) Upsert(in HostSecurityProfileInput) (HostSecu
 	if !validHostSecurityState(mode, state) {
 		return HostSecurityProfile{}, errors.New("invalid state for selected mode")
 	}
+	controls, err := normalizeHostSecurityControls(in.Controls)
+	if err != nil {
+		return HostSecurityProfile{}, err
+	}
 	item := HostSecurityProfile{
 		Mode:        mode,
 		TargetKind:  targetKind,
@@ -80,6 +92,7 @@ func (s *HostSecurityProfileStore) Upsert(in HostSecurityProfileInput) (HostSecu
 		Contexts:    normalizeStringList(in.Contexts),
 		Profiles:    normalizeStringList(in.Profiles),
 		Description: strings.TrimSpace(in.Description),
+		Controls:    controls,
 		UpdatedAt:   time.Now().UTC(),
 	}
 	id := strings.ToLower(strings.TrimSpace(in.ID))
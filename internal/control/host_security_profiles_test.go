@@ -1,6 +1,9 @@
 package control
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestHostSecurityProfileStoreUpsertAndEvaluate(t *testing.T) {
 	store := NewHostSecurityProfileStore()
@@ -64,3 +67,86 @@ func TestHostSecurityProfileStoreValidation(t *testing.T) {
 		t.Fatalf("expected invalid selinux state error")
 	}
 }
+
+func TestHostSecurityControlChecksAndRemediationConfig(t *testing.T) {
+	store := NewHostSecurityProfileStore()
+	profile, err := store.Upsert(HostSecurityProfileInput{
+		Mode:       "selinux",
+		TargetKind: "host",
+		Target:     "node-1",
+		State:      "enforcing",
+		Controls: []HostSecurityControl{
+			{ID: "ip-forward", Kind: "sysctl", Key: "net.ipv4.ip_forward", Value: "0"},
+			{ID: "root-login", Kind: "sshd", Key: "PermitRootLogin", Value: "no"},
+			{ID: "audit-passwd", Kind: "auditd", Key: "-w /etc/passwd  -p wa -k identity"},
+			{ID: "syncookies", Kind: "sysctl", Key: "net.ipv4.tcp_syncookies", Value: "1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("upsert profile with controls failed: %v", err)
+	}
+	eval, err := store.CheckControls(profile.ID, HostSecurityCheckInput{
+		Host: "node-1",
+		Facts: HostControlFacts{
+			Sysctl: map[string]string{"net.ipv4.ip_forward": "1", "net.ipv4.tcp_syncookies": "1"},
+			SSHD:   map[string]string{"permitrootlogin": "No"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("check controls failed: %v", err)
+	}
+	if eval.Status != "fail" || eval.Failed != 2 {
+		t.Fatalf("expected two failed checks, got %+v", eval)
+	}
+	statuses := map[string]string{}
+	for _, check := range eval.Checks {
+		statuses[check.ControlID] = check.Status
+	}
+	if statuses["ip-forward"] != "fail" || statuses["root-login"] != "pass" || statuses["audit-passwd"] != "missing" || statuses["syncookies"] != "pass" {
+		t.Fatalf("unexpected check statuses: %+v", statuses)
+	}
+
+	cfg, err := BuildHostRemediationConfig(eval, HostRemediationOptions{Transport: "local", RootDir: "/tmp/root"})
+	if err != nil {
+		t.Fatalf("build remediation config failed: %v", err)
+	}
+	if len(cfg.Resources) != 4 {
+		t.Fatalf("expected sysctl and auditd file+reload resources, got %+v", cfg.Resources)
+	}
+	if cfg.Resources[0].Path != "/tmp/root/etc/sysctl.d/99-masterchef-remediation.conf" || !strings.Contains(cfg.Resources[0].Content, "net.ipv4.ip_forward = 0") {
+		t.Fatalf("unexpected sysctl resource: %+v", cfg.Resources[0])
+	}
+	if strings.Contains(cfg.Resources[0].Content, "tcp_syncookies") {
+		t.Fatalf("passing controls must not be remediated: %q", cfg.Resources[0].Content)
+	}
+	if cfg.Resources[3].Command != "augenrules --load" || cfg.Resources[3].DependsOn[0] != "remediate-auditd" {
+		t.Fatalf("unexpected auditd reload resource: %+v", cfg.Resources[3])
+	}
+
+	passing, err := store.CheckControls(profile.ID, HostSecurityCheckInput{
+		Host: "node-1",
+		Facts: HostControlFacts{
+			Sysctl:     map[string]string{"net.ipv4.ip_forward": "0", "net.ipv4.tcp_syncookies": "1"},
+			SSHD:       map[string]string{"PermitRootLogin": "no"},
+			AuditRules: []string{"-w /etc/passwd -p wa -k identity"},
+		},
+	})
+	if err != nil || passing.Status != "pass" {
+		t.Fatalf("expected passing evaluation, got %+v err=%v", passing, err)
+	}
+	if _, err := BuildHostRemediationConfig(passing, HostRemediationOptions{}); err == nil {
+		t.Fatalf("expected no remediation for a passing evaluation")
+	}
+	if got := store.ListEvaluations(profile.ID); len(got) != 2 {
+		t.Fatalf("expected two evaluations, got %d", len(got))
+	}
+	if _, err := store.Upsert(HostSecurityProfileInput{
+		Mode:       "selinux",
+		TargetKind: "host",
+		Target:     "node-2",
+		State:      "enforcing",
+		Controls:   []HostSecurityControl{{ID: "bad", Kind: "pam", Key: "x", Value: "y"}},
+	}); err == nil {
+		t.Fatalf("expected unknown control kind to be rejected")
+	}
+}
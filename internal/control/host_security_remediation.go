@@ -0,0 +1,296 @@
+package control
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// HostSecurityControl is one baseline setting a host profile requires. For
+// sysctl and sshd controls Key/Value are the setting and its value; for
+// auditd controls Key is the full audit rule and Value is unused.
+type HostSecurityControl struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"` // sysctl|sshd|auditd
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// HostControlFacts is what a host reports about its current settings.
+type HostControlFacts struct {
+	Sysctl     map[string]string `json:"sysctl,omitempty"`
+	SSHD       map[string]string `json:"sshd,omitempty"`
+	AuditRules []string          `json:"audit_rules,omitempty"`
+}
+
+type HostSecurityCheckInput struct {
+	Host  string           `json:"host"`
+	Facts HostControlFacts `json:"facts"`
+}
+
+type HostSecurityCheck struct {
+	ControlID string `json:"control_id"`
+	Kind      string `json:"kind"`
+	Key       string `json:"key"`
+	Expected  string `json:"expected,omitempty"`
+	Actual    string `json:"actual,omitempty"`
+	Status    string `json:"status"` // pass|fail|missing
+}
+
+type HostSecurityEvaluation struct {
+	ID                   string              `json:"id"`
+	ProfileID            string              `json:"profile_id"`
+	Host                 string              `json:"host"`
+	Status               string              `json:"status"` // pass|fail
+	Failed               int                 `json:"failed"`
+	Checks               []HostSecurityCheck `json:"checks"`
+	RemediationRunbookID string              `json:"remediation_runbook_id,omitempty"`
+	EvaluatedAt          time.Time           `json:"evaluated_at"`
+}
+
+// HostRemediationOptions shapes the generated config. RootDir prefixes every
+// written path, for image builds or chroots; Reload controls whether the
+// generated config reloads sysctl, sshd, and auditd after writing.
+type HostRemediationOptions struct {
+	Transport string `json:"transport,omitempty"`
+	Address   string `json:"address,omitempty"`
+	User      string `json:"user,omitempty"`
+	RootDir   string `json:"root_dir,omitempty"`
+	Reload    *bool  `json:"reload,omitempty"`
+}
+
+// CheckControls evaluates a host's reported facts against the profile's
+// baseline controls and records the evaluation.
+func (s *HostSecurityProfileStore) CheckControls(profileID string, in HostSecurityCheckInput) (HostSecurityEvaluation, error) {
+	host := strings.TrimSpace(in.Host)
+	if host == "" {
+		return HostSecurityEvaluation{}, errors.New("host is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	profile, ok := s.profiles[strings.ToLower(strings.TrimSpace(profileID))]
+	if !ok {
+		return HostSecurityEvaluation{}, errors.New("host security profile not found")
+	}
+	if len(profile.Controls) == 0 {
+		return HostSecurityEvaluation{}, errors.New("host security profile has no controls")
+	}
+	eval := HostSecurityEvaluation{
+		ProfileID:   profile.ID,
+		Host:        host,
+		Status:      "pass",
+		Checks:      make([]HostSecurityCheck, 0, len(profile.Controls)),
+		EvaluatedAt: time.Now().UTC(),
+	}
+	for _, ctl := range profile.Controls {
+		check := checkHostSecurityControl(ctl, in.Facts)
+		if check.Status != "pass" {
+			eval.Failed++
+			eval.Status = "fail"
+		}
+		eval.Checks = append(eval.Checks, check)
+	}
+	s.nextEvalID++
+	eval.ID = "host-security-eval-" + itoa(s.nextEvalID)
+	s.evaluations[eval.ID] = &eval
+	return cloneHostSecurityEvaluation(eval), nil
+}
+
+func (s *HostSecurityProfileStore) GetEvaluation(id string) (HostSecurityEvaluation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.evaluations[strings.TrimSpace(id)]
+	if !ok {
+		return HostSecurityEvaluation{}, errors.New("host security evaluation not found")
+	}
+	return cloneHostSecurityEvaluation(*item), nil
+}
+
+func (s *HostSecurityProfileStore) ListEvaluations(profileID string) []HostSecurityEvaluation {
+	profileID = strings.ToLower(strings.TrimSpace(profileID))
+	s.mu.RLock()
+	out := make([]HostSecurityEvaluation, 0, len(s.evaluations))
+	for _, item := range s.evaluations {
+		if profileID != "" && item.ProfileID != profileID {
+			continue
+		}
+		out = append(out, cloneHostSecurityEvaluation(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].EvaluatedAt.After(out[j].EvaluatedAt) })
+	return out
+}
+
+// LinkRemediation records the runbook generated for an evaluation.
+func (s *HostSecurityProfileStore) LinkRemediation(evalID, runbookID string) (HostSecurityEvaluation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.evaluations[strings.TrimSpace(evalID)]
+	if !ok {
+		return HostSecurityEvaluation{}, errors.New("host security evaluation not found")
+	}
+	item.RemediationRunbookID = runbookID
+	return cloneHostSecurityEvaluation(*item), nil
+}
+
+// BuildHostRemediationConfig turns an evaluation's failed checks into a
+// config for the evaluated host: one drop-in file per setting kind, each
+// followed by the command that reloads it.
+func BuildHostRemediationConfig(eval HostSecurityEvaluation, opts HostRemediationOptions) (*config.Config, error) {
+	var sysctl, sshd, audit []string
+	for _, check := range eval.Checks {
+		if check.Status == "pass" {
+			continue
+		}
+		switch check.Kind {
+		case "sysctl":
+			sysctl = append(sysctl, check.Key+" = "+check.Expected)
+		case "sshd":
+			sshd = append(sshd, check.Key+" "+check.Expected)
+		case "auditd":
+			audit = append(audit, check.Key)
+		}
+	}
+	if len(sysctl)+len(sshd)+len(audit) == 0 {
+		return nil, errors.New("evaluation has no failed checks to remediate")
+	}
+	transport := strings.ToLower(strings.TrimSpace(opts.Transport))
+	if transport == "" {
+		transport = "ssh"
+	}
+	reload := opts.Reload == nil || *opts.Reload
+	root := strings.TrimSpace(opts.RootDir)
+	cfg := &config.Config{
+		Version: "v0",
+		Inventory: config.Inventory{Hosts: []config.Host{{
+			Name:      eval.Host,
+			Transport: transport,
+			Address:   strings.TrimSpace(opts.Address),
+			User:      strings.TrimSpace(opts.User),
+		}}},
+	}
+	header := "# Generated by masterchef from " + eval.ID + " (profile " + eval.ProfileID + ")\n"
+	add := func(id, file string, lines []string, reloadCmd string) {
+		if len(lines) == 0 {
+			return
+		}
+		cfg.Resources = append(cfg.Resources, config.Resource{
+			ID:      "remediate-" + id,
+			Type:    "file",
+			Host:    eval.Host,
+			Path:    path.Join("/", root, file),
+			Content: header + strings.Join(lines, "\n") + "\n",
+			Mode:    "0644",
+		})
+		if reload {
+			cfg.Resources = append(cfg.Resources, config.Resource{
+				ID:        "reload-" + id,
+				Type:      "command",
+				Host:      eval.Host,
+				Command:   reloadCmd,
+				DependsOn: []string{"remediate-" + id},
+				Become:    true,
+			})
+		}
+	}
+	add("sysctl", "/etc/sysctl.d/99-masterchef-remediation.conf", sysctl, "sysctl --system")
+	add("sshd", "/etc/ssh/sshd_config.d/99-masterchef-remediation.conf", sshd, "sshd -t && systemctl reload sshd")
+	add("auditd", "/etc/audit/rules.d/99-masterchef-remediation.rules", audit, "augenrules --load")
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func checkHostSecurityControl(ctl HostSecurityControl, facts HostControlFacts) HostSecurityCheck {
+	check := HostSecurityCheck{ControlID: ctl.ID, Kind: ctl.Kind, Key: ctl.Key, Expected: ctl.Value}
+	var actual string
+	var ok bool
+	switch ctl.Kind {
+	case "sysctl":
+		actual, ok = facts.Sysctl[ctl.Key]
+		actual = strings.Join(strings.Fields(actual), " ")
+		check.Actual = actual
+		check.Status = hostCheckStatus(ok, actual == ctl.Value)
+	case "sshd":
+		for k, v := range facts.SSHD {
+			if strings.EqualFold(k, ctl.Key) {
+				actual, ok = strings.TrimSpace(v), true
+				break
+			}
+		}
+		check.Actual = actual
+		check.Status = hostCheckStatus(ok, strings.EqualFold(actual, ctl.Value))
+	case "auditd":
+		want := strings.Join(strings.Fields(ctl.Key), " ")
+		for _, rule := range facts.AuditRules {
+			if strings.Join(strings.Fields(rule), " ") == want {
+				ok = true
+				break
+			}
+		}
+		check.Status = hostCheckStatus(ok, true)
+	}
+	return check
+}
+
+func hostCheckStatus(present, matches bool) string {
+	switch {
+	case !present:
+		return "missing"
+	case !matches:
+		return "fail"
+	default:
+		return "pass"
+	}
+}
+
+func normalizeHostSecurityControls(in []HostSecurityControl) ([]HostSecurityControl, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make([]HostSecurityControl, 0, len(in))
+	seen := map[string]bool{}
+	for _, ctl := range in {
+		ctl.ID = strings.TrimSpace(ctl.ID)
+		ctl.Kind = strings.ToLower(strings.TrimSpace(ctl.Kind))
+		ctl.Key = strings.Join(strings.Fields(ctl.Key), " ")
+		ctl.Value = strings.Join(strings.Fields(ctl.Value), " ")
+		ctl.Description = strings.TrimSpace(ctl.Description)
+		if ctl.ID == "" || ctl.Key == "" {
+			return nil, errors.New("each control requires id and key")
+		}
+		if seen[ctl.ID] {
+			return nil, errors.New("duplicate control id " + ctl.ID)
+		}
+		seen[ctl.ID] = true
+		switch ctl.Kind {
+		case "sysctl", "sshd":
+			if ctl.Value == "" {
+				return nil, errors.New("control " + ctl.ID + " requires a value")
+			}
+			if strings.ContainsAny(ctl.Key+ctl.Value, "\n\r") || strings.Contains(ctl.Key, " ") {
+				return nil, errors.New("control " + ctl.ID + " has an invalid key or value")
+			}
+		case "auditd":
+			if strings.ContainsAny(ctl.Key, "\n\r") {
+				return nil, errors.New("control " + ctl.ID + " has an invalid audit rule")
+			}
+		default:
+			return nil, errors.New("control kind must be sysctl, sshd, or auditd")
+		}
+		out = append(out, ctl)
+	}
+	return out, nil
+}
+
+func cloneHostSecurityEvaluation(in HostSecurityEvaluation) HostSecurityEvaluation {
+	out := in
+	out.Checks = append([]HostSecurityCheck{}, in.Checks...)
+	return out
+}
@@ -0,0 +1,440 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// DefaultLocale is the source locale: message ids are the English text, so
+// it needs no translations and ends every fallback chain.
+const DefaultLocale = "en"
+
+// Message domains group source messages in the completeness report.
+const (
+	MessageDomainAPI       = "api"
+	MessageDomainChecklist = "checklist"
+	MessageDomainDocs      = "docs"
+)
+
+type LocalePackInput struct {
+	Locale   string            `json:"locale"`
+	Fallback string            `json:"fallback,omitempty"` // default the base language, then en
+	Messages map[string]string `json:"messages"`           // source message -> translation; %s is a placeholder
+}
+
+type LocaleSummary struct {
+	Locale    string    `json:"locale"`
+	Fallback  string    `json:"fallback,omitempty"`
+	Chain     []string  `json:"chain"`
+	Messages  int       `json:"messages"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+type LocaleCompleteness struct {
+	Locale     string         `json:"locale"`
+	Chain      []string       `json:"chain"`
+	Total      int            `json:"total"`
+	Translated int            `json:"translated"` // by the locale itself or a fallback other than en
+	Percent    float64        `json:"percent"`
+	Complete   bool           `json:"complete"`
+	Missing    []string       `json:"missing,omitempty"`
+	ByDomain   map[string]int `json:"missing_by_domain,omitempty"`
+}
+
+type localePack struct {
+	fallback  string
+	messages  map[string]string
+	updatedAt time.Time
+}
+
+// MessageCatalog translates user-facing strings gettext-style: the English
+// text is the message id, and a message with a %s placeholder matches any
+// string it can be filled into. Lookups walk the locale's fallback chain
+// and return the English text when no locale in it has a translation.
+type MessageCatalog struct {
+	mu      sync.RWMutex
+	sources map[string]string // message id -> domain
+	locales map[string]*localePack
+}
+
+func NewMessageCatalog() *MessageCatalog {
+	c := &MessageCatalog{sources: map[string]string{}, locales: map[string]*localePack{}}
+	for domain, messages := range builtinSourceMessages() {
+		c.Register(domain, messages...)
+	}
+	for locale, messages := range builtinTranslations {
+		c.locales[locale] = &localePack{messages: cloneStringMap(messages), updatedAt: time.Now().UTC()}
+	}
+	return c
+}
+
+// builtinSourceMessages lists the strings the built-in locales translate:
+// common API errors, checklist templates, generated docs headings, and the
+// built-in action docs.
+func builtinSourceMessages() map[string][]string {
+	out := map[string][]string{
+		MessageDomainAPI: {
+			"invalid json body",
+			"unknown action",
+			"object store unavailable",
+			"%s not found",
+			"%s is required",
+			"%s are required",
+			"phase must be pre or post",
+		},
+		MessageDomainDocs: {
+			"Masterchef Generated Documentation",
+			"Modules and Providers",
+			"Policy APIs",
+			"none",
+		},
+	}
+	for _, item := range defaultChecklistItems("high") {
+		out[MessageDomainChecklist] = append(out[MessageDomainChecklist], item.Prompt)
+	}
+	for _, doc := range NewActionDocCatalog().List() {
+		out[MessageDomainDocs] = append(out[MessageDomainDocs], doc.Title, doc.Summary)
+	}
+	return out
+}
+
+// Register adds source messages, e.g. the title and summary of a newly
+// published action doc, so the completeness report tracks them.
+func (c *MessageCatalog) Register(domain string, messages ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, msg := range messages {
+		if msg = strings.TrimSpace(msg); msg != "" {
+			c.sources[msg] = domain
+		}
+	}
+}
+
+func (c *MessageCatalog) UpsertLocale(in LocalePackInput) (LocaleSummary, error) {
+	locale, ok := normalizeLocaleTag(in.Locale)
+	if !ok {
+		return LocaleSummary{}, errors.New("locale must be a language tag such as es or pt-BR")
+	}
+	if locale == DefaultLocale {
+		return LocaleSummary{}, errors.New("en is the source locale and cannot be translated")
+	}
+	fallback := ""
+	if strings.TrimSpace(in.Fallback) != "" {
+		if fallback, ok = normalizeLocaleTag(in.Fallback); !ok {
+			return LocaleSummary{}, errors.New("fallback must be a language tag")
+		}
+	}
+	if len(in.Messages) == 0 {
+		return LocaleSummary{}, errors.New("messages are required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if fallback != "" && fallback != DefaultLocale {
+		if _, exists := c.locales[fallback]; !exists {
+			return LocaleSummary{}, errors.New("fallback locale " + fallback + " is not registered")
+		}
+		for next := fallback; next != ""; next = c.locales[next].fallback {
+			if next == locale {
+				return LocaleSummary{}, errors.New("fallback chain loops back to " + locale)
+			}
+			if _, exists := c.locales[next]; !exists {
+				break
+			}
+		}
+	}
+	pack, exists := c.locales[locale]
+	if !exists {
+		pack = &localePack{messages: map[string]string{}}
+		c.locales[locale] = pack
+	}
+	pack.fallback = fallback
+	for msg, translated := range in.Messages {
+		msg, translated = strings.TrimSpace(msg), strings.TrimSpace(translated)
+		if msg == "" {
+			continue
+		}
+		if translated == "" {
+			delete(pack.messages, msg)
+			continue
+		}
+		pack.messages[msg] = translated
+	}
+	pack.updatedAt = time.Now().UTC()
+	return c.summaryLocked(locale), nil
+}
+
+// Locales lists the registered locales, with en first.
+func (c *MessageCatalog) Locales() []LocaleSummary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := []LocaleSummary{{Locale: DefaultLocale, Chain: []string{DefaultLocale}, Messages: len(c.sources)}}
+	names := make([]string, 0, len(c.locales))
+	for name := range c.locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out = append(out, c.summaryLocked(name))
+	}
+	return out
+}
+
+// Negotiate picks the best registered locale for an Accept-Language header,
+// trying each tag by descending quality, then its base language. It returns
+// en when nothing else matches.
+func (c *MessageCatalog) Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	candidates := []candidate{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, cand := range candidates {
+		if cand.tag == "*" {
+			return DefaultLocale
+		}
+		tag, ok := normalizeLocaleTag(cand.tag)
+		if !ok {
+			continue
+		}
+		for _, try := range []string{tag, localeBase(tag)} {
+			if try == DefaultLocale {
+				return DefaultLocale
+			}
+			if _, exists := c.locales[try]; exists {
+				return try
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// Translate returns msg in locale, filling %s placeholders from msg, or msg
+// itself when no locale in the chain translates it.
+func (c *MessageCatalog) Translate(locale, msg string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, name := range c.chainLocked(locale) {
+		pack := c.locales[name]
+		if pack == nil {
+			continue
+		}
+		if translated, ok := pack.messages[msg]; ok {
+			return translated
+		}
+		if translated, ok := matchMessageTemplate(pack.messages, msg); ok {
+			return translated
+		}
+	}
+	return msg
+}
+
+// LocalizeMarkdown translates headings and list items of generated markdown
+// whose whole text is a known message; other lines are left as they are.
+func (c *MessageCatalog) LocalizeMarkdown(locale, content string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	chain := c.chainLocked(locale)
+	lookup := func(text string) (string, bool) {
+		for _, name := range chain {
+			if pack := c.locales[name]; pack != nil {
+				if translated, ok := pack.messages[text]; ok {
+					return translated, true
+				}
+			}
+		}
+		return "", false
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		prefix := ""
+		switch {
+		case strings.HasPrefix(line, "#"):
+			level := strings.IndexFunc(line, func(r rune) bool { return r != '#' })
+			if level < 0 {
+				continue
+			}
+			prefix = line[:level] + " "
+		case strings.HasPrefix(line, "- "):
+			prefix = "- "
+		default:
+			continue
+		}
+		text, ok := strings.CutPrefix(line, prefix)
+		if !ok {
+			continue
+		}
+		if translated, ok := lookup(text); ok {
+			lines[i] = prefix + translated
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Completeness reports, per registered locale, which source messages
+// resolve to English because nothing in the locale's chain translates them.
+func (c *MessageCatalog) Completeness() []LocaleCompleteness {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.locales))
+	for name := range c.locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]LocaleCompleteness, 0, len(names))
+	for _, name := range names {
+		chain := c.chainLocked(name)
+		item := LocaleCompleteness{Locale: name, Chain: chain, Total: len(c.sources), Missing: []string{}, ByDomain: map[string]int{}}
+		for msg, domain := range c.sources {
+			translated := false
+			for _, locale := range chain {
+				if pack := c.locales[locale]; pack != nil {
+					if _, ok := pack.messages[msg]; ok {
+						translated = true
+						break
+					}
+				}
+			}
+			if translated {
+				item.Translated++
+				continue
+			}
+			item.Missing = append(item.Missing, msg)
+			item.ByDomain[domain]++
+		}
+		sort.Strings(item.Missing)
+		if item.Total > 0 {
+			item.Percent = math.Round(float64(item.Translated)/float64(item.Total)*1000) / 10
+		}
+		item.Complete = len(item.Missing) == 0
+		if item.Complete {
+			item.Missing = nil
+			item.ByDomain = nil
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// chainLocked returns the registered locales consulted for locale: the
+// locale, its explicit fallbacks, its base language, and finally en.
+func (c *MessageCatalog) chainLocked(locale string) []string {
+	locale, ok := normalizeLocaleTag(locale)
+	if !ok {
+		return []string{DefaultLocale}
+	}
+	out := []string{}
+	seen := map[string]bool{DefaultLocale: true}
+	var walk func(name string)
+	walk = func(name string) {
+		for name != "" && !seen[name] {
+			seen[name] = true
+			pack, exists := c.locales[name]
+			if !exists {
+				break
+			}
+			out = append(out, name)
+			name = pack.fallback
+		}
+	}
+	walk(locale)
+	walk(localeBase(locale))
+	return append(out, DefaultLocale)
+}
+
+func (c *MessageCatalog) summaryLocked(locale string) LocaleSummary {
+	pack := c.locales[locale]
+	return LocaleSummary{
+		Locale:    locale,
+		Fallback:  pack.fallback,
+		Chain:     c.chainLocked(locale),
+		Messages:  len(pack.messages),
+		UpdatedAt: pack.updatedAt,
+	}
+}
+
+// matchMessageTemplate finds the most specific %s message that msg fills
+// and substitutes the filled text into its translation.
+func matchMessageTemplate(messages map[string]string, msg string) (string, bool) {
+	best, bestLen, fill := "", -1, ""
+	for template, translated := range messages {
+		prefix, suffix, ok := strings.Cut(template, "%s")
+		if !ok || strings.Contains(suffix, "%s") {
+			continue
+		}
+		if len(msg) <= len(prefix)+len(suffix) || !strings.HasPrefix(msg, prefix) || !strings.HasSuffix(msg, suffix) {
+			continue
+		}
+		if literal := len(prefix) + len(suffix); literal > bestLen {
+			best, bestLen, fill = translated, literal, msg[len(prefix):len(msg)-len(suffix)]
+		}
+	}
+	if bestLen < 0 {
+		return "", false
+	}
+	return strings.Replace(best, "%s", fill, 1), true
+}
+
+// normalizeLocaleTag canonicalizes a BCP 47 style tag to lowercase language
+// and uppercase region, e.g. pt_br -> pt-BR.
+func normalizeLocaleTag(raw string) (string, bool) {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(raw), "_", "-"), "-")
+	if len(parts) > 3 || len(parts[0]) < 2 || len(parts[0]) > 3 {
+		return "", false
+	}
+	for _, part := range parts {
+		if part == "" || len(part) > 8 {
+			return "", false
+		}
+		for _, r := range part {
+			if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return "", false
+			}
+		}
+	}
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-"), true
+}
+
+func localeBase(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
@@ -0,0 +1,70 @@
+package control
+
+// builtinTranslations are the locales shipped with the control plane. Keys
+// are the English source messages registered by builtinSourceMessages.
+var builtinTranslations = map[string]map[string]string{
+	"es": {
+		"invalid json body":         "cuerpo JSON no válido",
+		"unknown action":            "acción desconocida",
+		"object store unavailable":  "almacén de objetos no disponible",
+		"%s not found":              "%s no encontrado",
+		"%s is required":            "%s es obligatorio",
+		"%s are required":           "%s son obligatorios",
+		"phase must be pre or post": "la fase debe ser pre o post",
+
+		"Confirm change scope, blast radius, and rollback path are reviewed.":            "Confirme que se revisaron el alcance del cambio, el radio de impacto y la ruta de reversión.",
+		"Capture baseline health metrics and canary status before execution.":            "Registre las métricas de salud de referencia y el estado del canario antes de la ejecución.",
+		"Validate service health, error rates, and run outcome after execution.":         "Valide la salud del servicio, las tasas de error y el resultado tras la ejecución.",
+		"Update handoff notes with risks, blockers, and follow-up actions.":              "Actualice las notas de traspaso con riesgos, bloqueos y acciones de seguimiento.",
+		"Confirm high-risk approval quorum and maintenance window are active.":           "Confirme que el quórum de aprobación de alto riesgo y la ventana de mantenimiento están activos.",
+		"Confirm rollback command path remains available until stability window closes.": "Confirme que la ruta de reversión sigue disponible hasta que se cierre la ventana de estabilidad.",
+
+		"Masterchef Generated Documentation": "Documentación generada de Masterchef",
+		"Modules and Providers":              "Módulos y proveedores",
+		"Policy APIs":                        "API de políticas",
+		"none":                               "ninguno",
+
+		"Bootstrap Workspace Template":                                                      "Inicializar plantilla de espacio de trabajo",
+		"Scaffold a workspace template and optionally create launch artifacts.":             "Genera una plantilla de espacio de trabajo y, opcionalmente, crea artefactos de lanzamiento.",
+		"Preview and Execute Bulk Operations":                                               "Previsualizar y ejecutar operaciones masivas",
+		"Run staged bulk changes with conflict detection and explicit confirmation.":        "Ejecuta cambios masivos por etapas con detección de conflictos y confirmación explícita.",
+		"Investigate Failed Run":                                                            "Investigar una ejecución fallida",
+		"Inspect timeline and triage bundle, then retry or rollback from the same context.": "Revisa la línea de tiempo y el paquete de triaje, y reintenta o revierte desde el mismo contexto.",
+		"Correlate Incident Signals":                                                        "Correlacionar señales de incidentes",
+		"Aggregate events, alerts, run outcomes, and observability links in one view.":      "Reúne eventos, alertas, resultados de ejecuciones y enlaces de observabilidad en una sola vista.",
+		"Preview a Config Change":                                                           "Previsualizar un cambio de configuración",
+		"Plan a config without applying it and explain every step it would take.":           "Planifica una configuración sin aplicarla y explica cada paso que daría.",
+	},
+	"de": {
+		"invalid json body":         "ungültiger JSON-Body",
+		"unknown action":            "unbekannte Aktion",
+		"object store unavailable":  "Objektspeicher nicht verfügbar",
+		"%s not found":              "%s nicht gefunden",
+		"%s is required":            "%s ist erforderlich",
+		"%s are required":           "%s sind erforderlich",
+		"phase must be pre or post": "Phase muss pre oder post sein",
+
+		"Confirm change scope, blast radius, and rollback path are reviewed.":            "Bestätigen Sie, dass Änderungsumfang, Auswirkungsradius und Rollback-Pfad geprüft wurden.",
+		"Capture baseline health metrics and canary status before execution.":            "Erfassen Sie vor der Ausführung die Basis-Gesundheitsmetriken und den Canary-Status.",
+		"Validate service health, error rates, and run outcome after execution.":         "Prüfen Sie nach der Ausführung Dienstzustand, Fehlerraten und Ergebnis des Laufs.",
+		"Update handoff notes with risks, blockers, and follow-up actions.":              "Ergänzen Sie die Übergabenotizen um Risiken, Blocker und Folgeaufgaben.",
+		"Confirm high-risk approval quorum and maintenance window are active.":           "Bestätigen Sie, dass Genehmigungsquorum für hohes Risiko und Wartungsfenster aktiv sind.",
+		"Confirm rollback command path remains available until stability window closes.": "Bestätigen Sie, dass der Rollback-Pfad verfügbar bleibt, bis das Stabilitätsfenster schließt.",
+
+		"Masterchef Generated Documentation": "Generierte Masterchef-Dokumentation",
+		"Modules and Providers":              "Module und Provider",
+		"Policy APIs":                        "Richtlinien-APIs",
+		"none":                               "keine",
+
+		"Bootstrap Workspace Template":                                                      "Arbeitsbereichsvorlage initialisieren",
+		"Scaffold a workspace template and optionally create launch artifacts.":             "Erstellt das Gerüst einer Arbeitsbereichsvorlage und optional Start-Artefakte.",
+		"Preview and Execute Bulk Operations":                                               "Massenoperationen prüfen und ausführen",
+		"Run staged bulk changes with conflict detection and explicit confirmation.":        "Führt gestaffelte Massenänderungen mit Konflikterkennung und expliziter Bestätigung aus.",
+		"Investigate Failed Run":                                                            "Fehlgeschlagenen Lauf untersuchen",
+		"Inspect timeline and triage bundle, then retry or rollback from the same context.": "Zeitleiste und Triage-Paket prüfen, dann aus demselben Kontext wiederholen oder zurückrollen.",
+		"Correlate Incident Signals":                                                        "Incident-Signale korrelieren",
+		"Aggregate events, alerts, run outcomes, and observability links in one view.":      "Bündelt Ereignisse, Alarme, Laufergebnisse und Observability-Links in einer Ansicht.",
+		"Preview a Config Change":                                                           "Konfigurationsänderung vorab prüfen",
+		"Plan a config without applying it and explain every step it would take.":           "Plant eine Konfiguration, ohne sie anzuwenden, und erklärt jeden Schritt.",
+	},
+}
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessageCatalogNegotiatesAndTranslatesWithFallbacks(t *testing.T) {
+	c := NewMessageCatalog()
+	cases := map[string]string{
+		"":                          "en",
+		"es":                        "es",
+		"es-MX,es;q=0.8":            "es",
+		"fr-CA, de;q=0.7, en;q=0.5": "de",
+		"fr, en;q=0.9, es;q=0.1":    "en",
+		"de;q=0, es;q=0.2":          "es",
+		"*":                         "en",
+		"not a tag!":                "en",
+	}
+	for header, want := range cases {
+		if got := c.Negotiate(header); got != want {
+			t.Fatalf("negotiate %q: got %q want %q", header, got, want)
+		}
+	}
+
+	if got := c.Translate("es", "invalid json body"); got != "cuerpo JSON no válido" {
+		t.Fatalf("unexpected exact translation %q", got)
+	}
+	if got := c.Translate("de", "checklist not found"); got != "checklist nicht gefunden" {
+		t.Fatalf("unexpected template translation %q", got)
+	}
+	if got := c.Translate("es", "name and version are required"); got != "name and version son obligatorios" {
+		t.Fatalf("expected most specific template, got %q", got)
+	}
+	if got := c.Translate("es", "something went wrong"); got != "something went wrong" {
+		t.Fatalf("expected english fallback, got %q", got)
+	}
+
+	if _, err := c.UpsertLocale(LocalePackInput{Locale: "es_mx", Fallback: "es", Messages: map[string]string{"unknown action": "acción no reconocida"}}); err != nil {
+		t.Fatalf("upsert locale: %v", err)
+	}
+	if got := c.Negotiate("es-MX"); got != "es-MX" {
+		t.Fatalf("expected registered regional locale, got %q", got)
+	}
+	if got := c.Translate("es-MX", "unknown action"); got != "acción no reconocida" {
+		t.Fatalf("expected regional override, got %q", got)
+	}
+	if got := c.Translate("es-MX", "invalid json body"); got != "cuerpo JSON no válido" {
+		t.Fatalf("expected fallback to es, got %q", got)
+	}
+	if _, err := c.UpsertLocale(LocalePackInput{Locale: "es", Fallback: "es-MX", Messages: map[string]string{"none": "nada"}}); err == nil {
+		t.Fatalf("expected fallback loop to be rejected")
+	}
+	if _, err := c.UpsertLocale(LocalePackInput{Locale: "en", Messages: map[string]string{"none": "nothing"}}); err == nil {
+		t.Fatalf("expected source locale to be rejected")
+	}
+	if _, err := c.UpsertLocale(LocalePackInput{Locale: "fr", Fallback: "it", Messages: map[string]string{"none": "aucun"}}); err == nil {
+		t.Fatalf("expected unknown fallback to be rejected")
+	}
+
+	md := c.LocalizeMarkdown("de", "# Masterchef Generated Documentation\n\n## Policy APIs\n- none\n- GET /v1/none\n")
+	if !strings.Contains(md, "# Generierte Masterchef-Dokumentation\n") || !strings.Contains(md, "## Richtlinien-APIs\n- keine\n- GET /v1/none") {
+		t.Fatalf("unexpected localized markdown: %q", md)
+	}
+}
+
+func TestMessageCatalogCompleteness(t *testing.T) {
+	c := NewMessageCatalog()
+	for _, item := range c.Completeness() {
+		if !item.Complete || item.Percent != 100 {
+			t.Fatalf("expected built-in locales to be complete, got %+v", item)
+		}
+	}
+	c.Register(MessageDomainDocs, "Rotate Certificates", "Renew and roll out expiring certificates.")
+	if _, err := c.UpsertLocale(LocalePackInput{Locale: "pt-BR", Messages: map[string]string{"none": "nenhum"}}); err != nil {
+		t.Fatalf("upsert locale: %v", err)
+	}
+	report := map[string]LocaleCompleteness{}
+	for _, item := range c.Completeness() {
+		report[item.Locale] = item
+	}
+	es := report["es"]
+	if es.Complete || len(es.Missing) != 2 || es.ByDomain[MessageDomainDocs] != 2 || es.Translated != es.Total-2 {
+		t.Fatalf("expected registered docs messages to be missing in es, got %+v", es)
+	}
+	pt := report["pt-BR"]
+	if pt.Translated != 1 || len(pt.Chain) != 2 || pt.Chain[1] != DefaultLocale || pt.ByDomain[MessageDomainChecklist] != 6 {
+		t.Fatalf("unexpected pt-BR completeness: %+v", pt)
+	}
+}
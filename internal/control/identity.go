@@ -12,25 +12,27 @@ import (
 )
 
 type SSOProvider struct {
-	ID             string    `json:"id"`
-	Name           string    `json:"name"`
-	Protocol       string    `json:"protocol"` // oidc|saml
-	IssuerURL      string    `json:"issuer_url"`
-	ClientID       string    `json:"client_id"`
-	RedirectURL    string    `json:"redirect_url"`
-	AllowedDomains []string  `json:"allowed_domains,omitempty"`
-	Enabled        bool      `json:"enabled"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Protocol       string                `json:"protocol"` // oidc|saml
+	IssuerURL      string                `json:"issuer_url"`
+	ClientID       string                `json:"client_id"`
+	RedirectURL    string                `json:"redirect_url"`
+	AllowedDomains []string              `json:"allowed_domains,omitempty"`
+	SAML           *SAMLProviderSettings `json:"saml,omitempty"`
+	Enabled        bool                  `json:"enabled"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
 }
 
 type SSOProviderInput struct {
-	Name           string   `json:"name"`
-	Protocol       string   `json:"protocol"`
-	IssuerURL      string   `json:"issuer_url"`
-	ClientID       string   `json:"client_id"`
-	RedirectURL    string   `json:"redirect_url"`
-	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	Name           string                `json:"name"`
+	Protocol       string                `json:"protocol"`
+	IssuerURL      string                `json:"issuer_url"`
+	ClientID       string                `json:"client_id"`
+	RedirectURL    string                `json:"redirect_url"`
+	AllowedDomains []string              `json:"allowed_domains,omitempty"`
+	SAML           *SAMLProviderSettings `json:"saml,omitempty"`
 }
 
 type SSOLoginStartInput struct {
@@ -55,37 +57,46 @@ type SSOLoginCompleteInput struct {
 }
 
 type SSOSession struct {
-	ID         string    `json:"id"`
-	ProviderID string    `json:"provider_id"`
-	Subject    string    `json:"subject"`
-	Email      string    `json:"email"`
-	Groups     []string  `json:"groups,omitempty"`
-	Token      string    `json:"token"`
-	IssuedAt   time.Time `json:"issued_at"`
-	ExpiresAt  time.Time `json:"expires_at"`
+	ID            string             `json:"id"`
+	ProviderID    string             `json:"provider_id"`
+	Subject       string             `json:"subject"`
+	Email         string             `json:"email"`
+	Groups        []string           `json:"groups,omitempty"`
+	RoleBindings  []RBACBindingInput `json:"role_bindings,omitempty"`
+	Token         string             `json:"-"`
+	CSRFToken     string             `json:"-"`
+	IssuedAt      time.Time          `json:"issued_at"`
+	LastSeenAt    time.Time          `json:"last_seen_at"`
+	IdleExpiresAt time.Time          `json:"idle_expires_at"`
+	ExpiresAt     time.Time          `json:"expires_at"`
 }
 
 type pendingSSOLogin struct {
-	providerID string
-	email      string
-	relayState string
-	expiresAt  time.Time
+	providerID    string
+	email         string
+	relayState    string
+	expiresAt     time.Time
+	samlRequestID string
 }
 
 type IdentityStore struct {
-	mu           sync.RWMutex
-	nextProvider int64
-	nextSession  int64
-	providers    map[string]*SSOProvider
-	pending      map[string]pendingSSOLogin
-	sessions     map[string]*SSOSession
+	mu             sync.RWMutex
+	nextProvider   int64
+	nextSession    int64
+	providers      map[string]*SSOProvider
+	pending        map[string]pendingSSOLogin
+	sessions       map[string]*SSOSession
+	samlAssertions map[string]time.Time
+	sessionPolicy  SSOSessionPolicy
 }
 
 func NewIdentityStore() *IdentityStore {
 	return &IdentityStore{
-		providers: map[string]*SSOProvider{},
-		pending:   map[string]pendingSSOLogin{},
-		sessions:  map[string]*SSOSession{},
+		providers:      map[string]*SSOProvider{},
+		pending:        map[string]pendingSSOLogin{},
+		sessions:       map[string]*SSOSession{},
+		samlAssertions: map[string]time.Time{},
+		sessionPolicy:  defaultSSOSessionPolicy(),
 	}
 }
 
@@ -107,6 +118,14 @@ func (s *IdentityStore) CreateProvider(in SSOProviderInput) (SSOProvider, error)
 	if _, err := url.ParseRequestURI(redirectURL); err != nil {
 		return SSOProvider{}, errors.New("redirect_url must be valid")
 	}
+	var samlSettings *SAMLProviderSettings
+	if protocol == "saml" {
+		settings, err := normalizeSAMLSettings(in.SAML)
+		if err != nil {
+			return SSOProvider{}, err
+		}
+		samlSettings = settings
+	}
 	now := time.Now().UTC()
 	item := SSOProvider{
 		Name:           name,
@@ -115,6 +134,7 @@ func (s *IdentityStore) CreateProvider(in SSOProviderInput) (SSOProvider, error)
 		ClientID:       clientID,
 		RedirectURL:    redirectURL,
 		AllowedDomains: normalizeStringSlice(in.AllowedDomains),
+		SAML:           samlSettings,
 		Enabled:        true,
 		CreatedAt:      now,
 		UpdatedAt:      now,
@@ -183,14 +203,28 @@ func (s *IdentityStore) StartLogin(in SSOLoginStartInput) (SSOLoginStart, error)
 	if err != nil {
 		return SSOLoginStart{}, err
 	}
-	expiresAt := time.Now().UTC().Add(10 * time.Minute)
-	s.pending[state] = pendingSSOLogin{
+	now := time.Now().UTC()
+	expiresAt := now.Add(10 * time.Minute)
+	pending := pendingSSOLogin{
 		providerID: providerID,
 		email:      email,
 		relayState: strings.TrimSpace(in.RelayState),
 		expiresAt:  expiresAt,
 	}
 	authURL := provider.IssuerURL + "/authorize?client_id=" + url.QueryEscape(provider.ClientID) + "&redirect_uri=" + url.QueryEscape(provider.RedirectURL) + "&state=" + url.QueryEscape(state)
+	if provider.Protocol == "saml" {
+		requestID, err := randomToken(16)
+		if err != nil {
+			return SSOLoginStart{}, err
+		}
+		// SAML IDs are xsd:ID values and may not start with a digit.
+		pending.samlRequestID = "_" + requestID
+		authURL, err = samlRedirectURL(cloneSSOProvider(*provider), pending.samlRequestID, state, now)
+		if err != nil {
+			return SSOLoginStart{}, err
+		}
+	}
+	s.pending[state] = pending
 	return SSOLoginStart{
 		ProviderID: providerID,
 		State:      state,
@@ -220,26 +254,18 @@ func (s *IdentityStore) CompleteLogin(in SSOLoginCompleteInput) (SSOSession, err
 	if !ok {
 		return SSOSession{}, errors.New("sso provider not found")
 	}
+	if provider.Protocol == "saml" {
+		return SSOSession{}, errors.New("saml providers complete login through the assertion consumer service")
+	}
 	if len(provider.AllowedDomains) > 0 && !emailDomainAllowed(email, provider.AllowedDomains) {
 		return SSOSession{}, errors.New("email domain not allowed for provider")
 	}
-	token, err := randomToken(24)
-	if err != nil {
-		return SSOSession{}, err
-	}
-	s.nextSession++
-	item := SSOSession{
-		ID:         "sso-session-" + itoa(s.nextSession),
+	return s.issueSessionLocked(SSOSession{
 		ProviderID: pending.providerID,
 		Subject:    subject,
 		Email:      email,
 		Groups:     normalizeStringSlice(in.Groups),
-		Token:      "mcsso_" + token,
-		IssuedAt:   now,
-		ExpiresAt:  now.Add(8 * time.Hour),
-	}
-	s.sessions[item.ID] = &item
-	return cloneSSOSession(item), nil
+	}, now)
 }
 
 func (s *IdentityStore) ListSessions() []SSOSession {
@@ -267,18 +293,31 @@ func (s *IdentityStore) GetSession(id string) (SSOSession, bool) {
 	return cloneSSOSession(*item), true
 }
 
-func (s *IdentityStore) expirePendingLocked(now time.Time) {
-	for state, item := range s.pending {
-		if !now.Before(item.expiresAt) {
-			delete(s.pending, state)
+// RevokeSessionsFor ends every session whose subject or email matches one
+// of principals, returning how many were revoked.
+func (s *IdentityStore) RevokeSessionsFor(principals ...string) int {
+	match := map[string]bool{}
+	for _, principal := range principals {
+		if principal = strings.ToLower(strings.TrimSpace(principal)); principal != "" {
+			match[principal] = true
 		}
 	}
-}
-
-func (s *IdentityStore) expireSessionsLocked(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revoked := 0
 	for id, item := range s.sessions {
-		if !now.Before(item.ExpiresAt) {
+		if match[strings.ToLower(item.Subject)] || match[item.Email] {
 			delete(s.sessions, id)
+			revoked++
+		}
+	}
+	return revoked
+}
+
+func (s *IdentityStore) expirePendingLocked(now time.Time) {
+	for state, item := range s.pending {
+		if !now.Before(item.expiresAt) {
+			delete(s.pending, state)
 		}
 	}
 }
@@ -308,11 +347,17 @@ func randomToken(bytesCount int) (string, error) {
 func cloneSSOProvider(in SSOProvider) SSOProvider {
 	out := in
 	out.AllowedDomains = append([]string{}, in.AllowedDomains...)
+	if in.SAML != nil {
+		settings := *in.SAML
+		settings.RoleMappings = append([]SSORoleMapping{}, in.SAML.RoleMappings...)
+		out.SAML = &settings
+	}
 	return out
 }
 
 func cloneSSOSession(in SSOSession) SSOSession {
 	out := in
 	out.Groups = append([]string{}, in.Groups...)
+	out.RoleBindings = append([]RBACBindingInput{}, in.RoleBindings...)
 	return out
 }
@@ -1,6 +1,9 @@
 package control
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestSSOProviderAndSessionFlow(t *testing.T) {
 	store := NewIdentityStore()
@@ -68,3 +71,73 @@ func TestSSODomainValidation(t *testing.T) {
 		t.Fatalf("expected domain restriction failure")
 	}
 }
+
+func TestSSOSessionLifecycleControls(t *testing.T) {
+	store := NewIdentityStore()
+	provider, err := store.CreateProvider(SSOProviderInput{Name: "Okta", Protocol: "oidc", IssuerURL: "https://id.example.com", ClientID: "mc", RedirectURL: "https://mc.example.com/cb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	login := func(email string) SSOSession {
+		start, err := store.StartLogin(SSOLoginStartInput{ProviderID: provider.ID, Email: email})
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := store.CompleteLogin(SSOLoginCompleteInput{State: start.State, Code: "c", Subject: email, Email: email})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return session
+	}
+
+	if _, err := store.SetSessionPolicy(SSOSessionPolicy{IdleTimeoutSeconds: 600, AbsoluteLifetimeSeconds: 60}); err == nil {
+		t.Fatalf("expected idle timeout above absolute lifetime to fail")
+	}
+	if _, err := store.SetSessionPolicy(SSOSessionPolicy{IdleTimeoutSeconds: 600, AbsoluteLifetimeSeconds: 3600, MaxConcurrentSessions: 2}); err != nil {
+		t.Fatal(err)
+	}
+	first := login("alice@example.com")
+	if first.CSRFToken == "" || !first.IdleExpiresAt.Equal(first.LastSeenAt.Add(10*time.Minute)) || !first.ExpiresAt.Equal(first.IssuedAt.Add(time.Hour)) {
+		t.Fatalf("unexpected session windows: %+v", first)
+	}
+	second := login("alice@example.com")
+	third := login("alice@example.com")
+	login("bob@example.com")
+	if _, ok := store.GetSession(first.ID); ok {
+		t.Fatalf("expected oldest session to be evicted by the concurrent limit")
+	}
+	if _, ok := store.GetSession(second.ID); !ok {
+		t.Fatalf("expected newer session to survive")
+	}
+
+	if _, err := store.VerifySessionCSRF(third.Token, "wrong"); err == nil {
+		t.Fatalf("expected csrf mismatch to fail")
+	}
+	if _, err := store.VerifySessionCSRF(third.Token, third.CSRFToken); err != nil {
+		t.Fatalf("expected csrf token bound to session to verify: %v", err)
+	}
+	refreshed, err := store.RefreshSession(third.Token)
+	if err != nil || refreshed.ID != third.ID || refreshed.Token == third.Token || refreshed.CSRFToken == third.CSRFToken || !refreshed.ExpiresAt.Equal(third.ExpiresAt) {
+		t.Fatalf("unexpected refresh: %+v err=%v", refreshed, err)
+	}
+	if _, err := store.AuthenticateSession(third.Token); err == nil {
+		t.Fatalf("expected rotated token to stop working")
+	}
+
+	store.mu.Lock()
+	store.sessions[second.ID].IdleExpiresAt = time.Now().UTC().Add(-time.Second)
+	store.mu.Unlock()
+	if _, err := store.AuthenticateSession(second.Token); err == nil {
+		t.Fatalf("expected idle session to expire")
+	}
+
+	if n := store.RevokeSessionsFor("ALICE@example.com"); n != 1 {
+		t.Fatalf("expected one remaining alice session to be revoked, got %d", n)
+	}
+	if _, err := store.RevokeSession("sso-session-99"); err == nil {
+		t.Fatalf("expected unknown session revoke to fail")
+	}
+	if sessions := store.ListSessions(); len(sessions) != 1 || sessions[0].Email != "bob@example.com" {
+		t.Fatalf("expected only bob's session to remain: %+v", sessions)
+	}
+}
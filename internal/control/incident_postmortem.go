@@ -0,0 +1,237 @@
+package control
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+// remediationEventPrefixes are event types that record an operator or
+// automation acting on an incident rather than observing it.
+var remediationEventPrefixes = []string{
+	"run.rollback.",
+	"runbook.launched",
+	"workflow.launched",
+	"rule.action.",
+	"drift.remediat",
+	"access.break_glass.",
+}
+
+// IsRemediationEvent reports whether an event type records a remediation
+// action.
+func IsRemediationEvent(eventType string) bool {
+	for _, prefix := range remediationEventPrefixes {
+		if strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IncidentPostmortemInput is the correlated incident state a postmortem
+// skeleton is rendered from.
+type IncidentPostmortemInput struct {
+	Title       string
+	Workload    string
+	RunID       string
+	WindowStart time.Time
+	GeneratedAt time.Time
+	RiskScore   int
+	RiskLevel   string
+	Events      []Event
+	Alerts      []AlertItem
+	Runs        []state.RunRecord
+	Annotations []Annotation
+	NextSteps   []string
+}
+
+type postmortemEntry struct {
+	at     time.Time
+	source string
+	text   string
+}
+
+// RenderIncidentPostmortem renders a Markdown postmortem skeleton: a merged
+// timeline of events, alerts, runs, annotations, and remediation actions,
+// followed by sections for the team to complete.
+func RenderIncidentPostmortem(in IncidentPostmortemInput) string {
+	title := strings.TrimSpace(in.Title)
+	if title == "" {
+		scope := firstNonEmpty(in.Workload, in.RunID, "fleet")
+		title = "Incident postmortem: " + scope
+	}
+	generated := in.GeneratedAt
+	if generated.IsZero() {
+		generated = time.Now().UTC()
+	}
+
+	var b strings.Builder
+	b.WriteString("# " + postmortemText(title) + "\n\n")
+	b.WriteString("- Generated: " + generated.UTC().Format(time.RFC3339) + "\n")
+	if !in.WindowStart.IsZero() {
+		b.WriteString("- Window: " + in.WindowStart.UTC().Format(time.RFC3339) + " to " + generated.UTC().Format(time.RFC3339) + "\n")
+	}
+	if in.Workload != "" {
+		b.WriteString("- Workload: " + postmortemText(in.Workload) + "\n")
+	}
+	if in.RunID != "" {
+		b.WriteString("- Run: " + postmortemText(in.RunID) + "\n")
+	}
+	b.WriteString("- Risk: " + firstNonEmpty(in.RiskLevel, "unknown") + " (score " + strconv.Itoa(in.RiskScore) + ")\n")
+	b.WriteString("- Status: draft\n\n")
+
+	failedRuns := 0
+	for _, run := range in.Runs {
+		if run.Status == state.RunFailed {
+			failedRuns++
+		}
+	}
+	remediations := make([]Event, 0)
+	for _, evt := range in.Events {
+		if IsRemediationEvent(evt.Type) {
+			remediations = append(remediations, evt)
+		}
+	}
+	sort.SliceStable(remediations, func(i, j int) bool { return remediations[i].Time.Before(remediations[j].Time) })
+	b.WriteString("## Summary\n\n")
+	b.WriteString("_Describe what happened and its impact._\n\n")
+	b.WriteString("| Signal | Count |\n|---|---|\n")
+	b.WriteString("| Events | " + strconv.Itoa(len(in.Events)) + " |\n")
+	b.WriteString("| Alerts | " + strconv.Itoa(len(in.Alerts)) + " |\n")
+	b.WriteString("| Runs | " + strconv.Itoa(len(in.Runs)) + " |\n")
+	b.WriteString("| Failed runs | " + strconv.Itoa(failedRuns) + " |\n")
+	b.WriteString("| Remediation actions | " + strconv.Itoa(len(remediations)) + " |\n")
+	b.WriteString("| Annotations | " + strconv.Itoa(len(in.Annotations)) + " |\n\n")
+
+	entries := make([]postmortemEntry, 0, len(in.Events)+len(in.Alerts)+2*len(in.Runs)+len(in.Annotations))
+	for _, evt := range in.Events {
+		source := "event"
+		if IsRemediationEvent(evt.Type) {
+			source = "remediation"
+		}
+		entries = append(entries, postmortemEntry{at: evt.Time, source: source, text: "`" + evt.Type + "` " + postmortemText(evt.Message)})
+	}
+	for _, alert := range in.Alerts {
+		entries = append(entries, postmortemEntry{at: alert.FirstSeenAt, source: "alert", text: "[" + alert.Severity + "] " + postmortemText(alert.Message) + " (" + alert.ID + ", " + string(alert.Status) + ")"})
+	}
+	for _, run := range in.Runs {
+		if !run.StartedAt.IsZero() {
+			entries = append(entries, postmortemEntry{at: run.StartedAt, source: "run", text: run.ID + " started"})
+		}
+		if !run.EndedAt.IsZero() {
+			entries = append(entries, postmortemEntry{at: run.EndedAt, source: "run", text: run.ID + " finished " + string(run.Status)})
+		}
+	}
+	for _, note := range in.Annotations {
+		entries = append(entries, postmortemEntry{at: note.CreatedAt, source: "annotation", text: postmortemText(note.Author) + " on " + note.TargetKind + " " + note.TargetID + ": " + postmortemText(firstLine(note.Body))})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+	b.WriteString("## Timeline\n\n")
+	if len(entries) == 0 {
+		b.WriteString("_No correlated signals in the window._\n")
+	}
+	for _, e := range entries {
+		b.WriteString("- " + e.at.UTC().Format(time.RFC3339) + " **" + e.source + "** " + e.text + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Alerts\n\n")
+	if len(in.Alerts) == 0 {
+		b.WriteString("_None._\n\n")
+	} else {
+		b.WriteString("| ID | Severity | Status | Count | Message |\n|---|---|---|---|---|\n")
+		for _, alert := range in.Alerts {
+			b.WriteString("| " + alert.ID + " | " + alert.Severity + " | " + string(alert.Status) + " | " + strconv.Itoa(alert.Count) + " | " + postmortemCell(alert.Message) + " |\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Runs\n\n")
+	if len(in.Runs) == 0 {
+		b.WriteString("_None._\n\n")
+	} else {
+		b.WriteString("| ID | Status | Started | Ended | Changed resources |\n|---|---|---|---|---|\n")
+		for _, run := range in.Runs {
+			changed := 0
+			for _, res := range run.Results {
+				if res.Changed {
+					changed++
+				}
+			}
+			b.WriteString("| " + run.ID + " | " + string(run.Status) + " | " + postmortemTime(run.StartedAt) + " | " + postmortemTime(run.EndedAt) + " | " + strconv.Itoa(changed) + " |\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Remediation actions\n\n")
+	if len(remediations) == 0 {
+		b.WriteString("_None recorded._\n")
+	}
+	for _, evt := range remediations {
+		b.WriteString("- " + evt.Time.UTC().Format(time.RFC3339) + " `" + evt.Type + "` " + postmortemText(evt.Message) + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Annotations\n\n")
+	if len(in.Annotations) == 0 {
+		b.WriteString("_None._\n\n")
+	}
+	for _, note := range in.Annotations {
+		b.WriteString("### " + postmortemText(note.Author) + " on " + note.TargetKind + " " + note.TargetID + " (" + note.CreatedAt.UTC().Format(time.RFC3339) + ")\n\n")
+		b.WriteString(note.Body + "\n\n")
+		for _, link := range note.Links {
+			b.WriteString("- [" + postmortemText(firstNonEmpty(link.Label, link.URL)) + "](" + link.URL + ")\n")
+		}
+		if len(note.Links) > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("## Root cause\n\n_To be completed._\n\n")
+	b.WriteString("## Action items\n\n")
+	for _, step := range in.NextSteps {
+		b.WriteString("- [ ] " + postmortemText(step) + "\n")
+	}
+	if len(in.NextSteps) == 0 {
+		b.WriteString("- [ ] _Add follow-up work._\n")
+	}
+	b.WriteString("\n## Lessons learned\n\n_To be completed._\n")
+	return b.String()
+}
+
+// postmortemText flattens signal text onto one line so it cannot break the
+// surrounding Markdown structure.
+func postmortemText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func postmortemCell(s string) string {
+	return strings.ReplaceAll(postmortemText(s), "|", `\|`)
+}
+
+func postmortemTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package control
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestRenderIncidentPostmortem(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	md := RenderIncidentPostmortem(IncidentPostmortemInput{
+		Workload:    "payments",
+		WindowStart: start,
+		GeneratedAt: start.Add(time.Hour),
+		RiskScore:   65,
+		RiskLevel:   "high",
+		Events: []Event{
+			{Time: start.Add(20 * time.Minute), Type: "run.rollback.requested", Message: "rollback requested"},
+			{Time: start.Add(5 * time.Minute), Type: "job.failed", Message: "apply failed\non db-1"},
+		},
+		Alerts:      []AlertItem{{ID: "alert-1", Severity: "high", Status: AlertOpen, Count: 2, Message: "disk | full", FirstSeenAt: start.Add(10 * time.Minute)}},
+		Runs:        []state.RunRecord{{ID: "run-1", Status: state.RunFailed, StartedAt: start.Add(time.Minute), EndedAt: start.Add(4 * time.Minute)}},
+		Annotations: []Annotation{{ID: "ann-1", TargetKind: "run", TargetID: "run-1", Author: "alice", Body: "root cause: disk full\nmore detail", CreatedAt: start.Add(30 * time.Minute), Links: []AnnotationLink{{Label: "graph", URL: "https://grafana.example.com/d/1"}}}},
+		NextSteps:   []string{"pause high-risk rollouts"},
+	})
+	for _, want := range []string{
+		"# Incident postmortem: payments",
+		"- Risk: high (score 65)",
+		"| Failed runs | 1 |",
+		"| Remediation actions | 1 |",
+		"**remediation** `run.rollback.requested` rollback requested",
+		"`job.failed` apply failed on db-1",
+		`disk \| full`,
+		"- [graph](https://grafana.example.com/d/1)",
+		"- [ ] pause high-risk rollouts",
+		"## Root cause",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected postmortem to contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Index(md, "run-1 started") > strings.Index(md, "**alert**") || strings.Index(md, "**alert**") > strings.Index(md, "**annotation**") {
+		t.Fatalf("expected timeline in chronological order:\n%s", md)
+	}
+}
+
+func TestRenderTextPDF(t *testing.T) {
+	text := strings.Repeat("line (with parens) and a long tail "+strings.Repeat("x", 120)+"\n", 80)
+	pdf := RenderTextPDF("Postmortem", text+"caf\u00e9")
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("expected a complete pdf document")
+	}
+	if !bytes.Contains(pdf, []byte(`line \(with parens\)`)) || !bytes.Contains(pdf, []byte("(caf?)")) {
+		t.Fatalf("expected escaped text in pdf content")
+	}
+	if !bytes.Contains(pdf, []byte("/Count 3")) {
+		t.Fatalf("expected wrapped text to span three pages")
+	}
+	xref := bytes.Index(pdf, []byte("xref\n"))
+	entries := strings.Split(string(pdf[xref:]), "\n")[3:]
+	for i := 0; i < 10; i++ {
+		off, err := strconv.Atoi(entries[i][:10])
+		if err != nil || !bytes.HasPrefix(pdf[off:], []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Fatalf("xref entry %d does not point at its object: %q", i+1, entries[i])
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package control
 
 import (
+	"errors"
 	"sort"
 	"strings"
 	"sync"
@@ -24,9 +25,10 @@ type InventoryDriftEntry struct {
 }
 
 type InventoryReconcileAction struct {
-	Host   string `json:"host"`
-	Action string `json:"action"` // enroll|decommission|update_labels
-	Reason string `json:"reason"`
+	Host   string            `json:"host"`
+	Action string            `json:"action"` // enroll|quarantine|update_labels
+	Reason string            `json:"reason"`
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type InventoryDriftReport struct {
@@ -35,6 +37,9 @@ type InventoryDriftReport struct {
 	Summary   map[string]int             `json:"summary"`
 	Entries   []InventoryDriftEntry      `json:"entries"`
 	Actions   []InventoryReconcileAction `json:"actions,omitempty"`
+	// Remediation is the bulk preview generated from Actions; it is applied
+	// only once confirmed through POST /v1/bulk/execute.
+	Remediation *BulkPreview `json:"remediation,omitempty"`
 }
 
 type InventoryDriftStore struct {
@@ -69,6 +74,7 @@ func (s *InventoryDriftStore) Analyze(in InventoryDriftAnalyzeInput, withActions
 					Host:   name,
 					Action: "enroll",
 					Reason: "re-enroll missing desired host",
+					Labels: want.Labels,
 				})
 			}
 			continue
@@ -85,6 +91,7 @@ func (s *InventoryDriftStore) Analyze(in InventoryDriftAnalyzeInput, withActions
 					Host:   name,
 					Action: "update_labels",
 					Reason: "align observed labels with desired inventory labels",
+					Labels: want.Labels,
 				})
 			}
 		}
@@ -102,8 +109,8 @@ func (s *InventoryDriftStore) Analyze(in InventoryDriftAnalyzeInput, withActions
 		if withActions {
 			actions = append(actions, InventoryReconcileAction{
 				Host:   name,
-				Action: "decommission",
-				Reason: "remove unexpected host from managed inventory",
+				Action: "quarantine",
+				Reason: "quarantine host observed outside the desired inventory",
 			})
 		}
 	}
@@ -124,6 +131,17 @@ func (s *InventoryDriftStore) Analyze(in InventoryDriftAnalyzeInput, withActions
 	return report
 }
 
+func (s *InventoryDriftStore) AttachRemediation(id string, preview BulkPreview) (InventoryDriftReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report, ok := s.reports[strings.TrimSpace(id)]
+	if !ok {
+		return InventoryDriftReport{}, errors.New("inventory drift report not found")
+	}
+	report.Remediation = &preview
+	return *report, nil
+}
+
 func (s *InventoryDriftStore) List(limit int) []InventoryDriftReport {
 	if limit <= 0 {
 		limit = 50
@@ -141,6 +159,48 @@ func (s *InventoryDriftStore) List(limit int) []InventoryDriftReport {
 	return out
 }
 
+// InventoryRemediationOperations turns reconcile actions into bulk operations
+// so they run through the bulk preview/confirm flow. Relabelled hosts also get
+// a converge job when convergeConfigPath is set.
+func InventoryRemediationOperations(report InventoryDriftReport, convergeConfigPath string) []BulkOperation {
+	convergeConfigPath = strings.TrimSpace(convergeConfigPath)
+	out := make([]BulkOperation, 0, len(report.Actions))
+	for _, action := range report.Actions {
+		switch action.Action {
+		case "enroll":
+			out = append(out, BulkOperation{
+				Action:     "node.enroll",
+				TargetType: "node",
+				TargetID:   action.Host,
+				Params:     map[string]any{"labels": action.Labels, "reason": action.Reason},
+			})
+		case "quarantine":
+			out = append(out, BulkOperation{
+				Action:     "node.quarantine",
+				TargetType: "node",
+				TargetID:   action.Host,
+				Params:     map[string]any{"reason": action.Reason},
+			})
+		case "update_labels":
+			out = append(out, BulkOperation{
+				Action:     "node.update_labels",
+				TargetType: "node",
+				TargetID:   action.Host,
+				Params:     map[string]any{"labels": action.Labels, "reason": action.Reason},
+			})
+			if convergeConfigPath != "" {
+				out = append(out, BulkOperation{
+					Action:     "node.converge",
+					TargetType: "node",
+					TargetID:   action.Host,
+					Params:     map[string]any{"config_path": convergeConfigPath, "reason": "converge reclassified host"},
+				})
+			}
+		}
+	}
+	return out
+}
+
 func normalizeInventoryHosts(in []InventoryDriftHost) map[string]InventoryDriftHost {
 	out := map[string]InventoryDriftHost{}
 	for _, host := range in {
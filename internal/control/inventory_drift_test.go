@@ -23,4 +23,19 @@ func TestInventoryDriftStoreAnalyzeAndReconcile(t *testing.T) {
 	if len(report.Actions) != 3 {
 		t.Fatalf("expected reconcile actions, got %+v", report.Actions)
 	}
+
+	ops := InventoryRemediationOperations(report, "converge.yaml")
+	if len(ops) != 4 {
+		t.Fatalf("expected enroll, quarantine, relabel, and converge operations, got %+v", ops)
+	}
+	actions := map[string]string{}
+	for _, op := range ops {
+		actions[op.Action] = op.TargetID
+	}
+	if actions["node.enroll"] != "node-b" || actions["node.quarantine"] != "node-c" || actions["node.update_labels"] != "node-a" || actions["node.converge"] != "node-a" {
+		t.Fatalf("unexpected remediation operations %+v", ops)
+	}
+	if len(InventoryRemediationOperations(report, "")) != 3 {
+		t.Fatalf("expected no converge operation without a config path")
+	}
 }
@@ -36,76 +36,117 @@ type CMDBImportResult struct {
 }
 
 func BulkImportFromCMDB(nodes *NodeLifecycleStore, req CMDBImportRequest) (CMDBImportResult, error) {
-	if nodes == nil {
-		return CMDBImportResult{}, errors.New("node lifecycle store is required")
-	}
-	source := strings.ToLower(strings.TrimSpace(req.SourceSystem))
-	if source == "" {
-		source = "cmdb"
+	importer, err := NewCMDBImporter(nodes, req.SourceSystem, req.DryRun)
+	if err != nil {
+		return CMDBImportResult{}, err
 	}
 	if len(req.Records) == 0 {
 		return CMDBImportResult{}, errors.New("records are required")
 	}
+	for _, record := range req.Records {
+		importer.Import(record)
+	}
+	return importer.Result(), nil
+}
+
+// CMDBImporter applies CMDB records one at a time so large imports can be
+// streamed without holding the full record set in memory.
+type CMDBImporter struct {
+	nodes       *NodeLifecycleStore
+	sourceLabel string
+	out         CMDBImportResult
 
-	out := CMDBImportResult{
-		SourceSystem: source,
-		DryRun:       req.DryRun,
-		Results:      make([]CMDBImportItemResult, 0, len(req.Records)),
+	// FailuresOnly keeps per-record results for failed records only, which
+	// bounds result size for streamed imports.
+	FailuresOnly bool
+}
+
+func NewCMDBImporter(nodes *NodeLifecycleStore, sourceSystem string, dryRun bool) (*CMDBImporter, error) {
+	if nodes == nil {
+		return nil, errors.New("node lifecycle store is required")
 	}
-	sourceLabel := "cmdb:" + source
-	for _, record := range req.Records {
-		name := strings.TrimSpace(record.Name)
-		if name == "" {
-			out.Failed++
-			out.Results = append(out.Results, CMDBImportItemResult{
-				Status: "failed",
-				Error:  "record name is required",
-			})
-			continue
-		}
-		input := NodeEnrollInput{
-			Name:      name,
-			Address:   record.Address,
-			Transport: record.Transport,
-			Labels:    record.Labels,
-			Roles:     record.Roles,
-			Topology:  record.Topology,
-			Source:    sourceLabel,
-		}
+	source := strings.ToLower(strings.TrimSpace(sourceSystem))
+	if source == "" {
+		source = "cmdb"
+	}
+	return &CMDBImporter{
+		nodes:       nodes,
+		sourceLabel: "cmdb:" + source,
+		out: CMDBImportResult{
+			SourceSystem: source,
+			DryRun:       dryRun,
+			Results:      []CMDBImportItemResult{},
+		},
+	}, nil
+}
 
-		if req.DryRun {
-			_, exists := nodes.Get(name)
-			status := "would_import"
-			if exists {
-				status = "would_update"
-			}
-			out.Results = append(out.Results, CMDBImportItemResult{Name: name, Status: status})
-			if exists {
-				out.Updated++
-			} else {
-				out.Imported++
-			}
-			continue
-		}
+// Processed returns how many records have been imported so far.
+func (i *CMDBImporter) Processed() int {
+	return i.out.Imported + i.out.Updated + i.out.Failed
+}
 
-		_, created, err := nodes.Enroll(input)
-		if err != nil {
-			out.Failed++
-			out.Results = append(out.Results, CMDBImportItemResult{
-				Name:   name,
-				Status: "failed",
-				Error:  err.Error(),
-			})
-			continue
-		}
-		status := "updated"
-		if created {
-			status = "imported"
-			out.Imported++
+func (i *CMDBImporter) Result() CMDBImportResult {
+	out := i.out
+	out.Results = append([]CMDBImportItemResult{}, i.out.Results...)
+	return out
+}
+
+func (i *CMDBImporter) Import(record CMDBRecord) {
+	name := strings.TrimSpace(record.Name)
+	if name == "" {
+		i.out.Failed++
+		i.out.Results = append(i.out.Results, CMDBImportItemResult{
+			Status: "failed",
+			Error:  "record name is required",
+		})
+		return
+	}
+	input := NodeEnrollInput{
+		Name:      name,
+		Address:   record.Address,
+		Transport: record.Transport,
+		Labels:    record.Labels,
+		Roles:     record.Roles,
+		Topology:  record.Topology,
+		Source:    i.sourceLabel,
+	}
+
+	if i.out.DryRun {
+		_, exists := i.nodes.Get(name)
+		status := "would_import"
+		if exists {
+			status = "would_update"
+			i.out.Updated++
 		} else {
-			out.Updated++
+			i.out.Imported++
 		}
-		out.Results = append(out.Results, CMDBImportItemResult{Name: name, Status: status})
+		i.record(CMDBImportItemResult{Name: name, Status: status})
+		return
+	}
+
+	_, created, err := i.nodes.Enroll(input)
+	if err != nil {
+		i.out.Failed++
+		i.out.Results = append(i.out.Results, CMDBImportItemResult{
+			Name:   name,
+			Status: "failed",
+			Error:  err.Error(),
+		})
+		return
+	}
+	status := "updated"
+	if created {
+		status = "imported"
+		i.out.Imported++
+	} else {
+		i.out.Updated++
+	}
+	i.record(CMDBImportItemResult{Name: name, Status: status})
+}
+
+func (i *CMDBImporter) record(item CMDBImportItemResult) {
+	if i.FailuresOnly {
+		return
 	}
-	return out, nil
+	i.out.Results = append(i.out.Results, item)
 }
@@ -0,0 +1,532 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IsolatedRun records one job run inside an execution environment.
+type IsolatedRun struct {
+	JobID         string    `json:"job_id"`
+	EnvironmentID string    `json:"environment_id"`
+	Mode          string    `json:"mode"`
+	Runtime       string    `json:"runtime,omitempty"`
+	Command       []string  `json:"command"`
+	CredentialID  string    `json:"credential_id,omitempty"`
+	CloudLeases   []string  `json:"cloud_credential_leases,omitempty"`
+	Status        string    `json:"status"` // running|succeeded|failed|timed_out
+	ExitCode      int       `json:"exit_code"`
+	Output        string    `json:"output,omitempty"`
+	Artifacts     []string  `json:"artifacts,omitempty"`
+	ArtifactDir   string    `json:"artifact_dir,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	EndedAt       time.Time `json:"ended_at,omitempty"`
+}
+
+type isolationCommand struct {
+	name string
+	args []string
+	env  []string
+}
+
+// IsolatedRunner runs jobs that select an execution environment inside a
+// container or chroot and passes every other job to the inner executor.
+//
+// The job's config directory is staged into a per-job workspace; the run
+// applies it with the masterchef binary inside the environment. Run
+// reports and the environment's artifact paths are copied back afterwards.
+type IsolatedRunner struct {
+	baseDir  string
+	inner    Executor
+	envs     *ExecutionEnvironmentStore
+	creds    *ExecutionCredentialStore
+	cloud    *CloudCredentialBroker
+	lookPath func(string) (string, error)
+	command  func(ctx context.Context, cmd isolationCommand) (string, int, error)
+
+	mu    sync.RWMutex
+	runs  map[string]*IsolatedRun
+	order []string
+}
+
+func NewIsolatedRunner(baseDir string, inner Executor, envs *ExecutionEnvironmentStore, creds *ExecutionCredentialStore) *IsolatedRunner {
+	return &IsolatedRunner{
+		baseDir:  baseDir,
+		inner:    inner,
+		envs:     envs,
+		creds:    creds,
+		lookPath: exec.LookPath,
+		command:  runIsolationCommand,
+		runs:     map[string]*IsolatedRun{},
+	}
+}
+
+// SetCloudCredentialBroker lets jobs request cloud credentials, which are
+// minted when the run starts and ended when it finishes.
+func (r *IsolatedRunner) SetCloudCredentialBroker(b *CloudCredentialBroker) {
+	r.mu.Lock()
+	r.cloud = b
+	r.mu.Unlock()
+}
+
+func (r *IsolatedRunner) ApplyPath(configPath string) error {
+	return r.inner.ApplyPath(configPath)
+}
+
+// applyInner runs a job in-process, handing the whole job to the inner
+// executor when it accepts one.
+func (r *IsolatedRunner) applyInner(job Job) error {
+	if jobExec, ok := r.inner.(JobExecutor); ok {
+		return jobExec.ApplyJob(job)
+	}
+	return r.inner.ApplyPath(job.ConfigPath)
+}
+
+func (r *IsolatedRunner) ApplyJob(job Job) error {
+	if job.ExecutionEnv == "" {
+		if len(job.CloudCredentials) > 0 {
+			return errors.New("cloud credentials require an isolated execution environment")
+		}
+		return r.applyInner(job)
+	}
+	env, ok := r.envs.Get(job.ExecutionEnv)
+	if !ok {
+		return errors.New("execution environment " + job.ExecutionEnv + " not found")
+	}
+	// Admission is checked again at run time in case the policy changed
+	// while the job was queued.
+	if result := r.envs.EvaluateAdmission(env); !result.Allowed {
+		return errors.New("execution environment rejected: " + result.Reason)
+	}
+	if env.Isolation.Mode == "none" {
+		if len(job.CloudCredentials) > 0 {
+			return errors.New("cloud credentials require an isolated execution environment")
+		}
+		return r.applyInner(job)
+	}
+	run := &IsolatedRun{
+		JobID:         job.ID,
+		EnvironmentID: env.ID,
+		Mode:          env.Isolation.Mode,
+		Status:        "running",
+		StartedAt:     time.Now().UTC(),
+	}
+	r.mu.Lock()
+	r.runs[job.ID] = run
+	r.order = append(r.order, job.ID)
+	if len(r.order) > 1000 {
+		for _, id := range r.order[:len(r.order)-1000] {
+			delete(r.runs, id)
+		}
+		r.order = append([]string{}, r.order[len(r.order)-1000:]...)
+	}
+	r.mu.Unlock()
+
+	err := r.execute(job, env, run)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.EndedAt = time.Now().UTC()
+	if err != nil {
+		run.Error = err.Error()
+		if run.Status == "running" {
+			run.Status = "failed"
+		}
+		return err
+	}
+	run.Status = "succeeded"
+	return nil
+}
+
+func (r *IsolatedRunner) Get(jobID string) (IsolatedRun, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	run, ok := r.runs[strings.TrimSpace(jobID)]
+	if !ok {
+		return IsolatedRun{}, false
+	}
+	return cloneIsolatedRun(*run), true
+}
+
+func (r *IsolatedRunner) List(limit int) []IsolatedRun {
+	r.mu.RLock()
+	out := make([]IsolatedRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		out = append(out, cloneIsolatedRun(*run))
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (r *IsolatedRunner) execute(job Job, env ExecutionEnvironment, run *IsolatedRun) error {
+	iso := env.Isolation
+	workspace := filepath.Join(r.baseDir, ".masterchef", "isolation", job.ID)
+	jobDir := "/workspace"
+	root := ""
+	if iso.Mode == "chroot" {
+		var err error
+		if root, err = isolationChrootRoot(iso.ChrootPath); err != nil {
+			return err
+		}
+		jobDir = "/var/lib/masterchef/jobs/" + job.ID
+		workspace = filepath.Join(root, filepath.FromSlash(jobDir))
+	}
+	if err := stageIsolationWorkspace(filepath.Dir(job.ConfigPath), workspace); err != nil {
+		return errors.New("stage workspace: " + err.Error())
+	}
+	defer os.RemoveAll(workspace)
+
+	injected := map[string]string{
+		"MC_JOB_ID":           job.ID,
+		"MC_EXECUTION_ENV_ID": env.ID,
+	}
+	for k, v := range iso.Env {
+		injected[k] = v
+	}
+	timeout := time.Duration(iso.Limits.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = time.Hour
+	}
+	if r.creds != nil {
+		ttl := int(timeout/time.Second) + 60
+		if ttl > 3600 {
+			ttl = 3600
+		}
+		issued, err := r.creds.Issue(ExecutionCredentialIssueInput{
+			Subject:    "job:" + job.ID,
+			Scopes:     iso.CredentialScopes,
+			TTLSeconds: ttl,
+		})
+		if err != nil {
+			return errors.New("issue execution credential: " + err.Error())
+		}
+		defer func() { _, _ = r.creds.Revoke(issued.Credential.ID) }()
+		injected["MC_EXECUTION_TOKEN"] = issued.Token
+		injected["MC_EXECUTION_CREDENTIAL_ID"] = issued.Credential.ID
+		r.mu.Lock()
+		run.CredentialID = issued.Credential.ID
+		r.mu.Unlock()
+	}
+	if len(job.CloudCredentials) > 0 {
+		r.mu.RLock()
+		broker := r.cloud
+		r.mu.RUnlock()
+		if broker == nil {
+			return errors.New("cloud credential brokering is not configured")
+		}
+		for _, ref := range job.CloudCredentials {
+			lease, vars, err := broker.Mint(ref, job.ID, run.CredentialID)
+			if err != nil {
+				return errors.New("mint cloud credential " + ref + ": " + err.Error())
+			}
+			defer func() { _, _ = broker.Release(lease.ID) }()
+			for k, v := range vars {
+				injected[k] = v
+			}
+			r.mu.Lock()
+			run.CloudLeases = append(run.CloudLeases, lease.ID)
+			r.mu.Unlock()
+		}
+	}
+
+	var cmd isolationCommand
+	runtime := ""
+	switch iso.Mode {
+	case "container":
+		runtime = iso.Runtime
+		if runtime == "" {
+			for _, candidate := range []string{"podman", "docker"} {
+				if _, err := r.lookPath(candidate); err == nil {
+					runtime = candidate
+					break
+				}
+			}
+			if runtime == "" {
+				return errors.New("no container runtime found; install podman or docker")
+			}
+		}
+		// The env file sits beside, not inside, the mounted workspace.
+		envFile := workspace + ".env"
+		if err := writeIsolationEnvFile(envFile, injected); err != nil {
+			return errors.New("write env file: " + err.Error())
+		}
+		defer os.Remove(envFile)
+		cmd = containerIsolationCommand(runtime, job, env, workspace, envFile)
+	case "chroot":
+		cmd = chrootIsolationCommand(job, env, root, jobDir, injected)
+	}
+	r.mu.Lock()
+	run.Runtime = runtime
+	run.Command = append([]string{cmd.name}, cmd.args...)
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	output, exitCode, err := r.command(ctx, cmd)
+	timedOut := ctx.Err() == context.DeadlineExceeded
+	if timedOut && iso.Mode == "container" {
+		// Killing the client does not always stop the container.
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, _, _ = r.command(cleanupCtx, isolationCommand{name: runtime, args: []string{"rm", "-f", isolationContainerName(job.ID)}})
+		cleanupCancel()
+	}
+
+	artifactDir := filepath.Join(r.baseDir, ".masterchef", "artifacts", job.ID)
+	artifacts, extractErr := extractIsolationArtifacts(workspace, r.baseDir, artifactDir, iso.ArtifactPaths)
+	r.mu.Lock()
+	run.ExitCode = exitCode
+	run.Output = output
+	run.Artifacts = artifacts
+	if len(artifacts) > 0 {
+		run.ArtifactDir = artifactDir
+	}
+	if timedOut {
+		run.Status = "timed_out"
+	}
+	r.mu.Unlock()
+
+	switch {
+	case timedOut:
+		return errors.New("isolated run timed out after " + timeout.String())
+	case err != nil:
+		return errors.New("isolated run failed: " + err.Error())
+	case exitCode != 0:
+		return errors.New("isolated run exited with code " + strconv.Itoa(exitCode) + lastOutputLine(output))
+	case extractErr != nil:
+		return errors.New("extract artifacts: " + extractErr.Error())
+	}
+	return nil
+}
+
+// containerIsolationCommand passes injected variables to the container
+// through an env file, so values stay out of argv and out of the runtime
+// client's own environment.
+func containerIsolationCommand(runtime string, job Job, env ExecutionEnvironment, workspace, envFile string) isolationCommand {
+	limits := env.Isolation.Limits
+	args := []string{"run", "--rm", "--name", isolationContainerName(job.ID)}
+	if limits.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64))
+	}
+	if limits.MemoryMB > 0 {
+		args = append(args, "--memory", strconv.Itoa(limits.MemoryMB)+"m")
+	}
+	args = append(args, "-v", workspace+":/workspace", "-w", "/workspace", "--env-file", envFile)
+	args = append(args, env.Isolation.Image+"@"+env.ImageDigest)
+	args = append(args, "masterchef", "apply", "-f", filepath.Base(job.ConfigPath), "-yes", "-non-interactive")
+	return isolationCommand{name: runtime, args: args, env: os.Environ()}
+}
+
+// writeIsolationEnvFile writes injected variables as NAME=VALUE lines
+// readable only by the control plane's user.
+func writeIsolationEnvFile(path string, injected map[string]string) error {
+	var b strings.Builder
+	for _, pair := range envPairs(injected, sortedKeys(injected)) {
+		if strings.ContainsAny(pair, "\r\n") {
+			return errors.New("env values must not contain newlines")
+		}
+		b.WriteString(pair)
+		b.WriteByte('\n')
+	}
+	_ = os.Remove(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// isolationChrootRoot resolves the chroot root and refuses the host root,
+// since the job workspace inside it is removed after every run.
+func isolationChrootRoot(path string) (string, error) {
+	root, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", errors.New("chroot root: " + err.Error())
+	}
+	if root == "/" || root == string(filepath.Separator) {
+		return "", errors.New("chroot_path must be a dedicated root, not /")
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", errors.New("chroot root: " + err.Error())
+	}
+	if !info.IsDir() {
+		return "", errors.New("chroot_path is not a directory")
+	}
+	return root, nil
+}
+
+// chrootIsolationCommand limits memory with an address-space rlimit and CPU
+// with a CPU-seconds budget of cpus x timeout, since a chroot has no cgroup
+// of its own.
+func chrootIsolationCommand(job Job, env ExecutionEnvironment, root, jobDir string, injected map[string]string) isolationCommand {
+	limits := env.Isolation.Limits
+	script := "cd " + isolationShellQuote(jobDir) + " && exec masterchef apply -f " + isolationShellQuote(filepath.Base(job.ConfigPath)) + " -yes -non-interactive"
+	args := []string{root, "/bin/sh", "-c", script}
+	name := "chroot"
+	if limits.MemoryMB > 0 || (limits.CPUs > 0 && limits.TimeoutSeconds > 0) {
+		prlimit := []string{}
+		if limits.MemoryMB > 0 {
+			prlimit = append(prlimit, "--as="+strconv.FormatInt(int64(limits.MemoryMB)<<20, 10))
+		}
+		if limits.CPUs > 0 && limits.TimeoutSeconds > 0 {
+			prlimit = append(prlimit, "--cpu="+strconv.Itoa(int(limits.CPUs*float64(limits.TimeoutSeconds)+0.5)))
+		}
+		args = append(append(prlimit, "--", "chroot"), args...)
+		name = "prlimit"
+	}
+	names := sortedKeys(injected)
+	base := []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin", "HOME=/root"}
+	return isolationCommand{name: name, args: args, env: append(base, envPairs(injected, names)...)}
+}
+
+func runIsolationCommand(ctx context.Context, cmd isolationCommand) (string, int, error) {
+	c := exec.CommandContext(ctx, cmd.name, cmd.args...)
+	c.Env = cmd.env
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.Stderr = &out
+	err := c.Run()
+	output := out.String()
+	if len(output) > 64<<10 {
+		output = output[len(output)-64<<10:]
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return output, -1, err
+	}
+	return output, 0, nil
+}
+
+// stageIsolationWorkspace copies the config directory into the job
+// workspace, leaving out local state.
+func stageIsolationWorkspace(src, dst string) error {
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".masterchef" || d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
+
+// extractIsolationArtifacts copies run reports into the control plane's
+// run history and files matching patterns into artifactDir.
+func extractIsolationArtifacts(workspace, baseDir, artifactDir string, patterns []string) ([]string, error) {
+	reports, _ := filepath.Glob(filepath.Join(workspace, ".masterchef", "runs", "*.json"))
+	for _, report := range reports {
+		if err := copyFile(report, filepath.Join(baseDir, ".masterchef", "runs", filepath.Base(report))); err != nil {
+			return nil, err
+		}
+	}
+	out := []string{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(workspace, filepath.FromSlash(pattern)))
+		if err != nil {
+			return out, err
+		}
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() || !d.Type().IsRegular() {
+					return err
+				}
+				rel, err := filepath.Rel(workspace, path)
+				if err != nil {
+					return err
+				}
+				if err := copyFile(path, filepath.Join(artifactDir, rel)); err != nil {
+					return err
+				}
+				out = append(out, filepath.ToSlash(rel))
+				return nil
+			})
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func isolationContainerName(jobID string) string {
+	return "mc-" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, jobID)
+}
+
+func isolationShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func sortedKeys(in map[string]string) []string {
+	out := make([]string, 0, len(in))
+	for k := range in {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func envPairs(in map[string]string, names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, name+"="+in[name])
+	}
+	return out
+}
+
+func lastOutputLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return ": " + last
+	}
+	return ""
+}
+
+func cloneIsolatedRun(in IsolatedRun) IsolatedRun {
+	out := in
+	out.Command = append([]string{}, in.Command...)
+	out.Artifacts = append([]string{}, in.Artifacts...)
+	out.CloudLeases = append([]string{}, in.CloudLeases...)
+	return out
+}
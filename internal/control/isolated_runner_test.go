@@ -0,0 +1,205 @@
+package control
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const isolatedTestDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+func newIsolatedTestRunner(t *testing.T, iso ExecutionIsolation) (*IsolatedRunner, *fakeExecutor, ExecutionEnvironment, string) {
+	t.Helper()
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "configs", ".masterchef"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(base, "configs", "site.yaml")
+	if err := os.WriteFile(cfg, []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	envs := NewExecutionEnvironmentStore()
+	env, err := envs.Create(ExecutionEnvironmentInput{
+		Name:         "hermetic",
+		ImageDigest:  isolatedTestDigest,
+		Signed:       true,
+		SignatureRef: "sigstore://hermetic",
+		Isolation:    iso,
+	})
+	if err != nil {
+		t.Fatalf("create env failed: %v", err)
+	}
+	inner := &fakeExecutor{}
+	r := NewIsolatedRunner(base, inner, envs, NewExecutionCredentialStore())
+	r.lookPath = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	return r, inner, env, cfg
+}
+
+func TestIsolatedRunnerContainerRun(t *testing.T) {
+	r, inner, env, cfg := newIsolatedTestRunner(t, ExecutionIsolation{
+		Mode:             "container",
+		Image:            "registry.local/masterchef-runner",
+		Limits:           ExecutionResourceLimits{CPUs: 1.5, MemoryMB: 512, TimeoutSeconds: 60},
+		Env:              map[string]string{"APP_ENV": "prod"},
+		CredentialScopes: []string{"secrets:read"},
+		ArtifactPaths:    []string{"out/*.txt"},
+	})
+	var got isolationCommand
+	var envFile, envFileData string
+	r.command = func(ctx context.Context, cmd isolationCommand) (string, int, error) {
+		got = cmd
+		var workspace string
+		for i, arg := range cmd.args {
+			switch arg {
+			case "-v":
+				workspace = strings.TrimSuffix(cmd.args[i+1], ":/workspace")
+			case "--env-file":
+				envFile = cmd.args[i+1]
+			}
+		}
+		if info, err := os.Stat(envFile); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("expected private env file, info=%v err=%v", info, err)
+		}
+		data, _ := os.ReadFile(envFile)
+		envFileData = string(data)
+		if _, err := os.Stat(filepath.Join(workspace, "site.yaml")); err != nil {
+			t.Errorf("config not staged: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(workspace, ".masterchef")); err == nil {
+			t.Errorf("local state should not be staged")
+		}
+		_ = os.MkdirAll(filepath.Join(workspace, "out"), 0o755)
+		_ = os.WriteFile(filepath.Join(workspace, "out", "report.txt"), []byte("done"), 0o644)
+		_ = os.MkdirAll(filepath.Join(workspace, ".masterchef", "runs"), 0o755)
+		_ = os.WriteFile(filepath.Join(workspace, ".masterchef", "runs", "run-1.json"), []byte("{}"), 0o644)
+		return "applied", 0, nil
+	}
+	if err := r.ApplyJob(Job{ID: "job-1", ConfigPath: cfg, ExecutionEnv: env.ID}); err != nil {
+		t.Fatalf("apply job failed: %v", err)
+	}
+	if inner.calls != 0 {
+		t.Fatalf("expected isolated run not to use inner executor")
+	}
+	args := strings.Join(got.args, " ")
+	for _, want := range []string{"run --rm --name mc-job-1", "--cpus 1.5", "--memory 512m", "--env-file", "registry.local/masterchef-runner@" + isolatedTestDigest, "apply -f site.yaml"} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("expected %q in container args: %s", want, args)
+		}
+	}
+	if got.name != "podman" || strings.Contains(args, "prod") {
+		t.Fatalf("unexpected runtime or env value in argv: %s %s", got.name, args)
+	}
+	if !strings.Contains(envFileData, "APP_ENV=prod\n") || !strings.Contains(envFileData, "MC_EXECUTION_TOKEN=") {
+		t.Fatalf("expected injected env values in env file, got %q", envFileData)
+	}
+	for _, kv := range got.env {
+		if strings.HasPrefix(kv, "APP_ENV=") || strings.HasPrefix(kv, "MC_EXECUTION_TOKEN=") {
+			t.Fatalf("injected env leaked into the runtime client: %v", got.env)
+		}
+	}
+	if strings.HasPrefix(envFile, filepath.Join(r.baseDir, ".masterchef", "isolation", "job-1")+string(filepath.Separator)) {
+		t.Fatalf("env file must not be inside the mounted workspace: %s", envFile)
+	}
+	if _, err := os.Stat(envFile); !os.IsNotExist(err) {
+		t.Fatalf("expected env file to be removed, err=%v", err)
+	}
+
+	run, ok := r.Get("job-1")
+	if !ok || run.Status != "succeeded" || run.CredentialID == "" {
+		t.Fatalf("unexpected run record: %+v", run)
+	}
+	if cred, ok := r.creds.Get(run.CredentialID); !ok || cred.RevokedAt == nil {
+		t.Fatalf("expected credential to be revoked after run: %+v", cred)
+	}
+	if len(run.Artifacts) != 1 || run.Artifacts[0] != "out/report.txt" {
+		t.Fatalf("unexpected artifacts: %+v", run.Artifacts)
+	}
+	if _, err := os.Stat(filepath.Join(run.ArtifactDir, "out", "report.txt")); err != nil {
+		t.Fatalf("artifact not extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(r.baseDir, ".masterchef", "runs", "run-1.json")); err != nil {
+		t.Fatalf("run report not extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(r.baseDir, ".masterchef", "isolation", "job-1")); !os.IsNotExist(err) {
+		t.Fatalf("expected workspace to be removed, err=%v", err)
+	}
+}
+
+func TestIsolatedRunnerChrootRunWithLimits(t *testing.T) {
+	root := t.TempDir()
+	r, _, env, cfg := newIsolatedTestRunner(t, ExecutionIsolation{
+		Mode:       "chroot",
+		ChrootPath: root,
+		Limits:     ExecutionResourceLimits{CPUs: 2, MemoryMB: 256, TimeoutSeconds: 30},
+	})
+	var got isolationCommand
+	r.command = func(ctx context.Context, cmd isolationCommand) (string, int, error) {
+		got = cmd
+		if _, err := os.Stat(filepath.Join(root, "var", "lib", "masterchef", "jobs", "job-2", "site.yaml")); err != nil {
+			t.Errorf("config not staged into chroot: %v", err)
+		}
+		return "boom\nresource failed", 2, nil
+	}
+	err := r.ApplyJob(Job{ID: "job-2", ConfigPath: cfg, ExecutionEnv: env.ID})
+	if err == nil || !strings.Contains(err.Error(), "exited with code 2: resource failed") {
+		t.Fatalf("expected exit code error, got %v", err)
+	}
+	args := strings.Join(got.args, " ")
+	if got.name != "prlimit" || !strings.Contains(args, "--as=268435456 --cpu=60 -- chroot "+root+" /bin/sh -c") {
+		t.Fatalf("unexpected chroot command: %s %s", got.name, args)
+	}
+	run, _ := r.Get("job-2")
+	if run.Status != "failed" || run.ExitCode != 2 {
+		t.Fatalf("unexpected run record: %+v", run)
+	}
+}
+
+func TestIsolatedRunnerTimeoutAndPassthrough(t *testing.T) {
+	r, inner, env, cfg := newIsolatedTestRunner(t, ExecutionIsolation{
+		Mode:   "container",
+		Image:  "registry.local/runner",
+		Limits: ExecutionResourceLimits{TimeoutSeconds: 1},
+	})
+	cleaned := false
+	r.command = func(ctx context.Context, cmd isolationCommand) (string, int, error) {
+		if cmd.args[0] == "rm" {
+			cleaned = true
+			return "", 0, nil
+		}
+		<-ctx.Done()
+		return "", -1, ctx.Err()
+	}
+	err := r.ApplyJob(Job{ID: "job-3", ConfigPath: cfg, ExecutionEnv: env.ID})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if run, _ := r.Get("job-3"); run.Status != "timed_out" || !cleaned {
+		t.Fatalf("expected timed_out run with container cleanup: %+v cleaned=%t", run, cleaned)
+	}
+
+	if err := r.ApplyJob(Job{ID: "job-4", ConfigPath: cfg}); err != nil || inner.calls != 1 {
+		t.Fatalf("expected job without environment to use inner executor: err=%v calls=%d", err, inner.calls)
+	}
+	if err := r.ApplyJob(Job{ID: "job-5", ConfigPath: cfg, ExecutionEnv: "env-missing"}); err == nil {
+		t.Fatalf("expected missing environment to fail")
+	}
+}
+
+func TestExecutionIsolationRejectsUnsafeSettings(t *testing.T) {
+	cases := []ExecutionIsolation{
+		{Mode: "container", Image: "--privileged"},
+		{Mode: "container", Image: "registry.local/runner", Env: map[string]string{"APP_ENV": "prod\nEVIL=1"}},
+		{Mode: "chroot", ChrootPath: "/"},
+		{Mode: "chroot", ChrootPath: "/srv/../"},
+	}
+	for _, iso := range cases {
+		if _, err := normalizeExecutionIsolation(iso); err == nil {
+			t.Fatalf("expected isolation %+v to be rejected", iso)
+		}
+	}
+	if _, err := isolationChrootRoot("/"); err == nil {
+		t.Fatalf("expected host root to be refused at run time")
+	}
+}
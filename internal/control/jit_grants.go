@@ -56,6 +56,33 @@ type JITAccessGrantValidationResult struct {
 	ExpiresAt time.Time `json:"expires_at,omitempty"`
 }
 
+// JITProtectedRoute is an API operation that requires an active grant for
+// Resource and Action. Path segments written as {name} match any segment.
+type JITProtectedRoute struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+}
+
+// JIT enforcement modes. Auto, the default, enforces once any grant has
+// been issued, so adopting grants cannot leave the routes they guard open.
+const (
+	JITEnforcementAuto    = "auto"
+	JITEnforcementEnforce = "enforce"
+	JITEnforcementOff     = "off"
+)
+
+// JITEnforcementPolicy selects the routes that require a grant. Enabled
+// reports whether enforcement is currently in effect; when setting a policy
+// without a Mode it picks enforce or off.
+type JITEnforcementPolicy struct {
+	Mode      string              `json:"mode"`
+	Enabled   bool                `json:"enabled"`
+	Routes    []JITProtectedRoute `json:"routes"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
 type jitGrantRecord struct {
 	grant     JITAccessGrant
 	tokenHash string
@@ -66,12 +93,30 @@ type JITAccessGrantStore struct {
 	nextID     int64
 	grants     map[string]*jitGrantRecord
 	tokenIndex map[string]string
+	policy     JITEnforcementPolicy
 }
 
 func NewJITAccessGrantStore() *JITAccessGrantStore {
 	return &JITAccessGrantStore{
 		grants:     map[string]*jitGrantRecord{},
 		tokenIndex: map[string]string{},
+		policy:     JITEnforcementPolicy{Mode: JITEnforcementAuto, Routes: DefaultJITProtectedRoutes(), UpdatedAt: time.Now().UTC()},
+	}
+}
+
+// DefaultJITProtectedRoutes covers emergency stop, secret reads, and
+// break-glass approval. Changing the enforcement policy is itself protected
+// so enforcement cannot be switched off without a grant.
+func DefaultJITProtectedRoutes() []JITProtectedRoute {
+	return []JITProtectedRoute{
+		{Method: "POST", Path: "/v1/control/emergency-stop", Resource: "control.emergency_stop", Action: "toggle"},
+		{Method: "POST", Path: "/v1/control/emergency-stop/scopes", Resource: "control.emergency_stop", Action: "toggle"},
+		{Method: "DELETE", Path: "/v1/control/emergency-stop/scopes/{id}", Resource: "control.emergency_stop", Action: "toggle"},
+		{Method: "POST", Path: "/v1/secrets/resolve", Resource: "secrets", Action: "read"},
+		{Method: "POST", Path: "/v1/secrets/encrypted-store/items/{name}/resolve", Resource: "secrets", Action: "read"},
+		{Method: "POST", Path: "/v1/secrets/runtime/consume", Resource: "secrets", Action: "read"},
+		{Method: "POST", Path: "/v1/access/break-glass/requests/{id}/approve", Resource: "access.break_glass", Action: "approve"},
+		{Method: "POST", Path: "/v1/access/jit-grants/enforcement", Resource: "access.jit_enforcement", Action: "update"},
 	}
 }
 
@@ -204,6 +249,102 @@ func (s *JITAccessGrantStore) validateAt(in JITAccessGrantValidationInput, now t
 	return resultFromJITGrant(grant, true, "")
 }
 
+func (s *JITAccessGrantStore) EnforcementPolicy() JITEnforcementPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := cloneJITEnforcementPolicy(s.policy)
+	out.Enabled = s.enforcingLocked()
+	return out
+}
+
+func (s *JITAccessGrantStore) enforcingLocked() bool {
+	switch s.policy.Mode {
+	case JITEnforcementEnforce:
+		return true
+	case JITEnforcementAuto:
+		return len(s.grants) > 0
+	default:
+		return false
+	}
+}
+
+// SetEnforcementPolicy replaces the policy. An empty route list restores
+// the default protected routes.
+func (s *JITAccessGrantStore) SetEnforcementPolicy(in JITEnforcementPolicy) (JITEnforcementPolicy, error) {
+	policy := JITEnforcementPolicy{Mode: strings.ToLower(strings.TrimSpace(in.Mode)), UpdatedAt: time.Now().UTC()}
+	switch policy.Mode {
+	case "":
+		policy.Mode = JITEnforcementOff
+		if in.Enabled {
+			policy.Mode = JITEnforcementEnforce
+		}
+	case JITEnforcementAuto, JITEnforcementEnforce, JITEnforcementOff:
+	default:
+		return JITEnforcementPolicy{}, errors.New("mode must be one of: auto, enforce, off")
+	}
+	for _, route := range in.Routes {
+		route.Method = strings.ToUpper(strings.TrimSpace(route.Method))
+		route.Path = "/" + strings.Trim(strings.TrimSpace(route.Path), "/")
+		route.Resource = strings.TrimSpace(route.Resource)
+		route.Action = strings.TrimSpace(route.Action)
+		if route.Method == "" || route.Path == "/" || route.Resource == "" || route.Action == "" {
+			return JITEnforcementPolicy{}, errors.New("each route requires method, path, resource, and action")
+		}
+		policy.Routes = append(policy.Routes, route)
+	}
+	if len(policy.Routes) == 0 {
+		policy.Routes = DefaultJITProtectedRoutes()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+	out := cloneJITEnforcementPolicy(policy)
+	out.Enabled = s.enforcingLocked()
+	return out, nil
+}
+
+// ProtectedRoute reports the route a request must hold a grant for. It
+// matches nothing while enforcement is not in effect.
+func (s *JITAccessGrantStore) ProtectedRoute(method, path string) (JITProtectedRoute, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.enforcingLocked() {
+		return JITProtectedRoute{}, false
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range s.policy.Routes {
+		if route.Method == method && jitRouteMatches(route.Path, segments) {
+			return route, true
+		}
+	}
+	return JITProtectedRoute{}, false
+}
+
+func jitRouteMatches(pattern string, segments []string) bool {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(parts) != len(segments) {
+		return false
+	}
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneJITEnforcementPolicy(in JITEnforcementPolicy) JITEnforcementPolicy {
+	out := in
+	out.Routes = append([]JITProtectedRoute{}, in.Routes...)
+	return out
+}
+
 func (s *JITAccessGrantStore) expireLocked(now time.Time) {
 	for _, item := range s.grants {
 		if item.grant.RevokedAt != nil {
@@ -80,3 +80,42 @@ func TestJITAccessGrantIssueValidation(t *testing.T) {
 		t.Fatalf("expected low ttl validation failure")
 	}
 }
+
+func TestJITEnforcementProtectedRoutes(t *testing.T) {
+	store := NewJITAccessGrantStore()
+	if _, ok := store.ProtectedRoute("POST", "/v1/control/emergency-stop"); ok {
+		t.Fatalf("expected no protected routes before any grant is issued")
+	}
+	if _, err := store.Issue(JITAccessGrantIssueInput{Subject: "oncall", Resource: "secrets", Action: "read", IssuedBy: "ic", Reason: "incident"}); err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	if _, ok := store.ProtectedRoute("POST", "/v1/control/emergency-stop"); !ok || !store.EnforcementPolicy().Enabled {
+		t.Fatalf("expected auto mode to enforce once a grant exists")
+	}
+	if _, err := store.SetEnforcementPolicy(JITEnforcementPolicy{Mode: "sometimes"}); err == nil {
+		t.Fatalf("expected invalid mode to fail")
+	}
+	if policy, err := store.SetEnforcementPolicy(JITEnforcementPolicy{Enabled: false}); err != nil || policy.Mode != JITEnforcementOff || policy.Enabled {
+		t.Fatalf("expected explicit disable to switch enforcement off: %+v err=%v", policy, err)
+	}
+	if _, ok := store.ProtectedRoute("POST", "/v1/control/emergency-stop"); ok {
+		t.Fatalf("expected no protected routes while enforcement is off")
+	}
+	if _, err := store.SetEnforcementPolicy(JITEnforcementPolicy{Enabled: true, Routes: []JITProtectedRoute{{Method: "post", Path: "/v1/x"}}}); err == nil {
+		t.Fatalf("expected route without resource and action to fail")
+	}
+	policy, err := store.SetEnforcementPolicy(JITEnforcementPolicy{Enabled: true})
+	if err != nil || len(policy.Routes) != len(DefaultJITProtectedRoutes()) {
+		t.Fatalf("expected default routes: %+v err=%v", policy, err)
+	}
+	route, ok := store.ProtectedRoute("POST", "/v1/access/break-glass/requests/bg-1/approve")
+	if !ok || route.Resource != "access.break_glass" || route.Action != "approve" {
+		t.Fatalf("expected break-glass approval to be protected: %+v %t", route, ok)
+	}
+	if _, ok := store.ProtectedRoute("POST", "/v1/access/break-glass/requests/bg-1/reject"); ok {
+		t.Fatalf("expected break-glass reject to be unprotected")
+	}
+	if _, ok := store.ProtectedRoute("GET", "/v1/control/emergency-stop"); ok {
+		t.Fatalf("expected emergency stop status reads to be unprotected")
+	}
+}
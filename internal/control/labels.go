@@ -0,0 +1,144 @@
+package control
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9._/-]{0,61}[a-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+// NormalizeLabels trims and validates an entity's labels. Keys are
+// lowercase and may contain '.', '_', '/' and '-'; values are at most 63
+// characters. Empty input yields nil.
+func NormalizeLabels(in map[string]string) (map[string]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		if !labelKeyPattern.MatchString(k) {
+			return nil, errors.New("invalid label key " + `"` + k + `"`)
+		}
+		if !labelValuePattern.MatchString(v) {
+			return nil, errors.New("invalid value for label " + k)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// LabelRequirement is one clause of a label selector.
+type LabelRequirement struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"` // eq|ne|exists|not_exists
+	Value    string `json:"value,omitempty"`
+}
+
+// LabelSelector matches entities whose labels satisfy every requirement.
+type LabelSelector []LabelRequirement
+
+// ParseLabelSelector parses a comma-separated selector such as
+// "team=payments,tier!=db,canary,!legacy". An empty selector matches
+// everything.
+func ParseLabelSelector(raw string) (LabelSelector, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	out := LabelSelector{}
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, errors.New("empty label selector term")
+		}
+		req := LabelRequirement{}
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			req = LabelRequirement{Key: parts[0], Operator: "ne", Value: parts[1]}
+		case strings.Contains(term, "=="):
+			parts := strings.SplitN(term, "==", 2)
+			req = LabelRequirement{Key: parts[0], Operator: "eq", Value: parts[1]}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			req = LabelRequirement{Key: parts[0], Operator: "eq", Value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			req = LabelRequirement{Key: term[1:], Operator: "not_exists"}
+		default:
+			req = LabelRequirement{Key: term, Operator: "exists"}
+		}
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if !labelKeyPattern.MatchString(req.Key) {
+			return nil, errors.New("invalid label key " + `"` + req.Key + `"` + " in selector")
+		}
+		if !labelValuePattern.MatchString(req.Value) {
+			return nil, errors.New("invalid label value for " + req.Key + " in selector")
+		}
+		out = append(out, req)
+	}
+	return out, nil
+}
+
+// Matches reports whether labels satisfy the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		v, ok := labels[req.Key]
+		switch req.Operator {
+		case "eq":
+			if !ok || v != req.Value {
+				return false
+			}
+		case "ne":
+			if ok && v == req.Value {
+				return false
+			}
+		case "exists":
+			if !ok {
+				return false
+			}
+		case "not_exists":
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String renders the selector in its canonical, sorted form.
+func (s LabelSelector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, req := range s {
+		switch req.Operator {
+		case "eq":
+			terms = append(terms, req.Key+"="+req.Value)
+		case "ne":
+			terms = append(terms, req.Key+"!="+req.Value)
+		case "exists":
+			terms = append(terms, req.Key)
+		case "not_exists":
+			terms = append(terms, "!"+req.Key)
+		}
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
+
+func cloneLabels(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package control
+
+import "testing"
+
+func TestLabelSelectors(t *testing.T) {
+	labels, err := NormalizeLabels(map[string]string{" team ": "payments", "tier": "web", "app.kubernetes.io/name": "checkout"})
+	if err != nil || labels["team"] != "payments" || len(labels) != 3 {
+		t.Fatalf("unexpected labels %+v err=%v", labels, err)
+	}
+	for _, bad := range []map[string]string{{"Team": "x"}, {"team": "has space"}, {"": "x"}} {
+		if _, err := NormalizeLabels(bad); err == nil {
+			t.Fatalf("expected %v to be rejected", bad)
+		}
+	}
+
+	cases := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"team=payments", true},
+		{"team==payments,tier=web", true},
+		{"team=search", false},
+		{"tier!=db", true},
+		{"tier!=web", false},
+		{"app.kubernetes.io/name", true},
+		{"canary", false},
+		{"!canary", true},
+		{"!team", false},
+	}
+	for _, tc := range cases {
+		selector, err := ParseLabelSelector(tc.selector)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.selector, err)
+		}
+		if got := selector.Matches(labels); got != tc.want {
+			t.Fatalf("selector %q matched=%v want %v", tc.selector, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"team=payments,", "Team=x", "team=a b"} {
+		if _, err := ParseLabelSelector(bad); err == nil {
+			t.Fatalf("expected selector %q to be rejected", bad)
+		}
+	}
+	selector, _ := ParseLabelSelector("tier!=db, team=payments,!legacy")
+	if selector.String() != "!legacy,team=payments,tier!=db" {
+		t.Fatalf("unexpected canonical selector %q", selector.String())
+	}
+}
+
+func TestRBACLabelSelectorScopesAccess(t *testing.T) {
+	store := NewRBACStore()
+	if _, err := store.CreateRole(RBACRoleInput{Name: "bad", Permissions: []RBACPermission{{Resource: "jobs", Action: "read", LabelSelector: "Team=x"}}}); err == nil {
+		t.Fatalf("expected invalid label selector to be rejected")
+	}
+	role, err := store.CreateRole(RBACRoleInput{Name: "payments-reader", Permissions: []RBACPermission{{Resource: "*", Action: "read", LabelSelector: "team=payments"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.HasBindings("alice") {
+		t.Fatalf("expected no bindings yet")
+	}
+	if _, err := store.CreateBinding(RBACBindingInput{Subject: "alice", RoleID: role.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if !store.HasBindings("alice") {
+		t.Fatalf("expected alice to have bindings")
+	}
+	check := func(labels map[string]string) bool {
+		return store.CheckAccess(RBACAccessCheckInput{Subject: "alice", Resource: "jobs", Action: "read", Labels: labels}).Allowed
+	}
+	if !check(map[string]string{"team": "payments"}) || check(map[string]string{"team": "search"}) || check(nil) {
+		t.Fatalf("label-scoped permission not applied")
+	}
+}
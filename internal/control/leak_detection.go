@@ -13,25 +13,31 @@ type LeakDetectionPolicy struct {
 	MemoryGrowthPercent      float64   `json:"memory_growth_percent"`
 	GoroutineGrowthPercent   float64   `json:"goroutine_growth_percent"`
 	FileDescriptorGrowthPerc float64   `json:"file_descriptor_growth_percent"`
+	StoreGrowthPercent       float64   `json:"store_growth_percent"`
+	MinStoreEntries          int       `json:"min_store_entries"`
+	CaptureIntervalSeconds   int       `json:"capture_interval_seconds"`
 	UpdatedAt                time.Time `json:"updated_at"`
 }
 
 type ResourceSnapshot struct {
-	Component  string `json:"component"`
-	MemoryMB   int    `json:"memory_mb"`
-	Goroutines int    `json:"goroutines"`
-	OpenFDs    int    `json:"open_fds"`
+	Component  string         `json:"component"`
+	MemoryMB   int            `json:"memory_mb"`
+	Goroutines int            `json:"goroutines"`
+	OpenFDs    int            `json:"open_fds"`
+	StoreSizes map[string]int `json:"store_sizes,omitempty"`
 }
 
 type LeakReport struct {
-	Component              string    `json:"component"`
-	Samples                int       `json:"samples"`
-	MemoryGrowthPercent    float64   `json:"memory_growth_percent"`
-	GoroutineGrowthPercent float64   `json:"goroutine_growth_percent"`
-	FDGrowthPercent        float64   `json:"fd_growth_percent"`
-	LeakDetected           bool      `json:"leak_detected"`
-	Reasons                []string  `json:"reasons,omitempty"`
-	LastSeenAt             time.Time `json:"last_seen_at"`
+	Component              string             `json:"component"`
+	Samples                int                `json:"samples"`
+	MemoryGrowthPercent    float64            `json:"memory_growth_percent"`
+	GoroutineGrowthPercent float64            `json:"goroutine_growth_percent"`
+	FDGrowthPercent        float64            `json:"fd_growth_percent"`
+	StoreGrowthPercent     map[string]float64 `json:"store_growth_percent,omitempty"`
+	OffendingStore         string             `json:"offending_store,omitempty"`
+	LeakDetected           bool               `json:"leak_detected"`
+	Reasons                []string           `json:"reasons,omitempty"`
+	LastSeenAt             time.Time          `json:"last_seen_at"`
 }
 
 type snapshotRecord struct {
@@ -52,6 +58,9 @@ func NewLeakDetectionStore() *LeakDetectionStore {
 			MemoryGrowthPercent:      20,
 			GoroutineGrowthPercent:   25,
 			FileDescriptorGrowthPerc: 30,
+			StoreGrowthPercent:       50,
+			MinStoreEntries:          100,
+			CaptureIntervalSeconds:   60,
 			UpdatedAt:                time.Now().UTC(),
 		},
 		samples: map[string][]snapshotRecord{},
@@ -68,9 +77,24 @@ func (s *LeakDetectionStore) SetPolicy(in LeakDetectionPolicy) (LeakDetectionPol
 	if in.MinSamples <= 1 {
 		in.MinSamples = 4
 	}
-	if in.MemoryGrowthPercent < 0 || in.GoroutineGrowthPercent < 0 || in.FileDescriptorGrowthPerc < 0 {
+	if in.MemoryGrowthPercent < 0 || in.GoroutineGrowthPercent < 0 || in.FileDescriptorGrowthPerc < 0 || in.StoreGrowthPercent < 0 {
 		return LeakDetectionPolicy{}, errors.New("growth thresholds must be non-negative")
 	}
+	if in.StoreGrowthPercent == 0 {
+		in.StoreGrowthPercent = 50
+	}
+	if in.MinStoreEntries < 0 {
+		return LeakDetectionPolicy{}, errors.New("min_store_entries must be non-negative")
+	}
+	if in.MinStoreEntries == 0 {
+		in.MinStoreEntries = 100
+	}
+	if in.CaptureIntervalSeconds < 0 {
+		return LeakDetectionPolicy{}, errors.New("capture_interval_seconds must be non-negative")
+	}
+	if in.CaptureIntervalSeconds == 0 {
+		in.CaptureIntervalSeconds = 60
+	}
 	in.UpdatedAt = time.Now().UTC()
 	s.mu.Lock()
 	s.policy = in
@@ -86,6 +110,19 @@ func (s *LeakDetectionStore) Observe(in ResourceSnapshot) (LeakReport, error) {
 	if in.MemoryMB < 0 || in.Goroutines < 0 || in.OpenFDs < 0 {
 		return LeakReport{}, errors.New("resource metrics must be non-negative")
 	}
+	sizes := map[string]int{}
+	for name, size := range in.StoreSizes {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if size < 0 {
+			return LeakReport{}, errors.New("store sizes must be non-negative")
+		}
+		sizes[name] = size
+	}
+	in.Component = component
+	in.StoreSizes = sizes
 	now := time.Now().UTC()
 	s.mu.Lock()
 	records := append(s.samples[component], snapshotRecord{ResourceSnapshot: in, ObservedAt: now})
@@ -134,12 +171,29 @@ func evaluateLeakReport(component string, records []snapshotRecord, policy LeakD
 	if len(records) == 0 {
 		return report
 	}
-	first := records[0]
 	last := records[len(records)-1]
 	report.LastSeenAt = last.ObservedAt
-	report.MemoryGrowthPercent = growthPercent(first.MemoryMB, last.MemoryMB)
-	report.GoroutineGrowthPercent = growthPercent(first.Goroutines, last.Goroutines)
-	report.FDGrowthPercent = growthPercent(first.OpenFDs, last.OpenFDs)
+	memory := make([]int, len(records))
+	goroutines := make([]int, len(records))
+	fds := make([]int, len(records))
+	stores := map[string][]int{}
+	for i, record := range records {
+		memory[i] = record.MemoryMB
+		goroutines[i] = record.Goroutines
+		fds[i] = record.OpenFDs
+		for name, size := range record.StoreSizes {
+			stores[name] = append(stores[name], size)
+		}
+	}
+	report.MemoryGrowthPercent = trendGrowthPercent(memory)
+	report.GoroutineGrowthPercent = trendGrowthPercent(goroutines)
+	report.FDGrowthPercent = trendGrowthPercent(fds)
+	if len(stores) > 0 {
+		report.StoreGrowthPercent = map[string]float64{}
+		for name, sizes := range stores {
+			report.StoreGrowthPercent[name] = trendGrowthPercent(sizes)
+		}
+	}
 	if len(records) < policy.MinSamples {
 		return report
 	}
@@ -153,11 +207,55 @@ func evaluateLeakReport(component string, records []snapshotRecord, policy LeakD
 	if report.FDGrowthPercent >= policy.FileDescriptorGrowthPerc {
 		reasons = append(reasons, "file descriptor growth exceeded threshold")
 	}
+	// The offending store is the fastest growing one that is large enough
+	// to matter and was sampled across the minimum window.
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sizes := stores[name]
+		growth := report.StoreGrowthPercent[name]
+		if len(sizes) < policy.MinSamples || sizes[len(sizes)-1] < policy.MinStoreEntries || growth < policy.StoreGrowthPercent {
+			continue
+		}
+		reasons = append(reasons, "store "+name+" growth exceeded threshold")
+		if report.OffendingStore == "" || growth > report.StoreGrowthPercent[report.OffendingStore] {
+			report.OffendingStore = name
+		}
+	}
 	report.LeakDetected = len(reasons) > 0
 	report.Reasons = reasons
 	return report
 }
 
+// trendGrowthPercent fits a least-squares line through the samples and
+// returns the growth of the fitted line across the window, so a single
+// spike or a post-GC dip does not dominate the result.
+func trendGrowthPercent(values []int) float64 {
+	n := len(values)
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		x, y := float64(i), float64(v)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope := (float64(n)*sumXY - sumX*sumY) / (float64(n)*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / float64(n)
+	start := intercept
+	end := intercept + slope*float64(n-1)
+	if start <= 0 {
+		return growthPercent(values[0], values[n-1])
+	}
+	return (end - start) / start * 100
+}
+
 func growthPercent(first, last int) float64 {
 	if first <= 0 {
 		if last <= 0 {
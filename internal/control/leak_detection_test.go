@@ -55,3 +55,79 @@ func TestLeakDetectionNoLeakWithStableMetrics(t *testing.T) {
 		t.Fatalf("expected no leak detected for stable metrics, got %+v", reports)
 	}
 }
+
+func TestLeakDetectionIdentifiesOffendingStore(t *testing.T) {
+	store := NewLeakDetectionStore()
+	if _, err := store.SetPolicy(LeakDetectionPolicy{
+		MinSamples:               3,
+		MemoryGrowthPercent:      50,
+		GoroutineGrowthPercent:   50,
+		FileDescriptorGrowthPerc: 50,
+		StoreGrowthPercent:       25,
+		MinStoreEntries:          10,
+	}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	var report LeakReport
+	for i, jobs := range []int{100, 140, 190, 230} {
+		var err error
+		report, err = store.Observe(ResourceSnapshot{
+			Component:  "control-plane",
+			MemoryMB:   50 + i,
+			Goroutines: 40,
+			StoreSizes: map[string]int{"queue_jobs": jobs, "events": 500, "tiny": 1 + i},
+		})
+		if err != nil {
+			t.Fatalf("observe failed: %v", err)
+		}
+	}
+	if !report.LeakDetected || report.OffendingStore != "queue_jobs" {
+		t.Fatalf("expected queue_jobs to be the offending store, got %+v", report)
+	}
+	if report.StoreGrowthPercent["events"] != 0 {
+		t.Fatalf("expected flat events store, got %+v", report.StoreGrowthPercent)
+	}
+}
+
+func TestTrendGrowthIgnoresSingleSpike(t *testing.T) {
+	// A spike in the middle of an otherwise flat series is not sustained
+	// growth, unlike the first-versus-last comparison.
+	if growth := trendGrowthPercent([]int{100, 100, 400, 100, 100}); growth > 1 || growth < -1 {
+		t.Fatalf("expected flat trend, got %.2f", growth)
+	}
+	if growth := trendGrowthPercent([]int{100, 110, 120, 130}); growth < 29 || growth > 31 {
+		t.Fatalf("expected 30%% trend growth, got %.2f", growth)
+	}
+}
+
+func TestLeakSamplerReportsTransitions(t *testing.T) {
+	store := NewLeakDetectionStore()
+	if _, err := store.SetPolicy(LeakDetectionPolicy{
+		MinSamples:               2,
+		MemoryGrowthPercent:      1e6,
+		GoroutineGrowthPercent:   1e6,
+		FileDescriptorGrowthPerc: 1e6,
+		StoreGrowthPercent:       10,
+		MinStoreEntries:          1,
+	}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	size := 10
+	var transitions []bool
+	sampler := NewLeakSampler(store, "control-plane", func() map[string]int {
+		return map[string]int{"events": size}
+	}, func(report LeakReport, transitioned bool) {
+		if transitioned {
+			transitions = append(transitions, report.LeakDetected)
+		}
+	})
+	for _, next := range []int{10, 20, 30} {
+		size = next
+		if _, err := sampler.CaptureNow(); err != nil {
+			t.Fatalf("capture failed: %v", err)
+		}
+	}
+	if len(transitions) != 1 || !transitions[0] {
+		t.Fatalf("expected a single transition into leaking, got %v", transitions)
+	}
+}
@@ -0,0 +1,112 @@
+package control
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LeakSampler periodically records a runtime snapshot of this process in a
+// LeakDetectionStore. onReport is called after every sample; transitioned
+// is true when the component starts or stops being reported as leaking.
+type LeakSampler struct {
+	mu        sync.Mutex
+	store     *LeakDetectionStore
+	component string
+	sizes     func() map[string]int
+	onReport  func(report LeakReport, transitioned bool)
+	leaking   bool
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewLeakSampler(store *LeakDetectionStore, component string, sizes func() map[string]int, onReport func(LeakReport, bool)) *LeakSampler {
+	return &LeakSampler{
+		store:     store,
+		component: component,
+		sizes:     sizes,
+		onReport:  onReport,
+	}
+}
+
+// Start begins sampling on the policy's capture interval. The interval is
+// re-read after every sample so policy changes apply without a restart.
+func (l *LeakSampler) Start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.done = make(chan struct{})
+	go l.loop(ctx, l.done)
+}
+
+func (l *LeakSampler) Shutdown() {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.cancel, l.done = nil, nil
+	l.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (l *LeakSampler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		interval := time.Duration(l.store.Policy().CaptureIntervalSeconds) * time.Second
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		_, _ = l.CaptureNow()
+	}
+}
+
+// CaptureNow records one snapshot immediately and evaluates it.
+func (l *LeakSampler) CaptureNow() (LeakReport, error) {
+	var sizes map[string]int
+	if l.sizes != nil {
+		sizes = l.sizes()
+	}
+	report, err := l.store.Observe(RuntimeSnapshot(l.component, sizes))
+	if err != nil {
+		return LeakReport{}, err
+	}
+	l.mu.Lock()
+	transitioned := report.LeakDetected != l.leaking
+	l.leaking = report.LeakDetected
+	onReport := l.onReport
+	l.mu.Unlock()
+	if onReport != nil {
+		onReport(report, transitioned)
+	}
+	return report, nil
+}
+
+// RuntimeSnapshot captures heap in use, goroutine count, and open file
+// descriptors for the current process. Open descriptors are read from
+// /proc and reported as zero where it is unavailable.
+func RuntimeSnapshot(component string, storeSizes map[string]int) ResourceSnapshot {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fds := 0
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		fds = len(entries)
+	}
+	return ResourceSnapshot{
+		Component:  component,
+		MemoryMB:   int(mem.HeapAlloc >> 20),
+		Goroutines: runtime.NumGoroutine(),
+		OpenFDs:    fds,
+		StoreSizes: storeSizes,
+	}
+}
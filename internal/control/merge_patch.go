@@ -0,0 +1,42 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ApplyMergePatch applies an RFC 7386 JSON merge patch to doc: object members
+// in the patch replace those in doc, null members remove them, and any other
+// patch value replaces doc wholesale.
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target any
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil {
+			return nil, errors.New("merge patch target must be valid json")
+		}
+	}
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, errors.New("merge patch must be valid json")
+	}
+	return json.Marshal(mergePatchValue(target, p))
+}
+
+func mergePatchValue(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatchValue(t[k], v)
+	}
+	return t
+}
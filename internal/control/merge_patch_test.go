@@ -0,0 +1,65 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	cases := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":1}}`, `{"a":{"b":"c","f":1}}`},
+		{`{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{``, `{"a":{"b":null}}`, `{"a":{}}`},
+	}
+	for _, tc := range cases {
+		got, err := ApplyMergePatch([]byte(tc.doc), []byte(tc.patch))
+		if err != nil || string(got) != tc.want {
+			t.Fatalf("ApplyMergePatch(%s, %s) = %s, %v; want %s", tc.doc, tc.patch, got, err, tc.want)
+		}
+	}
+	if _, err := ApplyMergePatch([]byte(`{}`), []byte(`{`)); err == nil {
+		t.Fatalf("expected invalid patch to fail")
+	}
+}
+
+func TestPartialUpdatesKeepCounters(t *testing.T) {
+	webhooks := NewWebhookDispatcher(10)
+	wh, err := webhooks.Register(WebhookSubscription{Name: "ops", URL: "https://example.test/hook", EventPrefix: "job."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks.webhooks[wh.ID].SuccessCount = 4
+	updated, err := webhooks.Update(wh.ID, WebhookSubscription{Name: "ops", URL: "https://example.test/v2", EventPrefix: "job."})
+	if err != nil || updated.URL != "https://example.test/v2" || updated.SuccessCount != 4 || updated.Enabled {
+		t.Fatalf("unexpected webhook update %+v err=%v", updated, err)
+	}
+	if _, err := webhooks.Update(wh.ID, WebhookSubscription{Name: "ops", URL: "ftp://x", EventPrefix: "job."}); err == nil {
+		t.Fatalf("expected webhook update to be validated")
+	}
+
+	router := NewNotificationRouter(10)
+	target, err := router.Register(NotificationTarget{Name: "pager", Kind: "incident", URL: "https://example.test/p", Route: "pager"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target, err = router.Update(target.ID, NotificationTarget{Name: "pager", Kind: "incident", URL: "https://example.test/p", Route: "ticket", Enabled: true}); err != nil || target.Route != "ticket" {
+		t.Fatalf("unexpected notification update %+v err=%v", target, err)
+	}
+
+	sched := NewScheduler(NewQueue(10))
+	defer sched.Shutdown()
+	sc := sched.CreateWithOptions(ScheduleOptions{ConfigPath: "c.yaml", Interval: time.Hour})
+	got, err := sched.Update(sc.ID, ScheduleOptions{ConfigPath: "c.yaml", Interval: 2 * time.Hour, Priority: "high"}, false)
+	if err != nil || got.Interval != 2*time.Hour || got.Priority != "high" || got.Enabled || !got.CreatedAt.Equal(sc.CreatedAt) {
+		t.Fatalf("unexpected schedule update %+v err=%v", got, err)
+	}
+	if _, err := sched.Update("sched-missing", ScheduleOptions{ConfigPath: "c.yaml", Interval: time.Hour}, true); err == nil {
+		t.Fatalf("expected missing schedule update to fail")
+	}
+}
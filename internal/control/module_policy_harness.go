@@ -122,6 +122,21 @@ func (s *ModulePolicyHarnessStore) ListCases() []ModulePolicyHarnessCase {
 }
 
 func (s *ModulePolicyHarnessStore) Run(in ModulePolicyHarnessRunInput) (ModulePolicyHarnessRun, error) {
+	run, err := s.Evaluate(in)
+	if err != nil {
+		return ModulePolicyHarnessRun{}, err
+	}
+	s.mu.Lock()
+	s.nextID++
+	run.ID = "harness-run-" + itoa(s.nextID)
+	s.runs[run.ID] = &run
+	s.mu.Unlock()
+	return run, nil
+}
+
+// Evaluate checks observed values against a case without recording a run,
+// for callers such as the mutation executor that evaluate many candidates.
+func (s *ModulePolicyHarnessStore) Evaluate(in ModulePolicyHarnessRunInput) (ModulePolicyHarnessRun, error) {
 	caseID := strings.ToLower(strings.TrimSpace(in.CaseID))
 	if caseID == "" {
 		return ModulePolicyHarnessRun{}, errors.New("case_id is required")
@@ -163,7 +178,7 @@ func (s *ModulePolicyHarnessStore) Run(in ModulePolicyHarnessRunInput) (ModulePo
 	if failed > 0 {
 		status = "failed"
 	}
-	run := ModulePolicyHarnessRun{
+	return ModulePolicyHarnessRun{
 		CaseID:    caseID,
 		Kind:      item.Kind,
 		Status:    status,
@@ -171,13 +186,7 @@ func (s *ModulePolicyHarnessStore) Run(in ModulePolicyHarnessRunInput) (ModulePo
 		Failed:    failed,
 		Checks:    checks,
 		CreatedAt: time.Now().UTC(),
-	}
-	s.mu.Lock()
-	s.nextID++
-	run.ID = "harness-run-" + itoa(s.nextID)
-	s.runs[run.ID] = &run
-	s.mu.Unlock()
-	return run, nil
+	}, nil
 }
 
 func (s *ModulePolicyHarnessStore) ListRuns(limit int) []ModulePolicyHarnessRun {
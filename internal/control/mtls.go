@@ -34,6 +34,9 @@ type MTLSHandshakeCheckInput struct {
 	AuthorityID string `json:"authority_id"`
 	TLSVersion  string `json:"tls_version"`
 	ClientCert  bool   `json:"client_cert"`
+	// ClientCertSerial identifies the presented certificate so revoked
+	// agent certificates are refused even while still within validity.
+	ClientCertSerial string `json:"client_cert_serial,omitempty"`
 }
 
 type MTLSHandshakeCheckResult struct {
@@ -46,6 +49,7 @@ type MTLSStore struct {
 	nextAuthID  int64
 	authorities map[string]*MTLSAuthority
 	policies    map[string]*MTLSComponentPolicy
+	revocation  CertificateRevocationChecker
 }
 
 func NewMTLSStore() *MTLSStore {
@@ -55,6 +59,14 @@ func NewMTLSStore() *MTLSStore {
 	}
 }
 
+// SetRevocationChecker makes handshake checks refuse revoked client
+// certificates.
+func (s *MTLSStore) SetRevocationChecker(checker CertificateRevocationChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revocation = checker
+}
+
 func (s *MTLSStore) CreateAuthority(in MTLSAuthorityInput) (MTLSAuthority, error) {
 	name := strings.TrimSpace(in.Name)
 	ca := strings.TrimSpace(in.CABundle)
@@ -152,6 +164,9 @@ func (s *MTLSStore) CheckHandshake(in MTLSHandshakeCheckInput) MTLSHandshakeChec
 	if policy.RequireClientCert && !in.ClientCert {
 		return MTLSHandshakeCheckResult{Allowed: false, Reason: "client certificate required by policy"}
 	}
+	if serial := strings.TrimSpace(in.ClientCertSerial); serial != "" && s.revocation != nil && s.revocation.IsRevoked(serial) {
+		return MTLSHandshakeCheckResult{Allowed: false, Reason: "client certificate revoked"}
+	}
 	if len(policy.AllowedAuthorities) > 0 {
 		matched := false
 		for _, id := range policy.AllowedAuthorities {
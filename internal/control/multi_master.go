@@ -46,12 +46,30 @@ type MultiMasterStore struct {
 	mu      sync.RWMutex
 	nodes   map[string]*MultiMasterNode
 	entries map[string]*MultiMasterCacheEntry
+
+	// Replication state; see multi_master_replication.go.
+	localNode         string
+	seq               int64
+	replicated        map[string]*ReplicatedEntity
+	policies          map[string]string
+	peers             map[string]ReplicationPeerStatus
+	outbox            []queuedInvalidation
+	conflicts         []ReplicationConflict
+	conflictsTotal    int64
+	conflictsByStore  map[string]int64
+	invalidationsSent int64
+	invalidationsRecv int64
+	cacheEvictions    int64
 }
 
 func NewMultiMasterStore() *MultiMasterStore {
 	return &MultiMasterStore{
-		nodes:   map[string]*MultiMasterNode{},
-		entries: map[string]*MultiMasterCacheEntry{},
+		nodes:            map[string]*MultiMasterNode{},
+		entries:          map[string]*MultiMasterCacheEntry{},
+		replicated:       map[string]*ReplicatedEntity{},
+		policies:         map[string]string{},
+		peers:            map[string]ReplicationPeerStatus{},
+		conflictsByStore: map[string]int64{},
 	}
 }
 
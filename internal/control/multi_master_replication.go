@@ -0,0 +1,540 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VectorClock counts the writes each master has contributed to an entity.
+type VectorClock map[string]int64
+
+// Compare reports whether c happened "before", "after", is "equal" to, or is
+// "concurrent" with other.
+func (c VectorClock) Compare(other VectorClock) string {
+	less, greater := false, false
+	for node, n := range c {
+		if n > other[node] {
+			greater = true
+		} else if n < other[node] {
+			less = true
+		}
+	}
+	for node, n := range other {
+		if _, ok := c[node]; !ok && n > 0 {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return "concurrent"
+	case less:
+		return "before"
+	case greater:
+		return "after"
+	default:
+		return "equal"
+	}
+}
+
+func mergeVectorClocks(a, b VectorClock) VectorClock {
+	out := VectorClock{}
+	for node, n := range a {
+		out[node] = n
+	}
+	for node, n := range b {
+		if n > out[node] {
+			out[node] = n
+		}
+	}
+	return out
+}
+
+// ReplicatedEntity is the replicated version of one entity of a store.
+type ReplicatedEntity struct {
+	Store     string         `json:"store"`
+	Key       string         `json:"key"`
+	Clock     VectorClock    `json:"clock"`
+	Timestamp time.Time      `json:"timestamp"`
+	Origin    string         `json:"origin"`
+	Deleted   bool           `json:"deleted,omitempty"`
+	Payload   map[string]any `json:"payload,omitempty"`
+	seq       int64
+}
+
+type ReplicatedWriteInput struct {
+	Store   string         `json:"store"`
+	Key     string         `json:"key"`
+	Payload map[string]any `json:"payload,omitempty"`
+	Deleted bool           `json:"deleted,omitempty"`
+}
+
+// CacheInvalidation tells masters to drop central cache entries for an
+// entity (or a whole kind when RefID is empty).
+type CacheInvalidation struct {
+	Kind   string    `json:"kind"`
+	RefID  string    `json:"ref_id,omitempty"`
+	Origin string    `json:"origin"`
+	At     time.Time `json:"at"`
+}
+
+// ReplicationMessage is one gossip exchange: the entity versions and cache
+// invalidations the sender has seen after Since, up to Seq.
+type ReplicationMessage struct {
+	From          string              `json:"from"`
+	Since         int64               `json:"since"`
+	Seq           int64               `json:"seq"`
+	Updates       []ReplicatedEntity  `json:"updates"`
+	Invalidations []CacheInvalidation `json:"invalidations,omitempty"`
+}
+
+type ReplicationApplyResult struct {
+	From        string `json:"from"`
+	Applied     int    `json:"applied"`
+	Stale       int    `json:"stale"`
+	Conflicts   int    `json:"conflicts"`
+	Invalidated int    `json:"invalidated"`
+}
+
+// ReplicationConflict records a concurrent update and how the store's
+// conflict policy resolved it.
+type ReplicationConflict struct {
+	Store      string    `json:"store"`
+	Key        string    `json:"key"`
+	Policy     string    `json:"policy"`
+	Local      string    `json:"local_origin"`
+	Remote     string    `json:"remote_origin"`
+	Winner     string    `json:"winner"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// ReplicationPeerStatus tracks gossip with one peer: AckedSeq is how far the
+// peer has received our updates, ReceivedSeq how far we have received its.
+type ReplicationPeerStatus struct {
+	NodeID       string    `json:"node_id"`
+	AckedSeq     int64     `json:"acked_seq"`
+	ReceivedSeq  int64     `json:"received_seq"`
+	Lag          int64     `json:"lag"`
+	LastGossipAt time.Time `json:"last_gossip_at,omitempty"`
+}
+
+type ReplicationStatus struct {
+	LocalNode         string                  `json:"local_node"`
+	Seq               int64                   `json:"seq"`
+	Entities          int                     `json:"entities"`
+	Policies          map[string]string       `json:"policies"`
+	Peers             []ReplicationPeerStatus `json:"peers"`
+	ConflictsTotal    int64                   `json:"conflicts_total"`
+	ConflictsByStore  map[string]int64        `json:"conflicts_by_store"`
+	InvalidationsSent int64                   `json:"invalidations_sent"`
+	InvalidationsRecv int64                   `json:"invalidations_received"`
+	CacheEvictions    int64                   `json:"cache_evictions"`
+}
+
+// ReplicationReconcileReport compares this master's digest with a peer's.
+type ReplicationReconcileReport struct {
+	Peer              string    `json:"peer,omitempty"`
+	GeneratedAt       time.Time `json:"generated_at"`
+	InSync            int       `json:"in_sync"`
+	LocalOnly         []string  `json:"local_only"`
+	RemoteOnly        []string  `json:"remote_only"`
+	Diverged          []string  `json:"diverged"`
+	DivergencePercent float64   `json:"divergence_percent"`
+}
+
+// SetLocalNode names this master in vector clocks and gossip.
+func (s *MultiMasterStore) SetLocalNode(nodeID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.localNode = strings.TrimSpace(nodeID)
+}
+
+func (s *MultiMasterStore) LocalNode() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.localNode
+}
+
+// SetConflictPolicy selects how concurrent updates to store's entities are
+// resolved: "lww" (last writer wins by timestamp, then origin) or
+// "vector_clock" (causal order; concurrent writes are merged field by field
+// with the later write winning each field).
+func (s *MultiMasterStore) SetConflictPolicy(store, policy string) error {
+	store = strings.ToLower(strings.TrimSpace(store))
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if store == "" {
+		return errors.New("store is required")
+	}
+	if policy != "lww" && policy != "vector_clock" {
+		return errors.New("policy must be lww or vector_clock")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[store] = policy
+	return nil
+}
+
+// LocalWrite versions a write made on this master, queues it for gossip, and
+// invalidates the entity's central cache entries.
+func (s *MultiMasterStore) LocalWrite(in ReplicatedWriteInput) (ReplicatedEntity, error) {
+	store := strings.ToLower(strings.TrimSpace(in.Store))
+	key := strings.TrimSpace(in.Key)
+	if store == "" || key == "" {
+		return ReplicatedEntity{}, errors.New("store and key are required")
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.localNode == "" {
+		return ReplicatedEntity{}, errors.New("local master node id is not set")
+	}
+	item, ok := s.replicated[store+"/"+key]
+	if !ok {
+		item = &ReplicatedEntity{Store: store, Key: key, Clock: VectorClock{}}
+		s.replicated[store+"/"+key] = item
+	}
+	item.Clock[s.localNode]++
+	item.Timestamp = now
+	item.Origin = s.localNode
+	item.Deleted = in.Deleted
+	item.Payload = cloneAnyMap(in.Payload)
+	if in.Deleted {
+		item.Payload = nil
+	}
+	s.seq++
+	item.seq = s.seq
+	// Peers invalidate their own caches when they apply the update, so the
+	// write itself does not need a separate invalidation message.
+	s.invalidateLocked(CacheInvalidation{Kind: store, RefID: key, Origin: s.localNode, At: now}, false)
+	return cloneReplicatedEntity(*item), nil
+}
+
+// Invalidate broadcasts a cache invalidation without an entity update.
+func (s *MultiMasterStore) Invalidate(kind, refID string) (CacheInvalidation, int, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		return CacheInvalidation{}, 0, errors.New("kind is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv := CacheInvalidation{Kind: kind, RefID: strings.TrimSpace(refID), Origin: s.localNode, At: time.Now().UTC()}
+	return inv, s.invalidateLocked(inv, true), nil
+}
+
+func (s *MultiMasterStore) GetReplicated(store, key string) (ReplicatedEntity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.replicated[strings.ToLower(strings.TrimSpace(store))+"/"+strings.TrimSpace(key)]
+	if !ok {
+		return ReplicatedEntity{}, false
+	}
+	return cloneReplicatedEntity(*item), true
+}
+
+// Gossip returns the updates peer has not acknowledged; asking for updates
+// after since acknowledges everything up to since.
+func (s *MultiMasterStore) Gossip(peer string, since int64) ReplicationMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ackPeerLocked(peer, since)
+	out := ReplicationMessage{From: s.localNode, Since: since, Seq: s.seq, Updates: []ReplicatedEntity{}}
+	for _, item := range s.replicated {
+		if item.seq > since {
+			out.Updates = append(out.Updates, cloneReplicatedEntity(*item))
+		}
+	}
+	sort.Slice(out.Updates, func(i, j int) bool {
+		return out.Updates[i].Store+"/"+out.Updates[i].Key < out.Updates[j].Store+"/"+out.Updates[j].Key
+	})
+	for _, inv := range s.outbox {
+		if inv.seq > since {
+			out.Invalidations = append(out.Invalidations, inv.CacheInvalidation)
+		}
+	}
+	return out
+}
+
+// AckPeer records that peer has received our updates up to seq.
+func (s *MultiMasterStore) AckPeer(peer string, seq int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ackPeerLocked(peer, seq)
+}
+
+// PeerCursors returns how far peer has received our updates and how far we
+// have received its updates.
+func (s *MultiMasterStore) PeerCursors(peer string) (acked, received int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p := s.peers[strings.TrimSpace(peer)]
+	return p.AckedSeq, p.ReceivedSeq
+}
+
+func (s *MultiMasterStore) ackPeerLocked(peer string, seq int64) {
+	peer = strings.TrimSpace(peer)
+	if peer == "" {
+		return
+	}
+	p := s.peers[peer]
+	p.NodeID = peer
+	if seq > p.AckedSeq {
+		p.AckedSeq = seq
+	}
+	p.LastGossipAt = time.Now().UTC()
+	s.peers[peer] = p
+}
+
+// ApplyGossip merges a peer's message. Updates the local version already
+// includes are stale; concurrent updates are resolved by the store's
+// conflict policy. Applied updates and received invalidations evict the
+// matching central cache entries and are re-gossiped to other peers.
+func (s *MultiMasterStore) ApplyGossip(msg ReplicationMessage) ReplicationApplyResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := ReplicationApplyResult{From: msg.From}
+	now := time.Now().UTC()
+	for _, in := range msg.Updates {
+		in.Store = strings.ToLower(strings.TrimSpace(in.Store))
+		in.Key = strings.TrimSpace(in.Key)
+		if in.Store == "" || in.Key == "" {
+			continue
+		}
+		id := in.Store + "/" + in.Key
+		local, ok := s.replicated[id]
+		if !ok {
+			s.acceptReplicatedLocked(id, in, in.Clock)
+			out.Applied++
+			out.Invalidated += s.invalidateLocked(CacheInvalidation{Kind: in.Store, RefID: in.Key, Origin: in.Origin, At: now}, false)
+			continue
+		}
+		switch local.Clock.Compare(in.Clock) {
+		case "before":
+			s.acceptReplicatedLocked(id, in, in.Clock)
+			out.Applied++
+			out.Invalidated += s.invalidateLocked(CacheInvalidation{Kind: in.Store, RefID: in.Key, Origin: in.Origin, At: now}, false)
+		case "after", "equal":
+			out.Stale++
+		default:
+			out.Conflicts++
+			merged := s.resolveConflictLocked(*local, in, now)
+			if replicatedDigest(merged) != replicatedDigest(*local) {
+				s.acceptReplicatedLocked(id, merged, merged.Clock)
+				out.Applied++
+				out.Invalidated += s.invalidateLocked(CacheInvalidation{Kind: in.Store, RefID: in.Key, Origin: in.Origin, At: now}, false)
+			} else {
+				// The local version won; only the causal history grows, and
+				// peers need it to stop treating the versions as concurrent.
+				local.Clock = merged.Clock
+				s.seq++
+				local.seq = s.seq
+			}
+		}
+	}
+	for _, inv := range msg.Invalidations {
+		s.invalidationsRecv++
+		out.Invalidated += s.invalidateLocked(inv, false)
+	}
+	if from := strings.TrimSpace(msg.From); from != "" {
+		p := s.peers[from]
+		p.NodeID = from
+		if msg.Seq > p.ReceivedSeq {
+			p.ReceivedSeq = msg.Seq
+		}
+		p.LastGossipAt = now
+		s.peers[from] = p
+	}
+	return out
+}
+
+func (s *MultiMasterStore) resolveConflictLocked(local, remote ReplicatedEntity, now time.Time) ReplicatedEntity {
+	policy := s.policies[local.Store]
+	if policy == "" {
+		policy = "lww"
+	}
+	winner, loser := local, remote
+	if replicatedNewer(remote, local) {
+		winner, loser = remote, local
+	}
+	out := cloneReplicatedEntity(winner)
+	out.Clock = mergeVectorClocks(local.Clock, remote.Clock)
+	if policy == "vector_clock" && !winner.Deleted && !loser.Deleted {
+		merged := cloneAnyMap(loser.Payload)
+		for k, v := range winner.Payload {
+			merged[k] = v
+		}
+		out.Payload = merged
+	}
+	s.conflicts = append(s.conflicts, ReplicationConflict{
+		Store:      local.Store,
+		Key:        local.Key,
+		Policy:     policy,
+		Local:      local.Origin,
+		Remote:     remote.Origin,
+		Winner:     winner.Origin,
+		ResolvedAt: now,
+	})
+	if len(s.conflicts) > 1000 {
+		s.conflicts = s.conflicts[len(s.conflicts)-1000:]
+	}
+	s.conflictsTotal++
+	s.conflictsByStore[local.Store]++
+	return out
+}
+
+func (s *MultiMasterStore) acceptReplicatedLocked(id string, in ReplicatedEntity, clock VectorClock) {
+	item := cloneReplicatedEntity(in)
+	item.Clock = mergeVectorClocks(nil, clock)
+	s.seq++
+	item.seq = s.seq
+	s.replicated[id] = &item
+}
+
+// invalidateLocked evicts matching central cache entries; locally originated
+// invalidations are queued for gossip.
+func (s *MultiMasterStore) invalidateLocked(inv CacheInvalidation, local bool) int {
+	evicted := 0
+	for id, entry := range s.entries {
+		if entry.Kind == inv.Kind && (inv.RefID == "" || entry.RefID == inv.RefID) {
+			delete(s.entries, id)
+			evicted++
+		}
+	}
+	s.cacheEvictions += int64(evicted)
+	if local {
+		s.seq++
+		s.outbox = append(s.outbox, queuedInvalidation{CacheInvalidation: inv, seq: s.seq})
+		if len(s.outbox) > 1000 {
+			s.outbox = s.outbox[len(s.outbox)-1000:]
+		}
+		s.invalidationsSent++
+	}
+	return evicted
+}
+
+func (s *MultiMasterStore) ListConflicts(store string, limit int) []ReplicationConflict {
+	store = strings.ToLower(strings.TrimSpace(store))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]ReplicationConflict, 0)
+	for i := len(s.conflicts) - 1; i >= 0; i-- {
+		if store != "" && s.conflicts[i].Store != store {
+			continue
+		}
+		out = append(out, s.conflicts[i])
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+func (s *MultiMasterStore) ReplicationStatus() ReplicationStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := ReplicationStatus{
+		LocalNode:         s.localNode,
+		Seq:               s.seq,
+		Entities:          len(s.replicated),
+		Policies:          map[string]string{},
+		Peers:             make([]ReplicationPeerStatus, 0, len(s.peers)),
+		ConflictsTotal:    s.conflictsTotal,
+		ConflictsByStore:  map[string]int64{},
+		InvalidationsSent: s.invalidationsSent,
+		InvalidationsRecv: s.invalidationsRecv,
+		CacheEvictions:    s.cacheEvictions,
+	}
+	for store, policy := range s.policies {
+		out.Policies[store] = policy
+	}
+	for store, n := range s.conflictsByStore {
+		out.ConflictsByStore[store] = n
+	}
+	for _, peer := range s.peers {
+		peer.Lag = s.seq - peer.AckedSeq
+		out.Peers = append(out.Peers, peer)
+	}
+	sort.Slice(out.Peers, func(i, j int) bool { return out.Peers[i].NodeID < out.Peers[j].NodeID })
+	return out
+}
+
+// ReplicationDigest maps each "store/key" to a hash of its current version.
+func (s *MultiMasterStore) ReplicationDigest() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]string, len(s.replicated))
+	for id, item := range s.replicated {
+		out[id] = replicatedDigest(*item)
+	}
+	return out
+}
+
+// Reconcile compares this master's digest with remote's.
+func (s *MultiMasterStore) Reconcile(peer string, remote map[string]string) ReplicationReconcileReport {
+	local := s.ReplicationDigest()
+	out := ReplicationReconcileReport{
+		Peer:        peer,
+		GeneratedAt: time.Now().UTC(),
+		LocalOnly:   []string{},
+		RemoteOnly:  []string{},
+		Diverged:    []string{},
+	}
+	for id, digest := range local {
+		other, ok := remote[id]
+		switch {
+		case !ok:
+			out.LocalOnly = append(out.LocalOnly, id)
+		case other != digest:
+			out.Diverged = append(out.Diverged, id)
+		default:
+			out.InSync++
+		}
+	}
+	for id := range remote {
+		if _, ok := local[id]; !ok {
+			out.RemoteOnly = append(out.RemoteOnly, id)
+		}
+	}
+	sort.Strings(out.LocalOnly)
+	sort.Strings(out.RemoteOnly)
+	sort.Strings(out.Diverged)
+	if total := out.InSync + len(out.LocalOnly) + len(out.RemoteOnly) + len(out.Diverged); total > 0 {
+		out.DivergencePercent = float64(total-out.InSync) * 100 / float64(total)
+	}
+	return out
+}
+
+type queuedInvalidation struct {
+	CacheInvalidation
+	seq int64
+}
+
+// replicatedNewer orders versions for last-writer-wins: later timestamp,
+// then the higher origin id so every master picks the same winner.
+func replicatedNewer(a, b ReplicatedEntity) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	return a.Origin > b.Origin
+}
+
+func replicatedDigest(item ReplicatedEntity) string {
+	payload, _ := json.Marshal(struct {
+		Deleted bool           `json:"deleted"`
+		Payload map[string]any `json:"payload"`
+	}{item.Deleted, item.Payload})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func cloneReplicatedEntity(in ReplicatedEntity) ReplicatedEntity {
+	out := in
+	out.Clock = mergeVectorClocks(nil, in.Clock)
+	if in.Payload != nil {
+		out.Payload = cloneAnyMap(in.Payload)
+	}
+	return out
+}
@@ -55,3 +55,78 @@ func TestMultiMasterStoreNodesAndCache(t *testing.T) {
 		t.Fatalf("expected one event cache entry")
 	}
 }
+
+func TestMultiMasterReplicationConvergesAndResolvesConflicts(t *testing.T) {
+	a, b := NewMultiMasterStore(), NewMultiMasterStore()
+	a.SetLocalNode("cp-a")
+	b.SetLocalNode("cp-b")
+	if err := a.SetConflictPolicy("templates", "vector_clock"); err != nil {
+		t.Fatal(err)
+	}
+	_ = b.SetConflictPolicy("templates", "vector_clock")
+	if err := a.SetConflictPolicy("templates", "crdt"); err == nil {
+		t.Fatalf("expected unknown conflict policy to be rejected")
+	}
+
+	if _, err := a.LocalWrite(ReplicatedWriteInput{Store: "templates", Key: "tpl-1", Payload: map[string]any{"name": "web", "owner": "sre"}}); err != nil {
+		t.Fatal(err)
+	}
+	res := b.ApplyGossip(a.Gossip("cp-b", 0))
+	if res.Applied != 1 || res.Stale != 0 {
+		t.Fatalf("expected first update applied, got %+v", res)
+	}
+	if res := b.ApplyGossip(a.Gossip("cp-b", 0)); res.Stale != 1 {
+		t.Fatalf("expected replayed update to be stale, got %+v", res)
+	}
+
+	// Concurrent edits on both masters.
+	_, _ = a.LocalWrite(ReplicatedWriteInput{Store: "templates", Key: "tpl-1", Payload: map[string]any{"name": "web", "owner": "platform"}})
+	time.Sleep(2 * time.Millisecond)
+	_, _ = b.LocalWrite(ReplicatedWriteInput{Store: "templates", Key: "tpl-1", Payload: map[string]any{"name": "web-v2"}})
+	resA := a.ApplyGossip(b.Gossip("cp-a", 0))
+	resB := b.ApplyGossip(a.Gossip("cp-b", 0))
+	if resA.Conflicts != 1 || resB.Conflicts != 0 || resB.Applied != 1 {
+		t.Fatalf("expected conflict resolved on a and the merge applied causally on b, got %+v %+v", resA, resB)
+	}
+	ea, _ := a.GetReplicated("templates", "tpl-1")
+	eb, _ := b.GetReplicated("templates", "tpl-1")
+	if ea.Payload["name"] != "web-v2" || ea.Payload["owner"] != "platform" || eb.Payload["owner"] != "platform" || ea.Clock.Compare(eb.Clock) != "equal" {
+		t.Fatalf("expected masters converged on merged version, got a=%+v b=%+v", ea, eb)
+	}
+	if report := a.Reconcile("cp-b", b.ReplicationDigest()); report.InSync != 1 || len(report.Diverged) != 0 {
+		t.Fatalf("expected no divergence after gossip, got %+v", report)
+	}
+
+	// Last writer wins for stores without a policy; deletes replicate.
+	_, _ = a.LocalWrite(ReplicatedWriteInput{Store: "runbooks", Key: "rb-1", Payload: map[string]any{"v": 1}})
+	if report := a.Reconcile("cp-b", b.ReplicationDigest()); len(report.LocalOnly) != 1 || report.DivergencePercent != 50 {
+		t.Fatalf("expected local-only entity reported, got %+v", report)
+	}
+	_, _ = b.LocalWrite(ReplicatedWriteInput{Store: "runbooks", Key: "rb-1", Deleted: true})
+	time.Sleep(2 * time.Millisecond)
+	_, _ = a.LocalWrite(ReplicatedWriteInput{Store: "runbooks", Key: "rb-1", Payload: map[string]any{"v": 2}})
+	b.ApplyGossip(a.Gossip("cp-b", 0))
+	if rb, _ := b.GetReplicated("runbooks", "rb-1"); rb.Deleted || rb.Payload["v"] != 2 {
+		t.Fatalf("expected later write to win, got %+v", rb)
+	}
+
+	a.SyncCentralCache([]Job{{ID: "job-1"}}, []Event{{Type: "deploy", Time: time.Now().UTC()}}, 0)
+	if _, n, _ := a.Invalidate("event", "deploy"); n != 1 {
+		t.Fatalf("expected cached event evicted, got %d", n)
+	}
+	_, _ = a.LocalWrite(ReplicatedWriteInput{Store: "job", Key: "job-1", Payload: map[string]any{"status": "failed"}})
+	if entries := a.ListCentralCache("", 0); len(entries) != 0 {
+		t.Fatalf("expected replicated write to evict cached job, got %+v", entries)
+	}
+	msg := a.Gossip("cp-b", 0)
+	if len(msg.Invalidations) != 1 {
+		t.Fatalf("expected invalidation message in gossip, got %+v", msg.Invalidations)
+	}
+	status := a.ReplicationStatus()
+	if status.ConflictsByStore["templates"] != 1 || status.InvalidationsSent != 1 || status.CacheEvictions != 2 || len(status.Peers) != 1 || status.Peers[0].Lag != status.Seq {
+		t.Fatalf("unexpected replication status %+v", status)
+	}
+	if len(a.ListConflicts("templates", 10)) != 1 {
+		t.Fatalf("expected conflict log entry")
+	}
+}
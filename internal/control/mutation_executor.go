@@ -0,0 +1,315 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+const (
+	MutationOpDropResource     = "drop-resource"
+	MutationOpCorruptField     = "corrupt-field"
+	MutationOpDropDependencies = "drop-dependencies"
+	MutationOpFlipBecome       = "flip-become"
+)
+
+var mutationOperators = []string{MutationOpDropResource, MutationOpCorruptField, MutationOpDropDependencies, MutationOpFlipBecome}
+
+// mutableResourceFields are the config fields corrupt-field rewrites.
+var mutableResourceFields = []string{"path", "content", "mode", "command", "creates", "only_if", "unless", "become_user", "registry_value", "task_command"}
+
+// MutationDiagnosticsSink persists a run's per-mutant diagnostics under key.
+type MutationDiagnosticsSink func(key string, data []byte) error
+
+type MutationDiagnostic struct {
+	ID       string   `json:"id"`
+	Operator string   `json:"operator"`
+	Target   string   `json:"target"`
+	Detail   string   `json:"detail"`
+	Killed   bool     `json:"killed"`
+	KilledBy []string `json:"killed_by,omitempty"`
+}
+
+type mutant struct {
+	diag MutationDiagnostic
+	cfg  config.Config
+}
+
+// MutationExecutor runs a suite for real: it mutates the suite's policy or
+// config package one change at a time, evaluates the associated module and
+// policy harness cases against each mutant, and counts a mutant as killed
+// when validation or any harness case fails.
+type MutationExecutor struct {
+	mutations *MutationStore
+	harness   *ModulePolicyHarnessStore
+	sink      MutationDiagnosticsSink
+	baseDir   string
+}
+
+// NewMutationExecutor resolves relative suite config paths against baseDir.
+func NewMutationExecutor(mutations *MutationStore, harness *ModulePolicyHarnessStore, sink MutationDiagnosticsSink, baseDir string) *MutationExecutor {
+	return &MutationExecutor{mutations: mutations, harness: harness, sink: sink, baseDir: baseDir}
+}
+
+func (e *MutationExecutor) Execute(in MutationRunInput) (MutationRun, []MutationDiagnostic, error) {
+	suite, err := e.mutations.GetSuite(in.SuiteID)
+	if err != nil {
+		return MutationRun{}, nil, err
+	}
+	if suite.ConfigPath == "" {
+		return MutationRun{}, nil, errors.New("suite has no config_path to mutate")
+	}
+	path := suite.ConfigPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.baseDir, path)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return MutationRun{}, nil, fmt.Errorf("load mutation target: %w", err)
+	}
+	started := time.Now().UTC()
+	// Mutation scores are meaningless unless the harness passes on the
+	// unmutated package.
+	failing, err := e.evaluate(suite.HarnessCaseIDs, *cfg)
+	if err != nil {
+		return MutationRun{}, nil, err
+	}
+	if len(failing) > 0 {
+		return MutationRun{}, nil, errors.New("harness cases fail on unmutated config: " + strings.Join(failing, ", "))
+	}
+
+	operators := suite.Operators
+	if len(operators) == 0 {
+		operators = mutationOperators
+	}
+	mutants := generateMutants(*cfg, operators)
+	diagnostics := make([]MutationDiagnostic, 0, len(mutants))
+	killed := 0
+	survivors := make([]string, 0)
+	for _, m := range mutants {
+		diag := m.diag
+		if err := config.Validate(&m.cfg); err != nil {
+			diag.KilledBy = []string{"validation"}
+		} else {
+			diag.KilledBy, err = e.evaluate(suite.HarnessCaseIDs, m.cfg)
+			if err != nil {
+				return MutationRun{}, nil, err
+			}
+		}
+		diag.Killed = len(diag.KilledBy) > 0
+		if diag.Killed {
+			killed++
+		} else {
+			survivors = append(survivors, diag.ID)
+		}
+		diagnostics = append(diagnostics, diag)
+	}
+
+	item := MutationRun{
+		SuiteID:       suite.ID,
+		Provider:      suite.Provider,
+		Mode:          "executed",
+		MutantsTotal:  len(mutants),
+		MutantsKilled: killed,
+		Survivors:     survivors,
+		TriggeredBy:   strings.TrimSpace(in.TriggeredBy),
+		StartedAt:     started,
+		CompletedAt:   time.Now().UTC(),
+	}
+	e.mutations.mu.Lock()
+	run := e.mutations.recordRunLocked(item)
+	e.mutations.mu.Unlock()
+
+	if e.sink != nil {
+		payload, err := json.MarshalIndent(map[string]any{"run": run, "mutants": diagnostics}, "", "  ")
+		if err != nil {
+			return run, diagnostics, err
+		}
+		key := "mutation-runs/" + run.ID + ".json"
+		if err := e.sink(key, payload); err != nil {
+			return run, diagnostics, fmt.Errorf("store mutation diagnostics: %w", err)
+		}
+		run = e.mutations.setDiagnosticsKey(run.ID, key)
+	}
+	return run, diagnostics, nil
+}
+
+// evaluate returns the harness case IDs that fail for cfg.
+func (e *MutationExecutor) evaluate(caseIDs []string, cfg config.Config) ([]string, error) {
+	observed := observeMutationConfig(cfg)
+	failing := make([]string, 0)
+	for _, caseID := range caseIDs {
+		result, err := e.harness.Evaluate(ModulePolicyHarnessRunInput{CaseID: caseID, Observed: observed})
+		if err != nil {
+			return nil, fmt.Errorf("harness case %s: %w", caseID, err)
+		}
+		if result.Failed > 0 {
+			failing = append(failing, caseID)
+		}
+	}
+	return failing, nil
+}
+
+func (s *MutationStore) setDiagnosticsKey(runID, key string) MutationRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.runs[runID]
+	if !ok {
+		return MutationRun{}
+	}
+	item.DiagnosticsKey = key
+	return cloneMutationRun(*item)
+}
+
+// observeMutationConfig flattens resources into the harness observation
+// namespace: resource.<id>.present plus resource.<id>.<field> for every set
+// field, with lists joined by commas.
+func observeMutationConfig(cfg config.Config) map[string]string {
+	out := map[string]string{}
+	for _, res := range cfg.Resources {
+		prefix := "resource." + res.ID + "."
+		out[prefix+"present"] = "true"
+		raw, err := json.Marshal(res)
+		if err != nil {
+			continue
+		}
+		fields := map[string]any{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			continue
+		}
+		for name, value := range fields {
+			switch v := value.(type) {
+			case string:
+				out[prefix+name] = v
+			case bool:
+				out[prefix+name] = strconv.FormatBool(v)
+			case float64:
+				out[prefix+name] = strconv.FormatFloat(v, 'f', -1, 64)
+			case []any:
+				parts := make([]string, 0, len(v))
+				for _, item := range v {
+					parts = append(parts, fmt.Sprint(item))
+				}
+				out[prefix+name] = strings.Join(parts, ",")
+			}
+		}
+	}
+	return out
+}
+
+// generateMutants applies each operator to each resource, producing one
+// single-change copy of cfg per mutant in a deterministic order.
+func generateMutants(cfg config.Config, operators []string) []mutant {
+	enabled := map[string]bool{}
+	for _, op := range operators {
+		enabled[op] = true
+	}
+	out := make([]mutant, 0)
+	add := func(op, target, detail string, mutate func(*config.Config)) {
+		next := cloneMutationConfig(cfg)
+		mutate(&next)
+		out = append(out, mutant{
+			diag: MutationDiagnostic{ID: "mutant-" + strconv.Itoa(len(out)+1), Operator: op, Target: target, Detail: detail},
+			cfg:  next,
+		})
+	}
+	for idx, res := range cfg.Resources {
+		idx, res := idx, res
+		target := "resource." + res.ID
+		if enabled[MutationOpDropResource] {
+			add(MutationOpDropResource, target, "remove resource", func(c *config.Config) {
+				c.Resources = append(c.Resources[:idx:idx], c.Resources[idx+1:]...)
+			})
+		}
+		if enabled[MutationOpCorruptField] {
+			values := observeMutationConfig(config.Config{Resources: []config.Resource{res}})
+			for _, field := range mutableResourceFields {
+				current, ok := values[target+"."+field]
+				if !ok || current == "" {
+					continue
+				}
+				field := field
+				replacement := corruptMutationValue(field, current)
+				add(MutationOpCorruptField, target+"."+field, "set "+field+" to "+strconv.Quote(replacement), func(c *config.Config) {
+					setMutationField(&c.Resources[idx], field, replacement)
+				})
+			}
+		}
+		if enabled[MutationOpDropDependencies] && len(res.DependsOn)+len(res.Require) > 0 {
+			add(MutationOpDropDependencies, target+".depends_on", "remove depends_on and require edges", func(c *config.Config) {
+				c.Resources[idx].DependsOn = nil
+				c.Resources[idx].Require = nil
+			})
+		}
+		if enabled[MutationOpFlipBecome] && res.Type == "command" {
+			add(MutationOpFlipBecome, target+".become", "set become to "+strconv.FormatBool(!res.Become), func(c *config.Config) {
+				c.Resources[idx].Become = !c.Resources[idx].Become
+			})
+		}
+	}
+	return out
+}
+
+func corruptMutationValue(field, current string) string {
+	if field == "mode" {
+		if current == "0777" {
+			return "0600"
+		}
+		return "0777"
+	}
+	return current + "-mutated"
+}
+
+func setMutationField(res *config.Resource, field, value string) {
+	switch field {
+	case "path":
+		res.Path = value
+	case "content":
+		res.Content = value
+	case "mode":
+		res.Mode = value
+	case "command":
+		res.Command = value
+	case "creates":
+		res.Creates = value
+	case "only_if":
+		res.OnlyIf = value
+	case "unless":
+		res.Unless = value
+	case "become_user":
+		res.BecomeUser = value
+	case "registry_value":
+		res.RegistryValue = value
+	case "task_command":
+		res.TaskCommand = value
+	}
+}
+
+// cloneMutationConfig deep-copies cfg through JSON so mutants never share
+// slices or maps with the original.
+func cloneMutationConfig(cfg config.Config) config.Config {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return cfg
+	}
+	var out config.Config
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return cfg
+	}
+	return out
+}
+
+func isMutationOperator(op string) bool {
+	for _, item := range mutationOperators {
+		if item == op {
+			return true
+		}
+	}
+	return false
+}
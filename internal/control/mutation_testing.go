@@ -16,12 +16,15 @@ type MutationPolicy struct {
 }
 
 type MutationSuite struct {
-	ID            string    `json:"id"`
-	Provider      string    `json:"provider"`
-	Name          string    `json:"name"`
-	CriticalPaths []string  `json:"critical_paths"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             string    `json:"id"`
+	Provider       string    `json:"provider"`
+	Name           string    `json:"name"`
+	CriticalPaths  []string  `json:"critical_paths"`
+	ConfigPath     string    `json:"config_path,omitempty"`      // policy/config package mutated by executed runs
+	HarnessCaseIDs []string  `json:"harness_case_ids,omitempty"` // module/policy harness cases that must kill mutants
+	Operators      []string  `json:"operators,omitempty"`        // empty for every mutation operator
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type MutationRunInput struct {
@@ -34,12 +37,15 @@ type MutationRun struct {
 	ID              string    `json:"id"`
 	SuiteID         string    `json:"suite_id"`
 	Provider        string    `json:"provider"`
+	Mode            string    `json:"mode"`   // simulated|executed
 	Status          string    `json:"status"` // pass|fail
 	MutantsTotal    int       `json:"mutants_total"`
 	MutantsKilled   int       `json:"mutants_killed"`
 	MutantsSurvived int       `json:"mutants_survived"`
 	KillRate        float64   `json:"kill_rate"`
 	BlockReasons    []string  `json:"block_reasons,omitempty"`
+	Survivors       []string  `json:"survivors,omitempty"`
+	DiagnosticsKey  string    `json:"diagnostics_key,omitempty"`
 	TriggeredBy     string    `json:"triggered_by,omitempty"`
 	StartedAt       time.Time `json:"started_at"`
 	CompletedAt     time.Time `json:"completed_at"`
@@ -116,18 +122,32 @@ func (s *MutationStore) UpsertSuite(in MutationSuite) (MutationSuite, error) {
 	if len(paths) == 0 {
 		return MutationSuite{}, errors.New("critical_paths are required")
 	}
+	configPath := strings.TrimSpace(in.ConfigPath)
+	caseIDs := normalizeStringSlice(in.HarnessCaseIDs)
+	if configPath != "" && len(caseIDs) == 0 {
+		return MutationSuite{}, errors.New("harness_case_ids are required when config_path is set")
+	}
+	operators := normalizeStringSlice(in.Operators)
+	for _, op := range operators {
+		if !isMutationOperator(op) {
+			return MutationSuite{}, errors.New("unknown mutation operator: " + op)
+		}
+	}
 	now := time.Now().UTC()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	existing, ok := s.suites[id]
 	if !ok {
 		item := MutationSuite{
-			ID:            id,
-			Provider:      provider,
-			Name:          name,
-			CriticalPaths: paths,
-			CreatedAt:     now,
-			UpdatedAt:     now,
+			ID:             id,
+			Provider:       provider,
+			Name:           name,
+			CriticalPaths:  paths,
+			ConfigPath:     configPath,
+			HarnessCaseIDs: caseIDs,
+			Operators:      operators,
+			CreatedAt:      now,
+			UpdatedAt:      now,
 		}
 		s.suites[id] = &item
 		return cloneMutationSuite(item), nil
@@ -135,6 +155,9 @@ func (s *MutationStore) UpsertSuite(in MutationSuite) (MutationSuite, error) {
 	existing.Provider = provider
 	existing.Name = name
 	existing.CriticalPaths = paths
+	existing.ConfigPath = configPath
+	existing.HarnessCaseIDs = caseIDs
+	existing.Operators = operators
 	existing.UpdatedAt = now
 	return cloneMutationSuite(*existing), nil
 }
@@ -171,43 +194,93 @@ func (s *MutationStore) Run(in MutationRunInput) (MutationRun, error) {
 	if killed > total {
 		killed = total
 	}
-	survived := total - killed
-	killRate := 0.0
-	if total > 0 {
-		killRate = float64(killed) / float64(total)
+	started := time.Now().UTC()
+	item := MutationRun{
+		SuiteID:       suite.ID,
+		Provider:      suite.Provider,
+		Mode:          "simulated",
+		MutantsTotal:  total,
+		MutantsKilled: killed,
+		TriggeredBy:   strings.TrimSpace(in.TriggeredBy),
+		StartedAt:     started,
+		CompletedAt:   started.Add(2 * time.Minute),
 	}
-	policy := s.policy
+	return s.recordRunLocked(item), nil
+}
+
+// recordRunLocked fills in kill rate and policy verdict and stores the run.
+// Callers must hold s.mu.
+func (s *MutationStore) recordRunLocked(item MutationRun) MutationRun {
+	item.MutantsSurvived = item.MutantsTotal - item.MutantsKilled
+	item.KillRate = 0
+	if item.MutantsTotal > 0 {
+		item.KillRate = float64(item.MutantsKilled) / float64(item.MutantsTotal)
+	}
+	item.BlockReasons = mutationPolicyReasons(s.policy, item)
+	item.Status = "pass"
+	if len(item.BlockReasons) > 0 {
+		item.Status = "fail"
+	}
+	s.nextID++
+	item.ID = "mutation-run-" + itoa(s.nextID)
+	s.runs[item.ID] = &item
+	s.runList = append(s.runList, item.ID)
+	return cloneMutationRun(item)
+}
+
+func mutationPolicyReasons(policy MutationPolicy, run MutationRun) []string {
 	reasons := make([]string, 0)
-	if killRate < policy.MinKillRate {
+	if run.KillRate < policy.MinKillRate {
 		reasons = append(reasons, "kill rate below minimum threshold")
 	}
-	if total < policy.MinMutantsCovered {
+	if run.MutantsTotal < policy.MinMutantsCovered {
 		reasons = append(reasons, "mutants covered below minimum threshold")
 	}
-	status := "pass"
-	if len(reasons) > 0 {
-		status = "fail"
+	return reasons
+}
+
+// ReadinessBlockers re-checks the latest executed run of every suite for
+// provider (every suite when provider is empty) against the current
+// policy. Simulated runs are estimates and never block readiness.
+func (s *MutationStore) ReadinessBlockers(provider string) []string {
+	provider = strings.TrimSpace(provider)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	latest := map[string]*MutationRun{}
+	for i := len(s.runList) - 1; i >= 0; i-- {
+		item := s.runs[s.runList[i]]
+		if item == nil || item.Mode != "executed" {
+			continue
+		}
+		if provider != "" && !strings.EqualFold(item.Provider, provider) {
+			continue
+		}
+		if _, ok := latest[item.SuiteID]; !ok {
+			latest[item.SuiteID] = item
+		}
 	}
-	started := time.Now().UTC()
-	completed := started.Add(2 * time.Minute)
-	s.nextID++
-	item := MutationRun{
-		ID:              "mutation-run-" + itoa(s.nextID),
-		SuiteID:         suite.ID,
-		Provider:        suite.Provider,
-		Status:          status,
-		MutantsTotal:    total,
-		MutantsKilled:   killed,
-		MutantsSurvived: survived,
-		KillRate:        killRate,
-		BlockReasons:    reasons,
-		TriggeredBy:     strings.TrimSpace(in.TriggeredBy),
-		StartedAt:       started,
-		CompletedAt:     completed,
+	out := make([]string, 0)
+	for suiteID, item := range latest {
+		for _, reason := range mutationPolicyReasons(s.policy, *item) {
+			out = append(out, "mutation suite "+suiteID+": "+reason)
+		}
 	}
-	s.runs[item.ID] = &item
-	s.runList = append(s.runList, item.ID)
-	return cloneMutationRun(item), nil
+	sort.Strings(out)
+	return out
+}
+
+func (s *MutationStore) GetSuite(id string) (MutationSuite, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return MutationSuite{}, errors.New("suite_id is required")
+	}
+	s.mu.RLock()
+	item, ok := s.suites[id]
+	s.mu.RUnlock()
+	if !ok {
+		return MutationSuite{}, errors.New("suite not found")
+	}
+	return cloneMutationSuite(*item), nil
 }
 
 func (s *MutationStore) ListRuns(suiteID string, limit int) []MutationRun {
@@ -258,10 +331,13 @@ func deterministicMutationScore(suiteID string, seed int64) uint32 {
 
 func cloneMutationSuite(in MutationSuite) MutationSuite {
 	in.CriticalPaths = cloneStringSlice(in.CriticalPaths)
+	in.HarnessCaseIDs = cloneStringSlice(in.HarnessCaseIDs)
+	in.Operators = cloneStringSlice(in.Operators)
 	return in
 }
 
 func cloneMutationRun(in MutationRun) MutationRun {
 	in.BlockReasons = cloneStringSlice(in.BlockReasons)
+	in.Survivors = cloneStringSlice(in.Survivors)
 	return in
 }
@@ -1,6 +1,11 @@
 package control
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestMutationStorePolicyAndRun(t *testing.T) {
 	store := NewMutationStore()
@@ -38,3 +43,118 @@ func TestMutationStoreUpsertSuite(t *testing.T) {
 		t.Fatalf("unexpected mutation suite payload: %+v", suite)
 	}
 }
+
+func TestMutationExecutorKillsMutantsWithHarnessCases(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "policy.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: sshd-config
+    type: file
+    host: localhost
+    path: /etc/ssh/sshd_config
+    content: "PermitRootLogin no\n"
+    mode: "0600"
+  - id: reload-sshd
+    type: command
+    host: localhost
+    command: systemctl reload sshd
+    depends_on: [sshd-config]
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	harness := NewModulePolicyHarnessStore()
+	if _, err := harness.UpsertCase(ModulePolicyHarnessCaseInput{
+		ID:   "sshd-hardening",
+		Name: "sshd hardening",
+		Kind: "policy",
+		Assertions: []ModulePolicyHarnessAssertionInput{
+			{Field: "resource.sshd-config.present", Expected: "true"},
+			{Field: "resource.sshd-config.mode", Expected: "0600"},
+			{Field: "resource.sshd-config.content", Expected: "PermitRootLogin no\n"},
+		},
+	}); err != nil {
+		t.Fatalf("upsert harness case failed: %v", err)
+	}
+	store := NewMutationStore()
+	if _, err := store.SetPolicy(MutationPolicy{MinKillRate: 0.3, MinMutantsCovered: 5}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if _, err := store.UpsertSuite(MutationSuite{
+		ID:             "mutation-sshd-policy",
+		Provider:       "file",
+		Name:           "sshd policy pack",
+		CriticalPaths:  []string{"sshd/hardening"},
+		ConfigPath:     "policy.yaml",
+		HarnessCaseIDs: []string{"sshd-hardening"},
+	}); err != nil {
+		t.Fatalf("upsert suite failed: %v", err)
+	}
+	stored := map[string][]byte{}
+	exec := NewMutationExecutor(store, harness, func(key string, data []byte) error {
+		stored[key] = data
+		return nil
+	}, tmp)
+
+	run, diagnostics, err := exec.Execute(MutationRunInput{SuiteID: "mutation-sshd-policy"})
+	if err != nil {
+		t.Fatalf("execute mutation suite failed: %v", err)
+	}
+	if run.Mode != "executed" || run.MutantsTotal != len(diagnostics) || run.MutantsTotal == 0 {
+		t.Fatalf("unexpected executed run: %+v", run)
+	}
+	if run.MutantsSurvived == 0 || len(run.Survivors) != run.MutantsSurvived {
+		t.Fatalf("expected command mutants to survive the file-only harness: %+v", run)
+	}
+	for _, diag := range diagnostics {
+		if diag.Target == "resource.sshd-config.mode" && !diag.Killed {
+			t.Fatalf("expected mode mutant to be killed: %+v", diag)
+		}
+	}
+	if run.DiagnosticsKey == "" || len(stored[run.DiagnosticsKey]) == 0 {
+		t.Fatalf("expected diagnostics to be stored, key=%q", run.DiagnosticsKey)
+	}
+	if blockers := store.ReadinessBlockers("file"); len(blockers) != 0 {
+		t.Fatalf("expected passing run not to block readiness, got %v", blockers)
+	}
+
+	if _, err := store.SetPolicy(MutationPolicy{MinKillRate: 0.99, MinMutantsCovered: 5}); err != nil {
+		t.Fatalf("tighten policy failed: %v", err)
+	}
+	if blockers := store.ReadinessBlockers("file"); len(blockers) == 0 {
+		t.Fatalf("expected tightened policy to block readiness")
+	}
+	if blockers := store.ReadinessBlockers("package"); len(blockers) != 0 {
+		t.Fatalf("expected other providers to be unaffected, got %v", blockers)
+	}
+	scorecards := NewReadinessScorecardStore()
+	scorecards.SetBlockerCheck(func(_, service string) []string {
+		return store.ReadinessBlockers(strings.TrimPrefix(service, "provider/"))
+	})
+	card, err := scorecards.Create(ReadinessScorecardInput{
+		Environment: "prod",
+		Service:     "provider/file",
+		Signals:     ReadinessSignals{QualityScore: 1, ReliabilityScore: 1, PerformanceScore: 1, TestPassRate: 1},
+	})
+	if err != nil {
+		t.Fatalf("create scorecard failed: %v", err)
+	}
+	if card.Report.Pass {
+		t.Fatalf("expected mutation blocker to fail scorecard: %+v", card.Report)
+	}
+
+	if _, err := harness.UpsertCase(ModulePolicyHarnessCaseInput{
+		ID:         "sshd-hardening",
+		Name:       "sshd hardening",
+		Kind:       "policy",
+		Assertions: []ModulePolicyHarnessAssertionInput{{Field: "resource.sshd-config.mode", Expected: "0644"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := exec.Execute(MutationRunInput{SuiteID: "mutation-sshd-policy"}); err == nil {
+		t.Fatalf("expected execution to refuse a harness that fails on the unmutated config")
+	}
+}
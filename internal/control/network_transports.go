@@ -90,6 +90,49 @@ func NewNetworkTransportCatalog() *NetworkTransportCatalog {
 		},
 		UpdatedAt: now,
 	}
+	items["ssh-cli"] = NetworkTransport{
+		Name:                  "ssh-cli",
+		Description:           "Interactive CLI over SSH for network switches without an API",
+		Category:              "cli",
+		DefaultPort:           22,
+		Builtin:               true,
+		SupportsConfigPush:    true,
+		SupportsTelemetryPull: true,
+		CredentialFieldsRequired: []string{
+			"address",
+			"username",
+		},
+		UpdatedAt: now,
+	}
+	items["redfish"] = NetworkTransport{
+		Name:                  "redfish",
+		Description:           "DMTF Redfish API for server BMCs",
+		Category:              "bmc",
+		DefaultPort:           443,
+		Builtin:               true,
+		SupportsConfigPush:    true,
+		SupportsTelemetryPull: true,
+		CredentialFieldsRequired: []string{
+			"endpoint",
+			"username",
+			"password_env",
+		},
+		UpdatedAt: now,
+	}
+	items["snmp"] = NetworkTransport{
+		Name:                  "snmp",
+		Description:           "SNMPv2c get/set for devices that only expose a MIB",
+		Category:              "snmp",
+		DefaultPort:           161,
+		Builtin:               true,
+		SupportsConfigPush:    true,
+		SupportsTelemetryPull: true,
+		CredentialFieldsRequired: []string{
+			"address",
+			"community",
+		},
+		UpdatedAt: now,
+	}
 	return &NetworkTransportCatalog{items: items}
 }
 
@@ -172,14 +215,14 @@ func (c *NetworkTransportCatalog) Validate(name string) (NetworkTransportValidat
 		Input:     trimmed,
 		Canonical: canonical,
 		Supported: false,
-		Message:   "unsupported transport; use netconf, restconf, api, plugin/<name>, or register a custom transport",
+		Message:   "unsupported transport; use netconf, restconf, api, ssh-cli, redfish, snmp, plugin/<name>, or register a custom transport",
 	}, nil
 }
 
 func normalizeNetworkTransportCategory(in string) string {
 	value := strings.ToLower(strings.TrimSpace(in))
 	switch value {
-	case "netconf", "restconf", "api", "plugin", "custom":
+	case "netconf", "restconf", "api", "cli", "bmc", "snmp", "plugin", "custom":
 		return value
 	default:
 		return "custom"
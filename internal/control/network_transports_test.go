@@ -24,7 +24,7 @@ func TestNetworkTransportCatalogDefaultsAndValidate(t *testing.T) {
 		t.Fatalf("unexpected plugin validation %+v", plugin)
 	}
 
-	unsupported, err := catalog.Validate("snmp")
+	unsupported, err := catalog.Validate("telnet")
 	if err != nil {
 		t.Fatalf("validate unsupported: %v", err)
 	}
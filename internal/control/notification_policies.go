@@ -0,0 +1,575 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const maxNotificationEscalationSteps = 10
+
+// NotificationQuietHours is a daily window during which a target receives
+// nothing but alerts of a bypass severity. Start and End are "HH:MM" in
+// Timezone; a window whose End is before Start spans midnight and belongs
+// to the day it starts on. Empty Days means every day.
+type NotificationQuietHours struct {
+	Start            string   `json:"start"`
+	End              string   `json:"end"`
+	Timezone         string   `json:"timezone,omitempty"`
+	Days             []string `json:"days,omitempty"`
+	BypassSeverities []string `json:"bypass_severities,omitempty"`
+}
+
+// NotificationBusinessHours splits a policy's day into business hours and
+// on-call hours. Empty Days means Monday to Friday.
+type NotificationBusinessHours struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// NotificationEscalationStep notifies more targets once an alert has stayed
+// open for AfterSeconds since the policy first routed it.
+type NotificationEscalationStep struct {
+	AfterSeconds int      `json:"after_seconds"`
+	Targets      []string `json:"targets"`
+}
+
+// NotificationPolicy routes alerts by event type, severity and workload.
+// Targets receive matching alerts during business hours (or always, when no
+// business hours are set); OnCallTargets receive them outside business
+// hours and fall back to Targets when empty. Policies are evaluated by
+// descending Priority and the first match wins.
+type NotificationPolicy struct {
+	ID            string                       `json:"id"`
+	Name          string                       `json:"name"`
+	Priority      int                          `json:"priority"`
+	Enabled       bool                         `json:"enabled"`
+	EventTypes    []string                     `json:"event_types,omitempty"` // prefixes; empty or "*" matches all
+	Severities    []string                     `json:"severities,omitempty"`
+	Workloads     []string                     `json:"workloads,omitempty"`
+	Timezone      string                       `json:"timezone,omitempty"`
+	BusinessHours *NotificationBusinessHours   `json:"business_hours,omitempty"`
+	Targets       []string                     `json:"targets"`
+	OnCallTargets []string                     `json:"on_call_targets,omitempty"`
+	Escalation    []NotificationEscalationStep `json:"escalation,omitempty"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+}
+
+// NotificationEscalation tracks an alert walking a policy's escalation
+// chain. Repeat occurrences of the alert do not restart the chain.
+type NotificationEscalation struct {
+	AlertID    string    `json:"alert_id"`
+	PolicyID   string    `json:"policy_id"`
+	StartedAt  time.Time `json:"started_at"`
+	NextStep   int       `json:"next_step"`
+	NextStepAt time.Time `json:"next_step_at"`
+
+	alert AlertItem
+}
+
+type NotificationPlannedDelivery struct {
+	TargetID     string `json:"target_id"`
+	Phase        string `json:"phase"`
+	AfterSeconds int    `json:"after_seconds,omitempty"`
+	Suppressed   bool   `json:"suppressed,omitempty"`
+}
+
+// NotificationRoutePlan describes where an alert would be delivered at a
+// given time, without sending anything.
+type NotificationRoutePlan struct {
+	PolicyID   string                        `json:"policy_id,omitempty"`
+	PolicyName string                        `json:"policy_name,omitempty"`
+	Phase      string                        `json:"phase"` // business_hours|on_call|route
+	Deliveries []NotificationPlannedDelivery `json:"deliveries"`
+}
+
+func (r *NotificationRouter) CreatePolicy(in NotificationPolicy) (NotificationPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.validatePolicyLocked(&in); err != nil {
+		return NotificationPolicy{}, err
+	}
+	r.nextPolicyID++
+	now := time.Now().UTC()
+	in.ID = "notify-policy-" + itoa(r.nextPolicyID)
+	in.CreatedAt = now
+	in.UpdatedAt = now
+	cp := cloneNotificationPolicy(in)
+	r.policies[in.ID] = &cp
+	return cloneNotificationPolicy(cp), nil
+}
+
+// UpdatePolicy replaces a policy definition. Escalations already under way
+// continue against the new chain.
+func (r *NotificationRouter) UpdatePolicy(id string, in NotificationPolicy) (NotificationPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.policies[id]
+	if !ok {
+		return NotificationPolicy{}, errors.New("notification policy not found")
+	}
+	if err := r.validatePolicyLocked(&in); err != nil {
+		return NotificationPolicy{}, err
+	}
+	in.ID = cur.ID
+	in.CreatedAt = cur.CreatedAt
+	in.UpdatedAt = time.Now().UTC()
+	cp := cloneNotificationPolicy(in)
+	r.policies[id] = &cp
+	return cloneNotificationPolicy(cp), nil
+}
+
+func (r *NotificationRouter) GetPolicy(id string) (NotificationPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.policies[id]
+	if !ok {
+		return NotificationPolicy{}, errors.New("notification policy not found")
+	}
+	return cloneNotificationPolicy(*p), nil
+}
+
+// ListPolicies returns policies in evaluation order.
+func (r *NotificationRouter) ListPolicies() []NotificationPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sortedPoliciesLocked()
+}
+
+// DeletePolicy removes a policy and abandons its pending escalations.
+func (r *NotificationRouter) DeletePolicy(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[id]; !ok {
+		return errors.New("notification policy not found")
+	}
+	delete(r.policies, id)
+	for alertID, esc := range r.escalations {
+		if esc.PolicyID == id {
+			delete(r.escalations, alertID)
+		}
+	}
+	return nil
+}
+
+// Plan reports where alert would be delivered at the given time, including
+// the escalation chain and which deliveries quiet hours would hold back.
+func (r *NotificationRouter) Plan(alert AlertItem, at time.Time) NotificationRoutePlan {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policy, phase, ok := r.matchPolicyLocked(alert, at)
+	if !ok {
+		plan := NotificationRoutePlan{Phase: "route", Deliveries: []NotificationPlannedDelivery{}}
+		ids := make([]string, 0, len(r.targets))
+		for id, t := range r.targets {
+			if t.Enabled && (t.Route == "*" || t.Route == alert.Route) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			plan.Deliveries = append(plan.Deliveries, NotificationPlannedDelivery{
+				TargetID:   id,
+				Phase:      "route",
+				Suppressed: r.targets[id].QuietHours.holdsBack(alert.Severity, at),
+			})
+		}
+		return plan
+	}
+	plan := NotificationRoutePlan{
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		Phase:      phase,
+		Deliveries: []NotificationPlannedDelivery{},
+	}
+	add := func(ids []string, phase string, after int) {
+		for _, id := range ids {
+			t, ok := r.targets[id]
+			if !ok || !t.Enabled {
+				continue
+			}
+			plan.Deliveries = append(plan.Deliveries, NotificationPlannedDelivery{
+				TargetID:     id,
+				Phase:        phase,
+				AfterSeconds: after,
+				Suppressed:   t.QuietHours.holdsBack(alert.Severity, at.Add(time.Duration(after)*time.Second)),
+			})
+		}
+	}
+	add(policy.targetsFor(phase), phase, 0)
+	for _, step := range policy.Escalation {
+		add(step.Targets, "escalation", step.AfterSeconds)
+	}
+	return plan
+}
+
+// Escalations lists alerts that still have escalation steps ahead of them.
+func (r *NotificationRouter) Escalations() []NotificationEscalation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]NotificationEscalation, 0, len(r.escalations))
+	for _, esc := range r.escalations {
+		item := *esc
+		if p, ok := r.policies[esc.PolicyID]; ok && esc.NextStep < len(p.Escalation) {
+			item.NextStepAt = esc.StartedAt.Add(time.Duration(p.Escalation[esc.NextStep].AfterSeconds) * time.Second)
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AlertID < out[j].AlertID })
+	return out
+}
+
+// RunEscalations delivers every escalation step that has come due by now.
+// active reports whether an alert still warrants escalation; chains for
+// alerts that were acknowledged, resolved or forgotten are dropped.
+func (r *NotificationRouter) RunEscalations(now time.Time, active func(alertID string) bool) []NotificationDelivery {
+	pending := r.Escalations()
+	deliveries := make([]NotificationDelivery, 0)
+	for _, snap := range pending {
+		if !active(snap.AlertID) {
+			r.mu.Lock()
+			delete(r.escalations, snap.AlertID)
+			r.mu.Unlock()
+			continue
+		}
+		r.mu.Lock()
+		esc, ok := r.escalations[snap.AlertID]
+		if !ok || esc.NextStep != snap.NextStep {
+			r.mu.Unlock()
+			continue
+		}
+		policy, ok := r.policies[esc.PolicyID]
+		if !ok || !policy.Enabled {
+			delete(r.escalations, esc.AlertID)
+			r.mu.Unlock()
+			continue
+		}
+		var targets []string
+		for esc.NextStep < len(policy.Escalation) {
+			step := policy.Escalation[esc.NextStep]
+			if now.Before(esc.StartedAt.Add(time.Duration(step.AfterSeconds) * time.Second)) {
+				break
+			}
+			targets = append(targets, step.Targets...)
+			esc.NextStep++
+		}
+		if esc.NextStep >= len(policy.Escalation) {
+			delete(r.escalations, esc.AlertID)
+		}
+		alert, policyID := esc.alert, esc.PolicyID
+		r.mu.Unlock()
+		if len(targets) > 0 {
+			deliveries = append(deliveries, r.deliverTo(alert, dedupeStrings(targets), policyID, "escalation", now)...)
+		}
+	}
+	return deliveries
+}
+
+func (r *NotificationRouter) armEscalationLocked(alert AlertItem, policy NotificationPolicy, now time.Time) {
+	if len(policy.Escalation) == 0 || alert.ID == "" {
+		return
+	}
+	if _, ok := r.escalations[alert.ID]; ok {
+		return
+	}
+	r.escalations[alert.ID] = &NotificationEscalation{
+		AlertID:   alert.ID,
+		PolicyID:  policy.ID,
+		StartedAt: now,
+		alert:     cloneAlert(alert),
+	}
+}
+
+func (r *NotificationRouter) matchPolicyLocked(alert AlertItem, at time.Time) (NotificationPolicy, string, bool) {
+	for _, p := range r.sortedPoliciesLocked() {
+		if p.matches(alert) {
+			return p, p.phaseAt(at), true
+		}
+	}
+	return NotificationPolicy{}, "", false
+}
+
+func (r *NotificationRouter) sortedPoliciesLocked() []NotificationPolicy {
+	out := make([]NotificationPolicy, 0, len(r.policies))
+	for _, p := range r.policies {
+		out = append(out, cloneNotificationPolicy(*p))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority > out[j].Priority
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func (r *NotificationRouter) validatePolicyLocked(in *NotificationPolicy) error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return errors.New("notification policy name is required")
+	}
+	in.EventTypes = dedupeStrings(in.EventTypes)
+	in.Workloads = normalizeStringList(in.Workloads)
+	severities, err := normalizeNotificationSeverities(in.Severities)
+	if err != nil {
+		return err
+	}
+	in.Severities = severities
+	in.Timezone = strings.TrimSpace(in.Timezone)
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(in.Timezone); err != nil {
+		return errors.New("unknown timezone: " + in.Timezone)
+	}
+	if bh := in.BusinessHours; bh != nil {
+		if err := checkNotificationWindow(bh.Start, bh.End); err != nil {
+			return errors.New("business_hours: " + err.Error())
+		}
+		if len(bh.Days) == 0 {
+			bh.Days = []string{"mon", "tue", "wed", "thu", "fri"}
+		}
+		days, err := normalizeNotificationDays(bh.Days)
+		if err != nil {
+			return errors.New("business_hours: " + err.Error())
+		}
+		bh.Days = days
+	}
+	in.Targets = dedupeStrings(in.Targets)
+	in.OnCallTargets = dedupeStrings(in.OnCallTargets)
+	if len(in.Targets) == 0 {
+		return errors.New("notification policy requires at least one target")
+	}
+	if len(in.OnCallTargets) > 0 && in.BusinessHours == nil {
+		return errors.New("on_call_targets require business_hours")
+	}
+	if len(in.Escalation) > maxNotificationEscalationSteps {
+		return errors.New("escalation supports at most " + itoa(maxNotificationEscalationSteps) + " steps")
+	}
+	refs := append(append([]string{}, in.Targets...), in.OnCallTargets...)
+	last := 0
+	for i := range in.Escalation {
+		step := &in.Escalation[i]
+		if step.AfterSeconds <= last {
+			return errors.New("escalation after_seconds must be positive and increasing")
+		}
+		last = step.AfterSeconds
+		step.Targets = dedupeStrings(step.Targets)
+		if len(step.Targets) == 0 {
+			return errors.New("escalation step requires at least one target")
+		}
+		refs = append(refs, step.Targets...)
+	}
+	for _, id := range refs {
+		if _, ok := r.targets[id]; !ok {
+			return errors.New("notification target not found: " + id)
+		}
+	}
+	return nil
+}
+
+func (p NotificationPolicy) matches(alert AlertItem) bool {
+	if !p.Enabled {
+		return false
+	}
+	if len(p.EventTypes) > 0 {
+		matched := false
+		for _, prefix := range p.EventTypes {
+			if prefix == "*" || strings.HasPrefix(alert.EventType, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(p.Severities) > 0 && !containsString(p.Severities, normalizeSeverity(alert.Severity)) {
+		return false
+	}
+	if len(p.Workloads) > 0 && !containsString(p.Workloads, alertWorkload(alert.Fields)) {
+		return false
+	}
+	return true
+}
+
+func (p NotificationPolicy) phaseAt(at time.Time) string {
+	if p.BusinessHours == nil {
+		return "business_hours"
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	bh := p.BusinessHours
+	if dailyWindowActive(at, loc, bh.Start, bh.End, bh.Days) {
+		return "business_hours"
+	}
+	return "on_call"
+}
+
+func (p NotificationPolicy) targetsFor(phase string) []string {
+	if phase == "on_call" && len(p.OnCallTargets) > 0 {
+		return p.OnCallTargets
+	}
+	return p.Targets
+}
+
+// holdsBack reports whether quiet hours keep an alert of severity from the
+// target at the given time. A nil receiver never holds anything back.
+func (q *NotificationQuietHours) holdsBack(severity string, at time.Time) bool {
+	if q == nil {
+		return false
+	}
+	if containsString(q.BypassSeverities, normalizeSeverity(severity)) {
+		return false
+	}
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return dailyWindowActive(at, loc, q.Start, q.End, q.Days)
+}
+
+func normalizeNotificationQuietHours(q *NotificationQuietHours) error {
+	if err := checkNotificationWindow(q.Start, q.End); err != nil {
+		return errors.New("quiet_hours: " + err.Error())
+	}
+	q.Start, q.End = strings.TrimSpace(q.Start), strings.TrimSpace(q.End)
+	q.Timezone = strings.TrimSpace(q.Timezone)
+	if q.Timezone == "" {
+		q.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return errors.New("quiet_hours: unknown timezone: " + q.Timezone)
+	}
+	days, err := normalizeNotificationDays(q.Days)
+	if err != nil {
+		return errors.New("quiet_hours: " + err.Error())
+	}
+	q.Days = days
+	severities, err := normalizeNotificationSeverities(q.BypassSeverities)
+	if err != nil {
+		return errors.New("quiet_hours: " + err.Error())
+	}
+	q.BypassSeverities = severities
+	return nil
+}
+
+func checkNotificationWindow(start, end string) error {
+	s, err := parseWindowClock(start)
+	if err != nil {
+		return errors.New("invalid start: " + err.Error())
+	}
+	e, err := parseWindowClock(end)
+	if err != nil {
+		return errors.New("invalid end: " + err.Error())
+	}
+	if s == e {
+		return errors.New("start and end must differ")
+	}
+	return nil
+}
+
+// dailyWindowActive reports whether at falls inside the start–end window in
+// loc on one of days. The part of an overnight window after midnight counts
+// towards the previous day.
+func dailyWindowActive(at time.Time, loc *time.Location, start, end string, days []string) bool {
+	s, _ := parseWindowClock(start)
+	e, _ := parseWindowClock(end)
+	local := at.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	inside := s <= minute && minute < e
+	if s > e {
+		inside = minute >= s || minute < e
+		if minute < e {
+			day = (day + 6) % 7
+		}
+	}
+	if !inside {
+		return false
+	}
+	return len(days) == 0 || containsString(days, weekdayAbbrev(day))
+}
+
+var notificationWeekdays = map[string]string{
+	"mon": "mon", "monday": "mon",
+	"tue": "tue", "tuesday": "tue",
+	"wed": "wed", "wednesday": "wed",
+	"thu": "thu", "thursday": "thu",
+	"fri": "fri", "friday": "fri",
+	"sat": "sat", "saturday": "sat",
+	"sun": "sun", "sunday": "sun",
+}
+
+func normalizeNotificationDays(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	seen := map[string]bool{}
+	out := make([]string, 0, len(in))
+	for _, raw := range in {
+		day, ok := notificationWeekdays[strings.ToLower(strings.TrimSpace(raw))]
+		if !ok {
+			return nil, errors.New("unknown day: " + raw)
+		}
+		if !seen[day] {
+			seen[day] = true
+			out = append(out, day)
+		}
+	}
+	return out, nil
+}
+
+func weekdayAbbrev(day time.Weekday) string {
+	return strings.ToLower(day.String()[:3])
+}
+
+func normalizeNotificationSeverities(in []string) ([]string, error) {
+	out := normalizeStringList(in)
+	for _, severity := range out {
+		switch severity {
+		case "critical", "high", "medium", "low":
+		default:
+			return nil, errors.New("severity must be critical, high, medium, or low")
+		}
+	}
+	return out, nil
+}
+
+// alertWorkload names the workload an alert belongs to, from the same
+// fields the workload views use.
+func alertWorkload(fields map[string]any) string {
+	for _, key := range []string{"workload", "service", "application", "app"} {
+		if v, ok := fields[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.ToLower(strings.TrimSpace(v))
+		}
+	}
+	return ""
+}
+
+func cloneNotificationPolicy(in NotificationPolicy) NotificationPolicy {
+	in.EventTypes = append([]string(nil), in.EventTypes...)
+	in.Severities = append([]string(nil), in.Severities...)
+	in.Workloads = append([]string(nil), in.Workloads...)
+	in.Targets = append([]string(nil), in.Targets...)
+	in.OnCallTargets = append([]string(nil), in.OnCallTargets...)
+	if in.BusinessHours != nil {
+		bh := *in.BusinessHours
+		bh.Days = append([]string(nil), bh.Days...)
+		in.BusinessHours = &bh
+	}
+	if len(in.Escalation) > 0 {
+		steps := make([]NotificationEscalationStep, len(in.Escalation))
+		for i, step := range in.Escalation {
+			step.Targets = append([]string(nil), step.Targets...)
+			steps[i] = step
+		}
+		in.Escalation = steps
+	}
+	return in
+}
@@ -0,0 +1,171 @@
+package control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotificationPoliciesRouteByTimeAndEscalate(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		hits = map[string]int{}
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path+"|"+r.Header.Get("X-Masterchef-Notification-Phase")]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	router := NewNotificationRouter(100)
+	register := func(name string) NotificationTarget {
+		t.Helper()
+		target, err := router.Register(NotificationTarget{Name: name, Kind: "incident", URL: receiver.URL + "/" + name, Route: "digest"})
+		if err != nil {
+			t.Fatalf("register %s failed: %v", name, err)
+		}
+		return target
+	}
+	team := register("team")
+	oncall := register("oncall")
+	manager := register("manager")
+
+	if _, err := router.CreatePolicy(NotificationPolicy{Name: "bad", Targets: []string{"notify-missing"}}); err == nil {
+		t.Fatalf("expected unknown target to be rejected")
+	}
+	if _, err := router.CreatePolicy(NotificationPolicy{Name: "bad", Targets: []string{team.ID}, OnCallTargets: []string{oncall.ID}}); err == nil {
+		t.Fatalf("expected on_call_targets without business_hours to be rejected")
+	}
+	if _, err := router.CreatePolicy(NotificationPolicy{Name: "bad", Targets: []string{team.ID}, Escalation: []NotificationEscalationStep{
+		{AfterSeconds: 600, Targets: []string{manager.ID}},
+		{AfterSeconds: 300, Targets: []string{manager.ID}},
+	}}); err == nil {
+		t.Fatalf("expected decreasing escalation steps to be rejected")
+	}
+
+	policy, err := router.CreatePolicy(NotificationPolicy{
+		Name:          "payments",
+		Enabled:       true,
+		EventTypes:    []string{"external.alert"},
+		Severities:    []string{"critical", "high"},
+		Workloads:     []string{"Payments"},
+		BusinessHours: &NotificationBusinessHours{Start: "09:00", End: "17:00"},
+		Targets:       []string{team.ID},
+		OnCallTargets: []string{oncall.ID},
+		Escalation:    []NotificationEscalationStep{{AfterSeconds: 300, Targets: []string{manager.ID}}},
+	})
+	if err != nil {
+		t.Fatalf("create policy failed: %v", err)
+	}
+	if len(policy.BusinessHours.Days) != 5 || policy.Workloads[0] != "payments" {
+		t.Fatalf("expected normalized policy, got %+v", policy)
+	}
+
+	alert := AlertItem{ID: "alert-1", EventType: "external.alert.db", Severity: "critical", Route: "pager", Fields: map[string]any{"service": "payments"}}
+	tuesdayNoon := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	plan := router.Plan(alert, tuesdayNoon)
+	if plan.PolicyID != policy.ID || plan.Phase != "business_hours" || len(plan.Deliveries) != 2 || plan.Deliveries[0].TargetID != team.ID {
+		t.Fatalf("unexpected business hours plan: %+v", plan)
+	}
+	if plan = router.Plan(alert, tuesdayNoon.Add(10*time.Hour)); plan.Phase != "on_call" || plan.Deliveries[0].TargetID != oncall.ID {
+		t.Fatalf("unexpected on-call plan: %+v", plan)
+	}
+	if plan = router.Plan(alert, time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)); plan.Phase != "on_call" {
+		t.Fatalf("expected saturday to be on-call, got %+v", plan)
+	}
+	other := alert
+	other.Fields = map[string]any{"service": "search"}
+	if plan = router.Plan(other, tuesdayNoon); plan.PolicyID != "" || plan.Phase != "route" || len(plan.Deliveries) != 0 {
+		t.Fatalf("expected unmatched workload to fall back to route matching, got %+v", plan)
+	}
+
+	dels := router.NotifyAlert(alert)
+	if len(dels) != 1 || dels[0].PolicyID != policy.ID || dels[0].Status != "delivered" {
+		t.Fatalf("expected one policy delivery, got %+v", dels)
+	}
+	router.NotifyAlert(alert)
+	escalations := router.Escalations()
+	if len(escalations) != 1 || escalations[0].AlertID != "alert-1" || escalations[0].NextStepAt.IsZero() {
+		t.Fatalf("expected one armed escalation, got %+v", escalations)
+	}
+	started := escalations[0].StartedAt
+
+	open := func(string) bool { return true }
+	if dels = router.RunEscalations(started.Add(time.Minute), open); len(dels) != 0 {
+		t.Fatalf("expected no escalation before the step is due, got %+v", dels)
+	}
+	dels = router.RunEscalations(started.Add(5*time.Minute), open)
+	if len(dels) != 1 || dels[0].TargetID != manager.ID || dels[0].Phase != "escalation" {
+		t.Fatalf("expected escalation to manager, got %+v", dels)
+	}
+	if len(router.Escalations()) != 0 {
+		t.Fatalf("expected finished chain to be dropped")
+	}
+
+	second := alert
+	second.ID = "alert-2"
+	router.NotifyAlert(second)
+	if dels = router.RunEscalations(time.Now().Add(time.Hour), func(string) bool { return false }); len(dels) != 0 {
+		t.Fatalf("expected acknowledged alert not to escalate, got %+v", dels)
+	}
+	if len(router.Escalations()) != 0 {
+		t.Fatalf("expected chain of inactive alert to be dropped")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hits["/manager|escalation"] != 1 {
+		t.Fatalf("expected one escalation hit on manager, got %+v", hits)
+	}
+}
+
+func TestNotificationTargetQuietHours(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	router := NewNotificationRouter(100)
+	if _, err := router.Register(NotificationTarget{Name: "bad", Kind: "chatops", URL: receiver.URL, Route: "*", QuietHours: &NotificationQuietHours{Start: "22:00", End: "22:00"}}); err == nil {
+		t.Fatalf("expected empty quiet hours window to be rejected")
+	}
+	if _, err := router.Register(NotificationTarget{Name: "bad", Kind: "chatops", URL: receiver.URL, Route: "*", QuietHours: &NotificationQuietHours{Start: "22:00", End: "07:00", Days: []string{"someday"}}}); err == nil {
+		t.Fatalf("expected unknown day to be rejected")
+	}
+	target, err := router.Register(NotificationTarget{
+		Name:  "chat",
+		Kind:  "chatops",
+		URL:   receiver.URL,
+		Route: "*",
+		QuietHours: &NotificationQuietHours{
+			Start:            "22:00",
+			End:              "07:00",
+			Days:             []string{"Friday"},
+			BypassSeverities: []string{"critical"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	q := target.QuietHours
+	fridayNight := time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC)
+	if !q.holdsBack("high", fridayNight) || !q.holdsBack("high", fridayNight.Add(4*time.Hour)) {
+		t.Fatalf("expected friday night and the following early morning to be quiet")
+	}
+	if q.holdsBack("critical", fridayNight) {
+		t.Fatalf("expected critical alerts to bypass quiet hours")
+	}
+	if q.holdsBack("high", fridayNight.Add(-24*time.Hour)) || q.holdsBack("high", fridayNight.Add(-12*time.Hour)) {
+		t.Fatalf("expected thursday night and friday midday not to be quiet")
+	}
+
+	plan := router.Plan(AlertItem{ID: "a", Severity: "high", Route: "ticket"}, fridayNight)
+	if len(plan.Deliveries) != 1 || !plan.Deliveries[0].Suppressed {
+		t.Fatalf("expected quiet hours to show in plan, got %+v", plan)
+	}
+}
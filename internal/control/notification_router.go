@@ -2,7 +2,6 @@ package control
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"sort"
@@ -12,18 +11,21 @@ import (
 )
 
 type NotificationTarget struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	Kind         string    `json:"kind"` // chatops|incident|ticket
-	URL          string    `json:"url"`
-	Route        string    `json:"route"` // pager|ticket|chatops|digest|*
-	Enabled      bool      `json:"enabled"`
-	SuccessCount int64     `json:"success_count"`
-	FailureCount int64     `json:"failure_count"`
-	LastError    string    `json:"last_error,omitempty"`
-	LastDelivery time.Time `json:"last_delivery,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              string                  `json:"id"`
+	Name            string                  `json:"name"`
+	Kind            string                  `json:"kind"` // chatops|incident|ticket|security
+	URL             string                  `json:"url"`
+	Route           string                  `json:"route"` // pager|ticket|chatops|digest|*
+	Enabled         bool                    `json:"enabled"`
+	PayloadTemplate string                  `json:"payload_template,omitempty"`
+	ContentType     string                  `json:"content_type,omitempty"`
+	QuietHours      *NotificationQuietHours `json:"quiet_hours,omitempty"`
+	SuccessCount    int64                   `json:"success_count"`
+	FailureCount    int64                   `json:"failure_count"`
+	LastError       string                  `json:"last_error,omitempty"`
+	LastDelivery    time.Time               `json:"last_delivery,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
 }
 
 type NotificationDelivery struct {
@@ -31,20 +33,25 @@ type NotificationDelivery struct {
 	TargetID    string    `json:"target_id"`
 	AlertID     string    `json:"alert_id"`
 	AlertRoute  string    `json:"alert_route"`
-	Status      string    `json:"status"` // delivered|failed
+	PolicyID    string    `json:"policy_id,omitempty"`
+	Phase       string    `json:"phase,omitempty"` // business_hours|on_call|escalation
+	Status      string    `json:"status"`          // delivered|failed|suppressed
 	StatusCode  int       `json:"status_code,omitempty"`
 	Error       string    `json:"error,omitempty"`
 	DeliveredAt time.Time `json:"delivered_at"`
 }
 
 type NotificationRouter struct {
-	mu          sync.RWMutex
-	nextID      int64
-	nextDelID   int64
-	targets     map[string]*NotificationTarget
-	deliveries  []NotificationDelivery
-	deliveryCap int
-	client      *http.Client
+	mu           sync.RWMutex
+	nextID       int64
+	nextDelID    int64
+	nextPolicyID int64
+	targets      map[string]*NotificationTarget
+	policies     map[string]*NotificationPolicy
+	escalations  map[string]*NotificationEscalation
+	deliveries   []NotificationDelivery
+	deliveryCap  int
+	client       *http.Client
 }
 
 func NewNotificationRouter(limit int) *NotificationRouter {
@@ -53,6 +60,8 @@ func NewNotificationRouter(limit int) *NotificationRouter {
 	}
 	return &NotificationRouter{
 		targets:     map[string]*NotificationTarget{},
+		policies:    map[string]*NotificationPolicy{},
+		escalations: map[string]*NotificationEscalation{},
 		deliveries:  make([]NotificationDelivery, 0, limit),
 		deliveryCap: limit,
 		client: &http.Client{
@@ -62,23 +71,8 @@ func NewNotificationRouter(limit int) *NotificationRouter {
 }
 
 func (r *NotificationRouter) Register(in NotificationTarget) (NotificationTarget, error) {
-	if strings.TrimSpace(in.Name) == "" {
-		return NotificationTarget{}, errors.New("notification target name is required")
-	}
-	if strings.TrimSpace(in.URL) == "" {
-		return NotificationTarget{}, errors.New("notification target url is required")
-	}
-	url := strings.ToLower(strings.TrimSpace(in.URL))
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		return NotificationTarget{}, errors.New("notification target url must be http or https")
-	}
-	kind := normalizeNotificationKind(in.Kind)
-	if kind == "" {
-		return NotificationTarget{}, errors.New("notification kind must be chatops, incident, or ticket")
-	}
-	route := normalizeNotificationRoute(in.Route)
-	if route == "" {
-		return NotificationTarget{}, errors.New("notification route must be pager, ticket, chatops, digest, or *")
+	if err := validateNotificationTarget(&in); err != nil {
+		return NotificationTarget{}, err
 	}
 
 	r.mu.Lock()
@@ -86,8 +80,6 @@ func (r *NotificationRouter) Register(in NotificationTarget) (NotificationTarget
 	r.nextID++
 	now := time.Now().UTC()
 	in.ID = "notify-" + itoa(r.nextID)
-	in.Kind = kind
-	in.Route = route
 	if !in.Enabled {
 		in.Enabled = true
 	}
@@ -109,6 +101,77 @@ func (r *NotificationRouter) List() []NotificationTarget {
 	return out
 }
 
+func (r *NotificationRouter) Get(id string) (NotificationTarget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.targets[id]
+	if !ok {
+		return NotificationTarget{}, errors.New("notification target not found")
+	}
+	return cloneNotificationTarget(*t), nil
+}
+
+// Update replaces the definition of a notification target, keeping its
+// delivery counters.
+func (r *NotificationRouter) Update(id string, in NotificationTarget) (NotificationTarget, error) {
+	if err := validateNotificationTarget(&in); err != nil {
+		return NotificationTarget{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.targets[id]
+	if !ok {
+		return NotificationTarget{}, errors.New("notification target not found")
+	}
+	in.ID = cur.ID
+	in.SuccessCount = cur.SuccessCount
+	in.FailureCount = cur.FailureCount
+	in.LastError = cur.LastError
+	in.LastDelivery = cur.LastDelivery
+	in.CreatedAt = cur.CreatedAt
+	in.UpdatedAt = time.Now().UTC()
+	cp := cloneNotificationTarget(in)
+	r.targets[id] = &cp
+	return cloneNotificationTarget(cp), nil
+}
+
+func validateNotificationTarget(in *NotificationTarget) error {
+	if strings.TrimSpace(in.Name) == "" {
+		return errors.New("notification target name is required")
+	}
+	if strings.TrimSpace(in.URL) == "" {
+		return errors.New("notification target url is required")
+	}
+	url := strings.ToLower(strings.TrimSpace(in.URL))
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return errors.New("notification target url must be http or https")
+	}
+	kind := normalizeNotificationKind(in.Kind)
+	if kind == "" {
+		return errors.New("notification kind must be chatops, incident, ticket, or security")
+	}
+	route := normalizeNotificationRoute(in.Route)
+	if route == "" {
+		return errors.New("notification route must be pager, ticket, chatops, digest, security, or *")
+	}
+	if err := ValidatePayloadTemplate(in.PayloadTemplate); err != nil {
+		return err
+	}
+	contentType, err := normalizePayloadContentType(in.ContentType)
+	if err != nil {
+		return err
+	}
+	if in.QuietHours != nil {
+		if err := normalizeNotificationQuietHours(in.QuietHours); err != nil {
+			return err
+		}
+	}
+	in.Kind = kind
+	in.Route = route
+	in.ContentType = contentType
+	return nil
+}
+
 func (r *NotificationRouter) SetEnabled(id string, enabled bool) (NotificationTarget, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -121,7 +184,34 @@ func (r *NotificationRouter) SetEnabled(id string, enabled bool) (NotificationTa
 	return cloneNotificationTarget(*t), nil
 }
 
+// NotifyAlert routes alert through the first matching routing policy, or
+// by route when no policy matches. A policy with an escalation chain arms
+// it the first time the alert is seen; RunEscalations walks it from there.
 func (r *NotificationRouter) NotifyAlert(alert AlertItem) []NotificationDelivery {
+	now := time.Now().UTC()
+	r.mu.Lock()
+	policy, phase, ok := r.matchPolicyLocked(alert, now)
+	if ok {
+		r.armEscalationLocked(alert, policy, now)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return r.deliver(alert, func(target NotificationTarget) bool {
+			return target.Route == "*" || target.Route == alert.Route
+		})
+	}
+	return r.deliverTo(alert, policy.targetsFor(phase), policy.ID, phase, now)
+}
+
+// NotifySecurity delivers alert to every enabled security target, whatever
+// route the target is registered for.
+func (r *NotificationRouter) NotifySecurity(alert AlertItem) []NotificationDelivery {
+	return r.deliver(alert, func(target NotificationTarget) bool {
+		return target.Kind == "security"
+	})
+}
+
+func (r *NotificationRouter) deliver(alert AlertItem, match func(NotificationTarget) bool) []NotificationDelivery {
 	r.mu.RLock()
 	targets := make([]NotificationTarget, 0, len(r.targets))
 	for _, t := range r.targets {
@@ -129,42 +219,81 @@ func (r *NotificationRouter) NotifyAlert(alert AlertItem) []NotificationDelivery
 	}
 	r.mu.RUnlock()
 
-	payload, _ := json.Marshal(map[string]any{
-		"type":  "alert.notification",
-		"alert": alert,
-	})
+	now := time.Now().UTC()
 	deliveries := make([]NotificationDelivery, 0)
 	for _, target := range targets {
-		if !target.Enabled {
+		if !target.Enabled || !match(target) {
 			continue
 		}
-		if target.Route != "*" && target.Route != alert.Route {
-			continue
-		}
-		req, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(payload))
-		if err != nil {
-			deliveries = append(deliveries, r.recordDelivery(target.ID, alert.ID, alert.Route, 0, err))
-			continue
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Masterchef-Notification-Kind", target.Kind)
-		req.Header.Set("X-Masterchef-Alert-Route", alert.Route)
+		deliveries = append(deliveries, r.send(target, alert, "", "", now))
+	}
+	return deliveries
+}
 
-		resp, err := r.client.Do(req)
-		if err != nil {
-			deliveries = append(deliveries, r.recordDelivery(target.ID, alert.ID, alert.Route, 0, err))
-			continue
-		}
-		_ = resp.Body.Close()
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			deliveries = append(deliveries, r.recordDelivery(target.ID, alert.ID, alert.Route, resp.StatusCode, errors.New("non-2xx status")))
-			continue
+// deliverTo sends alert to the named targets in order, skipping unknown or
+// disabled ones.
+func (r *NotificationRouter) deliverTo(alert AlertItem, ids []string, policyID, phase string, now time.Time) []NotificationDelivery {
+	r.mu.RLock()
+	targets := make([]NotificationTarget, 0, len(ids))
+	for _, id := range ids {
+		if t, ok := r.targets[id]; ok && t.Enabled {
+			targets = append(targets, cloneNotificationTarget(*t))
 		}
-		deliveries = append(deliveries, r.recordDelivery(target.ID, alert.ID, alert.Route, resp.StatusCode, nil))
+	}
+	r.mu.RUnlock()
+
+	deliveries := make([]NotificationDelivery, 0, len(targets))
+	for _, target := range targets {
+		deliveries = append(deliveries, r.send(target, alert, policyID, phase, now))
 	}
 	return deliveries
 }
 
+func (r *NotificationRouter) send(target NotificationTarget, alert AlertItem, policyID, phase string, now time.Time) NotificationDelivery {
+	d := NotificationDelivery{
+		TargetID:   target.ID,
+		AlertID:    alert.ID,
+		AlertRoute: alert.Route,
+		PolicyID:   policyID,
+		Phase:      phase,
+	}
+	if target.QuietHours.holdsBack(alert.Severity, now) {
+		d.Status = "suppressed"
+		d.Error = "target quiet hours"
+		return r.recordDelivery(d, nil)
+	}
+	payload, err := RenderNotificationPayload(target, alert)
+	if err != nil {
+		return r.recordDelivery(d, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(payload))
+	if err != nil {
+		return r.recordDelivery(d, err)
+	}
+	contentType := target.ContentType
+	if contentType == "" {
+		contentType = PayloadContentTypeJSON
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Masterchef-Notification-Kind", target.Kind)
+	req.Header.Set("X-Masterchef-Alert-Route", alert.Route)
+	if policyID != "" {
+		req.Header.Set("X-Masterchef-Notification-Policy", policyID)
+		req.Header.Set("X-Masterchef-Notification-Phase", phase)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return r.recordDelivery(d, err)
+	}
+	_ = resp.Body.Close()
+	d.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return r.recordDelivery(d, errors.New("non-2xx status"))
+	}
+	return r.recordDelivery(d, nil)
+}
+
 func (r *NotificationRouter) Deliveries(limit int) []NotificationDelivery {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -183,31 +312,29 @@ func (r *NotificationRouter) Deliveries(limit int) []NotificationDelivery {
 	return out
 }
 
-func (r *NotificationRouter) recordDelivery(targetID, alertID, alertRoute string, statusCode int, err error) NotificationDelivery {
+// recordDelivery stores d with its outcome. A suppressed delivery is kept
+// for the audit trail but leaves the target counters alone.
+func (r *NotificationRouter) recordDelivery(d NotificationDelivery, err error) NotificationDelivery {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.nextDelID++
 	now := time.Now().UTC()
-	d := NotificationDelivery{
-		ID:          "notify-del-" + itoa(r.nextDelID),
-		TargetID:    targetID,
-		AlertID:     alertID,
-		AlertRoute:  alertRoute,
-		StatusCode:  statusCode,
-		DeliveredAt: now,
-	}
-	if err != nil {
+	d.ID = "notify-del-" + itoa(r.nextDelID)
+	d.DeliveredAt = now
+	switch {
+	case d.Status == "suppressed":
+	case err != nil:
 		d.Status = "failed"
 		d.Error = err.Error()
-		if t, ok := r.targets[targetID]; ok {
+		if t, ok := r.targets[d.TargetID]; ok {
 			t.FailureCount++
 			t.LastError = err.Error()
 			t.LastDelivery = now
 			t.UpdatedAt = now
 		}
-	} else {
+	default:
 		d.Status = "delivered"
-		if t, ok := r.targets[targetID]; ok {
+		if t, ok := r.targets[d.TargetID]; ok {
 			t.SuccessCount++
 			t.LastError = ""
 			t.LastDelivery = now
@@ -223,6 +350,15 @@ func (r *NotificationRouter) recordDelivery(targetID, alertID, alertRoute string
 	return d
 }
 
+// RenderNotificationPayload builds the request body a target would receive
+// for alert, applying the target payload template when present.
+func RenderNotificationPayload(target NotificationTarget, alert AlertItem) ([]byte, error) {
+	return RenderPayloadTemplate(target.PayloadTemplate, map[string]any{
+		"type":  "alert.notification",
+		"alert": alert,
+	})
+}
+
 func normalizeNotificationKind(kind string) string {
 	switch strings.ToLower(strings.TrimSpace(kind)) {
 	case "chatops":
@@ -231,6 +367,8 @@ func normalizeNotificationKind(kind string) string {
 		return "incident"
 	case "ticket":
 		return "ticket"
+	case "security":
+		return "security"
 	default:
 		return ""
 	}
@@ -248,11 +386,19 @@ func normalizeNotificationRoute(route string) string {
 		return "chatops"
 	case "digest":
 		return "digest"
+	case "security":
+		return "security"
 	default:
 		return ""
 	}
 }
 
 func cloneNotificationTarget(in NotificationTarget) NotificationTarget {
+	if in.QuietHours != nil {
+		qh := *in.QuietHours
+		qh.Days = append([]string(nil), qh.Days...)
+		qh.BypassSeverities = append([]string(nil), qh.BypassSeverities...)
+		in.QuietHours = &qh
+	}
 	return in
 }
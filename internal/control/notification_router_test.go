@@ -1,6 +1,7 @@
 package control
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -60,3 +61,56 @@ func TestNotificationRouterDispatchByRoute(t *testing.T) {
 		t.Fatalf("expected no delivery for disabled target")
 	}
 }
+
+func TestNotificationRouterPayloadTemplate(t *testing.T) {
+	bodies := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != PayloadContentTypeText {
+			t.Errorf("expected text content type, got %q", r.Header.Get("Content-Type"))
+		}
+		bodies <- string(raw)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	router := NewNotificationRouter(100)
+	if _, err := router.Register(NotificationTarget{
+		Name:            "pager-text",
+		Kind:            "incident",
+		URL:             receiver.URL,
+		Route:           "pager",
+		ContentType:     "text/plain",
+		PayloadTemplate: `[{{upper .alert.severity}}] {{.alert.message}} ({{default "n/a" .alert.fields.host}})`,
+	}); err != nil {
+		t.Fatalf("register target failed: %v", err)
+	}
+	del := router.NotifyAlert(AlertItem{ID: "alert-1", Route: "pager", Severity: "high", Message: "disk full", Fields: map[string]any{"host": ""}})
+	if len(del) != 1 || del[0].Status != "delivered" {
+		t.Fatalf("expected one delivered notification, got %+v", del)
+	}
+	if body := <-bodies; body != "[HIGH] disk full (n/a)" {
+		t.Fatalf("unexpected rendered body %q", body)
+	}
+}
+
+func TestNotificationRouterNotifySecurity(t *testing.T) {
+	var hits atomic.Int64
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	router := NewNotificationRouter(100)
+	if _, err := router.Register(NotificationTarget{Name: "secops", Kind: "security", URL: receiver.URL, Route: "security"}); err != nil {
+		t.Fatalf("register security target failed: %v", err)
+	}
+	if _, err := router.Register(NotificationTarget{Name: "pager", Kind: "incident", URL: receiver.URL, Route: "security"}); err != nil {
+		t.Fatalf("register incident target failed: %v", err)
+	}
+	del := router.NotifySecurity(AlertItem{ID: "breakglass-1", Severity: "high"})
+	if len(del) != 1 || del[0].Status != "delivered" || hits.Load() != 1 {
+		t.Fatalf("expected delivery to security target only, got %+v hits=%d", del, hits.Load())
+	}
+}
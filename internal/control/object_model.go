@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sort"
 	"strings"
+	"sync"
 )
 
 type ObjectModelEntry struct {
@@ -18,6 +19,11 @@ type ObjectModelEntry struct {
 type ObjectModelRegistry struct {
 	entries []ObjectModelEntry
 	index   map[string]ObjectModelEntry
+
+	mu        sync.RWMutex
+	deps      map[string]map[string]string // node key -> dependency key -> relation
+	roots     map[string]bool              // configs indexed directly rather than only referenced
+	templates map[string]string            // template id -> config node key
 }
 
 func NewObjectModelRegistry() *ObjectModelRegistry {
@@ -30,6 +36,8 @@ func NewObjectModelRegistry() *ObjectModelRegistry {
 		{Canonical: "drift", CLI: "drift", API: "drift", UI: "Drift", Aliases: []string{"config-drift", "state-drift"}, Description: "Desired vs observed mismatch state."},
 		{Canonical: "host", CLI: "host", API: "host", UI: "Node", Aliases: []string{"node", "managed-host"}, Description: "Managed compute target."},
 		{Canonical: "module", CLI: "module", API: "module", UI: "Module", Aliases: []string{"cookbook", "collection", "package"}, Description: "Reusable content package for tasks/policies/providers."},
+		{Canonical: "template", CLI: "template", API: "template", UI: "Template", Aliases: []string{"job-template", "launch-template"}, Description: "Launchable config with defaults and survey."},
+		{Canonical: "data_bag", CLI: "data bag", API: "data_bag", UI: "Data Bag", Aliases: []string{"databag", "pillar"}, Description: "Shared structured data referenced by configs."},
 	}
 	sort.Slice(entries, func(i, j int) bool { return entries[i].Canonical < entries[j].Canonical })
 	index := map[string]ObjectModelEntry{}
@@ -43,8 +51,11 @@ func NewObjectModelRegistry() *ObjectModelRegistry {
 		}
 	}
 	return &ObjectModelRegistry{
-		entries: entries,
-		index:   index,
+		entries:   entries,
+		index:     index,
+		deps:      map[string]map[string]string{},
+		roots:     map[string]bool{},
+		templates: map[string]string{},
 	}
 }
 
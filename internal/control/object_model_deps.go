@@ -0,0 +1,310 @@
+package control
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// Object kinds tracked by the dependency graph. Modules are config files
+// pulled in through includes, imports, or overlays; they share a node with
+// the config of the same path.
+const (
+	ObjectKindConfig   = "config"
+	ObjectKindModule   = "module"
+	ObjectKindTemplate = "template"
+	ObjectKindDataBag  = "data_bag"
+)
+
+var (
+	dataBagCallPattern     = regexp.MustCompile(`data_bag(?:_item)?\(\s*["']([A-Za-z0-9_.-]+)["']`)
+	dataBagTemplatePattern = regexp.MustCompile(`\{\{\s*data_bag\.([A-Za-z0-9_-]+)`)
+)
+
+type ObjectDependencyNode struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type ObjectDependencyEdge struct {
+	From     ObjectDependencyNode `json:"from"`
+	To       ObjectDependencyNode `json:"to"`
+	Relation string               `json:"relation"` // include|import|overlay|data_bag|config
+}
+
+type ObjectDependencyGraph struct {
+	Nodes []ObjectDependencyNode `json:"nodes"`
+	Edges []ObjectDependencyEdge `json:"edges"`
+}
+
+type ObjectImpactReport struct {
+	Kind              string   `json:"kind"`
+	Name              string   `json:"name"`
+	Known             bool     `json:"known"`
+	AffectedConfigs   []string `json:"affected_configs"`
+	AffectedModules   []string `json:"affected_modules"`
+	AffectedTemplates []string `json:"affected_templates"`
+}
+
+// IndexConfig parses the config at path and every module it composes,
+// replacing their outgoing edges in the dependency graph. Referenced files
+// that cannot be parsed keep their incoming edge so the graph still answers
+// impact queries for them.
+func (r *ObjectModelRegistry) IndexConfig(path string) error {
+	path = filepath.Clean(strings.TrimSpace(path))
+	if path == "." {
+		return errors.New("config path is required")
+	}
+	if _, err := config.LoadRaw(path); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.indexConfigFile(path, map[string]bool{})
+	r.roots[objectDependencyKey(ObjectKindConfig, path)] = true
+	return nil
+}
+
+func (r *ObjectModelRegistry) indexConfigFile(path string, seen map[string]bool) {
+	key := objectDependencyKey(ObjectKindConfig, path)
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	cfg, err := config.LoadRaw(path)
+	if err != nil {
+		return
+	}
+	deps := map[string]string{}
+	baseDir := filepath.Dir(path)
+	var modules []string
+	for relation, refs := range map[string][]string{"include": cfg.Includes, "import": cfg.Imports, "overlay": cfg.Overlays} {
+		for _, ref := range refs {
+			ref = strings.TrimSpace(ref)
+			if ref == "" {
+				continue
+			}
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(baseDir, ref)
+			}
+			ref = filepath.Clean(ref)
+			deps[objectDependencyKey(ObjectKindConfig, ref)] = relation
+			modules = append(modules, ref)
+		}
+	}
+	for _, bag := range configDataBagReferences(cfg) {
+		deps[objectDependencyKey(ObjectKindDataBag, bag)] = "data_bag"
+	}
+	r.deps[key] = deps
+	for _, module := range modules {
+		r.indexConfigFile(module, seen)
+	}
+}
+
+// RegisterTemplate records that a template launches the config at
+// configPath and indexes that config.
+func (r *ObjectModelRegistry) RegisterTemplate(id, configPath string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return errors.New("template id is required")
+	}
+	if err := r.IndexConfig(configPath); err != nil {
+		return err
+	}
+	configKey := objectDependencyKey(ObjectKindConfig, filepath.Clean(strings.TrimSpace(configPath)))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[id] = configKey
+	r.deps[objectDependencyKey(ObjectKindTemplate, id)] = map[string]string{configKey: "config"}
+	return nil
+}
+
+func (r *ObjectModelRegistry) UnregisterTemplate(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.templates, id)
+	delete(r.deps, objectDependencyKey(ObjectKindTemplate, id))
+}
+
+func (r *ObjectModelRegistry) DependencyGraph() ObjectDependencyGraph {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := map[string]struct{}{}
+	out := ObjectDependencyGraph{Nodes: []ObjectDependencyNode{}, Edges: []ObjectDependencyEdge{}}
+	for from, deps := range r.deps {
+		nodes[from] = struct{}{}
+		for to, relation := range deps {
+			nodes[to] = struct{}{}
+			out.Edges = append(out.Edges, ObjectDependencyEdge{
+				From:     r.objectDependencyNode(from),
+				To:       r.objectDependencyNode(to),
+				Relation: relation,
+			})
+		}
+	}
+	for key := range nodes {
+		out.Nodes = append(out.Nodes, r.objectDependencyNode(key))
+	}
+	sort.Slice(out.Nodes, func(i, j int) bool {
+		if out.Nodes[i].Kind != out.Nodes[j].Kind {
+			return out.Nodes[i].Kind < out.Nodes[j].Kind
+		}
+		return out.Nodes[i].Name < out.Nodes[j].Name
+	})
+	sort.Slice(out.Edges, func(i, j int) bool {
+		a, b := out.Edges[i], out.Edges[j]
+		if a.From.Name != b.From.Name {
+			return a.From.Name < b.From.Name
+		}
+		return a.To.Name < b.To.Name
+	})
+	return out
+}
+
+// Impact reports every config, module, and template that transitively
+// depends on the named object and so needs re-testing or re-previewing when
+// it changes. A changed config counts as affected itself; a changed template
+// affects the config it launches.
+func (r *ObjectModelRegistry) Impact(kind, name string) (ObjectImpactReport, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ObjectImpactReport{}, errors.New("name is required")
+	}
+	switch kind {
+	case ObjectKindConfig, ObjectKindModule:
+		name = filepath.Clean(name)
+	case ObjectKindDataBag:
+		name = normalizeDataBagName(name)
+	case ObjectKindTemplate:
+	default:
+		return ObjectImpactReport{}, errors.New("kind must be config, module, template, or data_bag")
+	}
+	report := ObjectImpactReport{
+		Kind:              kind,
+		Name:              name,
+		AffectedConfigs:   []string{},
+		AffectedModules:   []string{},
+		AffectedTemplates: []string{},
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	start := objectDependencyKey(kind, name)
+	var distances map[string]int
+	distances, report.Known = r.impactDistancesLocked(kind, name)
+	for key := range distances {
+		node := r.objectDependencyNode(key)
+		switch {
+		case node.Kind == ObjectKindConfig:
+			report.AffectedConfigs = append(report.AffectedConfigs, node.Name)
+		case node.Kind == ObjectKindModule && key != start:
+			report.AffectedModules = append(report.AffectedModules, node.Name)
+		case node.Kind == ObjectKindTemplate:
+			report.AffectedTemplates = append(report.AffectedTemplates, node.Name)
+		}
+	}
+	sort.Strings(report.AffectedConfigs)
+	sort.Strings(report.AffectedModules)
+	sort.Strings(report.AffectedTemplates)
+	return report, nil
+}
+
+// impactDistances returns the config paths (roots and modules, including a
+// changed config itself) that depend on the named object, keyed by the
+// number of dependency edges between them. kind and name must already be
+// normalized.
+func (r *ObjectModelRegistry) impactDistances(kind, name string) map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	distances, _ := r.impactDistancesLocked(kind, name)
+	out := map[string]int{}
+	for key, hops := range distances {
+		if configKind, path, _ := strings.Cut(key, ":"); configKind == ObjectKindConfig {
+			out[path] = hops
+		}
+	}
+	return out
+}
+
+// impactDistancesLocked walks reverse dependency edges from the named object
+// and reports whether the graph knows about it. A template's launched config
+// counts as zero hops away.
+func (r *ObjectModelRegistry) impactDistancesLocked(kind, name string) (map[string]int, bool) {
+	start := objectDependencyKey(kind, name)
+	known := false
+	reverse := map[string][]string{}
+	for from, deps := range r.deps {
+		for to := range deps {
+			reverse[to] = append(reverse[to], from)
+		}
+		if from == start {
+			known = true
+		}
+	}
+	if len(reverse[start]) > 0 {
+		known = true
+	}
+	distances := map[string]int{start: 0}
+	queue := []string{start}
+	if kind == ObjectKindTemplate {
+		if configKey, ok := r.templates[name]; ok {
+			distances[configKey] = 0
+			queue = append(queue, configKey)
+		}
+	}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, dependent := range reverse[key] {
+			if _, ok := distances[dependent]; !ok {
+				distances[dependent] = distances[key] + 1
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	return distances, known
+}
+
+// objectDependencyNode reports config files that were only ever referenced
+// by another config as modules.
+func (r *ObjectModelRegistry) objectDependencyNode(key string) ObjectDependencyNode {
+	kind, name, _ := strings.Cut(key, ":")
+	if kind == ObjectKindConfig && !r.roots[key] {
+		kind = ObjectKindModule
+	}
+	return ObjectDependencyNode{Kind: kind, Name: name}
+}
+
+func objectDependencyKey(kind, name string) string {
+	if kind == ObjectKindModule {
+		kind = ObjectKindConfig
+	}
+	return kind + ":" + name
+}
+
+func configDataBagReferences(cfg *config.Config) []string {
+	set := map[string]struct{}{}
+	for _, res := range append(append([]config.Resource{}, cfg.Resources...), cfg.Handlers...) {
+		for _, field := range []string{res.Content, res.Command, res.OnlyIf, res.Unless, res.When, res.Creates, res.RefreshCommand, res.RescueCommand, res.AlwaysCommand, res.TaskCommand, res.RegistryValue} {
+			if field == "" {
+				continue
+			}
+			for _, pattern := range []*regexp.Regexp{dataBagCallPattern, dataBagTemplatePattern} {
+				for _, m := range pattern.FindAllStringSubmatch(field, -1) {
+					set[normalizeDataBagName(m[1])] = struct{}{}
+				}
+			}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for bag := range set {
+		out = append(out, bag)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,109 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestObjectModelDependencyImpact(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(tmp, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("base.yaml", `version: v0
+resources:
+  - id: motd
+    type: file
+    host: localhost
+    path: /etc/motd
+    content: "{{ data_bag.motd.default }}"
+`)
+	web := write("web.yaml", `version: v0
+includes:
+  - base.yaml
+resources:
+  - id: deploy
+    type: command
+    host: localhost
+    command: deploy --token "$(data_bag_item('secrets', 'deploy'))"
+`)
+	db := write("db.yaml", `version: v0
+imports:
+  - base.yaml
+`)
+	other := write("other.yaml", `version: v0
+resources: []
+`)
+
+	registry := NewObjectModelRegistry()
+	for _, path := range []string{web, db, other} {
+		if err := registry.IndexConfig(path); err != nil {
+			t.Fatalf("index %s: %v", path, err)
+		}
+	}
+	if err := registry.RegisterTemplate("tpl-1", web); err != nil {
+		t.Fatalf("register template: %v", err)
+	}
+	if err := registry.IndexConfig(filepath.Join(tmp, "missing.yaml")); err == nil {
+		t.Fatalf("expected error indexing missing config")
+	}
+
+	report, err := registry.Impact(ObjectKindDataBag, "MOTD")
+	if err != nil {
+		t.Fatalf("impact: %v", err)
+	}
+	if !report.Known || len(report.AffectedConfigs) != 2 || report.AffectedConfigs[0] != db || report.AffectedConfigs[1] != web {
+		t.Fatalf("expected db and web affected by motd data bag, got %+v", report)
+	}
+	if len(report.AffectedModules) != 1 || report.AffectedModules[0] != base {
+		t.Fatalf("expected base module in impact path, got %+v", report)
+	}
+	if len(report.AffectedTemplates) != 1 || report.AffectedTemplates[0] != "tpl-1" {
+		t.Fatalf("expected template affected through web config, got %+v", report)
+	}
+
+	report, err = registry.Impact(ObjectKindDataBag, "secrets")
+	if err != nil || len(report.AffectedConfigs) != 1 || report.AffectedConfigs[0] != web {
+		t.Fatalf("expected only web affected by secrets, got %+v err=%v", report, err)
+	}
+
+	report, err = registry.Impact(ObjectKindModule, base)
+	if err != nil || len(report.AffectedConfigs) != 2 || len(report.AffectedModules) != 0 {
+		t.Fatalf("expected module change to affect both composing configs, got %+v err=%v", report, err)
+	}
+
+	report, err = registry.Impact(ObjectKindTemplate, "tpl-1")
+	if err != nil || len(report.AffectedConfigs) != 1 || report.AffectedConfigs[0] != web {
+		t.Fatalf("expected template change to affect its config, got %+v err=%v", report, err)
+	}
+
+	report, err = registry.Impact(ObjectKindDataBag, "unused")
+	if err != nil || report.Known || len(report.AffectedConfigs) != 0 {
+		t.Fatalf("expected unknown data bag to affect nothing, got %+v err=%v", report, err)
+	}
+	if _, err := registry.Impact("host", "web-01"); err == nil {
+		t.Fatalf("expected unsupported kind error")
+	}
+
+	graph := registry.DependencyGraph()
+	var modules int
+	for _, node := range graph.Nodes {
+		if node.Kind == ObjectKindModule {
+			modules++
+		}
+	}
+	if modules != 1 || len(graph.Edges) != 5 {
+		t.Fatalf("unexpected dependency graph: %+v", graph)
+	}
+
+	registry.UnregisterTemplate("tpl-1")
+	report, _ = registry.Impact(ObjectKindDataBag, "secrets")
+	if len(report.AffectedTemplates) != 0 {
+		t.Fatalf("expected template removed from graph, got %+v", report)
+	}
+}
@@ -0,0 +1,302 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+type OrchestrationPhaseStatus string
+
+const (
+	PhasePending        OrchestrationPhaseStatus = "pending"
+	PhaseRunning        OrchestrationPhaseStatus = "running"
+	PhaseSucceeded      OrchestrationPhaseStatus = "succeeded"
+	PhaseFailed         OrchestrationPhaseStatus = "failed"
+	PhaseRollingBack    OrchestrationPhaseStatus = "rolling_back"
+	PhaseRolledBack     OrchestrationPhaseStatus = "rolled_back"
+	PhaseRollbackFailed OrchestrationPhaseStatus = "rollback_failed"
+	PhaseSkipped        OrchestrationPhaseStatus = "skipped"
+)
+
+// OrchestrationPhaseRun is one phase of an orchestration run: the phase's
+// config restricted to the hosts its target resolved to at launch.
+type OrchestrationPhaseRun struct {
+	Name               string                   `json:"name"`
+	Target             string                   `json:"target,omitempty"`
+	Hosts              []string                 `json:"hosts"`
+	SourceConfig       string                   `json:"source_config"`
+	ConfigPath         string                   `json:"config_path"`
+	FailurePolicy      string                   `json:"failure_policy"`
+	RollbackHosts      []string                 `json:"rollback_hosts,omitempty"`
+	RollbackConfigPath string                   `json:"rollback_config_path,omitempty"`
+	Status             OrchestrationPhaseStatus `json:"status"`
+	JobID              string                   `json:"job_id,omitempty"`
+	RollbackJobID      string                   `json:"rollback_job_id,omitempty"`
+	Error              string                   `json:"error,omitempty"`
+}
+
+type OrchestrationLaunch struct {
+	Name     string                  `json:"name"`
+	Source   string                  `json:"source"`
+	Phases   []OrchestrationPhaseRun `json:"phases"`
+	Priority string                  `json:"priority,omitempty"`
+	Force    bool                    `json:"force,omitempty"`
+}
+
+// PrepareOrchestration loads each phase's per-host config, resolves its
+// target, and writes the host-restricted config (and rollback config) under
+// dir. A phase whose target selects no hosts fails the whole preparation so
+// nothing runs against a partial fleet.
+func PrepareOrchestration(orch *config.Orchestration, dir string) ([]OrchestrationPhaseRun, error) {
+	if orch == nil {
+		return nil, errors.New("orchestration is required")
+	}
+	if err := config.ValidateOrchestration(orch); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]OrchestrationPhaseRun, 0, len(orch.Phases))
+	for i, ph := range orch.Phases {
+		run := OrchestrationPhaseRun{
+			Name:          ph.Name,
+			Target:        ph.Target,
+			SourceConfig:  ph.Config,
+			FailurePolicy: ph.FailurePolicy,
+			Status:        PhasePending,
+		}
+		hosts, path, err := restrictOrchestrationConfig(ph.Config, ph.Target, dir, "phase-"+itoa(int64(i+1))+".json")
+		if err != nil {
+			return nil, errors.New("phase " + ph.Name + ": " + err.Error())
+		}
+		run.Hosts, run.ConfigPath = hosts, path
+		if ph.FailurePolicy == "rollback" {
+			hosts, path, err := restrictOrchestrationConfig(ph.RollbackConfig, ph.RollbackTarget, dir, "phase-"+itoa(int64(i+1))+"-rollback.json")
+			if err != nil {
+				return nil, errors.New("phase " + ph.Name + " rollback: " + err.Error())
+			}
+			run.RollbackHosts, run.RollbackConfigPath = hosts, path
+		}
+		out = append(out, run)
+	}
+	return out, nil
+}
+
+func restrictOrchestrationConfig(configPath, target, dir, name string) ([]string, string, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, "", err
+	}
+	expr, err := config.ParseTargetExpression(target)
+	if err != nil {
+		return nil, "", err
+	}
+	hosts := config.ResolveTarget(cfg, expr)
+	if len(hosts) == 0 {
+		return nil, "", errors.New("target " + `"` + target + `"` + " matched no hosts")
+	}
+	if dir == "" {
+		return hosts, "", nil
+	}
+	encoded, err := json.MarshalIndent(config.RestrictToHosts(cfg, hosts), "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	out := filepath.Join(dir, name)
+	if err := os.WriteFile(out, encoded, 0o644); err != nil {
+		return nil, "", err
+	}
+	return hosts, out, nil
+}
+
+// LaunchOrchestration starts an orchestration run on the workflow engine.
+// Phases run strictly in order; each phase's failure policy decides whether
+// a failed phase aborts the run, is recorded and skipped past, or triggers
+// its rollback config before the run aborts.
+func (w *WorkflowStore) LaunchOrchestration(in OrchestrationLaunch) (WorkflowRun, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return WorkflowRun{}, errors.New("orchestration name is required")
+	}
+	if len(in.Phases) == 0 {
+		return WorkflowRun{}, errors.New("orchestration must include at least one phase")
+	}
+	phases := make([]OrchestrationPhaseRun, len(in.Phases))
+	for i, ph := range in.Phases {
+		if strings.TrimSpace(ph.ConfigPath) == "" {
+			return WorkflowRun{}, errors.New("orchestration phase " + ph.Name + " has no config_path")
+		}
+		ph.Status = PhasePending
+		phases[i] = cloneOrchestrationPhaseRun(ph)
+	}
+
+	w.mu.Lock()
+	w.nextRunID++
+	run := &WorkflowRun{
+		ID:              "wfrun-" + itoa(w.nextRunID),
+		Orchestration:   in.Name,
+		Source:          in.Source,
+		Status:          WorkflowPending,
+		TotalSteps:      len(phases),
+		StepJobIDs:      make([]string, len(phases)),
+		Phases:          phases,
+		DefaultPriority: normalizePriority(in.Priority),
+		Force:           in.Force,
+		CreatedAt:       time.Now().UTC(),
+	}
+	w.runs[run.ID] = run
+	w.mu.Unlock()
+
+	if err := w.dispatchPhase(run.ID, 0, false); err != nil {
+		return WorkflowRun{}, err
+	}
+	return w.GetRun(run.ID)
+}
+
+func (w *WorkflowStore) dispatchPhase(runID string, index int, rollback bool) error {
+	w.mu.RLock()
+	run, ok := w.runs[runID]
+	if !ok {
+		w.mu.RUnlock()
+		return errors.New("workflow run not found")
+	}
+	if run.Status == WorkflowFailed || run.Status == WorkflowSucceeded {
+		w.mu.RUnlock()
+		return nil
+	}
+	if index < 0 || index >= len(run.Phases) {
+		w.mu.RUnlock()
+		return errors.New("orchestration phase out of range")
+	}
+	name := run.Phases[index].Name
+	path := run.Phases[index].ConfigPath
+	key := runID + "-phase-" + itoa(int64(index))
+	if rollback {
+		path = run.Phases[index].RollbackConfigPath
+		key += "-rollback"
+	}
+	priority, force := run.DefaultPriority, run.Force
+	w.mu.RUnlock()
+
+	job, err := w.queue.Enqueue(path, key, force, priority)
+	if err != nil {
+		w.mu.Lock()
+		if r, ok := w.runs[runID]; ok {
+			r.Phases[index].Error = err.Error()
+		}
+		w.mu.Unlock()
+		w.failRun(runID, "orchestration phase "+name+" could not be queued: "+err.Error())
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	run, ok = w.runs[runID]
+	if !ok || run.Status == WorkflowFailed || run.Status == WorkflowSucceeded {
+		return nil
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	run.Status = WorkflowRunning
+	run.CurrentStep = index
+	ph := &run.Phases[index]
+	if rollback {
+		ph.Status = PhaseRollingBack
+		ph.RollbackJobID = job.ID
+	} else {
+		ph.Status = PhaseRunning
+		ph.JobID = job.ID
+		run.StepJobIDs[index] = job.ID
+	}
+	w.jobRefs[job.ID] = workflowJobRef{runID: runID, step: index, rollback: rollback}
+	return nil
+}
+
+// onPhaseJob advances an orchestration run when one of its phase (or
+// rollback) jobs finishes.
+func (w *WorkflowStore) onPhaseJob(ref workflowJobRef, job Job) {
+	succeeded := job.Status == JobSucceeded
+	w.mu.Lock()
+	run, ok := w.runs[ref.runID]
+	if !ok || ref.step >= len(run.Phases) {
+		w.mu.Unlock()
+		return
+	}
+	ph := &run.Phases[ref.step]
+	name := ph.Name
+	next, rollback, abort := -1, false, ""
+	switch {
+	case ref.rollback && succeeded:
+		ph.Status = PhaseRolledBack
+		abort = "orchestration phase " + name + " failed and was rolled back"
+	case ref.rollback:
+		ph.Status = PhaseRollbackFailed
+		abort = "orchestration phase " + name + " failed and its rollback job failed: " + job.ID
+	case succeeded:
+		ph.Status = PhaseSucceeded
+		next = ref.step + 1
+	default:
+		ph.Status = PhaseFailed
+		ph.Error = "phase job " + string(job.Status) + ": " + job.ID
+		if job.Error != "" {
+			ph.Error += ": " + job.Error
+		}
+		switch ph.FailurePolicy {
+		case "continue":
+			next = ref.step + 1
+		case "rollback":
+			rollback = true
+		default:
+			abort = "orchestration phase " + name + " failed: " + job.ID
+		}
+	}
+	if abort != "" {
+		for i := ref.step + 1; i < len(run.Phases); i++ {
+			run.Phases[i].Status = PhaseSkipped
+		}
+	}
+	finished := next >= len(run.Phases)
+	if finished {
+		var failed []string
+		for _, p := range run.Phases {
+			if p.Status == PhaseFailed {
+				failed = append(failed, p.Name)
+			}
+		}
+		run.CurrentStep = run.TotalSteps
+		run.EndedAt = time.Now().UTC()
+		if len(failed) == 0 {
+			run.Status = WorkflowSucceeded
+		} else {
+			run.Status = WorkflowFailed
+			run.Error = "orchestration phases failed: " + strings.Join(failed, ", ")
+		}
+	}
+	w.mu.Unlock()
+
+	switch {
+	case abort != "":
+		w.failRun(ref.runID, abort)
+	case rollback:
+		_ = w.dispatchPhase(ref.runID, ref.step, true)
+	case next >= 0 && !finished:
+		_ = w.dispatchPhase(ref.runID, next, false)
+	}
+}
+
+func cloneOrchestrationPhaseRun(in OrchestrationPhaseRun) OrchestrationPhaseRun {
+	out := in
+	out.Hosts = append([]string{}, in.Hosts...)
+	out.RollbackHosts = append([]string{}, in.RollbackHosts...)
+	return out
+}
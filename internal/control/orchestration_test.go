@@ -0,0 +1,120 @@
+package control
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+func waitOrchestrationRun(t *testing.T, ws *WorkflowStore, id string) WorkflowRun {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		run, err := ws.GetRun(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.Status == WorkflowSucceeded || run.Status == WorkflowFailed {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for orchestration run, last %+v", run)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWorkflowStore_OrchestrationFailurePolicies(t *testing.T) {
+	q := NewQueue(32)
+	exec := &fakeExecutor{failOn: "upgrade.json"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.StartWorker(ctx, exec)
+	ws := NewWorkflowStore(q, NewTemplateStore())
+
+	run, err := ws.LaunchOrchestration(OrchestrationLaunch{
+		Name: "rolling-web",
+		Phases: []OrchestrationPhaseRun{
+			{Name: "drain-a", ConfigPath: "drain.json", FailurePolicy: "abort"},
+			{Name: "upgrade-b", ConfigPath: "upgrade.json", FailurePolicy: "rollback", RollbackConfigPath: "readd.json"},
+			{Name: "readd-a", ConfigPath: "readd.json", FailurePolicy: "abort"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run = waitOrchestrationRun(t, ws, run.ID)
+	if run.Status != WorkflowFailed || !strings.Contains(run.Error, "rolled back") {
+		t.Fatalf("expected run aborted after rollback, got %+v", run)
+	}
+	if run.Phases[0].Status != PhaseSucceeded || run.Phases[1].Status != PhaseRolledBack || run.Phases[1].RollbackJobID == "" || run.Phases[2].Status != PhaseSkipped {
+		t.Fatalf("unexpected phase states %+v", run.Phases)
+	}
+
+	run, err = ws.LaunchOrchestration(OrchestrationLaunch{
+		Name: "best-effort",
+		Phases: []OrchestrationPhaseRun{
+			{Name: "upgrade", ConfigPath: "upgrade.json", FailurePolicy: "continue"},
+			{Name: "verify", ConfigPath: "verify.json", FailurePolicy: "abort"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run = waitOrchestrationRun(t, ws, run.ID)
+	if run.Status != WorkflowFailed || run.Phases[0].Status != PhaseFailed || run.Phases[1].Status != PhaseSucceeded || !strings.Contains(run.Error, "upgrade") {
+		t.Fatalf("expected continue policy to run later phases and report failure, got %+v", run)
+	}
+}
+
+func TestPrepareOrchestrationRestrictsPhaseConfigs(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "hosts.yaml")
+	if err := os.WriteFile(cfgPath, []byte(`version: v0
+inventory:
+  hosts:
+    - name: web-a
+      transport: local
+    - name: web-b
+      transport: local
+resources:
+  - id: a
+    type: command
+    host: web-a
+    command: "true"
+  - id: b
+    type: command
+    host: web-b
+    command: "true"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orch := &config.Orchestration{Kind: "orchestration", Name: "o", Phases: []config.OrchestrationPhase{
+		{Name: "only-b", Config: cfgPath, Target: "web-b"},
+	}}
+	dir := filepath.Join(tmp, "out")
+	phases, err := PrepareOrchestration(orch, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(phases) != 1 || strings.Join(phases[0].Hosts, ",") != "web-b" {
+		t.Fatalf("unexpected phases %+v", phases)
+	}
+	restricted, err := config.Load(phases[0].ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restricted.Resources) != 1 || restricted.Resources[0].ID != "b" {
+		t.Fatalf("expected phase config restricted to web-b, got %+v", restricted.Resources)
+	}
+
+	orch.Phases[0].Target = "db-*"
+	if _, err := PrepareOrchestration(orch, dir); err == nil || !strings.Contains(err.Error(), "matched no hosts") {
+		t.Fatalf("expected empty target to fail preparation, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VulnerabilityFeed matches SBOM components against a vulnerability
+// database.
+type VulnerabilityFeed interface {
+	Name() string
+	Query(ctx context.Context, components []SBOMComponent) ([]VulnerabilityFinding, error)
+}
+
+// OSVFeed queries the OSV.dev API (or a compatible mirror) once per
+// component. Components without an OSV ecosystem, including masterchef
+// packages themselves, are skipped.
+type OSVFeed struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOSVFeed(baseURL string, client *http.Client) *OSVFeed {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = "https://api.osv.dev"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &OSVFeed{baseURL: baseURL, client: client}
+}
+
+func (f *OSVFeed) Name() string { return "osv" }
+
+type osvQuery struct {
+	Version   string     `json:"version"`
+	Package   osvPackage `json:"package"`
+	PageToken string     `json:"page_token,omitempty"`
+}
+
+type osvPackage struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+type osvVuln struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package osvPackage `json:"package"`
+		Ranges  []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+		EcosystemSpecific map[string]any `json:"ecosystem_specific"`
+	} `json:"affected"`
+	DatabaseSpecific map[string]any `json:"database_specific"`
+}
+
+type osvQueryResponse struct {
+	Vulns         []osvVuln `json:"vulns"`
+	NextPageToken string    `json:"next_page_token"`
+}
+
+func (f *OSVFeed) Query(ctx context.Context, components []SBOMComponent) ([]VulnerabilityFinding, error) {
+	out := []VulnerabilityFinding{}
+	for _, component := range components {
+		if component.Ecosystem == "" || component.Ecosystem == SBOMInternalEcosystem || component.Version == "" {
+			continue
+		}
+		pageToken := ""
+		for {
+			vulns, next, err := f.query(ctx, osvQuery{
+				Version:   component.Version,
+				Package:   osvPackage{Name: component.Name, Ecosystem: component.Ecosystem},
+				PageToken: pageToken,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("osv query for %s@%s: %w", component.Name, component.Version, err)
+			}
+			for _, vuln := range vulns {
+				out = append(out, osvFinding(component, vuln))
+			}
+			if next == "" {
+				break
+			}
+			pageToken = next
+		}
+	}
+	return out, nil
+}
+
+func (f *OSVFeed) query(ctx context.Context, q osvQuery) ([]osvVuln, string, error) {
+	body, err := json.Marshal(q)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+"/v1/query", bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, "", errors.New("osv returned " + resp.Status + ": " + strings.TrimSpace(string(msg)))
+	}
+	var parsed osvQueryResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&parsed); err != nil {
+		return nil, "", err
+	}
+	return parsed.Vulns, parsed.NextPageToken, nil
+}
+
+func osvFinding(component SBOMComponent, vuln osvVuln) VulnerabilityFinding {
+	finding := VulnerabilityFinding{
+		ID:        vuln.ID,
+		Aliases:   append([]string{}, vuln.Aliases...),
+		Summary:   strings.TrimSpace(vuln.Summary),
+		Severity:  normalizeVulnerabilitySeverity(osvSeverityLabel(vuln.DatabaseSpecific)),
+		Component: component.Name,
+		Version:   component.Version,
+		Ecosystem: component.Ecosystem,
+	}
+	fixed := map[string]struct{}{}
+	for _, affected := range vuln.Affected {
+		if !strings.EqualFold(affected.Package.Name, component.Name) {
+			continue
+		}
+		if finding.Severity == VulnSeverityUnknown {
+			finding.Severity = normalizeVulnerabilitySeverity(osvSeverityLabel(affected.EcosystemSpecific))
+		}
+		for _, r := range affected.Ranges {
+			for _, event := range r.Events {
+				if event.Fixed != "" {
+					fixed[event.Fixed] = struct{}{}
+				}
+			}
+		}
+	}
+	for version := range fixed {
+		finding.FixedVersions = append(finding.FixedVersions, version)
+	}
+	sort.Strings(finding.FixedVersions)
+	return finding
+}
+
+// osvSeverityLabel reads the qualitative severity that GHSA, PyPA, and
+// distro advisories publish alongside their CVSS vectors.
+func osvSeverityLabel(fields map[string]any) string {
+	if v, ok := fields["severity"].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -30,8 +30,13 @@ type PackageArtifact struct {
 	Signature  string            `json:"signature,omitempty"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
 	Provenance PackageProvenance `json:"provenance"`
-	CreatedAt  time.Time         `json:"created_at"`
-	UpdatedAt  time.Time         `json:"updated_at"`
+
+	Dependencies map[string]string `json:"dependencies,omitempty"` // package name -> version constraint
+	ObjectKey    string            `json:"object_key,omitempty"`   // stored tarball, if uploaded
+	SizeBytes    int64             `json:"size_bytes,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type PackageArtifactInput struct {
@@ -45,6 +50,10 @@ type PackageArtifactInput struct {
 	Signature  string            `json:"signature,omitempty"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
 	Provenance PackageProvenance `json:"provenance"`
+
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	ObjectKey    string            `json:"-"`
+	SizeBytes    int64             `json:"-"`
 }
 
 type PackageSigningPolicy struct {
@@ -178,6 +187,7 @@ type PackageRegistryStore struct {
 	certPolicy     PackageCertificationPolicy
 	certifications map[string]*PackageCertificationReport
 	maintainers    map[string]*MaintainerHealthReport
+	vulnGate       func(key, stage string) (ArtifactScan, []string)
 }
 
 func NewPackageRegistryStore() *PackageRegistryStore {
@@ -217,6 +227,27 @@ func (s *PackageRegistryStore) Publish(in PackageArtifactInput) (PackageArtifact
 	if !packageDigestPattern.MatchString(digest) {
 		return PackageArtifact{}, errors.New("digest must be immutable sha256:<64-hex>")
 	}
+	if _, ok := parseVersionParts(version); !ok {
+		return PackageArtifact{}, errors.New("version must be semantic (major.minor.patch)")
+	}
+	deps := map[string]string{}
+	for depName, constraint := range in.Dependencies {
+		depName = strings.TrimSpace(depName)
+		if depName == "" {
+			continue
+		}
+		if strings.EqualFold(depName, name) {
+			return PackageArtifact{}, errors.New("package cannot depend on itself")
+		}
+		constraint = strings.Join(strings.Fields(constraint), " ")
+		if constraint == "" {
+			constraint = ">= 0"
+		}
+		if err := ValidateVersionConstraint(constraint); err != nil {
+			return PackageArtifact{}, errors.New("dependency " + depName + ": " + err.Error())
+		}
+		deps[depName] = constraint
+	}
 	visibility := strings.ToLower(strings.TrimSpace(in.Visibility))
 	if visibility == "" {
 		visibility = "private"
@@ -256,11 +287,21 @@ func (s *PackageRegistryStore) Publish(in PackageArtifactInput) (PackageArtifact
 		Signature:  strings.TrimSpace(in.Signature),
 		Metadata:   meta,
 		Provenance: prov,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+
+		Dependencies: deps,
+		ObjectKey:    strings.TrimSpace(in.ObjectKey),
+		SizeBytes:    in.SizeBytes,
+
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	for _, existing := range s.artifacts {
+		if existing.Kind == kind && strings.EqualFold(existing.Name, name) && compareVersions(existing.Version, version) == 0 {
+			return PackageArtifact{}, errors.New(kind + " " + name + "@" + version + " is already published; versions are immutable")
+		}
+	}
 	s.nextID++
 	item.ID = "pkg-artifact-" + itoa(s.nextID)
 	s.artifacts[item.ID] = &item
@@ -365,12 +406,21 @@ func (s *PackageRegistryStore) Verify(in PackageVerificationInput) PackageVerifi
 	if !ok {
 		return PackageVerificationResult{Allowed: false, Reason: "artifact not found", ArtifactID: artifactID}
 	}
+	if reason := packageSignatureProblem(*artifact, policy); reason != "" {
+		return PackageVerificationResult{Allowed: false, Reason: reason, ArtifactID: artifact.ID}
+	}
+	return PackageVerificationResult{Allowed: true, ArtifactID: artifact.ID}
+}
+
+// packageSignatureProblem explains why artifact fails the signing policy,
+// or returns "" when it passes.
+func packageSignatureProblem(artifact PackageArtifact, policy PackageSigningPolicy) string {
 	if policy.RequireSigned && !artifact.Signed {
-		return PackageVerificationResult{Allowed: false, Reason: "signed artifact required by policy", ArtifactID: artifact.ID}
+		return "signed artifact required by policy"
 	}
 	if artifact.Signed {
 		if artifact.KeyID == "" || artifact.Signature == "" {
-			return PackageVerificationResult{Allowed: false, Reason: "signed artifact missing key/signature", ArtifactID: artifact.ID}
+			return "signed artifact missing key/signature"
 		}
 		if len(policy.TrustedKeyIDs) > 0 {
 			matched := false
@@ -381,11 +431,55 @@ func (s *PackageRegistryStore) Verify(in PackageVerificationInput) PackageVerifi
 				}
 			}
 			if !matched {
-				return PackageVerificationResult{Allowed: false, Reason: "artifact signing key not trusted", ArtifactID: artifact.ID}
+				return "artifact signing key not trusted"
 			}
 		}
 	}
-	return PackageVerificationResult{Allowed: true, ArtifactID: artifact.ID}
+	return ""
+}
+
+// findVersion returns the published artifact for kind/name@version.
+func (s *PackageRegistryStore) findVersion(kind, name, version string) (PackageArtifact, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, item := range s.artifacts {
+		if item.Kind == kind && strings.EqualFold(item.Name, name) && compareVersions(item.Version, version) == 0 {
+			return clonePackageArtifact(*item), true
+		}
+	}
+	return PackageArtifact{}, false
+}
+
+func (s *PackageRegistryStore) setVulnerabilityGate(gate func(key, stage string) (ArtifactScan, []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vulnGate = gate
+}
+
+// attachSBOMDigest records a generated SBOM on an artifact that was
+// published without one.
+func (s *PackageRegistryStore) attachSBOMDigest(id, digest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.artifacts[id]
+	if !ok || item.Provenance.SBOMDigest != "" {
+		return
+	}
+	item.Provenance.SBOMDigest = digest
+	item.UpdatedAt = time.Now().UTC()
+}
+
+// attachAttestationDigest records a signed provenance attestation on an
+// artifact that was published without one.
+func (s *PackageRegistryStore) attachAttestationDigest(id, digest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.artifacts[id]
+	if !ok || item.Provenance.AttestationDigest != "" {
+		return
+	}
+	item.Provenance.AttestationDigest = digest
+	item.UpdatedAt = time.Now().UTC()
 }
 
 func (s *PackageRegistryStore) CertificationPolicy() PackageCertificationPolicy {
@@ -440,6 +534,12 @@ func (s *PackageRegistryStore) Certify(in PackageCertificationInput) (PackageCer
 	}
 	policy := s.certPolicy
 	reasons := make([]string, 0, 6)
+	if s.vulnGate != nil {
+		scan, gateReasons := s.vulnGate(scanArtifactKey(ScanArtifactPackage, artifact.ID), "certification")
+		reasons = append(reasons, gateReasons...)
+		in.HighVulnerabilities = maxInt(in.HighVulnerabilities, scan.Counts[VulnSeverityHigh])
+		in.CriticalVulnerabilities = maxInt(in.CriticalVulnerabilities, scan.Counts[VulnSeverityCritical])
+	}
 	if policy.RequireSigned && !artifact.Signed {
 		reasons = append(reasons, "artifact must be signed")
 	}
@@ -533,11 +633,16 @@ func (s *PackageRegistryStore) PublicationGateCheck(in PackagePublicationCheckIn
 	policy := clonePackageSigningPolicy(s.policy)
 	certPolicy := s.certPolicy
 	cert, certOK := s.certifications[artifactID]
+	vulnGate := s.vulnGate
 	s.mu.RUnlock()
 	if !ok {
 		result.Reasons = append(result.Reasons, "artifact not found")
 		return result
 	}
+	if vulnGate != nil {
+		_, gateReasons := vulnGate(scanArtifactKey(ScanArtifactPackage, artifactID), "publication")
+		result.Reasons = append(result.Reasons, gateReasons...)
+	}
 
 	verify := s.Verify(PackageVerificationInput{ArtifactID: artifactID})
 	if !verify.Allowed {
@@ -678,6 +783,12 @@ func clonePackageArtifact(in PackageArtifact) PackageArtifact {
 	for k, v := range in.Metadata {
 		out.Metadata[k] = v
 	}
+	if in.Dependencies != nil {
+		out.Dependencies = make(map[string]string, len(in.Dependencies))
+		for k, v := range in.Dependencies {
+			out.Dependencies[k] = v
+		}
+	}
 	return out
 }
 
@@ -1,6 +1,7 @@
 package control
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -274,3 +275,82 @@ func TestPackageRegistryQualityScoringAndBadges(t *testing.T) {
 		t.Fatalf("expected quality report list for modules")
 	}
 }
+
+func TestPackageRegistryResolveDependencies(t *testing.T) {
+	store := NewPackageRegistryStore()
+	digest := func(n int) string {
+		return "sha256:" + strings.Repeat(string(rune('a'+n)), 64)
+	}
+	publish := func(name, version string, n int, deps map[string]string) {
+		t.Helper()
+		if _, err := store.Publish(PackageArtifactInput{
+			Kind:         "module",
+			Name:         name,
+			Version:      version,
+			Digest:       digest(n),
+			Dependencies: deps,
+		}); err != nil {
+			t.Fatalf("publish %s@%s: %v", name, version, err)
+		}
+	}
+	publish("core/base", "1.0.0", 0, nil)
+	publish("core/base", "1.4.0", 1, nil)
+	publish("core/base", "2.0.0", 2, nil)
+	publish("core/network", "1.0.0", 3, map[string]string{"core/base": "~> 1.0"})
+	// network 2.0.0 needs base 2.x, which conflicts with web's pin below,
+	// so the solver must backtrack to network 1.0.0.
+	publish("core/network", "2.0.0", 4, map[string]string{"core/base": ">= 2.0"})
+	publish("app/web", "3.1.0", 5, map[string]string{"core/network": ">= 1.0", "core/base": "< 2.0"})
+
+	if _, err := store.Publish(PackageArtifactInput{Kind: "module", Name: "core/base", Version: "1.0.0", Digest: digest(6)}); err == nil {
+		t.Fatalf("expected republishing an existing version to fail")
+	}
+	if _, err := store.Publish(PackageArtifactInput{Kind: "module", Name: "core/bad", Version: "latest", Digest: digest(6)}); err == nil {
+		t.Fatalf("expected non-semantic version to be rejected")
+	}
+	if _, err := store.Publish(PackageArtifactInput{Kind: "module", Name: "core/bad", Version: "1.0.0", Digest: digest(6), Dependencies: map[string]string{"core/base": "about 1"}}); err == nil {
+		t.Fatalf("expected invalid dependency constraint to be rejected")
+	}
+
+	res, err := store.Resolve(PackageResolveInput{Requirements: map[string]string{"app/web": ">= 3.0"}})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	got := map[string]string{}
+	for _, pkg := range res.Packages {
+		got[pkg.Name] = pkg.Version
+	}
+	if got["app/web"] != "3.1.0" || got["core/network"] != "1.0.0" || got["core/base"] != "1.4.0" || len(got) != 3 {
+		t.Fatalf("unexpected resolution: %+v", res.Packages)
+	}
+	again, err := store.Resolve(PackageResolveInput{Requirements: map[string]string{"app/web": ">= 3.0"}})
+	if err != nil || again.LockDigest != res.LockDigest {
+		t.Fatalf("expected reproducible lock digest, got %q vs %q err=%v", again.LockDigest, res.LockDigest, err)
+	}
+
+	pinned, err := store.Resolve(PackageResolveInput{
+		Requirements: map[string]string{"core/network": ">= 1.0"},
+		Pins:         map[string]string{"core/base": "= 1.0.0", "unused/pkg": "= 9.9.9"},
+	})
+	if err != nil {
+		t.Fatalf("resolve with pins: %v", err)
+	}
+	if len(pinned.Packages) != 2 || pinned.Packages[0].Name != "core/base" || pinned.Packages[0].Version != "1.0.0" || pinned.Packages[1].Version != "1.0.0" {
+		t.Fatalf("expected pin to select base 1.0.0 and force network 1.0.0, got %+v", pinned.Packages)
+	}
+
+	if _, err := store.Resolve(PackageResolveInput{
+		Requirements: map[string]string{"app/web": ">= 3.0"},
+		Pins:         map[string]string{"core/base": ">= 2.0"},
+	}); err == nil || !strings.Contains(err.Error(), "core/base") {
+		t.Fatalf("expected unsatisfiable pin conflict naming core/base, got %v", err)
+	}
+	if _, err := store.Resolve(PackageResolveInput{Requirements: map[string]string{"missing/pkg": ">= 1.0"}}); err == nil || !strings.Contains(err.Error(), "not published") {
+		t.Fatalf("expected missing package error, got %v", err)
+	}
+
+	versions := store.ListVersions("module", "core/base")
+	if len(versions) != 3 || versions[0].Version != "2.0.0" {
+		t.Fatalf("expected versions newest first, got %+v", versions)
+	}
+}
@@ -0,0 +1,266 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// packageResolveBudget bounds backtracking so pathological constraint sets
+// fail fast instead of pinning a request goroutine.
+const packageResolveBudget = 10000
+
+type PackageResolveInput struct {
+	Kind         string            `json:"kind,omitempty"`        // module|provider, default module
+	Requirements map[string]string `json:"requirements"`          // package name -> version constraint
+	Environment  string            `json:"environment,omitempty"` // applies the environment's version pins
+	Pins         map[string]string `json:"pins,omitempty"`        // additional pins, same syntax as requirements
+}
+
+type ResolvedPackage struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	ArtifactID   string   `json:"artifact_id"`
+	Digest       string   `json:"digest"`
+	Constraints  []string `json:"constraints"`
+	RequiredBy   []string `json:"required_by"`
+	Downloadable bool     `json:"downloadable"`
+}
+
+type PackageResolution struct {
+	Kind        string            `json:"kind"`
+	Environment string            `json:"environment,omitempty"`
+	Packages    []ResolvedPackage `json:"packages"`
+	LockDigest  string            `json:"lock_digest"` // stable across resolutions of the same module set
+	ResolvedAt  time.Time         `json:"resolved_at"`
+}
+
+type packageConstraint struct {
+	constraint string
+	source     string
+}
+
+// ListVersions returns the published versions of a package, newest first.
+func (s *PackageRegistryStore) ListVersions(kind, name string) []PackageArtifact {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	name = strings.TrimSpace(name)
+	s.mu.RLock()
+	out := []PackageArtifact{}
+	for _, item := range s.artifacts {
+		if item.Kind == kind && strings.EqualFold(item.Name, name) {
+			out = append(out, clonePackageArtifact(*item))
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return compareVersions(out[i].Version, out[j].Version) > 0 })
+	return out
+}
+
+// Resolve picks one version of every package reachable from the
+// requirements such that each requirement, transitive dependency
+// constraint, and pin holds. Newer versions are preferred and the search
+// backtracks when a choice leads to a conflict, so the same registry
+// contents and inputs always yield the same set.
+func (s *PackageRegistryStore) Resolve(in PackageResolveInput) (PackageResolution, error) {
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	if kind == "" {
+		kind = "module"
+	}
+	if kind != "module" && kind != "provider" {
+		return PackageResolution{}, errors.New("kind must be module or provider")
+	}
+	if len(in.Requirements) == 0 {
+		return PackageResolution{}, errors.New("requirements are required")
+	}
+	constraints := map[string][]packageConstraint{}
+	for name, constraint := range in.Requirements {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			continue
+		}
+		constraint = strings.Join(strings.Fields(constraint), " ")
+		if constraint == "" {
+			constraint = ">= 0"
+		}
+		if err := ValidateVersionConstraint(constraint); err != nil {
+			return PackageResolution{}, errors.New(name + ": " + err.Error())
+		}
+		constraints[key] = append(constraints[key], packageConstraint{constraint: constraint, source: "request"})
+	}
+
+	// Pins only narrow packages that end up in the graph; they never pull
+	// a package in on their own.
+	pins := map[string][]packageConstraint{}
+	for name, constraint := range in.Pins {
+		key := strings.ToLower(strings.TrimSpace(name))
+		constraint = strings.Join(strings.Fields(constraint), " ")
+		if key == "" || constraint == "" {
+			continue
+		}
+		if err := ValidateVersionConstraint(constraint); err != nil {
+			return PackageResolution{}, errors.New("pin " + name + ": " + err.Error())
+		}
+		pins[key] = append(pins[key], packageConstraint{constraint: constraint, source: "pin"})
+	}
+
+	s.mu.RLock()
+	candidates := map[string][]PackageArtifact{}
+	for _, item := range s.artifacts {
+		if item.Kind == kind {
+			key := strings.ToLower(item.Name)
+			candidates[key] = append(candidates[key], clonePackageArtifact(*item))
+		}
+	}
+	s.mu.RUnlock()
+	for key := range candidates {
+		versions := candidates[key]
+		sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i].Version, versions[j].Version) > 0 })
+	}
+
+	solver := &packageSolver{candidates: candidates, pins: pins, selected: map[string]PackageArtifact{}}
+	if !solver.solve(constraints) {
+		if solver.steps > packageResolveBudget {
+			return PackageResolution{}, errors.New("dependency resolution exceeded search budget")
+		}
+		return PackageResolution{}, errors.New(solver.conflict)
+	}
+
+	out := PackageResolution{
+		Kind:        kind,
+		Environment: strings.TrimSpace(in.Environment),
+		Packages:    []ResolvedPackage{},
+		ResolvedAt:  time.Now().UTC(),
+	}
+	lock := sha256.New()
+	for _, key := range sortedPackageKeys(solver.selected) {
+		artifact := solver.selected[key]
+		item := ResolvedPackage{
+			Name:         artifact.Name,
+			Version:      artifact.Version,
+			ArtifactID:   artifact.ID,
+			Digest:       artifact.Digest,
+			Constraints:  []string{},
+			RequiredBy:   []string{},
+			Downloadable: artifact.ObjectKey != "",
+		}
+		for _, c := range append(append([]packageConstraint{}, solver.final[key]...), pins[key]...) {
+			item.Constraints = append(item.Constraints, c.constraint)
+			if c.source != "pin" && !containsString(item.RequiredBy, c.source) {
+				item.RequiredBy = append(item.RequiredBy, c.source)
+			}
+		}
+		out.Packages = append(out.Packages, item)
+		lock.Write([]byte(key + "@" + artifact.Version + "=" + artifact.Digest + "\n"))
+	}
+	out.LockDigest = "sha256:" + hex.EncodeToString(lock.Sum(nil))
+	return out, nil
+}
+
+type packageSolver struct {
+	candidates map[string][]PackageArtifact
+	pins       map[string][]packageConstraint
+	selected   map[string]PackageArtifact
+	final      map[string][]packageConstraint
+	conflict   string
+	steps      int
+}
+
+// solve selects the alphabetically first undecided package, tries its
+// candidates newest first, and recurses with the chosen version's
+// dependencies added to the constraint set.
+func (p *packageSolver) solve(constraints map[string][]packageConstraint) bool {
+	p.steps++
+	if p.steps > packageResolveBudget {
+		return false
+	}
+	next := ""
+	for _, key := range sortedPackageKeys(constraints) {
+		if _, ok := p.selected[key]; !ok {
+			next = key
+			break
+		}
+	}
+	if next == "" {
+		p.final = constraints
+		return true
+	}
+	required := append(append([]packageConstraint{}, constraints[next]...), p.pins[next]...)
+	versions := p.candidates[next]
+	if len(versions) == 0 {
+		p.conflict = "package " + next + " is not published (required by " + packageConstraintSources(required) + ")"
+		return false
+	}
+	matched := false
+	for _, artifact := range versions {
+		if !packageSatisfiesAll(artifact.Version, required) {
+			continue
+		}
+		matched = true
+		nextConstraints := make(map[string][]packageConstraint, len(constraints)+len(artifact.Dependencies))
+		for k, v := range constraints {
+			nextConstraints[k] = v
+		}
+		source := artifact.Name + "@" + artifact.Version
+		ok := true
+		for _, depName := range sortedPackageKeys(artifact.Dependencies) {
+			constraint := artifact.Dependencies[depName]
+			key := strings.ToLower(depName)
+			nextConstraints[key] = append(append([]packageConstraint{}, nextConstraints[key]...), packageConstraint{constraint: constraint, source: source})
+			if chosen, done := p.selected[key]; done && !packageSatisfiesAll(chosen.Version, nextConstraints[key]) {
+				p.conflict = source + " requires " + depName + " " + constraint + " but " + chosen.Name + "@" + chosen.Version + " was already selected"
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		p.selected[next] = artifact
+		if p.solve(nextConstraints) {
+			return true
+		}
+		delete(p.selected, next)
+		if p.steps > packageResolveBudget {
+			return false
+		}
+	}
+	if !matched {
+		clauses := make([]string, 0, len(required))
+		for _, c := range required {
+			clauses = append(clauses, c.constraint+" ("+c.source+")")
+		}
+		p.conflict = "no version of " + versions[0].Name + " satisfies " + strings.Join(clauses, ", ")
+	}
+	return false
+}
+
+func packageSatisfiesAll(version string, constraints []packageConstraint) bool {
+	for _, c := range constraints {
+		if ok, err := VersionSatisfiesConstraint(version, c.constraint); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func packageConstraintSources(constraints []packageConstraint) string {
+	sources := []string{}
+	for _, c := range constraints {
+		if !containsString(sources, c.source) {
+			sources = append(sources, c.source)
+		}
+	}
+	return strings.Join(sources, ", ")
+}
+
+func sortedPackageKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
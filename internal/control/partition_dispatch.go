@@ -0,0 +1,336 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type PartitionWorkerInput struct {
+	ID         string   `json:"id"`
+	Partitions []string `json:"partitions"`
+	TTLSeconds int      `json:"ttl_seconds,omitempty"`
+	WorkerTopology
+}
+
+type PartitionWorker struct {
+	ID         string   `json:"id"`
+	Partitions []string `json:"partitions"`
+	TTLSeconds int      `json:"ttl_seconds"`
+	WorkerTopology
+	RegisteredAt  time.Time `json:"registered_at"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// PartitionLag summarises dispatch for one partition. LagSeconds is the age
+// of its oldest unclaimed job.
+type PartitionLag struct {
+	Partition      string  `json:"partition"`
+	Workers        int     `json:"workers"`
+	MaxParallel    int     `json:"max_parallel"`
+	Pending        int     `json:"pending"`
+	Running        int     `json:"running"`
+	Claimed        int64   `json:"claimed"`
+	Succeeded      int64   `json:"succeeded"`
+	Failed         int64   `json:"failed"`
+	Requeued       int64   `json:"requeued"`
+	LagSeconds     float64 `json:"lag_seconds"`
+	AvgClaimWaitMS int64   `json:"avg_claim_wait_ms"`
+}
+
+type partitionDispatchStats struct {
+	claimed     int64
+	succeeded   int64
+	failed      int64
+	requeued    int64
+	claimWaitMS int64
+}
+
+// PartitionDispatcher routes jobs that match a scheduler partition rule to
+// workers registered for that partition. Workers pull work with Claim and
+// report it with Complete; a worker whose heartbeat lapses, deregisters, or
+// drops a partition gives its claimed jobs back to the partition.
+type PartitionDispatcher struct {
+	mu         sync.Mutex
+	queue      *Queue
+	partitions *SchedulerPartitionStore
+	workers    map[string]*PartitionWorker
+	stats      map[string]*partitionDispatchStats
+}
+
+func NewPartitionDispatcher(queue *Queue, partitions *SchedulerPartitionStore) *PartitionDispatcher {
+	return &PartitionDispatcher{
+		queue:      queue,
+		partitions: partitions,
+		workers:    map[string]*PartitionWorker{},
+		stats:      map[string]*partitionDispatchStats{},
+	}
+}
+
+// Route returns the partition a job with this placement should wait in, or
+// "" when no partition rule matches and the in-process worker should run it.
+func (d *PartitionDispatcher) Route(placement JobPlacement, workloadKey string) string {
+	decision := d.partitions.Decide(SchedulerPartitionDecisionInput{
+		Tenant:      placement.Tenant,
+		Environment: placement.Environment,
+		Region:      placement.Region,
+		WorkloadKey: workloadKey,
+	})
+	if decision.RuleID == "" {
+		return ""
+	}
+	return decision.Shard
+}
+
+// Rebalance re-routes pending partitioned jobs after partition rules were
+// added or removed and returns how many moved.
+func (d *PartitionDispatcher) Rebalance() int {
+	return d.queue.RepartitionPending(func(j Job) string {
+		if j.Placement != nil {
+			// Topology-placed jobs keep the partition chosen for their workers.
+			return j.Partition
+		}
+		return d.Route(JobPlacement{Tenant: j.Tenant, Environment: j.Environment, Region: j.Region}, j.ConfigPath)
+	})
+}
+
+// RegisterWorker adds or updates a worker. Jobs it holds in partitions it no
+// longer serves are returned to their partitions.
+func (d *PartitionDispatcher) RegisterWorker(in PartitionWorkerInput) (PartitionWorker, error) {
+	id := strings.TrimSpace(in.ID)
+	if id == "" {
+		return PartitionWorker{}, errors.New("id is required")
+	}
+	partitions := normalizeWorkerPartitions(in.Partitions)
+	if len(partitions) == 0 {
+		return PartitionWorker{}, errors.New("at least one partition is required")
+	}
+	ttl := in.TTLSeconds
+	if ttl <= 0 {
+		ttl = 30
+	}
+	now := time.Now().UTC()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w, ok := d.workers[id]
+	if !ok {
+		w = &PartitionWorker{ID: id, RegisteredAt: now}
+		d.workers[id] = w
+	}
+	w.Partitions = partitions
+	w.TTLSeconds = ttl
+	w.WorkerTopology = normalizeWorkerTopology(in.WorkerTopology)
+	w.LastHeartbeat = now
+	w.ExpiresAt = now.Add(time.Duration(ttl) * time.Second)
+	if ok {
+		d.countRequeuedLocked(d.queue.ReleaseWorkerJobs(id, func(partition string) bool {
+			return containsString(partitions, partition)
+		}))
+	}
+	return clonePartitionWorker(*w), nil
+}
+
+func (d *PartitionDispatcher) Heartbeat(id string) (PartitionWorker, error) {
+	now := time.Now().UTC()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expireLocked(now)
+	w, ok := d.workers[strings.TrimSpace(id)]
+	if !ok {
+		return PartitionWorker{}, errors.New("worker not registered")
+	}
+	w.LastHeartbeat = now
+	w.ExpiresAt = now.Add(time.Duration(w.TTLSeconds) * time.Second)
+	return clonePartitionWorker(*w), nil
+}
+
+// Deregister removes a worker and returns its claimed jobs to their
+// partitions.
+func (d *PartitionDispatcher) Deregister(id string) ([]Job, error) {
+	id = strings.TrimSpace(id)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.workers[id]; !ok {
+		return nil, errors.New("worker not registered")
+	}
+	delete(d.workers, id)
+	released := d.queue.ReleaseWorkerJobs(id, nil)
+	d.countRequeuedLocked(released)
+	return released, nil
+}
+
+func (d *PartitionDispatcher) Get(id string) (PartitionWorker, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expireLocked(time.Now().UTC())
+	w, ok := d.workers[strings.TrimSpace(id)]
+	if !ok {
+		return PartitionWorker{}, false
+	}
+	return clonePartitionWorker(*w), true
+}
+
+func (d *PartitionDispatcher) Workers() []PartitionWorker {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expireLocked(time.Now().UTC())
+	out := make([]PartitionWorker, 0, len(d.workers))
+	for _, w := range d.workers {
+		out = append(out, clonePartitionWorker(*w))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Claim hands the worker the oldest pending job across its partitions that
+// are below their rule's max_parallel. Claiming also counts as a heartbeat.
+func (d *PartitionDispatcher) Claim(workerID string) (Job, bool, error) {
+	now := time.Now().UTC()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expireLocked(now)
+	w, ok := d.workers[strings.TrimSpace(workerID)]
+	if !ok {
+		return Job{}, false, errors.New("worker not registered")
+	}
+	w.LastHeartbeat = now
+	w.ExpiresAt = now.Add(time.Duration(w.TTLSeconds) * time.Second)
+
+	backlog := d.queue.PartitionBacklog()
+	eligible := make([]string, 0, len(w.Partitions))
+	for _, partition := range w.Partitions {
+		if limit := d.partitions.ShardMaxParallel(partition); limit > 0 && backlog[partition].Running >= limit {
+			continue
+		}
+		eligible = append(eligible, partition)
+	}
+	worker := clonePartitionWorker(*w)
+	job, ok := d.queue.ClaimPartitionedFor(w.ID, eligible, func(j Job) bool {
+		return j.Placement.Allows(worker)
+	})
+	if !ok {
+		return Job{}, false, nil
+	}
+	st := d.statsLocked(job.Partition)
+	st.claimed++
+	st.claimWaitMS += job.StartedAt.Sub(job.CreatedAt).Milliseconds()
+	return job, true, nil
+}
+
+// Complete records the result a worker reports for a claimed job.
+func (d *PartitionDispatcher) Complete(workerID, jobID, errMsg string) (Job, error) {
+	job, err := d.queue.CompletePartitioned(jobID, workerID, errMsg)
+	if err != nil {
+		return Job{}, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := d.statsLocked(job.Partition)
+	if job.Status == JobSucceeded {
+		st.succeeded++
+	} else {
+		st.failed++
+	}
+	return job, nil
+}
+
+// Lag reports dispatch metrics for every partition with jobs, workers, or
+// history, sorted by partition.
+func (d *PartitionDispatcher) Lag() []PartitionLag {
+	now := time.Now().UTC()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expireLocked(now)
+	backlog := d.queue.PartitionBacklog()
+	names := map[string]struct{}{}
+	for name := range backlog {
+		names[name] = struct{}{}
+	}
+	for name := range d.stats {
+		names[name] = struct{}{}
+	}
+	workers := map[string]int{}
+	for _, w := range d.workers {
+		for _, partition := range w.Partitions {
+			workers[partition]++
+			names[partition] = struct{}{}
+		}
+	}
+	out := make([]PartitionLag, 0, len(names))
+	for name := range names {
+		item := PartitionLag{
+			Partition:   name,
+			Workers:     workers[name],
+			MaxParallel: d.partitions.ShardMaxParallel(name),
+			Pending:     backlog[name].Pending,
+			Running:     backlog[name].Running,
+		}
+		if oldest := backlog[name].OldestPending; !oldest.IsZero() {
+			item.LagSeconds = now.Sub(oldest).Seconds()
+		}
+		if st := d.stats[name]; st != nil {
+			item.Claimed = st.claimed
+			item.Succeeded = st.succeeded
+			item.Failed = st.failed
+			item.Requeued = st.requeued
+			if st.claimed > 0 {
+				item.AvgClaimWaitMS = st.claimWaitMS / st.claimed
+			}
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Partition < out[j].Partition })
+	return out
+}
+
+func (d *PartitionDispatcher) expireLocked(now time.Time) {
+	for id, w := range d.workers {
+		if now.Before(w.ExpiresAt) {
+			continue
+		}
+		delete(d.workers, id)
+		d.countRequeuedLocked(d.queue.ReleaseWorkerJobs(id, nil))
+	}
+}
+
+func (d *PartitionDispatcher) countRequeuedLocked(jobs []Job) {
+	for _, j := range jobs {
+		d.statsLocked(j.Partition).requeued++
+	}
+}
+
+func (d *PartitionDispatcher) statsLocked(partition string) *partitionDispatchStats {
+	st, ok := d.stats[partition]
+	if !ok {
+		st = &partitionDispatchStats{}
+		d.stats[partition] = st
+	}
+	return st
+}
+
+func normalizeWorkerPartitions(in []string) []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(in))
+	for _, partition := range in {
+		partition = strings.ToLower(strings.TrimSpace(partition))
+		if partition == "" {
+			continue
+		}
+		if _, ok := seen[partition]; ok {
+			continue
+		}
+		seen[partition] = struct{}{}
+		out = append(out, partition)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func clonePartitionWorker(in PartitionWorker) PartitionWorker {
+	out := in
+	out.Partitions = append([]string{}, in.Partitions...)
+	return out
+}
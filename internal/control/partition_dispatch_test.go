@@ -0,0 +1,159 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPartitionDispatchRoutesJobsToPartitionWorkers(t *testing.T) {
+	q := NewQueue(8)
+	partitions := NewSchedulerPartitionStore()
+	rule, err := partitions.Upsert(SchedulerPartitionRuleInput{Tenant: "payments", Region: "us-east-1", Shard: "east", MaxParallel: 1})
+	if err != nil {
+		t.Fatalf("upsert partition rule failed: %v", err)
+	}
+	d := NewPartitionDispatcher(q, partitions)
+
+	placement := JobPlacement{Tenant: "payments", Region: "us-east-1"}
+	placement.Partition = d.Route(placement, "a.yaml")
+	if placement.Partition != "east" {
+		t.Fatalf("expected rule-backed partition, got %q", placement.Partition)
+	}
+	if d.Route(JobPlacement{Tenant: "other"}, "a.yaml") != "" {
+		t.Fatalf("expected unmatched tenant to stay on the in-process worker")
+	}
+	first, _ := q.EnqueuePlaced(placement, "a.yaml", "", false, "normal")
+	second, _ := q.EnqueuePlaced(placement, "b.yaml", "", false, "normal")
+
+	if _, err := d.RegisterWorker(PartitionWorkerInput{ID: "west-1", Partitions: []string{"west"}}); err != nil {
+		t.Fatalf("register worker failed: %v", err)
+	}
+	if _, ok, _ := d.Claim("west-1"); ok {
+		t.Fatalf("expected worker for another partition to get nothing")
+	}
+	if _, err := d.RegisterWorker(PartitionWorkerInput{ID: "east-1", Partitions: []string{"East"}}); err != nil {
+		t.Fatalf("register worker failed: %v", err)
+	}
+	job, ok, err := d.Claim("east-1")
+	if err != nil || !ok || job.ID != first.ID || job.Worker != "east-1" {
+		t.Fatalf("expected east worker to claim oldest job, got ok=%v err=%v job=%+v", ok, err, job)
+	}
+	if _, ok, _ := d.Claim("east-1"); ok {
+		t.Fatalf("expected max_parallel to hold back the second job")
+	}
+
+	// The worker leaves mid-run; its job goes back to the partition.
+	released, err := d.Deregister("east-1")
+	if err != nil || len(released) != 1 || released[0].ID != first.ID {
+		t.Fatalf("expected claimed job to be released, got %+v err=%v", released, err)
+	}
+	_, _ = d.RegisterWorker(PartitionWorkerInput{ID: "east-2", Partitions: []string{"east"}})
+	job, ok, _ = d.Claim("east-2")
+	if !ok || job.ID != first.ID {
+		t.Fatalf("expected released job to be claimed first, got %+v", job)
+	}
+	if _, err := d.Complete("east-1", job.ID, ""); err == nil {
+		t.Fatalf("expected completion from another worker to fail")
+	}
+	if done, err := d.Complete("east-2", job.ID, ""); err != nil || done.Status != JobSucceeded {
+		t.Fatalf("complete failed: %+v err=%v", done, err)
+	}
+
+	var lag PartitionLag
+	for _, item := range d.Lag() {
+		if item.Partition == "east" {
+			lag = item
+		}
+	}
+	if lag.Workers != 1 || lag.Pending != 1 || lag.Claimed != 2 || lag.Succeeded != 1 || lag.Requeued != 1 || lag.MaxParallel != 1 {
+		t.Fatalf("unexpected partition lag: %+v", lag)
+	}
+
+	// Removing the rule sends the pending job back to the in-process worker.
+	partitions.Delete(rule.ID)
+	if moved := d.Rebalance(); moved != 1 {
+		t.Fatalf("expected one job to be rebalanced, got %d", moved)
+	}
+	if got, _ := q.Get(second.ID); got.Partition != "" || q.ControlStatus().PendingNormal != 1 {
+		t.Fatalf("expected job to move to the local queue, got %+v", got)
+	}
+}
+
+func TestPartitionDispatchExpiresSilentWorkers(t *testing.T) {
+	q := NewQueue(8)
+	partitions := NewSchedulerPartitionStore()
+	_, _ = partitions.Upsert(SchedulerPartitionRuleInput{Tenant: "payments", Shard: "east"})
+	d := NewPartitionDispatcher(q, partitions)
+	job, _ := q.EnqueuePlaced(JobPlacement{Tenant: "payments", Partition: "east"}, "a.yaml", "", false, "normal")
+	_, _ = d.RegisterWorker(PartitionWorkerInput{ID: "east-1", Partitions: []string{"east"}, TTLSeconds: 1})
+	if _, ok, _ := d.Claim("east-1"); !ok {
+		t.Fatalf("expected claim to succeed")
+	}
+
+	d.mu.Lock()
+	d.expireLocked(time.Now().UTC().Add(2 * time.Second))
+	d.mu.Unlock()
+	if got, _ := q.Get(job.ID); got.Status != JobPending || got.Worker != "" {
+		t.Fatalf("expected expired worker's job to be pending again, got %+v", got)
+	}
+	if _, _, err := d.Claim("east-1"); err == nil {
+		t.Fatalf("expected expired worker to be unregistered")
+	}
+}
+
+func TestPartitionDispatchHonoursTopologyPlacement(t *testing.T) {
+	q := NewQueue(8)
+	d := NewPartitionDispatcher(q, NewSchedulerPartitionStore())
+	topology := NewTopologyPlacementStore()
+	policy, err := topology.Upsert(TopologyPlacementPolicyInput{Environment: "prod", Region: "eu-west-1", DataResidency: true, MaxLatencyMS: 50})
+	if err != nil {
+		t.Fatalf("upsert topology policy failed: %v", err)
+	}
+	if _, err := topology.Upsert(TopologyPlacementPolicyInput{Environment: "prod", DataResidency: true}); err == nil {
+		t.Fatalf("expected data residency without region to be rejected")
+	}
+
+	_, _ = d.RegisterWorker(PartitionWorkerInput{ID: "us-1", Partitions: []string{"shared"}, WorkerTopology: WorkerTopology{Region: "us-east-1", LatencyMS: 5}})
+	_, _ = d.RegisterWorker(PartitionWorkerInput{ID: "eu-slow", Partitions: []string{"eu-b"}, WorkerTopology: WorkerTopology{Region: "EU-West-1", LatencyMS: 120}})
+	_, _ = d.RegisterWorker(PartitionWorkerInput{ID: "eu-1", Partitions: []string{"eu-a"}, WorkerTopology: WorkerTopology{Region: "eu-west-1", LatencyMS: 20}})
+
+	partition, placement := d.PlaceTopology(policy, "prod", "shared")
+	if partition != "eu-a" || placement.PreferredWorker != "eu-1" || len(placement.EligibleWorkers) != 1 {
+		t.Fatalf("expected residency and latency to pick eu-1, got partition=%q placement=%+v", partition, placement)
+	}
+	if len(placement.Explanation) != 4 {
+		t.Fatalf("expected policy, residency, latency, and worker explanation, got %v", placement.Explanation)
+	}
+	job, _ := q.EnqueuePlaced(JobPlacement{Environment: "prod", Partition: partition, Topology: placement}, "a.yaml", "", false, "normal")
+	if job.Placement == nil || job.Placement.PolicyID != policy.ID {
+		t.Fatalf("expected placement recorded on job, got %+v", job.Placement)
+	}
+
+	// A worker outside the region never claims the job, even when it serves
+	// the partition.
+	_, _ = d.RegisterWorker(PartitionWorkerInput{ID: "us-2", Partitions: []string{"eu-a"}, WorkerTopology: WorkerTopology{Region: "us-east-1"}})
+	if _, ok, _ := d.Claim("us-2"); ok {
+		t.Fatalf("expected out-of-region worker to get nothing")
+	}
+
+	// An operator override pins the job to the named worker.
+	if _, err := q.OverridePlacement(job.ID, "eu-a", JobPlacementOverride{Worker: "us-2", Reason: "eu capacity outage", By: "oncall"}); err != nil {
+		t.Fatalf("override failed: %v", err)
+	}
+	if _, ok, _ := d.Claim("eu-1"); ok {
+		t.Fatalf("expected override to hold the job for us-2")
+	}
+	claimed, ok, _ := d.Claim("us-2")
+	if !ok || claimed.ID != job.ID || claimed.Placement.Override == nil || claimed.Placement.Override.By != "oncall" {
+		t.Fatalf("expected overridden job claimed by us-2, got ok=%v job=%+v", ok, claimed)
+	}
+	if _, err := q.OverridePlacement(job.ID, "eu-a", JobPlacementOverride{Reason: "late"}); err == nil {
+		t.Fatalf("expected running job override to be rejected")
+	}
+
+	// Without an eligible worker the job waits in the policy's partition.
+	d2 := NewPartitionDispatcher(NewQueue(8), NewSchedulerPartitionStore())
+	if partition, placement := d2.PlaceTopology(policy, "prod", ""); partition != policy.ID || placement.PreferredWorker != "" {
+		t.Fatalf("expected job to wait in policy partition, got %q %+v", partition, placement)
+	}
+}
@@ -39,6 +39,7 @@ type PatchPlanInput struct {
 	HourUTC        int         `json:"hour_utc"`
 	Hosts          []PatchHost `json:"hosts"`
 	RebootApproved bool        `json:"reboot_approved"`
+	Service        string      `json:"service,omitempty"`
 }
 
 type PatchWave struct {
@@ -56,6 +57,7 @@ type PatchPlan struct {
 	BlockedReason        string      `json:"blocked_reason,omitempty"`
 	RebootApprovalNeeded bool        `json:"reboot_approval_needed"`
 	Waves                []PatchWave `json:"waves,omitempty"`
+	DisruptionClaim      string      `json:"disruption_claim_id,omitempty"`
 }
 
 type PatchManagementStore struct {
@@ -0,0 +1,113 @@
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+const (
+	PayloadContentTypeJSON = "application/json"
+	PayloadContentTypeText = "text/plain"
+	PayloadContentTypeForm = "application/x-www-form-urlencoded"
+	PayloadContentTypeXML  = "application/xml"
+)
+
+var payloadTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+	"quote": func(v any) (string, error) {
+		if v == nil {
+			return `""`, nil
+		}
+		b, err := json.Marshal(fmt.Sprint(v))
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"default": func(def, v any) any {
+		if v == nil {
+			return def
+		}
+		if s, ok := v.(string); ok && s == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// ValidatePayloadTemplate parses a delivery payload template so invalid
+// templates are rejected at registration instead of at dispatch time.
+func ValidatePayloadTemplate(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	_, err := parsePayloadTemplate(raw)
+	return err
+}
+
+// RenderPayloadTemplate renders a delivery payload. An empty template falls
+// back to the JSON encoding of data.
+func RenderPayloadTemplate(raw string, data any) ([]byte, error) {
+	if strings.TrimSpace(raw) == "" {
+		return json.Marshal(data)
+	}
+	tpl, err := parsePayloadTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, payloadTemplateData(data)); err != nil {
+		return nil, errors.New("render payload template: " + err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+func parsePayloadTemplate(raw string) (*template.Template, error) {
+	tpl, err := template.New("payload").Option("missingkey=zero").Funcs(payloadTemplateFuncs).Parse(raw)
+	if err != nil {
+		return nil, errors.New("invalid payload_template: " + err.Error())
+	}
+	return tpl, nil
+}
+
+// payloadTemplateData round-trips data through JSON so templates address
+// fields by their wire names (e.g. {{.type}}, {{.fields.job_id}}).
+func payloadTemplateData(data any) any {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return data
+	}
+	return out
+}
+
+func normalizePayloadContentType(contentType string) (string, error) {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	switch ct {
+	case "", "json", PayloadContentTypeJSON:
+		return PayloadContentTypeJSON, nil
+	case "text", PayloadContentTypeText:
+		return PayloadContentTypeText, nil
+	case "form", PayloadContentTypeForm:
+		return PayloadContentTypeForm, nil
+	case "xml", PayloadContentTypeXML:
+		return PayloadContentTypeXML, nil
+	default:
+		return "", errors.New("content_type must be application/json, text/plain, application/x-www-form-urlencoded, or application/xml")
+	}
+}
@@ -14,30 +14,99 @@ const (
 	PolicyEnforcementApplyAndAutocorrect = "apply-and-autocorrect"
 )
 
+// policyModeLadder orders modes from least to most enforcing; scheduled
+// promotion and spike demotion move one rung at a time.
+var policyModeLadder = []string{
+	PolicyEnforcementAudit,
+	PolicyEnforcementApplyAndMonitor,
+	PolicyEnforcementApplyAndAutocorrect,
+}
+
 type PolicyEnforcementModeInput struct {
 	PolicyRef string `json:"policy_ref"`
 	Mode      string `json:"mode"`
 	Reason    string `json:"reason,omitempty"`
 	UpdatedBy string `json:"updated_by,omitempty"`
+	// Promotion and Exemptions replace the policy's current settings when
+	// set and are left unchanged when omitted.
+	Promotion  *PolicyPromotionSchedule `json:"promotion,omitempty"`
+	Exemptions []PolicyExemption        `json:"exemptions,omitempty"`
+}
+
+// PolicyPromotionSchedule promotes a policy one mode after CleanDays without
+// violations, up to MaxMode, and demotes it one mode when
+// DemoteViolations violations land within DemoteWindowHours.
+type PolicyPromotionSchedule struct {
+	Enabled           bool   `json:"enabled"`
+	CleanDays         int    `json:"clean_days,omitempty"`
+	MaxMode           string `json:"max_mode,omitempty"`
+	DemoteViolations  int    `json:"demote_violations,omitempty"`
+	DemoteWindowHours int    `json:"demote_window_hours,omitempty"`
+}
+
+// PolicyExemption excludes a subject (node, resource, or team) from a
+// policy's violations until ExpiresAt, when set.
+type PolicyExemption struct {
+	Subject   string    `json:"subject"`
+	Reason    string    `json:"reason,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
 }
 
 type PolicyEnforcementMode struct {
-	ID        string    `json:"id"`
+	ID              string                   `json:"id"`
+	PolicyRef       string                   `json:"policy_ref"`
+	Mode            string                   `json:"mode"`
+	Reason          string                   `json:"reason,omitempty"`
+	UpdatedBy       string                   `json:"updated_by,omitempty"`
+	Promotion       *PolicyPromotionSchedule `json:"promotion,omitempty"`
+	Exemptions      []PolicyExemption        `json:"exemptions,omitempty"`
+	CleanSince      time.Time                `json:"clean_since"`
+	LastViolationAt time.Time                `json:"last_violation_at,omitempty"`
+	NextPromotionAt time.Time                `json:"next_promotion_at,omitempty"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+}
+
+// PolicyModeTransition is one entry of a policy's mode timeline.
+type PolicyModeTransition struct {
 	PolicyRef string    `json:"policy_ref"`
-	Mode      string    `json:"mode"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Trigger   string    `json:"trigger"` // manual, promotion, demotion
 	Reason    string    `json:"reason,omitempty"`
-	UpdatedBy string    `json:"updated_by,omitempty"`
-	UpdatedAt time.Time `json:"updated_at"`
+	By        string    `json:"by,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+type PolicyViolationInput struct {
+	PolicyRef string    `json:"policy_ref"`
+	Subject   string    `json:"subject,omitempty"`
+	Count     int       `json:"count,omitempty"`
+	At        time.Time `json:"at,omitempty"`
+}
+
+type PolicyViolationResult struct {
+	PolicyRef string                `json:"policy_ref"`
+	Subject   string                `json:"subject,omitempty"`
+	Exempt    bool                  `json:"exempt"`
+	Mode      string                `json:"mode"`
+	Recent    int                   `json:"recent_violations"`
+	Demotion  *PolicyModeTransition `json:"demotion,omitempty"`
 }
 
 type PolicyEnforcementStore struct {
-	mu     sync.RWMutex
-	nextID int64
-	items  map[string]PolicyEnforcementMode
+	mu         sync.RWMutex
+	nextID     int64
+	items      map[string]PolicyEnforcementMode
+	violations map[string][]time.Time
+	timeline   map[string][]PolicyModeTransition
 }
 
 func NewPolicyEnforcementStore() *PolicyEnforcementStore {
-	return &PolicyEnforcementStore{items: map[string]PolicyEnforcementMode{}}
+	return &PolicyEnforcementStore{
+		items:      map[string]PolicyEnforcementMode{},
+		violations: map[string][]time.Time{},
+		timeline:   map[string][]PolicyModeTransition{},
+	}
 }
 
 func (s *PolicyEnforcementStore) Upsert(in PolicyEnforcementModeInput) (PolicyEnforcementMode, error) {
@@ -47,26 +116,77 @@ func (s *PolicyEnforcementStore) Upsert(in PolicyEnforcementModeInput) (PolicyEn
 	}
 	mode := normalizePolicyMode(in.Mode)
 	if mode == "" {
-		return PolicyEnforcementMode{}, errors.New("mode must be one of audit, apply-and-monitor, apply-and-autocorrect")
+		return PolicyEnforcementMode{}, errors.New("mode must be one of audit, apply-and-monitor (warn), apply-and-autocorrect (enforce)")
+	}
+	var promotion *PolicyPromotionSchedule
+	if in.Promotion != nil {
+		p := *in.Promotion
+		if p.CleanDays < 0 || p.DemoteViolations < 0 || p.DemoteWindowHours < 0 {
+			return PolicyEnforcementMode{}, errors.New("promotion values must not be negative")
+		}
+		if p.CleanDays == 0 {
+			p.CleanDays = 7
+		}
+		if p.MaxMode == "" {
+			p.MaxMode = PolicyEnforcementApplyAndAutocorrect
+		}
+		if p.MaxMode = normalizePolicyMode(p.MaxMode); p.MaxMode == "" {
+			return PolicyEnforcementMode{}, errors.New("promotion max_mode must be a valid mode")
+		}
+		if p.DemoteViolations > 0 && p.DemoteWindowHours == 0 {
+			p.DemoteWindowHours = 24
+		}
+		promotion = &p
+	}
+	var exemptions []PolicyExemption
+	if in.Exemptions != nil {
+		exemptions = make([]PolicyExemption, 0, len(in.Exemptions))
+		for _, ex := range in.Exemptions {
+			ex.Subject = strings.ToLower(strings.TrimSpace(ex.Subject))
+			if ex.Subject == "" {
+				return PolicyEnforcementMode{}, errors.New("exemption subject is required")
+			}
+			ex.Reason = strings.TrimSpace(ex.Reason)
+			exemptions = append(exemptions, ex)
+		}
 	}
 	now := time.Now().UTC()
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	current, ok := s.items[policyRef]
 	if !ok {
 		s.nextID++
 		current = PolicyEnforcementMode{
-			ID:        "polmode-" + itoa(s.nextID),
-			PolicyRef: policyRef,
+			ID:         "polmode-" + itoa(s.nextID),
+			PolicyRef:  policyRef,
+			CleanSince: now,
 		}
 	}
+	if current.Mode != mode {
+		s.recordTransitionLocked(PolicyModeTransition{
+			PolicyRef: policyRef,
+			From:      current.Mode,
+			To:        mode,
+			Trigger:   "manual",
+			Reason:    strings.TrimSpace(in.Reason),
+			By:        strings.TrimSpace(in.UpdatedBy),
+			At:        now,
+		})
+		current.CleanSince = now
+	}
 	current.Mode = mode
 	current.Reason = strings.TrimSpace(in.Reason)
 	current.UpdatedBy = strings.TrimSpace(in.UpdatedBy)
 	current.UpdatedAt = now
+	if in.Promotion != nil {
+		current.Promotion = promotion
+	}
+	if in.Exemptions != nil {
+		current.Exemptions = exemptions
+	}
+	current.NextPromotionAt = nextPolicyPromotion(current)
 	s.items[policyRef] = current
-	return current, nil
+	return clonePolicyEnforcementMode(current), nil
 }
 
 func (s *PolicyEnforcementStore) Get(policyRef string) (PolicyEnforcementMode, bool) {
@@ -76,14 +196,14 @@ func (s *PolicyEnforcementStore) Get(policyRef string) (PolicyEnforcementMode, b
 	if !ok {
 		return PolicyEnforcementMode{}, false
 	}
-	return item, true
+	return clonePolicyEnforcementMode(item), true
 }
 
 func (s *PolicyEnforcementStore) List() []PolicyEnforcementMode {
 	s.mu.RLock()
 	out := make([]PolicyEnforcementMode, 0, len(s.items))
 	for _, item := range s.items {
-		out = append(out, item)
+		out = append(out, clonePolicyEnforcementMode(item))
 	}
 	s.mu.RUnlock()
 	sort.Slice(out, func(i, j int) bool {
@@ -95,11 +215,192 @@ func (s *PolicyEnforcementStore) List() []PolicyEnforcementMode {
 	return out
 }
 
+// Exempt reports whether subject holds an unexpired exemption from policyRef.
+func (s *PolicyEnforcementStore) Exempt(policyRef, subject string, now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.items[strings.TrimSpace(policyRef)]
+	return ok && policyExempt(item, subject, now)
+}
+
+// RecordViolation resets the policy's clean streak and demotes it one mode
+// when the violations inside the demotion window reach the spike threshold.
+// Violations by exempt subjects are reported but not counted.
+func (s *PolicyEnforcementStore) RecordViolation(in PolicyViolationInput) (PolicyViolationResult, error) {
+	policyRef := strings.TrimSpace(in.PolicyRef)
+	if policyRef == "" {
+		return PolicyViolationResult{}, errors.New("policy_ref is required")
+	}
+	if in.Count < 0 {
+		return PolicyViolationResult{}, errors.New("count must not be negative")
+	}
+	if in.Count == 0 {
+		in.Count = 1
+	}
+	at := in.At.UTC()
+	if in.At.IsZero() {
+		at = time.Now().UTC()
+	}
+	subject := strings.ToLower(strings.TrimSpace(in.Subject))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[policyRef]
+	if !ok {
+		return PolicyViolationResult{}, errors.New("policy enforcement mode not found")
+	}
+	out := PolicyViolationResult{PolicyRef: policyRef, Subject: subject, Mode: item.Mode}
+	if policyExempt(item, subject, at) {
+		out.Exempt = true
+		return out, nil
+	}
+	window := 24 * time.Hour
+	if item.Promotion != nil && item.Promotion.DemoteWindowHours > 0 {
+		window = time.Duration(item.Promotion.DemoteWindowHours) * time.Hour
+	}
+	recent := make([]time.Time, 0, len(s.violations[policyRef])+in.Count)
+	for _, ts := range s.violations[policyRef] {
+		if at.Sub(ts) < window {
+			recent = append(recent, ts)
+		}
+	}
+	for i := 0; i < in.Count; i++ {
+		recent = append(recent, at)
+	}
+	item.CleanSince = at
+	item.LastViolationAt = at
+	out.Recent = len(recent)
+
+	if p := item.Promotion; p != nil && p.Enabled && p.DemoteViolations > 0 && len(recent) >= p.DemoteViolations {
+		if lower := policyModeStep(item.Mode, -1); lower != "" {
+			tr := PolicyModeTransition{
+				PolicyRef: policyRef,
+				From:      item.Mode,
+				To:        lower,
+				Trigger:   "demotion",
+				Reason:    itoa(int64(len(recent))) + " violations within " + window.String(),
+				At:        at,
+			}
+			s.recordTransitionLocked(tr)
+			item.Mode = lower
+			item.UpdatedAt = at
+			recent = nil
+			out.Demotion = &tr
+		}
+	}
+	s.violations[policyRef] = recent
+	item.NextPromotionAt = nextPolicyPromotion(item)
+	s.items[policyRef] = item
+	out.Mode = item.Mode
+	return out, nil
+}
+
+// PromoteDue promotes every policy whose schedule is enabled, which is
+// below its max mode, and which has been clean for its CleanDays.
+func (s *PolicyEnforcementStore) PromoteDue(now time.Time) []PolicyModeTransition {
+	now = now.UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]PolicyModeTransition, 0)
+	for ref, item := range s.items {
+		due := nextPolicyPromotion(item)
+		if due.IsZero() || now.Before(due) {
+			continue
+		}
+		tr := PolicyModeTransition{
+			PolicyRef: ref,
+			From:      item.Mode,
+			To:        policyModeStep(item.Mode, 1),
+			Trigger:   "promotion",
+			Reason:    "no violations for " + itoa(int64(item.Promotion.CleanDays)) + " days",
+			At:        now,
+		}
+		s.recordTransitionLocked(tr)
+		item.Mode = tr.To
+		item.CleanSince = now
+		item.UpdatedAt = now
+		item.NextPromotionAt = nextPolicyPromotion(item)
+		s.items[ref] = item
+		out = append(out, tr)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PolicyRef < out[j].PolicyRef })
+	return out
+}
+
+// Timeline returns policyRef's mode changes, oldest first.
+func (s *PolicyEnforcementStore) Timeline(policyRef string) []PolicyModeTransition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]PolicyModeTransition{}, s.timeline[strings.TrimSpace(policyRef)]...)
+}
+
+func (s *PolicyEnforcementStore) recordTransitionLocked(tr PolicyModeTransition) {
+	items := append(s.timeline[tr.PolicyRef], tr)
+	if len(items) > 500 {
+		items = items[len(items)-500:]
+	}
+	s.timeline[tr.PolicyRef] = items
+}
+
+func nextPolicyPromotion(item PolicyEnforcementMode) time.Time {
+	p := item.Promotion
+	if p == nil || !p.Enabled || policyModeRank(item.Mode) >= policyModeRank(p.MaxMode) {
+		return time.Time{}
+	}
+	return item.CleanSince.Add(time.Duration(p.CleanDays) * 24 * time.Hour)
+}
+
+func policyExempt(item PolicyEnforcementMode, subject string, now time.Time) bool {
+	subject = strings.ToLower(strings.TrimSpace(subject))
+	if subject == "" {
+		return false
+	}
+	for _, ex := range item.Exemptions {
+		if ex.Subject == subject && (ex.ExpiresAt.IsZero() || now.Before(ex.ExpiresAt)) {
+			return true
+		}
+	}
+	return false
+}
+
+func policyModeRank(mode string) int {
+	for i, m := range policyModeLadder {
+		if m == mode {
+			return i
+		}
+	}
+	return -1
+}
+
+// policyModeStep returns the mode delta rungs away from mode, or "" past
+// either end of the ladder.
+func policyModeStep(mode string, delta int) string {
+	i := policyModeRank(mode) + delta
+	if i < 0 || i >= len(policyModeLadder) {
+		return ""
+	}
+	return policyModeLadder[i]
+}
+
+func clonePolicyEnforcementMode(in PolicyEnforcementMode) PolicyEnforcementMode {
+	out := in
+	if in.Promotion != nil {
+		p := *in.Promotion
+		out.Promotion = &p
+	}
+	out.Exemptions = append([]PolicyExemption(nil), in.Exemptions...)
+	return out
+}
+
 func normalizePolicyMode(mode string) string {
 	mode = strings.ToLower(strings.TrimSpace(mode))
 	switch mode {
 	case PolicyEnforcementAudit, PolicyEnforcementApplyAndMonitor, PolicyEnforcementApplyAndAutocorrect:
 		return mode
+	case "warn":
+		return PolicyEnforcementApplyAndMonitor
+	case "enforce":
+		return PolicyEnforcementApplyAndAutocorrect
 	default:
 		return ""
 	}
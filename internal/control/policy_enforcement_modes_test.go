@@ -1,6 +1,9 @@
 package control
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestPolicyEnforcementStoreUpsertAndList(t *testing.T) {
 	store := NewPolicyEnforcementStore()
@@ -41,3 +44,52 @@ func TestPolicyEnforcementStoreValidation(t *testing.T) {
 		t.Fatalf("expected invalid mode validation error")
 	}
 }
+
+func TestPolicyEnforcementStorePromotionDemotionAndExemptions(t *testing.T) {
+	store := NewPolicyEnforcementStore()
+	item, err := store.Upsert(PolicyEnforcementModeInput{
+		PolicyRef:  "policy/prod",
+		Mode:       "audit",
+		Promotion:  &PolicyPromotionSchedule{Enabled: true, CleanDays: 3, MaxMode: "enforce", DemoteViolations: 2},
+		Exemptions: []PolicyExemption{{Subject: "Legacy-1", Reason: "pending decommission"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Promotion.DemoteWindowHours != 24 || item.Promotion.MaxMode != PolicyEnforcementApplyAndAutocorrect || item.NextPromotionAt.IsZero() {
+		t.Fatalf("unexpected promotion defaults %+v", item)
+	}
+
+	start := item.CleanSince
+	if got := store.PromoteDue(start.Add(48 * time.Hour)); len(got) != 0 {
+		t.Fatalf("expected no promotion before clean days elapse, got %+v", got)
+	}
+	got := store.PromoteDue(start.Add(73 * time.Hour))
+	if len(got) != 1 || got[0].From != "audit" || got[0].To != "apply-and-monitor" {
+		t.Fatalf("expected audit -> warn promotion, got %+v", got)
+	}
+
+	// A violation restarts the clean streak.
+	res, err := store.RecordViolation(PolicyViolationInput{PolicyRef: "policy/prod", Subject: "web-1", At: start.Add(100 * time.Hour)})
+	if err != nil || res.Demotion != nil || res.Recent != 1 {
+		t.Fatalf("expected single violation recorded without demotion, got %+v err=%v", res, err)
+	}
+	if got := store.PromoteDue(start.Add(73*time.Hour + 72*time.Hour)); len(got) != 0 {
+		t.Fatalf("expected violation to reset clean streak, got %+v", got)
+	}
+	if res, _ := store.RecordViolation(PolicyViolationInput{PolicyRef: "policy/prod", Subject: "legacy-1", Count: 5, At: start.Add(101 * time.Hour)}); !res.Exempt || res.Recent != 0 {
+		t.Fatalf("expected exempt subject ignored, got %+v", res)
+	}
+	res, _ = store.RecordViolation(PolicyViolationInput{PolicyRef: "policy/prod", Subject: "web-2", At: start.Add(102 * time.Hour)})
+	if res.Demotion == nil || res.Mode != PolicyEnforcementAudit {
+		t.Fatalf("expected violation spike to demote to audit, got %+v", res)
+	}
+
+	timeline := store.Timeline("policy/prod")
+	if len(timeline) != 3 || timeline[0].Trigger != "manual" || timeline[1].Trigger != "promotion" || timeline[2].Trigger != "demotion" {
+		t.Fatalf("unexpected timeline %+v", timeline)
+	}
+	if _, err := store.RecordViolation(PolicyViolationInput{PolicyRef: "policy/missing"}); err == nil {
+		t.Fatalf("expected violation for unknown policy to fail")
+	}
+}
@@ -0,0 +1,638 @@
+package control
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	InTotoPayloadType       = "application/vnd.in-toto+json"
+	InTotoStatementType     = "https://in-toto.io/Statement/v1"
+	SLSAProvenancePredicate = "https://slsa.dev/provenance/v1"
+
+	attestationChainDepthLimit = 8
+)
+
+type AttestationMaterial struct {
+	URI    string `json:"uri"`
+	Digest string `json:"digest,omitempty"` // sha256:<hex>
+}
+
+type ProvenanceAttestationInput struct {
+	SubjectType   string                `json:"subject_type"` // package|image
+	SubjectName   string                `json:"subject_name"`
+	SubjectDigest string                `json:"subject_digest"`
+	SourceRepo    string                `json:"source_repo,omitempty"`
+	SourceRef     string                `json:"source_ref,omitempty"`
+	BuilderID     string                `json:"builder_id"`
+	BuildType     string                `json:"build_type,omitempty"`
+	Materials     []AttestationMaterial `json:"materials,omitempty"`
+	StartedAt     time.Time             `json:"started_at,omitempty"`
+	FinishedAt    time.Time             `json:"finished_at,omitempty"`
+}
+
+type DSSESignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// DSSEEnvelope is the signed wrapper around an in-toto statement.
+type DSSEEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"` // base64 statement
+	Signatures  []DSSESignature `json:"signatures"`
+}
+
+type ProvenanceAttestation struct {
+	ID            string                `json:"id"`
+	SubjectType   string                `json:"subject_type"`
+	SubjectName   string                `json:"subject_name"`
+	SubjectDigest string                `json:"subject_digest"`
+	SourceRepo    string                `json:"source_repo,omitempty"`
+	SourceRef     string                `json:"source_ref,omitempty"`
+	BuilderID     string                `json:"builder_id"`
+	Materials     []AttestationMaterial `json:"materials,omitempty"`
+	KeyID         string                `json:"key_id"`
+	Digest        string                `json:"digest"` // sha256 of the statement
+	Envelope      DSSEEnvelope          `json:"envelope"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+type AttestationKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	PublicKey string    `json:"public_key"` // base64 ed25519
+	Local     bool      `json:"local"`      // held by this control plane for build/bake attestations
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AttestationKeyInput struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+// ProvenanceAttestationPolicy decides which attestations admit an
+// artifact. Builder and source patterns are path.Match globs. RequireChain
+// also demands a passing attestation for every material that carries a
+// digest.
+type ProvenanceAttestationPolicy struct {
+	Required             bool      `json:"required"`
+	RequiredEnvironments []string  `json:"required_environments,omitempty"` // empty means every environment
+	TrustedKeyIDs        []string  `json:"trusted_key_ids,omitempty"`
+	TrustedBuilders      []string  `json:"trusted_builders,omitempty"`
+	AllowedSourceRepos   []string  `json:"allowed_source_repos,omitempty"`
+	RequireMaterials     bool      `json:"require_materials"`
+	RequireChain         bool      `json:"require_chain"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type AttestationVerifyInput struct {
+	Digest      string `json:"digest"`
+	Environment string `json:"environment,omitempty"`
+}
+
+type AttestationVerifyResult struct {
+	Allowed       bool      `json:"allowed"`
+	Digest        string    `json:"digest"`
+	Required      bool      `json:"required"`
+	AttestationID string    `json:"attestation_id,omitempty"`
+	Chain         []string  `json:"chain,omitempty"` // attestation ids that satisfied the policy
+	Reasons       []string  `json:"reasons,omitempty"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     slsaProvenance  `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition struct {
+		BuildType            string            `json:"buildType"`
+		ExternalParameters   map[string]string `json:"externalParameters"`
+		ResolvedDependencies []struct {
+			URI    string            `json:"uri"`
+			Digest map[string]string `json:"digest,omitempty"`
+		} `json:"resolvedDependencies,omitempty"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Metadata struct {
+			InvocationID string    `json:"invocationId,omitempty"`
+			StartedOn    time.Time `json:"startedOn,omitempty"`
+			FinishedOn   time.Time `json:"finishedOn,omitempty"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+type attestationKeyRecord struct {
+	item      AttestationKey
+	publicKey ed25519.PublicKey
+}
+
+// ProvenanceAttestationStore signs SLSA provenance for packages and baked
+// images with a control-plane key, accepts envelopes signed by registered
+// external builders, and verifies an artifact's attestation chain against
+// policy at deployment admission.
+type ProvenanceAttestationStore struct {
+	mu           sync.RWMutex
+	nextID       int64
+	nextKeyID    int64
+	registry     *PackageRegistryStore
+	signer       ed25519.PrivateKey
+	signerKeyID  string
+	keys         map[string]*attestationKeyRecord
+	attestations map[string]*ProvenanceAttestation
+	byDigest     map[string][]string // subject digest -> attestation ids, oldest first
+	policy       ProvenanceAttestationPolicy
+}
+
+func NewProvenanceAttestationStore(registry *PackageRegistryStore) *ProvenanceAttestationStore {
+	s := &ProvenanceAttestationStore{
+		registry:     registry,
+		keys:         map[string]*attestationKeyRecord{},
+		attestations: map[string]*ProvenanceAttestation{},
+		byDigest:     map[string][]string{},
+		policy:       ProvenanceAttestationPolicy{UpdatedAt: time.Now().UTC()},
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err == nil {
+		s.signer = priv
+		s.signerKeyID = s.addKeyLocked("masterchef-builder", pub, true).ID
+	}
+	return s
+}
+
+func (s *ProvenanceAttestationStore) AddKey(in AttestationKeyInput) (AttestationKey, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return AttestationKey{}, errors.New("name is required")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(in.PublicKey))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return AttestationKey{}, errors.New("public_key must be a base64 encoded ed25519 public key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addKeyLocked(name, ed25519.PublicKey(raw), false), nil
+}
+
+func (s *ProvenanceAttestationStore) addKeyLocked(name string, pub ed25519.PublicKey, local bool) AttestationKey {
+	s.nextKeyID++
+	item := AttestationKey{
+		ID:        "attest-key-" + itoa(s.nextKeyID),
+		Name:      name,
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+		Local:     local,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.keys[item.ID] = &attestationKeyRecord{item: item, publicKey: append(ed25519.PublicKey{}, pub...)}
+	return item
+}
+
+func (s *ProvenanceAttestationStore) ListKeys() []AttestationKey {
+	s.mu.RLock()
+	out := make([]AttestationKey, 0, len(s.keys))
+	for _, item := range s.keys {
+		out = append(out, item.item)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *ProvenanceAttestationStore) Policy() ProvenanceAttestationPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneProvenanceAttestationPolicy(s.policy)
+}
+
+func (s *ProvenanceAttestationStore) SetPolicy(policy ProvenanceAttestationPolicy) (ProvenanceAttestationPolicy, error) {
+	policy.RequiredEnvironments = normalizeStringSlice(policy.RequiredEnvironments)
+	for i := range policy.RequiredEnvironments {
+		policy.RequiredEnvironments[i] = strings.ToLower(policy.RequiredEnvironments[i])
+	}
+	policy.TrustedKeyIDs = normalizeStringSlice(policy.TrustedKeyIDs)
+	policy.TrustedBuilders = normalizeStringSlice(policy.TrustedBuilders)
+	policy.AllowedSourceRepos = normalizeStringSlice(policy.AllowedSourceRepos)
+	for _, pattern := range append(append([]string{}, policy.TrustedBuilders...), policy.AllowedSourceRepos...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ProvenanceAttestationPolicy{}, errors.New("invalid pattern " + pattern)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, keyID := range policy.TrustedKeyIDs {
+		if _, ok := s.keys[keyID]; !ok {
+			return ProvenanceAttestationPolicy{}, errors.New("attestation key not found: " + keyID)
+		}
+	}
+	policy.UpdatedAt = time.Now().UTC()
+	s.policy = policy
+	return cloneProvenanceAttestationPolicy(policy), nil
+}
+
+// Attest builds an in-toto statement with a SLSA v1 provenance predicate
+// and signs it with the control-plane builder key.
+func (s *ProvenanceAttestationStore) Attest(in ProvenanceAttestationInput) (ProvenanceAttestation, error) {
+	subjectType := strings.ToLower(strings.TrimSpace(in.SubjectType))
+	if subjectType != ScanArtifactPackage && subjectType != ScanArtifactImage {
+		return ProvenanceAttestation{}, errors.New("subject_type must be package or image")
+	}
+	name := strings.TrimSpace(in.SubjectName)
+	digest := normalizeAttestationDigest(in.SubjectDigest)
+	if name == "" || digest == "" {
+		return ProvenanceAttestation{}, errors.New("subject_name and a sha256 subject_digest are required")
+	}
+	builderID := strings.TrimSpace(in.BuilderID)
+	if builderID == "" {
+		return ProvenanceAttestation{}, errors.New("builder_id is required")
+	}
+	if s.signer == nil {
+		return ProvenanceAttestation{}, errors.New("attestation signing key unavailable")
+	}
+	buildType := strings.TrimSpace(in.BuildType)
+	if buildType == "" {
+		buildType = "https://masterchef.dev/build/" + subjectType + "/v1"
+	}
+
+	var statement inTotoStatement
+	statement.Type = InTotoStatementType
+	statement.PredicateType = SLSAProvenancePredicate
+	statement.Subject = []inTotoSubject{{Name: name, Digest: map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")}}}
+	def := &statement.Predicate.BuildDefinition
+	def.BuildType = buildType
+	def.ExternalParameters = map[string]string{}
+	if repo := strings.TrimSpace(in.SourceRepo); repo != "" {
+		def.ExternalParameters["source"] = repo
+	}
+	if ref := strings.TrimSpace(in.SourceRef); ref != "" {
+		def.ExternalParameters["ref"] = ref
+	}
+	for _, material := range in.Materials {
+		uri := strings.TrimSpace(material.URI)
+		if uri == "" {
+			return ProvenanceAttestation{}, errors.New("material uri is required")
+		}
+		dep := struct {
+			URI    string            `json:"uri"`
+			Digest map[string]string `json:"digest,omitempty"`
+		}{URI: uri}
+		if strings.TrimSpace(material.Digest) != "" {
+			md := normalizeAttestationDigest(material.Digest)
+			if md == "" {
+				return ProvenanceAttestation{}, errors.New("material digest must be sha256:<64-hex>")
+			}
+			dep.Digest = map[string]string{"sha256": strings.TrimPrefix(md, "sha256:")}
+		}
+		def.ResolvedDependencies = append(def.ResolvedDependencies, dep)
+	}
+	run := &statement.Predicate.RunDetails
+	run.Builder.ID = builderID
+	run.Metadata.StartedOn = in.StartedAt.UTC()
+	run.Metadata.FinishedOn = in.FinishedAt.UTC()
+	if run.Metadata.FinishedOn.IsZero() {
+		run.Metadata.FinishedOn = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return ProvenanceAttestation{}, err
+	}
+	sig := ed25519.Sign(s.signer, dssePAE(InTotoPayloadType, payload))
+	return s.store(DSSEEnvelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []DSSESignature{{KeyID: s.signerKeyID, Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, subjectType)
+}
+
+// Import records an envelope signed by a registered builder key, e.g. one
+// produced by an external CI system.
+func (s *ProvenanceAttestationStore) Import(subjectType string, envelope DSSEEnvelope) (ProvenanceAttestation, error) {
+	subjectType = strings.ToLower(strings.TrimSpace(subjectType))
+	if subjectType != ScanArtifactPackage && subjectType != ScanArtifactImage {
+		return ProvenanceAttestation{}, errors.New("subject_type must be package or image")
+	}
+	return s.store(envelope, subjectType)
+}
+
+func (s *ProvenanceAttestationStore) store(envelope DSSEEnvelope, subjectType string) (ProvenanceAttestation, error) {
+	statement, payload, keyID, err := s.openEnvelope(envelope)
+	if err != nil {
+		return ProvenanceAttestation{}, err
+	}
+	if len(statement.Subject) == 0 || statement.Subject[0].Digest["sha256"] == "" {
+		return ProvenanceAttestation{}, errors.New("statement must name a sha256 subject")
+	}
+	sum := sha256.Sum256(payload)
+	item := ProvenanceAttestation{
+		SubjectType:   subjectType,
+		SubjectName:   statement.Subject[0].Name,
+		SubjectDigest: normalizeAttestationDigest(statement.Subject[0].Digest["sha256"]),
+		SourceRepo:    statement.Predicate.BuildDefinition.ExternalParameters["source"],
+		SourceRef:     statement.Predicate.BuildDefinition.ExternalParameters["ref"],
+		BuilderID:     statement.Predicate.RunDetails.Builder.ID,
+		KeyID:         keyID,
+		Digest:        "sha256:" + hex.EncodeToString(sum[:]),
+		Envelope:      cloneDSSEEnvelope(envelope),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if item.SubjectDigest == "" {
+		return ProvenanceAttestation{}, errors.New("subject digest must be sha256")
+	}
+	for _, dep := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+		material := AttestationMaterial{URI: dep.URI}
+		if d := dep.Digest["sha256"]; d != "" {
+			material.Digest = normalizeAttestationDigest(d)
+		}
+		item.Materials = append(item.Materials, material)
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	item.ID = "attestation-" + itoa(s.nextID)
+	s.attestations[item.ID] = &item
+	s.byDigest[item.SubjectDigest] = append(s.byDigest[item.SubjectDigest], item.ID)
+	s.mu.Unlock()
+	return cloneProvenanceAttestation(item), nil
+}
+
+// openEnvelope checks the envelope against the registered keys and decodes
+// its statement.
+func (s *ProvenanceAttestationStore) openEnvelope(envelope DSSEEnvelope) (inTotoStatement, []byte, string, error) {
+	if envelope.PayloadType != InTotoPayloadType {
+		return inTotoStatement{}, nil, "", errors.New("payloadType must be " + InTotoPayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return inTotoStatement{}, nil, "", errors.New("payload must be base64 encoded")
+	}
+	keyID := ""
+	s.mu.RLock()
+	for _, signature := range envelope.Signatures {
+		record, ok := s.keys[signature.KeyID]
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err == nil && ed25519.Verify(record.publicKey, dssePAE(envelope.PayloadType, payload), raw) {
+			keyID = signature.KeyID
+			break
+		}
+	}
+	s.mu.RUnlock()
+	if keyID == "" {
+		return inTotoStatement{}, nil, "", errors.New("envelope is not signed by a registered attestation key")
+	}
+	var statement inTotoStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return inTotoStatement{}, nil, "", errors.New("payload is not an in-toto statement: " + err.Error())
+	}
+	if statement.Type != InTotoStatementType || statement.PredicateType != SLSAProvenancePredicate {
+		return inTotoStatement{}, nil, "", errors.New("statement must be an in-toto v1 statement with a SLSA v1 provenance predicate")
+	}
+	return statement, payload, keyID, nil
+}
+
+// AttestPackage signs provenance for a published package. Its resolved
+// dependencies become materials so admission can walk the chain.
+func (s *ProvenanceAttestationStore) AttestPackage(artifact PackageArtifact) (ProvenanceAttestation, error) {
+	if artifact.Provenance.Builder == "" {
+		return ProvenanceAttestation{}, errors.New("package provenance must name a builder")
+	}
+	in := ProvenanceAttestationInput{
+		SubjectType:   ScanArtifactPackage,
+		SubjectName:   artifact.Kind + "/" + artifact.Name + "@" + artifact.Version,
+		SubjectDigest: artifact.Digest,
+		SourceRepo:    artifact.Provenance.SourceRepo,
+		SourceRef:     artifact.Provenance.SourceRef,
+		BuilderID:     artifact.Provenance.Builder,
+		StartedAt:     artifact.Provenance.BuildTimestamp,
+		FinishedAt:    artifact.CreatedAt,
+	}
+	if len(artifact.Dependencies) > 0 && s.registry != nil {
+		if resolution, err := s.registry.Resolve(PackageResolveInput{Kind: artifact.Kind, Requirements: artifact.Dependencies}); err == nil {
+			for _, dep := range resolution.Packages {
+				in.Materials = append(in.Materials, AttestationMaterial{URI: "masterchef:" + artifact.Kind + "/" + dep.Name + "@" + dep.Version, Digest: dep.Digest})
+			}
+		}
+	}
+	item, err := s.Attest(in)
+	if err != nil {
+		return ProvenanceAttestation{}, err
+	}
+	if s.registry != nil {
+		s.registry.attachAttestationDigest(artifact.ID, item.Digest)
+	}
+	return item, nil
+}
+
+func (s *ProvenanceAttestationStore) Get(id string) (ProvenanceAttestation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.attestations[strings.TrimSpace(id)]
+	if !ok {
+		return ProvenanceAttestation{}, false
+	}
+	return cloneProvenanceAttestation(*item), true
+}
+
+// List returns attestations for a subject digest, or all of them, newest
+// first.
+func (s *ProvenanceAttestationStore) List(digest string) []ProvenanceAttestation {
+	filter := strings.TrimSpace(digest) != ""
+	digest = normalizeAttestationDigest(digest)
+	s.mu.RLock()
+	out := []ProvenanceAttestation{}
+	if filter {
+		for _, id := range s.byDigest[digest] {
+			out = append(out, cloneProvenanceAttestation(*s.attestations[id]))
+		}
+	} else {
+		for _, item := range s.attestations {
+			out = append(out, cloneProvenanceAttestation(*item))
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return attestationSeq(out[i].ID) > attestationSeq(out[j].ID)
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out
+}
+
+// Verify admits a digest when any of its attestations satisfies policy.
+// Unattested digests, and checksums that are not sha256, pass unless
+// policy requires attestations for the environment.
+func (s *ProvenanceAttestationStore) Verify(in AttestationVerifyInput) AttestationVerifyResult {
+	digest := normalizeAttestationDigest(in.Digest)
+	result := AttestationVerifyResult{Digest: digest, CheckedAt: time.Now().UTC()}
+	policy := s.Policy()
+	environment := strings.ToLower(strings.TrimSpace(in.Environment))
+	result.Required = policy.Required && (len(policy.RequiredEnvironments) == 0 || sliceContains(policy.RequiredEnvironments, environment))
+	if digest == "" {
+		// Only sha256 digests can be attested.
+		result.Allowed = !result.Required
+		if result.Required {
+			result.Reasons = []string{"digest must be sha256:<64-hex>"}
+		}
+		return result
+	}
+	if len(s.List(digest)) == 0 && !result.Required {
+		result.Allowed = true
+		return result
+	}
+	chain, reasons := s.verifyChain(digest, policy, map[string]bool{}, 0)
+	if len(reasons) > 0 {
+		result.Reasons = reasons
+		return result
+	}
+	result.Allowed = true
+	result.Chain = chain
+	result.AttestationID = chain[0]
+	return result
+}
+
+func (s *ProvenanceAttestationStore) verifyChain(digest string, policy ProvenanceAttestationPolicy, visiting map[string]bool, depth int) ([]string, []string) {
+	if depth > attestationChainDepthLimit {
+		return nil, []string{"attestation chain exceeds depth limit at " + digest}
+	}
+	if visiting[digest] {
+		return nil, []string{"attestation chain cycles through " + digest}
+	}
+	candidates := s.List(digest)
+	if len(candidates) == 0 {
+		return nil, []string{"no provenance attestation for " + digest}
+	}
+	visiting[digest] = true
+	defer delete(visiting, digest)
+	var firstReasons []string
+	for _, item := range candidates {
+		reasons := s.checkAttestation(item, policy)
+		chain := []string{item.ID}
+		if len(reasons) == 0 && policy.RequireChain {
+			for _, material := range item.Materials {
+				if material.Digest == "" {
+					continue
+				}
+				sub, subReasons := s.verifyChain(material.Digest, policy, visiting, depth+1)
+				if len(subReasons) > 0 {
+					for _, reason := range subReasons {
+						reasons = append(reasons, "material "+material.URI+": "+reason)
+					}
+					break
+				}
+				chain = append(chain, sub...)
+			}
+		}
+		if len(reasons) == 0 {
+			return chain, nil
+		}
+		if firstReasons == nil {
+			firstReasons = reasons
+		}
+	}
+	return nil, firstReasons
+}
+
+func (s *ProvenanceAttestationStore) checkAttestation(item ProvenanceAttestation, policy ProvenanceAttestationPolicy) []string {
+	reasons := []string{}
+	if _, _, keyID, err := s.openEnvelope(item.Envelope); err != nil {
+		reasons = append(reasons, item.ID+": "+err.Error())
+	} else if len(policy.TrustedKeyIDs) > 0 && !sliceContains(policy.TrustedKeyIDs, keyID) {
+		reasons = append(reasons, item.ID+": signing key "+keyID+" is not trusted by policy")
+	}
+	if len(policy.TrustedBuilders) > 0 && !matchesAnyPattern(policy.TrustedBuilders, item.BuilderID) {
+		reasons = append(reasons, item.ID+": builder "+item.BuilderID+" is not trusted by policy")
+	}
+	if len(policy.AllowedSourceRepos) > 0 && !matchesAnyPattern(policy.AllowedSourceRepos, item.SourceRepo) {
+		reasons = append(reasons, item.ID+": source repo "+strconv.Quote(item.SourceRepo)+" is not allowed by policy")
+	}
+	if policy.RequireMaterials {
+		if len(item.Materials) == 0 {
+			reasons = append(reasons, item.ID+": attestation records no materials")
+		}
+		for _, material := range item.Materials {
+			if material.Digest == "" {
+				reasons = append(reasons, item.ID+": material "+material.URI+" has no digest")
+			}
+		}
+	}
+	return reasons
+}
+
+// dssePAE is the DSSE v1 pre-authentication encoding that signatures cover.
+func dssePAE(payloadType string, payload []byte) []byte {
+	out := "DSSEv1 " + strconv.Itoa(len(payloadType)) + " " + payloadType + " " + strconv.Itoa(len(payload)) + " "
+	return append([]byte(out), payload...)
+}
+
+func normalizeAttestationDigest(in string) string {
+	digest := strings.ToLower(strings.TrimSpace(in))
+	if !strings.HasPrefix(digest, "sha256:") {
+		digest = "sha256:" + digest
+	}
+	if !packageDigestPattern.MatchString(digest) {
+		return ""
+	}
+	return digest
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func attestationSeq(id string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimPrefix(id, "attestation-"), 10, 64)
+	return n
+}
+
+func cloneProvenanceAttestationPolicy(in ProvenanceAttestationPolicy) ProvenanceAttestationPolicy {
+	out := in
+	out.RequiredEnvironments = append([]string{}, in.RequiredEnvironments...)
+	out.TrustedKeyIDs = append([]string{}, in.TrustedKeyIDs...)
+	out.TrustedBuilders = append([]string{}, in.TrustedBuilders...)
+	out.AllowedSourceRepos = append([]string{}, in.AllowedSourceRepos...)
+	return out
+}
+
+func cloneDSSEEnvelope(in DSSEEnvelope) DSSEEnvelope {
+	out := in
+	out.Signatures = append([]DSSESignature{}, in.Signatures...)
+	return out
+}
+
+func cloneProvenanceAttestation(in ProvenanceAttestation) ProvenanceAttestation {
+	out := in
+	out.Materials = append([]AttestationMaterial{}, in.Materials...)
+	out.Envelope = cloneDSSEEnvelope(in.Envelope)
+	return out
+}
@@ -0,0 +1,122 @@
+package control
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProvenanceAttestationChainAdmission(t *testing.T) {
+	registry := NewPackageRegistryStore()
+	attestations := NewProvenanceAttestationStore(registry)
+	deployments := NewArtifactDeploymentStore()
+	deployments.SetAdmissionCheck(func(item ArtifactDeployment) string {
+		result := attestations.Verify(AttestationVerifyInput{Digest: item.Checksum, Environment: item.Environment})
+		if result.Allowed {
+			return ""
+		}
+		return strings.Join(result.Reasons, "; ")
+	})
+
+	base, err := registry.Publish(PackageArtifactInput{
+		Kind: "module", Name: "core/base", Version: "1.0.0", Digest: "sha256:" + strings.Repeat("a", 64),
+		Provenance: PackageProvenance{SourceRepo: "github.com/acme/base", Builder: "gha://acme/base"},
+	})
+	if err != nil {
+		t.Fatalf("publish base failed: %v", err)
+	}
+	app, err := registry.Publish(PackageArtifactInput{
+		Kind: "module", Name: "core/app", Version: "1.0.0", Digest: "sha256:" + strings.Repeat("b", 64),
+		Dependencies: map[string]string{"core/base": ">= 1.0.0"},
+		Provenance:   PackageProvenance{SourceRepo: "github.com/acme/app", SourceRef: "refs/tags/v1.0.0", Builder: "gha://acme/app"},
+	})
+	if err != nil {
+		t.Fatalf("publish app failed: %v", err)
+	}
+	att, err := attestations.AttestPackage(app)
+	if err != nil {
+		t.Fatalf("attest app failed: %v", err)
+	}
+	if len(att.Materials) != 1 || att.Materials[0].Digest != base.Digest {
+		t.Fatalf("expected resolved dependency as material, got %+v", att.Materials)
+	}
+	if got, _ := registry.GetArtifact(app.ID); got.Provenance.AttestationDigest != att.Digest {
+		t.Fatalf("expected attestation digest on provenance, got %q", got.Provenance.AttestationDigest)
+	}
+	payload, _ := base64.StdEncoding.DecodeString(att.Envelope.Payload)
+	var statement map[string]any
+	if err := json.Unmarshal(payload, &statement); err != nil || statement["predicateType"] != SLSAProvenancePredicate {
+		t.Fatalf("expected slsa statement, got %s", payload)
+	}
+
+	if _, err := attestations.SetPolicy(ProvenanceAttestationPolicy{Required: true, RequiredEnvironments: []string{"prod"}, TrustedBuilders: []string{"gha://acme/*"}, RequireChain: true}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if result := attestations.Verify(AttestationVerifyInput{Digest: base.Digest, Environment: "dev"}); !result.Allowed {
+		t.Fatalf("expected unattested digest to pass outside required environments, got %+v", result)
+	}
+	_, plan, err := deployments.Create(ArtifactDeploymentInput{Environment: "prod", ArtifactRef: "core/app", Checksum: app.Digest, Targets: []string{"web-1"}})
+	if err != nil {
+		t.Fatalf("create deployment failed: %v", err)
+	}
+	if plan.Allowed || !strings.Contains(plan.BlockedReason, "no provenance attestation for "+base.Digest) {
+		t.Fatalf("expected broken chain to block admission, got %+v", plan)
+	}
+
+	if _, err := attestations.AttestPackage(base); err != nil {
+		t.Fatalf("attest base failed: %v", err)
+	}
+	result := attestations.Verify(AttestationVerifyInput{Digest: app.Digest, Environment: "prod"})
+	if !result.Allowed || len(result.Chain) != 2 {
+		t.Fatalf("expected full chain to verify, got %+v", result)
+	}
+
+	if _, err := attestations.SetPolicy(ProvenanceAttestationPolicy{Required: true, TrustedBuilders: []string{"jenkins://*"}}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if result := attestations.Verify(AttestationVerifyInput{Digest: app.Digest}); result.Allowed {
+		t.Fatalf("expected untrusted builder to be rejected, got %+v", result)
+	}
+}
+
+func TestProvenanceAttestationImportRequiresRegisteredKey(t *testing.T) {
+	attestations := NewProvenanceAttestationStore(nil)
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	payload := []byte(`{"_type":"` + InTotoStatementType + `","predicateType":"` + SLSAProvenancePredicate + `",` +
+		`"subject":[{"name":"registry.local/web","digest":{"sha256":"` + strings.Repeat("c", 64) + `"}}],` +
+		`"predicate":{"buildDefinition":{"buildType":"ci","externalParameters":{"source":"github.com/acme/web"}},"runDetails":{"builder":{"id":"ci://acme"}}}}`)
+	envelope := DSSEEnvelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+	}
+	if _, err := attestations.Import(ScanArtifactImage, envelope); err == nil {
+		t.Fatalf("expected unsigned envelope to be rejected")
+	}
+	key, err := attestations.AddKey(AttestationKeyInput{Name: "ci", PublicKey: base64.StdEncoding.EncodeToString(pub)})
+	if err != nil {
+		t.Fatalf("add key failed: %v", err)
+	}
+	envelope.Signatures = []DSSESignature{{KeyID: key.ID, Sig: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, dssePAE(InTotoPayloadType, payload)))}}
+	item, err := attestations.Import(ScanArtifactImage, envelope)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if item.BuilderID != "ci://acme" || item.SourceRepo != "github.com/acme/web" || item.KeyID != key.ID {
+		t.Fatalf("unexpected imported attestation %+v", item)
+	}
+
+	tampered := envelope
+	tampered.Payload = base64.StdEncoding.EncodeToString([]byte(strings.Replace(string(payload), "ci://acme", "ci://evil", 1)))
+	if _, err := attestations.Import(ScanArtifactImage, tampered); err == nil {
+		t.Fatalf("expected tampered payload to fail signature verification")
+	}
+	if _, err := attestations.SetPolicy(ProvenanceAttestationPolicy{Required: true, TrustedKeyIDs: []string{"attest-key-1"}}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if result := attestations.Verify(AttestationVerifyInput{Digest: strings.Repeat("c", 64)}); result.Allowed {
+		t.Fatalf("expected attestation from untrusted key to be rejected, got %+v", result)
+	}
+}
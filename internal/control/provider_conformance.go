@@ -30,7 +30,7 @@ type ProviderConformanceRunInput struct {
 
 type ProviderConformanceCheckResult struct {
 	Name      string `json:"name"`
-	Status    string `json:"status"` // pass|fail
+	Status    string `json:"status"` // pass|fail|skip
 	LatencyMS int    `json:"latency_ms"`
 	Detail    string `json:"detail,omitempty"`
 }
@@ -41,11 +41,13 @@ type ProviderConformanceRun struct {
 	Provider        string                           `json:"provider"`
 	ProviderVersion string                           `json:"provider_version,omitempty"`
 	Trigger         string                           `json:"trigger,omitempty"`
+	Mode            string                           `json:"mode"`   // simulated|executed
 	Status          string                           `json:"status"` // pass|degraded|fail
 	PassRate        float64                          `json:"pass_rate"`
 	PassedChecks    int                              `json:"passed_checks"`
 	FailedChecks    int                              `json:"failed_checks"`
 	Checks          []ProviderConformanceCheckResult `json:"checks"`
+	ScorecardID     string                           `json:"scorecard_id,omitempty"`
 	StartedAt       time.Time                        `json:"started_at"`
 	CompletedAt     time.Time                        `json:"completed_at"`
 }
@@ -163,24 +165,14 @@ func (s *ProviderConformanceStore) Run(in ProviderConformanceRunInput) (Provider
 	if suiteID == "" {
 		return ProviderConformanceRun{}, errors.New("suite_id is required")
 	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	suite, ok := s.suites[suiteID]
-	if !ok {
-		return ProviderConformanceRun{}, errors.New("suite not found")
-	}
-	version := strings.TrimSpace(in.ProviderVersion)
-	if version == "" {
-		version = "latest"
-	}
-	trigger := strings.TrimSpace(in.Trigger)
-	if trigger == "" {
-		trigger = "manual"
+	suite, err := s.GetSuite(suiteID)
+	if err != nil {
+		return ProviderConformanceRun{}, err
 	}
+	version, trigger := normalizeProviderConformanceRunInput(in)
 	started := time.Now().UTC()
 	checks := make([]ProviderConformanceCheckResult, 0, len(suite.Checks))
-	passed := 0
-	failed := 0
+	duration := 0
 	for _, check := range suite.Checks {
 		score := deterministicProviderConformanceScore(suite.ID, version, trigger, check)
 		status := "pass"
@@ -188,17 +180,50 @@ func (s *ProviderConformanceStore) Run(in ProviderConformanceRunInput) (Provider
 		if score >= 92 {
 			status = "fail"
 			detail = "deterministic conformance regression signal"
-			failed++
-		} else {
-			passed++
 		}
+		latency := 20 + int(score%120)
+		duration += latency
 		checks = append(checks, ProviderConformanceCheckResult{
 			Name:      check,
 			Status:    status,
-			LatencyMS: 20 + int(score%120),
+			LatencyMS: latency,
 			Detail:    detail,
 		})
 	}
+	return s.recordRun(suite.ID, version, trigger, "simulated", checks, started, started.Add(time.Duration(duration)*time.Millisecond))
+}
+
+func normalizeProviderConformanceRunInput(in ProviderConformanceRunInput) (version, trigger string) {
+	version = strings.TrimSpace(in.ProviderVersion)
+	if version == "" {
+		version = "latest"
+	}
+	trigger = strings.TrimSpace(in.Trigger)
+	if trigger == "" {
+		trigger = "manual"
+	}
+	return version, trigger
+}
+
+// recordRun scores check results against the suite's required pass rate.
+// Skipped checks do not count toward the pass rate.
+func (s *ProviderConformanceStore) recordRun(suiteID, version, trigger, mode string, checks []ProviderConformanceCheckResult, started, completed time.Time) (ProviderConformanceRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	suite, ok := s.suites[suiteID]
+	if !ok {
+		return ProviderConformanceRun{}, errors.New("suite not found")
+	}
+	passed := 0
+	failed := 0
+	for _, check := range checks {
+		switch check.Status {
+		case "pass":
+			passed++
+		case "fail":
+			failed++
+		}
+	}
 	passRate := 0.0
 	total := passed + failed
 	if total > 0 {
@@ -210,11 +235,6 @@ func (s *ProviderConformanceStore) Run(in ProviderConformanceRunInput) (Provider
 	} else if failed > 0 {
 		status = "degraded"
 	}
-	duration := 0
-	for _, item := range checks {
-		duration += item.LatencyMS
-	}
-	completed := started.Add(time.Duration(duration) * time.Millisecond)
 	s.nextID++
 	item := ProviderConformanceRun{
 		ID:              "provider-conformance-run-" + itoa(s.nextID),
@@ -222,6 +242,7 @@ func (s *ProviderConformanceStore) Run(in ProviderConformanceRunInput) (Provider
 		Provider:        suite.Provider,
 		ProviderVersion: version,
 		Trigger:         trigger,
+		Mode:            mode,
 		Status:          status,
 		PassRate:        passRate,
 		PassedChecks:    passed,
@@ -239,6 +260,15 @@ func (s *ProviderConformanceStore) Run(in ProviderConformanceRunInput) (Provider
 	return cloneProviderConformanceRun(item), nil
 }
 
+// attachScorecard links a run to the readiness scorecard it published.
+func (s *ProviderConformanceStore) attachScorecard(runID, scorecardID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item, ok := s.runs[runID]; ok {
+		item.ScorecardID = scorecardID
+	}
+}
+
 func (s *ProviderConformanceStore) ListRuns(suiteID string, limit int) []ProviderConformanceRun {
 	suiteID = strings.TrimSpace(suiteID)
 	if limit <= 0 {
@@ -0,0 +1,158 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/provider"
+)
+
+type ProviderConformanceExecuteInput struct {
+	SuiteID         string `json:"suite_id"`
+	ProviderVersion string `json:"provider_version,omitempty"`
+	Trigger         string `json:"trigger,omitempty"`
+	Environment     string `json:"environment,omitempty"` // readiness scorecard environment, default "conformance"
+	TimeoutSeconds  int    `json:"timeout_seconds,omitempty"`
+}
+
+// ProviderConformanceRunner executes conformance suites against real
+// provider handlers. Each run generates a fixture for the suite's
+// provider inside a throwaway workspace, drives the handler through the
+// standard behaviors, records the results as a run, and publishes a
+// readiness scorecard for the provider.
+type ProviderConformanceRunner struct {
+	suites     *ProviderConformanceStore
+	providers  *provider.Registry
+	scorecards *ReadinessScorecardStore
+	workRoot   string
+}
+
+// NewProviderConformanceRunner creates workspaces under workRoot, or the
+// system temp directory when it is empty.
+func NewProviderConformanceRunner(suites *ProviderConformanceStore, providers *provider.Registry, scorecards *ReadinessScorecardStore, workRoot string) *ProviderConformanceRunner {
+	return &ProviderConformanceRunner{
+		suites:     suites,
+		providers:  providers,
+		scorecards: scorecards,
+		workRoot:   workRoot,
+	}
+}
+
+// Execute runs the suite's checks that name a standard behavior; other
+// checks are reported as skipped. A suite without any behavior checks runs
+// every standard behavior.
+func (r *ProviderConformanceRunner) Execute(ctx context.Context, in ProviderConformanceExecuteInput) (ProviderConformanceRun, error) {
+	suite, err := r.suites.GetSuite(in.SuiteID)
+	if err != nil {
+		return ProviderConformanceRun{}, err
+	}
+	handler, ok := r.providers.Lookup(suite.Provider)
+	if !ok {
+		return ProviderConformanceRun{}, errors.New("no provider handler registered for " + suite.Provider)
+	}
+	if r.workRoot != "" {
+		if err := os.MkdirAll(r.workRoot, 0o755); err != nil {
+			return ProviderConformanceRun{}, err
+		}
+	}
+	workspace, err := os.MkdirTemp(r.workRoot, "conformance-"+suite.Provider+"-")
+	if err != nil {
+		return ProviderConformanceRun{}, err
+	}
+	defer os.RemoveAll(workspace)
+	fixture, ok := provider.GenerateFixture(suite.Provider, workspace)
+	if !ok {
+		return ProviderConformanceRun{}, errors.New("no conformance fixture generator for provider " + suite.Provider)
+	}
+
+	behaviors := []string{}
+	skipped := []string{}
+	for _, check := range suite.Checks {
+		if isStandardProviderBehavior(check) {
+			behaviors = append(behaviors, check)
+		} else {
+			skipped = append(skipped, check)
+		}
+	}
+	if len(behaviors) == 0 {
+		behaviors = provider.StandardBehaviors
+	}
+	timeout := time.Duration(in.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	version, trigger := normalizeProviderConformanceRunInput(ProviderConformanceRunInput{ProviderVersion: in.ProviderVersion, Trigger: in.Trigger})
+	started := time.Now().UTC()
+	results := provider.RunBehaviors(runCtx, handler, fixture, behaviors)
+	checks := make([]ProviderConformanceCheckResult, 0, len(results)+len(skipped))
+	var slowest time.Duration
+	for _, res := range results {
+		if res.Duration > slowest {
+			slowest = res.Duration
+		}
+		detail := res.Detail
+		if detail == "" {
+			detail = "behavior verified against generated fixture"
+		}
+		checks = append(checks, ProviderConformanceCheckResult{
+			Name:      res.Behavior,
+			Status:    res.Status,
+			LatencyMS: int(res.Duration / time.Millisecond),
+			Detail:    detail,
+		})
+	}
+	for _, check := range skipped {
+		checks = append(checks, ProviderConformanceCheckResult{Name: check, Status: "skip", Detail: "no executable fixture for check"})
+	}
+	run, err := r.suites.recordRun(suite.ID, version, trigger, "executed", checks, started, time.Now().UTC())
+	if err != nil {
+		return ProviderConformanceRun{}, err
+	}
+	if r.scorecards == nil {
+		return run, nil
+	}
+
+	environment := strings.TrimSpace(in.Environment)
+	if environment == "" {
+		environment = "conformance"
+	}
+	reliability := run.PassRate
+	for _, check := range run.Checks {
+		if (check.Name == provider.BehaviorIdempotency || check.Name == provider.BehaviorDrift) && check.Status == "fail" {
+			reliability = 0
+		}
+	}
+	card, err := r.scorecards.Create(ReadinessScorecardInput{
+		Environment: environment,
+		Service:     "provider/" + suite.Provider,
+		Owner:       "conformance:" + suite.ID,
+		Signals: ReadinessSignals{
+			QualityScore:      run.PassRate,
+			ReliabilityScore:  reliability,
+			PerformanceScore:  1,
+			TestPassRate:      run.PassRate,
+			P95ApplyLatencyMs: int64(slowest / time.Millisecond),
+		},
+	})
+	if err != nil {
+		return run, err
+	}
+	r.suites.attachScorecard(run.ID, card.ID)
+	run.ScorecardID = card.ID
+	return run, nil
+}
+
+func isStandardProviderBehavior(check string) bool {
+	for _, behavior := range provider.StandardBehaviors {
+		if check == behavior {
+			return true
+		}
+	}
+	return false
+}
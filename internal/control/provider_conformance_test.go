@@ -1,6 +1,11 @@
 package control
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/provider"
+)
 
 func TestProviderConformanceRunDeterministic(t *testing.T) {
 	store := NewProviderConformanceStore()
@@ -48,3 +53,45 @@ func TestProviderConformanceSuiteUpsert(t *testing.T) {
 		t.Fatalf("unexpected run response: %+v", run)
 	}
 }
+
+func TestProviderConformanceRunnerExecutesFixtures(t *testing.T) {
+	store := NewProviderConformanceStore()
+	scorecards := NewReadinessScorecardStore()
+	runner := NewProviderConformanceRunner(store, provider.NewBuiltinRegistry(), scorecards, t.TempDir())
+
+	run, err := runner.Execute(context.Background(), ProviderConformanceExecuteInput{SuiteID: "provider-file-core", ProviderVersion: "v1.0.0"})
+	if err != nil {
+		t.Fatalf("execute conformance failed: %v", err)
+	}
+	if run.Mode != "executed" || run.ScorecardID == "" {
+		t.Fatalf("expected executed run with scorecard, got %+v", run)
+	}
+	statuses := map[string]string{}
+	for _, check := range run.Checks {
+		statuses[check.Name] = check.Status
+	}
+	if statuses["idempotency"] != "pass" || statuses["drift-detection"] != "pass" {
+		t.Fatalf("expected file behaviors to pass, got %+v", run.Checks)
+	}
+	if statuses["permission-reconcile"] != "skip" {
+		t.Fatalf("expected checks without fixtures to be skipped, got %+v", run.Checks)
+	}
+	if run.PassRate != 1 {
+		t.Fatalf("expected skipped checks to be excluded from pass rate, got %.2f", run.PassRate)
+	}
+	card, err := scorecards.Get(run.ScorecardID)
+	if err != nil {
+		t.Fatalf("get published scorecard failed: %v", err)
+	}
+	if card.Service != "provider/file" || card.Environment != "conformance" || card.Report.Signals.TestPassRate != 1 {
+		t.Fatalf("unexpected published scorecard: %+v", card)
+	}
+	stored, err := store.GetRun(run.ID)
+	if err != nil || stored.ScorecardID != card.ID {
+		t.Fatalf("expected stored run to reference scorecard, got %+v err=%v", stored, err)
+	}
+
+	if _, err := runner.Execute(context.Background(), ProviderConformanceExecuteInput{SuiteID: "provider-package-core"}); err == nil {
+		t.Fatalf("expected execute to fail for provider without handler")
+	}
+}
@@ -0,0 +1,184 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DeviceResource is a resource definition for a device that cannot run an
+// agent. Properties are interpreted by the device module for its type.
+type DeviceResource struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// DeviceOperation is one device-specific step a module translated a
+// resource into: a CLI block, an HTTP call, or an SNMP set.
+type DeviceOperation struct {
+	ResourceID  string         `json:"resource_id"`
+	Kind        string         `json:"kind"` // cli|http|snmp-set
+	Description string         `json:"description"`
+	Commands    []string       `json:"commands,omitempty"`
+	Method      string         `json:"method,omitempty"`
+	Path        string         `json:"path,omitempty"`
+	Body        map[string]any `json:"body,omitempty"`
+	OID         string         `json:"oid,omitempty"`
+	Value       string         `json:"value,omitempty"`
+}
+
+type DeviceOperationResult struct {
+	Operation DeviceOperation `json:"operation"`
+	Status    string          `json:"status"` // applied|failed|skipped
+	Output    string          `json:"output,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// DeviceModule lets a proxy minion manage a class of device. Connection
+// details come from the binding's metadata; secrets are referenced by
+// environment variable name (for example password_env, which must start
+// with MC_DEVICE_SECRET_) rather than stored.
+type DeviceModule interface {
+	Name() string
+	ResourceTypes() []string
+	Translate(resources []DeviceResource) ([]DeviceOperation, error)
+	Apply(ctx context.Context, binding ProxyMinionBinding, ops []DeviceOperation) ([]DeviceOperationResult, error)
+	Facts(ctx context.Context, binding ProxyMinionBinding) (map[string]any, error)
+}
+
+type DeviceModuleInfo struct {
+	Transport     string   `json:"transport"`
+	Module        string   `json:"module"`
+	ResourceTypes []string `json:"resource_types"`
+}
+
+// DeviceModuleRegistry maps proxy-minion transports to device modules.
+type DeviceModuleRegistry struct {
+	mu      sync.RWMutex
+	modules map[string]DeviceModule
+}
+
+// NewDeviceModuleRegistry returns a registry with the built-in ssh-cli,
+// redfish, and snmp modules.
+func NewDeviceModuleRegistry() *DeviceModuleRegistry {
+	r := &DeviceModuleRegistry{modules: map[string]DeviceModule{}}
+	r.Register("ssh-cli", NewSSHCLIDeviceModule())
+	r.Register("redfish", NewRedfishDeviceModule())
+	r.Register("snmp", NewSNMPDeviceModule())
+	return r
+}
+
+func (r *DeviceModuleRegistry) Register(transport string, module DeviceModule) {
+	transport = strings.ToLower(strings.TrimSpace(transport))
+	if transport == "" || module == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modules[transport] = module
+}
+
+func (r *DeviceModuleRegistry) For(transport string) (DeviceModule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	module, ok := r.modules[strings.ToLower(strings.TrimSpace(transport))]
+	return module, ok
+}
+
+func (r *DeviceModuleRegistry) List() []DeviceModuleInfo {
+	r.mu.RLock()
+	out := make([]DeviceModuleInfo, 0, len(r.modules))
+	for transport, module := range r.modules {
+		out = append(out, DeviceModuleInfo{
+			Transport:     transport,
+			Module:        module.Name(),
+			ResourceTypes: module.ResourceTypes(),
+		})
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Transport < out[j].Transport })
+	return out
+}
+
+func unsupportedDeviceResource(module string, res DeviceResource) error {
+	return fmt.Errorf("%s module does not support resource type %q (resource %s)", module, res.Type, res.ID)
+}
+
+func deviceString(props map[string]any, key string) string {
+	switch v := props[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+func deviceBool(props map[string]any, key string) (bool, bool) {
+	switch v := props[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+func deviceStrings(props map[string]any, key string) []string {
+	out := []string{}
+	switch v := props[key].(type) {
+	case []string:
+		out = append(out, v...)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	case string:
+		out = append(out, strings.Split(v, "\n")...)
+	}
+	return out
+}
+
+// deviceSecretEnvPrefix is the prefix every environment variable named by a
+// binding's *_env metadata must carry. Bindings choose the endpoint the
+// secret is sent to, so they may only read variables set aside for devices.
+const deviceSecretEnvPrefix = "MC_DEVICE_SECRET_"
+
+// deviceSecret resolves a secret from the environment variable named by
+// metadata key+"_env".
+func deviceSecret(binding ProxyMinionBinding, key string) (string, error) {
+	name := strings.TrimSpace(binding.Metadata[key+"_env"])
+	if name == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(name, deviceSecretEnvPrefix) || name == deviceSecretEnvPrefix {
+		return "", errors.New(key + "_env must name an environment variable starting with " + deviceSecretEnvPrefix)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.New(key + "_env references unset environment variable " + name)
+	}
+	return value, nil
+}
+
+func failRemainingDeviceOps(results []DeviceOperationResult, ops []DeviceOperation) []DeviceOperationResult {
+	for _, op := range ops[len(results):] {
+		results = append(results, DeviceOperationResult{Operation: op, Status: "skipped"})
+	}
+	return results
+}
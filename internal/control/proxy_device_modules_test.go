@@ -0,0 +1,240 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSSHCLIDeviceModuleTranslateAndApply(t *testing.T) {
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "ssh")
+	// Echo the args and the session's stdin so the test can see what was sent.
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\necho \"args: $*\"\ncat\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	module := NewSSHCLIDeviceModule()
+	module.Command = fake
+
+	ops, err := module.Translate([]DeviceResource{
+		{ID: "vlan-10", Type: "vlan", Properties: map[string]any{"id": float64(10), "name": "users"}},
+		{ID: "eth1", Type: "interface", Properties: map[string]any{"name": "Ethernet1", "access_vlan": "10", "enabled": true}},
+	})
+	if err != nil {
+		t.Fatalf("translate failed: %v", err)
+	}
+	if len(ops) != 2 || ops[0].Commands[0] != "vlan 10" || ops[1].Commands[len(ops[1].Commands)-2] != " no shutdown" {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+	if _, err := module.Translate([]DeviceResource{{ID: "x", Type: "power"}}); err == nil {
+		t.Fatalf("expected unsupported resource type error")
+	}
+
+	binding := ProxyMinionBinding{Metadata: map[string]string{"address": "sw1", "username": "netops", "port": "2222"}}
+	results, err := module.Apply(context.Background(), binding, ops)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Status != "applied" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if !strings.Contains(results[0].Output, "-p 2222 -- netops@sw1") || !strings.Contains(results[0].Output, "configure terminal\nvlan 10\n name users\nexit\nend") {
+		t.Fatalf("unexpected session output %q", results[0].Output)
+	}
+
+	facts, err := module.Facts(context.Background(), binding)
+	if err != nil || facts["version"] == "" {
+		t.Fatalf("facts failed: %v %+v", err, facts)
+	}
+	if _, err := module.Apply(context.Background(), ProxyMinionBinding{}, ops); err == nil {
+		t.Fatalf("expected missing address error")
+	}
+	for _, meta := range []map[string]string{
+		{"address": "-oProxyCommand=touch /tmp/pwned"},
+		{"address": "sw1", "username": "-oProxyCommand=touch /tmp/pwned"},
+		{"address": "sw1", "port": "-oProxyCommand=x"},
+	} {
+		if _, err := module.Facts(context.Background(), ProxyMinionBinding{Metadata: meta}); err == nil {
+			t.Fatalf("expected option-like metadata %v to be rejected", meta)
+		}
+	}
+}
+
+func TestRedfishDeviceModuleApplyAndFacts(t *testing.T) {
+	t.Setenv("MC_DEVICE_SECRET_BMC_PASSWORD", "s3cret")
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"Manufacturer":     "Contoso",
+				"Model":            "R100",
+				"PowerState":       "On",
+				"ProcessorSummary": map[string]any{"Count": 2},
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	module := NewRedfishDeviceModule()
+	ops, err := module.Translate([]DeviceResource{
+		{ID: "boot", Type: "boot", Properties: map[string]any{"target": "pxe", "once": true}},
+		{ID: "power", Type: "power", Properties: map[string]any{"state": "restart"}},
+	})
+	if err != nil {
+		t.Fatalf("translate failed: %v", err)
+	}
+	if _, err := module.Translate([]DeviceResource{{ID: "p", Type: "power", Properties: map[string]any{"state": "sideways"}}}); err == nil {
+		t.Fatalf("expected invalid power state error")
+	}
+
+	binding := ProxyMinionBinding{Metadata: map[string]string{
+		"endpoint":     srv.URL,
+		"system_id":    "node-7",
+		"username":     "admin",
+		"password_env": "MC_DEVICE_SECRET_BMC_PASSWORD",
+	}}
+	results, err := module.Apply(context.Background(), binding, ops)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(results) != 2 || results[1].Status != "applied" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if len(calls) != 2 || calls[0] != "PATCH /redfish/v1/Systems/node-7" || calls[1] != "POST /redfish/v1/Systems/node-7/Actions/ComputerSystem.Reset" {
+		t.Fatalf("unexpected redfish calls %v", calls)
+	}
+
+	facts, err := module.Facts(context.Background(), binding)
+	if err != nil {
+		t.Fatalf("facts failed: %v", err)
+	}
+	if facts["manufacturer"] != "Contoso" || facts["power_state"] != "On" || facts["processor_count"] != 2 {
+		t.Fatalf("unexpected facts %+v", facts)
+	}
+
+	binding.Metadata["password_env"] = "HOME"
+	if _, err := module.Facts(context.Background(), binding); err == nil || !strings.Contains(err.Error(), "MC_DEVICE_SECRET_") {
+		t.Fatalf("expected password_env outside the device secret prefix to be rejected, got %v", err)
+	}
+	binding.Metadata["password_env"] = "MC_DEVICE_SECRET_BMC_PASSWORD"
+
+	binding.Metadata["username"] = "intruder"
+	results, err = module.Apply(context.Background(), binding, ops)
+	if err == nil || results[0].Status != "failed" || results[1].Status != "skipped" {
+		t.Fatalf("expected failed then skipped results, got %+v err=%v", results, err)
+	}
+}
+
+func TestSNMPDeviceModuleGetAndSet(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	mib := map[string]string{
+		snmpOIDSysDescr:    "Contoso PDU",
+		snmpOIDSysContact:  "noc",
+		snmpOIDSysName:     "pdu-1",
+		snmpOIDSysLocation: "rack 4",
+	}
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req, err := decodeSNMP(buf[:n])
+			if err != nil {
+				continue
+			}
+			resp := snmpPDU{Type: snmpGetResponse, Community: req.Community, RequestID: req.RequestID}
+			switch {
+			case req.Type == snmpSetRequest && req.Community != "private":
+				resp.ErrorStatus = 16 // authorizationError
+			case req.Type == snmpSetRequest:
+				for _, vb := range req.VarBinds {
+					mib[vb.OID], _ = vb.Value.(string)
+				}
+			}
+			for _, vb := range req.VarBinds {
+				switch vb.OID {
+				case snmpOIDSysUpTime:
+					resp.VarBinds = append(resp.VarBinds, snmpVarBind{OID: vb.OID, Type: snmpTimeTicks, Value: int64(123456)})
+				case snmpOIDSysObjectID:
+					resp.VarBinds = append(resp.VarBinds, snmpVarBind{OID: vb.OID, Type: snmpObjectID, Value: "1.3.6.1.4.1.99999.1"})
+				default:
+					resp.VarBinds = append(resp.VarBinds, snmpVarBind{OID: vb.OID, Type: snmpOctetString, Value: mib[vb.OID]})
+				}
+			}
+			out, _ := encodeSNMP(resp)
+			_, _ = conn.WriteTo(out, addr)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(conn.LocalAddr().String())
+
+	module := NewSNMPDeviceModule()
+	binding := ProxyMinionBinding{Metadata: map[string]string{"address": "127.0.0.1", "port": port}}
+	facts, err := module.Facts(context.Background(), binding)
+	if err != nil {
+		t.Fatalf("facts failed: %v", err)
+	}
+	if facts["description"] != "Contoso PDU" || facts["uptime_ticks"] != int64(123456) || facts["object_id"] != "1.3.6.1.4.1.99999.1" {
+		t.Fatalf("unexpected facts %+v", facts)
+	}
+
+	ops, err := module.Translate([]DeviceResource{{ID: "sys", Type: "system", Properties: map[string]any{"location": "rack 9"}}})
+	if err != nil || len(ops) != 1 || ops[0].OID != snmpOIDSysLocation {
+		t.Fatalf("unexpected translate result %+v err=%v", ops, err)
+	}
+	if _, err := module.Apply(context.Background(), binding, ops); err == nil {
+		t.Fatalf("expected set with read community to be rejected")
+	}
+	t.Setenv("MC_DEVICE_SECRET_SNMP_WRITE", "private")
+	binding.Metadata["write_community_env"] = "MC_DEVICE_SECRET_SNMP_WRITE"
+	results, err := module.Apply(context.Background(), binding, ops)
+	if err != nil || results[0].Status != "applied" {
+		t.Fatalf("set failed: %+v err=%v", results, err)
+	}
+	facts, err = module.Facts(context.Background(), binding)
+	if err != nil || facts["location"] != "rack 9" {
+		t.Fatalf("expected updated location, got %+v err=%v", facts, err)
+	}
+}
+
+func TestDeviceModuleRegistryAndFacts(t *testing.T) {
+	registry := NewDeviceModuleRegistry()
+	list := registry.List()
+	if len(list) != 3 || list[0].Transport != "redfish" || list[1].Transport != "snmp" || list[2].Transport != "ssh-cli" {
+		t.Fatalf("unexpected modules %+v", list)
+	}
+	if _, ok := registry.For("netconf"); ok {
+		t.Fatalf("expected no module for netconf")
+	}
+
+	store := NewProxyMinionStore()
+	binding, err := store.UpsertBinding(ProxyMinionBindingInput{ProxyID: "proxy-1", DeviceID: "bmc-1", Transport: "redfish"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := store.RecordFacts(binding.ID, map[string]any{"model": "R100"})
+	if err != nil || updated.Facts["model"] != "R100" || updated.FactsAt == nil {
+		t.Fatalf("unexpected facts record %+v err=%v", updated, err)
+	}
+	if _, err := store.RecordFacts("missing", nil); err == nil {
+		t.Fatalf("expected missing binding error")
+	}
+}
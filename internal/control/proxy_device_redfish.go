@@ -0,0 +1,214 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RedfishDeviceModule manages server BMCs through the DMTF Redfish API.
+//
+// Binding metadata: endpoint (required, e.g. https://bmc-1), system_id
+// (default "1"), username, password_env, and insecure_tls ("true" to skip
+// certificate verification for self-signed BMC certificates).
+type RedfishDeviceModule struct {
+	client   *http.Client
+	insecure *http.Client
+}
+
+func NewRedfishDeviceModule() *RedfishDeviceModule {
+	return &RedfishDeviceModule{
+		client: &http.Client{Timeout: 30 * time.Second},
+		// Only used for bindings that opt in with insecure_tls.
+		insecure: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+func (m *RedfishDeviceModule) Name() string { return "redfish" }
+
+func (m *RedfishDeviceModule) ResourceTypes() []string {
+	return []string{"bios", "boot", "power"}
+}
+
+// Translate maps resources to Redfish calls. Paths are relative to the
+// system, /redfish/v1/Systems/{system_id}.
+func (m *RedfishDeviceModule) Translate(resources []DeviceResource) ([]DeviceOperation, error) {
+	out := make([]DeviceOperation, 0, len(resources))
+	for _, res := range resources {
+		props := res.Properties
+		switch strings.ToLower(strings.TrimSpace(res.Type)) {
+		case "power":
+			state := strings.ToLower(deviceString(props, "state"))
+			resetType := map[string]string{
+				"on":                "On",
+				"off":               "ForceOff",
+				"restart":           "ForceRestart",
+				"graceful_shutdown": "GracefulShutdown",
+				"graceful_restart":  "GracefulRestart",
+			}[state]
+			if resetType == "" {
+				return nil, errors.New("power resource " + res.ID + " state must be on, off, restart, graceful_shutdown, or graceful_restart")
+			}
+			out = append(out, DeviceOperation{
+				ResourceID:  res.ID,
+				Kind:        "http",
+				Description: "power " + state,
+				Method:      http.MethodPost,
+				Path:        "/Actions/ComputerSystem.Reset",
+				Body:        map[string]any{"ResetType": resetType},
+			})
+		case "boot":
+			target := map[string]string{
+				"pxe":  "Pxe",
+				"hdd":  "Hdd",
+				"cd":   "Cd",
+				"usb":  "Usb",
+				"bios": "BiosSetup",
+				"none": "None",
+			}[strings.ToLower(deviceString(props, "target"))]
+			if target == "" {
+				return nil, errors.New("boot resource " + res.ID + " target must be pxe, hdd, cd, usb, bios, or none")
+			}
+			enabled := "Continuous"
+			if once, _ := deviceBool(props, "once"); once {
+				enabled = "Once"
+			}
+			out = append(out, DeviceOperation{
+				ResourceID:  res.ID,
+				Kind:        "http",
+				Description: "boot override " + target,
+				Method:      http.MethodPatch,
+				Body: map[string]any{"Boot": map[string]any{
+					"BootSourceOverrideTarget":  target,
+					"BootSourceOverrideEnabled": enabled,
+				}},
+			})
+		case "bios":
+			attrs, _ := props["attributes"].(map[string]any)
+			if len(attrs) == 0 {
+				return nil, errors.New("bios resource " + res.ID + " requires attributes")
+			}
+			out = append(out, DeviceOperation{
+				ResourceID:  res.ID,
+				Kind:        "http",
+				Description: fmt.Sprintf("stage %d bios attributes", len(attrs)),
+				Method:      http.MethodPatch,
+				Path:        "/Bios/Settings",
+				Body:        map[string]any{"Attributes": attrs},
+			})
+		default:
+			return nil, unsupportedDeviceResource(m.Name(), res)
+		}
+	}
+	return out, nil
+}
+
+func (m *RedfishDeviceModule) Apply(ctx context.Context, binding ProxyMinionBinding, ops []DeviceOperation) ([]DeviceOperationResult, error) {
+	results := make([]DeviceOperationResult, 0, len(ops))
+	for _, op := range ops {
+		raw, err := m.do(ctx, binding, op.Method, op.Path, op.Body)
+		if err != nil {
+			results = append(results, DeviceOperationResult{Operation: op, Status: "failed", Output: raw, Error: err.Error()})
+			return failRemainingDeviceOps(results, ops), err
+		}
+		results = append(results, DeviceOperationResult{Operation: op, Status: "applied", Output: raw})
+	}
+	return results, nil
+}
+
+func (m *RedfishDeviceModule) Facts(ctx context.Context, binding ProxyMinionBinding) (map[string]any, error) {
+	raw, err := m.do(ctx, binding, http.MethodGet, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var system struct {
+		Manufacturer  string `json:"Manufacturer"`
+		Model         string `json:"Model"`
+		SerialNumber  string `json:"SerialNumber"`
+		HostName      string `json:"HostName"`
+		PowerState    string `json:"PowerState"`
+		BiosVersion   string `json:"BiosVersion"`
+		MemorySummary struct {
+			TotalSystemMemoryGiB float64 `json:"TotalSystemMemoryGiB"`
+		} `json:"MemorySummary"`
+		ProcessorSummary struct {
+			Count int    `json:"Count"`
+			Model string `json:"Model"`
+		} `json:"ProcessorSummary"`
+		Status struct {
+			Health string `json:"Health"`
+		} `json:"Status"`
+	}
+	if err := json.Unmarshal([]byte(raw), &system); err != nil {
+		return nil, errors.New("invalid redfish system response: " + err.Error())
+	}
+	return map[string]any{
+		"manufacturer":    system.Manufacturer,
+		"model":           system.Model,
+		"serial_number":   system.SerialNumber,
+		"hostname":        system.HostName,
+		"power_state":     system.PowerState,
+		"bios_version":    system.BiosVersion,
+		"memory_gib":      system.MemorySummary.TotalSystemMemoryGiB,
+		"processor_count": system.ProcessorSummary.Count,
+		"processor_model": system.ProcessorSummary.Model,
+		"health":          system.Status.Health,
+	}, nil
+}
+
+func (m *RedfishDeviceModule) do(ctx context.Context, binding ProxyMinionBinding, method, path string, body map[string]any) (string, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(binding.Metadata["endpoint"]), "/")
+	if endpoint == "" {
+		return "", errors.New("redfish binding requires metadata.endpoint")
+	}
+	password, err := deviceSecret(binding, "password")
+	if err != nil {
+		return "", err
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	url := endpoint + "/redfish/v1/Systems/" + metadataOr(binding, "system_id", "1") + path
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user := strings.TrimSpace(binding.Metadata["username"]); user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	client := m.client
+	if strings.EqualFold(strings.TrimSpace(binding.Metadata["insecure_tls"]), "true") {
+		client = m.insecure
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return strings.TrimSpace(string(raw)), fmt.Errorf("redfish %s %s returned status %d", method, url, resp.StatusCode)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
@@ -0,0 +1,448 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SNMPDeviceModule reads facts from and sets string objects on devices over
+// SNMPv2c.
+//
+// Binding metadata: address (required), port (default 161), community
+// (default "public") or community_env, and write_community_env for sets
+// (falls back to the read community).
+type SNMPDeviceModule struct {
+	Timeout time.Duration
+}
+
+func NewSNMPDeviceModule() *SNMPDeviceModule {
+	return &SNMPDeviceModule{Timeout: 5 * time.Second}
+}
+
+const (
+	snmpOIDSysDescr    = "1.3.6.1.2.1.1.1.0"
+	snmpOIDSysObjectID = "1.3.6.1.2.1.1.2.0"
+	snmpOIDSysUpTime   = "1.3.6.1.2.1.1.3.0"
+	snmpOIDSysContact  = "1.3.6.1.2.1.1.4.0"
+	snmpOIDSysName     = "1.3.6.1.2.1.1.5.0"
+	snmpOIDSysLocation = "1.3.6.1.2.1.1.6.0"
+)
+
+func (m *SNMPDeviceModule) Name() string { return "snmp" }
+
+func (m *SNMPDeviceModule) ResourceTypes() []string {
+	return []string{"snmp_set", "system"}
+}
+
+func (m *SNMPDeviceModule) Translate(resources []DeviceResource) ([]DeviceOperation, error) {
+	out := make([]DeviceOperation, 0, len(resources))
+	for _, res := range resources {
+		props := res.Properties
+		switch strings.ToLower(strings.TrimSpace(res.Type)) {
+		case "system":
+			before := len(out)
+			for _, field := range []struct{ key, oid string }{
+				{"contact", snmpOIDSysContact},
+				{"name", snmpOIDSysName},
+				{"location", snmpOIDSysLocation},
+			} {
+				value := deviceString(props, field.key)
+				if value == "" {
+					continue
+				}
+				out = append(out, DeviceOperation{
+					ResourceID:  res.ID,
+					Kind:        "snmp-set",
+					Description: "set sys" + strings.ToUpper(field.key[:1]) + field.key[1:],
+					OID:         field.oid,
+					Value:       value,
+				})
+			}
+			if len(out) == before {
+				return nil, errors.New("system resource " + res.ID + " requires contact, name, or location")
+			}
+		case "snmp_set":
+			oid := strings.TrimPrefix(deviceString(props, "oid"), ".")
+			if _, err := encodeSNMPOID(oid); err != nil {
+				return nil, errors.New("snmp_set resource " + res.ID + ": " + err.Error())
+			}
+			out = append(out, DeviceOperation{
+				ResourceID:  res.ID,
+				Kind:        "snmp-set",
+				Description: "set " + oid,
+				OID:         oid,
+				Value:       deviceString(props, "value"),
+			})
+		default:
+			return nil, unsupportedDeviceResource(m.Name(), res)
+		}
+	}
+	return out, nil
+}
+
+func (m *SNMPDeviceModule) Apply(ctx context.Context, binding ProxyMinionBinding, ops []DeviceOperation) ([]DeviceOperationResult, error) {
+	community, err := deviceSecret(binding, "write_community")
+	if err != nil {
+		return nil, err
+	}
+	if community == "" {
+		if community, err = m.readCommunity(binding); err != nil {
+			return nil, err
+		}
+	}
+	results := make([]DeviceOperationResult, 0, len(ops))
+	for _, op := range ops {
+		resp, err := m.roundTrip(ctx, binding, snmpPDU{
+			Type:      snmpSetRequest,
+			Community: community,
+			VarBinds:  []snmpVarBind{{OID: op.OID, Type: snmpOctetString, Value: op.Value}},
+		})
+		if err == nil && resp.ErrorStatus != 0 {
+			err = fmt.Errorf("snmp set %s failed with error-status %d", op.OID, resp.ErrorStatus)
+		}
+		if err != nil {
+			results = append(results, DeviceOperationResult{Operation: op, Status: "failed", Error: err.Error()})
+			return failRemainingDeviceOps(results, ops), err
+		}
+		results = append(results, DeviceOperationResult{Operation: op, Status: "applied"})
+	}
+	return results, nil
+}
+
+func (m *SNMPDeviceModule) Facts(ctx context.Context, binding ProxyMinionBinding) (map[string]any, error) {
+	community, err := m.readCommunity(binding)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]string{
+		snmpOIDSysDescr:    "description",
+		snmpOIDSysObjectID: "object_id",
+		snmpOIDSysUpTime:   "uptime_ticks",
+		snmpOIDSysContact:  "contact",
+		snmpOIDSysName:     "name",
+		snmpOIDSysLocation: "location",
+	}
+	req := snmpPDU{Type: snmpGetRequest, Community: community}
+	for _, oid := range []string{snmpOIDSysDescr, snmpOIDSysObjectID, snmpOIDSysUpTime, snmpOIDSysContact, snmpOIDSysName, snmpOIDSysLocation} {
+		req.VarBinds = append(req.VarBinds, snmpVarBind{OID: oid, Type: snmpNull})
+	}
+	resp, err := m.roundTrip(ctx, binding, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorStatus != 0 {
+		return nil, fmt.Errorf("snmp get failed with error-status %d", resp.ErrorStatus)
+	}
+	facts := map[string]any{}
+	for _, vb := range resp.VarBinds {
+		if name, ok := names[vb.OID]; ok && vb.Value != nil {
+			facts[name] = vb.Value
+		}
+	}
+	return facts, nil
+}
+
+func (m *SNMPDeviceModule) readCommunity(binding ProxyMinionBinding) (string, error) {
+	community, err := deviceSecret(binding, "community")
+	if err != nil || community != "" {
+		return community, err
+	}
+	return metadataOr(binding, "community", "public"), nil
+}
+
+func (m *SNMPDeviceModule) roundTrip(ctx context.Context, binding ProxyMinionBinding, req snmpPDU) (snmpPDU, error) {
+	address := strings.TrimSpace(binding.Metadata["address"])
+	if address == "" {
+		return snmpPDU{}, errors.New("snmp binding requires metadata.address")
+	}
+	req.RequestID = int64(rand.Int31())
+	payload, err := encodeSNMP(req)
+	if err != nil {
+		return snmpPDU{}, err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", net.JoinHostPort(address, metadataOr(binding, "port", "161")))
+	if err != nil {
+		return snmpPDU{}, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(m.Timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+	if _, err := conn.Write(payload); err != nil {
+		return snmpPDU{}, err
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return snmpPDU{}, errors.New("snmp request to " + address + " failed: " + err.Error())
+		}
+		resp, err := decodeSNMP(buf[:n])
+		if err != nil {
+			return snmpPDU{}, err
+		}
+		// Late replies to earlier requests on a reused port are ignored.
+		if resp.RequestID == req.RequestID {
+			return resp, nil
+		}
+	}
+}
+
+// SNMPv2c message encoding: just enough BER for get/set of scalar objects.
+
+const (
+	snmpInteger        byte = 0x02
+	snmpOctetString    byte = 0x04
+	snmpNull           byte = 0x05
+	snmpObjectID       byte = 0x06
+	snmpSequence       byte = 0x30
+	snmpIPAddress      byte = 0x40
+	snmpCounter32      byte = 0x41
+	snmpGauge32        byte = 0x42
+	snmpTimeTicks      byte = 0x43
+	snmpCounter64      byte = 0x46
+	snmpNoSuchObject   byte = 0x80
+	snmpNoSuchInstance byte = 0x81
+	snmpEndOfMibView   byte = 0x82
+	snmpGetRequest     byte = 0xa0
+	snmpGetResponse    byte = 0xa2
+	snmpSetRequest     byte = 0xa3
+)
+
+type snmpVarBind struct {
+	OID   string
+	Type  byte
+	Value any
+}
+
+type snmpPDU struct {
+	Type        byte
+	Community   string
+	RequestID   int64
+	ErrorStatus int64
+	ErrorIndex  int64
+	VarBinds    []snmpVarBind
+}
+
+func encodeSNMP(p snmpPDU) ([]byte, error) {
+	var binds []byte
+	for _, vb := range p.VarBinds {
+		oid, err := encodeSNMPOID(vb.OID)
+		if err != nil {
+			return nil, err
+		}
+		var value []byte
+		switch vb.Type {
+		case snmpOctetString:
+			s, _ := vb.Value.(string)
+			value = berTLV(snmpOctetString, []byte(s))
+		case snmpInteger, snmpCounter32, snmpGauge32, snmpTimeTicks:
+			n, _ := vb.Value.(int64)
+			value = berTLV(vb.Type, berInt(n))
+		case snmpObjectID:
+			s, _ := vb.Value.(string)
+			raw, err := encodeSNMPOID(s)
+			if err != nil {
+				return nil, err
+			}
+			value = raw
+		default:
+			value = berTLV(snmpNull, nil)
+		}
+		binds = append(binds, berTLV(snmpSequence, append(oid, value...))...)
+	}
+	pdu := append(berTLV(snmpInteger, berInt(p.RequestID)), berTLV(snmpInteger, berInt(p.ErrorStatus))...)
+	pdu = append(pdu, berTLV(snmpInteger, berInt(p.ErrorIndex))...)
+	pdu = append(pdu, berTLV(snmpSequence, binds)...)
+	msg := append(berTLV(snmpInteger, berInt(1)), berTLV(snmpOctetString, []byte(p.Community))...)
+	msg = append(msg, berTLV(p.Type, pdu)...)
+	return berTLV(snmpSequence, msg), nil
+}
+
+func decodeSNMP(b []byte) (snmpPDU, error) {
+	bad := errors.New("malformed snmp message")
+	tag, msg, _, err := berRead(b)
+	if err != nil || tag != snmpSequence {
+		return snmpPDU{}, bad
+	}
+	var out snmpPDU
+	tag, version, msg, err := berRead(msg)
+	if err != nil || tag != snmpInteger || berParseInt(version) != 1 {
+		return snmpPDU{}, errors.New("unsupported snmp version")
+	}
+	tag, community, msg, err := berRead(msg)
+	if err != nil || tag != snmpOctetString {
+		return snmpPDU{}, bad
+	}
+	out.Community = string(community)
+	tag, pdu, _, err := berRead(msg)
+	if err != nil {
+		return snmpPDU{}, bad
+	}
+	out.Type = tag
+	ints := make([]int64, 3)
+	for i := range ints {
+		var raw []byte
+		if tag, raw, pdu, err = berRead(pdu); err != nil || tag != snmpInteger {
+			return snmpPDU{}, bad
+		}
+		ints[i] = berParseInt(raw)
+	}
+	out.RequestID, out.ErrorStatus, out.ErrorIndex = ints[0], ints[1], ints[2]
+	tag, binds, _, err := berRead(pdu)
+	if err != nil || tag != snmpSequence {
+		return snmpPDU{}, bad
+	}
+	for len(binds) > 0 {
+		var vb, oid, value []byte
+		if tag, vb, binds, err = berRead(binds); err != nil || tag != snmpSequence {
+			return snmpPDU{}, bad
+		}
+		if tag, oid, vb, err = berRead(vb); err != nil || tag != snmpObjectID {
+			return snmpPDU{}, bad
+		}
+		var vtag byte
+		if vtag, value, _, err = berRead(vb); err != nil {
+			return snmpPDU{}, bad
+		}
+		item := snmpVarBind{OID: decodeSNMPOID(oid), Type: vtag}
+		switch vtag {
+		case snmpOctetString:
+			item.Value = string(value)
+		case snmpInteger, snmpCounter32, snmpGauge32, snmpTimeTicks, snmpCounter64:
+			item.Value = berParseInt(value)
+		case snmpObjectID:
+			item.Value = decodeSNMPOID(value)
+		case snmpIPAddress:
+			if len(value) == 4 {
+				item.Value = net.IP(value).String()
+			}
+		}
+		out.VarBinds = append(out.VarBinds, item)
+	}
+	return out, nil
+}
+
+func berTLV(tag byte, content []byte) []byte {
+	out := []byte{tag}
+	n := len(content)
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n < 0x100:
+		out = append(out, 0x81, byte(n))
+	default:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	}
+	return append(out, content...)
+}
+
+// berRead splits the first TLV off b.
+func berRead(b []byte) (byte, []byte, []byte, error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errors.New("short ber value")
+	}
+	tag, n, at := b[0], int(b[1]), 2
+	if n&0x80 != 0 {
+		width := n & 0x7f
+		if width == 0 || width > 2 || len(b) < 2+width {
+			return 0, nil, nil, errors.New("unsupported ber length")
+		}
+		n = 0
+		for _, c := range b[2 : 2+width] {
+			n = n<<8 | int(c)
+		}
+		at += width
+	}
+	if len(b) < at+n {
+		return 0, nil, nil, errors.New("truncated ber value")
+	}
+	return tag, b[at : at+n], b[at+n:], nil
+}
+
+func berInt(n int64) []byte {
+	out := []byte{byte(n)}
+	for n > 127 || n < -128 {
+		n >>= 8
+		out = append([]byte{byte(n)}, out...)
+	}
+	return out
+}
+
+func berParseInt(b []byte) int64 {
+	var n int64
+	for i, c := range b {
+		if i == 0 && c&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int64(c)
+	}
+	return n
+}
+
+func encodeSNMPOID(oid string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(oid), "."), ".")
+	if len(parts) < 2 {
+		return nil, errors.New("oid must have at least two arcs")
+	}
+	arcs := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid oid " + oid)
+		}
+		arcs[i] = n
+	}
+	if arcs[0] > 2 || (arcs[0] < 2 && arcs[1] > 39) {
+		return nil, errors.New("invalid oid " + oid)
+	}
+	content := berBase128(arcs[0]*40 + arcs[1])
+	for _, arc := range arcs[2:] {
+		content = append(content, berBase128(arc)...)
+	}
+	return berTLV(snmpObjectID, content), nil
+}
+
+func berBase128(n uint64) []byte {
+	out := []byte{byte(n & 0x7f)}
+	for n >>= 7; n > 0; n >>= 7 {
+		out = append([]byte{byte(n&0x7f) | 0x80}, out...)
+	}
+	return out
+}
+
+func decodeSNMPOID(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	arcs := []string{}
+	var n uint64
+	first := true
+	for _, c := range b {
+		n = n<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			continue
+		}
+		if first {
+			x := n / 40
+			if x > 2 {
+				x = 2
+			}
+			arcs = append(arcs, strconv.FormatUint(x, 10), strconv.FormatUint(n-x*40, 10))
+			first = false
+		} else {
+			arcs = append(arcs, strconv.FormatUint(n, 10))
+		}
+		n = 0
+	}
+	return strings.Join(arcs, ".")
+}
@@ -0,0 +1,180 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// SSHCLIDeviceModule configures network switches through their CLI over
+// the system ssh client. Each operation is sent as a configuration block
+// wrapped in the device's enter/exit config-mode commands.
+//
+// Binding metadata: address (required), port, username, config_enter
+// (default "configure terminal"), config_exit (default "end"),
+// save_command (run after a successful apply when set), and facts_command
+// (default "show version"). Authentication uses the proxy's ssh keys.
+type SSHCLIDeviceModule struct {
+	// Command is the ssh client to run.
+	Command string
+}
+
+func NewSSHCLIDeviceModule() *SSHCLIDeviceModule {
+	return &SSHCLIDeviceModule{Command: "ssh"}
+}
+
+func (m *SSHCLIDeviceModule) Name() string { return "ssh-cli" }
+
+func (m *SSHCLIDeviceModule) ResourceTypes() []string {
+	return []string{"cli", "interface", "vlan"}
+}
+
+func (m *SSHCLIDeviceModule) Translate(resources []DeviceResource) ([]DeviceOperation, error) {
+	out := make([]DeviceOperation, 0, len(resources))
+	for _, res := range resources {
+		props := res.Properties
+		var lines []string
+		var desc string
+		switch strings.ToLower(strings.TrimSpace(res.Type)) {
+		case "vlan":
+			id := deviceString(props, "id")
+			if id == "" {
+				return nil, errors.New("vlan resource " + res.ID + " requires id")
+			}
+			lines = append(lines, "vlan "+id)
+			if name := deviceString(props, "name"); name != "" {
+				lines = append(lines, " name "+name)
+			}
+			lines = append(lines, "exit")
+			desc = "configure vlan " + id
+		case "interface":
+			name := deviceString(props, "name")
+			if name == "" {
+				return nil, errors.New("interface resource " + res.ID + " requires name")
+			}
+			lines = append(lines, "interface "+name)
+			if d := deviceString(props, "description"); d != "" {
+				lines = append(lines, " description "+d)
+			}
+			if vlan := deviceString(props, "access_vlan"); vlan != "" {
+				lines = append(lines, " switchport mode access", " switchport access vlan "+vlan)
+			}
+			if enabled, ok := deviceBool(props, "enabled"); ok {
+				if enabled {
+					lines = append(lines, " no shutdown")
+				} else {
+					lines = append(lines, " shutdown")
+				}
+			}
+			lines = append(lines, "exit")
+			desc = "configure interface " + name
+		case "cli":
+			for _, line := range deviceStrings(props, "lines") {
+				if strings.TrimSpace(line) != "" {
+					lines = append(lines, line)
+				}
+			}
+			if len(lines) == 0 {
+				return nil, errors.New("cli resource " + res.ID + " requires lines")
+			}
+			desc = "apply cli lines"
+		default:
+			return nil, unsupportedDeviceResource(m.Name(), res)
+		}
+		out = append(out, DeviceOperation{
+			ResourceID:  res.ID,
+			Kind:        "cli",
+			Description: desc,
+			Commands:    lines,
+		})
+	}
+	return out, nil
+}
+
+func (m *SSHCLIDeviceModule) Apply(ctx context.Context, binding ProxyMinionBinding, ops []DeviceOperation) ([]DeviceOperationResult, error) {
+	enter := metadataOr(binding, "config_enter", "configure terminal")
+	exit := metadataOr(binding, "config_exit", "end")
+	results := make([]DeviceOperationResult, 0, len(ops))
+	for _, op := range ops {
+		script := append([]string{enter}, op.Commands...)
+		script = append(script, exit)
+		out, err := m.run(ctx, binding, script)
+		if err != nil {
+			results = append(results, DeviceOperationResult{Operation: op, Status: "failed", Output: out, Error: err.Error()})
+			return failRemainingDeviceOps(results, ops), err
+		}
+		results = append(results, DeviceOperationResult{Operation: op, Status: "applied", Output: out})
+	}
+	if save := strings.TrimSpace(binding.Metadata["save_command"]); save != "" && len(ops) > 0 {
+		if out, err := m.run(ctx, binding, []string{save}); err != nil {
+			return results, errors.New("save_command failed: " + err.Error() + ": " + out)
+		}
+	}
+	return results, nil
+}
+
+func (m *SSHCLIDeviceModule) Facts(ctx context.Context, binding ProxyMinionBinding) (map[string]any, error) {
+	command := metadataOr(binding, "facts_command", "show version")
+	out, err := m.run(ctx, binding, []string{command})
+	if err != nil {
+		return nil, err
+	}
+	facts := map[string]any{
+		"facts_command": command,
+		"output":        out,
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			facts["version"] = line
+			break
+		}
+	}
+	return facts, nil
+}
+
+// run feeds lines to the device's CLI on stdin over a non-interactive ssh
+// session.
+func (m *SSHCLIDeviceModule) run(ctx context.Context, binding ProxyMinionBinding, lines []string) (string, error) {
+	address := strings.TrimSpace(binding.Metadata["address"])
+	if address == "" {
+		return "", errors.New("ssh-cli binding requires metadata.address")
+	}
+	user := strings.TrimSpace(binding.Metadata["username"])
+	// A leading "-" would be parsed by ssh as an option such as
+	// -oProxyCommand, which runs arbitrary commands on the proxy.
+	if strings.HasPrefix(address, "-") || strings.HasPrefix(user, "-") {
+		return "", errors.New("ssh-cli address and username may not start with -")
+	}
+	target := address
+	if user != "" {
+		target = user + "@" + address
+	}
+	args := []string{"-T", "-o", "BatchMode=yes"}
+	if port := strings.TrimSpace(binding.Metadata["port"]); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return "", errors.New("ssh-cli port must be a number between 1 and 65535")
+		}
+		args = append(args, "-p", port)
+	}
+	args = append(args, "--", target)
+	cmd := exec.CommandContext(ctx, m.Command, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(out.String()), errors.New("ssh-cli session failed: " + err.Error())
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func metadataOr(binding ProxyMinionBinding, key, fallback string) string {
+	if v := strings.TrimSpace(binding.Metadata[key]); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -23,6 +23,8 @@ type ProxyMinionBinding struct {
 	Transport  string            `json:"transport"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
 	Status     string            `json:"status"`
+	Facts      map[string]any    `json:"facts,omitempty"`
+	FactsAt    *time.Time        `json:"facts_at,omitempty"`
 	UpdatedAt  time.Time         `json:"updated_at"`
 	Registered time.Time         `json:"registered"`
 }
@@ -145,6 +147,23 @@ func (s *ProxyMinionStore) ResolveDevice(deviceID string) (ProxyMinionBinding, b
 	return cloneProxyBinding(*item), true
 }
 
+// RecordFacts stores facts a device module gathered for the binding's device.
+func (s *ProxyMinionStore) RecordFacts(id string, facts map[string]any) (ProxyMinionBinding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.bindings[strings.TrimSpace(id)]
+	if !ok {
+		return ProxyMinionBinding{}, errors.New("proxy-minion binding not found")
+	}
+	now := time.Now().UTC()
+	item.Facts = map[string]any{}
+	for k, v := range facts {
+		item.Facts[k] = v
+	}
+	item.FactsAt = &now
+	return cloneProxyBinding(*item), nil
+}
+
 func (s *ProxyMinionStore) RecordDispatch(binding ProxyMinionBinding, req ProxyMinionDispatchRequest, status, jobID string) ProxyMinionDispatchRecord {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -183,5 +202,15 @@ func cloneProxyBinding(in ProxyMinionBinding) ProxyMinionBinding {
 	for k, v := range in.Metadata {
 		out.Metadata[k] = v
 	}
+	if in.Facts != nil {
+		out.Facts = map[string]any{}
+		for k, v := range in.Facts {
+			out.Facts[k] = v
+		}
+	}
+	if in.FactsAt != nil {
+		at := *in.FactsAt
+		out.FactsAt = &at
+	}
 	return out
 }
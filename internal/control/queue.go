@@ -11,6 +11,7 @@ import (
 type JobStatus string
 
 const (
+	JobScheduled JobStatus = "scheduled" // waiting for its not_before time
 	JobPending   JobStatus = "pending"
 	JobRunning   JobStatus = "running"
 	JobSucceeded JobStatus = "succeeded"
@@ -19,15 +20,30 @@ const (
 )
 
 type Job struct {
-	ID             string    `json:"id"`
-	IdempotencyKey string    `json:"idempotency_key,omitempty"`
-	ConfigPath     string    `json:"config_path"`
-	Priority       string    `json:"priority"` // high, normal, low
-	Status         JobStatus `json:"status"`
-	Error          string    `json:"error,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	StartedAt      time.Time `json:"started_at,omitempty"`
-	EndedAt        time.Time `json:"ended_at,omitempty"`
+	ID               string                `json:"id"`
+	IdempotencyKey   string                `json:"idempotency_key,omitempty"`
+	Tenant           string                `json:"tenant,omitempty"`
+	Environment      string                `json:"environment,omitempty"`
+	Region           string                `json:"region,omitempty"`
+	Partition        string                `json:"partition,omitempty"`
+	Worker           string                `json:"worker,omitempty"`
+	ExecutionEnv     string                `json:"execution_env,omitempty"`
+	CloudCredentials []string              `json:"cloud_credentials,omitempty"`
+	ChangeRecordID   string                `json:"change_record_id,omitempty"`
+	Labels           map[string]string     `json:"labels,omitempty"`
+	Placement        *JobTopologyPlacement `json:"placement,omitempty"`
+	ConcurrencyGroup string                `json:"concurrency_group,omitempty"`
+	ConfigPath       string                `json:"config_path"`
+	Priority         string                `json:"priority"` // high, normal, low
+	Status           JobStatus             `json:"status"`
+	Error            string                `json:"error,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	NotBefore        time.Time             `json:"not_before,omitempty"`
+	DeferredBy       string                `json:"deferred_by,omitempty"` // dispatch window that set NotBefore
+	Timezone         string                `json:"timezone,omitempty"`    // target-local zone for dispatch windows
+	Force            bool                  `json:"force,omitempty"`       // bypasses freezes and emergency stops, also at delayed dispatch
+	StartedAt        time.Time             `json:"started_at,omitempty"`
+	EndedAt          time.Time             `json:"ended_at,omitempty"`
 }
 
 type WorkerLifecyclePolicy struct {
@@ -54,6 +70,12 @@ type Executor interface {
 	ApplyPath(configPath string) error
 }
 
+// JobExecutor is implemented by executors that need the whole job rather
+// than just its config path, such as the isolated runner.
+type JobExecutor interface {
+	ApplyJob(job Job) error
+}
+
 type Queue struct {
 	mu              sync.RWMutex
 	nextID          int64
@@ -67,14 +89,26 @@ type Queue struct {
 	emergencyStop   bool
 	emergencySince  time.Time
 	emergencyReason string
+	emergencySetBy  string
+	emergencyScopes map[string]EmergencyStopScope
+	nextScopeID     int64
 	freezeUntil     time.Time
 	freezeReason    string
 	paused          bool
+	draining        bool
 	running         int
 	rrIndex         int
 	workerPolicy    WorkerLifecyclePolicy
 	generation      int64
 	recycles        int64
+	shardPolicy     QueueShardPolicy
+	shards          map[string]*queueShard
+	overflow        map[string][]string
+	partitioned     map[string][]string
+	groupRunning    map[string]string
+	groupParked     map[string][]string
+	windows         map[string]DispatchWindow
+	shedding        queueShedding
 }
 
 func NewQueue(buffer int) *Queue {
@@ -82,12 +116,20 @@ func NewQueue(buffer int) *Queue {
 		buffer = 128
 	}
 	return &Queue{
-		jobs:           map[string]*Job{},
-		byIdempotency:  map[string]string{},
-		pendingHigh:    make(chan string, buffer),
-		pendingNormal:  make(chan string, buffer),
-		pendingLow:     make(chan string, buffer),
-		workerShutdown: make(chan struct{}),
+		jobs:            map[string]*Job{},
+		byIdempotency:   map[string]string{},
+		pendingHigh:     make(chan string, buffer),
+		pendingNormal:   make(chan string, buffer),
+		pendingLow:      make(chan string, buffer),
+		workerShutdown:  make(chan struct{}),
+		shardPolicy:     QueueShardPolicy{Capacities: map[string]int{}, UpdatedAt: time.Now().UTC()},
+		shards:          map[string]*queueShard{},
+		overflow:        map[string][]string{},
+		partitioned:     map[string][]string{},
+		groupRunning:    map[string]string{},
+		groupParked:     map[string][]string{},
+		windows:         map[string]DispatchWindow{},
+		emergencyScopes: map[string]EmergencyStopScope{},
 		workerPolicy: WorkerLifecyclePolicy{
 			Mode:             "persistent",
 			MaxJobsPerWorker: 0,
@@ -107,6 +149,22 @@ func (q *Queue) Subscribe(fn func(Job)) {
 }
 
 func (q *Queue) Enqueue(configPath, key string, force bool, priority string) (*Job, error) {
+	return q.EnqueueTenant("", configPath, key, force, priority)
+}
+
+// EnqueueTenant enqueues a job in the tenant's shard. The job is rejected
+// when the shard already holds its capacity of pending jobs.
+func (q *Queue) EnqueueTenant(tenant, configPath, key string, force bool, priority string) (*Job, error) {
+	return q.EnqueuePlaced(JobPlacement{Tenant: tenant}, configPath, key, force, priority)
+}
+
+// EnqueuePlaced enqueues a job with its tenant and scheduler placement. Jobs
+// with a partition wait for a worker registered for that partition instead
+// of the in-process worker.
+func (q *Queue) EnqueuePlaced(placement JobPlacement, configPath, key string, force bool, priority string) (*Job, error) {
+	placement = normalizeJobPlacement(placement)
+	tenant := placement.Tenant
+	group := normalizeConcurrencyGroup(placement.ConcurrencyGroup, configPath)
 	q.mu.Lock()
 	if key != "" {
 		if existingID, ok := q.byIdempotency[key]; ok {
@@ -115,10 +173,18 @@ func (q *Queue) Enqueue(configPath, key string, force bool, priority string) (*J
 			return cp, nil
 		}
 	}
+	if q.draining {
+		q.mu.Unlock()
+		return nil, errors.New("queue is draining for shutdown; new jobs are not accepted")
+	}
 	if q.emergencyStop && !force {
 		q.mu.Unlock()
 		return nil, errors.New("emergency stop active; new applies are halted")
 	}
+	if scope, stopped := q.emergencyScopeLocked(placement.Environment, tenant, placement.Labels); stopped && !force {
+		q.mu.Unlock()
+		return nil, errors.New("emergency stop active for " + scope.Describe() + " (" + scope.ID + "); new applies are halted")
+	}
 	if !force && !q.freezeUntil.IsZero() && time.Now().UTC().Before(q.freezeUntil) {
 		until := q.freezeUntil.Format(time.RFC3339)
 		reason := strings.TrimSpace(q.freezeReason)
@@ -130,28 +196,74 @@ func (q *Queue) Enqueue(configPath, key string, force bool, priority string) (*J
 	}
 
 	p := normalizePriority(priority)
+	if !force {
+		if err := q.shedLocked(&placement, p); err != nil {
+			q.mu.Unlock()
+			return nil, err
+		}
+	}
+	if err := q.admitShardLocked(tenant); err != nil {
+		q.mu.Unlock()
+		return nil, err
+	}
+
 	q.nextID++
 	id := "job-" + time.Now().UTC().Format("20060102T150405") + "-" + itoa(q.nextID)
 	j := &Job{
-		ID:             id,
-		IdempotencyKey: key,
-		ConfigPath:     configPath,
-		Priority:       p,
-		Status:         JobPending,
-		CreatedAt:      time.Now().UTC(),
+		ID:               id,
+		IdempotencyKey:   key,
+		Tenant:           tenant,
+		Environment:      placement.Environment,
+		Region:           placement.Region,
+		Partition:        placement.Partition,
+		ExecutionEnv:     placement.ExecutionEnv,
+		CloudCredentials: placement.CloudCredentials,
+		ChangeRecordID:   placement.ChangeRecordID,
+		Labels:           placement.Labels,
+		Placement:        placement.Topology,
+		ConcurrencyGroup: group,
+		ConfigPath:       configPath,
+		Priority:         p,
+		CreatedAt:        time.Now().UTC(),
+		NotBefore:        placement.NotBefore,
+		Timezone:         placement.Timezone,
+		Force:            force,
+	}
+	delayed := j.NotBefore.After(j.CreatedAt)
+	if !delayed {
+		if name, open := q.closedWindowLocked(j, j.CreatedAt); !open.IsZero() {
+			j.NotBefore, j.DeferredBy, delayed = open, name, true
+		}
+	}
+	if j.Partition == "" && !delayed {
+		if err := q.pushPending(id, p); err != nil {
+			q.shardLocked(tenant).rejected++
+			q.mu.Unlock()
+			return nil, err
+		}
 	}
 	q.jobs[id] = j
+	if j.Partition != "" && !delayed {
+		q.queuePartitionedLocked(j)
+	}
 	if key != "" {
 		q.byIdempotency[key] = id
 	}
-	if err := q.pushPending(id, p); err != nil {
-		delete(q.jobs, id)
-		delete(q.byIdempotency, key)
-		q.mu.Unlock()
-		return nil, err
+	if delayed {
+		q.setStatusLocked(j, JobScheduled)
+	} else {
+		q.setStatusLocked(j, JobPending)
+	}
+	q.shardLocked(tenant).enqueued++
+	var superseded []Job
+	if placement.CancelSuperseded {
+		superseded = q.cancelSupersededLocked(group, id)
 	}
 	cp := q.clone(j)
 	q.mu.Unlock()
+	for _, old := range superseded {
+		q.publish(old)
+	}
 	q.publish(*cp)
 	return cp, nil
 }
@@ -176,6 +288,13 @@ func (q *Queue) List() []Job {
 	return out
 }
 
+// Len returns the number of jobs tracked by the queue, finished or not.
+func (q *Queue) Len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return len(q.jobs)
+}
+
 func (q *Queue) Cancel(id string) error {
 	q.mu.Lock()
 	j, ok := q.jobs[id]
@@ -187,7 +306,7 @@ func (q *Queue) Cancel(id string) error {
 		q.mu.Unlock()
 		return errors.New("job already finished")
 	}
-	j.Status = JobCanceled
+	q.setStatusLocked(j, JobCanceled)
 	j.EndedAt = time.Now().UTC()
 	cp := *j
 	q.mu.Unlock()
@@ -207,7 +326,7 @@ func (q *Queue) FailJob(id, reason string) (Job, error) {
 		q.mu.Unlock()
 		return cp, nil
 	}
-	j.Status = JobFailed
+	q.setStatusLocked(j, JobFailed)
 	j.Error = strings.TrimSpace(reason)
 	if j.Error == "" {
 		j.Error = "job failed by operator action"
@@ -222,7 +341,38 @@ func (q *Queue) FailJob(id, reason string) (Job, error) {
 	return cp, nil
 }
 
+// InterruptJob fails a running job as if its worker died mid-apply. The
+// executor's eventual result for the job is discarded.
+func (q *Queue) InterruptJob(id, reason string) (Job, error) {
+	q.mu.Lock()
+	j, ok := q.jobs[strings.TrimSpace(id)]
+	if !ok {
+		q.mu.Unlock()
+		return Job{}, errors.New("job not found")
+	}
+	if j.Status != JobRunning {
+		q.mu.Unlock()
+		return Job{}, errors.New("job is not running")
+	}
+	q.setStatusLocked(j, JobFailed)
+	j.Error = strings.TrimSpace(reason)
+	if j.Error == "" {
+		j.Error = "worker interrupted"
+	}
+	j.EndedAt = time.Now().UTC()
+	// runOne settles the running count for in-process jobs; claimed jobs
+	// have no executor waiting on them here.
+	if j.Worker != "" && q.running > 0 {
+		q.running--
+	}
+	cp := *q.clone(j)
+	q.mu.Unlock()
+	q.publish(cp)
+	return cp, nil
+}
+
 func (q *Queue) StartWorker(ctx context.Context, exec Executor) {
+	go q.runDelayedDispatcher(ctx)
 	go func() {
 		defer close(q.workerShutdown)
 		q.mu.Lock()
@@ -258,23 +408,41 @@ func (q *Queue) Wait() {
 
 func (q *Queue) runOne(id string, exec Executor) {
 	q.mu.Lock()
+	// A channel slot was freed; move overflowed jobs back in behind it.
+	q.refillLocked()
 	j, ok := q.jobs[id]
-	if !ok || j.Status == JobCanceled {
+	if !ok || j.Status != JobPending {
+		q.mu.Unlock()
+		return
+	}
+	if q.deferForWindowLocked(j, time.Now().UTC()) {
+		cp := *q.clone(j)
+		q.mu.Unlock()
+		q.publish(cp)
+		return
+	}
+	if q.parkIfContendedLocked(j) {
 		q.mu.Unlock()
 		return
 	}
-	j.Status = JobRunning
+	q.setStatusLocked(j, JobRunning)
 	j.StartedAt = time.Now().UTC()
 	q.running++
 	cp := *j
 	q.mu.Unlock()
 	q.publish(cp)
 
-	err := exec.ApplyPath(j.ConfigPath)
+	var err error
+	if jobExec, ok := exec.(JobExecutor); ok {
+		err = jobExec.ApplyJob(cp)
+	} else {
+		err = exec.ApplyPath(cp.ConfigPath)
+	}
 
 	q.mu.Lock()
 	j = q.jobs[id]
-	if j.Status == JobCanceled {
+	if j.Status != JobRunning {
+		// Canceled or interrupted while the executor was busy.
 		if q.running > 0 {
 			q.running--
 		}
@@ -282,10 +450,10 @@ func (q *Queue) runOne(id string, exec Executor) {
 		return
 	}
 	if err != nil {
-		j.Status = JobFailed
+		q.setStatusLocked(j, JobFailed)
 		j.Error = err.Error()
 	} else {
-		j.Status = JobSucceeded
+		q.setStatusLocked(j, JobSucceeded)
 	}
 	j.EndedAt = time.Now().UTC()
 	if q.running > 0 {
@@ -300,7 +468,7 @@ func (q *Queue) runWorkerGeneration(ctx context.Context, exec Executor, policy W
 	maxJobs := normalizedMaxJobs(policy)
 	processed := 0
 	for {
-		if q.IsPaused() {
+		if q.IsPaused() || q.IsDraining() {
 			select {
 			case <-ctx.Done():
 				return processed, true
@@ -331,12 +499,20 @@ func (q *Queue) pushPending(id, priority string) error {
 	default:
 		ch = q.pendingNormal
 	}
-	select {
-	case ch <- id:
+	// Jobs already waiting in overflow go first.
+	q.refillLocked()
+	if len(q.overflow[class]) == 0 {
+		select {
+		case ch <- id:
+			return nil
+		default:
+		}
+	}
+	if q.overflowLenLocked() < q.shardPolicy.MaxOverflow {
+		q.overflow[class] = append(q.overflow[class], id)
 		return nil
-	default:
-		return errors.New("pending queue full for priority class: " + class)
 	}
+	return errors.New("pending queue full for priority class: " + class)
 }
 
 func (q *Queue) nextPending(ctx context.Context) (string, bool) {
@@ -387,6 +563,9 @@ func (q *Queue) clone(j *Job) *Job {
 		return nil
 	}
 	cp := *j
+	cp.CloudCredentials = append([]string(nil), j.CloudCredentials...)
+	cp.Labels = cloneLabels(j.Labels)
+	cp.Placement = cloneJobTopologyPlacement(j.Placement)
 	return &cp
 }
 
@@ -400,10 +579,14 @@ func (q *Queue) publish(job Job) {
 	}
 }
 
+// EmergencyStatus reports the global emergency stop; Active covers only the
+// global stop, while Scopes lists stops limited to one blast domain.
 type EmergencyStatus struct {
-	Active bool      `json:"active"`
-	Since  time.Time `json:"since,omitempty"`
-	Reason string    `json:"reason,omitempty"`
+	Active bool                 `json:"active"`
+	Since  time.Time            `json:"since,omitempty"`
+	Reason string               `json:"reason,omitempty"`
+	SetBy  string               `json:"set_by,omitempty"`
+	Scopes []EmergencyStopScope `json:"scopes"`
 }
 
 type FreezeStatus struct {
@@ -413,6 +596,12 @@ type FreezeStatus struct {
 }
 
 func (q *Queue) SetEmergencyStop(active bool, reason string) EmergencyStatus {
+	return q.SetEmergencyStopBy(active, reason, "")
+}
+
+// SetEmergencyStopBy toggles the global emergency stop and records who set
+// it.
+func (q *Queue) SetEmergencyStopBy(active bool, reason, setBy string) EmergencyStatus {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	q.emergencyStop = active
@@ -421,24 +610,28 @@ func (q *Queue) SetEmergencyStop(active bool, reason string) EmergencyStatus {
 			q.emergencySince = time.Now().UTC()
 		}
 		q.emergencyReason = reason
+		q.emergencySetBy = strings.TrimSpace(setBy)
 	} else {
 		q.emergencySince = time.Time{}
 		q.emergencyReason = ""
+		q.emergencySetBy = ""
 	}
-	return EmergencyStatus{
-		Active: q.emergencyStop,
-		Since:  q.emergencySince,
-		Reason: q.emergencyReason,
-	}
+	return q.emergencyStatusLocked()
 }
 
 func (q *Queue) EmergencyStatus() EmergencyStatus {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
+	return q.emergencyStatusLocked()
+}
+
+func (q *Queue) emergencyStatusLocked() EmergencyStatus {
 	return EmergencyStatus{
 		Active: q.emergencyStop,
 		Since:  q.emergencySince,
 		Reason: q.emergencyReason,
+		SetBy:  q.emergencySetBy,
+		Scopes: q.emergencyScopesLocked(),
 	}
 }
 
@@ -569,13 +762,17 @@ func (q *Queue) ControlStatus() QueueControlStatus {
 }
 
 func (q *Queue) controlStatusLocked() QueueControlStatus {
-	high := len(q.pendingHigh)
-	normal := len(q.pendingNormal)
-	low := len(q.pendingLow)
+	high := len(q.pendingHigh) + len(q.overflow["high"])
+	normal := len(q.pendingNormal) + len(q.overflow["normal"])
+	low := len(q.pendingLow) + len(q.overflow["low"])
+	partitioned := 0
+	for _, ids := range q.partitioned {
+		partitioned += len(ids)
+	}
 	return QueueControlStatus{
 		Paused:        q.paused,
 		Running:       q.running,
-		Pending:       high + normal + low,
+		Pending:       high + normal + low + partitioned,
 		PendingHigh:   high,
 		PendingNormal: normal,
 		PendingLow:    low,
@@ -613,7 +810,7 @@ func (q *Queue) RecoverStuckJobs(maxAge time.Duration) []Job {
 		if now.Sub(j.StartedAt) < maxAge {
 			continue
 		}
-		j.Status = JobFailed
+		q.setStatusLocked(j, JobFailed)
 		j.Error = "stale run lease recovered by control plane"
 		j.EndedAt = now
 		if q.running > 0 {
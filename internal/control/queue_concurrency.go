@@ -0,0 +1,224 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// NoConcurrencyGroup opts a job out of group serialization.
+const NoConcurrencyGroup = "none"
+
+// JobConcurrencyStatus explains why a pending job is waiting on its
+// concurrency group: the job holding the group and the older pending jobs
+// queued ahead of it.
+type JobConcurrencyStatus struct {
+	JobID      string    `json:"job_id"`
+	Group      string    `json:"group,omitempty"`
+	Status     JobStatus `json:"status"`
+	RunningJob string    `json:"running_job,omitempty"`
+	Ahead      []string  `json:"ahead,omitempty"`
+	Position   int       `json:"position"`
+	Contended  bool      `json:"contended"`
+}
+
+// concurrencyGroupTTL bounds how long a derived group is reused while the
+// config file itself is unchanged, so edits to included files are picked up.
+const concurrencyGroupTTL = 30 * time.Second
+
+type concurrencyGroupEntry struct {
+	modTime  time.Time
+	size     int64
+	group    string
+	cachedAt time.Time
+}
+
+var concurrencyGroups = struct {
+	sync.Mutex
+	items map[string]concurrencyGroupEntry
+}{items: map[string]concurrencyGroupEntry{}}
+
+// DefaultConcurrencyGroup derives the group for a config: its path plus a
+// hash of the hosts it targets, so two applies of the same config to the
+// same hosts never run at once. Configs that fail to load group by path.
+// Results are cached per path until the file changes or the TTL passes, so
+// enqueueing does not re-parse the config every time.
+func DefaultConcurrencyGroup(configPath string) string {
+	path := filepath.Clean(strings.TrimSpace(configPath))
+	if path == "." {
+		return ""
+	}
+	group := "config:" + path
+	info, err := os.Stat(path)
+	if err != nil {
+		return group
+	}
+	now := time.Now()
+	concurrencyGroups.Lock()
+	entry, ok := concurrencyGroups.items[path]
+	concurrencyGroups.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() && now.Sub(entry.cachedAt) < concurrencyGroupTTL {
+		return entry.group
+	}
+	if cfg, err := config.Load(path); err == nil {
+		hosts := make([]string, 0, len(cfg.Inventory.Hosts))
+		for _, h := range cfg.Inventory.Hosts {
+			hosts = append(hosts, strings.ToLower(strings.TrimSpace(h.Name)))
+		}
+		sort.Strings(hosts)
+		sum := sha256.Sum256([]byte(strings.Join(hosts, "\n")))
+		group += "#hosts:" + hex.EncodeToString(sum[:6])
+	}
+	concurrencyGroups.Lock()
+	concurrencyGroups.items[path] = concurrencyGroupEntry{modTime: info.ModTime(), size: info.Size(), group: group, cachedAt: now}
+	concurrencyGroups.Unlock()
+	return group
+}
+
+func normalizeConcurrencyGroup(group, configPath string) string {
+	group = strings.TrimSpace(group)
+	switch {
+	case strings.EqualFold(group, NoConcurrencyGroup):
+		return ""
+	case group == "":
+		return DefaultConcurrencyGroup(configPath)
+	default:
+		return group
+	}
+}
+
+// groupBusyLocked reports the job holding group, if any.
+func (q *Queue) groupBusyLocked(group string) (string, bool) {
+	if group == "" {
+		return "", false
+	}
+	holder, ok := q.groupRunning[group]
+	return holder, ok
+}
+
+// trackGroupLocked keeps the running holder of each concurrency group in
+// step with a status change and, when a holder stops, hands parked jobs
+// back to the in-process worker.
+func (q *Queue) trackGroupLocked(j *Job, status JobStatus) {
+	group := j.ConcurrencyGroup
+	if group == "" {
+		return
+	}
+	if status == JobRunning {
+		q.groupRunning[group] = j.ID
+		return
+	}
+	if j.Status != JobRunning || q.groupRunning[group] != j.ID {
+		return
+	}
+	delete(q.groupRunning, group)
+	q.releaseParkedLocked(group)
+}
+
+// releaseParkedLocked pushes group's parked jobs to the pending queue in
+// order. Jobs that do not fit in a full pending buffer stay parked and are
+// retried by RetryParked.
+func (q *Queue) releaseParkedLocked(group string) {
+	parked := q.groupParked[group]
+	delete(q.groupParked, group)
+	for i, id := range parked {
+		waiting, ok := q.jobs[id]
+		if !ok || waiting.Status != JobPending {
+			continue
+		}
+		if err := q.pushPending(id, waiting.Priority); err != nil {
+			q.groupParked[group] = append(q.groupParked[group], parked[i:]...)
+			return
+		}
+	}
+}
+
+// RetryParked releases jobs still parked on groups that no job holds, which
+// happens when the pending buffer was full as the holder stopped. It runs
+// on every delayed dispatcher pass.
+func (q *Queue) RetryParked() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	groups := make([]string, 0, len(q.groupParked))
+	for group := range q.groupParked {
+		if _, busy := q.groupBusyLocked(group); !busy {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		q.releaseParkedLocked(group)
+	}
+}
+
+// parkIfContendedLocked holds a pending in-process job back while another
+// job in its group is running. It reports whether the job was parked.
+func (q *Queue) parkIfContendedLocked(j *Job) bool {
+	if _, busy := q.groupBusyLocked(j.ConcurrencyGroup); !busy {
+		return false
+	}
+	q.groupParked[j.ConcurrencyGroup] = append(q.groupParked[j.ConcurrencyGroup], j.ID)
+	return true
+}
+
+// cancelSupersededLocked cancels pending jobs in group that a newer job
+// replaces. Running jobs are left alone.
+func (q *Queue) cancelSupersededLocked(group, by string) []Job {
+	if group == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+	var out []Job
+	for _, j := range q.jobs {
+		if j.ConcurrencyGroup != group || j.Status != JobPending || j.ID == by {
+			continue
+		}
+		q.setStatusLocked(j, JobCanceled)
+		j.Error = "superseded by " + by
+		j.EndedAt = now
+		out = append(out, *q.clone(j))
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.Before(out[b].CreatedAt) })
+	return out
+}
+
+// ConcurrencyStatus reports a job's place in its concurrency group.
+func (q *Queue) ConcurrencyStatus(id string) (JobConcurrencyStatus, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	j, ok := q.jobs[strings.TrimSpace(id)]
+	if !ok {
+		return JobConcurrencyStatus{}, errors.New("job not found")
+	}
+	out := JobConcurrencyStatus{JobID: j.ID, Group: j.ConcurrencyGroup, Status: j.Status}
+	if j.ConcurrencyGroup == "" || j.Status != JobPending {
+		return out, nil
+	}
+	if holder, busy := q.groupBusyLocked(j.ConcurrencyGroup); busy {
+		out.RunningJob = holder
+	}
+	ahead := make([]*Job, 0)
+	for _, other := range q.jobs {
+		if other.ID == j.ID || other.ConcurrencyGroup != j.ConcurrencyGroup || other.Status != JobPending {
+			continue
+		}
+		if other.CreatedAt.Before(j.CreatedAt) || (other.CreatedAt.Equal(j.CreatedAt) && other.ID < j.ID) {
+			ahead = append(ahead, other)
+		}
+	}
+	sort.Slice(ahead, func(a, b int) bool { return ahead[a].CreatedAt.Before(ahead[b].CreatedAt) })
+	for _, other := range ahead {
+		out.Ahead = append(out.Ahead, other.ID)
+	}
+	out.Position = len(out.Ahead) + 1
+	out.Contended = out.RunningJob != "" || len(out.Ahead) > 0
+	return out, nil
+}
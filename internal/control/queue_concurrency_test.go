@@ -0,0 +1,150 @@
+package control
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueue_ConcurrencyGroupSerializesConflictingJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewQueue(16)
+
+	held, err := q.EnqueuePlaced(JobPlacement{Partition: "edge"}, "site.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held.ConcurrencyGroup != "config:site.yaml" {
+		t.Fatalf("expected default group from config path, got %q", held.ConcurrencyGroup)
+	}
+	if _, ok := q.ClaimPartitioned("w1", []string{"edge"}); !ok {
+		t.Fatalf("expected first job claimed")
+	}
+
+	exec := &fakeExecutor{}
+	q.StartWorker(ctx, exec)
+	local, err := q.Enqueue("site.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	later, err := q.EnqueuePlaced(JobPlacement{Partition: "edge"}, "site.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unrelated, err := q.EnqueuePlaced(JobPlacement{Partition: "edge", ConcurrencyGroup: NoConcurrencyGroup}, "site.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if cur, _ := q.Get(local.ID); cur.Status != JobPending {
+		t.Fatalf("expected in-process job parked behind running group holder, got %s", cur.Status)
+	}
+	claimed, ok := q.ClaimPartitioned("w2", []string{"edge"})
+	if !ok || claimed.ID != unrelated.ID {
+		t.Fatalf("expected only the ungrouped job claimable while the group is busy, got %+v ok=%v", claimed, ok)
+	}
+	status, err := q.ConcurrencyStatus(later.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Contended || status.RunningJob != held.ID || status.Position != 2 || len(status.Ahead) != 1 || status.Ahead[0] != local.ID {
+		t.Fatalf("unexpected contention report: %+v", status)
+	}
+
+	if _, err := q.CompletePartitioned(held.ID, "w1", ""); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if cur, _ := q.Get(local.ID); cur.Status == JobSucceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for parked job to run after release")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if claimed, ok := q.ClaimPartitioned("w1", []string{"edge"}); !ok || claimed.ID != later.ID {
+		t.Fatalf("expected queued group job claimable once the group is free, got %+v ok=%v", claimed, ok)
+	}
+}
+
+func TestQueue_CancelSupersededPendingDuplicates(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, hosts string) string {
+		path := filepath.Join(tmp, name)
+		body := "version: v0\ninventory:\n  hosts:\n" + hosts + "resources:\n  - id: noop\n    type: command\n    host: a\n    command: \"true\"\n"
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	one := write("one.yaml", "    - name: a\n      transport: local\n")
+	two := write("two.yaml", "    - name: a\n      transport: local\n    - name: b\n      transport: local\n")
+	if g := DefaultConcurrencyGroup(one); !strings.Contains(g, "#hosts:") || g == DefaultConcurrencyGroup(two) {
+		t.Fatalf("expected host set hash in default group, got %q", g)
+	}
+	// A cached group is recomputed once the config file changes.
+	cached := DefaultConcurrencyGroup(two)
+	write("two.yaml", "    - name: a\n      transport: local\n    - name: c\n      transport: local\n    - name: d\n      transport: local\n")
+	if g := DefaultConcurrencyGroup(two); g == cached || !strings.Contains(g, "#hosts:") {
+		t.Fatalf("expected changed config to derive a new group, got %q", g)
+	}
+	two = write("two.yaml", "    - name: a\n      transport: local\n    - name: b\n      transport: local\n")
+
+	q := NewQueue(16)
+	first, _ := q.EnqueuePlaced(JobPlacement{Partition: "p"}, one, "", false, "")
+	second, _ := q.EnqueuePlaced(JobPlacement{Partition: "p"}, one, "", false, "")
+	other, _ := q.EnqueuePlaced(JobPlacement{Partition: "p"}, two, "", false, "")
+	latest, err := q.EnqueuePlaced(JobPlacement{Partition: "p", CancelSuperseded: true}, one, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{first.ID, second.ID} {
+		cur, _ := q.Get(id)
+		if cur.Status != JobCanceled || cur.Error != "superseded by "+latest.ID {
+			t.Fatalf("expected pending duplicate superseded, got %+v", cur)
+		}
+	}
+	if cur, _ := q.Get(other.ID); cur.Status != JobPending {
+		t.Fatalf("expected job in another group untouched, got %s", cur.Status)
+	}
+	if status, _ := q.ConcurrencyStatus(latest.ID); status.Contended || status.Position != 1 {
+		t.Fatalf("expected superseding job at the head of its group, got %+v", status)
+	}
+}
+
+func TestQueue_RetryParkedReleasesJobsWhenBufferFrees(t *testing.T) {
+	q := NewQueue(1)
+	if _, err := q.Enqueue("site.yaml", "", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	q.mu.Lock()
+	q.jobs["job-parked"] = &Job{ID: "job-parked", Status: JobPending, ConcurrencyGroup: "g", Priority: "normal"}
+	q.jobs["job-held"] = &Job{ID: "job-held", Status: JobPending, ConcurrencyGroup: "h", Priority: "normal"}
+	q.groupParked["g"] = []string{"job-parked"}
+	q.groupParked["h"] = []string{"job-held"}
+	q.groupRunning["h"] = "job-running"
+	q.mu.Unlock()
+
+	q.RetryParked()
+	if parked := q.groupParked["g"]; len(parked) != 1 {
+		t.Fatalf("expected job to stay parked while the pending buffer is full, got %v", parked)
+	}
+	<-q.pendingNormal
+	q.RetryParked()
+	if _, ok := q.groupParked["g"]; ok {
+		t.Fatalf("expected parked job released once the buffer has room")
+	}
+	if id := <-q.pendingNormal; id != "job-parked" {
+		t.Fatalf("expected parked job queued, got %s", id)
+	}
+	if parked := q.groupParked["h"]; len(parked) != 1 {
+		t.Fatalf("expected job parked on a held group to stay parked, got %v", parked)
+	}
+}
@@ -0,0 +1,151 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const delayedDispatchInterval = 250 * time.Millisecond
+
+// runDelayedDispatcher moves scheduled jobs into the pending queue once
+// their not_before time has passed, and retries jobs left parked on a free
+// concurrency group.
+func (q *Queue) runDelayedDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(delayedDispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			q.DispatchDue(now)
+			q.RetryParked()
+		}
+	}
+}
+
+// DispatchDue queues every scheduled job due at now, earliest first, and
+// returns them. Jobs whose dispatch window is closed are deferred to its next
+// opening, jobs held by an emergency stop or change freeze stay scheduled
+// until it lifts, and jobs that do not fit in a full pending buffer stay
+// scheduled and are retried on the next pass.
+func (q *Queue) DispatchDue(now time.Time) []Job {
+	q.mu.Lock()
+	due := make([]*Job, 0)
+	for _, j := range q.jobs {
+		if j.Status == JobScheduled && !j.NotBefore.After(now) {
+			due = append(due, j)
+		}
+	}
+	sort.Slice(due, func(a, b int) bool {
+		if !due[a].NotBefore.Equal(due[b].NotBefore) {
+			return due[a].NotBefore.Before(due[b].NotBefore)
+		}
+		return due[a].ID < due[b].ID
+	})
+	out := make([]Job, 0, len(due))
+	for _, j := range due {
+		if q.holdScheduledLocked(j, now) || q.deferForWindowLocked(j, now) {
+			continue
+		}
+		if err := q.dispatchScheduledLocked(j); err != nil {
+			break
+		}
+		out = append(out, *q.clone(j))
+	}
+	q.mu.Unlock()
+	for _, j := range out {
+		q.publish(j)
+	}
+	return out
+}
+
+// holdScheduledLocked applies the checks EnqueuePlaced makes for immediate
+// jobs to a scheduled job that has come due. Under an emergency stop the job
+// stays scheduled until the stop is cleared; under a change freeze its
+// not_before moves to the end of the freeze. Forced jobs are never held.
+func (q *Queue) holdScheduledLocked(j *Job, now time.Time) bool {
+	if j.Force {
+		return false
+	}
+	if q.emergencyStop {
+		j.DeferredBy = "emergency stop"
+		return true
+	}
+	if scope, stopped := q.emergencyScopeLocked(j.Environment, j.Tenant, j.Labels); stopped {
+		j.DeferredBy = "emergency stop " + scope.ID
+		return true
+	}
+	if !q.freezeUntil.IsZero() && now.Before(q.freezeUntil) {
+		j.NotBefore = q.freezeUntil
+		j.DeferredBy = "change freeze"
+		return true
+	}
+	return false
+}
+
+func (q *Queue) dispatchScheduledLocked(j *Job) error {
+	if j.Partition == "" {
+		if err := q.pushPending(j.ID, j.Priority); err != nil {
+			return err
+		}
+	}
+	q.setStatusLocked(j, JobPending)
+	if j.Partition != "" {
+		q.queuePartitionedLocked(j)
+	}
+	return nil
+}
+
+// Upcoming returns scheduled jobs in dispatch order.
+func (q *Queue) Upcoming() []Job {
+	q.mu.RLock()
+	out := make([]Job, 0)
+	for _, j := range q.jobs {
+		if j.Status == JobScheduled {
+			out = append(out, *q.clone(j))
+		}
+	}
+	q.mu.RUnlock()
+	sort.Slice(out, func(a, b int) bool {
+		if !out[a].NotBefore.Equal(out[b].NotBefore) {
+			return out[a].NotBefore.Before(out[b].NotBefore)
+		}
+		return out[a].ID < out[b].ID
+	})
+	return out
+}
+
+// Reschedule moves a scheduled job to a new not_before time. A time that
+// has already passed dispatches the job immediately unless an emergency stop
+// or change freeze holds it.
+func (q *Queue) Reschedule(id string, notBefore time.Time) (Job, error) {
+	if notBefore.IsZero() {
+		return Job{}, errors.New("run_at is required")
+	}
+	q.mu.Lock()
+	j, ok := q.jobs[strings.TrimSpace(id)]
+	if !ok {
+		q.mu.Unlock()
+		return Job{}, errors.New("job not found")
+	}
+	if j.Status != JobScheduled {
+		q.mu.Unlock()
+		return Job{}, errors.New("only scheduled jobs can be rescheduled; job is " + string(j.Status))
+	}
+	j.NotBefore = notBefore.UTC()
+	j.DeferredBy = ""
+	if now := time.Now().UTC(); !j.NotBefore.After(now) && !q.holdScheduledLocked(j, now) {
+		if err := q.dispatchScheduledLocked(j); err != nil {
+			q.mu.Unlock()
+			return Job{}, err
+		}
+	}
+	cp := *q.clone(j)
+	q.mu.Unlock()
+	q.publish(cp)
+	return cp, nil
+}
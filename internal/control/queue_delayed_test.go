@@ -0,0 +1,129 @@
+package control
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueue_DelayedJobsDispatchAtRunAt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewQueue(16)
+	exec := &fakeExecutor{}
+	q.StartWorker(ctx, exec)
+
+	now := time.Now().UTC()
+	soon, err := q.EnqueuePlaced(JobPlacement{NotBefore: now.Add(300 * time.Millisecond)}, "soon.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	later, _ := q.EnqueuePlaced(JobPlacement{NotBefore: now.Add(time.Hour)}, "later.yaml", "", false, "")
+	dropped, _ := q.EnqueuePlaced(JobPlacement{NotBefore: now.Add(2 * time.Hour), Partition: "edge"}, "dropped.yaml", "", false, "")
+	if soon.Status != JobScheduled {
+		t.Fatalf("expected future job scheduled, got %s", soon.Status)
+	}
+	upcoming := q.Upcoming()
+	if len(upcoming) != 3 || upcoming[0].ID != soon.ID || upcoming[2].ID != dropped.ID {
+		t.Fatalf("expected upcoming jobs in run_at order, got %+v", upcoming)
+	}
+	if _, ok := q.ClaimPartitioned("w1", []string{"edge"}); ok {
+		t.Fatalf("expected scheduled partitioned job not claimable before run_at")
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		cur, _ := q.Get(soon.ID)
+		if cur.Status == JobSucceeded {
+			if cur.StartedAt.Before(soon.NotBefore) {
+				t.Fatalf("job started before run_at: %+v", cur)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for delayed job; current=%+v", cur)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := q.Cancel(dropped.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Reschedule(dropped.ID, now); err == nil {
+		t.Fatalf("expected canceled job not reschedulable")
+	}
+	moved, err := q.Reschedule(later.ID, now.Add(-time.Second))
+	if err != nil || moved.Status != JobPending {
+		t.Fatalf("expected past run_at to dispatch immediately, got %+v err=%v", moved, err)
+	}
+	if len(q.Upcoming()) != 0 {
+		t.Fatalf("expected no upcoming jobs left, got %+v", q.Upcoming())
+	}
+}
+
+func TestQueue_RestoreKeepsFutureJobsScheduled(t *testing.T) {
+	q := NewQueue(4)
+	runAt := time.Now().UTC().Add(time.Hour)
+	restored, err := q.Restore(Job{ID: "job-20260101T000000-7", ConfigPath: "c.yaml", NotBefore: runAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Status != JobScheduled {
+		t.Fatalf("expected restored future job scheduled, got %s", restored.Status)
+	}
+	if due := q.DispatchDue(runAt.Add(time.Second)); len(due) != 1 || due[0].Status != JobPending {
+		t.Fatalf("expected job dispatched once due, got %+v", due)
+	}
+}
+
+func TestQueue_DelayedDispatchHonorsFreezeAndEmergencyStop(t *testing.T) {
+	q := NewQueue(8)
+	now := time.Now().UTC()
+	frozen, err := q.EnqueuePlaced(JobPlacement{NotBefore: now.Add(time.Minute)}, "frozen.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forced, err := q.EnqueuePlaced(JobPlacement{NotBefore: now.Add(time.Minute)}, "forced.yaml", "", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freezeEnd := now.Add(time.Hour)
+	q.SetFreezeUntil(freezeEnd, "quarter close")
+
+	due := q.DispatchDue(now.Add(2 * time.Minute))
+	if len(due) != 1 || due[0].ID != forced.ID {
+		t.Fatalf("expected only the forced job dispatched during the freeze, got %+v", due)
+	}
+	held, _ := q.Get(frozen.ID)
+	if held.Status != JobScheduled || !held.NotBefore.Equal(freezeEnd) || held.DeferredBy != "change freeze" {
+		t.Fatalf("expected job held until the freeze ends, got %+v", held)
+	}
+	if moved, err := q.Reschedule(frozen.ID, now.Add(-time.Second)); err != nil || moved.Status != JobScheduled {
+		t.Fatalf("expected reschedule into the past to stay held by the freeze, got %+v err=%v", moved, err)
+	}
+
+	q.ClearFreeze()
+	q.SetEmergencyStop(true, "incident")
+	if due := q.DispatchDue(freezeEnd.Add(time.Second)); len(due) != 0 {
+		t.Fatalf("expected emergency stop to hold scheduled jobs, got %+v", due)
+	}
+	if held, _ := q.Get(frozen.ID); held.Status != JobScheduled || held.DeferredBy != "emergency stop" {
+		t.Fatalf("expected job held by the emergency stop, got %+v", held)
+	}
+
+	q.SetEmergencyStop(false, "")
+	prod, err := q.EnqueuePlaced(JobPlacement{Environment: "prod", NotBefore: now.Add(time.Minute)}, "prod.yaml", "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.SetScopedEmergencyStop(EmergencyStopScope{Environment: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	due = q.DispatchDue(freezeEnd.Add(2 * time.Second))
+	if len(due) != 1 || due[0].ID != frozen.ID {
+		t.Fatalf("expected only the unscoped job dispatched once stops lift, got %+v", due)
+	}
+	if held, _ := q.Get(prod.ID); held.Status != JobScheduled {
+		t.Fatalf("expected scoped emergency stop to hold prod job, got %+v", held)
+	}
+}
@@ -0,0 +1,181 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // dispatch windows resolve IANA zones on hosts without zoneinfo
+)
+
+// DispatchWindow limits when matching jobs may start. Jobs of the window's
+// priority class and/or carrying its selector labels are deferred, as
+// scheduled jobs, until the window next opens. Start and End are "HH:MM" in
+// Timezone; a window whose End is before Start spans midnight. With
+// TargetLocal, a job's own timezone overrides Timezone so the window follows
+// the target's local clock.
+type DispatchWindow struct {
+	Name        string    `json:"name"`
+	Priority    string    `json:"priority,omitempty"`
+	Selector    string    `json:"selector,omitempty"` // label selector, e.g. "kind=bulk"
+	Start       string    `json:"start"`
+	End         string    `json:"end"`
+	Timezone    string    `json:"timezone,omitempty"`
+	TargetLocal bool      `json:"target_local,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	selector LabelSelector
+}
+
+// SetDispatchWindow creates or replaces a window by name.
+func (q *Queue) SetDispatchWindow(in DispatchWindow) (DispatchWindow, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return DispatchWindow{}, errors.New("window name is required")
+	}
+	if p := strings.TrimSpace(in.Priority); p != "" {
+		in.Priority = normalizePriority(p)
+		if in.Priority != strings.ToLower(p) {
+			return DispatchWindow{}, errors.New("priority must be high, normal, or low")
+		}
+	}
+	selector, err := ParseLabelSelector(in.Selector)
+	if err != nil {
+		return DispatchWindow{}, err
+	}
+	in.selector = selector
+	in.Selector = selector.String()
+	if in.Priority == "" && len(selector) == 0 {
+		return DispatchWindow{}, errors.New("window requires a priority or selector")
+	}
+	start, err := parseWindowClock(in.Start)
+	if err != nil {
+		return DispatchWindow{}, errors.New("invalid start: " + err.Error())
+	}
+	end, err := parseWindowClock(in.End)
+	if err != nil {
+		return DispatchWindow{}, errors.New("invalid end: " + err.Error())
+	}
+	if start == end {
+		return DispatchWindow{}, errors.New("window start and end must differ")
+	}
+	in.Timezone = strings.TrimSpace(in.Timezone)
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(in.Timezone); err != nil {
+		return DispatchWindow{}, errors.New("unknown timezone: " + in.Timezone)
+	}
+	in.UpdatedAt = time.Now().UTC()
+
+	q.mu.Lock()
+	q.windows[in.Name] = in
+	q.mu.Unlock()
+	return in, nil
+}
+
+// DeleteDispatchWindow removes a window; jobs it deferred keep their
+// not_before time and are re-evaluated when due.
+func (q *Queue) DeleteDispatchWindow(name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	name = strings.TrimSpace(name)
+	if _, ok := q.windows[name]; !ok {
+		return false
+	}
+	delete(q.windows, name)
+	return true
+}
+
+func (q *Queue) DispatchWindows() []DispatchWindow {
+	q.mu.RLock()
+	out := make([]DispatchWindow, 0, len(q.windows))
+	for _, w := range q.windows {
+		out = append(out, w)
+	}
+	q.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// closedWindowLocked returns the latest next-opening among the windows that
+// apply to j and are closed at now. A zero time means j may start.
+func (q *Queue) closedWindowLocked(j *Job, now time.Time) (string, time.Time) {
+	var (
+		name string
+		open time.Time
+	)
+	for _, w := range q.windows {
+		if w.Priority != "" && w.Priority != j.Priority {
+			continue
+		}
+		if !w.selector.Matches(j.Labels) {
+			continue
+		}
+		next, closed := w.nextOpening(now, j.Timezone)
+		if closed && (next.After(open) || (next.Equal(open) && w.Name < name)) {
+			name, open = w.Name, next
+		}
+	}
+	return name, open
+}
+
+// deferForWindowLocked moves a pending job back to scheduled when one of its
+// windows is closed, and reports whether it did.
+func (q *Queue) deferForWindowLocked(j *Job, now time.Time) bool {
+	name, open := q.closedWindowLocked(j, now)
+	if open.IsZero() {
+		return false
+	}
+	j.NotBefore = open
+	j.DeferredBy = name
+	if j.Status != JobScheduled {
+		q.setStatusLocked(j, JobScheduled)
+	}
+	return true
+}
+
+func (w DispatchWindow) nextOpening(now time.Time, targetZone string) (time.Time, bool) {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	if zone := strings.TrimSpace(targetZone); w.TargetLocal && zone != "" {
+		if targetLoc, err := time.LoadLocation(zone); err == nil {
+			loc = targetLoc
+		}
+	}
+	start, _ := parseWindowClock(w.Start)
+	end, _ := parseWindowClock(w.End)
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	open := start <= minute && minute < end
+	if start > end {
+		open = minute >= start || minute < end
+	}
+	if open {
+		return time.Time{}, false
+	}
+	next := time.Date(local.Year(), local.Month(), local.Day(), start/60, start%60, 0, 0, loc)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, start/60, start%60, 0, 0, loc)
+	}
+	return next.UTC(), true
+}
+
+func parseWindowClock(raw string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(raw), ":")
+	if !ok {
+		return 0, errors.New("expected HH:MM")
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 23 {
+		return 0, errors.New("hour must be 00-23")
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 {
+		return 0, errors.New("minute must be 00-59")
+	}
+	return h*60 + m, nil
+}
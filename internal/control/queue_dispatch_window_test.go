@@ -0,0 +1,89 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDispatchWindowNextOpeningSpansMidnightInTargetZone(t *testing.T) {
+	w := DispatchWindow{Start: "22:00", End: "06:00", Timezone: "UTC", TargetLocal: true}
+	midday := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC) // 11:00 in New York
+	next, closed := w.nextOpening(midday, "America/New_York")
+	if !closed || !next.Equal(time.Date(2026, 3, 11, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected window to open at 22:00 New York time, got %s closed=%v", next, closed)
+	}
+	if _, closed := w.nextOpening(time.Date(2026, 3, 10, 4, 0, 0, 0, time.UTC), "America/New_York"); closed {
+		t.Fatalf("expected midnight New York time inside the overnight window")
+	}
+	lateUTC := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC) // 19:00 in New York
+	if _, closed := w.nextOpening(lateUTC, ""); closed {
+		t.Fatalf("expected window's own zone used without a target zone")
+	}
+	if _, closed := w.nextOpening(lateUTC, "America/New_York"); !closed {
+		t.Fatalf("expected target zone to keep the window closed at 19:00 local")
+	}
+}
+
+func TestQueue_DispatchWindowsDeferMatchingJobs(t *testing.T) {
+	q := NewQueue(16)
+	hour := time.Now().UTC().Hour()
+	clock := func(h int) string { return fmt.Sprintf("%02d:00", (h+24)%24) }
+	if _, err := q.SetDispatchWindow(DispatchWindow{Name: "bulk", Priority: "low", Start: clock(hour + 2), End: clock(hour + 3)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.SetDispatchWindow(DispatchWindow{Name: "reports", Selector: "kind=report", Start: clock(hour - 1), End: clock(hour + 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.SetDispatchWindow(DispatchWindow{Name: "bad", Start: "22:00", End: "06:00"}); err == nil {
+		t.Fatalf("expected window without priority or selector rejected")
+	}
+	if _, err := q.SetDispatchWindow(DispatchWindow{Name: "bad", Priority: "low", Start: "25:00", End: "06:00"}); err == nil {
+		t.Fatalf("expected invalid clock rejected")
+	}
+
+	low, _ := q.Enqueue("bulk.yaml", "", false, "low")
+	if low.Status != JobScheduled || low.DeferredBy != "bulk" || low.NotBefore.UTC().Hour() != (hour+2)%24 {
+		t.Fatalf("expected low-priority job deferred to the bulk window, got %+v", low)
+	}
+	interactive, _ := q.Enqueue("web.yaml", "", false, "high")
+	report, _ := q.EnqueuePlaced(JobPlacement{Labels: map[string]string{"kind": "report"}}, "report.yaml", "", false, "")
+	if interactive.Status != JobPending || report.Status != JobPending {
+		t.Fatalf("expected unmatched and open-window jobs pending, got %s and %s", interactive.Status, report.Status)
+	}
+	if due := q.DispatchDue(time.Now().UTC()); len(due) != 0 {
+		t.Fatalf("expected nothing due before the window opens, got %+v", due)
+	}
+
+	// A window added after enqueue still holds back jobs before they start.
+	if _, err := q.SetDispatchWindow(DispatchWindow{Name: "quiet", Selector: "kind=report", Start: clock(hour + 5), End: clock(hour + 6)}); err != nil {
+		t.Fatal(err)
+	}
+	partitioned, _ := q.EnqueuePlaced(JobPlacement{Partition: "edge", Labels: map[string]string{"kind": "batch"}}, "batch.yaml", "", false, "low")
+	if partitioned.Status != JobScheduled {
+		t.Fatalf("expected partitioned low job deferred, got %s", partitioned.Status)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exec := &fakeExecutor{}
+	q.StartWorker(ctx, exec)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		cur, _ := q.Get(report.ID)
+		if cur.Status == JobScheduled && cur.DeferredBy == "quiet" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected worker to defer report job to the quiet window, got %+v", cur)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !q.DeleteDispatchWindow("bulk") || q.DeleteDispatchWindow("bulk") {
+		t.Fatalf("expected bulk window deleted once")
+	}
+	if due := q.DispatchDue(low.NotBefore); len(due) != 2 {
+		t.Fatalf("expected deferred low-priority jobs dispatched once their window passed, got %+v", due)
+	}
+}
@@ -0,0 +1,164 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueueState is the on-disk form of unfinished queue work, written at
+// shutdown and restored on the next start.
+type QueueState struct {
+	SavedAt     time.Time             `json:"saved_at"`
+	Jobs        []Job                 `json:"jobs"`
+	Checkpoints []ExecutionCheckpoint `json:"checkpoints,omitempty"`
+}
+
+// BeginDrain stops the queue from accepting or starting jobs. Jobs already
+// running are left to finish.
+func (q *Queue) BeginDrain() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.draining = true
+}
+
+func (q *Queue) IsDraining() bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.draining
+}
+
+// WaitIdle blocks until no job is running or ctx is done.
+func (q *Queue) WaitIdle(ctx context.Context) (QueueControlStatus, error) {
+	for {
+		st := q.ControlStatus()
+		if st.Running == 0 {
+			return st, nil
+		}
+		select {
+		case <-ctx.Done():
+			return st, ctx.Err()
+		case <-time.After(25 * time.Millisecond):
+		}
+	}
+}
+
+// Done is closed once the worker started by StartWorker has exited.
+func (q *Queue) Done() <-chan struct{} {
+	return q.workerShutdown
+}
+
+// Unfinished returns scheduled, pending, and running jobs, oldest first.
+func (q *Queue) Unfinished() []Job {
+	q.mu.RLock()
+	out := make([]Job, 0)
+	for _, j := range q.jobs {
+		if j.Status == JobScheduled || j.Status == JobPending || j.Status == JobRunning {
+			out = append(out, *q.clone(j))
+		}
+	}
+	q.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Restore re-queues a job from a saved queue state under its original ID.
+// Jobs that were running when the previous process stopped start over;
+// jobs whose not_before time is still ahead stay scheduled.
+func (q *Queue) Restore(job Job) (Job, error) {
+	id := strings.TrimSpace(job.ID)
+	if id == "" {
+		return Job{}, errors.New("job id is required")
+	}
+	if strings.TrimSpace(job.ConfigPath) == "" {
+		return Job{}, errors.New("config_path is required")
+	}
+	job.ID = id
+	job.Priority = normalizePriority(job.Priority)
+	job.Status = JobPending
+	job.StartedAt = time.Time{}
+	job.EndedAt = time.Time{}
+	job.Worker = ""
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now().UTC()
+	}
+
+	q.mu.Lock()
+	if _, ok := q.jobs[id]; ok {
+		q.mu.Unlock()
+		return Job{}, errors.New("job already exists: " + id)
+	}
+	delayed := job.NotBefore.After(time.Now().UTC())
+	if job.Partition == "" && !delayed {
+		if err := q.pushPending(id, job.Priority); err != nil {
+			q.mu.Unlock()
+			return Job{}, err
+		}
+	}
+	j := job
+	j.Status = ""
+	q.jobs[id] = &j
+	if j.Partition != "" && !delayed {
+		q.queuePartitionedLocked(&j)
+	}
+	if delayed {
+		q.setStatusLocked(&j, JobScheduled)
+	} else {
+		q.setStatusLocked(&j, JobPending)
+	}
+	if job.IdempotencyKey != "" {
+		q.byIdempotency[job.IdempotencyKey] = id
+	}
+	if i := strings.LastIndex(id, "-"); i >= 0 {
+		if n, err := strconv.ParseInt(id[i+1:], 10, 64); err == nil && n > q.nextID {
+			q.nextID = n
+		}
+	}
+	cp := *q.clone(&j)
+	q.mu.Unlock()
+	q.publish(cp)
+	return cp, nil
+}
+
+func SaveQueueState(path string, st QueueState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadQueueState reads a saved queue state. A missing file is not an error
+// and reports ok=false.
+func LoadQueueState(path string) (QueueState, bool, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return QueueState{}, false, nil
+	}
+	if err != nil {
+		return QueueState{}, false, err
+	}
+	var st QueueState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return QueueState{}, false, err
+	}
+	return st, true, nil
+}
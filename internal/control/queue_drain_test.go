@@ -0,0 +1,86 @@
+package control
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type blockingExecutor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (b *blockingExecutor) ApplyPath(path string) error {
+	b.started <- path
+	<-b.release
+	return nil
+}
+
+func TestQueueDrainPersistAndRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exec := &blockingExecutor{started: make(chan string, 1), release: make(chan struct{})}
+	defer close(exec.release)
+
+	q := NewQueue(16)
+	q.StartWorker(ctx, exec)
+	running, err := q.Enqueue("running.yaml", "", false, "high")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	<-exec.started
+	pending, err := q.Enqueue("pending.yaml", "pending-key", false, "low")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	q.BeginDrain()
+	if _, err := q.Enqueue("late.yaml", "", true, ""); err == nil {
+		t.Fatalf("expected draining queue to reject new jobs")
+	}
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer waitCancel()
+	if st, err := q.WaitIdle(waitCtx); err == nil || st.Running != 1 {
+		t.Fatalf("expected drain wait to time out with a running job: st=%+v err=%v", st, err)
+	}
+	unfinished := q.Unfinished()
+	if len(unfinished) != 2 || unfinished[0].ID != running.ID || unfinished[0].Status != JobRunning || unfinished[1].ID != pending.ID {
+		t.Fatalf("unexpected unfinished jobs: %+v", unfinished)
+	}
+
+	path := filepath.Join(t.TempDir(), "queue-state.json")
+	if err := SaveQueueState(path, QueueState{SavedAt: time.Now().UTC(), Jobs: unfinished}); err != nil {
+		t.Fatalf("save queue state failed: %v", err)
+	}
+	st, ok, err := LoadQueueState(path)
+	if err != nil || !ok || len(st.Jobs) != 2 {
+		t.Fatalf("load queue state failed: ok=%v err=%v st=%+v", ok, err, st)
+	}
+	if _, ok, err := LoadQueueState(filepath.Join(t.TempDir(), "missing.json")); ok || err != nil {
+		t.Fatalf("expected missing queue state to be ok=false without error")
+	}
+
+	restoredQueue := NewQueue(16)
+	for _, job := range st.Jobs {
+		restored, err := restoredQueue.Restore(job)
+		if err != nil {
+			t.Fatalf("restore job failed: %v", err)
+		}
+		if restored.ID != job.ID || restored.Status != JobPending || !restored.StartedAt.IsZero() {
+			t.Fatalf("unexpected restored job: %+v", restored)
+		}
+	}
+	if _, err := restoredQueue.Restore(st.Jobs[0]); err == nil {
+		t.Fatalf("expected duplicate restore to fail")
+	}
+	again, _ := restoredQueue.Enqueue("other.yaml", "pending-key", false, "")
+	if again.ID != pending.ID {
+		t.Fatalf("expected idempotency key to survive restore")
+	}
+	next, _ := restoredQueue.Enqueue("next.yaml", "", false, "")
+	if next.ID == running.ID || next.ID == pending.ID {
+		t.Fatalf("expected restored IDs to advance the job counter: %s", next.ID)
+	}
+}
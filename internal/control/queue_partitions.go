@@ -0,0 +1,345 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobPlacement carries the tenant and scheduler partition a job is routed
+// by. An empty Partition leaves the job to the in-process worker.
+type JobPlacement struct {
+	Tenant           string            `json:"tenant,omitempty"`
+	Environment      string            `json:"environment,omitempty"`
+	Region           string            `json:"region,omitempty"`
+	Partition        string            `json:"partition,omitempty"`
+	ExecutionEnv     string            `json:"execution_env,omitempty"`
+	CloudCredentials []string          `json:"cloud_credentials,omitempty"`
+	ChangeRecordID   string            `json:"change_record_id,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	// ConcurrencyGroup serializes jobs that would otherwise race; empty
+	// derives it from the config path and host set, "none" opts out.
+	ConcurrencyGroup string `json:"concurrency_group,omitempty"`
+	// CancelSuperseded cancels pending jobs in the same group on enqueue.
+	CancelSuperseded bool `json:"cancel_superseded,omitempty"`
+	// NotBefore delays dispatch until the given time; the job is listed as
+	// scheduled until then.
+	NotBefore time.Time `json:"not_before,omitempty"`
+	// Timezone is the target's IANA zone, used by target-local dispatch
+	// windows.
+	Timezone string `json:"timezone,omitempty"`
+	// Topology restricts which partition workers may claim the job.
+	Topology *JobTopologyPlacement `json:"topology,omitempty"`
+}
+
+// PartitionBacklog is the queue-side view of one scheduler partition.
+type PartitionBacklog struct {
+	Partition     string    `json:"partition"`
+	Pending       int       `json:"pending"`
+	Running       int       `json:"running"`
+	OldestPending time.Time `json:"oldest_pending,omitempty"`
+}
+
+func normalizeJobPlacement(in JobPlacement) JobPlacement {
+	var creds []string
+	for _, ref := range in.CloudCredentials {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			creds = append(creds, ref)
+		}
+	}
+	return JobPlacement{
+		Tenant:           strings.TrimSpace(in.Tenant),
+		Environment:      strings.ToLower(strings.TrimSpace(in.Environment)),
+		Region:           strings.ToLower(strings.TrimSpace(in.Region)),
+		Partition:        strings.ToLower(strings.TrimSpace(in.Partition)),
+		ExecutionEnv:     strings.TrimSpace(in.ExecutionEnv),
+		CloudCredentials: creds,
+		ChangeRecordID:   strings.TrimSpace(in.ChangeRecordID),
+		Labels:           cloneLabels(in.Labels),
+		ConcurrencyGroup: strings.TrimSpace(in.ConcurrencyGroup),
+		CancelSuperseded: in.CancelSuperseded,
+		NotBefore:        in.NotBefore.UTC(),
+		Timezone:         strings.TrimSpace(in.Timezone),
+		Topology:         cloneJobTopologyPlacement(in.Topology),
+	}
+}
+
+// ClaimPartitioned hands the oldest pending job in any of the given
+// partitions to worker and marks it running. Nothing is claimed while the
+// queue is paused, draining, or under emergency stop, and jobs covered by a
+// scoped emergency stop are passed over.
+func (q *Queue) ClaimPartitioned(worker string, partitions []string) (Job, bool) {
+	return q.ClaimPartitionedFor(worker, partitions, nil)
+}
+
+// ClaimPartitionedFor is ClaimPartitioned limited to jobs allow accepts; a
+// nil allow accepts every job.
+func (q *Queue) ClaimPartitionedFor(worker string, partitions []string, allow func(Job) bool) (Job, bool) {
+	worker = strings.TrimSpace(worker)
+	q.mu.Lock()
+	if worker == "" || q.paused || q.draining || q.emergencyStop {
+		q.mu.Unlock()
+		return Job{}, false
+	}
+	var picked *Job
+	var deferred []*Job
+	now := time.Now().UTC()
+	for _, partition := range partitions {
+		for _, id := range q.partitioned[partition] {
+			j := q.jobs[id]
+			if name, open := q.closedWindowLocked(j, now); !open.IsZero() {
+				j.NotBefore, j.DeferredBy = open, name
+				deferred = append(deferred, j)
+				continue
+			}
+			if _, busy := q.groupBusyLocked(j.ConcurrencyGroup); busy {
+				continue
+			}
+			if _, stopped := q.emergencyScopeLocked(j.Environment, j.Tenant, j.Labels); stopped {
+				continue
+			}
+			if allow != nil && !allow(*q.clone(j)) {
+				continue
+			}
+			if picked == nil || j.CreatedAt.Before(picked.CreatedAt) {
+				picked = j
+			}
+			break
+		}
+	}
+	deferredJobs := make([]Job, 0, len(deferred))
+	for _, j := range deferred {
+		q.setStatusLocked(j, JobScheduled)
+		deferredJobs = append(deferredJobs, *q.clone(j))
+	}
+	if picked == nil {
+		q.mu.Unlock()
+		for _, j := range deferredJobs {
+			q.publish(j)
+		}
+		return Job{}, false
+	}
+	q.setStatusLocked(picked, JobRunning)
+	picked.Worker = worker
+	picked.StartedAt = time.Now().UTC()
+	q.running++
+	cp := *q.clone(picked)
+	q.mu.Unlock()
+	for _, j := range deferredJobs {
+		q.publish(j)
+	}
+	q.publish(cp)
+	return cp, true
+}
+
+// CompletePartitioned records the outcome a worker reports for a job it
+// claimed. An empty errMsg marks the job succeeded.
+func (q *Queue) CompletePartitioned(id, worker, errMsg string) (Job, error) {
+	q.mu.Lock()
+	j, ok := q.jobs[strings.TrimSpace(id)]
+	if !ok {
+		q.mu.Unlock()
+		return Job{}, errors.New("job not found")
+	}
+	if j.Worker != strings.TrimSpace(worker) {
+		q.mu.Unlock()
+		return Job{}, errors.New("job is not claimed by this worker")
+	}
+	if j.Status != JobRunning {
+		q.mu.Unlock()
+		return Job{}, errors.New("job is no longer running")
+	}
+	if errMsg = strings.TrimSpace(errMsg); errMsg != "" {
+		q.setStatusLocked(j, JobFailed)
+		j.Error = errMsg
+	} else {
+		q.setStatusLocked(j, JobSucceeded)
+	}
+	j.EndedAt = time.Now().UTC()
+	if q.running > 0 {
+		q.running--
+	}
+	cp := *q.clone(j)
+	q.mu.Unlock()
+	q.publish(cp)
+	return cp, nil
+}
+
+// ReleaseWorkerJobs returns running jobs claimed by worker to their
+// partitions, in age order, so another worker can claim them. With keep set,
+// jobs in partitions it accepts stay with the worker.
+func (q *Queue) ReleaseWorkerJobs(worker string, keep func(partition string) bool) []Job {
+	worker = strings.TrimSpace(worker)
+	q.mu.Lock()
+	released := make([]*Job, 0)
+	for _, j := range q.jobs {
+		if j.Worker != worker || j.Status != JobRunning || j.Partition == "" {
+			continue
+		}
+		if keep != nil && keep(j.Partition) {
+			continue
+		}
+		released = append(released, j)
+	}
+	out := make([]Job, 0, len(released))
+	for _, j := range released {
+		q.setStatusLocked(j, JobPending)
+		j.Worker = ""
+		j.StartedAt = time.Time{}
+		if q.running > 0 {
+			q.running--
+		}
+		q.queuePartitionedLocked(j)
+		out = append(out, *q.clone(j))
+	}
+	q.mu.Unlock()
+	for _, j := range out {
+		q.publish(j)
+	}
+	return out
+}
+
+// RepartitionPending re-routes pending partitioned jobs through route. Jobs
+// routed to no partition move to the in-process worker, unless its buffer
+// is full, in which case they stay where they are. It returns the number of
+// jobs moved.
+func (q *Queue) RepartitionPending(route func(Job) string) int {
+	q.mu.Lock()
+	pending := make([]*Job, 0)
+	for _, ids := range q.partitioned {
+		for _, id := range ids {
+			pending = append(pending, q.jobs[id])
+		}
+	}
+	sort.Slice(pending, func(a, b int) bool { return pending[a].CreatedAt.Before(pending[b].CreatedAt) })
+	moved := make([]Job, 0)
+	for _, j := range pending {
+		next := strings.ToLower(strings.TrimSpace(route(*q.clone(j))))
+		if next == j.Partition {
+			continue
+		}
+		if next == "" {
+			if err := q.pushPending(j.ID, j.Priority); err != nil {
+				continue
+			}
+			q.unqueuePartitionedLocked(j)
+			j.Partition = ""
+		} else {
+			q.unqueuePartitionedLocked(j)
+			j.Partition = next
+			q.queuePartitionedLocked(j)
+		}
+		moved = append(moved, *q.clone(j))
+	}
+	q.mu.Unlock()
+	for _, j := range moved {
+		q.publish(j)
+	}
+	return len(moved)
+}
+
+// PartitionBacklog reports pending and running jobs per partition.
+func (q *Queue) PartitionBacklog() map[string]PartitionBacklog {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	out := map[string]PartitionBacklog{}
+	for partition, ids := range q.partitioned {
+		item := out[partition]
+		item.Partition = partition
+		item.Pending = len(ids)
+		if len(ids) > 0 {
+			item.OldestPending = q.jobs[ids[0]].CreatedAt
+		}
+		out[partition] = item
+	}
+	for _, j := range q.jobs {
+		if j.Partition == "" || j.Status != JobRunning {
+			continue
+		}
+		item := out[j.Partition]
+		item.Partition = j.Partition
+		item.Running++
+		out[j.Partition] = item
+	}
+	return out
+}
+
+// queuePartitionedLocked inserts a pending job into its partition, keeping
+// each partition ordered oldest first.
+func (q *Queue) queuePartitionedLocked(j *Job) {
+	ids := q.partitioned[j.Partition]
+	at := len(ids)
+	for at > 0 && q.jobs[ids[at-1]].CreatedAt.After(j.CreatedAt) {
+		at--
+	}
+	ids = append(ids, "")
+	copy(ids[at+1:], ids[at:])
+	ids[at] = j.ID
+	q.partitioned[j.Partition] = ids
+}
+
+func (q *Queue) unqueuePartitionedLocked(j *Job) {
+	ids := q.partitioned[j.Partition]
+	for i, id := range ids {
+		if id != j.ID {
+			continue
+		}
+		ids = append(ids[:i:i], ids[i+1:]...)
+		break
+	}
+	if len(ids) == 0 {
+		delete(q.partitioned, j.Partition)
+	} else {
+		q.partitioned[j.Partition] = ids
+	}
+}
+
+// OverridePlacement records an operator override on a pending partitioned
+// job and moves it to partition.
+func (q *Queue) OverridePlacement(id, partition string, override JobPlacementOverride) (Job, error) {
+	partition = strings.ToLower(strings.TrimSpace(partition))
+	if partition == "" {
+		return Job{}, errors.New("partition is required")
+	}
+	q.mu.Lock()
+	j, ok := q.jobs[strings.TrimSpace(id)]
+	if !ok {
+		q.mu.Unlock()
+		return Job{}, errors.New("job not found")
+	}
+	if j.Status != JobPending || j.Partition == "" {
+		q.mu.Unlock()
+		return Job{}, errors.New("only pending partitioned jobs can be re-placed")
+	}
+	if j.Placement == nil {
+		j.Placement = &JobTopologyPlacement{Environment: j.Environment, DecidedAt: time.Now().UTC()}
+	}
+	override.Partition = partition
+	if override.At.IsZero() {
+		override.At = time.Now().UTC()
+	}
+	j.Placement.Override = &override
+	j.Placement.PreferredWorker = override.Worker
+	line := "operator override"
+	if override.By != "" {
+		line += " by " + override.By
+	}
+	line += " to partition " + partition
+	if override.Worker != "" {
+		line += " on worker " + override.Worker
+	}
+	if override.Reason != "" {
+		line += ": " + override.Reason
+	}
+	j.Placement.Explanation = append(j.Placement.Explanation, line)
+	if partition != j.Partition {
+		q.unqueuePartitionedLocked(j)
+		j.Partition = partition
+		q.queuePartitionedLocked(j)
+	}
+	cp := *q.clone(j)
+	q.mu.Unlock()
+	q.publish(cp)
+	return cp, nil
+}
@@ -0,0 +1,203 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultShard is the shard for jobs and events without a tenant.
+const DefaultShard = "default"
+
+// QueueShardPolicy bounds how many pending jobs each tenant shard may hold,
+// so one busy tenant cannot fill the queue for everyone else. A capacity of
+// zero leaves the shard bounded only by the queue itself. MaxOverflow is how
+// many jobs may wait beyond the queue's channel buffer before enqueues are
+// rejected.
+type QueueShardPolicy struct {
+	DefaultCapacity int            `json:"default_capacity"`
+	Capacities      map[string]int `json:"capacities,omitempty"`
+	MaxOverflow     int            `json:"max_overflow"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type QueueShardStats struct {
+	Shard     string `json:"shard"`
+	Capacity  int    `json:"capacity"`
+	Pending   int    `json:"pending"`
+	Running   int    `json:"running"`
+	Enqueued  int64  `json:"enqueued"`
+	Rejected  int64  `json:"rejected"`
+	Succeeded int64  `json:"succeeded"`
+	Failed    int64  `json:"failed"`
+	Canceled  int64  `json:"canceled"`
+}
+
+type queueShard struct {
+	pending   int
+	running   int
+	enqueued  int64
+	rejected  int64
+	succeeded int64
+	failed    int64
+	canceled  int64
+}
+
+// QueueShardKey maps a tenant to its shard name.
+func QueueShardKey(tenant string) string {
+	tenant = strings.TrimSpace(tenant)
+	if tenant == "" {
+		return DefaultShard
+	}
+	return tenant
+}
+
+func (q *Queue) SetShardPolicy(in QueueShardPolicy) (QueueShardPolicy, error) {
+	if in.DefaultCapacity < 0 || in.MaxOverflow < 0 {
+		return QueueShardPolicy{}, errors.New("default_capacity and max_overflow must be non-negative")
+	}
+	capacities := map[string]int{}
+	for shard, capacity := range in.Capacities {
+		if capacity < 0 {
+			return QueueShardPolicy{}, errors.New("capacity for shard " + shard + " must be non-negative")
+		}
+		capacities[QueueShardKey(shard)] = capacity
+	}
+	in.Capacities = capacities
+	in.UpdatedAt = time.Now().UTC()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shardPolicy = in
+	q.refillLocked()
+	return cloneQueueShardPolicy(in), nil
+}
+
+func (q *Queue) ShardPolicy() QueueShardPolicy {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return cloneQueueShardPolicy(q.shardPolicy)
+}
+
+// ShardStats returns per-shard queue metrics sorted by shard name.
+func (q *Queue) ShardStats() []QueueShardStats {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	out := make([]QueueShardStats, 0, len(q.shards))
+	for name, sh := range q.shards {
+		out = append(out, QueueShardStats{
+			Shard:     name,
+			Capacity:  q.shardCapacityLocked(name),
+			Pending:   sh.pending,
+			Running:   sh.running,
+			Enqueued:  sh.enqueued,
+			Rejected:  sh.rejected,
+			Succeeded: sh.succeeded,
+			Failed:    sh.failed,
+			Canceled:  sh.canceled,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Shard < out[j].Shard })
+	return out
+}
+
+func (q *Queue) shardLocked(tenant string) *queueShard {
+	name := QueueShardKey(tenant)
+	sh, ok := q.shards[name]
+	if !ok {
+		sh = &queueShard{}
+		q.shards[name] = sh
+	}
+	return sh
+}
+
+func (q *Queue) shardCapacityLocked(name string) int {
+	if capacity, ok := q.shardPolicy.Capacities[name]; ok {
+		return capacity
+	}
+	return q.shardPolicy.DefaultCapacity
+}
+
+func (q *Queue) admitShardLocked(tenant string) error {
+	name := QueueShardKey(tenant)
+	capacity := q.shardCapacityLocked(name)
+	sh := q.shardLocked(tenant)
+	if capacity > 0 && sh.pending >= capacity {
+		sh.rejected++
+		return errors.New("pending queue full for shard " + name + " (capacity " + strconv.Itoa(capacity) + ")")
+	}
+	return nil
+}
+
+// setStatusLocked moves a job to status and keeps its shard's pending,
+// running, and outcome counters in step.
+func (q *Queue) setStatusLocked(j *Job, status JobStatus) {
+	q.trackGroupLocked(j, status)
+	sh := q.shardLocked(j.Tenant)
+	switch j.Status {
+	case JobPending:
+		sh.pending--
+		if j.Partition != "" && status != JobPending {
+			q.unqueuePartitionedLocked(j)
+		}
+	case JobRunning:
+		sh.running--
+	}
+	switch status {
+	case JobPending:
+		sh.pending++
+	case JobRunning:
+		sh.running++
+	case JobSucceeded:
+		sh.succeeded++
+	case JobFailed:
+		sh.failed++
+	case JobCanceled:
+		sh.canceled++
+	}
+	j.Status = status
+}
+
+func (q *Queue) overflowLenLocked() int {
+	n := 0
+	for _, ids := range q.overflow {
+		n += len(ids)
+	}
+	return n
+}
+
+// refillLocked moves overflowed job IDs into their priority channels while
+// there is room, preserving FIFO order within each class.
+func (q *Queue) refillLocked() {
+	for class, ids := range q.overflow {
+		var ch chan string
+		switch class {
+		case "high":
+			ch = q.pendingHigh
+		case "low":
+			ch = q.pendingLow
+		default:
+			ch = q.pendingNormal
+		}
+		moved := 0
+		for moved < len(ids) && len(ch) < cap(ch) {
+			ch <- ids[moved]
+			moved++
+		}
+		if moved == len(ids) {
+			delete(q.overflow, class)
+		} else if moved > 0 {
+			q.overflow[class] = append([]string{}, ids[moved:]...)
+		}
+	}
+}
+
+func cloneQueueShardPolicy(in QueueShardPolicy) QueueShardPolicy {
+	out := in
+	out.Capacities = make(map[string]int, len(in.Capacities))
+	for k, v := range in.Capacities {
+		out.Capacities[k] = v
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueueShardCapacityIsolatesTenants(t *testing.T) {
+	q := NewQueue(8)
+	if _, err := q.SetShardPolicy(QueueShardPolicy{DefaultCapacity: 2, Capacities: map[string]int{"big": 3}}); err != nil {
+		t.Fatalf("set shard policy failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := q.EnqueueTenant("acme", "a.yaml", "", false, "normal"); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+	if _, err := q.EnqueueTenant("acme", "a.yaml", "", false, "normal"); err == nil || !strings.Contains(err.Error(), "shard acme") {
+		t.Fatalf("expected acme shard to be full, got %v", err)
+	}
+	if _, err := q.EnqueueTenant("globex", "g.yaml", "", false, "normal"); err != nil {
+		t.Fatalf("expected other tenant to be admitted: %v", err)
+	}
+	job, _ := q.EnqueueTenant("big", "b.yaml", "", false, "normal")
+	if err := q.Cancel(job.ID); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := q.EnqueueTenant("big", "b.yaml", "", false, "normal"); err != nil {
+			t.Fatalf("expected canceled job to free big shard capacity: %v", err)
+		}
+	}
+
+	stats := map[string]QueueShardStats{}
+	for _, st := range q.ShardStats() {
+		stats[st.Shard] = st
+	}
+	if st := stats["acme"]; st.Pending != 2 || st.Rejected != 1 || st.Capacity != 2 {
+		t.Fatalf("unexpected acme shard stats: %+v", st)
+	}
+	if st := stats["big"]; st.Pending != 3 || st.Canceled != 1 || st.Capacity != 3 {
+		t.Fatalf("unexpected big shard stats: %+v", st)
+	}
+}
+
+func TestQueueOverflowBeyondBuffer(t *testing.T) {
+	q := NewQueue(2)
+	for i := 0; i < 2; i++ {
+		if _, err := q.Enqueue("a.yaml", "", false, "normal"); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+	if _, err := q.Enqueue("a.yaml", "", false, "normal"); err == nil {
+		t.Fatalf("expected full buffer to reject without overflow")
+	}
+	if _, err := q.SetShardPolicy(QueueShardPolicy{MaxOverflow: 3}); err != nil {
+		t.Fatalf("set shard policy failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := q.Enqueue("a.yaml", "", false, "normal"); err != nil {
+			t.Fatalf("expected overflow to admit job %d: %v", i, err)
+		}
+	}
+	if _, err := q.Enqueue("a.yaml", "", false, "normal"); err == nil {
+		t.Fatalf("expected overflow limit to reject")
+	}
+	if st := q.ControlStatus(); st.Pending != 5 {
+		t.Fatalf("expected overflowed jobs to count as pending, got %+v", st)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.StartWorker(ctx, &fakeExecutor{})
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		done := 0
+		for _, j := range q.List() {
+			if j.Status == JobSucceeded {
+				done++
+			}
+		}
+		if done == 5 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected all overflowed jobs to run, got %+v", q.List())
+}
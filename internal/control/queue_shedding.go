@@ -0,0 +1,197 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// QueueSheddingPolicy says how the queue sheds load while its backlog is
+// saturated. Shedding is switched on and off by the backlog SLO monitor;
+// the policy only decides what happens while it is on. New enqueues of a
+// RejectPriorities class are refused, unpartitioned enqueues of a
+// DivertPriorities class go to DivertPartition instead of the in-process
+// worker, and with CancelStaleDuplicates, pending jobs that have waited
+// StaleAfterSeconds behind a newer pending job for the same config,
+// tenant, and environment are canceled.
+type QueueSheddingPolicy struct {
+	Enabled               bool      `json:"enabled"`
+	RejectPriorities      []string  `json:"reject_priorities,omitempty"`
+	CancelStaleDuplicates bool      `json:"cancel_stale_duplicates,omitempty"`
+	StaleAfterSeconds     int       `json:"stale_after_seconds,omitempty"`
+	DivertPartition       string    `json:"divert_partition,omitempty"`
+	DivertPriorities      []string  `json:"divert_priorities,omitempty"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+type QueueSheddingStatus struct {
+	Active   bool                `json:"active"`
+	Since    time.Time           `json:"since,omitempty"`
+	Policy   QueueSheddingPolicy `json:"policy"`
+	Rejected int64               `json:"rejected"`
+	Canceled int64               `json:"canceled"`
+	Diverted int64               `json:"diverted"`
+}
+
+type queueShedding struct {
+	policy   QueueSheddingPolicy
+	active   bool
+	since    time.Time
+	rejected int64
+	canceled int64
+	diverted int64
+}
+
+func (q *Queue) SetSheddingPolicy(in QueueSheddingPolicy) (QueueSheddingPolicy, error) {
+	var err error
+	if in.RejectPriorities, err = normalizeShedPriorities(in.RejectPriorities); err != nil {
+		return QueueSheddingPolicy{}, err
+	}
+	if in.DivertPriorities, err = normalizeShedPriorities(in.DivertPriorities); err != nil {
+		return QueueSheddingPolicy{}, err
+	}
+	in.DivertPartition = strings.ToLower(strings.TrimSpace(in.DivertPartition))
+	if in.DivertPartition == "" && len(in.DivertPriorities) > 0 {
+		return QueueSheddingPolicy{}, errors.New("divert_priorities requires divert_partition")
+	}
+	if in.DivertPartition != "" && len(in.DivertPriorities) == 0 {
+		in.DivertPriorities = []string{"normal"}
+	}
+	for _, p := range in.DivertPriorities {
+		if containsString(in.RejectPriorities, p) {
+			return QueueSheddingPolicy{}, errors.New("priority " + p + " cannot be both rejected and diverted")
+		}
+	}
+	if in.StaleAfterSeconds < 0 {
+		return QueueSheddingPolicy{}, errors.New("stale_after_seconds must be non-negative")
+	}
+	if in.CancelStaleDuplicates && in.StaleAfterSeconds == 0 {
+		in.StaleAfterSeconds = 300
+	}
+	if in.Enabled && len(in.RejectPriorities) == 0 && in.DivertPartition == "" && !in.CancelStaleDuplicates {
+		return QueueSheddingPolicy{}, errors.New("policy must reject, divert, or cancel stale duplicates")
+	}
+	in.UpdatedAt = time.Now().UTC()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shedding.policy = in
+	if !in.Enabled {
+		q.shedding.active = false
+		q.shedding.since = time.Time{}
+	}
+	return cloneQueueSheddingPolicy(in), nil
+}
+
+// SetShedding starts or stops shedding. Shedding cannot start while the
+// policy is disabled.
+func (q *Queue) SetShedding(active bool) QueueSheddingStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	active = active && q.shedding.policy.Enabled
+	if active && !q.shedding.active {
+		q.shedding.since = time.Now().UTC()
+	}
+	if !active {
+		q.shedding.since = time.Time{}
+	}
+	q.shedding.active = active
+	return q.sheddingStatusLocked()
+}
+
+func (q *Queue) SheddingStatus() QueueSheddingStatus {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.sheddingStatusLocked()
+}
+
+func (q *Queue) sheddingStatusLocked() QueueSheddingStatus {
+	return QueueSheddingStatus{
+		Active:   q.shedding.active,
+		Since:    q.shedding.since,
+		Policy:   cloneQueueSheddingPolicy(q.shedding.policy),
+		Rejected: q.shedding.rejected,
+		Canceled: q.shedding.canceled,
+		Diverted: q.shedding.diverted,
+	}
+}
+
+// shedLocked applies the shedding policy to a new enqueue. It refuses the
+// job or moves it to the divert partition.
+func (q *Queue) shedLocked(placement *JobPlacement, priority string) error {
+	policy := q.shedding.policy
+	if !q.shedding.active {
+		return nil
+	}
+	if containsString(policy.RejectPriorities, priority) {
+		q.shedding.rejected++
+		return errors.New("queue backlog saturated; shedding " + priority + " priority enqueues")
+	}
+	if placement.Partition == "" && containsString(policy.DivertPriorities, priority) {
+		placement.Partition = policy.DivertPartition
+		q.shedding.diverted++
+	}
+	return nil
+}
+
+// ShedStaleDuplicates cancels pending jobs that a newer pending job for the
+// same config, tenant, and environment makes redundant, once they have
+// waited longer than the policy allows. It does nothing unless shedding is
+// active and the policy cancels stale duplicates.
+func (q *Queue) ShedStaleDuplicates(now time.Time) []Job {
+	q.mu.Lock()
+	policy := q.shedding.policy
+	if !q.shedding.active || !policy.CancelStaleDuplicates {
+		q.mu.Unlock()
+		return nil
+	}
+	newest := map[string]*Job{}
+	for _, j := range q.jobs {
+		if j.Status != JobPending {
+			continue
+		}
+		key := j.ConfigPath + "\x00" + j.Tenant + "\x00" + j.Environment
+		if cur, ok := newest[key]; !ok || j.CreatedAt.After(cur.CreatedAt) {
+			newest[key] = j
+		}
+	}
+	cutoff := now.Add(-time.Duration(policy.StaleAfterSeconds) * time.Second)
+	var out []Job
+	for _, j := range q.jobs {
+		if j.Status != JobPending || !j.CreatedAt.Before(cutoff) {
+			continue
+		}
+		by := newest[j.ConfigPath+"\x00"+j.Tenant+"\x00"+j.Environment]
+		if by == nil || by.ID == j.ID {
+			continue
+		}
+		q.setStatusLocked(j, JobCanceled)
+		j.Error = "shed as stale duplicate of " + by.ID
+		j.EndedAt = now.UTC()
+		q.shedding.canceled++
+		out = append(out, *q.clone(j))
+	}
+	q.mu.Unlock()
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.Before(out[b].CreatedAt) })
+	for _, j := range out {
+		q.publish(j)
+	}
+	return out
+}
+
+func normalizeShedPriorities(in []string) ([]string, error) {
+	out := normalizeStringList(in)
+	for _, p := range out {
+		if normalizePriority(p) != p {
+			return nil, errors.New("priority must be high, normal, or low")
+		}
+	}
+	return out, nil
+}
+
+func cloneQueueSheddingPolicy(in QueueSheddingPolicy) QueueSheddingPolicy {
+	out := in
+	out.RejectPriorities = append([]string(nil), in.RejectPriorities...)
+	out.DivertPriorities = append([]string(nil), in.DivertPriorities...)
+	return out
+}
@@ -0,0 +1,98 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueSheddingRejectsAndDiverts(t *testing.T) {
+	q := NewQueue(16)
+	if _, err := q.SetSheddingPolicy(QueueSheddingPolicy{Enabled: true}); err == nil {
+		t.Fatalf("expected policy without any action to be rejected")
+	}
+	if _, err := q.SetSheddingPolicy(QueueSheddingPolicy{Enabled: true, RejectPriorities: []string{"urgent"}}); err == nil {
+		t.Fatalf("expected unknown priority to be rejected")
+	}
+	if _, err := q.SetSheddingPolicy(QueueSheddingPolicy{Enabled: true, DivertPriorities: []string{"normal"}}); err == nil {
+		t.Fatalf("expected divert_priorities without a partition to be rejected")
+	}
+	if _, err := q.SetSheddingPolicy(QueueSheddingPolicy{Enabled: true, RejectPriorities: []string{"low"}, DivertPartition: "overflow", DivertPriorities: []string{"low"}}); err == nil {
+		t.Fatalf("expected a priority both rejected and diverted to be rejected")
+	}
+	policy, err := q.SetSheddingPolicy(QueueSheddingPolicy{Enabled: true, RejectPriorities: []string{"Low"}, DivertPartition: "Overflow"})
+	if err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if policy.RejectPriorities[0] != "low" || policy.DivertPartition != "overflow" || len(policy.DivertPriorities) != 1 || policy.DivertPriorities[0] != "normal" {
+		t.Fatalf("expected normalized policy, got %+v", policy)
+	}
+
+	if _, err := q.Enqueue("low.yaml", "", false, "low"); err != nil {
+		t.Fatalf("expected low enqueue to pass while not shedding: %v", err)
+	}
+	if st := q.SetShedding(true); !st.Active || st.Since.IsZero() {
+		t.Fatalf("expected shedding to start, got %+v", st)
+	}
+	if _, err := q.Enqueue("low.yaml", "", false, "low"); err == nil {
+		t.Fatalf("expected low enqueue to be shed")
+	}
+	if _, err := q.Enqueue("low.yaml", "", true, "low"); err != nil {
+		t.Fatalf("expected forced low enqueue to bypass shedding: %v", err)
+	}
+	diverted, err := q.Enqueue("normal.yaml", "", false, "normal")
+	if err != nil || diverted.Partition != "overflow" {
+		t.Fatalf("expected normal enqueue to be diverted, got %+v err=%v", diverted, err)
+	}
+	high, err := q.Enqueue("high.yaml", "", false, "high")
+	if err != nil || high.Partition != "" {
+		t.Fatalf("expected high enqueue to stay in process, got %+v err=%v", high, err)
+	}
+	if st := q.SheddingStatus(); st.Rejected != 1 || st.Diverted != 1 {
+		t.Fatalf("expected one rejected and one diverted job, got %+v", st)
+	}
+
+	if st := q.SetShedding(false); st.Active {
+		t.Fatalf("expected shedding to stop")
+	}
+	if _, err := q.Enqueue("low.yaml", "", false, "low"); err != nil {
+		t.Fatalf("expected low enqueue to pass after recovery: %v", err)
+	}
+	if _, err := q.SetSheddingPolicy(QueueSheddingPolicy{RejectPriorities: []string{"low"}}); err != nil {
+		t.Fatalf("disable policy failed: %v", err)
+	}
+	if st := q.SetShedding(true); st.Active {
+		t.Fatalf("expected a disabled policy never to shed")
+	}
+}
+
+func TestQueueShedStaleDuplicates(t *testing.T) {
+	q := NewQueue(16)
+	q.Pause()
+	if _, err := q.SetSheddingPolicy(QueueSheddingPolicy{Enabled: true, CancelStaleDuplicates: true, StaleAfterSeconds: 60}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	older, _ := q.EnqueuePlaced(JobPlacement{Environment: "prod"}, "app.yaml", "", false, "")
+	newer, _ := q.EnqueuePlaced(JobPlacement{Environment: "prod"}, "app.yaml", "", false, "")
+	other, _ := q.EnqueuePlaced(JobPlacement{Environment: "staging"}, "app.yaml", "", false, "")
+
+	later := time.Now().Add(2 * time.Minute)
+	if got := q.ShedStaleDuplicates(later); len(got) != 0 {
+		t.Fatalf("expected nothing shed while shedding is off, got %+v", got)
+	}
+	q.SetShedding(true)
+	if got := q.ShedStaleDuplicates(time.Now()); len(got) != 0 {
+		t.Fatalf("expected fresh duplicates to be kept, got %+v", got)
+	}
+	got := q.ShedStaleDuplicates(later)
+	if len(got) != 1 || got[0].ID != older.ID || got[0].Status != JobCanceled || got[0].Error != "shed as stale duplicate of "+newer.ID {
+		t.Fatalf("expected only the older prod job to be shed, got %+v", got)
+	}
+	for _, id := range []string{newer.ID, other.ID} {
+		if j, _ := q.Get(id); j.Status != JobPending {
+			t.Fatalf("expected %s to stay pending, got %s", id, j.Status)
+		}
+	}
+	if st := q.SheddingStatus(); st.Canceled != 1 {
+		t.Fatalf("expected one canceled job, got %+v", st)
+	}
+}
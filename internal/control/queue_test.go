@@ -2,6 +2,7 @@ package control
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -92,6 +93,78 @@ func TestQueue_EmergencyStopBlocksNewJobs(t *testing.T) {
 	}
 }
 
+func TestQueue_ScopedEmergencyStopBlocksOnlyMatchingJobs(t *testing.T) {
+	q := NewQueue(8)
+	if _, err := q.SetScopedEmergencyStop(EmergencyStopScope{Reason: "incident"}); err == nil {
+		t.Fatalf("expected scope without environment, tenant, or selector to be rejected")
+	}
+	if _, err := q.SetScopedEmergencyStop(EmergencyStopScope{Selector: "=payments"}); err == nil {
+		t.Fatalf("expected invalid selector to be rejected")
+	}
+	prod, err := q.SetScopedEmergencyStop(EmergencyStopScope{Environment: "Prod", Selector: "team=payments", Reason: "db incident", SetBy: "oncall"})
+	if err != nil {
+		t.Fatalf("set scoped stop failed: %v", err)
+	}
+	again, err := q.SetScopedEmergencyStop(EmergencyStopScope{Environment: "prod", Selector: "team=payments", Reason: "still down", SetBy: "lead"})
+	if err != nil || again.ID != prod.ID || again.SetBy != "lead" || !again.Since.Equal(prod.Since) {
+		t.Fatalf("expected same scope to be refreshed in place, got %+v err=%v", again, err)
+	}
+	if _, err := q.SetScopedEmergencyStop(EmergencyStopScope{Tenant: "acme"}); err != nil {
+		t.Fatalf("set tenant stop failed: %v", err)
+	}
+	if st := q.EmergencyStatus(); st.Active || len(st.Scopes) != 2 {
+		t.Fatalf("expected two scopes without a global stop, got %+v", st)
+	}
+
+	payments := JobPlacement{Environment: "prod", Labels: map[string]string{"team": "payments"}}
+	if _, err := q.EnqueuePlaced(payments, "pay.yaml", "", false, ""); err == nil || !strings.Contains(err.Error(), prod.ID) {
+		t.Fatalf("expected prod payments job to be halted by %s, got %v", prod.ID, err)
+	}
+	if _, err := q.EnqueuePlaced(JobPlacement{Environment: "staging", Labels: map[string]string{"team": "payments"}}, "pay.yaml", "", false, ""); err != nil {
+		t.Fatalf("expected staging job to run: %v", err)
+	}
+	if _, err := q.EnqueuePlaced(JobPlacement{Environment: "prod", Labels: map[string]string{"team": "search"}}, "search.yaml", "", false, ""); err != nil {
+		t.Fatalf("expected other team to run: %v", err)
+	}
+	if _, err := q.EnqueuePlaced(JobPlacement{Tenant: "acme"}, "acme.yaml", "", false, ""); err == nil {
+		t.Fatalf("expected tenant stop to halt acme job")
+	}
+	if _, err := q.EnqueuePlaced(payments, "pay.yaml", "", true, ""); err != nil {
+		t.Fatalf("expected forced enqueue to bypass scoped stop: %v", err)
+	}
+
+	if _, err := q.ClearScopedEmergencyStop(prod.ID); err != nil {
+		t.Fatalf("clear scoped stop failed: %v", err)
+	}
+	if _, err := q.ClearScopedEmergencyStop(prod.ID); err == nil {
+		t.Fatalf("expected clearing twice to fail")
+	}
+	if _, err := q.EnqueuePlaced(payments, "pay.yaml", "", false, ""); err != nil {
+		t.Fatalf("expected payments job to run once the scope is cleared: %v", err)
+	}
+}
+
+func TestQueue_ScopedEmergencyStopHoldsPartitionedJobs(t *testing.T) {
+	q := NewQueue(8)
+	job, err := q.EnqueuePlaced(JobPlacement{Environment: "prod", Partition: "edge"}, "edge.yaml", "", false, "")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	scope, err := q.SetScopedEmergencyStop(EmergencyStopScope{Environment: "prod"})
+	if err != nil {
+		t.Fatalf("set scoped stop failed: %v", err)
+	}
+	if _, ok := q.ClaimPartitioned("w1", []string{"edge"}); ok {
+		t.Fatalf("expected pending prod job to be held by the scoped stop")
+	}
+	if _, err := q.ClearScopedEmergencyStop(scope.ID); err != nil {
+		t.Fatalf("clear scoped stop failed: %v", err)
+	}
+	if claimed, ok := q.ClaimPartitioned("w1", []string{"edge"}); !ok || claimed.ID != job.ID {
+		t.Fatalf("expected job to be claimed once the stop is lifted, got %+v %t", claimed, ok)
+	}
+}
+
 func TestQueue_PauseResumeAndControlStatus(t *testing.T) {
 	q := NewQueue(8)
 	st := q.Pause()
@@ -12,6 +12,9 @@ type RBACPermission struct {
 	Resource string `json:"resource"`
 	Action   string `json:"action"`
 	Scope    string `json:"scope,omitempty"`
+	// LabelSelector limits the permission to entities whose labels match,
+	// e.g. "team=payments".
+	LabelSelector string `json:"label_selector,omitempty"`
 }
 
 type RBACRole struct {
@@ -34,6 +37,7 @@ type RBACBinding struct {
 	Subject   string    `json:"subject"`
 	RoleID    string    `json:"role_id"`
 	Scope     string    `json:"scope"`
+	Source    string    `json:"source,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -45,10 +49,11 @@ type RBACBindingInput struct {
 }
 
 type RBACAccessCheckInput struct {
-	Subject  string `json:"subject"`
-	Resource string `json:"resource"`
-	Action   string `json:"action"`
-	Scope    string `json:"scope,omitempty"`
+	Subject  string            `json:"subject"`
+	Resource string            `json:"resource"`
+	Action   string            `json:"action"`
+	Scope    string            `json:"scope,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
 }
 
 type RBACAccessCheckResult struct {
@@ -159,6 +164,70 @@ func (s *RBACStore) ListBindings() []RBACBinding {
 	return out
 }
 
+// SyncManagedBindings makes the bindings owned by source match desired,
+// creating missing bindings and deleting stale ones. Bindings without that
+// source, including those created through the API, are left alone; desired
+// bindings whose role no longer exists are skipped.
+func (s *RBACStore) SyncManagedBindings(source string, desired []RBACBindingInput) (added, removed []RBACBinding) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, nil
+	}
+	want := map[string]RBACBindingInput{}
+	for _, in := range desired {
+		in.Subject = strings.TrimSpace(in.Subject)
+		in.RoleID = strings.TrimSpace(in.RoleID)
+		in.Scope = strings.TrimSpace(in.Scope)
+		if in.Scope == "" {
+			in.Scope = "*"
+		}
+		if in.Subject == "" || in.RoleID == "" {
+			continue
+		}
+		want[in.Subject+"|"+in.RoleID+"|"+in.Scope] = in
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, binding := range s.bindings {
+		if binding.Source != source {
+			continue
+		}
+		key := binding.Subject + "|" + binding.RoleID + "|" + binding.Scope
+		if _, ok := want[key]; ok {
+			delete(want, key)
+			continue
+		}
+		removed = append(removed, cloneRBACBinding(*binding))
+		delete(s.bindings, id)
+	}
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		in := want[key]
+		if _, ok := s.roles[in.RoleID]; !ok {
+			continue
+		}
+		s.nextBindID++
+		item := RBACBinding{
+			ID:        "rbac-binding-" + itoa(s.nextBindID),
+			Subject:   in.Subject,
+			RoleID:    in.RoleID,
+			Scope:     in.Scope,
+			Source:    source,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		s.bindings[item.ID] = &item
+		added = append(added, cloneRBACBinding(item))
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].ID < removed[j].ID })
+	return added, removed
+}
+
 func (s *RBACStore) CheckAccess(in RBACAccessCheckInput) RBACAccessCheckResult {
 	subject := strings.TrimSpace(in.Subject)
 	resource := strings.TrimSpace(in.Resource)
@@ -191,6 +260,12 @@ func (s *RBACStore) CheckAccess(in RBACAccessCheckInput) RBACAccessCheckResult {
 			if perScope != "" && !rbacScopeMatches(perScope, scope) {
 				continue
 			}
+			if permission.LabelSelector != "" {
+				selector, err := ParseLabelSelector(permission.LabelSelector)
+				if err != nil || !selector.Matches(in.Labels) {
+					continue
+				}
+			}
 			return RBACAccessCheckResult{
 				Allowed:       true,
 				MatchedRoleID: role.ID,
@@ -201,6 +276,20 @@ func (s *RBACStore) CheckAccess(in RBACAccessCheckInput) RBACAccessCheckResult {
 	return RBACAccessCheckResult{Allowed: false, Reason: "no matching role binding permission"}
 }
 
+// HasBindings reports whether subject is bound to any role, i.e. whether
+// RBAC governs what the subject may see.
+func (s *RBACStore) HasBindings(subject string) bool {
+	subject = strings.TrimSpace(subject)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, binding := range s.bindings {
+		if binding.Subject == subject {
+			return true
+		}
+	}
+	return false
+}
+
 func normalizeRBACPermissions(in []RBACPermission) ([]RBACPermission, error) {
 	if len(in) == 0 {
 		return nil, errors.New("at least one permission is required")
@@ -212,10 +301,15 @@ func normalizeRBACPermissions(in []RBACPermission) ([]RBACPermission, error) {
 		if resource == "" || action == "" {
 			return nil, errors.New("permission resource and action are required")
 		}
+		selector, err := ParseLabelSelector(item.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
 		out = append(out, RBACPermission{
-			Resource: resource,
-			Action:   action,
-			Scope:    strings.TrimSpace(item.Scope),
+			Resource:      resource,
+			Action:        action,
+			Scope:         strings.TrimSpace(item.Scope),
+			LabelSelector: selector.String(),
 		})
 	}
 	return out, nil
@@ -242,6 +336,88 @@ func rbacScopeMatches(pattern, scope string) bool {
 	return strings.HasPrefix(scope, pattern+"/")
 }
 
+// RestoreRole inserts a role with its existing ID. It refuses to replace a
+// role that already exists so an import cannot rewrite live permissions.
+func (s *RBACStore) RestoreRole(in RBACRole) (RBACRole, error) {
+	return s.restoreRole(in, false)
+}
+
+// ReplaceRole inserts or overwrites a role by ID. It is for followers
+// mirroring their primary, where the primary's copy is authoritative.
+func (s *RBACStore) ReplaceRole(in RBACRole) (RBACRole, error) {
+	return s.restoreRole(in, true)
+}
+
+func (s *RBACStore) restoreRole(in RBACRole, replace bool) (RBACRole, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	in.Name = strings.TrimSpace(in.Name)
+	if in.ID == "" || in.Name == "" {
+		return RBACRole{}, errors.New("role id and name are required")
+	}
+	permissions, err := normalizeRBACPermissions(in.Permissions)
+	if err != nil {
+		return RBACRole{}, err
+	}
+	in.Permissions = permissions
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = time.Now().UTC()
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = in.CreatedAt
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.roles[in.ID]; ok && !replace {
+		return RBACRole{}, errors.New("role " + in.ID + " already exists")
+	}
+	s.nextRoleID = advanceRestoredID(s.nextRoleID, in.ID, "rbac-role-")
+	item := cloneRBACRole(in)
+	s.roles[in.ID] = &item
+	return cloneRBACRole(item), nil
+}
+
+// RestoreBinding inserts a binding with its existing ID; its role must
+// already exist and the binding ID must not.
+func (s *RBACStore) RestoreBinding(in RBACBinding) (RBACBinding, error) {
+	return s.restoreBinding(in, false)
+}
+
+// ReplaceBinding inserts or overwrites a binding by ID; its role must
+// already exist.
+func (s *RBACStore) ReplaceBinding(in RBACBinding) (RBACBinding, error) {
+	return s.restoreBinding(in, true)
+}
+
+func (s *RBACStore) restoreBinding(in RBACBinding, replace bool) (RBACBinding, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	in.Subject = strings.TrimSpace(in.Subject)
+	in.RoleID = strings.TrimSpace(in.RoleID)
+	if in.ID == "" || in.Subject == "" || in.RoleID == "" {
+		return RBACBinding{}, errors.New("binding id, subject, and role_id are required")
+	}
+	if strings.TrimSpace(in.Scope) == "" {
+		in.Scope = "*"
+	}
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = time.Now().UTC()
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = in.CreatedAt
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.bindings[in.ID]; ok && !replace {
+		return RBACBinding{}, errors.New("binding " + in.ID + " already exists")
+	}
+	if _, ok := s.roles[in.RoleID]; !ok {
+		return RBACBinding{}, errors.New("role not found")
+	}
+	s.nextBindID = advanceRestoredID(s.nextBindID, in.ID, "rbac-binding-")
+	item := cloneRBACBinding(in)
+	s.bindings[in.ID] = &item
+	return cloneRBACBinding(item), nil
+}
+
 func cloneRBACRole(in RBACRole) RBACRole {
 	out := in
 	out.Permissions = append([]RBACPermission{}, in.Permissions...)
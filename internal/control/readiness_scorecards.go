@@ -27,10 +27,11 @@ type ReadinessScorecard struct {
 }
 
 type ReadinessScorecardStore struct {
-	mu      sync.RWMutex
-	nextID  int64
-	items   map[string]*ReadinessScorecard
-	ordered []string
+	mu       sync.RWMutex
+	nextID   int64
+	items    map[string]*ReadinessScorecard
+	ordered  []string
+	blockers func(environment, service string) []string
 }
 
 func NewReadinessScorecardStore() *ReadinessScorecardStore {
@@ -40,6 +41,14 @@ func NewReadinessScorecardStore() *ReadinessScorecardStore {
 	}
 }
 
+// SetBlockerCheck installs a check whose blockers are added to every new
+// scorecard, for gates such as mutation policy that live in other stores.
+func (s *ReadinessScorecardStore) SetBlockerCheck(check func(environment, service string) []string) {
+	s.mu.Lock()
+	s.blockers = check
+	s.mu.Unlock()
+}
+
 func (s *ReadinessScorecardStore) Create(in ReadinessScorecardInput) (ReadinessScorecard, error) {
 	environment := strings.TrimSpace(in.Environment)
 	service := strings.TrimSpace(in.Service)
@@ -47,6 +56,15 @@ func (s *ReadinessScorecardStore) Create(in ReadinessScorecardInput) (ReadinessS
 		return ReadinessScorecard{}, errors.New("environment and service are required")
 	}
 	report := EvaluateReadiness(in.Signals, in.Thresholds)
+	s.mu.RLock()
+	check := s.blockers
+	s.mu.RUnlock()
+	if check != nil {
+		if extra := check(environment, service); len(extra) > 0 {
+			report.Blockers = append(report.Blockers, extra...)
+			report.Pass = false
+		}
+	}
 	grade := readinessGrade(report)
 	item := ReadinessScorecard{
 		Environment: environment,
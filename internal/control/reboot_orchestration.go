@@ -34,6 +34,7 @@ type RebootHost struct {
 type RebootPlanInput struct {
 	Environment string       `json:"environment"`
 	Hosts       []RebootHost `json:"hosts"`
+	Service     string       `json:"service,omitempty"`
 }
 
 type RebootWave struct {
@@ -44,12 +45,13 @@ type RebootWave struct {
 }
 
 type RebootPlan struct {
-	Allowed        bool         `json:"allowed"`
-	Environment    string       `json:"environment"`
-	PolicyID       string       `json:"policy_id,omitempty"`
-	HealthyPercent int          `json:"healthy_percent"`
-	BlockedReason  string       `json:"blocked_reason,omitempty"`
-	Waves          []RebootWave `json:"waves,omitempty"`
+	Allowed         bool         `json:"allowed"`
+	Environment     string       `json:"environment"`
+	PolicyID        string       `json:"policy_id,omitempty"`
+	HealthyPercent  int          `json:"healthy_percent"`
+	BlockedReason   string       `json:"blocked_reason,omitempty"`
+	Waves           []RebootWave `json:"waves,omitempty"`
+	DisruptionClaim string       `json:"disruption_claim_id,omitempty"`
 }
 
 type RebootOrchestrationStore struct {
@@ -0,0 +1,185 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CachedResponse struct {
+	Key          string    `json:"key"`
+	Status       int       `json:"status"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Body         []byte    `json:"-"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	Tags         []string  `json:"tags,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"` // zero never expires
+	Stale        bool      `json:"stale"`
+	Hits         int64     `json:"hits"`
+}
+
+type ResponseCacheStats struct {
+	Entries       int              `json:"entries"`
+	MaxEntries    int              `json:"max_entries"`
+	Hits          int64            `json:"hits"`
+	Misses        int64            `json:"misses"`
+	Invalidations int64            `json:"invalidations"`
+	Items         []CachedResponse `json:"items"`
+}
+
+// ResponseCache holds rendered GET responses keyed by request. Entries are
+// tagged with the stores they derive from so mutations can invalidate them.
+// Invalidated entries are kept as stale so an identical rebuild keeps its
+// original Last-Modified time.
+type ResponseCache struct {
+	mu            sync.Mutex
+	maxEntries    int
+	entries       map[string]*CachedResponse
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+func NewResponseCache(maxEntries int) *ResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = 256
+	}
+	return &ResponseCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*CachedResponse{},
+	}
+}
+
+// ResponseETag returns a strong entity tag for a response body.
+func ResponseETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func (c *ResponseCache) Get(key string) (CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.entries[key]
+	if !ok || item.Stale || (!item.ExpiresAt.IsZero() && time.Now().UTC().After(item.ExpiresAt)) {
+		c.misses++
+		return CachedResponse{}, false
+	}
+	c.hits++
+	item.Hits++
+	return cloneCachedResponse(*item), true
+}
+
+// Put stores a rendered response with the given ttl (zero means until
+// invalidated) and returns it with its validators filled in.
+func (c *ResponseCache) Put(in CachedResponse, ttl time.Duration) CachedResponse {
+	now := time.Now().UTC()
+	in.Key = strings.TrimSpace(in.Key)
+	in.ETag = ResponseETag(in.Body)
+	in.LastModified = now.Truncate(time.Second)
+	in.Tags = normalizeStringSlice(in.Tags)
+	in.Stale = false
+	in.Hits = 0
+	in.ExpiresAt = time.Time{}
+	if ttl > 0 {
+		in.ExpiresAt = now.Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.entries[in.Key]; ok && prev.ETag == in.ETag {
+		in.LastModified = prev.LastModified
+		in.Hits = prev.Hits
+	}
+	if _, ok := c.entries[in.Key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	item := cloneCachedResponse(in)
+	c.entries[in.Key] = &item
+	return cloneCachedResponse(item)
+}
+
+// Invalidate marks every entry carrying one of the tags as stale and
+// returns how many entries were affected.
+func (c *ResponseCache) Invalidate(tags ...string) int {
+	want := map[string]struct{}{}
+	for _, tag := range normalizeStringSlice(tags) {
+		want[tag] = struct{}{}
+	}
+	if len(want) == 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, item := range c.entries {
+		if item.Stale {
+			continue
+		}
+		for _, tag := range item.Tags {
+			if _, ok := want[tag]; ok {
+				item.Stale = true
+				count++
+				break
+			}
+		}
+	}
+	c.invalidations += int64(count)
+	return count
+}
+
+// Purge drops every entry, including stale validators.
+func (c *ResponseCache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := len(c.entries)
+	c.entries = map[string]*CachedResponse{}
+	c.invalidations += int64(count)
+	return count
+}
+
+func (c *ResponseCache) Stats() ResponseCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := ResponseCacheStats{
+		Entries:       len(c.entries),
+		MaxEntries:    c.maxEntries,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Invalidations: c.invalidations,
+		Items:         make([]CachedResponse, 0, len(c.entries)),
+	}
+	for _, item := range c.entries {
+		out.Items = append(out.Items, cloneCachedResponse(*item))
+	}
+	sort.Slice(out.Items, func(i, j int) bool { return out.Items[i].Key < out.Items[j].Key })
+	return out
+}
+
+// evictLocked drops a stale entry if there is one, otherwise the entry that
+// was least recently modified.
+func (c *ResponseCache) evictLocked() {
+	victim := ""
+	var oldest time.Time
+	for key, item := range c.entries {
+		if item.Stale {
+			victim = key
+			break
+		}
+		if victim == "" || item.LastModified.Before(oldest) {
+			victim = key
+			oldest = item.LastModified
+		}
+	}
+	delete(c.entries, victim)
+}
+
+func cloneCachedResponse(in CachedResponse) CachedResponse {
+	out := in
+	out.Body = append([]byte(nil), in.Body...)
+	out.Tags = append([]string(nil), in.Tags...)
+	return out
+}
@@ -0,0 +1,46 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseCacheInvalidateKeepsValidators(t *testing.T) {
+	cache := NewResponseCache(2)
+	first := cache.Put(CachedResponse{Key: "/v1/fleet/health", Status: 200, Body: []byte(`{"a":1}`), Tags: []string{"runs"}}, 0)
+	if first.ETag == "" || first.LastModified.IsZero() {
+		t.Fatalf("expected validators on cached response: %+v", first)
+	}
+	if _, ok := cache.Get("/v1/fleet/health"); !ok {
+		t.Fatalf("expected cache hit")
+	}
+	if n := cache.Invalidate("RUNS"); n != 1 {
+		t.Fatalf("expected one invalidated entry, got %d", n)
+	}
+	if _, ok := cache.Get("/v1/fleet/health"); ok {
+		t.Fatalf("expected invalidated entry to miss")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	same := cache.Put(CachedResponse{Key: "/v1/fleet/health", Status: 200, Body: []byte(`{"a":1}`), Tags: []string{"runs"}}, 0)
+	if same.ETag != first.ETag || !same.LastModified.Equal(first.LastModified) {
+		t.Fatalf("expected identical rebuild to keep validators: first=%+v same=%+v", first, same)
+	}
+	changed := cache.Put(CachedResponse{Key: "/v1/fleet/health", Status: 200, Body: []byte(`{"a":2}`), Tags: []string{"runs"}}, 0)
+	if changed.ETag == first.ETag || !changed.LastModified.After(first.LastModified) {
+		t.Fatalf("expected changed body to get new validators: %+v", changed)
+	}
+
+	cache.Put(CachedResponse{Key: "/b", Status: 200, Body: []byte(`1`)}, time.Millisecond)
+	cache.Put(CachedResponse{Key: "/c", Status: 200, Body: []byte(`2`)}, 0)
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected cache stats: %+v", stats)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("/b"); ok {
+		t.Fatalf("expected expired entry to miss")
+	}
+	if n := cache.Purge(); n != 2 {
+		t.Fatalf("expected purge to drop all entries, got %d", n)
+	}
+}
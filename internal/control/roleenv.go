@@ -3,6 +3,7 @@ package control
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -24,31 +25,44 @@ type RoleDefinition struct {
 }
 
 type EnvironmentDefinition struct {
-	Name               string              `json:"name"`
-	Description        string              `json:"description,omitempty"`
-	PolicyGroup        string              `json:"policy_group,omitempty"`
-	DefaultAttributes  map[string]any      `json:"default_attributes,omitempty"`
-	OverrideAttributes map[string]any      `json:"override_attributes,omitempty"`
-	RunListOverrides   map[string][]string `json:"run_list_overrides,omitempty"` // role -> run list
-	PolicyOverrides    map[string]any      `json:"policy_overrides,omitempty"`
-	UpdatedAt          time.Time           `json:"updated_at"`
-	Source             string              `json:"source"` // api|file
+	Name               string                 `json:"name"`
+	Description        string                 `json:"description,omitempty"`
+	PolicyGroup        string                 `json:"policy_group,omitempty"`
+	DefaultAttributes  map[string]any         `json:"default_attributes,omitempty"`
+	OverrideAttributes map[string]any         `json:"override_attributes,omitempty"`
+	RunListOverrides   map[string][]string    `json:"run_list_overrides,omitempty"` // role -> run list
+	PolicyOverrides    map[string]any         `json:"policy_overrides,omitempty"`
+	VersionConstraints map[string]string      `json:"version_constraints,omitempty"` // module/package -> constraint
+	Guardrails         *EnvironmentGuardrails `json:"guardrails,omitempty"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+	Source             string                 `json:"source"` // api|file
 }
 
 type RoleEnvironmentResolution struct {
-	Role        string         `json:"role"`
-	Environment string         `json:"environment"`
-	RunList     []string       `json:"run_list"`
-	Attributes  map[string]any `json:"attributes"`
-	PolicyGroup string         `json:"policy_group,omitempty"`
-	Precedence  []string       `json:"precedence"`
-	ResolvedAt  time.Time      `json:"resolved_at"`
+	Role               string            `json:"role"`
+	Environment        string            `json:"environment"`
+	RunList            []string          `json:"run_list"`
+	Attributes         map[string]any    `json:"attributes"`
+	PolicyGroup        string            `json:"policy_group,omitempty"`
+	VersionConstraints map[string]string `json:"version_constraints,omitempty"`
+	Precedence         []string          `json:"precedence"`
+	ResolvedAt         time.Time         `json:"resolved_at"`
+}
+
+// RoleEnvLoadError describes a role or environment file that could not be
+// loaded at startup. Name is empty when the file could not be parsed.
+type RoleEnvLoadError struct {
+	Kind  string `json:"kind"`
+	File  string `json:"file"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
 }
 
 type RoleEnvironmentStore struct {
 	mu           sync.RWMutex
 	roles        map[string]RoleDefinition
 	environments map[string]EnvironmentDefinition
+	loadErrors   []RoleEnvLoadError
 	rolesDir     string
 	envDir       string
 }
@@ -106,6 +120,16 @@ func (s *RoleEnvironmentStore) UpsertEnvironment(env EnvironmentDefinition) (Env
 	env.OverrideAttributes = cloneRoleEnvMap(env.OverrideAttributes)
 	env.PolicyOverrides = cloneRoleEnvMap(env.PolicyOverrides)
 	env.RunListOverrides = normalizeRunListOverrides(env.RunListOverrides)
+	constraints, err := normalizeVersionConstraints(env.VersionConstraints)
+	if err != nil {
+		return EnvironmentDefinition{}, err
+	}
+	env.VersionConstraints = constraints
+	guardrails, err := normalizeEnvironmentGuardrails(env.Guardrails)
+	if err != nil {
+		return EnvironmentDefinition{}, err
+	}
+	env.Guardrails = guardrails
 	env.UpdatedAt = time.Now().UTC()
 	if strings.TrimSpace(env.Source) == "" {
 		env.Source = "api"
@@ -114,6 +138,7 @@ func (s *RoleEnvironmentStore) UpsertEnvironment(env EnvironmentDefinition) (Env
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.environments[name] = cloneEnvironment(env)
+	s.clearLoadErrorLocked("environment", name)
 	if err := writeRoleEnvJSON(filepath.Join(s.envDir, name+".json"), env); err != nil {
 		return EnvironmentDefinition{}, err
 	}
@@ -135,11 +160,49 @@ func (s *RoleEnvironmentStore) GetEnvironment(name string) (EnvironmentDefinitio
 	defer s.mu.RUnlock()
 	env, ok := s.environments[normalizeRoleEnvName(name)]
 	if !ok {
+		if loadErr, failed := s.loadErrorLocked("environment", name); failed {
+			return EnvironmentDefinition{}, loadErr
+		}
 		return EnvironmentDefinition{}, errors.New("environment not found")
 	}
 	return cloneEnvironment(env), nil
 }
 
+// LoadErrors returns the role and environment files that failed to load.
+// An environment that failed to load is reported by GetEnvironment as a
+// load error rather than as missing, so its pins and guardrails are not
+// silently skipped; upserting it again clears the error.
+func (s *RoleEnvironmentStore) LoadErrors() []RoleEnvLoadError {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]RoleEnvLoadError{}, s.loadErrors...)
+}
+
+// errRoleEnvLoadFailed marks lookups of definitions whose file failed to
+// load, so callers that treat a missing environment as unconstrained fail
+// closed instead.
+var errRoleEnvLoadFailed = errors.New("failed to load")
+
+func (s *RoleEnvironmentStore) loadErrorLocked(kind, name string) (error, bool) {
+	name = normalizeRoleEnvName(name)
+	for _, item := range s.loadErrors {
+		if item.Kind == kind && item.Name == name && name != "" {
+			return fmt.Errorf("%s %w from %s: %s", kind, errRoleEnvLoadFailed, item.File, item.Error), true
+		}
+	}
+	return nil, false
+}
+
+func (s *RoleEnvironmentStore) clearLoadErrorLocked(kind, name string) {
+	kept := s.loadErrors[:0]
+	for _, item := range s.loadErrors {
+		if item.Kind != kind || item.Name != name {
+			kept = append(kept, item)
+		}
+	}
+	s.loadErrors = kept
+}
+
 func (s *RoleEnvironmentStore) ListRoles() []RoleDefinition {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -182,6 +245,7 @@ func (s *RoleEnvironmentStore) DeleteEnvironment(name string) bool {
 		return false
 	}
 	delete(s.environments, name)
+	s.clearLoadErrorLocked("environment", name)
 	_ = os.Remove(filepath.Join(s.envDir, name+".json"))
 	return true
 }
@@ -220,11 +284,12 @@ func (s *RoleEnvironmentStore) Resolve(roleName, envName string) (RoleEnvironmen
 		policyGroup = strings.TrimSpace(hier.PolicyGroup)
 	}
 	return RoleEnvironmentResolution{
-		Role:        role.Name,
-		Environment: env.Name,
-		RunList:     runList,
-		Attributes:  attrs,
-		PolicyGroup: policyGroup,
+		Role:               role.Name,
+		Environment:        env.Name,
+		RunList:            runList,
+		Attributes:         attrs,
+		PolicyGroup:        policyGroup,
+		VersionConstraints: cloneVersionConstraints(env.VersionConstraints),
 		Precedence: append(append([]string{}, hier.Precedence...),
 			"environment.default_attributes",
 			"environment.override_attributes",
@@ -235,6 +300,9 @@ func (s *RoleEnvironmentStore) Resolve(roleName, envName string) (RoleEnvironmen
 }
 
 func (s *RoleEnvironmentStore) loadFromDisk() {
+	fail := func(kind, file, name string, err error) {
+		s.loadErrors = append(s.loadErrors, RoleEnvLoadError{Kind: kind, File: file, Name: name, Error: err.Error()})
+	}
 	loadRoles := func() {
 		files, err := filepath.Glob(filepath.Join(s.rolesDir, "*.json"))
 		if err != nil {
@@ -242,11 +310,13 @@ func (s *RoleEnvironmentStore) loadFromDisk() {
 		}
 		for _, file := range files {
 			var role RoleDefinition
-			if !readRoleEnvJSON(file, &role) {
+			if err := readRoleEnvJSON(file, &role); err != nil {
+				fail("role", file, "", err)
 				continue
 			}
 			role.Name = normalizeRoleEnvName(role.Name)
 			if role.Name == "" {
+				fail("role", file, "", errors.New("name is required"))
 				continue
 			}
 			role.Profiles = normalizeRoleProfiles(role.Profiles)
@@ -266,17 +336,31 @@ func (s *RoleEnvironmentStore) loadFromDisk() {
 		}
 		for _, file := range files {
 			var env EnvironmentDefinition
-			if !readRoleEnvJSON(file, &env) {
+			if err := readRoleEnvJSON(file, &env); err != nil {
+				fail("environment", file, normalizeRoleEnvName(strings.TrimSuffix(filepath.Base(file), ".json")), err)
 				continue
 			}
 			env.Name = normalizeRoleEnvName(env.Name)
 			if env.Name == "" {
+				fail("environment", file, "", errors.New("name is required"))
 				continue
 			}
 			env.DefaultAttributes = cloneRoleEnvMap(env.DefaultAttributes)
 			env.OverrideAttributes = cloneRoleEnvMap(env.OverrideAttributes)
 			env.PolicyOverrides = cloneRoleEnvMap(env.PolicyOverrides)
 			env.RunListOverrides = normalizeRunListOverrides(env.RunListOverrides)
+			constraints, err := normalizeVersionConstraints(env.VersionConstraints)
+			if err != nil {
+				fail("environment", file, env.Name, err)
+				continue
+			}
+			env.VersionConstraints = constraints
+			guardrails, err := normalizeEnvironmentGuardrails(env.Guardrails)
+			if err != nil {
+				fail("environment", file, env.Name, err)
+				continue
+			}
+			env.Guardrails = guardrails
 			if strings.TrimSpace(env.Source) == "" {
 				env.Source = "file"
 			}
@@ -298,12 +382,12 @@ func writeRoleEnvJSON(path string, v any) error {
 	return os.WriteFile(path, append(buf, '\n'), 0o644)
 }
 
-func readRoleEnvJSON(path string, v any) bool {
+func readRoleEnvJSON(path string, v any) error {
 	raw, err := os.ReadFile(path)
 	if err != nil {
-		return false
+		return err
 	}
-	return json.Unmarshal(raw, v) == nil
+	return json.Unmarshal(raw, v)
 }
 
 func cloneRole(in RoleDefinition) RoleDefinition {
@@ -321,6 +405,16 @@ func cloneEnvironment(in EnvironmentDefinition) EnvironmentDefinition {
 	out.OverrideAttributes = cloneRoleEnvMap(in.OverrideAttributes)
 	out.PolicyOverrides = cloneRoleEnvMap(in.PolicyOverrides)
 	out.RunListOverrides = normalizeRunListOverrides(in.RunListOverrides)
+	out.VersionConstraints = cloneVersionConstraints(in.VersionConstraints)
+	out.Guardrails = cloneEnvironmentGuardrails(in.Guardrails)
+	return out
+}
+
+func cloneVersionConstraints(in map[string]string) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
 	return out
 }
 
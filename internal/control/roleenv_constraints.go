@@ -0,0 +1,309 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionConstraintCheck is the outcome of evaluating one environment pin
+// (e.g. "nginx ~> 2.1") against the version a bundle or config resolves to.
+type VersionConstraintCheck struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
+	Version    string `json:"version,omitempty"`
+	Satisfied  bool   `json:"satisfied"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type VersionConstraintReport struct {
+	Environment string                   `json:"environment"`
+	Satisfied   bool                     `json:"satisfied"`
+	Checks      []VersionConstraintCheck `json:"checks"`
+	Conflicts   []VersionConstraintCheck `json:"conflicts,omitempty"`
+	CheckedAt   time.Time                `json:"checked_at"`
+}
+
+type PolicyBundleRejection struct {
+	BundleID  string                   `json:"bundle_id"`
+	Version   string                   `json:"version"`
+	Conflicts []VersionConstraintCheck `json:"conflicts"`
+}
+
+type PolicyBundleSelection struct {
+	Environment string                  `json:"environment"`
+	Selected    *VersionedPolicyBundle  `json:"selected,omitempty"`
+	Report      VersionConstraintReport `json:"report"`
+	Rejected    []PolicyBundleRejection `json:"rejected,omitempty"`
+}
+
+// EvaluateVersionConstraints checks resolved versions against the pins of
+// an environment. Pins for names absent from versions are reported but do
+// not conflict, matching Chef's cookbook_versions behavior.
+func (s *RoleEnvironmentStore) EvaluateVersionConstraints(envName string, versions map[string]string) (VersionConstraintReport, error) {
+	env, err := s.GetEnvironment(envName)
+	if err != nil {
+		return VersionConstraintReport{}, err
+	}
+	return evaluateVersionConstraints(env, versions), nil
+}
+
+// SelectPolicyBundle picks the highest-versioned candidate whose bundle
+// version and lock entries satisfy the environment's pins. Environments
+// without a definition carry no pins; environments whose file failed to
+// load reject every candidate.
+func (s *RoleEnvironmentStore) SelectPolicyBundle(envName string, candidates []VersionedPolicyBundle) (PolicyBundleSelection, error) {
+	env, err := s.GetEnvironment(envName)
+	if errors.Is(err, errRoleEnvLoadFailed) {
+		return PolicyBundleSelection{}, err
+	}
+	if err != nil {
+		env = EnvironmentDefinition{Name: normalizeRoleEnvName(envName)}
+	}
+	if len(candidates) == 0 {
+		return PolicyBundleSelection{}, errors.New("no policy bundle candidates")
+	}
+	ordered := append([]VersionedPolicyBundle{}, candidates...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return compareVersions(ordered[i].Version, ordered[j].Version) > 0
+	})
+	out := PolicyBundleSelection{Environment: env.Name}
+	for _, bundle := range ordered {
+		report := evaluateVersionConstraints(env, PolicyBundleVersions(bundle))
+		if report.Satisfied {
+			selected := clonePolicyBundle(bundle)
+			out.Selected = &selected
+			out.Report = report
+			return out, nil
+		}
+		out.Rejected = append(out.Rejected, PolicyBundleRejection{
+			BundleID:  bundle.ID,
+			Version:   bundle.Version,
+			Conflicts: report.Conflicts,
+		})
+		out.Report = report
+	}
+	return out, nil
+}
+
+// PolicyBundleVersions flattens a bundle and its lock entries into a
+// name -> version map for constraint checks.
+func PolicyBundleVersions(bundle VersionedPolicyBundle) map[string]string {
+	out := map[string]string{}
+	for _, entry := range bundle.LockEntries {
+		out[normalizeRoleEnvName(entry.Name)] = strings.TrimSpace(entry.Version)
+	}
+	if name := normalizeRoleEnvName(bundle.Name); name != "" {
+		out[name] = strings.TrimSpace(bundle.Version)
+	}
+	return out
+}
+
+// ValidateVersionConstraint accepts Chef-style constraints: "=", "!=", ">",
+// "<", ">=", "<=", and "~>" joined by commas, e.g. ">= 1.2, < 2.0".
+func ValidateVersionConstraint(constraint string) error {
+	_, err := parseVersionConstraint(constraint)
+	return err
+}
+
+// VersionSatisfiesConstraint reports whether version matches constraint.
+func VersionSatisfiesConstraint(version, constraint string) (bool, error) {
+	clauses, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	version = strings.TrimSpace(version)
+	if _, ok := parseVersionParts(version); !ok {
+		return false, errors.New("invalid version " + strconv.Quote(version))
+	}
+	for _, c := range clauses {
+		if !c.matches(version) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func evaluateVersionConstraints(env EnvironmentDefinition, versions map[string]string) VersionConstraintReport {
+	normalized := map[string]string{}
+	for name, version := range versions {
+		normalized[normalizeRoleEnvName(name)] = strings.TrimSpace(version)
+	}
+	names := make([]string, 0, len(env.VersionConstraints))
+	for name := range env.VersionConstraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := VersionConstraintReport{
+		Environment: env.Name,
+		Satisfied:   true,
+		Checks:      []VersionConstraintCheck{},
+		CheckedAt:   time.Now().UTC(),
+	}
+	for _, name := range names {
+		check := VersionConstraintCheck{Name: name, Constraint: env.VersionConstraints[name]}
+		version, ok := normalized[name]
+		if !ok || version == "" {
+			check.Satisfied = true
+			check.Reason = "not referenced"
+			report.Checks = append(report.Checks, check)
+			continue
+		}
+		check.Version = version
+		matched, err := VersionSatisfiesConstraint(version, check.Constraint)
+		switch {
+		case err != nil:
+			check.Reason = err.Error()
+		case !matched:
+			check.Reason = "version " + version + " does not satisfy " + check.Constraint
+		default:
+			check.Satisfied = true
+		}
+		if !check.Satisfied {
+			report.Satisfied = false
+			report.Conflicts = append(report.Conflicts, check)
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return report
+}
+
+func normalizeVersionConstraints(in map[string]string) (map[string]string, error) {
+	out := map[string]string{}
+	for name, constraint := range in {
+		key := normalizeRoleEnvName(name)
+		if key == "" {
+			continue
+		}
+		constraint = strings.Join(strings.Fields(constraint), " ")
+		if err := ValidateVersionConstraint(constraint); err != nil {
+			return nil, errors.New("version constraint for " + key + ": " + err.Error())
+		}
+		out[key] = constraint
+	}
+	return out, nil
+}
+
+type versionClause struct {
+	op      string
+	version string
+}
+
+func parseVersionConstraint(constraint string) ([]versionClause, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return nil, errors.New("constraint is required")
+	}
+	out := []versionClause{}
+	for _, raw := range strings.Split(constraint, ",") {
+		raw = strings.TrimSpace(raw)
+		op := "="
+		for _, candidate := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(raw, candidate) {
+				op = candidate
+				raw = strings.TrimSpace(strings.TrimPrefix(raw, candidate))
+				break
+			}
+		}
+		parts, ok := parseVersionParts(raw)
+		if !ok {
+			return nil, errors.New("invalid version constraint " + strconv.Quote(constraint))
+		}
+		if op == "~>" && len(parts) < 2 {
+			return nil, errors.New("~> requires at least major.minor in " + strconv.Quote(constraint))
+		}
+		out = append(out, versionClause{op: op, version: raw})
+	}
+	return out, nil
+}
+
+func (c versionClause) matches(version string) bool {
+	cmp := compareVersions(version, c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		// ~> 2.1 allows >= 2.1, < 3.0; ~> 2.1.3 allows >= 2.1.3, < 2.2.0.
+		if cmp < 0 {
+			return false
+		}
+		parts, _ := parseVersionParts(c.version)
+		upper := append([]int{}, parts[:len(parts)-1]...)
+		upper[len(upper)-1]++
+		return compareVersionParts(mustVersionParts(version), upper) < 0
+	default:
+		return false
+	}
+}
+
+func parseVersionParts(raw string) ([]int, bool) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if raw == "" {
+		return nil, false
+	}
+	fields := strings.Split(raw, ".")
+	if len(fields) > 4 {
+		return nil, false
+	}
+	out := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		out = append(out, n)
+	}
+	return out, true
+}
+
+func mustVersionParts(raw string) []int {
+	parts, _ := parseVersionParts(raw)
+	return parts
+}
+
+func compareVersions(a, b string) int {
+	pa, okA := parseVersionParts(a)
+	pb, okB := parseVersionParts(b)
+	switch {
+	case okA && okB:
+		return compareVersionParts(pa, pb)
+	case okA:
+		return 1
+	case okB:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareVersionParts(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionSatisfiesConstraint(t *testing.T) {
+	cases := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"2.1.0", "~> 2.1", true},
+		{"2.9.4", "~> 2.1", true},
+		{"3.0.0", "~> 2.1", false},
+		{"2.1.5", "~> 2.1.3", true},
+		{"2.2.0", "~> 2.1.3", false},
+		{"1.4.0", ">= 1.2, < 2.0", true},
+		{"2.0.0", ">= 1.2, < 2.0", false},
+		{"1.2.3", "= 1.2.3", true},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "!= 1.2.3", false},
+	}
+	for _, tc := range cases {
+		got, err := VersionSatisfiesConstraint(tc.version, tc.constraint)
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error %v", tc.version, tc.constraint, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s %s: expected %t, got %t", tc.version, tc.constraint, tc.want, got)
+		}
+	}
+	if err := ValidateVersionConstraint("~> 2"); err == nil {
+		t.Fatalf("expected ~> with only a major version to be rejected")
+	}
+	if err := ValidateVersionConstraint(">= banana"); err == nil {
+		t.Fatalf("expected invalid version to be rejected")
+	}
+}
+
+func TestRoleEnvironmentVersionConstraints(t *testing.T) {
+	base := t.TempDir()
+	store := NewRoleEnvironmentStore(base)
+	if _, err := store.UpsertEnvironment(EnvironmentDefinition{
+		Name:               "prod",
+		VersionConstraints: map[string]string{"nginx": "bogus"},
+	}); err == nil {
+		t.Fatalf("expected invalid constraint to be rejected")
+	}
+	env, err := store.UpsertEnvironment(EnvironmentDefinition{
+		Name:               "prod",
+		VersionConstraints: map[string]string{"Nginx": "~>  2.1", "base": ">= 1.0"},
+	})
+	if err != nil {
+		t.Fatalf("upsert environment failed: %v", err)
+	}
+	if env.VersionConstraints["nginx"] != "~> 2.1" {
+		t.Fatalf("expected normalized constraint, got %#v", env.VersionConstraints)
+	}
+
+	report, err := store.EvaluateVersionConstraints("prod", map[string]string{"nginx": "3.0.0"})
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if report.Satisfied || len(report.Conflicts) != 1 || report.Conflicts[0].Name != "nginx" {
+		t.Fatalf("expected nginx conflict, got %#v", report)
+	}
+	if len(report.Checks) != 2 || report.Checks[0].Name != "base" || report.Checks[0].Reason != "not referenced" {
+		t.Fatalf("expected unreferenced pin to be reported, got %#v", report.Checks)
+	}
+
+	candidates := []VersionedPolicyBundle{
+		{ID: "bundle-1", Name: "web", Version: "1.0.0", LockEntries: []PolicyLockEntry{{Name: "nginx", Version: "2.1.4"}}},
+		{ID: "bundle-2", Name: "web", Version: "1.1.0", LockEntries: []PolicyLockEntry{{Name: "nginx", Version: "3.0.0"}}},
+	}
+	selection, err := store.SelectPolicyBundle("prod", candidates)
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if selection.Selected == nil || selection.Selected.ID != "bundle-1" {
+		t.Fatalf("expected newest satisfying bundle-1, got %#v", selection)
+	}
+	if len(selection.Rejected) != 1 || selection.Rejected[0].BundleID != "bundle-2" {
+		t.Fatalf("expected bundle-2 to be rejected, got %#v", selection.Rejected)
+	}
+
+	selection, err = store.SelectPolicyBundle("prod", candidates[1:])
+	if err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if selection.Selected != nil || selection.Report.Satisfied {
+		t.Fatalf("expected conflict selection, got %#v", selection)
+	}
+
+	unpinned, err := store.SelectPolicyBundle("dev", candidates)
+	if err != nil || unpinned.Selected == nil || unpinned.Selected.ID != "bundle-2" {
+		t.Fatalf("expected undefined environment to take newest bundle, got %#v err=%v", unpinned, err)
+	}
+
+	reloaded, err := NewRoleEnvironmentStore(base).GetEnvironment("prod")
+	if err != nil || reloaded.VersionConstraints["nginx"] != "~> 2.1" {
+		t.Fatalf("expected constraints to persist, got %#v err=%v", reloaded.VersionConstraints, err)
+	}
+}
+
+func TestRoleEnvironmentInvalidConstraintsOnDiskFailClosed(t *testing.T) {
+	baseDir := t.TempDir()
+	envDir := filepath.Join(baseDir, ".masterchef", "policy", "environments")
+	if err := os.MkdirAll(envDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(envDir, "prod.json"), []byte(`{"name":"prod","version_constraints":{"nginx":"~> banana"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(envDir, "broken.json"), []byte(`{not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewRoleEnvironmentStore(baseDir)
+	loadErrs := store.LoadErrors()
+	if len(loadErrs) != 2 {
+		t.Fatalf("expected both bad files reported, got %+v", loadErrs)
+	}
+	if _, err := store.GetEnvironment("prod"); err == nil || !strings.Contains(err.Error(), "failed to load") {
+		t.Fatalf("expected load error for prod, got %v", err)
+	}
+	candidates := []VersionedPolicyBundle{{ID: "b1", Version: "1.0.0"}}
+	if _, err := store.SelectPolicyBundle("prod", candidates); err == nil {
+		t.Fatalf("expected bundle selection to fail closed for an environment that failed to load")
+	}
+	if report := store.EvaluateGuardrails("broken", nil, false); report.Allowed {
+		t.Fatalf("expected guardrails to fail closed for an environment that failed to load: %+v", report)
+	}
+	if _, err := store.SelectPolicyBundle("staging", candidates); err != nil {
+		t.Fatalf("expected undefined environment to stay unconstrained: %v", err)
+	}
+
+	if _, err := store.UpsertEnvironment(EnvironmentDefinition{Name: "prod", VersionConstraints: map[string]string{"nginx": "~> 1.0"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetEnvironment("prod"); err != nil {
+		t.Fatalf("expected upsert to clear the load error: %v", err)
+	}
+	if len(store.LoadErrors()) != 1 {
+		t.Fatalf("expected only the unparseable file to remain, got %+v", store.LoadErrors())
+	}
+}
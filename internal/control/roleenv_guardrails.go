@@ -0,0 +1,224 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// EnvironmentGuardrails restrict what a config may touch when it runs in
+// an environment. Empty allow lists permit everything; deny lists win over
+// allow lists. Host entries are glob patterns such as "db-*".
+type EnvironmentGuardrails struct {
+	AllowedResourceTypes  []string `json:"allowed_resource_types,omitempty"`
+	DeniedResourceTypes   []string `json:"denied_resource_types,omitempty"`
+	AllowedHosts          []string `json:"allowed_hosts,omitempty"`
+	DeniedHosts           []string `json:"denied_hosts,omitempty"`
+	ApprovalResourceTypes []string `json:"approval_resource_types,omitempty"` // need an approved change record
+}
+
+const (
+	GuardrailDeniedResourceType     = "denied_resource_type"
+	GuardrailResourceTypeNotAllowed = "resource_type_not_allowed"
+	GuardrailDeniedHost             = "denied_host"
+	GuardrailHostNotAllowed         = "host_not_allowed"
+	GuardrailApprovalRequired       = "approval_required"
+)
+
+type GuardrailViolation struct {
+	ResourceID   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+	Host         string `json:"host,omitempty"`
+	Rule         string `json:"rule"`
+	Message      string `json:"message"`
+}
+
+type GuardrailReport struct {
+	Environment string               `json:"environment"`
+	Allowed     bool                 `json:"allowed"`
+	Approved    bool                 `json:"approved"`
+	Violations  []GuardrailViolation `json:"violations"`
+	CheckedAt   time.Time            `json:"checked_at"`
+}
+
+// Err summarizes the violations as an error suitable for a failed job, or
+// returns nil when the config is allowed.
+func (r GuardrailReport) Err() error {
+	if r.Allowed {
+		return nil
+	}
+	msgs := make([]string, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return fmt.Errorf("environment %s guardrails rejected config: %s", r.Environment, strings.Join(msgs, "; "))
+}
+
+// EvaluateGuardrails checks every resource and handler of cfg against the
+// environment's guardrails. approved reports whether the run carries an
+// approved change record, which satisfies approval_resource_types.
+// Environments without a definition or guardrails allow everything.
+func (s *RoleEnvironmentStore) EvaluateGuardrails(envName string, cfg *config.Config, approved bool) GuardrailReport {
+	report := GuardrailReport{
+		Environment: normalizeRoleEnvName(envName),
+		Allowed:     true,
+		Approved:    approved,
+		Violations:  []GuardrailViolation{},
+		CheckedAt:   time.Now().UTC(),
+	}
+	env, err := s.GetEnvironment(envName)
+	if errors.Is(err, errRoleEnvLoadFailed) {
+		report.Allowed = false
+		report.Violations = append(report.Violations, GuardrailViolation{Rule: "load_error", Message: err.Error()})
+		return report
+	}
+	if err != nil || env.Guardrails == nil || cfg == nil {
+		return report
+	}
+	g := env.Guardrails
+	add := func(res config.Resource, rule, message string) {
+		report.Violations = append(report.Violations, GuardrailViolation{
+			ResourceID:   res.ID,
+			ResourceType: res.Type,
+			Host:         res.Host,
+			Rule:         rule,
+			Message:      message,
+		})
+	}
+	for _, res := range append(append([]config.Resource{}, cfg.Resources...), cfg.Handlers...) {
+		typ := strings.ToLower(res.Type)
+		label := fmt.Sprintf("resource %q (%s on %s)", res.ID, res.Type, res.Host)
+		switch {
+		case containsString(g.DeniedResourceTypes, typ):
+			add(res, GuardrailDeniedResourceType, label+" uses resource type "+res.Type+", which "+report.Environment+" forbids")
+		case len(g.AllowedResourceTypes) > 0 && !containsString(g.AllowedResourceTypes, typ):
+			add(res, GuardrailResourceTypeNotAllowed, label+" uses resource type "+res.Type+"; "+report.Environment+" only allows "+strings.Join(g.AllowedResourceTypes, ", "))
+		case containsString(g.ApprovalResourceTypes, typ) && !approved:
+			add(res, GuardrailApprovalRequired, label+" requires an approved change record in "+report.Environment+"; pass change_record_id")
+		}
+		host := res.Host
+		if res.DelegateTo != "" {
+			host = res.DelegateTo
+		}
+		switch {
+		case host == "":
+		case guardrailHostMatches(g.DeniedHosts, host):
+			add(res, GuardrailDeniedHost, label+" targets host "+host+", which "+report.Environment+" forbids")
+		case len(g.AllowedHosts) > 0 && !guardrailHostMatches(g.AllowedHosts, host):
+			add(res, GuardrailHostNotAllowed, label+" targets host "+host+" outside the hosts "+report.Environment+" allows ("+strings.Join(g.AllowedHosts, ", ")+")")
+		}
+	}
+	report.Allowed = len(report.Violations) == 0
+	return report
+}
+
+func normalizeEnvironmentGuardrails(in *EnvironmentGuardrails) (*EnvironmentGuardrails, error) {
+	if in == nil {
+		return nil, nil
+	}
+	types := func(items []string) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			if item = strings.ToLower(strings.TrimSpace(item)); item != "" && !containsString(out, item) {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	hosts := func(items []string) ([]string, error) {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if _, err := path.Match(item, ""); err != nil {
+				return nil, errors.New("invalid host pattern " + item)
+			}
+			out = append(out, item)
+		}
+		return out, nil
+	}
+	out := &EnvironmentGuardrails{
+		AllowedResourceTypes:  types(in.AllowedResourceTypes),
+		DeniedResourceTypes:   types(in.DeniedResourceTypes),
+		ApprovalResourceTypes: types(in.ApprovalResourceTypes),
+	}
+	var err error
+	if out.AllowedHosts, err = hosts(in.AllowedHosts); err != nil {
+		return nil, err
+	}
+	if out.DeniedHosts, err = hosts(in.DeniedHosts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func cloneEnvironmentGuardrails(in *EnvironmentGuardrails) *EnvironmentGuardrails {
+	if in == nil {
+		return nil
+	}
+	return &EnvironmentGuardrails{
+		AllowedResourceTypes:  append([]string{}, in.AllowedResourceTypes...),
+		DeniedResourceTypes:   append([]string{}, in.DeniedResourceTypes...),
+		AllowedHosts:          append([]string{}, in.AllowedHosts...),
+		DeniedHosts:           append([]string{}, in.DeniedHosts...),
+		ApprovalResourceTypes: append([]string{}, in.ApprovalResourceTypes...),
+	}
+}
+
+func guardrailHostMatches(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GuardrailExecutor re-checks environment guardrails when a job starts, so
+// guardrails tightened while the job was queued still apply.
+type GuardrailExecutor struct {
+	inner       Executor
+	envs        *RoleEnvironmentStore
+	approved    func(changeRecordID string) bool
+	onViolation func(Job, GuardrailReport)
+}
+
+func NewGuardrailExecutor(inner Executor, envs *RoleEnvironmentStore, approved func(changeRecordID string) bool) *GuardrailExecutor {
+	return &GuardrailExecutor{inner: inner, envs: envs, approved: approved}
+}
+
+// SetViolationHandler registers a callback for jobs rejected at apply time.
+// Call before any job is enqueued.
+func (e *GuardrailExecutor) SetViolationHandler(fn func(Job, GuardrailReport)) {
+	e.onViolation = fn
+}
+
+func (e *GuardrailExecutor) ApplyPath(configPath string) error {
+	return e.inner.ApplyPath(configPath)
+}
+
+func (e *GuardrailExecutor) ApplyJob(job Job) error {
+	if job.Environment != "" {
+		// Configs that fail to load are reported by the inner executor.
+		if cfg, err := config.Load(job.ConfigPath); err == nil {
+			approved := job.ChangeRecordID != "" && e.approved != nil && e.approved(job.ChangeRecordID)
+			report := e.envs.EvaluateGuardrails(job.Environment, cfg, approved)
+			if !report.Allowed {
+				if e.onViolation != nil {
+					e.onViolation(job, report)
+				}
+				return report.Err()
+			}
+		}
+	}
+	if jobExec, ok := e.inner.(JobExecutor); ok {
+		return jobExec.ApplyJob(job)
+	}
+	return e.inner.ApplyPath(job.ConfigPath)
+}
@@ -0,0 +1,123 @@
+package control
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+func TestEnvironmentGuardrails(t *testing.T) {
+	baseDir := t.TempDir()
+	store := NewRoleEnvironmentStore(baseDir)
+	if _, err := store.UpsertEnvironment(EnvironmentDefinition{
+		Name: "prod",
+		Guardrails: &EnvironmentGuardrails{
+			DeniedResourceTypes:   []string{"Registry"},
+			ApprovalResourceTypes: []string{"command"},
+			AllowedHosts:          []string{"web-*", "db-*"},
+			DeniedHosts:           []string{"db-primary"},
+		},
+	}); err != nil {
+		t.Fatalf("upsert environment failed: %v", err)
+	}
+	if _, err := store.UpsertEnvironment(EnvironmentDefinition{Name: "bad", Guardrails: &EnvironmentGuardrails{DeniedHosts: []string{"[web"}}}); err == nil {
+		t.Fatalf("expected invalid host pattern to be rejected")
+	}
+
+	cfg := &config.Config{Resources: []config.Resource{
+		{ID: "motd", Type: "file", Host: "web-1"},
+		{ID: "restart", Type: "command", Host: "web-2"},
+		{ID: "tuning", Type: "registry", Host: "web-3"},
+		{ID: "primary", Type: "file", Host: "db-primary"},
+		{ID: "cache", Type: "file", Host: "cache-1"},
+	}}
+	report := store.EvaluateGuardrails("PROD", cfg, false)
+	if report.Allowed || len(report.Violations) != 4 {
+		t.Fatalf("unexpected guardrail report: %+v", report)
+	}
+	rules := map[string]string{}
+	for _, v := range report.Violations {
+		rules[v.ResourceID] = v.Rule
+	}
+	want := map[string]string{
+		"restart": GuardrailApprovalRequired,
+		"tuning":  GuardrailDeniedResourceType,
+		"primary": GuardrailDeniedHost,
+		"cache":   GuardrailHostNotAllowed,
+	}
+	for id, rule := range want {
+		if rules[id] != rule {
+			t.Fatalf("expected %s violation for %s, got %+v", rule, id, report.Violations)
+		}
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "change_record_id") {
+		t.Fatalf("expected actionable error, got %v", err)
+	}
+
+	approved := store.EvaluateGuardrails("prod", cfg, true)
+	if len(approved.Violations) != 3 {
+		t.Fatalf("approved change record should only clear approval violations: %+v", approved)
+	}
+	if open := store.EvaluateGuardrails("dev", cfg, false); !open.Allowed {
+		t.Fatalf("environments without guardrails must allow everything: %+v", open)
+	}
+
+	reloaded := NewRoleEnvironmentStore(baseDir)
+	if env, err := reloaded.GetEnvironment("prod"); err != nil || env.Guardrails == nil || env.Guardrails.DeniedResourceTypes[0] != "registry" {
+		t.Fatalf("expected guardrails to persist: %+v err=%v", env, err)
+	}
+}
+
+type recordingJobExecutor struct {
+	jobs []Job
+}
+
+func (e *recordingJobExecutor) ApplyPath(string) error { return errors.New("unexpected ApplyPath") }
+
+func (e *recordingJobExecutor) ApplyJob(job Job) error {
+	e.jobs = append(e.jobs, job)
+	return nil
+}
+
+func TestGuardrailExecutorRechecksAtApply(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfgPath, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: restart
+    type: command
+    host: localhost
+    command: "true"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewRoleEnvironmentStore(tmp)
+	if _, err := store.UpsertEnvironment(EnvironmentDefinition{Name: "prod", Guardrails: &EnvironmentGuardrails{ApprovalResourceTypes: []string{"command"}}}); err != nil {
+		t.Fatal(err)
+	}
+	inner := &recordingJobExecutor{}
+	exec := NewGuardrailExecutor(inner, store, func(id string) bool { return id == "chg-1" })
+	var rejected []GuardrailReport
+	exec.SetViolationHandler(func(_ Job, report GuardrailReport) { rejected = append(rejected, report) })
+
+	if err := exec.ApplyJob(Job{ID: "job-1", Environment: "prod", ConfigPath: cfgPath}); err == nil {
+		t.Fatalf("expected unapproved command job to be rejected")
+	}
+	if err := exec.ApplyJob(Job{ID: "job-2", Environment: "prod", ConfigPath: cfgPath, ChangeRecordID: "chg-1"}); err != nil {
+		t.Fatalf("expected approved job to run: %v", err)
+	}
+	if err := exec.ApplyJob(Job{ID: "job-3", ConfigPath: cfgPath}); err != nil {
+		t.Fatalf("expected job without environment to run: %v", err)
+	}
+	if len(rejected) != 1 || len(inner.jobs) != 2 || inner.jobs[0].ID != "job-2" {
+		t.Fatalf("unexpected executor activity: rejected=%d jobs=%+v", len(rejected), inner.jobs)
+	}
+}
@@ -31,8 +31,10 @@ type RolloutPolicy struct {
 }
 
 type RolloutPlanInput struct {
-	Environment string   `json:"environment"`
-	Targets     []string `json:"targets"`
+	Environment      string   `json:"environment"`
+	Targets          []string `json:"targets"`
+	CanaryAnalysisID string   `json:"canary_analysis_id,omitempty"`
+	Service          string   `json:"service,omitempty"`
 }
 
 type RolloutWave struct {
@@ -43,13 +45,17 @@ type RolloutWave struct {
 }
 
 type RolloutPlan struct {
-	Allowed       bool          `json:"allowed"`
-	Environment   string        `json:"environment"`
-	PolicyID      string        `json:"policy_id,omitempty"`
-	Strategy      string        `json:"strategy"`
-	Mode          string        `json:"mode"`
-	Waves         []RolloutWave `json:"waves,omitempty"`
-	BlockedReason string        `json:"blocked_reason,omitempty"`
+	Allowed          bool          `json:"allowed"`
+	Environment      string        `json:"environment"`
+	PolicyID         string        `json:"policy_id,omitempty"`
+	Strategy         string        `json:"strategy"`
+	Mode             string        `json:"mode"`
+	Waves            []RolloutWave `json:"waves,omitempty"`
+	BlockedReason    string        `json:"blocked_reason,omitempty"`
+	CanaryAnalysisID string        `json:"canary_analysis_id,omitempty"`
+	CanaryVerdict    string        `json:"canary_verdict,omitempty"`
+	CanaryScore      int           `json:"canary_score,omitempty"`
+	DisruptionClaim  string        `json:"disruption_claim_id,omitempty"`
 }
 
 type RolloutControlStore struct {
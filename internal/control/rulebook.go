@@ -37,6 +37,7 @@ type Rule struct {
 	CooldownSeconds int             `json:"cooldown_seconds,omitempty"`
 	LastTriggeredAt time.Time       `json:"last_triggered_at,omitempty"`
 	TriggerCount    int64           `json:"trigger_count"`
+	Version         int64           `json:"version"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
 }
@@ -59,44 +60,80 @@ func NewRuleEngine() *RuleEngine {
 }
 
 func (r *RuleEngine) Create(in Rule) (Rule, error) {
+	if err := validateRule(&in); err != nil {
+		return Rule{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	now := time.Now().UTC()
+	in.ID = "rule-" + itoa(r.nextID)
+	in.SourcePrefix = strings.TrimSpace(in.SourcePrefix)
+	if !in.Enabled {
+		in.Enabled = true
+	}
+	in.Version = 1
+	in.CreatedAt = now
+	in.UpdatedAt = now
+	cp := cloneRule(in)
+	r.rules[in.ID] = &cp
+	return cp, nil
+}
+
+// Update replaces the definition of a rule, keeping its trigger history,
+// and bumps its version. A non-zero expectedVersion must match the stored
+// version.
+func (r *RuleEngine) Update(id string, in Rule, expectedVersion int64) (Rule, error) {
+	if err := validateRule(&in); err != nil {
+		return Rule{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.rules[id]
+	if !ok {
+		return Rule{}, errors.New("rule not found")
+	}
+	if err := checkVersion(VersionedKindRule, id, cur.Version, expectedVersion); err != nil {
+		return Rule{}, err
+	}
+	in.ID = cur.ID
+	in.SourcePrefix = strings.TrimSpace(in.SourcePrefix)
+	in.LastTriggeredAt = cur.LastTriggeredAt
+	in.TriggerCount = cur.TriggerCount
+	in.Version = cur.Version + 1
+	in.CreatedAt = cur.CreatedAt
+	in.UpdatedAt = time.Now().UTC()
+	cp := cloneRule(in)
+	r.rules[id] = &cp
+	return cloneRule(cp), nil
+}
+
+func validateRule(in *Rule) error {
 	if strings.TrimSpace(in.Name) == "" {
-		return Rule{}, errors.New("rule name is required")
+		return errors.New("rule name is required")
 	}
 	if strings.TrimSpace(in.SourcePrefix) == "" {
-		return Rule{}, errors.New("source_prefix is required")
+		return errors.New("source_prefix is required")
 	}
 	if len(in.Actions) == 0 {
-		return Rule{}, errors.New("at least one action is required")
+		return errors.New("at least one action is required")
 	}
 	in.MatchMode = normalizeMatchMode(in.MatchMode)
 	for i := range in.Actions {
 		if err := validateRuleAction(&in.Actions[i]); err != nil {
-			return Rule{}, err
+			return err
 		}
 	}
 	for i := range in.Conditions {
 		if err := validateRuleCondition(&in.Conditions[i]); err != nil {
-			return Rule{}, err
+			return err
 		}
 	}
 	if in.CooldownSeconds < 0 {
 		in.CooldownSeconds = 0
 	}
-
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.nextID++
-	now := time.Now().UTC()
-	in.ID = "rule-" + itoa(r.nextID)
-	in.SourcePrefix = strings.TrimSpace(in.SourcePrefix)
-	if !in.Enabled {
-		in.Enabled = true
-	}
-	in.CreatedAt = now
-	in.UpdatedAt = now
-	cp := cloneRule(in)
-	r.rules[in.ID] = &cp
-	return cp, nil
+	return nil
 }
 
 func (r *RuleEngine) List() []Rule {
@@ -121,17 +158,38 @@ func (r *RuleEngine) Get(id string) (Rule, error) {
 }
 
 func (r *RuleEngine) SetEnabled(id string, enabled bool) (Rule, error) {
+	return r.SetEnabledVersion(id, enabled, 0)
+}
+
+// SetEnabledVersion toggles a rule and bumps its version. A non-zero
+// expectedVersion must match the stored version.
+func (r *RuleEngine) SetEnabledVersion(id string, enabled bool, expectedVersion int64) (Rule, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	rule, ok := r.rules[id]
 	if !ok {
 		return Rule{}, errors.New("rule not found")
 	}
+	if err := checkVersion(VersionedKindRule, id, rule.Version, expectedVersion); err != nil {
+		return Rule{}, err
+	}
 	rule.Enabled = enabled
+	rule.Version++
 	rule.UpdatedAt = time.Now().UTC()
 	return cloneRule(*rule), nil
 }
 
+func (r *RuleEngine) Delete(id string) (Rule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rule, ok := r.rules[id]
+	if !ok {
+		return Rule{}, errors.New("rule not found")
+	}
+	delete(r.rules, id)
+	return cloneRule(*rule), nil
+}
+
 func (r *RuleEngine) Evaluate(event Event) ([]RuleMatch, error) {
 	ruleIDs := make([]string, 0)
 	r.mu.RLock()
@@ -190,6 +248,53 @@ func (r *RuleEngine) Evaluate(event Event) ([]RuleMatch, error) {
 	return matches, nil
 }
 
+// Preview reports the rules an event would match without recording a
+// trigger or honouring cooldowns. An empty ruleIDs checks every rule;
+// disabled rules are only considered when includeDisabled is set, so a new
+// rule can be tried against history before it is enabled.
+func (r *RuleEngine) Preview(event Event, ruleIDs []string, includeDisabled bool) ([]RuleMatch, error) {
+	eventMap, err := eventToMap(event)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	candidates := make([]Rule, 0, len(r.rules))
+	if len(ruleIDs) == 0 {
+		for _, rule := range r.rules {
+			candidates = append(candidates, cloneRule(*rule))
+		}
+	} else {
+		for _, id := range ruleIDs {
+			if rule, ok := r.rules[strings.TrimSpace(id)]; ok {
+				candidates = append(candidates, cloneRule(*rule))
+			}
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	matches := make([]RuleMatch, 0)
+	for i := range candidates {
+		rule := &candidates[i]
+		if (!rule.Enabled && !includeDisabled) || !strings.HasPrefix(event.Type, rule.SourcePrefix) {
+			continue
+		}
+		matched, err := ruleMatchesEvent(rule, eventMap)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, RuleMatch{
+				RuleID:   rule.ID,
+				RuleName: rule.Name,
+				Event:    event,
+				Actions:  append([]RuleAction{}, rule.Actions...),
+			})
+		}
+	}
+	return matches, nil
+}
+
 func ruleMatchesEvent(rule *Rule, event map[string]any) (bool, error) {
 	if rule == nil {
 		return false, nil
@@ -342,6 +447,47 @@ func getPathValue(root map[string]any, path string) (any, bool) {
 	return cur, true
 }
 
+// Restore inserts a rule with its existing ID and enabled state.
+func (r *RuleEngine) Restore(in Rule) (Rule, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		return Rule{}, errors.New("rule id is required")
+	}
+	if err := ValidateConfigAsCodeID(in.ID); err != nil {
+		return Rule{}, errors.New("rule " + err.Error())
+	}
+	if len(in.Actions) == 0 {
+		return Rule{}, errors.New("at least one action is required")
+	}
+	in.MatchMode = normalizeMatchMode(in.MatchMode)
+	for i := range in.Actions {
+		if err := validateRuleAction(&in.Actions[i]); err != nil {
+			return Rule{}, err
+		}
+	}
+	for i := range in.Conditions {
+		if err := validateRuleCondition(&in.Conditions[i]); err != nil {
+			return Rule{}, err
+		}
+	}
+	now := time.Now().UTC()
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = now
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = in.CreatedAt
+	}
+	if in.Version <= 0 {
+		in.Version = 1
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID = advanceRestoredID(r.nextID, in.ID, "rule-")
+	cp := cloneRule(in)
+	r.rules[in.ID] = &cp
+	return cp, nil
+}
+
 func cloneRule(in Rule) Rule {
 	out := in
 	out.Conditions = append([]RuleCondition{}, in.Conditions...)
@@ -95,3 +95,34 @@ func TestRuleEngine_CreateValidation(t *testing.T) {
 		t.Fatalf("expected action validation error")
 	}
 }
+
+func TestRuleEngine_PreviewLeavesTriggerStateAlone(t *testing.T) {
+	eng := NewRuleEngine()
+	rule, err := eng.Create(Rule{
+		Name:            "draft",
+		SourcePrefix:    "external.alert",
+		Conditions:      []RuleCondition{{Field: "fields.sev", Comparator: "eq", Value: "high"}},
+		Actions:         []RuleAction{{Type: "enqueue_apply", ConfigPath: "cfg.yaml"}},
+		CooldownSeconds: 3600,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.SetEnabled(rule.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	evt := Event{Type: "external.alert.cpu", Fields: map[string]any{"sev": "high"}}
+	if matches, _ := eng.Preview(evt, nil, false); len(matches) != 0 {
+		t.Fatalf("expected disabled rule skipped by default")
+	}
+	for i := 0; i < 2; i++ {
+		matches, err := eng.Preview(evt, []string{rule.ID}, true)
+		if err != nil || len(matches) != 1 || matches[0].RuleID != rule.ID {
+			t.Fatalf("expected disabled rule previewed despite cooldown, got %+v err=%v", matches, err)
+		}
+	}
+	cur, _ := eng.Get(rule.ID)
+	if cur.TriggerCount != 0 || !cur.LastTriggeredAt.IsZero() {
+		t.Fatalf("expected preview not to record triggers: %+v", cur)
+	}
+}
@@ -0,0 +1,340 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RunDigestScheduleInput struct {
+	Name        string `json:"name"`
+	Period      string `json:"period"` // daily|weekly
+	Environment string `json:"environment,omitempty"`
+	Team        string `json:"team,omitempty"`
+	Route       string `json:"route,omitempty"`
+	Prefix      string `json:"prefix,omitempty"`
+}
+
+// RunDigestSchedule builds a run digest every period for the runs labelled
+// with Environment and Team (either may be empty to take every run),
+// archives it to the object store under Prefix, and sends it through the
+// notification router on Route.
+type RunDigestSchedule struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Period      string    `json:"period"`
+	Environment string    `json:"environment,omitempty"`
+	Team        string    `json:"team,omitempty"`
+	Route       string    `json:"route"`
+	Prefix      string    `json:"prefix"`
+	Enabled     bool      `json:"enabled"`
+	Runs        int       `json:"runs"`
+	LastRunAt   time.Time `json:"last_run_at,omitempty"`
+	LastStatus  string    `json:"last_status,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextRunAt   time.Time `json:"next_run_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RunDigestTotals summarizes the runs of one digest window.
+type RunDigestTotals struct {
+	TotalRuns        int     `json:"total_runs"`
+	SucceededRuns    int     `json:"succeeded_runs"`
+	FailedRuns       int     `json:"failed_runs"`
+	FailRate         float64 `json:"fail_rate"`
+	ChangedResources int     `json:"changed_resources"`
+}
+
+// RunDigestDelta compares one metric with the previous period. Worse is set
+// when the metric moved in the direction an operator should look at.
+type RunDigestDelta struct {
+	Metric    string  `json:"metric"`
+	Previous  float64 `json:"previous"`
+	Current   float64 `json:"current"`
+	Change    float64 `json:"change"`
+	Direction string  `json:"direction"` // up|down|flat
+	Worse     bool    `json:"worse,omitempty"`
+}
+
+type RunDigestReport struct {
+	ID             string           `json:"id"`
+	ScheduleID     string           `json:"schedule_id,omitempty"`
+	Period         string           `json:"period"`
+	Environment    string           `json:"environment,omitempty"`
+	Team           string           `json:"team,omitempty"`
+	WindowStart    time.Time        `json:"window_start"`
+	WindowEnd      time.Time        `json:"window_end"`
+	Current        RunDigestTotals  `json:"current"`
+	Previous       RunDigestTotals  `json:"previous"`
+	Deltas         []RunDigestDelta `json:"deltas"`
+	Highlights     []string         `json:"highlights,omitempty"`
+	RecentFailures []string         `json:"recent_failures,omitempty"`
+	ObjectKey      string           `json:"object_key,omitempty"`
+	ArchiveError   string           `json:"archive_error,omitempty"`
+	Delivered      int              `json:"delivered"`
+	Suppressed     int              `json:"suppressed,omitempty"`
+	DeliveryFailed int              `json:"delivery_failed"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+type RunDigestScheduleStore struct {
+	mu           sync.Mutex
+	nextSchedule int64
+	nextReport   int64
+	schedules    map[string]*RunDigestSchedule
+	reports      []RunDigestReport
+	reportCap    int
+}
+
+func NewRunDigestScheduleStore(reportCap int) *RunDigestScheduleStore {
+	if reportCap <= 0 {
+		reportCap = 500
+	}
+	return &RunDigestScheduleStore{schedules: map[string]*RunDigestSchedule{}, reportCap: reportCap}
+}
+
+func (s *RunDigestScheduleStore) Create(in RunDigestScheduleInput) (RunDigestSchedule, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return RunDigestSchedule{}, errors.New("name is required")
+	}
+	period := strings.ToLower(strings.TrimSpace(in.Period))
+	if period == "" {
+		period = "daily"
+	}
+	if RunDigestPeriod(period) == 0 {
+		return RunDigestSchedule{}, errors.New("period must be daily or weekly")
+	}
+	route := "digest"
+	if strings.TrimSpace(in.Route) != "" {
+		route = normalizeNotificationRoute(in.Route)
+		if route == "" || route == "*" {
+			return RunDigestSchedule{}, errors.New("route must be digest, chatops, ticket, pager, or security")
+		}
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSchedule++
+	item := &RunDigestSchedule{
+		ID:          "digest-schedule-" + itoa(s.nextSchedule),
+		Name:        name,
+		Period:      period,
+		Environment: strings.TrimSpace(in.Environment),
+		Team:        strings.TrimSpace(in.Team),
+		Route:       route,
+		Prefix:      strings.Trim(strings.TrimSpace(in.Prefix), "/"),
+		Enabled:     true,
+		NextRunAt:   now.Add(RunDigestPeriod(period)),
+		CreatedAt:   now,
+	}
+	if item.Prefix == "" {
+		item.Prefix = "digests/" + item.ID
+	}
+	s.schedules[item.ID] = item
+	return *item, nil
+}
+
+func (s *RunDigestScheduleStore) Get(id string) (RunDigestSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.schedules[strings.TrimSpace(id)]
+	if !ok {
+		return RunDigestSchedule{}, errors.New("digest schedule not found")
+	}
+	return *item, nil
+}
+
+func (s *RunDigestScheduleStore) List() []RunDigestSchedule {
+	s.mu.Lock()
+	out := make([]RunDigestSchedule, 0, len(s.schedules))
+	for _, item := range s.schedules {
+		out = append(out, *item)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *RunDigestScheduleStore) SetEnabled(id string, enabled bool) (RunDigestSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.schedules[strings.TrimSpace(id)]
+	if !ok {
+		return RunDigestSchedule{}, errors.New("digest schedule not found")
+	}
+	item.Enabled = enabled
+	if enabled && item.NextRunAt.Before(time.Now().UTC()) {
+		item.NextRunAt = time.Now().UTC()
+	}
+	return *item, nil
+}
+
+func (s *RunDigestScheduleStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schedules[strings.TrimSpace(id)]; !ok {
+		return errors.New("digest schedule not found")
+	}
+	delete(s.schedules, strings.TrimSpace(id))
+	return nil
+}
+
+// Due returns enabled schedules whose next digest has passed and advances
+// them by one period.
+func (s *RunDigestScheduleStore) Due(now time.Time) []RunDigestSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RunDigestSchedule, 0)
+	for _, item := range s.schedules {
+		if !item.Enabled || item.NextRunAt.After(now) {
+			continue
+		}
+		item.NextRunAt = now.Add(RunDigestPeriod(item.Period))
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// RecordRun notes the outcome of one scheduled digest.
+func (s *RunDigestScheduleStore) RecordRun(id string, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.schedules[strings.TrimSpace(id)]
+	if !ok {
+		return errors.New("digest schedule not found")
+	}
+	item.LastRunAt = time.Now().UTC()
+	if runErr != nil {
+		item.LastStatus = "failed"
+		item.LastError = runErr.Error()
+		return nil
+	}
+	item.Runs++
+	item.LastStatus = "succeeded"
+	item.LastError = ""
+	return nil
+}
+
+func (s *RunDigestScheduleStore) AddReport(report RunDigestReport) RunDigestReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextReport++
+	report.ID = "run-digest-" + itoa(s.nextReport)
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = time.Now().UTC()
+	}
+	s.reports = append(s.reports, cloneRunDigestReport(report))
+	if len(s.reports) > s.reportCap {
+		s.reports = append([]RunDigestReport{}, s.reports[len(s.reports)-s.reportCap:]...)
+	}
+	return cloneRunDigestReport(report)
+}
+
+// UpdateReport stores the archive and delivery outcome of a report.
+func (s *RunDigestScheduleStore) UpdateReport(report RunDigestReport) (RunDigestReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.reports {
+		if s.reports[i].ID == report.ID {
+			s.reports[i] = cloneRunDigestReport(report)
+			return cloneRunDigestReport(report), nil
+		}
+	}
+	return RunDigestReport{}, errors.New("digest report not found")
+}
+
+func (s *RunDigestScheduleStore) GetReport(id string) (RunDigestReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.reports {
+		if s.reports[i].ID == strings.TrimSpace(id) {
+			return cloneRunDigestReport(s.reports[i]), nil
+		}
+	}
+	return RunDigestReport{}, errors.New("digest report not found")
+}
+
+// Reports lists digest reports newest first, optionally for one schedule.
+func (s *RunDigestScheduleStore) Reports(scheduleID string, limit int) []RunDigestReport {
+	scheduleID = strings.TrimSpace(scheduleID)
+	if limit <= 0 {
+		limit = 100
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RunDigestReport, 0)
+	for i := len(s.reports) - 1; i >= 0 && len(out) < limit; i-- {
+		if scheduleID == "" || s.reports[i].ScheduleID == scheduleID {
+			out = append(out, cloneRunDigestReport(s.reports[i]))
+		}
+	}
+	return out
+}
+
+// RunDigestPeriod is the window length of a digest period, or zero for an
+// unknown period.
+func RunDigestPeriod(period string) time.Duration {
+	switch period {
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// CompareRunDigestTotals compares current with previous and returns the
+// per-metric deltas plus a highlight line for every metric that got worse.
+func CompareRunDigestTotals(previous, current RunDigestTotals) ([]RunDigestDelta, []string) {
+	metrics := []struct {
+		name      string
+		prev, cur float64
+		worseUp   bool
+		worseDown bool
+	}{
+		{name: "total_runs", prev: float64(previous.TotalRuns), cur: float64(current.TotalRuns)},
+		{name: "succeeded_runs", prev: float64(previous.SucceededRuns), cur: float64(current.SucceededRuns), worseDown: true},
+		{name: "failed_runs", prev: float64(previous.FailedRuns), cur: float64(current.FailedRuns), worseUp: true},
+		{name: "fail_rate", prev: previous.FailRate, cur: current.FailRate, worseUp: true},
+		{name: "changed_resources", prev: float64(previous.ChangedResources), cur: float64(current.ChangedResources)},
+	}
+	deltas := make([]RunDigestDelta, 0, len(metrics))
+	highlights := make([]string, 0)
+	for _, m := range metrics {
+		d := RunDigestDelta{Metric: m.name, Previous: m.prev, Current: m.cur, Change: m.cur - m.prev, Direction: "flat"}
+		switch {
+		case d.Change > 0:
+			d.Direction = "up"
+			d.Worse = m.worseUp
+		case d.Change < 0:
+			d.Direction = "down"
+			d.Worse = m.worseDown
+		}
+		if d.Worse {
+			highlights = append(highlights, m.name+" "+d.Direction+" from "+formatDigestValue(m.name, m.prev)+" to "+formatDigestValue(m.name, m.cur))
+		}
+		deltas = append(deltas, d)
+	}
+	return deltas, highlights
+}
+
+func formatDigestValue(metric string, v float64) string {
+	if metric == "fail_rate" {
+		return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func cloneRunDigestReport(in RunDigestReport) RunDigestReport {
+	out := in
+	out.Deltas = append([]RunDigestDelta(nil), in.Deltas...)
+	out.Highlights = append([]string(nil), in.Highlights...)
+	out.RecentFailures = append([]string(nil), in.RecentFailures...)
+	return out
+}
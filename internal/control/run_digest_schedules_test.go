@@ -0,0 +1,91 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunDigestScheduleStoreDueAndReports(t *testing.T) {
+	store := NewRunDigestScheduleStore(2)
+	if _, err := store.Create(RunDigestScheduleInput{Name: "prod", Period: "monthly"}); err == nil {
+		t.Fatalf("expected unknown period to be rejected")
+	}
+	if _, err := store.Create(RunDigestScheduleInput{Name: "prod", Route: "*"}); err == nil {
+		t.Fatalf("expected wildcard route to be rejected")
+	}
+	daily, err := store.Create(RunDigestScheduleInput{Name: "prod", Environment: "prod"})
+	if err != nil {
+		t.Fatalf("create schedule failed: %v", err)
+	}
+	if daily.Period != "daily" || daily.Route != "digest" || daily.Prefix != "digests/"+daily.ID {
+		t.Fatalf("expected daily digest defaults, got %+v", daily)
+	}
+	weekly, err := store.Create(RunDigestScheduleInput{Name: "payments", Period: "Weekly", Team: "payments", Route: "chatops"})
+	if err != nil {
+		t.Fatalf("create weekly schedule failed: %v", err)
+	}
+
+	if due := store.Due(time.Now().UTC()); len(due) != 0 {
+		t.Fatalf("expected no digest due before the first period ends, got %+v", due)
+	}
+	later := time.Now().UTC().Add(25 * time.Hour)
+	due := store.Due(later)
+	if len(due) != 1 || due[0].ID != daily.ID {
+		t.Fatalf("expected only the daily digest due, got %+v", due)
+	}
+	if again := store.Due(later); len(again) != 0 {
+		t.Fatalf("expected a due digest to be advanced a period, got %+v", again)
+	}
+	if _, err := store.SetEnabled(weekly.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if due := store.Due(later.Add(7 * 24 * time.Hour)); len(due) != 1 || due[0].ID != daily.ID {
+		t.Fatalf("expected disabled weekly digest to be skipped, got %+v", due)
+	}
+
+	for i := 0; i < 3; i++ {
+		store.AddReport(RunDigestReport{ScheduleID: daily.ID, Period: "daily"})
+	}
+	reports := store.Reports(daily.ID, 10)
+	if len(reports) != 2 || reports[0].ID != "run-digest-3" {
+		t.Fatalf("expected the two newest reports, got %+v", reports)
+	}
+	reports[0].Delivered = 2
+	if _, err := store.UpdateReport(reports[0]); err != nil {
+		t.Fatalf("update report failed: %v", err)
+	}
+	if got, _ := store.GetReport("run-digest-3"); got.Delivered != 2 {
+		t.Fatalf("expected delivery count to be stored, got %+v", got)
+	}
+	if _, err := store.GetReport("run-digest-1"); err == nil {
+		t.Fatalf("expected the oldest report to be trimmed")
+	}
+}
+
+func TestCompareRunDigestTotalsHighlightsRegressions(t *testing.T) {
+	previous := RunDigestTotals{TotalRuns: 4, SucceededRuns: 4}
+	current := RunDigestTotals{TotalRuns: 4, SucceededRuns: 2, FailedRuns: 2, FailRate: 0.5, ChangedResources: 3}
+	deltas, highlights := CompareRunDigestTotals(previous, current)
+	byMetric := map[string]RunDigestDelta{}
+	for _, d := range deltas {
+		byMetric[d.Metric] = d
+	}
+	if byMetric["total_runs"].Direction != "flat" || byMetric["changed_resources"].Worse {
+		t.Fatalf("unexpected deltas %+v", deltas)
+	}
+	if !byMetric["failed_runs"].Worse || !byMetric["succeeded_runs"].Worse || byMetric["succeeded_runs"].Change != -2 {
+		t.Fatalf("expected failures up and successes down to be flagged, got %+v", deltas)
+	}
+	want := []string{"succeeded_runs down from 4 to 2", "failed_runs up from 0 to 2", "fail_rate up from 0.0% to 50.0%"}
+	if len(highlights) != len(want) {
+		t.Fatalf("expected highlights %v, got %v", want, highlights)
+	}
+	for i := range want {
+		if highlights[i] != want[i] {
+			t.Fatalf("expected highlights %v, got %v", want, highlights)
+		}
+	}
+	if _, none := CompareRunDigestTotals(current, current); len(none) != 0 {
+		t.Fatalf("expected no highlights for an unchanged period, got %v", none)
+	}
+}
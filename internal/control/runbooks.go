@@ -25,18 +25,22 @@ const (
 )
 
 type Runbook struct {
-	ID          string            `json:"id"`
-	Name        string            `json:"name"`
-	Description string            `json:"description,omitempty"`
-	TargetType  RunbookTargetType `json:"target_type"`
-	TargetID    string            `json:"target_id,omitempty"`
-	ConfigPath  string            `json:"config_path,omitempty"`
-	RiskLevel   string            `json:"risk_level,omitempty"` // low|medium|high
-	Owner       string            `json:"owner,omitempty"`
-	Tags        []string          `json:"tags,omitempty"`
-	Status      RunbookStatus     `json:"status"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	TargetType     RunbookTargetType `json:"target_type"`
+	TargetID       string            `json:"target_id,omitempty"`
+	ConfigPath     string            `json:"config_path,omitempty"`
+	RiskLevel      string            `json:"risk_level,omitempty"` // low|medium|high
+	Owner          string            `json:"owner,omitempty"`
+	Team           string            `json:"team,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Evidence       []string          `json:"evidence,omitempty"`
+	Status         RunbookStatus     `json:"status"`
+	LaunchCount    int64             `json:"launch_count"`
+	LastLaunchedAt time.Time         `json:"last_launched_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
 type RunbookStore struct {
@@ -179,6 +183,19 @@ func (s *RunbookStore) Deprecate(id string) (Runbook, error) {
 	return cloneRunbook(*rb), nil
 }
 
+// RecordLaunch counts a launch of the runbook for stewardship reporting.
+func (s *RunbookStore) RecordLaunch(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rb, ok := s.runbooks[strings.TrimSpace(id)]
+	if !ok {
+		return errors.New("runbook not found")
+	}
+	rb.LaunchCount++
+	rb.LastLaunchedAt = time.Now().UTC()
+	return nil
+}
+
 func normalizeRunbookTargetType(raw string) string {
 	switch strings.ToLower(strings.TrimSpace(raw)) {
 	case "template":
@@ -232,9 +249,40 @@ func normalizeTags(in []string) []string {
 	return out
 }
 
+// Restore inserts a runbook with its existing ID and status.
+func (s *RunbookStore) Restore(in Runbook) (Runbook, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		return Runbook{}, errors.New("runbook id is required")
+	}
+	if err := ValidateConfigAsCodeID(in.ID); err != nil {
+		return Runbook{}, errors.New("runbook " + err.Error())
+	}
+	if strings.TrimSpace(in.Name) == "" {
+		return Runbook{}, errors.New("runbook name is required")
+	}
+	if in.Status == "" {
+		in.Status = RunbookDraft
+	}
+	now := time.Now().UTC()
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = now
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = in.CreatedAt
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = advanceRestoredID(s.nextID, in.ID, "rb-")
+	cp := cloneRunbook(in)
+	s.runbooks[in.ID] = &cp
+	return cp, nil
+}
+
 func cloneRunbook(in Runbook) Runbook {
 	out := in
 	out.Tags = append([]string{}, in.Tags...)
+	out.Evidence = append([]string{}, in.Evidence...)
 	return out
 }
 
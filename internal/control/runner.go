@@ -7,32 +7,100 @@ import (
 	"github.com/masterchef/masterchef/internal/executor"
 	"github.com/masterchef/masterchef/internal/planner"
 	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
 )
 
 type Runner struct {
-	baseDir string
+	baseDir   string
+	exports   *ExportedResourceStore
+	artifacts storage.ObjectStore
+	facts     *FactCache
 }
 
 func NewRunner(baseDir string) *Runner {
-	return &Runner{baseDir: baseDir}
+	return &Runner{baseDir: baseDir, exports: NewExportedResourceStore(0)}
+}
+
+// SetExportedResources shares the store runs publish exports to and collect
+// them from. Call before the runner starts processing jobs.
+func (r *Runner) SetExportedResources(store *ExportedResourceStore) {
+	if store != nil {
+		r.exports = store
+	}
+}
+
+// SetArtifactStore enables upload of declared output artifacts to store after
+// each run. Call before the runner starts processing jobs.
+func (r *Runner) SetArtifactStore(store storage.ObjectStore) {
+	r.artifacts = store
+}
+
+// SetFactCache supplies observed host facts for fact-based when conditions.
+// Call before the runner starts processing jobs.
+func (r *Runner) SetFactCache(facts *FactCache) {
+	r.facts = facts
 }
 
 func (r *Runner) ApplyPath(configPath string) error {
+	return r.apply(r.baseDir, configPath, nil, r.artifacts)
+}
+
+// ApplyJob applies the job's config and labels the resulting run with the
+// job's labels. Jobs carrying a tenant and a "workspace" label run against
+// that workspace's partition, so their run record, session recordings, and
+// filebucket backups never land in shared paths.
+func (r *Runner) ApplyJob(job Job) error {
+	baseDir := r.baseDir
+	artifacts := r.artifacts
+	if ws := job.Labels["workspace"]; ws != "" && job.Tenant != "" {
+		dir, err := storage.WorkspaceBaseDir(r.baseDir, job.Tenant, ws)
+		if err != nil {
+			return fmt.Errorf("resolve workspace partition: %w", err)
+		}
+		baseDir = dir
+		if artifacts != nil {
+			prefix, err := storage.WorkspacePrefix(job.Tenant, ws)
+			if err != nil {
+				return fmt.Errorf("resolve workspace partition: %w", err)
+			}
+			if artifacts, err = storage.NewScopedObjectStore(artifacts, prefix); err != nil {
+				return fmt.Errorf("resolve workspace partition: %w", err)
+			}
+		}
+	}
+	return r.apply(baseDir, job.ConfigPath, job.Labels, artifacts)
+}
+
+func (r *Runner) apply(baseDir, configPath string, labels map[string]string, artifacts storage.ObjectStore) error {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
-	p, err := planner.Build(cfg)
+	if _, err := RealizeExportedResources(cfg, r.exports); err != nil {
+		return fmt.Errorf("realize exported resources: %w", err)
+	}
+	if err := config.Validate(cfg); err != nil {
+		return fmt.Errorf("validate collected resources: %w", err)
+	}
+	var observed map[string]map[string]string
+	if r.facts != nil {
+		observed = r.facts.PlanFacts()
+	}
+	p, err := planner.BuildWithFacts(cfg, observed)
 	if err != nil {
 		return fmt.Errorf("build plan: %w", err)
 	}
 
-	ex := executor.New(r.baseDir)
+	ex := executor.New(baseDir)
+	if artifacts != nil {
+		ex.SetArtifactStore(artifacts)
+	}
 	run, err := ex.Apply(p)
 	if err != nil {
 		return err
 	}
-	st := state.New(r.baseDir)
+	run.Labels = cloneLabels(labels)
+	st := state.New(baseDir)
 	if err := st.SaveRun(run); err != nil {
 		return err
 	}
@@ -3,7 +3,11 @@ package control
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
 )
 
 func TestRunner_ApplyPath(t *testing.T) {
@@ -35,3 +39,156 @@ resources:
 		t.Fatalf("expected out file: %v", err)
 	}
 }
+
+func TestRunner_ApplyPathRealizesExportedResources(t *testing.T) {
+	tmp := t.TempDir()
+	checkPath := filepath.Join(tmp, "check-web-01.cfg")
+	localPath := filepath.Join(tmp, "web-local.txt")
+	exporter := filepath.Join(tmp, "web.yaml")
+	collector := filepath.Join(tmp, "monitor.yaml")
+	if err := os.WriteFile(exporter, []byte(`version: v0
+inventory:
+  hosts:
+    - name: web-01
+      transport: local
+resources:
+  - id: local
+    type: file
+    host: web-01
+    path: `+localPath+`
+    content: "web"
+  - id: check-web-01
+    type: file
+    host: web-01
+    export: true
+    tags: [monitoring]
+    path: `+checkPath+`
+    content: "check_http web-01"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(collector, []byte(`version: v0
+inventory:
+  hosts:
+    - name: monitor
+      transport: local
+collect:
+  - host: monitor
+    selector: type=file and tag=monitoring
+resources: []
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewExportedResourceStore(100)
+	r := NewRunner(tmp)
+	r.SetExportedResources(store)
+	if err := r.ApplyPath(exporter); err != nil {
+		t.Fatalf("exporter apply failed: %v", err)
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		t.Fatalf("expected local resource applied: %v", err)
+	}
+	if _, err := os.Stat(checkPath); !os.IsNotExist(err) {
+		t.Fatalf("exported resource must not be applied on the exporting node")
+	}
+	if got := store.List(0); len(got) != 1 || got[0].Host != "web-01" || got[0].Source != ExportedRunSource {
+		t.Fatalf("expected one published export, got %#v", got)
+	}
+
+	if err := r.ApplyPath(collector); err != nil {
+		t.Fatalf("collector apply failed: %v", err)
+	}
+	body, err := os.ReadFile(checkPath)
+	if err != nil || string(body) != "check_http web-01" {
+		t.Fatalf("expected collected resource applied, got %q err=%v", body, err)
+	}
+
+	// Re-running the exporter replaces rather than duplicates its exports.
+	if err := r.ApplyPath(exporter); err != nil {
+		t.Fatalf("exporter re-apply failed: %v", err)
+	}
+	if got := store.List(0); len(got) != 1 {
+		t.Fatalf("expected exports replaced on re-run, got %d", len(got))
+	}
+}
+
+func TestRunner_ApplyJobWritesRunIntoWorkspacePartition(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "masterchef.yaml")
+	cfg := `version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: write-file
+    type: file
+    host: localhost
+    path: ` + filepath.Join(tmp, "out.txt") + `
+    content: "ok\n"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	r := NewRunner(tmp)
+	job := Job{ID: "job-1", Tenant: "Acme", ConfigPath: cfgPath, Labels: map[string]string{"workspace": "payments"}}
+	if err := r.ApplyJob(job); err != nil {
+		t.Fatalf("apply job failed: %v", err)
+	}
+	runs, err := os.ReadDir(filepath.Join(tmp, ".masterchef", "workspaces", "acme", "payments", ".masterchef", "runs"))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one run in the workspace partition, got %d (%v)", len(runs), err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".masterchef", "runs")); !os.IsNotExist(err) {
+		t.Fatalf("expected no shared run directory, got %v", err)
+	}
+
+	job.Labels["workspace"] = "../escape"
+	if err := r.ApplyJob(job); err == nil {
+		t.Fatalf("expected invalid workspace label to be rejected")
+	}
+}
+
+func TestRunner_ApplyJobUploadsArtifactsIntoWorkspacePartition(t *testing.T) {
+	tmp := t.TempDir()
+	cfgPath := filepath.Join(tmp, "masterchef.yaml")
+	outPath := filepath.Join(tmp, "out.txt")
+	cfg := `version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: write-file
+    type: file
+    host: localhost
+    path: ` + outPath + `
+    content: "ok\n"
+    artifacts:
+      - name: output
+        path: ` + outPath + `
+`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	store, err := storage.NewLocalFSStore(filepath.Join(tmp, "objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRunner(tmp)
+	r.SetArtifactStore(store)
+	if err := r.ApplyJob(Job{ID: "job-1", Tenant: "acme", ConfigPath: cfgPath, Labels: map[string]string{"workspace": "payments"}}); err != nil {
+		t.Fatalf("apply job failed: %v", err)
+	}
+	items, err := store.List("workspaces/acme/payments/runs/", 10)
+	if err != nil || len(items) != 1 || !strings.HasSuffix(items[0].Key, "/artifacts/localhost/write-file/output") {
+		t.Fatalf("expected artifact in the workspace partition, got %+v (%v)", items, err)
+	}
+	runs, err := state.New(filepath.Join(tmp, ".masterchef", "workspaces", "acme", "payments")).ListRuns(1)
+	if err != nil || len(runs) != 1 || len(runs[0].Artifacts) != 1 || runs[0].Artifacts[0].Status != "collected" {
+		t.Fatalf("expected collected artifact linked from run record, got %+v (%v)", runs, err)
+	}
+}
@@ -0,0 +1,394 @@
+package control
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	samlProtocolNS   = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlAssertionNS  = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	samlBearer       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	samlHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	samlEmailNameID  = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	samlDefaultSkew  = 2 * time.Minute
+	samlMaxRespBytes = 512 << 10
+)
+
+// SAMLProviderSettings configures a protocol=saml SSO provider. On such a
+// provider issuer_url is the IdP entity ID, client_id is the SP entity ID
+// (the audience), and redirect_url is the assertion consumer service URL.
+type SAMLProviderSettings struct {
+	SSOURL           string           `json:"sso_url"`
+	Certificate      string           `json:"certificate"`
+	EmailAttribute   string           `json:"email_attribute,omitempty"`
+	GroupsAttribute  string           `json:"groups_attribute,omitempty"`
+	RoleMappings     []SSORoleMapping `json:"role_mappings,omitempty"`
+	ClockSkewSeconds int              `json:"clock_skew_seconds,omitempty"`
+}
+
+// SSORoleMapping grants an RBAC role when the asserted groups include Group.
+type SSORoleMapping struct {
+	Group  string `json:"group"`
+	RoleID string `json:"role_id"`
+	Scope  string `json:"scope,omitempty"`
+}
+
+type SAMLLoginInput struct {
+	SAMLResponse string `json:"saml_response"`
+	RelayState   string `json:"relay_state"`
+}
+
+type samlAssertion struct {
+	ID         string
+	NameID     string
+	Attributes map[string][]string
+	ExpiresAt  time.Time
+}
+
+func normalizeSAMLSettings(in *SAMLProviderSettings) (*SAMLProviderSettings, error) {
+	if in == nil {
+		return nil, errors.New("saml settings are required for saml providers")
+	}
+	out := *in
+	out.SSOURL = strings.TrimSpace(out.SSOURL)
+	out.Certificate = strings.TrimSpace(out.Certificate)
+	if _, err := url.ParseRequestURI(out.SSOURL); err != nil || out.SSOURL == "" {
+		return nil, errors.New("saml sso_url must be valid")
+	}
+	if _, err := parseSAMLCertificate(out.Certificate); err != nil {
+		return nil, err
+	}
+	out.EmailAttribute = strings.TrimSpace(out.EmailAttribute)
+	if out.EmailAttribute == "" {
+		out.EmailAttribute = "email"
+	}
+	out.GroupsAttribute = strings.TrimSpace(out.GroupsAttribute)
+	if out.GroupsAttribute == "" {
+		out.GroupsAttribute = "groups"
+	}
+	if out.ClockSkewSeconds < 0 {
+		return nil, errors.New("saml clock_skew_seconds must not be negative")
+	}
+	out.RoleMappings = []SSORoleMapping{}
+	for _, m := range in.RoleMappings {
+		m.Group = strings.TrimSpace(m.Group)
+		m.RoleID = strings.TrimSpace(m.RoleID)
+		m.Scope = strings.TrimSpace(m.Scope)
+		if m.Group == "" || m.RoleID == "" {
+			return nil, errors.New("saml role mappings need group and role_id")
+		}
+		if m.Scope == "" {
+			m.Scope = "*"
+		}
+		out.RoleMappings = append(out.RoleMappings, m)
+	}
+	return &out, nil
+}
+
+// parseSAMLCertificate accepts a PEM certificate or the bare base64 DER
+// found in IdP metadata.
+func parseSAMLCertificate(raw string) (*x509.Certificate, error) {
+	der := []byte(nil)
+	if block, _ := pem.Decode([]byte(raw)); block != nil {
+		der = block.Bytes
+	} else if decoded, err := base64.StdEncoding.DecodeString(compactBase64(raw)); err == nil {
+		der = decoded
+	}
+	if len(der) == 0 {
+		return nil, errors.New("saml certificate must be a PEM or base64 x509 certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.New("saml certificate must be a PEM or base64 x509 certificate")
+	}
+	return cert, nil
+}
+
+// samlRedirectURL builds the HTTP-Redirect binding URL carrying a deflated
+// AuthnRequest, with the login state as RelayState.
+func samlRedirectURL(provider SSOProvider, requestID, relayState string, now time.Time) (string, error) {
+	var req bytes.Buffer
+	req.WriteString(`<samlp:AuthnRequest xmlns:samlp="` + samlProtocolNS + `" xmlns:saml="` + samlAssertionNS + `"`)
+	req.WriteString(` ID="` + xmlEscapeString(requestID) + `" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `"`)
+	req.WriteString(` Destination="` + xmlEscapeString(provider.SAML.SSOURL) + `" AssertionConsumerServiceURL="` + xmlEscapeString(provider.RedirectURL) + `"`)
+	req.WriteString(` ProtocolBinding="` + samlHTTPPost + `">`)
+	req.WriteString(`<saml:Issuer>` + xmlEscapeString(provider.ClientID) + `</saml:Issuer>`)
+	req.WriteString(`<samlp:NameIDPolicy Format="` + samlEmailNameID + `" AllowCreate="true"/>`)
+	req.WriteString(`</samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	fw, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(req.Bytes()); err != nil {
+		return "", err
+	}
+	if err := fw.Close(); err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	q.Set("RelayState", relayState)
+	sep := "?"
+	if strings.Contains(provider.SAML.SSOURL, "?") {
+		sep = "&"
+	}
+	return provider.SAML.SSOURL + sep + q.Encode(), nil
+}
+
+// validateSAMLResponse verifies a base64 SAML Response for provider and
+// returns its single assertion. Either the Response or the Assertion must
+// carry a valid signature from the configured IdP certificate, and values
+// are only read from the signed element to defeat signature wrapping.
+func validateSAMLResponse(provider SSOProvider, requestID, encoded string, now time.Time) (samlAssertion, error) {
+	raw, err := base64.StdEncoding.DecodeString(compactBase64(encoded))
+	if err != nil {
+		return samlAssertion{}, errors.New("saml response is not base64")
+	}
+	if len(raw) > samlMaxRespBytes {
+		return samlAssertion{}, errors.New("saml response is too large")
+	}
+	cert, err := parseSAMLCertificate(provider.SAML.Certificate)
+	if err != nil {
+		return samlAssertion{}, err
+	}
+	root, err := parseXMLNode(raw)
+	if err != nil {
+		return samlAssertion{}, errors.New("saml response is not valid xml")
+	}
+	if root.Local != "Response" || root.namespace() != samlProtocolNS {
+		return samlAssertion{}, errors.New("saml document is not a Response")
+	}
+	if dest := root.attr("Destination"); dest != "" && dest != provider.RedirectURL {
+		return samlAssertion{}, errors.New("saml response destination does not match the assertion consumer service")
+	}
+	if root.attr("InResponseTo") != requestID {
+		return samlAssertion{}, errors.New("saml response does not answer this login request")
+	}
+	status := root.child(samlProtocolNS, "Status")
+	if status == nil || status.child(samlProtocolNS, "StatusCode") == nil || status.child(samlProtocolNS, "StatusCode").attr("Value") != samlSuccess {
+		return samlAssertion{}, errors.New("saml response status is not success")
+	}
+	responseSigned := false
+	if root.child(xmlDSigNS, "Signature") != nil {
+		if err := verifyEnvelopedSignature(root, cert); err != nil {
+			return samlAssertion{}, errors.New("saml response " + err.Error())
+		}
+		responseSigned = true
+	}
+	if len(root.childrenNamed(samlAssertionNS, "EncryptedAssertion")) > 0 {
+		return samlAssertion{}, errors.New("encrypted saml assertions are not supported")
+	}
+	assertions := root.childrenNamed(samlAssertionNS, "Assertion")
+	if len(assertions) != 1 {
+		return samlAssertion{}, errors.New("saml response must contain exactly one assertion")
+	}
+	a := assertions[0]
+	if a.child(xmlDSigNS, "Signature") != nil {
+		if err := verifyEnvelopedSignature(a, cert); err != nil {
+			return samlAssertion{}, errors.New("saml assertion " + err.Error())
+		}
+	} else if !responseSigned {
+		return samlAssertion{}, errors.New("saml assertion is not signed")
+	}
+
+	skew := samlDefaultSkew
+	if provider.SAML.ClockSkewSeconds > 0 {
+		skew = time.Duration(provider.SAML.ClockSkewSeconds) * time.Second
+	}
+	if issuer := a.child(samlAssertionNS, "Issuer"); issuer == nil || issuer.text() != provider.IssuerURL {
+		return samlAssertion{}, errors.New("saml assertion issuer does not match the provider")
+	}
+	subject := a.child(samlAssertionNS, "Subject")
+	if subject == nil || subject.child(samlAssertionNS, "NameID") == nil {
+		return samlAssertion{}, errors.New("saml assertion has no subject")
+	}
+	out := samlAssertion{
+		ID:         a.attr("ID"),
+		NameID:     subject.child(samlAssertionNS, "NameID").text(),
+		Attributes: map[string][]string{},
+	}
+	if out.ID == "" || out.NameID == "" {
+		return samlAssertion{}, errors.New("saml assertion is missing its id or name id")
+	}
+	confirmed := false
+	for _, sc := range subject.childrenNamed(samlAssertionNS, "SubjectConfirmation") {
+		data := sc.child(samlAssertionNS, "SubjectConfirmationData")
+		if sc.attr("Method") != samlBearer || data == nil {
+			continue
+		}
+		if data.attr("Recipient") != provider.RedirectURL {
+			continue
+		}
+		if irt := data.attr("InResponseTo"); irt != "" && irt != requestID {
+			continue
+		}
+		notOnOrAfter, err := time.Parse(time.RFC3339, data.attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(skew)) {
+			continue
+		}
+		confirmed = true
+		out.ExpiresAt = notOnOrAfter
+	}
+	if !confirmed {
+		return samlAssertion{}, errors.New("saml assertion has no valid bearer subject confirmation")
+	}
+	conditions := a.child(samlAssertionNS, "Conditions")
+	if conditions == nil {
+		return samlAssertion{}, errors.New("saml assertion has no conditions")
+	}
+	if v := conditions.attr("NotBefore"); v != "" {
+		notBefore, err := time.Parse(time.RFC3339, v)
+		if err != nil || now.Add(skew).Before(notBefore) {
+			return samlAssertion{}, errors.New("saml assertion is not yet valid")
+		}
+	}
+	if v := conditions.attr("NotOnOrAfter"); v != "" {
+		notOnOrAfter, err := time.Parse(time.RFC3339, v)
+		if err != nil || !now.Before(notOnOrAfter.Add(skew)) {
+			return samlAssertion{}, errors.New("saml assertion has expired")
+		}
+		if notOnOrAfter.After(out.ExpiresAt) {
+			out.ExpiresAt = notOnOrAfter
+		}
+	}
+	audienceOK := false
+	for _, ar := range conditions.childrenNamed(samlAssertionNS, "AudienceRestriction") {
+		for _, aud := range ar.childrenNamed(samlAssertionNS, "Audience") {
+			if aud.text() == provider.ClientID {
+				audienceOK = true
+			}
+		}
+	}
+	if !audienceOK {
+		return samlAssertion{}, errors.New("saml assertion audience does not include this service provider")
+	}
+	for _, stmt := range a.childrenNamed(samlAssertionNS, "AttributeStatement") {
+		for _, attr := range stmt.childrenNamed(samlAssertionNS, "Attribute") {
+			values := []string{}
+			for _, v := range attr.childrenNamed(samlAssertionNS, "AttributeValue") {
+				values = append(values, v.text())
+			}
+			for _, name := range []string{attr.attr("Name"), attr.attr("FriendlyName")} {
+				if name != "" {
+					out.Attributes[name] = append(out.Attributes[name], values...)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// samlSPMetadata renders the SP EntityDescriptor an IdP imports.
+func samlSPMetadata(provider SSOProvider) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="` + xmlEscapeString(provider.ClientID) + `">` + "\n")
+	b.WriteString(`  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + samlProtocolNS + `">` + "\n")
+	b.WriteString(`    <md:NameIDFormat>` + samlEmailNameID + `</md:NameIDFormat>` + "\n")
+	b.WriteString(`    <md:AssertionConsumerService Binding="` + samlHTTPPost + `" Location="` + xmlEscapeString(provider.RedirectURL) + `" index="0" isDefault="true"/>` + "\n")
+	b.WriteString(`  </md:SPSSODescriptor>` + "\n")
+	b.WriteString(`</md:EntityDescriptor>` + "\n")
+	return b.Bytes()
+}
+
+func xmlEscapeString(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// SAMLMetadata returns the SP metadata document for a saml provider.
+func (s *IdentityStore) SAMLMetadata(providerID string) ([]byte, error) {
+	provider, ok := s.GetProvider(providerID)
+	if !ok {
+		return nil, errors.New("sso provider not found")
+	}
+	if provider.Protocol != "saml" {
+		return nil, errors.New("sso provider does not use saml")
+	}
+	return samlSPMetadata(provider), nil
+}
+
+// CompleteSAMLLogin consumes a SAML Response posted to the assertion
+// consumer service. RelayState must name a login started with StartLogin
+// for the same provider, so unsolicited (IdP-initiated) responses are
+// rejected, and each assertion ID is accepted only once.
+func (s *IdentityStore) CompleteSAMLLogin(providerID string, in SAMLLoginInput) (SSOSession, error) {
+	providerID = strings.TrimSpace(providerID)
+	state := strings.TrimSpace(in.RelayState)
+	if strings.TrimSpace(in.SAMLResponse) == "" || state == "" {
+		return SSOSession{}, errors.New("saml_response and relay_state are required")
+	}
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expirePendingLocked(now)
+	for id, expires := range s.samlAssertions {
+		if now.After(expires) {
+			delete(s.samlAssertions, id)
+		}
+	}
+	pending, ok := s.pending[state]
+	if !ok || pending.providerID != providerID {
+		return SSOSession{}, errors.New("login state is invalid or expired")
+	}
+	delete(s.pending, state)
+	provider, ok := s.providers[providerID]
+	if !ok || provider.Protocol != "saml" || provider.SAML == nil {
+		return SSOSession{}, errors.New("sso provider does not use saml")
+	}
+	if !provider.Enabled {
+		return SSOSession{}, errors.New("sso provider is disabled")
+	}
+	assertion, err := validateSAMLResponse(cloneSSOProvider(*provider), pending.samlRequestID, in.SAMLResponse, now)
+	if err != nil {
+		return SSOSession{}, err
+	}
+	if _, seen := s.samlAssertions[assertion.ID]; seen {
+		return SSOSession{}, errors.New("saml assertion has already been used")
+	}
+	s.samlAssertions[assertion.ID] = assertion.ExpiresAt.Add(samlDefaultSkew)
+
+	email := ""
+	if values := assertion.Attributes[provider.SAML.EmailAttribute]; len(values) > 0 {
+		email = values[0]
+	} else if strings.Contains(assertion.NameID, "@") {
+		email = assertion.NameID
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return SSOSession{}, errors.New("saml assertion does not carry an email")
+	}
+	if len(provider.AllowedDomains) > 0 && !emailDomainAllowed(email, provider.AllowedDomains) {
+		return SSOSession{}, errors.New("email domain not allowed for provider")
+	}
+	groups := normalizeStringSlice(assertion.Attributes[provider.SAML.GroupsAttribute])
+	bindings := []RBACBindingInput{}
+	for _, m := range provider.SAML.RoleMappings {
+		for _, g := range groups {
+			if strings.EqualFold(g, m.Group) {
+				bindings = append(bindings, RBACBindingInput{Subject: email, RoleID: m.RoleID, Scope: m.Scope})
+				break
+			}
+		}
+	}
+	return s.issueSessionLocked(SSOSession{
+		ProviderID:   providerID,
+		Subject:      assertion.NameID,
+		Email:        email,
+		Groups:       groups,
+		RoleBindings: bindings,
+	}, now)
+}
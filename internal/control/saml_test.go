@@ -0,0 +1,263 @@
+package control
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type samlTestIdP struct {
+	t    *testing.T
+	key  *rsa.PrivateKey
+	cert string
+}
+
+func newSAMLTestIdP(t *testing.T) samlTestIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return samlTestIdP{t: t, key: key, cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+type samlTestAssertion struct {
+	ID, InResponseTo, Audience, Email string
+	Groups                            []string
+	NotOnOrAfter                      time.Time
+}
+
+// response renders a signed Response; tamper edits the assertion after it
+// has been signed.
+func (idp samlTestIdP) response(acs string, a samlTestAssertion, sign bool, tamper func(string) string) string {
+	idp.t.Helper()
+	exp := a.NotOnOrAfter.UTC().Format(time.RFC3339)
+	groups := ""
+	for _, g := range a.Groups {
+		groups += `<saml:AttributeValue>` + g + `</saml:AttributeValue>`
+	}
+	assertion := `<saml:Assertion xmlns:saml="` + samlAssertionNS + `" ID="` + a.ID + `" Version="2.0" IssueInstant="` + time.Now().UTC().Format(time.RFC3339) + `">` +
+		`<saml:Issuer>https://idp.example.com</saml:Issuer>` +
+		`<saml:Subject><saml:NameID>00u-alice</saml:NameID>` +
+		`<saml:SubjectConfirmation Method="` + samlBearer + `"><saml:SubjectConfirmationData InResponseTo="` + a.InResponseTo + `" NotOnOrAfter="` + exp + `" Recipient="` + acs + `"/></saml:SubjectConfirmation></saml:Subject>` +
+		`<saml:Conditions NotBefore="` + time.Now().Add(-time.Minute).UTC().Format(time.RFC3339) + `" NotOnOrAfter="` + exp + `">` +
+		`<saml:AudienceRestriction><saml:Audience>` + a.Audience + `</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+		`<saml:AttributeStatement>` +
+		`<saml:Attribute Name="email"><saml:AttributeValue>` + a.Email + `</saml:AttributeValue></saml:Attribute>` +
+		`<saml:Attribute Name="groups">` + groups + `</saml:Attribute>` +
+		`</saml:AttributeStatement></saml:Assertion>`
+	if sign {
+		assertion = idp.sign(assertion, a.ID)
+	}
+	if tamper != nil {
+		assertion = tamper(assertion)
+	}
+	doc := `<samlp:Response xmlns:samlp="` + samlProtocolNS + `" xmlns:saml="` + samlAssertionNS + `" ID="_resp" Version="2.0" Destination="` + acs + `" InResponseTo="` + a.InResponseTo + `">` +
+		`<saml:Issuer>https://idp.example.com</saml:Issuer>` +
+		`<samlp:Status><samlp:StatusCode Value="` + samlSuccess + `"/></samlp:Status>` +
+		assertion + `</samlp:Response>`
+	return base64.StdEncoding.EncodeToString([]byte(doc))
+}
+
+func (idp samlTestIdP) sign(element, id string) string {
+	idp.t.Helper()
+	n, err := parseXMLNode([]byte(element))
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	digest := sha256.Sum256(canonicalizeExclusive(n, nil, nil))
+	signedInfo := `<ds:CanonicalizationMethod Algorithm="` + xmlExcC14NAlgorithm + `"/>` +
+		`<ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"/>` +
+		`<ds:Reference URI="#` + id + `"><ds:Transforms>` +
+		`<ds:Transform Algorithm="` + xmlEnvelopedSig + `"/><ds:Transform Algorithm="` + xmlExcC14NAlgorithm + `"/>` +
+		`</ds:Transforms><ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue></ds:Reference>`
+	// Exclusive canonicalization of SignedInfo does not depend on where the
+	// ds prefix is declared, so it can be signed standalone.
+	si, err := parseXMLNode([]byte(`<ds:SignedInfo xmlns:ds="` + xmlDSigNS + `">` + signedInfo + `</ds:SignedInfo>`))
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	h := sha256.Sum256(canonicalizeExclusive(si, nil, nil))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, h[:])
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	signature := `<ds:Signature xmlns:ds="` + xmlDSigNS + `"><ds:SignedInfo>` + signedInfo + `</ds:SignedInfo>` +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(sig) + `</ds:SignatureValue></ds:Signature>`
+	return strings.Replace(element, `</saml:Issuer>`, `</saml:Issuer>`+signature, 1)
+}
+
+func samlRequestID(t *testing.T, authURL string) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(` ID="([^"]+)"`).FindStringSubmatch(string(inflated))
+	if m == nil {
+		t.Fatalf("authn request has no id: %s", inflated)
+	}
+	return m[1]
+}
+
+func TestSAMLProviderLoginFlow(t *testing.T) {
+	idp := newSAMLTestIdP(t)
+	store := NewIdentityStore()
+	const acs = "https://mc.example.com/v1/identity/sso/saml/acs"
+	provider, err := store.CreateProvider(SSOProviderInput{
+		Name:           "Corp IdP",
+		Protocol:       "saml",
+		IssuerURL:      "https://idp.example.com",
+		ClientID:       "https://mc.example.com/saml",
+		RedirectURL:    acs,
+		AllowedDomains: []string{"example.com"},
+		SAML: &SAMLProviderSettings{
+			SSOURL:       "https://idp.example.com/sso",
+			Certificate:  idp.cert,
+			RoleMappings: []SSORoleMapping{{Group: "SRE", RoleID: "rbac-role-1", Scope: "prod"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create saml provider failed: %v", err)
+	}
+	metadata, err := store.SAMLMetadata(provider.ID)
+	if err != nil || !strings.Contains(string(metadata), `entityID="https://mc.example.com/saml"`) || !strings.Contains(string(metadata), `Location="`+acs+`"`) {
+		t.Fatalf("unexpected metadata: %s err=%v", metadata, err)
+	}
+
+	login := func() (string, string) {
+		start, err := store.StartLogin(SSOLoginStartInput{ProviderID: provider.ID, Email: "alice@example.com"})
+		if err != nil {
+			t.Fatalf("start login failed: %v", err)
+		}
+		if !strings.HasPrefix(start.AuthURL, "https://idp.example.com/sso?") || !strings.Contains(start.AuthURL, "RelayState="+start.State) {
+			t.Fatalf("unexpected auth url: %s", start.AuthURL)
+		}
+		return start.State, samlRequestID(t, start.AuthURL)
+	}
+	valid := func(id, requestID string) samlTestAssertion {
+		return samlTestAssertion{ID: id, InResponseTo: requestID, Audience: "https://mc.example.com/saml", Email: "Alice@example.com", Groups: []string{"sre", "oncall"}, NotOnOrAfter: time.Now().Add(5 * time.Minute)}
+	}
+
+	state, requestID := login()
+	if _, err := store.CompleteLogin(SSOLoginCompleteInput{State: state, Code: "x", Subject: "alice", Email: "alice@example.com"}); err == nil {
+		t.Fatalf("expected oidc callback to reject saml provider")
+	}
+	state, requestID = login()
+	response := idp.response(acs, valid("_a1", requestID), true, nil)
+	session, err := store.CompleteSAMLLogin(provider.ID, SAMLLoginInput{SAMLResponse: response, RelayState: state})
+	if err != nil {
+		t.Fatalf("complete saml login failed: %v", err)
+	}
+	if session.Email != "alice@example.com" || session.Subject != "00u-alice" || len(session.Groups) != 2 {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+	if len(session.RoleBindings) != 1 || session.RoleBindings[0].RoleID != "rbac-role-1" || session.RoleBindings[0].Subject != "alice@example.com" || session.RoleBindings[0].Scope != "prod" {
+		t.Fatalf("expected group mapping to grant role: %+v", session.RoleBindings)
+	}
+
+	state, _ = login()
+	if _, err := store.CompleteSAMLLogin(provider.ID, SAMLLoginInput{SAMLResponse: response, RelayState: state}); err == nil {
+		t.Fatalf("expected response for another request to be rejected")
+	}
+
+	cases := map[string]func(requestID string) string{
+		"replayed assertion": func(requestID string) string {
+			return idp.response(acs, valid("_a1", requestID), true, nil)
+		},
+		"tampered attribute": func(requestID string) string {
+			return idp.response(acs, valid("_a2", requestID), true, func(s string) string {
+				return strings.Replace(s, ">oncall<", ">admins<", 1)
+			})
+		},
+		"unsigned": func(requestID string) string {
+			return idp.response(acs, valid("_a3", requestID), false, nil)
+		},
+		"wrong audience": func(requestID string) string {
+			a := valid("_a4", requestID)
+			a.Audience = "https://other.example.com"
+			return idp.response(acs, a, true, nil)
+		},
+		"expired": func(requestID string) string {
+			a := valid("_a5", requestID)
+			a.NotOnOrAfter = time.Now().Add(-time.Hour)
+			return idp.response(acs, a, true, nil)
+		},
+		"wrong domain": func(requestID string) string {
+			a := valid("_a6", requestID)
+			a.Email = "mallory@evil.example.net"
+			return idp.response(acs, a, true, nil)
+		},
+	}
+	for name, build := range cases {
+		state, requestID := login()
+		if _, err := store.CompleteSAMLLogin(provider.ID, SAMLLoginInput{SAMLResponse: build(requestID), RelayState: state}); err == nil {
+			t.Fatalf("%s: expected saml response to be rejected", name)
+		}
+	}
+}
+
+func TestSAMLProviderValidation(t *testing.T) {
+	idp := newSAMLTestIdP(t)
+	store := NewIdentityStore()
+	base := SSOProviderInput{
+		Name:        "Corp IdP",
+		Protocol:    "saml",
+		IssuerURL:   "https://idp.example.com",
+		ClientID:    "https://mc.example.com/saml",
+		RedirectURL: "https://mc.example.com/acs",
+	}
+	if _, err := store.CreateProvider(base); err == nil {
+		t.Fatalf("expected saml provider without settings to fail")
+	}
+	base.SAML = &SAMLProviderSettings{SSOURL: "https://idp.example.com/sso", Certificate: "not a cert"}
+	if _, err := store.CreateProvider(base); err == nil {
+		t.Fatalf("expected invalid certificate to fail")
+	}
+	block, _ := pem.Decode([]byte(idp.cert))
+	base.SAML = &SAMLProviderSettings{SSOURL: "https://idp.example.com/sso", Certificate: base64.StdEncoding.EncodeToString(block.Bytes)}
+	provider, err := store.CreateProvider(base)
+	if err != nil {
+		t.Fatalf("expected base64 der certificate to be accepted: %v", err)
+	}
+	if provider.SAML.EmailAttribute != "email" || provider.SAML.GroupsAttribute != "groups" {
+		t.Fatalf("expected attribute defaults: %+v", provider.SAML)
+	}
+	oidc, _ := store.CreateProvider(SSOProviderInput{Name: "okta", Protocol: "oidc", IssuerURL: "https://id.example.com", ClientID: "mc", RedirectURL: "https://mc.example.com/cb"})
+	if _, err := store.SAMLMetadata(oidc.ID); err == nil {
+		t.Fatalf("expected metadata for oidc provider to fail")
+	}
+}
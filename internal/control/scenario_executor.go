@@ -0,0 +1,235 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+	"github.com/masterchef/masterchef/internal/executor"
+	"github.com/masterchef/masterchef/internal/planner"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+const scenarioWorkspaceToken = "{{workspace}}"
+
+type ScenarioExecuteInput struct {
+	ScenarioID  string `json:"scenario_id"`
+	TriggeredBy string `json:"triggered_by,omitempty"`
+	BaselineID  string `json:"baseline_id,omitempty"`
+
+	// Modules maps package names to directories holding fetched module
+	// contents; {{module.<name>}} in the scenario config expands to them.
+	Modules map[string]string `json:"-"`
+}
+
+// ScenarioExecutor applies a scenario's config for real inside a throwaway
+// workspace. The plan and run results are normalized (workspace paths are
+// replaced, timestamps dropped) so they can be stored as golden outputs and
+// diffed against baselines.
+type ScenarioExecutor struct {
+	scenarios *ScenarioTestStore
+	workRoot  string
+}
+
+// NewScenarioExecutor creates workspaces under workRoot, or the system temp
+// directory when it is empty.
+func NewScenarioExecutor(scenarios *ScenarioTestStore, workRoot string) *ScenarioExecutor {
+	return &ScenarioExecutor{scenarios: scenarios, workRoot: workRoot}
+}
+
+func (e *ScenarioExecutor) Execute(in ScenarioExecuteInput) (ScenarioRun, error) {
+	scenario, err := e.scenarios.GetScenario(in.ScenarioID)
+	if err != nil {
+		return ScenarioRun{}, err
+	}
+	if scenario.Config == "" {
+		return ScenarioRun{}, errors.New("scenario has no config to execute")
+	}
+	if e.workRoot != "" {
+		if err := os.MkdirAll(e.workRoot, 0o755); err != nil {
+			return ScenarioRun{}, err
+		}
+	}
+	workspace, err := os.MkdirTemp(e.workRoot, "scenario-"+scenario.ID+"-")
+	if err != nil {
+		return ScenarioRun{}, err
+	}
+	defer os.RemoveAll(workspace)
+
+	name := "scenario.yaml"
+	if strings.HasPrefix(scenario.Config, "{") {
+		name = "scenario.json"
+	}
+	configPath := filepath.Join(workspace, name)
+	body := strings.ReplaceAll(scenario.Config, scenarioWorkspaceToken, workspace)
+	replacements := []string{workspace, scenarioWorkspaceToken}
+	for name, dir := range in.Modules {
+		token := scenarioModuleToken(name)
+		body = strings.ReplaceAll(body, token, dir)
+		replacements = append(replacements, dir, token)
+	}
+	if err := os.WriteFile(configPath, []byte(body), 0o644); err != nil {
+		return ScenarioRun{}, err
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return ScenarioRun{}, fmt.Errorf("load scenario config: %w", err)
+	}
+	if err := config.Validate(cfg); err != nil {
+		return ScenarioRun{}, fmt.Errorf("validate scenario config: %w", err)
+	}
+	plan, err := planner.Build(cfg)
+	if err != nil {
+		return ScenarioRun{}, fmt.Errorf("build scenario plan: %w", err)
+	}
+
+	started := time.Now().UTC()
+	ex := executor.New(workspace)
+	run, err := ex.Apply(plan)
+	if err != nil {
+		return ScenarioRun{}, fmt.Errorf("apply scenario config: %w", err)
+	}
+	applied := time.Now().UTC()
+	// A second apply should converge without changes; anything it still
+	// changes is counted as drift.
+	drift := 0
+	if run.Status == state.RunSucceeded {
+		rerun, err := ex.Apply(plan)
+		if err != nil {
+			return ScenarioRun{}, fmt.Errorf("re-apply scenario config: %w", err)
+		}
+		for _, res := range rerun.Results {
+			if res.Changed && !res.Skipped {
+				drift++
+			}
+		}
+	}
+
+	total := len(plan.Steps)
+	failed := 0
+	if run.Status != state.RunSucceeded {
+		// Apply stops at the first failing step for linear strategies, so
+		// the failing step and everything after it did not converge.
+		failed = total - len(run.Results) + 1
+		if failed < 1 {
+			failed = 1
+		}
+		if failed > total {
+			failed = total
+		}
+	}
+	latency := 0
+	if total > 0 {
+		latency = int(applied.Sub(started)/time.Millisecond) / total
+	}
+	item := ScenarioRun{
+		ScenarioID:         scenario.ID,
+		ScenarioName:       scenario.Name,
+		Mode:               "executed",
+		NodesTotal:         total,
+		NodesSucceeded:     total - failed,
+		NodesFailed:        failed,
+		DriftFindings:      drift,
+		MeanApplyLatencyMS: latency,
+		TriggeredBy:        strings.TrimSpace(in.TriggeredBy),
+		BaselineID:         strings.TrimSpace(in.BaselineID),
+		Outputs: map[string]string{
+			"plan": normalizeScenarioPlan(plan, replacements),
+			"run":  normalizeScenarioRunRecord(run, replacements),
+		},
+		StartedAt:   started,
+		CompletedAt: time.Now().UTC(),
+	}
+	return e.scenarios.recordExecutedRun(item)
+}
+
+// recordExecutedRun stores a run produced by ScenarioExecutor and compares
+// it with the requested baseline.
+func (s *ScenarioTestStore) recordExecutedRun(item ScenarioRun) (ScenarioRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item.BaselineID != "" {
+		baseline, ok := s.baselines[item.BaselineID]
+		if !ok {
+			return ScenarioRun{}, errors.New("baseline not found")
+		}
+		if baseline.ScenarioID != item.ScenarioID {
+			return ScenarioRun{}, errors.New("baseline scenario mismatch")
+		}
+	}
+	item.Status = "passed"
+	if item.NodesFailed > 0 {
+		item.Status = "failed"
+	} else if item.DriftFindings > 0 {
+		item.Status = "degraded"
+	}
+	item.DurationMS = int(item.CompletedAt.Sub(item.StartedAt) / time.Millisecond)
+	s.nextRunID++
+	item.ID = "scenario-run-" + itoa(s.nextRunID)
+	s.runs[item.ID] = &item
+	if item.BaselineID != "" {
+		report, err := s.compareScenarioRunWithBaseline(item.ID, item.BaselineID)
+		if err != nil {
+			return ScenarioRun{}, err
+		}
+		item.RegressionDetected = report.RegressionDetected
+		item.RegressionReasons = cloneStringSlice(report.Reasons)
+		item.RegressionScore = report.RegressionScore
+		s.runs[item.ID] = &item
+	}
+	return cloneScenarioRun(item), nil
+}
+
+// scenarioModuleToken is the placeholder a scenario config uses to reference
+// the contents of a registry module.
+func scenarioModuleToken(name string) string {
+	return "{{module." + name + "}}"
+}
+
+// ReferencesModule reports whether the scenario config reads the contents of
+// the named registry module.
+func (d ScenarioDefinition) ReferencesModule(name string) bool {
+	return strings.Contains(d.Config, scenarioModuleToken(name))
+}
+
+// normalizeScenarioPlan and normalizeScenarioRunRecord replace workspace and
+// module directories, given as old/new pairs, with their tokens.
+func normalizeScenarioPlan(plan *planner.Plan, replacements []string) string {
+	var b strings.Builder
+	for _, step := range plan.Steps {
+		b.WriteString("order=" + strconv.Itoa(step.Order))
+		b.WriteString(" host=" + step.Resource.Host)
+		b.WriteString(" type=" + step.Resource.Type)
+		b.WriteString(" id=" + step.Resource.ID)
+		if len(step.Resource.DependsOn) > 0 {
+			b.WriteString(" depends_on=" + strings.Join(step.Resource.DependsOn, ","))
+		}
+		if step.Resource.Path != "" {
+			b.WriteString(" path=" + step.Resource.Path)
+		}
+		b.WriteString("\n")
+	}
+	return strings.NewReplacer(replacements...).Replace(b.String())
+}
+
+func normalizeScenarioRunRecord(run state.RunRecord, replacements []string) string {
+	var b strings.Builder
+	b.WriteString("status=" + string(run.Status) + "\n")
+	for _, res := range run.Results {
+		b.WriteString("id=" + res.ResourceID)
+		b.WriteString(" type=" + res.Type)
+		b.WriteString(" host=" + res.Host)
+		b.WriteString(" changed=" + strconv.FormatBool(res.Changed))
+		b.WriteString(" skipped=" + strconv.FormatBool(res.Skipped))
+		if msg := strings.Join(strings.Fields(res.Message), " "); msg != "" {
+			b.WriteString(" message=" + msg)
+		}
+		b.WriteString("\n")
+	}
+	return strings.NewReplacer(replacements...).Replace(b.String())
+}
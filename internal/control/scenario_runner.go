@@ -3,6 +3,8 @@ package control
 import (
 	"errors"
 	"hash/fnv"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -10,15 +12,25 @@ import (
 )
 
 type ScenarioDefinition struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	FleetSize   int       `json:"fleet_size"`
-	Services    int       `json:"services"`
-	FailureRate float64   `json:"failure_rate"`
-	ChaosLevel  int       `json:"chaos_level"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	FleetSize   int                 `json:"fleet_size"`
+	Services    int                 `json:"services"`
+	FailureRate float64             `json:"failure_rate"`
+	ChaosLevel  int                 `json:"chaos_level"`
+	Config      string              `json:"config,omitempty"`       // YAML or JSON applied by executed runs; {{workspace}} expands to the run workspace
+	ConfigPaths []string            `json:"config_paths,omitempty"` // repository configs the scenario covers, used by test impact analysis
+	Tolerances  []ScenarioTolerance `json:"tolerances,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// ScenarioTolerance relaxes golden-output comparison for executed runs.
+type ScenarioTolerance struct {
+	Output          string `json:"output,omitempty"`            // plan|run, empty for every output
+	IgnorePattern   string `json:"ignore_pattern,omitempty"`    // lines matching this regexp are dropped before diffing
+	MaxChangedLines int    `json:"max_changed_lines,omitempty"` // differing lines allowed before a regression is reported
 }
 
 type ScenarioRunInput struct {
@@ -29,24 +41,26 @@ type ScenarioRunInput struct {
 }
 
 type ScenarioRun struct {
-	ID                 string    `json:"id"`
-	ScenarioID         string    `json:"scenario_id"`
-	ScenarioName       string    `json:"scenario_name"`
-	Seed               int64     `json:"seed"`
-	Status             string    `json:"status"` // passed|degraded|failed
-	NodesTotal         int       `json:"nodes_total"`
-	NodesSucceeded     int       `json:"nodes_succeeded"`
-	NodesFailed        int       `json:"nodes_failed"`
-	DriftFindings      int       `json:"drift_findings"`
-	MeanApplyLatencyMS int       `json:"mean_apply_latency_ms"`
-	DurationMS         int       `json:"duration_ms"`
-	TriggeredBy        string    `json:"triggered_by,omitempty"`
-	BaselineID         string    `json:"baseline_id,omitempty"`
-	RegressionDetected bool      `json:"regression_detected"`
-	RegressionReasons  []string  `json:"regression_reasons,omitempty"`
-	RegressionScore    float64   `json:"regression_score"`
-	StartedAt          time.Time `json:"started_at"`
-	CompletedAt        time.Time `json:"completed_at"`
+	ID                 string            `json:"id"`
+	ScenarioID         string            `json:"scenario_id"`
+	ScenarioName       string            `json:"scenario_name"`
+	Seed               int64             `json:"seed"`
+	Mode               string            `json:"mode"`   // simulated|executed
+	Status             string            `json:"status"` // passed|degraded|failed
+	NodesTotal         int               `json:"nodes_total"`
+	NodesSucceeded     int               `json:"nodes_succeeded"`
+	NodesFailed        int               `json:"nodes_failed"`
+	DriftFindings      int               `json:"drift_findings"`
+	MeanApplyLatencyMS int               `json:"mean_apply_latency_ms"`
+	DurationMS         int               `json:"duration_ms"`
+	TriggeredBy        string            `json:"triggered_by,omitempty"`
+	BaselineID         string            `json:"baseline_id,omitempty"`
+	RegressionDetected bool              `json:"regression_detected"`
+	RegressionReasons  []string          `json:"regression_reasons,omitempty"`
+	RegressionScore    float64           `json:"regression_score"`
+	Outputs            map[string]string `json:"outputs,omitempty"` // normalized plan/run text from executed runs
+	StartedAt          time.Time         `json:"started_at"`
+	CompletedAt        time.Time         `json:"completed_at"`
 }
 
 type ScenarioBaselineInput struct {
@@ -54,30 +68,44 @@ type ScenarioBaselineInput struct {
 	RunID string `json:"run_id"`
 }
 
+// scenarioLatencyRegression is the regression reason reported for slower
+// applies, which callers treat as a performance rather than correctness
+// failure.
+const scenarioLatencyRegression = "mean apply latency exceeded baseline tolerance"
+
 type ScenarioBaseline struct {
-	ID                 string    `json:"id"`
-	Name               string    `json:"name"`
-	ScenarioID         string    `json:"scenario_id"`
-	ScenarioName       string    `json:"scenario_name"`
-	ReferenceRunID     string    `json:"reference_run_id"`
-	NodesTotal         int       `json:"nodes_total"`
-	NodesFailed        int       `json:"nodes_failed"`
-	DriftFindings      int       `json:"drift_findings"`
-	MeanApplyLatencyMS int       `json:"mean_apply_latency_ms"`
-	FailureRatio       float64   `json:"failure_ratio"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	ScenarioID         string            `json:"scenario_id"`
+	ScenarioName       string            `json:"scenario_name"`
+	ReferenceRunID     string            `json:"reference_run_id"`
+	NodesTotal         int               `json:"nodes_total"`
+	NodesFailed        int               `json:"nodes_failed"`
+	DriftFindings      int               `json:"drift_findings"`
+	MeanApplyLatencyMS int               `json:"mean_apply_latency_ms"`
+	FailureRatio       float64           `json:"failure_ratio"`
+	Golden             map[string]string `json:"golden,omitempty"` // reference run outputs diffed against executed runs
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+type ScenarioOutputDiff struct {
+	Output    string   `json:"output"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	Tolerated bool     `json:"tolerated"`
 }
 
 type ScenarioRegressionReport struct {
-	RunID              string   `json:"run_id"`
-	BaselineID         string   `json:"baseline_id"`
-	RegressionDetected bool     `json:"regression_detected"`
-	Reasons            []string `json:"reasons,omitempty"`
-	FailureDelta       float64  `json:"failure_delta"`
-	DriftDelta         int      `json:"drift_delta"`
-	LatencyDeltaMS     int      `json:"latency_delta_ms"`
-	RegressionScore    float64  `json:"regression_score"`
+	RunID              string               `json:"run_id"`
+	BaselineID         string               `json:"baseline_id"`
+	RegressionDetected bool                 `json:"regression_detected"`
+	Reasons            []string             `json:"reasons,omitempty"`
+	FailureDelta       float64              `json:"failure_delta"`
+	DriftDelta         int                  `json:"drift_delta"`
+	LatencyDeltaMS     int                  `json:"latency_delta_ms"`
+	RegressionScore    float64              `json:"regression_score"`
+	OutputDiffs        []ScenarioOutputDiff `json:"output_diffs,omitempty"`
 }
 
 type ScenarioTestStore struct {
@@ -136,12 +164,22 @@ func (s *ScenarioTestStore) UpsertScenario(in ScenarioDefinition) (ScenarioDefin
 	if id == "" || name == "" {
 		return ScenarioDefinition{}, errors.New("id and name are required")
 	}
-	if in.FleetSize <= 0 {
+	in.Config = strings.TrimSpace(in.Config)
+	if in.Config == "" && in.FleetSize <= 0 {
 		return ScenarioDefinition{}, errors.New("fleet_size must be greater than zero")
 	}
-	if in.Services <= 0 {
+	if in.Config == "" && in.Services <= 0 {
 		return ScenarioDefinition{}, errors.New("services must be greater than zero")
 	}
+	if in.FleetSize < 0 || in.Services < 0 {
+		return ScenarioDefinition{}, errors.New("fleet_size and services must not be negative")
+	}
+	tolerances, err := normalizeScenarioTolerances(in.Tolerances)
+	if err != nil {
+		return ScenarioDefinition{}, err
+	}
+	in.Tolerances = tolerances
+	in.ConfigPaths = normalizeScenarioConfigPaths(in.ConfigPaths)
 	if in.FailureRate < 0 || in.FailureRate > 1 {
 		return ScenarioDefinition{}, errors.New("failure_rate must be between 0 and 1")
 	}
@@ -166,6 +204,9 @@ func (s *ScenarioTestStore) UpsertScenario(in ScenarioDefinition) (ScenarioDefin
 	existing.Services = in.Services
 	existing.FailureRate = in.FailureRate
 	existing.ChaosLevel = in.ChaosLevel
+	existing.Config = in.Config
+	existing.Tolerances = in.Tolerances
+	existing.ConfigPaths = in.ConfigPaths
 	existing.UpdatedAt = now
 	return cloneScenarioDefinition(*existing), nil
 }
@@ -183,6 +224,20 @@ func (s *ScenarioTestStore) ListScenarios() []ScenarioDefinition {
 	return out
 }
 
+func (s *ScenarioTestStore) GetScenario(id string) (ScenarioDefinition, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ScenarioDefinition{}, errors.New("scenario id is required")
+	}
+	s.mu.RLock()
+	item, ok := s.definitions[id]
+	s.mu.RUnlock()
+	if !ok {
+		return ScenarioDefinition{}, errors.New("scenario not found")
+	}
+	return cloneScenarioDefinition(*item), nil
+}
+
 func (s *ScenarioTestStore) Run(in ScenarioRunInput) (ScenarioRun, error) {
 	scenarioID := strings.TrimSpace(in.ScenarioID)
 	if scenarioID == "" {
@@ -194,6 +249,9 @@ func (s *ScenarioTestStore) Run(in ScenarioRunInput) (ScenarioRun, error) {
 	if !ok {
 		return ScenarioRun{}, errors.New("scenario not found")
 	}
+	if scenario.FleetSize <= 0 {
+		return ScenarioRun{}, errors.New("scenario has no fleet simulation profile; execute its config instead")
+	}
 	baselineID := strings.TrimSpace(in.BaselineID)
 	if baselineID != "" {
 		baseline, ok := s.baselines[baselineID]
@@ -238,6 +296,7 @@ func (s *ScenarioTestStore) Run(in ScenarioRunInput) (ScenarioRun, error) {
 		ScenarioID:         scenario.ID,
 		ScenarioName:       scenario.Name,
 		Seed:               seed,
+		Mode:               "simulated",
 		Status:             status,
 		NodesTotal:         scenario.FleetSize,
 		NodesSucceeded:     nodesSucceeded,
@@ -316,6 +375,7 @@ func (s *ScenarioTestStore) CreateBaseline(in ScenarioBaselineInput) (ScenarioBa
 		DriftFindings:      run.DriftFindings,
 		MeanApplyLatencyMS: run.MeanApplyLatencyMS,
 		FailureRatio:       failureRatio(*run),
+		Golden:             cloneScenarioOutputs(run.Outputs),
 		CreatedAt:          now,
 		UpdatedAt:          now,
 	}
@@ -323,6 +383,38 @@ func (s *ScenarioTestStore) CreateBaseline(in ScenarioBaselineInput) (ScenarioBa
 	return cloneScenarioBaseline(item), nil
 }
 
+// Rebaseline replaces a baseline's reference metrics and golden outputs
+// with those of a newer run of the same scenario.
+func (s *ScenarioTestStore) Rebaseline(id, runID string) (ScenarioBaseline, error) {
+	id = strings.TrimSpace(id)
+	runID = strings.TrimSpace(runID)
+	if id == "" || runID == "" {
+		return ScenarioBaseline{}, errors.New("baseline id and run_id are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	baseline, ok := s.baselines[id]
+	if !ok {
+		return ScenarioBaseline{}, errors.New("baseline not found")
+	}
+	run, ok := s.runs[runID]
+	if !ok {
+		return ScenarioBaseline{}, errors.New("reference run not found")
+	}
+	if run.ScenarioID != baseline.ScenarioID {
+		return ScenarioBaseline{}, errors.New("run and baseline scenario mismatch")
+	}
+	baseline.ReferenceRunID = run.ID
+	baseline.NodesTotal = run.NodesTotal
+	baseline.NodesFailed = run.NodesFailed
+	baseline.DriftFindings = run.DriftFindings
+	baseline.MeanApplyLatencyMS = run.MeanApplyLatencyMS
+	baseline.FailureRatio = failureRatio(*run)
+	baseline.Golden = cloneScenarioOutputs(run.Outputs)
+	baseline.UpdatedAt = time.Now().UTC()
+	return cloneScenarioBaseline(*baseline), nil
+}
+
 func (s *ScenarioTestStore) ListBaselines() []ScenarioBaseline {
 	s.mu.RLock()
 	out := make([]ScenarioBaseline, 0, len(s.baselines))
@@ -382,9 +474,28 @@ func (s *ScenarioTestStore) compareScenarioRunWithBaseline(runID, baselineID str
 	}
 	latencyDelta := run.MeanApplyLatencyMS - baseline.MeanApplyLatencyMS
 	if latencyDelta > maxScenarioInt(40, int(float64(maxScenarioInt(1, baseline.MeanApplyLatencyMS))*0.25)) {
-		reasons = append(reasons, "mean apply latency exceeded baseline tolerance")
+		reasons = append(reasons, scenarioLatencyRegression)
+	}
+	var diffs []ScenarioOutputDiff
+	if len(baseline.Golden) > 0 {
+		scenario := s.definitions[run.ScenarioID]
+		var tolerances []ScenarioTolerance
+		if scenario != nil {
+			tolerances = scenario.Tolerances
+		}
+		diffs = diffScenarioOutputs(baseline.Golden, run.Outputs, tolerances)
+		for _, diff := range diffs {
+			if !diff.Tolerated {
+				reasons = append(reasons, diff.Output+" output differs from golden baseline")
+			}
+		}
 	}
 	score := 0.0
+	for _, diff := range diffs {
+		if !diff.Tolerated {
+			score += float64(len(diff.Added) + len(diff.Removed))
+		}
+	}
 	if failureDelta > 0 {
 		score += failureDelta * 100
 	}
@@ -403,6 +514,7 @@ func (s *ScenarioTestStore) compareScenarioRunWithBaseline(runID, baselineID str
 		DriftDelta:         driftDelta,
 		LatencyDeltaMS:     latencyDelta,
 		RegressionScore:    score,
+		OutputDiffs:        diffs,
 	}
 	run.BaselineID = baseline.ID
 	run.RegressionDetected = report.RegressionDetected
@@ -427,19 +539,162 @@ func failureRatio(run ScenarioRun) float64 {
 	return float64(run.NodesFailed) / float64(run.NodesTotal)
 }
 
+// diffScenarioOutputs compares normalized outputs line by line, ignoring
+// line order. Lines matching a tolerance's ignore pattern are dropped, and
+// differences within its max_changed_lines budget are marked tolerated.
+func diffScenarioOutputs(golden, current map[string]string, tolerances []ScenarioTolerance) []ScenarioOutputDiff {
+	names := make([]string, 0, len(golden)+len(current))
+	seen := map[string]struct{}{}
+	for _, outputs := range []map[string]string{golden, current} {
+		for name := range outputs {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := make([]ScenarioOutputDiff, 0)
+	for _, name := range names {
+		var ignore []*regexp.Regexp
+		budget := 0
+		for _, tol := range tolerances {
+			if tol.Output != "" && tol.Output != name {
+				continue
+			}
+			if tol.IgnorePattern != "" {
+				ignore = append(ignore, regexp.MustCompile(tol.IgnorePattern))
+			}
+			budget += tol.MaxChangedLines
+		}
+		want := scenarioOutputLines(golden[name], ignore)
+		got := scenarioOutputLines(current[name], ignore)
+		remaining := map[string]int{}
+		for _, line := range want {
+			remaining[line]++
+		}
+		diff := ScenarioOutputDiff{Output: name}
+		for _, line := range got {
+			if remaining[line] > 0 {
+				remaining[line]--
+				continue
+			}
+			diff.Added = append(diff.Added, line)
+		}
+		for _, line := range want {
+			if remaining[line] > 0 {
+				remaining[line]--
+				diff.Removed = append(diff.Removed, line)
+			}
+		}
+		if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+			continue
+		}
+		diff.Tolerated = len(diff.Added)+len(diff.Removed) <= budget
+		out = append(out, diff)
+	}
+	return out
+}
+
+func scenarioOutputLines(text string, ignore []*regexp.Regexp) []string {
+	out := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		skip := false
+		for _, re := range ignore {
+			if re.MatchString(line) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func normalizeScenarioTolerances(in []ScenarioTolerance) ([]ScenarioTolerance, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make([]ScenarioTolerance, 0, len(in))
+	for _, tol := range in {
+		tol.Output = strings.ToLower(strings.TrimSpace(tol.Output))
+		tol.IgnorePattern = strings.TrimSpace(tol.IgnorePattern)
+		switch tol.Output {
+		case "", "plan", "run":
+		default:
+			return nil, errors.New("tolerance output must be plan or run")
+		}
+		if tol.MaxChangedLines < 0 {
+			return nil, errors.New("tolerance max_changed_lines must not be negative")
+		}
+		if tol.IgnorePattern != "" {
+			if _, err := regexp.Compile(tol.IgnorePattern); err != nil {
+				return nil, errors.New("invalid tolerance ignore_pattern: " + err.Error())
+			}
+		}
+		out = append(out, tol)
+	}
+	return out, nil
+}
+
 func cloneScenarioDefinition(in ScenarioDefinition) ScenarioDefinition {
+	if len(in.Tolerances) > 0 {
+		in.Tolerances = append([]ScenarioTolerance{}, in.Tolerances...)
+	}
+	in.ConfigPaths = cloneStringSlice(in.ConfigPaths)
 	return in
 }
 
+func normalizeScenarioConfigPaths(in []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(in))
+	for _, path := range in {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		out = append(out, path)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 func cloneScenarioRun(in ScenarioRun) ScenarioRun {
 	in.RegressionReasons = cloneStringSlice(in.RegressionReasons)
+	in.Outputs = cloneScenarioOutputs(in.Outputs)
 	return in
 }
 
 func cloneScenarioBaseline(in ScenarioBaseline) ScenarioBaseline {
+	in.Golden = cloneScenarioOutputs(in.Golden)
 	return in
 }
 
+func cloneScenarioOutputs(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
 func cloneStringSlice(in []string) []string {
 	if len(in) == 0 {
 		return nil
@@ -1,6 +1,9 @@
 package control
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestScenarioStoreRunDeterministic(t *testing.T) {
 	store := NewScenarioTestStore()
@@ -94,3 +97,100 @@ func TestScenarioStoreBaselineAndRegression(t *testing.T) {
 		t.Fatalf("expected baseline id to be attached, got %+v", withBaseline)
 	}
 }
+
+const scenarioExecutorConfig = `version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: motd
+    type: file
+    host: localhost
+    path: "{{workspace}}/motd"
+    content: "hello\n"
+  - id: marker
+    type: command
+    host: localhost
+    command: "touch {{workspace}}/marker"
+    creates: "{{workspace}}/marker"
+    depends_on: [motd]
+`
+
+func TestScenarioExecutorGoldenBaselines(t *testing.T) {
+	store := NewScenarioTestStore()
+	if _, err := store.UpsertScenario(ScenarioDefinition{ID: "local-files", Name: "Local Files", Config: scenarioExecutorConfig}); err != nil {
+		t.Fatalf("upsert config scenario failed: %v", err)
+	}
+	if _, err := store.Run(ScenarioRunInput{ScenarioID: "local-files"}); err == nil {
+		t.Fatalf("expected simulated run to reject scenario without fleet profile")
+	}
+	exec := NewScenarioExecutor(store, t.TempDir())
+
+	first, err := exec.Execute(ScenarioExecuteInput{ScenarioID: "local-files"})
+	if err != nil {
+		t.Fatalf("execute scenario failed: %v", err)
+	}
+	if first.Mode != "executed" || first.Status != "passed" || first.NodesTotal != 2 {
+		t.Fatalf("unexpected executed run: %+v", first)
+	}
+	if !strings.Contains(first.Outputs["plan"], "path={{workspace}}/motd") || !strings.Contains(first.Outputs["run"], "status=succeeded") {
+		t.Fatalf("expected normalized outputs, got %+v", first.Outputs)
+	}
+	baseline, err := store.CreateBaseline(ScenarioBaselineInput{Name: "golden-local", RunID: first.ID})
+	if err != nil {
+		t.Fatalf("create baseline failed: %v", err)
+	}
+	if baseline.Golden["plan"] != first.Outputs["plan"] {
+		t.Fatalf("expected baseline to capture golden outputs, got %+v", baseline.Golden)
+	}
+
+	second, err := exec.Execute(ScenarioExecuteInput{ScenarioID: "local-files", BaselineID: baseline.ID})
+	if err != nil {
+		t.Fatalf("execute against baseline failed: %v", err)
+	}
+	if second.RegressionDetected {
+		t.Fatalf("expected identical workspace run to match golden outputs: %+v", second)
+	}
+
+	changed := strings.Replace(scenarioExecutorConfig, `content: "hello\n"`, `content: "bye\n"`, 1)
+	changed = strings.Replace(changed, "  - id: marker", "  - id: banner\n    type: file\n    host: localhost\n    path: \"{{workspace}}/banner\"\n    content: \"x\"\n  - id: marker", 1)
+	if _, err := store.UpsertScenario(ScenarioDefinition{ID: "local-files", Name: "Local Files", Config: changed}); err != nil {
+		t.Fatalf("update config scenario failed: %v", err)
+	}
+	third, err := exec.Execute(ScenarioExecuteInput{ScenarioID: "local-files", BaselineID: baseline.ID})
+	if err != nil {
+		t.Fatalf("execute changed scenario failed: %v", err)
+	}
+	if !third.RegressionDetected {
+		t.Fatalf("expected golden diff to report regression: %+v", third)
+	}
+	report, err := store.CompareRunToBaseline(third.ID, baseline.ID)
+	if err != nil || len(report.OutputDiffs) == 0 {
+		t.Fatalf("expected output diffs, got %+v err=%v", report, err)
+	}
+
+	if _, err := store.UpsertScenario(ScenarioDefinition{
+		ID:         "local-files",
+		Name:       "Local Files",
+		Config:     changed,
+		Tolerances: []ScenarioTolerance{{IgnorePattern: "id=banner"}, {Output: "plan", MaxChangedLines: 4}},
+	}); err != nil {
+		t.Fatalf("update tolerances failed: %v", err)
+	}
+	report, err = store.CompareRunToBaseline(third.ID, baseline.ID)
+	if err != nil || report.RegressionDetected {
+		t.Fatalf("expected ignored lines to be tolerated, got %+v err=%v", report, err)
+	}
+
+	rebased, err := store.Rebaseline(baseline.ID, third.ID)
+	if err != nil {
+		t.Fatalf("rebaseline failed: %v", err)
+	}
+	if rebased.ReferenceRunID != third.ID || rebased.Golden["plan"] != third.Outputs["plan"] {
+		t.Fatalf("expected rebaseline to adopt run outputs: %+v", rebased)
+	}
+	if _, err := store.UpsertScenario(ScenarioDefinition{ID: "bad", Name: "Bad", Config: changed, Tolerances: []ScenarioTolerance{{IgnorePattern: "("}}}); err == nil {
+		t.Fatalf("expected invalid tolerance pattern to be rejected")
+	}
+}
@@ -2,6 +2,7 @@ package control
 
 import (
 	"context"
+	"errors"
 	"math/rand"
 	"strings"
 	"sync"
@@ -9,19 +10,22 @@ import (
 )
 
 type Schedule struct {
-	ID            string        `json:"id"`
-	ConfigPath    string        `json:"config_path"`
-	Priority      string        `json:"priority"`
-	ExecutionCost int           `json:"execution_cost"`
-	Host          string        `json:"host,omitempty"`
-	Cluster       string        `json:"cluster,omitempty"`
-	Environment   string        `json:"environment,omitempty"`
-	Interval      time.Duration `json:"interval"`
-	Jitter        time.Duration `json:"jitter"`
-	Enabled       bool          `json:"enabled"`
-	CreatedAt     time.Time     `json:"created_at"`
-	LastRunAt     time.Time     `json:"last_run_at,omitempty"`
-	NextRunAt     time.Time     `json:"next_run_at,omitempty"`
+	ID            string            `json:"id"`
+	ConfigPath    string            `json:"config_path"`
+	Priority      string            `json:"priority"`
+	ExecutionCost int               `json:"execution_cost"`
+	Host          string            `json:"host,omitempty"`
+	Cluster       string            `json:"cluster,omitempty"`
+	Environment   string            `json:"environment,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Owner         string            `json:"owner,omitempty"`
+	Team          string            `json:"team,omitempty"`
+	Interval      time.Duration     `json:"interval"`
+	Jitter        time.Duration     `json:"jitter"`
+	Enabled       bool              `json:"enabled"`
+	CreatedAt     time.Time         `json:"created_at"`
+	LastRunAt     time.Time         `json:"last_run_at,omitempty"`
+	NextRunAt     time.Time         `json:"next_run_at,omitempty"`
 }
 
 type Scheduler struct {
@@ -34,6 +38,8 @@ type Scheduler struct {
 	maxBacklog       int
 	maxExecutionCost int
 	hostHealth       map[string]bool
+	hostMaintenance  func(host string) bool
+	onMaintenance    func(Schedule)
 }
 
 func NewScheduler(q *Queue) *Scheduler {
@@ -68,6 +74,9 @@ type ScheduleOptions struct {
 	Host          string
 	Cluster       string
 	Environment   string
+	Labels        map[string]string
+	Owner         string
+	Team          string
 	Interval      time.Duration
 	Jitter        time.Duration
 }
@@ -95,6 +104,9 @@ func (s *Scheduler) CreateWithOptions(opts ScheduleOptions) *Schedule {
 		Host:          opts.Host,
 		Cluster:       opts.Cluster,
 		Environment:   opts.Environment,
+		Labels:        cloneLabels(opts.Labels),
+		Owner:         strings.TrimSpace(opts.Owner),
+		Team:          strings.TrimSpace(opts.Team),
 		Interval:      interval,
 		Jitter:        jitter,
 		Enabled:       true,
@@ -141,6 +153,22 @@ func (s *Scheduler) Disable(id string) bool {
 	return true
 }
 
+// Delete stops a schedule and removes it, returning its last state.
+func (s *Scheduler) Delete(id string) (Schedule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, false
+	}
+	if cancel, ok := s.cancel[id]; ok {
+		cancel()
+		delete(s.cancel, id)
+	}
+	delete(s.schedules, id)
+	return *cloneSchedule(sc), true
+}
+
 func (s *Scheduler) Enable(id string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -156,6 +184,52 @@ func (s *Scheduler) Enable(id string) bool {
 	return true
 }
 
+// Update replaces the options of a schedule and restarts its timer so a
+// new interval takes effect immediately.
+func (s *Scheduler) Update(id string, opts ScheduleOptions, enabled bool) (Schedule, error) {
+	if strings.TrimSpace(opts.ConfigPath) == "" {
+		return Schedule{}, errors.New("schedule config_path is required")
+	}
+	if opts.Interval <= 0 {
+		return Schedule{}, errors.New("schedule interval must be positive")
+	}
+	if opts.Jitter < 0 {
+		opts.Jitter = 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, errors.New("schedule not found")
+	}
+	if cancel, ok := s.cancel[id]; ok {
+		cancel()
+		delete(s.cancel, id)
+	}
+	now := time.Now().UTC()
+	sc := &Schedule{
+		ID:            cur.ID,
+		ConfigPath:    opts.ConfigPath,
+		Priority:      normalizePriority(opts.Priority),
+		ExecutionCost: normalizeExecutionCost(opts.ExecutionCost),
+		Host:          opts.Host,
+		Cluster:       opts.Cluster,
+		Environment:   opts.Environment,
+		Labels:        cloneLabels(opts.Labels),
+		Owner:         strings.TrimSpace(opts.Owner),
+		Team:          strings.TrimSpace(opts.Team),
+		Interval:      opts.Interval,
+		Jitter:        opts.Jitter,
+		Enabled:       enabled,
+		CreatedAt:     cur.CreatedAt,
+		LastRunAt:     cur.LastRunAt,
+		NextRunAt:     now.Add(opts.Interval),
+	}
+	s.schedules[id] = sc
+	s.startLocked(sc)
+	return *cloneSchedule(sc), nil
+}
+
 func (s *Scheduler) Shutdown() {
 	s.mu.Lock()
 	cancels := make([]context.CancelFunc, 0, len(s.cancel))
@@ -186,7 +260,7 @@ func (s *Scheduler) startLocked(sc *Schedule) {
 				return
 			case <-timer.C:
 				if s.allowDispatch(sc) {
-					_, _ = s.queue.Enqueue(sc.ConfigPath, "", false, sc.Priority)
+					_, _ = s.queue.EnqueuePlaced(JobPlacement{Labels: sc.Labels}, sc.ConfigPath, "", false, sc.Priority)
 				}
 				s.mu.Lock()
 				if cur, ok := s.schedules[scheduleID]; ok {
@@ -208,6 +282,22 @@ func (s *Scheduler) MaintenanceStatus() []MaintenanceTarget {
 	return s.maint.List()
 }
 
+// SetHostMaintenanceCheck adds a host-level maintenance source consulted
+// alongside the scheduler's own maintenance targets.
+func (s *Scheduler) SetHostMaintenanceCheck(fn func(host string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hostMaintenance = fn
+}
+
+// OnMaintenanceSkip registers a callback for each tick a schedule skips
+// because its target is in maintenance.
+func (s *Scheduler) OnMaintenanceSkip(fn func(Schedule)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onMaintenance = fn
+}
+
 func (s *Scheduler) skipForMaintenance(sc *Schedule) bool {
 	if sc == nil {
 		return false
@@ -215,6 +305,12 @@ func (s *Scheduler) skipForMaintenance(sc *Schedule) bool {
 	if s.maint.IsActive("host", sc.Host) {
 		return true
 	}
+	s.mu.RLock()
+	hostMaintenance := s.hostMaintenance
+	s.mu.RUnlock()
+	if hostMaintenance != nil && strings.TrimSpace(sc.Host) != "" && hostMaintenance(sc.Host) {
+		return true
+	}
 	if s.maint.IsActive("cluster", sc.Cluster) {
 		return true
 	}
@@ -276,6 +372,13 @@ func (s *Scheduler) allowDispatch(sc *Schedule) bool {
 		return false
 	}
 	if s.skipForMaintenance(sc) {
+		s.mu.RLock()
+		hook := s.onMaintenance
+		snapshot := *cloneSchedule(sc)
+		s.mu.RUnlock()
+		if hook != nil {
+			hook(snapshot)
+		}
 		return false
 	}
 
@@ -320,10 +423,50 @@ func randomJitter(j time.Duration) time.Duration {
 	return time.Duration(n)
 }
 
+// Restore inserts a schedule with its existing ID, starting it when enabled.
+func (s *Scheduler) Restore(in Schedule) (Schedule, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		return Schedule{}, errors.New("schedule id is required")
+	}
+	if err := ValidateConfigAsCodeID(in.ID); err != nil {
+		return Schedule{}, errors.New("schedule " + err.Error())
+	}
+	if strings.TrimSpace(in.ConfigPath) == "" {
+		return Schedule{}, errors.New("schedule config_path is required")
+	}
+	if in.Interval <= 0 {
+		in.Interval = time.Minute
+	}
+	if in.Jitter < 0 {
+		in.Jitter = 0
+	}
+	in.Priority = normalizePriority(in.Priority)
+	in.ExecutionCost = normalizeExecutionCost(in.ExecutionCost)
+	now := time.Now().UTC()
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = now
+	}
+	in.NextRunAt = now.Add(in.Interval)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.cancel[in.ID]; ok {
+		cancel()
+		delete(s.cancel, in.ID)
+	}
+	s.nextID = advanceRestoredID(s.nextID, in.ID, "sched-")
+	sc := cloneSchedule(&in)
+	s.schedules[in.ID] = sc
+	s.startLocked(sc)
+	return *cloneSchedule(sc), nil
+}
+
 func cloneSchedule(s *Schedule) *Schedule {
 	if s == nil {
 		return nil
 	}
 	cp := *s
+	cp.Labels = cloneLabels(s.Labels)
 	return &cp
 }
@@ -115,6 +115,32 @@ func (s *SchedulerPartitionStore) Get(id string) (SchedulerPartitionRule, bool)
 	return *item, true
 }
 
+func (s *SchedulerPartitionStore) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok := s.rules[id]; !ok {
+		return false
+	}
+	delete(s.rules, id)
+	return true
+}
+
+// ShardMaxParallel returns the largest max_parallel of the rules routing to
+// shard, or zero when no rule does.
+func (s *SchedulerPartitionStore) ShardMaxParallel(shard string) int {
+	shard = strings.ToLower(strings.TrimSpace(shard))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := 0
+	for _, rule := range s.rules {
+		if rule.Shard == shard && rule.MaxParallel > out {
+			out = rule.MaxParallel
+		}
+	}
+	return out
+}
+
 func (s *SchedulerPartitionStore) Decide(in SchedulerPartitionDecisionInput) SchedulerPartitionDecision {
 	tenant := strings.ToLower(strings.TrimSpace(in.Tenant))
 	env := strings.ToLower(strings.TrimSpace(in.Environment))
@@ -73,6 +73,33 @@ func TestScheduler_MaintenanceSkipsScheduledRuns(t *testing.T) {
 	}
 }
 
+func TestScheduler_HostMaintenanceCheckReportsSkips(t *testing.T) {
+	q := NewQueue(32)
+	s := NewScheduler(q)
+	hosts := NewHostMaintenanceStore()
+	if _, err := hosts.Schedule(HostMaintenanceWindowInput{Host: "web-1", DurationMinutes: 5}); err != nil {
+		t.Fatal(err)
+	}
+	hosts.Advance(time.Now().UTC())
+	skipped := make(chan Schedule, 16)
+	s.SetHostMaintenanceCheck(hosts.InMaintenance)
+	s.OnMaintenanceSkip(func(sc Schedule) { skipped <- sc })
+
+	sc := s.CreateWithOptions(ScheduleOptions{ConfigPath: "x.yaml", Interval: 30 * time.Millisecond, Host: "web-1"})
+	select {
+	case got := <-skipped:
+		if got.ID != sc.ID {
+			t.Fatalf("expected skip for %s, got %s", sc.ID, got.ID)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected maintenance skip callback")
+	}
+	if got := len(q.List()); got != 0 {
+		t.Fatalf("expected no jobs queued for host in maintenance, got %d", got)
+	}
+	s.Shutdown()
+}
+
 func TestScheduler_CapacityGuardsBacklogHostHealthAndCost(t *testing.T) {
 	t.Run("backlog", func(t *testing.T) {
 		q := NewQueue(32)
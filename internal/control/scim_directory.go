@@ -0,0 +1,992 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SCIMUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMGroupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMPatchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// SCIMBindingSource marks RBAC bindings owned by SCIM group mappings.
+	SCIMBindingSource = "scim"
+)
+
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMRef is a reference to another SCIM resource, used for group members
+// and the read-only groups attribute of a user.
+type SCIMRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	Emails      []SCIMEmail `json:"emails,omitempty"`
+	Active      bool        `json:"active"`
+	Groups      []SCIMRef   `json:"groups,omitempty"`
+	Meta        SCIMMeta    `json:"meta"`
+}
+
+type SCIMUserInput struct {
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	Emails      []SCIMEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+}
+
+type SCIMGroup struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	DisplayName string    `json:"displayName"`
+	Members     []SCIMRef `json:"members"`
+	Meta        SCIMMeta  `json:"meta"`
+}
+
+type SCIMGroupInput struct {
+	ExternalID  string    `json:"externalId,omitempty"`
+	DisplayName string    `json:"displayName"`
+	Members     []SCIMRef `json:"members,omitempty"`
+}
+
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMGroupRoleMapping grants an RBAC role to every active member of a SCIM
+// group. Group matches the group's displayName, externalId, or id.
+type SCIMGroupRoleMapping struct {
+	ID        string    `json:"id"`
+	Group     string    `json:"group"`
+	RoleID    string    `json:"role_id"`
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SCIMGroupRoleMappingInput struct {
+	Group  string `json:"group"`
+	RoleID string `json:"role_id"`
+	Scope  string `json:"scope,omitempty"`
+}
+
+// SCIMError carries the HTTP status and scimType of a SCIM protocol error.
+type SCIMError struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *SCIMError) Error() string {
+	return e.Detail
+}
+
+func scimErr(status int, scimType, detail string) error {
+	return &SCIMError{Status: status, ScimType: scimType, Detail: detail}
+}
+
+// SCIMDirectory holds users and groups provisioned by an identity provider
+// over SCIM 2.0, plus the group to RBAC role mappings derived from them.
+type SCIMDirectory struct {
+	mu          sync.RWMutex
+	nextUser    int64
+	nextGroup   int64
+	nextMapping int64
+	users       map[string]*SCIMUser
+	groups      map[string]*SCIMGroup
+	mappings    map[string]*SCIMGroupRoleMapping
+}
+
+func NewSCIMDirectory() *SCIMDirectory {
+	return &SCIMDirectory{
+		users:    map[string]*SCIMUser{},
+		groups:   map[string]*SCIMGroup{},
+		mappings: map[string]*SCIMGroupRoleMapping{},
+	}
+}
+
+func (d *SCIMDirectory) CreateUser(in SCIMUserInput) (SCIMUser, error) {
+	userName := strings.TrimSpace(in.UserName)
+	if userName == "" {
+		return SCIMUser{}, scimErr(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	now := time.Now().UTC()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.userNameTakenLocked(userName, "") {
+		return SCIMUser{}, scimErr(http.StatusConflict, "uniqueness", "userName "+userName+" already exists")
+	}
+	d.nextUser++
+	item := SCIMUser{
+		ID:   "scim-user-" + itoa(d.nextUser),
+		Meta: SCIMMeta{ResourceType: "User", Created: now, LastModified: now},
+	}
+	applySCIMUserInput(&item, in)
+	item.Meta.Location = "/scim/v2/Users/" + item.ID
+	d.users[item.ID] = &item
+	return d.userViewLocked(item), nil
+}
+
+// ReplaceUser implements PUT: every mutable attribute is replaced.
+func (d *SCIMDirectory) ReplaceUser(id string, in SCIMUserInput) (SCIMUser, error) {
+	userName := strings.TrimSpace(in.UserName)
+	if userName == "" {
+		return SCIMUser{}, scimErr(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, ok := d.users[strings.TrimSpace(id)]
+	if !ok {
+		return SCIMUser{}, scimErr(http.StatusNotFound, "", "user not found")
+	}
+	if d.userNameTakenLocked(userName, item.ID) {
+		return SCIMUser{}, scimErr(http.StatusConflict, "uniqueness", "userName "+userName+" already exists")
+	}
+	applySCIMUserInput(item, in)
+	item.Meta.LastModified = time.Now().UTC()
+	d.refreshMemberDisplayLocked(item)
+	return d.userViewLocked(*item), nil
+}
+
+// PatchUser applies SCIM PATCH operations atomically: either every
+// operation applies or the user is left unchanged.
+func (d *SCIMDirectory) PatchUser(id string, ops []SCIMPatchOperation) (SCIMUser, error) {
+	if len(ops) == 0 {
+		return SCIMUser{}, scimErr(http.StatusBadRequest, "invalidValue", "Operations is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current, ok := d.users[strings.TrimSpace(id)]
+	if !ok {
+		return SCIMUser{}, scimErr(http.StatusNotFound, "", "user not found")
+	}
+	item := cloneSCIMUser(*current)
+	for _, op := range ops {
+		if err := patchSCIMUser(&item, op); err != nil {
+			return SCIMUser{}, err
+		}
+	}
+	if strings.TrimSpace(item.UserName) == "" {
+		return SCIMUser{}, scimErr(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	if d.userNameTakenLocked(item.UserName, item.ID) {
+		return SCIMUser{}, scimErr(http.StatusConflict, "uniqueness", "userName "+item.UserName+" already exists")
+	}
+	item.Meta.LastModified = time.Now().UTC()
+	*current = item
+	d.refreshMemberDisplayLocked(current)
+	return d.userViewLocked(*current), nil
+}
+
+// DeleteUser removes the user and its group memberships.
+func (d *SCIMDirectory) DeleteUser(id string) (SCIMUser, error) {
+	id = strings.TrimSpace(id)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, ok := d.users[id]
+	if !ok {
+		return SCIMUser{}, scimErr(http.StatusNotFound, "", "user not found")
+	}
+	out := d.userViewLocked(*item)
+	delete(d.users, id)
+	now := time.Now().UTC()
+	for _, group := range d.groups {
+		if members, removed := removeSCIMRefs(group.Members, map[string]bool{id: true}); removed {
+			group.Members = members
+			group.Meta.LastModified = now
+		}
+	}
+	return out, nil
+}
+
+func (d *SCIMDirectory) GetUser(id string) (SCIMUser, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	item, ok := d.users[strings.TrimSpace(id)]
+	if !ok {
+		return SCIMUser{}, false
+	}
+	return d.userViewLocked(*item), true
+}
+
+// ListUsers returns users matching the SCIM filter expression, oldest
+// first so startIndex paging is stable.
+func (d *SCIMDirectory) ListUsers(filter string) ([]SCIMUser, error) {
+	match, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]SCIMUser, 0, len(d.users))
+	for _, item := range d.users {
+		view := d.userViewLocked(*item)
+		if match(func(attr string) []string { return scimUserAttr(view, attr) }) {
+			out = append(out, view)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return scimResourceLess(out[i].Meta, out[i].ID, out[j].Meta, out[j].ID) })
+	return out, nil
+}
+
+func (d *SCIMDirectory) CreateGroup(in SCIMGroupInput) (SCIMGroup, error) {
+	displayName := strings.TrimSpace(in.DisplayName)
+	if displayName == "" {
+		return SCIMGroup{}, scimErr(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	now := time.Now().UTC()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.groupNameTakenLocked(displayName, "") {
+		return SCIMGroup{}, scimErr(http.StatusConflict, "uniqueness", "group "+displayName+" already exists")
+	}
+	members, err := d.resolveMembersLocked(in.Members)
+	if err != nil {
+		return SCIMGroup{}, err
+	}
+	d.nextGroup++
+	item := SCIMGroup{
+		Schemas:     []string{SCIMGroupSchema},
+		ID:          "scim-group-" + itoa(d.nextGroup),
+		ExternalID:  strings.TrimSpace(in.ExternalID),
+		DisplayName: displayName,
+		Members:     members,
+		Meta:        SCIMMeta{ResourceType: "Group", Created: now, LastModified: now},
+	}
+	item.Meta.Location = "/scim/v2/Groups/" + item.ID
+	d.groups[item.ID] = &item
+	return cloneSCIMGroup(item), nil
+}
+
+func (d *SCIMDirectory) ReplaceGroup(id string, in SCIMGroupInput) (SCIMGroup, error) {
+	displayName := strings.TrimSpace(in.DisplayName)
+	if displayName == "" {
+		return SCIMGroup{}, scimErr(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, ok := d.groups[strings.TrimSpace(id)]
+	if !ok {
+		return SCIMGroup{}, scimErr(http.StatusNotFound, "", "group not found")
+	}
+	if d.groupNameTakenLocked(displayName, item.ID) {
+		return SCIMGroup{}, scimErr(http.StatusConflict, "uniqueness", "group "+displayName+" already exists")
+	}
+	members, err := d.resolveMembersLocked(in.Members)
+	if err != nil {
+		return SCIMGroup{}, err
+	}
+	item.ExternalID = strings.TrimSpace(in.ExternalID)
+	item.DisplayName = displayName
+	item.Members = members
+	item.Meta.LastModified = time.Now().UTC()
+	return cloneSCIMGroup(*item), nil
+}
+
+// PatchGroup applies SCIM PATCH operations atomically, including member
+// add/remove with value filters such as members[value eq "id"].
+func (d *SCIMDirectory) PatchGroup(id string, ops []SCIMPatchOperation) (SCIMGroup, error) {
+	if len(ops) == 0 {
+		return SCIMGroup{}, scimErr(http.StatusBadRequest, "invalidValue", "Operations is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current, ok := d.groups[strings.TrimSpace(id)]
+	if !ok {
+		return SCIMGroup{}, scimErr(http.StatusNotFound, "", "group not found")
+	}
+	item := cloneSCIMGroup(*current)
+	for _, op := range ops {
+		if err := d.patchGroupLocked(&item, op); err != nil {
+			return SCIMGroup{}, err
+		}
+	}
+	if strings.TrimSpace(item.DisplayName) == "" {
+		return SCIMGroup{}, scimErr(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	if d.groupNameTakenLocked(item.DisplayName, item.ID) {
+		return SCIMGroup{}, scimErr(http.StatusConflict, "uniqueness", "group "+item.DisplayName+" already exists")
+	}
+	item.Meta.LastModified = time.Now().UTC()
+	*current = item
+	return cloneSCIMGroup(*current), nil
+}
+
+func (d *SCIMDirectory) DeleteGroup(id string) (SCIMGroup, error) {
+	id = strings.TrimSpace(id)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, ok := d.groups[id]
+	if !ok {
+		return SCIMGroup{}, scimErr(http.StatusNotFound, "", "group not found")
+	}
+	delete(d.groups, id)
+	return cloneSCIMGroup(*item), nil
+}
+
+func (d *SCIMDirectory) GetGroup(id string) (SCIMGroup, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	item, ok := d.groups[strings.TrimSpace(id)]
+	if !ok {
+		return SCIMGroup{}, false
+	}
+	return cloneSCIMGroup(*item), true
+}
+
+func (d *SCIMDirectory) ListGroups(filter string) ([]SCIMGroup, error) {
+	match, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]SCIMGroup, 0, len(d.groups))
+	for _, item := range d.groups {
+		view := cloneSCIMGroup(*item)
+		if match(func(attr string) []string { return scimGroupAttr(view, attr) }) {
+			out = append(out, view)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return scimResourceLess(out[i].Meta, out[i].ID, out[j].Meta, out[j].ID) })
+	return out, nil
+}
+
+func (d *SCIMDirectory) CreateGroupMapping(in SCIMGroupRoleMappingInput) (SCIMGroupRoleMapping, error) {
+	group := strings.TrimSpace(in.Group)
+	roleID := strings.TrimSpace(in.RoleID)
+	if group == "" || roleID == "" {
+		return SCIMGroupRoleMapping{}, errors.New("group and role_id are required")
+	}
+	scope := strings.TrimSpace(in.Scope)
+	if scope == "" {
+		scope = "*"
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextMapping++
+	item := SCIMGroupRoleMapping{
+		ID:        "scim-mapping-" + itoa(d.nextMapping),
+		Group:     group,
+		RoleID:    roleID,
+		Scope:     scope,
+		CreatedAt: time.Now().UTC(),
+	}
+	d.mappings[item.ID] = &item
+	return item, nil
+}
+
+func (d *SCIMDirectory) ListGroupMappings() []SCIMGroupRoleMapping {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]SCIMGroupRoleMapping, 0, len(d.mappings))
+	for _, item := range d.mappings {
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (d *SCIMDirectory) DeleteGroupMapping(id string) (SCIMGroupRoleMapping, error) {
+	id = strings.TrimSpace(id)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, ok := d.mappings[id]
+	if !ok {
+		return SCIMGroupRoleMapping{}, errors.New("scim group mapping not found")
+	}
+	delete(d.mappings, id)
+	return *item, nil
+}
+
+// DesiredBindings returns the RBAC bindings implied by the group mappings:
+// one per active member per mapped role, keyed on the member's userName.
+func (d *SCIMDirectory) DesiredBindings() []RBACBindingInput {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	seen := map[string]bool{}
+	out := []RBACBindingInput{}
+	for _, mapping := range d.mappings {
+		for _, group := range d.groups {
+			if !strings.EqualFold(mapping.Group, group.DisplayName) && mapping.Group != group.ID &&
+				(group.ExternalID == "" || mapping.Group != group.ExternalID) {
+				continue
+			}
+			for _, member := range group.Members {
+				user, ok := d.users[member.Value]
+				if !ok || !user.Active {
+					continue
+				}
+				key := user.UserName + "|" + mapping.RoleID + "|" + mapping.Scope
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				out = append(out, RBACBindingInput{Subject: user.UserName, RoleID: mapping.RoleID, Scope: mapping.Scope})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Subject != out[j].Subject {
+			return out[i].Subject < out[j].Subject
+		}
+		return out[i].RoleID < out[j].RoleID
+	})
+	return out
+}
+
+func (d *SCIMDirectory) userNameTakenLocked(userName, exceptID string) bool {
+	for id, item := range d.users {
+		if id != exceptID && strings.EqualFold(item.UserName, userName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *SCIMDirectory) groupNameTakenLocked(displayName, exceptID string) bool {
+	for id, item := range d.groups {
+		if id != exceptID && strings.EqualFold(item.DisplayName, displayName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *SCIMDirectory) resolveMembersLocked(in []SCIMRef) ([]SCIMRef, error) {
+	out := []SCIMRef{}
+	seen := map[string]bool{}
+	for _, ref := range in {
+		id := strings.TrimSpace(ref.Value)
+		if id == "" || seen[id] {
+			continue
+		}
+		user, ok := d.users[id]
+		if !ok {
+			return nil, scimErr(http.StatusBadRequest, "invalidValue", "member "+id+" is not a known user")
+		}
+		seen[id] = true
+		out = append(out, SCIMRef{Value: id, Display: user.UserName})
+	}
+	return out, nil
+}
+
+func (d *SCIMDirectory) refreshMemberDisplayLocked(user *SCIMUser) {
+	for _, group := range d.groups {
+		for i := range group.Members {
+			if group.Members[i].Value == user.ID {
+				group.Members[i].Display = user.UserName
+			}
+		}
+	}
+}
+
+// userViewLocked fills in the read-only groups attribute.
+func (d *SCIMDirectory) userViewLocked(in SCIMUser) SCIMUser {
+	out := cloneSCIMUser(in)
+	out.Groups = nil
+	for _, group := range d.groups {
+		for _, member := range group.Members {
+			if member.Value == in.ID {
+				out.Groups = append(out.Groups, SCIMRef{Value: group.ID, Display: group.DisplayName})
+				break
+			}
+		}
+	}
+	sort.Slice(out.Groups, func(i, j int) bool { return out.Groups[i].Display < out.Groups[j].Display })
+	return out
+}
+
+func (d *SCIMDirectory) patchGroupLocked(item *SCIMGroup, op SCIMPatchOperation) error {
+	kind := strings.ToLower(strings.TrimSpace(op.Op))
+	path := strings.TrimSpace(op.Path)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return scimErr(http.StatusBadRequest, "invalidSyntax", "unsupported patch op "+op.Op)
+	}
+	if path == "" {
+		if kind == "remove" {
+			return scimErr(http.StatusBadRequest, "noTarget", "remove requires a path")
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return scimErr(http.StatusBadRequest, "invalidValue", "patch value must be an object when path is omitted")
+		}
+		for _, key := range sortedRawKeys(attrs) {
+			if err := d.patchGroupLocked(item, SCIMPatchOperation{Op: kind, Path: key, Value: attrs[key]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	attr, filterAttr, filterValue, _, err := parseSCIMValuePath(path)
+	if err != nil {
+		return err
+	}
+	switch attr {
+	case "displayname":
+		if kind == "remove" {
+			return scimErr(http.StatusBadRequest, "mutability", "displayName cannot be removed")
+		}
+		return unmarshalSCIMString(op.Value, &item.DisplayName)
+	case "externalid":
+		if kind == "remove" {
+			item.ExternalID = ""
+			return nil
+		}
+		return unmarshalSCIMString(op.Value, &item.ExternalID)
+	case "members":
+		if filterAttr != "" {
+			if kind != "remove" || filterAttr != "value" {
+				return scimErr(http.StatusBadRequest, "invalidPath", "only remove is supported with a members value filter")
+			}
+			item.Members, _ = removeSCIMRefs(item.Members, map[string]bool{filterValue: true})
+			return nil
+		}
+		var refs []SCIMRef
+		if len(op.Value) > 0 && string(op.Value) != "null" {
+			if err := json.Unmarshal(op.Value, &refs); err != nil {
+				var single SCIMRef
+				if json.Unmarshal(op.Value, &single) != nil {
+					return scimErr(http.StatusBadRequest, "invalidValue", "members must be an array of references")
+				}
+				refs = []SCIMRef{single}
+			}
+		}
+		switch kind {
+		case "remove":
+			if len(refs) == 0 {
+				item.Members = []SCIMRef{}
+				return nil
+			}
+			ids := map[string]bool{}
+			for _, ref := range refs {
+				ids[strings.TrimSpace(ref.Value)] = true
+			}
+			item.Members, _ = removeSCIMRefs(item.Members, ids)
+		case "replace":
+			members, err := d.resolveMembersLocked(refs)
+			if err != nil {
+				return err
+			}
+			item.Members = members
+		default:
+			members, err := d.resolveMembersLocked(append(append([]SCIMRef{}, item.Members...), refs...))
+			if err != nil {
+				return err
+			}
+			item.Members = members
+		}
+		return nil
+	case "id", "meta", "schemas":
+		return nil
+	default:
+		if strings.HasPrefix(attr, "urn:") {
+			return nil
+		}
+		return scimErr(http.StatusBadRequest, "invalidPath", "unsupported group attribute "+path)
+	}
+}
+
+func applySCIMUserInput(item *SCIMUser, in SCIMUserInput) {
+	item.Schemas = []string{SCIMUserSchema}
+	item.ExternalID = strings.TrimSpace(in.ExternalID)
+	item.UserName = strings.TrimSpace(in.UserName)
+	item.DisplayName = strings.TrimSpace(in.DisplayName)
+	item.Name = nil
+	if in.Name != nil {
+		name := *in.Name
+		item.Name = &name
+	}
+	item.Emails = append([]SCIMEmail{}, in.Emails...)
+	item.Active = in.Active == nil || *in.Active
+}
+
+func patchSCIMUser(item *SCIMUser, op SCIMPatchOperation) error {
+	kind := strings.ToLower(strings.TrimSpace(op.Op))
+	path := strings.TrimSpace(op.Path)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return scimErr(http.StatusBadRequest, "invalidSyntax", "unsupported patch op "+op.Op)
+	}
+	if path == "" {
+		if kind == "remove" {
+			return scimErr(http.StatusBadRequest, "noTarget", "remove requires a path")
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return scimErr(http.StatusBadRequest, "invalidValue", "patch value must be an object when path is omitted")
+		}
+		for _, key := range sortedRawKeys(attrs) {
+			if err := patchSCIMUser(item, SCIMPatchOperation{Op: kind, Path: key, Value: attrs[key]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	attr, filterAttr, filterValue, sub, err := parseSCIMValuePath(path)
+	if err != nil {
+		return err
+	}
+	remove := kind == "remove"
+	switch attr {
+	case "username":
+		if remove {
+			return scimErr(http.StatusBadRequest, "mutability", "userName cannot be removed")
+		}
+		return unmarshalSCIMString(op.Value, &item.UserName)
+	case "externalid", "displayname":
+		target := &item.ExternalID
+		if attr == "displayname" {
+			target = &item.DisplayName
+		}
+		if remove {
+			*target = ""
+			return nil
+		}
+		return unmarshalSCIMString(op.Value, target)
+	case "active":
+		if remove {
+			item.Active = false
+			return nil
+		}
+		return unmarshalSCIMBool(op.Value, &item.Active)
+	case "name":
+		if remove {
+			item.Name = nil
+			return nil
+		}
+		var name SCIMName
+		if err := json.Unmarshal(op.Value, &name); err != nil {
+			return scimErr(http.StatusBadRequest, "invalidValue", "name must be an object")
+		}
+		item.Name = &name
+		return nil
+	case "name.givenname", "name.familyname", "name.formatted":
+		if item.Name == nil {
+			item.Name = &SCIMName{}
+		}
+		target := &item.Name.Formatted
+		switch attr {
+		case "name.givenname":
+			target = &item.Name.GivenName
+		case "name.familyname":
+			target = &item.Name.FamilyName
+		}
+		if remove {
+			*target = ""
+			return nil
+		}
+		return unmarshalSCIMString(op.Value, target)
+	case "emails":
+		return patchSCIMEmails(item, kind, filterAttr, filterValue, sub, op.Value)
+	case "id", "meta", "schemas", "groups":
+		return nil
+	default:
+		// Enterprise and vendor extension attributes are accepted and
+		// ignored so IdP attribute mappings do not break provisioning.
+		if strings.HasPrefix(attr, "urn:") {
+			return nil
+		}
+		return scimErr(http.StatusBadRequest, "invalidPath", "unsupported user attribute "+path)
+	}
+}
+
+// patchSCIMEmails handles both whole-list updates and value paths such as
+// emails[type eq "work"].value, which Azure AD sends.
+func patchSCIMEmails(item *SCIMUser, kind, filterAttr, filterValue, sub string, raw json.RawMessage) error {
+	if filterAttr == "" {
+		if kind == "remove" {
+			item.Emails = nil
+			return nil
+		}
+		var emails []SCIMEmail
+		if err := json.Unmarshal(raw, &emails); err != nil {
+			return scimErr(http.StatusBadRequest, "invalidValue", "emails must be an array")
+		}
+		if kind == "add" {
+			item.Emails = append(item.Emails, emails...)
+		} else {
+			item.Emails = emails
+		}
+		return nil
+	}
+	if filterAttr != "type" && filterAttr != "value" {
+		return scimErr(http.StatusBadRequest, "invalidFilter", "emails can only be filtered by type or value")
+	}
+	idx := -1
+	for i, email := range item.Emails {
+		if (filterAttr == "type" && strings.EqualFold(email.Type, filterValue)) || (filterAttr == "value" && strings.EqualFold(email.Value, filterValue)) {
+			idx = i
+			break
+		}
+	}
+	if kind == "remove" {
+		if idx >= 0 {
+			item.Emails = append(item.Emails[:idx], item.Emails[idx+1:]...)
+		}
+		return nil
+	}
+	if idx < 0 {
+		email := SCIMEmail{}
+		if filterAttr == "type" {
+			email.Type = filterValue
+		} else {
+			email.Value = filterValue
+		}
+		item.Emails = append(item.Emails, email)
+		idx = len(item.Emails) - 1
+	}
+	switch sub {
+	case "", "value":
+		if sub == "" {
+			var email SCIMEmail
+			if err := json.Unmarshal(raw, &email); err != nil {
+				return scimErr(http.StatusBadRequest, "invalidValue", "email must be an object")
+			}
+			item.Emails[idx] = email
+			return nil
+		}
+		return unmarshalSCIMString(raw, &item.Emails[idx].Value)
+	case "type":
+		return unmarshalSCIMString(raw, &item.Emails[idx].Type)
+	case "primary":
+		return unmarshalSCIMBool(raw, &item.Emails[idx].Primary)
+	default:
+		return scimErr(http.StatusBadRequest, "invalidPath", "unsupported email attribute "+sub)
+	}
+}
+
+// parseSCIMValuePath splits paths like `members[value eq "x"]` or
+// `emails[type eq "work"].value` into lowercased attribute, filter
+// attribute, filter value, and sub-attribute. Only eq filters are allowed.
+func parseSCIMValuePath(path string) (attr, filterAttr, filterValue, sub string, err error) {
+	path = stripSCIMSchemaPrefix(strings.TrimSpace(path))
+	open := strings.Index(path, "[")
+	if open < 0 {
+		return strings.ToLower(path), "", "", "", nil
+	}
+	closeIdx := strings.LastIndex(path, "]")
+	if closeIdx < open {
+		return "", "", "", "", scimErr(http.StatusBadRequest, "invalidPath", "malformed path "+path)
+	}
+	attr = strings.ToLower(strings.TrimSpace(path[:open]))
+	sub = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(path[closeIdx+1:]), "."))
+	fields := strings.Fields(path[open+1 : closeIdx])
+	if len(fields) < 3 || !strings.EqualFold(fields[1], "eq") {
+		return "", "", "", "", scimErr(http.StatusBadRequest, "invalidFilter", "only eq value filters are supported in paths")
+	}
+	filterAttr = strings.ToLower(fields[0])
+	filterValue = strings.Join(fields[2:], " ")
+	if unquoted, qerr := strconv.Unquote(filterValue); qerr == nil {
+		filterValue = unquoted
+	}
+	return attr, filterAttr, filterValue, sub, nil
+}
+
+// stripSCIMSchemaPrefix turns fully qualified core attribute names such as
+// urn:ietf:params:scim:schemas:core:2.0:User:userName into userName.
+// Extension attributes keep their URN so callers can recognise them.
+func stripSCIMSchemaPrefix(path string) string {
+	for _, schema := range []string{SCIMUserSchema, SCIMGroupSchema} {
+		if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+			return path[len(schema)+1:]
+		}
+	}
+	return path
+}
+
+func unmarshalSCIMString(raw json.RawMessage, out *string) error {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return scimErr(http.StatusBadRequest, "invalidValue", "expected a string value")
+	}
+	*out = strings.TrimSpace(value)
+	return nil
+}
+
+// unmarshalSCIMBool accepts JSON booleans and the "True"/"False" strings
+// some IdPs send.
+func unmarshalSCIMBool(raw json.RawMessage, out *bool) error {
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil {
+		*out = value
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if parsed, perr := strconv.ParseBool(strings.ToLower(strings.TrimSpace(text))); perr == nil {
+			*out = parsed
+			return nil
+		}
+	}
+	return scimErr(http.StatusBadRequest, "invalidValue", "expected a boolean value")
+}
+
+func removeSCIMRefs(in []SCIMRef, ids map[string]bool) ([]SCIMRef, bool) {
+	out := make([]SCIMRef, 0, len(in))
+	removed := false
+	for _, ref := range in {
+		if ids[ref.Value] {
+			removed = true
+			continue
+		}
+		out = append(out, ref)
+	}
+	return out, removed
+}
+
+func sortedRawKeys(in map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(in))
+	for key := range in {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func scimResourceLess(a SCIMMeta, aID string, b SCIMMeta, bID string) bool {
+	if !a.Created.Equal(b.Created) {
+		return a.Created.Before(b.Created)
+	}
+	if len(aID) != len(bID) {
+		return len(aID) < len(bID)
+	}
+	return aID < bID
+}
+
+func scimUserAttr(u SCIMUser, attr string) []string {
+	switch attr {
+	case "id":
+		return []string{u.ID}
+	case "externalid":
+		return []string{u.ExternalID}
+	case "username":
+		return []string{u.UserName}
+	case "displayname":
+		return []string{u.DisplayName}
+	case "active":
+		return []string{strconv.FormatBool(u.Active)}
+	case "name.givenname", "name.familyname", "name.formatted":
+		if u.Name == nil {
+			return nil
+		}
+		switch attr {
+		case "name.givenname":
+			return []string{u.Name.GivenName}
+		case "name.familyname":
+			return []string{u.Name.FamilyName}
+		}
+		return []string{u.Name.Formatted}
+	case "emails", "emails.value", "emails.type":
+		out := make([]string, 0, len(u.Emails))
+		for _, email := range u.Emails {
+			if attr == "emails.type" {
+				out = append(out, email.Type)
+			} else {
+				out = append(out, email.Value)
+			}
+		}
+		return out
+	case "groups", "groups.value", "groups.display":
+		out := make([]string, 0, len(u.Groups))
+		for _, group := range u.Groups {
+			if attr == "groups.display" {
+				out = append(out, group.Display)
+			} else {
+				out = append(out, group.Value)
+			}
+		}
+		return out
+	case "meta.created":
+		return []string{u.Meta.Created.Format(time.RFC3339)}
+	case "meta.lastmodified":
+		return []string{u.Meta.LastModified.Format(time.RFC3339)}
+	}
+	return nil
+}
+
+func scimGroupAttr(g SCIMGroup, attr string) []string {
+	switch attr {
+	case "id":
+		return []string{g.ID}
+	case "externalid":
+		return []string{g.ExternalID}
+	case "displayname":
+		return []string{g.DisplayName}
+	case "members", "members.value", "members.display":
+		out := make([]string, 0, len(g.Members))
+		for _, member := range g.Members {
+			if attr == "members.display" {
+				out = append(out, member.Display)
+			} else {
+				out = append(out, member.Value)
+			}
+		}
+		return out
+	case "meta.created":
+		return []string{g.Meta.Created.Format(time.RFC3339)}
+	case "meta.lastmodified":
+		return []string{g.Meta.LastModified.Format(time.RFC3339)}
+	}
+	return nil
+}
+
+func cloneSCIMUser(in SCIMUser) SCIMUser {
+	out := in
+	out.Schemas = append([]string{}, in.Schemas...)
+	if in.Name != nil {
+		name := *in.Name
+		out.Name = &name
+	}
+	out.Emails = append([]SCIMEmail{}, in.Emails...)
+	out.Groups = append([]SCIMRef{}, in.Groups...)
+	return out
+}
+
+func cloneSCIMGroup(in SCIMGroup) SCIMGroup {
+	out := in
+	out.Schemas = append([]string{}, in.Schemas...)
+	out.Members = append([]SCIMRef{}, in.Members...)
+	return out
+}
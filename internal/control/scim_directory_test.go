@@ -0,0 +1,126 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSCIMDirectoryUsersFilterAndPatch(t *testing.T) {
+	d := NewSCIMDirectory()
+	alice, err := d.CreateUser(SCIMUserInput{
+		UserName:   "alice@example.com",
+		ExternalID: "00u1",
+		Name:       &SCIMName{GivenName: "Alice", FamilyName: "Ng"},
+		Emails:     []SCIMEmail{{Value: "alice@example.com", Type: "work", Primary: true}},
+	})
+	if err != nil || !alice.Active || alice.Meta.Location != "/scim/v2/Users/"+alice.ID {
+		t.Fatalf("create user failed: %+v err=%v", alice, err)
+	}
+	if _, err := d.CreateUser(SCIMUserInput{UserName: "bob@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	var scimErr *SCIMError
+	if _, err := d.CreateUser(SCIMUserInput{UserName: "ALICE@example.com"}); !errors.As(err, &scimErr) || scimErr.Status != http.StatusConflict || scimErr.ScimType != "uniqueness" {
+		t.Fatalf("expected uniqueness conflict, got %v", err)
+	}
+
+	for filter, want := range map[string]int{
+		`userName eq "Alice@Example.com"`:                        1,
+		`externalId eq "00u1"`:                                   1,
+		`userName sw "b" or name.givenName eq "alice"`:           2,
+		`emails[type eq "work"] and active eq true`:              1,
+		`not (userName co "alice")`:                              1,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName pr`: 2,
+	} {
+		users, err := d.ListUsers(filter)
+		if err != nil || len(users) != want {
+			t.Fatalf("filter %q: got %d users err=%v, want %d", filter, len(users), err, want)
+		}
+	}
+	if _, err := d.ListUsers(`userName zz "x"`); !errors.As(err, &scimErr) || scimErr.ScimType != "invalidFilter" {
+		t.Fatalf("expected invalid filter error, got %v", err)
+	}
+
+	patched, err := d.PatchUser(alice.ID, []SCIMPatchOperation{
+		{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		{Op: "replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"alice@corp.example.com"`)},
+		{Op: "add", Value: json.RawMessage(`{"displayName":"Alice Ng","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department":"sre"}`)},
+	})
+	if err != nil {
+		t.Fatalf("patch user failed: %v", err)
+	}
+	if patched.Active || patched.DisplayName != "Alice Ng" || len(patched.Emails) != 1 || patched.Emails[0].Value != "alice@corp.example.com" {
+		t.Fatalf("unexpected patched user: %+v", patched)
+	}
+	if _, err := d.PatchUser(alice.ID, []SCIMPatchOperation{
+		{Op: "replace", Path: "displayName", Value: json.RawMessage(`"changed"`)},
+		{Op: "replace", Path: "nickName", Value: json.RawMessage(`"x"`)},
+	}); err == nil {
+		t.Fatalf("expected unknown attribute to fail")
+	}
+	if got, _ := d.GetUser(alice.ID); got.DisplayName != "Alice Ng" {
+		t.Fatalf("failed patch should leave user unchanged: %+v", got)
+	}
+}
+
+func TestSCIMDirectoryGroupsDriveRBACBindings(t *testing.T) {
+	d := NewSCIMDirectory()
+	rbac := NewRBACStore()
+	role, err := rbac.CreateRole(RBACRoleInput{Name: "operator", Permissions: []RBACPermission{{Resource: "runs", Action: "*"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manual, err := rbac.CreateBinding(RBACBindingInput{Subject: "carol", RoleID: role.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, _ := d.CreateUser(SCIMUserInput{UserName: "alice"})
+	bob, _ := d.CreateUser(SCIMUserInput{UserName: "bob"})
+	if _, err := d.CreateGroup(SCIMGroupInput{DisplayName: "ops", Members: []SCIMRef{{Value: "scim-user-99"}}}); err == nil {
+		t.Fatalf("expected unknown member to fail")
+	}
+	group, err := d.CreateGroup(SCIMGroupInput{DisplayName: "Ops", Members: []SCIMRef{{Value: alice.ID}}})
+	if err != nil {
+		t.Fatalf("create group failed: %v", err)
+	}
+	if _, err := d.CreateGroupMapping(SCIMGroupRoleMappingInput{Group: "ops", RoleID: role.ID, Scope: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	added, removed := rbac.SyncManagedBindings(SCIMBindingSource, d.DesiredBindings())
+	if len(added) != 1 || added[0].Subject != "alice" || added[0].Scope != "prod" || added[0].Source != SCIMBindingSource || len(removed) != 0 {
+		t.Fatalf("unexpected initial sync: added=%+v removed=%+v", added, removed)
+	}
+	if got := rbac.CheckAccess(RBACAccessCheckInput{Subject: "alice", Resource: "runs", Action: "launch", Scope: "prod"}); !got.Allowed {
+		t.Fatalf("expected provisioned access for alice: %+v", got)
+	}
+
+	group, err = d.PatchGroup(group.ID, []SCIMPatchOperation{
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + bob.ID + `"}]`)},
+		{Op: "remove", Path: `members[value eq "` + alice.ID + `"]`},
+	})
+	if err != nil || len(group.Members) != 1 || group.Members[0].Display != "bob" {
+		t.Fatalf("patch group failed: %+v err=%v", group, err)
+	}
+	if u, _ := d.GetUser(bob.ID); len(u.Groups) != 1 || u.Groups[0].Display != "Ops" {
+		t.Fatalf("expected bob's groups attribute to list Ops: %+v", u)
+	}
+	added, removed = rbac.SyncManagedBindings(SCIMBindingSource, d.DesiredBindings())
+	if len(added) != 1 || added[0].Subject != "bob" || len(removed) != 1 || removed[0].Subject != "alice" {
+		t.Fatalf("unexpected membership sync: added=%+v removed=%+v", added, removed)
+	}
+
+	if _, err := d.PatchUser(bob.ID, []SCIMPatchOperation{{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}}); err != nil {
+		t.Fatal(err)
+	}
+	_, removed = rbac.SyncManagedBindings(SCIMBindingSource, d.DesiredBindings())
+	if len(removed) != 1 || removed[0].Subject != "bob" {
+		t.Fatalf("expected deactivated user binding to be removed: %+v", removed)
+	}
+	bindings := rbac.ListBindings()
+	if len(bindings) != 1 || bindings[0].ID != manual.ID {
+		t.Fatalf("expected only the manual binding to remain: %+v", bindings)
+	}
+}
@@ -0,0 +1,241 @@
+package control
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// scimFilter evaluates a parsed SCIM filter against a resource. get returns
+// every value of a lowercased attribute path such as "emails.value".
+type scimFilter func(get func(attr string) []string) bool
+
+// parseSCIMFilter parses the RFC 7644 filter grammar: attribute
+// comparisons (eq, ne, co, sw, ew, gt, ge, lt, le, pr) combined with and,
+// or, not, and parentheses, plus single-level value filters such as
+// emails[type eq "work"]. String comparisons are case-insensitive.
+func parseSCIMFilter(raw string) (scimFilter, error) {
+	if strings.TrimSpace(raw) == "" {
+		return func(func(string) []string) bool { return true }, nil
+	}
+	tokens, err := tokenizeSCIMFilter(raw)
+	if err != nil {
+		return nil, err
+	}
+	p := &scimFilterParser{tokens: tokens}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, scimErr(http.StatusBadRequest, "invalidFilter", "unexpected "+p.tokens[p.pos].text+" in filter")
+	}
+	return match, nil
+}
+
+type scimFilterToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeSCIMFilter(raw string) ([]scimFilterToken, error) {
+	var tokens []scimFilterToken
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, scimFilterToken{text: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(raw) && raw[j] != '"' {
+				if raw[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(raw) {
+				return nil, scimErr(http.StatusBadRequest, "invalidFilter", "unterminated string in filter")
+			}
+			value, err := strconv.Unquote(raw[i : j+1])
+			if err != nil {
+				return nil, scimErr(http.StatusBadRequest, "invalidFilter", "invalid string in filter")
+			}
+			tokens = append(tokens, scimFilterToken{text: value, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(raw) && !strings.ContainsRune(" \t()[]\"", rune(raw[j])) {
+				j++
+			}
+			tokens = append(tokens, scimFilterToken{text: raw[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type scimFilterParser struct {
+	tokens []scimFilterToken
+	pos    int
+	prefix string
+}
+
+func (p *scimFilterParser) peekKeyword(word string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+func (p *scimFilterParser) next() (scimFilterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return scimFilterToken{}, false
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok, true
+}
+
+func (p *scimFilterParser) parseOr() (scimFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(get func(string) []string) bool { return l(get) || right(get) }
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseAnd() (scimFilter, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(get func(string) []string) bool { return l(get) && right(get) }
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseFactor() (scimFilter, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return func(get func(string) []string) bool { return !inner(get) }, nil
+	}
+	tok, ok := p.next()
+	if !ok {
+		return nil, scimErr(http.StatusBadRequest, "invalidFilter", "filter ended unexpectedly")
+	}
+	if tok.text == "(" && !tok.quoted {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.next(); !ok || closing.text != ")" {
+			return nil, scimErr(http.StatusBadRequest, "invalidFilter", "missing ) in filter")
+		}
+		return inner, nil
+	}
+	if tok.quoted {
+		return nil, scimErr(http.StatusBadRequest, "invalidFilter", "expected attribute name in filter")
+	}
+	attr := strings.ToLower(stripSCIMSchemaPrefix(tok.text))
+	if p.pos < len(p.tokens) && p.tokens[p.pos].text == "[" && !p.tokens[p.pos].quoted {
+		// A value filter such as emails[type eq "work"] is evaluated as the
+		// same comparison against the sub-attribute of the multi-valued
+		// attribute, which is exact for single-condition filters.
+		p.pos++
+		outer := p.prefix
+		p.prefix = attr + "."
+		inner, err := p.parseOr()
+		p.prefix = outer
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.next(); !ok || closing.text != "]" {
+			return nil, scimErr(http.StatusBadRequest, "invalidFilter", "missing ] in filter")
+		}
+		return inner, nil
+	}
+	attr = p.prefix + attr
+	opTok, ok := p.next()
+	if !ok || opTok.quoted {
+		return nil, scimErr(http.StatusBadRequest, "invalidFilter", "expected operator after "+tok.text)
+	}
+	op := strings.ToLower(opTok.text)
+	if op == "pr" {
+		return func(get func(string) []string) bool {
+			for _, v := range get(attr) {
+				if v != "" {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	valTok, ok := p.next()
+	if !ok {
+		return nil, scimErr(http.StatusBadRequest, "invalidFilter", "expected value after "+opTok.text)
+	}
+	want := strings.ToLower(valTok.text)
+	if !valTok.quoted && want == "null" {
+		want = ""
+	}
+	var cmp func(have string) bool
+	switch op {
+	case "eq":
+		cmp = func(have string) bool { return have == want }
+	case "ne":
+		cmp = func(have string) bool { return have != want }
+	case "co":
+		cmp = func(have string) bool { return strings.Contains(have, want) }
+	case "sw":
+		cmp = func(have string) bool { return strings.HasPrefix(have, want) }
+	case "ew":
+		cmp = func(have string) bool { return strings.HasSuffix(have, want) }
+	case "gt":
+		cmp = func(have string) bool { return have > want }
+	case "ge":
+		cmp = func(have string) bool { return have >= want }
+	case "lt":
+		cmp = func(have string) bool { return have < want }
+	case "le":
+		cmp = func(have string) bool { return have <= want }
+	default:
+		return nil, scimErr(http.StatusBadRequest, "invalidFilter", "unsupported filter operator "+opTok.text)
+	}
+	return func(get func(string) []string) bool {
+		values := get(attr)
+		if op == "ne" {
+			for _, v := range values {
+				if !cmp(strings.ToLower(v)) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, v := range values {
+			if cmp(strings.ToLower(v)) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
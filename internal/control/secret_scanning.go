@@ -0,0 +1,430 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretScanPolicy decides what happens when content entering the control
+// plane looks like it carries credentials. Mode applies to every kind unless
+// KindModes overrides it (e.g. block data bags, warn on templates).
+type SecretScanPolicy struct {
+	Mode             string            `json:"mode"` // off|warn|block
+	KindModes        map[string]string `json:"kind_modes,omitempty"`
+	EntropyThreshold float64           `json:"entropy_threshold"`
+	MinTokenLength   int               `json:"min_token_length"`
+	Allowlist        []string          `json:"allowlist,omitempty"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+type SecretScanInput struct {
+	Kind        string `json:"kind"` // config|template|data_bag|gitops
+	Target      string `json:"target"`
+	Content     string `json:"content"`
+	Team        string `json:"team,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Service     string `json:"service,omitempty"`
+}
+
+// SecretFinding never carries the matched secret, only a redacted preview.
+type SecretFinding struct {
+	Rule     string  `json:"rule"`
+	Severity string  `json:"severity"`
+	Line     int     `json:"line"`
+	Preview  string  `json:"preview"`
+	Entropy  float64 `json:"entropy,omitempty"`
+}
+
+type SecretScanResult struct {
+	ID               string          `json:"id"`
+	Kind             string          `json:"kind"`
+	Target           string          `json:"target"`
+	Mode             string          `json:"mode"`
+	Status           string          `json:"status"` // clean|warned|blocked|skipped
+	Blocked          bool            `json:"blocked"`
+	Findings         []SecretFinding `json:"findings"`
+	ComplianceScanID string          `json:"compliance_scan_id,omitempty"`
+	ScannedAt        time.Time       `json:"scanned_at"`
+}
+
+type secretScanRule struct {
+	id       string
+	severity string
+	pattern  *regexp.Regexp
+}
+
+var secretScanRules = []secretScanRule{
+	{id: "aws_access_key_id", severity: "critical", pattern: regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{id: "aws_secret_access_key", severity: "critical", pattern: regexp.MustCompile(`(?i)aws.{0,20}(secret|private)?.{0,20}['"]?\s*[:=]\s*['"]?[A-Za-z0-9/+=]{40}\b`)},
+	{id: "private_key", severity: "critical", pattern: regexp.MustCompile(`-----BEGIN ((RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----`)},
+	{id: "github_token", severity: "high", pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{id: "slack_token", severity: "high", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{id: "google_api_key", severity: "high", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{id: "jwt", severity: "medium", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{id: "password_assignment", severity: "high", pattern: regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key)['"]?\s*[:=]\s*['"]?[^\s'"{$<][^\s'"]{7,}`)},
+}
+
+// secretScanRuleIDs lists every control a scan evaluates, including the
+// entropy heuristic, in the order reported to the compliance scorecard.
+func secretScanRuleIDs() []string {
+	out := make([]string, 0, len(secretScanRules)+1)
+	for _, rule := range secretScanRules {
+		out = append(out, rule.id)
+	}
+	return append(out, "high_entropy_string")
+}
+
+var secretEntropyTokenRe = regexp.MustCompile(`[A-Za-z0-9+/_=-]+`)
+var secretHexTokenRe = regexp.MustCompile(`^[A-Fa-f0-9]+$`)
+
+type SecretScanStore struct {
+	mu        sync.RWMutex
+	nextID    int64
+	policy    SecretScanPolicy
+	allowlist []*regexp.Regexp
+	results   map[string]*SecretScanResult
+}
+
+func NewSecretScanStore() *SecretScanStore {
+	return &SecretScanStore{
+		policy: SecretScanPolicy{
+			Mode:             "warn",
+			EntropyThreshold: 4.0,
+			MinTokenLength:   24,
+			UpdatedAt:        time.Now().UTC(),
+		},
+		results: map[string]*SecretScanResult{},
+	}
+}
+
+func (s *SecretScanStore) Policy() SecretScanPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneSecretScanPolicy(s.policy)
+}
+
+func (s *SecretScanStore) SetPolicy(in SecretScanPolicy) (SecretScanPolicy, error) {
+	mode := strings.ToLower(strings.TrimSpace(in.Mode))
+	if mode == "" {
+		mode = "warn"
+	}
+	if !validSecretScanMode(mode) {
+		return SecretScanPolicy{}, errors.New("mode must be off, warn, or block")
+	}
+	kindModes := map[string]string{}
+	for kind, value := range in.KindModes {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if kind == "" {
+			continue
+		}
+		if !validSecretScanMode(value) {
+			return SecretScanPolicy{}, errors.New("kind_modes values must be off, warn, or block")
+		}
+		kindModes[kind] = value
+	}
+	allowlist := make([]*regexp.Regexp, 0, len(in.Allowlist))
+	patterns := make([]string, 0, len(in.Allowlist))
+	for _, raw := range in.Allowlist {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return SecretScanPolicy{}, fmt.Errorf("invalid allowlist pattern %q: %v", raw, err)
+		}
+		allowlist = append(allowlist, re)
+		patterns = append(patterns, raw)
+	}
+	if in.EntropyThreshold <= 0 {
+		in.EntropyThreshold = 4.0
+	}
+	if in.MinTokenLength <= 0 {
+		in.MinTokenLength = 24
+	}
+	policy := SecretScanPolicy{
+		Mode:             mode,
+		KindModes:        kindModes,
+		EntropyThreshold: in.EntropyThreshold,
+		MinTokenLength:   in.MinTokenLength,
+		Allowlist:        patterns,
+		UpdatedAt:        time.Now().UTC(),
+	}
+	s.mu.Lock()
+	s.policy = policy
+	s.allowlist = allowlist
+	s.mu.Unlock()
+	return cloneSecretScanPolicy(policy), nil
+}
+
+// Scan checks content against the credential rules and the entropy
+// heuristic and records the outcome. A result is blocked only when findings
+// exist and the effective mode for the kind is block.
+func (s *SecretScanStore) Scan(in SecretScanInput) (SecretScanResult, error) {
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	target := strings.TrimSpace(in.Target)
+	if kind == "" || target == "" {
+		return SecretScanResult{}, errors.New("kind and target are required")
+	}
+	s.mu.RLock()
+	policy := cloneSecretScanPolicy(s.policy)
+	allowlist := append([]*regexp.Regexp{}, s.allowlist...)
+	s.mu.RUnlock()
+
+	mode := policy.Mode
+	if override, ok := policy.KindModes[kind]; ok {
+		mode = override
+	}
+	result := SecretScanResult{
+		Kind:      kind,
+		Target:    target,
+		Mode:      mode,
+		Findings:  []SecretFinding{},
+		ScannedAt: time.Now().UTC(),
+	}
+	if mode == "off" {
+		result.Status = "skipped"
+	} else {
+		result.Findings = scanSecretContent(in.Content, policy, allowlist)
+		result.Status = "clean"
+		if len(result.Findings) > 0 {
+			result.Status = "warned"
+			if mode == "block" {
+				result.Status = "blocked"
+				result.Blocked = true
+			}
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	result.ID = "secret-scan-" + itoa(s.nextID)
+	s.results[result.ID] = &result
+	return cloneSecretScanResult(result), nil
+}
+
+// LinkComplianceScan notes the scorecard entry a scan was recorded as.
+func (s *SecretScanStore) LinkComplianceScan(id, scanID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item, ok := s.results[id]; ok {
+		item.ComplianceScanID = scanID
+	}
+}
+
+func (s *SecretScanStore) List(kind string, limit int) []SecretScanResult {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	s.mu.RLock()
+	out := make([]SecretScanResult, 0, len(s.results))
+	for _, item := range s.results {
+		if kind != "" && item.Kind != kind {
+			continue
+		}
+		out = append(out, cloneSecretScanResult(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ScannedAt.Equal(out[j].ScannedAt) {
+			return out[i].ID > out[j].ID
+		}
+		return out[i].ScannedAt.After(out[j].ScannedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (s *SecretScanStore) Get(id string) (SecretScanResult, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.results[strings.TrimSpace(id)]
+	if !ok {
+		return SecretScanResult{}, false
+	}
+	return cloneSecretScanResult(*item), true
+}
+
+// SecretScanComplianceScan converts a result into a compliance scan with one
+// control per rule, so intake findings show up on the team/environment/
+// service scorecards alongside profile scans.
+func SecretScanComplianceScan(result SecretScanResult, in SecretScanInput) ComplianceScan {
+	failed := map[string]SecretFinding{}
+	counts := map[string]int{}
+	for _, finding := range result.Findings {
+		if _, ok := failed[finding.Rule]; !ok {
+			failed[finding.Rule] = finding
+		}
+		counts[finding.Rule]++
+	}
+	rules := secretScanRuleIDs()
+	findings := make([]ComplianceFinding, 0, len(rules))
+	passCount := 0
+	for _, rule := range rules {
+		finding, hit := failed[rule]
+		if !hit {
+			passCount++
+			findings = append(findings, ComplianceFinding{
+				ControlID: "secret-scan/" + rule,
+				Status:    "pass",
+				Severity:  secretScanRuleSeverity(rule),
+				Message:   "no " + rule + " detected",
+				Evidence:  fmt.Sprintf("scan=%s target=%s/%s", result.ID, result.Kind, result.Target),
+			})
+			continue
+		}
+		findings = append(findings, ComplianceFinding{
+			ControlID: "secret-scan/" + rule,
+			Status:    "fail",
+			Severity:  finding.Severity,
+			Message:   fmt.Sprintf("%d %s finding(s), first at line %d", counts[rule], rule, finding.Line),
+			Evidence:  fmt.Sprintf("scan=%s target=%s/%s preview=%s", result.ID, result.Kind, result.Target, finding.Preview),
+		})
+	}
+	status := "pass"
+	if passCount < len(rules) {
+		status = "fail"
+	}
+	return ComplianceScan{
+		ProfileID:   "secret-scanning",
+		TargetKind:  result.Kind,
+		TargetName:  result.Target,
+		Team:        strings.TrimSpace(in.Team),
+		Environment: strings.TrimSpace(in.Environment),
+		Service:     strings.TrimSpace(in.Service),
+		Status:      status,
+		Score:       passCount * 100 / len(rules),
+		StartedAt:   result.ScannedAt,
+		EndedAt:     result.ScannedAt,
+		Findings:    findings,
+	}
+}
+
+func scanSecretContent(content string, policy SecretScanPolicy, allowlist []*regexp.Regexp) []SecretFinding {
+	findings := make([]SecretFinding, 0)
+	for i, line := range strings.Split(content, "\n") {
+		if secretLineAllowed(line, allowlist) {
+			continue
+		}
+		matched := false
+		for _, rule := range secretScanRules {
+			loc := rule.pattern.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			matched = true
+			findings = append(findings, SecretFinding{
+				Rule:     rule.id,
+				Severity: rule.severity,
+				Line:     i + 1,
+				Preview:  redactSecret(line[loc[0]:loc[1]]),
+			})
+		}
+		if matched {
+			continue
+		}
+		for _, token := range secretEntropyTokenRe.FindAllString(line, -1) {
+			if len(token) < policy.MinTokenLength || secretHexTokenRe.MatchString(token) || !mixedCharClasses(token) {
+				continue
+			}
+			entropy := shannonEntropy(token)
+			if entropy < policy.EntropyThreshold {
+				continue
+			}
+			findings = append(findings, SecretFinding{
+				Rule:     "high_entropy_string",
+				Severity: "medium",
+				Line:     i + 1,
+				Preview:  redactSecret(token),
+				Entropy:  math.Round(entropy*100) / 100,
+			})
+			break
+		}
+	}
+	return findings
+}
+
+func secretLineAllowed(line string, allowlist []*regexp.Regexp) bool {
+	for _, re := range allowlist {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func secretScanRuleSeverity(rule string) string {
+	for _, item := range secretScanRules {
+		if item.id == rule {
+			return item.severity
+		}
+	}
+	return "medium"
+}
+
+func shannonEntropy(in string) float64 {
+	if in == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, r := range in {
+		counts[r]++
+	}
+	total := float64(len([]rune(in)))
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func mixedCharClasses(in string) bool {
+	var upper, lower, digit bool
+	for _, r := range in {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// redactSecret keeps just enough of a match to locate it.
+func redactSecret(in string) string {
+	if len(in) <= 8 {
+		return strings.Repeat("*", len(in))
+	}
+	return in[:4] + strings.Repeat("*", 8) + " (" + itoa(int64(len(in))) + " chars)"
+}
+
+func validSecretScanMode(mode string) bool {
+	return mode == "off" || mode == "warn" || mode == "block"
+}
+
+func cloneSecretScanPolicy(in SecretScanPolicy) SecretScanPolicy {
+	out := in
+	out.KindModes = map[string]string{}
+	for k, v := range in.KindModes {
+		out.KindModes[k] = v
+	}
+	out.Allowlist = append([]string{}, in.Allowlist...)
+	return out
+}
+
+func cloneSecretScanResult(in SecretScanResult) SecretScanResult {
+	out := in
+	out.Findings = append([]SecretFinding{}, in.Findings...)
+	return out
+}
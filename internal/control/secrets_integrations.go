@@ -173,6 +173,21 @@ func (s *SecretsIntegrationStore) Resolve(in SecretResolveInput) (SecretResolveR
 	return result, nil
 }
 
+// RecordUsage appends a trace for a secret served outside Resolve, such as
+// a brokered cloud credential. The value is always stored redacted.
+func (s *SecretsIntegrationStore) RecordUsage(in SecretUsageTrace) SecretUsageTrace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextTrace++
+	in.ID = "secret-trace-" + itoa(s.nextTrace)
+	in.RedactedValue = "<redacted>"
+	if in.ResolvedAt.IsZero() {
+		in.ResolvedAt = time.Now().UTC()
+	}
+	s.traces = append(s.traces, in)
+	return in
+}
+
 func (s *SecretsIntegrationStore) ListUsageTraces(limit int) []SecretUsageTrace {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -189,6 +204,42 @@ func (s *SecretsIntegrationStore) ListUsageTraces(limit int) []SecretUsageTrace
 	return out
 }
 
+// Restore inserts an integration with its existing ID. Inline "secret.*"
+// values in Config are loaded as with Upsert.
+func (s *SecretsIntegrationStore) Restore(in SecretsIntegration) (SecretsIntegration, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	in.Name = strings.TrimSpace(in.Name)
+	in.Provider = strings.ToLower(strings.TrimSpace(in.Provider))
+	if in.ID == "" || in.Name == "" || in.Provider == "" {
+		return SecretsIntegration{}, errors.New("id, name, and provider are required")
+	}
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = time.Now().UTC()
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = in.CreatedAt
+	}
+	item := cloneSecretsIntegration(in)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextIntegration = advanceRestoredID(s.nextIntegration, item.ID, "secret-integration-")
+	s.integrations[item.ID] = &item
+	s.secrets[item.ID] = extractInlineSecrets(item.Config)
+	return cloneSecretsIntegration(item), nil
+}
+
+// SecretsIntegrationMetadata drops inline "secret.*" values so an integration
+// can be exported without the secrets it serves.
+func SecretsIntegrationMetadata(in SecretsIntegration) SecretsIntegration {
+	out := cloneSecretsIntegration(in)
+	for k := range out.Config {
+		if strings.HasPrefix(k, "secret.") {
+			delete(out.Config, k)
+		}
+	}
+	return out
+}
+
 func extractInlineSecrets(cfg map[string]string) map[string]string {
 	out := map[string]string{}
 	for k, v := range cfg {
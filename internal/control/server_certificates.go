@@ -0,0 +1,230 @@
+package control
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerACMEConfig points server certificate issuance at an ACME directory.
+type ServerACMEConfig struct {
+	DirectoryURL string    `json:"directory_url"`
+	Email        string    `json:"email,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ServerCertificateRequest struct {
+	Domains []string `json:"domains"`
+}
+
+// ServerCertificate is a TLS certificate for the control plane itself. The
+// key never leaves disk; CertPath/KeyPath are where the chain and key were
+// written for the listener or fronting proxy to load.
+type ServerCertificate struct {
+	ID        string    `json:"id"`
+	Domains   []string  `json:"domains"`
+	Backend   string    `json:"backend"`
+	Status    string    `json:"status"` // issued|failed
+	Serial    string    `json:"serial,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	NotAfter  time.Time `json:"not_after,omitempty"`
+	CertPath  string    `json:"cert_path,omitempty"`
+	KeyPath   string    `json:"key_path,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ServerCertificateStore struct {
+	mu         sync.RWMutex
+	nextID     int64
+	dir        string
+	acme       ServerACMEConfig
+	client     *ACMEClient
+	challenges *ACMEChallengeStore
+	certs      map[string]*ServerCertificate
+}
+
+func NewServerCertificateStore(dir string, challenges *ACMEChallengeStore) *ServerCertificateStore {
+	return &ServerCertificateStore{
+		dir:        dir,
+		challenges: challenges,
+		certs:      map[string]*ServerCertificate{},
+	}
+}
+
+func (s *ServerCertificateStore) ACME() ServerACMEConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.acme
+}
+
+func (s *ServerCertificateStore) SetACME(cfg ServerACMEConfig) (ServerACMEConfig, error) {
+	cfg.DirectoryURL = strings.TrimSpace(cfg.DirectoryURL)
+	cfg.Email = strings.TrimSpace(cfg.Email)
+	parsed, err := url.Parse(cfg.DirectoryURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return ServerACMEConfig{}, errors.New("directory_url must be an absolute http(s) url")
+	}
+	cfg.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	s.acme = cfg
+	s.client = NewACMEClient(cfg.DirectoryURL, cfg.Email)
+	s.mu.Unlock()
+	return cfg, nil
+}
+
+// SetPollInterval adjusts how often pending ACME orders are polled.
+func (s *ServerCertificateStore) SetPollInterval(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		s.client.PollInterval = d
+	}
+}
+
+// Request orders a certificate for the domains over ACME and writes the
+// chain and a freshly generated key under the store directory.
+func (s *ServerCertificateStore) Request(in ServerCertificateRequest) (ServerCertificate, error) {
+	domains := normalizeStringSlice(in.Domains)
+	if len(domains) == 0 {
+		return ServerCertificate{}, errors.New("at least one domain is required")
+	}
+	s.mu.Lock()
+	if s.client == nil {
+		s.mu.Unlock()
+		return ServerCertificate{}, errors.New("acme is not configured")
+	}
+	s.nextID++
+	now := time.Now().UTC()
+	item := &ServerCertificate{
+		ID:        "server-cert-" + itoa(s.nextID),
+		Domains:   domains,
+		Backend:   "acme",
+		CreatedAt: now,
+	}
+	s.certs[item.ID] = item
+	s.mu.Unlock()
+	return s.issue(item)
+}
+
+// Renew reorders a certificate for the same domains with a new key.
+func (s *ServerCertificateStore) Renew(id string) (ServerCertificate, error) {
+	s.mu.RLock()
+	item, ok := s.certs[strings.TrimSpace(id)]
+	s.mu.RUnlock()
+	if !ok {
+		return ServerCertificate{}, errors.New("server certificate not found")
+	}
+	return s.issue(item)
+}
+
+func (s *ServerCertificateStore) issue(item *ServerCertificate) (ServerCertificate, error) {
+	s.mu.RLock()
+	client, domains := s.client, append([]string{}, item.Domains...)
+	s.mu.RUnlock()
+	if client == nil {
+		return ServerCertificate{}, errors.New("acme is not configured")
+	}
+	chain, keyPEM, err := obtainServerCertificate(client, domains, s.challenges)
+	var certPath, keyPath string
+	if err == nil {
+		certPath, keyPath, err = s.writeFiles(item.ID, encodeCertificatesPEM(chain), keyPEM)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item.UpdatedAt = time.Now().UTC()
+	if err != nil {
+		item.Status = "failed"
+		item.Error = err.Error()
+		return cloneServerCertificate(*item), err
+	}
+	leaf := chain[0]
+	item.Status = "issued"
+	item.Error = ""
+	item.Serial = strings.ToUpper(leaf.SerialNumber.Text(16))
+	item.Issuer = leaf.Issuer.String()
+	item.NotAfter = leaf.NotAfter.UTC()
+	item.CertPath = certPath
+	item.KeyPath = keyPath
+	return cloneServerCertificate(*item), nil
+}
+
+func (s *ServerCertificateStore) writeFiles(id, certPEM string, keyPEM []byte) (string, string, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", "", err
+	}
+	certPath := filepath.Join(s.dir, id+".crt")
+	keyPath := filepath.Join(s.dir, id+".key")
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(certPath, []byte(certPEM), 0o644); err != nil {
+		return "", "", err
+	}
+	return certPath, keyPath, nil
+}
+
+func (s *ServerCertificateStore) List() []ServerCertificate {
+	s.mu.RLock()
+	out := make([]ServerCertificate, 0, len(s.certs))
+	for _, item := range s.certs {
+		out = append(out, cloneServerCertificate(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *ServerCertificateStore) Get(id string) (ServerCertificate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.certs[strings.TrimSpace(id)]
+	if !ok {
+		return ServerCertificate{}, false
+	}
+	return cloneServerCertificate(*item), true
+}
+
+func obtainServerCertificate(client *ACMEClient, domains []string, solver ACMEHTTP01Solver) ([]*x509.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	chain, err := client.ObtainCertificate(ctx, csrDER, solver)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chain, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+func cloneServerCertificate(in ServerCertificate) ServerCertificate {
+	out := in
+	out.Domains = append([]string{}, in.Domains...)
+	return out
+}
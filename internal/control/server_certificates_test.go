@@ -0,0 +1,190 @@
+package control
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeACMEServer implements enough of RFC 8555 to issue one certificate per
+// order. It verifies every JWS and validates http-01 through fetch.
+type fakeACMEServer struct {
+	*httptest.Server
+	t        *testing.T
+	ca       testCA
+	fetch    func(token string) (string, bool)
+	mu       sync.Mutex
+	nonces   int
+	account  *ecdsa.PublicKey
+	thumb    string
+	status   string
+	certPEM  []byte
+	badNonce bool
+}
+
+func newFakeACMEServer(t *testing.T, ca testCA, fetch func(token string) (string, bool)) *fakeACMEServer {
+	f := &fakeACMEServer{t: t, ca: ca, fetch: fetch, status: "pending"}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeACMEServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nonces++
+	w.Header().Set("Replay-Nonce", "nonce-"+strconv.Itoa(f.nonces))
+	if r.URL.Path == "/directory" {
+		writeFakeACME(w, http.StatusOK, map[string]string{
+			"newNonce":   f.URL + "/nonce",
+			"newAccount": f.URL + "/account",
+			"newOrder":   f.URL + "/order",
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		return
+	}
+	var jws struct{ Protected, Payload, Signature string }
+	_ = json.NewDecoder(r.Body).Decode(&jws)
+	header, _ := base64.RawURLEncoding.DecodeString(jws.Protected)
+	var protected struct {
+		Nonce, URL, Kid string
+		JWK             map[string]string
+	}
+	_ = json.Unmarshal(header, &protected)
+	if f.badNonce {
+		f.badNonce = false
+		writeFakeACME(w, http.StatusBadRequest, map[string]string{"type": "urn:ietf:params:acme:error:badNonce"})
+		return
+	}
+	if protected.URL != f.URL+r.URL.Path || !strings.HasPrefix(protected.Nonce, "nonce-") {
+		f.t.Errorf("bad jws header %+v for %s", protected, r.URL.Path)
+	}
+	if r.URL.Path == "/account" {
+		x, _ := base64.RawURLEncoding.DecodeString(protected.JWK["x"])
+		y, _ := base64.RawURLEncoding.DecodeString(protected.JWK["y"])
+		f.account = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		sum := sha256.Sum256([]byte(`{"crv":"P-256","kty":"EC","x":"` + protected.JWK["x"] + `","y":"` + protected.JWK["y"] + `"}`))
+		f.thumb = base64.RawURLEncoding.EncodeToString(sum[:])
+		w.Header().Set("Location", f.URL+"/acct/1")
+		writeFakeACME(w, http.StatusCreated, map[string]string{"status": "valid"})
+		return
+	}
+	if protected.Kid != f.URL+"/acct/1" {
+		f.t.Errorf("expected kid auth for %s, got %+v", r.URL.Path, protected)
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(jws.Signature)
+	digest := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	if len(sig) != 64 || !ecdsa.Verify(f.account, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		f.t.Errorf("jws signature invalid for %s", r.URL.Path)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+	order := map[string]any{
+		"authorizations": []string{f.URL + "/authz/1"},
+		"finalize":       f.URL + "/finalize/1",
+	}
+	switch r.URL.Path {
+	case "/order", "/order/1":
+		order["status"] = "pending"
+		if f.certPEM != nil {
+			order["status"] = "valid"
+			order["certificate"] = f.URL + "/cert/1"
+		}
+		w.Header().Set("Location", f.URL+"/order/1")
+		writeFakeACME(w, http.StatusCreated, order)
+	case "/authz/1":
+		writeFakeACME(w, http.StatusOK, map[string]any{
+			"status":     f.status,
+			"identifier": map[string]string{"type": "dns", "value": "cp.example.test"},
+			"challenges": []map[string]string{
+				{"type": "dns-01", "url": f.URL + "/chall/dns", "token": "dns"},
+				{"type": "http-01", "url": f.URL + "/chall/1", "token": "tok-1"},
+			},
+		})
+	case "/chall/1":
+		f.mu.Unlock()
+		keyAuth, ok := f.fetch("tok-1")
+		f.mu.Lock()
+		if ok && keyAuth == "tok-1."+f.thumb {
+			f.status = "valid"
+		} else {
+			f.status = "invalid"
+		}
+		writeFakeACME(w, http.StatusOK, map[string]string{"status": "processing"})
+	case "/finalize/1":
+		var req struct{ CSR string }
+		_ = json.Unmarshal(payload, &req)
+		csrDER, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		leaf := f.ca.sign(f.t, csrDER, 7)
+		f.certPEM = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.ca.cert.Raw})...)
+		order["status"] = "processing"
+		writeFakeACME(w, http.StatusOK, order)
+	case "/cert/1":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(f.certPEM)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeFakeACME(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestServerCertificateStoreObtainsCertificateOverACME(t *testing.T) {
+	ca := newTestCA(t)
+	challenges := NewACMEChallengeStore()
+	acme := newFakeACMEServer(t, ca, challenges.Get)
+	acme.badNonce = true
+	dir := t.TempDir()
+	store := NewServerCertificateStore(dir, challenges)
+
+	if _, err := store.Request(ServerCertificateRequest{Domains: []string{"cp.example.test"}}); err == nil {
+		t.Fatalf("expected request without acme config to fail")
+	}
+	if _, err := store.SetACME(ServerACMEConfig{DirectoryURL: acme.URL + "/directory", Email: "ops@example.test"}); err != nil {
+		t.Fatal(err)
+	}
+	store.SetPollInterval(10 * time.Millisecond)
+	item, err := store.Request(ServerCertificateRequest{Domains: []string{"cp.example.test"}})
+	if err != nil {
+		t.Fatalf("acme request failed: %v", err)
+	}
+	if item.Status != "issued" || item.Serial != "7" || !strings.Contains(item.Issuer, "Test Enterprise CA") {
+		t.Fatalf("unexpected server certificate: %+v", item)
+	}
+	chain, err := os.ReadFile(item.CertPath)
+	if err != nil || strings.Count(string(chain), "BEGIN CERTIFICATE") != 2 {
+		t.Fatalf("expected chain written to disk: %v", err)
+	}
+	if info, err := os.Stat(item.KeyPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected private key written with 0600: %v", err)
+	}
+	if _, ok := challenges.Get("tok-1"); ok {
+		t.Fatalf("expected challenge token cleaned up after validation")
+	}
+
+	acme.mu.Lock()
+	acme.status = "pending"
+	acme.certPEM = nil
+	acme.mu.Unlock()
+	renewed, err := store.Renew(item.ID)
+	if err != nil || renewed.Status != "issued" {
+		t.Fatalf("renew failed: %+v err=%v", renewed, err)
+	}
+}
@@ -84,6 +84,22 @@ func (s *SessionRecordingStore) Get(id string) (SessionRecording, error) {
 	return item, nil
 }
 
+// MoveTo relocates a recording into dst, e.g. when migrating shared history
+// into a workspace partition.
+func (s *SessionRecordingStore) MoveTo(id string, dst *SessionRecordingStore) error {
+	if dst == nil {
+		return errors.New("destination session store is required")
+	}
+	item, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst.sessionsDir(), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(item.Path, filepath.Join(dst.sessionsDir(), item.ID+".json"))
+}
+
 func (s *SessionRecordingStore) readByID(id string) (SessionRecording, error) {
 	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
 		return SessionRecording{}, errors.New("invalid session id")
@@ -0,0 +1,219 @@
+package control
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SSOSessionPolicy bounds SSO session lifetimes. Sessions expire after
+// IdleTimeoutSeconds without use (each authenticated use slides the window)
+// and never outlive AbsoluteLifetimeSeconds from issue. When a user exceeds
+// MaxConcurrentSessions (0 means unlimited) their oldest sessions are ended.
+type SSOSessionPolicy struct {
+	IdleTimeoutSeconds      int       `json:"idle_timeout_seconds"`
+	AbsoluteLifetimeSeconds int       `json:"absolute_lifetime_seconds"`
+	MaxConcurrentSessions   int       `json:"max_concurrent_sessions"`
+	UpdatedAt               time.Time `json:"updated_at,omitempty"`
+}
+
+func defaultSSOSessionPolicy() SSOSessionPolicy {
+	return SSOSessionPolicy{
+		IdleTimeoutSeconds:      30 * 60,
+		AbsoluteLifetimeSeconds: 8 * 60 * 60,
+		MaxConcurrentSessions:   5,
+	}
+}
+
+func (s *IdentityStore) SessionPolicy() SSOSessionPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sessionPolicy
+}
+
+// SetSessionPolicy replaces the session policy. Existing sessions are held
+// to the new limits but never gain lifetime they were not issued with.
+func (s *IdentityStore) SetSessionPolicy(in SSOSessionPolicy) (SSOSessionPolicy, error) {
+	if in.IdleTimeoutSeconds <= 0 || in.AbsoluteLifetimeSeconds <= 0 {
+		return SSOSessionPolicy{}, errors.New("idle_timeout_seconds and absolute_lifetime_seconds must be positive")
+	}
+	if in.IdleTimeoutSeconds > in.AbsoluteLifetimeSeconds {
+		return SSOSessionPolicy{}, errors.New("idle_timeout_seconds must not exceed absolute_lifetime_seconds")
+	}
+	if in.MaxConcurrentSessions < 0 {
+		return SSOSessionPolicy{}, errors.New("max_concurrent_sessions must not be negative")
+	}
+	now := time.Now().UTC()
+	in.UpdatedAt = now
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessionPolicy = in
+	absolute := time.Duration(in.AbsoluteLifetimeSeconds) * time.Second
+	for _, item := range s.sessions {
+		if limit := item.IssuedAt.Add(absolute); limit.Before(item.ExpiresAt) {
+			item.ExpiresAt = limit
+		}
+		s.slideSessionLocked(item, item.LastSeenAt)
+	}
+	s.expireSessionsLocked(now)
+	for email := range s.sessionEmailsLocked() {
+		s.enforceSessionLimitLocked(email)
+	}
+	return in, nil
+}
+
+// issueSessionLocked mints tokens for item, stores it, and applies the
+// concurrent session limit for its user.
+func (s *IdentityStore) issueSessionLocked(item SSOSession, now time.Time) (SSOSession, error) {
+	token, err := randomToken(24)
+	if err != nil {
+		return SSOSession{}, err
+	}
+	csrf, err := randomToken(16)
+	if err != nil {
+		return SSOSession{}, err
+	}
+	s.expireSessionsLocked(now)
+	s.nextSession++
+	item.ID = "sso-session-" + itoa(s.nextSession)
+	item.Token = "mcsso_" + token
+	item.CSRFToken = csrf
+	item.IssuedAt = now
+	item.ExpiresAt = now.Add(time.Duration(s.sessionPolicy.AbsoluteLifetimeSeconds) * time.Second)
+	s.slideSessionLocked(&item, now)
+	s.sessions[item.ID] = &item
+	s.enforceSessionLimitLocked(item.Email)
+	return cloneSSOSession(item), nil
+}
+
+func (s *IdentityStore) slideSessionLocked(item *SSOSession, seen time.Time) {
+	item.LastSeenAt = seen
+	item.IdleExpiresAt = seen.Add(time.Duration(s.sessionPolicy.IdleTimeoutSeconds) * time.Second)
+	if item.IdleExpiresAt.After(item.ExpiresAt) {
+		item.IdleExpiresAt = item.ExpiresAt
+	}
+}
+
+func (s *IdentityStore) enforceSessionLimitLocked(email string) {
+	limit := s.sessionPolicy.MaxConcurrentSessions
+	if limit <= 0 {
+		return
+	}
+	owned := []*SSOSession{}
+	for _, item := range s.sessions {
+		if item.Email == email {
+			owned = append(owned, item)
+		}
+	}
+	if len(owned) <= limit {
+		return
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		if !owned[i].IssuedAt.Equal(owned[j].IssuedAt) {
+			return owned[i].IssuedAt.Before(owned[j].IssuedAt)
+		}
+		return owned[i].ID < owned[j].ID
+	})
+	for _, item := range owned[:len(owned)-limit] {
+		delete(s.sessions, item.ID)
+	}
+}
+
+func (s *IdentityStore) sessionEmailsLocked() map[string]bool {
+	out := map[string]bool{}
+	for _, item := range s.sessions {
+		out[item.Email] = true
+	}
+	return out
+}
+
+func (s *IdentityStore) expireSessionsLocked(now time.Time) {
+	for id, item := range s.sessions {
+		if !now.Before(item.ExpiresAt) || !now.Before(item.IdleExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+func (s *IdentityStore) sessionByTokenLocked(token string) *SSOSession {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil
+	}
+	for _, item := range s.sessions {
+		if subtle.ConstantTimeCompare([]byte(item.Token), []byte(token)) == 1 {
+			return item
+		}
+	}
+	return nil
+}
+
+// AuthenticateSession resolves a session token and slides its idle window.
+func (s *IdentityStore) AuthenticateSession(token string) (SSOSession, error) {
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireSessionsLocked(now)
+	item := s.sessionByTokenLocked(token)
+	if item == nil {
+		return SSOSession{}, errors.New("sso session is invalid or expired")
+	}
+	s.slideSessionLocked(item, now)
+	return cloneSSOSession(*item), nil
+}
+
+// VerifySessionCSRF authenticates token and checks csrf against the token
+// bound to that session, for browser requests carrying a session cookie.
+func (s *IdentityStore) VerifySessionCSRF(token, csrf string) (SSOSession, error) {
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireSessionsLocked(now)
+	item := s.sessionByTokenLocked(token)
+	if item == nil {
+		return SSOSession{}, errors.New("sso session is invalid or expired")
+	}
+	if subtle.ConstantTimeCompare([]byte(item.CSRFToken), []byte(strings.TrimSpace(csrf))) != 1 {
+		return SSOSession{}, errors.New("csrf token does not match the session")
+	}
+	s.slideSessionLocked(item, now)
+	return cloneSSOSession(*item), nil
+}
+
+// RefreshSession rotates the session and CSRF tokens of the session holding
+// token and slides its idle window. The absolute lifetime is unchanged.
+func (s *IdentityStore) RefreshSession(token string) (SSOSession, error) {
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireSessionsLocked(now)
+	item := s.sessionByTokenLocked(token)
+	if item == nil {
+		return SSOSession{}, errors.New("sso session is invalid or expired")
+	}
+	next, err := randomToken(24)
+	if err != nil {
+		return SSOSession{}, err
+	}
+	csrf, err := randomToken(16)
+	if err != nil {
+		return SSOSession{}, err
+	}
+	item.Token = "mcsso_" + next
+	item.CSRFToken = csrf
+	s.slideSessionLocked(item, now)
+	return cloneSSOSession(*item), nil
+}
+
+func (s *IdentityStore) RevokeSession(id string) (SSOSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.sessions[strings.TrimSpace(id)]
+	if !ok {
+		return SSOSession{}, errors.New("sso session not found")
+	}
+	delete(s.sessions, item.ID)
+	return cloneSSOSession(*item), nil
+}
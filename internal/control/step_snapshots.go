@@ -3,6 +3,7 @@ package control
 import (
 	"errors"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -154,6 +155,108 @@ func (s *StepSnapshotStore) List(q StepSnapshotQuery) []StepSnapshot {
 	return out
 }
 
+// StepSnapshotChange is one field that differs between two snapshots.
+// Metadata keys are reported as "metadata.<key>".
+type StepSnapshotChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type StepSnapshotDiff struct {
+	From     StepSnapshot         `json:"from"`
+	To       StepSnapshot         `json:"to"`
+	SameRun  bool                 `json:"same_run"`
+	SameStep bool                 `json:"same_step"`
+	Changes  []StepSnapshotChange `json:"changes"`
+}
+
+// DiffStepSnapshots lists the fields that changed from one snapshot to
+// another. Identity and timestamp fields are left out; duration is kept
+// since a slower or faster step is usually what an operator is after.
+func DiffStepSnapshots(from, to StepSnapshot) StepSnapshotDiff {
+	out := StepSnapshotDiff{
+		From:     from,
+		To:       to,
+		SameRun:  snapshotRunKey(from) != "" && snapshotRunKey(from) == snapshotRunKey(to),
+		SameStep: from.StepID == to.StepID,
+		Changes:  []StepSnapshotChange{},
+	}
+	add := func(field, a, b string) {
+		if a != b {
+			out.Changes = append(out.Changes, StepSnapshotChange{Field: field, From: a, To: b})
+		}
+	}
+	add("step_id", from.StepID, to.StepID)
+	add("resource_type", from.ResourceType, to.ResourceType)
+	add("host", from.Host, to.Host)
+	add("status", from.Status, to.Status)
+	add("duration_ms", strconv.FormatInt(from.DurationMS, 10), strconv.FormatInt(to.DurationMS, 10))
+	add("stdout_hash", from.StdoutHash, to.StdoutHash)
+	add("stderr_hash", from.StderrHash, to.StderrHash)
+	keys := map[string]struct{}{}
+	for k := range from.Metadata {
+		keys[k] = struct{}{}
+	}
+	for k := range to.Metadata {
+		keys[k] = struct{}{}
+	}
+	ordered := make([]string, 0, len(keys))
+	for k := range keys {
+		ordered = append(ordered, k)
+	}
+	sort.Strings(ordered)
+	for _, k := range ordered {
+		add("metadata."+k, from.Metadata[k], to.Metadata[k])
+	}
+	return out
+}
+
+// PriorSteps returns the latest snapshot of every step in the snapshot's
+// run that was recorded up to and including the snapshot, oldest first.
+// Runs are matched on run_id, falling back to job_id.
+func (s *StepSnapshotStore) PriorSteps(id string) ([]StepSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	target, ok := s.byID[strings.TrimSpace(id)]
+	if !ok {
+		return nil, errors.New("snapshot not found")
+	}
+	key := snapshotRunKey(target)
+	latest := map[string]int{}
+	order := []string{}
+	for i, item := range s.items {
+		isTarget := item.SnapshotID == target.SnapshotID
+		if isTarget || (key != "" && snapshotRunKey(item) == key) {
+			if _, seen := latest[item.StepID]; !seen {
+				order = append(order, item.StepID)
+			}
+			latest[item.StepID] = i
+		}
+		if isTarget {
+			break
+		}
+	}
+	out := make([]StepSnapshot, 0, len(order))
+	for _, stepID := range order {
+		item := s.items[latest[stepID]]
+		item.Metadata = cloneStringMap(item.Metadata)
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out, nil
+}
+
+func snapshotRunKey(item StepSnapshot) string {
+	if item.RunID != "" {
+		return "run:" + item.RunID
+	}
+	if item.JobID != "" {
+		return "job:" + item.JobID
+	}
+	return ""
+}
+
 func normalizeSnapshotStatus(in string) string {
 	switch strings.ToLower(strings.TrimSpace(in)) {
 	case "pending", "running", "succeeded", "failed", "skipped":
@@ -39,3 +39,57 @@ func TestStepSnapshotStoreRejectInvalidStatus(t *testing.T) {
 		t.Fatalf("expected invalid status to fail")
 	}
 }
+
+func TestStepSnapshotDiffAndPriorSteps(t *testing.T) {
+	store := NewStepSnapshotStore(100)
+	base := time.Now().UTC().Add(-time.Minute)
+	record := func(runID, stepID, status string, offset int, meta map[string]string) StepSnapshot {
+		t.Helper()
+		item, err := store.Record(StepSnapshotInput{
+			RunID:     runID,
+			StepID:    stepID,
+			Status:    status,
+			StartedAt: base.Add(time.Duration(offset) * time.Second).Format(time.RFC3339),
+			Metadata:  meta,
+		})
+		if err != nil {
+			t.Fatalf("record snapshot failed: %v", err)
+		}
+		return item
+	}
+	build := record("run-1", "build", "succeeded", 0, map[string]string{"artifact": "app-1.tgz"})
+	record("run-2", "build", "succeeded", 1, nil)
+	testFail := record("run-1", "test", "failed", 2, map[string]string{"exit_code": "1"})
+	testPass := record("run-1", "test", "succeeded", 3, map[string]string{"exit_code": "0", "report": "ok"})
+	record("run-1", "deploy", "running", 4, nil)
+
+	diff := DiffStepSnapshots(testFail, testPass)
+	if !diff.SameRun || !diff.SameStep {
+		t.Fatalf("expected snapshots of the same run and step, got %+v", diff)
+	}
+	fields := map[string]StepSnapshotChange{}
+	for _, change := range diff.Changes {
+		fields[change.Field] = change
+	}
+	if len(fields) != 3 || fields["status"].To != "succeeded" || fields["metadata.exit_code"].From != "1" || fields["metadata.report"].To != "ok" {
+		t.Fatalf("unexpected diff changes %+v", diff.Changes)
+	}
+	if got := DiffStepSnapshots(build, build); len(got.Changes) != 0 {
+		t.Fatalf("expected no changes diffing a snapshot with itself, got %+v", got.Changes)
+	}
+
+	prior, err := store.PriorSteps(testFail.SnapshotID)
+	if err != nil {
+		t.Fatalf("prior steps failed: %v", err)
+	}
+	if len(prior) != 2 || prior[0].SnapshotID != build.SnapshotID || prior[1].SnapshotID != testFail.SnapshotID {
+		t.Fatalf("expected build and the failed test snapshot, got %+v", prior)
+	}
+	prior, _ = store.PriorSteps(testPass.SnapshotID)
+	if len(prior) != 2 || prior[1].SnapshotID != testPass.SnapshotID {
+		t.Fatalf("expected the latest test snapshot to replace the failed one, got %+v", prior)
+	}
+	if _, err := store.PriorSteps("step-snap-404"); err == nil {
+		t.Fatalf("expected unknown snapshot to fail")
+	}
+}
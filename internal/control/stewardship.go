@@ -0,0 +1,173 @@
+package control
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StewardshipPolicy controls ownership enforcement and what the stewardship
+// report considers stale.
+type StewardshipPolicy struct {
+	RequireOwner   bool      `json:"require_owner"`
+	StaleAfterDays int       `json:"stale_after_days"`
+	UpdatedAt      time.Time `json:"updated_at,omitempty"`
+}
+
+// StewardshipStore holds the installation's stewardship policy.
+type StewardshipStore struct {
+	mu     sync.RWMutex
+	policy StewardshipPolicy
+}
+
+func NewStewardshipStore() *StewardshipStore {
+	return &StewardshipStore{policy: StewardshipPolicy{StaleAfterDays: 90}}
+}
+
+func (s *StewardshipStore) Policy() StewardshipPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+func (s *StewardshipStore) SetPolicy(in StewardshipPolicy) (StewardshipPolicy, error) {
+	if in.StaleAfterDays < 0 {
+		return StewardshipPolicy{}, errors.New("stale_after_days must not be negative")
+	}
+	if in.StaleAfterDays == 0 {
+		in.StaleAfterDays = 90
+	}
+	in.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = in
+	return s.policy, nil
+}
+
+// CheckOwnership rejects an entity without an owner or team when the policy
+// requires one.
+func (s *StewardshipStore) CheckOwnership(owner, team string) error {
+	if !s.Policy().RequireOwner {
+		return nil
+	}
+	if strings.TrimSpace(owner) == "" && strings.TrimSpace(team) == "" {
+		return errors.New("owner or team is required")
+	}
+	return nil
+}
+
+// Stewardship finding reasons.
+const (
+	StewardshipDepartedOwner = "departed_owner"
+	StewardshipUnowned       = "unowned"
+	StewardshipUnused        = "unused"
+	StewardshipNeverLaunched = "never_launched"
+)
+
+// StewardshipFinding flags one entity that needs attention.
+type StewardshipFinding struct {
+	Kind       string    `json:"kind"` // template|runbook|schedule|webhook
+	ID         string    `json:"id"`
+	Name       string    `json:"name,omitempty"`
+	Owner      string    `json:"owner,omitempty"`
+	Team       string    `json:"team,omitempty"`
+	Reason     string    `json:"reason"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	IdleDays   int       `json:"idle_days"`
+}
+
+// StewardshipReportInput is the inventory a stewardship report inspects.
+// DepartedOwner reports whether an owner has left the organization; it may
+// be nil when no identity directory is provisioned.
+type StewardshipReportInput struct {
+	Templates     []Template
+	Runbooks      []Runbook
+	Schedules     []Schedule
+	Webhooks      []WebhookSubscription
+	DepartedOwner func(owner string) bool
+	StaleAfter    time.Duration
+	Now           time.Time
+}
+
+type StewardshipReport struct {
+	GeneratedAt    time.Time            `json:"generated_at"`
+	StaleAfterDays int                  `json:"stale_after_days"`
+	Scanned        int                  `json:"scanned"`
+	Counts         map[string]int       `json:"counts"`
+	Findings       []StewardshipFinding `json:"findings"`
+}
+
+// BuildStewardshipReport lists entities owned by departed principals,
+// entities with no owner or team, templates that were never launched, and
+// entities unused for longer than the stale window. An entity can appear
+// once per reason.
+func BuildStewardshipReport(in StewardshipReportInput) StewardshipReport {
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	staleAfter := in.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = 90 * 24 * time.Hour
+	}
+	report := StewardshipReport{
+		GeneratedAt:    now,
+		StaleAfterDays: int(staleAfter / (24 * time.Hour)),
+		Counts:         map[string]int{},
+		Findings:       []StewardshipFinding{},
+	}
+	inspect := func(base StewardshipFinding, createdAt time.Time, neverLaunched bool) {
+		report.Scanned++
+		idleSince := base.LastUsedAt
+		if idleSince.IsZero() {
+			idleSince = createdAt
+		}
+		base.IdleDays = int(now.Sub(idleSince) / (24 * time.Hour))
+		if base.IdleDays < 0 {
+			base.IdleDays = 0
+		}
+		add := func(reason string) {
+			f := base
+			f.Reason = reason
+			report.Findings = append(report.Findings, f)
+			report.Counts[reason]++
+		}
+		owner := strings.TrimSpace(base.Owner)
+		switch {
+		case owner == "" && strings.TrimSpace(base.Team) == "":
+			add(StewardshipUnowned)
+		case owner != "" && in.DepartedOwner != nil && in.DepartedOwner(owner):
+			add(StewardshipDepartedOwner)
+		}
+		if neverLaunched {
+			add(StewardshipNeverLaunched)
+		} else if now.Sub(idleSince) >= staleAfter {
+			add(StewardshipUnused)
+		}
+	}
+	for _, t := range in.Templates {
+		inspect(StewardshipFinding{Kind: "template", ID: t.ID, Name: t.Name, Owner: t.Owner, Team: t.Team, LastUsedAt: t.LastLaunchedAt}, t.CreatedAt, t.LaunchCount == 0)
+	}
+	for _, rb := range in.Runbooks {
+		inspect(StewardshipFinding{Kind: "runbook", ID: rb.ID, Name: rb.Name, Owner: rb.Owner, Team: rb.Team, LastUsedAt: rb.LastLaunchedAt}, rb.CreatedAt, false)
+	}
+	for _, sc := range in.Schedules {
+		inspect(StewardshipFinding{Kind: "schedule", ID: sc.ID, Name: sc.ConfigPath, Owner: sc.Owner, Team: sc.Team, LastUsedAt: sc.LastRunAt}, sc.CreatedAt, false)
+	}
+	for _, wh := range in.Webhooks {
+		inspect(StewardshipFinding{Kind: "webhook", ID: wh.ID, Name: wh.Name, Owner: wh.Owner, Team: wh.Team, LastUsedAt: wh.LastDelivery}, wh.CreatedAt, false)
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Reason != b.Reason {
+			return a.Reason < b.Reason
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+	return report
+}
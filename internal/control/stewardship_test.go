@@ -0,0 +1,67 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStewardshipPolicyRequiresOwner(t *testing.T) {
+	store := NewStewardshipStore()
+	if err := store.CheckOwnership("", ""); err != nil {
+		t.Fatalf("expected ownership to be optional by default: %v", err)
+	}
+	if _, err := store.SetPolicy(StewardshipPolicy{StaleAfterDays: -1}); err == nil {
+		t.Fatalf("expected negative stale window to be rejected")
+	}
+	policy, err := store.SetPolicy(StewardshipPolicy{RequireOwner: true})
+	if err != nil || policy.StaleAfterDays != 90 {
+		t.Fatalf("unexpected policy %+v err=%v", policy, err)
+	}
+	if err := store.CheckOwnership(" ", ""); err == nil {
+		t.Fatalf("expected missing owner to be rejected")
+	}
+	if err := store.CheckOwnership("", "payments"); err != nil {
+		t.Fatalf("expected team to satisfy ownership: %v", err)
+	}
+}
+
+func TestBuildStewardshipReport(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-120 * 24 * time.Hour)
+	report := BuildStewardshipReport(StewardshipReportInput{
+		Templates: []Template{
+			{ID: "tpl-1", Name: "fresh", Owner: "alice", CreatedAt: now},
+			{ID: "tpl-2", Name: "busy", Owner: "bob", LaunchCount: 3, LastLaunchedAt: now.Add(-time.Hour), CreatedAt: old},
+		},
+		Runbooks:  []Runbook{{ID: "rb-1", Name: "rollback", Team: "sre", CreatedAt: old}},
+		Schedules: []Schedule{{ID: "sched-1", ConfigPath: "c.yaml", CreatedAt: now, LastRunAt: now}},
+		Webhooks:  []WebhookSubscription{{ID: "wh-1", Name: "ops", Owner: "bob", CreatedAt: old, LastDelivery: now}},
+		DepartedOwner: func(owner string) bool {
+			return owner == "bob"
+		},
+		StaleAfter: 30 * 24 * time.Hour,
+		Now:        now,
+	})
+	if report.Scanned != 5 || report.StaleAfterDays != 30 {
+		t.Fatalf("unexpected report header %+v", report)
+	}
+	want := map[string]int{
+		StewardshipDepartedOwner: 2,
+		StewardshipNeverLaunched: 1,
+		StewardshipUnowned:       1,
+		StewardshipUnused:        1,
+	}
+	for reason, n := range want {
+		if report.Counts[reason] != n {
+			t.Fatalf("expected %d %s findings, got %+v", n, reason, report.Counts)
+		}
+	}
+	for _, f := range report.Findings {
+		if f.Reason == StewardshipUnused && (f.Kind != "runbook" || f.IdleDays != 120) {
+			t.Fatalf("unexpected unused finding %+v", f)
+		}
+		if f.Reason == StewardshipNeverLaunched && f.ID != "tpl-1" {
+			t.Fatalf("unexpected never-launched finding %+v", f)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sort"
 	"strings"
+	"sync"
 )
 
 type StyleRule struct {
@@ -39,10 +40,17 @@ type StyleAnalysisReport struct {
 
 type StyleAnalyzer struct {
 	rules []StyleRule
+
+	mu           sync.RWMutex
+	nextID       int64
+	contentRules map[string]*StyleContentRule
+	waivers      map[string]*StyleWaiver
 }
 
 func NewStyleAnalyzer() *StyleAnalyzer {
 	return &StyleAnalyzer{
+		contentRules: map[string]*StyleContentRule{},
+		waivers:      map[string]*StyleWaiver{},
 		rules: []StyleRule{
 			{
 				ID:          "style-tabs",
@@ -0,0 +1,388 @@
+package control
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+const (
+	StyleContentRuleWorldWritableMode = "world_writable_mode"
+	StyleContentRuleLatestPackage     = "latest_package_version"
+	StyleContentRuleRequiredTags      = "required_tags"
+)
+
+type StyleWaiverStatus string
+
+const (
+	StyleWaiverPending  StyleWaiverStatus = "pending"
+	StyleWaiverApproved StyleWaiverStatus = "approved"
+	StyleWaiverRejected StyleWaiverStatus = "rejected"
+	StyleWaiverExpired  StyleWaiverStatus = "expired"
+)
+
+// StyleContentRule is an organization-defined lint rule evaluated on the
+// parsed config of a job, template, or runbook when it is created.
+// Violations of error rules block creation unless an approved waiver
+// covers them.
+type StyleContentRule struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`     // world_writable_mode|latest_package_version|required_tags
+	Severity     string    `json:"severity"` // info|warning|error
+	RequiredTags []string  `json:"required_tags,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type StyleContentRuleInput struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Severity     string   `json:"severity,omitempty"`
+	RequiredTags []string `json:"required_tags,omitempty"`
+}
+
+// StyleWaiver exempts violations of one rule, optionally narrowed to a
+// config path and resource, for a limited time after a second person
+// approves it.
+type StyleWaiver struct {
+	ID          string            `json:"id"`
+	RuleID      string            `json:"rule_id"`
+	ConfigPath  string            `json:"config_path,omitempty"`
+	ResourceID  string            `json:"resource_id,omitempty"`
+	Reason      string            `json:"reason"`
+	RequestedBy string            `json:"requested_by"`
+	TTLSeconds  int               `json:"ttl_seconds"`
+	Status      StyleWaiverStatus `json:"status"`
+	DecidedBy   string            `json:"decided_by,omitempty"`
+	Comment     string            `json:"comment,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	DecidedAt   *time.Time        `json:"decided_at,omitempty"`
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+}
+
+type StyleWaiverInput struct {
+	RuleID      string `json:"rule_id"`
+	ConfigPath  string `json:"config_path,omitempty"`
+	ResourceID  string `json:"resource_id,omitempty"`
+	Reason      string `json:"reason"`
+	RequestedBy string `json:"requested_by"`
+	TTLSeconds  int    `json:"ttl_seconds,omitempty"`
+}
+
+type StyleWaiverDecision struct {
+	Actor   string `json:"actor"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type StyleViolation struct {
+	RuleID     string `json:"rule_id"`
+	RuleName   string `json:"rule_name"`
+	Type       string `json:"type"`
+	Severity   string `json:"severity"`
+	ResourceID string `json:"resource_id,omitempty"`
+	Message    string `json:"message"`
+	Waived     bool   `json:"waived,omitempty"`
+	WaiverID   string `json:"waiver_id,omitempty"`
+}
+
+type StyleEnforcementReport struct {
+	Kind       string           `json:"kind"` // job|template|runbook
+	ConfigPath string           `json:"config_path"`
+	Allowed    bool             `json:"allowed"`
+	Blocking   int              `json:"blocking"`
+	Violations []StyleViolation `json:"violations"`
+}
+
+const (
+	defaultStyleWaiverTTL = 7 * 24 * time.Hour
+	maxStyleWaiverTTL     = 90 * 24 * time.Hour
+)
+
+var (
+	packageInstallPattern = regexp.MustCompile(`(?i)\b(apt-get|apt|yum|dnf|zypper|apk|pip3?|npm|yarn|gem|brew|choco|docker|podman|helm)\b`)
+	latestVersionPattern  = regexp.MustCompile(`(?i)(^|[\s@:=])latest\b`)
+)
+
+func (a *StyleAnalyzer) CreateContentRule(in StyleContentRuleInput) (StyleContentRule, error) {
+	rule := StyleContentRule{
+		Name:     strings.TrimSpace(in.Name),
+		Type:     strings.ToLower(strings.TrimSpace(in.Type)),
+		Severity: strings.ToLower(strings.TrimSpace(in.Severity)),
+	}
+	if rule.Name == "" {
+		return StyleContentRule{}, errors.New("name is required")
+	}
+	switch rule.Type {
+	case StyleContentRuleWorldWritableMode, StyleContentRuleLatestPackage:
+	case StyleContentRuleRequiredTags:
+		for _, tag := range in.RequiredTags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				rule.RequiredTags = append(rule.RequiredTags, tag)
+			}
+		}
+		if len(rule.RequiredTags) == 0 {
+			return StyleContentRule{}, errors.New("required_tags rule needs at least one tag")
+		}
+	default:
+		return StyleContentRule{}, errors.New("type must be world_writable_mode, latest_package_version, or required_tags")
+	}
+	if rule.Severity == "" {
+		rule.Severity = "error"
+	}
+	if rule.Severity != "info" && rule.Severity != "warning" && rule.Severity != "error" {
+		return StyleContentRule{}, errors.New("severity must be info, warning, or error")
+	}
+	rule.CreatedAt = time.Now().UTC()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	rule.ID = "lint-rule-" + itoa(a.nextID)
+	a.contentRules[rule.ID] = &rule
+	return cloneStyleContentRule(rule), nil
+}
+
+func (a *StyleAnalyzer) ListContentRules() []StyleContentRule {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := make([]StyleContentRule, 0, len(a.contentRules))
+	for _, rule := range a.contentRules {
+		out = append(out, cloneStyleContentRule(*rule))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (a *StyleAnalyzer) DeleteContentRule(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok := a.contentRules[id]; !ok {
+		return false
+	}
+	delete(a.contentRules, id)
+	return true
+}
+
+func (a *StyleAnalyzer) HasContentRules() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.contentRules) > 0
+}
+
+func (a *StyleAnalyzer) RequestWaiver(in StyleWaiverInput) (StyleWaiver, error) {
+	waiver := StyleWaiver{
+		RuleID:      strings.TrimSpace(in.RuleID),
+		ConfigPath:  strings.TrimSpace(in.ConfigPath),
+		ResourceID:  strings.TrimSpace(in.ResourceID),
+		Reason:      strings.TrimSpace(in.Reason),
+		RequestedBy: strings.TrimSpace(in.RequestedBy),
+		TTLSeconds:  in.TTLSeconds,
+		Status:      StyleWaiverPending,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if waiver.RuleID == "" || waiver.Reason == "" || waiver.RequestedBy == "" {
+		return StyleWaiver{}, errors.New("rule_id, reason, and requested_by are required")
+	}
+	if waiver.TTLSeconds <= 0 {
+		waiver.TTLSeconds = int(defaultStyleWaiverTTL.Seconds())
+	}
+	if time.Duration(waiver.TTLSeconds)*time.Second > maxStyleWaiverTTL {
+		return StyleWaiver{}, errors.New("ttl_seconds cannot exceed 90 days")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.contentRules[waiver.RuleID]; !ok {
+		return StyleWaiver{}, errors.New("lint rule not found")
+	}
+	a.nextID++
+	waiver.ID = "lint-waiver-" + itoa(a.nextID)
+	a.waivers[waiver.ID] = &waiver
+	return waiver, nil
+}
+
+func (a *StyleAnalyzer) ListWaivers() []StyleWaiver {
+	now := time.Now().UTC()
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := make([]StyleWaiver, 0, len(a.waivers))
+	for _, waiver := range a.waivers {
+		out = append(out, waiverAt(*waiver, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (a *StyleAnalyzer) GetWaiver(id string) (StyleWaiver, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	waiver, ok := a.waivers[strings.TrimSpace(id)]
+	if !ok {
+		return StyleWaiver{}, false
+	}
+	return waiverAt(*waiver, time.Now().UTC()), true
+}
+
+// DecideWaiver approves or rejects a pending waiver. The requester cannot
+// decide their own waiver; an approved waiver starts its TTL at approval.
+func (a *StyleAnalyzer) DecideWaiver(id string, approve bool, in StyleWaiverDecision) (StyleWaiver, error) {
+	actor := strings.TrimSpace(in.Actor)
+	if actor == "" {
+		return StyleWaiver{}, errors.New("actor is required")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	waiver, ok := a.waivers[strings.TrimSpace(id)]
+	if !ok {
+		return StyleWaiver{}, errors.New("lint waiver not found")
+	}
+	if waiver.Status != StyleWaiverPending {
+		return StyleWaiver{}, errors.New("lint waiver is not pending")
+	}
+	if strings.EqualFold(actor, waiver.RequestedBy) {
+		return StyleWaiver{}, errors.New("requester cannot decide their own waiver")
+	}
+	now := time.Now().UTC()
+	waiver.DecidedBy = actor
+	waiver.Comment = strings.TrimSpace(in.Comment)
+	waiver.DecidedAt = &now
+	if approve {
+		expires := now.Add(time.Duration(waiver.TTLSeconds) * time.Second)
+		waiver.Status = StyleWaiverApproved
+		waiver.ExpiresAt = &expires
+	} else {
+		waiver.Status = StyleWaiverRejected
+	}
+	return *waiver, nil
+}
+
+// EnforceConfig evaluates the content rules against a parsed config.
+// kind names what is being created (job, template, runbook) and is only
+// reported back.
+func (a *StyleAnalyzer) EnforceConfig(kind, configPath string, cfg *config.Config) StyleEnforcementReport {
+	return a.enforceConfigAt(kind, configPath, cfg, time.Now().UTC())
+}
+
+func (a *StyleAnalyzer) enforceConfigAt(kind, configPath string, cfg *config.Config, now time.Time) StyleEnforcementReport {
+	report := StyleEnforcementReport{
+		Kind:       kind,
+		ConfigPath: configPath,
+		Allowed:    true,
+		Violations: []StyleViolation{},
+	}
+	rules := a.ListContentRules()
+	a.mu.RLock()
+	waivers := make([]StyleWaiver, 0, len(a.waivers))
+	for _, waiver := range a.waivers {
+		if w := waiverAt(*waiver, now); w.Status == StyleWaiverApproved {
+			waivers = append(waivers, w)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, rule := range rules {
+		for _, v := range evaluateStyleContentRule(rule, cfg) {
+			for _, waiver := range waivers {
+				if waiver.RuleID == rule.ID &&
+					(waiver.ConfigPath == "" || waiver.ConfigPath == configPath) &&
+					(waiver.ResourceID == "" || waiver.ResourceID == v.ResourceID) {
+					v.Waived = true
+					v.WaiverID = waiver.ID
+					break
+				}
+			}
+			if v.Severity == "error" && !v.Waived {
+				report.Blocking++
+				report.Allowed = false
+			}
+			report.Violations = append(report.Violations, v)
+		}
+	}
+	return report
+}
+
+func evaluateStyleContentRule(rule StyleContentRule, cfg *config.Config) []StyleViolation {
+	var out []StyleViolation
+	add := func(resourceID, message string) {
+		out = append(out, StyleViolation{
+			RuleID:     rule.ID,
+			RuleName:   rule.Name,
+			Type:       rule.Type,
+			Severity:   rule.Severity,
+			ResourceID: resourceID,
+			Message:    message,
+		})
+	}
+	resources := append(append([]config.Resource{}, cfg.Resources...), cfg.Handlers...)
+	switch rule.Type {
+	case StyleContentRuleWorldWritableMode:
+		for _, res := range resources {
+			if res.Type == "file" && worldWritableMode(res.Mode) {
+				add(res.ID, "file mode "+res.Mode+" is world-writable")
+			}
+		}
+	case StyleContentRuleLatestPackage:
+		for _, res := range resources {
+			for _, cmd := range []string{res.Command, res.RefreshCommand, res.RescueCommand, res.AlwaysCommand, res.TaskCommand} {
+				if packageInstallPattern.MatchString(cmd) && latestVersionPattern.MatchString(cmd) {
+					add(res.ID, "command installs a package at its latest version; pin an explicit version")
+					break
+				}
+			}
+		}
+	case StyleContentRuleRequiredTags:
+		for _, res := range cfg.Resources {
+			var missing []string
+			for _, tag := range rule.RequiredTags {
+				if !containsString(res.Tags, tag) {
+					missing = append(missing, tag)
+				}
+			}
+			if len(missing) > 0 {
+				add(res.ID, "resource is missing required tags: "+strings.Join(missing, ", "))
+			}
+		}
+	}
+	return out
+}
+
+// worldWritableMode accepts octal modes (0644, 0o777) and symbolic
+// grants such as o+w or a+w.
+func worldWritableMode(mode string) bool {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		return false
+	}
+	if n, err := strconv.ParseUint(strings.TrimPrefix(mode, "0o"), 8, 32); err == nil {
+		return n&0o002 != 0
+	}
+	for _, clause := range strings.Split(mode, ",") {
+		who, perms, ok := strings.Cut(clause, "+")
+		if !ok {
+			who, perms, ok = strings.Cut(clause, "=")
+		}
+		if ok && (who == "" || strings.ContainsAny(who, "oa")) && strings.Contains(perms, "w") {
+			return true
+		}
+	}
+	return false
+}
+
+func waiverAt(w StyleWaiver, now time.Time) StyleWaiver {
+	if w.Status == StyleWaiverApproved && w.ExpiresAt != nil && !now.Before(*w.ExpiresAt) {
+		w.Status = StyleWaiverExpired
+	}
+	return w
+}
+
+func cloneStyleContentRule(in StyleContentRule) StyleContentRule {
+	out := in
+	out.RequiredTags = append([]string{}, in.RequiredTags...)
+	return out
+}
@@ -0,0 +1,81 @@
+package control
+
+import (
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+func TestStyleContentRulesEnforceConfig(t *testing.T) {
+	analyzer := NewStyleAnalyzer()
+	modeRule, err := analyzer.CreateContentRule(StyleContentRuleInput{Name: "no world-writable files", Type: "world_writable_mode"})
+	if err != nil {
+		t.Fatalf("create mode rule failed: %v", err)
+	}
+	if _, err := analyzer.CreateContentRule(StyleContentRuleInput{Name: "pin packages", Type: "latest_package_version", Severity: "warning"}); err != nil {
+		t.Fatalf("create package rule failed: %v", err)
+	}
+	if _, err := analyzer.CreateContentRule(StyleContentRuleInput{Name: "ownership tags", Type: "required_tags", Severity: "info", RequiredTags: []string{"owner"}}); err != nil {
+		t.Fatalf("create tags rule failed: %v", err)
+	}
+
+	cfg := &config.Config{Resources: []config.Resource{
+		{ID: "motd", Type: "file", Mode: "0666", Tags: []string{"owner"}},
+		{ID: "secure", Type: "file", Mode: "0640", Tags: []string{"owner"}},
+		{ID: "nginx", Type: "command", Command: "apt-get install -y nginx=latest", Tags: []string{"owner"}},
+		{ID: "tool", Type: "command", Command: "npm install -g tool@1.2.3"},
+	}}
+	report := analyzer.EnforceConfig("job", "/srv/c.yaml", cfg)
+	if report.Allowed || report.Blocking != 1 || len(report.Violations) != 3 {
+		t.Fatalf("unexpected enforcement report: %+v", report)
+	}
+
+	waiver, err := analyzer.RequestWaiver(StyleWaiverInput{RuleID: modeRule.ID, ConfigPath: "/srv/c.yaml", ResourceID: "motd", Reason: "legacy banner", RequestedBy: "dev", TTLSeconds: 60})
+	if err != nil {
+		t.Fatalf("request waiver failed: %v", err)
+	}
+	if report := analyzer.EnforceConfig("job", "/srv/c.yaml", cfg); report.Allowed {
+		t.Fatalf("pending waiver must not suppress violations: %+v", report)
+	}
+	if _, err := analyzer.DecideWaiver(waiver.ID, true, StyleWaiverDecision{Actor: "dev"}); err == nil {
+		t.Fatalf("expected self-approval to be rejected")
+	}
+	approved, err := analyzer.DecideWaiver(waiver.ID, true, StyleWaiverDecision{Actor: "security-lead"})
+	if err != nil || approved.Status != StyleWaiverApproved || approved.ExpiresAt == nil {
+		t.Fatalf("approve waiver failed: %+v err=%v", approved, err)
+	}
+
+	report = analyzer.EnforceConfig("job", "/srv/c.yaml", cfg)
+	if !report.Allowed || report.Violations[0].WaiverID != waiver.ID {
+		t.Fatalf("expected approved waiver to suppress blocking violation: %+v", report)
+	}
+	if other := analyzer.EnforceConfig("job", "/srv/other.yaml", cfg); other.Allowed {
+		t.Fatalf("waiver scoped to one config must not apply elsewhere: %+v", other)
+	}
+	if later := analyzer.enforceConfigAt("job", "/srv/c.yaml", cfg, approved.ExpiresAt.Add(time.Second)); later.Allowed {
+		t.Fatalf("expired waiver must not suppress violations: %+v", later)
+	}
+}
+
+func TestStyleContentRuleValidation(t *testing.T) {
+	analyzer := NewStyleAnalyzer()
+	for _, in := range []StyleContentRuleInput{
+		{Type: "world_writable_mode"},
+		{Name: "unknown", Type: "max_line_length"},
+		{Name: "tags", Type: "required_tags"},
+		{Name: "severity", Type: "world_writable_mode", Severity: "critical"},
+	} {
+		if _, err := analyzer.CreateContentRule(in); err == nil {
+			t.Fatalf("expected rule %+v to be rejected", in)
+		}
+	}
+	if _, err := analyzer.RequestWaiver(StyleWaiverInput{RuleID: "lint-rule-404", Reason: "x", RequestedBy: "dev"}); err == nil {
+		t.Fatalf("expected waiver for unknown rule to be rejected")
+	}
+	for mode, want := range map[string]bool{"0777": true, "644": false, "0o662": true, "o+w": true, "u+rw,g+r": false, "a=rw": true} {
+		if got := worldWritableMode(mode); got != want {
+			t.Fatalf("worldWritableMode(%q) = %v, want %v", mode, got, want)
+		}
+	}
+}
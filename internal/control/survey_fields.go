@@ -0,0 +1,179 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const redactedSurveyValue = "<redacted>"
+
+// ValidateSurveySchema checks field types, patterns, and bounds so broken
+// surveys are rejected when a template or workflow is saved rather than at
+// launch.
+func ValidateSurveySchema(schema map[string]SurveyField) error {
+	for name, def := range schema {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("survey field name is required")
+		}
+		kind := surveyFieldType(def)
+		switch kind {
+		case "string", "int", "bool", "multiselect", "secret":
+		default:
+			return fmt.Errorf("unsupported survey field type for %s: %s", name, def.Type)
+		}
+		if def.Pattern != "" {
+			if _, err := regexp.Compile(def.Pattern); err != nil {
+				return fmt.Errorf("invalid survey pattern for %s: %v", name, err)
+			}
+		}
+		if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+			return fmt.Errorf("survey field %s min exceeds max", name)
+		}
+		if kind == "multiselect" && len(def.Enum) == 0 {
+			return fmt.Errorf("multiselect survey field %s requires enum choices", name)
+		}
+		if kind == "secret" && len(def.Enum) > 0 {
+			return fmt.Errorf("secret survey field %s cannot declare enum choices", name)
+		}
+	}
+	return nil
+}
+
+// CheckSurveyDefaults rejects defaults for secret fields, which would
+// otherwise sit in plain text on the template or workflow.
+func CheckSurveyDefaults(schema map[string]SurveyField, defaults map[string]string) error {
+	for name, value := range defaults {
+		if def, ok := schema[name]; ok && surveyFieldType(def) == "secret" && strings.TrimSpace(value) != "" {
+			return fmt.Errorf("secret survey field %s cannot have a default", name)
+		}
+	}
+	return nil
+}
+
+// RedactSurveyAnswers returns a copy of answers with secret values replaced.
+func RedactSurveyAnswers(schema map[string]SurveyField, answers map[string]string) map[string]string {
+	if answers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(answers))
+	for key, value := range answers {
+		if def, ok := schema[key]; ok && surveyFieldType(def) == "secret" && value != "" {
+			value = redactedSurveyValue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// SealSurveySecrets stores each non-empty secret answer in the encrypted
+// secret store as "<scope>/<field>" and returns the stored names.
+func SealSurveySecrets(store *EncryptedSecretStore, scope string, schema map[string]SurveyField, answers map[string]string) ([]string, error) {
+	var names []string
+	for _, key := range sortedKeys(answers) {
+		def, ok := schema[key]
+		if !ok || surveyFieldType(def) != "secret" || strings.TrimSpace(answers[key]) == "" {
+			continue
+		}
+		if store == nil {
+			return nil, errors.New("secret survey answers require an encrypted secret store")
+		}
+		item, err := store.Upsert(EncryptedSecretUpsertInput{Name: scope + "/" + key, Value: answers[key]})
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, item.Name)
+	}
+	return names, nil
+}
+
+func surveyFieldType(def SurveyField) string {
+	switch kind := strings.ToLower(strings.TrimSpace(def.Type)); kind {
+	case "":
+		return "string"
+	case "integer":
+		return "int"
+	case "boolean":
+		return "bool"
+	default:
+		return kind
+	}
+}
+
+func validateSurveyValue(field string, def SurveyField, raw string) error {
+	var pattern *regexp.Regexp
+	if def.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + def.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid survey pattern for %s", field)
+		}
+		pattern = re
+	}
+	checkBounds := func(n int, what string) error {
+		if def.Min != nil && n < *def.Min {
+			return fmt.Errorf("%s for %s must be at least %d", what, field, *def.Min)
+		}
+		if def.Max != nil && n > *def.Max {
+			return fmt.Errorf("%s for %s must be at most %d", what, field, *def.Max)
+		}
+		return nil
+	}
+	inEnum := func(value string) bool {
+		for _, allowed := range def.Enum {
+			if value == allowed {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch surveyFieldType(def) {
+	case "string", "secret":
+		if err := checkBounds(utf8.RuneCountInString(raw), "length"); err != nil {
+			return err
+		}
+	case "int":
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s", field)
+		}
+		if err := checkBounds(n, "value"); err != nil {
+			return err
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return fmt.Errorf("invalid boolean value for %s", field)
+		}
+	case "multiselect":
+		seen := map[string]struct{}{}
+		for _, choice := range strings.Split(raw, ",") {
+			choice = strings.TrimSpace(choice)
+			if choice == "" {
+				continue
+			}
+			if _, dup := seen[choice]; dup {
+				return fmt.Errorf("duplicate choice for %s: %s", field, choice)
+			}
+			seen[choice] = struct{}{}
+			if !inEnum(choice) {
+				return fmt.Errorf("invalid choice for %s: must be among %v", field, def.Enum)
+			}
+			if pattern != nil && !pattern.MatchString(choice) {
+				return fmt.Errorf("choice for %s does not match pattern", field)
+			}
+		}
+		return checkBounds(len(seen), "choice count")
+	default:
+		return fmt.Errorf("unsupported survey field type for %s: %s", field, def.Type)
+	}
+	if pattern != nil && !pattern.MatchString(raw) {
+		return fmt.Errorf("value for %s does not match pattern", field)
+	}
+	if len(def.Enum) > 0 && !inEnum(raw) {
+		return fmt.Errorf("invalid value for %s: must be one of %v", field, def.Enum)
+	}
+	return nil
+}
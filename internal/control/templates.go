@@ -6,29 +6,46 @@ import (
 	"os"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
 )
 
 var templateVariablePattern = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
 
+// SurveyField describes one launch-time answer. Min and Max bound int values,
+// string/secret lengths, and multiselect choice counts; Pattern must match the
+// whole value (each choice for multiselect). A field with When only applies
+// when the expression over the other answers holds. Secret answers are sealed
+// in the encrypted secret store and redacted wherever answers are echoed.
 type SurveyField struct {
-	Type     string   `json:"type"` // string|int|bool
+	Type     string   `json:"type"` // string|int|bool|multiselect|secret
 	Required bool     `json:"required,omitempty"`
 	Enum     []string `json:"enum,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Min      *int     `json:"min,omitempty"`
+	Max      *int     `json:"max,omitempty"`
+	When     string   `json:"when,omitempty"`
 }
 
 type Template struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description,omitempty"`
-	ConfigPath  string                 `json:"config_path"`
-	StrictMode  bool                   `json:"strict_mode,omitempty"`
-	Defaults    map[string]string      `json:"defaults,omitempty"`
-	Survey      map[string]SurveyField `json:"survey,omitempty"`
-	CreatedAt   time.Time              `json:"created_at"`
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description,omitempty"`
+	ConfigPath     string                 `json:"config_path"`
+	StrictMode     bool                   `json:"strict_mode,omitempty"`
+	Defaults       map[string]string      `json:"defaults,omitempty"`
+	Survey         map[string]SurveyField `json:"survey,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Owner          string                 `json:"owner,omitempty"`
+	Team           string                 `json:"team,omitempty"`
+	Version        int64                  `json:"version"`
+	LaunchCount    int64                  `json:"launch_count"`
+	LastLaunchedAt time.Time              `json:"last_launched_at,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at,omitempty"`
 }
 
 type TemplateStore struct {
@@ -48,7 +65,9 @@ func (s *TemplateStore) Create(t Template) Template {
 	defer s.mu.Unlock()
 	s.nextID++
 	t.ID = "tpl-" + itoa(s.nextID)
+	t.Version = 1
 	t.CreatedAt = time.Now().UTC()
+	t.UpdatedAt = t.CreatedAt
 	if t.Defaults == nil {
 		t.Defaults = map[string]string{}
 	}
@@ -90,6 +109,78 @@ func (s *TemplateStore) Delete(id string) error {
 	return nil
 }
 
+// Update replaces the editable fields of a template and bumps its version.
+// A non-zero expectedVersion must match the stored version.
+func (s *TemplateStore) Update(id string, in Template, expectedVersion int64) (Template, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.templates[id]
+	if !ok {
+		return Template{}, errors.New("template not found")
+	}
+	if err := checkVersion(VersionedKindTemplate, id, cur.Version, expectedVersion); err != nil {
+		return Template{}, err
+	}
+	in.ID = cur.ID
+	in.Version = cur.Version + 1
+	in.LaunchCount = cur.LaunchCount
+	in.LastLaunchedAt = cur.LastLaunchedAt
+	in.CreatedAt = cur.CreatedAt
+	in.UpdatedAt = time.Now().UTC()
+	if in.Defaults == nil {
+		in.Defaults = map[string]string{}
+	}
+	if in.Survey == nil {
+		in.Survey = map[string]SurveyField{}
+	}
+	cp := *cloneTemplate(&in)
+	s.templates[id] = &cp
+	return *cloneTemplate(&cp), nil
+}
+
+// RecordLaunch counts a launch of the template for stewardship reporting.
+func (s *TemplateStore) RecordLaunch(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.templates[id]
+	if !ok {
+		return errors.New("template not found")
+	}
+	t.LaunchCount++
+	t.LastLaunchedAt = time.Now().UTC()
+	return nil
+}
+
+// Restore inserts a template with its existing ID, e.g. when importing
+// configuration as code, and keeps later IDs from colliding with it.
+func (s *TemplateStore) Restore(t Template) (Template, error) {
+	t.ID = strings.TrimSpace(t.ID)
+	if t.ID == "" {
+		return Template{}, errors.New("template id is required")
+	}
+	if err := ValidateConfigAsCodeID(t.ID); err != nil {
+		return Template{}, errors.New("template " + err.Error())
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
+	}
+	if t.Version <= 0 {
+		t.Version = 1
+	}
+	if t.Defaults == nil {
+		t.Defaults = map[string]string{}
+	}
+	if t.Survey == nil {
+		t.Survey = map[string]SurveyField{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = advanceRestoredID(s.nextID, t.ID, "tpl-")
+	cp := *cloneTemplate(&t)
+	s.templates[t.ID] = &cp
+	return cp, nil
+}
+
 func cloneTemplate(t *Template) *Template {
 	if t == nil {
 		return nil
@@ -103,6 +194,7 @@ func cloneTemplate(t *Template) *Template {
 	for k, v := range t.Survey {
 		cp.Survey[k] = v
 	}
+	cp.Labels = cloneLabels(t.Labels)
 	return &cp
 }
 
@@ -120,39 +212,27 @@ func ValidateSurveyAnswers(schema map[string]SurveyField, answers map[string]str
 		}
 	}
 
+	vars := make(map[string]string, len(schema))
+	for field := range schema {
+		vars[field] = strings.TrimSpace(answers[field])
+	}
 	for field, def := range schema {
 		raw, ok := answers[field]
 		raw = strings.TrimSpace(raw)
+		if strings.TrimSpace(def.When) != "" && !config.EvaluateWhen(def.When, vars) {
+			if ok && raw != "" {
+				return fmt.Errorf("survey field %s does not apply unless %s", field, strings.TrimSpace(def.When))
+			}
+			continue
+		}
 		if def.Required && (!ok || raw == "") {
 			return fmt.Errorf("missing required survey field: %s", field)
 		}
 		if !ok || raw == "" {
 			continue
 		}
-		switch strings.ToLower(strings.TrimSpace(def.Type)) {
-		case "", "string":
-		case "int", "integer":
-			if _, err := strconv.Atoi(raw); err != nil {
-				return fmt.Errorf("invalid integer value for %s", field)
-			}
-		case "bool", "boolean":
-			if _, err := strconv.ParseBool(raw); err != nil {
-				return fmt.Errorf("invalid boolean value for %s", field)
-			}
-		default:
-			return fmt.Errorf("unsupported survey field type for %s: %s", field, def.Type)
-		}
-		if len(def.Enum) > 0 {
-			match := false
-			for _, allowed := range def.Enum {
-				if raw == allowed {
-					match = true
-					break
-				}
-			}
-			if !match {
-				return fmt.Errorf("invalid value for %s: must be one of %v", field, def.Enum)
-			}
+		if err := validateSurveyValue(field, def, raw); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -177,7 +257,17 @@ func MergeTemplateVariables(defaults, answers map[string]string) map[string]stri
 	return out
 }
 
+// TemplateMineLookup returns mine values for function from nodes matching
+// the target glob, in node order.
+type TemplateMineLookup func(function, target string) []string
+
 func RenderTemplateText(template string, vars map[string]string, strict bool) (string, []string) {
+	return RenderTemplateTextWithMine(template, vars, strict, nil)
+}
+
+// RenderTemplateTextWithMine also resolves {{ mine "function" "target" [sep] }}
+// expressions against peer node data published to the fact mine.
+func RenderTemplateTextWithMine(template string, vars map[string]string, strict bool, mine TemplateMineLookup) (string, []string) {
 	missing := map[string]struct{}{}
 	rendered := templateVariablePattern.ReplaceAllStringFunc(template, func(token string) string {
 		matches := templateVariablePattern.FindStringSubmatch(token)
@@ -185,7 +275,7 @@ func RenderTemplateText(template string, vars map[string]string, strict bool) (s
 			return token
 		}
 		expr := strings.TrimSpace(matches[1])
-		value, unresolved, handled := renderTemplateExpression(expr, vars)
+		value, unresolved, handled := renderTemplateExpression(expr, vars, mine)
 		for _, key := range unresolved {
 			missing[key] = struct{}{}
 		}
@@ -208,12 +298,35 @@ func RenderTemplateText(template string, vars map[string]string, strict bool) (s
 	return rendered, missingList
 }
 
-func renderTemplateExpression(expr string, vars map[string]string) (string, []string, bool) {
+func renderTemplateExpression(expr string, vars map[string]string, mine TemplateMineLookup) (string, []string, bool) {
 	fields := splitTemplateExpression(expr)
 	if len(fields) == 0 {
 		return "", nil, false
 	}
 	switch strings.ToLower(fields[0]) {
+	case "mine":
+		if mine == nil || len(fields) < 3 || len(fields) > 4 {
+			return "", nil, false
+		}
+		function, missing, ok := resolveTemplateOperand(fields[1], vars)
+		if !ok {
+			return "", missing, true
+		}
+		target, missing, ok := resolveTemplateOperand(fields[2], vars)
+		if !ok {
+			return "", missing, true
+		}
+		sep := ","
+		if len(fields) == 4 {
+			if sep, missing, ok = resolveTemplateOperand(fields[3], vars); !ok {
+				return "", missing, true
+			}
+		}
+		values := mine(function, target)
+		if len(values) == 0 {
+			return "", []string{"mine:" + function + ":" + target}, true
+		}
+		return strings.Join(values, sep), nil, true
 	case "upper":
 		if len(fields) != 2 {
 			return "", nil, false
@@ -324,6 +437,10 @@ func splitTemplateExpression(expr string) []string {
 }
 
 func RenderTemplateFile(path string, vars map[string]string, strict bool) (string, []string, error) {
+	return RenderTemplateFileWithMine(path, vars, strict, nil)
+}
+
+func RenderTemplateFileWithMine(path string, vars map[string]string, strict bool, mine TemplateMineLookup) (string, []string, error) {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return "", nil, errors.New("config_path is required")
@@ -332,7 +449,7 @@ func RenderTemplateFile(path string, vars map[string]string, strict bool) (strin
 	if err != nil {
 		return "", nil, err
 	}
-	rendered, missing := RenderTemplateText(string(body), vars, strict)
+	rendered, missing := RenderTemplateTextWithMine(string(body), vars, strict, mine)
 	if strict && len(missing) > 0 {
 		return "", missing, fmt.Errorf("undefined template variables: %s", strings.Join(missing, ", "))
 	}
@@ -34,6 +34,72 @@ func TestValidateSurveyAnswers(t *testing.T) {
 	}
 }
 
+func TestSurveyDependentFieldsPatternsBoundsAndSecrets(t *testing.T) {
+	one, three := 1, 3
+	schema := map[string]SurveyField{
+		"tls":       {Type: "bool"},
+		"cert_name": {Type: "string", Required: true, When: "tls == true", Pattern: `[a-z0-9-]+`},
+		"replicas":  {Type: "int", Min: &one, Max: &three},
+		"zones":     {Type: "multiselect", Enum: []string{"a", "b", "c"}, Min: &one},
+		"api_token": {Type: "secret", Min: &three},
+	}
+	if err := ValidateSurveySchema(schema); err != nil {
+		t.Fatalf("expected valid schema, got %v", err)
+	}
+	if err := ValidateSurveySchema(map[string]SurveyField{"x": {Type: "multiselect"}}); err == nil {
+		t.Fatalf("expected multiselect without enum to be rejected")
+	}
+	if err := ValidateSurveySchema(map[string]SurveyField{"x": {Pattern: "("}}); err == nil {
+		t.Fatalf("expected bad pattern to be rejected")
+	}
+	if err := ValidateSurveySchema(map[string]SurveyField{"x": {Type: "int", Min: &three, Max: &one}}); err == nil {
+		t.Fatalf("expected inverted bounds to be rejected")
+	}
+	if err := CheckSurveyDefaults(schema, map[string]string{"api_token": "abc"}); err == nil {
+		t.Fatalf("expected secret default to be rejected")
+	}
+
+	cases := []struct {
+		answers map[string]string
+		ok      bool
+	}{
+		{map[string]string{"tls": "false"}, true},
+		{map[string]string{"tls": "true"}, false},                         // dependent field now required
+		{map[string]string{"tls": "false", "cert_name": "web"}, false},    // dependent field does not apply
+		{map[string]string{"tls": "true", "cert_name": "Web_1"}, false},   // pattern
+		{map[string]string{"tls": "true", "cert_name": "web-1"}, true},    // pattern match
+		{map[string]string{"replicas": "4"}, false},                       // max
+		{map[string]string{"zones": "a, c"}, true},                        // multiselect
+		{map[string]string{"zones": "a,d"}, false},                        // choice outside enum
+		{map[string]string{"zones": "a,a"}, false},                        // duplicate choice
+		{map[string]string{"api_token": "ab"}, false},                     // secret length
+		{map[string]string{"api_token": "s3cr3t", "replicas": "2"}, true}, // secret ok
+	}
+	for i, tc := range cases {
+		err := ValidateSurveyAnswers(schema, tc.answers)
+		if (err == nil) != tc.ok {
+			t.Fatalf("case %d %v: expected ok=%v, got %v", i, tc.answers, tc.ok, err)
+		}
+	}
+
+	answers := map[string]string{"api_token": "s3cr3t", "replicas": "2"}
+	redacted := RedactSurveyAnswers(schema, answers)
+	if redacted["api_token"] != "<redacted>" || redacted["replicas"] != "2" || answers["api_token"] != "s3cr3t" {
+		t.Fatalf("unexpected redaction %v", redacted)
+	}
+	store := NewEncryptedSecretStore()
+	refs, err := SealSurveySecrets(store, "template-launch/job-1", schema, answers)
+	if err != nil || len(refs) != 1 || refs[0] != "template-launch/job-1/api_token" {
+		t.Fatalf("unexpected sealed refs %v err=%v", refs, err)
+	}
+	if got, err := store.Resolve(refs[0]); err != nil || got.Value != "s3cr3t" {
+		t.Fatalf("expected sealed secret to resolve, got %+v err=%v", got, err)
+	}
+	if _, err := SealSurveySecrets(nil, "x", schema, answers); err == nil {
+		t.Fatalf("expected sealing without a store to fail")
+	}
+}
+
 func TestRenderTemplateText_StrictMode(t *testing.T) {
 	rendered, missing := RenderTemplateText("env={{ env }} token={{token}}", map[string]string{"env": "prod"}, true)
 	if rendered != "env=prod token={{token}}" {
@@ -1,7 +1,17 @@
 package control
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -43,16 +53,168 @@ type TenantBoundaryDecision struct {
 
 type TenantCryptoStore struct {
 	mu             sync.RWMutex
-	nextID         int64
+	path           string
 	keysByID       map[string]*TenantCryptoKey
+	salts          map[string][]byte
 	activeByTenant map[string]string
+	material       map[string][]byte
+	masterSecret   []byte
 }
 
-func NewTenantCryptoStore() *TenantCryptoStore {
-	return &TenantCryptoStore{
+// tenantCryptoKeyRecord is the persisted form of a key. The salt is random
+// per key and, together with the master secret and the key ID, derives the
+// key material; the material itself is never written to disk.
+type tenantCryptoKeyRecord struct {
+	TenantCryptoKey
+	Salt []byte `json:"salt"`
+}
+
+func NewTenantCryptoStore(baseDir string) *TenantCryptoStore {
+	s := &TenantCryptoStore{
+		path:           filepath.Join(baseDir, ".masterchef", "security", "tenant-keys.json"),
 		keysByID:       map[string]*TenantCryptoKey{},
+		salts:          map[string][]byte{},
 		activeByTenant: map[string]string{},
+		material:       map[string][]byte{},
+	}
+	s.load()
+	return s
+}
+
+// SetMasterSecret derives key material from secret with HKDF, salted per key
+// and bound to the key ID, tenant, and algorithm, so data sealed under a
+// tenant key can still be opened after a restart. Without it key material is
+// random and lives only in memory: keys restored from disk cannot open data.
+func (s *TenantCryptoStore) SetMasterSecret(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret = strings.TrimSpace(secret); secret == "" {
+		s.masterSecret = nil
+	} else {
+		s.masterSecret = []byte(secret)
+	}
+	s.material = map[string][]byte{}
+}
+
+// Seal encrypts plaintext under the tenant's active key, creating one if
+// needed. aad is bound to the ciphertext and must be passed again to Open.
+func (s *TenantCryptoStore) Seal(tenant string, plaintext, aad []byte) (TenantCryptoKey, []byte, []byte, error) {
+	key, err := s.EnsureTenantKey(TenantCryptoKeyInput{Tenant: tenant})
+	if err != nil {
+		return TenantCryptoKey{}, nil, nil, err
+	}
+	aead, err := s.aead(key.ID)
+	if err != nil {
+		return TenantCryptoKey{}, nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return TenantCryptoKey{}, nil, nil, err
+	}
+	return key, nonce, aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// Open decrypts data sealed under keyID, which may since have been retired
+// by rotation.
+func (s *TenantCryptoStore) Open(keyID string, nonce, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := s.aead(strings.TrimSpace(keyID))
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	plain, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, errors.New("failed to decrypt with tenant key")
+	}
+	return plain, nil
+}
+
+func (s *TenantCryptoStore) aead(keyID string) (cipher.AEAD, error) {
+	s.mu.Lock()
+	key, ok := s.keysByID[keyID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, errors.New("tenant key not found")
+	}
+	if key.Algorithm != "aes-256-gcm" {
+		s.mu.Unlock()
+		return nil, errors.New("tenant key algorithm " + key.Algorithm + " cannot seal data")
+	}
+	material, ok := s.material[keyID]
+	if !ok {
+		if s.masterSecret == nil {
+			s.mu.Unlock()
+			return nil, errors.New("tenant key material unavailable; set MC_TENANT_KEY_SECRET to keep keys usable across restarts")
+		}
+		info := "masterchef tenant key|" + key.ID + "|" + key.Tenant + "|" + key.Algorithm
+		material = hkdfSHA256(s.masterSecret, s.salts[keyID], []byte(info), 32)
+		s.material[keyID] = material
+	}
+	s.mu.Unlock()
+	block, err := aes.NewCipher(material)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hkdfSHA256 implements HKDF (RFC 5869) extract-and-expand with SHA-256.
+func hkdfSHA256(secret, salt, info []byte, length int) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, sha256.Size)
+	}
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	out := make([]byte, 0, length)
+	var block []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(block)
+		expand.Write(info)
+		expand.Write([]byte{counter})
+		block = expand.Sum(nil)
+		out = append(out, block...)
+	}
+	return out[:length]
+}
+
+// newKeyLocked registers a fresh key with a random ID and salt. Random IDs
+// keep a key ID from ever naming another tenant's key, even if key records
+// are lost and reissued.
+func (s *TenantCryptoStore) newKeyLocked(tenant, algorithm string, version int) (*TenantCryptoKey, error) {
+	raw := make([]byte, 8+32)
+	if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+		return nil, err
+	}
+	item := &TenantCryptoKey{
+		ID:          "tenant-key-" + hex.EncodeToString(raw[:8]),
+		Tenant:      tenant,
+		Algorithm:   algorithm,
+		Version:     version,
+		Status:      "active",
+		Fingerprint: tenant + ":" + algorithm + ":v" + itoa(int64(version)),
+		CreatedAt:   time.Now().UTC(),
 	}
+	if s.masterSecret == nil {
+		material := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, material); err != nil {
+			return nil, err
+		}
+		s.material[item.ID] = material
+	}
+	s.keysByID[item.ID] = item
+	s.salts[item.ID] = raw[8:]
+	return item, nil
+}
+
+func (s *TenantCryptoStore) dropKeyLocked(id string) {
+	delete(s.keysByID, id)
+	delete(s.salts, id)
+	delete(s.material, id)
 }
 
 func (s *TenantCryptoStore) EnsureTenantKey(in TenantCryptoKeyInput) (TenantCryptoKey, error) {
@@ -76,19 +238,16 @@ func (s *TenantCryptoStore) EnsureTenantKey(in TenantCryptoKeyInput) (TenantCryp
 		}
 	}
 
-	s.nextID++
-	id := "tenant-key-" + itoa(s.nextID)
-	item := &TenantCryptoKey{
-		ID:          id,
-		Tenant:      tenant,
-		Algorithm:   algorithm,
-		Version:     1,
-		Status:      "active",
-		Fingerprint: tenant + ":" + algorithm + ":v1",
-		CreatedAt:   time.Now().UTC(),
+	item, err := s.newKeyLocked(tenant, algorithm, 1)
+	if err != nil {
+		return TenantCryptoKey{}, err
+	}
+	s.activeByTenant[tenant] = item.ID
+	if err := s.persistLocked(); err != nil {
+		s.dropKeyLocked(item.ID)
+		delete(s.activeByTenant, tenant)
+		return TenantCryptoKey{}, err
 	}
-	s.keysByID[id] = item
-	s.activeByTenant[tenant] = id
 	return *item, nil
 }
 
@@ -108,25 +267,67 @@ func (s *TenantCryptoStore) Rotate(in TenantKeyRotateInput) (TenantCryptoKey, er
 	if !ok {
 		return TenantCryptoKey{}, errors.New("active tenant key missing")
 	}
-	active.Status = "retired"
 
-	s.nextID++
-	newID := "tenant-key-" + itoa(s.nextID)
-	nextVersion := active.Version + 1
-	newKey := &TenantCryptoKey{
-		ID:          newID,
-		Tenant:      tenant,
-		Algorithm:   active.Algorithm,
-		Version:     nextVersion,
-		Status:      "active",
-		Fingerprint: tenant + ":" + active.Algorithm + ":v" + itoa(int64(nextVersion)),
-		CreatedAt:   time.Now().UTC(),
+	newKey, err := s.newKeyLocked(tenant, active.Algorithm, active.Version+1)
+	if err != nil {
+		return TenantCryptoKey{}, err
+	}
+	active.Status = "retired"
+	s.activeByTenant[tenant] = newKey.ID
+	if err := s.persistLocked(); err != nil {
+		s.dropKeyLocked(newKey.ID)
+		active.Status = "active"
+		s.activeByTenant[tenant] = activeID
+		return TenantCryptoKey{}, err
 	}
-	s.keysByID[newID] = newKey
-	s.activeByTenant[tenant] = newID
 	return *newKey, nil
 }
 
+func (s *TenantCryptoStore) load() {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var records []tenantCryptoKeyRecord
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rec := range records {
+		if rec.ID == "" || rec.Tenant == "" || len(rec.Salt) == 0 {
+			continue
+		}
+		item := rec.TenantCryptoKey
+		s.keysByID[item.ID] = &item
+		s.salts[item.ID] = rec.Salt
+		if item.Status == "active" {
+			s.activeByTenant[item.Tenant] = item.ID
+		}
+	}
+}
+
+func (s *TenantCryptoStore) persistLocked() error {
+	records := make([]tenantCryptoKeyRecord, 0, len(s.keysByID))
+	for id, item := range s.keysByID {
+		records = append(records, tenantCryptoKeyRecord{TenantCryptoKey: *item, Salt: s.salts[id]})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Tenant == records[j].Tenant {
+			return records[i].Version < records[j].Version
+		}
+		return records[i].Tenant < records[j].Tenant
+	})
+	raw, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(raw, '\n'), 0o600)
+}
+
 func (s *TenantCryptoStore) List() []TenantCryptoKey {
 	s.mu.RLock()
 	out := make([]TenantCryptoKey, 0, len(s.keysByID))
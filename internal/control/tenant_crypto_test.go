@@ -1,9 +1,13 @@
 package control
 
-import "testing"
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
 
 func TestTenantCryptoStoreLifecycleAndBoundaryCheck(t *testing.T) {
-	store := NewTenantCryptoStore()
+	store := NewTenantCryptoStore(t.TempDir())
 	key, err := store.EnsureTenantKey(TenantCryptoKeyInput{
 		Tenant:    "tenant-a",
 		Algorithm: "aes-256-gcm",
@@ -53,7 +57,7 @@ func TestTenantCryptoStoreLifecycleAndBoundaryCheck(t *testing.T) {
 }
 
 func TestTenantCryptoStoreValidation(t *testing.T) {
-	store := NewTenantCryptoStore()
+	store := NewTenantCryptoStore(t.TempDir())
 	if _, err := store.EnsureTenantKey(TenantCryptoKeyInput{
 		Tenant:    "tenant-a",
 		Algorithm: "rsa-4096",
@@ -64,3 +68,80 @@ func TestTenantCryptoStoreValidation(t *testing.T) {
 		t.Fatalf("expected missing key rotate error")
 	}
 }
+
+func TestTenantCryptoStoreSealSurvivesRotation(t *testing.T) {
+	baseDir := t.TempDir()
+	store := NewTenantCryptoStore(baseDir)
+	store.SetMasterSecret("fleet-master")
+	key, nonce, sealed, err := store.Seal("tenant-a", []byte("backup payload"), []byte("set-1"))
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	rotated, err := store.Rotate(TenantKeyRotateInput{Tenant: "tenant-a"})
+	if err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	plain, err := store.Open(key.ID, nonce, sealed, []byte("set-1"))
+	if err != nil || string(plain) != "backup payload" {
+		t.Fatalf("expected retired key to open sealed data, got %q err=%v", plain, err)
+	}
+	if _, err := store.Open(key.ID, nonce, sealed, []byte("set-2")); err == nil {
+		t.Fatalf("expected mismatched aad to fail")
+	}
+
+	// Key records persist, so a restarted store opens earlier data without
+	// reissuing keys and keeps the rotated key active.
+	restarted := NewTenantCryptoStore(baseDir)
+	restarted.SetMasterSecret("fleet-master")
+	if plain, err := restarted.Open(key.ID, nonce, sealed, []byte("set-1")); err != nil || string(plain) != "backup payload" {
+		t.Fatalf("expected derived key to open after restart, got %q err=%v", plain, err)
+	}
+	active, err := restarted.EnsureTenantKey(TenantCryptoKeyInput{Tenant: "tenant-a"})
+	if err != nil || active.ID != rotated.ID {
+		t.Fatalf("expected rotated key to stay active after restart, got %+v err=%v", active, err)
+	}
+
+	// The same secret in a different data directory derives different keys.
+	other := NewTenantCryptoStore(t.TempDir())
+	other.SetMasterSecret("fleet-master")
+	otherKey, _, _, err := other.Seal("tenant-a", []byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherKey.ID == key.ID {
+		t.Fatalf("expected independent key IDs, got %s twice", key.ID)
+	}
+
+	// Without a master secret, keys restored from disk cannot open data.
+	noSecret := NewTenantCryptoStore(baseDir)
+	if _, err := noSecret.Open(key.ID, nonce, sealed, []byte("set-1")); err == nil || !strings.Contains(err.Error(), "MC_TENANT_KEY_SECRET") {
+		t.Fatalf("expected missing key material error, got %v", err)
+	}
+
+	if _, err := store.EnsureTenantKey(TenantCryptoKeyInput{Tenant: "tenant-b", Algorithm: "chacha20-poly1305"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := store.Seal("tenant-b", []byte("x"), nil); err == nil {
+		t.Fatalf("expected chacha20 key to be rejected for sealing")
+	}
+}
+
+func TestHKDFSHA256MatchesRFC5869(t *testing.T) {
+	ikm := make([]byte, 22)
+	for i := range ikm {
+		ikm[i] = 0x0b
+	}
+	salt := make([]byte, 13)
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+	info := make([]byte, 10)
+	for i := range info {
+		info[i] = byte(0xf0 + i)
+	}
+	got := hex.EncodeToString(hkdfSHA256(ikm, salt, info, 42))
+	want := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+	if got != want {
+		t.Fatalf("unexpected okm %s", got)
+	}
+}
@@ -0,0 +1,266 @@
+package control
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// Test kinds a test impact selection can target.
+const (
+	TestImpactScenario         = "scenario"
+	TestImpactHarnessCase      = "harness_case"
+	TestImpactMutationSuite    = "mutation_suite"
+	TestImpactConformanceSuite = "conformance_suite"
+)
+
+// Confidence weights per link between a changed object and a test. Each
+// dependency hop between the changed object and the config a test covers
+// further scales the weight by testImpactHopDecay.
+const (
+	testImpactConfigWeight      = 1.0
+	testImpactSuiteCaseWeight   = 0.9
+	testImpactResourceWeight    = 0.75
+	testImpactProviderWeight    = 0.6
+	testImpactHopDecay          = 0.85
+	testImpactDefaultConfidence = 0.5
+)
+
+type TestImpactTarget struct {
+	Kind       string   `json:"kind"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Confidence float64  `json:"confidence"`
+	Reasons    []string `json:"reasons"`
+}
+
+type TestImpactSelection struct {
+	Targets            []TestImpactTarget `json:"targets"`
+	Skipped            int                `json:"skipped"`  // matched targets below the confidence threshold
+	Unmapped           []string           `json:"unmapped"` // changed config files that select no test at min_confidence
+	FullRunRecommended bool               `json:"full_run_recommended"`
+	MinConfidence      float64            `json:"min_confidence"`
+}
+
+// TestImpactSelector maps changed objects to the scenarios, harness cases,
+// mutation suites, and conformance suites that exercise them, by walking the
+// object model dependency graph from each change to the configs tests cover.
+type TestImpactSelector struct {
+	model       *ObjectModelRegistry
+	scenarios   *ScenarioTestStore
+	harness     *ModulePolicyHarnessStore
+	mutations   *MutationStore
+	conformance *ProviderConformanceStore
+	baseDir     string
+}
+
+// NewTestImpactSelector resolves relative config paths on scenarios and
+// mutation suites against baseDir.
+func NewTestImpactSelector(model *ObjectModelRegistry, scenarios *ScenarioTestStore, harness *ModulePolicyHarnessStore, mutations *MutationStore, conformance *ProviderConformanceStore, baseDir string) *TestImpactSelector {
+	return &TestImpactSelector{
+		model:       model,
+		scenarios:   scenarios,
+		harness:     harness,
+		mutations:   mutations,
+		conformance: conformance,
+		baseDir:     baseDir,
+	}
+}
+
+// Select returns the minimal set of tests whose confidence reaches
+// minConfidence (default 0.5), ordered by confidence. Config paths in changes
+// must already be resolved. Changed config files that select no test at
+// minConfidence are reported as unmapped, and CI should fall back to a full
+// run for them.
+func (t *TestImpactSelector) Select(changes []ObjectDependencyNode, minConfidence float64) (TestImpactSelection, error) {
+	if minConfidence < 0 || minConfidence > 1 {
+		return TestImpactSelection{}, errors.New("min_confidence must be between 0 and 1")
+	}
+	if minConfidence == 0 {
+		minConfidence = testImpactDefaultConfidence
+	}
+	scenarios := t.scenarios.ListScenarios()
+	suites := t.mutations.ListSuites()
+	// Index the configs tests cover so their includes, imports, and overlays
+	// are part of the graph even when no template or job references them.
+	for _, scenario := range scenarios {
+		for _, path := range scenario.ConfigPaths {
+			_ = t.model.IndexConfig(t.resolve(path))
+		}
+	}
+	for _, suite := range suites {
+		if suite.ConfigPath != "" {
+			_ = t.model.IndexConfig(t.resolve(suite.ConfigPath))
+		}
+	}
+
+	selected := map[string]*TestImpactTarget{}
+	best := 0.0
+	add := func(kind, id, name string, confidence float64, reason string) {
+		key := kind + ":" + id
+		item, ok := selected[key]
+		if !ok {
+			item = &TestImpactTarget{Kind: kind, ID: id, Name: name, Reasons: []string{}}
+			selected[key] = item
+		}
+		confidence = math.Round(confidence*1000) / 1000
+		if confidence > best {
+			best = confidence
+		}
+		if confidence > item.Confidence {
+			item.Confidence = confidence
+		}
+		for _, existing := range item.Reasons {
+			if existing == reason {
+				return
+			}
+		}
+		item.Reasons = append(item.Reasons, reason)
+	}
+
+	cases := t.harness.ListCases()
+	conformance := t.conformance.ListSuites()
+	unmapped := []string{}
+	for _, change := range changes {
+		kind := strings.ToLower(strings.TrimSpace(change.Kind))
+		name := strings.TrimSpace(change.Name)
+		if name == "" {
+			continue
+		}
+		switch kind {
+		case ObjectKindConfig, ObjectKindModule:
+			name = filepath.Clean(name)
+		case ObjectKindDataBag:
+			name = normalizeDataBagName(name)
+		case ObjectKindTemplate:
+		default:
+			return TestImpactSelection{}, errors.New("kind must be config, module, template, or data_bag")
+		}
+		label := kind + " " + name
+		best = 0
+		distances := t.model.impactDistances(kind, name)
+
+		for _, scenario := range scenarios {
+			for _, path := range scenario.ConfigPaths {
+				if hops, ok := distances[t.resolve(path)]; ok {
+					add(TestImpactScenario, scenario.ID, scenario.Name, testImpactConfigWeight*math.Pow(testImpactHopDecay, float64(hops)), testImpactReason(label, path, hops))
+				}
+			}
+		}
+		for _, suite := range suites {
+			if suite.ConfigPath == "" {
+				continue
+			}
+			hops, ok := distances[t.resolve(suite.ConfigPath)]
+			if !ok {
+				continue
+			}
+			decay := math.Pow(testImpactHopDecay, float64(hops))
+			reason := testImpactReason(label, suite.ConfigPath, hops)
+			add(TestImpactMutationSuite, suite.ID, suite.Name, testImpactConfigWeight*decay, reason)
+			for _, caseID := range suite.HarnessCaseIDs {
+				add(TestImpactHarnessCase, caseID, harnessCaseName(cases, caseID), testImpactSuiteCaseWeight*decay, reason+" via mutation suite "+suite.ID)
+			}
+		}
+
+		// A changed config file also selects harness cases asserting on the
+		// resources it declares and conformance suites for their providers.
+		if kind == ObjectKindConfig || kind == ObjectKindModule {
+			if raw, err := config.LoadRaw(name); err == nil {
+				ids := map[string]bool{}
+				types := map[string]bool{}
+				for _, res := range raw.Resources {
+					ids[strings.ToLower(res.ID)] = true
+					types[res.Type] = true
+				}
+				for _, c := range cases {
+					for _, assertion := range c.Assertions {
+						if id := harnessAssertionResource(assertion.Field); id != "" && ids[id] {
+							add(TestImpactHarnessCase, c.ID, c.Name, testImpactResourceWeight, label+" declares resource "+id)
+						}
+					}
+				}
+				for _, suite := range conformance {
+					if types[suite.Provider] {
+						add(TestImpactConformanceSuite, suite.ID, suite.Provider, testImpactProviderWeight, label+" declares "+suite.Provider+" resources")
+					}
+				}
+			}
+			if best < minConfidence && isConfigFileName(name) {
+				unmapped = append(unmapped, name)
+			}
+		}
+	}
+
+	out := TestImpactSelection{Targets: []TestImpactTarget{}, Unmapped: unmapped, MinConfidence: minConfidence}
+	for _, item := range selected {
+		if item.Confidence < minConfidence {
+			out.Skipped++
+			continue
+		}
+		out.Targets = append(out.Targets, *item)
+	}
+	sort.Slice(out.Targets, func(i, j int) bool {
+		a, b := out.Targets[i], out.Targets[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+	sort.Strings(out.Unmapped)
+	out.FullRunRecommended = len(out.Unmapped) > 0
+	return out, nil
+}
+
+func (t *TestImpactSelector) resolve(path string) string {
+	path = strings.TrimSpace(path)
+	if path != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(t.baseDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+func testImpactReason(label, path string, hops int) string {
+	if hops == 0 {
+		return label + " is covered config " + path
+	}
+	return label + " reaches covered config " + path + " in " + itoa(int64(hops)) + " hop(s)"
+}
+
+// harnessAssertionResource extracts <id> from resource.<id>.<field>
+// observation fields.
+func harnessAssertionResource(field string) string {
+	rest, ok := strings.CutPrefix(field, "resource.")
+	if !ok {
+		return ""
+	}
+	idx := strings.LastIndex(rest, ".")
+	if idx <= 0 {
+		return ""
+	}
+	return rest[:idx]
+}
+
+func harnessCaseName(cases []ModulePolicyHarnessCase, id string) string {
+	for _, c := range cases {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+func isConfigFileName(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
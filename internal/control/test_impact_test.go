@@ -0,0 +1,111 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTestImpactSelectorWalksDependencyGraph(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(tmp, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("base.yaml", `version: v0
+resources:
+  - id: motd
+    type: file
+    host: localhost
+    path: /etc/motd
+    content: "{{ data_bag.motd.default }}"
+`)
+	write("web.yaml", `version: v0
+includes:
+  - base.yaml
+`)
+	write("policy.yaml", `version: v0
+resources:
+  - id: sshd
+    type: file
+    host: localhost
+    path: /etc/ssh/sshd_config
+    content: "PermitRootLogin no\n"
+`)
+	orphan := write("orphan.yaml", `version: v0
+resources: []
+`)
+
+	scenarios := NewScenarioTestStore()
+	if _, err := scenarios.UpsertScenario(ScenarioDefinition{ID: "web-smoke", Name: "Web smoke", FleetSize: 3, Services: 1, ConfigPaths: []string{"web.yaml", " web.yaml "}}); err != nil {
+		t.Fatalf("upsert scenario: %v", err)
+	}
+	if _, err := scenarios.UpsertScenario(ScenarioDefinition{ID: "db-smoke", Name: "DB smoke", FleetSize: 3, Services: 1, ConfigPaths: []string{"db.yaml"}}); err != nil {
+		t.Fatalf("upsert scenario: %v", err)
+	}
+	harness := NewModulePolicyHarnessStore()
+	if _, err := harness.UpsertCase(ModulePolicyHarnessCaseInput{ID: "motd-present", Name: "motd present", Kind: "module", Assertions: []ModulePolicyHarnessAssertionInput{{Field: "resource.motd.present", Expected: "true"}}}); err != nil {
+		t.Fatalf("upsert harness case: %v", err)
+	}
+	if _, err := harness.UpsertCase(ModulePolicyHarnessCaseInput{ID: "sshd-root", Name: "sshd root login", Kind: "policy", Assertions: []ModulePolicyHarnessAssertionInput{{Field: "resource.sshd.content", Expected: "PermitRootLogin no\n"}}}); err != nil {
+		t.Fatalf("upsert harness case: %v", err)
+	}
+	mutations := NewMutationStore()
+	if _, err := mutations.UpsertSuite(MutationSuite{ID: "sshd-policy", Provider: "file", Name: "sshd policy", CriticalPaths: []string{"policy"}, ConfigPath: "policy.yaml", HarnessCaseIDs: []string{"sshd-root"}}); err != nil {
+		t.Fatalf("upsert mutation suite: %v", err)
+	}
+	selector := NewTestImpactSelector(NewObjectModelRegistry(), scenarios, harness, mutations, NewProviderConformanceStore(), tmp)
+
+	sel, err := selector.Select([]ObjectDependencyNode{{Kind: ObjectKindConfig, Name: base}}, 0)
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	got := map[string]TestImpactTarget{}
+	for _, target := range sel.Targets {
+		got[target.Kind+":"+target.ID] = target
+	}
+	if len(got) != 3 || sel.FullRunRecommended || sel.MinConfidence != 0.5 {
+		t.Fatalf("expected scenario, harness case, and conformance suite, got %+v", sel)
+	}
+	if web := got["scenario:web-smoke"]; web.Confidence != 0.85 || len(web.Reasons) != 1 {
+		t.Fatalf("expected one-hop scenario confidence, got %+v", web)
+	}
+	if got["harness_case:motd-present"].Confidence != 0.75 || got["conformance_suite:provider-file-core"].Confidence != 0.6 {
+		t.Fatalf("unexpected indirect confidences: %+v", sel.Targets)
+	}
+	if sel.Targets[0].ID != "web-smoke" {
+		t.Fatalf("expected targets ordered by confidence, got %+v", sel.Targets)
+	}
+
+	sel, err = selector.Select([]ObjectDependencyNode{{Kind: ObjectKindDataBag, Name: "motd"}}, 0)
+	if err != nil {
+		t.Fatalf("select data bag: %v", err)
+	}
+	if len(sel.Targets) != 1 || sel.Targets[0].ID != "web-smoke" || sel.Targets[0].Confidence != 0.722 {
+		t.Fatalf("expected data bag to reach web scenario in two hops, got %+v", sel.Targets)
+	}
+
+	sel, err = selector.Select([]ObjectDependencyNode{
+		{Kind: ObjectKindConfig, Name: filepath.Join(tmp, "policy.yaml")},
+		{Kind: ObjectKindConfig, Name: orphan},
+	}, 0.8)
+	if err != nil {
+		t.Fatalf("select policy: %v", err)
+	}
+	if len(sel.Targets) != 2 || sel.Targets[0].Kind != TestImpactMutationSuite || sel.Targets[1].ID != "sshd-root" || sel.Targets[1].Confidence != 0.9 {
+		t.Fatalf("expected mutation suite and its harness case, got %+v", sel.Targets)
+	}
+	if sel.Skipped != 1 || !sel.FullRunRecommended || len(sel.Unmapped) != 1 || sel.Unmapped[0] != orphan {
+		t.Fatalf("expected low-confidence conformance skip and orphan fallback, got %+v", sel)
+	}
+
+	if _, err := selector.Select(nil, 2); err == nil {
+		t.Fatalf("expected min_confidence validation error")
+	}
+	if _, err := selector.Select([]ObjectDependencyNode{{Kind: "host", Name: "web-1"}}, 0); err == nil {
+		t.Fatalf("expected unknown kind error")
+	}
+}
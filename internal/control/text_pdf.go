@@ -0,0 +1,113 @@
+package control
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+const (
+	textPDFPageWidth  = 612 // US Letter, points
+	textPDFPageHeight = 792
+	textPDFMargin     = 54
+	textPDFFontSize   = 9
+	textPDFLeading    = 12
+	textPDFColumns    = 95 // Courier glyphs are 0.6em wide
+)
+
+// RenderTextPDF lays out plain text as a paginated PDF in the built-in
+// Courier font. Long lines wrap at the page width and characters outside
+// printable ASCII are replaced, so the output needs no embedded fonts.
+func RenderTextPDF(title, text string) []byte {
+	lines := wrapTextPDFLines(text)
+	perPage := (textPDFPageHeight - 2*textPDFMargin) / textPDFLeading
+	pages := make([][]string, 0, len(lines)/perPage+1)
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, 4 info, then a page and its
+	// content stream per page.
+	objects := make([]string, 4, 4+2*len(pages))
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, strconv.Itoa(5+2*i)+" 0 R")
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = "<< /Type /Pages /Kids [" + strings.Join(kids, " ") + "] /Count " + strconv.Itoa(len(pages)) + " >>"
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"
+	objects[3] = "<< /Title (" + escapeTextPDF(title) + ") /Producer (masterchef) >>"
+	for i, page := range pages {
+		var content strings.Builder
+		content.WriteString("BT /F1 " + strconv.Itoa(textPDFFontSize) + " Tf " + strconv.Itoa(textPDFLeading) + " TL ")
+		content.WriteString(strconv.Itoa(textPDFMargin) + " " + strconv.Itoa(textPDFPageHeight-textPDFMargin) + " Td\n")
+		for _, line := range page {
+			content.WriteString("(" + escapeTextPDF(line) + ") Tj T*\n")
+		}
+		content.WriteString("ET")
+		stream := content.String()
+		objects = append(objects,
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 "+strconv.Itoa(textPDFPageWidth)+" "+strconv.Itoa(textPDFPageHeight)+"] /Resources << /Font << /F1 3 0 R >> >> /Contents "+strconv.Itoa(6+2*i)+" 0 R >>",
+			"<< /Length "+strconv.Itoa(len(stream))+" >>\nstream\n"+stream+"\nendstream",
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		out.WriteString(strconv.Itoa(i+1) + " 0 obj\n" + obj + "\nendobj\n")
+	}
+	xref := out.Len()
+	out.WriteString("xref\n0 " + strconv.Itoa(len(objects)+1) + "\n0000000000 65535 f \n")
+	for _, off := range offsets {
+		pad := strconv.Itoa(off)
+		out.WriteString(strings.Repeat("0", 10-len(pad)) + pad + " 00000 n \n")
+	}
+	out.WriteString("trailer\n<< /Size " + strconv.Itoa(len(objects)+1) + " /Root 1 0 R /Info 4 0 R >>\n")
+	out.WriteString("startxref\n" + strconv.Itoa(xref) + "\n%%EOF\n")
+	return out.Bytes()
+}
+
+func wrapTextPDFLines(text string) []string {
+	out := make([]string, 0, 64)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		runes := []rune(line)
+		for len(runes) > textPDFColumns {
+			cut := textPDFColumns
+			for i := textPDFColumns; i > textPDFColumns/2; i-- {
+				if runes[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			out = append(out, string(runes[:cut]))
+			runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+			if len(runes) > 0 {
+				runes = append([]rune("  "), runes...)
+			}
+		}
+		out = append(out, string(runes))
+	}
+	return out
+}
+
+func escapeTextPDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,495 @@
+package control
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type TicketPushResult struct {
+	IntegrationID  string           `json:"integration_id"`
+	Provider       string           `json:"provider"`
+	ChangeRecordID string           `json:"change_record_id"`
+	Operation      string           `json:"operation"` // create|update|noop
+	Status         string           `json:"status"`    // synced|failed|skipped
+	Error          string           `json:"error,omitempty"`
+	Link           ChangeTicketLink `json:"link,omitempty"`
+}
+
+// TicketPush is a change record waiting to be mirrored to auto_create ticket
+// integrations by the background push worker.
+type TicketPush struct {
+	ChangeRecordID string    `json:"change_record_id"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+type TicketCallbackInput struct {
+	TicketID string `json:"ticket_id"`
+	Status   string `json:"status"`
+	Actor    string `json:"actor,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type TicketCallbackResult struct {
+	IntegrationID  string           `json:"integration_id"`
+	ChangeRecordID string           `json:"change_record_id"`
+	TicketStatus   string           `json:"ticket_status"`
+	Decision       string           `json:"decision,omitempty"` // approve|reject|complete|fail
+	Actor          string           `json:"actor"`
+	Comment        string           `json:"comment,omitempty"`
+	Link           ChangeTicketLink `json:"link"`
+}
+
+// TicketStatusForChangeRecord maps a change record lifecycle status onto the
+// ticket status vocabulary used by change ticket links.
+func TicketStatusForChangeRecord(status ChangeRecordStatus) string {
+	switch status {
+	case ChangeRecordApproved:
+		return "approved"
+	case ChangeRecordRejected:
+		return "rejected"
+	case ChangeRecordExecuting:
+		return "implementing"
+	case ChangeRecordCompleted:
+		return "implemented"
+	case ChangeRecordFailed:
+		return "failed"
+	default:
+		return "open"
+	}
+}
+
+// PushChangeRecord creates or updates tickets in every enabled auto_create
+// integration for the given change record.
+func (s *TicketIntegrationStore) PushChangeRecord(rec ChangeRecord) []TicketPushResult {
+	s.mu.RLock()
+	targets := make([]TicketIntegration, 0, len(s.integrations))
+	for _, item := range s.integrations {
+		if item.Enabled && item.AutoCreate {
+			targets = append(targets, *item)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ID < targets[j].ID })
+
+	status := TicketStatusForChangeRecord(rec.Status)
+	out := make([]TicketPushResult, 0, len(targets))
+	for _, integration := range targets {
+		key := integration.ID + "|" + rec.ID
+		s.mu.RLock()
+		existing, linked := s.links[key]
+		var link ChangeTicketLink
+		if linked {
+			link = *existing
+		}
+		s.mu.RUnlock()
+
+		result := TicketPushResult{IntegrationID: integration.ID, Provider: integration.Provider, ChangeRecordID: rec.ID}
+		if linked && link.Status == status {
+			result.Operation = "noop"
+			result.Status = "skipped"
+			result.Link = link
+			out = append(out, result)
+			continue
+		}
+		var err error
+		if !linked {
+			result.Operation = "create"
+			link, err = s.createRemoteTicket(integration, rec)
+			link.Origin = "outbound"
+		} else {
+			result.Operation = "update"
+			err = s.updateRemoteTicket(integration, link, rec, status)
+		}
+		link.IntegrationID = integration.ID
+		link.ChangeRecordID = rec.ID
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			if !linked {
+				out = append(out, result)
+				continue
+			}
+			link.LastError = err.Error()
+		} else {
+			result.Status = "synced"
+			link.Status = status
+			link.LastError = ""
+		}
+		link.SyncedAt = time.Now().UTC()
+		result.Link = s.storeLink(key, link)
+		out = append(out, result)
+	}
+	return out
+}
+
+// EnqueuePush queues a change record for the push worker when any
+// auto_create integration is enabled. A record already waiting is reset so
+// the worker pushes its latest state promptly.
+func (s *TicketIntegrationStore) EnqueuePush(changeRecordID string) bool {
+	changeRecordID = strings.TrimSpace(changeRecordID)
+	if changeRecordID == "" || !s.HasAutoCreate() {
+		return false
+	}
+	s.mu.Lock()
+	s.pushes[changeRecordID] = &TicketPush{ChangeRecordID: changeRecordID, NextAttemptAt: time.Now().UTC()}
+	s.mu.Unlock()
+	select {
+	case s.pushReady <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// PushReady signals when EnqueuePush has queued new work.
+func (s *TicketIntegrationStore) PushReady() <-chan struct{} {
+	return s.pushReady
+}
+
+// NextPush claims the push that has been due the longest.
+func (s *TicketIntegrationStore) NextPush(now time.Time) (TicketPush, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next *TicketPush
+	for _, item := range s.pushes {
+		if item.NextAttemptAt.After(now) {
+			continue
+		}
+		if next == nil || item.NextAttemptAt.Before(next.NextAttemptAt) {
+			next = item
+		}
+	}
+	if next == nil {
+		return TicketPush{}, false
+	}
+	delete(s.pushes, next.ChangeRecordID)
+	next.Attempts++
+	return *next, true
+}
+
+// CompletePush records a push attempt. Failed pushes are retried with
+// exponential backoff unless the record was queued again in the meantime;
+// the second result reports a push that ran out of attempts.
+func (s *TicketIntegrationStore) CompletePush(push TicketPush, pushErr error) (TicketPush, bool) {
+	if pushErr == nil {
+		push.LastError = ""
+		return push, false
+	}
+	push.LastError = pushErr.Error()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, queued := s.pushes[push.ChangeRecordID]; queued {
+		return push, false
+	}
+	if push.Attempts >= s.maxPushAttempts {
+		return push, true
+	}
+	push.NextAttemptAt = time.Now().UTC().Add(s.pushBackoff << (push.Attempts - 1))
+	cp := push
+	s.pushes[push.ChangeRecordID] = &cp
+	return push, false
+}
+
+// ApplyCallback verifies and records an inbound ticket status webhook. The
+// returned decision tells the caller which change record transition to apply.
+func (s *TicketIntegrationStore) ApplyCallback(integrationID string, body []byte, signature string) (TicketCallbackResult, error) {
+	integrationID = strings.TrimSpace(integrationID)
+	s.mu.RLock()
+	integration, ok := s.integrations[integrationID]
+	var cp TicketIntegration
+	if ok {
+		cp = *integration
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return TicketCallbackResult{}, errors.New("ticket integration not found")
+	}
+	if !cp.Enabled {
+		return TicketCallbackResult{}, errors.New("ticket integration is disabled")
+	}
+	if cp.CallbackSecret == "" {
+		return TicketCallbackResult{}, errors.New("callback signature cannot be verified: integration has no callback secret")
+	}
+	expected := signPayload(body, cp.CallbackSecret)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return TicketCallbackResult{}, errors.New("invalid callback signature")
+	}
+	in, err := parseTicketCallback(cp.Provider, body)
+	if err != nil {
+		return TicketCallbackResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var link *ChangeTicketLink
+	for _, candidate := range s.links {
+		if candidate.IntegrationID != integrationID {
+			continue
+		}
+		if strings.EqualFold(candidate.TicketID, in.TicketID) || (candidate.ExternalID != "" && candidate.ExternalID == in.TicketID) {
+			link = candidate
+			break
+		}
+	}
+	if link == nil {
+		return TicketCallbackResult{}, errors.New("no change record linked to ticket " + in.TicketID)
+	}
+	status, decision := normalizeTicketCallbackStatus(in.Status)
+	if status == "" {
+		return TicketCallbackResult{}, errors.New("unsupported ticket status: " + in.Status)
+	}
+	link.Status = status
+	link.Origin = "callback"
+	link.LastError = ""
+	link.SyncedAt = time.Now().UTC()
+	actor := strings.TrimSpace(in.Actor)
+	if actor == "" {
+		actor = cp.Provider + ":" + cp.Name
+	}
+	return TicketCallbackResult{
+		IntegrationID:  integrationID,
+		ChangeRecordID: link.ChangeRecordID,
+		TicketStatus:   status,
+		Decision:       decision,
+		Actor:          actor,
+		Comment:        strings.TrimSpace(in.Comment),
+		Link:           *link,
+	}, nil
+}
+
+func (s *TicketIntegrationStore) storeLink(key string, link ChangeTicketLink) ChangeTicketLink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.links[key]; ok {
+		link.ID = existing.ID
+	} else {
+		s.nextLinkID++
+		link.ID = "ticket-link-" + itoa(s.nextLinkID)
+	}
+	cp := link
+	s.links[key] = &cp
+	return cp
+}
+
+func (s *TicketIntegrationStore) createRemoteTicket(integration TicketIntegration, rec ChangeRecord) (ChangeTicketLink, error) {
+	description := changeRecordTicketDescription(rec)
+	switch integration.Provider {
+	case "jira":
+		body := map[string]any{
+			"fields": map[string]any{
+				"project":     map[string]string{"key": integration.ProjectKey},
+				"summary":     rec.Summary,
+				"description": description,
+				"issuetype":   map[string]string{"name": integration.IssueType},
+				"labels":      []string{"masterchef", rec.ID},
+			},
+		}
+		var resp struct {
+			ID  string `json:"id"`
+			Key string `json:"key"`
+		}
+		if err := s.doTicketRequest(integration, http.MethodPost, "/rest/api/2/issue", body, &resp); err != nil {
+			return ChangeTicketLink{}, err
+		}
+		if resp.Key == "" {
+			return ChangeTicketLink{}, errors.New("jira response missing issue key")
+		}
+		return ChangeTicketLink{
+			TicketID:   resp.Key,
+			ExternalID: resp.ID,
+			TicketURL:  buildTicketURL(integration, resp.Key),
+		}, nil
+	case "servicenow":
+		body := map[string]any{
+			"short_description": rec.Summary,
+			"description":       description,
+			"correlation_id":    rec.ID,
+			"requested_by":      rec.RequestedBy,
+		}
+		var resp struct {
+			Result struct {
+				SysID  string `json:"sys_id"`
+				Number string `json:"number"`
+			} `json:"result"`
+		}
+		if err := s.doTicketRequest(integration, http.MethodPost, "/api/now/table/change_request", body, &resp); err != nil {
+			return ChangeTicketLink{}, err
+		}
+		if resp.Result.Number == "" {
+			return ChangeTicketLink{}, errors.New("servicenow response missing change number")
+		}
+		return ChangeTicketLink{
+			TicketID:   resp.Result.Number,
+			ExternalID: resp.Result.SysID,
+			TicketURL:  buildTicketURL(integration, resp.Result.Number),
+		}, nil
+	default:
+		return ChangeTicketLink{}, errors.New("provider does not support ticket creation: " + integration.Provider)
+	}
+}
+
+func (s *TicketIntegrationStore) updateRemoteTicket(integration TicketIntegration, link ChangeTicketLink, rec ChangeRecord, status string) error {
+	switch integration.Provider {
+	case "jira":
+		comment := fmt.Sprintf("Masterchef change record %s is now %s.", rec.ID, status)
+		if rec.LinkedJobID != "" {
+			comment += " Linked job: " + rec.LinkedJobID + "."
+		}
+		if rec.FailureReason != "" {
+			comment += " Failure reason: " + rec.FailureReason
+		}
+		return s.doTicketRequest(integration, http.MethodPost, "/rest/api/2/issue/"+link.TicketID+"/comment", map[string]string{"body": comment}, nil)
+	case "servicenow":
+		if link.ExternalID == "" {
+			return errors.New("servicenow link is missing sys_id")
+		}
+		body := map[string]string{
+			"state":      servicenowChangeState(status),
+			"work_notes": fmt.Sprintf("Masterchef change record %s is now %s.", rec.ID, status),
+		}
+		return s.doTicketRequest(integration, http.MethodPatch, "/api/now/table/change_request/"+link.ExternalID, body, nil)
+	default:
+		return errors.New("provider does not support ticket updates: " + integration.Provider)
+	}
+}
+
+func (s *TicketIntegrationStore) doTicketRequest(integration TicketIntegration, method, path string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, integration.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if integration.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+integration.APIToken)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", integration.Provider, resp.StatusCode)
+	}
+	if out == nil || len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return errors.New("invalid " + integration.Provider + " response: " + err.Error())
+	}
+	return nil
+}
+
+func parseTicketCallback(provider string, body []byte) (TicketCallbackInput, error) {
+	var in TicketCallbackInput
+	if err := json.Unmarshal(body, &in); err != nil {
+		return TicketCallbackInput{}, errors.New("invalid json body")
+	}
+	if provider == "jira" && strings.TrimSpace(in.TicketID) == "" {
+		// Native Jira issue webhooks carry the key and status name nested
+		// under issue.fields.
+		var native struct {
+			Issue struct {
+				Key    string `json:"key"`
+				Fields struct {
+					Status struct {
+						Name string `json:"name"`
+					} `json:"status"`
+				} `json:"fields"`
+			} `json:"issue"`
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		}
+		if err := json.Unmarshal(body, &native); err == nil {
+			in.TicketID = native.Issue.Key
+			in.Status = native.Issue.Fields.Status.Name
+			if in.Actor == "" && native.User.Name != "" {
+				in.Actor = "jira:" + native.User.Name
+			}
+		}
+	}
+	in.TicketID = strings.TrimSpace(in.TicketID)
+	if in.TicketID == "" || strings.TrimSpace(in.Status) == "" {
+		return TicketCallbackInput{}, errors.New("ticket_id and status are required")
+	}
+	return in, nil
+}
+
+func normalizeTicketCallbackStatus(status string) (string, string) {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "open", "new", "to do", "assess", "authorize":
+		return "open", ""
+	case "approved", "scheduled":
+		return "approved", "approve"
+	case "rejected", "declined", "canceled", "cancelled":
+		return "rejected", "reject"
+	case "implementing", "in progress", "implement":
+		return "implementing", ""
+	case "implemented", "done", "closed", "resolved", "review":
+		return "implemented", "complete"
+	case "failed":
+		return "failed", "fail"
+	default:
+		return "", ""
+	}
+}
+
+func servicenowChangeState(status string) string {
+	switch status {
+	case "approved":
+		return "scheduled"
+	case "implementing":
+		return "implement"
+	case "implemented":
+		return "review"
+	case "rejected":
+		return "canceled"
+	case "failed":
+		return "review"
+	default:
+		return "assess"
+	}
+}
+
+func changeRecordTicketDescription(rec ChangeRecord) string {
+	lines := []string{
+		"Masterchef change record " + rec.ID,
+		"Summary: " + rec.Summary,
+	}
+	if rec.RequestedBy != "" {
+		lines = append(lines, "Requested by: "+rec.RequestedBy)
+	}
+	if rec.ConfigPath != "" {
+		lines = append(lines, "Config: "+rec.ConfigPath)
+	}
+	if rec.LinkedJobID != "" {
+		lines = append(lines, "Linked job: "+rec.LinkedJobID)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *TicketIntegrationStore) HasAutoCreate() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, item := range s.integrations {
+		if item.Enabled && item.AutoCreate {
+			return true
+		}
+	}
+	return false
+}
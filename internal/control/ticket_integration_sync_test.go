@@ -0,0 +1,190 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTicketIntegrationPushCreatesAndUpdatesJiraIssue(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	jira := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path == "/rest/api/2/issue" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": "10001", "key": "OPS-7"})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer jira.Close()
+
+	store := NewTicketIntegrationStore()
+	integration, err := store.Upsert(TicketIntegrationInput{
+		Name:           "jira-prod",
+		Provider:       "jira",
+		BaseURL:        jira.URL,
+		ProjectKey:     "OPS",
+		AutoCreate:     true,
+		APIToken:       "token-1",
+		CallbackSecret: "shh",
+		Enabled:        true,
+	})
+	if err != nil {
+		t.Fatalf("upsert ticket integration failed: %v", err)
+	}
+	if !integration.HasAPIToken || integration.IssueType != "Task" {
+		t.Fatalf("expected token flag and default issue type, got %+v", integration)
+	}
+
+	rec := ChangeRecord{ID: "cr-1", Summary: "deploy", Status: ChangeRecordProposed}
+	results := store.PushChangeRecord(rec)
+	if len(results) != 1 || results[0].Operation != "create" || results[0].Status != "synced" {
+		t.Fatalf("expected created ticket, got %+v", results)
+	}
+	if results[0].Link.TicketID != "OPS-7" || results[0].Link.Status != "open" {
+		t.Fatalf("unexpected link %+v", results[0].Link)
+	}
+
+	results = store.PushChangeRecord(rec)
+	if len(results) != 1 || results[0].Operation != "noop" {
+		t.Fatalf("expected noop for unchanged status, got %+v", results)
+	}
+
+	rec.Status = ChangeRecordApproved
+	results = store.PushChangeRecord(rec)
+	if len(results) != 1 || results[0].Operation != "update" || results[0].Link.Status != "approved" {
+		t.Fatalf("expected ticket update, got %+v", results)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 2 || calls[1] != "POST /rest/api/2/issue/OPS-7/comment" {
+		t.Fatalf("unexpected jira calls %v", calls)
+	}
+}
+
+func TestTicketIntegrationCallbackVerifiesSignatureAndMapsDecision(t *testing.T) {
+	snow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]string{"sys_id": "abc123", "number": "CHG0042"}})
+	}))
+	defer snow.Close()
+
+	store := NewTicketIntegrationStore()
+	integration, err := store.Upsert(TicketIntegrationInput{
+		Name:           "snow",
+		Provider:       "servicenow",
+		BaseURL:        snow.URL,
+		AutoCreate:     true,
+		CallbackSecret: "shh",
+		Enabled:        true,
+	})
+	if err != nil {
+		t.Fatalf("upsert ticket integration failed: %v", err)
+	}
+	if results := store.PushChangeRecord(ChangeRecord{ID: "cr-9", Summary: "patch", Status: ChangeRecordProposed}); len(results) != 1 || results[0].Status != "synced" {
+		t.Fatalf("expected servicenow change request creation, got %+v", results)
+	}
+
+	body := []byte(`{"ticket_id":"CHG0042","status":"Scheduled","actor":"cab"}`)
+	if _, err := store.ApplyCallback(integration.ID, body, "sha256=bad"); err == nil {
+		t.Fatalf("expected signature rejection")
+	}
+	result, err := store.ApplyCallback(integration.ID, body, signPayload(body, "shh"))
+	if err != nil {
+		t.Fatalf("apply callback failed: %v", err)
+	}
+	if result.ChangeRecordID != "cr-9" || result.Decision != "approve" || result.Actor != "cab" {
+		t.Fatalf("unexpected callback result %+v", result)
+	}
+	if result.Link.Status != "approved" || result.Link.Origin != "callback" {
+		t.Fatalf("expected callback to update link status, got %+v", result.Link)
+	}
+}
+
+func TestTicketIntegrationAutoCreateRequiresSupportedProvider(t *testing.T) {
+	store := NewTicketIntegrationStore()
+	if _, err := store.Upsert(TicketIntegrationInput{
+		Name:           "gh",
+		Provider:       "github",
+		BaseURL:        "https://github.example.com/org/repo",
+		AutoCreate:     true,
+		CallbackSecret: "shh",
+		Enabled:        true,
+	}); err == nil {
+		t.Fatalf("expected auto_create rejection for github provider")
+	}
+}
+
+func TestTicketIntegrationRequiresCallbackSecret(t *testing.T) {
+	store := NewTicketIntegrationStore()
+	if _, err := store.Upsert(TicketIntegrationInput{Name: "jira", Provider: "jira", BaseURL: "https://jira.example.com", Enabled: true}); err == nil {
+		t.Fatalf("expected integration without callback secret to be rejected")
+	}
+}
+
+func TestTicketPushQueueRetriesWithBackoff(t *testing.T) {
+	store := NewTicketIntegrationStore()
+	store.maxPushAttempts = 2
+	store.pushBackoff = time.Minute
+	if store.EnqueuePush("cr-1") {
+		t.Fatalf("expected no push without auto_create integrations")
+	}
+	if _, err := store.Upsert(TicketIntegrationInput{
+		Name:           "jira",
+		Provider:       "jira",
+		BaseURL:        "https://jira.example.com",
+		AutoCreate:     true,
+		CallbackSecret: "shh",
+		Enabled:        true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !store.EnqueuePush("cr-1") {
+		t.Fatalf("expected push to be queued")
+	}
+	select {
+	case <-store.PushReady():
+	default:
+		t.Fatalf("expected enqueue to signal the worker")
+	}
+
+	now := time.Now().UTC()
+	push, ok := store.NextPush(now)
+	if !ok || push.ChangeRecordID != "cr-1" || push.Attempts != 1 {
+		t.Fatalf("unexpected claimed push %+v ok=%v", push, ok)
+	}
+	if _, exhausted := store.CompletePush(push, errors.New("jira down")); exhausted {
+		t.Fatalf("expected first failure to be retried")
+	}
+	if _, ok := store.NextPush(now); ok {
+		t.Fatalf("expected retry to wait for backoff")
+	}
+	push, ok = store.NextPush(now.Add(2 * time.Minute))
+	if !ok || push.Attempts != 2 || push.LastError != "jira down" {
+		t.Fatalf("expected retry after backoff, got %+v ok=%v", push, ok)
+	}
+	if push, exhausted := store.CompletePush(push, errors.New("jira down")); !exhausted || push.Attempts != 2 {
+		t.Fatalf("expected push to give up after max attempts, got %+v", push)
+	}
+	if _, ok := store.NextPush(now.Add(time.Hour)); ok {
+		t.Fatalf("expected abandoned push to leave the queue")
+	}
+
+	// A record queued again while a push is in flight supersedes the retry.
+	store.EnqueuePush("cr-2")
+	push, _ = store.NextPush(time.Now().UTC())
+	store.EnqueuePush("cr-2")
+	store.CompletePush(push, errors.New("jira down"))
+	if next, ok := store.NextPush(time.Now().UTC()); !ok || next.Attempts != 1 {
+		t.Fatalf("expected fresh push for re-queued record, got %+v ok=%v", next, ok)
+	}
+}
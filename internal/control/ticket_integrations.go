@@ -2,6 +2,7 @@ package control
 
 import (
 	"errors"
+	"net/http"
 	"sort"
 	"strings"
 	"sync"
@@ -9,21 +10,31 @@ import (
 )
 
 type TicketIntegrationInput struct {
-	Name       string `json:"name"`
-	Provider   string `json:"provider"` // jira|servicenow|github|custom
-	BaseURL    string `json:"base_url"`
-	ProjectKey string `json:"project_key,omitempty"`
-	Enabled    bool   `json:"enabled"`
+	Name           string `json:"name"`
+	Provider       string `json:"provider"` // jira|servicenow|github|custom
+	BaseURL        string `json:"base_url"`
+	ProjectKey     string `json:"project_key,omitempty"`
+	IssueType      string `json:"issue_type,omitempty"`
+	AutoCreate     bool   `json:"auto_create,omitempty"`
+	APIToken       string `json:"api_token,omitempty"`
+	CallbackSecret string `json:"callback_secret,omitempty"`
+	Enabled        bool   `json:"enabled"`
 }
 
 type TicketIntegration struct {
-	ID         string    `json:"id"`
-	Name       string    `json:"name"`
-	Provider   string    `json:"provider"`
-	BaseURL    string    `json:"base_url"`
-	ProjectKey string    `json:"project_key,omitempty"`
-	Enabled    bool      `json:"enabled"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	Provider          string    `json:"provider"`
+	BaseURL           string    `json:"base_url"`
+	ProjectKey        string    `json:"project_key,omitempty"`
+	IssueType         string    `json:"issue_type,omitempty"`
+	AutoCreate        bool      `json:"auto_create"`
+	APIToken          string    `json:"-"`
+	CallbackSecret    string    `json:"-"`
+	HasAPIToken       bool      `json:"has_api_token"`
+	HasCallbackSecret bool      `json:"has_callback_secret"`
+	Enabled           bool      `json:"enabled"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type ChangeTicketSyncInput struct {
@@ -38,8 +49,11 @@ type ChangeTicketLink struct {
 	IntegrationID  string    `json:"integration_id"`
 	ChangeRecordID string    `json:"change_record_id"`
 	TicketID       string    `json:"ticket_id"`
+	ExternalID     string    `json:"external_id,omitempty"`
 	TicketURL      string    `json:"ticket_url"`
 	Status         string    `json:"status"`
+	Origin         string    `json:"origin"` // manual|outbound|callback
+	LastError      string    `json:"last_error,omitempty"`
 	SyncedAt       time.Time `json:"synced_at"`
 }
 
@@ -55,12 +69,25 @@ type TicketIntegrationStore struct {
 	nextLinkID   int64
 	integrations map[string]*TicketIntegration
 	links        map[string]*ChangeTicketLink
+	client       *http.Client
+
+	pushes          map[string]*TicketPush
+	pushReady       chan struct{}
+	maxPushAttempts int
+	pushBackoff     time.Duration
 }
 
 func NewTicketIntegrationStore() *TicketIntegrationStore {
 	return &TicketIntegrationStore{
 		integrations: map[string]*TicketIntegration{},
 		links:        map[string]*ChangeTicketLink{},
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		pushes:          map[string]*TicketPush{},
+		pushReady:       make(chan struct{}, 1),
+		maxPushAttempts: 5,
+		pushBackoff:     5 * time.Second,
 	}
 }
 
@@ -76,14 +103,29 @@ func (s *TicketIntegrationStore) Upsert(in TicketIntegrationInput) (TicketIntegr
 	default:
 		return TicketIntegration{}, errors.New("provider must be jira, servicenow, github, or custom")
 	}
-	item := TicketIntegration{
-		Name:       name,
-		Provider:   provider,
-		BaseURL:    strings.TrimRight(baseURL, "/"),
-		ProjectKey: strings.TrimSpace(in.ProjectKey),
-		Enabled:    in.Enabled,
-		UpdatedAt:  time.Now().UTC(),
+	if in.AutoCreate && provider != "jira" && provider != "servicenow" {
+		return TicketIntegration{}, errors.New("auto_create is only supported for jira and servicenow")
 	}
+	if strings.TrimSpace(in.CallbackSecret) == "" {
+		return TicketIntegration{}, errors.New("callback_secret is required")
+	}
+	item := TicketIntegration{
+		Name:           name,
+		Provider:       provider,
+		BaseURL:        strings.TrimRight(baseURL, "/"),
+		ProjectKey:     strings.TrimSpace(in.ProjectKey),
+		IssueType:      strings.TrimSpace(in.IssueType),
+		AutoCreate:     in.AutoCreate,
+		APIToken:       strings.TrimSpace(in.APIToken),
+		CallbackSecret: strings.TrimSpace(in.CallbackSecret),
+		Enabled:        in.Enabled,
+		UpdatedAt:      time.Now().UTC(),
+	}
+	if provider == "jira" && item.IssueType == "" {
+		item.IssueType = "Task"
+	}
+	item.HasAPIToken = item.APIToken != ""
+	item.HasCallbackSecret = item.CallbackSecret != ""
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, existing := range s.integrations {
@@ -179,6 +221,8 @@ func (s *TicketIntegrationStore) Sync(in ChangeTicketSyncInput, changeRecordExis
 	item.TicketID = ticketID
 	item.TicketURL = buildTicketURL(*integration, ticketID)
 	item.Status = status
+	item.Origin = "manual"
+	item.LastError = ""
 	item.SyncedAt = time.Now().UTC()
 	s.links[key] = item
 	return TicketSyncResult{Linked: true, Link: *item}
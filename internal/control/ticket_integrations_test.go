@@ -5,11 +5,12 @@ import "testing"
 func TestTicketIntegrationSync(t *testing.T) {
 	store := NewTicketIntegrationStore()
 	integration, err := store.Upsert(TicketIntegrationInput{
-		Name:       "jira-prod",
-		Provider:   "jira",
-		BaseURL:    "https://tickets.example.com",
-		ProjectKey: "OPS",
-		Enabled:    true,
+		Name:           "jira-prod",
+		Provider:       "jira",
+		BaseURL:        "https://tickets.example.com",
+		ProjectKey:     "OPS",
+		CallbackSecret: "shh",
+		Enabled:        true,
 	})
 	if err != nil {
 		t.Fatalf("upsert ticket integration failed: %v", err)
@@ -31,10 +32,11 @@ func TestTicketIntegrationSync(t *testing.T) {
 func TestTicketIntegrationSyncRejectsDisabled(t *testing.T) {
 	store := NewTicketIntegrationStore()
 	integration, err := store.Upsert(TicketIntegrationInput{
-		Name:     "custom-disabled",
-		Provider: "custom",
-		BaseURL:  "https://custom.example.com/tickets",
-		Enabled:  false,
+		Name:           "custom-disabled",
+		Provider:       "custom",
+		BaseURL:        "https://custom.example.com/tickets",
+		CallbackSecret: "shh",
+		Enabled:        false,
 	})
 	if err != nil {
 		t.Fatalf("upsert ticket integration failed: %v", err)
@@ -16,17 +16,23 @@ type TopologyPlacementPolicyInput struct {
 	FailureDomain string `json:"failure_domain,omitempty"`
 	Weight        int    `json:"weight,omitempty"`
 	MaxParallel   int    `json:"max_parallel,omitempty"`
+	DataResidency bool   `json:"data_residency,omitempty"`
+	MaxLatencyMS  int    `json:"max_latency_ms,omitempty"`
 }
 
 type TopologyPlacementPolicy struct {
-	ID            string    `json:"id"`
-	Environment   string    `json:"environment"`
-	Region        string    `json:"region,omitempty"`
-	Zone          string    `json:"zone,omitempty"`
-	Cluster       string    `json:"cluster,omitempty"`
-	FailureDomain string    `json:"failure_domain,omitempty"`
-	Weight        int       `json:"weight"`
-	MaxParallel   int       `json:"max_parallel"`
+	ID            string `json:"id"`
+	Environment   string `json:"environment"`
+	Region        string `json:"region,omitempty"`
+	Zone          string `json:"zone,omitempty"`
+	Cluster       string `json:"cluster,omitempty"`
+	FailureDomain string `json:"failure_domain,omitempty"`
+	Weight        int    `json:"weight"`
+	MaxParallel   int    `json:"max_parallel"`
+	// DataResidency pins matching jobs to workers in Region; MaxLatencyMS
+	// excludes workers reporting a higher latency.
+	DataResidency bool      `json:"data_residency,omitempty"`
+	MaxLatencyMS  int       `json:"max_latency_ms,omitempty"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
@@ -78,6 +84,12 @@ func (s *TopologyPlacementStore) Upsert(in TopologyPlacementPolicyInput) (Topolo
 	if maxParallel <= 0 {
 		maxParallel = 25
 	}
+	if in.DataResidency && region == "" {
+		return TopologyPlacementPolicy{}, errors.New("data residency requires a region")
+	}
+	if in.MaxLatencyMS < 0 {
+		return TopologyPlacementPolicy{}, errors.New("max_latency_ms must be non-negative")
+	}
 	item := TopologyPlacementPolicy{
 		Environment:   environment,
 		Region:        region,
@@ -86,6 +98,8 @@ func (s *TopologyPlacementStore) Upsert(in TopologyPlacementPolicyInput) (Topolo
 		FailureDomain: failureDomain,
 		Weight:        weight,
 		MaxParallel:   maxParallel,
+		DataResidency: in.DataResidency,
+		MaxLatencyMS:  in.MaxLatencyMS,
 		UpdatedAt:     time.Now().UTC(),
 	}
 
@@ -125,6 +139,18 @@ func (s *TopologyPlacementStore) List() []TopologyPlacementPolicy {
 	return out
 }
 
+func (s *TopologyPlacementStore) Get(id string) (TopologyPlacementPolicy, bool) {
+	id = strings.TrimSpace(id)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, item := range s.policies {
+		if item.ID == id {
+			return *item, true
+		}
+	}
+	return TopologyPlacementPolicy{}, false
+}
+
 func (s *TopologyPlacementStore) Decide(in TopologyPlacementDecisionInput) TopologyPlacementDecision {
 	environment := strings.ToLower(strings.TrimSpace(in.Environment))
 	region := strings.ToLower(strings.TrimSpace(in.Region))
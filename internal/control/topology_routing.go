@@ -0,0 +1,203 @@
+package control
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkerTopology is where a partition worker runs and the latency it reports
+// to the control plane.
+type WorkerTopology struct {
+	Region        string `json:"region,omitempty"`
+	Zone          string `json:"zone,omitempty"`
+	Cluster       string `json:"cluster,omitempty"`
+	FailureDomain string `json:"failure_domain,omitempty"`
+	LatencyMS     int    `json:"latency_ms,omitempty"`
+}
+
+// JobPlacementOverride is an operator decision that replaces the policy's
+// worker constraints for one job.
+type JobPlacementOverride struct {
+	Worker    string    `json:"worker,omitempty"`
+	Partition string    `json:"partition"`
+	Reason    string    `json:"reason"`
+	By        string    `json:"by,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// JobTopologyPlacement records which topology placement policy routed a job,
+// the worker constraints it imposes, and why the job landed where it did.
+type JobTopologyPlacement struct {
+	PolicyID        string                `json:"policy_id"`
+	Environment     string                `json:"environment,omitempty"`
+	Region          string                `json:"region,omitempty"`
+	Zone            string                `json:"zone,omitempty"`
+	Cluster         string                `json:"cluster,omitempty"`
+	FailureDomain   string                `json:"failure_domain,omitempty"`
+	DataResidency   bool                  `json:"data_residency,omitempty"`
+	MaxLatencyMS    int                   `json:"max_latency_ms,omitempty"`
+	PreferredWorker string                `json:"preferred_worker,omitempty"`
+	EligibleWorkers []string              `json:"eligible_workers,omitempty"`
+	Explanation     []string              `json:"explanation"`
+	Override        *JobPlacementOverride `json:"override,omitempty"`
+	DecidedAt       time.Time             `json:"decided_at"`
+}
+
+// Allows reports whether w may claim a job with this placement. An override
+// naming a worker admits only that worker; any other override lifts the
+// policy's constraints.
+func (p *JobTopologyPlacement) Allows(w PartitionWorker) bool {
+	if p == nil {
+		return true
+	}
+	if p.Override != nil {
+		return p.Override.Worker == "" || p.Override.Worker == w.ID
+	}
+	if p.DataResidency && p.Region != "" && w.Region != p.Region {
+		return false
+	}
+	if p.MaxLatencyMS > 0 && w.LatencyMS > p.MaxLatencyMS {
+		return false
+	}
+	return true
+}
+
+// PlaceTopology applies a topology placement policy to a job routed to
+// partition. The job stays in partition when an eligible worker serves it;
+// otherwise it moves to the first partition of the best eligible worker,
+// ranked by region, zone, cluster, and failure domain match, then latency.
+// With no eligible worker a resident job waits in a partition named for the
+// policy and any other unpartitioned job stays on the in-process worker.
+func (d *PartitionDispatcher) PlaceTopology(policy TopologyPlacementPolicy, environment, partition string) (string, *JobTopologyPlacement) {
+	p := &JobTopologyPlacement{
+		PolicyID:      policy.ID,
+		Environment:   strings.ToLower(strings.TrimSpace(environment)),
+		Region:        policy.Region,
+		Zone:          policy.Zone,
+		Cluster:       policy.Cluster,
+		FailureDomain: policy.FailureDomain,
+		DataResidency: policy.DataResidency,
+		MaxLatencyMS:  policy.MaxLatencyMS,
+		DecidedAt:     time.Now().UTC(),
+	}
+	p.Explanation = append(p.Explanation, "matched topology placement policy "+policy.ID+" for environment "+p.Environment)
+	if p.DataResidency {
+		p.Explanation = append(p.Explanation, "data residency restricts workers to region "+p.Region)
+	}
+	if p.MaxLatencyMS > 0 {
+		p.Explanation = append(p.Explanation, "workers reporting more than "+strconv.Itoa(p.MaxLatencyMS)+"ms latency are excluded")
+	}
+
+	type ranked struct {
+		worker PartitionWorker
+		score  int
+	}
+	candidates := make([]ranked, 0)
+	for _, w := range d.Workers() {
+		if !p.Allows(w) {
+			continue
+		}
+		score := 0
+		if p.Region != "" && w.Region == p.Region {
+			score += 3
+		}
+		if p.Zone != "" && w.Zone == p.Zone {
+			score += 4
+		}
+		if p.Cluster != "" && w.Cluster == p.Cluster {
+			score += 2
+		}
+		if p.FailureDomain != "" && w.FailureDomain == p.FailureDomain {
+			score += 5
+		}
+		candidates = append(candidates, ranked{worker: w, score: score})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return workerLatency(candidates[i].worker) < workerLatency(candidates[j].worker)
+	})
+	if len(candidates) == 0 {
+		switch {
+		case partition != "":
+		case p.DataResidency:
+			partition = policy.ID
+		default:
+			p.Explanation = append(p.Explanation, "no registered worker satisfies the policy; job runs on the in-process worker")
+			return "", p
+		}
+		p.Explanation = append(p.Explanation, "no registered worker satisfies the policy; job waits in partition "+partition)
+		return partition, p
+	}
+	for _, c := range candidates {
+		p.EligibleWorkers = append(p.EligibleWorkers, c.worker.ID)
+	}
+	picked := candidates[0].worker
+	if partition != "" {
+		for _, c := range candidates {
+			if containsString(c.worker.Partitions, partition) {
+				picked = c.worker
+				break
+			}
+		}
+	}
+	p.PreferredWorker = picked.ID
+	next := partition
+	if !containsString(picked.Partitions, partition) {
+		next = picked.Partitions[0]
+	}
+	reason := "worker " + picked.ID + " ranked best"
+	if picked.Region != "" {
+		reason += " in region " + picked.Region
+	}
+	if picked.LatencyMS > 0 {
+		reason += " at " + strconv.Itoa(picked.LatencyMS) + "ms"
+	}
+	if next != partition && partition != "" {
+		reason += "; moved from partition " + partition + " to " + next
+	} else {
+		reason += "; routed to partition " + next
+	}
+	p.Explanation = append(p.Explanation, reason)
+	return next, p
+}
+
+// workerLatency orders workers without a latency report after those with one.
+func workerLatency(w PartitionWorker) int {
+	if w.LatencyMS <= 0 {
+		return math.MaxInt
+	}
+	return w.LatencyMS
+}
+
+func normalizeWorkerTopology(in WorkerTopology) WorkerTopology {
+	latency := in.LatencyMS
+	if latency < 0 {
+		latency = 0
+	}
+	return WorkerTopology{
+		Region:        strings.ToLower(strings.TrimSpace(in.Region)),
+		Zone:          strings.ToLower(strings.TrimSpace(in.Zone)),
+		Cluster:       strings.ToLower(strings.TrimSpace(in.Cluster)),
+		FailureDomain: strings.ToLower(strings.TrimSpace(in.FailureDomain)),
+		LatencyMS:     latency,
+	}
+}
+
+func cloneJobTopologyPlacement(in *JobTopologyPlacement) *JobTopologyPlacement {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	out.EligibleWorkers = append([]string(nil), in.EligibleWorkers...)
+	out.Explanation = append([]string(nil), in.Explanation...)
+	if in.Override != nil {
+		o := *in.Override
+		out.Override = &o
+	}
+	return &out
+}
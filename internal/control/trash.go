@@ -0,0 +1,235 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entity kinds that are soft-deleted into the trash.
+const (
+	TrashKindView     = "view"
+	TrashKindTemplate = "template"
+	TrashKindSchedule = "schedule"
+	TrashKindRule     = "rule"
+)
+
+// TrashItem is a snapshot of a deleted entity, restorable until it expires.
+type TrashItem struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	EntityID  string          `json:"entity_id"`
+	Name      string          `json:"name,omitempty"`
+	Entity    json.RawMessage `json:"entity"`
+	DeletedBy string          `json:"deleted_by,omitempty"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// TrashPolicy bounds how long and how many deleted entities are kept.
+// Expired items, and the oldest items beyond MaxItems, are purged.
+type TrashPolicy struct {
+	RetentionHours int       `json:"retention_hours"`
+	MaxItems       int       `json:"max_items"`
+	UpdatedAt      time.Time `json:"updated_at,omitempty"`
+}
+
+type TrashStore struct {
+	mu     sync.RWMutex
+	nextID int64
+	items  map[string]*TrashItem
+	policy TrashPolicy
+}
+
+func NewTrashStore() *TrashStore {
+	return &TrashStore{
+		items:  map[string]*TrashItem{},
+		policy: TrashPolicy{RetentionHours: 168, MaxItems: 1000},
+	}
+}
+
+// Put snapshots entity into the trash.
+func (s *TrashStore) Put(kind, entityID, name string, entity any, deletedBy string) (TrashItem, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if !validTrashKind(kind) {
+		return TrashItem{}, errors.New("kind must be view, template, schedule, or rule")
+	}
+	entityID = strings.TrimSpace(entityID)
+	if entityID == "" {
+		return TrashItem{}, errors.New("entity id is required")
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	s.nextID++
+	item := &TrashItem{
+		ID:        "trash-" + itoa(s.nextID),
+		Kind:      kind,
+		EntityID:  entityID,
+		Name:      strings.TrimSpace(name),
+		Entity:    raw,
+		DeletedBy: strings.TrimSpace(deletedBy),
+		DeletedAt: now,
+		ExpiresAt: now.Add(time.Duration(s.policy.RetentionHours) * time.Hour),
+	}
+	s.items[item.ID] = item
+	s.purgeLocked(now)
+	return cloneTrashItem(*item), nil
+}
+
+// List returns unexpired items newest first, optionally filtered by kind.
+func (s *TrashStore) List(kind string) []TrashItem {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	now := time.Now().UTC()
+	s.mu.RLock()
+	out := make([]TrashItem, 0, len(s.items))
+	for _, item := range s.items {
+		if !now.Before(item.ExpiresAt) || (kind != "" && item.Kind != kind) {
+			continue
+		}
+		out = append(out, cloneTrashItem(*item))
+	}
+	s.mu.RUnlock()
+	sortTrashItems(out)
+	return out
+}
+
+func (s *TrashStore) Get(id string) (TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.items[strings.TrimSpace(id)]
+	if !ok || !time.Now().UTC().Before(item.ExpiresAt) {
+		return TrashItem{}, errors.New("trash item not found")
+	}
+	return cloneTrashItem(*item), nil
+}
+
+// Take removes an unexpired item from the trash so it can be restored.
+func (s *TrashStore) Take(id string) (TrashItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id = strings.TrimSpace(id)
+	item, ok := s.items[id]
+	if !ok || !time.Now().UTC().Before(item.ExpiresAt) {
+		return TrashItem{}, errors.New("trash item not found")
+	}
+	delete(s.items, id)
+	return cloneTrashItem(*item), nil
+}
+
+// Purge permanently deletes one item.
+func (s *TrashStore) Purge(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok := s.items[id]; !ok {
+		return errors.New("trash item not found")
+	}
+	delete(s.items, id)
+	return nil
+}
+
+// PurgeMatching permanently deletes every item of kind (all kinds when
+// empty) and returns them.
+func (s *TrashStore) PurgeMatching(kind string) []TrashItem {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []TrashItem{}
+	for id, item := range s.items {
+		if kind != "" && item.Kind != kind {
+			continue
+		}
+		out = append(out, cloneTrashItem(*item))
+		delete(s.items, id)
+	}
+	sortTrashItems(out)
+	return out
+}
+
+// PurgeExpired applies the retention policy as of now and returns the purged
+// items.
+func (s *TrashStore) PurgeExpired(now time.Time) []TrashItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.purgeLocked(now)
+}
+
+func (s *TrashStore) Policy() TrashPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+// SetPolicy changes retention for items deleted from now on and trims the
+// trash to the new maximum size.
+func (s *TrashStore) SetPolicy(in TrashPolicy) (TrashPolicy, error) {
+	if in.RetentionHours <= 0 || in.RetentionHours > 8760 {
+		return TrashPolicy{}, errors.New("retention_hours must be between 1 and 8760")
+	}
+	if in.MaxItems <= 0 || in.MaxItems > 100000 {
+		return TrashPolicy{}, errors.New("max_items must be between 1 and 100000")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in.UpdatedAt = time.Now().UTC()
+	s.policy = in
+	s.purgeLocked(in.UpdatedAt)
+	return s.policy, nil
+}
+
+func (s *TrashStore) purgeLocked(now time.Time) []TrashItem {
+	purged := []TrashItem{}
+	live := make([]TrashItem, 0, len(s.items))
+	for id, item := range s.items {
+		if !now.Before(item.ExpiresAt) {
+			purged = append(purged, *item)
+			delete(s.items, id)
+			continue
+		}
+		live = append(live, *item)
+	}
+	if len(live) > s.policy.MaxItems {
+		sortTrashItems(live)
+		for _, item := range live[s.policy.MaxItems:] {
+			purged = append(purged, item)
+			delete(s.items, item.ID)
+		}
+	}
+	sortTrashItems(purged)
+	return purged
+}
+
+func validTrashKind(kind string) bool {
+	switch kind {
+	case TrashKindView, TrashKindTemplate, TrashKindSchedule, TrashKindRule:
+		return true
+	}
+	return false
+}
+
+func sortTrashItems(items []TrashItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return trashSeq(items[i].ID) > trashSeq(items[j].ID)
+		}
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+}
+
+func trashSeq(id string) int64 {
+	return advanceRestoredID(0, id, "trash-")
+}
+
+func cloneTrashItem(in TrashItem) TrashItem {
+	out := in
+	out.Entity = append(json.RawMessage{}, in.Entity...)
+	return out
+}
@@ -0,0 +1,58 @@
+package control
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashStoreRetentionAndPurge(t *testing.T) {
+	store := NewTrashStore()
+	if _, err := store.Put("workflow", "wf-1", "", map[string]string{}, ""); err == nil {
+		t.Fatalf("expected unsupported kind to fail")
+	}
+	view, err := store.Put(TrashKindView, "view-1", "Failed runs", SavedView{ID: "view-1", Name: "Failed runs"}, "alice")
+	if err != nil {
+		t.Fatalf("put view failed: %v", err)
+	}
+	if view.ExpiresAt.Sub(view.DeletedAt) != 168*time.Hour || view.DeletedBy != "alice" {
+		t.Fatalf("unexpected trash item: %+v", view)
+	}
+	rule, _ := store.Put(TrashKindRule, "rule-1", "auto-apply", Rule{ID: "rule-1"}, "")
+	if items := store.List(""); len(items) != 2 || items[0].ID != rule.ID {
+		t.Fatalf("expected newest first: %+v", items)
+	}
+	if items := store.List(TrashKindView); len(items) != 1 || items[0].EntityID != "view-1" {
+		t.Fatalf("expected kind filter: %+v", items)
+	}
+
+	if _, err := store.SetPolicy(TrashPolicy{RetentionHours: 0, MaxItems: 10}); err == nil {
+		t.Fatalf("expected invalid retention to fail")
+	}
+	if _, err := store.SetPolicy(TrashPolicy{RetentionHours: 24, MaxItems: 1}); err != nil {
+		t.Fatalf("set policy failed: %v", err)
+	}
+	if items := store.List(""); len(items) != 1 || items[0].ID != rule.ID {
+		t.Fatalf("expected max_items to purge the oldest item: %+v", items)
+	}
+	if _, err := store.Get(view.ID); err == nil {
+		t.Fatalf("expected purged item lookup to fail")
+	}
+
+	purged := store.PurgeExpired(time.Now().UTC().Add(25 * time.Hour))
+	if len(purged) != 0 {
+		t.Fatalf("items keep the retention they were deleted under: %+v", purged)
+	}
+	purged = store.PurgeExpired(time.Now().UTC().Add(169 * time.Hour))
+	if len(purged) != 1 || purged[0].ID != rule.ID {
+		t.Fatalf("expected expired rule to be purged: %+v", purged)
+	}
+
+	next, _ := store.Put(TrashKindSchedule, "sched-1", "", Schedule{ID: "sched-1"}, "")
+	taken, err := store.Take(next.ID)
+	if err != nil || taken.EntityID != "sched-1" {
+		t.Fatalf("take failed: err=%v item=%+v", err, taken)
+	}
+	if _, err := store.Take(next.ID); err == nil {
+		t.Fatalf("expected item to leave the trash once taken")
+	}
+}
@@ -0,0 +1,229 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type UpgradeDrillInput struct {
+	Binary         string   `json:"binary,omitempty"` // candidate server binary from MC_UPGRADE_DRILL_BINARIES; defaults to the running executable
+	Port           int      `json:"port,omitempty"`   // shadow port; 0 picks a free port
+	SampleSize     int      `json:"sample_size,omitempty"`
+	Paths          []string `json:"paths,omitempty"` // explicit read paths instead of sampled traffic
+	IgnoreFields   []string `json:"ignore_fields,omitempty"`
+	MinScore       float64  `json:"min_score,omitempty"` // pass threshold in percent
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+type UpgradeDrillComparison struct {
+	Path          string   `json:"path"`
+	PrimaryStatus int      `json:"primary_status"`
+	ShadowStatus  int      `json:"shadow_status"`
+	Match         bool     `json:"match"`
+	Differences   []string `json:"differences,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+type UpgradeDrillScorecard struct {
+	ID                 string                   `json:"id"`
+	PlanID             string                   `json:"plan_id"`
+	Binary             string                   `json:"binary,omitempty"`
+	ShadowAddr         string                   `json:"shadow_addr,omitempty"`
+	Status             string                   `json:"status"`  // completed|failed
+	Verdict            string                   `json:"verdict"` // compatible|degraded|incompatible
+	CompatibilityScore float64                  `json:"compatibility_score"`
+	MinScore           float64                  `json:"min_score"`
+	Sampled            int                      `json:"sampled"`
+	Matched            int                      `json:"matched"`
+	Mismatched         int                      `json:"mismatched"`
+	Errors             int                      `json:"errors"`
+	Comparisons        []UpgradeDrillComparison `json:"comparisons"`
+	Error              string                   `json:"error,omitempty"`
+	StartedAt          time.Time                `json:"started_at"`
+	CompletedAt        time.Time                `json:"completed_at"`
+}
+
+// defaultUpgradeDrillIgnore lists response fields that legitimately differ
+// between two servers serving the same state.
+var defaultUpgradeDrillIgnore = []string{"request_id", "uptime_seconds", "pid"}
+
+// RecordDrill scores comparisons and attaches the scorecard to its plan so
+// operators can gate the real upgrade on it.
+func (s *UpgradeOrchestrationStore) RecordDrill(card UpgradeDrillScorecard) (UpgradeDrillScorecard, error) {
+	card.PlanID = strings.TrimSpace(card.PlanID)
+	if card.MinScore <= 0 {
+		card.MinScore = 95
+	}
+	scoreUpgradeDrill(&card)
+	if card.CompletedAt.IsZero() {
+		card.CompletedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[card.PlanID]
+	if !ok {
+		return UpgradeDrillScorecard{}, errors.New("upgrade plan not found")
+	}
+	s.nextDrillID++
+	card.ID = "upgrade-drill-" + itoa(s.nextDrillID)
+	cp := cloneUpgradeDrill(card)
+	s.drills[card.ID] = &cp
+	plan.LastDrillID = card.ID
+	plan.LastDrillVerdict = card.Verdict
+	plan.UpdatedAt = time.Now().UTC()
+	return cloneUpgradeDrill(cp), nil
+}
+
+func (s *UpgradeOrchestrationStore) ListDrills(planID string) []UpgradeDrillScorecard {
+	planID = strings.TrimSpace(planID)
+	s.mu.RLock()
+	out := make([]UpgradeDrillScorecard, 0, len(s.drills))
+	for _, item := range s.drills {
+		if planID != "" && item.PlanID != planID {
+			continue
+		}
+		out = append(out, cloneUpgradeDrill(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	return out
+}
+
+func (s *UpgradeOrchestrationStore) GetDrill(id string) (UpgradeDrillScorecard, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.drills[strings.TrimSpace(id)]
+	if !ok {
+		return UpgradeDrillScorecard{}, false
+	}
+	return cloneUpgradeDrill(*item), true
+}
+
+// CompareUpgradeDrillResponses compares a primary and shadow response.
+// JSON bodies are compared structurally, skipping ignored field names and
+// any field ending in "_at"; other bodies must match byte for byte.
+func CompareUpgradeDrillResponses(path string, primaryStatus int, primaryBody []byte, shadowStatus int, shadowBody []byte, ignore []string) UpgradeDrillComparison {
+	out := UpgradeDrillComparison{Path: path, PrimaryStatus: primaryStatus, ShadowStatus: shadowStatus}
+	if primaryStatus != shadowStatus {
+		out.Differences = append(out.Differences, fmt.Sprintf("status: %d != %d", primaryStatus, shadowStatus))
+	}
+	skip := map[string]struct{}{}
+	for _, field := range append(append([]string{}, defaultUpgradeDrillIgnore...), ignore...) {
+		if field = strings.TrimSpace(field); field != "" {
+			skip[field] = struct{}{}
+		}
+	}
+	var a, b any
+	if json.Unmarshal(primaryBody, &a) == nil && json.Unmarshal(shadowBody, &b) == nil {
+		diffUpgradeDrillValues("$", a, b, skip, &out.Differences)
+	} else if string(primaryBody) != string(shadowBody) {
+		out.Differences = append(out.Differences, "body differs")
+	}
+	if len(out.Differences) > 20 {
+		out.Differences = append(out.Differences[:20], fmt.Sprintf("... %d more", len(out.Differences)-20))
+	}
+	out.Match = len(out.Differences) == 0
+	return out
+}
+
+func diffUpgradeDrillValues(path string, a, b any, skip map[string]struct{}, diffs *[]string) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			*diffs = append(*diffs, path+": type differs")
+			return
+		}
+		keys := map[string]struct{}{}
+		for k := range av {
+			keys[k] = struct{}{}
+		}
+		for k := range bv {
+			keys[k] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			if _, ok := skip[k]; ok || strings.HasSuffix(k, "_at") {
+				continue
+			}
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inB:
+				*diffs = append(*diffs, path+"."+k+": missing in shadow")
+			case !inA:
+				*diffs = append(*diffs, path+"."+k+": only in shadow")
+			default:
+				diffUpgradeDrillValues(path+"."+k, x, y, skip, diffs)
+			}
+		}
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			*diffs = append(*diffs, path+": type differs")
+			return
+		}
+		if len(av) != len(bv) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: length %d != %d", path, len(av), len(bv)))
+			return
+		}
+		for i := range av {
+			diffUpgradeDrillValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], skip, diffs)
+		}
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %v != %v", path, a, b))
+		}
+	}
+}
+
+func scoreUpgradeDrill(card *UpgradeDrillScorecard) {
+	card.Sampled = len(card.Comparisons)
+	card.Matched, card.Mismatched, card.Errors = 0, 0, 0
+	for _, cmp := range card.Comparisons {
+		switch {
+		case cmp.Error != "":
+			card.Errors++
+		case cmp.Match:
+			card.Matched++
+		default:
+			card.Mismatched++
+		}
+	}
+	if card.Status == "" {
+		card.Status = "completed"
+	}
+	if card.Sampled > 0 {
+		card.CompatibilityScore = float64(card.Matched) * 100 / float64(card.Sampled)
+	}
+	switch {
+	case card.Status != "completed" || card.Sampled == 0:
+		card.Verdict = "incompatible"
+	case card.CompatibilityScore >= card.MinScore:
+		card.Verdict = "compatible"
+	case card.CompatibilityScore >= card.MinScore/2:
+		card.Verdict = "degraded"
+	default:
+		card.Verdict = "incompatible"
+	}
+}
+
+func cloneUpgradeDrill(in UpgradeDrillScorecard) UpgradeDrillScorecard {
+	out := in
+	out.Comparisons = make([]UpgradeDrillComparison, 0, len(in.Comparisons))
+	for _, cmp := range in.Comparisons {
+		cmp.Differences = append([]string{}, cmp.Differences...)
+		out.Comparisons = append(out.Comparisons, cmp)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package control
+
+import "testing"
+
+func TestCompareUpgradeDrillResponses(t *testing.T) {
+	same := CompareUpgradeDrillResponses("/v1/templates", 200, []byte(`{"id":"a","created_at":"x","request_id":"1"}`), 200, []byte(`{"id":"a","created_at":"y","request_id":"2"}`), nil)
+	if !same.Match {
+		t.Fatalf("expected volatile fields to be ignored: %+v", same)
+	}
+	diff := CompareUpgradeDrillResponses("/v1/templates", 200, []byte(`{"items":[1,2]}`), 404, []byte(`{"items":[1]}`), nil)
+	if diff.Match || len(diff.Differences) != 2 {
+		t.Fatalf("expected status and length differences: %+v", diff)
+	}
+	ignored := CompareUpgradeDrillResponses("/v1/version", 200, []byte(`{"version":"1"}`), 200, []byte(`{"version":"2"}`), []string{"version"})
+	if !ignored.Match {
+		t.Fatalf("expected ignore_fields to apply: %+v", ignored)
+	}
+}
+
+func TestUpgradeOrchestrationStoreRecordDrill(t *testing.T) {
+	store := NewUpgradeOrchestrationStore()
+	plan, err := store.CreatePlan(UpgradeOrchestrationPlanInput{
+		Component:   "control-plane",
+		FromChannel: "stable",
+		ToChannel:   "candidate",
+		TotalNodes:  3,
+		WaveSize:    1,
+	})
+	if err != nil {
+		t.Fatalf("create upgrade plan failed: %v", err)
+	}
+	if _, err := store.RecordDrill(UpgradeDrillScorecard{PlanID: "missing"}); err == nil {
+		t.Fatalf("expected unknown plan to be rejected")
+	}
+	card, err := store.RecordDrill(UpgradeDrillScorecard{
+		PlanID: plan.ID,
+		Comparisons: []UpgradeDrillComparison{
+			{Path: "/a", Match: true},
+			{Path: "/b", Match: true},
+			{Path: "/c", Match: false},
+			{Path: "/d", Error: "timeout"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("record drill failed: %v", err)
+	}
+	if card.Sampled != 4 || card.Matched != 2 || card.Mismatched != 1 || card.Errors != 1 {
+		t.Fatalf("unexpected drill counts: %+v", card)
+	}
+	if card.CompatibilityScore != 50 || card.Verdict != "degraded" {
+		t.Fatalf("unexpected drill score: %+v", card)
+	}
+	got, _ := store.GetPlan(plan.ID)
+	if got.LastDrillID != card.ID || got.LastDrillVerdict != "degraded" {
+		t.Fatalf("expected plan to reference drill: %+v", got)
+	}
+	if len(store.ListDrills(plan.ID)) != 1 || len(store.ListDrills("other")) != 0 {
+		t.Fatalf("unexpected drill listing")
+	}
+}
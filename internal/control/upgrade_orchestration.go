@@ -19,19 +19,21 @@ type UpgradeOrchestrationPlanInput struct {
 }
 
 type UpgradeOrchestrationPlan struct {
-	ID             string    `json:"id"`
-	Component      string    `json:"component"`
-	FromChannel    string    `json:"from_channel"`
-	ToChannel      string    `json:"to_channel"`
-	Strategy       string    `json:"strategy"`
-	TotalNodes     int       `json:"total_nodes"`
-	WaveSize       int       `json:"wave_size"`
-	MaxUnavailable int       `json:"max_unavailable"`
-	CurrentWave    int       `json:"current_wave"`
-	UpgradedNodes  int       `json:"upgraded_nodes"`
-	Status         string    `json:"status"` // pending|in_progress|blocked|completed|aborted
-	LastMessage    string    `json:"last_message,omitempty"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID               string    `json:"id"`
+	Component        string    `json:"component"`
+	FromChannel      string    `json:"from_channel"`
+	ToChannel        string    `json:"to_channel"`
+	Strategy         string    `json:"strategy"`
+	TotalNodes       int       `json:"total_nodes"`
+	WaveSize         int       `json:"wave_size"`
+	MaxUnavailable   int       `json:"max_unavailable"`
+	CurrentWave      int       `json:"current_wave"`
+	UpgradedNodes    int       `json:"upgraded_nodes"`
+	Status           string    `json:"status"` // pending|in_progress|blocked|completed|aborted
+	LastMessage      string    `json:"last_message,omitempty"`
+	LastDrillID      string    `json:"last_drill_id,omitempty"`
+	LastDrillVerdict string    `json:"last_drill_verdict,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 type UpgradeOrchestrationAdvanceInput struct {
@@ -43,13 +45,18 @@ type UpgradeOrchestrationAbortInput struct {
 }
 
 type UpgradeOrchestrationStore struct {
-	mu     sync.RWMutex
-	nextID int64
-	plans  map[string]*UpgradeOrchestrationPlan
+	mu          sync.RWMutex
+	nextID      int64
+	nextDrillID int64
+	plans       map[string]*UpgradeOrchestrationPlan
+	drills      map[string]*UpgradeDrillScorecard
 }
 
 func NewUpgradeOrchestrationStore() *UpgradeOrchestrationStore {
-	return &UpgradeOrchestrationStore{plans: map[string]*UpgradeOrchestrationPlan{}}
+	return &UpgradeOrchestrationStore{
+		plans:  map[string]*UpgradeOrchestrationPlan{},
+		drills: map[string]*UpgradeDrillScorecard{},
+	}
 }
 
 func (s *UpgradeOrchestrationStore) CreatePlan(in UpgradeOrchestrationPlanInput) (UpgradeOrchestrationPlan, error) {
@@ -0,0 +1,175 @@
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const defaultConsulAddress = "http://127.0.0.1:8500"
+
+// resolveConsul reads a Consul KV prefix recursively; nested keys become
+// nested maps and values that parse as JSON keep their type.
+func (r *VariableSourceRegistry) resolveConsul(ctx context.Context, environment string, config map[string]any) (map[string]any, error) {
+	address := strings.TrimRight(configString(config, "address"), "/")
+	if address == "" {
+		address = defaultConsulAddress
+	}
+	prefix := strings.Trim(configString(config, "prefix"), "/")
+	if prefix == "" {
+		return nil, errors.New("consul source requires config.prefix")
+	}
+	prefix, err := expandSourceEnvironment(prefix, environment)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"recurse": []string{"true"}}
+	if dc := configString(config, "datacenter"); dc != "" {
+		query.Set("dc", dc)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+"/v1/kv/"+prefix+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := configString(config, "token"); token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]any{}, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New("unexpected consul status: " + resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Key   string  `json:"Key"`
+		Value *string `json:"Value"`
+	}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, errors.New("consul response must be a kv entry list")
+	}
+	out := map[string]any{}
+	for _, entry := range entries {
+		rel := strings.Trim(strings.TrimPrefix(entry.Key, prefix), "/")
+		if rel == "" || entry.Value == nil {
+			// Folder markers and the prefix key itself carry no variable.
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(*entry.Value)
+		if err != nil {
+			return nil, errors.New("invalid consul value encoding for " + entry.Key)
+		}
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+		setNestedMapValue(out, strings.ReplaceAll(rel, "/", "."), value)
+	}
+	return out, nil
+}
+
+// resolveEnvFile reads KEY=VALUE pairs from the environment's dotenv file,
+// <dir>/<environment>.env by default, or config.path with {environment}.
+func (r *VariableSourceRegistry) resolveEnvFile(environment string, config map[string]any) (map[string]any, error) {
+	path := configString(config, "path")
+	if path == "" {
+		if environment == "" {
+			return nil, errors.New("env_file source requires an environment or config.path")
+		}
+		dir := configString(config, "dir")
+		if dir == "" {
+			dir = "vars"
+		}
+		path = filepath.Join(dir, environment+".env")
+	}
+	path, err := expandSourceEnvironment(path, environment)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.baseDir, path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := parseEnvFile(raw)
+	if err != nil {
+		return nil, err
+	}
+	prefix := configString(config, "prefix")
+	out := map[string]any{}
+	for key, value := range pairs {
+		if prefix != "" && !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		out[normalizeEnvVarKey(key, prefix)] = value
+	}
+	target := configString(config, "target")
+	if target == "" {
+		return out, nil
+	}
+	wrapped := map[string]any{}
+	setNestedMapValue(wrapped, target, out)
+	return wrapped, nil
+}
+
+func parseEnvFile(raw []byte) (map[string]string, error) {
+	out := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, errors.New("invalid env file line " + strconv.Itoa(lineNo))
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				if unquoted, err := strconv.Unquote(value); err == nil {
+					value = unquoted
+				} else {
+					value = value[1 : len(value)-1]
+				}
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		out[key] = value
+	}
+	return out, scanner.Err()
+}
+
+// configString reads an optional string setting; absent keys yield "".
+func configString(config map[string]any, key string) string {
+	v, ok := config[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return strings.TrimSpace(stringValue(v))
+}
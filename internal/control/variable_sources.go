@@ -4,25 +4,50 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type VariableSourceSpec struct {
-	Name   string         `json:"name"`
-	Type   string         `json:"type"` // inline|env|file|http
-	Config map[string]any `json:"config"`
+	Name        string         `json:"name"`
+	Type        string         `json:"type"` // inline|env|file|http|consul|env_file
+	Config      map[string]any `json:"config"`
+	Environment string         `json:"environment,omitempty"`
+	TTLSeconds  int            `json:"ttl_seconds,omitempty"`
+	OnError     string         `json:"on_error,omitempty"` // fail|stale|skip
+}
+
+// VariableSourceStatus reports how a source produced its layer: freshly
+// fetched, served from its TTL cache, or a stale/skipped fallback after a
+// fetch failure.
+type VariableSourceStatus struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Environment string    `json:"environment,omitempty"`
+	Status      string    `json:"status"` // fetched|cached|stale|skipped
+	FetchedAt   time.Time `json:"fetched_at,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type variableSourceCacheEntry struct {
+	data      map[string]any
+	fetchedAt time.Time
 }
 
 type VariableSourceRegistry struct {
 	baseDir string
 	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]variableSourceCacheEntry
 }
 
 func NewVariableSourceRegistry(baseDir string) *VariableSourceRegistry {
@@ -31,11 +56,22 @@ func NewVariableSourceRegistry(baseDir string) *VariableSourceRegistry {
 		client: &http.Client{
 			Timeout: 8 * time.Second,
 		},
+		cache: map[string]variableSourceCacheEntry{},
 	}
 }
 
 func (r *VariableSourceRegistry) ResolveLayers(ctx context.Context, specs []VariableSourceSpec) ([]VariableLayer, error) {
+	layers, _, err := r.ResolveSources(ctx, specs, "")
+	return layers, err
+}
+
+// ResolveSources resolves each source into a layer for the given environment
+// (a source's own environment wins). Sources with a TTL are served from cache
+// while fresh; on fetch failure on_error=stale falls back to the last good
+// value and on_error=skip additionally drops the layer when nothing is cached.
+func (r *VariableSourceRegistry) ResolveSources(ctx context.Context, specs []VariableSourceSpec, environment string) ([]VariableLayer, []VariableSourceStatus, error) {
 	layers := make([]VariableLayer, 0, len(specs))
+	statuses := make([]VariableSourceStatus, 0, len(specs))
 	for i, spec := range specs {
 		name := strings.TrimSpace(spec.Name)
 		if name == "" {
@@ -43,33 +79,105 @@ func (r *VariableSourceRegistry) ResolveLayers(ctx context.Context, specs []Vari
 		}
 		sourceType := strings.ToLower(strings.TrimSpace(spec.Type))
 		if sourceType == "" {
-			return nil, errors.New("source type is required")
+			return nil, nil, errors.New("source type is required")
 		}
-		var (
-			data map[string]any
-			err  error
-		)
-		switch sourceType {
-		case "inline":
-			data, err = r.resolveInline(spec.Config)
-		case "env":
-			data, err = r.resolveEnv(spec.Config)
-		case "file":
-			data, err = r.resolveFile(spec.Config)
-		case "http":
-			data, err = r.resolveHTTP(ctx, spec.Config)
+		onError := strings.ToLower(strings.TrimSpace(spec.OnError))
+		switch onError {
+		case "":
+			onError = "fail"
+		case "fail", "stale", "skip":
 		default:
-			return nil, errors.New("unsupported variable source type: " + sourceType)
+			return nil, nil, errors.New(name + ": unsupported on_error mode: " + spec.OnError)
+		}
+		if spec.TTLSeconds < 0 {
+			return nil, nil, errors.New(name + ": ttl_seconds must be non-negative")
+		}
+		env := strings.TrimSpace(spec.Environment)
+		if env == "" {
+			env = strings.TrimSpace(environment)
 		}
+		status := VariableSourceStatus{Name: name, Type: sourceType, Environment: env}
+		cacheKey := variableSourceCacheKey(sourceType, env, spec.Config)
+
+		r.mu.Lock()
+		cached, hasCached := r.cache[cacheKey]
+		r.mu.Unlock()
+		now := time.Now().UTC()
+		if hasCached && spec.TTLSeconds > 0 && now.Sub(cached.fetchedAt) < time.Duration(spec.TTLSeconds)*time.Second {
+			status.Status = "cached"
+			status.FetchedAt = cached.fetchedAt
+			statuses = append(statuses, status)
+			layers = append(layers, VariableLayer{Name: name, Data: cloneVariableMap(cached.data)})
+			continue
+		}
+
+		data, err := r.fetchSource(ctx, sourceType, env, spec.Config)
 		if err != nil {
-			return nil, errors.New(name + ": " + err.Error())
+			if errors.Is(err, errUnsupportedVariableSource) || onError == "fail" || (onError == "stale" && !hasCached) {
+				return nil, nil, errors.New(name + ": " + err.Error())
+			}
+			status.Error = err.Error()
+			if !hasCached {
+				status.Status = "skipped"
+				statuses = append(statuses, status)
+				continue
+			}
+			status.Status = "stale"
+			status.FetchedAt = cached.fetchedAt
+			statuses = append(statuses, status)
+			layers = append(layers, VariableLayer{Name: name, Data: cloneVariableMap(cached.data)})
+			continue
 		}
+		r.mu.Lock()
+		r.cache[cacheKey] = variableSourceCacheEntry{data: cloneVariableMap(data), fetchedAt: now}
+		r.mu.Unlock()
+		status.Status = "fetched"
+		status.FetchedAt = now
+		statuses = append(statuses, status)
 		layers = append(layers, VariableLayer{
 			Name: name,
 			Data: data,
 		})
 	}
-	return layers, nil
+	return layers, statuses, nil
+}
+
+var errUnsupportedVariableSource = errors.New("unsupported variable source type")
+
+func (r *VariableSourceRegistry) fetchSource(ctx context.Context, sourceType, environment string, config map[string]any) (map[string]any, error) {
+	switch sourceType {
+	case "inline":
+		return r.resolveInline(config)
+	case "env":
+		return r.resolveEnv(config)
+	case "file":
+		return r.resolveFile(config)
+	case "http":
+		return r.resolveHTTP(ctx, environment, config)
+	case "consul":
+		return r.resolveConsul(ctx, environment, config)
+	case "env_file":
+		return r.resolveEnvFile(environment, config)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedVariableSource, sourceType)
+	}
+}
+
+func variableSourceCacheKey(sourceType, environment string, config map[string]any) string {
+	buf, _ := json.Marshal(config)
+	return sourceType + "|" + environment + "|" + string(buf)
+}
+
+// expandSourceEnvironment substitutes {environment} in source locations so
+// one spec can address per-environment endpoints, KV prefixes, and files.
+func expandSourceEnvironment(value, environment string) (string, error) {
+	if !strings.Contains(value, "{environment}") {
+		return value, nil
+	}
+	if environment == "" {
+		return "", errors.New("source location references {environment} but no environment was given")
+	}
+	return strings.ReplaceAll(value, "{environment}", environment), nil
 }
 
 func (r *VariableSourceRegistry) resolveInline(config map[string]any) (map[string]any, error) {
@@ -140,11 +248,15 @@ func (r *VariableSourceRegistry) resolveFile(config map[string]any) (map[string]
 	return parseVariablePayload(raw)
 }
 
-func (r *VariableSourceRegistry) resolveHTTP(ctx context.Context, config map[string]any) (map[string]any, error) {
+func (r *VariableSourceRegistry) resolveHTTP(ctx context.Context, environment string, config map[string]any) (map[string]any, error) {
 	url := strings.TrimSpace(stringValue(config["url"]))
 	if url == "" {
 		return nil, errors.New("http source requires config.url")
 	}
+	url, err := expandSourceEnvironment(url, environment)
+	if err != nil {
+		return nil, err
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
@@ -288,3 +400,13 @@ func setNestedMapValue(dst map[string]any, path string, val any) {
 		cur = next
 	}
 }
+
+// VariableWinners maps each resolved variable path to the layer whose value
+// survived the merge.
+func VariableWinners(result VariableResolveResult) map[string]string {
+	out := make(map[string]string, len(result.SourceGraph))
+	for _, edge := range result.SourceGraph {
+		out[edge.Path] = edge.To
+	}
+	return out
+}
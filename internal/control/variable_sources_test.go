@@ -2,10 +2,12 @@ package control
 
 import (
 	"context"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 )
 
@@ -74,3 +76,86 @@ func TestVariableSourceRegistryInlineEnvFileHTTP(t *testing.T) {
 		t.Fatalf("expected http layer parsing, got %#v", layers[3].Data)
 	}
 }
+
+func TestVariableSourceRegistryConsulEnvFileCachingAndFallback(t *testing.T) {
+	baseDir := t.TempDir()
+	reg := NewVariableSourceRegistry(baseDir)
+	if err := os.MkdirAll(filepath.Join(baseDir, "vars"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(baseDir, "vars", "staging.env"), []byte("# staging\nexport APP_DB_HOST=db-staging\nAPP_BANNER=\"hello world\"\nOTHER=x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var consulHits, httpHits atomic.Int64
+	var httpDown atomic.Bool
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/kv/apps/staging":
+			consulHits.Add(1)
+			if r.URL.Query().Get("recurse") != "true" || r.Header.Get("X-Consul-Token") != "tok" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"Key":"apps/staging/","Value":null},{"Key":"apps/staging/db/port","Value":"` + b64("5432") + `"},{"Key":"apps/staging/region","Value":"` + b64("eu-west-1") + `"}]`))
+		case r.URL.Path == "/flags/staging":
+			httpHits.Add(1)
+			if httpDown.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(`{"flags":{"beta":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	specs := []VariableSourceSpec{
+		{Name: "consul", Type: "consul", TTLSeconds: 60, Config: map[string]any{"address": srv.URL, "prefix": "apps/{environment}", "token": "tok"}},
+		{Name: "dotenv", Type: "env_file", Config: map[string]any{"prefix": "APP_"}},
+		{Name: "flags", Type: "http", OnError: "stale", Config: map[string]any{"url": srv.URL + "/flags/{environment}"}},
+	}
+	layers, statuses, err := reg.ResolveSources(context.Background(), specs, "staging")
+	if err != nil {
+		t.Fatalf("resolve sources failed: %v", err)
+	}
+	db, _ := layers[0].Data["db"].(map[string]any)
+	if db["port"] != float64(5432) || layers[0].Data["region"] != "eu-west-1" {
+		t.Fatalf("unexpected consul layer: %#v", layers[0].Data)
+	}
+	if layers[1].Data["db_host"] != "db-staging" || layers[1].Data["banner"] != "hello world" || layers[1].Data["other"] != nil {
+		t.Fatalf("unexpected env file layer: %#v", layers[1].Data)
+	}
+	for _, st := range statuses {
+		if st.Status != "fetched" || st.Environment != "staging" {
+			t.Fatalf("expected fresh fetches, got %+v", statuses)
+		}
+	}
+
+	httpDown.Store(true)
+	layers, statuses, err = reg.ResolveSources(context.Background(), specs, "staging")
+	if err != nil {
+		t.Fatalf("expected stale fallback, got %v", err)
+	}
+	if consulHits.Load() != 1 || statuses[0].Status != "cached" {
+		t.Fatalf("expected consul served from ttl cache, hits=%d statuses=%+v", consulHits.Load(), statuses)
+	}
+	if statuses[2].Status != "stale" || statuses[2].Error == "" || layers[2].Data["flags"] == nil {
+		t.Fatalf("expected stale http layer, got %+v %#v", statuses[2], layers[2].Data)
+	}
+
+	specs[2].OnError = "fail"
+	if _, _, err := reg.ResolveSources(context.Background(), specs, "staging"); err == nil {
+		t.Fatalf("expected on_error=fail to surface http failure")
+	}
+	skip := []VariableSourceSpec{{Name: "flags", Type: "http", OnError: "skip", Config: map[string]any{"url": srv.URL + "/flags/{environment}"}}}
+	layers, statuses, err = reg.ResolveSources(context.Background(), skip, "prod")
+	if err != nil || len(layers) != 0 || statuses[0].Status != "skipped" {
+		t.Fatalf("expected uncached failure to be skipped, err=%v layers=%#v statuses=%+v", err, layers, statuses)
+	}
+	if _, _, err := reg.ResolveSources(context.Background(), []VariableSourceSpec{{Type: "env_file"}}, ""); err == nil {
+		t.Fatalf("expected env_file without environment to fail")
+	}
+}
@@ -101,6 +101,22 @@ func (s *SavedViewStore) Delete(id string) error {
 	return nil
 }
 
+// Restore inserts a view with its existing ID, e.g. when recovering it from
+// the trash, and keeps later IDs from colliding with it.
+func (s *SavedViewStore) Restore(in SavedView) (SavedView, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		return SavedView{}, errors.New("view id is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = advanceRestoredID(s.nextID, in.ID, "view-")
+	in.UpdatedAt = time.Now().UTC()
+	cp := in
+	s.views[in.ID] = &cp
+	return cp, nil
+}
+
 func (s *SavedViewStore) SetPinned(id string, pinned bool) (SavedView, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
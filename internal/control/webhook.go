@@ -5,7 +5,6 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"sort"
@@ -15,18 +14,22 @@ import (
 )
 
 type WebhookSubscription struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	URL          string    `json:"url"`
-	EventPrefix  string    `json:"event_prefix"`
-	Enabled      bool      `json:"enabled"`
-	Secret       string    `json:"secret,omitempty"`
-	SuccessCount int64     `json:"success_count"`
-	FailureCount int64     `json:"failure_count"`
-	LastError    string    `json:"last_error,omitempty"`
-	LastDelivery time.Time `json:"last_delivery,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	URL             string    `json:"url"`
+	EventPrefix     string    `json:"event_prefix"`
+	Enabled         bool      `json:"enabled"`
+	Secret          string    `json:"secret,omitempty"`
+	PayloadTemplate string    `json:"payload_template,omitempty"`
+	ContentType     string    `json:"content_type,omitempty"`
+	Owner           string    `json:"owner,omitempty"`
+	Team            string    `json:"team,omitempty"`
+	SuccessCount    int64     `json:"success_count"`
+	FailureCount    int64     `json:"failure_count"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastDelivery    time.Time `json:"last_delivery,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 type WebhookDelivery struct {
@@ -47,6 +50,7 @@ type WebhookDispatcher struct {
 	deliveries  []WebhookDelivery
 	deliveryCap int
 	client      *http.Client
+	chaos       *ChaosInjector
 }
 
 func NewWebhookDispatcher(limit int) *WebhookDispatcher {
@@ -64,17 +68,8 @@ func NewWebhookDispatcher(limit int) *WebhookDispatcher {
 }
 
 func (d *WebhookDispatcher) Register(in WebhookSubscription) (WebhookSubscription, error) {
-	if strings.TrimSpace(in.Name) == "" {
-		return WebhookSubscription{}, errors.New("webhook name is required")
-	}
-	if strings.TrimSpace(in.URL) == "" {
-		return WebhookSubscription{}, errors.New("webhook url is required")
-	}
-	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(in.URL)), "http://") && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(in.URL)), "https://") {
-		return WebhookSubscription{}, errors.New("webhook url must be http or https")
-	}
-	if strings.TrimSpace(in.EventPrefix) == "" {
-		return WebhookSubscription{}, errors.New("event_prefix is required")
+	if err := validateWebhook(&in); err != nil {
+		return WebhookSubscription{}, err
 	}
 
 	d.mu.Lock()
@@ -113,6 +108,54 @@ func (d *WebhookDispatcher) Get(id string) (WebhookSubscription, error) {
 	return cloneWebhook(*w), nil
 }
 
+// Update replaces the definition of a webhook, keeping its delivery
+// counters.
+func (d *WebhookDispatcher) Update(id string, in WebhookSubscription) (WebhookSubscription, error) {
+	if err := validateWebhook(&in); err != nil {
+		return WebhookSubscription{}, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cur, ok := d.webhooks[id]
+	if !ok {
+		return WebhookSubscription{}, errors.New("webhook not found")
+	}
+	in.ID = cur.ID
+	in.SuccessCount = cur.SuccessCount
+	in.FailureCount = cur.FailureCount
+	in.LastError = cur.LastError
+	in.LastDelivery = cur.LastDelivery
+	in.CreatedAt = cur.CreatedAt
+	in.UpdatedAt = time.Now().UTC()
+	cp := cloneWebhook(in)
+	d.webhooks[id] = &cp
+	return cloneWebhook(cp), nil
+}
+
+func validateWebhook(in *WebhookSubscription) error {
+	if strings.TrimSpace(in.Name) == "" {
+		return errors.New("webhook name is required")
+	}
+	if strings.TrimSpace(in.URL) == "" {
+		return errors.New("webhook url is required")
+	}
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(in.URL)), "http://") && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(in.URL)), "https://") {
+		return errors.New("webhook url must be http or https")
+	}
+	if strings.TrimSpace(in.EventPrefix) == "" {
+		return errors.New("event_prefix is required")
+	}
+	if err := ValidatePayloadTemplate(in.PayloadTemplate); err != nil {
+		return err
+	}
+	contentType, err := normalizePayloadContentType(in.ContentType)
+	if err != nil {
+		return err
+	}
+	in.ContentType = contentType
+	return nil
+}
+
 func (d *WebhookDispatcher) SetEnabled(id string, enabled bool) (WebhookSubscription, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -125,15 +168,22 @@ func (d *WebhookDispatcher) SetEnabled(id string, enabled bool) (WebhookSubscrip
 	return cloneWebhook(*w), nil
 }
 
+// SetChaosInjector lets webhook-drop chaos experiments drop deliveries.
+func (d *WebhookDispatcher) SetChaosInjector(c *ChaosInjector) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.chaos = c
+}
+
 func (d *WebhookDispatcher) Dispatch(event Event) []WebhookDelivery {
 	d.mu.RLock()
 	subs := make([]WebhookSubscription, 0, len(d.webhooks))
 	for _, wh := range d.webhooks {
 		subs = append(subs, cloneWebhook(*wh))
 	}
+	chaos := d.chaos
 	d.mu.RUnlock()
 
-	payload, _ := json.Marshal(event)
 	delivered := make([]WebhookDelivery, 0)
 	for _, sub := range subs {
 		if !sub.Enabled {
@@ -142,32 +192,75 @@ func (d *WebhookDispatcher) Dispatch(event Event) []WebhookDelivery {
 		if !strings.HasPrefix(event.Type, sub.EventPrefix) {
 			continue
 		}
-		req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(payload))
-		if err != nil {
-			delivered = append(delivered, d.recordDelivery(sub.ID, event.Type, 0, err))
-			continue
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Masterchef-Event-Type", event.Type)
-		if strings.TrimSpace(sub.Secret) != "" {
-			req.Header.Set("X-Masterchef-Signature", signPayload(payload, sub.Secret))
-		}
+		delivered = append(delivered, d.deliver(sub, event, chaos, false))
+	}
+	return delivered
+}
 
-		resp, err := d.client.Do(req)
-		if err != nil {
-			delivered = append(delivered, d.recordDelivery(sub.ID, event.Type, 0, err))
-			continue
-		}
-		_ = resp.Body.Close()
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			delivered = append(delivered, d.recordDelivery(sub.ID, event.Type, resp.StatusCode, errors.New("non-2xx status")))
-			continue
+// MatchingTargets returns the subscriptions among ids whose event prefix
+// matches event, enabled or not, without delivering anything.
+func (d *WebhookDispatcher) MatchingTargets(event Event, ids []string) []WebhookSubscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]WebhookSubscription, 0, len(ids))
+	for _, id := range ids {
+		wh, ok := d.webhooks[strings.TrimSpace(id)]
+		if ok && strings.HasPrefix(event.Type, wh.EventPrefix) {
+			out = append(out, cloneWebhook(*wh))
 		}
-		delivered = append(delivered, d.recordDelivery(sub.ID, event.Type, resp.StatusCode, nil))
+	}
+	return out
+}
+
+// Replay delivers a historical event to the listed subscriptions only,
+// marking each request with X-Masterchef-Replay so receivers can tell it
+// from live traffic.
+func (d *WebhookDispatcher) Replay(event Event, ids []string) []WebhookDelivery {
+	targets := d.MatchingTargets(event, ids)
+	d.mu.RLock()
+	chaos := d.chaos
+	d.mu.RUnlock()
+	delivered := make([]WebhookDelivery, 0, len(targets))
+	for _, sub := range targets {
+		delivered = append(delivered, d.deliver(sub, event, chaos, true))
 	}
 	return delivered
 }
 
+func (d *WebhookDispatcher) deliver(sub WebhookSubscription, event Event, chaos *ChaosInjector, replay bool) WebhookDelivery {
+	if chaos != nil {
+		if expID, drop := chaos.DropWebhook(); drop {
+			return d.recordDelivery(sub.ID, event.Type, 0, errors.New("delivery dropped by chaos experiment "+expID))
+		}
+	}
+	payload, err := RenderWebhookPayload(sub, event)
+	if err != nil {
+		return d.recordDelivery(sub.ID, event.Type, 0, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return d.recordDelivery(sub.ID, event.Type, 0, err)
+	}
+	req.Header.Set("Content-Type", webhookContentType(sub))
+	req.Header.Set("X-Masterchef-Event-Type", event.Type)
+	if replay {
+		req.Header.Set("X-Masterchef-Replay", "true")
+	}
+	if strings.TrimSpace(sub.Secret) != "" {
+		req.Header.Set("X-Masterchef-Signature", signPayload(payload, sub.Secret))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return d.recordDelivery(sub.ID, event.Type, 0, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return d.recordDelivery(sub.ID, event.Type, resp.StatusCode, errors.New("non-2xx status"))
+	}
+	return d.recordDelivery(sub.ID, event.Type, resp.StatusCode, nil)
+}
+
 func (d *WebhookDispatcher) recordDelivery(webhookID, eventType string, statusCode int, err error) WebhookDelivery {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -225,12 +318,57 @@ func (d *WebhookDispatcher) Deliveries(limit int) []WebhookDelivery {
 	return out
 }
 
+// RenderWebhookPayload builds the request body a subscription would receive
+// for event, applying the subscription payload template when present.
+func RenderWebhookPayload(sub WebhookSubscription, event Event) ([]byte, error) {
+	return RenderPayloadTemplate(sub.PayloadTemplate, event)
+}
+
+func webhookContentType(sub WebhookSubscription) string {
+	if strings.TrimSpace(sub.ContentType) == "" {
+		return PayloadContentTypeJSON
+	}
+	return sub.ContentType
+}
+
 func signPayload(payload []byte, secret string) string {
 	h := hmac.New(sha256.New, []byte(secret))
 	_, _ = h.Write(payload)
 	return "sha256=" + hex.EncodeToString(h.Sum(nil))
 }
 
+// Restore inserts a webhook with its existing ID and delivery counters, e.g.
+// when importing a control-plane snapshot.
+func (d *WebhookDispatcher) Restore(in WebhookSubscription) (WebhookSubscription, error) {
+	in.ID = strings.TrimSpace(in.ID)
+	if in.ID == "" {
+		return WebhookSubscription{}, errors.New("webhook id is required")
+	}
+	if strings.TrimSpace(in.URL) == "" || strings.TrimSpace(in.EventPrefix) == "" {
+		return WebhookSubscription{}, errors.New("webhook url and event_prefix are required")
+	}
+	if err := ValidatePayloadTemplate(in.PayloadTemplate); err != nil {
+		return WebhookSubscription{}, err
+	}
+	contentType, err := normalizePayloadContentType(in.ContentType)
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+	in.ContentType = contentType
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = time.Now().UTC()
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = in.CreatedAt
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID = advanceRestoredID(d.nextID, in.ID, "wh-")
+	cp := cloneWebhook(in)
+	d.webhooks[in.ID] = &cp
+	return cloneWebhook(cp), nil
+}
+
 func cloneWebhook(in WebhookSubscription) WebhookSubscription {
 	out := in
 	return out
@@ -1,6 +1,7 @@
 package control
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -67,3 +68,97 @@ func TestWebhookDispatcher_FailureDeliveryRecorded(t *testing.T) {
 		t.Fatalf("expected persisted delivery history")
 	}
 }
+
+func TestWebhookDispatcher_PayloadTemplate(t *testing.T) {
+	d := NewWebhookDispatcher(100)
+	bodies := make(chan string, 1)
+	contentTypes := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		bodies <- string(raw)
+		contentTypes <- r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	_, err := d.Register(WebhookSubscription{
+		Name:            "slack",
+		URL:             receiver.URL,
+		EventPrefix:     "job.",
+		PayloadTemplate: `{"text":{{quote .message}},"job":{{quote .fields.job_id}}}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+	deliveries := d.Dispatch(Event{Type: "job.failed", Message: `job "a" failed`, Fields: map[string]any{"job_id": "job-7"}})
+	if len(deliveries) != 1 || deliveries[0].Status != "delivered" {
+		t.Fatalf("expected one successful delivery, got %#v", deliveries)
+	}
+	body := <-bodies
+	if body != `{"text":"job \"a\" failed","job":"job-7"}` {
+		t.Fatalf("unexpected rendered body %s", body)
+	}
+	if ct := <-contentTypes; ct != PayloadContentTypeJSON {
+		t.Fatalf("expected json content type, got %q", ct)
+	}
+}
+
+func TestWebhookDispatcher_RejectsInvalidTemplateAndContentType(t *testing.T) {
+	d := NewWebhookDispatcher(100)
+	if _, err := d.Register(WebhookSubscription{
+		Name:            "bad-template",
+		URL:             "https://example.com/hook",
+		EventPrefix:     "job.",
+		PayloadTemplate: `{{.type`,
+	}); err == nil {
+		t.Fatalf("expected invalid template to be rejected")
+	}
+	if _, err := d.Register(WebhookSubscription{
+		Name:        "bad-content-type",
+		URL:         "https://example.com/hook",
+		EventPrefix: "job.",
+		ContentType: "application/octet-stream",
+	}); err == nil {
+		t.Fatalf("expected unsupported content type to be rejected")
+	}
+	wh, err := d.Register(WebhookSubscription{
+		Name:        "form",
+		URL:         "https://example.com/hook",
+		EventPrefix: "job.",
+		ContentType: "form",
+	})
+	if err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+	if wh.ContentType != PayloadContentTypeForm {
+		t.Fatalf("expected normalized form content type, got %q", wh.ContentType)
+	}
+}
+
+func TestWebhookDispatcher_ReplayTargetsOnlyListedSubscriptions(t *testing.T) {
+	d := NewWebhookDispatcher(100)
+	var replays int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Masterchef-Replay") == "true" {
+			atomic.AddInt32(&replays, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	draft, err := d.Register(WebhookSubscription{Name: "draft", URL: receiver.URL, EventPrefix: "job.", Enabled: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Register(WebhookSubscription{Name: "live", URL: receiver.URL, EventPrefix: "job.", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	evt := Event{Type: "job.failed"}
+	if got := d.MatchingTargets(Event{Type: "run.done"}, []string{draft.ID}); len(got) != 0 {
+		t.Fatalf("expected prefix mismatch to exclude target")
+	}
+	out := d.Replay(evt, []string{draft.ID})
+	if len(out) != 1 || out[0].WebhookID != draft.ID || out[0].Status != "delivered" || atomic.LoadInt32(&replays) != 1 {
+		t.Fatalf("expected a single replay delivery to the listed webhook, got %+v replays=%d", out, replays)
+	}
+}
@@ -15,37 +15,51 @@ const (
 	WorkflowFailed    WorkflowStatus = "failed"
 )
 
+// WorkflowStep launches a template. Answer values and When may reference
+// workflow inputs, e.g. answers {"region": "{{ region }}"} and
+// when "environment == prod".
 type WorkflowStep struct {
-	TemplateID string `json:"template_id"`
-	Priority   string `json:"priority,omitempty"`
+	TemplateID string            `json:"template_id"`
+	Priority   string            `json:"priority,omitempty"`
+	Answers    map[string]string `json:"answers,omitempty"`
+	When       string            `json:"when,omitempty"`
 }
 
 type WorkflowTemplate struct {
-	ID          string         `json:"id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description,omitempty"`
-	Steps       []WorkflowStep `json:"steps"`
-	CreatedAt   time.Time      `json:"created_at"`
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Inputs      map[string]SurveyField `json:"inputs,omitempty"`
+	Defaults    map[string]string      `json:"defaults,omitempty"`
+	Steps       []WorkflowStep         `json:"steps"`
+	CreatedAt   time.Time              `json:"created_at"`
 }
 
 type WorkflowRun struct {
-	ID              string         `json:"id"`
-	WorkflowID      string         `json:"workflow_id"`
-	Status          WorkflowStatus `json:"status"`
-	CurrentStep     int            `json:"current_step"`
-	TotalSteps      int            `json:"total_steps"`
-	StepJobIDs      []string       `json:"step_job_ids,omitempty"`
-	DefaultPriority string         `json:"default_priority"`
-	Force           bool           `json:"force"`
-	Error           string         `json:"error,omitempty"`
-	CreatedAt       time.Time      `json:"created_at"`
-	StartedAt       time.Time      `json:"started_at,omitempty"`
-	EndedAt         time.Time      `json:"ended_at,omitempty"`
+	ID              string                  `json:"id"`
+	WorkflowID      string                  `json:"workflow_id"`
+	Orchestration   string                  `json:"orchestration,omitempty"`
+	Source          string                  `json:"source,omitempty"`
+	Status          WorkflowStatus          `json:"status"`
+	CurrentStep     int                     `json:"current_step"`
+	TotalSteps      int                     `json:"total_steps"`
+	StepJobIDs      []string                `json:"step_job_ids,omitempty"`
+	Inputs          map[string]string       `json:"inputs,omitempty"`
+	Steps           []WorkflowRunStep       `json:"steps,omitempty"`
+	SecretRefs      []string                `json:"secret_refs,omitempty"`
+	Phases          []OrchestrationPhaseRun `json:"phases,omitempty"`
+	DefaultPriority string                  `json:"default_priority"`
+	Force           bool                    `json:"force"`
+	Error           string                  `json:"error,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	StartedAt       time.Time               `json:"started_at,omitempty"`
+	EndedAt         time.Time               `json:"ended_at,omitempty"`
 }
 
 type workflowJobRef struct {
-	runID string
-	step  int
+	runID    string
+	step     int
+	rollback bool
 }
 
 type WorkflowStore struct {
@@ -57,6 +71,7 @@ type WorkflowStore struct {
 	jobRefs        map[string]workflowJobRef
 	queue          *Queue
 	templates      *TemplateStore
+	secrets        *EncryptedSecretStore
 }
 
 func NewWorkflowStore(queue *Queue, templates *TemplateStore) *WorkflowStore {
@@ -73,6 +88,14 @@ func NewWorkflowStore(queue *Queue, templates *TemplateStore) *WorkflowStore {
 	return ws
 }
 
+// SetSecretStore is where secret workflow inputs and step answers are sealed
+// at launch. Without one, launches that carry secrets are refused.
+func (w *WorkflowStore) SetSecretStore(store *EncryptedSecretStore) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.secrets = store
+}
+
 func (w *WorkflowStore) Create(in WorkflowTemplate) (WorkflowTemplate, error) {
 	if in.Name == "" {
 		return WorkflowTemplate{}, errors.New("workflow name is required")
@@ -89,6 +112,9 @@ func (w *WorkflowStore) Create(in WorkflowTemplate) (WorkflowTemplate, error) {
 		}
 		in.Steps[i].Priority = normalizePriority(step.Priority)
 	}
+	if err := w.normalizeWorkflowInputs(&in); err != nil {
+		return WorkflowTemplate{}, err
+	}
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -121,12 +147,23 @@ func (w *WorkflowStore) Get(id string) (WorkflowTemplate, bool) {
 }
 
 func (w *WorkflowStore) Launch(workflowID, priority string, force bool) (WorkflowRun, error) {
-	w.mu.Lock()
-	wf, ok := w.workflows[workflowID]
+	return w.LaunchWithInputs(workflowID, priority, force, nil)
+}
+
+// LaunchWithInputs validates inputs against the workflow's input schema,
+// resolves every step's answers and condition from them, and starts the run.
+// Nothing is enqueued when any input or resolved step answer is invalid.
+func (w *WorkflowStore) LaunchWithInputs(workflowID, priority string, force bool, inputs map[string]string) (WorkflowRun, error) {
+	wf, ok := w.Get(workflowID)
 	if !ok {
-		w.mu.Unlock()
 		return WorkflowRun{}, errors.New("workflow not found")
 	}
+	resolved, steps, err := w.resolveWorkflowInputs(wf, inputs)
+	if err != nil {
+		return WorkflowRun{}, err
+	}
+
+	w.mu.Lock()
 	w.nextRunID++
 	run := &WorkflowRun{
 		ID:              "wfrun-" + itoa(w.nextRunID),
@@ -135,10 +172,16 @@ func (w *WorkflowStore) Launch(workflowID, priority string, force bool) (Workflo
 		CurrentStep:     0,
 		TotalSteps:      len(wf.Steps),
 		StepJobIDs:      make([]string, len(wf.Steps)),
+		Inputs:          resolved,
+		Steps:           steps,
 		DefaultPriority: normalizePriority(priority),
 		Force:           force,
 		CreatedAt:       time.Now().UTC(),
 	}
+	if err := w.sealWorkflowRunSecrets(run, wf, resolved); err != nil {
+		w.mu.Unlock()
+		return WorkflowRun{}, err
+	}
 	w.runs[run.ID] = run
 	w.mu.Unlock()
 
@@ -188,6 +231,14 @@ func (w *WorkflowStore) dispatchStep(runID string, stepIndex int) error {
 		w.mu.RUnlock()
 		return errors.New("workflow step out of range")
 	}
+	for stepIndex < len(run.Steps) && run.Steps[stepIndex].Status == WorkflowStepSkipped {
+		stepIndex++
+	}
+	if stepIndex >= len(wf.Steps) {
+		w.mu.RUnlock()
+		w.completeRun(runID)
+		return nil
+	}
 	step := wf.Steps[stepIndex]
 	priority := step.Priority
 	if priority == "" || priority == "normal" {
@@ -226,6 +277,10 @@ func (w *WorkflowStore) dispatchStep(runID string, stepIndex int) error {
 	if stepIndex >= 0 && stepIndex < len(run.StepJobIDs) {
 		run.StepJobIDs[stepIndex] = job.ID
 	}
+	if stepIndex < len(run.Steps) {
+		run.Steps[stepIndex].JobID = job.ID
+		run.Steps[stepIndex].Status = WorkflowStepDispatched
+	}
 	w.jobRefs[job.ID] = workflowJobRef{runID: runID, step: stepIndex}
 	return nil
 }
@@ -250,6 +305,11 @@ func (w *WorkflowStore) onJob(job Job) {
 		w.mu.RUnlock()
 		return
 	}
+	if run.Orchestration != "" {
+		w.mu.RUnlock()
+		w.onPhaseJob(ref, job)
+		return
+	}
 	wf, ok := w.workflows[run.WorkflowID]
 	if !ok {
 		w.mu.RUnlock()
@@ -259,16 +319,11 @@ func (w *WorkflowStore) onJob(job Job) {
 	nextStep := ref.step + 1
 	isLast := nextStep >= len(wf.Steps)
 	w.mu.RUnlock()
+	w.setStepStatus(ref.runID, ref.step, job.Status == JobSucceeded)
 
 	if job.Status == JobSucceeded {
 		if isLast {
-			w.mu.Lock()
-			if r, ok := w.runs[ref.runID]; ok && (r.Status == WorkflowRunning || r.Status == WorkflowPending) {
-				r.Status = WorkflowSucceeded
-				r.CurrentStep = r.TotalSteps
-				r.EndedAt = time.Now().UTC()
-			}
-			w.mu.Unlock()
+			w.completeRun(ref.runID)
 			return
 		}
 		_ = w.dispatchStep(ref.runID, nextStep)
@@ -278,6 +333,32 @@ func (w *WorkflowStore) onJob(job Job) {
 	w.failRun(ref.runID, "workflow step job failed: "+job.ID)
 }
 
+func (w *WorkflowStore) completeRun(runID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if r, ok := w.runs[runID]; ok && (r.Status == WorkflowRunning || r.Status == WorkflowPending) {
+		r.Status = WorkflowSucceeded
+		r.CurrentStep = r.TotalSteps
+		if r.StartedAt.IsZero() {
+			r.StartedAt = time.Now().UTC()
+		}
+		r.EndedAt = time.Now().UTC()
+	}
+}
+
+func (w *WorkflowStore) setStepStatus(runID string, stepIndex int, succeeded bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	run, ok := w.runs[runID]
+	if !ok || stepIndex < 0 || stepIndex >= len(run.Steps) {
+		return
+	}
+	run.Steps[stepIndex].Status = WorkflowStepFailed
+	if succeeded {
+		run.Steps[stepIndex].Status = WorkflowStepSucceeded
+	}
+}
+
 func (w *WorkflowStore) failRun(runID, reason string) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -295,12 +376,39 @@ func (w *WorkflowStore) failRun(runID, reason string) {
 
 func cloneWorkflowTemplate(in WorkflowTemplate) WorkflowTemplate {
 	out := in
-	out.Steps = append([]WorkflowStep{}, in.Steps...)
+	out.Steps = make([]WorkflowStep, len(in.Steps))
+	for i, step := range in.Steps {
+		step.Answers = cloneLabels(step.Answers)
+		out.Steps[i] = step
+	}
+	if in.Inputs != nil {
+		out.Inputs = make(map[string]SurveyField, len(in.Inputs))
+		for k, v := range in.Inputs {
+			v.Enum = append([]string{}, v.Enum...)
+			out.Inputs[k] = v
+		}
+	}
+	out.Defaults = cloneLabels(in.Defaults)
 	return out
 }
 
 func cloneWorkflowRun(in WorkflowRun) WorkflowRun {
 	out := in
 	out.StepJobIDs = append([]string{}, in.StepJobIDs...)
+	out.Inputs = cloneLabels(in.Inputs)
+	out.SecretRefs = append([]string(nil), in.SecretRefs...)
+	if in.Steps != nil {
+		out.Steps = make([]WorkflowRunStep, len(in.Steps))
+		for i, step := range in.Steps {
+			step.Answers = cloneLabels(step.Answers)
+			out.Steps[i] = step
+		}
+	}
+	if in.Phases != nil {
+		out.Phases = make([]OrchestrationPhaseRun, len(in.Phases))
+		for i, ph := range in.Phases {
+			out.Phases[i] = cloneOrchestrationPhaseRun(ph)
+		}
+	}
 	return out
 }
@@ -0,0 +1,167 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+const (
+	WorkflowStepPending    = "pending"
+	WorkflowStepSkipped    = "skipped"
+	WorkflowStepDispatched = "dispatched"
+	WorkflowStepSucceeded  = "succeeded"
+	WorkflowStepFailed     = "failed"
+)
+
+// WorkflowRunStep is the launch-time resolution of a workflow step: the
+// template answers rendered from the run's inputs and whether its condition
+// held.
+type WorkflowRunStep struct {
+	Index      int               `json:"index"`
+	TemplateID string            `json:"template_id"`
+	When       string            `json:"when,omitempty"`
+	Status     string            `json:"status"`
+	Answers    map[string]string `json:"answers,omitempty"`
+	JobID      string            `json:"job_id,omitempty"`
+}
+
+// normalizeWorkflowInputs checks the input schema, its defaults, and that
+// step answers only reference declared inputs and known template fields.
+func (w *WorkflowStore) normalizeWorkflowInputs(in *WorkflowTemplate) error {
+	schema := map[string]SurveyField{}
+	for name, field := range in.Inputs {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return errors.New("workflow input name is required")
+		}
+		field.Type = strings.ToLower(strings.TrimSpace(field.Type))
+		schema[name] = field
+	}
+	if err := ValidateSurveySchema(schema); err != nil {
+		return fmt.Errorf("invalid workflow inputs: %w", err)
+	}
+	if err := CheckSurveyDefaults(schema, in.Defaults); err != nil {
+		return fmt.Errorf("invalid workflow input defaults: %w", err)
+	}
+	in.Inputs = schema
+	optional := make(map[string]SurveyField, len(schema))
+	for name, field := range schema {
+		field.Required = false
+		optional[name] = field
+	}
+	for name := range in.Defaults {
+		if _, ok := schema[name]; !ok {
+			return fmt.Errorf("workflow default references undeclared input: %s", name)
+		}
+	}
+	if err := ValidateSurveyAnswers(optional, in.Defaults); err != nil {
+		return fmt.Errorf("invalid workflow input defaults: %w", err)
+	}
+
+	declared := make(map[string]string, len(schema))
+	for name := range schema {
+		declared[name] = ""
+	}
+	for i := range in.Steps {
+		step := &in.Steps[i]
+		step.When = strings.TrimSpace(step.When)
+		tpl, _ := w.templates.Get(step.TemplateID)
+		for key, value := range step.Answers {
+			if len(tpl.Survey) > 0 {
+				if _, ok := tpl.Survey[key]; !ok {
+					return fmt.Errorf("workflow step %d answer %s is not a survey field of template %s", i, key, tpl.ID)
+				}
+			}
+			if _, missing := RenderTemplateText(value, declared, true); len(missing) > 0 {
+				return fmt.Errorf("workflow step %d answer %s references undeclared inputs: %s", i, key, strings.Join(missing, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// resolveWorkflowInputs merges inputs over the workflow defaults, validates
+// them, and renders each step's answers and condition.
+func (w *WorkflowStore) resolveWorkflowInputs(wf WorkflowTemplate, inputs map[string]string) (map[string]string, []WorkflowRunStep, error) {
+	if len(wf.Inputs) == 0 && len(inputs) > 0 {
+		return nil, nil, errors.New("workflow does not declare inputs")
+	}
+	resolved := MergeTemplateVariables(wf.Defaults, inputs)
+	if err := ValidateSurveyAnswers(wf.Inputs, resolved); err != nil {
+		return nil, nil, fmt.Errorf("invalid workflow inputs: %w", err)
+	}
+	steps := make([]WorkflowRunStep, 0, len(wf.Steps))
+	for i, step := range wf.Steps {
+		item := WorkflowRunStep{
+			Index:      i,
+			TemplateID: step.TemplateID,
+			When:       step.When,
+			Status:     WorkflowStepPending,
+		}
+		if step.When != "" && !config.EvaluateWhen(step.When, resolved) {
+			item.Status = WorkflowStepSkipped
+			steps = append(steps, item)
+			continue
+		}
+		if len(step.Answers) > 0 {
+			item.Answers = make(map[string]string, len(step.Answers))
+			for key, value := range step.Answers {
+				item.Answers[key], _ = RenderTemplateText(value, resolved, false)
+			}
+			tpl, ok := w.templates.Get(step.TemplateID)
+			if !ok {
+				return nil, nil, errors.New("workflow step references missing template: " + step.TemplateID)
+			}
+			if err := ValidateSurveyAnswers(tpl.Survey, item.Answers); err != nil {
+				return nil, nil, fmt.Errorf("workflow step %d (%s): %w", i, step.TemplateID, err)
+			}
+		}
+		steps = append(steps, item)
+	}
+	return cloneLabels(resolved), steps, nil
+}
+
+// sealWorkflowRunSecrets moves secret inputs, and step answers that are
+// secret fields or carry a secret input, into the encrypted secret store and
+// redacts them on the run record.
+func (w *WorkflowStore) sealWorkflowRunSecrets(run *WorkflowRun, wf WorkflowTemplate, inputs map[string]string) error {
+	names, err := SealSurveySecrets(w.secrets, "workflow-run/"+run.ID+"/inputs", wf.Inputs, inputs)
+	if err != nil {
+		return err
+	}
+	var secretValues []string
+	for name, def := range wf.Inputs {
+		if surveyFieldType(def) == "secret" && inputs[name] != "" {
+			secretValues = append(secretValues, inputs[name])
+		}
+	}
+	run.Inputs = RedactSurveyAnswers(wf.Inputs, inputs)
+	for i := range run.Steps {
+		step := &run.Steps[i]
+		if len(step.Answers) == 0 {
+			continue
+		}
+		tpl, _ := w.templates.Get(step.TemplateID)
+		schema := make(map[string]SurveyField, len(step.Answers))
+		for key, value := range step.Answers {
+			def := tpl.Survey[key]
+			for _, secret := range secretValues {
+				if strings.Contains(value, secret) {
+					def.Type = "secret"
+				}
+			}
+			schema[key] = def
+		}
+		stepNames, err := SealSurveySecrets(w.secrets, "workflow-run/"+run.ID+"/step-"+itoa(int64(i)), schema, step.Answers)
+		if err != nil {
+			return err
+		}
+		names = append(names, stepNames...)
+		step.Answers = RedactSurveyAnswers(schema, step.Answers)
+	}
+	run.SecretRefs = names
+	return nil
+}
@@ -112,3 +112,87 @@ func TestWorkflowStore_FailsOnStepError(t *testing.T) {
 		time.Sleep(10 * time.Millisecond)
 	}
 }
+
+func TestWorkflowStore_TypedInputsFlowIntoStepAnswersAndConditions(t *testing.T) {
+	q := NewQueue(32)
+	exec := &fakeExecutor{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.StartWorker(ctx, exec)
+
+	tpls := NewTemplateStore()
+	deploy := tpls.Create(Template{Name: "deploy", ConfigPath: "deploy.yaml", Survey: map[string]SurveyField{
+		"region":   {Type: "string", Required: true, Enum: []string{"us-east-1", "eu-west-1"}},
+		"replicas": {Type: "int"},
+	}})
+	smoke := tpls.Create(Template{Name: "smoke", ConfigPath: "smoke.yaml"})
+
+	ws := NewWorkflowStore(q, tpls)
+	if _, err := ws.Create(WorkflowTemplate{
+		Name:   "bad",
+		Inputs: map[string]SurveyField{"region": {Type: "string"}},
+		Steps:  []WorkflowStep{{TemplateID: deploy.ID, Answers: map[string]string{"region": "{{ zone }}"}}},
+	}); err == nil {
+		t.Fatalf("expected undeclared input reference to be rejected")
+	}
+	if _, err := ws.Create(WorkflowTemplate{
+		Name:     "bad-default",
+		Inputs:   map[string]SurveyField{"replicas": {Type: "int"}},
+		Defaults: map[string]string{"replicas": "many"},
+		Steps:    []WorkflowStep{{TemplateID: smoke.ID}},
+	}); err == nil {
+		t.Fatalf("expected mistyped default to be rejected")
+	}
+	wf, err := ws.Create(WorkflowTemplate{
+		Name: "release",
+		Inputs: map[string]SurveyField{
+			"region":      {Type: "string", Required: true},
+			"replicas":    {Type: "int"},
+			"environment": {Type: "string", Enum: []string{"staging", "prod"}},
+		},
+		Defaults: map[string]string{"replicas": "2", "environment": "staging"},
+		Steps: []WorkflowStep{
+			{TemplateID: deploy.ID, Answers: map[string]string{"region": "{{ region }}", "replicas": "{{ replicas }}"}},
+			{TemplateID: smoke.ID, When: "environment == prod"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected workflow create error: %v", err)
+	}
+
+	if _, err := ws.LaunchWithInputs(wf.ID, "normal", false, map[string]string{"replicas": "3"}); err == nil {
+		t.Fatalf("expected missing required input to be rejected")
+	}
+	if _, err := ws.LaunchWithInputs(wf.ID, "normal", false, map[string]string{"region": "ap-south-1"}); err == nil {
+		t.Fatalf("expected resolved step answer outside template enum to be rejected")
+	}
+	if len(ws.ListRuns()) != 0 {
+		t.Fatalf("expected no runs from rejected launches")
+	}
+
+	run, err := ws.LaunchWithInputs(wf.ID, "normal", false, map[string]string{"region": "eu-west-1"})
+	if err != nil {
+		t.Fatalf("unexpected launch error: %v", err)
+	}
+	if run.Inputs["region"] != "eu-west-1" || run.Inputs["replicas"] != "2" || run.Inputs["environment"] != "staging" {
+		t.Fatalf("expected resolved inputs persisted on run, got %#v", run.Inputs)
+	}
+	if len(run.Steps) != 2 || run.Steps[0].Answers["region"] != "eu-west-1" || run.Steps[0].Answers["replicas"] != "2" || run.Steps[1].Status != WorkflowStepSkipped {
+		t.Fatalf("unexpected resolved steps %#v", run.Steps)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		cur, _ := ws.GetRun(run.ID)
+		if cur.Status == WorkflowSucceeded {
+			if cur.Steps[0].Status != WorkflowStepSucceeded || cur.Steps[0].JobID == "" || cur.StepJobIDs[1] != "" {
+				t.Fatalf("expected only the unconditional step dispatched, got %#v", cur.Steps)
+			}
+			break
+		}
+		if cur.Status == WorkflowFailed || time.Now().After(deadline) {
+			t.Fatalf("expected workflow success, got %#v", cur)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
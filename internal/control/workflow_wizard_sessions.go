@@ -0,0 +1,438 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+const (
+	WizardSessionDraft     = "draft"     // collecting step inputs
+	WizardSessionReady     = "ready"     // every step validated, awaiting materialization
+	WizardSessionCompleted = "completed" // outputs materialized
+)
+
+var wizardSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+type WorkflowWizardArtifact struct {
+	Kind string `json:"kind"` // template|schedule|runbook
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WorkflowWizardSession collects a wizard's inputs one step at a time. Drafts
+// are persisted so a session can be resumed later, including after a
+// restart, from its current step.
+type WorkflowWizardSession struct {
+	ID             string                   `json:"id"`
+	WizardID       string                   `json:"wizard_id"`
+	Owner          string                   `json:"owner,omitempty"`
+	Status         string                   `json:"status"`
+	CurrentStepID  string                   `json:"current_step_id,omitempty"`
+	CompletedSteps []string                 `json:"completed_steps"`
+	Inputs         map[string]string        `json:"inputs"`
+	StepErrors     map[string]string        `json:"step_errors,omitempty"` // last failed submission of the current step
+	Artifacts      []WorkflowWizardArtifact `json:"artifacts,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+	CompletedAt    time.Time                `json:"completed_at,omitempty"`
+}
+
+type WorkflowWizardStepResult struct {
+	Session WorkflowWizardSession `json:"session"`
+	Valid   bool                  `json:"valid"`
+	Errors  map[string]string     `json:"errors,omitempty"`
+}
+
+// WorkflowWizardOutputPlan is a wizard output with its name and inputs
+// resolved against a ready session.
+type WorkflowWizardOutputPlan struct {
+	Kind            string `json:"kind"`
+	Name            string `json:"name"`
+	ConfigPath      string `json:"config_path"`
+	IntervalSeconds int    `json:"interval_seconds,omitempty"`
+	Environment     string `json:"environment,omitempty"`
+	RiskLevel       string `json:"risk_level,omitempty"`
+}
+
+type WorkflowWizardSessionStore struct {
+	mu       sync.RWMutex
+	nextID   int64
+	catalog  *WorkflowWizardCatalog
+	baseDir  string
+	path     string
+	sessions map[string]*WorkflowWizardSession
+}
+
+// NewWorkflowWizardSessionStore resolves config inputs against baseDir and
+// persists sessions under .masterchef/wizards.
+func NewWorkflowWizardSessionStore(catalog *WorkflowWizardCatalog, baseDir string) *WorkflowWizardSessionStore {
+	s := &WorkflowWizardSessionStore{
+		catalog:  catalog,
+		baseDir:  baseDir,
+		path:     filepath.Join(baseDir, ".masterchef", "wizards", "sessions.json"),
+		sessions: map[string]*WorkflowWizardSession{},
+	}
+	s.load()
+	return s
+}
+
+// Start opens a draft session. Prefilled inputs are kept but only validated
+// when their step is submitted.
+func (s *WorkflowWizardSessionStore) Start(wizardID, owner string, inputs map[string]string) (WorkflowWizardSession, error) {
+	wizard, err := s.catalog.Get(wizardID)
+	if err != nil {
+		return WorkflowWizardSession{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	now := time.Now().UTC()
+	item := &WorkflowWizardSession{
+		ID:             "wizard-session-" + itoa(s.nextID),
+		WizardID:       wizard.ID,
+		Owner:          strings.TrimSpace(owner),
+		Status:         WizardSessionDraft,
+		CompletedSteps: []string{},
+		Inputs:         normalizeWizardInputs(inputs),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if len(wizard.Steps) > 0 {
+		item.CurrentStepID = wizard.Steps[0].ID
+	} else {
+		item.Status = WizardSessionReady
+	}
+	s.sessions[item.ID] = item
+	if err := s.persistLocked(); err != nil {
+		return WorkflowWizardSession{}, err
+	}
+	return cloneWizardSession(*item), nil
+}
+
+func (s *WorkflowWizardSessionStore) Get(id string) (WorkflowWizardSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.sessions[strings.TrimSpace(id)]
+	if !ok {
+		return WorkflowWizardSession{}, errors.New("wizard session not found")
+	}
+	return cloneWizardSession(*item), nil
+}
+
+// List returns sessions newest first, optionally filtered by owner and
+// status.
+func (s *WorkflowWizardSessionStore) List(owner, status string) []WorkflowWizardSession {
+	owner, status = strings.TrimSpace(owner), strings.ToLower(strings.TrimSpace(status))
+	s.mu.RLock()
+	out := make([]WorkflowWizardSession, 0, len(s.sessions))
+	for _, item := range s.sessions {
+		if owner != "" && item.Owner != owner {
+			continue
+		}
+		if status != "" && item.Status != status {
+			continue
+		}
+		out = append(out, cloneWizardSession(*item))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].UpdatedAt.Equal(out[j].UpdatedAt) {
+			return out[i].ID > out[j].ID
+		}
+		return out[i].UpdatedAt.After(out[j].UpdatedAt)
+	})
+	return out
+}
+
+// SubmitStep merges inputs into the draft and validates the current step.
+// A valid step advances the session; an invalid one stays current with its
+// errors recorded so a resumed session shows them.
+func (s *WorkflowWizardSessionStore) SubmitStep(id, stepID string, inputs map[string]string) (WorkflowWizardStepResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.sessions[strings.TrimSpace(id)]
+	if !ok {
+		return WorkflowWizardStepResult{}, errors.New("wizard session not found")
+	}
+	if item.Status != WizardSessionDraft {
+		return WorkflowWizardStepResult{}, errors.New("wizard session is " + item.Status)
+	}
+	stepID = strings.TrimSpace(stepID)
+	if stepID != item.CurrentStepID {
+		return WorkflowWizardStepResult{}, errors.New("step " + stepID + " is not the current step " + item.CurrentStepID)
+	}
+	wizard, err := s.catalog.Get(item.WizardID)
+	if err != nil {
+		return WorkflowWizardStepResult{}, err
+	}
+	idx := wizardStepIndex(wizard, stepID)
+	if idx < 0 {
+		return WorkflowWizardStepResult{}, errors.New("wizard step not found")
+	}
+	for k, v := range normalizeWizardInputs(inputs) {
+		item.Inputs[k] = v
+	}
+	errs := s.validateStep(wizard.Steps[idx], item.Inputs)
+	item.UpdatedAt = time.Now().UTC()
+	if len(errs) > 0 {
+		item.StepErrors = errs
+	} else {
+		item.StepErrors = nil
+		item.CompletedSteps = append(item.CompletedSteps, stepID)
+		if idx+1 < len(wizard.Steps) {
+			item.CurrentStepID = wizard.Steps[idx+1].ID
+		} else {
+			item.CurrentStepID = ""
+			item.Status = WizardSessionReady
+		}
+	}
+	if err := s.persistLocked(); err != nil {
+		return WorkflowWizardStepResult{}, err
+	}
+	return WorkflowWizardStepResult{
+		Session: cloneWizardSession(*item),
+		Valid:   len(errs) == 0,
+		Errors:  errs,
+	}, nil
+}
+
+// Back reopens the previously completed step so its inputs can be changed.
+func (s *WorkflowWizardSessionStore) Back(id string) (WorkflowWizardSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.sessions[strings.TrimSpace(id)]
+	if !ok {
+		return WorkflowWizardSession{}, errors.New("wizard session not found")
+	}
+	if item.Status == WizardSessionCompleted {
+		return WorkflowWizardSession{}, errors.New("wizard session is completed")
+	}
+	if len(item.CompletedSteps) == 0 {
+		return WorkflowWizardSession{}, errors.New("wizard session is at its first step")
+	}
+	last := len(item.CompletedSteps) - 1
+	item.CurrentStepID = item.CompletedSteps[last]
+	item.CompletedSteps = item.CompletedSteps[:last]
+	item.Status = WizardSessionDraft
+	item.StepErrors = nil
+	item.UpdatedAt = time.Now().UTC()
+	if err := s.persistLocked(); err != nil {
+		return WorkflowWizardSession{}, err
+	}
+	return cloneWizardSession(*item), nil
+}
+
+func (s *WorkflowWizardSessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok := s.sessions[id]; !ok {
+		return errors.New("wizard session not found")
+	}
+	delete(s.sessions, id)
+	return s.persistLocked()
+}
+
+// MaterializationPlan resolves the wizard outputs for a ready session.
+// Outputs whose config input was left empty are skipped, as are schedules
+// without an interval.
+func (s *WorkflowWizardSessionStore) MaterializationPlan(id string) ([]WorkflowWizardOutputPlan, error) {
+	item, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item.Status != WizardSessionReady {
+		return nil, errors.New("wizard session must be ready to materialize; current status is " + item.Status)
+	}
+	wizard, err := s.catalog.Get(item.WizardID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]WorkflowWizardOutputPlan, 0, len(wizard.Outputs))
+	for _, output := range wizard.Outputs {
+		configPath := item.Inputs[output.ConfigInput]
+		if configPath == "" {
+			continue
+		}
+		plan := WorkflowWizardOutputPlan{
+			Kind:        output.Kind,
+			Name:        expandWizardInputs(output.Name, item.Inputs),
+			ConfigPath:  s.resolve(configPath),
+			Environment: expandWizardInputs(output.Environment, item.Inputs),
+			RiskLevel:   output.RiskLevel,
+		}
+		if output.IntervalInput != "" {
+			raw := item.Inputs[output.IntervalInput]
+			if raw == "" {
+				continue
+			}
+			plan.IntervalSeconds, _ = strconv.Atoi(raw)
+		}
+		out = append(out, plan)
+	}
+	return out, nil
+}
+
+// Complete records the materialized artifacts and closes the session.
+func (s *WorkflowWizardSessionStore) Complete(id string, artifacts []WorkflowWizardArtifact) (WorkflowWizardSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.sessions[strings.TrimSpace(id)]
+	if !ok {
+		return WorkflowWizardSession{}, errors.New("wizard session not found")
+	}
+	if item.Status != WizardSessionReady {
+		return WorkflowWizardSession{}, errors.New("wizard session must be ready to materialize; current status is " + item.Status)
+	}
+	now := time.Now().UTC()
+	item.Status = WizardSessionCompleted
+	item.Artifacts = append([]WorkflowWizardArtifact{}, artifacts...)
+	item.CompletedAt = now
+	item.UpdatedAt = now
+	if err := s.persistLocked(); err != nil {
+		return WorkflowWizardSession{}, err
+	}
+	return cloneWizardSession(*item), nil
+}
+
+func (s *WorkflowWizardSessionStore) validateStep(step WorkflowWizardStep, inputs map[string]string) map[string]string {
+	errs := map[string]string{}
+	for _, key := range step.RequiredInputs {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if inputs[key] == "" {
+			errs[key] = "is required"
+		}
+	}
+	for key, rule := range step.InputRules {
+		value := inputs[key]
+		if value == "" || errs[key] != "" {
+			continue
+		}
+		if msg := s.checkWizardRule(rule, value); msg != "" {
+			errs[key] = msg
+		}
+	}
+	return errs
+}
+
+func (s *WorkflowWizardSessionStore) checkWizardRule(rule, value string) string {
+	switch {
+	case rule == "slug":
+		if !wizardSlugPattern.MatchString(value) {
+			return "must be lowercase letters, digits, '.', '_' or '-'"
+		}
+	case rule == "interval":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 60 {
+			return "must be a whole number of seconds >= 60"
+		}
+	case rule == "config":
+		if _, err := config.Load(s.resolve(value)); err != nil {
+			return "must be a loadable config file: " + err.Error()
+		}
+	case strings.HasPrefix(rule, "enum:"):
+		allowed := strings.Split(strings.TrimPrefix(rule, "enum:"), "|")
+		if !containsString(allowed, value) {
+			return "must be one of " + strings.Join(allowed, ", ")
+		}
+	}
+	return ""
+}
+
+func (s *WorkflowWizardSessionStore) resolve(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.baseDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+func (s *WorkflowWizardSessionStore) load() {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var items []WorkflowWizardSession
+	if json.Unmarshal(raw, &items) != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		cp := cloneWizardSession(item)
+		s.sessions[cp.ID] = &cp
+		if n, err := strconv.ParseInt(strings.TrimPrefix(cp.ID, "wizard-session-"), 10, 64); err == nil && n > s.nextID {
+			s.nextID = n
+		}
+	}
+}
+
+func (s *WorkflowWizardSessionStore) persistLocked() error {
+	items := make([]WorkflowWizardSession, 0, len(s.sessions))
+	for _, item := range s.sessions {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	raw, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(raw, '\n'), 0o600)
+}
+
+func wizardStepIndex(wizard WorkflowWizard, stepID string) int {
+	for i, step := range wizard.Steps {
+		if step.ID == stepID {
+			return i
+		}
+	}
+	return -1
+}
+
+func normalizeWizardInputs(in map[string]string) map[string]string {
+	out := map[string]string{}
+	for key, value := range in {
+		k := strings.TrimSpace(strings.ToLower(key))
+		if k == "" {
+			continue
+		}
+		out[k] = strings.TrimSpace(value)
+	}
+	return out
+}
+
+func expandWizardInputs(text string, inputs map[string]string) string {
+	for key, value := range inputs {
+		text = strings.ReplaceAll(text, "{"+key+"}", value)
+	}
+	return text
+}
+
+func cloneWizardSession(in WorkflowWizardSession) WorkflowWizardSession {
+	out := in
+	out.CompletedSteps = append([]string{}, in.CompletedSteps...)
+	out.Inputs = map[string]string{}
+	for k, v := range in.Inputs {
+		out.Inputs[k] = v
+	}
+	if in.StepErrors != nil {
+		out.StepErrors = map[string]string{}
+		for k, v := range in.StepErrors {
+			out.StepErrors[k] = v
+		}
+	}
+	out.Artifacts = append([]WorkflowWizardArtifact(nil), in.Artifacts...)
+	return out
+}
@@ -13,16 +13,32 @@ type WorkflowWizardStep struct {
 	Title          string   `json:"title"`
 	Description    string   `json:"description"`
 	RequiredInputs []string `json:"required_inputs,omitempty"`
-	ActionHint     string   `json:"action_hint,omitempty"`
+	OptionalInputs []string `json:"optional_inputs,omitempty"`
+	// InputRules validates step inputs server-side: config (loadable config
+	// file), slug, interval (seconds >= 60), or enum:a|b|c.
+	InputRules map[string]string `json:"input_rules,omitempty"`
+	ActionHint string            `json:"action_hint,omitempty"`
+}
+
+// WorkflowWizardOutput describes an object a completed wizard session
+// materializes. Name may reference inputs as {input}.
+type WorkflowWizardOutput struct {
+	Kind          string `json:"kind"` // template|schedule|runbook
+	Name          string `json:"name"`
+	ConfigInput   string `json:"config_input"`
+	IntervalInput string `json:"interval_input,omitempty"` // schedule; skipped when the input is empty
+	Environment   string `json:"environment,omitempty"`    // schedule
+	RiskLevel     string `json:"risk_level,omitempty"`     // runbook
 }
 
 type WorkflowWizard struct {
-	ID          string               `json:"id"`
-	Title       string               `json:"title"`
-	Description string               `json:"description"`
-	UseCase     string               `json:"use_case"`
-	Steps       []WorkflowWizardStep `json:"steps"`
-	UpdatedAt   time.Time            `json:"updated_at"`
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	UseCase     string                 `json:"use_case"`
+	Steps       []WorkflowWizardStep   `json:"steps"`
+	Outputs     []WorkflowWizardOutput `json:"outputs,omitempty"`
+	UpdatedAt   time.Time              `json:"updated_at"`
 }
 
 type WorkflowWizardLaunchInput struct {
@@ -55,10 +71,14 @@ func NewWorkflowWizardCatalog() *WorkflowWizardCatalog {
 			UseCase:     "bootstrap",
 			UpdatedAt:   now,
 			Steps: []WorkflowWizardStep{
-				{ID: "validate-topology", Title: "Validate Topology", Description: "Validate DNS/network/storage prerequisites for control-plane deployment.", RequiredInputs: []string{"environment", "region"}, ActionHint: "POST /v1/control/bootstrap/ha"},
-				{ID: "select-template", Title: "Select Workspace Template", Description: "Choose opinionated workspace template for fleet type.", RequiredInputs: []string{"workspace_template"}, ActionHint: "GET /v1/workspace-templates"},
+				{ID: "validate-topology", Title: "Validate Topology", Description: "Validate DNS/network/storage prerequisites for control-plane deployment.", RequiredInputs: []string{"environment", "region"}, InputRules: map[string]string{"environment": "slug", "region": "slug"}, ActionHint: "POST /v1/control/bootstrap/ha"},
+				{ID: "select-template", Title: "Select Workspace Template", Description: "Choose opinionated workspace template for fleet type.", RequiredInputs: []string{"workspace_template"}, OptionalInputs: []string{"config_path"}, InputRules: map[string]string{"workspace_template": "slug", "config_path": "config"}, ActionHint: "GET /v1/workspace-templates"},
 				{ID: "generate-runbook", Title: "Generate Bootstrap Runbook", Description: "Generate runbook/checklist for handoff.", ActionHint: "POST /v1/runbooks"},
 			},
+			Outputs: []WorkflowWizardOutput{
+				{Kind: "template", Name: "bootstrap-{environment}-{region}", ConfigInput: "config_path"},
+				{Kind: "runbook", Name: "Bootstrap {environment} ({region})", ConfigInput: "config_path", RiskLevel: "medium"},
+			},
 		},
 		{
 			ID:          "rollout",
@@ -67,9 +87,14 @@ func NewWorkflowWizardCatalog() *WorkflowWizardCatalog {
 			UseCase:     "rollout",
 			UpdatedAt:   now,
 			Steps: []WorkflowWizardStep{
-				{ID: "preflight", Title: "Preflight Validation", Description: "Run policy simulation and preflight checks for candidate change.", RequiredInputs: []string{"config_path"}, ActionHint: "POST /v1/policy/simulate"},
-				{ID: "start-deployment", Title: "Start Deployment", Description: "Create deployment rollout with strategy and concurrency guards.", RequiredInputs: []string{"strategy", "target_environment"}, ActionHint: "POST /v1/deployments"},
-				{ID: "gate-promotion", Title: "Gate Promotion", Description: "Evaluate health probes and disruption budgets before promotion.", ActionHint: "POST /v1/control/health-probes/evaluate"},
+				{ID: "preflight", Title: "Preflight Validation", Description: "Run policy simulation and preflight checks for candidate change.", RequiredInputs: []string{"config_path"}, InputRules: map[string]string{"config_path": "config"}, ActionHint: "POST /v1/policy/simulate"},
+				{ID: "start-deployment", Title: "Start Deployment", Description: "Create deployment rollout with strategy and concurrency guards.", RequiredInputs: []string{"strategy", "target_environment"}, InputRules: map[string]string{"strategy": "enum:canary|blue-green|rolling", "target_environment": "slug"}, ActionHint: "POST /v1/deployments"},
+				{ID: "gate-promotion", Title: "Gate Promotion", Description: "Evaluate health probes and disruption budgets before promotion.", OptionalInputs: []string{"verify_interval_seconds"}, InputRules: map[string]string{"verify_interval_seconds": "interval"}, ActionHint: "POST /v1/control/health-probes/evaluate"},
+			},
+			Outputs: []WorkflowWizardOutput{
+				{Kind: "template", Name: "rollout-{target_environment}", ConfigInput: "config_path"},
+				{Kind: "schedule", Name: "verify {target_environment}", ConfigInput: "config_path", IntervalInput: "verify_interval_seconds", Environment: "{target_environment}"},
+				{Kind: "runbook", Name: "Rollout to {target_environment} ({strategy})", ConfigInput: "config_path", RiskLevel: "medium"},
 			},
 		},
 		{
@@ -79,10 +104,13 @@ func NewWorkflowWizardCatalog() *WorkflowWizardCatalog {
 			UseCase:     "rollback",
 			UpdatedAt:   now,
 			Steps: []WorkflowWizardStep{
-				{ID: "capture-triage", Title: "Capture Triage Bundle", Description: "Export failure context and dependency impacts.", RequiredInputs: []string{"run_id"}, ActionHint: "POST /v1/runs/{id}/triage-bundle"},
-				{ID: "execute-rollback", Title: "Execute Rollback", Description: "Run scoped rollback and enforce checkpoint resume safety.", RequiredInputs: []string{"rollback_config_path"}, ActionHint: "POST /v1/runs/{id}/rollback"},
+				{ID: "capture-triage", Title: "Capture Triage Bundle", Description: "Export failure context and dependency impacts.", RequiredInputs: []string{"run_id"}, InputRules: map[string]string{"run_id": "slug"}, ActionHint: "POST /v1/runs/{id}/triage-bundle"},
+				{ID: "execute-rollback", Title: "Execute Rollback", Description: "Run scoped rollback and enforce checkpoint resume safety.", RequiredInputs: []string{"rollback_config_path"}, InputRules: map[string]string{"rollback_config_path": "config"}, ActionHint: "POST /v1/runs/{id}/rollback"},
 				{ID: "post-verify", Title: "Post-Change Verification", Description: "Validate invariants, health probes, and drift state.", ActionHint: "POST /v1/invariants/evaluate"},
 			},
+			Outputs: []WorkflowWizardOutput{
+				{Kind: "runbook", Name: "Rollback {run_id}", ConfigInput: "rollback_config_path", RiskLevel: "high"},
+			},
 		},
 		{
 			ID:          "incident-remediation",
@@ -92,7 +120,7 @@ func NewWorkflowWizardCatalog() *WorkflowWizardCatalog {
 			UpdatedAt:   now,
 			Steps: []WorkflowWizardStep{
 				{ID: "collect-signals", Title: "Collect Incident Signals", Description: "Load incident view and correlate runs, alerts, and health.", RequiredInputs: []string{"workload"}, ActionHint: "GET /v1/incidents/view"},
-				{ID: "approved-action", Title: "Execute Approved Action", Description: "Run approved runbook/task with checklist and access controls.", RequiredInputs: []string{"runbook_id"}, ActionHint: "POST /v1/runbooks/{id}/launch"},
+				{ID: "approved-action", Title: "Execute Approved Action", Description: "Run approved runbook/task with checklist and access controls.", RequiredInputs: []string{"runbook_id"}, InputRules: map[string]string{"runbook_id": "slug"}, ActionHint: "POST /v1/runbooks/{id}/launch"},
 				{ID: "handoff", Title: "Generate Handoff Package", Description: "Create on-call handoff package with active risks and blockers.", ActionHint: "GET /v1/control/handoff"},
 			},
 		},
@@ -187,7 +215,13 @@ func cloneWorkflowWizard(in WorkflowWizard) WorkflowWizard {
 	for _, step := range in.Steps {
 		copied := step
 		copied.RequiredInputs = append([]string{}, step.RequiredInputs...)
+		copied.OptionalInputs = append([]string{}, step.OptionalInputs...)
+		copied.InputRules = map[string]string{}
+		for k, v := range step.InputRules {
+			copied.InputRules[k] = v
+		}
 		out.Steps = append(out.Steps, copied)
 	}
+	out.Outputs = append([]WorkflowWizardOutput{}, in.Outputs...)
 	return out
 }
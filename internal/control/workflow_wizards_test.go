@@ -1,6 +1,10 @@
 package control
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestWorkflowWizardCatalogListAndGet(t *testing.T) {
 	catalog := NewWorkflowWizardCatalog()
@@ -55,3 +59,58 @@ func TestWorkflowWizardCatalogLaunchValidation(t *testing.T) {
 		t.Fatalf("expected rollout wizard to be ready: %+v", ready)
 	}
 }
+
+func TestWorkflowWizardSessionStepsAndPlan(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rollback.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewWorkflowWizardSessionStore(NewWorkflowWizardCatalog(), dir)
+	if _, err := store.Start("missing", "", nil); err == nil {
+		t.Fatalf("expected unknown wizard to fail")
+	}
+	session, err := store.Start("rollback", "oncall", map[string]string{"Run_ID": "run-7"})
+	if err != nil {
+		t.Fatalf("start session failed: %v", err)
+	}
+	if session.CurrentStepID != "capture-triage" || session.Inputs["run_id"] != "run-7" {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+	result, err := store.SubmitStep(session.ID, "capture-triage", map[string]string{"run_id": "Run 7"})
+	if err != nil || result.Valid || result.Errors["run_id"] == "" {
+		t.Fatalf("expected slug validation error: err=%v result=%+v", err, result)
+	}
+	if result, err = store.SubmitStep(session.ID, "capture-triage", map[string]string{"run_id": "run-7"}); err != nil || !result.Valid {
+		t.Fatalf("capture-triage failed: err=%v result=%+v", err, result)
+	}
+	if result, err = store.SubmitStep(session.ID, "execute-rollback", nil); err != nil || result.Errors["rollback_config_path"] != "is required" {
+		t.Fatalf("expected missing input error: err=%v result=%+v", err, result)
+	}
+	if back, err := store.Back(session.ID); err != nil || back.CurrentStepID != "capture-triage" {
+		t.Fatalf("back failed: err=%v session=%+v", err, back)
+	}
+	_, _ = store.SubmitStep(session.ID, "capture-triage", nil)
+	_, _ = store.SubmitStep(session.ID, "execute-rollback", map[string]string{"rollback_config_path": "rollback.yaml"})
+	if _, err := store.MaterializationPlan(session.ID); err == nil {
+		t.Fatalf("expected plan to require a ready session")
+	}
+	if result, err = store.SubmitStep(session.ID, "post-verify", nil); err != nil || result.Session.Status != WizardSessionReady {
+		t.Fatalf("expected ready session: err=%v result=%+v", err, result)
+	}
+
+	reloaded := NewWorkflowWizardSessionStore(NewWorkflowWizardCatalog(), dir)
+	plan, err := reloaded.MaterializationPlan(session.ID)
+	if err != nil {
+		t.Fatalf("plan failed after reload: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Kind != "runbook" || plan[0].Name != "Rollback run-7" || plan[0].ConfigPath != filepath.Join(dir, "rollback.yaml") {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	done, err := reloaded.Complete(session.ID, []WorkflowWizardArtifact{{Kind: "runbook", ID: "rb-1", Name: "Rollback run-7"}})
+	if err != nil || done.Status != WizardSessionCompleted {
+		t.Fatalf("complete failed: err=%v session=%+v", err, done)
+	}
+	if next, err := reloaded.Start("rollback", "", nil); err != nil || next.ID == session.ID {
+		t.Fatalf("expected fresh session id after reload: err=%v id=%s", err, next.ID)
+	}
+}
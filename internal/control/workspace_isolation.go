@@ -200,6 +200,22 @@ func (s *WorkspaceIsolationStore) Evaluate(in WorkspaceIsolationEvaluateInput) W
 	}
 }
 
+// AllowsCrossWorkspaceRead reports whether any of the workspace's policies
+// opts it into reading sibling workspaces of the same tenant. Workspaces
+// without a policy are denied, matching Evaluate's default.
+func (s *WorkspaceIsolationStore) AllowsCrossWorkspaceRead(tenant, workspace string) bool {
+	tenant = strings.ToLower(strings.TrimSpace(tenant))
+	workspace = strings.ToLower(strings.TrimSpace(workspace))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, item := range s.policies {
+		if item.Tenant == tenant && item.Workspace == workspace && item.AllowCrossWorkspaceRead {
+			return true
+		}
+	}
+	return false
+}
+
 func workspaceIsolationKey(tenant, workspace, environment string) string {
 	return tenant + "|" + workspace + "|" + environment
 }
@@ -0,0 +1,357 @@
+package control
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	xmlDSigNS           = "http://www.w3.org/2000/09/xmldsig#"
+	xmlExcC14NAlgorithm = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	xmlEnvelopedSig     = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	xmlNamespaceURI     = "http://www.w3.org/XML/1998/namespace"
+)
+
+// xmlNode is a minimal DOM that keeps namespace prefixes as written, which
+// canonicalization needs and encoding/xml's resolved names lose.
+type xmlNode struct {
+	Prefix   string
+	Local    string
+	Attrs    []xml.Attr
+	Children []*xmlNode
+	Text     string
+	IsText   bool
+	parent   *xmlNode
+}
+
+func parseXMLNode(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+	var root, cur *xmlNode
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{Prefix: t.Name.Space, Local: t.Name.Local, Attrs: append([]xml.Attr{}, t.Attr...), parent: cur}
+			if cur == nil {
+				if root != nil {
+					return nil, errors.New("xml document has more than one root element")
+				}
+				root = n
+			} else {
+				cur.Children = append(cur.Children, n)
+			}
+			cur = n
+		case xml.EndElement:
+			if cur == nil || cur.Prefix != t.Name.Space || cur.Local != t.Name.Local {
+				return nil, errors.New("xml document is not well formed")
+			}
+			cur = cur.parent
+		case xml.CharData:
+			if cur != nil {
+				cur.Children = append(cur.Children, &xmlNode{Text: string(t), IsText: true, parent: cur})
+			}
+		case xml.Directive:
+			// DTDs enable entity expansion attacks and never appear in SAML.
+			return nil, errors.New("xml directives are not allowed")
+		}
+	}
+	if root == nil || cur != nil {
+		return nil, errors.New("xml document is not well formed")
+	}
+	return root, nil
+}
+
+// lookupNS resolves prefix ("" for the default namespace) against the
+// declarations in scope at n.
+func (n *xmlNode) lookupNS(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespaceURI, true
+	}
+	for e := n; e != nil; e = e.parent {
+		for _, a := range e.Attrs {
+			if (prefix == "" && a.Name.Space == "" && a.Name.Local == "xmlns") || (prefix != "" && a.Name.Space == "xmlns" && a.Name.Local == prefix) {
+				return a.Value, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+func (n *xmlNode) namespace() string {
+	ns, _ := n.lookupNS(n.Prefix)
+	return ns
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) child(ns, local string) *xmlNode {
+	for _, c := range n.Children {
+		if !c.IsText && c.Local == local && c.namespace() == ns {
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) childrenNamed(ns, local string) []*xmlNode {
+	var out []*xmlNode
+	for _, c := range n.Children {
+		if !c.IsText && c.Local == local && c.namespace() == ns {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (n *xmlNode) text() string {
+	var b strings.Builder
+	for _, c := range n.Children {
+		if c.IsText {
+			b.WriteString(c.Text)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// canonicalizeExclusive serializes n with Exclusive XML Canonicalization
+// (without comments). skip, when set, is omitted from the output, which
+// implements the enveloped-signature transform. inclusive lists prefixes
+// from an InclusiveNamespaces PrefixList ("#default" for the default
+// namespace).
+func canonicalizeExclusive(n *xmlNode, inclusive []string, skip *xmlNode) []byte {
+	var buf bytes.Buffer
+	incl := map[string]bool{}
+	for _, p := range inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		incl[p] = true
+	}
+	writeCanonicalNode(&buf, n, map[string]string{"": ""}, incl, skip)
+	return buf.Bytes()
+}
+
+func writeCanonicalNode(buf *bytes.Buffer, n *xmlNode, rendered map[string]string, inclusive map[string]bool, skip *xmlNode) {
+	if n == skip {
+		return
+	}
+	if n.IsText {
+		buf.WriteString(escapeCanonicalText(n.Text))
+		return
+	}
+	used := map[string]bool{n.Prefix: true}
+	type attr struct {
+		ns, name, value string
+	}
+	attrs := []attr{}
+	for _, a := range n.Attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		name := a.Name.Local
+		ns := ""
+		if a.Name.Space != "" {
+			name = a.Name.Space + ":" + a.Name.Local
+			ns, _ = n.lookupNS(a.Name.Space)
+			if a.Name.Space != "xml" {
+				used[a.Name.Space] = true
+			}
+		}
+		attrs = append(attrs, attr{ns: ns, name: name, value: a.Value})
+	}
+	for p := range inclusive {
+		used[p] = true
+	}
+	next := map[string]string{}
+	for k, v := range rendered {
+		next[k] = v
+	}
+	prefixes := make([]string, 0, len(used))
+	for p := range used {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	var decls strings.Builder
+	for _, p := range prefixes {
+		uri, ok := n.lookupNS(p)
+		if !ok || p == "xml" {
+			continue
+		}
+		if prev, seen := next[p]; seen && prev == uri {
+			continue
+		}
+		if p == "" && uri == "" {
+			if prev := next[""]; prev == "" {
+				continue
+			}
+		}
+		next[p] = uri
+		if p == "" {
+			decls.WriteString(` xmlns="` + escapeCanonicalAttr(uri) + `"`)
+		} else {
+			decls.WriteString(` xmlns:` + p + `="` + escapeCanonicalAttr(uri) + `"`)
+		}
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].ns != attrs[j].ns {
+			return attrs[i].ns < attrs[j].ns
+		}
+		return attrs[i].name[strings.Index(attrs[i].name, ":")+1:] < attrs[j].name[strings.Index(attrs[j].name, ":")+1:]
+	})
+	name := n.Local
+	if n.Prefix != "" {
+		name = n.Prefix + ":" + n.Local
+	}
+	buf.WriteString("<" + name + decls.String())
+	for _, a := range attrs {
+		buf.WriteString(" " + a.name + `="` + escapeCanonicalAttr(a.value) + `"`)
+	}
+	buf.WriteString(">")
+	for _, c := range n.Children {
+		writeCanonicalNode(buf, c, next, inclusive, skip)
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+func escapeCanonicalText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;").Replace(s)
+}
+
+func escapeCanonicalAttr(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;").Replace(s)
+}
+
+// verifyEnvelopedSignature checks the ds:Signature that is a direct child
+// of n against cert: the reference must point at n, its digest must match
+// n's canonical form, and SignedInfo must verify. Only exclusive
+// canonicalization with SHA-256/SHA-512 is accepted.
+func verifyEnvelopedSignature(n *xmlNode, cert *x509.Certificate) error {
+	sigs := n.childrenNamed(xmlDSigNS, "Signature")
+	if len(sigs) == 0 {
+		return errors.New("element is not signed")
+	}
+	if len(sigs) > 1 {
+		return errors.New("element has more than one signature")
+	}
+	sig := sigs[0]
+	signedInfo := sig.child(xmlDSigNS, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("signature has no SignedInfo")
+	}
+	c14n := signedInfo.child(xmlDSigNS, "CanonicalizationMethod")
+	if c14n == nil || c14n.attr("Algorithm") != xmlExcC14NAlgorithm {
+		return errors.New("signature must use exclusive canonicalization")
+	}
+	refs := signedInfo.childrenNamed(xmlDSigNS, "Reference")
+	if len(refs) != 1 {
+		return errors.New("signature must have exactly one reference")
+	}
+	ref := refs[0]
+	id := n.attr("ID")
+	if id == "" || ref.attr("URI") != "#"+id {
+		return errors.New("signature does not reference the signed element")
+	}
+	var inclusive []string
+	if transforms := ref.child(xmlDSigNS, "Transforms"); transforms != nil {
+		for _, tr := range transforms.childrenNamed(xmlDSigNS, "Transform") {
+			switch tr.attr("Algorithm") {
+			case xmlEnvelopedSig:
+			case xmlExcC14NAlgorithm:
+				inclusive = inclusivePrefixes(tr)
+			default:
+				return errors.New("unsupported signature transform " + tr.attr("Algorithm"))
+			}
+		}
+	}
+	digestMethod := ref.child(xmlDSigNS, "DigestMethod")
+	digestValue := ref.child(xmlDSigNS, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return errors.New("signature reference is incomplete")
+	}
+	digestHash, err := xmlDSigHash(digestMethod.attr("Algorithm"))
+	if err != nil {
+		return err
+	}
+	h := digestHash.New()
+	h.Write(canonicalizeExclusive(n, inclusive, sig))
+	want, err := base64.StdEncoding.DecodeString(compactBase64(digestValue.text()))
+	if err != nil || !bytes.Equal(h.Sum(nil), want) {
+		return errors.New("signature digest does not match")
+	}
+
+	sigMethod := signedInfo.child(xmlDSigNS, "SignatureMethod")
+	sigValue := sig.child(xmlDSigNS, "SignatureValue")
+	if sigMethod == nil || sigValue == nil {
+		return errors.New("signature is incomplete")
+	}
+	var sigHash crypto.Hash
+	switch sigMethod.attr("Algorithm") {
+	case "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256":
+		sigHash = crypto.SHA256
+	case "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512":
+		sigHash = crypto.SHA512
+	default:
+		return errors.New("unsupported signature method " + sigMethod.attr("Algorithm"))
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("idp certificate must hold an RSA key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(compactBase64(sigValue.text()))
+	if err != nil {
+		return errors.New("signature value is not base64")
+	}
+	sh := sigHash.New()
+	sh.Write(canonicalizeExclusive(signedInfo, inclusivePrefixes(c14n), nil))
+	if err := rsa.VerifyPKCS1v15(pub, sigHash, sh.Sum(nil), raw); err != nil {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+func inclusivePrefixes(transform *xmlNode) []string {
+	for _, c := range transform.Children {
+		if !c.IsText && c.Local == "InclusiveNamespaces" && c.namespace() == xmlExcC14NAlgorithm {
+			return strings.Fields(c.attr("PrefixList"))
+		}
+	}
+	return nil
+}
+
+func xmlDSigHash(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case "http://www.w3.org/2001/04/xmlenc#sha256":
+		return crypto.SHA256, nil
+	case "http://www.w3.org/2001/04/xmlenc#sha512":
+		return crypto.SHA512, nil
+	}
+	return 0, errors.New("unsupported digest method " + algorithm)
+}
+
+func compactBase64(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
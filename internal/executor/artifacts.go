@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/config"
+	"github.com/masterchef/masterchef/internal/planner"
+	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+const (
+	defaultArtifactMaxBytes = 10 << 20
+	artifactMissingMarker   = "__MASTERCHEF_ARTIFACT_MISSING__"
+)
+
+var errArtifactMissing = errors.New("artifact not found on host")
+
+// SetArtifactStore enables upload of declared output artifacts after each run.
+// Without a store artifacts are ignored.
+func (e *Executor) SetArtifactStore(store storage.ObjectStore) {
+	e.artifacts = store
+}
+
+// collectArtifacts uploads the output artifacts declared by executed steps and
+// by the plan itself. Collection never changes the run status; failures are
+// recorded on the artifact so triage can see what evidence is missing.
+func (e *Executor) collectArtifacts(runID string, executed []planner.Step, runLevel []planner.ArtifactStep) []state.RunArtifact {
+	if e.artifacts == nil {
+		return nil
+	}
+	var out []state.RunArtifact
+	for _, step := range executed {
+		for _, artifact := range step.Resource.Artifacts {
+			key := path.Join("runs", runID, "artifacts", step.Host.Name, step.Resource.ID, artifact.Name)
+			item := e.collectArtifact(step.Host, artifact, key)
+			item.ResourceID = step.Resource.ID
+			out = append(out, item)
+		}
+	}
+	for _, as := range runLevel {
+		key := path.Join("runs", runID, "artifacts", as.Host.Name, as.Artifact.Name)
+		out = append(out, e.collectArtifact(as.Host, as.Artifact, key))
+	}
+	return out
+}
+
+func (e *Executor) collectArtifact(host config.Host, artifact config.OutputArtifact, key string) state.RunArtifact {
+	item := state.RunArtifact{
+		Host: host.Name,
+		Name: artifact.Name,
+		Path: artifact.Path,
+	}
+	data, err := e.readHostFile(host, artifact.Path)
+	if errors.Is(err, errArtifactMissing) {
+		item.Status = "missing"
+		if !artifact.Optional {
+			item.Error = err.Error()
+		}
+		return item
+	}
+	if err != nil {
+		item.Status = "failed"
+		item.Error = err.Error()
+		return item
+	}
+	limit := artifact.MaxBytes
+	if limit <= 0 {
+		limit = defaultArtifactMaxBytes
+	}
+	if int64(len(data)) > limit {
+		// Keep the tail: the end of a log is usually what explains a failure.
+		data = data[int64(len(data))-limit:]
+		item.Truncated = true
+	}
+	info, err := e.artifacts.Put(key, data, "application/octet-stream")
+	if err != nil {
+		item.Status = "failed"
+		item.Error = err.Error()
+		return item
+	}
+	item.Status = "collected"
+	item.ObjectKey = info.Key
+	item.SizeBytes = info.SizeBytes
+	item.SHA256 = sha256HexString(data)
+	return item
+}
+
+func (e *Executor) readHostFile(host config.Host, filePath string) ([]byte, error) {
+	switch host.Transport {
+	case "", "local":
+		data, err := os.ReadFile(filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errArtifactMissing
+		}
+		return data, err
+	case "ssh":
+		q := shellQuote(filePath)
+		out, err := e.runSSH(host, "if [ -f "+q+" ]; then cat -- "+q+"; else printf '%s' "+artifactMissingMarker+"; fi")
+		if err != nil {
+			return nil, err
+		}
+		if string(out) == artifactMissingMarker {
+			return nil, errArtifactMissing
+		}
+		return out, nil
+	case "winrm":
+		if isLocalWinRMHost(host) {
+			return e.readHostFile(config.Host{Name: host.Name, Transport: "local"}, filePath)
+		}
+		target := host.Address
+		if target == "" {
+			target = host.Name
+		}
+		q := quotePowerShell(filePath)
+		out, err := e.runWinRMPowerShell(target, "if (Test-Path -PathType Leaf "+q+") { [Convert]::ToBase64String([IO.File]::ReadAllBytes("+q+")) } else { '"+artifactMissingMarker+"' }")
+		if err != nil {
+			return nil, err
+		}
+		text := strings.TrimSpace(string(out))
+		if text == artifactMissingMarker {
+			return nil, errArtifactMissing
+		}
+		return base64.StdEncoding.DecodeString(text)
+	default:
+		return nil, fmt.Errorf("artifact collection is not supported for transport %q", host.Transport)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/masterchef/masterchef/internal/planner"
 	"github.com/masterchef/masterchef/internal/provider"
 	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
 )
 
 type Executor struct {
@@ -30,6 +31,7 @@ type Executor struct {
 	baseDir           string
 	registry          *provider.Registry
 	transportHandlers map[string]transportApplyFunc
+	artifacts         storage.ObjectStore
 }
 
 type transportApplyFunc func(step planner.Step, r config.Resource) (bool, bool, string, error)
@@ -66,6 +68,18 @@ func NewWithRegistry(stepTimeout time.Duration, reg *provider.Registry) *Executo
 	return e
 }
 
+// skippedByCondition records a step whose fact-based when condition was
+// false at plan time.
+func skippedByCondition(step planner.Step) state.ResourceRun {
+	return state.ResourceRun{
+		ResourceID: step.Resource.ID,
+		Type:       step.Resource.Type,
+		Host:       step.Resource.Host,
+		Skipped:    true,
+		Message:    step.SkipReason,
+	}
+}
+
 func (e *Executor) Apply(p *planner.Plan) (state.RunRecord, error) {
 	run := state.RunRecord{
 		ID:        time.Now().UTC().Format("20060102T150405.000000000"),
@@ -87,6 +101,7 @@ func (e *Executor) Apply(p *planner.Plan) (state.RunRecord, error) {
 	refreshSources := buildRefreshSourceIndex(steps)
 	changedByResource := map[string]bool{}
 	notifiedHandlers := map[string]struct{}{}
+	var executed []planner.Step
 
 	failedSteps := 0
 	executedSteps := 0
@@ -110,6 +125,12 @@ func (e *Executor) Apply(p *planner.Plan) (state.RunRecord, error) {
 	}
 
 	for _, step := range steps {
+		if step.SkipReason != "" {
+			run.Results = append(run.Results, skippedByCondition(step))
+			changedByResource[step.Resource.ID] = false
+			executedSteps++
+			continue
+		}
 		triggeredSources := refreshTriggeredSources(step.Resource, refreshSources, changedByResource)
 		if step.Resource.RefreshOnly && len(triggeredSources) == 0 {
 			run.Results = append(run.Results, state.ResourceRun{
@@ -130,6 +151,7 @@ func (e *Executor) Apply(p *planner.Plan) (state.RunRecord, error) {
 			step.Resource.Unless = ""
 		}
 		res, failed := e.executeStep(step)
+		executed = append(executed, step)
 		if len(triggeredSources) > 0 {
 			res.Message = appendAuditMessage(res.Message, "refresh triggered by: "+strings.Join(triggeredSources, ", "))
 		}
@@ -170,7 +192,12 @@ func (e *Executor) Apply(p *planner.Plan) (state.RunRecord, error) {
 				})
 				break
 			}
+			if handlerStep.SkipReason != "" {
+				run.Results = append(run.Results, skippedByCondition(handlerStep))
+				continue
+			}
 			res, failed := e.executeStep(handlerStep)
+			executed = append(executed, handlerStep)
 			res.Message = appendAuditMessage(res.Message, "handler executed")
 			run.Results = append(run.Results, res)
 			if failed {
@@ -180,6 +207,7 @@ func (e *Executor) Apply(p *planner.Plan) (state.RunRecord, error) {
 		}
 	}
 
+	run.Artifacts = e.collectArtifacts(run.ID, executed, p.Artifacts)
 	run.EndedAt = time.Now().UTC()
 	if run.Status == "" {
 		run.Status = state.RunSucceeded
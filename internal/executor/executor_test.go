@@ -17,6 +17,7 @@ import (
 	"github.com/masterchef/masterchef/internal/config"
 	"github.com/masterchef/masterchef/internal/planner"
 	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
 )
 
 func TestApply_FileIsIdempotent(t *testing.T) {
@@ -1125,3 +1126,93 @@ func TestApply_PrivilegedRemoteSessionRecording(t *testing.T) {
 		t.Fatalf("unexpected session record %+v", rec)
 	}
 }
+
+func TestApply_CollectsOutputArtifacts(t *testing.T) {
+	tmp := t.TempDir()
+	report := filepath.Join(tmp, "report.txt")
+	logPath := filepath.Join(tmp, "app.log")
+	if err := os.WriteFile(logPath, []byte("line1\nline2\nfatal: disk full\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	host := config.Host{Name: "localhost", Transport: "local"}
+	p := &planner.Plan{
+		Steps: []planner.Step{
+			{
+				Order: 1,
+				Host:  host,
+				Resource: config.Resource{
+					ID:      "gen-report",
+					Type:    "file",
+					Host:    "localhost",
+					Path:    report,
+					Content: "all checks passed\n",
+					Artifacts: []config.OutputArtifact{
+						{Name: "report", Path: report},
+						{Name: "coredump", Path: filepath.Join(tmp, "core"), Optional: true},
+					},
+				},
+			},
+		},
+		Artifacts: []planner.ArtifactStep{
+			{Host: host, Artifact: config.OutputArtifact{Name: "app-log", Path: logPath, MaxBytes: 16}},
+		},
+	}
+
+	store, err := storage.NewLocalFSStore(filepath.Join(tmp, "objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := New(tmp)
+	ex.SetArtifactStore(store)
+	run, err := ex.Apply(p)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(run.Artifacts) != 3 {
+		t.Fatalf("expected three artifacts, got %#v", run.Artifacts)
+	}
+	got := run.Artifacts[0]
+	sum := sha256.Sum256([]byte("all checks passed\n"))
+	if got.Status != "collected" || got.ResourceID != "gen-report" || got.SHA256 != hex.EncodeToString(sum[:]) || got.SizeBytes != 18 {
+		t.Fatalf("unexpected report artifact %#v", got)
+	}
+	data, _, err := store.Get(got.ObjectKey)
+	if err != nil || string(data) != "all checks passed\n" {
+		t.Fatalf("expected uploaded report, got %q err=%v", data, err)
+	}
+	if missing := run.Artifacts[1]; missing.Status != "missing" || missing.Error != "" {
+		t.Fatalf("expected optional missing artifact without error, got %#v", missing)
+	}
+	tail := run.Artifacts[2]
+	if tail.Status != "collected" || !tail.Truncated || tail.ResourceID != "" {
+		t.Fatalf("expected truncated run-level log, got %#v", tail)
+	}
+	if data, _, _ := store.Get(tail.ObjectKey); string(data) != "atal: disk full\n" {
+		t.Fatalf("expected log tail kept, got %q", data)
+	}
+}
+
+func TestApply_RecordsFactConditionSkip(t *testing.T) {
+	tmp := t.TempDir()
+	marker := filepath.Join(tmp, "apt.marker")
+	p := &planner.Plan{
+		Steps: []planner.Step{
+			{
+				Order:      1,
+				Host:       config.Host{Name: "localhost", Transport: "local"},
+				Resource:   config.Resource{ID: "apt", Type: "command", Host: "localhost", Command: "touch " + marker, When: "facts.os_family == debian"},
+				SkipReason: "when condition false on localhost: facts.os_family == debian (facts.os_family=rhel)",
+			},
+		},
+	}
+	run, err := New(tmp).Apply(p)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if run.Status != state.RunSucceeded || len(run.Results) != 1 || !run.Results[0].Skipped || !strings.Contains(run.Results[0].Message, "facts.os_family=rhel") {
+		t.Fatalf("expected fact-conditioned step skipped with reason, got %#v", run)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("expected skipped command not to run, stat err=%v", err)
+	}
+}
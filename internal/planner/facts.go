@@ -0,0 +1,84 @@
+package planner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/config"
+)
+
+// HostFacts returns the facts a when condition can reference for h as
+// "facts.<name>": inventory classifications (name, transport, address,
+// labels.*, topology.*, roles.*, capabilities.*), static inventory facts, and
+// observed facts, with observed values winning.
+func HostFacts(h config.Host, observed map[string]string) map[string]string {
+	facts := map[string]string{
+		"facts.name":      h.Name,
+		"facts.transport": h.Transport,
+		"facts.address":   h.Address,
+	}
+	for k, v := range h.Labels {
+		facts["facts.labels."+k] = v
+	}
+	for k, v := range h.Topology {
+		facts["facts.topology."+k] = v
+	}
+	for _, role := range h.Roles {
+		facts["facts.roles."+strings.TrimSpace(role)] = "true"
+	}
+	for _, capability := range h.Capabilities {
+		facts["facts.capabilities."+strings.TrimSpace(capability)] = "true"
+	}
+	for k, v := range h.Facts {
+		facts["facts."+k] = v
+	}
+	for k, v := range observed {
+		facts["facts."+k] = v
+	}
+	return facts
+}
+
+func factSkipReason(when string, h config.Host, observed map[string]map[string]string) string {
+	if !config.WhenReferencesFacts(when) {
+		return ""
+	}
+	hostObserved, ok := observed[h.Name]
+	if !ok {
+		hostObserved = observed[strings.ToLower(strings.TrimSpace(h.Name))]
+	}
+	facts := HostFacts(h, hostObserved)
+	if config.EvaluateWhen(when, facts) {
+		return ""
+	}
+	return "when condition false on " + h.Name + ": " + strings.TrimSpace(when) + describeFacts(when, facts)
+}
+
+// describeFacts lists the fact values the condition saw, so a skip can be
+// explained without re-gathering facts.
+func describeFacts(when string, facts map[string]string) string {
+	seen := map[string]string{}
+	for _, field := range strings.FieldsFunc(when, func(r rune) bool {
+		return r == ' ' || r == '=' || r == '!' || r == '\'' || r == '"'
+	}) {
+		if strings.HasPrefix(field, "facts.") {
+			value, ok := facts[field]
+			if !ok {
+				value = "<unset>"
+			}
+			seen[field] = value
+		}
+	}
+	if len(seen) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+seen[k])
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -12,16 +12,32 @@ type Plan struct {
 	Execution config.Execution `json:"execution,omitempty"`
 	Steps     []Step           `json:"steps"`
 	Handlers  map[string]Step  `json:"handlers,omitempty"`
+	Artifacts []ArtifactStep   `json:"artifacts,omitempty"`
+}
+
+// ArtifactStep is a run-level output artifact resolved to its host.
+type ArtifactStep struct {
+	Host     config.Host           `json:"host"`
+	Artifact config.OutputArtifact `json:"artifact"`
 }
 
 type Step struct {
 	Order    int             `json:"order"`
 	Host     config.Host     `json:"host"`
 	Resource config.Resource `json:"resource"`
+	// SkipReason is set when the resource's fact-based when condition is
+	// false for Host; the step stays in the graph but is not applied.
+	SkipReason string `json:"skip_reason,omitempty"`
 }
 
 // Build constructs a deterministic topological plan from config resources.
 func Build(cfg *config.Config) (*Plan, error) {
+	return BuildWithFacts(cfg, nil)
+}
+
+// BuildWithFacts is Build with observed host facts (flattened, keyed by host
+// name) layered over inventory facts when evaluating fact-based conditions.
+func BuildWithFacts(cfg *config.Config, observed map[string]map[string]string) (*Plan, error) {
 	idToRes := map[string]config.Resource{}
 	inDegree := map[string]int{}
 	graph := map[string][]string{}
@@ -98,10 +114,12 @@ func Build(cfg *config.Config) (*Plan, error) {
 		if strings.TrimSpace(idToRes[id].DelegateTo) != "" {
 			execHost = idToRes[id].DelegateTo
 		}
+		host := hostByName[execHost]
 		steps = append(steps, Step{
-			Order:    i + 1,
-			Host:     hostByName[execHost],
-			Resource: idToRes[id],
+			Order:      i + 1,
+			Host:       host,
+			Resource:   idToRes[id],
+			SkipReason: factSkipReason(idToRes[id].When, host, observed),
 		})
 	}
 	handlers := map[string]Step{}
@@ -111,15 +129,21 @@ func Build(cfg *config.Config) (*Plan, error) {
 			execHost = handler.DelegateTo
 		}
 		handlers[handler.ID] = Step{
-			Order:    len(steps) + i + 1,
-			Host:     hostByName[execHost],
-			Resource: handler,
+			Order:      len(steps) + i + 1,
+			Host:       hostByName[execHost],
+			Resource:   handler,
+			SkipReason: factSkipReason(handler.When, hostByName[execHost], observed),
 		}
 	}
+	var artifacts []ArtifactStep
+	for _, artifact := range cfg.Artifacts {
+		artifacts = append(artifacts, ArtifactStep{Host: hostByName[artifact.Host], Artifact: artifact})
+	}
 	return &Plan{
 		Execution: cfg.Execution,
 		Steps:     steps,
 		Handlers:  handlers,
+		Artifacts: artifacts,
 	}, nil
 }
 
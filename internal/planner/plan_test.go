@@ -1,6 +1,7 @@
 package planner
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/masterchef/masterchef/internal/config"
@@ -117,3 +118,38 @@ func TestBuild_AutoTransportDiscovery(t *testing.T) {
 		t.Fatalf("expected ssh auto transport, got %q", got["c-linux"])
 	}
 }
+
+func TestBuildWithFacts_RecordsSkipReasonPerHost(t *testing.T) {
+	cfg := &config.Config{
+		Version: "v0",
+		Inventory: config.Inventory{
+			Hosts: []config.Host{
+				{Name: "deb", Transport: "local", Facts: map[string]string{"os_family": "debian"}},
+				{Name: "rhel", Transport: "local", Roles: []string{"db"}, Facts: map[string]string{"os_family": "debian"}},
+			},
+		},
+		Resources: []config.Resource{
+			{ID: "apt-deb", Type: "command", Host: "deb", Command: "echo", When: "facts.os_family == debian"},
+			{ID: "apt-rhel", Type: "command", Host: "rhel", Command: "echo", When: "facts.os_family == debian", DependsOn: []string{"apt-deb"}},
+			{ID: "backup", Type: "command", Host: "rhel", Command: "echo", When: "facts.roles.db", DependsOn: []string{"apt-rhel"}},
+			{ID: "web", Type: "command", Host: "rhel", Command: "echo", When: "facts.roles.web"},
+		},
+	}
+	p, err := BuildWithFacts(cfg, map[string]map[string]string{"rhel": {"os_family": "rhel"}})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	reasons := map[string]string{}
+	for _, step := range p.Steps {
+		reasons[step.Resource.ID] = step.SkipReason
+	}
+	if len(p.Steps) != 4 || reasons["apt-deb"] != "" || reasons["backup"] != "" {
+		t.Fatalf("expected matching steps to run and skipped steps to stay in the graph, got %+v", reasons)
+	}
+	if !strings.Contains(reasons["apt-rhel"], "facts.os_family=rhel") {
+		t.Fatalf("expected observed facts to override inventory facts in skip reason, got %q", reasons["apt-rhel"])
+	}
+	if !strings.Contains(reasons["web"], "facts.roles.web=<unset>") {
+		t.Fatalf("expected unset fact in skip reason, got %q", reasons["web"])
+	}
+}
@@ -30,6 +30,17 @@ func (h *FileHandler) Apply(_ context.Context, resource config.Resource) (Result
 	return Result{Changed: true, Message: "file updated"}, nil
 }
 
+func (h *FileHandler) Delete(_ context.Context, resource config.Resource) (Result, error) {
+	err := os.Remove(filepath.Clean(resource.Path))
+	if os.IsNotExist(err) {
+		return Result{Changed: false, Message: "file already absent"}, nil
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("remove file: %w", err)
+	}
+	return Result{Changed: true, Message: "file removed"}, nil
+}
+
 type CommandHandler struct{}
 
 func (h *CommandHandler) Type() string { return "command" }
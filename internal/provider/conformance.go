@@ -2,7 +2,11 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/config"
 )
@@ -35,3 +39,191 @@ func CheckIdempotency(ctx context.Context, h Handler, sample config.Resource) Co
 	rep.IdempotentPass = true
 	return rep
 }
+
+// Conformance behaviors exercised by RunBehaviors, in execution order.
+const (
+	BehaviorCreate      = "create"
+	BehaviorIdempotency = "idempotency"
+	BehaviorDrift       = "drift-detection"
+	BehaviorUpdate      = "update"
+	BehaviorDelete      = "delete"
+)
+
+var StandardBehaviors = []string{BehaviorCreate, BehaviorIdempotency, BehaviorDrift, BehaviorUpdate, BehaviorDelete}
+
+// Deleter is implemented by handlers that can remove what Apply created.
+type Deleter interface {
+	Delete(ctx context.Context, resource config.Resource) (Result, error)
+}
+
+// Fixture is a generated resource pair plus probes that observe the state
+// a provider leaves behind in a scratch workspace.
+type Fixture struct {
+	Create config.Resource
+	Update config.Resource
+	Verify func(config.Resource) error // desired state of the resource is present
+	Drift  func(config.Resource) error // mutates state out of band
+	Absent func(config.Resource) error // resource has been removed
+}
+
+type BehaviorResult struct {
+	Behavior string        `json:"behavior"`
+	Status   string        `json:"status"` // pass|fail|skip
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// GenerateFixture builds a fixture for a built-in provider type rooted in
+// workspace.
+func GenerateFixture(providerType, workspace string) (Fixture, bool) {
+	switch providerType {
+	case "file":
+		path := filepath.Join(workspace, "fixture", "managed.txt")
+		base := config.Resource{ID: "conformance-file", Type: "file", Host: "localhost", Path: path}
+		create, update := base, base
+		create.Content = "masterchef conformance v1\n"
+		update.Content = "masterchef conformance v2\n"
+		return Fixture{
+			Create: create,
+			Update: update,
+			Verify: func(r config.Resource) error {
+				got, err := os.ReadFile(r.Path)
+				if err != nil {
+					return err
+				}
+				if string(got) != r.Content {
+					return fmt.Errorf("file content %q does not match desired %q", got, r.Content)
+				}
+				return nil
+			},
+			Drift: func(r config.Resource) error {
+				return os.WriteFile(r.Path, []byte("drifted out of band\n"), 0o644)
+			},
+			Absent: func(r config.Resource) error {
+				if _, err := os.Stat(r.Path); !os.IsNotExist(err) {
+					return fmt.Errorf("%s still exists", r.Path)
+				}
+				return nil
+			},
+		}, true
+	case "command":
+		marker := func(version string) config.Resource {
+			path := filepath.Join(workspace, "marker-"+version)
+			return config.Resource{
+				ID:      "conformance-command",
+				Type:    "command",
+				Host:    "localhost",
+				Command: "printf " + version + " > '" + path + "'",
+				Creates: path,
+			}
+		}
+		return Fixture{
+			Create: marker("v1"),
+			Update: marker("v2"),
+			Verify: func(r config.Resource) error {
+				_, err := os.Stat(r.Creates)
+				return err
+			},
+			Drift: func(r config.Resource) error {
+				return os.Remove(r.Creates)
+			},
+			Absent: func(r config.Resource) error {
+				if _, err := os.Stat(r.Creates); !os.IsNotExist(err) {
+					return fmt.Errorf("%s still exists", r.Creates)
+				}
+				return nil
+			},
+		}, true
+	default:
+		return Fixture{}, false
+	}
+}
+
+// RunBehaviors drives h through the requested behaviors against fx. The
+// behaviors share state, so ones that were not requested still run as
+// setup but are not reported. Delete is skipped for handlers that do not
+// implement Deleter.
+func RunBehaviors(ctx context.Context, h Handler, fx Fixture, behaviors []string) []BehaviorResult {
+	requested := map[string]bool{}
+	for _, b := range behaviors {
+		requested[b] = true
+	}
+	out := []BehaviorResult{}
+	setupErr := ""
+	step := func(name string, fn func() error) {
+		start := time.Now()
+		err := fn()
+		if setupErr == "" && err != nil && name == BehaviorCreate {
+			setupErr = "setup failed: " + err.Error()
+		}
+		if !requested[name] {
+			return
+		}
+		res := BehaviorResult{Behavior: name, Status: "pass", Duration: time.Since(start)}
+		if err != nil {
+			res.Status = "fail"
+			res.Detail = err.Error()
+		}
+		out = append(out, res)
+	}
+	expectApply := func(r config.Resource, changed bool) error {
+		res, err := h.Apply(ctx, r)
+		if err != nil {
+			return fmt.Errorf("apply failed: %w", err)
+		}
+		if res.Changed != changed {
+			return fmt.Errorf("apply reported changed=%t, expected %t", res.Changed, changed)
+		}
+		return fx.Verify(r)
+	}
+
+	step(BehaviorCreate, func() error { return expectApply(fx.Create, true) })
+	if setupErr != "" {
+		for _, name := range StandardBehaviors[1:] {
+			if requested[name] {
+				out = append(out, BehaviorResult{Behavior: name, Status: "fail", Detail: setupErr})
+			}
+		}
+		return out
+	}
+	step(BehaviorIdempotency, func() error { return expectApply(fx.Create, false) })
+	step(BehaviorDrift, func() error {
+		if err := fx.Drift(fx.Create); err != nil {
+			return fmt.Errorf("inject drift: %w", err)
+		}
+		if err := expectApply(fx.Create, true); err != nil {
+			return fmt.Errorf("drift not corrected: %w", err)
+		}
+		return expectApply(fx.Create, false)
+	})
+	step(BehaviorUpdate, func() error {
+		if err := expectApply(fx.Update, true); err != nil {
+			return err
+		}
+		return expectApply(fx.Update, false)
+	})
+	deleter, ok := h.(Deleter)
+	if !ok {
+		if requested[BehaviorDelete] {
+			out = append(out, BehaviorResult{Behavior: BehaviorDelete, Status: "skip", Detail: "provider does not implement delete"})
+		}
+		return out
+	}
+	step(BehaviorDelete, func() error {
+		res, err := deleter.Delete(ctx, fx.Update)
+		if err != nil {
+			return fmt.Errorf("delete failed: %w", err)
+		}
+		if !res.Changed {
+			return errors.New("delete reported no change for an existing resource")
+		}
+		if err := fx.Absent(fx.Update); err != nil {
+			return err
+		}
+		if res, err = deleter.Delete(ctx, fx.Update); err != nil || res.Changed {
+			return errors.New("second delete was not a no-op")
+		}
+		return nil
+	})
+	return out
+}
@@ -79,3 +79,43 @@ func TestCommandHandler_OnlyIfGuardAllowsExecution(t *testing.T) {
 		t.Fatalf("expected command to create marker, got %v", err)
 	}
 }
+
+func TestRunBehaviors_BuiltinProviders(t *testing.T) {
+	r := NewBuiltinRegistry()
+	for _, typ := range []string{"file", "command"} {
+		h, _ := r.Lookup(typ)
+		fx, ok := GenerateFixture(typ, t.TempDir())
+		if !ok {
+			t.Fatalf("expected fixture for %s", typ)
+		}
+		results := RunBehaviors(context.Background(), h, fx, StandardBehaviors)
+		if len(results) != len(StandardBehaviors) {
+			t.Fatalf("%s: expected a result per behavior, got %+v", typ, results)
+		}
+		for _, res := range results {
+			want := "pass"
+			if typ == "command" && res.Behavior == BehaviorDelete {
+				want = "skip"
+			}
+			if res.Status != want {
+				t.Fatalf("%s %s: expected %s, got %+v", typ, res.Behavior, want, res)
+			}
+		}
+	}
+}
+
+type stubbornHandler struct{}
+
+func (stubbornHandler) Type() string { return "file" }
+
+func (stubbornHandler) Apply(context.Context, config.Resource) (Result, error) {
+	return Result{Changed: true}, nil
+}
+
+func TestRunBehaviors_ReportsNonIdempotentProvider(t *testing.T) {
+	fx, _ := GenerateFixture("file", t.TempDir())
+	results := RunBehaviors(context.Background(), stubbornHandler{}, fx, []string{BehaviorIdempotency})
+	if len(results) != 1 || results[0].Status != "fail" {
+		t.Fatalf("expected idempotency failure after failed setup, got %+v", results)
+	}
+}
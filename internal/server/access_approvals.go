@@ -1,8 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -68,7 +71,11 @@ func (s *Server) handleBreakGlassRequests(w http.ResponseWriter, r *http.Request
 		}
 		item, err := s.accessApprovals.CreateBreakGlassRequest(req)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			code := http.StatusBadRequest
+			if strings.Contains(err.Error(), "needs a postmortem") {
+				code = http.StatusConflict
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
 			return
 		}
 		s.recordEvent(control.Event{
@@ -89,7 +96,7 @@ func (s *Server) handleBreakGlassRequests(w http.ResponseWriter, r *http.Request
 
 func (s *Server) handleBreakGlassRequestAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/access/break-glass/requests/{id}[/approve|reject|revoke]
+	// /v1/access/break-glass/requests/{id}[/approve|reject|revoke|postmortem]
 	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "access" || parts[2] != "break-glass" || parts[3] != "requests" {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -113,6 +120,10 @@ func (s *Server) handleBreakGlassRequestAction(w http.ResponseWriter, r *http.Re
 		return
 	}
 	action := parts[5]
+	if action == "postmortem" {
+		s.attachBreakGlassPostmortem(w, r, id)
+		return
+	}
 	var req struct {
 		Actor   string `json:"actor"`
 		Comment string `json:"comment"`
@@ -137,7 +148,7 @@ func (s *Server) handleBreakGlassRequestAction(w http.ResponseWriter, r *http.Re
 		return
 	}
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		writeJSON(w, approvalErrorCode(err), map[string]string{"error": err.Error()})
 		return
 	}
 	s.recordEvent(control.Event{
@@ -149,5 +160,113 @@ func (s *Server) handleBreakGlassRequestAction(w http.ResponseWriter, r *http.Re
 			"status":     item.Status,
 		},
 	}, true)
+	switch {
+	case action == "approve" && item.Status == control.BreakGlassActive:
+		s.noteBreakGlassTransition(item, "activated")
+	case action == "revoke" && item.ActivatedAt != nil:
+		s.noteBreakGlassTransition(item, "ended")
+	}
 	writeJSON(w, http.StatusOK, item)
 }
+
+func (s *Server) attachBreakGlassPostmortem(w http.ResponseWriter, r *http.Request, id string) {
+	var req control.BreakGlassPostmortem
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	item, err := s.accessApprovals.AttachBreakGlassPostmortem(id, req)
+	if err != nil {
+		code := http.StatusBadRequest
+		if err.Error() == "break-glass request not found" {
+			code = http.StatusNotFound
+		}
+		writeJSON(w, code, map[string]any{"error": err.Error(), "checklist": control.BreakGlassPostmortemChecklist})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "access.break_glass.postmortem",
+		Message: "break-glass postmortem attached",
+		Fields: map[string]any{
+			"request_id": item.ID,
+			"author":     item.Postmortem.Author,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) handleBreakGlassElevation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	principal := strings.TrimSpace(r.URL.Query().Get("principal"))
+	if principal == "" {
+		principal, _ = requestIdentity(r)
+	}
+	item, ok := s.accessApprovals.ActiveBreakGlassFor(principal)
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]any{"principal": principal, "elevated": false})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"principal": principal, "elevated": true, "request": item})
+}
+
+// breakGlassElevated reports whether the caller is in an active break-glass
+// elevated mode, which bypasses change-record gates.
+func (s *Server) breakGlassElevated(r *http.Request) (control.BreakGlassRequest, bool) {
+	principal, _ := requestIdentity(r)
+	return s.accessApprovals.ActiveBreakGlassFor(principal)
+}
+
+// noteBreakGlassTransition records elevated mode starting or ending and
+// notifies security targets directly, independent of alert routing.
+func (s *Server) noteBreakGlassTransition(item control.BreakGlassRequest, transition string) {
+	fields := map[string]any{
+		"request_id":   item.ID,
+		"requested_by": item.RequestedBy,
+		"scope":        item.Scope,
+		"status":       item.Status,
+		"severity":     "high",
+	}
+	if item.ExpiresAt != nil {
+		fields["expires_at"] = *item.ExpiresAt
+	}
+	evt := control.Event{
+		Type:    "access.break_glass." + transition,
+		Message: "break-glass elevated mode " + transition + " for " + item.RequestedBy,
+		Fields:  fields,
+	}
+	s.recordEvent(evt, true)
+	now := time.Now().UTC()
+	s.notifications.NotifySecurity(control.AlertItem{
+		ID:          item.ID + "-" + transition,
+		Fingerprint: "break-glass:" + item.ID + ":" + transition,
+		EventType:   evt.Type,
+		Message:     evt.Message,
+		Severity:    "high",
+		Route:       "security",
+		Count:       1,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+		Status:      control.AlertOpen,
+		Fields:      fields,
+	})
+}
+
+// sweepBreakGlass expires elevated modes on schedule so security targets
+// hear about expiry even when nobody reads the request.
+func (s *Server) sweepBreakGlass(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, item := range s.accessApprovals.ExpireBreakGlassRequests() {
+				s.noteBreakGlassTransition(item, "expired")
+			}
+		}
+	}
+}
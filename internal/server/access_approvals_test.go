@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -122,3 +124,113 @@ resources:
 		t.Fatalf("revoke break-glass request failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestBreakGlassElevatedModeAndPostmortem(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "marker.txt")+`
+    content: "ok"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var securityHits atomic.Int64
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		securityHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	decodeID := func(rr *httptest.ResponseRecorder) string {
+		obj.ID = ""
+		if err := json.Unmarshal(rr.Body.Bytes(), &obj); err != nil || obj.ID == "" {
+			t.Fatalf("decode id failed: %v body=%s", err, rr.Body.String())
+		}
+		return obj.ID
+	}
+
+	if rr := post("/v1/notifications/targets", "", `{"name":"secops","kind":"security","route":"security","enabled":true,"url":"`+receiver.URL+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("register security target failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	policyID := decodeID(post("/v1/access/approval-policies", "", `{"name":"single","stages":[{"name":"security","required_approvals":1}]}`))
+	rr := post("/v1/access/break-glass/requests", "", `{"requested_by":"oncall","reason":"prod outage","scope":"service/payments","policy_id":"`+policyID+`"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create break-glass request failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	bgID := decodeID(rr)
+
+	runbookID := decodeID(post("/v1/runbooks", "", `{"name":"payments-fix","target_type":"config","config_path":"c.yaml","risk_level":"high","owner":"sre"}`))
+	if rr := post("/v1/runbooks/"+runbookID+"/approve", "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("approve runbook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	changeID := decodeID(post("/v1/change-records", "", `{"summary":"payments fix","config_path":"c.yaml","requested_by":"oncall"}`))
+	if rr := post("/v1/change-records/"+changeID+"/reject", "", `{"actor":"cab","comment":"freeze"}`); rr.Code != http.StatusOK {
+		t.Fatalf("reject change record failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	launch := `{"change_record_id":"` + changeID + `"}`
+	if rr := post("/v1/runbooks/"+runbookID+"/launch", "oncall", launch); rr.Code != http.StatusConflict {
+		t.Fatalf("expected rejected change record to block launch, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := post("/v1/access/break-glass/requests/"+bgID+"/approve", "", `{"actor":"security-1"}`); rr.Code != http.StatusOK {
+		t.Fatalf("approve break-glass failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if securityHits.Load() != 1 {
+		t.Fatalf("expected security target to be notified on activation, hits=%d", securityHits.Load())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/access/break-glass/elevated?principal=oncall", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"elevated":true`) {
+		t.Fatalf("expected elevated mode for requester: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/runbooks/"+runbookID+"/launch", "someone-else", launch); rr.Code != http.StatusConflict {
+		t.Fatalf("expected other principals to stay gated, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/runbooks/"+runbookID+"/launch", "oncall", launch); rr.Code >= 300 {
+		t.Fatalf("expected break-glass to bypass rejected change record, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := post("/v1/access/break-glass/requests/"+bgID+"/revoke", "", `{"actor":"incident-commander"}`); rr.Code != http.StatusOK {
+		t.Fatalf("revoke break-glass failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if securityHits.Load() != 2 {
+		t.Fatalf("expected security target to be notified when elevated mode ends, hits=%d", securityHits.Load())
+	}
+	next := `{"requested_by":"oncall","reason":"follow-up","scope":"service/payments","policy_id":"` + policyID + `"}`
+	if rr := post("/v1/access/break-glass/requests", "", next); rr.Code != http.StatusConflict {
+		t.Fatalf("expected new request to be blocked pending postmortem, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/access/break-glass/requests/"+bgID+"/postmortem", "", `{"author":"oncall","summary":"fixed","checklist":{"timeline_documented":true}}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected incomplete postmortem to fail, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	postmortem := `{"author":"oncall","summary":"fixed","checklist":{"timeline_documented":true,"actions_reviewed":true,"access_removal_verified":true,"follow_ups_filed":true}}`
+	if rr := post("/v1/access/break-glass/requests/"+bgID+"/postmortem", "", postmortem); rr.Code != http.StatusOK {
+		t.Fatalf("attach postmortem failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/access/break-glass/requests", "", next); rr.Code != http.StatusCreated {
+		t.Fatalf("expected new request after postmortem, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -55,3 +55,14 @@ func (s *Server) handleAccessibilityActive(w http.ResponseWriter, r *http.Reques
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
+
+// requestAccessibilityProfile resolves the profile UI metadata is adapted
+// for: the accessibility_profile query parameter, else the active profile.
+func (s *Server) requestAccessibilityProfile(w http.ResponseWriter, r *http.Request) (control.AccessibilityProfile, bool) {
+	profile, err := s.accessibility.Resolve(r.URL.Query().Get("accessibility_profile"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "accessibility " + err.Error()})
+		return control.AccessibilityProfile{}, false
+	}
+	return profile, true
+}
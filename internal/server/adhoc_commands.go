@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -193,3 +195,29 @@ func trimAdHocOutput(output string) string {
 	}
 	return output[:maxLen] + "\n...truncated..."
 }
+
+// guardExecCommand applies the ad-hoc guardrail policy to commands that
+// other features run on the server, and requires the executable to be one
+// of the absolute paths listed in the comma-separated allowlistEnv.
+func (s *Server) guardExecCommand(source, allowlistEnv, command string, args []string) error {
+	line := strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
+	_, allowed, reasons, err := s.adhocCommands.Evaluate(control.AdHocCommandRequest{
+		Command:     line,
+		Reason:      source,
+		RequestedBy: source,
+	})
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("command blocked: " + strings.Join(reasons, "; "))
+	}
+	command = strings.TrimSpace(command)
+	for _, entry := range strings.Split(os.Getenv(allowlistEnv), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" && filepath.IsAbs(entry) && entry == command {
+			return nil
+		}
+	}
+	return errors.New("command " + command + " is not listed in " + allowlistEnv)
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleAdmissionPolicies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.admissionPolicies.List())
+	case http.MethodPost:
+		var req control.AdmissionPolicyInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.admissionPolicies.Create(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEntityVersion(r, control.VersionedKindAdmissionPolicy, item.ID, item.Version, "created", item)
+		s.recordEvent(control.Event{
+			Type:    "policy.admission.created",
+			Message: "admission policy created",
+			Fields:  admissionPolicyFields(item),
+		}, true)
+		writeVersioned(w, http.StatusCreated, item.Version, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAdmissionPolicyAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/policy/admission/policies/{id} or /v1/policy/admission/policies/{id}/versions[/{version}]
+	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "policy" || parts[2] != "admission" || parts[3] != "policies" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := parts[4]
+	if len(parts) > 5 {
+		if parts[5] != "versions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.handleEntityVersions(w, r, control.VersionedKindAdmissionPolicy, id, parts[6:])
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := s.admissionPolicies.Get(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "admission policy not found"})
+			return
+		}
+		writeVersioned(w, http.StatusOK, item.Version, item)
+	case http.MethodPut:
+		expected, ok := ifMatchVersion(w, r, true)
+		if !ok {
+			return
+		}
+		var req control.AdmissionPolicyInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if _, ok := s.admissionPolicies.Get(id); !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "admission policy not found"})
+			return
+		}
+		item, err := s.admissionPolicies.Update(id, req, expected)
+		if err != nil {
+			writeEntityUpdateError(w, err)
+			return
+		}
+		s.recordEntityVersion(r, control.VersionedKindAdmissionPolicy, item.ID, item.Version, "updated", item)
+		s.recordEvent(control.Event{
+			Type:    "policy.admission.updated",
+			Message: "admission policy updated",
+			Fields:  admissionPolicyFields(item),
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		item, _ := s.admissionPolicies.Get(id)
+		if !s.admissionPolicies.Delete(id) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "admission policy not found"})
+			return
+		}
+		s.recordEntityVersion(r, control.VersionedKindAdmissionPolicy, item.ID, item.Version, "deleted", item)
+		s.recordEvent(control.Event{
+			Type:    "policy.admission.deleted",
+			Message: "admission policy deleted",
+			Fields:  map[string]any{"policy_id": id},
+		}, true)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAdmissionPolicyTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.AdmissionPolicyInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	report, err := s.admissionPolicies.Test(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleAdmissionEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.AdmissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	req.Method = strings.ToUpper(strings.TrimSpace(req.Method))
+	if req.Method == "" || strings.TrimSpace(req.Path) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "method and path are required"})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.admissionPolicies.Evaluate(req))
+}
+
+// admitRequest evaluates admission policies against mutating requests
+// before they reach their handler. Every decision made by at least one
+// policy is recorded as a policy.admission.* event for the audit timeline;
+// dry-run policies only ever log. The admission API itself is exempt so a
+// broken policy can always be fixed.
+func (s *Server) admitRequest(w http.ResponseWriter, r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/v1/policy/admission/") || !s.admissionPolicies.Applies(r.Method, r.URL.Path) {
+		return true
+	}
+	var body any
+	if r.Body != nil && r.Body != http.NoBody {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeBodyTooLarge(w, tooLarge.Limit)
+				return false
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+			return false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		if len(bytes.TrimSpace(raw)) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			if err := dec.Decode(&body); err != nil {
+				body = nil
+			}
+		}
+	}
+	principal, tenant := requestIdentity(r)
+	req := control.AdmissionRequest{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Principal: principal,
+		Tenant:    tenant,
+		Query:     map[string]string{},
+		Headers:   map[string]string{},
+		Body:      body,
+	}
+	for k, v := range r.URL.Query() {
+		req.Query[k] = strings.Join(v, ",")
+	}
+	for k, v := range r.Header {
+		switch strings.ToLower(k) {
+		case "authorization", "proxy-authorization", "cookie", "x-jit-grant", "x-masterchef-csrf":
+			continue
+		}
+		req.Headers[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+
+	decision := s.admissionPolicies.Evaluate(req)
+	if len(decision.Results) == 0 {
+		return true
+	}
+	fields := map[string]any{
+		"method":    r.Method,
+		"path":      r.URL.Path,
+		"principal": principal,
+		"tenant":    tenant,
+		"results":   decision.Results,
+	}
+	if dryRun := decision.DryRunDenials(); len(dryRun) > 0 {
+		names := make([]string, 0, len(dryRun))
+		for _, res := range dryRun {
+			names = append(names, res.PolicyName)
+		}
+		s.recordEvent(control.Event{
+			Type:    "policy.admission.dry_run_denied",
+			Message: "dry-run admission policy would deny request",
+			Fields: map[string]any{
+				"method":   r.Method,
+				"path":     r.URL.Path,
+				"policies": names,
+			},
+		}, true)
+	}
+	if !decision.Allowed {
+		fields["reason"] = decision.Message
+		s.recordEvent(control.Event{
+			Type:    "policy.admission.denied",
+			Message: "request denied by admission policy",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusForbidden, map[string]any{
+			"error":     decision.Message,
+			"admission": decision,
+		})
+		return false
+	}
+	s.recordEvent(control.Event{
+		Type:    "policy.admission.allowed",
+		Message: "request admitted by admission policies",
+		Fields:  fields,
+	}, true)
+	return true
+}
+
+func admissionPolicyFields(item control.AdmissionPolicy) map[string]any {
+	return map[string]any{
+		"policy_id":      item.ID,
+		"name":           item.Name,
+		"language":       item.Language,
+		"dry_run":        item.DryRun,
+		"failure_policy": item.FailurePolicy,
+		"tests":          len(item.Tests),
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAdmissionPolicyEnforcement(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "marker.txt")+`
+    content: "ok"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	policy := []byte(`{
+		"name":"prod-jobs-need-change-record",
+		"methods":["POST"],
+		"paths":["/v1/jobs"],
+		"match":"has(request.body.environment) && request.body.environment == 'prod'",
+		"expression":"has(request.body.change_record) && request.body.priority != 'low'",
+		"message":"prod jobs require change_record and priority other than low",
+		"tests":[
+			{"name":"missing change record","expect":"deny","request":{"path":"/v1/jobs","body":{"environment":"prod","priority":"high"}}},
+			{"name":"approved","expect":"allow","request":{"path":"/v1/jobs","body":{"environment":"prod","priority":"high","change_record":"CHG-7"}}}
+		]
+	}`)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/policy/admission/policies", bytes.NewReader(policy))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create admission policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/jobs", strings.NewReader(`{"config_path":"`+cfg+`","environment":"prod","priority":"low","change_record":"CHG-7"}`))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "require change_record") {
+		t.Fatalf("expected admission denial: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/jobs", strings.NewReader(`{"config_path":"`+cfg+`","environment":"prod","priority":"high","change_record":"CHG-7"}`))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected admitted job to reach handler: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	update := bytes.Replace(policy, []byte(`"methods"`), []byte(`"dry_run":true,"methods"`), 1)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/v1/policy/admission/policies/"+created.ID, bytes.NewReader(update))
+	req.Header.Set("If-Match", `"v1"`)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("switch policy to dry-run failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/policy/admission/policies/"+created.ID+"/versions", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"action":"updated"`) || !strings.Contains(rr.Body.String(), `"count":2`) {
+		t.Fatalf("admission policy history failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/jobs", strings.NewReader(`{"config_path":"`+cfg+`","environment":"prod","priority":"low"}`))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected dry-run policy to admit job: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/activity/audit-timeline?category=resource", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("audit timeline failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, typ := range []string{"policy.admission.denied", "policy.admission.allowed", "policy.admission.dry_run_denied"} {
+		if !strings.Contains(rr.Body.String(), `"type":"`+typ+`"`) {
+			t.Fatalf("expected %s in audit timeline: %s", typ, rr.Body.String())
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/policy/admission/evaluate", strings.NewReader(`{"method":"POST","path":"/v1/jobs","body":{"environment":"prod"}}`))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"dry_run":true`) {
+		t.Fatalf("evaluate admission request failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	failing := bytes.Replace(policy, []byte(`"expect":"allow"`), []byte(`"expect":"deny"`), 1)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/policy/admission/test", bytes.NewReader(failing))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"passed":false`) {
+		t.Fatalf("expected failing fixture report: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/v1/policy/admission/policies/"+created.ID, nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("delete admission policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
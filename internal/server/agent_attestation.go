@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -22,9 +23,11 @@ func (s *Server) handleAgentAttestationPolicy(w http.ResponseWriter, r *http.Req
 			Type:    "agents.attestation.policy.updated",
 			Message: "agent attestation policy updated",
 			Fields: map[string]any{
-				"require_before_cert": item.RequireBeforeCert,
-				"allowed_providers":   item.AllowedProviders,
-				"max_age_minutes":     item.MaxAgeMinutes,
+				"require_before_cert":       item.RequireBeforeCert,
+				"require_verified_evidence": item.RequireVerifiedEvidence,
+				"require_for_dispatch":      item.RequireForDispatch,
+				"allowed_providers":         item.AllowedProviders,
+				"max_age_minutes":           item.MaxAgeMinutes,
 			},
 		}, true)
 		writeJSON(w, http.StatusOK, item)
@@ -48,16 +51,7 @@ func (s *Server) handleAgentAttestations(w http.ResponseWriter, r *http.Request)
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		s.recordEvent(control.Event{
-			Type:    "agents.attestation.submitted",
-			Message: "agent attestation evidence submitted",
-			Fields: map[string]any{
-				"attestation_id": item.ID,
-				"agent_id":       item.AgentID,
-				"provider":       item.Provider,
-				"verified":       item.Verified,
-			},
-		}, true)
+		s.recordAgentAttestation(item)
 		writeJSON(w, http.StatusCreated, item)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -102,3 +96,97 @@ func (s *Server) handleAgentAttestationCheck(w http.ResponseWriter, r *http.Requ
 	}
 	writeJSON(w, code, result)
 }
+
+func (s *Server) recordAgentAttestation(item control.AgentAttestationEvidence) {
+	fields := map[string]any{
+		"attestation_id": item.ID,
+		"agent_id":       item.AgentID,
+		"provider":       item.Provider,
+		"method":         item.Method,
+		"verified":       item.Verified,
+	}
+	if item.Reason != "" {
+		fields["reason"] = item.Reason
+	}
+	s.recordEvent(control.Event{
+		Type:    "agents.attestation.submitted",
+		Message: "agent attestation evidence submitted",
+		Fields:  fields,
+	}, true)
+	if !item.Verified {
+		s.recordEvent(control.Event{
+			Type:    "agents.attestation.failed",
+			Message: "agent attestation verification failed",
+			Fields:  fields,
+		}, true)
+	}
+}
+
+func (s *Server) handleAgentAttestationKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.agentAttestation.ListKeys())
+	case http.MethodPost:
+		var req control.AgentAttestationKeyInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.agentAttestation.RegisterKey(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "agents.attestation.key.registered",
+			Message: "agent attestation key registered",
+			Fields: map[string]any{
+				"key_id":      item.ID,
+				"agent_id":    item.AgentID,
+				"provider":    item.Provider,
+				"fingerprint": item.Fingerprint,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAgentAttestationChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		AgentID string `json:"agent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	item, err := s.agentAttestation.IssueChallenge(req.AgentID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (s *Server) handleAgentAttestationStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	agentID := strings.TrimSpace(r.URL.Query().Get("agent_id"))
+	if agentID == "" {
+		writeJSON(w, http.StatusOK, s.agentAttestation.Statuses())
+		return
+	}
+	item, ok := s.agentAttestation.Status(agentID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no attestation recorded for agent"})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
@@ -3,12 +3,20 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestAgentAttestationEndpointsAndCSRGuard(t *testing.T) {
@@ -176,3 +184,100 @@ resources:
 		t.Fatalf("approve after attestation failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestAgentAttestationCheckinGatesDispatch(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: node-a
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: node-a
+    path: `+filepath.Join(tmp, "marker.txt")+`
+    content: "ok"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if rr := do(http.MethodPost, "/v1/agents/attestation/keys", map[string]any{"provider": "aws_iid", "public_key": keyPEM}); rr.Code != http.StatusCreated {
+		t.Fatalf("register key failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/agents/attestation/policy", map[string]any{"require_for_dispatch": true, "max_age_minutes": 30}); rr.Code != http.StatusOK {
+		t.Fatalf("set policy failed: %s", rr.Body.String())
+	}
+	dispatch := map[string]any{"config_path": cfg, "host": "node-a"}
+	if rr := do(http.MethodPost, "/v1/agents/dispatch", dispatch); rr.Code != http.StatusConflict {
+		t.Fatalf("expected unattested dispatch blocked, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	checkin := func(valid bool) control.AgentCheckin {
+		rr := do(http.MethodPost, "/v1/agents/attestations/challenge", map[string]any{"agent_id": "node-a"})
+		var ch control.AgentAttestationChallenge
+		if err := json.Unmarshal(rr.Body.Bytes(), &ch); err != nil || ch.Nonce == "" {
+			t.Fatalf("challenge failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		payload := []byte(`{"agent_id":"node-a","provider":"aws_iid","nonce":"` + ch.Nonce + `"}`)
+		sig := ed25519.Sign(priv, payload)
+		if !valid {
+			sig[0] ^= 0xff
+		}
+		rr = do(http.MethodPost, "/v1/agents/checkins", map[string]any{
+			"agent_id": "node-a",
+			"attestation": map[string]any{
+				"provider": "aws_iid",
+				"nonce":    ch.Nonce,
+				"statement": map[string]string{
+					"payload":   base64.StdEncoding.EncodeToString(payload),
+					"signature": base64.StdEncoding.EncodeToString(sig),
+				},
+			},
+		})
+		var item control.AgentCheckin
+		if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("checkin failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		if item.Attestation == nil || item.AttestationChallenge == nil {
+			t.Fatalf("expected attestation result and next challenge, got %s", rr.Body.String())
+		}
+		return item
+	}
+
+	if item := checkin(true); !item.Attestation.Verified {
+		t.Fatalf("expected verified check-in attestation, got %+v", item.Attestation)
+	}
+	if rr := do(http.MethodPost, "/v1/agents/dispatch", dispatch); rr.Code != http.StatusCreated {
+		t.Fatalf("expected attested dispatch allowed, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/agents/attestation/status?agent_id=node-a", nil); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"verified"`) {
+		t.Fatalf("unexpected status: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if item := checkin(false); item.Attestation.Verified {
+		t.Fatalf("expected forged statement rejected")
+	}
+	rr := do(http.MethodPost, "/v1/agents/dispatch", dispatch)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "signature") {
+		t.Fatalf("expected failed attestation to block dispatch, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleAgentBeacons serves GET /v1/compat/beacons (?node= lists what that
+// node's agent runs) and POST to create or replace a beacon by name.
+func (s *Server) handleAgentBeacons(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.agentBeacons.List(r.URL.Query().Get("node")))
+	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req control.AgentBeaconInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.agentBeacons.Upsert(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAgentBeaconAction serves /v1/compat/beacons/{id} (GET, DELETE),
+// GET /v1/compat/beacons/states?node=, and POST /v1/compat/beacons/evaluate.
+func (s *Server) handleAgentBeaconAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "compat" || parts[2] != "beacons" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch parts[3] {
+	case "states":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		items := s.agentBeacons.States(r.URL.Query().Get("node"))
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+		return
+	case "evaluate":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req struct {
+			Node string `json:"node"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		// Evaluation probes this host, so it can only report for this host;
+		// remote agents post their observations to the beacon reactor.
+		node := localBeaconNode()
+		if requested := strings.ToLower(strings.TrimSpace(req.Node)); requested != "" && requested != node {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "evaluate only probes this host (" + node + "); remote agents post to /v1/compat/beacon-reactor/emit"})
+			return
+		}
+		emits := s.runAgentBeacons(r.Context(), node, s.agentBeacons.LocalProbe())
+		writeJSON(w, http.StatusOK, map[string]any{"node": node, "count": len(emits), "items": emits})
+		return
+	}
+	id := parts[3]
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := s.agentBeacons.Get(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "beacon not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		if err := s.agentBeacons.Delete(id); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// runAgentBeacons evaluates node's due beacons and feeds what they emit
+// through the beacon-reactor emit path, as a remote agent posting to
+// /v1/compat/beacon-reactor/emit would.
+func (s *Server) runAgentBeacons(ctx context.Context, node string, probe control.BeaconProbe) []control.AgentBeaconEmit {
+	emits := s.agentBeacons.Evaluate(ctx, node, time.Now().UTC(), probe)
+	for _, emit := range emits {
+		s.ingestBeaconEmit(emit.Beacon, emit.Message, emit.Host, emit.Fields)
+	}
+	return emits
+}
+
+// sweepAgentBeacons is the control plane's own agent loop for beacons
+// targeting this host.
+func (s *Server) sweepAgentBeacons(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	node := localBeaconNode()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runAgentBeacons(ctx, node, s.agentBeacons.LocalProbe())
+		}
+	}
+}
+
+// localBeaconNode names this host for beacon targeting: MC_AGENT_NODE, else
+// the hostname.
+func localBeaconNode() string {
+	if node := strings.TrimSpace(os.Getenv("MC_AGENT_NODE")); node != "" {
+		return strings.ToLower(node)
+	}
+	host, _ := os.Hostname()
+	return strings.ToLower(host)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgentBeaconsEmitIntoBeaconReactor(t *testing.T) {
+	t.Setenv("MC_AGENT_NODE", "web-1")
+	t.Setenv("MC_BEACON_CHECK_COMMANDS", "/usr/bin/false")
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	grantControlAdmin(t, s, "ops-admin")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "ops-admin")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	watched := filepath.Join(tmp, "app.conf")
+	if err := os.WriteFile(watched, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rr := do(http.MethodPost, "/v1/compat/beacons", `{"name":"bad","kind":"process"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown beacon kind to be rejected, got code=%d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v1/compat/beacons", `{"name":"conf","kind":"file","path":"`+watched+`","interval_seconds":1,"nodes":["web-1"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create file beacon failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	anon := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(anon, httptest.NewRequest(http.MethodPost, "/v1/compat/beacons", strings.NewReader(`{"name":"anon","kind":"file","path":"`+watched+`"}`)))
+	if anon.Code != http.StatusUnauthorized {
+		t.Fatalf("expected anonymous beacon create to be rejected, got code=%d", anon.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/compat/beacons", `{"name":"sh","kind":"service","check_command":["/bin/sh","-c","true"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unlisted check command to be rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/compat/beacons", `{"name":"web","kind":"service","service":"web","check_command":["/usr/bin/false"],"nodes":["web-1"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("create service beacon failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/compat/beacons/evaluate", `{"node":"db-1"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected evaluation for another node to be rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/compat/beacons/evaluate", `{"node":"web-1"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) || !strings.Contains(rr.Body.String(), `"beacon":"service.web"`) {
+		t.Fatalf("expected down service emitted on first evaluation: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if err := os.WriteFile(watched, []byte("v2 longer"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rr = do(http.MethodPost, "/v1/compat/beacons/evaluate", `{"node":"web-1"}`)
+	if !strings.Contains(rr.Body.String(), `"beacon":"file.conf"`) || !strings.Contains(rr.Body.String(), `"change":"modified"`) {
+		t.Fatalf("expected file modification emitted, body=%s", rr.Body.String())
+	}
+
+	found := map[string]bool{}
+	for _, e := range s.events.List() {
+		if strings.HasPrefix(e.Type, "beacon.") && e.Fields["host"] == "web-1" {
+			found[e.Type] = true
+		}
+	}
+	if !found["beacon.service.web"] || !found["beacon.file.conf"] {
+		t.Fatalf("expected beacon events ingested for reactor rules, got %+v", found)
+	}
+
+	rr = do(http.MethodGet, "/v1/compat/beacons/states?node=web-1", "")
+	var states struct {
+		Count int `json:"count"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &states)
+	if rr.Code != http.StatusOK || states.Count != 2 {
+		t.Fatalf("expected beacon states for node: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/compat/beacons?node=db-1", ""); strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Fatalf("expected no beacons for untargeted node, body=%s", rr.Body.String())
+	}
+}
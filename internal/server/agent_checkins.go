@@ -22,6 +22,21 @@ func (s *Server) handleAgentCheckins(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		if req.Attestation != nil {
+			req.Attestation.AgentID = item.AgentID
+			evidence, err := s.agentAttestation.Submit(*req.Attestation)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordAgentAttestation(evidence)
+			item.Attestation = &evidence
+		}
+		if req.Attestation != nil || s.agentAttestation.HasKey(item.AgentID) {
+			if challenge, err := s.agentAttestation.IssueChallenge(item.AgentID); err == nil {
+				item.AttestationChallenge = &challenge
+			}
+		}
 		s.recordEvent(control.Event{
 			Type:    "agent.checkin",
 			Message: "agent check-in scheduled with splay",
@@ -116,6 +116,54 @@ func (s *Server) handleAgentDispatch(baseDir string) http.HandlerFunc {
 			}
 			strategy := s.agentDispatch.EffectiveStrategy(req.Environment)
 			mode := s.agentDispatch.Mode()
+			if host := strings.TrimSpace(req.Host); host != "" {
+				if check := s.agentAttestation.CheckForDispatch(host); !check.Allowed {
+					item := s.agentDispatch.Record(mode, strategy.Strategy, req, "blocked", "")
+					s.recordEvent(control.Event{
+						Type:    "agent.dispatch.blocked",
+						Message: "agent dispatch blocked by attestation",
+						Fields: map[string]any{
+							"dispatch_id": item.ID,
+							"host":        host,
+							"config_path": item.ConfigPath,
+							"reason":      check.Reason,
+						},
+					}, true)
+					writeJSON(w, http.StatusConflict, map[string]any{
+						"error":    check.Reason,
+						"dispatch": item,
+					})
+					return
+				}
+			}
+			if window, ok := s.hostMaintenance.ActiveWindow(req.Host); ok {
+				deferred := s.hostMaintenance.Defer(control.DeferredHostWork{
+					Host:       window.Host,
+					Kind:       "dispatch",
+					Ref:        req.ConfigPath,
+					ConfigPath: req.ConfigPath,
+					Priority:   req.Priority,
+					Force:      req.Force,
+				})
+				item := s.agentDispatch.Record(mode, strategy.Strategy, req, "deferred", "")
+				s.recordEvent(control.Event{
+					Type:    "agent.dispatch.deferred",
+					Message: "agent dispatch deferred for host maintenance",
+					Fields: map[string]any{
+						"dispatch_id":           item.ID,
+						"deferred_id":           deferred.ID,
+						"host":                  window.Host,
+						"config_path":           item.ConfigPath,
+						"maintenance_window_id": window.ID,
+					},
+				}, true)
+				writeJSON(w, http.StatusAccepted, map[string]any{
+					"dispatch":           item,
+					"deferred":           deferred,
+					"maintenance_window": window,
+				})
+				return
+			}
 			switch strategy.Strategy {
 			case control.AgentDispatchStrategyPush:
 				mode = control.AgentDispatchModeLocal
@@ -163,7 +211,16 @@ func (s *Server) handleAgentDispatch(baseDir string) http.HandlerFunc {
 					},
 				}
 				_ = s.eventBus.Publish(event)
-				item := s.agentDispatch.Record(mode, strategy.Strategy, req, "dispatched", "")
+				msg, sendErr := s.agentTransports.Send(control.AgentTransportSend{
+					Environment: req.Environment,
+					Type:        "dispatch",
+					Payload:     event.Fields,
+				})
+				status := "dispatched"
+				if sendErr != nil {
+					status = "undeliverable"
+				}
+				item := s.agentDispatch.RecordTransport(mode, strategy.Strategy, req, status, msg)
 				s.recordEvent(control.Event{
 					Type:    "agent.dispatch.dispatched",
 					Message: "agent dispatch published to event bus",
@@ -172,6 +229,8 @@ func (s *Server) handleAgentDispatch(baseDir string) http.HandlerFunc {
 						"config_path": item.ConfigPath,
 						"environment": item.Environment,
 						"strategy":    item.Strategy,
+						"transport":   item.Transport,
+						"message_id":  item.MessageID,
 					},
 				}, true)
 				writeJSON(w, http.StatusCreated, item)
@@ -109,6 +109,20 @@ func (s *Server) handleAgentCSRAction(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		item, err = s.agentPKI.DecideCSR(parts[3], "approve", req.Reason)
+		if err != nil && item.Status == "failed" {
+			s.recordEvent(control.Event{
+				Type:    "agents.pki.csr.issue_failed",
+				Message: "ca backend failed to issue agent certificate",
+				Fields: map[string]any{
+					"csr_id":   item.ID,
+					"agent_id": item.AgentID,
+					"backend":  s.agentPKI.Backend().Type,
+					"error":    item.Reason,
+				},
+			}, true)
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "csr": item})
+			return
+		}
 	case "reject":
 		item, err = s.agentPKI.DecideCSR(parts[3], "reject", req.Reason)
 	default:
@@ -146,6 +160,20 @@ func (s *Server) handleAgentCertificateAction(w http.ResponseWriter, r *http.Req
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	s.recordEvent(control.Event{
+		Type:    "agents.pki.certificate.revoked",
+		Message: "agent certificate revoked",
+		Fields: map[string]any{
+			"cert_id":  item.ID,
+			"agent_id": item.AgentID,
+			"serial":   item.Serial,
+			"backend":  item.Backend,
+		},
+	}, true)
+	if _, err := s.publishAgentCRL("revocation"); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "certificate revoked but crl publication failed: " + err.Error()})
+		return
+	}
 	writeJSON(w, http.StatusOK, item)
 }
 
@@ -211,3 +239,34 @@ func (s *Server) handleAgentCertificateRenewExpiring(w http.ResponseWriter, r *h
 	}, true)
 	writeJSON(w, http.StatusOK, result)
 }
+
+func (s *Server) handleAgentCABackend(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.agentPKI.Backend())
+	case http.MethodPost:
+		var req control.AgentCABackendConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.agentPKI.SetBackend(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		fields := map[string]any{"type": item.Type}
+		if item.EST != nil {
+			fields["url"] = item.EST.URL
+			fields["label"] = item.EST.Label
+		}
+		s.recordEvent(control.Event{
+			Type:    "agents.pki.ca_backend.updated",
+			Message: "agent ca backend updated",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
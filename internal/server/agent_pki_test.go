@@ -3,12 +3,22 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestAgentPKIEndpoints(t *testing.T) {
@@ -103,3 +113,162 @@ resources:
 		t.Fatalf("renew expiring failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestAgentCABackendAndACMEChallengeEndpoints(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	est := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer est.Close()
+
+	if rr := do(http.MethodPost, "/v1/agents/ca-backend", `{"type":"scep"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown backend rejected, got %d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v1/agents/ca-backend", `{"type":"est","est":{"url":"`+est.URL+`","username":"u","password":"p"}}`)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"password":"p"`) {
+		t.Fatalf("set backend failed or leaked password: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "agent-9"}}, key)
+	csrPEM, _ := json.Marshal(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})))
+	rr = do(http.MethodPost, "/v1/agents/csrs", `{"agent_id":"agent-9","csr_pem":`+string(csrPEM)+`}`)
+	var csr control.AgentCSR
+	if err := json.Unmarshal(rr.Body.Bytes(), &csr); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("submit csr failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/agents/csrs/"+csr.ID+"/approve", `{}`)
+	if rr.Code != http.StatusBadGateway || !strings.Contains(rr.Body.String(), "pending manual approval") || !strings.Contains(rr.Body.String(), `"status":"failed"`) {
+		t.Fatalf("expected pending est enrollment surfaced, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/agents/ca-backend", `{"type":"internal"}`); rr.Code != http.StatusOK {
+		t.Fatalf("reset backend failed: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/agents/csrs/"+csr.ID+"/approve", `{}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"issued"`) {
+		t.Fatalf("expected failed csr to be retried, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/server-certificates", `{"domains":["cp.example.test"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected request without acme config rejected, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/server-certificates/acme", `{"directory_url":"not a url"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid directory rejected, got %d", rr.Code)
+	}
+	s.acmeChallenges.Present("tok-1", "tok-1.thumb")
+	if rr := do(http.MethodGet, "/.well-known/acme-challenge/tok-1", ""); rr.Code != http.StatusOK || rr.Body.String() != "tok-1.thumb" {
+		t.Fatalf("expected challenge served, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/.well-known/acme-challenge/unknown", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown challenge 404, got %d", rr.Code)
+	}
+}
+
+func TestAgentCRLPublicationAndRevocationEnforcement(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := do(http.MethodPost, "/v1/agents/pki/revocation", `{"distribution_url":"https://cp.example.test/v1/agents/pki/crl"}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"validity_hours":24`) {
+		t.Fatalf("set revocation config failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "agent-7"}}, key)
+	csrPEM, _ := json.Marshal(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})))
+	rr := do(http.MethodPost, "/v1/agents/csrs", `{"agent_id":"agent-7","csr_pem":`+string(csrPEM)+`}`)
+	var csr control.AgentCSR
+	_ = json.Unmarshal(rr.Body.Bytes(), &csr)
+	rr = do(http.MethodPost, "/v1/agents/csrs/"+csr.ID+"/approve", `{}`)
+	_ = json.Unmarshal(rr.Body.Bytes(), &csr)
+	if csr.Status != "issued" {
+		t.Fatalf("approve failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var cert control.AgentCertificate
+	for _, item := range s.agentPKI.ListCertificates() {
+		if item.ID == csr.CertID {
+			cert = item
+		}
+	}
+	block, _ := pem.Decode([]byte(cert.CertificatePEM))
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || len(leaf.CRLDistributionPoints) != 1 {
+		t.Fatalf("expected issued cert to reference the crl, err=%v", err)
+	}
+
+	if rr := do(http.MethodPost, "/v1/security/mtls/authorities", `{"name":"agents","ca_bundle":"x"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create authority failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	authority := s.mtls.ListAuthorities()[0]
+	if rr := do(http.MethodPost, "/v1/security/mtls/policies", `{"component":"relay","min_tls_version":"1.2","require_client_cert":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("set policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/execution/relays/endpoints", `{"name":"hop","kind":"hop","url":"relay:443"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create relay endpoint failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	endpoint := s.hopRelay.ListEndpoints()[0]
+	session := `{"endpoint_id":"` + endpoint.ID + `","node_id":"agent-7","target_host":"db:5432","client_cert_serial":"` + cert.Serial + `"}`
+	if rr := do(http.MethodPost, "/v1/execution/relays/sessions", session); rr.Code != http.StatusCreated {
+		t.Fatalf("open relay session failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/agents/certificates/"+cert.ID+"/revoke", `{}`); rr.Code != http.StatusOK {
+		t.Fatalf("revoke failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/agents/pki/crl", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/pkix-crl" {
+		t.Fatalf("fetch crl failed: code=%d", rr.Code)
+	}
+	list, err := x509.ParseRevocationList(rr.Body.Bytes())
+	if err != nil || len(list.RevokedCertificateEntries) != 1 || list.RevokedCertificateEntries[0].SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		t.Fatalf("expected revoked cert in crl, err=%v", err)
+	}
+	caPEM := do(http.MethodGet, "/v1/agents/pki/ca", "").Body.Bytes()
+	caBlock, _ := pem.Decode(caPEM)
+	caCert, _ := x509.ParseCertificate(caBlock.Bytes)
+	if err := list.CheckSignatureFrom(caCert); err != nil {
+		t.Fatalf("crl not signed by published ca: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.baseDir, ".masterchef", "pki", "agents.crl")); err != nil {
+		t.Fatalf("expected crl written to workspace: %v", err)
+	}
+	if rr := do(http.MethodGet, "/v1/agents/pki/status/"+cert.Serial, ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"revoked"`) {
+		t.Fatalf("expected revoked status, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/agents/pki/status/ABCDEF", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown serial 404, got %d", rr.Code)
+	}
+	if got := s.hopRelay.ListSessions(10); len(got) != 1 || got[0].Status != "revoked" {
+		t.Fatalf("expected relay session terminated on revocation, got %+v", got)
+	}
+
+	check := `{"component":"relay","authority_id":"` + authority.ID + `","tls_version":"1.3","client_cert":true,"client_cert_serial":"` + cert.Serial + `"}`
+	if rr := do(http.MethodPost, "/v1/security/mtls/handshake-check", check); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "revoked") {
+		t.Fatalf("expected revoked handshake refused, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/execution/relays/sessions", session); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected relay to refuse revoked cert, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/agents/certificates", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected mtls request with revoked cert refused, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// publishAgentCRL re-signs the agent CRL, writes it under the workspace for
+// fronting proxies to load, and drops relay connections whose certificates
+// are now revoked.
+func (s *Server) publishAgentCRL(trigger string) (control.AgentCRL, error) {
+	crl, err := s.agentPKI.PublishCRL()
+	if err != nil {
+		return control.AgentCRL{}, err
+	}
+	if _, der, ok := s.agentPKI.CRL(); ok {
+		dir := filepath.Join(s.baseDir, ".masterchef", "pki")
+		if err := os.MkdirAll(dir, 0o755); err == nil {
+			_ = os.WriteFile(filepath.Join(dir, "agents.crl"), der, 0o644)
+		}
+	}
+	sessions := s.hopRelay.TerminateRevokedSessions()
+	sites := s.edgeRelay.DisconnectRevoked()
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, item := range sessions {
+		sessionIDs = append(sessionIDs, item.ID)
+	}
+	s.recordEvent(control.Event{
+		Type:    "agents.pki.crl.published",
+		Message: "agent certificate revocation list published",
+		Fields: map[string]any{
+			"number":                  crl.Number,
+			"revoked_count":           crl.RevokedCount,
+			"next_update":             crl.NextUpdate,
+			"trigger":                 trigger,
+			"relay_sessions_revoked":  sessionIDs,
+			"edge_sites_disconnected": sites,
+		},
+	}, true)
+	return crl, nil
+}
+
+func (s *Server) sweepAgentCRL(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.publishAgentCRL("schedule")
+		}
+	}
+}
+
+// rejectRevokedClientCert refuses requests made over mTLS with a revoked
+// agent certificate.
+func (s *Server) rejectRevokedClientCert(w http.ResponseWriter, r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return true
+	}
+	serial := strings.ToUpper(r.TLS.PeerCertificates[0].SerialNumber.Text(16))
+	if !s.agentPKI.IsRevoked(serial) {
+		return true
+	}
+	writeJSON(w, http.StatusForbidden, map[string]string{"error": "client certificate revoked"})
+	return false
+}
+
+func (s *Server) handleAgentCRL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	crl, der, ok := s.agentPKI.CRL()
+	if !ok {
+		var err error
+		if crl, err = s.publishAgentCRL("request"); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		_, der, _ = s.agentPKI.CRL()
+	}
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))) {
+	case "json":
+		writeJSON(w, http.StatusOK, crl)
+	case "pem":
+		w.Header().Set("Content-Type", "application/x-pem-file")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(crl.CRLPEM))
+	default:
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(der)
+	}
+}
+
+func (s *Server) handleAgentCRLPublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	crl, err := s.publishAgentCRL("manual")
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, crl)
+}
+
+func (s *Server) handleAgentCACertificate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	certPEM, err := s.agentPKI.CACertificatePEM()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(certPEM))
+}
+
+func (s *Server) handleAgentRevocationConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.agentPKI.RevocationConfig())
+	case http.MethodPost:
+		var req control.AgentRevocationConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.agentPKI.SetRevocationConfig(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "agents.pki.revocation.config",
+			Message: "agent crl publication updated",
+			Fields: map[string]any{
+				"distribution_url": item.DistributionURL,
+				"validity_hours":   item.ValidityHours,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAgentRevocationStatus(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/agents/pki/status/{serial}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "agents" || parts[2] != "pki" || parts[3] != "status" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item := s.agentPKI.CheckRevocation(parts[4])
+	if item.Status == "unknown" {
+		writeJSON(w, http.StatusNotFound, item)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
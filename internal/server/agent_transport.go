@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleAgentTransports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"metrics":  s.agentTransports.Metrics(),
+		"policies": s.agentTransports.ListPolicies(),
+	})
+}
+
+func (s *Server) handleAgentTransportPolicies(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Environment string   `json:"environment"`
+		Transports  []string `json:"transports"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if env := strings.TrimSpace(r.URL.Query().Get("environment")); env != "" {
+			writeJSON(w, http.StatusOK, s.agentTransports.Policy(env))
+			return
+		}
+		writeJSON(w, http.StatusOK, s.agentTransports.ListPolicies())
+	case http.MethodPost:
+		var req reqBody
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.agentTransports.SetPolicy(req.Environment, req.Transports)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "agent.transport.policy",
+			Message: "agent transport policy set for environment",
+			Fields: map[string]any{
+				"environment": item.Environment,
+				"transports":  item.Transports,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAgentTransportMessages(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.agentTransports.List(parseIntQuery(r, "limit", 100)))
+	case http.MethodPost:
+		var req control.AgentTransportSend
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		msg, err := s.agentTransports.Send(req)
+		if err != nil {
+			if msg.ID == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error(), "message": msg})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, msg)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAgentTransportMessageAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/agents/transports/messages/{id}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "agents" || parts[2] != "transports" || parts[3] != "messages" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	msg, ok := s.agentTransports.Get(parts[4])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "message not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, msg)
+}
+
+// handleAgentTransportPoll is the HTTPS long-poll transport: it holds the
+// request until a message arrives or wait_seconds (max 60) passes.
+func (s *Server) handleAgentTransportPoll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	wait := parseIntQuery(r, "wait_seconds", 30)
+	if wait > 60 {
+		wait = 60
+	}
+	q := r.URL.Query()
+	msgs, err := s.agentTransports.Poll(r.Context(), q.Get("agent_id"), q.Get("environment"), time.Duration(wait)*time.Second)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"messages": msgs})
+}
+
+func (s *Server) handleAgentTransportAck(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		AgentID   string `json:"agent_id"`
+		MessageID string `json:"message_id"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req reqBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	msg, err := s.agentTransports.Ack(req.AgentID, req.MessageID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, msg)
+}
+
+// handleAgentTransportWebSocket upgrades the request to a websocket that
+// streams messages to the agent and reads its acks.
+func (s *Server) handleAgentTransportWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "websocket upgrade required"})
+		return
+	}
+	q := r.URL.Query()
+	sess, err := s.agentTransports.Connect(q.Get("agent_id"), q.Get("environment"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		sess.Close()
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "connection does not support websocket upgrade"})
+		return
+	}
+	_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + control.WebSocketAccept(key) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		sess.Close()
+		conn.Close()
+		return
+	}
+	s.serveAgentWebSocket(conn, brw.Reader, sess)
+}
+
+func (s *Server) serveAgentWebSocket(conn net.Conn, br *bufio.Reader, sess *control.AgentTransportSession) {
+	defer func() {
+		sess.Close()
+		// Messages buffered for the session go back to the mailbox.
+		for msg := range sess.Messages() {
+			s.agentTransports.Requeue(sess, msg.ID)
+		}
+		conn.Close()
+	}()
+	var writeMu sync.Mutex
+	write := func(opcode byte, payload []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return control.WriteWebSocketFrame(conn, opcode, payload, false)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			// Agents answer the 30s pings, so a silent connection is dead.
+			_ = conn.SetReadDeadline(time.Now().Add(90 * time.Second))
+			opcode, payload, err := control.ReadWebSocketFrame(br)
+			if err != nil {
+				return
+			}
+			switch opcode {
+			case control.WebSocketOpText:
+				var ack struct {
+					Ack string `json:"ack"`
+				}
+				if json.Unmarshal(payload, &ack) == nil && ack.Ack != "" {
+					_, _ = s.agentTransports.Ack(sess.AgentID, ack.Ack)
+				}
+			case control.WebSocketOpPing:
+				_ = write(control.WebSocketOpPong, payload)
+			case control.WebSocketOpClose:
+				_ = write(control.WebSocketOpClose, nil)
+				return
+			}
+		}
+	}()
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-sess.Messages():
+			if !ok {
+				_ = write(control.WebSocketOpClose, nil)
+				return
+			}
+			payload, _ := json.Marshal(msg)
+			if err := write(control.WebSocketOpText, payload); err != nil {
+				s.agentTransports.Requeue(sess, msg.ID)
+				return
+			}
+		case <-ticker.C:
+			if err := write(control.WebSocketOpPing, nil); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
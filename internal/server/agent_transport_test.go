@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestAgentTransportWebSocketDispatch(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	srv := httptest.NewServer(s.httpServer.Handler)
+	defer srv.Close()
+
+	client := control.NewAgentTransportClient(srv.URL, "agent-1", "prod")
+	received := make(chan control.AgentTransportMessage, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = client.Run(ctx, func(m control.AgentTransportMessage) error {
+			received <- m
+			return nil
+		})
+	}()
+	deadline := time.Now().Add(3 * time.Second)
+	for client.Active() != control.AgentTransportWebSocket {
+		if time.Now().After(deadline) {
+			t.Fatalf("websocket transport did not connect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/dispatch-environments", strings.NewReader(`{"environment":"prod","strategy":"pull"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set dispatch strategy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/dispatch", strings.NewReader(`{"config_path":"site.yaml","environment":"prod"}`)))
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"transport":"websocket"`) {
+		t.Fatalf("pull dispatch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var record struct {
+		MessageID string `json:"message_id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &record)
+
+	select {
+	case m := <-received:
+		if m.ID != record.MessageID || m.Type != "dispatch" || m.Payload["config_path"] != "site.yaml" {
+			t.Fatalf("unexpected dispatch message %+v", m)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("agent did not receive dispatch over websocket")
+	}
+	for {
+		rr = httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/agents/transports/messages/"+record.MessageID, nil))
+		if strings.Contains(rr.Body.String(), `"status":"acked"`) {
+			break
+		}
+		if time.Now().After(deadline.Add(3 * time.Second)) {
+			t.Fatalf("dispatch message was not acked: %s", rr.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/agents/transports", nil))
+	var overview struct {
+		Metrics []control.AgentTransportMetrics `json:"metrics"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &overview)
+	for _, m := range overview.Metrics {
+		if m.Transport == control.AgentTransportWebSocket && (m.Connections != 1 || m.Acked != 1) {
+			t.Fatalf("unexpected websocket metrics %+v", m)
+		}
+	}
+}
+
+func TestAgentTransportLongPollEndpoints(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/transports/policies", strings.NewReader(`{"environment":"edge","transports":["websocket"]}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set transport policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/transports/messages", strings.NewReader(`{"agent_id":"agent-9","environment":"edge","type":"run"}`)))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected undeliverable message conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/transports/messages", strings.NewReader(`{"agent_id":"agent-2","type":"run","payload":{"x":1}}`)))
+	if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"transport":"long_poll"`) {
+		t.Fatalf("send message failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var msg control.AgentTransportMessage
+	_ = json.Unmarshal(rr.Body.Bytes(), &msg)
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/agents/transports/poll?agent_id=agent-2&wait_seconds=1", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), msg.ID) {
+		t.Fatalf("poll failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/transports/ack", bytes.NewReader([]byte(`{"agent_id":"agent-2","message_id":"`+msg.ID+`"}`))))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"acked"`) {
+		t.Fatalf("ack failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/agents/transports/ws?agent_id=agent-2", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected plain request to websocket endpoint to be rejected: code=%d", rr.Code)
+	}
+}
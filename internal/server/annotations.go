@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleAnnotations serves /v1/{runs|jobs|alerts}/{id}/annotations[/{annotation_id}]
+// once the caller has resolved the target. Authors come from the request
+// principal, falling back to the body's author.
+func (s *Server) handleAnnotations(w http.ResponseWriter, r *http.Request, kind, targetID string, rest []string) {
+	if len(rest) > 1 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown annotation path"})
+		return
+	}
+	if len(rest) == 1 {
+		item, err := s.annotations.Get(rest[0])
+		if err != nil || item.TargetKind != kind || item.TargetID != targetID {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "annotation not found"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, item)
+		case http.MethodDelete:
+			principal, _ := requestIdentity(r)
+			if principal == "" || principal != item.Author {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "only the author may delete an annotation"})
+				return
+			}
+			if err := s.annotations.Delete(item.ID); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			s.events.Append(control.Event{
+				Type:    "annotation.deleted",
+				Message: "annotation deleted",
+				Fields: map[string]any{
+					"annotation_id": item.ID,
+					"target_kind":   kind,
+					"target_id":     targetID,
+					"author":        principal,
+				},
+			})
+			writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		items := s.annotations.List(kind, targetID)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"target_kind": kind,
+			"target_id":   targetID,
+			"count":       len(items),
+			"items":       items,
+		})
+	case http.MethodPost:
+		var req control.AnnotationInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if principal, _ := requestIdentity(r); principal != "" {
+			req.Author = principal
+		}
+		req.TargetKind = kind
+		req.TargetID = targetID
+		item, err := s.annotations.Add(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.events.Append(control.Event{
+			Type:    "annotation.created",
+			Message: "annotation added to " + kind + " " + targetID,
+			Fields: map[string]any{
+				"annotation_id": item.ID,
+				"target_kind":   kind,
+				"target_id":     targetID,
+				"author":        item.Author,
+			},
+		})
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAlertAction serves /v1/alerts/{id} and /v1/alerts/{id}/annotations.
+func (s *Server) handleAlertAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 3 || strings.TrimSpace(parts[2]) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid alert path"})
+		return
+	}
+	item, err := s.alerts.Get(parts[2])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(parts) == 3 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
+	if parts[3] != "annotations" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown alert action"})
+		return
+	}
+	s.handleAnnotations(w, r, control.AnnotationTargetAlert, item.ID, parts[4:])
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestAnnotationsOnRunsJobsAndAlerts(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	if err := state.New(tmp).SaveRun(state.RunRecord{ID: "run-1", Status: state.RunFailed, StartedAt: now.Add(-time.Minute), EndedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/runs/run-1/annotations", "alice", `{"body":"**root cause**: disk full on db-1","links":[{"label":"graph","url":"https://grafana.example.com/d/disk"}]}`)
+	var note control.Annotation
+	if err := json.Unmarshal(rr.Body.Bytes(), &note); err != nil || rr.Code != http.StatusCreated || note.Author != "alice" || note.TargetKind != "run" {
+		t.Fatalf("annotate run failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/runs/run-1/annotations", "", `{"body":"anonymous"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected annotation without author to be rejected: code=%d", rr.Code)
+	}
+	if rr = do(http.MethodPost, "/v1/runs/run-missing/annotations", "alice", `{"body":"x"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown run to 404: code=%d", rr.Code)
+	}
+
+	alert := s.alerts.Ingest(control.AlertIngest{Fingerprint: "db-disk", EventType: "host.disk", Message: "disk full", Severity: "high", Fields: map[string]any{"run_id": "run-1"}})
+	if rr = do(http.MethodPost, "/v1/alerts/"+alert.Item.ID+"/annotations", "bob", `{"body":"paged the db team"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("annotate alert failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v1/alerts/"+alert.Item.ID+"/annotations", "", ""); !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("unexpected alert annotations: %s", rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/jobs", "", `{"config_path":"c.yaml"}`)
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil || job.ID == "" {
+		t.Fatalf("create job failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/jobs/"+job.ID+"/annotations", "carol", `{"body":"retrying after fix"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("annotate job failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v1/jobs/"+job.ID+"/annotations", "", ""); !strings.Contains(rr.Body.String(), "retrying after fix") {
+		t.Fatalf("unexpected job annotations: %s", rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/jobs/job-missing/annotations", "carol", `{"body":"x"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown job to 404: code=%d", rr.Code)
+	}
+
+	rr = do(http.MethodGet, "/v1/runs/run-1/timeline", "", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"source":"annotation"`) || !strings.Contains(rr.Body.String(), "disk full on db-1") {
+		t.Fatalf("expected annotation on run timeline: %s", rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), `"type":"annotation.created"`) {
+		t.Fatalf("expected annotation events not to be duplicated on the timeline: %s", rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/incidents/view?run_id=run-1", "", "")
+	var view struct {
+		Annotations []control.Annotation `json:"annotations"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &view); err != nil || len(view.Annotations) != 2 {
+		t.Fatalf("expected run and alert annotations in incident view: %s", rr.Body.String())
+	}
+
+	if rr = do(http.MethodDelete, "/v1/runs/run-1/annotations/"+note.ID, "bob", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected non-author delete to be forbidden: code=%d", rr.Code)
+	}
+	if rr = do(http.MethodDelete, "/v1/runs/run-1/annotations/"+note.ID, "alice", ""); rr.Code != http.StatusOK {
+		t.Fatalf("author delete failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
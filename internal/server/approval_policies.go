@@ -0,0 +1,135 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// approverIdentity resolves an actor's roles from their RBAC bindings and
+// SCIM team roles, and their teams from SCIM team membership. Roles match
+// by id or name and teams by name or external id.
+func (s *Server) approverIdentity(actor string) control.ApproverIdentity {
+	var identity control.ApproverIdentity
+	for _, binding := range s.rbac.ListBindings() {
+		if binding.Subject != actor {
+			continue
+		}
+		identity.Roles = append(identity.Roles, binding.RoleID)
+		if role, ok := s.rbac.GetRole(binding.RoleID); ok {
+			identity.Roles = append(identity.Roles, role.Name)
+		}
+	}
+	for _, team := range s.scim.ListTeams() {
+		for _, member := range team.Members {
+			if strings.EqualFold(member, actor) {
+				identity.Teams = append(identity.Teams, team.Name, team.ExternalID)
+				identity.Roles = append(identity.Roles, team.Roles...)
+				break
+			}
+		}
+	}
+	return identity
+}
+
+func (s *Server) changeRecordApprovalGate(rec control.ChangeRecord, actor string) (bool, error) {
+	subject := changeRecordApprovalSubject(rec)
+	if actor != "" {
+		if err := s.accessApprovals.CheckApprover(subject, actor); err != nil {
+			return false, err
+		}
+	}
+	return s.evaluateChangeRecordApprovals(rec).Satisfied, nil
+}
+
+func (s *Server) evaluateChangeRecordApprovals(rec control.ChangeRecord) control.ApprovalEvaluation {
+	votes := make([]control.ApprovalVote, 0, len(rec.Approvals))
+	for _, a := range rec.Approvals {
+		votes = append(votes, control.ApprovalVote{Actor: a.Actor, Decision: a.Decision, CreatedAt: a.CreatedAt})
+	}
+	return s.accessApprovals.EvaluateApprovals(changeRecordApprovalSubject(rec), votes, time.Now().UTC())
+}
+
+func changeRecordApprovalSubject(rec control.ChangeRecord) control.ApprovalSubject {
+	return control.ApprovalSubject{
+		Kind:        control.ApprovalSubjectChangeRecord,
+		ID:          rec.ID,
+		Scope:       rec.ConfigPath,
+		RequestedBy: rec.RequestedBy,
+	}
+}
+
+func (s *Server) promotionApprovalGate(p control.GitOpsPromotion, stage, actor string) (bool, error) {
+	if actor != "" {
+		if err := s.accessApprovals.CheckApprover(promotionApprovalSubject(p, stage), actor); err != nil {
+			return false, err
+		}
+	}
+	return s.evaluatePromotionApprovals(p, stage).Satisfied, nil
+}
+
+// evaluatePromotionApprovals checks the approvals given for promoting p into
+// stage; approvals for other stages do not carry over.
+func (s *Server) evaluatePromotionApprovals(p control.GitOpsPromotion, stage string) control.ApprovalEvaluation {
+	votes := make([]control.ApprovalVote, 0, len(p.Approvals))
+	for _, a := range p.Approvals {
+		if a.Stage == stage {
+			votes = append(votes, control.ApprovalVote{Actor: a.Actor, Decision: a.Decision, CreatedAt: a.CreatedAt})
+		}
+	}
+	return s.accessApprovals.EvaluateApprovals(promotionApprovalSubject(p, stage), votes, time.Now().UTC())
+}
+
+func promotionApprovalSubject(p control.GitOpsPromotion, stage string) control.ApprovalSubject {
+	return control.ApprovalSubject{
+		Kind:        control.ApprovalSubjectPromotion,
+		ID:          p.ID,
+		Scope:       stage,
+		RequestedBy: p.RequestedBy,
+	}
+}
+
+// handleApprovalEvaluation reports where a change record or GitOps
+// promotion stands against the approval policies that cover it.
+func (s *Server) handleApprovalEvaluation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	kind := strings.TrimSpace(r.URL.Query().Get("kind"))
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	switch kind {
+	case control.ApprovalSubjectChangeRecord:
+		rec, err := s.changeRecords.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.evaluateChangeRecordApprovals(rec))
+	case control.ApprovalSubjectPromotion:
+		p, ok := s.gitopsPromotions.Get(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "promotion pipeline not found"})
+			return
+		}
+		if p.Status == control.PromotionStatusCompleted || p.CurrentIndex+1 >= len(p.Stages) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "promotion pipeline already completed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.evaluatePromotionApprovals(p, p.Stages[p.CurrentIndex+1]))
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "kind must be change_record or gitops_promotion"})
+	}
+}
+
+// approvalErrorCode answers approvals refused by policy with 403 and any
+// other approval failure with 400.
+func approvalErrorCode(err error) int {
+	msg := err.Error()
+	if strings.HasPrefix(msg, "self-approval is not allowed") || strings.HasPrefix(msg, "actor is not an eligible approver") {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
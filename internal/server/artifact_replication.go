@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+// handleArtifactReplicationTargets serves GET/POST
+// /v1/control/artifact-replication/targets; DELETE with ?name= removes one.
+func (s *Server) handleArtifactReplicationTargets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.artifactReplication.Targets())
+	case http.MethodPost:
+		var req control.ArtifactReplicaTargetInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.artifactReplication.UpsertTarget(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "artifact.replication.target.updated",
+			Message: "artifact replica target updated",
+			Fields: map[string]any{
+				"name":   item.Name,
+				"kind":   item.Kind,
+				"region": item.Region,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		if err := s.artifactReplication.DeleteTarget(r.URL.Query().Get("name")); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleArtifactReplications serves GET /v1/control/artifact-replication/jobs
+// (?object_key=, ?status=) and POST to replicate a stored object to every
+// target.
+func (s *Server) handleArtifactReplications(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.artifactReplication.List(r.URL.Query().Get("object_key"), r.URL.Query().Get("status"))
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req struct {
+			ObjectKey string `json:"object_key"`
+			Class     string `json:"class"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.ObjectKey) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "object_key is required"})
+			return
+		}
+		if s.objectStore == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+			return
+		}
+		data, _, err := s.objectStore.Get(req.ObjectKey)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "object not found"})
+			return
+		}
+		items, err := s.artifactReplication.Enqueue(req.ObjectKey, req.Class, objectDigest(data))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]any{"count": len(items), "items": items})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleArtifactReplicationRun serves POST
+// /v1/control/artifact-replication/run, draining pending copies now instead
+// of waiting for the replication worker.
+func (s *Server) handleArtifactReplicationRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	items := s.replicateArtifacts()
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+}
+
+func (s *Server) handleArtifactReplicationLag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": s.artifactReplication.Lag(time.Now().UTC())})
+}
+
+// replicateArtifacts copies every pending replication from the primary object
+// store to its target and verifies the digest read back from the target.
+func (s *Server) replicateArtifacts() []control.ArtifactReplication {
+	out := []control.ArtifactReplication{}
+	for {
+		item, ok := s.artifactReplication.Next()
+		if !ok {
+			return out
+		}
+		got, err := s.copyArtifactReplica(item)
+		done, cerr := s.artifactReplication.Complete(item.ID, got, err)
+		if cerr != nil {
+			continue
+		}
+		out = append(out, done)
+		switch done.Status {
+		case control.ArtifactReplicaVerified:
+			s.events.Append(control.Event{
+				Type:    "artifact.replication.verified",
+				Message: "artifact " + done.ObjectKey + " replicated to " + done.Target,
+				Fields: map[string]any{
+					"replication_id": done.ID,
+					"object_key":     done.ObjectKey,
+					"class":          done.Class,
+					"target":         done.Target,
+					"lag_seconds":    done.LagSeconds,
+				},
+			})
+		case control.ArtifactReplicaFailed:
+			s.recordEvent(control.Event{
+				Type:    "artifact.replication.failed",
+				Message: "artifact " + done.ObjectKey + " failed to replicate to " + done.Target,
+				Fields: map[string]any{
+					"severity":       "high",
+					"replication_id": done.ID,
+					"object_key":     done.ObjectKey,
+					"class":          done.Class,
+					"target":         done.Target,
+					"attempts":       done.Attempts,
+					"error":          done.Error,
+				},
+			}, true)
+		}
+	}
+}
+
+func (s *Server) copyArtifactReplica(item control.ArtifactReplication) (string, error) {
+	if s.objectStore == nil {
+		return "", errors.New("object store unavailable")
+	}
+	target, ok := s.artifactReplication.Target(item.Target)
+	if !ok {
+		return "", errors.New("replica target not found")
+	}
+	replica, err := s.artifactReplicaStore(target)
+	if err != nil {
+		return "", err
+	}
+	data, info, err := s.objectStore.Get(item.ObjectKey)
+	if err != nil {
+		return "", err
+	}
+	if _, err := replica.Put(item.ObjectKey, data, info.ContentType); err != nil {
+		return "", err
+	}
+	copied, _, err := replica.Get(item.ObjectKey)
+	if err != nil {
+		return "", err
+	}
+	return objectDigest(copied), nil
+}
+
+func (s *Server) artifactReplicaStore(target control.ArtifactReplicaTarget) (storage.ObjectStore, error) {
+	root := target.Root
+	if root == "" {
+		root = filepath.Join(s.baseDir, ".masterchef", "replicas", target.Name)
+	}
+	return storage.NewLocalFSStore(root)
+}
+
+// fetchLocalReplica reads an object from a verified replica in region,
+// re-checking its digest; ok is false when the agent should use the primary.
+func (s *Server) fetchLocalReplica(objectKey, digest, region string) ([]byte, string, bool) {
+	target, ok := s.artifactReplication.LocalReplica(objectKey, digest, region)
+	if !ok {
+		return nil, "", false
+	}
+	replica, err := s.artifactReplicaStore(target)
+	if err != nil {
+		return nil, "", false
+	}
+	data, _, err := replica.Get(objectKey)
+	if err != nil || objectDigest(data) != strings.ToLower(digest) {
+		return nil, "", false
+	}
+	return data, target.Name, true
+}
+
+func (s *Server) sweepArtifactReplication(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.replicateArtifacts()
+		}
+	}
+}
+
+func objectDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
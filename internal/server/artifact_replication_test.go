@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestArtifactReplicationVerifiesCopiesAndPrefersLocalReplica(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/artifact-replication/targets", `{"name":"eu-west-1"}`); rr.Code != http.StatusOK {
+		t.Fatalf("create target failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/artifact-replication/targets", `{"name":"edge-1","kind":"relay","region":"ap-south-1"}`); rr.Code != http.StatusOK {
+		t.Fatalf("create relay target failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	tarball := []byte("replicated module archive")
+	rr := do(http.MethodPost, "/v1/packages/artifacts", `{"kind":"module","name":"core/base","version":"1.0.0","tarball":"`+base64.StdEncoding.EncodeToString(tarball)+`"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("publish failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var artifact struct {
+		ID     string `json:"id"`
+		Digest string `json:"digest"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &artifact)
+
+	// Before replication agents fall back to the primary store.
+	rr = do(http.MethodGet, "/v1/packages/artifacts/"+artifact.ID+"/download?region=eu-west-1", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Masterchef-Replica") != "primary" {
+		t.Fatalf("expected primary download, got code=%d replica=%q", rr.Code, rr.Header().Get("X-Masterchef-Replica"))
+	}
+
+	rr = do(http.MethodPost, "/v1/control/artifact-replication/run", "")
+	var run struct {
+		Items []control.ArtifactReplication `json:"items"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &run)
+	if rr.Code != http.StatusOK || len(run.Items) != 2 {
+		t.Fatalf("expected two replications, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, item := range run.Items {
+		if item.Status != control.ArtifactReplicaVerified || item.Digest != artifact.Digest || item.Class != "package" {
+			t.Fatalf("expected verified package replica, got %+v", item)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".masterchef", "replicas", "eu-west-1", filepath.FromSlash(run.Items[0].ObjectKey))); err != nil {
+		t.Fatalf("expected replica written under state dir: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/packages/artifacts/"+artifact.ID+"/download", nil)
+	req.Header.Set("X-Masterchef-Region", "eu-west-1")
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Masterchef-Replica") != "eu-west-1" || !bytes.Equal(rr.Body.Bytes(), tarball) {
+		t.Fatalf("expected local replica download, got code=%d replica=%q", rr.Code, rr.Header().Get("X-Masterchef-Replica"))
+	}
+
+	// A corrupted replica is not served; the agent falls back to the primary.
+	if err := os.WriteFile(filepath.Join(tmp, ".masterchef", "replicas", "eu-west-1", filepath.FromSlash(run.Items[0].ObjectKey)), []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rr = do(http.MethodGet, "/v1/packages/artifacts/"+artifact.ID+"/download?region=eu-west-1", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Masterchef-Replica") != "primary" || !bytes.Equal(rr.Body.Bytes(), tarball) {
+		t.Fatalf("expected fallback to primary for corrupt replica, got code=%d replica=%q", rr.Code, rr.Header().Get("X-Masterchef-Replica"))
+	}
+
+	rr = do(http.MethodGet, "/v1/control/artifact-replication/lag", "")
+	var lag struct {
+		Items []control.ArtifactReplicationLag `json:"items"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &lag)
+	if len(lag.Items) != 1 || lag.Items[0].Class != "package" || lag.Items[0].Verified != 2 || lag.Items[0].Pending != 0 {
+		t.Fatalf("unexpected replication lag: %s", rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/artifact-replication/jobs", `{"object_key":"missing/key"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected missing object to 404, got code=%d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/control/artifact-replication/jobs", `{"object_key":"`+run.Items[0].ObjectKey+`","class":"package"}`); rr.Code != http.StatusAccepted || !bytes.Contains(rr.Body.Bytes(), []byte(`"count":0`)) {
+		t.Fatalf("expected already-replicated object to be skipped, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
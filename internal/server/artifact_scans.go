@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleArtifactScanAction serves the sbom and scans sub-resources shared
+// by package artifacts and image bake pipelines.
+func (s *Server) handleArtifactScanAction(w http.ResponseWriter, r *http.Request, artifactType, id, action string) {
+	switch {
+	case action == "sbom" && r.Method == http.MethodGet:
+		item, ok := s.artifactScans.GetSBOM(artifactType, id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "sbom not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case action == "sbom" && r.Method == http.MethodPost:
+		var req control.SBOMGenerateInput
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		req.ArtifactType = artifactType
+		req.ArtifactID = id
+		item, err := s.artifactScans.GenerateSBOM(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "supply_chain.sbom.generated",
+			Message: "artifact sbom generated",
+			Fields: map[string]any{
+				"sbom_id":       item.ID,
+				"artifact_type": item.ArtifactType,
+				"artifact_id":   item.ArtifactID,
+				"format":        item.Format,
+				"digest":        item.Digest,
+				"components":    len(item.Components),
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	case action == "scans" && r.Method == http.MethodGet:
+		items := s.artifactScans.ListScans(artifactType, id)
+		writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+	case action == "scans" && r.Method == http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+		defer cancel()
+		scan, err := s.artifactScans.Scan(ctx, artifactType, id)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "supply_chain.vulnerability_scan.completed",
+			Message: "artifact vulnerability scan completed",
+			Fields: map[string]any{
+				"scan_id":       scan.ID,
+				"artifact_type": scan.ArtifactType,
+				"artifact_id":   scan.ArtifactID,
+				"feed":          scan.Feed,
+				"findings":      len(scan.Findings),
+				"max_severity":  scan.MaxSeverity,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, scan)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleVulnerabilityGatePolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.artifactScans.Policy())
+	case http.MethodPost:
+		var req control.VulnerabilityGatePolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		policy, err := s.artifactScans.SetPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "supply_chain.vulnerability_policy.updated",
+			Message: "vulnerability gate policy updated",
+			Fields: map[string]any{
+				"max_certification_severity": policy.MaxCertificationSeverity,
+				"max_publication_severity":   policy.MaxPublicationSeverity,
+				"require_scan":               policy.RequireScan,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestImageScanGatesPromotion(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "features.md"), []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	osv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"vulns":[{"id":"UBUNTU-CVE-2024-9","affected":[{"package":{"name":"openssl","ecosystem":"Ubuntu"},"ecosystem_specific":{"severity":"critical"}}]}]}`))
+	}))
+	defer osv.Close()
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	s.artifactScans.SetFeed(control.NewOSVFeed(osv.URL, osv.Client()))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+	rr := do(http.MethodPost, "/v1/execution/image-baking/pipelines", `{"environment":"prod","name":"web","builder":"packer","base_image":"ubuntu-24.04","target_image":"web","promote_after_bake":true}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create pipeline failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Pipeline struct {
+			ID string `json:"id"`
+		} `json:"pipeline"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+	base := "/v1/execution/image-baking/pipelines/" + created.Pipeline.ID
+
+	rr = do(http.MethodPost, base+"/sbom", `{"format":"cyclonedx","version":"build-7","components":[{"name":"openssl","version":"3.0.2","ecosystem":"Ubuntu"}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("generate sbom failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, base+"/sbom", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"format":"cyclonedx"`) {
+		t.Fatalf("get sbom failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, base+"/scans", "")
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"max_severity":"critical"`) {
+		t.Fatalf("scan failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, base+"/scans", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("list scans failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, base+"/plan", `{}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "critical vulnerabilities exceed publication threshold") {
+		t.Fatalf("expected promotion to be blocked: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, base+"/plan", `{"promote_after_bake":false}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected bake-only plan to pass: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/packages/vulnerability-policy", `{"max_publication_severity":"critical"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set vulnerability policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, base+"/plan", `{}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected promotion under relaxed policy: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/packages/artifacts/pkg-artifact-404/sbom", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected sbom for missing package to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
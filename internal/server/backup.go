@@ -15,19 +15,25 @@ import (
 )
 
 type backupSnapshot struct {
-	Version   string            `json:"version"`
-	CreatedAt time.Time         `json:"created_at"`
-	Runs      []state.RunRecord `json:"runs,omitempty"`
-	Events    []control.Event   `json:"events,omitempty"`
+	Version      string                `json:"version"`
+	CreatedAt    time.Time             `json:"created_at"`
+	Runs         []state.RunRecord     `json:"runs,omitempty"`
+	Events       []control.Event       `json:"events,omitempty"`
+	ControlPlane *controlPlaneSnapshot `json:"control_plane,omitempty"`
 }
 
 var errInvalidBackupSnapshotPayload = errors.New("invalid backup snapshot payload")
 
-func (s *Server) buildBackupSnapshot(baseDir string, includeRuns, includeEvents bool) (backupSnapshot, error) {
+func (s *Server) buildBackupSnapshot(baseDir string, includeRuns, includeEvents, includeControlPlane bool) (backupSnapshot, error) {
 	snap := backupSnapshot{
 		Version:   "v1",
 		CreatedAt: time.Now().UTC(),
 	}
+	if includeControlPlane {
+		cp := s.buildControlPlaneSnapshot()
+		snap.Version = "v2"
+		snap.ControlPlane = &cp
+	}
 	if includeRuns {
 		runs, err := state.New(baseDir).ListRuns(100000)
 		if err != nil {
@@ -58,6 +64,10 @@ func (s *Server) getBackupSnapshot(key string) (backupSnapshot, storage.ObjectIn
 	if err != nil {
 		return backupSnapshot{}, storage.ObjectInfo{}, err
 	}
+	payload, err = s.openBackupPayload(payload)
+	if err != nil {
+		return backupSnapshot{}, storage.ObjectInfo{}, err
+	}
 	var snap backupSnapshot
 	if err := json.Unmarshal(payload, &snap); err != nil {
 		return backupSnapshot{}, storage.ObjectInfo{}, errInvalidBackupSnapshotPayload
@@ -67,9 +77,10 @@ func (s *Server) getBackupSnapshot(key string) (backupSnapshot, storage.ObjectIn
 
 func (s *Server) handleBackup(baseDir string) http.HandlerFunc {
 	type reqBody struct {
-		IncludeRuns   bool   `json:"include_runs"`
-		IncludeEvents bool   `json:"include_events"`
-		Prefix        string `json:"prefix"`
+		IncludeRuns         bool   `json:"include_runs"`
+		IncludeEvents       bool   `json:"include_events"`
+		IncludeControlPlane bool   `json:"include_control_plane"`
+		Prefix              string `json:"prefix"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -93,7 +104,7 @@ func (s *Server) handleBackup(baseDir string) http.HandlerFunc {
 			req.Prefix = "backups"
 		}
 
-		snap, err := s.buildBackupSnapshot(baseDir, req.IncludeRuns, req.IncludeEvents)
+		snap, err := s.buildBackupSnapshot(baseDir, req.IncludeRuns, req.IncludeEvents, req.IncludeControlPlane)
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
@@ -104,9 +115,11 @@ func (s *Server) handleBackup(baseDir string) http.HandlerFunc {
 			return
 		}
 		writeJSON(w, http.StatusOK, map[string]any{
-			"object":          obj,
-			"snapshot_runs":   len(snap.Runs),
-			"snapshot_events": len(snap.Events),
+			"object":                 obj,
+			"snapshot_version":       snap.Version,
+			"snapshot_runs":          len(snap.Runs),
+			"snapshot_events":        len(snap.Events),
+			"snapshot_control_plane": snap.ControlPlane.counts(),
 		})
 	}
 }
@@ -140,7 +153,7 @@ func (s *Server) handleDRDrill(baseDir string) http.HandlerFunc {
 		}
 
 		start := time.Now().UTC()
-		snap, err := s.buildBackupSnapshot(baseDir, req.IncludeRuns, req.IncludeEvents)
+		snap, err := s.buildBackupSnapshot(baseDir, req.IncludeRuns, req.IncludeEvents, false)
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
@@ -207,10 +220,14 @@ func (s *Server) handleBackups(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleRestore(baseDir string) http.HandlerFunc {
 	type reqBody struct {
-		Key        string `json:"key"`
-		Prefix     string `json:"prefix"`
-		AtOrBefore string `json:"at_or_before"`
-		VerifyOnly bool   `json:"verify_only"`
+		Key         string   `json:"key"`
+		Prefix      string   `json:"prefix"`
+		AtOrBefore  string   `json:"at_or_before"`
+		VerifyOnly  bool     `json:"verify_only"`
+		Include     []string `json:"include"`
+		Tenant      string   `json:"tenant"`
+		PointInTime string   `json:"point_in_time"`
+		DryRun      bool     `json:"dry_run"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -226,6 +243,22 @@ func (s *Server) handleRestore(baseDir string) http.HandlerFunc {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 			return
 		}
+		sel, err := parseRestoreSelection(req.Include, req.Tenant)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		var pointInTime time.Time
+		if pit := strings.TrimSpace(req.PointInTime); pit != "" {
+			pointInTime, err = time.Parse(time.RFC3339Nano, pit)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "point_in_time must be RFC3339 timestamp"})
+				return
+			}
+			if strings.TrimSpace(req.AtOrBefore) == "" {
+				req.AtOrBefore = pit
+			}
+		}
 		key, err := s.resolveRestoreKey(req.Key, req.Prefix, req.AtOrBefore)
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
@@ -242,27 +275,66 @@ func (s *Server) handleRestore(baseDir string) http.HandlerFunc {
 		}
 		if req.VerifyOnly {
 			writeJSON(w, http.StatusOK, map[string]any{
-				"status":  "verified",
-				"object":  obj,
-				"key":     key,
-				"runs":    len(snap.Runs),
-				"events":  len(snap.Events),
-				"version": snap.Version,
+				"status":        "verified",
+				"object":        obj,
+				"key":           key,
+				"runs":          len(snap.Runs),
+				"events":        len(snap.Events),
+				"control_plane": snap.ControlPlane.counts(),
+				"version":       snap.Version,
+			})
+			return
+		}
+		var replayed map[string]int
+		if !pointInTime.IsZero() {
+			replayed, err = s.replayToPointInTime(baseDir, &snap, pointInTime)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		snap, err = s.selectRestore(baseDir, snap, sel)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if req.DryRun {
+			diff, err := s.diffRestore(baseDir, snap, sel)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"status":   "dry_run",
+				"object":   obj,
+				"key":      key,
+				"include":  sel.kinds(),
+				"tenant":   sel.Tenant,
+				"replayed": replayed,
+				"diff":     diff,
 			})
 			return
 		}
-		if err := state.New(baseDir).ReplaceRuns(snap.Runs); err != nil {
+		if snap.ControlPlane != nil && !s.requireControlAdmin(w, r) {
+			return
+		}
+		restored, err := s.applyBackupSnapshot(baseDir, snap)
+		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
-		s.events.Replace(snap.Events)
-		writeJSON(w, http.StatusOK, map[string]any{
-			"status":          "restored",
-			"object":          obj,
-			"key":             key,
-			"restored_runs":   len(snap.Runs),
-			"restored_events": len(snap.Events),
-		})
+		restored["status"] = "restored"
+		restored["object"] = obj
+		restored["key"] = key
+		if sel.active() {
+			restored["include"] = sel.kinds()
+			restored["tenant"] = sel.Tenant
+		}
+		if replayed != nil {
+			restored["replayed"] = replayed
+			restored["point_in_time"] = pointInTime
+		}
+		writeJSON(w, http.StatusOK, restored)
 	}
 }
 
@@ -0,0 +1,441 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+const encryptedBackupFormat = "masterchef-encrypted-backup-v1"
+
+// encryptedBackup is the object-store envelope for a scheduled backup sealed
+// under a tenant key.
+type encryptedBackup struct {
+	Format     string `json:"format"`
+	Tenant     string `json:"tenant"`
+	KeyID      string `json:"key_id"`
+	Algorithm  string `json:"algorithm"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func backupAAD(tenant string) []byte {
+	return []byte("masterchef-backup:" + tenant)
+}
+
+// openBackupPayload decrypts an encrypted backup envelope; plain snapshots
+// are returned unchanged.
+func (s *Server) openBackupPayload(payload []byte) ([]byte, error) {
+	var env encryptedBackup
+	if err := json.Unmarshal(payload, &env); err != nil || env.Format != encryptedBackupFormat {
+		return payload, nil
+	}
+	return s.tenantCrypto.Open(env.KeyID, env.Nonce, env.Ciphertext, backupAAD(env.Tenant))
+}
+
+// handleBackupSchedules serves GET/POST /v1/control/backup-schedules.
+func (s *Server) handleBackupSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.backupSchedules.List())
+	case http.MethodPost:
+		var req control.BackupScheduleInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.Tenant) == "" {
+			_, req.Tenant = requestIdentity(r)
+		}
+		item, err := s.backupSchedules.Create(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "backup.schedule.created",
+			Message: "backup schedule " + item.Name + " created",
+			Fields: map[string]any{
+				"schedule_id":      item.ID,
+				"tenant":           item.Tenant,
+				"interval_minutes": item.IntervalMinutes,
+				"retain_count":     item.RetainCount,
+				"offsite":          item.Offsite,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleBackupScheduleAction serves /v1/control/backup-schedules/{id} and
+// its run, enable, and disable actions.
+func (s *Server) handleBackupScheduleAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 4 || len(parts) > 5 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown backup schedule path"})
+		return
+	}
+	id := parts[3]
+	if len(parts) == 4 {
+		switch r.Method {
+		case http.MethodGet:
+			item, err := s.backupSchedules.Get(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"schedule": item, "sets": s.backupSchedules.Sets(id)})
+		case http.MethodDelete:
+			if err := s.backupSchedules.Delete(id); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[4] {
+	case "enable", "disable":
+		item, err := s.backupSchedules.SetEnabled(id, parts[4] == "enable")
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case "run":
+		schedule, err := s.backupSchedules.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		if s.objectStore == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+			return
+		}
+		set, err := s.runScheduledBackup(schedule)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, set)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown backup schedule action"})
+	}
+}
+
+// handleBackupSets serves GET /v1/control/backup-sets (?schedule_id=).
+func (s *Server) handleBackupSets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	items := s.backupSchedules.Sets(r.URL.Query().Get("schedule_id"))
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+}
+
+// handleBackupSetAction serves GET /v1/control/backup-sets/{id} and POST
+// /v1/control/backup-sets/{id}/verify for an on-demand restore drill.
+func (s *Server) handleBackupSetAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 4 || len(parts) > 5 || (len(parts) == 5 && parts[4] != "verify") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown backup set path"})
+		return
+	}
+	set, err := s.backupSchedules.GetSet(parts[3])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(parts) == 4 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, set)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if set.Status != control.BackupSetAvailable {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "backup set has been pruned"})
+		return
+	}
+	rto := 300
+	if schedule, err := s.backupSchedules.Get(set.ScheduleID); err == nil {
+		rto = schedule.TargetRTOSeconds
+	}
+	writeJSON(w, http.StatusOK, s.verifyBackupSet(set, rto))
+}
+
+// runScheduledBackup captures, encrypts, stores, copies offsite, verifies,
+// and prunes one backup for schedule.
+func (s *Server) runScheduledBackup(schedule control.BackupSchedule) (control.BackupSet, error) {
+	set, err := s.captureScheduledBackup(schedule)
+	if err != nil {
+		_, _ = s.backupSchedules.RecordRun(schedule.ID, err)
+		s.recordEvent(control.Event{
+			Type:    "backup.scheduled.failed",
+			Message: "scheduled backup " + schedule.Name + " failed",
+			Fields: map[string]any{
+				"severity":    "high",
+				"schedule_id": schedule.ID,
+				"error":       err.Error(),
+			},
+		}, true)
+		return control.BackupSet{}, err
+	}
+	if schedule.Offsite {
+		set = s.copyBackupOffsite(set)
+	}
+	drill, _ := s.backupSchedules.RecordRun(schedule.ID, nil)
+	s.events.Append(control.Event{
+		Type:    "backup.scheduled.completed",
+		Message: "scheduled backup " + schedule.Name + " stored",
+		Fields: map[string]any{
+			"schedule_id":    schedule.ID,
+			"set_id":         set.ID,
+			"object_key":     set.ObjectKey,
+			"key_id":         set.KeyID,
+			"offsite_status": set.OffsiteStatus,
+		},
+	})
+	if drill {
+		set = s.verifyBackupSet(set, schedule.TargetRTOSeconds)
+	}
+	for _, pruned := range s.backupSchedules.Prune(schedule.ID, time.Now().UTC()) {
+		_ = s.objectStore.Delete(pruned.ObjectKey)
+		if pruned.OffsiteKey != "" {
+			if offsite, err := s.offsiteBackupStore(); err == nil {
+				_ = offsite.Delete(pruned.OffsiteKey)
+			}
+		}
+		s.events.Append(control.Event{
+			Type:    "backup.set.pruned",
+			Message: "backup set " + pruned.ID + " pruned by retention",
+			Fields:  map[string]any{"schedule_id": schedule.ID, "set_id": pruned.ID, "object_key": pruned.ObjectKey},
+		})
+	}
+	return set, nil
+}
+
+func (s *Server) captureScheduledBackup(schedule control.BackupSchedule) (control.BackupSet, error) {
+	if s.objectStore == nil {
+		return control.BackupSet{}, errors.New("object store unavailable")
+	}
+	snap, err := s.buildBackupSnapshot(s.baseDir, schedule.IncludeRuns, schedule.IncludeEvents, schedule.IncludeControlPlane)
+	if err != nil {
+		return control.BackupSet{}, err
+	}
+	plain, err := json.Marshal(snap)
+	if err != nil {
+		return control.BackupSet{}, err
+	}
+	key, nonce, sealed, err := s.tenantCrypto.Seal(schedule.Tenant, plain, backupAAD(schedule.Tenant))
+	if err != nil {
+		return control.BackupSet{}, err
+	}
+	payload, err := json.Marshal(encryptedBackup{
+		Format:     encryptedBackupFormat,
+		Tenant:     key.Tenant,
+		KeyID:      key.ID,
+		Algorithm:  key.Algorithm,
+		Nonce:      nonce,
+		Ciphertext: sealed,
+	})
+	if err != nil {
+		return control.BackupSet{}, err
+	}
+	obj, err := s.objectStore.Put(storage.TimestampedJSONKey(schedule.Prefix, "snapshot"), payload, "application/json")
+	if err != nil {
+		return control.BackupSet{}, err
+	}
+	return s.backupSchedules.AddSet(control.BackupSet{
+		ScheduleID: schedule.ID,
+		Tenant:     key.Tenant,
+		KeyID:      key.ID,
+		ObjectKey:  obj.Key,
+		Digest:     objectDigest(payload),
+		SizeBytes:  obj.SizeBytes,
+		Runs:       len(snap.Runs),
+		Events:     len(snap.Events),
+	}), nil
+}
+
+// copyBackupOffsite writes the set to the secondary object store and checks
+// the copy's digest against the primary.
+func (s *Server) copyBackupOffsite(set control.BackupSet) control.BackupSet {
+	err := func() error {
+		offsite, err := s.offsiteBackupStore()
+		if err != nil {
+			return err
+		}
+		payload, info, err := s.objectStore.Get(set.ObjectKey)
+		if err != nil {
+			return err
+		}
+		if _, err := offsite.Put(set.ObjectKey, payload, info.ContentType); err != nil {
+			return err
+		}
+		copied, _, err := offsite.Get(set.ObjectKey)
+		if err != nil {
+			return err
+		}
+		if objectDigest(copied) != set.Digest {
+			return errors.New("offsite copy digest mismatch")
+		}
+		return nil
+	}()
+	updated, markErr := s.backupSchedules.MarkOffsite(set.ID, set.ObjectKey, err)
+	if markErr != nil {
+		return set
+	}
+	if err != nil {
+		s.recordEvent(control.Event{
+			Type:    "backup.offsite.failed",
+			Message: "offsite copy of backup set " + set.ID + " failed",
+			Fields: map[string]any{
+				"severity":    "high",
+				"schedule_id": set.ScheduleID,
+				"set_id":      set.ID,
+				"error":       err.Error(),
+			},
+		}, true)
+	}
+	return updated
+}
+
+// verifyBackupSet runs a restore-verification drill: it reads the set back
+// (from the offsite copy when there is one), checks its digest, decrypts it
+// with the tenant key, and compares it with what was captured. The result is
+// recorded on the set and in the DR drill scorecards.
+func (s *Server) verifyBackupSet(set control.BackupSet, targetRTOSeconds int) control.BackupSet {
+	start := time.Now()
+	v := control.BackupVerification{Source: "primary"}
+	err := func() error {
+		store := s.objectStore
+		key := set.ObjectKey
+		if set.OffsiteStatus == control.BackupOffsiteVerified {
+			offsite, err := s.offsiteBackupStore()
+			if err != nil {
+				return err
+			}
+			store, key, v.Source = offsite, set.OffsiteKey, "offsite"
+		}
+		if store == nil {
+			return errors.New("object store unavailable")
+		}
+		payload, _, err := store.Get(key)
+		if err != nil {
+			return err
+		}
+		if objectDigest(payload) != set.Digest {
+			return errors.New("backup digest mismatch")
+		}
+		plain, err := s.openBackupPayload(payload)
+		if err != nil {
+			return err
+		}
+		var snap backupSnapshot
+		if err := json.Unmarshal(plain, &snap); err != nil {
+			return errInvalidBackupSnapshotPayload
+		}
+		v.Runs, v.Events = len(snap.Runs), len(snap.Events)
+		if v.Runs != set.Runs || v.Events != set.Events {
+			return errors.New("restored snapshot does not match captured counts")
+		}
+		return nil
+	}()
+	v.DurationMS = time.Since(start).Milliseconds()
+	v.Passed = err == nil
+	failure := ""
+	if err != nil {
+		v.Error = err.Error()
+		failure = v.Error
+	}
+	recovery := v.DurationMS
+	if recovery < 1 {
+		recovery = 1
+	}
+	if drill, derr := s.failoverDrills.Run(control.RegionalFailoverDrillInput{
+		Kind:                "backup-restore",
+		Region:              set.ScheduleID,
+		TargetRTOSeconds:    targetRTOSeconds,
+		SimulatedRecoveryMs: recovery,
+		Notes:               "restore verification of backup set " + set.ID + " from " + v.Source,
+		Failure:             failure,
+	}); derr == nil {
+		v.DrillID = drill.ID
+	}
+	updated, markErr := s.backupSchedules.MarkVerified(set.ID, v)
+	if markErr != nil {
+		updated = set
+	}
+	if !v.Passed {
+		s.recordEvent(control.Event{
+			Type:    "backup.verification.failed",
+			Message: "restore verification of backup set " + set.ID + " failed",
+			Fields: map[string]any{
+				"severity":    "high",
+				"schedule_id": set.ScheduleID,
+				"set_id":      set.ID,
+				"source":      v.Source,
+				"error":       v.Error,
+			},
+		}, true)
+	} else {
+		s.events.Append(control.Event{
+			Type:    "backup.verification.passed",
+			Message: "restore verification of backup set " + set.ID + " passed",
+			Fields: map[string]any{
+				"schedule_id": set.ScheduleID,
+				"set_id":      set.ID,
+				"source":      v.Source,
+				"duration_ms": v.DurationMS,
+			},
+		})
+	}
+	return updated
+}
+
+// offsiteBackupStore is the secondary object store for offsite copies,
+// MC_BACKUP_OFFSITE_PATH or a directory beside the primary store.
+func (s *Server) offsiteBackupStore() (storage.ObjectStore, error) {
+	root := strings.TrimSpace(os.Getenv("MC_BACKUP_OFFSITE_PATH"))
+	if root == "" {
+		root = filepath.Join(s.baseDir, ".masterchef", "offsite-backups")
+	}
+	return storage.NewLocalFSStore(root)
+}
+
+func (s *Server) sweepBackupSchedules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, schedule := range s.backupSchedules.Due(time.Now().UTC()) {
+				_, _ = s.runScheduledBackup(schedule)
+			}
+		}
+	}
+}
@@ -115,27 +115,33 @@ func (s *Server) handleBeaconReactorEmit(w http.ResponseWriter, r *http.Request)
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
-	beacon := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(req.Beacon), "beacon."))
-	if beacon == "" {
+	if strings.TrimSpace(strings.TrimPrefix(strings.ToLower(req.Beacon), "beacon.")) == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "beacon is required"})
 		return
 	}
-	fields := req.Fields
+	eventType := s.ingestBeaconEmit(req.Beacon, req.Message, req.Host, req.Fields)
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"status":     "ingested",
+		"event_type": eventType,
+	})
+}
+
+// ingestBeaconEmit records a beacon emit as a beacon.<name> event so reactor
+// rules can match it, and returns the event type.
+func (s *Server) ingestBeaconEmit(beacon, message, host string, fields map[string]any) string {
+	beacon = strings.TrimSpace(strings.TrimPrefix(strings.ToLower(beacon), "beacon."))
 	if fields == nil {
 		fields = map[string]any{}
 	}
-	if strings.TrimSpace(req.Host) != "" {
-		fields["host"] = strings.TrimSpace(req.Host)
+	if strings.TrimSpace(host) != "" {
+		fields["host"] = strings.TrimSpace(host)
 	}
 	s.recordEvent(control.Event{
 		Type:    "beacon." + beacon,
-		Message: strings.TrimSpace(req.Message),
+		Message: strings.TrimSpace(message),
 		Fields:  fields,
 	}, true)
-	writeJSON(w, http.StatusAccepted, map[string]any{
-		"status":     "ingested",
-		"event_type": "beacon." + beacon,
-	})
+	return "beacon." + beacon
 }
 
 func normalizeBeaconPrefix(raw string) string {
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -50,6 +52,11 @@ func (s *Server) handleBulkExecute(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
 		PreviewToken string `json:"preview_token"`
 		Confirm      bool   `json:"confirm"`
+		// Service and Hosts declare the hosts a disruptive bulk execute takes
+		// down; the claim is held for the duration of the execute.
+		Service    string   `json:"service,omitempty"`
+		Hosts      []string `json:"hosts,omitempty"`
+		TotalHosts int      `json:"total_hosts,omitempty"`
 	}
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -65,6 +72,32 @@ func (s *Server) handleBulkExecute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if strings.TrimSpace(req.Service) != "" && len(req.Hosts) > 0 {
+		decision, err := s.acquireDisruption(control.DisruptionClaimInput{
+			Service:    req.Service,
+			Source:     "bulk",
+			Ref:        req.PreviewToken,
+			Hosts:      req.Hosts,
+			TotalHosts: req.TotalHosts,
+		})
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if !decision.Granted {
+			writeJSON(w, http.StatusConflict, map[string]any{
+				"error":      "disruption budget exceeded",
+				"disruption": decision,
+			})
+			return
+		}
+		defer func() {
+			if claim, err := s.disruptionBudgets.Release(decision.Claim.ID); err == nil {
+				s.noteDisruptionReleased(claim)
+			}
+		}()
+	}
+
 	preview, err := s.bulk.ConsumePreview(req.PreviewToken)
 	if err != nil {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
@@ -151,6 +184,9 @@ func detectBulkConflicts(ops []control.BulkOperation) []string {
 		if actions["runbook.approve"] > 0 && actions["runbook.deprecate"] > 0 {
 			conflicts = append(conflicts, "conflicting runbook approve/deprecate operations for "+key)
 		}
+		if actions["node.enroll"] > 0 && actions["node.quarantine"] > 0 {
+			conflicts = append(conflicts, "conflicting node enroll/quarantine operations for "+key)
+		}
 	}
 	return conflicts
 }
@@ -194,6 +230,19 @@ func (s *Server) validateBulkOperation(op control.BulkOperation) string {
 			return "template not found"
 		}
 		return ""
+	case "node.enroll", "node.quarantine", "node.update_labels":
+		if op.TargetType != "node" {
+			return "node actions require target_type=node"
+		}
+		return ""
+	case "node.converge":
+		if op.TargetType != "node" {
+			return "node actions require target_type=node"
+		}
+		if strings.TrimSpace(bulkParamString(op.Params, "config_path")) == "" {
+			return "node.converge requires params.config_path"
+		}
+		return ""
 	default:
 		return "unsupported bulk action"
 	}
@@ -224,8 +273,71 @@ func (s *Server) applyBulkOperation(op control.BulkOperation) error {
 		_, err := s.views.SetPinned(op.TargetID, false)
 		return err
 	case "template.delete":
-		return s.templates.Delete(op.TargetID)
+		if err := s.templates.Delete(op.TargetID); err != nil {
+			return err
+		}
+		s.objectModel.UnregisterTemplate(op.TargetID)
+		return nil
+	case "node.enroll", "node.update_labels":
+		_, err := s.enrollNodeWithLabels(op.TargetID, bulkParamLabels(op.Params))
+		return err
+	case "node.quarantine":
+		if _, ok := s.nodes.Get(op.TargetID); !ok {
+			if _, _, err := s.nodes.Enroll(control.NodeEnrollInput{Name: op.TargetID, Source: "inventory-drift"}); err != nil {
+				return err
+			}
+		}
+		_, err := s.nodes.SetStatus(op.TargetID, control.NodeStatusQuarantined, bulkParamString(op.Params, "reason"))
+		return err
+	case "node.converge":
+		_, err := s.queue.EnqueuePlaced(
+			control.JobPlacement{Labels: map[string]string{"host": op.TargetID}},
+			bulkParamString(op.Params, "config_path"),
+			"bulk-node-converge:"+op.TargetID+":"+strconv.FormatInt(time.Now().UTC().UnixNano(), 10),
+			false,
+			"normal",
+		)
+		return err
 	default:
 		return errors.New("unsupported bulk action")
 	}
 }
+
+// enrollNodeWithLabels enrolls a node, or replaces the labels of an enrolled
+// one while keeping its address, roles, and topology.
+func (s *Server) enrollNodeWithLabels(name string, labels map[string]string) (control.ManagedNode, error) {
+	in := control.NodeEnrollInput{Name: name, Labels: labels, Source: "inventory-drift"}
+	if node, ok := s.nodes.Get(name); ok {
+		in.Address = node.Address
+		in.Transport = node.Transport
+		in.Roles = node.Roles
+		in.Topology = node.Topology
+		in.Source = node.Source
+	}
+	node, _, err := s.nodes.Enroll(in)
+	return node, err
+}
+
+func bulkParamString(params map[string]any, key string) string {
+	v, _ := params[key].(string)
+	return v
+}
+
+// bulkParamLabels reads a label map from params, whether it was built in
+// process or decoded from a JSON request.
+func bulkParamLabels(params map[string]any) map[string]string {
+	switch v := params["labels"].(type) {
+	case map[string]string:
+		return v
+	case map[string]any:
+		out := make(map[string]string, len(v))
+		for k, raw := range v {
+			if str, ok := raw.(string); ok {
+				out[k] = str
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+// handleCanaryAnalyses runs a canary analysis. Samples may be supplied
+// directly or loaded from recorded runs via baseline_run_ids and
+// canary_run_ids.
+func (s *Server) handleCanaryAnalyses(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.canaryAnalyses.List(parseIntQuery(r, "limit", 100)))
+		case http.MethodPost:
+			var req struct {
+				control.CanaryAnalysisInput
+				BaselineRunIDs []string `json:"baseline_run_ids"`
+				CanaryRunIDs   []string `json:"canary_run_ids"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			in := req.CanaryAnalysisInput
+			runs := state.New(baseDir)
+			for _, side := range []struct {
+				ids []string
+				out *[]control.CanaryAnalysisSample
+			}{{req.BaselineRunIDs, &in.Baseline}, {req.CanaryRunIDs, &in.Canary}} {
+				for _, id := range side.ids {
+					run, err := runs.GetRun(strings.TrimSpace(id))
+					if err != nil {
+						writeJSON(w, http.StatusBadRequest, map[string]string{"error": "run not found: " + strings.TrimSpace(id)})
+						return
+					}
+					*side.out = append(*side.out, canarySampleFromRun(run))
+				}
+			}
+			item, err := s.canaryAnalyses.Analyze(in)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			fields := map[string]any{
+				"analysis_id":    item.ID,
+				"name":           item.Name,
+				"component":      item.Component,
+				"score":          item.Score,
+				"verdict":        item.Verdict,
+				"recommendation": item.Recommendation,
+			}
+			if item.Verdict == "fail" {
+				fields["severity"] = "high"
+			}
+			s.recordEvent(control.Event{
+				Type:    "canary.analysis.completed",
+				Message: "canary analysis " + item.Verdict,
+				Fields:  fields,
+			}, true)
+			writeJSON(w, http.StatusCreated, item)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *Server) handleCanaryAnalysisAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/canary-analysis/{id}
+	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "canary-analysis" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, err := s.canaryAnalyses.Get(parts[3])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func canarySampleFromRun(run state.RunRecord) control.CanaryAnalysisSample {
+	sample := control.CanaryAnalysisSample{
+		RunID:  run.ID,
+		Failed: run.Status == state.RunFailed,
+	}
+	if !run.StartedAt.IsZero() && run.EndedAt.After(run.StartedAt) {
+		sample.DurationMS = float64(run.EndedAt.Sub(run.StartedAt).Milliseconds())
+	}
+	for _, res := range run.Results {
+		if res.Changed {
+			sample.ChangedResources++
+		}
+	}
+	return sample
+}
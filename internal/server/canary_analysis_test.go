@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestCanaryAnalysisGatesRolloutAndUpgrades(t *testing.T) {
+	tmp := t.TempDir()
+	st := state.New(tmp)
+	start := time.Now().UTC().Add(-time.Hour)
+	ids := map[string][]string{}
+	for i := 0; i < 8; i++ {
+		for _, side := range []string{"baseline", "canary"} {
+			duration, status := 100*time.Millisecond+time.Duration(i)*time.Millisecond, state.RunSucceeded
+			if side == "canary" && i < 5 {
+				status = state.RunFailed
+			}
+			id := side + "-" + strconv.Itoa(i)
+			if err := st.SaveRun(state.RunRecord{ID: id, StartedAt: start, EndedAt: start.Add(duration), Status: status}); err != nil {
+				t.Fatal(err)
+			}
+			ids[side] = append(ids[side], id)
+		}
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	quote := func(in []string) string { return `"` + strings.Join(in, `","`) + `"` }
+	body := `{"name":"web-canary","component":"web","baseline_run_ids":[` + quote(ids["baseline"]) + `],"canary_run_ids":[` + quote(ids["canary"]) + `]}`
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/canary-analysis", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("analysis failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var analysis struct {
+		ID      string `json:"id"`
+		Verdict string `json:"verdict"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &analysis)
+	if analysis.Verdict != "fail" {
+		t.Fatalf("expected failing verdict: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/canary-analysis/"+analysis.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get analysis failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/deployments/rollout/policies", strings.NewReader(`{"environment":"prod","strategy":"canary"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	plan := `{"environment":"prod","targets":["a","b","c"],"canary_analysis_id":"` + analysis.ID + `"}`
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/deployments/rollout/plan", strings.NewReader(plan)))
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"canary_verdict":"fail"`) {
+		t.Fatalf("expected rollout plan blocked by canary analysis: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	upgrade := `{"component":"scheduler","to_channel":"candidate","auto_rollback":true,"canary_analysis_id":"` + analysis.ID + `"}`
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/canary-upgrades", strings.NewReader(upgrade)))
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"status":"rolled_back"`) {
+		t.Fatalf("expected canary upgrade rollback: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -11,10 +11,11 @@ import (
 
 func (s *Server) handleCanaryUpgrades(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Component    string   `json:"component"`
-		ToChannel    string   `json:"to_channel"`
-		AutoRollback bool     `json:"auto_rollback"`
-		CanaryIDs    []string `json:"canary_ids"`
+		Component        string   `json:"component"`
+		ToChannel        string   `json:"to_channel"`
+		AutoRollback     bool     `json:"auto_rollback"`
+		CanaryIDs        []string `json:"canary_ids"`
+		CanaryAnalysisID string   `json:"canary_analysis_id"`
 	}
 	switch r.Method {
 	case http.MethodGet:
@@ -48,6 +49,14 @@ func (s *Server) handleCanaryUpgrades(w http.ResponseWriter, r *http.Request) {
 				break
 			}
 		}
+		var analysis control.CanaryAnalysis
+		if id := strings.TrimSpace(req.CanaryAnalysisID); id != "" {
+			var err error
+			if analysis, err = s.canaryAnalyses.Get(id); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+		}
 		if _, err := s.channels.SetChannel(component, toChannel); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -77,6 +86,11 @@ func (s *Server) handleCanaryUpgrades(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if !regression && analysis.Verdict == "fail" {
+			regression = true
+			reason = "canary analysis " + analysis.ID + " failed with score " + strconv.Itoa(analysis.Score)
+		}
+
 		status := "completed"
 		rolledBack := false
 		if regression && req.AutoRollback {
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
@@ -23,6 +24,24 @@ func (s *Server) handleChaosExperiments(w http.ResponseWriter, r *http.Request)
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		if item.Injected && item.Status == "running" {
+			item, err = s.chaosInjector.Start(item)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "chaos.experiment." + item.Status,
+				Message: "chaos experiment " + item.Status,
+				Fields: map[string]any{
+					"experiment_id": item.ID,
+					"fault_type":    item.FaultType,
+					"target":        item.Target,
+					"intensity":     item.Intensity,
+					"findings":      item.Findings,
+				},
+			}, true)
+		}
 		writeJSON(w, http.StatusOK, item)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -66,14 +85,26 @@ func (s *Server) handleChaosExperimentAction(w http.ResponseWriter, r *http.Requ
 		if r.ContentLength > 0 {
 			_ = json.NewDecoder(r.Body).Decode(&req)
 		}
-		item, err := s.chaosExperiments.Abort(id, req.Reason)
+		var item control.ChaosExperiment
+		var err error
+		if s.chaosInjector.Active(id) {
+			item, err = s.chaosInjector.Stop(id, "aborted", req.Reason)
+		} else {
+			item, err = s.chaosExperiments.Abort(id, req.Reason)
+		}
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
 		writeJSON(w, http.StatusOK, item)
 	case "complete":
-		item, err := s.chaosExperiments.Complete(id)
+		var item control.ChaosExperiment
+		var err error
+		if s.chaosInjector.Active(id) {
+			item, err = s.chaosInjector.Stop(id, "completed", "")
+		} else {
+			item, err = s.chaosExperiments.Complete(id)
+		}
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -83,3 +114,42 @@ func (s *Server) handleChaosExperimentAction(w http.ResponseWriter, r *http.Requ
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown chaos experiment action"})
 	}
 }
+
+// chaosSLOGuard stops injected experiments from starting, or aborts them,
+// while the control plane is already breaching its SLOs: a saturated queue
+// backlog (except for deliberate saturation experiments) or unhealthy
+// health probes.
+func (s *Server) chaosSLOGuard(exp control.ChaosExperiment) string {
+	if exp.FaultType != control.ChaosFaultQueueSaturation {
+		s.metricsMu.Lock()
+		saturated := s.backlogSatActive
+		s.metricsMu.Unlock()
+		if saturated {
+			return "queue backlog SLO is saturated"
+		}
+	}
+	if summary := s.healthProbes.Summary(); summary.Unhealthy > 0 {
+		return strconv.Itoa(summary.Unhealthy) + " health probe targets are unhealthy"
+	}
+	return ""
+}
+
+func (s *Server) noteChaosExperimentFinished(item control.ChaosExperiment) {
+	fields := map[string]any{
+		"experiment_id": item.ID,
+		"fault_type":    item.FaultType,
+		"target":        item.Target,
+		"status":        item.Status,
+		"abort_reason":  item.AbortReason,
+		"observations":  item.Observations,
+		"findings":      item.Findings,
+	}
+	if item.AutoRollbackTriggered {
+		fields["severity"] = "high"
+	}
+	s.recordEvent(control.Event{
+		Type:    "chaos.experiment." + item.Status,
+		Message: "chaos experiment " + item.Status,
+		Fields:  fields,
+	}, true)
+}
@@ -83,3 +83,60 @@ func TestChaosExperimentEndpoints(t *testing.T) {
 		t.Fatalf("list chaos experiments failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestChaosExperimentInjectionGuards(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	create := func(body string) map[string]any {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/control/chaos/experiments", bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("create chaos experiment failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var out map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode chaos experiment failed: %v", err)
+		}
+		return out
+	}
+
+	exp := create(`{"name":"drop-hooks","target":"staging/webhooks","fault_type":"webhook-drop","intensity":100,"duration_sec":60}`)
+	if exp["status"] != "running" || exp["injected"] != true {
+		t.Fatalf("expected running injected experiment, got %+v", exp)
+	}
+	id := exp["id"].(string)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/control/chaos/experiments/"+id+"/abort", bytes.NewReader([]byte(`{"reason":"operator stop"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("abort injected experiment failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var aborted struct {
+		Status                string   `json:"status"`
+		AutoRollbackTriggered bool     `json:"auto_rollback_triggered"`
+		Findings              []string `json:"findings"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &aborted); err != nil {
+		t.Fatalf("decode aborted experiment failed: %v", err)
+	}
+	if aborted.Status != "aborted" || aborted.AutoRollbackTriggered || len(aborted.Findings) == 0 {
+		t.Fatalf("unexpected aborted experiment: %+v", aborted)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/emergency-stop", bytes.NewReader([]byte(`{"enabled":true,"reason":"incident"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enable emergency stop failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	blocked := create(`{"name":"kill-workers","target":"environment=staging","fault_type":"worker-kill","intensity":50,"duration_sec":60}`)
+	if blocked["status"] != "blocked" {
+		t.Fatalf("expected injected experiment to be blocked during emergency stop, got %+v", blocked)
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleCloudCredentialBindings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.cloudCredentials.ListBindings())
+	case http.MethodPost:
+		var req control.CloudCredentialBindingInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.cloudCredentials.CreateBinding(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "execution.cloud_credentials.binding",
+			Message: "cloud credential binding created",
+			Fields: map[string]any{
+				"binding_id":       item.ID,
+				"provider":         item.Provider,
+				"oidc_provider_id": item.OIDCProviderID,
+				"service_account":  item.ServiceAccount,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleCloudCredentialBindingAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/execution/cloud-credentials/bindings/{id}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "execution" || parts[2] != "cloud-credentials" || parts[3] != "bindings" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, ok := s.cloudCredentials.GetBinding(parts[4])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "cloud credential binding not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) handleCloudCredentialLeases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	jobID := strings.TrimSpace(r.URL.Query().Get("job_id"))
+	writeJSON(w, http.StatusOK, s.cloudCredentials.ListLeases(jobID, parseIntQuery(r, "limit", 100)))
+}
+
+func (s *Server) handleCloudCredentialLeaseAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/execution/cloud-credentials/leases/{id}[/revoke]
+	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "execution" || parts[2] != "cloud-credentials" || parts[3] != "leases" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(parts) == 5 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		item, ok := s.cloudCredentials.GetLease(parts[4])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cloud credential lease not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
+	if len(parts) != 6 || parts[5] != "revoke" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, err := s.cloudCredentials.Release(parts[4])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "execution.cloud_credentials.revoked",
+		Message: "cloud credential lease ended manually",
+		Fields: map[string]any{
+			"lease_id": item.ID,
+			"job_id":   item.JobID,
+			"status":   item.Status,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, item)
+}
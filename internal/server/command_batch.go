@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+type commandIngestReq struct {
+	Action         string `json:"action"`
+	ConfigPath     string `json:"config_path"`
+	Priority       string `json:"priority"`
+	IdempotencyKey string `json:"idempotency_key"`
+	Checksum       string `json:"checksum"`
+	Force          bool   `json:"force"`
+}
+
+type commandBatchItem struct {
+	Index   int                      `json:"index"`
+	Status  string                   `json:"status"`
+	Command *control.CommandEnvelope `json:"command,omitempty"`
+	Job     *control.Job             `json:"job,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// decodeCommandBody decodes a JSON command body, inflating it first when
+// the client sent Content-Encoding: gzip. The inflated size is held to the
+// route's body limit so a small compressed body cannot expand unbounded.
+func (s *Server) decodeCommandBody(r *http.Request, v any) error {
+	var body io.Reader = r.Body
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return errors.New("invalid gzip body")
+		}
+		defer zr.Close()
+		limit := s.requestBodyLimits().limitFor(r.URL.Path)
+		raw, err := io.ReadAll(io.LimitReader(zr, limit+1))
+		if err != nil {
+			return errors.New("invalid gzip body")
+		}
+		if int64(len(raw)) > limit {
+			return errors.New("decompressed body exceeds " + strconv.FormatInt(limit, 10) + " bytes")
+		}
+		body = bytes.NewReader(raw)
+	default:
+		return errors.New("unsupported content encoding")
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return errors.New("invalid json body")
+	}
+	return nil
+}
+
+// checkCommandEnvelope validates an ingested command. A non-empty reason
+// means the envelope belongs in the dead-letter queue, answered with code.
+func checkCommandEnvelope(baseDir string, req commandIngestReq) (control.CommandEnvelope, string, string, int) {
+	env := control.CommandEnvelope{
+		Action:         req.Action,
+		ConfigPath:     req.ConfigPath,
+		Priority:       req.Priority,
+		IdempotencyKey: req.IdempotencyKey,
+		Checksum:       req.Checksum,
+	}
+	if strings.TrimSpace(req.Checksum) == "" {
+		return env, "", "checksum is required", http.StatusUnprocessableEntity
+	}
+	expected := control.ComputeCommandChecksum(req.Action, req.ConfigPath, req.Priority, req.IdempotencyKey)
+	if !strings.EqualFold(strings.TrimSpace(req.Checksum), expected) {
+		return env, "", "checksum mismatch", http.StatusUnprocessableEntity
+	}
+	if strings.ToLower(strings.TrimSpace(req.Action)) != "apply" {
+		return env, "", "unsupported action", http.StatusBadRequest
+	}
+	if strings.TrimSpace(req.ConfigPath) == "" {
+		return env, "", "config_path is required", http.StatusBadRequest
+	}
+	configPath := req.ConfigPath
+	if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(baseDir, configPath)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return env, "", "config_path not found", http.StatusBadRequest
+	}
+	return env, configPath, "", 0
+}
+
+func (s *Server) handleCommandIngestBatch(baseDir string) http.HandlerFunc {
+	type reqBody struct {
+		Mode     string             `json:"mode"`
+		Commands []commandIngestReq `json:"commands"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req reqBody
+		if err := s.decodeCommandBody(r, &req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		policy := s.commands.BatchPolicy()
+		mode, err := control.NormalizeCommandBatchMode(req.Mode, policy.DefaultMode)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if len(req.Commands) == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "commands are required"})
+			return
+		}
+		if len(req.Commands) > policy.MaxItems {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{
+				"error":     "batch exceeds max_items",
+				"max_items": policy.MaxItems,
+			})
+			return
+		}
+		forceAll := strings.ToLower(r.Header.Get("X-Force-Apply")) == "true"
+
+		items := make([]commandBatchItem, len(req.Commands))
+		envs := make([]control.CommandEnvelope, len(req.Commands))
+		paths := make([]string, len(req.Commands))
+		invalid := false
+		for i, cmd := range req.Commands {
+			items[i].Index = i
+			env, path, reason, _ := checkCommandEnvelope(baseDir, cmd)
+			envs[i], paths[i] = env, path
+			if reason != "" {
+				dlq := s.commands.RecordDeadLetter(env, reason)
+				items[i].Status, items[i].Command, items[i].Error = "dead_letter", &dlq, reason
+				invalid = true
+			}
+		}
+		code := http.StatusAccepted
+		if mode == control.CommandBatchAtomic && invalid {
+			for i := range items {
+				if items[i].Status == "" {
+					items[i].Status, items[i].Error = "rejected", "batch rejected: another command failed validation"
+				}
+			}
+			code = http.StatusUnprocessableEntity
+		} else if mode == control.CommandBatchAtomic {
+			code = s.enqueueCommandBatchAtomic(req.Commands, envs, paths, items, forceAll)
+		} else {
+			for i, cmd := range req.Commands {
+				if items[i].Status != "" {
+					continue
+				}
+				job, err := s.queue.Enqueue(paths[i], cmd.IdempotencyKey, cmd.Force || forceAll, cmd.Priority)
+				if err != nil {
+					dlq := s.commands.RecordDeadLetter(envs[i], err.Error())
+					items[i].Status, items[i].Command, items[i].Error = "dead_letter", &dlq, err.Error()
+					continue
+				}
+				accepted := s.commands.RecordAccepted(envs[i])
+				items[i].Status, items[i].Command, items[i].Job = "accepted", &accepted, job
+			}
+		}
+
+		counts := map[string]int{"accepted": 0, "dead_letter": 0, "rejected": 0}
+		for _, item := range items {
+			counts[item.Status]++
+		}
+		if mode == control.CommandBatchIndividual && counts["accepted"] == 0 {
+			code = http.StatusUnprocessableEntity
+		}
+		s.events.Append(control.Event{
+			Type:    "command.batch_ingested",
+			Message: "asynchronous command batch ingested",
+			Fields: map[string]any{
+				"mode":          mode,
+				"count":         len(items),
+				"accepted":      counts["accepted"],
+				"dead_lettered": counts["dead_letter"],
+				"rejected":      counts["rejected"],
+			},
+		})
+		writeJSON(w, code, map[string]any{
+			"mode":          mode,
+			"count":         len(items),
+			"accepted":      counts["accepted"],
+			"dead_lettered": counts["dead_letter"],
+			"rejected":      counts["rejected"],
+			"items":         items,
+		})
+	}
+}
+
+// enqueueCommandBatchAtomic enqueues every command or none: if one enqueue
+// fails, jobs created earlier in the batch are canceled and the rest of the
+// batch is rejected. Jobs that already existed under an idempotency key are
+// left alone.
+func (s *Server) enqueueCommandBatchAtomic(cmds []commandIngestReq, envs []control.CommandEnvelope, paths []string, items []commandBatchItem, forceAll bool) int {
+	started := time.Now().UTC()
+	jobs := make([]*control.Job, len(cmds))
+	for i, cmd := range cmds {
+		job, err := s.queue.Enqueue(paths[i], cmd.IdempotencyKey, cmd.Force || forceAll, cmd.Priority)
+		if err == nil {
+			jobs[i] = job
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if !jobs[j].CreatedAt.Before(started) {
+				_ = s.queue.Cancel(jobs[j].ID)
+			}
+		}
+		dlq := s.commands.RecordDeadLetter(envs[i], err.Error())
+		items[i].Status, items[i].Command, items[i].Error = "dead_letter", &dlq, err.Error()
+		for j := range items {
+			if items[j].Status == "" {
+				items[j].Status, items[j].Error = "rejected", "batch rejected: command "+strconv.Itoa(i)+" could not be queued"
+			}
+		}
+		return http.StatusConflict
+	}
+	for i := range cmds {
+		accepted := s.commands.RecordAccepted(envs[i])
+		items[i].Status, items[i].Command, items[i].Job = "accepted", &accepted, jobs[i]
+	}
+	return http.StatusAccepted
+}
+
+func (s *Server) handleCommandIngestPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.commands.BatchPolicy())
+	case http.MethodPost:
+		var req control.CommandBatchPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.commands.SetBatchPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "command.ingest_policy.updated",
+			Message: "command batch ingest policy updated",
+			Fields: map[string]any{
+				"max_items":    policy.MaxItems,
+				"default_mode": policy.DefaultMode,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
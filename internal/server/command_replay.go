@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+type commandReplayResult struct {
+	DeadLetter control.CommandEnvelope  `json:"dead_letter"`
+	Command    *control.CommandEnvelope `json:"command,omitempty"`
+	Job        *control.Job             `json:"job,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// replayDeadLetter re-validates and enqueues a dead letter. Non-empty fields
+// in fix replace the stored ones, so a corrected checksum or config path can
+// be supplied with the replay. Failures are counted against the dead letter.
+func (s *Server) replayDeadLetter(dl control.CommandEnvelope, fix commandIngestReq) (commandReplayResult, int) {
+	req := commandIngestReq{
+		Action:         dl.Action,
+		ConfigPath:     dl.ConfigPath,
+		Priority:       dl.Priority,
+		IdempotencyKey: dl.IdempotencyKey,
+		Checksum:       dl.Checksum,
+		Force:          fix.Force,
+	}
+	if strings.TrimSpace(fix.Action) != "" {
+		req.Action = fix.Action
+	}
+	if strings.TrimSpace(fix.ConfigPath) != "" {
+		req.ConfigPath = fix.ConfigPath
+	}
+	if strings.TrimSpace(fix.Priority) != "" {
+		req.Priority = fix.Priority
+	}
+	if strings.TrimSpace(fix.IdempotencyKey) != "" {
+		req.IdempotencyKey = fix.IdempotencyKey
+	}
+	if strings.TrimSpace(fix.Checksum) != "" {
+		req.Checksum = fix.Checksum
+	}
+	fail := func(reason string, code int) (commandReplayResult, int) {
+		updated, err := s.commands.RecordReplayFailure(dl.ID, reason)
+		if err != nil {
+			updated = dl
+		}
+		return commandReplayResult{DeadLetter: updated, Error: reason}, code
+	}
+	if dl.Status != "dead_letter" {
+		return commandReplayResult{DeadLetter: dl, Error: "dead letter already replayed"}, http.StatusConflict
+	}
+	env, configPath, reason, code := checkCommandEnvelope(s.baseDir, req)
+	if reason != "" {
+		return fail(reason, code)
+	}
+	job, err := s.queue.Enqueue(configPath, req.IdempotencyKey, req.Force, req.Priority)
+	if err != nil {
+		return fail(err.Error(), http.StatusConflict)
+	}
+	accepted := s.commands.RecordAccepted(env)
+	updated, err := s.commands.MarkReplayed(dl.ID, accepted.ID, job.ID)
+	if err != nil {
+		updated = dl
+	}
+	return commandReplayResult{DeadLetter: updated, Command: &accepted, Job: job}, http.StatusAccepted
+}
+
+func (s *Server) handleCommandDeadLetterAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	switch {
+	case len(parts) == 4 && parts[3] == "replay":
+		s.handleCommandDeadLetterBulkReplay(w, r)
+	case len(parts) == 4:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		dl, err := s.commands.GetDeadLetter(parts[3])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, dl)
+	case len(parts) == 5 && parts[4] == "replay":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var fix commandIngestReq
+		if err := json.NewDecoder(r.Body).Decode(&fix); err != nil && !errors.Is(err, io.EOF) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		dl, err := s.commands.GetDeadLetter(parts[3])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		res, code := s.replayDeadLetter(dl, fix)
+		s.recordDeadLetterReplay(res, "manual")
+		writeJSON(w, code, res)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown dead letter path"})
+	}
+}
+
+func (s *Server) handleCommandDeadLetterBulkReplay(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		control.CommandDeadLetterFilter
+		Force bool `json:"force"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req reqBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	filter := req.CommandDeadLetterFilter
+	filter.Status = "dead_letter"
+	if limit := s.commands.BatchPolicy().MaxItems; filter.Limit <= 0 || filter.Limit > limit {
+		filter.Limit = limit
+	}
+	items := make([]commandReplayResult, 0)
+	replayed := 0
+	for _, dl := range s.commands.FilterDeadLetters(filter) {
+		res, _ := s.replayDeadLetter(dl, commandIngestReq{Force: req.Force})
+		s.recordDeadLetterReplay(res, "bulk")
+		if res.Error == "" {
+			replayed++
+		}
+		items = append(items, res)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count":    len(items),
+		"replayed": replayed,
+		"failed":   len(items) - replayed,
+		"items":    items,
+	})
+}
+
+func (s *Server) recordDeadLetterReplay(res commandReplayResult, trigger string) {
+	fields := map[string]any{
+		"dead_letter_id": res.DeadLetter.ID,
+		"trigger":        trigger,
+		"attempts":       res.DeadLetter.Attempts,
+	}
+	if res.Error != "" {
+		fields["error"] = res.Error
+		fields["failure_class"] = res.DeadLetter.FailureClass
+		s.recordEvent(control.Event{
+			Type:    "command.dead_letter.replay_failed",
+			Message: "dead-lettered command replay failed",
+			Fields:  fields,
+		}, true)
+		return
+	}
+	fields["command_id"] = res.Command.ID
+	fields["job_id"] = res.Job.ID
+	s.recordEvent(control.Event{
+		Type:    "command.dead_letter.replayed",
+		Message: "dead-lettered command replayed",
+		Fields:  fields,
+	}, true)
+}
+
+func (s *Server) handleCommandRetryPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.commands.RetryPolicy())
+	case http.MethodPost:
+		var req control.CommandRetryPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.commands.SetRetryPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "command.retry_policy.updated",
+			Message: "command dead-letter retry policy updated",
+			Fields: map[string]any{
+				"enabled":      policy.Enabled,
+				"max_attempts": policy.MaxAttempts,
+				"classes":      policy.Classes,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// sweepCommandRetries replays dead letters whose automatic retry is due.
+func (s *Server) sweepCommandRetries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.retryDueDeadLetters(time.Now().UTC())
+		}
+	}
+}
+
+func (s *Server) retryDueDeadLetters(now time.Time) []commandReplayResult {
+	out := make([]commandReplayResult, 0)
+	for _, dl := range s.commands.DueRetries(now) {
+		res, _ := s.replayDeadLetter(dl, commandIngestReq{})
+		s.recordDeadLetterReplay(res, "auto_retry")
+		out = append(out, res)
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleGrainModules serves GET/POST /v1/compat/grains/modules, the registry
+// of custom grain modules agents run.
+func (s *Server) handleGrainModules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.grainModules.List())
+	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req control.GrainModuleInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if pipeline := strings.TrimSpace(req.Pipeline); pipeline != "" {
+			if _, ok := s.fileSync.Get(pipeline); !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "file sync pipeline not found"})
+				return
+			}
+		}
+		item, err := s.grainModules.Upsert(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "compat.grains.module.updated",
+			Message: "custom grain module " + item.Name + " updated",
+			Fields: map[string]any{
+				"module":   item.Name,
+				"pipeline": item.Pipeline,
+				"path":     item.Path,
+				"enabled":  item.Enabled,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleGrainModuleByName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/compat/grains/modules/")
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := s.grainModules.Get(name)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "grain module not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		if err := s.grainModules.Delete(name); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGrainModulesDue serves GET /v1/compat/grains/agent/due?node=, which
+// agents poll for modules whose cached result has expired.
+func (s *Server) handleGrainModulesDue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	node := strings.TrimSpace(r.URL.Query().Get("node"))
+	if node == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "node is required"})
+		return
+	}
+	items := s.grainModules.Due(node, time.Now().UTC())
+	writeJSON(w, http.StatusOK, map[string]any{"node": node, "count": len(items), "items": items})
+}
+
+// handleGrainModuleResults serves GET /v1/compat/grains/agent/results?node=
+// and POST, where agents report a module run.
+func (s *Server) handleGrainModuleResults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.grainModules.Results(r.URL.Query().Get("node"))
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.GrainModuleResult
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.grainModules.Record(req)
+		if err != nil {
+			code := http.StatusBadRequest
+			if strings.Contains(err.Error(), "not found") {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		s.noteGrainModuleResult(item)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGrainModulesRun serves POST /v1/compat/grains/agent/run for the
+// control plane's local agent: it runs the node's due modules (all enabled
+// modules with force) from their file-sync live paths and caches the results.
+func (s *Server) handleGrainModulesRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.requireControlAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Node  string `json:"node"`
+		Force bool   `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if strings.TrimSpace(req.Node) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "node is required"})
+		return
+	}
+	modules := s.grainModules.Due(req.Node, time.Now().UTC())
+	if req.Force {
+		modules = modules[:0]
+		for _, item := range s.grainModules.List() {
+			if item.Enabled {
+				modules = append(modules, item)
+			}
+		}
+	}
+	out := make([]control.GrainModuleResult, 0, len(modules))
+	for _, module := range modules {
+		var res control.GrainModuleResult
+		if pipeline, ok := s.fileSync.Get(module.Pipeline); ok {
+			res = s.grainModules.Run(r.Context(), module, pipeline.LivePath, req.Node)
+		} else {
+			res = control.GrainModuleResult{Node: req.Node, Module: module.Name, Error: "file sync pipeline not found"}
+		}
+		item, err := s.grainModules.Record(res)
+		if err != nil {
+			continue
+		}
+		s.noteGrainModuleResult(item)
+		out = append(out, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"node": req.Node, "count": len(out), "items": out})
+}
+
+func (s *Server) noteGrainModuleResult(item control.GrainModuleResult) {
+	if item.Error == "" {
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "compat.grains.module.failed",
+		Message: "custom grain module " + item.Module + " failed on " + item.Node,
+		Fields: map[string]any{
+			"module": item.Module,
+			"node":   item.Node,
+			"error":  item.Error,
+		},
+	}, true)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestCustomGrainModulesRunCacheAndTarget(t *testing.T) {
+	t.Setenv("MC_GRAIN_MODULE_INTERPRETERS", "/bin/sh")
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	grantControlAdmin(t, s, "ops-admin")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "ops-admin")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	staging := filepath.Join(tmp, "staging")
+	live := filepath.Join(tmp, "live")
+	if err := os.MkdirAll(filepath.Join(staging, "grains"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nprintf '{\"rack\":\"r12\",\"id\":\"spoofed\",\"seen_node\":\"%s\"}' \"$MASTERCHEF_NODE\"\n"
+	if err := os.WriteFile(filepath.Join(staging, "grains", "rack.sh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := s.fileSync.Create(control.FileSyncPipelineInput{Name: "grains", StagingPath: staging, LivePath: live})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.fileSync.Run(pipeline.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := do(http.MethodPost, "/v1/compat/grains/modules", `{"name":"rack","pipeline":"filesync-404","path":"grains/rack.sh"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown pipeline to be rejected, got code=%d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/compat/grains/modules", `{"name":"rack","pipeline":"`+pipeline.ID+`","path":"grains/rack.sh","interpreter":"/usr/bin/python3"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unlisted interpreter to be rejected, got code=%d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v1/compat/grains/modules", `{"name":"rack","pipeline":"`+pipeline.ID+`","path":"grains/rack.sh","interpreter":"/bin/sh","cache_ttl_seconds":600}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("register grain module failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/v1/compat/grains/agent/due?node=web-9", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("expected module due for new node: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	anon := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(anon, httptest.NewRequest(http.MethodPost, "/v1/compat/grains/agent/run", strings.NewReader(`{"node":"web-9"}`)))
+	if anon.Code != http.StatusUnauthorized {
+		t.Fatalf("expected anonymous module run to be rejected, got code=%d", anon.Code)
+	}
+	rr = do(http.MethodPost, "/v1/compat/grains/agent/run", `{"node":"web-9"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("run grain modules failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var run struct {
+		Items []control.GrainModuleResult `json:"items"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &run)
+	if len(run.Items) != 1 || run.Items[0].Error != "" {
+		t.Fatalf("expected one successful module run, got %s", rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/compat/grains/agent/due?node=web-9", ""); !strings.Contains(rr.Body.String(), `"count":0`) {
+		t.Fatalf("expected cached result to clear due list, body=%s", rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/v1/compat/grains?node=web-9", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get custom grains failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var rec control.GrainRecord
+	_ = json.Unmarshal(rr.Body.Bytes(), &rec)
+	if rec.Grains["rack"] != "r12" || rec.Grains["id"] != "web-9" || rec.Grains["seen_node"] != "web-9" {
+		t.Fatalf("expected custom grains merged without overriding id, got %+v", rec.Grains)
+	}
+
+	rr = do(http.MethodPost, "/v1/compat/grains/query", `{"grain":"rack","equals":"r12"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("expected custom grain to be targetable: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Agents running modules themselves report results directly.
+	rr = do(http.MethodPost, "/v1/compat/grains/agent/results", `{"node":"db-1","module":"rack","grains":{"rack":"r7"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("report grain result failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/compat/grains/agent/results", `{"node":"db-1","module":"nope"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown module result to be rejected, got code=%d", rr.Code)
+	}
+	rr = do(http.MethodGet, "/v1/compat/grains?grain=rack&equals=r7", "")
+	if !strings.Contains(rr.Body.String(), `"node":"db-1"`) || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("expected reported grain to be targetable, body=%s", rr.Body.String())
+	}
+
+	if rr := do(http.MethodDelete, "/v1/compat/grains/modules/rack", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete grain module failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/compat/grains?node=web-9", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected custom grains dropped with their module, got code=%d", rr.Code)
+	}
+}
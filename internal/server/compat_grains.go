@@ -3,8 +3,10 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -16,12 +18,12 @@ func (s *Server) handleCompatGrains(w http.ResponseWriter, r *http.Request) {
 	}
 	node := strings.TrimSpace(r.URL.Query().Get("node"))
 	if node != "" {
-		item, ok := s.facts.Get(node)
+		item, ok := s.grainRecord(node)
 		if !ok {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "grain record not found"})
 			return
 		}
-		writeJSON(w, http.StatusOK, control.FactRecordToGrains(item))
+		writeJSON(w, http.StatusOK, item)
 		return
 	}
 	limit := 100
@@ -30,16 +32,12 @@ func (s *Server) handleCompatGrains(w http.ResponseWriter, r *http.Request) {
 			limit = n
 		}
 	}
-	items := s.facts.Query(control.FactCacheQuery{
-		Field:    strings.TrimSpace(r.URL.Query().Get("grain")),
+	out := control.FilterGrainRecords(s.grainRecords(), control.GrainQueryInput{
+		Grain:    strings.TrimSpace(r.URL.Query().Get("grain")),
 		Equals:   strings.TrimSpace(r.URL.Query().Get("equals")),
 		Contains: strings.TrimSpace(r.URL.Query().Get("contains")),
 		Limit:    limit,
 	})
-	out := make([]control.GrainRecord, 0, len(items))
-	for _, item := range items {
-		out = append(out, control.FactRecordToGrains(item))
-	}
 	writeJSON(w, http.StatusOK, map[string]any{
 		"count": len(out),
 		"items": out,
@@ -56,15 +54,41 @@ func (s *Server) handleCompatGrainsQuery(w http.ResponseWriter, r *http.Request)
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 		return
 	}
-	query := control.GrainQueryToFactQuery(req)
-	items := s.facts.Query(query)
-	out := make([]control.GrainRecord, 0, len(items))
-	for _, item := range items {
-		out = append(out, control.FactRecordToGrains(item))
-	}
+	out := control.FilterGrainRecords(s.grainRecords(), req)
 	writeJSON(w, http.StatusOK, map[string]any{
 		"count": len(out),
 		"items": out,
 		"query": req,
 	})
 }
+
+// grainRecords returns the grains of every node: cached facts overlaid with
+// unexpired custom grain module results.
+func (s *Server) grainRecords() []control.GrainRecord {
+	now := time.Now().UTC()
+	custom := s.grainModules.Grains(now)
+	out := make([]control.GrainRecord, 0, len(custom))
+	for _, item := range s.facts.List() {
+		out = append(out, control.MergeCustomGrains(control.FactRecordToGrains(item), custom[item.Node]))
+		delete(custom, item.Node)
+	}
+	for node, grains := range custom {
+		rec := control.FactRecordToGrains(control.FactRecord{Node: node, UpdatedAt: now})
+		out = append(out, control.MergeCustomGrains(rec, grains))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Node < out[j].Node })
+	return out
+}
+
+func (s *Server) grainRecord(node string) (control.GrainRecord, bool) {
+	node = strings.ToLower(strings.TrimSpace(node))
+	custom := s.grainModules.Grains(time.Now().UTC())[node]
+	if item, ok := s.facts.Get(node); ok {
+		return control.MergeCustomGrains(control.FactRecordToGrains(item), custom), true
+	}
+	if len(custom) == 0 {
+		return control.GrainRecord{}, false
+	}
+	rec := control.FactRecordToGrains(control.FactRecord{Node: node, UpdatedAt: time.Now().UTC()})
+	return control.MergeCustomGrains(rec, custom), true
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+type responseCompressionSettings struct {
+	Enabled  bool `json:"enabled"`
+	MinBytes int  `json:"min_bytes"`
+	Level    int  `json:"level"`
+}
+
+// loadResponseCompressionSettings reads MC_RESPONSE_COMPRESSION (set to
+// false/off/0 to disable on CPU-constrained deployments),
+// MC_RESPONSE_COMPRESSION_MIN_BYTES, and MC_RESPONSE_COMPRESSION_LEVEL.
+func loadResponseCompressionSettings() responseCompressionSettings {
+	enabled := true
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("MC_RESPONSE_COMPRESSION"))) {
+	case "false", "off", "0", "no":
+		enabled = false
+	}
+	return normalizeResponseCompression(responseCompressionSettings{
+		Enabled:  enabled,
+		MinBytes: readIntEnv("MC_RESPONSE_COMPRESSION_MIN_BYTES", 1024),
+		Level:    readIntEnv("MC_RESPONSE_COMPRESSION_LEVEL", 5),
+	})
+}
+
+func normalizeResponseCompression(in responseCompressionSettings) responseCompressionSettings {
+	if in.MinBytes <= 0 {
+		in.MinBytes = 1024
+	}
+	if in.Level < flate.BestSpeed || in.Level > flate.BestCompression {
+		in.Level = 5
+	}
+	return in
+}
+
+func (s *Server) responseCompressionSettings() responseCompressionSettings {
+	s.compressionMu.RLock()
+	defer s.compressionMu.RUnlock()
+	return s.compression
+}
+
+func (s *Server) handleResponseCompression(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req responseCompressionSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		req = normalizeResponseCompression(req)
+		s.compressionMu.Lock()
+		s.compression = req
+		s.compressionMu.Unlock()
+		s.recordEvent(control.Event{
+			Type:    "control.response_compression.updated",
+			Message: "response compression settings updated",
+			Fields: map[string]any{
+				"enabled":   req.Enabled,
+				"min_bytes": req.MinBytes,
+				"level":     req.Level,
+			},
+		}, true)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.metricsMu.Lock()
+	stats := map[string]int64{}
+	for _, key := range []string{"responses", "responses.gzip", "responses.deflate", "bytes_in", "bytes_out", "bytes_saved"} {
+		stats[key] = s.metrics["compression."+key]
+	}
+	s.metricsMu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": s.responseCompressionSettings(),
+		"stats":    stats,
+	})
+}
+
+// negotiateResponseEncoding picks gzip or deflate from Accept-Encoding,
+// honouring q=0 exclusions and preferring gzip on ties.
+func negotiateResponseEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if name == "*" {
+			name = "gzip"
+		}
+		if (name != "gzip" && name != "deflate") || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressResponseWriter buffers the start of a response until it knows
+// whether the body is large enough to be worth compressing. Event streams,
+// bodiless statuses, and already-encoded responses pass through untouched.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	settings responseCompressionSettings
+
+	status   int
+	buf      []byte
+	decided  bool
+	enc      io.WriteCloser
+	counter  *countingWriter
+	bytesIn  int64
+	bytesOut int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// newCompressResponseWriter returns nil when the request should not be
+// compressed.
+func (s *Server) newCompressResponseWriter(w http.ResponseWriter, r *http.Request) *compressResponseWriter {
+	settings := s.responseCompressionSettings()
+	if !settings.Enabled || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+		return nil
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	encoding := negotiateResponseEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return nil
+	}
+	return &compressResponseWriter{ResponseWriter: w, encoding: encoding, settings: settings}
+}
+
+func (c *compressResponseWriter) WriteHeader(code int) {
+	if c.decided || c.status != 0 {
+		return
+	}
+	c.status = code
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		_ = c.decide(false)
+	}
+}
+
+func (c *compressResponseWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.bytesIn += int64(len(p))
+	if c.decided {
+		if c.enc != nil {
+			return c.enc.Write(p)
+		}
+		c.bytesOut += int64(len(p))
+		return c.ResponseWriter.Write(p)
+	}
+	c.buf = append(c.buf, p...)
+	if len(c.buf) >= c.settings.MinBytes {
+		if err := c.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *compressResponseWriter) Flush() {
+	if !c.decided {
+		_ = c.decide(false)
+	}
+	if f, ok := c.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close flushes any buffered body and finishes the compressed stream.
+func (c *compressResponseWriter) Close() error {
+	if !c.decided {
+		if err := c.decide(len(c.buf) >= c.settings.MinBytes); err != nil {
+			return err
+		}
+	}
+	if c.enc == nil {
+		return nil
+	}
+	err := c.enc.Close()
+	c.bytesOut = c.counter.n
+	return err
+}
+
+func (c *compressResponseWriter) decide(compress bool) error {
+	c.decided = true
+	h := c.Header()
+	if h.Get("Content-Encoding") != "" || strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") {
+		compress = false
+	}
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if compress {
+		h.Set("Content-Encoding", c.encoding)
+		h.Del("Content-Length")
+		// Validators cover the identity body, so the encoded form is only
+		// weakly equivalent.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		c.counter = &countingWriter{w: c.ResponseWriter}
+		if c.encoding == "gzip" {
+			c.enc, _ = gzip.NewWriterLevel(c.counter, c.settings.Level)
+		} else {
+			c.enc, _ = flate.NewWriter(c.counter, c.settings.Level)
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	if len(c.buf) == 0 {
+		return nil
+	}
+	buf := c.buf
+	c.buf = nil
+	if c.enc != nil {
+		_, err := c.enc.Write(buf)
+		return err
+	}
+	c.bytesOut += int64(len(buf))
+	_, err := c.ResponseWriter.Write(buf)
+	return err
+}
+
+func (s *Server) recordResponseCompression(c *compressResponseWriter) {
+	if c.enc == nil {
+		return
+	}
+	s.metricsMu.Lock()
+	s.metrics["compression.responses"]++
+	s.metrics["compression.responses."+c.encoding]++
+	s.metrics["compression.bytes_in"] += c.bytesIn
+	s.metrics["compression.bytes_out"] += c.bytesOut
+	s.metrics["compression.bytes_saved"] += c.bytesIn - c.bytesOut
+	s.metricsMu.Unlock()
+}
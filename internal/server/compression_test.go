@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateResponseEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                          "",
+		"gzip, deflate":             "gzip",
+		"deflate":                   "deflate",
+		"gzip;q=0.2, deflate":       "deflate",
+		"gzip;q=0, identity":        "",
+		"br, *;q=0.5":               "gzip",
+		"identity;q=1, deflate;q=0": "",
+	}
+	for header, want := range cases {
+		if got := negotiateResponseEncoding(header); got != want {
+			t.Fatalf("negotiate %q: got %q want %q", header, got, want)
+		}
+	}
+}
+
+func TestResponseCompressionMiddleware(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/release/api-contract", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip response: code=%d headers=%v", rr.Code, rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	var spec struct {
+		Endpoints []string `json:"endpoints"`
+	}
+	if err := json.Unmarshal(plain, &spec); err != nil || len(spec.Endpoints) == 0 {
+		t.Fatalf("unexpected decompressed body: err=%v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected small body to stay uncompressed")
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/response-compression", nil))
+	var status struct {
+		Stats map[string]int64 `json:"stats"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &status)
+	if status.Stats["responses.gzip"] != 1 || status.Stats["bytes_saved"] <= 0 {
+		t.Fatalf("expected compression metrics: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/response-compression", bytes.NewReader([]byte(`{"enabled":false}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("disable compression failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/release/api-contract", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Content-Encoding") != "" || !json.Valid(rr.Body.Bytes()) {
+		t.Fatalf("expected plain response when compression disabled")
+	}
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleConfigAsCode(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.configAsCode.Status())
+	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req control.ConfigAsCodeSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		req.Dir = s.resolveConfigAsCodeDir(req.Dir)
+		settings, err := s.configAsCode.Configure(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "config_as_code.configured",
+			Message: "configuration as code settings updated",
+			Fields: map[string]any{
+				"enabled": settings.Enabled,
+				"dir":     settings.Dir,
+				"git":     settings.Git,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, s.configAsCode.Status())
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleConfigAsCodeSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	out, err := s.configAsCode.Sync(s.configAsCodeSnapshot(), "masterchef: manual configuration sync")
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleConfigAsCodeImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	out, err := s.importConfigAsCode()
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "config_as_code.imported",
+		Message: "control-plane configuration imported from files",
+		Fields:  map[string]any{"counts": out},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]any{"imported": out})
+}
+
+func (s *Server) handleConfigAsCodeDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	out, err := s.configAsCode.Diff(s.configAsCodeSnapshot())
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) configAsCodeSnapshot() control.ConfigAsCodeSnapshot {
+	return control.ConfigAsCodeSnapshot{
+		Templates: s.templates.List(),
+		Runbooks:  s.runbooks.List(),
+		Rules:     s.rules.List(),
+		Schedules: s.scheduler.List(),
+	}
+}
+
+// importConfigAsCode restores entities from files into the live stores,
+// keeping their IDs, and returns how many of each kind were loaded.
+func (s *Server) importConfigAsCode() (map[string]int, error) {
+	snap, err := s.configAsCode.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range snap.Templates {
+		content, err := os.ReadFile(item.ConfigPath)
+		if err != nil {
+			continue
+		}
+		result, err := s.scanForSecrets(control.SecretScanInput{Kind: "template", Target: item.Name, Team: item.Team, Content: string(content)})
+		if err == nil && result.Blocked {
+			return nil, errors.New("secret scan " + result.ID + " blocked import of template " + item.Name)
+		}
+	}
+	return s.restoreConfigAsCodeSnapshot(snap)
+}
+
+func (s *Server) restoreConfigAsCodeSnapshot(snap control.ConfigAsCodeSnapshot) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, item := range snap.Templates {
+		if _, err := s.templates.Restore(item); err != nil {
+			return counts, err
+		}
+		counts[control.ConfigAsCodeTemplates]++
+	}
+	for _, item := range snap.Runbooks {
+		if _, err := s.runbooks.Restore(item); err != nil {
+			return counts, err
+		}
+		counts[control.ConfigAsCodeRunbooks]++
+	}
+	for _, item := range snap.Rules {
+		if _, err := s.rules.Restore(item); err != nil {
+			return counts, err
+		}
+		counts[control.ConfigAsCodeRules]++
+	}
+	for _, item := range snap.Schedules {
+		if _, err := s.scheduler.Restore(item); err != nil {
+			return counts, err
+		}
+		counts[control.ConfigAsCodeSchedules]++
+	}
+	return counts, nil
+}
+
+// syncConfigAsCodeAfter mirrors live state to files after a mutating
+// request. Requests that leave the mirrored stores unchanged skip the sync.
+func (s *Server) syncConfigAsCodeAfter(r *http.Request) {
+	if s.configAsCode == nil || !s.configAsCode.Enabled() {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/v1/config-as-code") {
+		return
+	}
+	if _, _, err := s.configAsCode.SyncChanged(s.configAsCodeSnapshot(), "masterchef: "+r.Method+" "+r.URL.Path); err != nil {
+		s.requestLogger(r.Context()).Warn("configuration as code sync failed", "error", err)
+		s.events.Append(control.Event{
+			Type:    "config_as_code.sync.error",
+			Message: "configuration as code sync failed",
+			Fields: map[string]any{
+				"path":  r.URL.Path,
+				"error": err.Error(),
+			},
+		})
+	}
+}
+
+// loadConfigAsCodeFromEnv enables configuration as code when
+// MC_CONFIG_AS_CODE_DIR is set and re-imports any files found there.
+func (s *Server) loadConfigAsCodeFromEnv() {
+	dir := strings.TrimSpace(os.Getenv("MC_CONFIG_AS_CODE_DIR"))
+	if dir == "" {
+		return
+	}
+	gitMode := strings.EqualFold(strings.TrimSpace(os.Getenv("MC_CONFIG_AS_CODE_GIT")), "true")
+	if _, err := s.configAsCode.Configure(control.ConfigAsCodeSettings{
+		Enabled: true,
+		Dir:     s.resolveConfigAsCodeDir(dir),
+		Git:     gitMode,
+	}); err != nil {
+		s.events.Append(control.Event{
+			Type:    "config_as_code.configure.error",
+			Message: "configuration as code could not be enabled",
+			Fields:  map[string]any{"error": err.Error()},
+		})
+		return
+	}
+	counts, err := s.importConfigAsCode()
+	if err != nil {
+		s.events.Append(control.Event{
+			Type:    "config_as_code.import.error",
+			Message: "configuration as code import failed",
+			Fields:  map[string]any{"error": err.Error()},
+		})
+		return
+	}
+	s.events.Append(control.Event{
+		Type:    "config_as_code.imported",
+		Message: "control-plane configuration imported from files",
+		Fields:  map[string]any{"counts": counts},
+	})
+}
+
+func (s *Server) resolveConfigAsCodeDir(dir string) string {
+	dir = strings.TrimSpace(dir)
+	if dir == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(s.baseDir, dir)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigAsCodeSyncDiffAndStartupImport(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte("version: v0\ninventory:\n  hosts: []\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cacDir := filepath.Join(tmp, "cac")
+
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(srv *Server, method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(s, http.MethodGet, "/v1/config-as-code/diff", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected diff to require enabled mode: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(s, http.MethodPost, "/v1/config-as-code", `{"enabled":true,"dir":"cac"}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthenticated configure to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	grantControlAdmin(t, s, "config-admin")
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/config-as-code", strings.NewReader(`{"enabled":true,"dir":"cac"}`))
+	req.Header.Set("X-Masterchef-Principal", "config-admin")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), cacDir) {
+		t.Fatalf("enable failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(s, http.MethodPost, "/v1/templates", `{"name":"deploy","config_path":"c.yaml"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("template create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(s, http.MethodPost, "/v1/schedules", `{"config_path":"c.yaml","interval_seconds":3600}`); rr.Code != http.StatusCreated {
+		t.Fatalf("schedule create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, name := range []string{"templates/tpl-1.yaml", "schedules/sched-1.yaml"} {
+		if _, err := os.Stat(filepath.Join(cacDir, name)); err != nil {
+			t.Fatalf("expected %s written on change: %v", name, err)
+		}
+	}
+	rr = do(s, http.MethodGet, "/v1/config-as-code/diff", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"in_sync":true`) {
+		t.Fatalf("expected in-sync diff: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	tplFile := filepath.Join(cacDir, "templates", "tpl-1.yaml")
+	body, err := os.ReadFile(tplFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tplFile, []byte(strings.Replace(string(body), "name: deploy", "name: deploy-edited", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rr = do(s, http.MethodGet, "/v1/config-as-code/diff", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"changed"`) {
+		t.Fatalf("expected drift after file edit: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(s, http.MethodPost, "/v1/templates", `{`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid template body to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if body, err := os.ReadFile(tplFile); err != nil || !strings.Contains(string(body), "name: deploy-edited") {
+		t.Fatalf("expected request that changed nothing to leave files alone: %s %v", body, err)
+	}
+
+	t.Setenv("MC_CONFIG_AS_CODE_DIR", "cac")
+	restarted := New(":0", tmp)
+	t.Cleanup(func() { _ = restarted.Shutdown(context.Background()) })
+	rr = do(restarted, http.MethodGet, "/v1/templates", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"deploy-edited"`) {
+		t.Fatalf("expected template imported at startup: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(restarted, http.MethodGet, "/v1/schedules", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"id":"sched-1"`) {
+		t.Fatalf("expected schedule imported at startup: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(restarted, http.MethodGet, "/v1/config-as-code/diff", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"in_sync":true`) {
+		t.Fatalf("expected imported state in sync: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
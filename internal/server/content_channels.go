@@ -67,7 +67,7 @@ func (s *Server) handleContentChannelRemotes(w http.ResponseWriter, r *http.Requ
 }
 
 func (s *Server) handleContentChannelRemoteAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/packages/content-channels/remotes/{id}[/rotate-token]
+	// /v1/packages/content-channels/remotes/{id}[/rotate-token|/sync|/syncs]
 	parts := splitPath(r.URL.Path)
 	if len(parts) < 5 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid content channel remote path"})
@@ -111,5 +111,73 @@ func (s *Server) handleContentChannelRemoteAction(w http.ResponseWriter, r *http
 		writeJSON(w, http.StatusOK, item)
 		return
 	}
+	if len(parts) == 6 && parts[5] == "sync" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if _, err := s.contentChannels.GetRemote(id); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		run, err := s.contentMirror.Trigger(id)
+		if err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "packages.content_sync.started",
+			Message: "content channel sync started",
+			Fields: map[string]any{
+				"run_id":    run.ID,
+				"remote_id": run.RemoteID,
+				"channel":   run.Channel,
+				"trigger":   run.Trigger,
+			},
+		}, true)
+		writeJSON(w, http.StatusAccepted, run)
+		return
+	}
+	if len(parts) == 6 && parts[5] == "syncs" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		remote, err := s.contentChannels.GetRemote(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		runs := s.contentMirror.History(id)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"remote_id":        remote.ID,
+			"last_sync_at":     remote.LastSyncAt,
+			"last_success_at":  remote.LastSuccessAt,
+			"sync_lag_seconds": remote.SyncLagSeconds,
+			"items":            runs,
+			"count":            len(runs),
+		})
+		return
+	}
 	writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown remote action"})
 }
+
+func (s *Server) noteContentSyncFinished(run control.ContentSyncRun) {
+	fields := map[string]any{
+		"run_id":            run.ID,
+		"remote_id":         run.RemoteID,
+		"channel":           run.Channel,
+		"status":            run.Status,
+		"discovered":        run.Discovered,
+		"counts":            run.Counts,
+		"bytes_transferred": run.BytesTransferred,
+	}
+	if run.Error != "" {
+		fields["error"] = run.Error
+	}
+	s.recordEvent(control.Event{
+		Type:    "packages.content_sync.finished",
+		Message: "content channel sync finished",
+		Fields:  fields,
+	}, true)
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// linkedProfileCapture adds download paths for each stored profile.
+type linkedProfileCapture struct {
+	control.ProfileCapture
+	Links map[string]string `json:"links,omitempty"`
+}
+
+func linkProfileCaptures(items []control.ProfileCapture) []linkedProfileCapture {
+	out := make([]linkedProfileCapture, 0, len(items))
+	for _, item := range items {
+		linked := linkedProfileCapture{ProfileCapture: item}
+		if len(item.Artifacts) > 0 {
+			linked.Links = map[string]string{}
+			for _, artifact := range item.Artifacts {
+				linked.Links[artifact.Kind] = "/v1/control/performance/profiler/captures/" + item.ID + "/" + artifact.Kind
+			}
+		}
+		out = append(out, linked)
+	}
+	return out
+}
+
+// requireControlAdmin allows a request only when its principal is granted
+// control/admin through RBAC. Denials are recorded as events.
+func (s *Server) requireControlAdmin(w http.ResponseWriter, r *http.Request) bool {
+	principal, _ := requestIdentity(r)
+	if principal == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "X-Masterchef-Principal header is required"})
+		return false
+	}
+	decision := s.rbac.CheckAccess(control.RBACAccessCheckInput{
+		Subject:  principal,
+		Resource: "control",
+		Action:   "admin",
+	})
+	if !decision.Allowed {
+		s.recordEvent(control.Event{
+			Type:    "control.admin.denied",
+			Message: "admin-only endpoint denied",
+			Fields:  map[string]any{"principal": principal, "path": r.URL.Path},
+		}, true)
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "principal requires control/admin permission"})
+		return false
+	}
+	return true
+}
+
+// handlePprof serves net/http/pprof under /v1/control/debug/pprof/ for
+// control admins.
+func (s *Server) handlePprof(w http.ResponseWriter, r *http.Request) {
+	if !s.requireControlAdmin(w, r) {
+		return
+	}
+	switch name := strings.TrimPrefix(r.URL.Path, "/v1/control/debug/pprof/"); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+func (s *Server) handleContinuousProfiler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"config":    s.profiler.Config(),
+			"capturing": s.profiler.Capturing(),
+			"captures":  linkProfileCaptures(s.profiler.List(parseIntQuery(r, "limit", 20))),
+		})
+	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req control.ContinuousProfilerConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		cfg, err := s.profiler.SetConfig(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.performance.profiler.updated",
+			Message: "continuous profiler configuration updated",
+			Fields: map[string]any{
+				"enabled":               cfg.Enabled,
+				"on_backlog_saturation": cfg.OnBacklogSaturation,
+				"on_high_gc":            cfg.OnHighGC,
+				"cpu_seconds":           cfg.CPUSeconds,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, cfg)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleProfileCaptures(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"items": linkProfileCaptures(s.profiler.List(parseIntQuery(r, "limit", 50)))})
+	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+		}
+		item, _, err := s.profiler.Trigger(control.ProfileTriggerManual, req.Reason)
+		if err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleProfileCaptureAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/performance/profiler/captures/{id}[/{kind}]
+	if len(parts) < 6 || len(parts) > 7 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "performance" || parts[3] != "profiler" || parts[4] != "captures" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid profile capture path"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, err := s.profiler.Get(parts[5])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(parts) == 6 {
+		writeJSON(w, http.StatusOK, linkProfileCaptures([]control.ProfileCapture{item})[0])
+		return
+	}
+	// Profiles expose stack traces and heap contents, so downloads are
+	// restricted like the live pprof endpoints.
+	if !s.requireControlAdmin(w, r) {
+		return
+	}
+	for _, artifact := range item.Artifacts {
+		if artifact.Kind != parts[6] {
+			continue
+		}
+		if s.objectStore == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+			return
+		}
+		data, _, err := s.objectStore.Get(artifact.ObjectKey)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+item.ID+"-"+artifact.Kind+`.pb.gz"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "profile kind not found in capture"})
+}
+
+func (s *Server) noteProfileCaptured(item control.ProfileCapture) {
+	keys := make([]string, 0, len(item.Artifacts))
+	for _, artifact := range item.Artifacts {
+		keys = append(keys, artifact.ObjectKey)
+	}
+	fields := map[string]any{
+		"capture_id":  item.ID,
+		"trigger":     item.Trigger,
+		"reason":      item.Reason,
+		"status":      item.Status,
+		"object_keys": keys,
+	}
+	if item.Error != "" {
+		fields["error"] = item.Error
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.performance.profile.captured",
+		Message: "performance profile " + item.Status,
+		Fields:  fields,
+	}, true)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestPprofRequiresControlAdmin(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	get := func(principal string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/control/debug/pprof/goroutine?debug=1", nil)
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := get(""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without principal, got %d", rr.Code)
+	}
+	if rr := get("sre-bob"); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for principal without admin role, got %d", rr.Code)
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/access/rbac/roles", bytes.NewReader([]byte(`{"name":"control-admin","permissions":[{"resource":"control","action":"admin"}]}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create role failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var role struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &role)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/access/rbac/bindings", bytes.NewReader([]byte(`{"subject":"sre-bob","role_id":"`+role.ID+`"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create binding failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = get("sre-bob")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "goroutine") {
+		t.Fatalf("expected goroutine profile for admin, got code=%d body=%.200s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestContinuousProfilerCaptureAndDiagnosticsLinks(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/control/performance/profiler", bytes.NewReader([]byte(`{"enabled":false}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected profiler config change to require admin, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/performance/profiler/captures", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected manual capture to require admin, got %d", rr.Code)
+	}
+
+	grantControlAdmin(t, s, "sre-bob")
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/performance/profiler", bytes.NewReader([]byte(`{"enabled":true,"on_backlog_saturation":true,"cpu_seconds":1}`)))
+	req.Header.Set("X-Masterchef-Principal", "sre-bob")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update profiler config failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/performance/profiler/captures", bytes.NewReader([]byte(`{"reason":"investigate latency"}`)))
+	req.Header.Set("X-Masterchef-Principal", "sre-bob")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("manual capture failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var capture struct {
+		ID     string            `json:"id"`
+		Status string            `json:"status"`
+		Links  map[string]string `json:"links"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &capture)
+	if capture.ID == "" || capture.Status != "capturing" {
+		t.Fatalf("unexpected capture: %s", rr.Body.String())
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for capture.Status == "capturing" && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/v1/control/performance/profiler/captures/"+capture.ID, nil)
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		_ = json.Unmarshal(rr.Body.Bytes(), &capture)
+	}
+	if capture.Status != "completed" || capture.Links["cpu"] == "" || capture.Links["heap"] == "" {
+		t.Fatalf("expected completed capture with links, got %+v", capture)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, capture.Links["heap"], nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected profile download to require admin, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/control/performance/diagnostics", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), capture.Links["cpu"]) {
+		t.Fatalf("expected diagnostics to link capture, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+// grantControlAdmin binds subject to a control/admin role so it passes
+// requireControlAdmin.
+func grantControlAdmin(t *testing.T, s *Server, subject string) {
+	t.Helper()
+	role, err := s.rbac.CreateRole(control.RBACRoleInput{
+		Name:        "control-admin-" + subject,
+		Permissions: []control.RBACPermission{{Resource: "control", Action: "admin"}},
+	})
+	if err != nil {
+		t.Fatalf("create admin role: %v", err)
+	}
+	if _, err := s.rbac.CreateBinding(control.RBACBindingInput{Subject: subject, RoleID: role.ID}); err != nil {
+		t.Fatalf("bind admin role: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+// controlPlaneSnapshot captures the in-memory control-plane stores with their
+// IDs so a snapshot can seed a fresh server. Secret integrations carry
+// metadata only; inline secret values are not exported.
+type controlPlaneSnapshot struct {
+	control.ConfigAsCodeSnapshot
+	Webhooks           []control.WebhookSubscription `json:"webhooks"`
+	RBACRoles          []control.RBACRole            `json:"rbac_roles"`
+	RBACBindings       []control.RBACBinding         `json:"rbac_bindings"`
+	SecretIntegrations []control.SecretsIntegration  `json:"secret_integrations"`
+}
+
+func (s *Server) buildControlPlaneSnapshot() controlPlaneSnapshot {
+	out := controlPlaneSnapshot{
+		ConfigAsCodeSnapshot: s.configAsCodeSnapshot(),
+		Webhooks:             s.webhooks.List(),
+		RBACRoles:            s.rbac.ListRoles(),
+		RBACBindings:         s.rbac.ListBindings(),
+		SecretIntegrations:   []control.SecretsIntegration{},
+	}
+	for _, item := range s.secretIntegrations.List() {
+		out.SecretIntegrations = append(out.SecretIntegrations, control.SecretsIntegrationMetadata(item))
+	}
+	return out
+}
+
+func (cp *controlPlaneSnapshot) counts() map[string]int {
+	if cp == nil {
+		return map[string]int{}
+	}
+	return map[string]int{
+		control.ConfigAsCodeTemplates: len(cp.Templates),
+		control.ConfigAsCodeRunbooks:  len(cp.Runbooks),
+		control.ConfigAsCodeRules:     len(cp.Rules),
+		control.ConfigAsCodeSchedules: len(cp.Schedules),
+		"webhooks":                    len(cp.Webhooks),
+		"rbac_roles":                  len(cp.RBACRoles),
+		"rbac_bindings":               len(cp.RBACBindings),
+		"secret_integrations":         len(cp.SecretIntegrations),
+	}
+}
+
+// restoreControlPlane upserts every entity by ID. Roles are restored before
+// bindings so binding role references resolve. RBAC entities are only
+// inserted unless replace is set, which overwrites them by ID.
+func (s *Server) restoreControlPlane(cp controlPlaneSnapshot, replace bool) (map[string]int, error) {
+	restoreRole, restoreBinding := s.rbac.RestoreRole, s.rbac.RestoreBinding
+	if replace {
+		restoreRole, restoreBinding = s.rbac.ReplaceRole, s.rbac.ReplaceBinding
+	}
+	counts, err := s.restoreConfigAsCodeSnapshot(cp.ConfigAsCodeSnapshot)
+	if err != nil {
+		return counts, err
+	}
+	for _, item := range cp.Webhooks {
+		if _, err := s.webhooks.Restore(item); err != nil {
+			return counts, err
+		}
+		counts["webhooks"]++
+	}
+	for _, item := range cp.RBACRoles {
+		if _, err := restoreRole(item); err != nil {
+			return counts, err
+		}
+		counts["rbac_roles"]++
+	}
+	for _, item := range cp.RBACBindings {
+		if _, err := restoreBinding(item); err != nil {
+			return counts, err
+		}
+		counts["rbac_bindings"]++
+	}
+	for _, item := range cp.SecretIntegrations {
+		if _, err := s.secretIntegrations.Restore(item); err != nil {
+			return counts, err
+		}
+		counts["secret_integrations"]++
+	}
+	return counts, nil
+}
+
+// checkControlPlaneRestore refuses snapshots whose RBAC roles or bindings
+// would overwrite existing ones, before anything is restored.
+func (s *Server) checkControlPlaneRestore(cp controlPlaneSnapshot) error {
+	for _, item := range cp.RBACRoles {
+		if _, ok := s.rbac.GetRole(strings.TrimSpace(item.ID)); ok {
+			return errors.New("rbac role " + item.ID + " already exists")
+		}
+	}
+	existing := map[string]struct{}{}
+	for _, item := range s.rbac.ListBindings() {
+		existing[item.ID] = struct{}{}
+	}
+	for _, item := range cp.RBACBindings {
+		if _, ok := existing[strings.TrimSpace(item.ID)]; ok {
+			return errors.New("rbac binding " + item.ID + " already exists")
+		}
+	}
+	return nil
+}
+
+// applyBackupSnapshot restores runs, events, and control-plane entities.
+// Snapshots that carry control-plane data but no runs or events leave the
+// existing run history and event log untouched.
+func (s *Server) applyBackupSnapshot(baseDir string, snap backupSnapshot) (map[string]any, error) {
+	return s.applySnapshot(baseDir, snap, false)
+}
+
+// applyReplicatedSnapshot applies a primary's snapshot on a follower. Every
+// sync after the first carries the same RBAC IDs, so they are overwritten
+// by ID instead of refused.
+func (s *Server) applyReplicatedSnapshot(baseDir string, snap backupSnapshot) (map[string]any, error) {
+	return s.applySnapshot(baseDir, snap, true)
+}
+
+func (s *Server) applySnapshot(baseDir string, snap backupSnapshot, replace bool) (map[string]any, error) {
+	if snap.ControlPlane != nil && !replace {
+		if err := s.checkControlPlaneRestore(*snap.ControlPlane); err != nil {
+			return nil, err
+		}
+	}
+	out := map[string]any{
+		"version":         snap.Version,
+		"restored_runs":   0,
+		"restored_events": 0,
+	}
+	if snap.ControlPlane == nil || snap.Runs != nil {
+		if err := state.New(baseDir).ReplaceRuns(snap.Runs); err != nil {
+			return nil, err
+		}
+		out["restored_runs"] = len(snap.Runs)
+		s.responseCache.Invalidate(responseCacheTagRuns)
+	}
+	if snap.ControlPlane == nil || snap.Events != nil {
+		s.events.Replace(snap.Events)
+		out["restored_events"] = len(snap.Events)
+		s.responseCache.Invalidate(responseCacheTagWorkloads)
+	}
+	if snap.ControlPlane != nil {
+		counts, err := s.restoreControlPlane(*snap.ControlPlane, replace)
+		if err != nil {
+			return nil, err
+		}
+		out["restored_control_plane"] = counts
+	}
+	return out, nil
+}
+
+func (s *Server) handleControlSnapshot(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// Followers authenticate with the federation token; everyone else
+		// needs control admin, since snapshots carry RBAC and run history.
+		if !s.federationBearerValid(r) && !s.requireControlAdmin(w, r) {
+			return
+		}
+		include := map[string]bool{}
+		for _, part := range strings.Split(r.URL.Query().Get("include"), ",") {
+			if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+				include[part] = true
+			}
+		}
+		if len(include) == 0 {
+			include = map[string]bool{"runs": true, "events": true, "control_plane": true}
+		}
+		snap, err := s.buildBackupSnapshot(baseDir, include["runs"], include["events"], include["control_plane"])
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, snap)
+	}
+}
+
+func (s *Server) handleControlSnapshotImport(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var snap backupSnapshot
+		if err := json.NewDecoder(r.Body).Decode(&snap); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		switch snap.Version {
+		case "v1", "v2":
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported snapshot version"})
+			return
+		}
+		out, err := s.applyBackupSnapshot(baseDir, snap)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.snapshot.imported",
+			Message: "control-plane snapshot imported",
+			Fields:  out,
+		}, false)
+		out["status"] = "imported"
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestControlSnapshotExportImportPreservesIDs(t *testing.T) {
+	t.Setenv("MC_FEDERATION_TOKEN", "peer-token")
+	src := New(":0", t.TempDir())
+	t.Cleanup(func() { _ = src.Shutdown(context.Background()) })
+	do := func(srv *Server, method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		srv.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, step := range []struct{ path, body string }{
+		{"/v1/rules", `{"name":"on-drift","source_prefix":"drift.","actions":[{"type":"enqueue_apply","config_path":"fix.yaml"}]}`},
+		{"/v1/rules", `{"name":"on-alert","source_prefix":"alert.","actions":[{"type":"enqueue_apply","config_path":"alert.yaml"}]}`},
+		{"/v1/webhooks", `{"name":"ops","url":"http://127.0.0.1:1/hook","event_prefix":"job."}`},
+		{"/v1/access/rbac/roles", `{"name":"operator","permissions":[{"resource":"runs","action":"read"}]}`},
+		{"/v1/access/rbac/bindings", `{"subject":"alice","role_id":"rbac-role-1"}`},
+		{"/v1/secrets/integrations", `{"name":"inline","provider":"inline","config":{"region":"us-east-1","secret.db/password":"hunter2"}}`},
+	} {
+		if rr := do(src, http.MethodPost, step.path, step.body); rr.Code >= 300 {
+			t.Fatalf("seed %s failed: code=%d body=%s", step.path, rr.Code, rr.Body.String())
+		}
+	}
+
+	if rr := do(src, http.MethodGet, "/v1/control/snapshot?include=control_plane", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthenticated export to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	exportWith := func(bearer string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/control/snapshot?include=control_plane", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		src.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := exportWith("wrong-token"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected export with a wrong federation token to be rejected: code=%d", rr.Code)
+	}
+	rr := exportWith("peer-token")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("snapshot export failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	exported := rr.Body.String()
+	if !strings.Contains(exported, `"version":"v2"`) || strings.Contains(exported, "hunter2") {
+		t.Fatalf("expected v2 snapshot without secret values, got %s", exported)
+	}
+
+	dst := New(":0", t.TempDir())
+	t.Cleanup(func() { _ = dst.Shutdown(context.Background()) })
+	if rr := do(dst, http.MethodPost, "/v1/control/snapshot/import", exported); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthenticated import to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	// The admin role and binding on dst take the IDs the snapshot uses, so
+	// the import must refuse to overwrite them.
+	grantControlAdmin(t, dst, "snapshot-admin")
+	importAs := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/control/snapshot/import", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "snapshot-admin")
+		dst.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	rr = importAs(exported)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "already exists") {
+		t.Fatalf("expected rbac id collision to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(dst, http.MethodGet, "/v1/rules/rule-1", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected rejected import to restore nothing: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var snap map[string]any
+	if err := json.Unmarshal([]byte(exported), &snap); err != nil {
+		t.Fatal(err)
+	}
+	cp := snap["control_plane"].(map[string]any)
+	for _, key := range []string{"rbac_roles", "rbac_bindings"} {
+		for _, item := range cp[key].([]any) {
+			entry := item.(map[string]any)
+			entry["id"] = entry["id"].(string) + "0"
+			if roleID, ok := entry["role_id"].(string); ok {
+				entry["role_id"] = roleID + "0"
+			}
+		}
+	}
+	renumbered, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = importAs(string(renumbered))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("snapshot import failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var imported struct {
+		Counts map[string]int `json:"restored_control_plane"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &imported); err != nil {
+		t.Fatal(err)
+	}
+	if imported.Counts["rules"] != 2 || imported.Counts["rbac_bindings"] != 1 || imported.Counts["secret_integrations"] != 1 {
+		t.Fatalf("unexpected import counts %#v", imported.Counts)
+	}
+
+	rr = do(dst, http.MethodGet, "/v1/rules/rule-2", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"on-alert"`) {
+		t.Fatalf("expected rule id preserved: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(dst, http.MethodPost, "/v1/access/rbac/check", `{"subject":"alice","resource":"runs","action":"read"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"allowed":true`) {
+		t.Fatalf("expected rbac binding restored: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(dst, http.MethodPost, "/v1/rules", `{"name":"new","source_prefix":"x.","actions":[{"type":"enqueue_apply","config_path":"x.yaml"}]}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"id":"rule-3"`) {
+		t.Fatalf("expected new ids to continue after restored ones: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := importAs(`{"version":"v9"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unsupported version rejection, got %d", rr.Code)
+	}
+}
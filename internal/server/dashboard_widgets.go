@@ -2,26 +2,41 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
 
 func (s *Server) handleDashboardWidgets(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		ViewID      string `json:"view_id"`
-		Title       string `json:"title,omitempty"`
-		Description string `json:"description,omitempty"`
-		Width       int    `json:"width,omitempty"`
-		Height      int    `json:"height,omitempty"`
-		Column      int    `json:"column,omitempty"`
-		Row         int    `json:"row,omitempty"`
-		Pinned      bool   `json:"pinned,omitempty"`
+		ViewID                 string `json:"view_id,omitempty"`
+		Provider               string `json:"provider,omitempty"`
+		EventType              string `json:"event_type,omitempty"`
+		Environment            string `json:"environment,omitempty"`
+		Service                string `json:"service,omitempty"`
+		WindowSeconds          int    `json:"window_seconds,omitempty"`
+		BucketSeconds          int    `json:"bucket_seconds,omitempty"`
+		Limit                  int    `json:"limit,omitempty"`
+		RefreshIntervalSeconds int    `json:"refresh_interval_seconds,omitempty"`
+		Title                  string `json:"title,omitempty"`
+		Description            string `json:"description,omitempty"`
+		Width                  int    `json:"width,omitempty"`
+		Height                 int    `json:"height,omitempty"`
+		Column                 int    `json:"column,omitempty"`
+		Row                    int    `json:"row,omitempty"`
+		Pinned                 bool   `json:"pinned,omitempty"`
 	}
 	switch r.Method {
 	case http.MethodGet:
+		profile, ok := s.requestAccessibilityProfile(w, r)
+		if !ok {
+			return
+		}
+		hints := profile.VariantHints()
 		items := s.dashboardWidgets.List()
 		if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("pinned_only")), "true") {
 			filtered := make([]control.DashboardWidget, 0, len(items))
@@ -33,8 +48,10 @@ func (s *Server) handleDashboardWidgets(w http.ResponseWriter, r *http.Request)
 			items = filtered
 		}
 		writeJSON(w, http.StatusOK, map[string]any{
-			"items": items,
-			"count": len(items),
+			"items":          control.AdaptDashboardWidgets(items, hints),
+			"count":          len(items),
+			"active_profile": profile,
+			"variant":        hints,
 		})
 	case http.MethodPost:
 		var req reqBody
@@ -42,24 +59,36 @@ func (s *Server) handleDashboardWidgets(w http.ResponseWriter, r *http.Request)
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 			return
 		}
-		view, err := s.views.Get(req.ViewID)
-		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "view not found"})
-			return
-		}
 		title := strings.TrimSpace(req.Title)
-		if title == "" {
-			title = view.Name
+		provider := strings.ToLower(strings.TrimSpace(req.Provider))
+		if provider == "" || provider == control.WidgetProviderQuery {
+			view, err := s.views.Get(req.ViewID)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "view not found"})
+				return
+			}
+			req.ViewID = view.ID
+			if title == "" {
+				title = view.Name
+			}
 		}
 		item, err := s.dashboardWidgets.Create(control.DashboardWidget{
-			ViewID:      view.ID,
-			Title:       title,
-			Description: req.Description,
-			Width:       req.Width,
-			Height:      req.Height,
-			Column:      req.Column,
-			Row:         req.Row,
-			Pinned:      req.Pinned,
+			ViewID:                 req.ViewID,
+			Provider:               provider,
+			EventType:              req.EventType,
+			Environment:            req.Environment,
+			Service:                req.Service,
+			WindowSeconds:          req.WindowSeconds,
+			BucketSeconds:          req.BucketSeconds,
+			Limit:                  req.Limit,
+			RefreshIntervalSeconds: req.RefreshIntervalSeconds,
+			Title:                  title,
+			Description:            req.Description,
+			Width:                  req.Width,
+			Height:                 req.Height,
+			Column:                 req.Column,
+			Row:                    req.Row,
+			Pinned:                 req.Pinned,
 		})
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
@@ -72,7 +101,7 @@ func (s *Server) handleDashboardWidgets(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleDashboardWidgetAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/ui/dashboard/widgets/{id}[/pin|refresh]
+	// /v1/ui/dashboard/widgets/{id}[/pin|refresh|data]
 	parts := splitPath(r.URL.Path)
 	if len(parts) < 5 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid dashboard widget path"})
@@ -103,11 +132,15 @@ func (s *Server) handleDashboardWidgetAction(w http.ResponseWriter, r *http.Requ
 		}
 		return
 	}
+	action := parts[5]
+	if action == "data" {
+		s.handleDashboardWidgetData(w, r, id)
+		return
+	}
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	action := parts[5]
 	switch action {
 	case "pin":
 		var req struct {
@@ -135,6 +168,118 @@ func (s *Server) handleDashboardWidgetAction(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// handleDashboardWidgetData serves a widget's resolved data, reusing the
+// cached result until the widget's refresh interval elapses unless
+// ?refresh=true forces a fresh resolve.
+func (s *Server) handleDashboardWidgetData(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	widget, err := s.dashboardWidgets.Get(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	force := strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("refresh")), "true")
+	if !force {
+		if cached, ok := s.dashboardWidgets.CachedData(widget.ID, now); ok {
+			w.Header().Set("X-Cache", "HIT")
+			w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(cached.ExpiresAt.Sub(now).Seconds())))
+			writeJSON(w, http.StatusOK, cached)
+			return
+		}
+	}
+	data, err := s.resolveDashboardWidgetData(widget, now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	out, err := s.dashboardWidgets.StoreData(widget.ID, data, now)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("X-Cache", "MISS")
+	w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(widget.RefreshIntervalSeconds))
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) resolveDashboardWidgetData(widget control.DashboardWidget, now time.Time) (any, error) {
+	switch widget.Provider {
+	case control.WidgetProviderMetricSeries:
+		window := time.Duration(widget.WindowSeconds) * time.Second
+		bucket := time.Duration(widget.BucketSeconds) * time.Second
+		events := s.events.Query(control.EventQuery{
+			Since:      now.Truncate(bucket).Add(bucket - window),
+			TypePrefix: widget.EventType,
+			Limit:      1 << 20,
+		})
+		points := control.EventCountSeries(events, widget.EventType, window, bucket, now)
+		total := 0
+		for _, p := range points {
+			total += p.Count
+		}
+		return map[string]any{
+			"event_type":     widget.EventType,
+			"bucket_seconds": widget.BucketSeconds,
+			"points":         points,
+			"total":          total,
+		}, nil
+	case control.WidgetProviderEventFeed:
+		items := s.events.Query(control.EventQuery{TypePrefix: widget.EventType, Limit: widget.Limit, Desc: true})
+		return map[string]any{"items": items, "count": len(items)}, nil
+	case control.WidgetProviderScorecard:
+		items := s.readinessScorecards.List(widget.Environment, widget.Service, widget.Limit)
+		passed := 0
+		for _, item := range items {
+			if item.Report.Pass {
+				passed++
+			}
+		}
+		return map[string]any{"items": items, "count": len(items), "passed": passed}, nil
+	default:
+		view, err := s.views.Get(widget.ViewID)
+		if err != nil {
+			return nil, errors.New("view not found")
+		}
+		var root *queryNode
+		if strings.EqualFold(view.Mode, "ast") {
+			if strings.TrimSpace(view.QueryAST) != "" {
+				root = &queryNode{}
+				if err := json.Unmarshal([]byte(view.QueryAST), root); err != nil {
+					return nil, errors.New("view query_ast is invalid: " + err.Error())
+				}
+			}
+		} else {
+			root, err = parseHumanQuery(view.Query)
+			if err != nil {
+				return nil, err
+			}
+		}
+		records, err := s.queryEntityRecords(strings.ToLower(strings.TrimSpace(view.Entity)), s.baseDir)
+		if err != nil {
+			return nil, err
+		}
+		limit := view.Limit
+		if limit <= 0 {
+			limit = 100
+		}
+		matched, err := filterQueryRecords(records, root, limit)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"view_id":       view.ID,
+			"entity":        view.Entity,
+			"total":         len(records),
+			"matched_count": len(matched),
+			"items":         matched,
+		}, nil
+	}
+}
+
 func parseDashboardInt(raw string, fallback int) int {
 	if raw == "" {
 		return fallback
@@ -68,6 +68,10 @@ func (s *Server) handleDataBagItem(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
+		if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("decrypt")), "true") {
+			s.handleDataBagServerDecrypt(w, r, bag, item)
+			return
+		}
 		passphrase := strings.TrimSpace(r.URL.Query().Get("passphrase"))
 		out, err := s.dataBags.Get(bag, item, passphrase)
 		if err != nil {
@@ -81,6 +85,13 @@ func (s *Server) handleDataBagItem(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 			return
 		}
+		// Encrypted items exist to hold secrets; only plaintext items are scanned.
+		if !req.Encrypted {
+			content, _ := json.MarshalIndent(req.Data, "", "  ")
+			if !s.enforceSecretScan(w, control.SecretScanInput{Kind: "data_bag", Target: bag + "/" + item, Content: string(content)}, "") {
+				return
+			}
+		}
 		out, err := s.dataBags.Upsert(bag, item, req.Data, req.Encrypted, req.Passphrase, req.Tags)
 		if err != nil {
 			writeDataBagError(w, err)
@@ -120,6 +131,95 @@ func (s *Server) handleDataBagSearch(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleDataBagKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.dataBags.ListBagKeys()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.DataBagKeyInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		// Only a principal already on the bag's key may rotate it; new keys
+		// and everyone else need control admin, since the response carries
+		// the secret.
+		principal, _ := requestIdentity(r)
+		if existing, ok := s.dataBags.GetBagKey(req.Bag); !ok || !existing.PrincipalListed(principal) {
+			if !s.requireControlAdmin(w, r) {
+				return
+			}
+		}
+		key, secret, err := s.dataBags.SetBagKey(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "data_bag.key.updated",
+			Message: "data bag key updated",
+			Fields: map[string]any{
+				"bag":               key.Bag,
+				"version":           key.Version,
+				"fingerprint":       key.Fingerprint,
+				"reencrypted_items": key.ReencryptedItems,
+				"principal":         principal,
+			},
+		}, true)
+		// The secret is returned once so operators can distribute it to
+		// nodes and knife; it is never included in later reads.
+		writeJSON(w, http.StatusOK, map[string]any{"key": key, "secret": secret})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDataBagServerDecrypt decrypts an item with its bag key on behalf of
+// the calling principal. Access requires the principal to be listed on the
+// bag key or granted data_bags:decrypt through RBAC.
+func (s *Server) handleDataBagServerDecrypt(w http.ResponseWriter, r *http.Request, bag, item string) {
+	principal := strings.TrimSpace(r.Header.Get("X-Masterchef-Principal"))
+	if principal == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "X-Masterchef-Principal header is required for server-side decryption"})
+		return
+	}
+	key, ok := s.dataBags.GetBagKey(bag)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "data bag key not found"})
+		return
+	}
+	allowed := key.PrincipalAuthorized(principal)
+	if !allowed {
+		allowed = s.rbac.CheckAccess(control.RBACAccessCheckInput{
+			Subject:  principal,
+			Resource: "data_bags",
+			Action:   "decrypt",
+			Scope:    key.Bag,
+		}).Allowed
+	}
+	if !allowed {
+		s.recordEvent(control.Event{
+			Type:    "data_bag.decrypt.denied",
+			Message: "data bag decryption denied",
+			Fields:  map[string]any{"bag": key.Bag, "item": item, "principal": principal},
+		}, true)
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "principal is not authorized to decrypt items in this bag"})
+		return
+	}
+	out, err := s.dataBags.DecryptWithBagKey(bag, item)
+	if err != nil {
+		writeDataBagError(w, err)
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "data_bag.decrypt",
+		Message: "data bag item decrypted server-side",
+		Fields:  map[string]any{"bag": out.Bag, "item": out.Item, "principal": principal},
+	}, false)
+	writeJSON(w, http.StatusOK, out)
+}
+
 func writeDataBagError(w http.ResponseWriter, err error) {
 	if strings.Contains(strings.ToLower(err.Error()), "not found") {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -56,3 +57,224 @@ func (s *Server) handleDelegatedAdminAuthorize(w http.ResponseWriter, r *http.Re
 	}
 	writeJSON(w, http.StatusOK, decision)
 }
+
+// handleDelegatedAdminKeys manages API keys minted under delegated admin
+// grants:
+//
+//	GET  /v1/control/delegated-admin/keys[?grant_id=]
+//	POST /v1/control/delegated-admin/keys              mint (token returned once)
+//	GET  /v1/control/delegated-admin/keys/{id}
+//	POST /v1/control/delegated-admin/keys/{id}/rotate  {"grace_seconds":N}
+//	POST /v1/control/delegated-admin/keys/{id}/revoke
+func (s *Server) handleDelegatedAdminKeys(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 4 || len(parts) > 6 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "delegated-admin" || parts[3] != "keys" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	principal, _ := requestIdentity(r)
+	if len(parts) == 4 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.delegatedKeys.List(r.URL.Query().Get("grant_id")))
+		case http.MethodPost:
+			var req control.DelegatedAPIKeyInput
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			grant, ok := s.delegatedAdmin.Get(req.GrantID)
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "delegated admin grant not found"})
+				return
+			}
+			if !s.requireDelegatedKeyManager(w, r, grant) {
+				return
+			}
+			// The caller's identity, not the body, records who minted the key.
+			req.CreatedBy = principal
+			issued, err := s.delegatedKeys.Mint(req)
+			if err != nil {
+				code := http.StatusBadRequest
+				if strings.Contains(err.Error(), "not found") {
+					code = http.StatusNotFound
+				}
+				writeJSON(w, code, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "access.delegated_key.minted",
+				Message: "delegated admin api key minted",
+				Fields:  delegatedKeyEventFields(issued.Key),
+			}, true)
+			writeJSON(w, http.StatusCreated, issued)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id := parts[4]
+	if len(parts) == 5 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		key, err := s.delegatedKeys.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, key)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	key, err := s.delegatedKeys.Get(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	grant, _ := s.delegatedAdmin.Get(key.GrantID)
+	if !s.requireDelegatedKeyManager(w, r, grant) {
+		return
+	}
+	switch parts[5] {
+	case "rotate":
+		var req struct {
+			GraceSeconds int `json:"grace_seconds"`
+		}
+		if r.Body != nil && r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+		}
+		issued, err := s.delegatedKeys.Rotate(id, req.GraceSeconds)
+		if err != nil {
+			code := http.StatusBadRequest
+			if strings.Contains(err.Error(), "not found") {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		fields := delegatedKeyEventFields(issued.Key)
+		fields["rotated_from"] = id
+		fields["grace_seconds"] = req.GraceSeconds
+		fields["rotated_by"] = principal
+		s.recordEvent(control.Event{
+			Type:    "access.delegated_key.rotated",
+			Message: "delegated admin api key rotated",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusOK, issued)
+	case "revoke":
+		key, err := s.delegatedKeys.Revoke(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		fields := delegatedKeyEventFields(key)
+		fields["revoked_by"] = principal
+		s.recordEvent(control.Event{
+			Type:    "access.delegated_key.revoked",
+			Message: "delegated admin api key revoked",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusOK, key)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// requireDelegatedKeyManager lets the grant's delegator or a control admin
+// mint, rotate, or revoke the grant's keys. It writes the error response and
+// returns false otherwise.
+func (s *Server) requireDelegatedKeyManager(w http.ResponseWriter, r *http.Request, grant control.DelegatedAdminGrant) bool {
+	principal, _ := requestIdentity(r)
+	if principal != "" && grant.Delegator != "" && strings.EqualFold(principal, grant.Delegator) {
+		return true
+	}
+	return s.requireControlAdmin(w, r)
+}
+
+func delegatedKeyEventFields(key control.DelegatedAPIKey) map[string]any {
+	return map[string]any{
+		"api_key_id":   key.ID,
+		"grant_id":     key.GrantID,
+		"tenant":       key.Tenant,
+		"principal":    key.Principal,
+		"delegator":    key.Delegator,
+		"routes":       key.Routes,
+		"environments": key.Environments,
+		"expires_at":   key.ExpiresAt,
+	}
+}
+
+// resolveDelegatedKey authenticates a delegated API key presented in
+// X-Masterchef-API-Key (or as an "mcdak_" bearer token). An authorized key
+// replaces any caller-supplied principal and tenant headers with the grant's,
+// so downstream checks and the audit trail see who the key acts for.
+func (s *Server) resolveDelegatedKey(r *http.Request) (control.DelegatedAPIKeyDecision, bool) {
+	token := strings.TrimSpace(r.Header.Get("X-Masterchef-API-Key"))
+	if token == "" {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.HasPrefix(strings.TrimSpace(bearer), "mcdak_") {
+			token = strings.TrimSpace(bearer)
+		}
+	}
+	if token == "" {
+		return control.DelegatedAPIKeyDecision{}, false
+	}
+	env := strings.TrimSpace(r.Header.Get("X-Masterchef-Environment"))
+	if env == "" {
+		env = strings.TrimSpace(r.URL.Query().Get("environment"))
+	}
+	decision := s.delegatedKeys.Authorize(control.DelegatedAPIKeyRequest{
+		Token:       token,
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Environment: env,
+	})
+	if decision.Allowed {
+		r.Header.Set("X-Masterchef-Principal", decision.Principal)
+		r.Header.Set("X-Masterchef-Tenant", decision.Tenant)
+	}
+	return decision, true
+}
+
+// delegatedKeyAuditFields names the key and its delegating admin on every
+// request and response event the key produces.
+func delegatedKeyAuditFields(decision control.DelegatedAPIKeyDecision) map[string]any {
+	out := map[string]any{"auth": "delegated_api_key"}
+	if decision.KeyID != "" {
+		out["api_key_id"] = decision.KeyID
+		out["grant_id"] = decision.GrantID
+		out["delegator"] = decision.Delegator
+	}
+	return out
+}
+
+func (s *Server) enforceDelegatedKey(w http.ResponseWriter, r *http.Request, decision control.DelegatedAPIKeyDecision, presented bool) bool {
+	if !presented || decision.Allowed {
+		return true
+	}
+	fields := delegatedKeyAuditFields(decision)
+	fields["method"] = r.Method
+	fields["path"] = r.URL.Path
+	fields["principal"] = decision.Principal
+	fields["reason"] = decision.Reason
+	s.recordEvent(control.Event{
+		Type:    "access.delegated_key.denied",
+		Message: "delegated api key request denied",
+		Fields:  fields,
+	}, true)
+	code := http.StatusForbidden
+	if decision.KeyID == "" {
+		code = http.StatusUnauthorized
+	}
+	writeJSON(w, code, map[string]string{"error": decision.Reason})
+	return false
+}
@@ -3,11 +3,14 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestDelegatedAdminEndpoints(t *testing.T) {
@@ -51,3 +54,104 @@ func TestDelegatedAdminEndpoints(t *testing.T) {
 		t.Fatalf("delegated authorize should deny ungranted action: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestDelegatedAdminKeysScopeRequestsAndAttributeAudit(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/control/delegated-admin/grants", `{"tenant":"acme","environment":"prod","principal":"ops-bot","scopes":["runs.*"],"delegator":"alice"}`, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create grant failed: %s", rr.Body.String())
+	}
+	var grant control.DelegatedAdminGrant
+	_ = json.Unmarshal(rr.Body.Bytes(), &grant)
+	mintBody := `{"grant_id":"` + grant.ID + `","name":"dashboards","routes":["GET /v1/runs"],"created_by":"mallory"}`
+	if rr := do(http.MethodPost, "/v1/control/delegated-admin/keys", mintBody, nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected anonymous mint rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/delegated-admin/keys", mintBody, map[string]string{"X-Masterchef-Principal": "mallory"}); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected mint by a non-delegator rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	delegator := map[string]string{"X-Masterchef-Principal": "alice"}
+	if rr := do(http.MethodPost, "/v1/control/delegated-admin/keys", `{"grant_id":"`+grant.ID+`","name":"jobs","routes":["GET /v1/jobs"]}`, delegator); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected route outside the grant's scopes rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/control/delegated-admin/keys", mintBody, delegator)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("mint key failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var issued control.IssuedDelegatedAPIKey
+	_ = json.Unmarshal(rr.Body.Bytes(), &issued)
+	if issued.Key.CreatedBy != "alice" || issued.Key.Delegator != "alice" {
+		t.Fatalf("expected creator from the caller's identity, got %+v", issued.Key)
+	}
+
+	keyHeaders := map[string]string{"X-Masterchef-API-Key": issued.Token, "X-Masterchef-Environment": "prod", "X-Masterchef-Principal": "mallory"}
+	if rr := do(http.MethodGet, "/v1/runs", "", keyHeaders); rr.Code != http.StatusOK {
+		t.Fatalf("expected scoped read allowed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/jobs", "", keyHeaders); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected route outside key scope denied, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/runs", "", map[string]string{"Authorization": "Bearer " + issued.Token}); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected request without environment denied, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/runs", "", map[string]string{"X-Masterchef-API-Key": "mcdak_nope"}); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unknown key unauthorized, got %d", rr.Code)
+	}
+
+	var used, denied, request bool
+	for _, evt := range s.events.List() {
+		switch evt.Type {
+		case "access.delegated_key.used":
+			used = evt.Fields["api_key_id"] == issued.Key.ID && evt.Fields["delegator"] == "alice" && evt.Fields["principal"] == "ops-bot"
+		case "access.delegated_key.denied":
+			denied = denied || evt.Fields["path"] == "/v1/jobs"
+		case "http.request":
+			if evt.Fields["path"] == "/v1/runs" && evt.Fields["api_key_id"] == issued.Key.ID {
+				request = request || evt.Fields["principal"] == "ops-bot"
+			}
+		}
+	}
+	if !used || !denied || !request {
+		t.Fatalf("expected key and delegator attribution in audit events (used=%v denied=%v request=%v)", used, denied, request)
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/delegated-admin/keys/"+issued.Key.ID+"/revoke", "", map[string]string{"X-Masterchef-Principal": "mallory"}); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected revoke by a non-delegator rejected, got %d", rr.Code)
+	}
+	rr = do(http.MethodPost, "/v1/control/delegated-admin/keys/"+issued.Key.ID+"/rotate", `{"grace_seconds":0}`, delegator)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("rotate failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var rotated control.IssuedDelegatedAPIKey
+	_ = json.Unmarshal(rr.Body.Bytes(), &rotated)
+	if rr := do(http.MethodGet, "/v1/runs", "", keyHeaders); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected rotated-out key denied, got %d", rr.Code)
+	}
+	keyHeaders["X-Masterchef-API-Key"] = rotated.Token
+	if rr := do(http.MethodGet, "/v1/runs", "", keyHeaders); rr.Code != http.StatusOK {
+		t.Fatalf("expected replacement key allowed, got %d", rr.Code)
+	}
+	grantControlAdmin(t, s, "root-admin")
+	if rr := do(http.MethodPost, "/v1/control/delegated-admin/keys/"+rotated.Key.ID+"/revoke", "", map[string]string{"X-Masterchef-Principal": "root-admin"}); rr.Code != http.StatusOK {
+		t.Fatalf("revoke failed: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/runs", "", keyHeaders); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected revoked key denied, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/control/delegated-admin/keys/missing", "", nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown key, got %d", rr.Code)
+	}
+}
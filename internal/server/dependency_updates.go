@@ -144,3 +144,47 @@ func (s *Server) handleDependencyUpdateAction(w http.ResponseWriter, r *http.Req
 	}
 	w.WriteHeader(http.StatusNotFound)
 }
+
+// handleDependencyUpdateExecutions fetches a newer registry version of a
+// module, runs the scenarios that read it, and records the proposal with
+// the runs attached as evidence.
+func (s *Server) handleDependencyUpdateExecutions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.DependencyUpdateExecuteInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	item, err := s.dependencyExecutor.Execute(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	runIDs := make([]string, 0, len(item.Evidence))
+	for _, ev := range item.Evidence {
+		if ev.RunID != "" {
+			runIDs = append(runIDs, ev.RunID)
+		}
+	}
+	s.recordEvent(control.Event{
+		Type:    "release.dependency.update.executed",
+		Message: "dependency update executed against impacted scenarios",
+		Fields: map[string]any{
+			"update_id":       item.ID,
+			"package":         item.Package,
+			"target_version":  item.TargetVersion,
+			"auto_mergeable":  item.AutoMergeable,
+			"scenario_runs":   runIDs,
+			"blocked_reasons": item.BlockedReasons,
+			"triggered_by":    req.TriggeredBy,
+		},
+	}, true)
+	status := http.StatusCreated
+	if !item.ReadyForMerge {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, item)
+}
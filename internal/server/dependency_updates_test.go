@@ -1,8 +1,11 @@
 package server
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -68,3 +71,94 @@ func TestDependencyUpdateBotEndpoints(t *testing.T) {
 		t.Fatalf("get dependency update failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestDependencyUpdateBotExecutionEndpoint(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	publish := func(version string) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		body := "version: v0\nresources:\n  - id: motd-check\n    type: command\n    host: localhost\n    command: \"exit 0\"\n    creates: \"/\"\n"
+		if err := tw.WriteHeader(&tar.Header{Name: "main.yaml", Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		payload, _ := json.Marshal(map[string]any{"kind": "module", "name": "acme/motd", "version": version, "tarball": base64.StdEncoding.EncodeToString(buf.Bytes())})
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/packages/artifacts", bytes.NewReader(payload)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("publish %s failed: code=%d body=%s", version, rr.Code, rr.Body.String())
+		}
+	}
+	publish("1.0.0")
+	publish("1.1.0")
+
+	scenario, _ := json.Marshal(map[string]any{
+		"id":     "motd",
+		"name":   "MOTD",
+		"config": "version: v0\nincludes:\n  - \"{{module.acme/motd}}/main.yaml\"\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n",
+	})
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenarios", bytes.NewReader(scenario)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upsert scenario failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	type proposal struct {
+		AutoMergeable  bool     `json:"auto_mergeable"`
+		BlockedReasons []string `json:"blocked_reasons"`
+		Evidence       []struct {
+			RunID  string `json:"run_id"`
+			Status string `json:"status"`
+		} `json:"evidence"`
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/release/dependency-bot/executions", bytes.NewReader([]byte(`{"package":"acme/motd","current_version":"0.1.0","target_version":"1.0.0"}`))))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected unbaselined execution to be blocked: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var first proposal
+	if err := json.Unmarshal(rr.Body.Bytes(), &first); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Evidence) != 1 || first.Evidence[0].Status != "passed" || first.AutoMergeable {
+		t.Fatalf("expected passing evidence without auto-merge, got %+v", first)
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenario-baselines", bytes.NewReader([]byte(`{"name":"motd-1.0.0","run_id":"`+first.Evidence[0].RunID+`"}`))))
+	if rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+		t.Fatalf("create baseline failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/release/dependency-bot/executions", bytes.NewReader([]byte(`{"package":"acme/motd","current_version":"1.0.0"}`))))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected baselined bump to be auto-mergeable: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var second proposal
+	if err := json.Unmarshal(rr.Body.Bytes(), &second); err != nil {
+		t.Fatal(err)
+	}
+	if !second.AutoMergeable {
+		t.Fatalf("expected auto-mergeable proposal, got %+v", second)
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/release/dependency-bot/executions", bytes.NewReader([]byte(`{"package":"acme/motd","current_version":"1.1.0"}`))))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected no newer version error: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -76,3 +77,137 @@ func (s *Server) handleDisruptionBudgetEvaluate(w http.ResponseWriter, r *http.R
 	}
 	writeJSON(w, code, report)
 }
+
+// handleDisruptionClaims serves GET/POST /v1/control/disruption-budgets/claims.
+// GET lists claims (?service=, ?active=true); POST acquires one for callers
+// outside the built-in rollout, patch, reboot, and bulk paths.
+func (s *Server) handleDisruptionClaims(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.disruptionBudgets.Claims(r.URL.Query().Get("service"), strings.EqualFold(r.URL.Query().Get("active"), "true"))
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.DisruptionClaimInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.Source) == "" {
+			req.Source = "api"
+		}
+		decision, err := s.acquireDisruption(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if !decision.Granted {
+			writeJSON(w, http.StatusConflict, decision)
+			return
+		}
+		writeJSON(w, http.StatusCreated, decision)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDisruptionClaimAction serves POST /v1/control/disruption-budgets/claims/{id}/release.
+func (s *Server) handleDisruptionClaimAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 6 || parts[5] != "release" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown disruption claim action"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	claim, err := s.disruptionBudgets.Release(parts[4])
+	if err != nil {
+		code := http.StatusConflict
+		if strings.Contains(err.Error(), "not found") {
+			code = http.StatusNotFound
+		}
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	s.noteDisruptionReleased(claim)
+	writeJSON(w, http.StatusOK, claim)
+}
+
+func (s *Server) acquireDisruption(in control.DisruptionClaimInput) (control.DisruptionClaimDecision, error) {
+	decision, err := s.disruptionBudgets.Acquire(in)
+	if err != nil {
+		return decision, err
+	}
+	if !decision.Granted {
+		s.recordEvent(control.Event{
+			Type:    "control.disruption_budget.denied",
+			Message: "disruptive operation blocked by disruption budget",
+			Fields: map[string]any{
+				"service":     decision.Service,
+				"source":      in.Source,
+				"ref":         in.Ref,
+				"budget_id":   decision.BlockedBy,
+				"reason":      decision.Reason,
+				"unavailable": decision.Unavailable,
+			},
+		}, true)
+		return decision, nil
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.disruption_budget.claimed",
+		Message: "disruption budget claimed",
+		Fields: map[string]any{
+			"claim_id":    decision.Claim.ID,
+			"service":     decision.Service,
+			"source":      decision.Claim.Source,
+			"ref":         decision.Claim.Ref,
+			"hosts":       len(decision.Claim.Hosts),
+			"unavailable": decision.Unavailable,
+		},
+	}, true)
+	return decision, nil
+}
+
+func (s *Server) noteDisruptionReleased(claim control.DisruptionClaim) {
+	s.recordEvent(control.Event{
+		Type:    "control.disruption_budget.released",
+		Message: "disruption budget claim released",
+		Fields: map[string]any{
+			"claim_id": claim.ID,
+			"service":  claim.Service,
+			"source":   claim.Source,
+		},
+	}, true)
+}
+
+// claimPlannedDisruption reserves a planned operation's largest wave, the
+// most hosts it takes down at once, against the service's budgets. It
+// returns the claim ID, or the reason the plan must be blocked.
+func (s *Server) claimPlannedDisruption(service, source string, waves [][]string, totalHosts int) (string, string) {
+	if strings.TrimSpace(service) == "" {
+		return "", ""
+	}
+	var peak []string
+	for _, wave := range waves {
+		if len(wave) > len(peak) {
+			peak = wave
+		}
+	}
+	if len(peak) == 0 {
+		return "", ""
+	}
+	decision, err := s.acquireDisruption(control.DisruptionClaimInput{
+		Service:    service,
+		Source:     source,
+		Hosts:      peak,
+		TotalHosts: totalHosts,
+	})
+	if err != nil {
+		return "", err.Error()
+	}
+	if !decision.Granted {
+		return "", "disruption budget " + decision.BlockedBy + ": " + decision.Reason
+	}
+	return decision.Claim.ID, ""
+}
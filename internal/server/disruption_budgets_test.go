@@ -77,3 +77,60 @@ resources:
 		t.Fatalf("expected disruption budget conflict: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestDisruptionBudgetsEnforcedAcrossSubsystems(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/disruption-budgets", `{"name":"checkout","scope":"checkout","max_unavailable":2,"min_healthy_pct":50,"total_hosts":4}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create budget: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/deployments/rollout/policies", `{"environment":"prod","strategy":"rolling","mode":"serial"}`); rr.Code != http.StatusOK {
+		t.Fatalf("rollout policy: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/deployments/rollout/plan", `{"environment":"prod","service":"checkout","targets":["web-a","web-b","web-c","web-d"]}`)
+	var rollout struct {
+		DisruptionClaim string `json:"disruption_claim_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &rollout); err != nil || rr.Code != http.StatusOK || rollout.DisruptionClaim == "" {
+		t.Fatalf("expected rollout plan to claim budget: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/execution/reboot/plan", `{"environment":"prod","service":"checkout","hosts":[{"id":"db-1","role":"db","healthy":true}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected reboot plan within budget: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Rollout and reboot already hold two of the two allowed hosts.
+	rr = do(http.MethodPost, "/v1/bulk/execute", `{"preview_token":"none","confirm":true,"service":"checkout","hosts":["web-c"]}`)
+	if rr.Code != http.StatusConflict || !bytes.Contains(rr.Body.Bytes(), []byte(`"blocked_by"`)) {
+		t.Fatalf("expected bulk execute blocked by budget: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/execution/patch/plan", `{"environment":"prod","service":"checkout","hour_utc":3,"hosts":[{"id":"web-c","classification":"security"}]}`)
+	if rr.Code != http.StatusConflict || !bytes.Contains(rr.Body.Bytes(), []byte("disruption budget")) {
+		t.Fatalf("expected patch plan blocked by budget: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/disruption-budgets/claims/"+rollout.DisruptionClaim+"/release", ""); rr.Code != http.StatusOK {
+		t.Fatalf("release claim: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/disruption-budgets/claims/"+rollout.DisruptionClaim+"/release", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected double release to conflict, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/control/disruption-budgets/claims", `{"service":"checkout","source":"maintenance","hosts":["web-c"]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected claim after release: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/control/disruption-budgets/claims?service=checkout&active=true", "")
+	var claims struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &claims); err != nil || claims.Count != 2 {
+		t.Fatalf("expected two active claims: %s", rr.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
 	"sort"
 	"strconv"
@@ -10,29 +11,49 @@ import (
 )
 
 func (s *Server) handleActionDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var req control.ActionDoc
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.actionDocs.Upsert(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.messages.Register(control.MessageDomainDocs, item.Title, item.Summary)
+		s.responseCache.Invalidate(responseCacheTagActionDocs)
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	items := s.actionDocs.List()
-	if q := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("q"))); q != "" {
-		filtered := make([]any, 0, len(items))
-		for _, item := range items {
-			text := strings.ToLower(item.ID + " " + item.Title + " " + item.Summary + " " + strings.Join(item.Tags, " "))
-			if strings.Contains(text, q) {
-				filtered = append(filtered, item)
+	s.serveCachedJSON(w, r, 0, []string{responseCacheTagActionDocs}, func() (int, any) {
+		items := s.actionDocs.List()
+		for i := range items {
+			items[i] = s.localizeActionDoc(r, items[i])
+		}
+		if q := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("q"))); q != "" {
+			filtered := make([]any, 0, len(items))
+			for _, item := range items {
+				text := strings.ToLower(item.ID + " " + item.Title + " " + item.Summary + " " + strings.Join(item.Tags, " "))
+				if strings.Contains(text, q) {
+					filtered = append(filtered, item)
+				}
+			}
+			return http.StatusOK, map[string]any{
+				"items": filtered,
+				"count": len(filtered),
+				"query": q,
 			}
 		}
-		writeJSON(w, http.StatusOK, map[string]any{
-			"items": filtered,
-			"count": len(filtered),
-			"query": q,
-		})
-		return
-	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items": items,
-		"count": len(items),
+		return http.StatusOK, map[string]any{
+			"items": items,
+			"count": len(items),
+		}
 	})
 }
 
@@ -52,7 +73,7 @@ func (s *Server) handleActionDocByID(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusOK, item)
+	writeJSON(w, http.StatusOK, s.localizeActionDoc(r, item))
 }
 
 type inlineDocMatch struct {
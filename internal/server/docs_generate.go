@@ -1,15 +1,23 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
 
 func (s *Server) handleDocsGenerate(w http.ResponseWriter, r *http.Request) {
 	build := func(req control.DocumentationGenerateInput) control.DocumentationArtifact {
-		return control.GenerateDocumentation(req, s.packageRegistry.ListArtifacts(), currentAPISpec().Endpoints)
+		item := control.GenerateDocumentation(req, s.packageRegistry.ListArtifacts(), currentAPISpec().Endpoints)
+		item.Locale = requestLocale(r)
+		if item.Format == "markdown" {
+			item.Content = s.messages.LocalizeMarkdown(item.Locale, item.Content)
+		}
+		return item
 	}
 
 	switch r.Method {
@@ -25,6 +33,10 @@ func (s *Server) handleDocsGenerate(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 			return
 		}
+		if req.Bundle {
+			s.publishDocsBundle(w, req.Channel)
+			return
+		}
 		item := build(req)
 		s.recordEvent(control.Event{
 			Type:    "docs.generated",
@@ -41,15 +53,141 @@ func (s *Server) handleDocsGenerate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleDocsExampleVerify(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// publishDocsBundle renders the registered action docs, object model, API
+// spec, provider catalog, and examples into the object store under the
+// release channel the control plane runs on, unless another is named.
+func (s *Server) publishDocsBundle(w http.ResponseWriter, channel string) {
+	if strings.TrimSpace(channel) == "" {
+		channel = "stable"
+		for _, assignment := range s.channels.List() {
+			if assignment.Component == "control-plane" {
+				channel = assignment.Channel
+			}
+		}
+	}
+	bundle, err := s.docsBundles.Publish(channel, control.DocumentationSources{
+		ActionDocs:   s.actionDocs.List(),
+		ObjectModel:  s.objectModel.List(),
+		Dependencies: s.objectModel.DependencyGraph(),
+		API:          currentAPISpec(),
+		Providers:    s.providerCatalog.List(),
+		Packages:     s.packageRegistry.ListArtifacts(),
+	})
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if bundle.Unchanged {
+		writeJSON(w, http.StatusOK, bundle)
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "docs.bundle.published",
+		Message: "published static documentation bundle",
+		Fields: map[string]any{
+			"channel": bundle.Channel,
+			"version": bundle.Version,
+			"files":   len(bundle.Files),
+			"counts":  bundle.Counts,
+		},
+	}, true)
+	writeJSON(w, http.StatusCreated, bundle)
+}
+
+func (s *Server) handleDocsBundles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	report := control.VerifyActionDocExamples(s.actionDocs.List(), currentAPISpec().Endpoints)
-	code := http.StatusOK
-	if !report.Passed {
-		code = http.StatusConflict
+	writeJSON(w, http.StatusOK, s.docsBundles.List(r.URL.Query().Get("channel")))
+}
+
+// handleDocsBundleFile serves a published bundle file so the static site can
+// be browsed straight from the control plane.
+func (s *Server) handleDocsBundleFile(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/docs/bundles/{channel}/{version}[/{path}]
+	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "docs" || parts[2] != "bundles" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	bundle, ok := s.docsBundles.Lookup(parts[3], parts[4])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "documentation bundle not found"})
+		return
+	}
+	name := "index.html"
+	if len(parts) > 5 {
+		name = strings.Join(parts[5:], "/")
+	}
+	if name == "manifest.json" {
+		writeJSON(w, http.StatusOK, bundle)
+		return
+	}
+	for _, file := range bundle.Files {
+		if file.Path != name {
+			continue
+		}
+		if s.objectStore == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+			return
+		}
+		data, _, err := s.objectStore.Get(file.Key)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", file.ContentType)
+		w.Header().Set("X-Docs-Version", bundle.Version)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "documentation bundle file not found"})
+}
+
+func (s *Server) handleDocsExampleVerify(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"items": s.docsExamples.List()})
+	case http.MethodPost:
+		report, err := s.docsExamples.Verify(currentAPISpec().Endpoints, s.serveDocsExample)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if len(report.Regressions) > 0 {
+			s.recordEvent(control.Event{
+				Type:    "docs.examples.regressed",
+				Message: "documentation examples regressed",
+				Fields: map[string]any{
+					"report_id":   report.ID,
+					"regressions": report.Regressions,
+				},
+			}, true)
+		}
+		code := http.StatusOK
+		if !report.Passed {
+			code = http.StatusConflict
+		}
+		writeJSON(w, code, report)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// serveDocsExample runs a plan-only docs example through the server's own
+// handler.
+func (s *Server) serveDocsExample(method, path string, body []byte) (int, []byte) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	writeJSON(w, code, report)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	return rr.Code, rr.Body.Bytes()
 }
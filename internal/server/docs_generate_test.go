@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -66,4 +67,111 @@ func TestDocsGenerateEndpoint(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Fatalf("docs examples verify failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/channels", bytes.NewReader([]byte(`{"action":"set_channel","component":"control-plane","channel":"candidate"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set channel failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/docs/generate", bytes.NewReader([]byte(`{"bundle":true}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("docs bundle failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var bundle struct {
+		Channel string `json:"channel"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Channel != "candidate" || bundle.Version == "" {
+		t.Fatalf("expected bundle on the control plane channel, got %+v", bundle)
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/docs/bundles/candidate/latest/providers.html", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") || !strings.Contains(rr.Body.String(), "core/network@1.0.0") {
+		t.Fatalf("expected provider catalog page from object store: code=%d headers=%v body=%s", rr.Code, rr.Header(), rr.Body.String())
+	}
+	if rr.Header().Get("X-Docs-Version") != bundle.Version {
+		t.Fatalf("expected docs version header, got %q", rr.Header().Get("X-Docs-Version"))
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/docs/bundles?channel=candidate", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), bundle.Version) {
+		t.Fatalf("expected bundle listing: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/docs/bundles/stable/latest", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected missing channel bundle to 404: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDocsExampleVerifyExecutesExamplesAndGatesReadiness(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	verify := func() (int, map[string]any) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/docs/examples/verify", nil)
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		var report map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatalf("decode verify report: %v body=%s", err, rr.Body.String())
+		}
+		return rr.Code, report
+	}
+	code, report := verify()
+	if code != http.StatusOK || report["executed"].(float64) < 2 {
+		t.Fatalf("expected seeded plan examples to execute and pass: code=%d report=%v", code, report)
+	}
+
+	// Break the seeded plan example by pointing it at a missing config.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/docs/actions/preview-config-change", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	var doc map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["body"] = strings.Replace(doc["body"].(string), `{"config_path":"motd.yaml"}`, `{"config_path":"missing.yaml"}`, 1)
+	payload, _ := json.Marshal(doc)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/docs/actions", bytes.NewReader(payload))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upsert action doc failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	code, report = verify()
+	if code != http.StatusConflict {
+		t.Fatalf("expected broken example to fail verification: code=%d report=%v", code, report)
+	}
+	regressions, _ := report["regressions"].([]any)
+	if len(regressions) != 1 || regressions[0] != "preview-config-change#2" {
+		t.Fatalf("expected plan example regression, got %v", report)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/release/readiness", bytes.NewReader([]byte(`{"signals":{"quality_score":1,"test_pass_rate":1}}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "docs example preview-config-change#2 regressed") {
+		t.Fatalf("expected readiness to be blocked by docs regression: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/docs/examples/verify", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || strings.Count(rr.Body.String(), `"id":"docs-verify-`) != 2 {
+		t.Fatalf("expected verification history: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 }
@@ -67,7 +67,11 @@ func (s *Server) handleEdgeRelaySiteAction(w http.ResponseWriter, r *http.Reques
 		}
 		site, err := s.edgeRelay.Heartbeat(siteID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			code := http.StatusNotFound
+			if strings.Contains(err.Error(), "revoked") {
+				code = http.StatusForbidden
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
 			return
 		}
 		writeJSON(w, http.StatusOK, site)
@@ -87,7 +91,11 @@ func (s *Server) handleEdgeRelaySiteAction(w http.ResponseWriter, r *http.Reques
 		}
 		result, err := s.edgeRelay.Deliver(siteID, req.Limit)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			code := http.StatusBadRequest
+			if strings.Contains(err.Error(), "revoked") {
+				code = http.StatusForbidden
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
 			return
 		}
 		writeJSON(w, http.StatusOK, result)
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleEmergencyStopScopes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.queue.EmergencyScopes()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.EmergencyStopScope
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		req.SetBy = emergencyStopActor(r, req.SetBy)
+		scope, err := s.queue.SetScopedEmergencyStop(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEmergencyScopeEvent(true, scope, scope.SetBy)
+		writeJSON(w, http.StatusCreated, scope)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleEmergencyStopScopeAction(w http.ResponseWriter, r *http.Request) {
+	// /v1/control/emergency-stop/scopes/{id}
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 5 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown emergency stop scope path"})
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	scope, err := s.queue.ClearScopedEmergencyStop(parts[4])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEmergencyScopeEvent(false, scope, emergencyStopActor(r, ""))
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": true, "id": scope.ID})
+}
+
+func (s *Server) recordEmergencyScopeEvent(active bool, scope control.EmergencyStopScope, actor string) {
+	s.events.Append(control.Event{
+		Type:    "control.emergency_stop.scope",
+		Message: "scoped emergency stop toggled",
+		Fields: map[string]any{
+			"active":      active,
+			"scope_id":    scope.ID,
+			"environment": scope.Environment,
+			"tenant":      scope.Tenant,
+			"selector":    scope.Selector,
+			"reason":      scope.Reason,
+			"set_by":      scope.SetBy,
+			"actor":       actor,
+		},
+	})
+}
+
+// emergencyStopActor names who toggled a stop: the set_by field when given,
+// otherwise the request principal.
+func emergencyStopActor(r *http.Request, setBy string) string {
+	if setBy = strings.TrimSpace(setBy); setBy != "" {
+		return setBy
+	}
+	principal, _ := requestIdentity(r)
+	return principal
+}
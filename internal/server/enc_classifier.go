@@ -13,6 +13,9 @@ func (s *Server) handleENCProviders(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		writeJSON(w, http.StatusOK, s.encProviders.List())
 	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
 		var req control.ENCProviderInput
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
@@ -70,6 +73,9 @@ func (s *Server) handleENCProviderAction(w http.ResponseWriter, r *http.Request)
 		}
 		switch action {
 		case "enable", "disable":
+			if !s.requireControlAdmin(w, r) {
+				return
+			}
 			item, err := s.encProviders.SetEnabled(id, action == "enable")
 			if err != nil {
 				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
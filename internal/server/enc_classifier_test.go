@@ -48,6 +48,15 @@ func TestENCProviderEndpoints(t *testing.T) {
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPost, "/v1/inventory/node-classifiers", bytes.NewReader(create))
 	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthenticated provider registration to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	grantControlAdmin(t, s, "inventory-admin")
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/inventory/node-classifiers", bytes.NewReader(create))
+	req.Header.Set("X-Masterchef-Principal", "inventory-admin")
+	s.httpServer.Handler.ServeHTTP(rr, req)
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("create enc provider failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
@@ -81,6 +90,7 @@ func TestENCProviderEndpoints(t *testing.T) {
 
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/inventory/node-classifiers/"+provider.ID+"/disable", nil)
+	req.Header.Set("X-Masterchef-Principal", "inventory-admin")
 	s.httpServer.Handler.ServeHTTP(rr, req)
 	if rr.Code != http.StatusOK {
 		t.Fatalf("disable enc provider failed: code=%d body=%s", rr.Code, rr.Body.String())
@@ -93,3 +103,72 @@ func TestENCProviderEndpoints(t *testing.T) {
 		t.Fatalf("expected classify to fail for disabled provider: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestENCExecProviderFallbackEndpoint(t *testing.T) {
+	tmp := t.TempDir()
+	features := filepath.Join(tmp, "features.md")
+	if err := os.WriteFile(features, []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MC_ENC_EXEC_COMMANDS", "/bin/sh")
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	grantControlAdmin(t, s, "inventory-admin")
+
+	for _, body := range []string{
+		`{"name":"unlisted","type":"exec","command":"/usr/bin/env","args":["true"],"enabled":true}`,
+		`{"name":"blocked","type":"exec","command":"/bin/sh","args":["-c","reboot","enc"],"enabled":true}`,
+	} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/inventory/node-classifiers", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "inventory-admin")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected disallowed exec provider to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+
+	for _, body := range []string{
+		`{"name":"primary","type":"exec","command":"/bin/sh","args":["-c","exit 1","enc"],"priority":1,"enabled":true}`,
+		`{"name":"secondary","type":"exec","command":"/bin/sh","args":["-c","echo '{\"classes\":[\"base\"],\"environment\":\"staging\"}'","enc"],"priority":2,"enabled":true}`,
+	} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/inventory/node-classifiers", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "inventory-admin")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("create exec provider failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/inventory/node-classifiers/classify", bytes.NewReader([]byte(`{"node":"db-01"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("fallback classify failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Source      string `json:"source"`
+		Environment string `json:"environment"`
+		Attempts    []struct {
+			Name  string `json:"name"`
+			Error string `json:"error"`
+		} `json:"attempts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Source != "secondary" || out.Environment != "staging" || len(out.Attempts) != 2 || out.Attempts[0].Error == "" {
+		t.Fatalf("unexpected fallback classification %+v", out)
+	}
+}
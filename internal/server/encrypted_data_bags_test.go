@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedDataBagKeysAndServerDecrypt(t *testing.T) {
+	tmp := t.TempDir()
+	features := filepath.Join(tmp, "features.md")
+	if err := os.WriteFile(features, []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	setKey := func(principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/data-bags/keys", bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := setKey("", `{"bag":"secrets","authorized_principals":["node-a"]}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected anonymous key creation rejected, got code=%d", rr.Code)
+	}
+	if rr := setKey("node-a", `{"bag":"secrets","authorized_principals":["node-a"]}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected key creation without control admin rejected, got code=%d", rr.Code)
+	}
+	grantControlAdmin(t, s, "sec-admin")
+	rr := setKey("sec-admin", `{"bag":"secrets","authorized_principals":["node-a"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create bag key failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var keyResp struct {
+		Secret string `json:"secret"`
+		Key    struct {
+			Version int `json:"version"`
+		} `json:"key"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &keyResp); err != nil {
+		t.Fatal(err)
+	}
+	if keyResp.Secret == "" || keyResp.Key.Version != 1 {
+		t.Fatalf("unexpected bag key response %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/v1/data-bags/secrets/db", bytes.NewReader([]byte(`{"data":{"id":"db","password":"p1"},"encrypted":true}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("put encrypted item failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte(`"p1"`)) {
+		t.Fatalf("plaintext leaked in stored item: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/data-bags/keys", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || bytes.Contains(rr.Body.Bytes(), []byte(keyResp.Secret)) {
+		t.Fatalf("list keys must not expose secret: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	decrypt := func(principal string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/data-bags/secrets/db?decrypt=true", nil)
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := decrypt(""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without principal, got %d", rr.Code)
+	}
+	if rr := decrypt("node-b"); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for unauthorized principal, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = decrypt("node-a")
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"password":"p1"`)) {
+		t.Fatalf("expected authorized decrypt, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Rotation is limited to principals on the key and control admins, and
+	// the new secret goes only to them.
+	if rr := setKey("node-b", `{"bag":"secrets","authorized_principals":["node-b"]}`); rr.Code != http.StatusForbidden || bytes.Contains(rr.Body.Bytes(), []byte(`"secret"`)) {
+		t.Fatalf("expected rotation by an unlisted principal rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = setKey("node-a", `{"bag":"secrets","authorized_principals":["node-a"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected rotation by a listed principal, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &keyResp); err != nil {
+		t.Fatal(err)
+	}
+	if keyResp.Secret == "" || keyResp.Key.Version != 2 {
+		t.Fatalf("unexpected rotated key response %s", rr.Body.String())
+	}
+
+	// The shared secret still works as a Chef-style passphrase.
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/data-bags/secrets/db?passphrase="+url.QueryEscape(keyResp.Secret), nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"password":"p1"`)) {
+		t.Fatalf("expected passphrase decrypt, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// ifMatchVersion reads the version a client expects to modify from If-Match.
+// When required, a missing header is rejected with 428 so concurrent edits
+// cannot silently overwrite each other. A zero version means "*" or, when
+// not required, no header at all.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, required bool) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if required {
+			writeJSON(w, http.StatusPreconditionRequired, map[string]string{"error": "If-Match header with the entity ETag is required"})
+			return 0, false
+		}
+		return 0, true
+	}
+	version, err := control.ParseIfMatch(header)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersioned writes body with the entity's version as its ETag.
+func writeVersioned(w http.ResponseWriter, code int, version int64, body any) {
+	w.Header().Set("ETag", control.EntityETag(version))
+	writeJSON(w, code, body)
+}
+
+// writeEntityUpdateError maps a store update error to 409 on a version
+// conflict, 404 on a missing entity, and 400 otherwise.
+func writeEntityUpdateError(w http.ResponseWriter, err error) {
+	var conflict *control.VersionConflictError
+	switch {
+	case errors.As(err, &conflict):
+		w.Header().Set("ETag", control.EntityETag(conflict.Current))
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error":            err.Error(),
+			"current_version":  conflict.Current,
+			"expected_version": conflict.Expected,
+		})
+	case strings.Contains(err.Error(), "not found"):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+}
+
+func (s *Server) recordEntityVersion(r *http.Request, kind, id string, version int64, action string, entity any) {
+	principal, _ := requestIdentity(r)
+	if err := s.entityVersions.Record(kind, id, version, action, principal, entity); err != nil {
+		s.requestLogger(r.Context()).Warn("entity version snapshot failed", "kind", kind, "entity_id", id, "error", err)
+	}
+}
+
+// handleEntityVersions serves /.../{id}/versions[/{version}] for a versioned
+// entity kind.
+func (s *Server) handleEntityVersions(w http.ResponseWriter, r *http.Request, kind, id string, rest []string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch len(rest) {
+	case 0:
+		items := s.entityVersions.History(kind, id)
+		if len(items) == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no version history for " + kind + " " + id})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"kind":      kind,
+			"entity_id": id,
+			"items":     items,
+			"count":     len(items),
+		})
+	case 1:
+		version, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || version <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "version must be a positive integer"})
+			return
+		}
+		item, err := s.entityVersions.Get(kind, id, version)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeVersioned(w, http.StatusOK, item.Version, item)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown version path"})
+	}
+}
+
+// handleTemplateEntity serves GET, PUT, and PATCH on /v1/templates/{id}.
+func (s *Server) handleTemplateEntity(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		t, ok := s.templates.Get(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "template not found"})
+			return
+		}
+		writeVersioned(w, http.StatusOK, t.Version, t)
+	case http.MethodPut:
+		expected, ok := ifMatchVersion(w, r, true)
+		if !ok {
+			return
+		}
+		var req templateDocument
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		s.saveTemplate(w, r, id, req, expected, "updated")
+	case http.MethodPatch:
+		expected, ok := ifMatchVersion(w, r, false)
+		if !ok {
+			return
+		}
+		t, found := s.templates.Get(id)
+		if !found {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "template not found"})
+			return
+		}
+		if expected == 0 {
+			expected = t.Version
+		}
+		req, ok := mergePatchRequest(w, r, templateDocument{
+			Name:        t.Name,
+			Description: t.Description,
+			ConfigPath:  t.ConfigPath,
+			StrictMode:  t.StrictMode,
+			Defaults:    t.Defaults,
+			Survey:      t.Survey,
+			Labels:      t.Labels,
+			Owner:       t.Owner,
+			Team:        t.Team,
+		})
+		if !ok {
+			return
+		}
+		s.saveTemplate(w, r, id, req, expected, "patched")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) saveTemplate(w http.ResponseWriter, r *http.Request, id string, req templateDocument, expected int64, action string) {
+	if req.Name == "" || req.ConfigPath == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name and config_path are required"})
+		return
+	}
+	if !filepath.IsAbs(req.ConfigPath) {
+		req.ConfigPath = filepath.Join(s.baseDir, req.ConfigPath)
+	}
+	if _, err := os.Stat(req.ConfigPath); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("config_path not found: %v", err)})
+		return
+	}
+	if !s.enforceConfigLint(w, "template", req.ConfigPath) {
+		return
+	}
+	labels, err := control.NormalizeLabels(req.Labels)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+	if !ok {
+		return
+	}
+	if !validateTemplateSurvey(w, req.Survey, req.Defaults) {
+		return
+	}
+	if !s.enforceSecretScan(w, control.SecretScanInput{Kind: "template", Target: req.Name, Team: team}, req.ConfigPath) {
+		return
+	}
+	t, err := s.templates.Update(id, control.Template{
+		Name:        req.Name,
+		Description: req.Description,
+		ConfigPath:  req.ConfigPath,
+		StrictMode:  req.StrictMode,
+		Defaults:    req.Defaults,
+		Survey:      req.Survey,
+		Labels:      labels,
+		Owner:       owner,
+		Team:        team,
+	}, expected)
+	if err != nil {
+		writeEntityUpdateError(w, err)
+		return
+	}
+	s.objectModel.UnregisterTemplate(t.ID)
+	_ = s.objectModel.RegisterTemplate(t.ID, t.ConfigPath)
+	s.recordEntityVersion(r, control.VersionedKindTemplate, t.ID, t.Version, action, t)
+	s.events.Append(control.Event{
+		Type:    "template.updated",
+		Message: "template updated",
+		Fields: map[string]any{
+			"template_id": t.ID,
+			"version":     t.Version,
+		},
+	})
+	writeVersioned(w, http.StatusOK, t.Version, t)
+}
+
+// updateRule serves PUT and PATCH on /v1/rules/{id}.
+func (s *Server) updateRule(w http.ResponseWriter, r *http.Request, id string) {
+	expected, ok := ifMatchVersion(w, r, r.Method == http.MethodPut)
+	if !ok {
+		return
+	}
+	var req ruleDocument
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+	} else {
+		cur, err := s.rules.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		if expected == 0 {
+			expected = cur.Version
+		}
+		if req, ok = mergePatchRequest(w, r, ruleDocument{
+			Name:            cur.Name,
+			SourcePrefix:    cur.SourcePrefix,
+			Enabled:         cur.Enabled,
+			MatchMode:       cur.MatchMode,
+			Conditions:      cur.Conditions,
+			Actions:         cur.Actions,
+			CooldownSeconds: cur.CooldownSeconds,
+		}); !ok {
+			return
+		}
+	}
+	rule, err := s.rules.Update(id, control.Rule{
+		Name:            req.Name,
+		SourcePrefix:    req.SourcePrefix,
+		Enabled:         req.Enabled,
+		MatchMode:       req.MatchMode,
+		Conditions:      req.Conditions,
+		Actions:         req.Actions,
+		CooldownSeconds: req.CooldownSeconds,
+	}, expected)
+	if err != nil {
+		writeEntityUpdateError(w, err)
+		return
+	}
+	action := "updated"
+	if r.Method == http.MethodPatch {
+		action = "patched"
+	}
+	s.recordEntityVersion(r, control.VersionedKindRule, rule.ID, rule.Version, action, rule)
+	s.events.Append(control.Event{
+		Type:    "rule.updated",
+		Message: "event rule updated",
+		Fields: map[string]any{
+			"rule_id": rule.ID,
+			"version": rule.Version,
+		},
+	})
+	writeVersioned(w, http.StatusOK, rule.Version, rule)
+}
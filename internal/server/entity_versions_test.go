@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEntityVersionsRequireIfMatch(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "alice")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/templates", "", `{"name":"web","config_path":"c.yaml"}`)
+	if rr.Code != http.StatusCreated || rr.Header().Get("ETag") != `"v1"` {
+		t.Fatalf("create template failed: code=%d etag=%q body=%s", rr.Code, rr.Header().Get("ETag"), rr.Body.String())
+	}
+	var tpl struct {
+		ID      string `json:"id"`
+		Version int64  `json:"version"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &tpl)
+	path := "/v1/templates/" + tpl.ID
+
+	rr = do(http.MethodPut, path, "", `{"name":"web-v2","config_path":"c.yaml"}`)
+	if rr.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without If-Match: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, path, "", "")
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag != `"v1"` {
+		t.Fatalf("get template failed: code=%d etag=%q", rr.Code, etag)
+	}
+	rr = do(http.MethodPut, path, etag, `{"name":"web-v2","config_path":"c.yaml"}`)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"v2"` || !strings.Contains(rr.Body.String(), `"version":2`) {
+		t.Fatalf("update template failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPut, path, etag, `{"name":"web-lost","config_path":"c.yaml"}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"current_version":2`) {
+		t.Fatalf("expected 409 for stale If-Match: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, path+"/versions", "", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":2`) || !strings.Contains(rr.Body.String(), `"actor":"alice"`) {
+		t.Fatalf("template history failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, path+"/versions/1", "", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"web"`) {
+		t.Fatalf("template version 1 failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/rules", "", `{"name":"on-drift","source_prefix":"drift.","actions":[{"type":"enqueue_apply","config_path":"c.yaml"}]}`)
+	var rule struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &rule)
+	rulePath := "/v1/rules/" + rule.ID
+	rr = do(http.MethodPost, rulePath+"/disable", "", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"v2"` {
+		t.Fatalf("disable rule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, rulePath+"/enable", `"v1"`, "")
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected stale enable to conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	update := `{"name":"on-drift-v2","source_prefix":"drift.","enabled":true,"actions":[{"type":"enqueue_apply","config_path":"c.yaml"}]}`
+	if rr = do(http.MethodPut, rulePath, "", update); rr.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 for rule update: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPut, rulePath, `W/"v2"`, update)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"version":3`) || !strings.Contains(rr.Body.String(), `"enabled":true`) {
+		t.Fatalf("update rule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, rulePath+"/versions", "", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"action":"disabled"`) || !strings.Contains(rr.Body.String(), `"count":3`) {
+		t.Fatalf("rule history failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -116,6 +116,11 @@ func (s *Server) handleEventBusPublish(w http.ResponseWriter, r *http.Request) {
 		Message: req.Message,
 		Fields:  req.Fields,
 	}
+	if res, rejected := s.enforceEventSchema(evt); rejected {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "event does not match its schema", "validation": res})
+		return
+	}
+	s.eventSchemas.Observe(evt)
 	d := s.eventBus.Publish(evt)
 	writeJSON(w, http.StatusAccepted, map[string]any{
 		"status":     "published",
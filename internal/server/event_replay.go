@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+const maxEventReplayEvents = 5000
+
+type eventReplayAction struct {
+	Type   string `json:"type"`
+	Status string `json:"status"` // planned|succeeded|failed
+	Error  string `json:"error,omitempty"`
+}
+
+type eventReplayMatch struct {
+	RuleID   string              `json:"rule_id"`
+	RuleName string              `json:"rule_name"`
+	Actions  []eventReplayAction `json:"actions"`
+}
+
+type eventReplayWebhook struct {
+	WebhookID  string `json:"webhook_id"`
+	Status     string `json:"status"` // would_deliver|delivered|failed
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type eventReplayItem struct {
+	EventIndex int64                `json:"event_index"`
+	EventType  string               `json:"event_type"`
+	Time       time.Time            `json:"time"`
+	Matches    []eventReplayMatch   `json:"matches,omitempty"`
+	Webhooks   []eventReplayWebhook `json:"webhooks,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// handleEventReplay re-feeds a window of recorded events through the rule
+// engine and chosen webhooks. dry_run (the default) only reports what would
+// happen; live runs rule actions and delivers to the webhooks. Rule trigger
+// counts and cooldowns are never touched by a replay.
+func (s *Server) handleEventReplay(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Since                time.Time `json:"since"`
+		Until                time.Time `json:"until"`
+		TypePrefix           string    `json:"type_prefix"`
+		Contains             string    `json:"contains"`
+		Limit                int       `json:"limit"`
+		Mode                 string    `json:"mode"` // dry_run|live
+		Targets              []string  `json:"targets"`
+		RuleIDs              []string  `json:"rule_ids"`
+		IncludeDisabledRules bool      `json:"include_disabled_rules"`
+		WebhookIDs           []string  `json:"webhook_ids"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req reqBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	switch mode {
+	case "":
+		mode = "dry_run"
+	case "dry_run", "live":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "mode must be dry_run or live"})
+		return
+	}
+	if len(req.Targets) == 0 {
+		req.Targets = []string{"rules"}
+	}
+	replayRules, replayWebhooks := false, false
+	for _, target := range req.Targets {
+		switch strings.ToLower(strings.TrimSpace(target)) {
+		case "rules":
+			replayRules = true
+		case "webhooks":
+			replayWebhooks = true
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "targets must be rules and/or webhooks"})
+			return
+		}
+	}
+	if replayWebhooks && len(req.WebhookIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "webhook_ids are required when replaying to webhooks"})
+		return
+	}
+	for _, id := range req.RuleIDs {
+		if _, err := s.rules.Get(id); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "rule not found: " + id})
+			return
+		}
+	}
+	for _, id := range req.WebhookIDs {
+		if _, err := s.webhooks.Get(id); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found: " + id})
+			return
+		}
+	}
+	if !req.Since.IsZero() && !req.Until.IsZero() && req.Until.Before(req.Since) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "until must not be before since"})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > maxEventReplayEvents {
+		req.Limit = maxEventReplayEvents
+	}
+	live := mode == "live"
+
+	// Snapshot the window first so events emitted by the replay itself are
+	// never fed back into it.
+	events := s.events.Query(control.EventQuery{
+		Since:      req.Since,
+		Until:      req.Until,
+		TypePrefix: req.TypePrefix,
+		Contains:   req.Contains,
+		Limit:      req.Limit,
+	})
+	items := make([]eventReplayItem, 0)
+	byRule := map[string]int{}
+	matched, executed, failed, deliveries := 0, 0, 0, 0
+	for _, e := range events {
+		item := eventReplayItem{EventIndex: e.Index, EventType: e.Type, Time: e.Time}
+		if replayRules {
+			matches, err := s.rules.Preview(e, req.RuleIDs, req.IncludeDisabledRules)
+			if err != nil {
+				item.Error = err.Error()
+			}
+			for _, match := range matches {
+				matched++
+				byRule[match.RuleID]++
+				out := eventReplayMatch{RuleID: match.RuleID, RuleName: match.RuleName, Actions: make([]eventReplayAction, 0, len(match.Actions))}
+				for _, action := range match.Actions {
+					result := eventReplayAction{Type: action.Type, Status: "planned"}
+					if live {
+						if err := s.executeRuleAction(match, action); err != nil {
+							result.Status, result.Error = "failed", err.Error()
+							failed++
+						} else {
+							result.Status = "succeeded"
+							executed++
+						}
+					}
+					out.Actions = append(out.Actions, result)
+				}
+				item.Matches = append(item.Matches, out)
+			}
+		}
+		if replayWebhooks {
+			if live {
+				for _, d := range s.webhooks.Replay(e, req.WebhookIDs) {
+					deliveries++
+					item.Webhooks = append(item.Webhooks, eventReplayWebhook{WebhookID: d.WebhookID, Status: d.Status, StatusCode: d.StatusCode, Error: d.Error})
+				}
+			} else {
+				for _, wh := range s.webhooks.MatchingTargets(e, req.WebhookIDs) {
+					deliveries++
+					item.Webhooks = append(item.Webhooks, eventReplayWebhook{WebhookID: wh.ID, Status: "would_deliver"})
+				}
+			}
+		}
+		if len(item.Matches) > 0 || len(item.Webhooks) > 0 || item.Error != "" {
+			items = append(items, item)
+		}
+	}
+
+	s.recordEvent(control.Event{
+		Type:    "events.replayed",
+		Message: "historical events replayed",
+		Fields: map[string]any{
+			"mode":           mode,
+			"events_scanned": len(events),
+			"rule_matches":   matched,
+			"webhooks":       deliveries,
+		},
+	}, false)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"mode":             mode,
+		"events_scanned":   len(events),
+		"rule_matches":     matched,
+		"matches_by_rule":  byRule,
+		"actions_executed": executed,
+		"actions_failed":   failed,
+		"webhook_results":  deliveries,
+		"items":            items,
+	})
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// enforceEventSchema validates an externally supplied event. It reports
+// rejected only when the event's schema is in enforce mode; warn-mode
+// mismatches are accepted and recorded as violations once the event lands.
+func (s *Server) enforceEventSchema(e control.Event) (control.EventSchemaValidation, bool) {
+	res := s.eventSchemas.Validate(e)
+	return res, !res.Valid && res.Mode == control.EventSchemaEnforce
+}
+
+func (s *Server) handleEventSchemas(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.eventSchemas.List()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.EventSchema
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.eventSchemas.Register(req)
+		if err != nil {
+			var incompatible *control.EventSchemaIncompatibleError
+			if errors.As(err, &incompatible) {
+				writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error(), "problems": incompatible.Problems})
+				return
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "event.schema.registered",
+			Message: "event schema version registered",
+			Fields: map[string]any{
+				"event_type": item.EventType,
+				"version":    item.Version,
+				"mode":       item.Mode,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleEventSchemaAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 4 || len(parts) > 5 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown event schema path"})
+		return
+	}
+	switch {
+	case len(parts) == 4 && parts[3] == "violations":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		items, counts := s.eventSchemas.Violations(limit)
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items, "by_type": counts})
+	case len(parts) == 4 && parts[3] == "validate":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Type   string         `json:"type"`
+			Fields map[string]any `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.Type) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "type is required"})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.eventSchemas.Validate(control.Event{Type: req.Type, Fields: req.Fields}))
+	case len(parts) == 4:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		versions, err := s.eventSchemas.Versions(parts[3])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"event_type": parts[3],
+			"latest":     versions[len(versions)-1],
+			"versions":   versions,
+		})
+	case parts[4] == "compatibility":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Schema map[string]any `json:"schema"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		problems, err := s.eventSchemas.CheckCompatibility(parts[3], req.Schema)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"event_type": parts[3],
+			"compatible": len(problems) == 0,
+			"problems":   problems,
+		})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown event schema path"})
+	}
+}
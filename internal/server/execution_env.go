@@ -115,3 +115,30 @@ func (s *Server) handleExecutionAdmissionCheck(w http.ResponseWriter, r *http.Re
 		"result":      result,
 	})
 }
+
+func (s *Server) handleIsolatedRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.isolatedRunner.List(parseIntQuery(r, "limit", 100)))
+}
+
+func (s *Server) handleIsolatedRunAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/execution/isolated-runs/{job_id}
+	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "execution" || parts[2] != "isolated-runs" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, ok := s.isolatedRunner.Get(parts[3])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "isolated run not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
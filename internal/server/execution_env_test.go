@@ -106,3 +106,120 @@ resources:
 		t.Fatalf("expected unsigned env to fail admission: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestJobExecutionEnvironmentSelection(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/execution/environments", bytes.NewReader([]byte(`{
+  "name":"unsigned-container",
+  "image_digest":"sha256:3333333333333333333333333333333333333333333333333333333333333333",
+  "isolation":{"mode":"container","image":"registry.local/runner","limits":{"cpus":1,"memory_mb":256}}
+}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"mode":"container"`) {
+		t.Fatalf("create isolated env failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var env struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &env)
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/execution/environments", bytes.NewReader([]byte(`{
+  "name":"bad",
+  "image_digest":"sha256:3333333333333333333333333333333333333333333333333333333333333333",
+  "isolation":{"mode":"chroot","chroot_path":"relative/root"}
+}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected relative chroot path to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/jobs", bytes.NewReader([]byte(`{"config_path":"c.yaml","execution_env":"env-404"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown execution env to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/jobs", bytes.NewReader([]byte(`{"config_path":"c.yaml","execution_env":"`+env.ID+`"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "admission") {
+		t.Fatalf("expected unsigned execution env to fail admission: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/execution/isolated-runs", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list isolated runs failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/execution/isolated-runs/job-404", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected missing isolated run 404: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCloudCredentialBindingEndpoints(t *testing.T) {
+	t.Setenv("MC_CLOUD_SUBJECT_TOKEN_ENVS", "MC_SUBJECT")
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rr
+	}
+
+	rr := post("/v1/identity/oidc/workload/providers", `{"name":"cp","issuer_url":"https://issuer.example.com","audience":"sts.amazonaws.com","jwks_url":"https://issuer.example.com/jwks"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create oidc provider failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var provider struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &provider)
+
+	rr = post("/v1/execution/cloud-credentials/bindings", `{"name":"deploy","provider":"aws_sts","oidc_provider_id":"`+provider.ID+`","service_account":"runner","subject_token_env":"MC_SUBJECT","role_arn":"arn:aws:iam::123456789012:role/deploy"}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"duration_seconds":900`) {
+		t.Fatalf("create binding failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/execution/cloud-credentials/bindings", `{"name":"bad","provider":"azure","oidc_provider_id":"`+provider.ID+`","service_account":"runner","subject_token_env":"MC_SUBJECT"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unsupported provider to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/execution/cloud-credentials/bindings/deploy", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get binding by name failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/v1/jobs", `{"config_path":"c.yaml","cloud_credentials":["deploy"]}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected cloud credentials without isolation to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/execution/cloud-credentials/leases", nil))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Fatalf("expected empty lease list: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/execution/cloud-credentials/leases/cloud-cred-lease-404/revoke", "")
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown lease revoke 404: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
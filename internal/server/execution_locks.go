@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -24,14 +26,12 @@ func (s *Server) handleExecutionLocks(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
 			return
 		}
-		s.recordEvent(control.Event{
-			Type:    "execution.lock.acquired",
-			Message: "execution lock acquired",
-			Fields: map[string]any{
-				"lock_key": lock.Key,
-				"holder":   lock.Holder,
-			},
-		}, true)
+		if lock.Status == "waiting" {
+			s.recordExecutionLockEvent("execution.lock.queued", "execution lock request queued", lock)
+			writeJSON(w, http.StatusAccepted, lock)
+			return
+		}
+		s.recordExecutionLockEvent("execution.lock.acquired", "execution lock acquired", lock)
 		writeJSON(w, http.StatusCreated, lock)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -68,32 +68,69 @@ func (s *Server) handleExecutionLockCleanup(w http.ResponseWriter, r *http.Reque
 	})
 }
 
-func (s *Server) enqueueJobWithOptionalLock(configPath, idempotencyKey string, force bool, priority, lockKey string, lockTTLSeconds int, lockOwner string) (*control.Job, error) {
-	lockKey = strings.TrimSpace(lockKey)
-	if lockKey == "" {
-		return s.queue.Enqueue(configPath, idempotencyKey, force, priority)
+// handleExecutionLockWaitGraph shows which holders waiting lock requests
+// are queued behind.
+func (s *Server) handleExecutionLockWaitGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	edges := s.executionLocks.WaitForGraph()
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(edges), "edges": edges})
+}
+
+func (s *Server) recordExecutionLockEvent(eventType, message string, lock control.ExecutionLock) {
+	s.recordEvent(control.Event{
+		Type:    eventType,
+		Message: message,
+		Fields: map[string]any{
+			"lock_id":  lock.ID,
+			"lock_key": lock.Key,
+			"mode":     lock.Mode,
+			"holder":   lock.Holder,
+		},
+	}, true)
+}
+
+func (s *Server) sweepExecutionLocks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, lock := range s.executionLocks.CleanupExpired() {
+				s.recordExecutionLockEvent("execution.lock.expired", "execution lock expired", lock)
+			}
+		}
 	}
-	owner := strings.TrimSpace(lockOwner)
-	if owner == "" {
-		owner = strings.TrimSpace(idempotencyKey)
+}
+
+// enqueueJobWithOptionalLock takes lock, when it has a key, before
+// enqueueing and binds it to the job. Jobs never wait for a lock: a
+// conflicting lock fails the enqueue.
+func (s *Server) enqueueJobWithOptionalLock(placement control.JobPlacement, configPath, idempotencyKey string, force bool, priority string, lock control.ExecutionLockAcquireInput) (*control.Job, error) {
+	lock.Key = strings.TrimSpace(lock.Key)
+	if lock.Key == "" {
+		return s.queue.EnqueuePlaced(placement, configPath, idempotencyKey, force, priority)
 	}
-	if owner == "" {
-		owner = "control-plane"
+	lock.Holder = strings.TrimSpace(lock.Holder)
+	if lock.Holder == "" {
+		lock.Holder = strings.TrimSpace(idempotencyKey)
 	}
-	if _, err := s.executionLocks.Acquire(control.ExecutionLockAcquireInput{
-		Key:        lockKey,
-		Holder:     owner,
-		TTLSeconds: lockTTLSeconds,
-	}); err != nil {
+	lock.Wait = false
+	held, err := s.executionLocks.Acquire(lock)
+	if err != nil {
 		return nil, err
 	}
-	job, err := s.queue.Enqueue(configPath, idempotencyKey, force, priority)
+	job, err := s.queue.EnqueuePlaced(placement, configPath, idempotencyKey, force, priority)
 	if err != nil {
-		_, _ = s.executionLocks.Release(control.ExecutionLockReleaseInput{Key: lockKey})
+		_, _ = s.executionLocks.Release(control.ExecutionLockReleaseInput{ID: held.ID})
 		return nil, err
 	}
-	if _, err := s.executionLocks.BindJob(lockKey, job.ID); err != nil {
-		_, _ = s.executionLocks.Release(control.ExecutionLockReleaseInput{Key: lockKey})
+	if _, err := s.executionLocks.BindJob(held.ID, job.ID); err != nil {
+		_, _ = s.executionLocks.Release(control.ExecutionLockReleaseInput{ID: held.ID})
 		_ = s.queue.Cancel(job.ID)
 		return nil, err
 	}
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestExecutionLocksEndpointsAndJobConflict(t *testing.T) {
@@ -83,3 +85,55 @@ resources:
 		t.Fatalf("expected third locked job accepted after release: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestExecutionLockQueueingAndWaitGraphEndpoints(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	s.queue.Pause()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, owner := range []string{"deploy-a", "deploy-b"} {
+		body := `{"config_path":"c.yaml","lock_key":"prod/api","lock_mode":"shared","lock_owner":"` + owner + `"}`
+		if rr := do(http.MethodPost, "/v1/jobs", body); rr.Code != http.StatusAccepted {
+			t.Fatalf("expected shared locked job for %s: code=%d body=%s", owner, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"c.yaml","lock_key":"prod","lock_owner":"migrate"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected exclusive lock on the cluster to conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := do(http.MethodPost, "/v1/control/execution-locks", `{"key":"prod","holder":"migrate","wait":true}`)
+	if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"status":"waiting"`) {
+		t.Fatalf("expected queued lock request: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/control/execution-locks/wait-graph", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":2`) {
+		t.Fatalf("expected migrate to wait on both shared holders: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	jobs := s.queue.List()
+	for _, job := range jobs {
+		if rr := do(http.MethodDelete, "/v1/jobs/"+job.ID, ""); rr.Code != http.StatusOK {
+			t.Fatalf("cancel job failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	rr = do(http.MethodGet, "/v1/control/execution-locks", "")
+	if !strings.Contains(rr.Body.String(), `"holder":"migrate"`) || !strings.Contains(rr.Body.String(), `"status":"active"`) || strings.Contains(rr.Body.String(), `"status":"waiting"`) {
+		t.Fatalf("expected migrate to be granted once the shared locks were released: %s", rr.Body.String())
+	}
+	granted := s.events.Query(control.EventQuery{TypePrefix: "execution.lock.granted"})
+	if len(granted) != 1 || granted[0].Fields["holder"] != "migrate" {
+		t.Fatalf("expected one grant event for migrate, got %+v", granted)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFactMinePublishAndTemplateRender(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "lb.yaml")
+	features := filepath.Join(tmp, "features.md")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: upstreams
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "upstreams.txt")+`
+    content: "{{ mine 'network.ip' 'web-*' }}"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(features, []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("/v1/facts/mine/functions", `{"name":"network.ip","fact":"ipaddress","interval_seconds":30}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create mine function failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/facts/mine/functions?node=web-01", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"interval_seconds":30`) {
+		t.Fatalf("agent function list failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, body := range []string{
+		`{"node":"web-01","facts":{"ipaddress":"10.0.0.1"}}`,
+		`{"node":"web-02","facts":{"ipaddress":"10.0.0.2"}}`,
+		`{"node":"db-01","facts":{"ipaddress":"10.0.9.9"}}`,
+	} {
+		if rr := post("/v1/facts/mine/publish", body); rr.Code != http.StatusOK {
+			t.Fatalf("publish failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/facts/mine?function=network.ip&target=web-*", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":2`) {
+		t.Fatalf("mine get failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/v1/templates", `{"name":"lb","config_path":"`+cfg+`","strict_mode":true}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("template create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var tpl struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &tpl); err != nil {
+		t.Fatal(err)
+	}
+	rr = post("/v1/templates/"+tpl.ID+"/render", `{}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("template render failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Rendered string `json:"rendered"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Rendered, `content: "10.0.0.1,10.0.0.2"`) {
+		t.Fatalf("expected mine data in rendered config, got %s", out.Rendered)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
@@ -93,3 +94,81 @@ func (s *Server) handleFactMineQuery(w http.ResponseWriter, r *http.Request) {
 		"query": req,
 	})
 }
+
+func (s *Server) handleFactMine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	function := strings.TrimSpace(r.URL.Query().Get("function"))
+	if function == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "function query parameter is required"})
+		return
+	}
+	target := r.URL.Query().Get("target")
+	items := s.factMine.Get(function, target)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"function": function,
+		"target":   target,
+		"count":    len(items),
+		"items":    items,
+	})
+}
+
+func (s *Server) handleFactMineFunctions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.factMine.Functions(r.URL.Query().Get("node"))
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.FactMineFunction
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.factMine.UpsertFunction(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleFactMineFunctionAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/facts/mine/functions/{name}
+	if len(parts) != 5 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mine function path"})
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.factMine.DeleteFunction(parts[4]) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "mine function not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (s *Server) handleFactMinePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.FactMinePublishInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	result, err := s.factMine.Publish(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -77,5 +77,15 @@ func (s *Server) handleRegionalFailoverScorecards(w http.ResponseWriter, r *http
 			windowHours = n
 		}
 	}
-	writeJSON(w, http.StatusOK, s.failoverDrills.Scorecards(windowHours))
+	cards := s.failoverDrills.Scorecards(windowHours)
+	if kind := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("kind"))); kind != "" {
+		filtered := make([]control.RegionalFailoverScorecard, 0, len(cards))
+		for _, card := range cards {
+			if card.Kind == kind {
+				filtered = append(filtered, card)
+			}
+		}
+		cards = filtered
+	}
+	writeJSON(w, http.StatusOK, cards)
 }
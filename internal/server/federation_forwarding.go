@@ -0,0 +1,221 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// forwardJob sends a job to the peer control plane serving its region and
+// answers with the local forwarded-job record.
+func (s *Server) forwardJob(w http.ResponseWriter, r *http.Request, peer control.FederationPeer, configPath, priority string) {
+	if priority == "" {
+		priority = r.Header.Get("X-Queue-Priority")
+	}
+	_, tenant := requestIdentity(r)
+	item, err := s.federationForwarder.Forward(r.Context(), peer, control.FederationJobRequest{
+		ConfigPath:     configPath,
+		Priority:       priority,
+		Tenant:         tenant,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		s.recordEvent(control.Event{
+			Type:    "control.federation.job.forward_failed",
+			Message: "forwarding job to federation peer failed",
+			Fields: map[string]any{
+				"peer_id":     peer.ID,
+				"region":      peer.Region,
+				"config_path": configPath,
+				"error":       err.Error(),
+			},
+		}, true)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.federation.job.forwarded",
+		Message: "job forwarded to federation peer",
+		Fields: map[string]any{
+			"forward_id":    item.ID,
+			"peer_id":       item.PeerID,
+			"region":        item.Region,
+			"remote_job_id": item.RemoteJobID,
+			"config_path":   item.ConfigPath,
+		},
+	}, true)
+	writeJSON(w, http.StatusAccepted, item)
+}
+
+// requireFederationPeer authenticates a request from a peer control plane
+// against MC_FEDERATION_TOKEN.
+func (s *Server) requireFederationPeer(w http.ResponseWriter, r *http.Request) bool {
+	if s.federationToken == "" {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "federation is not enabled; set MC_FEDERATION_TOKEN"})
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.federationToken)) != 1 {
+		s.recordEvent(control.Event{
+			Type:    "control.federation.auth.denied",
+			Message: "federation request rejected",
+			Fields: map[string]any{
+				"path":   r.URL.Path,
+				"region": r.Header.Get(control.FederationRegionHeader),
+			},
+		}, true)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid federation token"})
+		return false
+	}
+	return true
+}
+
+// federationBearerValid reports whether the request carries the
+// MC_FEDERATION_TOKEN bearer token. Unlike requireFederationPeer it writes
+// nothing, so endpoints can fall back to another check.
+func (s *Server) federationBearerValid(r *http.Request) bool {
+	if s.federationToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.federationToken)) == 1
+}
+
+// handleFederationJobs is the peer-facing endpoint: peers submit forwarded
+// jobs here and list this control plane's jobs for the global view.
+func (s *Server) handleFederationJobs(w http.ResponseWriter, r *http.Request) {
+	if !s.requireFederationPeer(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.queue.List())
+	case http.MethodPost:
+		var req control.FederationJobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.ConfigPath) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
+			return
+		}
+		configPath := req.ConfigPath
+		if !filepath.IsAbs(configPath) {
+			configPath = filepath.Join(s.baseDir, configPath)
+		}
+		if _, err := os.Stat(configPath); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("config_path not found: %v", err)})
+			return
+		}
+		job, err := s.queue.EnqueuePlaced(control.JobPlacement{Tenant: req.Tenant}, configPath, req.IdempotencyKey, false, req.Priority)
+		if err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.federation.job.received",
+			Message: "job received from federation peer",
+			Fields: map[string]any{
+				"job_id":        job.ID,
+				"origin_region": req.OriginRegion,
+				"forward_id":    req.ForwardID,
+				"config_path":   configPath,
+			},
+		}, true)
+		writeJSON(w, http.StatusAccepted, job)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleFederationJobAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/federation/jobs/{id}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "federation" || parts[3] != "jobs" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !s.requireFederationPeer(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	job, ok := s.queue.Get(parts[4])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleForwardedJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.federationForwarder.List())
+}
+
+func (s *Server) handleForwardedJobAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/federation/forwarded/{id|sync}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "federation" || parts[3] != "forwarded" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if parts[4] == "sync" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		changed := s.federationForwarder.Sync(r.Context())
+		writeJSON(w, http.StatusOK, map[string]any{"changed": changed, "items": s.federationForwarder.List()})
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, ok := s.federationForwarder.Get(parts[4])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "forwarded job not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) handleFederationRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.federationForwarder.GlobalRuns(r.Context(), s.queue.List(), parseIntQuery(r, "limit", 100)))
+}
+
+func (s *Server) noteForwardedJobUpdated(item control.ForwardedJob) {
+	fields := map[string]any{
+		"forward_id":    item.ID,
+		"peer_id":       item.PeerID,
+		"region":        item.Region,
+		"remote_job_id": item.RemoteJobID,
+		"status":        item.Status,
+	}
+	if item.Status == control.JobFailed {
+		fields["severity"] = "high"
+		fields["error"] = item.Error
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.federation.job.status",
+		Message: "forwarded job is " + string(item.Status),
+		Fields:  fields,
+	}, true)
+}
@@ -8,7 +8,11 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestFederationEndpoints(t *testing.T) {
@@ -59,3 +63,93 @@ func TestFederationEndpoints(t *testing.T) {
 		t.Fatalf("federation health matrix failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestFederationForwardsRemoteRegionJobs(t *testing.T) {
+	t.Setenv("MC_FEDERATION_TOKEN", "peer-secret")
+	t.Setenv("MC_FEDERATION_REGION", "us-east-1")
+	remoteDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(remoteDir, "c.yaml"), []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	remote := New(":0", remoteDir)
+	t.Cleanup(func() {
+		_ = remote.Shutdown(context.Background())
+	})
+	peerServer := httptest.NewServer(remote.httpServer.Handler)
+	defer peerServer.Close()
+
+	local := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = local.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/control/federation/jobs", nil)
+	remote.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected peer endpoint to require the federation token, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/federation/peers", strings.NewReader(`{"region":"eu-west-1","endpoint":"`+peerServer.URL+`","mode":"active_active","token":"peer-secret"}`))
+	local.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated || strings.Contains(rr.Body.String(), "peer-secret") {
+		t.Fatalf("create peer failed or leaked token: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/jobs", strings.NewReader(`{"config_path":"c.yaml","region":"eu-west-1"}`))
+	local.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("forward job failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var forwarded struct {
+		ID          string `json:"id"`
+		RemoteJobID string `json:"remote_job_id"`
+		Status      string `json:"status"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &forwarded)
+	if forwarded.RemoteJobID == "" || forwarded.Status != "pending" {
+		t.Fatalf("unexpected forwarded job: %s", rr.Body.String())
+	}
+	if _, ok := remote.queue.Get(forwarded.RemoteJobID); !ok {
+		t.Fatalf("expected job %s on the remote control plane", forwarded.RemoteJobID)
+	}
+
+	var remoteJob *control.Job
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		remoteJob, _ = remote.queue.Get(forwarded.RemoteJobID)
+		if remoteJob.Status != control.JobPending && remoteJob.Status != control.JobRunning {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/federation/forwarded/sync", nil)
+	local.httpServer.Handler.ServeHTTP(rr, req)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/control/federation/forwarded/"+forwarded.ID, nil)
+	local.httpServer.Handler.ServeHTTP(rr, req)
+	_ = json.Unmarshal(rr.Body.Bytes(), &forwarded)
+	if forwarded.Status != string(remoteJob.Status) || forwarded.Status == "pending" {
+		t.Fatalf("expected mirrored %s status, got %s", remoteJob.Status, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/control/federation/runs", nil)
+	local.httpServer.Handler.ServeHTTP(rr, req)
+	var view struct {
+		Regions []struct {
+			Region string `json:"region"`
+			Jobs   []struct {
+				ID string `json:"id"`
+			} `json:"jobs"`
+			Error string `json:"error"`
+		} `json:"regions"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &view)
+	if len(view.Regions) != 2 || view.Regions[1].Region != "eu-west-1" || len(view.Regions[1].Jobs) != 1 || view.Regions[1].Error != "" {
+		t.Fatalf("unexpected global runs view: %s", rr.Body.String())
+	}
+}
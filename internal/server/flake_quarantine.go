@@ -1,9 +1,12 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -114,3 +117,96 @@ func (s *Server) handleFlakeCaseAction(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown flake case action"})
 	}
 }
+
+func (s *Server) handleFlakeReevaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.reevaluateFlakeQuarantine())
+}
+
+func (s *Server) reevaluateFlakeQuarantine() control.FlakeReleaseResult {
+	result := s.flakes.ReevaluateQuarantined()
+	for _, item := range result.Released {
+		s.recordEvent(control.Event{
+			Type:    "release.tests.flake.released",
+			Message: "quarantined test case released after passing consistently",
+			Fields: map[string]any{
+				"case_id": item.ID,
+				"suite":   item.Suite,
+				"test":    item.Test,
+				"reason":  item.QuarantineReason,
+			},
+		}, true)
+	}
+	return result
+}
+
+// sweepFlakeQuarantine periodically re-evaluates auto-quarantined cases so
+// stabilized tests rejoin release gating without manual action.
+func (s *Server) sweepFlakeQuarantine(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reevaluateFlakeQuarantine()
+		}
+	}
+}
+
+// observeTestOutcomes feeds scenario, harness, and conformance results
+// into flake history. outcomes maps test name to pass or fail.
+func (s *Server) observeTestOutcomes(source, suite, runID string, outcomes map[string]string) {
+	names := make([]string, 0, len(outcomes))
+	for name := range outcomes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result, err := s.flakes.Observe(control.FlakeObservation{
+			Suite:  suite,
+			Test:   name,
+			Status: outcomes[name],
+			Source: source,
+			RunID:  runID,
+		})
+		if err != nil || result.Action != "auto-quarantined" {
+			continue
+		}
+		s.recordEvent(control.Event{
+			Type:    "release.tests.flake.quarantined",
+			Message: "test case auto-quarantined by flake policy",
+			Fields: map[string]any{
+				"case_id":     result.Case.ID,
+				"suite":       result.Case.Suite,
+				"test":        result.Case.Test,
+				"source":      source,
+				"run_id":      runID,
+				"flake_rate":  result.Case.FlakeRate,
+				"flake_score": result.Case.FlakeScore,
+			},
+		}, true)
+	}
+}
+
+func (s *Server) observeScenarioRun(run control.ScenarioRun) {
+	status := "pass"
+	if run.Status == "failed" || run.RegressionDetected {
+		status = "fail"
+	}
+	s.observeTestOutcomes("scenario", "scenario", run.ID, map[string]string{run.ScenarioID: status})
+}
+
+func (s *Server) observeConformanceRun(run control.ProviderConformanceRun) {
+	outcomes := map[string]string{}
+	for _, check := range run.Checks {
+		if check.Status == "pass" || check.Status == "fail" {
+			outcomes[check.Name] = check.Status
+		}
+	}
+	s.observeTestOutcomes("conformance", "conformance/"+run.SuiteID, run.ID, outcomes)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -89,3 +90,52 @@ func TestFlakeQuarantineEndpoints(t *testing.T) {
 		t.Fatalf("get flake case failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestFlakeHistoryRecordedFromHarnessRuns(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := post("/v1/release/tests/flake-policy", `{"auto_quarantine":true,"min_samples":4,"flake_rate_threshold":0.9,"consecutive_failure_threshold":10,"flake_score_threshold":0.05,"release_after_passes":2}`); rr.Code != http.StatusOK {
+		t.Fatalf("set flake policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/release/tests/harness/cases", `{"id":"port-open","name":"port open","kind":"module","assertions":[{"field":"port","expected":"443"}]}`); rr.Code != http.StatusOK {
+		t.Fatalf("upsert harness case failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, port := range []string{"443", "80", "443", "80", "443", "80"} {
+		post("/v1/release/tests/harness/runs", `{"case_id":"port-open","observed":{"port":"`+port+`"}}`)
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/release/tests/flake-cases?filter=quarantined", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	var listed struct {
+		Items []struct {
+			ID      string   `json:"id"`
+			Suite   string   `json:"suite"`
+			Test    string   `json:"test"`
+			Source  string   `json:"source"`
+			History []string `json:"history"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode flake cases failed: %v", err)
+	}
+	if len(listed.Items) != 1 || listed.Items[0].Suite != "harness/module" || listed.Items[0].Test != "port-open" || listed.Items[0].Source != "harness" {
+		t.Fatalf("expected harness case to be auto-quarantined, got %s", rr.Body.String())
+	}
+
+	for i := 0; i < 2; i++ {
+		post("/v1/release/tests/harness/runs", `{"case_id":"port-open","observed":{"port":"443"}}`)
+	}
+	rr = post("/v1/release/tests/flake-reevaluate", `{}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), listed.Items[0].ID) {
+		t.Fatalf("expected re-evaluation to release stabilized case: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -16,85 +16,87 @@ func (s *Server) handleFleetHealth(baseDir string) http.HandlerFunc {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		hours := parseIntQuery(r, "hours", 24)
-		if hours <= 0 {
-			hours = 24
-		}
-		if hours > 24*30 {
-			hours = 24 * 30
-		}
-		slo := parseFloatQuery(r, "slo", 99.9)
-		if slo < 50 {
-			slo = 50
-		}
-		if slo > 100 {
-			slo = 100
-		}
-		since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
-		runs, err := state.New(baseDir).ListRuns(5000)
-		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-			return
-		}
-		total := 0
-		succeeded := 0
-		failed := 0
-		hostFailures := map[string]int{}
-		for _, run := range runs {
-			ref := run.StartedAt
-			if ref.IsZero() {
-				ref = run.EndedAt
+		s.serveCachedJSON(w, r, aggregateResponseCacheTTL, []string{responseCacheTagRuns, responseCacheTagHealthProbes}, func() (int, any) {
+			hours := parseIntQuery(r, "hours", 24)
+			if hours <= 0 {
+				hours = 24
+			}
+			if hours > 24*30 {
+				hours = 24 * 30
+			}
+			slo := parseFloatQuery(r, "slo", 99.9)
+			if slo < 50 {
+				slo = 50
 			}
-			if ref.IsZero() || ref.Before(since) {
-				continue
+			if slo > 100 {
+				slo = 100
 			}
-			total++
-			if run.Status == state.RunSucceeded {
-				succeeded++
+			since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+			runs, err := state.New(baseDir).ListRuns(5000)
+			if err != nil {
+				return http.StatusInternalServerError, map[string]string{"error": err.Error()}
 			}
-			if run.Status == state.RunFailed {
-				failed++
-				for _, res := range run.Results {
-					host := strings.TrimSpace(res.Host)
-					if host == "" {
-						host = "unknown-host"
+			total := 0
+			succeeded := 0
+			failed := 0
+			hostFailures := map[string]int{}
+			for _, run := range runs {
+				ref := run.StartedAt
+				if ref.IsZero() {
+					ref = run.EndedAt
+				}
+				if ref.IsZero() || ref.Before(since) {
+					continue
+				}
+				total++
+				if run.Status == state.RunSucceeded {
+					succeeded++
+				}
+				if run.Status == state.RunFailed {
+					failed++
+					for _, res := range run.Results {
+						host := strings.TrimSpace(res.Host)
+						if host == "" {
+							host = "unknown-host"
+						}
+						hostFailures[host]++
 					}
-					hostFailures[host]++
 				}
 			}
-		}
-		availability := 100.0
-		if total > 0 {
-			availability = (float64(succeeded) / float64(total)) * 100
-		}
-		allowedFailures := (float64(total) * (100 - slo)) / 100
-		remainingBudget := allowedFailures - float64(failed)
-		burnRate := 0.0
-		if allowedFailures > 0 {
-			burnRate = float64(failed) / allowedFailures
-		} else if failed > 0 {
-			burnRate = 9999
-		}
-		status := "healthy"
-		if remainingBudget < 0 {
-			status = "breached"
-		}
-		writeJSON(w, http.StatusOK, map[string]any{
-			"window_hours":       hours,
-			"slo_target_percent": slo,
-			"since":              since,
-			"runs_total":         total,
-			"runs_succeeded":     succeeded,
-			"runs_failed":        failed,
-			"availability_pct":   availability,
-			"error_budget": map[string]any{
-				"allowed_failures":   allowedFailures,
-				"consumed_failures":  failed,
-				"remaining_failures": remainingBudget,
-				"burn_rate":          burnRate,
-				"status":             status,
-			},
-			"top_failing_hosts": topHostCounts(hostFailures, 10),
+			availability := 100.0
+			if total > 0 {
+				availability = (float64(succeeded) / float64(total)) * 100
+			}
+			allowedFailures := (float64(total) * (100 - slo)) / 100
+			remainingBudget := allowedFailures - float64(failed)
+			burnRate := 0.0
+			if allowedFailures > 0 {
+				burnRate = float64(failed) / allowedFailures
+			} else if failed > 0 {
+				burnRate = 9999
+			}
+			status := "healthy"
+			if remainingBudget < 0 {
+				status = "breached"
+			}
+			return http.StatusOK, map[string]any{
+				"window_hours":       hours,
+				"slo_target_percent": slo,
+				"since":              since,
+				"runs_total":         total,
+				"runs_succeeded":     succeeded,
+				"runs_failed":        failed,
+				"availability_pct":   availability,
+				"error_budget": map[string]any{
+					"allowed_failures":   allowedFailures,
+					"consumed_failures":  failed,
+					"remaining_failures": remainingBudget,
+					"burn_rate":          burnRate,
+					"status":             status,
+				},
+				"top_failing_hosts": topHostCounts(hostFailures, 10),
+				"health_probes":     s.healthProbes.Summary(),
+			}
 		})
 	}
 }
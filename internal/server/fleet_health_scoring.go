@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+// handleFleetHealthScoring serves GET/POST /v1/fleet/health/scoring for the
+// per-environment weightings; DELETE with ?environment= drops an override.
+func (s *Server) handleFleetHealthScoring(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"default":  control.DefaultFleetHealthScoringPolicy(),
+			"policies": s.fleetHealthScoring.Policies(),
+		})
+	case http.MethodPost:
+		var req control.FleetHealthScoringPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.fleetHealthScoring.SetPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, policy)
+	case http.MethodDelete:
+		if err := s.fleetHealthScoring.DeletePolicy(r.URL.Query().Get("environment")); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFleetHealthScores scores every managed node from its check-ins,
+// recent runs, agent certificate, and latest compliance scan, records the
+// result in the trend history, and raises an alert for each host or group
+// that newly falls below its environment's threshold.
+func (s *Server) handleFleetHealthScores(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		hours := parseIntQuery(r, "hours", 24)
+		if hours <= 0 {
+			hours = 24
+		}
+		if hours > 24*30 {
+			hours = 24 * 30
+		}
+		signals, err := s.fleetHealthSignals(baseDir, time.Now().UTC().Add(-time.Duration(hours)*time.Hour))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		report := s.fleetHealthScoring.Score(signals, time.Now().UTC())
+		for _, crossing := range s.fleetHealthScoring.Record(report) {
+			s.recordEvent(control.Event{
+				Type:    "fleet.health.degraded",
+				Message: "fleet health " + crossing.Scope + " " + crossing.Name + " scored below threshold",
+				Fields: map[string]any{
+					"severity":    "high",
+					"scope":       crossing.Scope,
+					"name":        crossing.Name,
+					"environment": crossing.Environment,
+					"score":       crossing.Score,
+					"threshold":   crossing.Threshold,
+				},
+			}, true)
+		}
+		env := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("environment")))
+		if env != "" {
+			hosts := make([]control.FleetHostHealth, 0, len(report.Hosts))
+			for _, h := range report.Hosts {
+				if h.Environment == env {
+					hosts = append(hosts, h)
+				}
+			}
+			groups := make([]control.FleetGroupHealth, 0, 1)
+			for _, g := range report.Groups {
+				if g.Environment == env {
+					groups = append(groups, g)
+				}
+			}
+			report.Hosts, report.Groups = hosts, groups
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"window_hours": hours,
+			"generated_at": report.GeneratedAt,
+			"hosts":        report.Hosts,
+			"groups":       report.Groups,
+		})
+	}
+}
+
+// handleFleetHealthHistory serves GET /v1/fleet/health/history?host= or
+// ?environment= with the recorded score trend.
+func (s *Server) handleFleetHealthHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	scope, name := "host", strings.TrimSpace(r.URL.Query().Get("host"))
+	if name == "" {
+		scope, name = "group", strings.ToLower(strings.TrimSpace(r.URL.Query().Get("environment")))
+	}
+	if name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "host or environment is required"})
+		return
+	}
+	points := s.fleetHealthScoring.History(scope, name)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"scope":  scope,
+		"name":   name,
+		"count":  len(points),
+		"points": points,
+	})
+}
+
+func (s *Server) fleetHealthSignals(baseDir string, since time.Time) ([]control.FleetHostSignals, error) {
+	runs, err := state.New(baseDir).ListRuns(5000)
+	if err != nil {
+		return nil, err
+	}
+	drift := map[string]int{}
+	failures := map[string]int{}
+	for _, run := range runs {
+		ref := run.StartedAt
+		if ref.IsZero() {
+			ref = run.EndedAt
+		}
+		if ref.IsZero() || ref.Before(since) {
+			continue
+		}
+		failedHosts := map[string]bool{}
+		for _, res := range run.Results {
+			host := strings.TrimSpace(res.Host)
+			if host == "" {
+				continue
+			}
+			if res.Changed {
+				drift[host]++
+			}
+			if run.Status == state.RunFailed {
+				failedHosts[host] = true
+			}
+		}
+		for host := range failedHosts {
+			failures[host]++
+		}
+	}
+	certs := map[string]time.Time{}
+	for _, cert := range s.agentPKI.ListCertificates() {
+		if cert.Status != "active" {
+			continue
+		}
+		if cur, ok := certs[cert.AgentID]; !ok || cert.ExpiresAt.After(cur) {
+			certs[cert.AgentID] = cert.ExpiresAt
+		}
+	}
+	compliance := map[string]control.ComplianceScan{}
+	for _, scan := range s.compliance.ListScans() {
+		if cur, ok := compliance[scan.TargetName]; !ok || scan.EndedAt.After(cur.EndedAt) {
+			compliance[scan.TargetName] = scan
+		}
+	}
+
+	nodes := s.nodes.List("")
+	out := make([]control.FleetHostSignals, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Status == control.NodeStatusDecommissioned {
+			continue
+		}
+		sig := control.FleetHostSignals{
+			Host:            node.Name,
+			Environment:     fleetNodeEnvironment(node),
+			LastSeenAt:      node.LastSeenAt,
+			DriftCount:      drift[node.Name],
+			FailedRuns:      failures[node.Name],
+			CertExpiresAt:   certs[node.Name],
+			ComplianceScore: -1,
+		}
+		if scan, ok := compliance[node.Name]; ok {
+			sig.ComplianceScore = scan.Score
+		}
+		out = append(out, sig)
+	}
+	return out, nil
+}
+
+func fleetNodeEnvironment(node control.ManagedNode) string {
+	for _, v := range []string{node.Labels["environment"], node.Labels["env"], node.Topology["environment"]} {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return "default"
+}
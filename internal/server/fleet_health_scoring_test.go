@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestFleetHealthScoringEndpoints(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	st := state.New(tmp)
+	now := time.Now().UTC()
+	for _, id := range []string{"run-f1", "run-f2", "run-f3"} {
+		if err := st.SaveRun(state.RunRecord{
+			ID:        id,
+			Status:    state.RunFailed,
+			StartedAt: now.Add(-time.Hour),
+			Results:   []state.ResourceRun{{ResourceID: "pkg", Host: "web-2"}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	for _, name := range []string{"web-1", "web-2"} {
+		if rr := do(http.MethodPost, "/v1/inventory/runtime-hosts", `{"name":"`+name+`","labels":{"environment":"prod"}}`); rr.Code != http.StatusCreated && rr.Code != http.StatusOK {
+			t.Fatalf("enroll %s: code=%d body=%s", name, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodPost, "/v1/inventory/runtime-hosts/web-1/heartbeat", `{}`); rr.Code != http.StatusOK {
+		t.Fatalf("heartbeat: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/fleet/health/scoring", `{"environment":"prod","weights":{"drift":-1}}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected negative weight rejected, got %d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v1/fleet/health/scoring", `{"environment":"prod","weights":{"check_in_freshness":1,"failed_runs":1},"alert_below_score":60}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set policy: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	type scores struct {
+		Hosts []struct {
+			Host           string  `json:"host"`
+			Score          float64 `json:"score"`
+			Grade          string  `json:"grade"`
+			BelowThreshold bool    `json:"below_threshold"`
+		} `json:"hosts"`
+		Groups []struct {
+			Environment string  `json:"environment"`
+			Hosts       int     `json:"hosts"`
+			Score       float64 `json:"score"`
+		} `json:"groups"`
+	}
+	var first scores
+	rr = do(http.MethodGet, "/v1/fleet/health/scores?environment=prod", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("scores: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &first); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Hosts) != 2 || first.Hosts[0].Host != "web-2" || first.Hosts[0].Grade != "F" || !first.Hosts[0].BelowThreshold {
+		t.Fatalf("expected web-2 graded F first, got %+v", first.Hosts)
+	}
+	if first.Hosts[1].Host != "web-1" || first.Hosts[1].Grade != "A" {
+		t.Fatalf("expected web-1 graded A, got %+v", first.Hosts[1])
+	}
+	if len(first.Groups) != 1 || first.Groups[0].Hosts != 2 || first.Groups[0].Score != 50 {
+		t.Fatalf("unexpected prod group: %+v", first.Groups)
+	}
+
+	// A second evaluation must not re-raise alerts for hosts still below.
+	do(http.MethodGet, "/v1/fleet/health/scores", "")
+	degraded := 0
+	for _, evt := range s.events.List() {
+		if evt.Type == "fleet.health.degraded" {
+			degraded++
+		}
+	}
+	if degraded != 2 {
+		t.Fatalf("expected degraded events for web-2 and the prod group, got %d", degraded)
+	}
+
+	rr = do(http.MethodGet, "/v1/fleet/health/history?host=web-2", "")
+	var history struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil || history.Count != 2 {
+		t.Fatalf("expected two history points, code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/fleet/health/history", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected missing scope rejected, got %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/v1/fleet/health/scoring?environment=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete policy: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
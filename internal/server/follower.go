@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// followerState tracks read-replica mode. A follower (MC_FOLLOWER_OF set to
+// the primary's base URL) periodically pulls the primary's control snapshot,
+// serves reads from it, and redirects every mutation to the primary.
+type followerState struct {
+	mu              sync.Mutex
+	primary         string
+	lastSyncAt      time.Time
+	snapshotAt      time.Time
+	lastAttemptAt   time.Time
+	lastError       string
+	syncs           int64
+	failures        int64
+	consecutiveFail int
+}
+
+type followerStatus struct {
+	Mode                string    `json:"mode"`
+	Primary             string    `json:"primary,omitempty"`
+	LastSyncAt          time.Time `json:"last_sync_at,omitempty"`
+	SnapshotCreatedAt   time.Time `json:"snapshot_created_at,omitempty"`
+	LastAttemptAt       time.Time `json:"last_attempt_at,omitempty"`
+	LagSeconds          float64   `json:"lag_seconds"`
+	Synced              bool      `json:"synced"`
+	Syncs               int64     `json:"syncs"`
+	Failures            int64     `json:"failures"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+func newFollowerState(primary string) *followerState {
+	primary = strings.TrimRight(strings.TrimSpace(primary), "/")
+	if primary != "" && !strings.Contains(primary, "://") {
+		primary = "http://" + primary
+	}
+	return &followerState{primary: primary}
+}
+
+func (f *followerState) enabled() bool {
+	return f != nil && f.primary != ""
+}
+
+// status reports replication lag as the age of the newest primary snapshot
+// applied locally; a follower that has never synced reports lag -1.
+func (f *followerState) status(now time.Time) followerStatus {
+	if !f.enabled() {
+		return followerStatus{Mode: "primary"}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := followerStatus{
+		Mode:                "follower",
+		Primary:             f.primary,
+		LastSyncAt:          f.lastSyncAt,
+		SnapshotCreatedAt:   f.snapshotAt,
+		LastAttemptAt:       f.lastAttemptAt,
+		LagSeconds:          -1,
+		Synced:              !f.snapshotAt.IsZero(),
+		Syncs:               f.syncs,
+		Failures:            f.failures,
+		ConsecutiveFailures: f.consecutiveFail,
+		LastError:           f.lastError,
+	}
+	if out.Synced {
+		out.LagSeconds = now.Sub(f.snapshotAt).Seconds()
+		if out.LagSeconds < 0 {
+			out.LagSeconds = 0
+		}
+	}
+	return out
+}
+
+// allowFollowerRequest lets reads through on a follower and answers every
+// mutation with a 307 to the same path on the primary, so clients that follow
+// redirects keep working unchanged. The follower's own endpoints stay local.
+func (s *Server) allowFollowerRequest(w http.ResponseWriter, r *http.Request) bool {
+	if !s.follower.enabled() {
+		return true
+	}
+	st := s.follower.status(time.Now().UTC())
+	if st.Synced {
+		w.Header().Set("X-Replication-Lag-Seconds", strconv.FormatFloat(st.LagSeconds, 'f', 3, 64))
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	if strings.HasPrefix(r.URL.Path, "/v1/control/follower/") {
+		return true
+	}
+	location := s.follower.primary + r.URL.RequestURI()
+	w.Header().Set("Location", location)
+	writeJSON(w, http.StatusTemporaryRedirect, map[string]any{
+		"error":    "this server is a read-only follower; send mutations to the primary",
+		"primary":  s.follower.primary,
+		"location": location,
+	})
+	return false
+}
+
+// syncFollower pulls the primary's full control snapshot and applies it.
+func (s *Server) syncFollower(ctx context.Context) (map[string]any, error) {
+	if !s.follower.enabled() {
+		return nil, errors.New("server is not running in follower mode")
+	}
+	now := time.Now().UTC()
+	snap, err := s.fetchPrimarySnapshot(ctx)
+	var out map[string]any
+	if err == nil {
+		out, err = s.applyReplicatedSnapshot(s.baseDir, snap)
+	}
+
+	f := s.follower
+	f.mu.Lock()
+	f.lastAttemptAt = now
+	if err != nil {
+		f.failures++
+		f.consecutiveFail++
+		f.lastError = err.Error()
+	} else {
+		f.syncs++
+		f.consecutiveFail = 0
+		f.lastError = ""
+		f.lastSyncAt = time.Now().UTC()
+		f.snapshotAt = snap.CreatedAt
+	}
+	f.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	lag := s.follower.status(time.Now().UTC()).LagSeconds
+	s.metricsMu.Lock()
+	s.metrics["follower.syncs"]++
+	s.metrics["follower.lag_ms"] = int64(lag * 1000)
+	s.metricsMu.Unlock()
+	out["snapshot_created_at"] = snap.CreatedAt
+	out["lag_seconds"] = lag
+	return out, nil
+}
+
+func (s *Server) fetchPrimarySnapshot(ctx context.Context) (backupSnapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.follower.primary+"/v1/control/snapshot?include=runs,events,control_plane", nil)
+	if err != nil {
+		return backupSnapshot{}, err
+	}
+	if s.federationToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.federationToken)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return backupSnapshot{}, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 256<<20))
+	if resp.StatusCode != http.StatusOK {
+		return backupSnapshot{}, fmt.Errorf("primary returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var snap backupSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return backupSnapshot{}, errInvalidBackupSnapshotPayload
+	}
+	switch snap.Version {
+	case "v1", "v2":
+	default:
+		return backupSnapshot{}, errors.New("unsupported snapshot version")
+	}
+	return snap, nil
+}
+
+func (s *Server) sweepFollowerSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.syncFollower(ctx); err != nil && ctx.Err() == nil {
+			s.recordEvent(control.Event{
+				Type:    "control.follower.sync_failed",
+				Message: "follower snapshot sync from primary failed",
+				Fields:  map[string]any{"primary": s.follower.primary, "error": err.Error()},
+			}, true)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleFollower serves read-replica status and on-demand sync:
+//
+//	GET  /v1/control/follower/status  mode, primary, and replication lag
+//	POST /v1/control/follower/sync    pull a fresh snapshot from the primary
+func (s *Server) handleFollower(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "follower" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch parts[3] {
+	case "status":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.follower.status(time.Now().UTC()))
+	case "sync":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.follower.enabled() {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "server is not running in follower mode"})
+			return
+		}
+		out, err := s.syncFollower(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+		out["status"] = "synced"
+		writeJSON(w, http.StatusOK, out)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestFollowerServesReplicatedReadsAndRedirectsMutations(t *testing.T) {
+	t.Setenv("MC_FEDERATION_TOKEN", "follower-token")
+	primary := New(":0", t.TempDir())
+	upstream := httptest.NewServer(primary.httpServer.Handler)
+	defer upstream.Close()
+	t.Setenv("MC_FOLLOWER_OF", upstream.URL)
+	t.Setenv("MC_FOLLOWER_SYNC_SECONDS", "3600")
+	follower := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = primary.Shutdown(context.Background())
+		_ = follower.Shutdown(context.Background())
+	})
+	do := func(s *Server, method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := do(primary, http.MethodGet, "/v1/control/follower/status", ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"mode":"primary"`) {
+		t.Fatalf("expected primary mode: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(primary, http.MethodPost, "/v1/control/follower/sync", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected sync on primary to conflict, got %d", rr.Code)
+	}
+	if rr := do(primary, http.MethodPost, "/v1/webhooks", `{"name":"ops","url":"http://127.0.0.1:1/hook","event_prefix":"job."}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create webhook on primary failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := do(follower, http.MethodPost, "/v1/webhooks", `{"name":"dev","url":"http://127.0.0.1:1/hook"}`)
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected mutation redirect, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if loc := rr.Header().Get("Location"); loc != upstream.URL+"/v1/webhooks" {
+		t.Fatalf("unexpected redirect location %q", loc)
+	}
+
+	rr = do(follower, http.MethodPost, "/v1/control/follower/sync", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"synced"`) {
+		t.Fatalf("follower sync failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(follower, http.MethodGet, "/v1/webhooks", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"ops"`) {
+		t.Fatalf("expected replicated webhook on follower: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Replication-Lag-Seconds") == "" {
+		t.Fatalf("expected replication lag header on follower reads")
+	}
+
+	rr = do(follower, http.MethodGet, "/v1/control/follower/status", "")
+	var st followerStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decode follower status: %v", err)
+	}
+	if st.Mode != "follower" || st.Primary != upstream.URL || !st.Synced || st.Syncs < 1 || st.LagSeconds < 0 {
+		t.Fatalf("unexpected follower status %+v", st)
+	}
+}
+
+func TestFollowerReportsSyncFailures(t *testing.T) {
+	t.Setenv("MC_FOLLOWER_OF", "http://127.0.0.1:1")
+	t.Setenv("MC_FOLLOWER_SYNC_SECONDS", "3600")
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/follower/sync", nil))
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected bad gateway for unreachable primary, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	st := s.follower.status(time.Now().UTC())
+	if st.Synced || st.LagSeconds != -1 || st.Failures < 1 || st.LastError == "" {
+		t.Fatalf("unexpected follower status after failure %+v", st)
+	}
+}
+
+func TestFollowerResyncsRBACRoles(t *testing.T) {
+	t.Setenv("MC_FEDERATION_TOKEN", "follower-token")
+	primary := New(":0", t.TempDir())
+	upstream := httptest.NewServer(primary.httpServer.Handler)
+	defer upstream.Close()
+	t.Setenv("MC_FOLLOWER_OF", upstream.URL)
+	t.Setenv("MC_FOLLOWER_SYNC_SECONDS", "3600")
+	follower := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = primary.Shutdown(context.Background())
+		_ = follower.Shutdown(context.Background())
+	})
+	role, err := primary.rbac.CreateRole(control.RBACRoleInput{
+		Name:        "deployer",
+		Permissions: []control.RBACPermission{{Resource: "runs", Action: "create"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := primary.rbac.CreateBinding(control.RBACBindingInput{Subject: "alice", RoleID: role.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if _, err := follower.syncFollower(context.Background()); err != nil {
+			t.Fatalf("sync %d failed: %v", i, err)
+		}
+		got, ok := follower.rbac.GetRole(role.ID)
+		if !ok || got.Name != "deployer" || len(follower.rbac.ListBindings()) != 1 {
+			t.Fatalf("expected replicated role and binding after sync %d, got %+v bindings=%+v", i, got, follower.rbac.ListBindings())
+		}
+	}
+	if st := follower.follower.status(time.Now().UTC()); st.Syncs < 2 || st.Failures != 0 {
+		t.Fatalf("expected two clean syncs, got %+v", st)
+	}
+}
@@ -25,12 +25,14 @@ func (s *Server) handleGitOpsDeploymentTriggerAlias(baseDir, source string) http
 
 func (s *Server) handleGitOpsDeployments(baseDir string) http.HandlerFunc {
 	type createReq struct {
-		Environment string `json:"environment"`
-		Branch      string `json:"branch"`
-		ConfigPath  string `json:"config_path"`
-		Source      string `json:"source,omitempty"`
-		Priority    string `json:"priority,omitempty"`
-		Force       bool   `json:"force,omitempty"`
+		Environment    string `json:"environment"`
+		Branch         string `json:"branch"`
+		ConfigPath     string `json:"config_path"`
+		Source         string `json:"source,omitempty"`
+		Priority       string `json:"priority,omitempty"`
+		Force          bool   `json:"force,omitempty"`
+		PolicyBundle   string `json:"policy_bundle,omitempty"`
+		PolicyBundleID string `json:"policy_bundle_id,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -65,13 +67,28 @@ func (s *Server) handleGitOpsDeployments(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path not found"})
 				return
 			}
+			if !s.enforceSecretScan(w, control.SecretScanInput{Kind: "gitops", Target: branch + ":" + configPath, Environment: env}, resolved) {
+				return
+			}
+			bundleSelection, requested, err := s.resolveEnvironmentPolicyBundle(env, req.PolicyBundleID, req.PolicyBundle)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			if requested && bundleSelection.Selected == nil {
+				writeJSON(w, http.StatusConflict, map[string]any{
+					"error":     "no policy bundle revision satisfies environment version constraints",
+					"selection": bundleSelection,
+				})
+				return
+			}
 			key := "gitops-deploy:" + env + ":" + branch + ":" + configPath
 			job, err := s.queue.Enqueue(resolved, key, req.Force, req.Priority)
 			if err != nil {
 				writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
 				return
 			}
-			item, err := s.deployments.Create(control.DeploymentTriggerInput{
+			input := control.DeploymentTriggerInput{
 				Environment: env,
 				Branch:      branch,
 				ConfigPath:  configPath,
@@ -79,7 +96,12 @@ func (s *Server) handleGitOpsDeployments(baseDir string) http.HandlerFunc {
 				Priority:    req.Priority,
 				Force:       req.Force,
 				JobID:       job.ID,
-			})
+			}
+			if bundleSelection.Selected != nil {
+				input.PolicyBundleID = bundleSelection.Selected.ID
+				input.PolicyBundleVersion = bundleSelection.Selected.Version
+			}
+			item, err := s.deployments.Create(input)
 			if err != nil {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 				return
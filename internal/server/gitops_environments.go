@@ -61,6 +61,9 @@ func (s *Server) handleGitOpsEnvironments(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 				return
 			}
+			if !s.enforceSecretScan(w, control.SecretScanInput{Kind: "gitops", Target: branch + ":" + source, Environment: name, Content: string(content)}, "") {
+				return
+			}
 
 			outputPath := strings.TrimSpace(req.OutputPath)
 			if outputPath == "" {
@@ -19,6 +19,9 @@ func (s *Server) handleGitOpsPreviews(baseDir string) http.HandlerFunc {
 		TTLSeconds     int    `json:"ttl_seconds,omitempty"`
 		Priority       string `json:"priority,omitempty"`
 		Force          bool   `json:"force,omitempty"`
+
+		ChangedFiles   []string                       `json:"changed_files,omitempty"`
+		ChangedObjects []control.ObjectDependencyNode `json:"changed_objects,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -46,11 +49,31 @@ func (s *Server) handleGitOpsPreviews(baseDir string) http.HandlerFunc {
 				return
 			}
 
+			// A change set scopes the preview to the configs it affects;
+			// previewing an unaffected config is rejected unless forced.
+			var scope []string
+			if len(req.ChangedFiles) > 0 || len(req.ChangedObjects) > 0 {
+				_ = s.objectModel.IndexConfig(resolvedConfig)
+				configs, _, err := s.changeImpact(baseDir, req.ChangedFiles, req.ChangedObjects)
+				if err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+					return
+				}
+				if !req.Force && !containsString(configs, filepath.Clean(resolvedConfig)) {
+					writeJSON(w, http.StatusConflict, map[string]any{
+						"error":            "config_path is not affected by the change set",
+						"affected_configs": configs,
+					})
+					return
+				}
+				scope = configs
+			}
 			preview, err := s.gitopsPreviews.Create(control.GitOpsPreviewInput{
 				Branch:         req.Branch,
 				Environment:    req.Environment,
 				ConfigPath:     configPath,
 				ArtifactDigest: req.ArtifactDigest,
+				ChangeScope:    scope,
 				TTLSeconds:     req.TTLSeconds,
 			})
 			if err != nil {
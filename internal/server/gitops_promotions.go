@@ -75,7 +75,16 @@ func (s *Server) handleGitOpsPromotionAction(w http.ResponseWriter, r *http.Requ
 		writeJSON(w, http.StatusOK, item)
 		return
 	}
-	if len(parts) != 5 || parts[4] != "advance" || r.Method != http.MethodPost {
+	if len(parts) != 5 || r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[4] {
+	case "advance":
+	case "approve", "reject":
+		s.decideGitOpsPromotion(w, r, id, parts[4])
+		return
+	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
@@ -93,7 +102,7 @@ func (s *Server) handleGitOpsPromotionAction(w http.ResponseWriter, r *http.Requ
 	item, err := s.gitopsPromotions.Advance(id, req.ArtifactDigest, req.Actor, req.Note)
 	if err != nil {
 		code := http.StatusBadRequest
-		if strings.Contains(strings.ToLower(err.Error()), "mismatch") || strings.Contains(strings.ToLower(err.Error()), "completed") {
+		if strings.Contains(strings.ToLower(err.Error()), "mismatch") || strings.Contains(strings.ToLower(err.Error()), "completed") || strings.Contains(err.Error(), "awaiting approval") {
 			code = http.StatusConflict
 		}
 		writeJSON(w, code, map[string]string{"error": err.Error()})
@@ -111,3 +120,40 @@ func (s *Server) handleGitOpsPromotionAction(w http.ResponseWriter, r *http.Requ
 	}, true)
 	writeJSON(w, http.StatusOK, item)
 }
+
+func (s *Server) decideGitOpsPromotion(w http.ResponseWriter, r *http.Request, id, decision string) {
+	var req struct {
+		Actor   string `json:"actor"`
+		Comment string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	item, err := s.gitopsPromotions.Approve(id, req.Actor, decision, req.Comment)
+	if err != nil {
+		code := approvalErrorCode(err)
+		switch {
+		case err.Error() == "promotion pipeline not found":
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "completed"):
+			code = http.StatusConflict
+		}
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	stage := item.Approvals[len(item.Approvals)-1].Stage
+	s.recordEvent(control.Event{
+		Type:    "gitops.promotion." + decision + "d",
+		Message: "promotion approval decision recorded",
+		Fields: map[string]any{
+			"promotion_id": item.ID,
+			"actor":        req.Actor,
+			"stage":        stage,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"promotion":  item,
+		"evaluation": s.evaluatePromotionApprovals(item, stage),
+	})
+}
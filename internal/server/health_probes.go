@@ -2,7 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"maps"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -29,8 +31,11 @@ func (s *Server) handleHealthProbes(w http.ResponseWriter, r *http.Request) {
 				"probe_target_id": item.ID,
 				"name":            item.Name,
 				"service":         item.Service,
+				"kind":            item.Kind,
 			},
 		}, true)
+		s.healthProbeRunner.Sync()
+		s.responseCache.Invalidate(responseCacheTagHealthProbes)
 		writeJSON(w, http.StatusOK, item)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -62,6 +67,7 @@ func (s *Server) handleHealthProbeChecks(w http.ResponseWriter, r *http.Request)
 			"latency_ms":     item.LatencyMS,
 		},
 	}, true)
+	s.noteHealthProbeCheck(item)
 	writeJSON(w, http.StatusCreated, item)
 }
 
@@ -78,3 +84,101 @@ func (s *Server) handleHealthProbeGateEvaluate(w http.ResponseWriter, r *http.Re
 	result := s.healthProbes.EvaluateGate(req)
 	writeJSON(w, http.StatusOK, result)
 }
+
+// handleHealthProbeAction serves GET /v1/control/health-probes/{id} with the
+// target's recent checks and POST /v1/control/health-probes/{id}/run to
+// execute a probe immediately.
+func (s *Server) handleHealthProbeAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(strings.TrimPrefix(r.URL.Path, "/v1/control/health-probes/"))
+	if len(parts) == 0 || len(parts) > 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	target, err := s.healthProbes.GetTarget(parts[0])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"target": target,
+			"checks": s.healthProbes.ListChecks(target.ID, parseIntQuery(r, "limit", 20)),
+		})
+		return
+	}
+	if parts[1] != "run" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	check, err := s.healthProbeRunner.RunNow(r.Context(), target.ID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, check)
+}
+
+// noteHealthProbeCheck reacts to a recorded check, whether executed by the
+// probe runner or reported manually. Status transitions and flap state
+// changes become events; unhealthy, degraded, and flapping targets carry a
+// severity so they reach the alert inbox.
+func (s *Server) noteHealthProbeCheck(check control.HealthProbeCheck) {
+	firstFailure := check.PreviousStatus == "" && check.Status != "healthy"
+	if !check.Changed && !firstFailure && !check.FlapChanged {
+		return
+	}
+	s.responseCache.Invalidate(responseCacheTagHealthProbes)
+	target, err := s.healthProbes.GetTarget(check.TargetID)
+	if err != nil {
+		return
+	}
+	fields := map[string]any{
+		"target_id":       target.ID,
+		"resource":        target.ID,
+		"name":            target.Name,
+		"service":         target.Service,
+		"host":            target.Host,
+		"kind":            target.Kind,
+		"status":          check.Status,
+		"previous_status": check.PreviousStatus,
+		"source":          check.Source,
+		"latency_ms":      check.LatencyMS,
+		"detail":          check.Message,
+	}
+	if check.Changed || firstFailure {
+		statusFields := maps.Clone(fields)
+		switch check.Status {
+		case "unhealthy":
+			statusFields["severity"] = "high"
+		case "degraded":
+			statusFields["severity"] = "medium"
+		}
+		s.recordEvent(control.Event{
+			Type:    "health.probe.status.changed",
+			Message: "health probe " + check.Status,
+			Fields:  statusFields,
+		}, true)
+	}
+	if check.FlapChanged {
+		flapFields := maps.Clone(fields)
+		message := "health probe stopped flapping"
+		if check.Flapping {
+			message = "health probe flapping"
+			flapFields["severity"] = "medium"
+		}
+		flapFields["flapping"] = check.Flapping
+		s.recordEvent(control.Event{
+			Type:    "health.probe.flapping",
+			Message: message,
+			Fields:  flapFields,
+		}, true)
+	}
+}
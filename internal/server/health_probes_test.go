@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -86,4 +87,70 @@ func TestHealthProbeEndpoints(t *testing.T) {
 	if !strings.Contains(rr.Body.String(), `"decision":"block"`) || !strings.Contains(rr.Body.String(), `"recommended_action":"rollback"`) {
 		t.Fatalf("expected blocked rollback recommendation: %s", rr.Body.String())
 	}
+
+	t.Setenv("MC_HEALTH_PROBE_COMMANDS", "/bin/true")
+	for body, want := range map[string]int{
+		`{"name":"cmd-relative","kind":"command","command":"true"}`:            http.StatusBadRequest,
+		`{"name":"cmd-unlisted","kind":"command","command":"/bin/false"}`:      http.StatusBadRequest,
+		`{"name":"cmd-listed","kind":"command","command":"/bin/true --quiet"}`: http.StatusOK,
+	} {
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/v1/control/health-probes", bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Fatalf("expected %d for %s, got code=%d body=%s", want, body, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestHealthProbeRunFeedsAlertsAndFleetHealth(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer backend.Close()
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	body := `{"name":"checkout","service":"checkout","kind":"http","endpoint":"` + backend.URL + `/healthz","interval_seconds":3600,"enabled":true}`
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/health-probes", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create probe failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var target struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &target)
+	if s.healthProbeRunner.Running() != 1 {
+		t.Fatalf("expected probe loop to be scheduled")
+	}
+
+	healthy.Store(false)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/health-probes/"+target.ID+"/run", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"unhealthy"`) {
+		t.Fatalf("run probe failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/alerts/inbox", nil))
+	if !strings.Contains(rr.Body.String(), "health.probe.status.changed") {
+		t.Fatalf("expected probe failure in alert inbox: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/fleet/health", nil))
+	if !strings.Contains(rr.Body.String(), `"failing":["checkout"]`) {
+		t.Fatalf("expected failing probe in fleet health: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/health-probes/"+target.ID, nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"source":"probe"`) {
+		t.Fatalf("get probe failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 }
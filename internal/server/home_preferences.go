@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+const personaRequiredError = "persona must be one of: sre, platform, release, service-owner"
+
+// homePreferenceScope resolves the authenticated principal and persona a
+// home preference request applies to.
+func homePreferenceScope(w http.ResponseWriter, r *http.Request, persona string) (string, string, bool) {
+	principal, _ := requestIdentity(r)
+	if principal == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "X-Masterchef-Principal header is required"})
+		return "", "", false
+	}
+	persona = normalizePersona(persona)
+	if persona == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": personaRequiredError})
+		return "", "", false
+	}
+	return principal, persona, true
+}
+
+// unknownHomeCard returns the first ID that is not a card of the persona.
+func unknownHomeCard(known []string, lists ...[]string) string {
+	for _, ids := range lists {
+		for _, id := range ids {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			found := false
+			for _, k := range known {
+				if k == id {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Server) handleHomePreferences(baseDir string) http.HandlerFunc {
+	type reqBody struct {
+		Persona        string            `json:"persona"`
+		PinnedCards    []string          `json:"pinned_cards"`
+		CardOrder      []string          `json:"card_order"`
+		HiddenCards    []string          `json:"hidden_cards"`
+		DefaultFilters map[string]string `json:"default_filters"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			principal, persona, ok := homePreferenceScope(w, r, r.URL.Query().Get("persona"))
+			if !ok {
+				return
+			}
+			pref, customized := s.homePreferences.Get(principal, persona)
+			writeJSON(w, http.StatusOK, map[string]any{
+				"preference":      pref,
+				"customized":      customized,
+				"mandatory_cards": s.homePreferences.Mandatory(persona),
+				"available_cards": s.personaCardIDs(persona, baseDir),
+			})
+		case http.MethodPut:
+			var req reqBody
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			principal, persona, ok := homePreferenceScope(w, r, req.Persona)
+			if !ok {
+				return
+			}
+			if id := unknownHomeCard(s.personaCardIDs(persona, baseDir), req.PinnedCards, req.CardOrder, req.HiddenCards); id != "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown card " + id + " for persona " + persona})
+				return
+			}
+			item, err := s.homePreferences.Save(control.HomePreference{
+				Principal:      principal,
+				Persona:        persona,
+				PinnedCards:    req.PinnedCards,
+				CardOrder:      req.CardOrder,
+				HiddenCards:    req.HiddenCards,
+				DefaultFilters: req.DefaultFilters,
+			})
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, item)
+		case http.MethodDelete:
+			principal, persona, ok := homePreferenceScope(w, r, r.URL.Query().Get("persona"))
+			if !ok {
+				return
+			}
+			if err := s.homePreferences.Delete(principal, persona); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "reset"})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *Server) handleHomePreferenceCards(baseDir string) http.HandlerFunc {
+	type reqBody struct {
+		Persona string `json:"persona"`
+		control.HomeCardActionInput
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req reqBody
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		principal, persona, ok := homePreferenceScope(w, r, req.Persona)
+		if !ok {
+			return
+		}
+		if id := unknownHomeCard(s.personaCardIDs(persona, baseDir), []string{req.CardID}); id != "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown card " + id + " for persona " + persona})
+			return
+		}
+		item, err := s.homePreferences.ApplyAction(principal, persona, req.HomeCardActionInput)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	}
+}
+
+func (s *Server) handleHomeMandatoryCards(baseDir string) http.HandlerFunc {
+	type reqBody struct {
+		Persona string   `json:"persona"`
+		CardIDs []string `json:"card_ids"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, map[string]any{"items": s.homePreferences.ListMandatory()})
+		case http.MethodPut:
+			if !s.requireControlAdmin(w, r) {
+				return
+			}
+			var req reqBody
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			persona := normalizePersona(req.Persona)
+			if persona == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": personaRequiredError})
+				return
+			}
+			if id := unknownHomeCard(s.personaCardIDs(persona, baseDir), req.CardIDs); id != "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown card " + id + " for persona " + persona})
+				return
+			}
+			principal, _ := requestIdentity(r)
+			item, err := s.homePreferences.SetMandatory(persona, req.CardIDs, principal)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "ui.home.mandatory_cards.updated",
+				Message: "persona home mandatory cards updated",
+				Fields: map[string]any{
+					"persona":    item.Persona,
+					"card_ids":   item.CardIDs,
+					"updated_by": item.UpdatedBy,
+				},
+			}, true)
+			writeJSON(w, http.StatusOK, item)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHomePreferencesCustomizeAndMandatoryCards(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	cardIDs := func(rr *httptest.ResponseRecorder) []string {
+		var payload struct {
+			Cards []struct {
+				ID string `json:"id"`
+			} `json:"cards"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("decode home failed: %v body=%s", err, rr.Body.String())
+		}
+		ids := []string{}
+		for _, card := range payload.Cards {
+			ids = append(ids, card.ID)
+		}
+		return ids
+	}
+
+	if rr := do(http.MethodGet, "/v1/views/home/preferences?persona=sre", "", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without principal, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPut, "/v1/views/home/preferences", "alice", `{"persona":"sre","pinned_cards":["nope"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown card to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPut, "/v1/views/home/preferences", "alice", `{"persona":"service-owner","hidden_cards":["change-approvals"],"card_order":["runbook-catalog"],"default_filters":{"owner":"payments-team","hours":"48"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("save preference failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/views/home/preferences/cards", "alice", `{"persona":"service-owner","action":"pin","card_id":"release-risk"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("pin card failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/v1/views/home?persona=service-owner", "alice", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("customized home failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if got := strings.Join(cardIDs(rr), ","); got != "release-risk,runbook-catalog" {
+		t.Fatalf("unexpected customized card layout: %s", got)
+	}
+	if !strings.Contains(rr.Body.String(), `"owner":"payments-team"`) || !strings.Contains(rr.Body.String(), `"window_hours":48`) || !strings.Contains(rr.Body.String(), `"customized":true`) {
+		t.Fatalf("expected default filters applied: %s", rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/views/home?persona=service-owner", "bob", "")
+	if got := strings.Join(cardIDs(rr), ","); got != "release-risk,change-approvals,runbook-catalog" {
+		t.Fatalf("expected default layout for another principal: %s", got)
+	}
+
+	if rr := do(http.MethodPut, "/v1/views/home/mandatory", "alice", `{"persona":"service-owner","card_ids":["change-approvals"]}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admin mandatory update to be denied: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/access/rbac/roles", "", `{"name":"control-admin","permissions":[{"resource":"control","action":"admin"}]}`)
+	var role struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &role)
+	if rr := do(http.MethodPost, "/v1/access/rbac/bindings", "", `{"subject":"admin","role_id":"`+role.ID+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create binding failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPut, "/v1/views/home/mandatory", "admin", `{"persona":"service-owner","card_ids":["change-approvals"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("mandatory update failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/views/home?persona=service-owner", "alice", "")
+	if got := strings.Join(cardIDs(rr), ","); got != "change-approvals,release-risk,runbook-catalog" {
+		t.Fatalf("expected mandatory card shown first: %s", got)
+	}
+	if !strings.Contains(rr.Body.String(), `"mandatory":true`) {
+		t.Fatalf("expected mandatory flag on card: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/views/home/preferences/cards", "alice", `{"persona":"service-owner","action":"hide","card_id":"change-approvals"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected hiding mandatory card to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/views/home/preferences/cards", "alice", `{"persona":"service-owner","action":"move","card_id":"runbook-catalog","position":0}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected card move to succeed once mandatory card is unhidden: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodDelete, "/v1/views/home/preferences?persona=service-owner", "alice", ""); rr.Code != http.StatusOK {
+		t.Fatalf("reset preference failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/views/home?persona=service-owner", "alice", "")
+	if !strings.Contains(rr.Body.String(), `"customized":false`) {
+		t.Fatalf("expected reset home view: %s", rr.Body.String())
+	}
+}
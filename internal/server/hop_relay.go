@@ -76,7 +76,11 @@ func (s *Server) handleRelaySessions(w http.ResponseWriter, r *http.Request) {
 		}
 		item, err := s.hopRelay.OpenSession(req)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			code := http.StatusBadRequest
+			if strings.Contains(err.Error(), "revoked") {
+				code = http.StatusForbidden
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
 			return
 		}
 		s.recordEvent(control.Event{
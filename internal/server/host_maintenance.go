@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleHostMaintenance serves GET/POST /v1/control/host-maintenance: the
+// maintenance calendar (optionally ?host=) and new windows.
+func (s *Server) handleHostMaintenance(w http.ResponseWriter, r *http.Request) {
+	s.advanceHostMaintenance()
+	switch r.Method {
+	case http.MethodGet:
+		host := strings.TrimSpace(r.URL.Query().Get("host"))
+		items := s.hostMaintenance.Calendar(host)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"host":           strings.ToLower(host),
+			"in_maintenance": host != "" && s.hostMaintenance.InMaintenance(host),
+			"count":          len(items),
+			"items":          items,
+		})
+	case http.MethodPost:
+		var req control.HostMaintenanceWindowInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if principal, _ := requestIdentity(r); principal != "" {
+			req.CreatedBy = principal
+		}
+		window, err := s.hostMaintenance.Schedule(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.events.Append(control.Event{
+			Type:    "host.maintenance.scheduled",
+			Message: "host maintenance window scheduled",
+			Fields: map[string]any{
+				"window_id": window.ID,
+				"host":      window.Host,
+				"start_at":  window.StartAt,
+				"end_at":    window.EndAt,
+				"reason":    window.Reason,
+			},
+		})
+		s.advanceHostMaintenance()
+		if cur, err := s.hostMaintenance.Get(window.ID); err == nil {
+			window = cur
+		}
+		writeJSON(w, http.StatusCreated, window)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleHostMaintenanceAction serves /v1/control/host-maintenance/deferred,
+// /v1/control/host-maintenance/{id}, and /v1/control/host-maintenance/{id}/end.
+func (s *Server) handleHostMaintenanceAction(w http.ResponseWriter, r *http.Request) {
+	s.advanceHostMaintenance()
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 4 || strings.TrimSpace(parts[3]) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid host maintenance path"})
+		return
+	}
+	if parts[3] == "deferred" && len(parts) == 4 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		items := s.hostMaintenance.Deferred(r.URL.Query().Get("host"), r.URL.Query().Get("status"))
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+		return
+	}
+	id := parts[3]
+	if len(parts) == 4 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		window, err := s.hostMaintenance.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, window)
+		return
+	}
+	if len(parts) != 5 || parts[4] != "end" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown host maintenance action"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := s.hostMaintenance.Get(id); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	window, wasActive, err := s.hostMaintenance.End(id)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	rescheduled := []control.DeferredHostWork{}
+	if wasActive {
+		rescheduled = s.finishHostMaintenance(window)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"window":      window,
+		"rescheduled": rescheduled,
+	})
+}
+
+// advanceHostMaintenance moves the calendar forward, announcing windows that
+// open and rescheduling deferred work for windows that close.
+func (s *Server) advanceHostMaintenance() {
+	for _, t := range s.hostMaintenance.Advance(time.Now().UTC()) {
+		if !t.Started {
+			s.finishHostMaintenance(t.Window)
+			continue
+		}
+		s.recordEvent(control.Event{
+			Type:    "host.maintenance.started",
+			Message: "host " + t.Window.Host + " entered maintenance",
+			Fields: map[string]any{
+				"window_id": t.Window.ID,
+				"host":      t.Window.Host,
+				"end_at":    t.Window.EndAt,
+				"reason":    t.Window.Reason,
+			},
+		}, true)
+	}
+}
+
+// finishHostMaintenance re-enqueues the work a host skipped during its
+// window, once per deferred schedule, association, or dispatch.
+func (s *Server) finishHostMaintenance(window control.HostMaintenanceWindow) []control.DeferredHostWork {
+	if s.hostMaintenance.InMaintenance(window.Host) {
+		return []control.DeferredHostWork{}
+	}
+	items := s.hostMaintenance.TakePending(window.Host)
+	for i, item := range items {
+		path := item.ConfigPath
+		if item.Kind == "dispatch" && !filepath.IsAbs(path) {
+			path = filepath.Join(s.baseDir, path)
+		}
+		job, err := s.queue.Enqueue(path, "host-maintenance:"+item.ID, item.Force, item.Priority)
+		if err != nil {
+			items[i].Status = control.DeferredWorkFailed
+			items[i].Error = err.Error()
+			s.hostMaintenance.MarkRescheduled(item.ID, "", err)
+			continue
+		}
+		items[i].JobID = job.ID
+		s.hostMaintenance.MarkRescheduled(item.ID, job.ID, nil)
+	}
+	s.recordEvent(control.Event{
+		Type:    "host.maintenance.ended",
+		Message: "host " + window.Host + " left maintenance",
+		Fields: map[string]any{
+			"window_id":   window.ID,
+			"host":        window.Host,
+			"status":      window.Status,
+			"rescheduled": len(items),
+		},
+	}, true)
+	return items
+}
+
+// noteMaintenanceSkip records a schedule tick skipped because its host is in
+// a maintenance window, attributing association schedules to their
+// association.
+func (s *Server) noteMaintenanceSkip(sc control.Schedule) {
+	window, ok := s.hostMaintenance.ActiveWindow(sc.Host)
+	if !ok {
+		return
+	}
+	kind, ref := "schedule", sc.ID
+	for _, assoc := range s.assocs.List() {
+		if assoc.ScheduleID == sc.ID {
+			kind, ref = "association", assoc.ID
+			break
+		}
+	}
+	item := s.hostMaintenance.Defer(control.DeferredHostWork{
+		Host:       window.Host,
+		Kind:       kind,
+		Ref:        ref,
+		ConfigPath: sc.ConfigPath,
+		Priority:   sc.Priority,
+	})
+	if item.Skipped > 1 {
+		return
+	}
+	s.events.Append(control.Event{
+		Type:    "host.maintenance.deferred",
+		Message: kind + " " + ref + " deferred for host maintenance",
+		Fields: map[string]any{
+			"deferred_id": item.ID,
+			"window_id":   window.ID,
+			"host":        window.Host,
+			"kind":        kind,
+			"ref":         ref,
+		},
+	})
+}
+
+func (s *Server) sweepHostMaintenance(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.advanceHostMaintenance()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestHostMaintenanceDefersAndReschedulesWork(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "web.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "ops")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/host-maintenance", `{"host":"web-1"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected window without end rejected, got %d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v1/control/host-maintenance", `{"host":"Web-1","duration_minutes":30,"reason":"kernel patch"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("schedule window: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var window control.HostMaintenanceWindow
+	if err := json.Unmarshal(rr.Body.Bytes(), &window); err != nil {
+		t.Fatal(err)
+	}
+	if window.Host != "web-1" || window.Status != control.HostMaintenanceActive || window.CreatedBy != "ops" {
+		t.Fatalf("expected active window for web-1, got %+v", window)
+	}
+	if rr := do(http.MethodPost, "/v1/control/host-maintenance", `{"host":"web-1","duration_minutes":10}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected overlapping window rejected, got %d", rr.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		rr = do(http.MethodPost, "/v1/agents/dispatch", `{"config_path":"web.yaml","host":"web-1"}`)
+		if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"status":"deferred"`) {
+			t.Fatalf("expected deferred dispatch: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodPost, "/v1/agents/dispatch", `{"config_path":"web.yaml","host":"web-2"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected dispatch to other host to proceed, got %d", rr.Code)
+	}
+	s.noteMaintenanceSkip(control.Schedule{ID: "sched-9", Host: "web-1", ConfigPath: filepath.Join(tmp, "web.yaml")})
+
+	for _, host := range []string{"web-1", "web-2"} {
+		if rr := do(http.MethodPost, "/v1/resources/exported", `{"type":"file","host":"`+host+`","resource_id":"motd"}`); rr.Code != http.StatusCreated {
+			t.Fatalf("export: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	rr = do(http.MethodPost, "/v1/resources/collect", `{"selector":"type=file"}`)
+	var collected control.CollectorResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &collected); err != nil {
+		t.Fatal(err)
+	}
+	if collected.Count != 1 || collected.Items[0].Host != "web-2" || len(collected.BypassedHosts) != 1 || collected.BypassedHosts[0] != "web-1" {
+		t.Fatalf("expected collection to bypass web-1: %+v", collected)
+	}
+
+	rr = do(http.MethodGet, "/v1/control/host-maintenance/deferred?host=web-1&status=pending", "")
+	var deferred struct {
+		Count int                        `json:"count"`
+		Items []control.DeferredHostWork `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &deferred); err != nil {
+		t.Fatal(err)
+	}
+	if deferred.Count != 2 {
+		t.Fatalf("expected dispatch and schedule deferrals, got %+v", deferred.Items)
+	}
+
+	rr = do(http.MethodPost, "/v1/control/host-maintenance/"+window.ID+"/end", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("end window: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var ended struct {
+		Window      control.HostMaintenanceWindow `json:"window"`
+		Rescheduled []control.DeferredHostWork    `json:"rescheduled"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &ended); err != nil {
+		t.Fatal(err)
+	}
+	if ended.Window.Status != control.HostMaintenanceCompleted || len(ended.Rescheduled) != 2 {
+		t.Fatalf("expected two rescheduled items: %+v", ended)
+	}
+	for _, item := range ended.Rescheduled {
+		if item.JobID == "" {
+			t.Fatalf("expected rescheduled job for %+v", item)
+		}
+	}
+	if rr := do(http.MethodPost, "/v1/control/host-maintenance/"+window.ID+"/end", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected second end to conflict, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/agents/dispatch", `{"config_path":"web.yaml","host":"web-1"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected dispatch after maintenance, got %d", rr.Code)
+	}
+	rr = do(http.MethodGet, "/v1/control/host-maintenance?host=web-1", "")
+	if !strings.Contains(rr.Body.String(), `"in_maintenance":false`) || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("unexpected calendar: %s", rr.Body.String())
+	}
+}
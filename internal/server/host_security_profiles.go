@@ -3,8 +3,12 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/planner"
 )
 
 func (s *Server) handleHostSecurityProfiles(w http.ResponseWriter, r *http.Request) {
@@ -56,3 +60,174 @@ func (s *Server) handleHostSecurityEvaluate(w http.ResponseWriter, r *http.Reque
 	}
 	writeJSON(w, http.StatusOK, decision)
 }
+
+// handleHostSecurityProfileActions serves control checks against a profile
+// and the evaluations they produce, including remediation generated from
+// failed checks:
+//
+//	POST /v1/security/host-profiles/{id}/check
+//	GET  /v1/security/host-profiles/evaluations[/{id}]
+//	POST /v1/security/host-profiles/evaluations/{id}/remediation/plan
+//	POST /v1/security/host-profiles/evaluations/{id}/remediation/runbook
+func (s *Server) handleHostSecurityProfileActions(baseDir string) http.HandlerFunc {
+	type remediationReq struct {
+		control.HostRemediationOptions
+		Name      string `json:"name,omitempty"`
+		Owner     string `json:"owner,omitempty"`
+		RiskLevel string `json:"risk_level,omitempty"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := splitPath(strings.TrimPrefix(r.URL.Path, "/v1/security/host-profiles/"))
+		if len(parts) == 2 && parts[1] == "check" && parts[0] != "evaluations" {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			var req control.HostSecurityCheckInput
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			eval, err := s.hostSecurityProfiles.CheckControls(parts[0], req)
+			if err != nil {
+				code := http.StatusBadRequest
+				if strings.Contains(err.Error(), "not found") {
+					code = http.StatusNotFound
+				}
+				writeJSON(w, code, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "security.host_profiles.checked",
+				Message: "host security controls evaluated",
+				Fields: map[string]any{
+					"evaluation_id": eval.ID,
+					"profile_id":    eval.ProfileID,
+					"host":          eval.Host,
+					"status":        eval.Status,
+					"failed":        eval.Failed,
+				},
+			}, true)
+			writeJSON(w, http.StatusOK, eval)
+			return
+		}
+		if len(parts) == 0 || parts[0] != "evaluations" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown host profile action"})
+			return
+		}
+		if len(parts) == 1 {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, http.StatusOK, s.hostSecurityProfiles.ListEvaluations(r.URL.Query().Get("profile_id")))
+			return
+		}
+		eval, err := s.hostSecurityProfiles.GetEvaluation(parts[1])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		if len(parts) == 2 {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, http.StatusOK, eval)
+			return
+		}
+		if len(parts) != 4 || parts[2] != "remediation" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown evaluation action"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req remediationReq
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+		}
+		cfg, err := control.BuildHostRemediationConfig(eval, req.HostRemediationOptions)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		switch parts[3] {
+		case "plan":
+			plan, err := planner.Build(cfg)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			items, changed := buildPlanDiffPreview(plan, baseDir)
+			writeJSON(w, http.StatusOK, map[string]any{
+				"evaluation":      eval,
+				"config":          cfg,
+				"step_count":      len(items),
+				"changed_actions": changed,
+				"items":           items,
+			})
+		case "runbook":
+			encoded, err := json.MarshalIndent(cfg, "", "  ")
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			dir := filepath.Join(baseDir, ".masterchef", "remediations")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			configPath := filepath.Join(dir, eval.ID+".json")
+			if err := os.WriteFile(configPath, encoded, 0o644); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			name := strings.TrimSpace(req.Name)
+			if name == "" {
+				name = "Remediate " + eval.Host + " (" + eval.ProfileID + ")"
+			}
+			riskLevel := req.RiskLevel
+			if riskLevel == "" {
+				riskLevel = "medium"
+			}
+			rb, err := s.runbooks.Create(control.Runbook{
+				Name:        name,
+				Description: "Applies settings that failed host security evaluation " + eval.ID,
+				TargetType:  control.RunbookTargetConfig,
+				ConfigPath:  configPath,
+				RiskLevel:   riskLevel,
+				Owner:       req.Owner,
+				Tags:        []string{"security", "remediation"},
+				Evidence:    []string{"host-security-evaluation:" + eval.ID},
+			})
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			eval, _ = s.hostSecurityProfiles.LinkRemediation(eval.ID, rb.ID)
+			s.recordEvent(control.Event{
+				Type:    "security.host_profiles.remediation_generated",
+				Message: "host security remediation runbook generated",
+				Fields: map[string]any{
+					"evaluation_id": eval.ID,
+					"profile_id":    eval.ProfileID,
+					"host":          eval.Host,
+					"runbook_id":    rb.ID,
+					"config_path":   configPath,
+				},
+			}, true)
+			writeJSON(w, http.StatusCreated, map[string]any{
+				"evaluation": eval,
+				"runbook":    rb,
+				"config":     cfg,
+			})
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown remediation action"})
+		}
+	}
+}
@@ -3,11 +3,15 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestHostSecurityProfileEndpoints(t *testing.T) {
@@ -51,3 +55,79 @@ func TestHostSecurityProfileEndpoints(t *testing.T) {
 		t.Fatalf("expected enforcing downgrade block: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestHostSecurityRemediationPlanAndRunbook(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	profile := `{"id":"baseline","mode":"selinux","target_kind":"host","target":"node-1","state":"enforcing","controls":[
+		{"id":"ip-forward","kind":"sysctl","key":"net.ipv4.ip_forward","value":"0"},
+		{"id":"root-login","kind":"sshd","key":"PermitRootLogin","value":"no"}]}`
+	if rr := do(http.MethodPost, "/v1/security/host-profiles", profile); rr.Code != http.StatusOK {
+		t.Fatalf("upsert profile failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/security/host-profiles/baseline/check", `{"host":"node-1","facts":{"sysctl":{"net.ipv4.ip_forward":"1"},"sshd":{"PermitRootLogin":"no"}}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("check failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var eval control.HostSecurityEvaluation
+	if err := json.Unmarshal(rr.Body.Bytes(), &eval); err != nil {
+		t.Fatal(err)
+	}
+	if eval.Status != "fail" || eval.Failed != 1 {
+		t.Fatalf("expected one failed check, got %+v", eval)
+	}
+	if rr := do(http.MethodPost, "/v1/security/host-profiles/missing/check", `{"host":"node-1"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected missing profile 404, got %d", rr.Code)
+	}
+
+	root := filepath.Join(tmp, "root")
+	opts := `{"transport":"local","root_dir":"` + root + `","reload":false}`
+	rr = do(http.MethodPost, "/v1/security/host-profiles/evaluations/"+eval.ID+"/remediation/plan", opts)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"step_count":1`) || !strings.Contains(rr.Body.String(), "net.ipv4.ip_forward = 0") {
+		t.Fatalf("remediation plan failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "etc", "sysctl.d", "99-masterchef-remediation.conf")); err == nil {
+		t.Fatalf("plan preview must not write remediation files")
+	}
+
+	rr = do(http.MethodPost, "/v1/security/host-profiles/evaluations/"+eval.ID+"/remediation/runbook", opts)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("remediation runbook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Evaluation control.HostSecurityEvaluation `json:"evaluation"`
+		Runbook    control.Runbook                `json:"runbook"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Evaluation.RemediationRunbookID != created.Runbook.ID || len(created.Runbook.Evidence) != 1 || created.Runbook.Evidence[0] != "host-security-evaluation:"+eval.ID {
+		t.Fatalf("expected runbook linked to evaluation evidence, got %+v", created)
+	}
+	if rr := do(http.MethodPost, "/v1/runbooks/"+created.Runbook.ID+"/approve", ""); rr.Code != http.StatusOK {
+		t.Fatalf("approve failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/runbooks/"+created.Runbook.ID+"/launch", ""); rr.Code != http.StatusAccepted {
+		t.Fatalf("launch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	found := false
+	for _, evt := range s.events.List() {
+		if evt.Type == "runbook.launched" && evt.Fields["runbook_id"] == created.Runbook.ID {
+			evidence, _ := evt.Fields["evidence"].([]string)
+			found = len(evidence) == 1 && evidence[0] == "host-security-evaluation:"+eval.ID
+		}
+	}
+	if !found {
+		t.Fatalf("expected runbook.launched event to carry evaluation evidence")
+	}
+}
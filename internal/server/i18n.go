@@ -0,0 +1,186 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+type requestLocaleKey struct{}
+
+// requestLocale is the locale negotiated from the request's Accept-Language
+// header by the HTTP wrapper.
+func requestLocale(r *http.Request) string {
+	if locale, ok := r.Context().Value(requestLocaleKey{}).(string); ok {
+		return locale
+	}
+	return control.DefaultLocale
+}
+
+// withRequestLocale negotiates the response locale, advertises it with
+// Content-Language, and records it on the request context.
+func (s *Server) withRequestLocale(w http.ResponseWriter, r *http.Request) *http.Request {
+	locale := s.messages.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", locale)
+	return r.WithContext(context.WithValue(r.Context(), requestLocaleKey{}, locale))
+}
+
+// localizeResponseWriter holds back JSON error responses so the "error"
+// message of the envelope can be translated; everything else passes
+// straight through.
+type localizeResponseWriter struct {
+	http.ResponseWriter
+	locale   string
+	messages *control.MessageCatalog
+
+	status int
+	held   bool
+	buf    bytes.Buffer
+}
+
+// newLocalizeResponseWriter returns nil when responses need no translation.
+func (s *Server) newLocalizeResponseWriter(w http.ResponseWriter, r *http.Request) *localizeResponseWriter {
+	locale := requestLocale(r)
+	if locale == control.DefaultLocale || r.Header.Get("Upgrade") != "" {
+		return nil
+	}
+	return &localizeResponseWriter{ResponseWriter: w, locale: locale, messages: s.messages}
+}
+
+func (l *localizeResponseWriter) WriteHeader(code int) {
+	if l.status != 0 {
+		return
+	}
+	l.status = code
+	if code >= http.StatusBadRequest && strings.HasPrefix(l.Header().Get("Content-Type"), "application/json") {
+		l.held = true
+		return
+	}
+	l.ResponseWriter.WriteHeader(code)
+}
+
+func (l *localizeResponseWriter) Write(p []byte) (int, error) {
+	if l.status == 0 {
+		l.WriteHeader(http.StatusOK)
+	}
+	if l.held {
+		return l.buf.Write(p)
+	}
+	return l.ResponseWriter.Write(p)
+}
+
+func (l *localizeResponseWriter) Flush() {
+	if l.held {
+		return
+	}
+	if f, ok := l.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes a held error response, translating its envelope. The
+// English message is kept as message_id so clients can still match on it.
+func (l *localizeResponseWriter) Close() {
+	if !l.held {
+		return
+	}
+	body := l.buf.Bytes()
+	var envelope map[string]any
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		if msg, ok := envelope["error"].(string); ok {
+			if translated := l.messages.Translate(l.locale, msg); translated != msg {
+				envelope["error"] = translated
+				envelope["message_id"] = msg
+				var out bytes.Buffer
+				_ = json.NewEncoder(&out).Encode(envelope)
+				body = out.Bytes()
+			}
+		}
+	}
+	l.Header().Del("Content-Length")
+	l.ResponseWriter.WriteHeader(l.status)
+	_, _ = l.ResponseWriter.Write(body)
+}
+
+func (s *Server) localizeChecklist(r *http.Request, run control.ChecklistRun) control.ChecklistRun {
+	run.Items = s.localizeChecklistItems(r, run.Items)
+	return run
+}
+
+func (s *Server) localizeChecklistItems(r *http.Request, items []control.ChecklistItem) []control.ChecklistItem {
+	locale := requestLocale(r)
+	if locale == control.DefaultLocale || len(items) == 0 {
+		return items
+	}
+	out := append([]control.ChecklistItem{}, items...)
+	for i := range out {
+		out[i].Prompt = s.messages.Translate(locale, out[i].Prompt)
+	}
+	return out
+}
+
+func (s *Server) localizeActionDoc(r *http.Request, doc control.ActionDoc) control.ActionDoc {
+	if locale := requestLocale(r); locale != control.DefaultLocale {
+		doc.Title = s.messages.Translate(locale, doc.Title)
+		doc.Summary = s.messages.Translate(locale, doc.Summary)
+	}
+	return doc
+}
+
+func (s *Server) handleLocales(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"default":    control.DefaultLocale,
+			"negotiated": requestLocale(r),
+			"items":      s.messages.Locales(),
+		})
+	case http.MethodPost:
+		var req control.LocalePackInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.messages.UpsertLocale(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.responseCache.Invalidate(responseCacheTagActionDocs)
+		s.recordEvent(control.Event{
+			Type:    "i18n.locale.updated",
+			Message: "locale message pack updated",
+			Fields: map[string]any{
+				"locale":   item.Locale,
+				"fallback": item.Fallback,
+				"messages": item.Messages,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleLocaleCompleteness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	items := s.messages.Completeness()
+	if locale := strings.TrimSpace(r.URL.Query().Get("locale")); locale != "" {
+		filtered := make([]control.LocaleCompleteness, 0, 1)
+		for _, item := range items {
+			if strings.EqualFold(item.Locale, locale) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
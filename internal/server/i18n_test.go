@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalizedErrorsChecklistsAndDocs(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, lang string, body []byte) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/control/checklists", "es-ES,es;q=0.9", []byte(`{`))
+	var envelope map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode error envelope: %v body=%s", err, rr.Body.String())
+	}
+	if rr.Code != http.StatusBadRequest || envelope["error"] != "cuerpo JSON no válido" || envelope["message_id"] != "invalid json body" {
+		t.Fatalf("expected translated error envelope: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Language") != "es" {
+		t.Fatalf("expected negotiated content language, got %q", rr.Header().Get("Content-Language"))
+	}
+	rr = do(http.MethodPost, "/v1/control/checklists", "", []byte(`{`))
+	if !strings.Contains(rr.Body.String(), `"error":"invalid json body"`) || strings.Contains(rr.Body.String(), "message_id") || rr.Header().Get("Content-Language") != "en" {
+		t.Fatalf("expected english error by default: body=%s headers=%v", rr.Body.String(), rr.Header())
+	}
+
+	rr = do(http.MethodPost, "/v1/control/checklists", "de", []byte(`{"name":"deploy","risk_level":"high"}`))
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), "Wartungsfenster") {
+		t.Fatalf("expected german checklist prompts: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var checklist struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &checklist)
+	rr = do(http.MethodPost, "/v1/control/checklists/"+checklist.ID+"/gate", "de", []byte(`{"phase":"post"}`))
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "Stabilitätsfenster") {
+		t.Fatalf("expected german gate blockers: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/control/checklists/"+checklist.ID, "", nil)
+	if !strings.Contains(rr.Body.String(), "maintenance window are active") {
+		t.Fatalf("expected stored prompts to stay english: %s", rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/v1/docs/actions", "es", nil)
+	if !strings.Contains(rr.Body.String(), "Investigar una ejecución fallida") {
+		t.Fatalf("expected spanish action docs: %s", rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/docs/actions", "", nil)
+	if !strings.Contains(rr.Body.String(), "Investigate Failed Run") {
+		t.Fatalf("expected cached english action docs to stay english: %s", rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/docs/generate", "de", []byte(`{"format":"markdown"}`))
+	if !strings.Contains(rr.Body.String(), "Generierte Masterchef-Dokumentation") || !strings.Contains(rr.Body.String(), `"locale":"de"`) {
+		t.Fatalf("expected german generated docs: %s", rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/i18n/locales", "", []byte(`{"locale":"es-MX","fallback":"es","messages":{"unknown action":"acción no reconocida"}}`))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"chain":["es-MX","es","en"]`) {
+		t.Fatalf("upsert locale failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/control/checklists", "es-MX", []byte(`{"action":"archive"}`))
+	if !strings.Contains(rr.Body.String(), "acción no reconocida") || rr.Header().Get("Content-Language") != "es-MX" {
+		t.Fatalf("expected regional translation: body=%s headers=%v", rr.Body.String(), rr.Header())
+	}
+
+	rr = do(http.MethodPost, "/v1/docs/actions", "", []byte(`{"id":"rotate-certs","title":"Rotate Certificates","summary":"Renew expiring certificates.","endpoints":["GET /v1/docs/actions"]}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upsert action doc failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/i18n/completeness?locale=de", "", nil)
+	var report struct {
+		Items []struct {
+			Locale   string   `json:"locale"`
+			Complete bool     `json:"complete"`
+			Missing  []string `json:"missing"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Items) != 1 || report.Items[0].Complete || len(report.Items[0].Missing) != 2 {
+		t.Fatalf("expected new doc strings to be missing from de: %s", rr.Body.String())
+	}
+}
@@ -2,11 +2,28 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
 
+const ssoSessionCookie = "mc_session"
+
+// issuedSSOSession is the only response that carries a session's bearer and
+// CSRF tokens: they are returned when a session is issued or rotated, never
+// by session lookups.
+type issuedSSOSession struct {
+	control.SSOSession
+	Token     string `json:"token"`
+	CSRFToken string `json:"csrf_token"`
+}
+
+func newIssuedSSOSession(session control.SSOSession) issuedSSOSession {
+	return issuedSSOSession{SSOSession: session, Token: session.Token, CSRFToken: session.CSRFToken}
+}
+
 func (s *Server) handleSSOProviders(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -121,7 +138,7 @@ func (s *Server) handleSSOLoginCallback(w http.ResponseWriter, r *http.Request)
 			"subject":     session.Subject,
 		},
 	}, true)
-	writeJSON(w, http.StatusCreated, session)
+	writeJSON(w, http.StatusCreated, newIssuedSSOSession(session))
 }
 
 func (s *Server) handleSSOSessions(w http.ResponseWriter, r *http.Request) {
@@ -134,19 +151,220 @@ func (s *Server) handleSSOSessions(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSSOSessionAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/identity/sso/sessions/{id}
+	// /v1/identity/sso/sessions/{id|current|refresh|revoke}
 	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "identity" || parts[2] != "sso" || parts[3] != "sessions" {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	if r.Method != http.MethodGet {
+	switch {
+	case parts[4] == "current" && r.Method == http.MethodGet:
+		session, code, err := s.ssoSessionFromRequest(r)
+		if err != nil {
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, session)
+	case parts[4] == "refresh" && r.Method == http.MethodPost:
+		current, code, err := s.ssoSessionFromRequest(r)
+		if err != nil {
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		session, err := s.identity.RefreshSession(current.Token)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "identity.sso.session.refreshed",
+			Message: "sso session tokens rotated",
+			Fields: map[string]any{
+				"session_id": session.ID,
+				"subject":    session.Subject,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, newIssuedSSOSession(session))
+	case parts[4] == "revoke" && r.Method == http.MethodPost:
+		var req struct {
+			Principal string `json:"principal"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.Principal) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "principal is required"})
+			return
+		}
+		revoked := s.identity.RevokeSessionsFor(req.Principal)
+		actor, _ := requestIdentity(r)
+		s.recordEvent(control.Event{
+			Type:    "identity.sso.session.revoked",
+			Message: "sso sessions revoked for user",
+			Fields: map[string]any{
+				"principal":  req.Principal,
+				"revoked":    revoked,
+				"revoked_by": actor,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, map[string]any{"principal": req.Principal, "revoked": revoked})
+	case r.Method == http.MethodGet:
+		item, ok := s.identity.GetSession(parts[4])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "sso session not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case r.Method == http.MethodDelete:
+		item, err := s.identity.RevokeSession(parts[4])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		actor, _ := requestIdentity(r)
+		s.recordEvent(control.Event{
+			Type:    "identity.sso.session.revoked",
+			Message: "sso session revoked",
+			Fields: map[string]any{
+				"session_id": item.ID,
+				"principal":  item.Email,
+				"revoked":    1,
+				"revoked_by": actor,
+			},
+		}, true)
+		w.WriteHeader(http.StatusNoContent)
+	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
 	}
-	item, ok := s.identity.GetSession(parts[4])
-	if !ok {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "sso session not found"})
+}
+
+func (s *Server) handleSSOSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.identity.SessionPolicy())
+	case http.MethodPost:
+		var req control.SSOSessionPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.identity.SetSessionPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "identity.sso.session_policy.updated",
+			Message: "sso session policy updated",
+			Fields: map[string]any{
+				"idle_timeout_seconds":      item.IdleTimeoutSeconds,
+				"absolute_lifetime_seconds": item.AbsoluteLifetimeSeconds,
+				"max_concurrent_sessions":   item.MaxConcurrentSessions,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// ssoSessionFromRequest authenticates the SSO session presented as a bearer
+// token or, for browsers, as the mc_session cookie. Cookie-borne sessions
+// must echo the session's CSRF token in X-Masterchef-CSRF on any request
+// that is not a safe method.
+func (s *Server) ssoSessionFromRequest(r *http.Request) (control.SSOSession, int, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.TrimSpace(token) != "" {
+		session, err := s.identity.AuthenticateSession(token)
+		if err != nil {
+			return control.SSOSession{}, http.StatusUnauthorized, err
+		}
+		return session, http.StatusOK, nil
+	}
+	cookie, err := r.Cookie(ssoSessionCookie)
+	if err != nil || strings.TrimSpace(cookie.Value) == "" {
+		return control.SSOSession{}, http.StatusUnauthorized, errors.New("sso session token is required")
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		session, err := s.identity.AuthenticateSession(cookie.Value)
+		if err != nil {
+			return control.SSOSession{}, http.StatusUnauthorized, err
+		}
+		return session, http.StatusOK, nil
+	}
+	session, err := s.identity.VerifySessionCSRF(cookie.Value, r.Header.Get("X-Masterchef-CSRF"))
+	if err != nil {
+		if strings.Contains(err.Error(), "csrf") {
+			return control.SSOSession{}, http.StatusForbidden, err
+		}
+		return control.SSOSession{}, http.StatusUnauthorized, err
+	}
+	return session, http.StatusOK, nil
+}
+
+func (s *Server) handleSSOSAML(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/identity/sso/saml/{provider_id}/metadata|acs
+	if len(parts) != 6 || parts[0] != "v1" || parts[1] != "identity" || parts[2] != "sso" || parts[3] != "saml" {
+		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	writeJSON(w, http.StatusOK, item)
+	providerID := parts[4]
+	switch parts[5] {
+	case "metadata":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		metadata, err := s.identity.SAMLMetadata(providerID)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", "application/samlmetadata+xml")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(metadata)
+	case "acs":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// IdPs deliver the response with the HTTP-POST binding as a form.
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid form body"})
+			return
+		}
+		session, err := s.identity.CompleteSAMLLogin(providerID, control.SAMLLoginInput{
+			SAMLResponse: r.PostForm.Get("SAMLResponse"),
+			RelayState:   r.PostForm.Get("RelayState"),
+		})
+		if err != nil {
+			s.recordEvent(control.Event{
+				Type:    "identity.sso.saml.rejected",
+				Message: "saml assertion rejected",
+				Fields: map[string]any{
+					"provider_id": providerID,
+					"reason":      err.Error(),
+					"severity":    "medium",
+				},
+			}, true)
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		added, removed := s.rbac.SyncManagedBindings("saml:"+session.Email, session.RoleBindings)
+		s.recordEvent(control.Event{
+			Type:    "identity.sso.session.issued",
+			Message: "sso session established",
+			Fields: map[string]any{
+				"session_id":       session.ID,
+				"provider_id":      session.ProviderID,
+				"subject":          session.Subject,
+				"protocol":         "saml",
+				"bindings_added":   len(added),
+				"bindings_removed": len(removed),
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, newIssuedSSOSession(session))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
 }
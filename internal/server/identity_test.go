@@ -3,12 +3,23 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestSSOAndSCIMEndpoints(t *testing.T) {
@@ -83,8 +94,8 @@ resources:
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/identity/sso/login/callback", bytes.NewReader(completeLogin))
 	s.httpServer.Handler.ServeHTTP(rr, req)
-	if rr.Code != http.StatusCreated {
-		t.Fatalf("complete login failed: code=%d body=%s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"token":"`) || !strings.Contains(rr.Body.String(), `"csrf_token":"`) {
+		t.Fatalf("complete login failed or omitted issued tokens: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 
 	upsertRole := []byte(`{"external_id":"role-ext-1","name":"Platform Admin","description":"full access"}`)
@@ -107,3 +118,144 @@ resources:
 		t.Fatalf("upsert scim team failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestSAMLProviderEndpoints(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "idp"}, NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	body, _ := json.Marshal(map[string]any{
+		"name":         "corp",
+		"protocol":     "saml",
+		"issuer_url":   "https://idp.example.com",
+		"client_id":    "https://mc.example.com/saml",
+		"redirect_url": "https://mc.example.com/acs",
+		"saml":         map[string]any{"sso_url": "https://idp.example.com/sso", "certificate": certPEM},
+	})
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/identity/sso/providers", bytes.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create saml provider failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var provider struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &provider)
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/identity/sso/saml/"+provider.ID+"/metadata", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/samlmetadata+xml" || !strings.Contains(rr.Body.String(), "AssertionConsumerService") {
+		t.Fatalf("unexpected metadata response: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/identity/sso/saml/sso-provider-99/metadata", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown provider metadata to 404, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/identity/sso/login/start", bytes.NewReader([]byte(`{"provider_id":"`+provider.ID+`","email":"alice@example.com"}`))))
+	var start struct {
+		State   string `json:"state"`
+		AuthURL string `json:"auth_url"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &start); err != nil || !strings.Contains(start.AuthURL, "SAMLRequest=") {
+		t.Fatalf("expected saml redirect url: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	form := url.Values{"SAMLResponse": {"PHNhbWxwOlJlc3BvbnNlLz4="}, "RelayState": {start.State}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/identity/sso/saml/"+provider.ID+"/acs", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unsigned response to be rejected, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	found := false
+	for _, evt := range s.events.List() {
+		if evt.Type == "identity.sso.saml.rejected" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected saml rejection event")
+	}
+}
+
+func TestSSOSessionLifecycleEndpoints(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	rr := do(httptest.NewRequest(http.MethodPost, "/v1/identity/sso/session-policy", strings.NewReader(`{"idle_timeout_seconds":900,"absolute_lifetime_seconds":3600,"max_concurrent_sessions":3}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set session policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(httptest.NewRequest(http.MethodPost, "/v1/identity/sso/session-policy", strings.NewReader(`{"idle_timeout_seconds":0}`))); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid policy to fail, got %d", rr.Code)
+	}
+
+	provider, _ := s.identity.CreateProvider(control.SSOProviderInput{Name: "okta", Protocol: "oidc", IssuerURL: "https://id.example.com", ClientID: "mc", RedirectURL: "https://mc.example.com/cb"})
+	start, _ := s.identity.StartLogin(control.SSOLoginStartInput{ProviderID: provider.ID, Email: "alice@example.com"})
+	session, err := s.identity.CompleteLogin(control.SSOLoginCompleteInput{State: start.State, Code: "ok", Subject: "00u1", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/identity/sso/sessions/current", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	if rr := do(req); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), session.ID) || strings.Contains(rr.Body.String(), session.Token) {
+		t.Fatalf("current session lookup failed or leaked token: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, path := range []string{"/v1/identity/sso/sessions", "/v1/identity/sso/sessions/" + session.ID} {
+		rr := do(httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), session.Token) || strings.Contains(rr.Body.String(), session.CSRFToken) || strings.Contains(rr.Body.String(), "csrf_token") {
+			t.Fatalf("expected %s to omit session tokens: code=%d body=%s", path, rr.Code, rr.Body.String())
+		}
+	}
+	req = httptest.NewRequest(http.MethodPost, "/v1/identity/sso/sessions/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "mc_session", Value: session.Token})
+	if rr := do(req); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected cookie refresh without csrf token to be forbidden, got %d", rr.Code)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/v1/identity/sso/sessions/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "mc_session", Value: session.Token})
+	req.Header.Set("X-Masterchef-CSRF", session.CSRFToken)
+	rr = do(req)
+	var refreshed struct {
+		Token     string `json:"token"`
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &refreshed); err != nil || rr.Code != http.StatusOK || refreshed.Token == "" || refreshed.Token == session.Token || refreshed.CSRFToken == "" {
+		t.Fatalf("cookie refresh failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodGet, "/v1/identity/sso/sessions/current", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	if rr := do(req); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected rotated token to be rejected, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/identity/sso/sessions/revoke", strings.NewReader(`{"principal":"alice@example.com"}`))
+	req.Header.Set("X-Masterchef-Principal", "admin")
+	if rr := do(req); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"revoked":1`) {
+		t.Fatalf("revoke user sessions failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(httptest.NewRequest(http.MethodDelete, "/v1/identity/sso/sessions/"+session.ID, nil)); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected revoked session delete to 404, got %d", rr.Code)
+	}
+}
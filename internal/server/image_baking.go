@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -34,7 +35,7 @@ func (s *Server) handleImageBakePipelines(w http.ResponseWriter, r *http.Request
 
 func (s *Server) handleImageBakePipelineAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/execution/image-baking/pipelines/{id}[/plan]
+	// /v1/execution/image-baking/pipelines/{id}[/plan|/sbom|/scans|/attestations]
 	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "execution" || parts[2] != "image-baking" || parts[3] != "pipelines" {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -71,6 +72,12 @@ func (s *Server) handleImageBakePipelineAction(w http.ResponseWriter, r *http.Re
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 			return
 		}
+		if plan.Allowed && plan.PromoteAfterBake {
+			if reasons := s.artifactScans.Gate(control.ScanArtifactImage, id, "publication"); len(reasons) > 0 {
+				plan.Allowed = false
+				plan.BlockedReason = "promotion blocked: " + strings.Join(reasons, "; ")
+			}
+		}
 		if !plan.Allowed {
 			writeJSON(w, http.StatusConflict, plan)
 			return
@@ -79,5 +86,23 @@ func (s *Server) handleImageBakePipelineAction(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if len(parts) == 6 && parts[5] == "attestations" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleImageBakeAttestation(w, r, id)
+		return
+	}
+
+	if len(parts) == 6 && (parts[5] == "sbom" || parts[5] == "scans") {
+		if _, ok := s.imageBaking.Get(id); !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "image bake pipeline not found"})
+			return
+		}
+		s.handleArtifactScanAction(w, r, control.ScanArtifactImage, id, parts[5])
+		return
+	}
+
 	w.WriteHeader(http.StatusNotFound)
 }
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+// handleIncidentExport renders the incident view for a scope into a
+// Markdown postmortem skeleton, optionally with a PDF copy, and stores both
+// in the object store under incidents/<scope>/.
+func (s *Server) handleIncidentExport(baseDir string) http.HandlerFunc {
+	type exportReq struct {
+		Workload string `json:"workload"`
+		RunID    string `json:"run_id"`
+		Hours    int    `json:"hours"`
+		Limit    int    `json:"limit"`
+		Title    string `json:"title"`
+		Format   string `json:"format"` // markdown|pdf|both
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if s.objectStore == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+			return
+		}
+		var req exportReq
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+		}
+		format := strings.ToLower(strings.TrimSpace(req.Format))
+		switch format {
+		case "":
+			format = "markdown"
+		case "markdown", "pdf", "both":
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be markdown, pdf, or both"})
+			return
+		}
+		if req.Hours <= 0 {
+			req.Hours = 6
+		}
+		if req.Limit <= 0 {
+			req.Limit = 300
+		}
+		inc := s.collectIncident(baseDir, normalizeWorkload(req.Workload), strings.TrimSpace(req.RunID), req.Hours, req.Limit)
+		md := control.RenderIncidentPostmortem(control.IncidentPostmortemInput{
+			Title:       req.Title,
+			Workload:    inc.Workload,
+			RunID:       inc.RunID,
+			WindowStart: inc.WindowStart,
+			GeneratedAt: inc.GeneratedAt,
+			RiskScore:   inc.RiskScore,
+			RiskLevel:   inc.RiskLevel,
+			Events:      inc.Events,
+			Alerts:      inc.Alerts,
+			Runs:        inc.Runs,
+			Annotations: inc.Annotations,
+			NextSteps:   inc.NextSteps,
+		})
+
+		scope := incidentExportScope(inc.Workload, inc.RunID)
+		base := "incidents/" + scope + "/postmortem-" + strconv.FormatInt(inc.GeneratedAt.UnixNano(), 10)
+		objects := make([]storage.ObjectInfo, 0, 2)
+		if format == "markdown" || format == "both" {
+			obj, err := s.objectStore.Put(base+".md", []byte(md), "text/markdown; charset=utf-8")
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			objects = append(objects, obj)
+		}
+		if format == "pdf" || format == "both" {
+			title := strings.TrimSpace(req.Title)
+			if title == "" {
+				title = "Incident postmortem: " + scope
+			}
+			obj, err := s.objectStore.Put(base+".pdf", control.RenderTextPDF(title, md), "application/pdf")
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			objects = append(objects, obj)
+		}
+		s.events.Append(control.Event{
+			Type:    "incident.exported",
+			Message: "incident postmortem exported",
+			Fields: map[string]any{
+				"workload": inc.Workload,
+				"run_id":   inc.RunID,
+				"format":   format,
+				"key":      objects[0].Key,
+			},
+		})
+		writeJSON(w, http.StatusCreated, map[string]any{
+			"workload":     inc.Workload,
+			"run_id":       inc.RunID,
+			"format":       format,
+			"generated_at": inc.GeneratedAt,
+			"objects":      objects,
+			"summary":      incidentSummary(inc.Alerts, inc.Runs, inc.Events, inc.Drift, inc.Health),
+			"markdown":     md,
+		})
+	}
+}
+
+func incidentExportScope(workload, runID string) string {
+	scope := workload
+	if scope == "" {
+		scope = runID
+	}
+	if scope == "" {
+		return "fleet"
+	}
+	var b strings.Builder
+	for _, r := range scope {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	if out := strings.Trim(b.String(), ".-"); out != "" {
+		return out
+	}
+	return "fleet"
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+func TestIncidentExportStoresPostmortem(t *testing.T) {
+	tmp := t.TempDir()
+	now := time.Now().UTC()
+	if err := state.New(tmp).SaveRun(state.RunRecord{ID: "run-9", Status: state.RunFailed, StartedAt: now.Add(-10 * time.Minute), EndedAt: now.Add(-9 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := do(http.MethodPost, "/v1/runs/run-9/annotations", "alice", `{"body":"disk filled during apply"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("annotate run failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/incidents/export", "", `{"run_id":"run-9","format":"docx"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown format to be rejected: code=%d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v1/incidents/export", "", `{"run_id":"run-9","format":"both","title":"DB outage"}`)
+	var resp struct {
+		Objects  []storage.ObjectInfo `json:"objects"`
+		Markdown string               `json:"markdown"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusCreated || len(resp.Objects) != 2 {
+		t.Fatalf("export failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.HasPrefix(resp.Objects[0].Key, "incidents/run-9/postmortem-") || !strings.HasSuffix(resp.Objects[0].Key, ".md") || !strings.HasSuffix(resp.Objects[1].Key, ".pdf") {
+		t.Fatalf("unexpected object keys %+v", resp.Objects)
+	}
+	md, _, err := s.objectStore.Get(resp.Objects[0].Key)
+	if err != nil || !strings.Contains(string(md), "# DB outage") || !strings.Contains(string(md), "run-9 finished failed") || !strings.Contains(string(md), "disk filled during apply") {
+		t.Fatalf("unexpected stored markdown err=%v:\n%s", err, md)
+	}
+	pdf, _, err := s.objectStore.Get(resp.Objects[1].Key)
+	if err != nil || !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Fatalf("expected stored pdf, err=%v", err)
+	}
+}
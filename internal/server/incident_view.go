@@ -32,88 +32,147 @@ type incidentHealthSignals struct {
 	Gate           control.HealthProbeGateResult `json:"gate"`
 }
 
-func (s *Server) handleIncidentView(baseDir string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		workload := normalizeWorkload(r.URL.Query().Get("workload"))
-		runID := strings.TrimSpace(r.URL.Query().Get("run_id"))
-		hours := 6
-		if raw := strings.TrimSpace(r.URL.Query().Get("hours")); raw != "" {
-			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
-				hours = n
-			}
-		}
-		if hours > 24*7 {
-			hours = 24 * 7
-		}
-		limit := 300
-		if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
-			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
-				limit = n
-			}
+// incidentSnapshot is the correlated state behind the incident view and
+// its postmortem export.
+type incidentSnapshot struct {
+	Workload    string
+	RunID       string
+	Hours       int
+	WindowStart time.Time
+	GeneratedAt time.Time
+	RiskScore   int
+	RiskLevel   string
+	Canary      map[string]any
+	Events      []control.Event
+	Alerts      []control.AlertItem
+	Runs        []state.RunRecord
+	Annotations []control.Annotation
+	Drift       incidentDriftSignals
+	Health      incidentHealthSignals
+	Links       []observabilityLink
+	NextSteps   []string
+}
+
+// incidentScope reads the workload, run_id, hours, and limit query
+// parameters shared by the incident view and export.
+func incidentScope(r *http.Request) (workload, runID string, hours, limit int) {
+	workload = normalizeWorkload(r.URL.Query().Get("workload"))
+	runID = strings.TrimSpace(r.URL.Query().Get("run_id"))
+	hours = 6
+	if raw := strings.TrimSpace(r.URL.Query().Get("hours")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			hours = n
 		}
-		if limit > 2000 {
-			limit = 2000
+	}
+	limit = 300
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			limit = n
 		}
+	}
+	return workload, runID, hours, limit
+}
 
-		windowStart := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
-		events := s.events.Query(control.EventQuery{Since: windowStart, Limit: limit, Desc: true})
-		correlatedEvents := make([]control.Event, 0, len(events))
-		for _, evt := range events {
-			if !incidentMatches(evt, workload, runID) {
-				continue
-			}
-			correlatedEvents = append(correlatedEvents, evt)
+func (s *Server) collectIncident(baseDir, workload, runID string, hours, limit int) incidentSnapshot {
+	if hours > 24*7 {
+		hours = 24 * 7
+	}
+	if limit > 2000 {
+		limit = 2000
+	}
+	windowStart := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+	events := s.events.Query(control.EventQuery{Since: windowStart, Limit: limit, Desc: true})
+	correlatedEvents := make([]control.Event, 0, len(events))
+	for _, evt := range events {
+		if !incidentMatches(evt, workload, runID) {
+			continue
 		}
+		correlatedEvents = append(correlatedEvents, evt)
+	}
 
-		alerts := s.alerts.List("all", limit)
-		correlatedAlerts := make([]control.AlertItem, 0, len(alerts))
-		for _, alert := range alerts {
-			if incidentAlertMatches(alert, workload, runID) {
-				correlatedAlerts = append(correlatedAlerts, alert)
-			}
+	alerts := s.alerts.List("all", limit)
+	correlatedAlerts := make([]control.AlertItem, 0, len(alerts))
+	for _, alert := range alerts {
+		if incidentAlertMatches(alert, workload, runID) {
+			correlatedAlerts = append(correlatedAlerts, alert)
 		}
+	}
 
-		runs, _ := state.New(baseDir).ListRuns(limit)
-		correlatedRuns := make([]state.RunRecord, 0, len(runs))
-		for _, run := range runs {
-			if incidentRunMatches(run, workload, runID) {
-				correlatedRuns = append(correlatedRuns, run)
-			}
+	runs, _ := state.New(baseDir).ListRuns(limit)
+	correlatedRuns := make([]state.RunRecord, 0, len(runs))
+	for _, run := range runs {
+		if incidentRunMatches(run, workload, runID) {
+			correlatedRuns = append(correlatedRuns, run)
 		}
+	}
 
-		links := collectObservabilityLinks(correlatedEvents)
-		canary := s.canaries.HealthSummary()
-		driftSignals := buildIncidentDriftSignals(correlatedRuns, s.driftPolicies, windowStart, hours)
-		healthSignals := buildIncidentHealthSignals(workload, correlatedRuns, s.healthProbes)
-		riskScore := incidentRiskScore(correlatedAlerts, correlatedRuns, canary, driftSignals, healthSignals)
-		riskLevel := "low"
-		if riskScore >= 60 {
-			riskLevel = "high"
-		} else if riskScore >= 30 {
-			riskLevel = "medium"
-		}
+	targets := map[string][]string{}
+	for _, run := range correlatedRuns {
+		targets[control.AnnotationTargetRun] = append(targets[control.AnnotationTargetRun], run.ID)
+	}
+	for _, alert := range correlatedAlerts {
+		targets[control.AnnotationTargetAlert] = append(targets[control.AnnotationTargetAlert], alert.ID)
+	}
+	if runID != "" {
+		targets[control.AnnotationTargetJob] = append(targets[control.AnnotationTargetJob], runID)
+	}
 
+	canary := s.canaries.HealthSummary()
+	driftSignals := buildIncidentDriftSignals(correlatedRuns, s.driftPolicies, windowStart, hours)
+	healthSignals := buildIncidentHealthSignals(workload, correlatedRuns, s.healthProbes)
+	riskScore := incidentRiskScore(correlatedAlerts, correlatedRuns, canary, driftSignals, healthSignals)
+	riskLevel := "low"
+	if riskScore >= 60 {
+		riskLevel = "high"
+	} else if riskScore >= 30 {
+		riskLevel = "medium"
+	}
+	return incidentSnapshot{
+		Workload:    workload,
+		RunID:       runID,
+		Hours:       hours,
+		WindowStart: windowStart,
+		GeneratedAt: time.Now().UTC(),
+		RiskScore:   riskScore,
+		RiskLevel:   riskLevel,
+		Canary:      canary,
+		Events:      correlatedEvents,
+		Alerts:      correlatedAlerts,
+		Runs:        correlatedRuns,
+		Annotations: s.annotations.ListForTargets(targets),
+		Drift:       driftSignals,
+		Health:      healthSignals,
+		Links:       collectObservabilityLinks(correlatedEvents),
+		NextSteps:   incidentNextSteps(riskScore, correlatedAlerts, correlatedRuns, driftSignals, healthSignals),
+	}
+}
+
+func (s *Server) handleIncidentView(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		workload, runID, hours, limit := incidentScope(r)
+		inc := s.collectIncident(baseDir, workload, runID, hours, limit)
 		writeJSON(w, http.StatusOK, map[string]any{
-			"workload":             workload,
-			"run_id":               runID,
-			"window_hours":         hours,
-			"window_start":         windowStart,
-			"generated_at":         time.Now().UTC(),
-			"risk_score":           riskScore,
-			"risk_level":           riskLevel,
-			"canary_health":        canary,
-			"events":               correlatedEvents,
-			"alerts":               correlatedAlerts,
-			"runs":                 correlatedRuns,
-			"drift_signals":        driftSignals,
-			"health_signals":       healthSignals,
-			"observability_links":  links,
-			"summary":              incidentSummary(correlatedAlerts, correlatedRuns, correlatedEvents, driftSignals, healthSignals),
-			"suggested_next_steps": incidentNextSteps(riskScore, correlatedAlerts, correlatedRuns, driftSignals, healthSignals),
+			"workload":             inc.Workload,
+			"run_id":               inc.RunID,
+			"window_hours":         inc.Hours,
+			"window_start":         inc.WindowStart,
+			"generated_at":         inc.GeneratedAt,
+			"risk_score":           inc.RiskScore,
+			"risk_level":           inc.RiskLevel,
+			"canary_health":        inc.Canary,
+			"events":               inc.Events,
+			"alerts":               inc.Alerts,
+			"runs":                 inc.Runs,
+			"annotations":          inc.Annotations,
+			"drift_signals":        inc.Drift,
+			"health_signals":       inc.Health,
+			"observability_links":  inc.Links,
+			"summary":              incidentSummary(inc.Alerts, inc.Runs, inc.Events, inc.Drift, inc.Health),
+			"suggested_next_steps": inc.NextSteps,
 		})
 	}
 }
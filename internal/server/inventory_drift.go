@@ -23,22 +23,48 @@ func (s *Server) handleInventoryDriftAnalyze(w http.ResponseWriter, r *http.Requ
 	writeJSON(w, http.StatusOK, report)
 }
 
+// handleInventoryDriftReconcile plans reconcile actions. With remediate set it
+// also saves them as a bulk preview (enroll missing hosts, quarantine
+// unexpected ones, relabel drifted ones, and optionally converge them) that
+// runs only when confirmed through POST /v1/bulk/execute.
 func (s *Server) handleInventoryDriftReconcile(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		control.InventoryDriftAnalyzeInput
+		Remediate          bool   `json:"remediate"`
+		ConvergeConfigPath string `json:"converge_config_path"`
+	}
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	var req control.InventoryDriftAnalyzeInput
+	var req reqBody
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 		return
 	}
-	report := s.inventoryDrift.Analyze(req, true)
-	code := http.StatusOK
+	report := s.inventoryDrift.Analyze(req.InventoryDriftAnalyzeInput, true)
 	if len(report.Actions) == 0 {
-		code = http.StatusNoContent
+		writeJSON(w, http.StatusNoContent, report)
+		return
 	}
-	writeJSON(w, code, report)
+	if req.Remediate {
+		ops := control.InventoryRemediationOperations(report, req.ConvergeConfigPath)
+		previews := make([]control.BulkOperationPreview, 0, len(ops))
+		for _, op := range ops {
+			norm := normalizeBulkOperation(op)
+			reason := s.validateBulkOperation(norm)
+			previews = append(previews, control.BulkOperationPreview{
+				Operation: norm,
+				Ready:     reason == "",
+				Reason:    reason,
+			})
+		}
+		preview := s.bulk.SavePreview("inventory drift "+report.ID, previews, detectBulkConflicts(ops))
+		if updated, err := s.inventoryDrift.AttachRemediation(report.ID, preview); err == nil {
+			report = updated
+		}
+	}
+	writeJSON(w, http.StatusOK, report)
 }
 
 func (s *Server) handleInventoryDriftReports(w http.ResponseWriter, r *http.Request) {
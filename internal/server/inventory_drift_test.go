@@ -3,11 +3,14 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestInventoryDriftEndpoints(t *testing.T) {
@@ -42,4 +45,49 @@ func TestInventoryDriftEndpoints(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Fatalf("inventory drift reconcile failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
+
+	if _, ok := s.nodes.Get("node-b"); ok {
+		t.Fatalf("expected plain reconcile to leave inventory untouched")
+	}
+
+	remediate := []byte(`{"remediate":true,"converge_config_path":"converge.yaml","desired":[{"name":"node-a","labels":{"role":"web"}},{"name":"node-b","labels":{"role":"db"}}],"observed":[{"name":"node-a","labels":{"role":"api"}},{"name":"node-c","labels":{"role":"cache"}}]}`)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/inventory/drift/reconcile", bytes.NewReader(remediate))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("inventory drift remediate failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var report control.InventoryDriftReport
+	_ = json.Unmarshal(rr.Body.Bytes(), &report)
+	if report.Remediation == nil || !report.Remediation.Ready || len(report.Remediation.Operations) != 4 {
+		t.Fatalf("expected ready remediation preview, got %s", rr.Body.String())
+	}
+	if _, ok := s.nodes.Get("node-b"); ok {
+		t.Fatalf("expected remediation to wait for confirmation")
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/bulk/execute", bytes.NewReader([]byte(`{"preview_token":"`+report.Remediation.Token+`","confirm":true}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("remediation execute failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if node, ok := s.nodes.Get("node-b"); !ok || node.Labels["role"] != "db" {
+		t.Fatalf("expected missing host enrolled with desired labels, got %+v", node)
+	}
+	if node, ok := s.nodes.Get("node-c"); !ok || node.Status != control.NodeStatusQuarantined {
+		t.Fatalf("expected unexpected host quarantined, got %+v", node)
+	}
+	if node, ok := s.nodes.Get("node-a"); !ok || node.Labels["role"] != "web" {
+		t.Fatalf("expected drifted host relabelled, got %+v", node)
+	}
+	converges := 0
+	for _, job := range s.queue.List() {
+		if job.ConfigPath == "converge.yaml" && job.Labels["host"] == "node-a" {
+			converges++
+		}
+	}
+	if converges != 1 {
+		t.Fatalf("expected one converge job for relabelled host, got %d", converges)
+	}
 }
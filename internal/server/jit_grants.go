@@ -105,3 +105,91 @@ func (s *Server) handleJITAccessGrantValidate(w http.ResponseWriter, r *http.Req
 	}
 	writeJSON(w, code, result)
 }
+
+func (s *Server) handleJITEnforcement(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.jitGrants.EnforcementPolicy())
+	case http.MethodPost:
+		if !s.requireControlAdmin(w, r) {
+			return
+		}
+		var req control.JITEnforcementPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.jitGrants.SetEnforcementPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "access.jit_enforcement.updated",
+			Message: "jit grant enforcement policy updated",
+			Fields: map[string]any{
+				"mode":    policy.Mode,
+				"enabled": policy.Enabled,
+				"routes":  len(policy.Routes),
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// requireJITGrant gates protected routes on an active grant whose token is
+// presented in the X-JIT-Grant header. When the caller names a principal it
+// must be the grant's subject. Denials answer 403 and are recorded as
+// access.jit_grant.denied events for the audit timeline.
+func (s *Server) requireJITGrant(w http.ResponseWriter, r *http.Request) bool {
+	route, ok := s.jitGrants.ProtectedRoute(r.Method, r.URL.Path)
+	if !ok {
+		return true
+	}
+	principal, _ := requestIdentity(r)
+	token := strings.TrimSpace(r.Header.Get("X-JIT-Grant"))
+	result := s.jitGrants.Validate(control.JITAccessGrantValidationInput{
+		Token:    token,
+		Resource: route.Resource,
+		Action:   route.Action,
+	})
+	switch {
+	case token == "":
+		result.Reason = "jit access grant required"
+	case result.Allowed && principal != "" && principal != result.Subject:
+		result.Allowed = false
+		result.Reason = "jit access grant subject mismatch"
+	}
+	fields := map[string]any{
+		"method":    r.Method,
+		"path":      r.URL.Path,
+		"resource":  route.Resource,
+		"action":    route.Action,
+		"principal": principal,
+		"grant_id":  result.GrantID,
+	}
+	if !result.Allowed {
+		fields["reason"] = result.Reason
+		fields["severity"] = "medium"
+		s.recordEvent(control.Event{
+			Type:    "access.jit_grant.denied",
+			Message: "request denied without an active jit access grant",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusForbidden, map[string]any{
+			"error":    result.Reason,
+			"resource": route.Resource,
+			"action":   route.Action,
+			"grant_id": result.GrantID,
+		})
+		return false
+	}
+	s.recordEvent(control.Event{
+		Type:    "access.jit_grant.used",
+		Message: "jit access grant authorized request",
+		Fields:  fields,
+	}, true)
+	return true
+}
@@ -119,3 +119,85 @@ resources:
 		t.Fatalf("expected revoked jit grant validation failure: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestJITGrantEnforcementGatesSensitiveRoutes(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(path, grant, principal, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		if grant != "" {
+			req.Header.Set("X-JIT-Grant", grant)
+		}
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	issue := func(resource, action string) (string, string) {
+		rr := do("/v1/access/jit-grants", "", "", `{"subject":"oncall","resource":"`+resource+`","action":"`+action+`","issued_by":"ic","reason":"incident"}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("issue jit grant failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var issued struct {
+			Token string `json:"token"`
+			Grant struct {
+				ID string `json:"id"`
+			} `json:"grant"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &issued)
+		return issued.Grant.ID, issued.Token
+	}
+
+	if rr := do("/v1/access/jit-grants/enforcement", "", "", `{"mode":"off"}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected enforcement change without principal to be unauthorized: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/control/emergency-stop", "", "", `{"enabled":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected emergency stop to be open before any grant exists: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	// Enforcement switches on by default once grants exist.
+	_, secretsToken := issue("secrets", "read")
+	if rr := do("/v1/control/emergency-stop", "", "", `{"enabled":true,"reason":"drill"}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected emergency stop without grant to be forbidden: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/control/emergency-stop", secretsToken, "", `{"enabled":true,"reason":"drill"}`); rr.Code != http.StatusForbidden || !bytes.Contains(rr.Body.Bytes(), []byte("resource mismatch")) {
+		t.Fatalf("expected grant for another resource to be forbidden: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	grantID, stopToken := issue("control.emergency_stop", "toggle")
+	if rr := do("/v1/control/emergency-stop", stopToken, "someone-else", `{"enabled":true,"reason":"drill"}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected subject mismatch to be forbidden: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/control/emergency-stop", stopToken, "oncall", `{"enabled":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected emergency stop with grant to succeed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/access/jit-grants/"+grantID+"/revoke", "", "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("revoke grant failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/control/emergency-stop", stopToken, "", `{"enabled":false}`); rr.Code != http.StatusForbidden || !bytes.Contains(rr.Body.Bytes(), []byte("revoked")) {
+		t.Fatalf("expected revoked grant to be forbidden: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/activity/audit-timeline?category=identity", nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte("access.jit_grant.denied")) {
+		t.Fatalf("expected denial in audit timeline: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	grantControlAdmin(t, s, "oncall")
+	if rr := do("/v1/access/jit-grants/enforcement", "", "oncall", `{"enabled":false}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected disabling enforcement without grant to be forbidden: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	_, policyToken := issue("access.jit_enforcement", "update")
+	if rr := do("/v1/access/jit-grants/enforcement", policyToken, "", `{"enabled":false}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected disabling enforcement without admin to be unauthorized: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/access/jit-grants/enforcement", policyToken, "oncall", `{"enabled":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected disabling enforcement with grant to succeed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/control/emergency-stop", "", "", `{"enabled":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected emergency stop to be open once enforcement is disabled: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
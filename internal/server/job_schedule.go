@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleJobReschedule serves POST /v1/jobs/{id}/reschedule, moving a
+// delayed job's run_at before it is dispatched.
+func (s *Server) handleJobReschedule(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		RunAt time.Time `json:"run_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	previous, ok := s.queue.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+	job, err := s.queue.Reschedule(id, req.RunAt)
+	if err != nil {
+		code := http.StatusConflict
+		if strings.Contains(err.Error(), "required") {
+			code = http.StatusBadRequest
+		}
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "job.rescheduled",
+		Message: "delayed job rescheduled",
+		Fields: map[string]any{
+			"job_id":   job.ID,
+			"previous": previous.NotBefore,
+			"run_at":   job.NotBefore,
+			"status":   job.Status,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleDispatchWindows(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.queue.DispatchWindows()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.DispatchWindow
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		window, err := s.queue.SetDispatchWindow(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.queue.dispatch_window.updated",
+			Message: "queue dispatch window updated",
+			Fields: map[string]any{
+				"name":     window.Name,
+				"priority": window.Priority,
+				"selector": window.Selector,
+				"start":    window.Start,
+				"end":      window.End,
+				"timezone": window.Timezone,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, window)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleDispatchWindowByName(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/queue/dispatch-windows/{name}
+	if len(parts) != 5 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "dispatch window not found"})
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.queue.DeleteDispatchWindow(parts[4]) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "dispatch window not found"})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.queue.dispatch_window.deleted",
+		Message: "queue dispatch window deleted",
+		Fields:  map[string]any{"name": parts[4]},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// labeledQueryEntities maps query engine entities that carry labels to the
+// RBAC resource that scopes them.
+var labeledQueryEntities = map[string]string{
+	"jobs":      "jobs",
+	"runs":      "runs",
+	"templates": "templates",
+	"schedules": "schedules",
+	"hosts":     "hosts",
+}
+
+// labelFilter builds the predicate a list endpoint applies to labeled
+// entities. It combines the label_selector query parameter with RBAC label
+// scoping: a principal bound to any role sees only entities its roles may
+// read, so a permission with label_selector "team=payments" limits it to
+// the payments team's entities. Requests without RBAC bindings are not
+// scoped.
+func (s *Server) labelFilter(w http.ResponseWriter, r *http.Request, resource string) (func(map[string]string) bool, bool) {
+	selector, err := control.ParseLabelSelector(r.URL.Query().Get("label_selector"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	scoped := s.labelScope(r, resource)
+	return func(labels map[string]string) bool {
+		return selector.Matches(labels) && scoped(labels)
+	}, true
+}
+
+// labelScope returns whether the request's principal may read an entity of
+// resource with the given labels.
+func (s *Server) labelScope(r *http.Request, resource string) func(map[string]string) bool {
+	principal, _ := requestIdentity(r)
+	if principal == "" || !s.rbac.HasBindings(principal) {
+		return func(map[string]string) bool { return true }
+	}
+	return func(labels map[string]string) bool {
+		return s.rbac.CheckAccess(control.RBACAccessCheckInput{
+			Subject:  principal,
+			Resource: resource,
+			Action:   "read",
+			Labels:   labels,
+		}).Allowed
+	}
+}
+
+// filterLabeled keeps the items whose labels pass keep.
+func filterLabeled[T any](items []T, labels func(T) map[string]string, keep func(map[string]string) bool) []T {
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		if keep(labels(item)) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// recordLabels extracts the labels of a query engine record.
+func recordLabels(rec map[string]any) map[string]string {
+	raw, ok := rec["labels"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if str, ok := v.(string); ok {
+			out[k] = str
+		}
+	}
+	return out
+}
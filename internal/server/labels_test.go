@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestLabelSelectorsAndScopedListing(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	st := state.New(tmp)
+	for id, team := range map[string]string{"run-pay": "payments", "run-search": "search"} {
+		if err := st.SaveRun(state.RunRecord{ID: id, Status: state.RunSucceeded, Labels: map[string]string{"team": team}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	count := func(rr *httptest.ResponseRecorder) int {
+		var items []json.RawMessage
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil {
+			t.Fatalf("decode list: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		return len(items)
+	}
+
+	var payTemplate struct {
+		ID string `json:"id"`
+	}
+	for _, team := range []string{"payments", "search"} {
+		rr := do(http.MethodPost, "/v1/templates", "", `{"name":"`+team+`","config_path":"c.yaml","labels":{"team":"`+team+`"}}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("create template failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		if team == "payments" {
+			_ = json.Unmarshal(rr.Body.Bytes(), &payTemplate)
+		}
+		if rr = do(http.MethodPost, "/v1/schedules", "", `{"config_path":"c.yaml","interval_seconds":3600,"labels":{"team":"`+team+`"}}`); rr.Code != http.StatusCreated {
+			t.Fatalf("create schedule failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		if rr = do(http.MethodPost, "/v1/inventory/runtime-hosts", "", `{"name":"`+team+`-1","labels":{"team":"`+team+`"}}`); rr.Code >= 300 {
+			t.Fatalf("enroll host failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodPost, "/v1/templates", "", `{"name":"bad","config_path":"c.yaml","labels":{"Team":"x"}}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid label key to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/templates/"+payTemplate.ID+"/launch", "", "")
+	if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"labels":{"team":"payments"}`) {
+		t.Fatalf("expected launched job to inherit template labels: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	for _, path := range []string{"/v1/templates", "/v1/schedules", "/v1/inventory/runtime-hosts", "/v1/runs", "/v1/jobs"} {
+		if n := count(do(http.MethodGet, path+"?label_selector=team=payments", "", "")); n != 1 {
+			t.Fatalf("expected one payments entity from %s, got %d", path, n)
+		}
+	}
+	if n := count(do(http.MethodGet, "/v1/templates?label_selector=team!=payments", "", "")); n != 1 {
+		t.Fatalf("expected one non-payments template, got %d", n)
+	}
+	if rr = do(http.MethodGet, "/v1/templates?label_selector=Team=x", "", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid selector to be rejected: code=%d", rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/v1/query", "", `{"entity":"hosts","query":"labels.team=search"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"matched_count":1`) || !strings.Contains(rr.Body.String(), `"name":"search-1"`) {
+		t.Fatalf("query by label failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/access/rbac/roles", "", `{"name":"payments-reader","permissions":[{"resource":"*","action":"read","label_selector":"team=payments"}]}`)
+	var role struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &role)
+	if rr = do(http.MethodPost, "/v1/access/rbac/bindings", "", `{"subject":"bob","role_id":"`+role.ID+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create binding failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, path := range []string{"/v1/templates", "/v1/schedules", "/v1/inventory/runtime-hosts", "/v1/runs"} {
+		if n := count(do(http.MethodGet, path, "bob", "")); n != 1 {
+			t.Fatalf("expected bob to see one entity from %s, got %d", path, n)
+		}
+		if n := count(do(http.MethodGet, path, "carol", "")); n != 2 {
+			t.Fatalf("expected unbound principal to see both entities from %s, got %d", path, n)
+		}
+	}
+	rr = do(http.MethodPost, "/v1/query", "bob", `{"entity":"templates","query":"labels.team=search"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"matched_count":0`) {
+		t.Fatalf("expected query to honor label scope: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
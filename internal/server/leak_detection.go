@@ -3,6 +3,8 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -53,3 +55,64 @@ func (s *Server) handleLeakDetectionReports(w http.ResponseWriter, r *http.Reque
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"items": s.leakDetection.Reports()})
 }
+
+// handleLeakDetectionCapture records a runtime snapshot of the control plane
+// immediately instead of waiting for the next sampling interval.
+func (s *Server) handleLeakDetectionCapture(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	report, err := s.leakSampler.CaptureNow()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// leakStoreSizes reports the entry counts of in-memory stores that grow
+// with traffic, so a leak report can name the store responsible.
+func (s *Server) leakStoreSizes() map[string]int {
+	return map[string]int{
+		"events":         s.events.Len(),
+		"queue_jobs":     s.queue.Len(),
+		"alerts":         s.alerts.Summary().Total,
+		"response_cache": s.responseCache.Stats().Entries,
+	}
+}
+
+// noteLeakReport raises an alert when the control plane starts leaking and
+// records recovery once growth falls back under the policy thresholds.
+func (s *Server) noteLeakReport(report control.LeakReport, transitioned bool) {
+	if !transitioned {
+		return
+	}
+	if !report.LeakDetected {
+		s.recordEvent(control.Event{
+			Type:    "control.leak.recovered",
+			Message: "resource growth returned within leak detection thresholds",
+			Fields:  map[string]any{"component": report.Component},
+		}, true)
+		return
+	}
+	message := "resource leak detected in " + report.Component
+	if report.OffendingStore != "" {
+		message += ": store " + report.OffendingStore + " grew " + strconv.FormatFloat(report.StoreGrowthPercent[report.OffendingStore], 'f', 1, 64) + "%"
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.leak.detected",
+		Message: message,
+		Fields: map[string]any{
+			"severity":                 "high",
+			"component":                report.Component,
+			"resource":                 report.OffendingStore,
+			"offending_store":          report.OffendingStore,
+			"reasons":                  strings.Join(report.Reasons, "; "),
+			"memory_growth_percent":    report.MemoryGrowthPercent,
+			"goroutine_growth_percent": report.GoroutineGrowthPercent,
+			"fd_growth_percent":        report.FDGrowthPercent,
+			"samples":                  report.Samples,
+		},
+	}, true)
+}
@@ -3,10 +3,12 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -57,3 +59,50 @@ func TestLeakDetectionEndpoints(t *testing.T) {
 		t.Fatalf("list leak reports failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestLeakDetectionAutomaticCaptureAlertsOffendingStore(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	policy := []byte(`{"min_samples":3,"memory_growth_percent":100000,"goroutine_growth_percent":100000,"file_descriptor_growth_percent":100000,"store_growth_percent":20,"min_store_entries":1,"capture_interval_seconds":3600}`)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/control/leak-detection/policy", bytes.NewReader(policy))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set leak policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	var report struct {
+		LeakDetected   bool   `json:"leak_detected"`
+		OffendingStore string `json:"offending_store"`
+	}
+	for i := 0; i < 3; i++ {
+		// Every request appends events, so the event store grows between
+		// captures.
+		for j := 0; j < 50; j++ {
+			rr = httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		}
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/v1/control/leak-detection/capture", nil)
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("leak capture failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatalf("decode leak report failed: %v", err)
+		}
+	}
+	if !report.LeakDetected || report.OffendingStore != "events" {
+		t.Fatalf("expected events store to be reported as leaking, got %+v", report)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/alerts/inbox", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "control.leak.detected") {
+		t.Fatalf("expected leak alert in inbox, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+type requestLoggerKey struct{}
+
+// newServerLogger builds the server's structured logger. MC_LOG_FORMAT
+// selects json or text output and MC_LOG_LEVEL the initial level; the level
+// can be changed at runtime through the returned LevelVar.
+func newServerLogger(out io.Writer) (*slog.Logger, *slog.LevelVar, string) {
+	level := &slog.LevelVar{}
+	if parsed, err := parseLogLevel(os.Getenv("MC_LOG_LEVEL")); err == nil {
+		level.Set(parsed)
+	}
+	format := strings.ToLower(strings.TrimSpace(os.Getenv("MC_LOG_FORMAT")))
+	if format != "json" {
+		format = "text"
+	}
+	return slog.New(newLogHandler(out, format, level)), level, format
+}
+
+func newLogHandler(out io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.NewJSONHandler(out, opts)
+	}
+	return slog.NewTextHandler(out, opts)
+}
+
+// parseLogLevel accepts debug, info, warn/warning, and error. An empty
+// string means info.
+func parseLogLevel(raw string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, errors.New("level must be debug, info, warn, or error")
+	}
+}
+
+// Logger returns the server's structured logger.
+func (s *Server) Logger() *slog.Logger {
+	return s.logger
+}
+
+// requestLogger returns the logger attached to a request by wrapHTTP, which
+// carries the request ID, principal, and tenant on every line.
+func (s *Server) requestLogger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(requestLoggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return s.logger
+}
+
+// requestIdentity extracts the caller principal and tenant for log and event
+// correlation.
+func requestIdentity(r *http.Request) (string, string) {
+	principal := strings.TrimSpace(r.Header.Get("X-Masterchef-Principal"))
+	tenant := strings.TrimSpace(r.Header.Get("X-Masterchef-Tenant"))
+	if tenant == "" {
+		tenant = strings.TrimSpace(r.URL.Query().Get("tenant"))
+	}
+	return principal, tenant
+}
+
+// statusRecorder captures the status code and body size written to the
+// client for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		level, err := parseLogLevel(req.Level)
+		if err != nil || strings.TrimSpace(req.Level) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "level must be debug, info, warn, or error"})
+			return
+		}
+		previous := s.logLevel.Level()
+		s.logLevel.Set(level)
+		s.requestLogger(r.Context()).Info("log level changed", "from", previous.String(), "to", level.String())
+		s.recordEvent(control.Event{
+			Type:    "control.log_level.updated",
+			Message: "log level updated",
+			Fields: map[string]any{
+				"from": strings.ToLower(previous.String()),
+				"to":   strings.ToLower(level.String()),
+			},
+		}, true)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"level":  strings.ToLower(s.logLevel.Level().String()),
+		"format": s.logFormat,
+	})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStructuredRequestLogging(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	var buf bytes.Buffer
+	s.logger = slog.New(newLogHandler(&buf, "json", s.logLevel))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/providers/catalog", nil)
+	req.Header.Set("X-Masterchef-Principal", "alice")
+	req.Header.Set("X-Masterchef-Tenant", "acme")
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	reqID := rr.Header().Get("X-Request-ID")
+
+	var line map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &line); err != nil {
+		t.Fatalf("expected one json log line, got %q: %v", buf.String(), err)
+	}
+	if line["request_id"] != reqID || line["principal"] != "alice" || line["tenant"] != "acme" || line["status"] != float64(http.StatusOK) {
+		t.Fatalf("unexpected request log line: %v", line)
+	}
+	found := false
+	for _, e := range s.events.List() {
+		if e.Type == "http.request" && e.Fields["id"] == reqID && e.Fields["principal"] == "alice" && e.Fields["tenant"] == "acme" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected http.request event correlated with log request_id %s", reqID)
+	}
+
+	buf.Reset()
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/log-level", bytes.NewReader([]byte(`{"level":"error"}`))))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"level":"error"`)) {
+		t.Fatalf("set log level failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	buf.Reset()
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/log-level", nil))
+	if buf.Len() != 0 {
+		t.Fatalf("expected info request log to be suppressed at error level: %s", buf.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/log-level", bytes.NewReader([]byte(`{"level":"verbose"}`))))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid level to be rejected: code=%d", rr.Code)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected 4xx warn line to be suppressed at error level: %s", buf.String())
+	}
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// Patch documents expose the editable fields of an entity under the same
+// names as its create request. PATCH bodies are JSON merge patches against
+// them; read-only fields such as ids and counters are rejected as unknown.
+
+type templateDocument struct {
+	Name        string                         `json:"name"`
+	Description string                         `json:"description"`
+	ConfigPath  string                         `json:"config_path"`
+	StrictMode  bool                           `json:"strict_mode,omitempty"`
+	Defaults    map[string]string              `json:"defaults"`
+	Survey      map[string]control.SurveyField `json:"survey"`
+	Labels      map[string]string              `json:"labels"`
+	Owner       string                         `json:"owner"`
+	Team        string                         `json:"team"`
+}
+
+type ruleDocument struct {
+	Name            string                  `json:"name"`
+	SourcePrefix    string                  `json:"source_prefix"`
+	Enabled         bool                    `json:"enabled"`
+	MatchMode       string                  `json:"match_mode"`
+	Conditions      []control.RuleCondition `json:"conditions"`
+	Actions         []control.RuleAction    `json:"actions"`
+	CooldownSeconds int                     `json:"cooldown_seconds"`
+}
+
+type webhookDocument struct {
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	EventPrefix     string `json:"event_prefix"`
+	Secret          string `json:"secret"`
+	Enabled         bool   `json:"enabled"`
+	PayloadTemplate string `json:"payload_template"`
+	ContentType     string `json:"content_type"`
+	Owner           string `json:"owner"`
+	Team            string `json:"team"`
+}
+
+type notificationTargetDocument struct {
+	Name            string                          `json:"name"`
+	Kind            string                          `json:"kind"`
+	URL             string                          `json:"url"`
+	Route           string                          `json:"route"`
+	Enabled         bool                            `json:"enabled"`
+	PayloadTemplate string                          `json:"payload_template"`
+	ContentType     string                          `json:"content_type"`
+	QuietHours      *control.NotificationQuietHours `json:"quiet_hours"`
+}
+
+type scheduleDocument struct {
+	ConfigPath      string            `json:"config_path"`
+	IntervalSeconds int               `json:"interval_seconds"`
+	JitterSeconds   int               `json:"jitter_seconds"`
+	Priority        string            `json:"priority"`
+	ExecutionCost   int               `json:"execution_cost"`
+	Host            string            `json:"host"`
+	Cluster         string            `json:"cluster"`
+	Environment     string            `json:"environment"`
+	Labels          map[string]string `json:"labels"`
+	Owner           string            `json:"owner"`
+	Team            string            `json:"team"`
+	Enabled         bool              `json:"enabled"`
+}
+
+// mergePatchRequest applies the request body, a JSON merge patch, to
+// current and decodes the result strictly into a fresh document.
+func mergePatchRequest[T any](w http.ResponseWriter, r *http.Request, current T) (T, bool) {
+	var out T
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+			writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "PATCH requires Content-Type application/merge-patch+json"})
+			return out, false
+		}
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil || len(bytes.TrimSpace(patch)) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "merge patch body is required"})
+		return out, false
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return out, false
+	}
+	merged, err := control.ApplyMergePatch(doc, patch)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return out, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid merge patch: " + strings.TrimPrefix(err.Error(), "json: ")})
+		return out, false
+	}
+	return out, true
+}
+
+func (s *Server) patchWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	cur, err := s.webhooks.Get(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	req, ok := mergePatchRequest(w, r, webhookDocument{
+		Name:            cur.Name,
+		URL:             cur.URL,
+		EventPrefix:     cur.EventPrefix,
+		Secret:          cur.Secret,
+		Enabled:         cur.Enabled,
+		PayloadTemplate: cur.PayloadTemplate,
+		ContentType:     cur.ContentType,
+		Owner:           cur.Owner,
+		Team:            cur.Team,
+	})
+	if !ok {
+		return
+	}
+	owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+	if !ok {
+		return
+	}
+	wh, err := s.webhooks.Update(id, control.WebhookSubscription{
+		Name:            req.Name,
+		URL:             req.URL,
+		EventPrefix:     req.EventPrefix,
+		Secret:          req.Secret,
+		Enabled:         req.Enabled,
+		PayloadTemplate: req.PayloadTemplate,
+		ContentType:     req.ContentType,
+		Owner:           owner,
+		Team:            team,
+	})
+	if err != nil {
+		writeEntityUpdateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, wh)
+}
+
+func (s *Server) patchNotificationTarget(w http.ResponseWriter, r *http.Request, id string) {
+	cur, err := s.notifications.Get(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	req, ok := mergePatchRequest(w, r, notificationTargetDocument{
+		Name:            cur.Name,
+		Kind:            cur.Kind,
+		URL:             cur.URL,
+		Route:           cur.Route,
+		Enabled:         cur.Enabled,
+		PayloadTemplate: cur.PayloadTemplate,
+		ContentType:     cur.ContentType,
+		QuietHours:      cur.QuietHours,
+	})
+	if !ok {
+		return
+	}
+	target, err := s.notifications.Update(id, control.NotificationTarget{
+		Name:            req.Name,
+		Kind:            req.Kind,
+		URL:             req.URL,
+		Route:           req.Route,
+		Enabled:         req.Enabled,
+		PayloadTemplate: req.PayloadTemplate,
+		ContentType:     req.ContentType,
+		QuietHours:      req.QuietHours,
+	})
+	if err != nil {
+		writeEntityUpdateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, target)
+}
+
+func (s *Server) patchSchedule(w http.ResponseWriter, r *http.Request, id string) {
+	cur, ok := s.scheduler.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+		return
+	}
+	req, ok := mergePatchRequest(w, r, scheduleDocument{
+		ConfigPath:      cur.ConfigPath,
+		IntervalSeconds: int(cur.Interval / time.Second),
+		JitterSeconds:   int(cur.Jitter / time.Second),
+		Priority:        cur.Priority,
+		ExecutionCost:   cur.ExecutionCost,
+		Host:            cur.Host,
+		Cluster:         cur.Cluster,
+		Environment:     cur.Environment,
+		Labels:          cur.Labels,
+		Owner:           cur.Owner,
+		Team:            cur.Team,
+		Enabled:         cur.Enabled,
+	})
+	if !ok {
+		return
+	}
+	if req.ConfigPath == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
+		return
+	}
+	if req.IntervalSeconds <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "interval_seconds must be positive"})
+		return
+	}
+	if !filepath.IsAbs(req.ConfigPath) {
+		req.ConfigPath = filepath.Join(s.baseDir, req.ConfigPath)
+	}
+	if _, err := os.Stat(req.ConfigPath); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("config_path not found: %v", err)})
+		return
+	}
+	labels, err := control.NormalizeLabels(req.Labels)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+	if !ok {
+		return
+	}
+	sc, err := s.scheduler.Update(id, control.ScheduleOptions{
+		ConfigPath:    req.ConfigPath,
+		Priority:      req.Priority,
+		ExecutionCost: req.ExecutionCost,
+		Host:          req.Host,
+		Cluster:       req.Cluster,
+		Environment:   req.Environment,
+		Labels:        labels,
+		Owner:         owner,
+		Team:          team,
+		Interval:      time.Duration(req.IntervalSeconds) * time.Second,
+		Jitter:        time.Duration(req.JitterSeconds) * time.Second,
+	}, req.Enabled)
+	if err != nil {
+		writeEntityUpdateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sc)
+}
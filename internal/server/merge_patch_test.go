@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergePatchEndpoints(t *testing.T) {
+	tmp := t.TempDir()
+	for _, name := range []string{"c.yaml", "d.yaml"} {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	created := func(path, body string) string {
+		rr := do(http.MethodPost, path, body)
+		if rr.Code >= 300 {
+			t.Fatalf("create %s failed: code=%d body=%s", path, rr.Code, rr.Body.String())
+		}
+		var out struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &out)
+		return out.ID
+	}
+	templateID := created("/v1/templates", `{"name":"web","description":"keep me","config_path":"c.yaml","defaults":{"region":"us","tier":"web"}}`)
+	ruleID := created("/v1/rules", `{"name":"on-drift","source_prefix":"drift.","actions":[{"type":"enqueue_apply","config_path":"c.yaml"}]}`)
+	webhookID := created("/v1/webhooks", `{"name":"ops","url":"https://example.test/hook","event_prefix":"job."}`)
+	targetID := created("/v1/notifications/targets", `{"name":"pager","kind":"incident","url":"https://example.test/p","route":"pager","enabled":true}`)
+	scheduleID := created("/v1/schedules", `{"config_path":"c.yaml","interval_seconds":3600}`)
+
+	rr := do(http.MethodPatch, "/v1/templates/"+templateID, `{"name":"web-v2","defaults":{"tier":null}}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"web-v2"`) || !strings.Contains(rr.Body.String(), `"description":"keep me"`) ||
+		!strings.Contains(rr.Body.String(), `"defaults":{"region":"us"}`) || rr.Header().Get("ETag") != `"v2"` {
+		t.Fatalf("patch template failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/templates/"+templateID, `{"name":"stale"}`, "If-Match", `"v1"`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected stale template patch to conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/templates/"+templateID, `{"id":"tpl-99"}`); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `unknown field \"id\"`) {
+		t.Fatalf("expected read-only field to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/templates/"+templateID, `{"config_path":"missing.yaml"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected merged template to be validated: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/templates/"+templateID, `{"name":"x"}`, "Content-Type", "text/plain"); rr.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected unsupported media type: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPatch, "/v1/rules/"+ruleID, `{"cooldown_seconds":30}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"cooldown_seconds":30`) || !strings.Contains(rr.Body.String(), `"name":"on-drift"`) {
+		t.Fatalf("patch rule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v1/rules/"+ruleID+"/versions", ""); !strings.Contains(rr.Body.String(), `"action":"patched"`) {
+		t.Fatalf("expected patched rule revision: %s", rr.Body.String())
+	}
+
+	rr = do(http.MethodPatch, "/v1/webhooks/"+webhookID, `{"enabled":false}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"enabled":false`) || !strings.Contains(rr.Body.String(), `"url":"https://example.test/hook"`) {
+		t.Fatalf("patch webhook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/webhooks/"+webhookID, `{"url":"ftp://nope"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid webhook url to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPatch, "/v1/notifications/targets/"+targetID, `{"route":"ticket"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"route":"ticket"`) || !strings.Contains(rr.Body.String(), `"name":"pager"`) {
+		t.Fatalf("patch notification target failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPatch, "/v1/schedules/"+scheduleID, `{"config_path":"d.yaml","priority":"high"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `d.yaml`) || !strings.Contains(rr.Body.String(), `"priority":"high"`) || !strings.Contains(rr.Body.String(), `"interval":3600000000000`) {
+		t.Fatalf("patch schedule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/schedules/"+scheduleID, `{"interval_seconds":0}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected zero interval to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
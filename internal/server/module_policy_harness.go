@@ -51,6 +51,11 @@ func (s *Server) handleModulePolicyHarnessRuns(w http.ResponseWriter, r *http.Re
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		outcome := "pass"
+		if run.Status == "failed" {
+			outcome = "fail"
+		}
+		s.observeTestOutcomes("harness", "harness/"+run.Kind, run.ID, map[string]string{run.CaseID: outcome})
 		if run.Status == "failed" {
 			writeJSON(w, http.StatusConflict, run)
 			return
@@ -1,10 +1,18 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -124,3 +132,241 @@ func (s *Server) handleMultiMasterCache(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
+
+// handleMultiMasterReplication serves the replication protocol between
+// masters under /v1/control/multi-master/replication/:
+//
+//	GET  status                   sequence, peer lag, conflict and invalidation counters
+//	POST policies                 per-store conflict policy (lww|vector_clock)
+//	POST writes                   version a local entity update for gossip
+//	GET  gossip?peer=&since=      pull updates after since (acknowledges since)
+//	POST gossip                   push a peer's ReplicationMessage
+//	POST invalidations            broadcast a central cache invalidation
+//	GET  conflicts?store=&limit=  resolved conflicts, newest first
+//	GET  digest                   per-entity version hashes
+//	POST reconcile                compare with a peer's digest (peer or digest)
+//	POST sync                     push-pull gossip round with a peer node
+func (s *Server) handleMultiMasterReplication(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 5 || parts[3] != "replication" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch action := parts[4]; {
+	case action == "status" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.multiMaster.ReplicationStatus())
+	case action == "policies" && r.Method == http.MethodPost:
+		var req struct {
+			Store  string `json:"store"`
+			Policy string `json:"policy"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := s.multiMaster.SetConflictPolicy(req.Store, req.Policy); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.multiMaster.ReplicationStatus().Policies)
+	case action == "writes" && r.Method == http.MethodPost:
+		var req control.ReplicatedWriteInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.multiMaster.LocalWrite(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case action == "gossip" && r.Method == http.MethodGet:
+		since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+		writeJSON(w, http.StatusOK, s.multiMaster.Gossip(r.URL.Query().Get("peer"), since))
+	case action == "gossip" && r.Method == http.MethodPost:
+		var msg control.ReplicationMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.applyReplicationGossip(msg))
+	case action == "invalidations" && r.Method == http.MethodPost:
+		var req struct {
+			Kind  string `json:"kind"`
+			RefID string `json:"ref_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		inv, evicted, err := s.multiMaster.Invalidate(req.Kind, req.RefID)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"invalidation": inv, "evicted": evicted})
+	case action == "conflicts" && r.Method == http.MethodGet:
+		limit := 100
+		if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+			limit = n
+		}
+		items := s.multiMaster.ListConflicts(r.URL.Query().Get("store"), limit)
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case action == "digest" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"node_id": s.multiMaster.LocalNode(), "entities": s.multiMaster.ReplicationDigest()})
+	case action == "reconcile" && r.Method == http.MethodPost:
+		var req struct {
+			Peer   string            `json:"peer"`
+			Digest map[string]string `json:"digest"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		remote := req.Digest
+		if remote == nil {
+			if strings.TrimSpace(req.Peer) == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "peer or digest is required"})
+				return
+			}
+			var out struct {
+				Entities map[string]string `json:"entities"`
+			}
+			if err := s.multiMasterPeerRequest(r.Context(), req.Peer, http.MethodGet, "/digest", nil, &out); err != nil {
+				writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+				return
+			}
+			remote = out.Entities
+		}
+		writeJSON(w, http.StatusOK, s.multiMaster.Reconcile(req.Peer, remote))
+	case action == "sync" && r.Method == http.MethodPost:
+		var req struct {
+			Peer string `json:"peer"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		res, err := s.gossipWithMaster(r.Context(), req.Peer)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) applyReplicationGossip(msg control.ReplicationMessage) control.ReplicationApplyResult {
+	res := s.multiMaster.ApplyGossip(msg)
+	if res.Conflicts > 0 {
+		s.recordEvent(control.Event{
+			Type:    "control.multi_master.replication.conflict",
+			Message: "concurrent multi-master updates resolved",
+			Fields: map[string]any{
+				"from":      res.From,
+				"conflicts": res.Conflicts,
+			},
+		}, true)
+	}
+	return res
+}
+
+// gossipWithMaster runs one push-pull round with peer: pull the updates we
+// have not seen, then push the ones it has not acknowledged.
+func (s *Server) gossipWithMaster(ctx context.Context, peer string) (map[string]any, error) {
+	local := s.multiMaster.LocalNode()
+	acked, received := s.multiMaster.PeerCursors(peer)
+	var pulled control.ReplicationMessage
+	query := "/gossip?peer=" + url.QueryEscape(local) + "&since=" + strconv.FormatInt(received, 10)
+	if err := s.multiMasterPeerRequest(ctx, peer, http.MethodGet, query, nil, &pulled); err != nil {
+		return nil, err
+	}
+	pullResult := s.applyReplicationGossip(pulled)
+	push := s.multiMaster.Gossip("", acked)
+	var pushResult control.ReplicationApplyResult
+	if err := s.multiMasterPeerRequest(ctx, peer, http.MethodPost, "/gossip", push, &pushResult); err != nil {
+		return nil, err
+	}
+	s.multiMaster.AckPeer(peer, push.Seq)
+	return map[string]any{"peer": peer, "pulled": pullResult, "pushed": pushResult}, nil
+}
+
+func (s *Server) multiMasterPeerRequest(ctx context.Context, peer, method, path string, body, out any) error {
+	node, ok := s.multiMaster.GetNode(peer)
+	if !ok {
+		return errors.New("multi-master node not found")
+	}
+	base := strings.TrimRight(strings.TrimSpace(node.Address), "/")
+	if base == "" {
+		return errors.New("multi-master node has no address")
+	}
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+"/v1/control/multi-master/replication"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.federationToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.federationToken)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("multi-master peer %s returned status %d: %s", peer, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// sweepMultiMasterGossip gossips with every active peer that has an address.
+func (s *Server) sweepMultiMasterGossip(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			local := s.multiMaster.LocalNode()
+			for _, node := range s.multiMaster.ListNodes() {
+				if node.NodeID == local || node.Status != "active" || strings.TrimSpace(node.Address) == "" {
+					continue
+				}
+				if _, err := s.gossipWithMaster(ctx, node.NodeID); err != nil {
+					s.recordEvent(control.Event{
+						Type:    "control.multi_master.replication.gossip_failed",
+						Message: "multi-master gossip round failed",
+						Fields:  map[string]any{"peer": node.NodeID, "error": err.Error()},
+					}, true)
+				}
+			}
+		}
+	}
+}
+
+// localMasterNodeID names this master for replication: MC_MASTER_NODE_ID,
+// else the hostname.
+func localMasterNodeID() string {
+	if id := strings.TrimSpace(os.Getenv("MC_MASTER_NODE_ID")); id != "" {
+		return id
+	}
+	host, _ := os.Hostname()
+	return host
+}
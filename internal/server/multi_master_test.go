@@ -90,3 +90,53 @@ resources:
 		t.Fatalf("expected job cache entries: %s", rr.Body.String())
 	}
 }
+
+func TestMultiMasterReplicationGossipBetweenMasters(t *testing.T) {
+	t.Setenv("MC_MULTI_MASTER_GOSSIP_SECONDS", "3600")
+	t.Setenv("MC_MASTER_NODE_ID", "cp-a")
+	a := New(":0", t.TempDir())
+	t.Setenv("MC_MASTER_NODE_ID", "cp-b")
+	b := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = a.Shutdown(context.Background())
+		_ = b.Shutdown(context.Background())
+	})
+	peerB := httptest.NewServer(b.httpServer.Handler)
+	defer peerB.Close()
+	do := func(s *Server, method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := do(a, http.MethodPost, "/v1/control/multi-master/nodes", `{"node_id":"cp-b","address":"`+peerB.URL+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("register peer failed: %s", rr.Body.String())
+	}
+	if rr := do(a, http.MethodPost, "/v1/control/multi-master/replication/writes", `{"store":"templates","key":"tpl-1","payload":{"name":"web"}}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"cp-a":1`) {
+		t.Fatalf("local write failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(b, http.MethodPost, "/v1/control/multi-master/replication/writes", `{"store":"runbooks","key":"rb-1","payload":{"name":"restart"}}`); rr.Code != http.StatusOK {
+		t.Fatalf("peer write failed: %s", rr.Body.String())
+	}
+
+	rr := do(a, http.MethodPost, "/v1/control/multi-master/replication/reconcile", `{"peer":"cp-b"}`)
+	if !strings.Contains(rr.Body.String(), `"divergence_percent":100`) {
+		t.Fatalf("expected full divergence before gossip, body=%s", rr.Body.String())
+	}
+	rr = do(a, http.MethodPost, "/v1/control/multi-master/replication/sync", `{"peer":"cp-b"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"pulled":{"from":"cp-b","applied":1`) || !strings.Contains(rr.Body.String(), `"pushed":{"from":"cp-a","applied":1`) {
+		t.Fatalf("expected push-pull gossip round: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(a, http.MethodPost, "/v1/control/multi-master/replication/reconcile", `{"peer":"cp-b"}`)
+	if !strings.Contains(rr.Body.String(), `"in_sync":2`) || !strings.Contains(rr.Body.String(), `"divergence_percent":0`) {
+		t.Fatalf("expected masters in sync after gossip, body=%s", rr.Body.String())
+	}
+
+	rr = do(a, http.MethodGet, "/v1/control/multi-master/replication/status", "")
+	if !strings.Contains(rr.Body.String(), `"local_node":"cp-a"`) || !strings.Contains(rr.Body.String(), `"lag":0`) {
+		t.Fatalf("expected peer caught up in status, body=%s", rr.Body.String())
+	}
+	if rr := do(a, http.MethodPost, "/v1/control/multi-master/replication/policies", `{"store":"templates","policy":"quorum"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid policy rejected, got %d", rr.Code)
+	}
+}
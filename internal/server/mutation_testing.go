@@ -85,8 +85,8 @@ func (s *Server) handleMutationRuns(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleMutationRunAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/release/tests/mutation/runs/{id}
-	if len(parts) != 6 || !strings.EqualFold(parts[0], "v1") || !strings.EqualFold(parts[1], "release") || !strings.EqualFold(parts[2], "tests") || !strings.EqualFold(parts[3], "mutation") || !strings.EqualFold(parts[4], "runs") {
+	// /v1/release/tests/mutation/runs/{id}[/diagnostics]
+	if len(parts) < 6 || len(parts) > 7 || !strings.EqualFold(parts[0], "v1") || !strings.EqualFold(parts[1], "release") || !strings.EqualFold(parts[2], "tests") || !strings.EqualFold(parts[3], "mutation") || !strings.EqualFold(parts[4], "runs") {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mutation run path"})
 		return
 	}
@@ -99,5 +99,54 @@ func (s *Server) handleMutationRunAction(w http.ResponseWriter, r *http.Request)
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusOK, item)
+	if len(parts) == 6 {
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
+	if !strings.EqualFold(parts[6], "diagnostics") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown mutation run action"})
+		return
+	}
+	if item.DiagnosticsKey == "" || s.objectStore == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "mutation run has no stored diagnostics"})
+		return
+	}
+	data, _, err := s.objectStore.Get(item.DiagnosticsKey)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (s *Server) handleMutationExecutions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.MutationRunInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	item, _, err := s.mutationExecutor.Execute(req)
+	if err != nil && item.ID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// The run is recorded; only persisting its diagnostics failed.
+		s.recordEvent(control.Event{
+			Type:    "release.tests.mutation.diagnostics_failed",
+			Message: "mutation diagnostics could not be stored",
+			Fields:  map[string]any{"run_id": item.ID, "error": err.Error()},
+		}, true)
+	}
+	code := http.StatusOK
+	if strings.EqualFold(item.Status, "fail") {
+		code = http.StatusConflict
+	}
+	writeJSON(w, code, item)
 }
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -84,3 +85,83 @@ func TestMutationTestingEndpoints(t *testing.T) {
 		t.Fatalf("get mutation run failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestMutationExecutionEndpoints(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "policy.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: motd
+    type: file
+    host: localhost
+    path: /etc/motd
+    content: "authorized use only\n"
+    mode: "0644"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("/v1/release/tests/harness/cases", `{"id":"motd-policy","name":"motd policy","kind":"policy","assertions":[{"field":"resource.motd.present","expected":"true"},{"field":"resource.motd.mode","expected":"0644"},{"field":"resource.motd.path","expected":"/etc/motd"},{"field":"resource.motd.content","expected":"authorized use only"}]}`); rr.Code != http.StatusOK {
+		t.Fatalf("upsert harness case failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/release/tests/mutation/policy", `{"min_kill_rate":0.9,"min_mutants_covered":3}`); rr.Code != http.StatusOK {
+		t.Fatalf("set mutation policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/release/tests/mutation/suites", `{"id":"mutation-motd","provider":"motd","name":"motd policy pack","critical_paths":["motd/banner"],"config_path":"policy.yaml","harness_case_ids":["motd-policy"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("upsert mutation suite failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := post("/v1/release/tests/mutation/executions", `{"suite_id":"mutation-motd","triggered_by":"ci"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("execute mutation suite failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var run struct {
+		ID             string  `json:"id"`
+		Mode           string  `json:"mode"`
+		KillRate       float64 `json:"kill_rate"`
+		DiagnosticsKey string  `json:"diagnostics_key"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &run); err != nil {
+		t.Fatalf("decode mutation run failed: %v", err)
+	}
+	if run.Mode != "executed" || run.KillRate != 1 || run.DiagnosticsKey == "" {
+		t.Fatalf("unexpected executed mutation run: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/release/tests/mutation/runs/"+run.ID+"/diagnostics", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get mutation diagnostics failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var diagnostics struct {
+		Mutants []struct {
+			Target string `json:"target"`
+			Killed bool   `json:"killed"`
+		} `json:"mutants"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &diagnostics); err != nil || len(diagnostics.Mutants) == 0 {
+		t.Fatalf("expected per-mutant diagnostics, err=%v body=%s", err, rr.Body.String())
+	}
+
+	if rr := post("/v1/release/tests/mutation/policy", `{"min_kill_rate":0.9,"min_mutants_covered":50}`); rr.Code != http.StatusOK {
+		t.Fatalf("tighten mutation policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/release/readiness/scorecards", `{"environment":"prod","service":"provider/motd","signals":{"quality_score":1,"reliability_score":1,"performance_score":1,"test_pass_rate":1}}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "mutation suite mutation-motd") {
+		t.Fatalf("expected mutation policy to block readiness: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
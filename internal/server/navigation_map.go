@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 type navigationWorkflow struct {
@@ -11,6 +13,16 @@ type navigationWorkflow struct {
 	ShortcutIDs      []string `json:"shortcut_ids"`
 	NoMouseSupported bool     `json:"no_mouse_supported"`
 	Description      string   `json:"description,omitempty"`
+	// Keyboard-only profiles get the keystroke sequence for workflows that
+	// support it, and a pointer warning for those that do not.
+	KeyboardFlow    []navigationStep `json:"keyboard_flow,omitempty"`
+	RequiresPointer bool             `json:"requires_pointer,omitempty"`
+}
+
+type navigationStep struct {
+	ShortcutID string `json:"shortcut_id"`
+	Title      string `json:"title"`
+	Keystroke  string `json:"keystroke"`
 }
 
 func (s *Server) handleUINavigationMap(w http.ResponseWriter, r *http.Request) {
@@ -18,11 +30,16 @@ func (s *Server) handleUINavigationMap(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	profile, ok := s.requestAccessibilityProfile(w, r)
+	if !ok {
+		return
+	}
+	hints := profile.VariantHints()
 	workflowFilter := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("workflow")))
 	shortcuts := s.shortcuts.List()
-	shortcutIndex := map[string]struct{}{}
+	shortcutIndex := map[string]control.UIShortcut{}
 	for _, item := range shortcuts {
-		shortcutIndex[item.ID] = struct{}{}
+		shortcutIndex[item.ID] = item
 	}
 
 	workflows := []navigationWorkflow{
@@ -70,6 +87,16 @@ func (s *Server) handleUINavigationMap(w http.ResponseWriter, r *http.Request) {
 				break
 			}
 		}
+		if hints.Interaction == "keyboard-only" {
+			if wf.NoMouseSupported {
+				for _, id := range wf.ShortcutIDs {
+					item := shortcutIndex[id]
+					wf.KeyboardFlow = append(wf.KeyboardFlow, navigationStep{ShortcutID: id, Title: item.Title, Keystroke: item.Keystroke})
+				}
+			} else {
+				wf.RequiresPointer = true
+			}
+		}
 		filtered = append(filtered, wf)
 	}
 	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Workflow < filtered[j].Workflow })
@@ -89,6 +116,7 @@ func (s *Server) handleUINavigationMap(w http.ResponseWriter, r *http.Request) {
 		"workflows":                 filtered,
 		"count":                     len(filtered),
 		"no_mouse_coverage_percent": coverage,
-		"active_profile":            s.accessibility.ActiveProfile(),
+		"active_profile":            profile,
+		"variant":                   hints,
 	})
 }
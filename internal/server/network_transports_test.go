@@ -59,7 +59,7 @@ func TestNetworkTransportEndpoints(t *testing.T) {
 		t.Fatalf("expected supported=true in validation response: %s", rr.Body.String())
 	}
 
-	unsupported := []byte(`{"transport":"snmp"}`)
+	unsupported := []byte(`{"transport":"telnet"}`)
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/execution/network-transports/validate", bytes.NewReader(unsupported))
 	s.httpServer.Handler.ServeHTTP(rr, req)
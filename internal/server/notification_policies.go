@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleNotificationPolicies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.notifications.ListPolicies()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+	case http.MethodPost:
+		var req control.NotificationPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.notifications.CreatePolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordNotificationPolicyEvent("notification.policy.created", policy)
+		writeJSON(w, http.StatusCreated, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleNotificationPolicyAction(w http.ResponseWriter, r *http.Request) {
+	// /v1/notifications/policies/{id} or /v1/notifications/policies/simulate
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 4 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown notification policy path"})
+		return
+	}
+	if parts[3] == "simulate" {
+		s.handleNotificationPolicySimulate(w, r)
+		return
+	}
+	id := parts[3]
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := s.notifications.GetPolicy(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, policy)
+	case http.MethodPut:
+		if _, err := s.notifications.GetPolicy(id); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		var req control.NotificationPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.notifications.UpdatePolicy(id, req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordNotificationPolicyEvent("notification.policy.updated", policy)
+		writeJSON(w, http.StatusOK, policy)
+	case http.MethodDelete:
+		if err := s.notifications.DeletePolicy(id); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordNotificationPolicyEvent("notification.policy.deleted", control.NotificationPolicy{ID: id})
+		writeJSON(w, http.StatusOK, map[string]any{"deleted": true, "id": id})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleNotificationPolicySimulate shows where an alert would be routed at
+// a given time (now by default) without delivering anything.
+func (s *Server) handleNotificationPolicySimulate(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		EventType string         `json:"event_type"`
+		Severity  string         `json:"severity"`
+		Route     string         `json:"route"`
+		Fields    map[string]any `json:"fields"`
+		At        time.Time      `json:"at"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req reqBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if strings.TrimSpace(req.EventType) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "event_type is required"})
+		return
+	}
+	if req.At.IsZero() {
+		req.At = time.Now().UTC()
+	}
+	alert := control.AlertItem{
+		ID:        "alert-simulated",
+		EventType: req.EventType,
+		Severity:  req.Severity,
+		Route:     req.Route,
+		Fields:    req.Fields,
+	}
+	writeJSON(w, http.StatusOK, s.notifications.Plan(alert, req.At))
+}
+
+func (s *Server) handleNotificationEscalations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	items := s.notifications.Escalations()
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+}
+
+func (s *Server) recordNotificationPolicyEvent(eventType string, policy control.NotificationPolicy) {
+	s.recordEvent(control.Event{
+		Type:    eventType,
+		Message: "notification routing policy changed",
+		Fields: map[string]any{
+			"policy_id": policy.ID,
+			"name":      policy.Name,
+		},
+	}, true)
+}
+
+func (s *Server) sweepNotificationEscalations(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runNotificationEscalations(time.Now().UTC())
+		}
+	}
+}
+
+// runNotificationEscalations fires due escalation steps for alerts that are
+// still open; acknowledging or resolving an alert ends its chain.
+func (s *Server) runNotificationEscalations(now time.Time) []control.NotificationDelivery {
+	return s.notifications.RunEscalations(now, func(alertID string) bool {
+		alert, err := s.alerts.Get(alertID)
+		return err == nil && alert.Status == control.AlertOpen
+	})
+}
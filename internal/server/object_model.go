@@ -1,8 +1,13 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func (s *Server) handleObjectModel(w http.ResponseWriter, r *http.Request) {
@@ -37,3 +42,100 @@ func (s *Server) handleObjectModelResolve(w http.ResponseWriter, r *http.Request
 		"match": entry,
 	})
 }
+
+func (s *Server) handleObjectDependencies(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.objectModel.DependencyGraph())
+		case http.MethodPost:
+			var req struct {
+				ConfigPaths []string `json:"config_paths"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			if len(req.ConfigPaths) == 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_paths is required"})
+				return
+			}
+			for _, path := range req.ConfigPaths {
+				if err := s.objectModel.IndexConfig(resolveObjectPath(baseDir, path)); err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+					return
+				}
+			}
+			writeJSON(w, http.StatusOK, s.objectModel.DependencyGraph())
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *Server) handleObjectDependencyImpact(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		kind := strings.TrimSpace(r.URL.Query().Get("kind"))
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if kind == control.ObjectKindConfig || kind == control.ObjectKindModule {
+			name = resolveObjectPath(baseDir, name)
+		}
+		report, err := s.objectModel.Impact(kind, name)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// changeImpact merges the impact of changed files and objects into the
+// configs and templates that need re-testing or re-previewing.
+func (s *Server) changeImpact(baseDir string, files []string, objects []control.ObjectDependencyNode) (configs, templates []string, err error) {
+	configSet := map[string]struct{}{}
+	templateSet := map[string]struct{}{}
+	for _, path := range files {
+		if strings.TrimSpace(path) != "" {
+			objects = append(objects, control.ObjectDependencyNode{Kind: control.ObjectKindConfig, Name: path})
+		}
+	}
+	for _, obj := range objects {
+		name := obj.Name
+		if obj.Kind == control.ObjectKindConfig || obj.Kind == control.ObjectKindModule {
+			name = resolveObjectPath(baseDir, name)
+		}
+		report, err := s.objectModel.Impact(obj.Kind, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, cfg := range report.AffectedConfigs {
+			configSet[cfg] = struct{}{}
+		}
+		for _, id := range report.AffectedTemplates {
+			templateSet[id] = struct{}{}
+		}
+	}
+	configs = make([]string, 0, len(configSet))
+	for cfg := range configSet {
+		configs = append(configs, cfg)
+	}
+	templates = make([]string, 0, len(templateSet))
+	for id := range templateSet {
+		templates = append(templates, id)
+	}
+	sort.Strings(configs)
+	sort.Strings(templates)
+	return configs, templates, nil
+}
+
+func resolveObjectPath(baseDir, path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
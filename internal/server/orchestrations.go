@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/config"
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// handleOrchestrations serves the orchestrate runner:
+//
+//	POST /v1/orchestrations/plan    resolve each phase's target hosts
+//	POST /v1/orchestrations/launch  run the phases on the workflow engine
+//	GET  /v1/orchestrations/runs    orchestration runs (also under /v1/workflow-runs)
+func (s *Server) handleOrchestrations(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 3 || parts[0] != "v1" || parts[1] != "orchestrations" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch parts[2] {
+	case "runs":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		out := make([]control.WorkflowRun, 0)
+		for _, run := range s.workflows.ListRuns() {
+			if run.Orchestration == "" || (name != "" && run.Orchestration != name) {
+				continue
+			}
+			out = append(out, run)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(out), "items": out})
+	case "plan", "launch":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			ConfigPath string `json:"config_path"`
+			Priority   string `json:"priority"`
+			Force      bool   `json:"force"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		configPath := strings.TrimSpace(req.ConfigPath)
+		if configPath == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
+			return
+		}
+		if !filepath.IsAbs(configPath) {
+			configPath = filepath.Join(s.baseDir, configPath)
+		}
+		orch, err := config.LoadOrchestration(configPath)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if parts[2] == "plan" {
+			phases, err := control.PrepareOrchestration(orch, "")
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"name": orch.Name, "source": configPath, "phases": phases})
+			return
+		}
+		dir := filepath.Join(s.baseDir, ".masterchef", "orchestrations", strconv.FormatInt(time.Now().UTC().UnixNano(), 10))
+		phases, err := control.PrepareOrchestration(orch, dir)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		force := req.Force || strings.ToLower(r.Header.Get("X-Force-Apply")) == "true"
+		run, err := s.workflows.LaunchOrchestration(control.OrchestrationLaunch{
+			Name:     orch.Name,
+			Source:   configPath,
+			Phases:   phases,
+			Priority: req.Priority,
+			Force:    force,
+		})
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.events.Append(control.Event{
+			Type:    "orchestration.launched",
+			Message: "orchestration " + orch.Name + " launch started",
+			Fields: map[string]any{
+				"orchestration": orch.Name,
+				"run_id":        run.ID,
+				"phases":        len(phases),
+			},
+		})
+		writeJSON(w, http.StatusAccepted, run)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestOrchestrationPlanAndLaunch(t *testing.T) {
+	tmp := t.TempDir()
+	hosts := `version: v0
+inventory:
+  hosts:
+    - name: web-a
+      transport: local
+      labels: {lb: blue}
+    - name: web-b
+      transport: local
+resources:
+  - id: mark-a
+    type: file
+    host: web-a
+    path: ` + filepath.Join(tmp, "PHASE-web-a") + `
+    content: "x"
+  - id: mark-b
+    type: file
+    host: web-b
+    path: ` + filepath.Join(tmp, "PHASE-web-b") + `
+    content: "x"
+`
+	for _, phase := range []string{"drain", "upgrade"} {
+		if err := os.WriteFile(filepath.Join(tmp, phase+".yaml"), []byte(strings.ReplaceAll(hosts, "PHASE", phase)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "rolling.yaml"), []byte(`kind: orchestration
+name: rolling-web
+phases:
+  - name: drain-a
+    config: drain.yaml
+    target: label:lb=blue
+  - name: upgrade-b
+    config: upgrade.yaml
+    target: web-*,!web-a
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/orchestrations/plan", `{"config_path":"rolling.yaml"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"hosts":["web-a"]`) || !strings.Contains(rr.Body.String(), `"hosts":["web-b"]`) {
+		t.Fatalf("expected per-phase hosts in plan: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/orchestrations/plan", `{"config_path":"drain.yaml"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected per-host config rejected as orchestration, got code=%d", rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/v1/orchestrations/launch", `{"config_path":"rolling.yaml"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("launch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var run control.WorkflowRun
+	_ = json.Unmarshal(rr.Body.Bytes(), &run)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for run.Status != control.WorkflowSucceeded {
+		if run.Status == control.WorkflowFailed || time.Now().After(deadline) {
+			t.Fatalf("expected orchestration to succeed, got %+v", run)
+		}
+		time.Sleep(20 * time.Millisecond)
+		rr = do(http.MethodGet, "/v1/workflow-runs/"+run.ID, "")
+		_ = json.Unmarshal(rr.Body.Bytes(), &run)
+	}
+	for name, want := range map[string]bool{"drain-web-a": true, "drain-web-b": false, "upgrade-web-a": false, "upgrade-web-b": true} {
+		if _, err := os.Stat(filepath.Join(tmp, name)); (err == nil) != want {
+			t.Fatalf("expected %s applied=%v", name, want)
+		}
+	}
+
+	rr = do(http.MethodGet, "/v1/orchestrations/runs?name=rolling-web", "")
+	if !strings.Contains(rr.Body.String(), `"count":1`) || !strings.Contains(rr.Body.String(), run.ID) {
+		t.Fatalf("expected orchestration run listed, body=%s", rr.Body.String())
+	}
+}
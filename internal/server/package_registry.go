@@ -1,11 +1,16 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/storage"
 )
 
 func (s *Server) handlePackageArtifacts(w http.ResponseWriter, r *http.Request) {
@@ -14,16 +19,50 @@ func (s *Server) handlePackageArtifacts(w http.ResponseWriter, r *http.Request)
 		visibility := strings.TrimSpace(r.URL.Query().Get("visibility"))
 		writeJSON(w, http.StatusOK, s.packageRegistry.ListArtifactsByVisibility(visibility))
 	case http.MethodPost:
-		var req control.PackageArtifactInput
+		var req struct {
+			control.PackageArtifactInput
+			Tarball string `json:"tarball,omitempty"` // base64 module archive
+		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		item, err := s.packageRegistry.Publish(req)
+		input := req.PackageArtifactInput
+		if req.Tarball != "" {
+			data, err := base64.StdEncoding.DecodeString(req.Tarball)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "tarball must be base64 encoded"})
+				return
+			}
+			sum := sha256.Sum256(data)
+			digest := "sha256:" + hex.EncodeToString(sum[:])
+			if input.Digest != "" && !strings.EqualFold(strings.TrimSpace(input.Digest), digest) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "digest does not match tarball (computed " + digest + ")"})
+				return
+			}
+			key, err := storePackageTarball(s.objectStore, data)
+			if err != nil {
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+				return
+			}
+			input.Digest = digest
+			input.ObjectKey = key
+			input.SizeBytes = int64(len(data))
+		}
+		item, err := s.packageRegistry.Publish(input)
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		if item.Provenance.Builder != "" && item.Provenance.AttestationDigest == "" {
+			// Attest at publish time so admission can verify the build.
+			if att, err := s.provenanceAttestations.AttestPackage(item); err == nil {
+				item.Provenance.AttestationDigest = att.Digest
+			}
+		}
+		if item.ObjectKey != "" {
+			_, _ = s.artifactReplication.Enqueue(item.ObjectKey, "package", item.Digest)
+		}
 		s.recordEvent(control.Event{
 			Type:    "packages.artifact.published",
 			Message: "module/provider package artifact published",
@@ -43,11 +82,15 @@ func (s *Server) handlePackageArtifacts(w http.ResponseWriter, r *http.Request)
 
 func (s *Server) handlePackageArtifactAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/packages/artifacts/{id}
-	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "packages" || parts[2] != "artifacts" {
+	// /v1/packages/artifacts/{id}[/download|/sbom|/scans]
+	if len(parts) < 4 || len(parts) > 5 || parts[0] != "v1" || parts[1] != "packages" || parts[2] != "artifacts" {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if len(parts) == 5 && (parts[4] == "sbom" || parts[4] == "scans") {
+		s.handleArtifactScanAction(w, r, control.ScanArtifactPackage, parts[3], parts[4])
+		return
+	}
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
@@ -57,7 +100,51 @@ func (s *Server) handlePackageArtifactAction(w http.ResponseWriter, r *http.Requ
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "package artifact not found"})
 		return
 	}
-	writeJSON(w, http.StatusOK, item)
+	if len(parts) == 4 {
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
+	if parts[4] != "download" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if item.ObjectKey == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "package artifact has no stored tarball"})
+		return
+	}
+	if s.objectStore == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+		return
+	}
+	// Agents name their region so a verified local replica is preferred
+	// over a cross-region read from the primary store.
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		region = r.Header.Get("X-Masterchef-Region")
+	}
+	data, replica, local := s.fetchLocalReplica(item.ObjectKey, item.Digest, region)
+	if !local {
+		var err error
+		data, _, err = s.objectStore.Get(item.ObjectKey)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		replica = "primary"
+	}
+	sum := sha256.Sum256(data)
+	if "sha256:"+hex.EncodeToString(sum[:]) != item.Digest {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "stored tarball failed checksum verification"})
+		return
+	}
+	filename := strings.ReplaceAll(item.Name, "/", "-") + "-" + item.Version + ".tgz"
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	w.Header().Set("X-Checksum-Sha256", hex.EncodeToString(sum[:]))
+	w.Header().Set("X-Masterchef-Replica", replica)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
 }
 
 func (s *Server) handlePackageSigningPolicy(w http.ResponseWriter, r *http.Request) {
@@ -280,3 +367,78 @@ func (s *Server) handlePackageQualityEvaluate(w http.ResponseWriter, r *http.Req
 	}
 	writeJSON(w, http.StatusOK, report)
 }
+
+func (s *Server) handlePackageVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	kind := strings.TrimSpace(r.URL.Query().Get("kind"))
+	if kind == "" {
+		kind = "module"
+	}
+	writeJSON(w, http.StatusOK, s.packageRegistry.ListVersions(kind, name))
+}
+
+// handlePackageResolve solves a module set for the requested constraints.
+// Naming an environment folds its version_constraints in as pins, so every
+// resolution for that environment lands on the same reproducible set.
+func (s *Server) handlePackageResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.PackageResolveInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if len(req.Requirements) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "requirements are required"})
+		return
+	}
+	if env := strings.TrimSpace(req.Environment); env != "" {
+		def, err := s.roleEnv.GetEnvironment(env)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		pins := map[string]string{}
+		for name, constraint := range def.VersionConstraints {
+			pins[name] = constraint
+		}
+		for name, constraint := range req.Pins {
+			if existing, ok := pins[strings.ToLower(strings.TrimSpace(name))]; ok {
+				constraint = existing + ", " + constraint
+			}
+			pins[strings.ToLower(strings.TrimSpace(name))] = constraint
+		}
+		req.Pins = pins
+	}
+	resolution, err := s.packageRegistry.Resolve(req)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resolution)
+}
+
+// storePackageTarball writes a module archive under a content-addressed
+// key, so a rejected publish cannot clobber a tarball another version
+// already points at.
+func storePackageTarball(store storage.ObjectStore, data []byte) (string, error) {
+	if store == nil {
+		return "", errors.New("object store unavailable")
+	}
+	sum := sha256.Sum256(data)
+	obj, err := store.Put("packages/blobs/sha256/"+hex.EncodeToString(sum[:])+".tgz", data, "application/gzip")
+	if err != nil {
+		return "", err
+	}
+	return obj.Key, nil
+}
@@ -3,11 +3,15 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -237,3 +241,78 @@ resources:
 		t.Fatalf("package artifacts visibility filter failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestPackageRegistryTarballDownloadAndResolve(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tarball := []byte("fake module archive")
+	sum := sha256.Sum256(tarball)
+	encoded := base64.StdEncoding.EncodeToString(tarball)
+	rr := post("/v1/packages/artifacts", `{"kind":"module","name":"core/base","version":"1.0.0","tarball":"`+encoded+`"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("publish tarball failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var base struct {
+		ID     string `json:"id"`
+		Digest string `json:"digest"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &base)
+	if base.Digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected digest computed from tarball, got %q", base.Digest)
+	}
+	if rr := post("/v1/packages/artifacts", `{"kind":"module","name":"core/base","version":"1.1.0","digest":"sha256:`+strings.Repeat("0", 64)+`","tarball":"`+encoded+`"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected digest mismatch rejection: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/packages/artifacts", `{"kind":"module","name":"core/base","version":"1.5.0","digest":"sha256:`+strings.Repeat("1", 64)+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("publish base 1.5.0 failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/packages/artifacts", `{"kind":"module","name":"core/web","version":"2.0.0","digest":"sha256:`+strings.Repeat("2", 64)+`","dependencies":{"core/base":"~> 1.0"}}`); rr.Code != http.StatusCreated {
+		t.Fatalf("publish web failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/packages/artifacts/"+base.ID+"/download", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Equal(rr.Body.Bytes(), tarball) {
+		t.Fatalf("download failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Checksum-Sha256") != hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected checksum header, got %q", rr.Header().Get("X-Checksum-Sha256"))
+	}
+
+	rr = post("/v1/packages/resolve", `{"requirements":{"core/web":">= 2.0"}}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"version":"1.5.0"`) {
+		t.Fatalf("expected newest compatible base without pins: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/environments", `{"name":"prod","version_constraints":{"core/base":"= 1.0.0"}}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create environment failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/packages/resolve", `{"requirements":{"core/web":">= 2.0"},"environment":"prod"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("resolve with environment pins failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var resolution struct {
+		Packages []struct {
+			Name         string `json:"name"`
+			Version      string `json:"version"`
+			Downloadable bool   `json:"downloadable"`
+		} `json:"packages"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &resolution)
+	if len(resolution.Packages) != 2 || resolution.Packages[0].Version != "1.0.0" || !resolution.Packages[0].Downloadable {
+		t.Fatalf("expected environment pin to select base 1.0.0, got %+v", resolution.Packages)
+	}
+	if rr := post("/v1/packages/resolve", `{"requirements":{"core/web":">= 3.0"}}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected unsatisfiable resolution conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleSchedulerWorkers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.partitionDispatch.Workers())
+	case http.MethodPost:
+		var req control.PartitionWorkerInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.partitionDispatch.RegisterWorker(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.scheduler.worker.registered",
+			Message: "scheduler worker registered",
+			Fields: map[string]any{
+				"worker_id":  item.ID,
+				"partitions": item.Partitions,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSchedulerWorkerAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/scheduler/workers/{id}[/{action}]
+	if len(parts) < 5 || len(parts) > 6 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "scheduler" || parts[3] != "workers" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := parts[4]
+	if len(parts) == 5 {
+		switch r.Method {
+		case http.MethodGet:
+			item, ok := s.partitionDispatch.Get(id)
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "worker not registered"})
+				return
+			}
+			writeJSON(w, http.StatusOK, item)
+		case http.MethodDelete:
+			released, err := s.partitionDispatch.Deregister(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "control.scheduler.worker.deregistered",
+				Message: "scheduler worker deregistered",
+				Fields: map[string]any{
+					"worker_id": id,
+					"requeued":  len(released),
+				},
+			}, true)
+			writeJSON(w, http.StatusOK, map[string]any{"deregistered": id, "requeued": released})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[5] {
+	case "heartbeat":
+		item, err := s.partitionDispatch.Heartbeat(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case "claim":
+		job, ok, err := s.partitionDispatch.Claim(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case "complete":
+		var req struct {
+			JobID string `json:"job_id"`
+			Error string `json:"error,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		job, err := s.partitionDispatch.Complete(id, req.JobID, req.Error)
+		if err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleSchedulerPartitionLag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": s.partitionDispatch.Lag()})
+}
+
+func (s *Server) handleSchedulerRebalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	moved := s.partitionDispatch.Rebalance()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"rebalanced": moved,
+		"items":      s.partitionDispatch.Lag(),
+	})
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWebhookAndNotificationPayloadTemplatePreview(t *testing.T) {
+	tmp := t.TempDir()
+	features := filepath.Join(tmp, "features.md")
+	if err := os.WriteFile(features, []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/webhooks", bytes.NewReader([]byte(`{"name":"bad","url":"https://hooks.example.com","event_prefix":"job.","payload_template":"{{.type"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid template rejection, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	webhookBody := []byte(`{"name":"slack","url":"https://hooks.example.com","event_prefix":"job.","content_type":"json","payload_template":"{\"text\":{{quote .message}}}"}`)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/webhooks", bytes.NewReader(webhookBody))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("webhook create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var webhook struct {
+		ID          string `json:"id"`
+		ContentType string `json:"content_type"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &webhook); err != nil {
+		t.Fatalf("webhook decode failed: %v", err)
+	}
+	if webhook.ContentType != "application/json" {
+		t.Fatalf("expected normalized content type, got %q", webhook.ContentType)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/webhooks/"+webhook.ID+"/preview", bytes.NewReader([]byte(`{"type":"job.failed","message":"job failed"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("webhook preview failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var preview struct {
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("preview decode failed: %v", err)
+	}
+	if preview.Body != `{"text":"job failed"}` {
+		t.Fatalf("unexpected webhook preview body %q", preview.Body)
+	}
+
+	targetBody := []byte(`{"name":"pager","kind":"incident","url":"https://pager.example.com","route":"pager","enabled":true,"content_type":"text/plain","payload_template":"{{.alert.severity}}: {{.alert.message}}"}`)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/notifications/targets", bytes.NewReader(targetBody))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("notification target create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var target struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &target); err != nil {
+		t.Fatalf("notification target decode failed: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/notifications/targets/"+target.ID+"/preview", bytes.NewReader([]byte(`{"severity":"critical","message":"disk full","route":"pager"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("notification preview failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("preview decode failed: %v", err)
+	}
+	if preview.Body != "critical: disk full" {
+		t.Fatalf("unexpected notification preview body %q", preview.Body)
+	}
+}
@@ -38,7 +38,16 @@ func (s *Server) handlePerformanceProfiles(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// handlePerformanceDiagnostics diagnoses bottlenecks and links the most
+// recent profiles captured by the continuous profiler.
 func (s *Server) handlePerformanceDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"profiler": s.profiler.Config(),
+			"profiles": linkProfileCaptures(s.profiler.List(parseIntQuery(r, "limit", 10))),
+		})
+		return
+	}
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
@@ -53,5 +62,8 @@ func (s *Server) handlePerformanceDiagnostics(w http.ResponseWriter, r *http.Req
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusOK, diagnostics)
+	writeJSON(w, http.StatusOK, struct {
+		control.BottleneckDiagnostics
+		Profiles []linkedProfileCapture `json:"profiles"`
+	}{diagnostics, linkProfileCaptures(s.profiler.List(5))})
 }
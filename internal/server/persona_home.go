@@ -17,6 +17,9 @@ type homeCard struct {
 	Summary  string         `json:"summary"`
 	Severity string         `json:"severity,omitempty"` // info|warning|critical
 	Fields   map[string]any `json:"fields,omitempty"`
+
+	Pinned    bool `json:"pinned,omitempty"`
+	Mandatory bool `json:"mandatory,omitempty"`
 }
 
 func (s *Server) handlePersonaHome(baseDir string) http.HandlerFunc {
@@ -27,28 +30,75 @@ func (s *Server) handlePersonaHome(baseDir string) http.HandlerFunc {
 		}
 		persona := normalizePersona(r.URL.Query().Get("persona"))
 		if persona == "" {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "persona must be one of: sre, platform, release, service-owner"})
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": personaRequiredError})
 			return
 		}
+		// Authenticated principals get their saved layout and default
+		// filters; explicit query parameters still win over the defaults.
+		principal, _ := requestIdentity(r)
+		pref, customized := control.HomePreference{}, false
+		if principal != "" {
+			pref, customized = s.homePreferences.Get(principal, persona)
+		}
 		owner := strings.TrimSpace(r.URL.Query().Get("owner"))
+		if owner == "" {
+			owner = pref.DefaultFilters["owner"]
+		}
 		hours := 24
-		if raw := strings.TrimSpace(r.URL.Query().Get("hours")); raw != "" {
-			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+		rawHours := strings.TrimSpace(r.URL.Query().Get("hours"))
+		if rawHours == "" {
+			rawHours = pref.DefaultFilters["hours"]
+		}
+		if rawHours != "" {
+			if n, err := strconv.Atoi(rawHours); err == nil && n > 0 {
 				hours = n
 			}
 		}
 		cards, actions := s.personaCards(persona, owner, hours, baseDir)
+		cards = s.arrangeHomeCards(principal, persona, cards)
 		writeJSON(w, http.StatusOK, map[string]any{
 			"persona":      persona,
 			"owner":        owner,
 			"window_hours": hours,
 			"generated_at": time.Now().UTC(),
+			"principal":    principal,
+			"customized":   customized,
 			"cards":        cards,
 			"actions":      actions,
 		})
 	}
 }
 
+// arrangeHomeCards orders, hides, and flags cards per the principal's
+// preference and the persona's mandatory cards.
+func (s *Server) arrangeHomeCards(principal, persona string, cards []homeCard) []homeCard {
+	byID := make(map[string]homeCard, len(cards))
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		byID[card.ID] = card
+		ids = append(ids, card.ID)
+	}
+	placements := s.homePreferences.Arrange(principal, persona, ids)
+	out := make([]homeCard, 0, len(placements))
+	for _, p := range placements {
+		card := byID[p.ID]
+		card.Pinned = p.Pinned
+		card.Mandatory = p.Mandatory
+		out = append(out, card)
+	}
+	return out
+}
+
+// personaCardIDs lists the cards a persona's home view can show.
+func (s *Server) personaCardIDs(persona, baseDir string) []string {
+	cards, _ := s.personaCards(persona, "", 24, baseDir)
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.ID)
+	}
+	return ids
+}
+
 func normalizePersona(raw string) string {
 	switch strings.ToLower(strings.TrimSpace(raw)) {
 	case "sre":
@@ -18,6 +18,8 @@ type planExplainItem struct {
 	ResourceType    string   `json:"resource_type"`
 	Host            string   `json:"host"`
 	Dependencies    []string `json:"dependencies,omitempty"`
+	When            string   `json:"when,omitempty"`
+	Skipped         bool     `json:"skipped,omitempty"`
 	Reason          string   `json:"reason"`
 	TriggeredBy     string   `json:"triggered_by"`
 	ExpectedOutcome string   `json:"expected_outcome"`
@@ -55,7 +57,7 @@ func (s *Server) handlePlanExplain(baseDir string) http.HandlerFunc {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		plan, err := planner.Build(cfg)
+		plan, err := planner.BuildWithFacts(cfg, s.facts.PlanFacts())
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -87,12 +89,17 @@ func explainPlan(cfg *config.Config, p *planner.Plan) []planExplainItem {
 		if len(deps) > 0 {
 			reason = "scheduled after dependencies: " + strings.Join(deps, ", ")
 		}
+		if step.SkipReason != "" {
+			reason = "skipped: " + step.SkipReason
+		}
 		items = append(items, planExplainItem{
 			Order:           step.Order,
 			ResourceID:      step.Resource.ID,
 			ResourceType:    step.Resource.Type,
 			Host:            step.Resource.Host,
 			Dependencies:    deps,
+			When:            step.Resource.When,
+			Skipped:         step.SkipReason != "",
 			Reason:          reason,
 			TriggeredBy:     "config resource declaration and dependency graph",
 			ExpectedOutcome: expectedOutcomeForResource(step.Resource.Type),
@@ -133,7 +140,12 @@ func riskHintForResource(resourceType string) string {
 func explainSummary(items []planExplainItem) map[string]any {
 	highRisk := 0
 	mediumRisk := 0
+	skipped := 0
 	for _, item := range items {
+		if item.Skipped {
+			skipped++
+			continue
+		}
 		risk := strings.ToLower(item.RiskHint)
 		switch {
 		case strings.Contains(risk, "higher risk"):
@@ -146,6 +158,7 @@ func explainSummary(items []planExplainItem) map[string]any {
 		"step_count":        len(items),
 		"high_risk_steps":   highRisk,
 		"medium_risk_steps": mediumRisk,
+		"skipped_steps":     skipped,
 		"recommended_actions": []string{
 			"review high-risk steps before apply",
 			"validate dependency ordering against maintenance windows",
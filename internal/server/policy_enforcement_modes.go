@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -40,12 +42,42 @@ func (s *Server) handlePolicyEnforcementModes(w http.ResponseWriter, r *http.Req
 
 func (s *Server) handlePolicyEnforcementModeAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/policy/enforcement-modes/{policy_ref}|evaluate
+	// /v1/policy/enforcement-modes/{policy_ref}[/timeline]|evaluate|violations|promote
 	if len(parts) < 4 || parts[0] != "v1" || parts[1] != "policy" || parts[2] != "enforcement-modes" {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 	switch {
+	case len(parts) == 5 && parts[4] == "timeline" && r.Method == http.MethodGet:
+		policyRef := strings.TrimSpace(parts[3])
+		if _, ok := s.policyModes.Get(policyRef); !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "policy enforcement mode not found"})
+			return
+		}
+		items := s.policyModes.Timeline(policyRef)
+		writeJSON(w, http.StatusOK, map[string]any{"policy_ref": policyRef, "count": len(items), "items": items})
+	case len(parts) == 4 && parts[3] == "violations" && r.Method == http.MethodPost:
+		var req control.PolicyViolationInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		res, err := s.policyModes.RecordViolation(req)
+		if err != nil {
+			code := http.StatusBadRequest
+			if strings.Contains(err.Error(), "not found") {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		if res.Demotion != nil {
+			s.recordPolicyModeTransition(*res.Demotion)
+		}
+		writeJSON(w, http.StatusOK, res)
+	case len(parts) == 4 && parts[3] == "promote" && r.Method == http.MethodPost:
+		items := s.promotePolicyModes()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
 	case len(parts) == 4 && r.Method == http.MethodGet:
 		policyRef := strings.TrimSpace(parts[3])
 		item, ok := s.policyModes.Get(policyRef)
@@ -57,6 +89,7 @@ func (s *Server) handlePolicyEnforcementModeAction(w http.ResponseWriter, r *htt
 	case len(parts) == 4 && parts[3] == "evaluate" && r.Method == http.MethodPost:
 		var req struct {
 			PolicyRef            string `json:"policy_ref"`
+			Subject              string `json:"subject,omitempty"`
 			DriftDetected        bool   `json:"drift_detected"`
 			HighRisk             bool   `json:"high_risk,omitempty"`
 			CanAutocorrect       bool   `json:"can_autocorrect,omitempty"`
@@ -79,6 +112,16 @@ func (s *Server) handlePolicyEnforcementModeAction(w http.ResponseWriter, r *htt
 		action := "audit-only"
 		decision := "allow"
 		reason := "audit mode does not apply changes"
+		if s.policyModes.Exempt(policyRef, req.Subject, time.Now().UTC()) {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"policy_ref": policyRef,
+				"mode":       mode,
+				"decision":   decision,
+				"action":     "exempt",
+				"reason":     "subject is exempt from policy",
+			})
+			return
+		}
 		switch mode {
 		case control.PolicyEnforcementApplyAndMonitor:
 			action = "apply-and-monitor"
@@ -113,3 +156,44 @@ func (s *Server) handlePolicyEnforcementModeAction(w http.ResponseWriter, r *htt
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
+
+// promotePolicyModes applies every due scheduled promotion and records each
+// as an event.
+func (s *Server) promotePolicyModes() []control.PolicyModeTransition {
+	items := s.policyModes.PromoteDue(time.Now().UTC())
+	for _, tr := range items {
+		s.recordPolicyModeTransition(tr)
+	}
+	return items
+}
+
+func (s *Server) recordPolicyModeTransition(tr control.PolicyModeTransition) {
+	eventType := "policy.enforcement.mode.promoted"
+	if tr.Trigger == "demotion" {
+		eventType = "policy.enforcement.mode.demoted"
+	}
+	s.recordEvent(control.Event{
+		Type:    eventType,
+		Message: "policy " + tr.PolicyRef + " moved from " + tr.From + " to " + tr.To,
+		Fields: map[string]any{
+			"policy_ref": tr.PolicyRef,
+			"from":       tr.From,
+			"to":         tr.To,
+			"trigger":    tr.Trigger,
+			"reason":     tr.Reason,
+		},
+	}, true)
+}
+
+func (s *Server) sweepPolicyPromotions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.promotePolicyModes()
+		}
+	}
+}
@@ -77,3 +77,48 @@ func TestPolicyEnforcementModeEndpoints(t *testing.T) {
 		t.Fatalf("expected blocked monitor-only action for high risk: %s", rr.Body.String())
 	}
 }
+
+func TestPolicyEnforcementViolationsExemptionsAndTimeline(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/policy/enforcement-modes", `{"policy_ref":"policy-prod","mode":"enforce","promotion":{"enabled":true,"clean_days":14,"demote_violations":3},"exemptions":[{"subject":"legacy-1"}]}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"mode":"apply-and-autocorrect"`) {
+		t.Fatalf("upsert with promotion failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/policy/enforcement-modes/evaluate", `{"policy_ref":"policy-prod","subject":"legacy-1","drift_detected":true,"can_autocorrect":true}`)
+	if !strings.Contains(rr.Body.String(), `"action":"exempt"`) {
+		t.Fatalf("expected exempt subject evaluation, body=%s", rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/policy/enforcement-modes/violations", `{"policy_ref":"policy-prod","subject":"web-1","count":3}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"mode":"apply-and-monitor"`) || !strings.Contains(rr.Body.String(), `"trigger":"demotion"`) {
+		t.Fatalf("expected violation spike demotion: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/policy/enforcement-modes/violations", `{"policy_ref":"policy-missing"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown policy, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v1/policy/enforcement-modes/promote", `{}`); !strings.Contains(rr.Body.String(), `"count":0`) {
+		t.Fatalf("expected nothing due for promotion, body=%s", rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/v1/policy/enforcement-modes/policy-prod/timeline", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":2`) {
+		t.Fatalf("expected manual and demotion entries in timeline: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	demoted := false
+	for _, e := range s.events.List() {
+		if e.Type == "policy.enforcement.mode.demoted" && e.Fields["policy_ref"] == "policy-prod" {
+			demoted = true
+		}
+	}
+	if !demoted {
+		t.Fatalf("expected demotion event")
+	}
+}
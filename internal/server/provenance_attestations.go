@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleProvenanceAttestations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.provenanceAttestations.List(r.URL.Query().Get("digest")))
+	case http.MethodPost:
+		// Imports an envelope signed by an external builder.
+		var req struct {
+			SubjectType string               `json:"subject_type"`
+			Envelope    control.DSSEEnvelope `json:"envelope"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.provenanceAttestations.Import(req.SubjectType, req.Envelope)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.noteProvenanceAttested(item, "import")
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleProvenanceAttestationAction(w http.ResponseWriter, r *http.Request) {
+	// /v1/packages/attestations/{id|keys|policy|verify}
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 4 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch parts[3] {
+	case "keys":
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.provenanceAttestations.ListKeys())
+		case http.MethodPost:
+			var req control.AttestationKeyInput
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			key, err := s.provenanceAttestations.AddKey(req)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "packages.attestation_key.added",
+				Message: "provenance attestation key registered",
+				Fields: map[string]any{
+					"key_id": key.ID,
+					"name":   key.Name,
+				},
+			}, true)
+			writeJSON(w, http.StatusCreated, key)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case "policy":
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.provenanceAttestations.Policy())
+		case http.MethodPost:
+			var req control.ProvenanceAttestationPolicy
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			policy, err := s.provenanceAttestations.SetPolicy(req)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEvent(control.Event{
+				Type:    "packages.attestation_policy.updated",
+				Message: "provenance attestation policy updated",
+				Fields: map[string]any{
+					"required":              policy.Required,
+					"required_environments": policy.RequiredEnvironments,
+					"trusted_builders":      policy.TrustedBuilders,
+					"require_chain":         policy.RequireChain,
+				},
+			}, true)
+			writeJSON(w, http.StatusOK, policy)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case "verify":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req control.AttestationVerifyInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		result := s.provenanceAttestations.Verify(req)
+		code := http.StatusOK
+		if !result.Allowed {
+			code = http.StatusConflict
+		}
+		writeJSON(w, code, result)
+	default:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		item, ok := s.provenanceAttestations.Get(parts[3])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "attestation not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	}
+}
+
+// handleImageBakeAttestation signs provenance for an image once its bake
+// has produced a digest. The pipeline's base image is always recorded as a
+// material.
+func (s *Server) handleImageBakeAttestation(w http.ResponseWriter, r *http.Request, pipelineID string) {
+	pipeline, ok := s.imageBaking.Get(pipelineID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "image bake pipeline not found"})
+		return
+	}
+	var req struct {
+		Digest          string                        `json:"digest"`
+		BuildRef        string                        `json:"build_ref,omitempty"`
+		BaseImageDigest string                        `json:"base_image_digest,omitempty"`
+		SourceRepo      string                        `json:"source_repo,omitempty"`
+		SourceRef       string                        `json:"source_ref,omitempty"`
+		Materials       []control.AttestationMaterial `json:"materials,omitempty"`
+		StartedAt       time.Time                     `json:"started_at,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	materials := append([]control.AttestationMaterial{{URI: "oci://" + pipeline.BaseImage, Digest: req.BaseImageDigest}}, req.Materials...)
+	item, err := s.provenanceAttestations.Attest(control.ProvenanceAttestationInput{
+		SubjectType:   control.ScanArtifactImage,
+		SubjectName:   pipeline.TargetImage,
+		SubjectDigest: req.Digest,
+		SourceRepo:    req.SourceRepo,
+		SourceRef:     req.SourceRef,
+		BuilderID:     "masterchef/image-bake/" + pipeline.Builder,
+		BuildType:     "https://masterchef.dev/image-bake/" + pipeline.ArtifactFormat + "/v1",
+		Materials:     materials,
+		StartedAt:     req.StartedAt,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.noteProvenanceAttested(item, "bake")
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// admitArtifactProvenance is the artifact deployment admission check.
+func (s *Server) admitArtifactProvenance(item control.ArtifactDeployment) string {
+	result := s.provenanceAttestations.Verify(control.AttestationVerifyInput{Digest: item.Checksum, Environment: item.Environment})
+	if result.Allowed {
+		return ""
+	}
+	s.recordEvent(control.Event{
+		Type:    "packages.attestation.admission_rejected",
+		Message: "artifact deployment rejected by provenance attestation policy",
+		Fields: map[string]any{
+			"deployment_id": item.ID,
+			"environment":   item.Environment,
+			"artifact_ref":  item.ArtifactRef,
+			"digest":        item.Checksum,
+			"reasons":       result.Reasons,
+		},
+	}, true)
+	return "provenance attestation rejected: " + strings.Join(result.Reasons, "; ")
+}
+
+func (s *Server) noteProvenanceAttested(item control.ProvenanceAttestation, source string) {
+	s.recordEvent(control.Event{
+		Type:    "packages.attestation.recorded",
+		Message: "provenance attestation recorded",
+		Fields: map[string]any{
+			"attestation_id": item.ID,
+			"subject_type":   item.SubjectType,
+			"subject_digest": item.SubjectDigest,
+			"builder_id":     item.BuilderID,
+			"key_id":         item.KeyID,
+			"source":         source,
+		},
+	}, true)
+}
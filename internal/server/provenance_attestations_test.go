@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProvenanceAttestationAdmission(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "features.md"), []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	pkgDigest := "sha256:" + strings.Repeat("1", 64)
+	rr := do(http.MethodPost, "/v1/packages/artifacts", `{"kind":"module","name":"core/app","version":"1.0.0","digest":"`+pkgDigest+`",
+		"provenance":{"source_repo":"github.com/acme/app","source_ref":"refs/tags/v1.0.0","builder":"gha://acme/app"}}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"attestation_digest":"sha256:`) {
+		t.Fatalf("expected publish to attest provenance: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/packages/attestations?digest="+pkgDigest, "")
+	var listed []struct {
+		ID        string `json:"id"`
+		BuilderID string `json:"builder_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil || len(listed) != 1 || listed[0].BuilderID != "gha://acme/app" {
+		t.Fatalf("unexpected attestation list: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/packages/attestations/"+listed[0].ID, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"payloadType":"application/vnd.in-toto+json"`) {
+		t.Fatalf("get attestation failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/execution/image-baking/pipelines", `{"environment":"prod","name":"web","builder":"packer","base_image":"ubuntu-24.04","target_image":"registry.local/web"}`)
+	var created struct {
+		Pipeline struct {
+			ID string `json:"id"`
+		} `json:"pipeline"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+	imageDigest := "sha256:" + strings.Repeat("2", 64)
+	rr = do(http.MethodPost, "/v1/execution/image-baking/pipelines/"+created.Pipeline.ID+"/attestations", `{"digest":"`+imageDigest+`","source_repo":"github.com/acme/images"}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"builder_id":"masterchef/image-bake/packer"`) {
+		t.Fatalf("image attestation failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/packages/attestations/policy", `{"required":true,"required_environments":["prod"],"trusted_builders":["gha://acme/*"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set attestation policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/execution/artifacts/deployments", `{"environment":"prod","artifact_ref":"core/app","checksum":"`+pkgDigest+`","targets":["a"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected attested package to be admitted: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/execution/artifacts/deployments", `{"environment":"prod","artifact_ref":"registry.local/web","checksum":"`+imageDigest+`","targets":["a"]}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "builder masterchef/image-bake/packer is not trusted") {
+		t.Fatalf("expected untrusted image builder to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/execution/artifacts/deployments", `{"environment":"prod","artifact_ref":"other","checksum":"sha256:`+strings.Repeat("3", 64)+`","targets":["a"]}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "no provenance attestation") {
+		t.Fatalf("expected unattested artifact to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/packages/attestations/verify", `{"digest":"`+imageDigest+`","environment":"dev"}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected attested digest to be checked outside required environments: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -12,7 +12,9 @@ func (s *Server) handleProviderCatalog(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	writeJSON(w, http.StatusOK, s.providerCatalog.List())
+	s.serveCachedJSON(w, r, 0, []string{responseCacheTagStatic}, func() (int, any) {
+		return http.StatusOK, s.providerCatalog.List()
+	})
 }
 
 func (s *Server) handleProviderCatalogValidate(w http.ResponseWriter, r *http.Request) {
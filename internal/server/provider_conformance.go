@@ -52,12 +52,44 @@ func (s *Server) handleProviderConformanceRuns(w http.ResponseWriter, r *http.Re
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.observeConformanceRun(item)
 		writeJSON(w, http.StatusOK, item)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
+func (s *Server) handleProviderConformanceExecute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.ProviderConformanceExecuteInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	item, err := s.conformanceRunner.Execute(r.Context(), req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.observeConformanceRun(item)
+	s.recordEvent(control.Event{
+		Type:    "providers.conformance.executed",
+		Message: "provider conformance suite executed against generated fixtures",
+		Fields: map[string]any{
+			"run_id":       item.ID,
+			"suite_id":     item.SuiteID,
+			"provider":     item.Provider,
+			"status":       item.Status,
+			"pass_rate":    item.PassRate,
+			"scorecard_id": item.ScorecardID,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, item)
+}
+
 func (s *Server) handleProviderConformanceRunAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
 	// /v1/providers/conformance/runs/{id}
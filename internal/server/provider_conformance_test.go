@@ -83,4 +83,29 @@ func TestProviderConformanceEndpoints(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Fatalf("get run failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/providers/conformance/execute", bytes.NewReader([]byte(`{"suite_id":"provider-file-core","provider_version":"v1.0.0","environment":"staging"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("execute conformance failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var executed struct {
+		Mode        string `json:"mode"`
+		Status      string `json:"status"`
+		ScorecardID string `json:"scorecard_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &executed); err != nil {
+		t.Fatalf("decode execute response failed: %v", err)
+	}
+	if executed.Mode != "executed" || executed.Status != "pass" || executed.ScorecardID == "" {
+		t.Fatalf("unexpected execute response: %+v body=%s", executed, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/release/readiness/scorecards/"+executed.ScorecardID, nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get published scorecard failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 }
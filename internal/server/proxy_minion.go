@@ -54,21 +54,166 @@ func (s *Server) handleProxyMinions(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleProxyMinionAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/agents/proxy-minions/{id}
-	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "agents" || parts[2] != "proxy-minions" {
+	// /v1/agents/proxy-minions/{id}[/apply|facts]
+	if len(parts) < 4 || len(parts) > 5 || parts[0] != "v1" || parts[1] != "agents" || parts[2] != "proxy-minions" {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	item, ok := s.proxyMinions.GetBinding(parts[3])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "proxy-minion binding not found"})
+		return
+	}
+	if len(parts) == 4 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
+	switch parts[4] {
+	case "apply":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.applyProxyMinionResources(w, r, item)
+	case "facts":
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, map[string]any{
+				"binding_id": item.ID,
+				"device_id":  item.DeviceID,
+				"facts":      item.Facts,
+				"facts_at":   item.FactsAt,
+			})
+		case http.MethodPost:
+			s.collectProxyMinionFacts(w, r, item)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleDeviceModules(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	item, ok := s.proxyMinions.GetBinding(parts[3])
+	writeJSON(w, http.StatusOK, s.deviceModules.List())
+}
+
+// deviceModuleFor resolves the device module for a binding's transport and
+// writes the error response when there is none or the binding is inactive.
+func (s *Server) deviceModuleFor(w http.ResponseWriter, binding control.ProxyMinionBinding) (control.DeviceModule, bool) {
+	if binding.Status != "active" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "proxy-minion binding is " + binding.Status})
+		return nil, false
+	}
+	module, ok := s.deviceModules.For(binding.Transport)
 	if !ok {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "proxy-minion binding not found"})
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "no device module for transport " + binding.Transport})
+		return nil, false
+	}
+	return module, true
+}
+
+func (s *Server) applyProxyMinionResources(w http.ResponseWriter, r *http.Request, binding control.ProxyMinionBinding) {
+	var req struct {
+		Resources []control.DeviceResource `json:"resources"`
+		DryRun    bool                     `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if len(req.Resources) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "resources are required"})
+		return
+	}
+	module, ok := s.deviceModuleFor(w, binding)
+	if !ok {
+		return
+	}
+	ops, err := module.Translate(req.Resources)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	resp := map[string]any{
+		"binding_id": binding.ID,
+		"device_id":  binding.DeviceID,
+		"module":     module.Name(),
+		"dry_run":    req.DryRun,
+		"operations": ops,
+	}
+	if req.DryRun {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	results, err := module.Apply(r.Context(), binding, ops)
+	resp["results"] = results
+	fields := map[string]any{
+		"binding_id": binding.ID,
+		"proxy_id":   binding.ProxyID,
+		"device_id":  binding.DeviceID,
+		"module":     module.Name(),
+		"operations": len(ops),
+	}
+	if err != nil {
+		fields["severity"] = "high"
+		fields["error"] = err.Error()
+		s.recordEvent(control.Event{
+			Type:    "agents.proxy_minion.device.apply_failed",
+			Message: "proxy-minion device apply failed",
+			Fields:  fields,
+		}, true)
+		resp["error"] = err.Error()
+		writeJSON(w, http.StatusBadGateway, resp)
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "agents.proxy_minion.device.applied",
+		Message: "proxy-minion device resources applied",
+		Fields:  fields,
+	}, true)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) collectProxyMinionFacts(w http.ResponseWriter, r *http.Request, binding control.ProxyMinionBinding) {
+	module, ok := s.deviceModuleFor(w, binding)
+	if !ok {
+		return
+	}
+	facts, err := module.Facts(r.Context(), binding)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	item, err := s.proxyMinions.RecordFacts(binding.ID, facts)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusOK, item)
+	s.recordEvent(control.Event{
+		Type:    "agents.proxy_minion.device.facts",
+		Message: "proxy-minion device facts collected",
+		Fields: map[string]any{
+			"binding_id": item.ID,
+			"device_id":  item.DeviceID,
+			"module":     module.Name(),
+			"fact_count": len(item.Facts),
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"binding_id": item.ID,
+		"device_id":  item.DeviceID,
+		"facts":      item.Facts,
+		"facts_at":   item.FactsAt,
+	})
 }
 
 func (s *Server) handleProxyMinionDispatch(baseDir string) http.HandlerFunc {
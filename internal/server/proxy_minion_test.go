@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -90,7 +91,7 @@ resources:
 		t.Fatalf("list proxy dispatches failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 
-	unsupportedBind := []byte(`{"proxy_id":"proxy-east-2","device_id":"switch-2","transport":"snmp"}`)
+	unsupportedBind := []byte(`{"proxy_id":"proxy-east-2","device_id":"switch-2","transport":"telnet"}`)
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions", bytes.NewReader(unsupportedBind))
 	s.httpServer.Handler.ServeHTTP(rr, req)
@@ -98,3 +99,78 @@ resources:
 		t.Fatalf("unsupported transport expected conflict: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestProxyMinionDeviceModuleEndpoints(t *testing.T) {
+	bmc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(map[string]any{"Model": "R100", "PowerState": "Off"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer bmc.Close()
+
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/agents/proxy-minions/modules", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"transport":"redfish"`) {
+		t.Fatalf("list device modules failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	bindBody := []byte(`{"proxy_id":"proxy-east-1","device_id":"bmc-1","transport":"redfish","metadata":{"endpoint":"` + bmc.URL + `"}}`)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions", bytes.NewReader(bindBody)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create redfish binding failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var binding struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &binding)
+
+	applyBody := []byte(`{"resources":[{"id":"power","type":"power","properties":{"state":"on"}}],"dry_run":true}`)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions/"+binding.ID+"/apply", bytes.NewReader(applyBody)))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"ResetType":"On"`) || strings.Contains(rr.Body.String(), `"results"`) {
+		t.Fatalf("dry-run apply failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	applyBody = []byte(`{"resources":[{"id":"power","type":"power","properties":{"state":"on"}}]}`)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions/"+binding.ID+"/apply", bytes.NewReader(applyBody)))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"applied"`) {
+		t.Fatalf("apply failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	badBody := []byte(`{"resources":[{"id":"vlan","type":"vlan","properties":{"id":10}}]}`)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions/"+binding.ID+"/apply", bytes.NewReader(badBody)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unsupported resource type to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions/"+binding.ID+"/facts", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"model":"R100"`) {
+		t.Fatalf("collect facts failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/agents/proxy-minions/"+binding.ID, nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"power_state":"Off"`) {
+		t.Fatalf("expected facts stored on binding: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	netconfBody := []byte(`{"proxy_id":"proxy-east-1","device_id":"router-1","transport":"netconf"}`)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions", bytes.NewReader(netconfBody)))
+	_ = json.Unmarshal(rr.Body.Bytes(), &binding)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/agents/proxy-minions/"+binding.ID+"/facts", nil))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected conflict for transport without device module: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -58,6 +58,12 @@ func (s *Server) handleQuery(baseDir string) http.HandlerFunc {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		if resource, ok := labeledQueryEntities[entity]; ok {
+			records = filterLabeled(records, func(rec any) map[string]string {
+				m, _ := toMap(rec)
+				return recordLabels(m)
+			}, s.labelScope(r, resource))
+		}
 
 		var root *queryNode
 		switch mode {
@@ -75,23 +81,10 @@ func (s *Server) handleQuery(baseDir string) http.HandlerFunc {
 			return
 		}
 
-		matched := make([]any, 0, minInt(req.Limit, len(records)))
-		for _, rec := range records {
-			m, err := toMap(rec)
-			if err != nil {
-				continue
-			}
-			ok, err := matchNode(m, root)
-			if err != nil {
-				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-				return
-			}
-			if ok {
-				matched = append(matched, rec)
-				if len(matched) >= req.Limit {
-					break
-				}
-			}
+		matched, err := filterQueryRecords(records, root, req.Limit)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
 		}
 
 		writeJSON(w, http.StatusOK, map[string]any{
@@ -105,6 +98,28 @@ func (s *Server) handleQuery(baseDir string) http.HandlerFunc {
 	}
 }
 
+// filterQueryRecords returns up to limit records matching root.
+func filterQueryRecords(records []any, root *queryNode, limit int) ([]any, error) {
+	matched := make([]any, 0, minInt(limit, len(records)))
+	for _, rec := range records {
+		m, err := toMap(rec)
+		if err != nil {
+			continue
+		}
+		ok, err := matchNode(m, root)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, rec)
+			if len(matched) >= limit {
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
 func (s *Server) queryEntityRecords(entity, baseDir string) ([]any, error) {
 	switch entity {
 	case "events":
@@ -205,6 +220,13 @@ func (s *Server) queryEntityRecords(entity, baseDir string) ([]any, error) {
 			out = append(out, j)
 		}
 		return out, nil
+	case "hosts":
+		nodes := s.nodes.List("")
+		out := make([]any, 0, len(nodes))
+		for _, n := range nodes {
+			out = append(out, n)
+		}
+		return out, nil
 	case "runs":
 		runs, err := state.New(baseDir).ListRuns(2000)
 		if err != nil {
@@ -257,6 +279,13 @@ func (s *Server) queryEntityRecords(entity, baseDir string) ([]any, error) {
 			out = append(out, item)
 		}
 		return out, nil
+	case "grains":
+		items := s.grainRecords()
+		out := make([]any, 0, len(items))
+		for _, item := range items {
+			out = append(out, item)
+		}
+		return out, nil
 	case "plugin_extensions":
 		items := s.plugins.List("")
 		out := make([]any, 0, len(items))
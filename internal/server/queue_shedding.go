@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleQueueSheddingPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.queue.SheddingStatus().Policy)
+	case http.MethodPost:
+		var req control.QueueSheddingPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.queue.SetSheddingPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		// A policy enabled mid-saturation starts shedding right away.
+		s.observeQueueBacklog()
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleQueueSheddingStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.queue.SheddingStatus())
+}
+
+// updateQueueShedding starts shedding when the backlog saturates and stops
+// it once the backlog drains to the SLO recovery threshold, so shedding
+// does not flap around the saturation threshold.
+func (s *Server) updateQueueShedding(now time.Time, saturated bool, pending, recoveryThreshold int) {
+	shed := s.queue.SheddingStatus()
+	switch {
+	case !shed.Active && saturated && shed.Policy.Enabled:
+		shed = s.queue.SetShedding(true)
+		s.events.Append(control.Event{
+			Type:    "queue.shedding.started",
+			Message: "queue backlog saturated; shedding load",
+			Fields: map[string]any{
+				"pending":                 pending,
+				"reject_priorities":       shed.Policy.RejectPriorities,
+				"divert_partition":        shed.Policy.DivertPartition,
+				"cancel_stale_duplicates": shed.Policy.CancelStaleDuplicates,
+			},
+		})
+	case shed.Active && pending <= recoveryThreshold:
+		shed = s.queue.SetShedding(false)
+		s.events.Append(control.Event{
+			Type:    "queue.shedding.recovered",
+			Message: "queue backlog recovered; shedding stopped",
+			Fields: map[string]any{
+				"pending":         pending,
+				"recovery_target": recoveryThreshold,
+			},
+		})
+	}
+	if shed.Active {
+		for _, job := range s.queue.ShedStaleDuplicates(now) {
+			s.events.Append(control.Event{
+				Type:    "queue.shedding.canceled",
+				Message: "stale duplicate job shed from saturated queue",
+				Fields: map[string]any{
+					"job_id":      job.ID,
+					"config_path": job.ConfigPath,
+					"reason":      job.Error,
+				},
+			})
+		}
+	}
+	shed = s.queue.SheddingStatus()
+	s.metricsMu.Lock()
+	s.metrics["queue.shedding.active"] = boolToInt64(shed.Active)
+	s.metrics["queue.shed.rejected"] = shed.Rejected
+	s.metrics["queue.shed.canceled"] = shed.Canceled
+	s.metrics["queue.shed.diverted"] = shed.Diverted
+	s.metricsMu.Unlock()
+}
+
+// enqueueErrorCode answers enqueues shed by a saturated queue with 429 and
+// any other refusal with 409.
+func enqueueErrorCode(err error) int {
+	if strings.HasPrefix(err.Error(), "queue backlog saturated") {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusConflict
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestQueueSheddingFollowsBacklogSaturation(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	status := func() control.QueueSheddingStatus {
+		t.Helper()
+		rr := do(http.MethodGet, "/v1/control/queue/shedding/status", "")
+		var st control.QueueSheddingStatus
+		if err := json.Unmarshal(rr.Body.Bytes(), &st); err != nil {
+			t.Fatalf("decode shedding status failed: %v body=%s", err, rr.Body.String())
+		}
+		return st
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/queue/shedding/policy", `{"enabled":true}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty shedding policy to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/queue/shedding/policy", `{"enabled":true,"reject_priorities":["low"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("set shedding policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/queue/backlog-slo/policy", `{"threshold":2,"warning_percent":60,"recovery_percent":40,"projection_seconds":300}`); rr.Code != http.StatusOK {
+		t.Fatalf("set backlog slo policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/queue", `{"action":"pause"}`); rr.Code != http.StatusOK {
+		t.Fatalf("pause queue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// The paused worker may already hold one job, so keep enqueueing until
+	// the backlog crosses the threshold.
+	for i := 0; i < 4 && !status().Active; i++ {
+		if rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"c.yaml"}`); rr.Code != http.StatusAccepted {
+			t.Fatalf("enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	if st := status(); !st.Active {
+		t.Fatalf("expected saturation to start shedding, got %+v", st)
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"c.yaml","priority":"low"}`); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected low priority enqueue to be shed with 429: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"c.yaml","priority":"high"}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected high priority enqueue to be accepted: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := do(http.MethodGet, "/v1/metrics", "")
+	var metrics map[string]int64
+	if err := json.Unmarshal(rr.Body.Bytes(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	if metrics["queue.shed.rejected"] != 1 || metrics["queue.shedding.active"] != 1 {
+		t.Fatalf("expected shed counts in metrics, got %+v", metrics)
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/queue", `{"action":"resume"}`); rr.Code != http.StatusOK {
+		t.Fatalf("resume queue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for status().Active && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if st := status(); st.Active || st.Rejected != 1 {
+		t.Fatalf("expected shedding to stop once the backlog drained, got %+v", st)
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"c.yaml","priority":"low"}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected low priority enqueue after recovery: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	recovered := s.events.Query(control.EventQuery{TypePrefix: "queue.shedding.recovered"})
+	if len(recovered) != 1 {
+		t.Fatalf("expected one shedding recovery event, got %+v", recovered)
+	}
+}
@@ -39,6 +39,18 @@ func (s *Server) handleRebootPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	plan := s.rebootOrchestration.Plan(req)
+	if plan.Allowed {
+		waves := make([][]string, 0, len(plan.Waves))
+		for _, wave := range plan.Waves {
+			waves = append(waves, wave.Hosts)
+		}
+		claimID, blocked := s.claimPlannedDisruption(req.Service, "reboot", waves, len(req.Hosts))
+		if blocked != "" {
+			plan.Allowed = false
+			plan.BlockedReason = blocked
+		}
+		plan.DisruptionClaim = claimID
+	}
 	if !plan.Allowed {
 		writeJSON(w, http.StatusConflict, plan)
 		return
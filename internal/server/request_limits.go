@@ -0,0 +1,328 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// requestBodyLimits caps request body sizes. Routes maps a path prefix to
+// its own limit; the longest matching prefix wins.
+type requestBodyLimits struct {
+	DefaultMaxBytes int64            `json:"default_max_bytes"`
+	Routes          map[string]int64 `json:"routes,omitempty"`
+}
+
+const maxStreamErrors = 20
+
+// loadRequestBodyLimits reads MC_MAX_BODY_BYTES for the default limit and
+// MC_MAX_STREAM_BODY_BYTES for streaming ingestion and snapshot routes.
+func loadRequestBodyLimits() requestBodyLimits {
+	stream := int64(readIntEnv("MC_MAX_STREAM_BODY_BYTES", 256<<20))
+	return requestBodyLimits{
+		DefaultMaxBytes: int64(readIntEnv("MC_MAX_BODY_BYTES", 8<<20)),
+		Routes: map[string]int64{
+			"/v1/events/ingest/stream":         stream,
+			"/v1/inventory/import/cmdb/stream": stream,
+			"/v1/control/snapshot/import":      stream,
+		},
+	}
+}
+
+func normalizeRequestBodyLimits(in requestBodyLimits) (requestBodyLimits, error) {
+	if in.DefaultMaxBytes <= 0 {
+		return requestBodyLimits{}, errors.New("default_max_bytes must be greater than zero")
+	}
+	out := requestBodyLimits{DefaultMaxBytes: in.DefaultMaxBytes, Routes: map[string]int64{}}
+	for prefix, limit := range in.Routes {
+		prefix = strings.TrimSpace(prefix)
+		if !strings.HasPrefix(prefix, "/") {
+			return requestBodyLimits{}, errors.New("route prefixes must start with /")
+		}
+		if limit <= 0 {
+			return requestBodyLimits{}, errors.New("route limit for " + prefix + " must be greater than zero")
+		}
+		out.Routes[prefix] = limit
+	}
+	return out, nil
+}
+
+func (l requestBodyLimits) limitFor(path string) int64 {
+	limit, matched := l.DefaultMaxBytes, ""
+	for prefix, n := range l.Routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+			limit, matched = n, prefix
+		}
+	}
+	return limit
+}
+
+func (s *Server) requestBodyLimits() requestBodyLimits {
+	s.bodyLimitsMu.RLock()
+	defer s.bodyLimitsMu.RUnlock()
+	out := requestBodyLimits{DefaultMaxBytes: s.bodyLimits.DefaultMaxBytes, Routes: map[string]int64{}}
+	for k, v := range s.bodyLimits.Routes {
+		out.Routes[k] = v
+	}
+	return out
+}
+
+// limitRequestBody enforces the route's body limit. Requests that declare
+// an oversized Content-Length are rejected up front; others are cut off by
+// http.MaxBytesReader while the handler reads.
+func (s *Server) limitRequestBody(w http.ResponseWriter, r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+	limit := s.requestBodyLimits().limitFor(r.URL.Path)
+	if r.ContentLength > limit {
+		s.metricsMu.Lock()
+		s.metrics["requests.body_too_large"]++
+		s.metricsMu.Unlock()
+		writeBodyTooLarge(w, limit)
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return true
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{
+		"error":     "request body too large",
+		"max_bytes": limit,
+	})
+}
+
+func (s *Server) handleRequestLimits(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.requestBodyLimits())
+	case http.MethodPost:
+		var req requestBodyLimits
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		limits, err := normalizeRequestBodyLimits(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.bodyLimitsMu.Lock()
+		s.bodyLimits = limits
+		s.bodyLimitsMu.Unlock()
+		routes := make([]string, 0, len(limits.Routes))
+		for prefix := range limits.Routes {
+			routes = append(routes, prefix)
+		}
+		sort.Strings(routes)
+		s.recordEvent(control.Event{
+			Type:    "control.request_limits.updated",
+			Message: "request body limits updated",
+			Fields: map[string]any{
+				"default_max_bytes": limits.DefaultMaxBytes,
+				"routes":            routes,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, limits)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeJSONStream calls fn for each item of a top-level JSON array, or for
+// each value of a newline-delimited JSON stream, without buffering the whole
+// body. It returns the number of items decoded.
+func decodeJSONStream(body io.Reader, fn func(raw json.RawMessage) error) (int, error) {
+	br := bufio.NewReader(body)
+	first, err := peekJSONStart(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	dec := json.NewDecoder(br)
+	array := first == '['
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return 0, err
+		}
+	}
+	count := 0
+	for {
+		if array && !dec.More() {
+			break
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if !array && errors.Is(err, io.EOF) {
+				break
+			}
+			return count, streamItemError(count, err)
+		}
+		if err := fn(raw); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return count, streamItemError(count, err)
+		}
+	}
+	return count, nil
+}
+
+func peekJSONStart(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+func streamItemError(index int, err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	return errors.New("invalid json at item " + strconv.Itoa(index) + ": " + err.Error())
+}
+
+func (s *Server) handleEventIngestStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ingested, failed := 0, 0
+	itemErrors := []string{}
+	_, err := decodeJSONStream(r.Body, func(raw json.RawMessage) error {
+		var item struct {
+			Type    string         `json:"type"`
+			Message string         `json:"message"`
+			Fields  map[string]any `json:"fields"`
+		}
+		reason := ""
+		if err := json.Unmarshal(raw, &item); err != nil {
+			reason = "invalid event"
+		} else if strings.TrimSpace(item.Type) == "" {
+			reason = "type is required"
+		}
+		if reason != "" {
+			if len(itemErrors) < maxStreamErrors {
+				itemErrors = append(itemErrors, "item "+strconv.Itoa(ingested+failed)+": "+reason)
+			}
+			failed++
+			return nil
+		}
+		if item.Message == "" {
+			item.Message = "external event"
+		}
+		evt := control.Event{
+			Type:    item.Type,
+			Message: item.Message,
+			Fields:  item.Fields,
+		}
+		if res, rejected := s.enforceEventSchema(evt); rejected {
+			if len(itemErrors) < maxStreamErrors {
+				itemErrors = append(itemErrors, "item "+strconv.Itoa(ingested+failed)+": "+strings.Join(res.Errors, "; "))
+			}
+			failed++
+			return nil
+		}
+		s.recordEvent(evt, true)
+		ingested++
+		return nil
+	})
+	out := map[string]any{
+		"ingested": ingested,
+		"failed":   failed,
+		"errors":   itemErrors,
+	}
+	if err != nil {
+		s.writeStreamError(w, err, out)
+		return
+	}
+	out["status"] = "ingested"
+	writeJSON(w, http.StatusAccepted, out)
+}
+
+// handleInventoryCMDBImportStream imports CMDB records streamed as a JSON
+// array or NDJSON. source_system and dry_run come from the query string
+// because they must be known before the first record is applied.
+func (s *Server) handleInventoryCMDBImportStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	importer, err := control.NewCMDBImporter(s.nodes, r.URL.Query().Get("source_system"), dryRun)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	importer.FailuresOnly = true
+	_, err = decodeJSONStream(r.Body, func(raw json.RawMessage) error {
+		var record control.CMDBRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return errors.New("invalid cmdb record at item " + strconv.Itoa(importer.Processed()) + ": " + err.Error())
+		}
+		importer.Import(record)
+		return nil
+	})
+	result := importer.Result()
+	if err != nil {
+		s.writeStreamError(w, err, map[string]any{"partial_result": result})
+		return
+	}
+	if importer.Processed() == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "records are required"})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "inventory.import.cmdb",
+		Message: "cmdb import processed",
+		Fields: map[string]any{
+			"source_system": result.SourceSystem,
+			"dry_run":       result.DryRun,
+			"imported":      result.Imported,
+			"updated":       result.Updated,
+			"failed":        result.Failed,
+			"streamed":      true,
+		},
+	}, true)
+	code := http.StatusOK
+	if !dryRun {
+		code = http.StatusCreated
+	}
+	writeJSON(w, code, result)
+}
+
+// writeStreamError reports a stream that stopped part way. Items applied
+// before the failure are kept and reported alongside the error.
+func (s *Server) writeStreamError(w http.ResponseWriter, err error, out map[string]any) {
+	var tooLarge *http.MaxBytesError
+	code := http.StatusBadRequest
+	if errors.As(err, &tooLarge) {
+		code = http.StatusRequestEntityTooLarge
+		out["max_bytes"] = tooLarge.Limit
+		out["error"] = "request body too large"
+	} else {
+		out["error"] = err.Error()
+	}
+	writeJSON(w, code, out)
+}
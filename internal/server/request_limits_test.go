@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestBodyLimits(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/control/request-limits", bytes.NewReader([]byte(`{"default_max_bytes":64,"routes":{"/v1/events/ingest/stream":4096,"/v1/control/request-limits":1024}}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update request limits failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	big := []byte(`{"type":"deploy","message":"` + strings.Repeat("x", 128) + `"}`)
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/events/ingest", bytes.NewReader(big)))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for declared oversized body: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Chunked bodies without Content-Length are cut off while streaming.
+	stream := strings.Repeat(`{"type":"deploy.started","fields":{"service":"api"}}`+"\n", 200)
+	req = httptest.NewRequest(http.MethodPost, "/v1/events/ingest/stream", strings.NewReader(stream))
+	req.ContentLength = -1
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for oversized stream: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var partial struct {
+		Ingested int `json:"ingested"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &partial)
+	if partial.Ingested == 0 {
+		t.Fatalf("expected events before the limit to be ingested: %s", rr.Body.String())
+	}
+}
+
+func TestStreamingIngestion(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	body := `[{"type":"deploy.started","message":"a"},{"message":"missing type"},{"type":"deploy.finished"}]`
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/events/ingest/stream", strings.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("stream ingest failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var ingest struct {
+		Ingested int      `json:"ingested"`
+		Failed   int      `json:"failed"`
+		Errors   []string `json:"errors"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &ingest)
+	if ingest.Ingested != 2 || ingest.Failed != 1 || len(ingest.Errors) != 1 || !strings.HasPrefix(ingest.Errors[0], "item 1:") {
+		t.Fatalf("unexpected stream ingest result: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/events/ingest/stream", strings.NewReader(`{"type":"a"}`+"\n"+`{"type":`)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid json at item 1") {
+		t.Fatalf("expected malformed stream error: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	ndjson := `{"name":"node-a","address":"10.0.0.1","roles":["web"]}
+{"name":""}
+{"name":"node-b","address":"10.0.0.2"}
+`
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/inventory/import/cmdb/stream?source_system=servicenow", strings.NewReader(ndjson)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("cmdb stream import failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var result struct {
+		SourceSystem string `json:"source_system"`
+		Imported     int    `json:"imported"`
+		Failed       int    `json:"failed"`
+		Results      []struct {
+			Status string `json:"status"`
+		} `json:"results"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &result)
+	if result.SourceSystem != "servicenow" || result.Imported != 2 || result.Failed != 1 || len(result.Results) != 1 {
+		t.Fatalf("unexpected cmdb stream result: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/inventory/import/cmdb/stream", strings.NewReader(`[]`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty cmdb stream to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
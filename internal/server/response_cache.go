@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// Response cache tags. Handlers tag cached responses with the state they are
+// derived from; mutations of that state invalidate the tag.
+const (
+	responseCacheTagStatic       = "static"
+	responseCacheTagRuns         = "runs"
+	responseCacheTagWorkloads    = "workloads"
+	responseCacheTagHealthProbes = "health_probes"
+	responseCacheTagActionDocs   = "action_docs"
+)
+
+// aggregateResponseCacheTTL bounds how long event- and run-derived
+// aggregations are served from cache when they change outside the server,
+// e.g. runs written by the CLI.
+const aggregateResponseCacheTTL = 30 * time.Second
+
+// serveCachedJSON answers a GET from the response cache, rendering it with
+// build on a miss. Only 200 responses are cached; ttl of zero keeps the
+// entry until its tags are invalidated.
+func (s *Server) serveCachedJSON(w http.ResponseWriter, r *http.Request, ttl time.Duration, tags []string, build func() (int, any)) {
+	key := r.URL.Path
+	if q := r.URL.Query().Encode(); q != "" {
+		key += "?" + q
+	}
+	if locale := requestLocale(r); locale != control.DefaultLocale {
+		key += "#" + locale
+	}
+	if item, ok := s.responseCache.Get(key); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeConditionalResponse(w, r, item)
+		return
+	}
+	code, body := build()
+	var buf bytes.Buffer
+	_ = json.NewEncoder(&buf).Encode(body)
+	if code != http.StatusOK {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+	item := s.responseCache.Put(control.CachedResponse{
+		Key:         key,
+		Status:      code,
+		ContentType: "application/json",
+		Body:        buf.Bytes(),
+		Tags:        tags,
+	}, ttl)
+	w.Header().Set("X-Cache", "MISS")
+	writeConditionalResponse(w, r, item)
+}
+
+// writeConditionalResponse writes a cached response with ETag and
+// Last-Modified validators, answering 304 when the client copy is current.
+// If-None-Match takes precedence over If-Modified-Since.
+func writeConditionalResponse(w http.ResponseWriter, r *http.Request, item control.CachedResponse) {
+	w.Header().Set("ETag", item.ETag)
+	w.Header().Set("Last-Modified", item.LastModified.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-cache")
+	if responseNotModified(r, item) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", item.ContentType)
+	w.WriteHeader(item.Status)
+	_, _ = w.Write(item.Body)
+}
+
+func responseNotModified(r *http.Request, item control.CachedResponse) bool {
+	if inm := strings.TrimSpace(r.Header.Get("If-None-Match")); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == item.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := strings.TrimSpace(r.Header.Get("If-Modified-Since")); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !item.LastModified.After(t)
+		}
+	}
+	return false
+}
+
+func (s *Server) handleResponseCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.responseCache.Stats())
+}
+
+func (s *Server) handleResponseCacheInvalidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	var count int
+	if len(req.Tags) == 0 {
+		count = s.responseCache.Purge()
+	} else {
+		count = s.responseCache.Invalidate(req.Tags...)
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.response_cache.invalidated",
+		Message: "response cache invalidated",
+		Fields: map[string]any{
+			"tags":        req.Tags,
+			"invalidated": count,
+		},
+	}, false)
+	writeJSON(w, http.StatusOK, map[string]any{"invalidated": count})
+}
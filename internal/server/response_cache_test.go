@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheConditionalGET(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/providers/catalog", nil))
+	etag := rr.Header().Get("ETag")
+	lastModified := rr.Header().Get("Last-Modified")
+	if rr.Code != http.StatusOK || etag == "" || lastModified == "" || rr.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("expected cacheable provider catalog: code=%d headers=%v", rr.Code, rr.Header())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/providers/catalog", nil)
+	req.Header.Set("If-None-Match", etag)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 || rr.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected 304 for matching etag: code=%d headers=%v", rr.Code, rr.Header())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/release/api-contract", nil)
+	req.Header.Set("If-Modified-Since", time.Now().UTC().Add(time.Hour).Format(http.TimeFormat))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for if-modified-since: code=%d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/providers/catalog", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.Len() == 0 {
+		t.Fatalf("expected full response for mismatched etag: code=%d", rr.Code)
+	}
+}
+
+func TestResponseCacheWorkloadInvalidation(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/views/workloads", nil))
+	if rr.Code != http.StatusOK || bytes.Contains(rr.Body.Bytes(), []byte(`"checkout"`)) {
+		t.Fatalf("unexpected initial workload view: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/views/workloads", nil))
+	if rr.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected cached workload view")
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/events/ingest", bytes.NewReader([]byte(`{"type":"deploy.failed","message":"x","fields":{"service":"checkout"}}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code >= 300 {
+		t.Fatalf("event ingest failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/views/workloads", nil))
+	if rr.Header().Get("X-Cache") != "MISS" || !bytes.Contains(rr.Body.Bytes(), []byte(`"checkout"`)) {
+		t.Fatalf("expected workload view to be rebuilt after event: headers=%v body=%s", rr.Header(), rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/control/response-cache/invalidate", bytes.NewReader([]byte(`{}`))))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("purge response cache failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/response-cache", nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"entries":0`)) {
+		t.Fatalf("expected empty cache after purge: %s", rr.Body.String())
+	}
+}
@@ -2,8 +2,12 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"path/filepath"
+	"strings"
 
+	"github.com/masterchef/masterchef/internal/config"
 	"github.com/masterchef/masterchef/internal/control"
 )
 
@@ -116,6 +120,14 @@ func (s *Server) handleEnvironmentAction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	name := parts[2]
+	if len(parts) == 5 && parts[3] == "constraints" && parts[4] == "check" {
+		s.handleEnvironmentConstraintCheck(w, r, name)
+		return
+	}
+	if len(parts) == 5 && parts[3] == "guardrails" && parts[4] == "check" {
+		s.handleEnvironmentGuardrailCheck(w, r, name)
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
 		item, err := s.roleEnv.GetEnvironment(name)
@@ -134,3 +146,176 @@ func (s *Server) handleEnvironmentAction(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
+
+func (s *Server) handleEnvironmentConstraintCheck(w http.ResponseWriter, r *http.Request, name string) {
+	type checkReq struct {
+		Versions       map[string]string `json:"versions,omitempty"`
+		PolicyBundleID string            `json:"policy_bundle_id,omitempty"`
+		PolicyBundle   string            `json:"policy_bundle,omitempty"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req checkReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if _, err := s.roleEnv.GetEnvironment(name); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.PolicyBundleID) != "" || strings.TrimSpace(req.PolicyBundle) != "" {
+		selection, _, err := s.resolveEnvironmentPolicyBundle(name, req.PolicyBundleID, req.PolicyBundle)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, selection)
+		return
+	}
+	report, err := s.roleEnv.EvaluateVersionConstraints(name, req.Versions)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// resolveEnvironmentPolicyBundle decides which policy bundle revision an
+// association or GitOps deployment applies to env. A bundle ID pins an exact
+// revision; a bundle name selects the highest version that satisfies the
+// environment's version constraints. The bool reports whether a bundle was
+// requested at all. A selection without Selected is a constraint conflict.
+func (s *Server) resolveEnvironmentPolicyBundle(env, bundleID, bundleName string) (control.PolicyBundleSelection, bool, error) {
+	bundleID = strings.TrimSpace(bundleID)
+	bundleName = strings.TrimSpace(bundleName)
+	if bundleID == "" && bundleName == "" {
+		return control.PolicyBundleSelection{}, false, nil
+	}
+	candidates := []control.VersionedPolicyBundle{}
+	if bundleID != "" {
+		bundle, ok := s.policyBundles.Get(bundleID)
+		if !ok {
+			return control.PolicyBundleSelection{}, true, errors.New("policy bundle not found")
+		}
+		candidates = append(candidates, bundle)
+	} else {
+		for _, bundle := range s.policyBundles.List() {
+			if strings.EqualFold(bundle.Name, bundleName) {
+				candidates = append(candidates, bundle)
+			}
+		}
+		if len(candidates) == 0 {
+			return control.PolicyBundleSelection{}, true, errors.New("policy bundle not found")
+		}
+	}
+	selection, err := s.roleEnv.SelectPolicyBundle(env, candidates)
+	if err != nil {
+		return control.PolicyBundleSelection{}, true, err
+	}
+	if selection.Selected == nil {
+		s.recordEvent(control.Event{
+			Type:    "environment.version_constraints.conflict",
+			Message: "no policy bundle revision satisfies environment version constraints",
+			Fields: map[string]any{
+				"environment":      selection.Environment,
+				"policy_bundle":    bundleName,
+				"policy_bundle_id": bundleID,
+				"rejected":         len(selection.Rejected),
+			},
+		}, true)
+	}
+	return selection, true, nil
+}
+
+func (s *Server) handleEnvironmentGuardrailCheck(w http.ResponseWriter, r *http.Request, name string) {
+	type checkReq struct {
+		ConfigPath     string `json:"config_path"`
+		ChangeRecordID string `json:"change_record_id,omitempty"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req checkReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if _, err := s.roleEnv.GetEnvironment(name); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.ConfigPath) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
+		return
+	}
+	configPath := req.ConfigPath
+	if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(s.baseDir, configPath)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.roleEnv.EvaluateGuardrails(name, cfg, s.changeRecordApproved(req.ChangeRecordID)))
+}
+
+// enforceEnvironmentGuardrails rejects a job whose config touches resource
+// types or hosts its environment forbids. The check runs again when the job
+// starts; see control.GuardrailExecutor.
+func (s *Server) enforceEnvironmentGuardrails(w http.ResponseWriter, environment, configPath, changeRecordID string) bool {
+	if strings.TrimSpace(environment) == "" {
+		return true
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return true
+	}
+	report := s.roleEnv.EvaluateGuardrails(environment, cfg, s.changeRecordApproved(changeRecordID))
+	if report.Allowed {
+		return true
+	}
+	s.recordGuardrailViolation("enqueue", configPath, "", report)
+	writeJSON(w, http.StatusConflict, map[string]any{
+		"error":      report.Err().Error(),
+		"guardrails": report,
+	})
+	return false
+}
+
+func (s *Server) changeRecordApproved(id string) bool {
+	if strings.TrimSpace(id) == "" {
+		return false
+	}
+	rec, err := s.changeRecords.RecheckApproval(id)
+	return err == nil && rec.Status == control.ChangeRecordApproved
+}
+
+func (s *Server) recordGuardrailViolation(stage, configPath, jobID string, report control.GuardrailReport) {
+	rules := make([]string, 0, len(report.Violations))
+	resources := make([]string, 0, len(report.Violations))
+	for _, v := range report.Violations {
+		rules = append(rules, v.Rule)
+		resources = append(resources, v.ResourceID)
+	}
+	fields := map[string]any{
+		"stage":       stage,
+		"environment": report.Environment,
+		"config_path": configPath,
+		"rules":       rules,
+		"resources":   resources,
+		"violations":  len(report.Violations),
+	}
+	if jobID != "" {
+		fields["job_id"] = jobID
+	}
+	s.recordEvent(control.Event{
+		Type:    "policy.guardrail.violation",
+		Message: report.Err().Error(),
+		Fields:  fields,
+	}, true)
+}
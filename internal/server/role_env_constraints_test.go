@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentVersionConstraintsEnforced(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "web.yaml")
+	features := filepath.Join(tmp, "features.md")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "marker.txt")+`
+    content: "ok"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(features, []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("/v1/environments", `{"name":"prod","version_constraints":{"nginx":"~> 2.1"}}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create environment failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, body := range []string{
+		`{"name":"web","version":"1.0.0","lock_entries":[{"name":"nginx","version":"2.1.4","digest":"sha256:a"}]}`,
+		`{"name":"web","version":"1.1.0","lock_entries":[{"name":"nginx","version":"3.0.0","digest":"sha256:b"}]}`,
+	} {
+		if rr := post("/v1/policy/bundles", body); rr.Code != http.StatusCreated {
+			t.Fatalf("create bundle failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := post("/v1/environments/prod/constraints/check", `{"versions":{"nginx":"3.0.0"}}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"satisfied":false`) {
+		t.Fatalf("expected constraint conflict report: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/v1/associations", `{"config_path":"web.yaml","target_kind":"environment","target_name":"prod","policy_bundle":"web"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create association failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var assoc struct {
+		PolicyBundleVersion string `json:"policy_bundle_version"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &assoc); err != nil {
+		t.Fatal(err)
+	}
+	if assoc.PolicyBundleVersion != "1.0.0" {
+		t.Fatalf("expected association to resolve pinned bundle 1.0.0, got %q", assoc.PolicyBundleVersion)
+	}
+
+	rr = post("/v1/environments", `{"name":"prod","version_constraints":{"nginx":"~> 4.0"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update environment failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/gitops/deployments", `{"environment":"prod","branch":"env/prod","config_path":"web.yaml","policy_bundle":"web"}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected deployment conflict, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"rejected"`) {
+		t.Fatalf("expected conflict report with rejected bundles: %s", rr.Body.String())
+	}
+
+	rr = post("/v1/gitops/deployments", `{"environment":"staging","branch":"env/staging","config_path":"web.yaml","policy_bundle":"web"}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"policy_bundle_version":"1.1.0"`) {
+		t.Fatalf("expected unpinned environment to deploy newest bundle: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -127,3 +128,70 @@ func TestRoleEnvironmentEndpointsAndFileBackedReload(t *testing.T) {
 		t.Fatalf("delete environment failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestEnvironmentGuardrailsRejectJobs(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: restart
+    type: command
+    host: localhost
+    command: "true"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := post("/v1/environments", `{"name":"prod","guardrails":{"approval_resource_types":["command"],"denied_hosts":["db-*"]}}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create environment failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := post("/v1/environments/prod/guardrails/check", `{"config_path":"c.yaml"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"rule":"approval_required"`) {
+		t.Fatalf("expected guardrail check to report approval requirement: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/v1/jobs", `{"config_path":"c.yaml","environment":"prod"}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "requires an approved change record") {
+		t.Fatalf("expected guardrails to reject job: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = post("/v1/jobs", `{"config_path":"c.yaml","environment":"staging"}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected environment without guardrails to accept job: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/v1/change-records", `{"summary":"restart","config_path":"c.yaml","requested_by":"sre"}`)
+	var rec struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &rec)
+	if rr = post("/v1/jobs", `{"config_path":"c.yaml","environment":"prod","change_record_id":"`+rec.ID+`"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected proposed change record to be insufficient: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = post("/v1/change-records/"+rec.ID+"/approve", `{"actor":"approver"}`); rr.Code != http.StatusOK {
+		t.Fatalf("approve change record failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/jobs", `{"config_path":"c.yaml","environment":"prod","change_record_id":"`+rec.ID+`"}`)
+	if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"change_record_id":"`+rec.ID+`"`) {
+		t.Fatalf("expected approved job to be enqueued: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/activity/audit-timeline?category=resource", nil))
+	if !strings.Contains(rr.Body.String(), `"type":"policy.guardrail.violation"`) {
+		t.Fatalf("expected guardrail violation event: %s", rr.Body.String())
+	}
+}
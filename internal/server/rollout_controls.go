@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -39,6 +40,26 @@ func (s *Server) handleRolloutPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	plan := s.rolloutControls.Plan(req)
+	if id := strings.TrimSpace(req.CanaryAnalysisID); id != "" && plan.Allowed {
+		analysis, err := s.canaryAnalyses.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		plan = control.ApplyCanaryAnalysis(plan, analysis)
+	}
+	if plan.Allowed {
+		waves := make([][]string, 0, len(plan.Waves))
+		for _, wave := range plan.Waves {
+			waves = append(waves, wave.Targets)
+		}
+		claimID, blocked := s.claimPlannedDisruption(req.Service, "rollout", waves, len(req.Targets))
+		if blocked != "" {
+			plan.Allowed = false
+			plan.BlockedReason = blocked
+		}
+		plan.DisruptionClaim = claimID
+	}
 	if !plan.Allowed {
 		writeJSON(w, http.StatusConflict, plan)
 		return
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+// handleRunDigestSchedules serves GET/POST /v1/runs/digest/schedules.
+func (s *Server) handleRunDigestSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.runDigestSchedules.List())
+	case http.MethodPost:
+		var req control.RunDigestScheduleInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.runDigestSchedules.Create(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "runs.digest.schedule.created",
+			Message: "run digest schedule " + item.Name + " created",
+			Fields: map[string]any{
+				"schedule_id": item.ID,
+				"period":      item.Period,
+				"environment": item.Environment,
+				"team":        item.Team,
+				"route":       item.Route,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRunDigestScheduleAction serves /v1/runs/digest/schedules/{id} and
+// its run, enable, and disable actions.
+func (s *Server) handleRunDigestScheduleAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 5 || len(parts) > 6 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown digest schedule path"})
+		return
+	}
+	id := parts[4]
+	if len(parts) == 5 {
+		switch r.Method {
+		case http.MethodGet:
+			item, err := s.runDigestSchedules.Get(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"schedule": item, "reports": s.runDigestSchedules.Reports(id, 20)})
+		case http.MethodDelete:
+			if err := s.runDigestSchedules.Delete(id); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch parts[5] {
+	case "enable", "disable":
+		item, err := s.runDigestSchedules.SetEnabled(id, parts[5] == "enable")
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case "run":
+		schedule, err := s.runDigestSchedules.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		report, err := s.runScheduledDigest(schedule, time.Now().UTC())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown digest schedule action"})
+	}
+}
+
+// handleRunDigestReports serves GET /v1/runs/digest/reports (?schedule_id=)
+// and GET /v1/runs/digest/reports/{id}.
+func (s *Server) handleRunDigestReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	parts := splitPath(r.URL.Path)
+	if len(parts) == 5 {
+		report, err := s.runDigestSchedules.GetReport(parts[4])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+	limit := 100
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	items := s.runDigestSchedules.Reports(r.URL.Query().Get("schedule_id"), limit)
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+}
+
+// runScheduledDigest builds the digest for the period ending at now,
+// compares it with the period before, archives it to the object store, and
+// delivers it through the notification router. Archive and delivery
+// failures are kept on the report rather than failing the digest.
+func (s *Server) runScheduledDigest(schedule control.RunDigestSchedule, now time.Time) (control.RunDigestReport, error) {
+	period := control.RunDigestPeriod(schedule.Period)
+	windowStart := now.Add(-period)
+	runs, err := state.New(s.baseDir).ListRuns(0)
+	if err != nil {
+		_ = s.runDigestSchedules.RecordRun(schedule.ID, err)
+		s.recordEvent(control.Event{
+			Type:    "runs.digest.failed",
+			Message: "run digest " + schedule.Name + " failed",
+			Fields: map[string]any{
+				"severity":    "high",
+				"schedule_id": schedule.ID,
+				"error":       err.Error(),
+			},
+		}, true)
+		return control.RunDigestReport{}, err
+	}
+	keep := func(labels map[string]string) bool {
+		return (schedule.Environment == "" || labels["environment"] == schedule.Environment) &&
+			(schedule.Team == "" || labels["team"] == schedule.Team)
+	}
+	current, failedRunIDs := summarizeRunWindow(runs, windowStart, now, keep)
+	previous, _ := summarizeRunWindow(runs, windowStart.Add(-period), windowStart, keep)
+	deltas, highlights := control.CompareRunDigestTotals(previous, current)
+	if len(failedRunIDs) > 5 {
+		failedRunIDs = failedRunIDs[:5]
+	}
+	report := s.runDigestSchedules.AddReport(control.RunDigestReport{
+		ScheduleID:     schedule.ID,
+		Period:         schedule.Period,
+		Environment:    schedule.Environment,
+		Team:           schedule.Team,
+		WindowStart:    windowStart,
+		WindowEnd:      now,
+		Current:        current,
+		Previous:       previous,
+		Deltas:         deltas,
+		Highlights:     highlights,
+		RecentFailures: failedRunIDs,
+	})
+
+	if err := s.archiveRunDigest(&report, schedule.Prefix); err != nil {
+		report.ArchiveError = err.Error()
+	}
+	severity := "info"
+	if len(highlights) > 0 {
+		severity = "warning"
+	}
+	message := schedule.Period + " run digest " + schedule.Name + ": " + strconv.Itoa(current.TotalRuns) + " runs, " + strconv.Itoa(current.FailedRuns) + " failed"
+	if len(highlights) > 0 {
+		message += "; " + strings.Join(highlights, "; ")
+	}
+	for _, d := range s.notifications.NotifyAlert(control.AlertItem{
+		ID:          report.ID,
+		Fingerprint: "runs.digest:" + schedule.ID,
+		EventType:   "runs.digest",
+		Message:     message,
+		Severity:    severity,
+		Route:       schedule.Route,
+		Count:       1,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+		Status:      control.AlertOpen,
+		Fields: map[string]any{
+			"schedule_id": schedule.ID,
+			"environment": schedule.Environment,
+			"team":        schedule.Team,
+			"object_key":  report.ObjectKey,
+			"current":     current,
+			"previous":    previous,
+			"highlights":  highlights,
+		},
+	}) {
+		switch d.Status {
+		case "delivered":
+			report.Delivered++
+		case "suppressed":
+			report.Suppressed++
+		default:
+			report.DeliveryFailed++
+		}
+	}
+	if updated, err := s.runDigestSchedules.UpdateReport(report); err == nil {
+		report = updated
+	}
+	_ = s.runDigestSchedules.RecordRun(schedule.ID, nil)
+	s.events.Append(control.Event{
+		Type:    "runs.digest.delivered",
+		Message: "run digest " + schedule.Name + " delivered",
+		Fields: map[string]any{
+			"schedule_id":     schedule.ID,
+			"report_id":       report.ID,
+			"object_key":      report.ObjectKey,
+			"archive_error":   report.ArchiveError,
+			"delivered":       report.Delivered,
+			"delivery_failed": report.DeliveryFailed,
+			"highlights":      report.Highlights,
+		},
+	})
+	return report, nil
+}
+
+func (s *Server) archiveRunDigest(report *control.RunDigestReport, prefix string) error {
+	if s.objectStore == nil {
+		return errors.New("object store unavailable")
+	}
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	obj, err := s.objectStore.Put(storage.TimestampedJSONKey(prefix, report.ID), payload, "application/json")
+	if err != nil {
+		return err
+	}
+	report.ObjectKey = obj.Key
+	return nil
+}
+
+// summarizeRunWindow totals the runs that started in [start, end); a zero
+// end leaves the window open. Runs without a start time count by their end
+// time. Failed run IDs are returned newest first.
+func summarizeRunWindow(runs []state.RunRecord, start, end time.Time, keep func(map[string]string) bool) (control.RunDigestTotals, []string) {
+	filtered := make([]state.RunRecord, 0, len(runs))
+	for _, run := range runs {
+		ref := run.StartedAt
+		if ref.IsZero() {
+			ref = run.EndedAt
+		}
+		if ref.IsZero() || ref.Before(start) || (!end.IsZero() && !ref.Before(end)) {
+			continue
+		}
+		if keep != nil && !keep(run.Labels) {
+			continue
+		}
+		filtered = append(filtered, run)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].StartedAt.After(filtered[j].StartedAt)
+	})
+
+	var totals control.RunDigestTotals
+	failedRunIDs := make([]string, 0)
+	totals.TotalRuns = len(filtered)
+	for _, run := range filtered {
+		switch run.Status {
+		case state.RunSucceeded:
+			totals.SucceededRuns++
+		case state.RunFailed:
+			totals.FailedRuns++
+			failedRunIDs = append(failedRunIDs, run.ID)
+		}
+		for _, res := range run.Results {
+			if res.Changed {
+				totals.ChangedResources++
+			}
+		}
+	}
+	if totals.TotalRuns > 0 {
+		totals.FailRate = float64(totals.FailedRuns) / float64(totals.TotalRuns)
+	}
+	return totals, failedRunIDs
+}
+
+func (s *Server) sweepRunDigestSchedules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			for _, schedule := range s.runDigestSchedules.Due(now) {
+				_, _ = s.runScheduledDigest(schedule, now)
+			}
+		}
+	}
+}
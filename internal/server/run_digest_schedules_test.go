@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestScheduledRunDigestDeliveredAndArchived(t *testing.T) {
+	tmp := t.TempDir()
+	now := time.Now().UTC()
+	st := state.New(tmp)
+	for _, run := range []state.RunRecord{
+		{ID: "run-prev-1", StartedAt: now.Add(-30 * time.Hour), Status: state.RunSucceeded, Labels: map[string]string{"environment": "prod"}},
+		{ID: "run-prev-2", StartedAt: now.Add(-28 * time.Hour), Status: state.RunSucceeded, Labels: map[string]string{"environment": "prod"}},
+		{ID: "run-cur-1", StartedAt: now.Add(-3 * time.Hour), Status: state.RunSucceeded, Labels: map[string]string{"environment": "prod"}},
+		{ID: "run-cur-2", StartedAt: now.Add(-2 * time.Hour), Status: state.RunFailed, Labels: map[string]string{"environment": "prod"}},
+		{ID: "run-cur-3", StartedAt: now.Add(-1 * time.Hour), Status: state.RunFailed, Labels: map[string]string{"environment": "prod"}},
+		{ID: "run-staging", StartedAt: now.Add(-1 * time.Hour), Status: state.RunFailed, Labels: map[string]string{"environment": "staging"}},
+	} {
+		if err := st.SaveRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.Close)
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/notifications/targets", `{"name":"ops-digest","kind":"chatops","route":"digest","enabled":true,"url":"`+receiver.URL+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("register target failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/runs/digest/schedules", `{"name":"prod","period":"hourly"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown period to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/runs/digest/schedules", `{"name":"prod","period":"daily","environment":"prod"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create digest schedule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var schedule control.RunDigestSchedule
+	if err := json.Unmarshal(rr.Body.Bytes(), &schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = do(http.MethodPost, "/v1/runs/digest/schedules/"+schedule.ID+"/run", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("run digest failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var report control.RunDigestReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Current.TotalRuns != 3 || report.Current.FailedRuns != 2 || report.Previous.TotalRuns != 2 || report.Previous.FailedRuns != 0 {
+		t.Fatalf("expected prod-only totals for both periods, got current=%+v previous=%+v", report.Current, report.Previous)
+	}
+	if len(report.Highlights) == 0 || !strings.Contains(strings.Join(report.Highlights, ";"), "failed_runs up from 0 to 2") {
+		t.Fatalf("expected failure regression to be highlighted, got %v", report.Highlights)
+	}
+	if report.Delivered != 1 || report.DeliveryFailed != 0 {
+		t.Fatalf("expected one delivery, got %+v", report)
+	}
+	mu.Lock()
+	if len(received) != 1 || !strings.Contains(received[0], "daily run digest prod") {
+		t.Fatalf("expected digest payload at the target, got %v", received)
+	}
+	mu.Unlock()
+
+	if report.ObjectKey == "" || report.ArchiveError != "" {
+		t.Fatalf("expected digest to be archived, got %+v", report)
+	}
+	payload, _, err := s.objectStore.Get(report.ObjectKey)
+	if err != nil {
+		t.Fatalf("read archived digest failed: %v", err)
+	}
+	var archived control.RunDigestReport
+	if err := json.Unmarshal(payload, &archived); err != nil || archived.ID != report.ID {
+		t.Fatalf("expected archived digest %s, got %s err=%v", report.ID, payload, err)
+	}
+
+	rr = do(http.MethodGet, "/v1/runs/digest/reports?schedule_id="+schedule.ID, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("list digest reports failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/runs/digest/reports/"+report.ID, ""); rr.Code != http.StatusOK {
+		t.Fatalf("get digest report failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/runs/digest/schedules/"+schedule.ID, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"last_status":"succeeded"`) {
+		t.Fatalf("expected schedule to record the run: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/runs/digest", ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"total_runs":4`) {
+		t.Fatalf("expected on-demand digest to be unchanged: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
@@ -42,6 +43,10 @@ func (s *Server) buildRunTimeline(run state.RunRecord, beforeWindow, afterWindow
 		if !windowEnd.IsZero() && evt.Time.After(windowEnd) {
 			continue
 		}
+		if strings.HasPrefix(evt.Type, "annotation.") {
+			// Annotations are rendered below from the store, whenever written.
+			continue
+		}
 		items = append(items, timelineItem{
 			Time:    evt.Time,
 			Phase:   timelinePhase(evt.Time, started, ended),
@@ -99,6 +104,21 @@ func (s *Server) buildRunTimeline(run state.RunRecord, beforeWindow, afterWindow
 		}
 	}
 
+	for _, note := range s.annotations.List(control.AnnotationTargetRun, run.ID) {
+		items = append(items, timelineItem{
+			Time:    note.CreatedAt,
+			Phase:   timelinePhase(note.CreatedAt, started, ended),
+			Source:  "annotation",
+			Type:    "annotation",
+			Message: note.Body,
+			Fields: map[string]any{
+				"annotation_id": note.ID,
+				"author":        note.Author,
+				"links":         note.Links,
+			},
+		})
+	}
+
 	if !run.EndedAt.IsZero() {
 		items = append(items, timelineItem{
 			Time:    run.EndedAt,
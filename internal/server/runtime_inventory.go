@@ -19,8 +19,12 @@ func (s *Server) handleRuntimeEnrollAlias(w http.ResponseWriter, r *http.Request
 func (s *Server) handleRuntimeHosts(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		keep, ok := s.labelFilter(w, r, "hosts")
+		if !ok {
+			return
+		}
 		status := r.URL.Query().Get("status")
-		writeJSON(w, http.StatusOK, s.nodes.List(status))
+		writeJSON(w, http.StatusOK, filterLabeled(s.nodes.List(status), func(n control.ManagedNode) map[string]string { return n.Labels }, keep))
 	case http.MethodPost:
 		var req control.NodeEnrollInput
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -44,12 +44,44 @@ func (s *Server) handleTestScenarioRuns(w http.ResponseWriter, r *http.Request)
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.observeScenarioRun(item)
 		writeJSON(w, http.StatusOK, item)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
+func (s *Server) handleTestScenarioExecutions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req control.ScenarioExecuteInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	item, err := s.scenarioExecutor.Execute(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.observeScenarioRun(item)
+	if item.RegressionDetected {
+		s.recordEvent(control.Event{
+			Type:    "release.tests.scenario.regression",
+			Message: "executed scenario diverged from golden baseline",
+			Fields: map[string]any{
+				"run_id":      item.ID,
+				"scenario_id": item.ScenarioID,
+				"baseline_id": item.BaselineID,
+				"reasons":     item.RegressionReasons,
+			},
+		}, true)
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
 func (s *Server) handleTestScenarioBaselines(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -78,16 +110,32 @@ func (s *Server) handleTestScenarioBaselineAction(w http.ResponseWriter, r *http
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scenario baseline path"})
 		return
 	}
-	if r.Method != http.MethodGet {
+	switch r.Method {
+	case http.MethodGet:
+		item, err := s.scenarioTests.GetBaseline(parts[4])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodPost:
+		// Re-baseline: adopt a newer run's metrics and golden outputs.
+		var req struct {
+			RunID string `json:"run_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.scenarioTests.Rebaseline(parts[4], req.RunID)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
 	}
-	item, err := s.scenarioTests.GetBaseline(parts[4])
-	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
-		return
-	}
-	writeJSON(w, http.StatusOK, item)
 }
 
 func (s *Server) handleTestScenarioRunAction(w http.ResponseWriter, r *http.Request) {
@@ -128,3 +128,92 @@ func TestScenarioRunnerEndpoints(t *testing.T) {
 		t.Fatalf("compare baseline failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestScenarioExecutionGoldenBaselineEndpoints(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	scenario, _ := json.Marshal(map[string]any{
+		"id":   "local-motd",
+		"name": "Local MOTD",
+		"config": `version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: motd
+    type: file
+    host: localhost
+    path: "{{workspace}}/motd"
+    content: "hello\n"
+`,
+	})
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenarios", bytes.NewReader(scenario))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upsert config scenario failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	execute := func(body string) (id string, regression bool) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenario-executions", bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("execute scenario failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var run struct {
+			ID                 string            `json:"id"`
+			Mode               string            `json:"mode"`
+			RegressionDetected bool              `json:"regression_detected"`
+			Outputs            map[string]string `json:"outputs"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &run); err != nil {
+			t.Fatalf("decode executed run failed: %v", err)
+		}
+		if run.Mode != "executed" || run.Outputs["plan"] == "" {
+			t.Fatalf("unexpected executed run: %s", rr.Body.String())
+		}
+		return run.ID, run.RegressionDetected
+	}
+
+	firstID, _ := execute(`{"scenario_id":"local-motd"}`)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenario-baselines", bytes.NewReader([]byte(`{"name":"golden","run_id":"`+firstID+`"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create baseline failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var baseline struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &baseline); err != nil {
+		t.Fatalf("decode baseline failed: %v", err)
+	}
+
+	secondID, regression := execute(`{"scenario_id":"local-motd","baseline_id":"` + baseline.ID + `"}`)
+	if regression {
+		t.Fatalf("expected identical execution to match golden baseline")
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenario-baselines/"+baseline.ID, bytes.NewReader([]byte(`{"run_id":"`+secondID+`"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("rebaseline failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var rebased struct {
+		ReferenceRunID string `json:"reference_run_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &rebased); err != nil {
+		t.Fatalf("decode rebaseline failed: %v", err)
+	}
+	if rebased.ReferenceRunID != secondID {
+		t.Fatalf("expected rebaseline to reference %s, got %s", secondID, rr.Body.String())
+	}
+}
@@ -22,6 +22,7 @@ func (s *Server) handleSchedulerPartitions(w http.ResponseWriter, r *http.Reques
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		rebalanced := s.partitionDispatch.Rebalance()
 		s.recordEvent(control.Event{
 			Type:    "control.scheduler.partition.rule",
 			Message: "scheduler partition rule updated",
@@ -32,6 +33,7 @@ func (s *Server) handleSchedulerPartitions(w http.ResponseWriter, r *http.Reques
 				"region":       item.Region,
 				"shard":        item.Shard,
 				"max_parallel": item.MaxParallel,
+				"rebalanced":   rebalanced,
 			},
 		}, true)
 		writeJSON(w, http.StatusCreated, item)
@@ -47,16 +49,35 @@ func (s *Server) handleSchedulerPartitionAction(w http.ResponseWriter, r *http.R
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	if r.Method != http.MethodGet {
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := s.schedulerPartitions.Get(parts[4])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "partition rule not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		item, ok := s.schedulerPartitions.Get(parts[4])
+		if !ok || !s.schedulerPartitions.Delete(item.ID) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "partition rule not found"})
+			return
+		}
+		rebalanced := s.partitionDispatch.Rebalance()
+		s.recordEvent(control.Event{
+			Type:    "control.scheduler.partition.rule.deleted",
+			Message: "scheduler partition rule deleted",
+			Fields: map[string]any{
+				"rule_id":    item.ID,
+				"tenant":     item.Tenant,
+				"shard":      item.Shard,
+				"rebalanced": rebalanced,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, map[string]any{"deleted": item.ID, "rebalanced": rebalanced})
+	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-	item, ok := s.schedulerPartitions.Get(parts[4])
-	if !ok {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "partition rule not found"})
-		return
 	}
-	writeJSON(w, http.StatusOK, item)
 }
 
 func (s *Server) handleSchedulerPartitionDecision(w http.ResponseWriter, r *http.Request) {
@@ -52,3 +52,69 @@ func TestSchedulerPartitionEndpoints(t *testing.T) {
 		t.Fatalf("scheduler partition decision failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestSchedulerPartitionDispatchToRegisteredWorkers(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Tenant", "payments")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := post("/v1/control/scheduler/partitions", `{"tenant":"payments","region":"us-east-1","shard":"east"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create scheduler partition failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := post("/v1/jobs", `{"config_path":"c.yaml","region":"us-east-1"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var job struct {
+		ID        string `json:"id"`
+		Partition string `json:"partition"`
+		Status    string `json:"status"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &job)
+	if job.Partition != "east" {
+		t.Fatalf("expected job routed to east partition, got %s", rr.Body.String())
+	}
+
+	if rr := post("/v1/control/scheduler/workers", `{"id":"east-1","partitions":["east"]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("register worker failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/control/scheduler/workers/east-1/claim", ``)
+	_ = json.Unmarshal(rr.Body.Bytes(), &job)
+	if rr.Code != http.StatusOK || job.Status != "running" {
+		t.Fatalf("expected claimed job, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/control/scheduler/workers/east-1/claim", ``); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected empty claim, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/control/scheduler/workers/east-1/complete", `{"job_id":"`+job.ID+`"}`)
+	_ = json.Unmarshal(rr.Body.Bytes(), &job)
+	if rr.Code != http.StatusOK || job.Status != "succeeded" {
+		t.Fatalf("complete failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/scheduler/partition-lag", nil))
+	var lag struct {
+		Items []struct {
+			Partition string `json:"partition"`
+			Workers   int    `json:"workers"`
+			Succeeded int    `json:"succeeded"`
+		} `json:"items"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &lag)
+	if len(lag.Items) != 1 || lag.Items[0].Partition != "east" || lag.Items[0].Workers != 1 || lag.Items[0].Succeeded != 1 {
+		t.Fatalf("unexpected partition lag: %s", rr.Body.String())
+	}
+}
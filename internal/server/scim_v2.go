@@ -0,0 +1,423 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// requireSCIMToken authenticates an identity provider against
+// MC_SCIM_TOKEN. SCIM endpoints stay disabled until a token is set.
+func (s *Server) requireSCIMToken(w http.ResponseWriter, r *http.Request) bool {
+	if s.scimToken == "" {
+		writeSCIMError(w, &control.SCIMError{Status: http.StatusServiceUnavailable, Detail: "scim provisioning is not enabled; set MC_SCIM_TOKEN"})
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.scimToken)) != 1 {
+		s.recordEvent(control.Event{
+			Type:    "identity.scim.auth.denied",
+			Message: "scim request rejected",
+			Fields: map[string]any{
+				"path":     r.URL.Path,
+				"severity": "medium",
+			},
+		}, true)
+		writeSCIMError(w, &control.SCIMError{Status: http.StatusUnauthorized, Detail: "invalid scim bearer token"})
+		return false
+	}
+	return true
+}
+
+func writeSCIM(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeSCIMError(w http.ResponseWriter, err error) {
+	var scimErr *control.SCIMError
+	if !errors.As(err, &scimErr) {
+		scimErr = &control.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: err.Error()}
+	}
+	body := map[string]any{
+		"schemas": []string{control.SCIMErrorSchema},
+		"status":  strconv.Itoa(scimErr.Status),
+		"detail":  scimErr.Detail,
+	}
+	if scimErr.ScimType != "" {
+		body["scimType"] = scimErr.ScimType
+	}
+	writeSCIM(w, scimErr.Status, body)
+}
+
+// writeSCIMList pages resources with the 1-based startIndex and count
+// query parameters.
+func writeSCIMList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	start := parseIntQuery(r, "startIndex", 1)
+	if start < 1 {
+		start = 1
+	}
+	count := parseIntQuery(r, "count", 100)
+	if count < 0 {
+		count = 0
+	}
+	if count > 1000 {
+		count = 1000
+	}
+	page := []T{}
+	if start-1 < len(items) {
+		end := start - 1 + count
+		if end > len(items) {
+			end = len(items)
+		}
+		page = items[start-1 : end]
+	}
+	writeSCIM(w, http.StatusOK, map[string]any{
+		"schemas":      []string{control.SCIMListResponseSchema},
+		"totalResults": len(items),
+		"startIndex":   start,
+		"itemsPerPage": len(page),
+		"Resources":    page,
+	})
+}
+
+func decodeSCIMPatch(r *http.Request) ([]control.SCIMPatchOperation, error) {
+	var req control.SCIMPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, &control.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "invalid json body"}
+	}
+	return req.Operations, nil
+}
+
+func (s *Server) handleSCIMServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.requireSCIMToken(w, r) {
+		return
+	}
+	writeSCIM(w, http.StatusOK, map[string]any{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": 1000},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Static bearer token configured with MC_SCIM_TOKEN",
+			"primary":     true,
+		}},
+	})
+}
+
+func (s *Server) handleSCIMUsers(w http.ResponseWriter, r *http.Request) {
+	if !s.requireSCIMToken(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.scimDirectory.ListUsers(r.URL.Query().Get("filter"))
+		if err != nil {
+			writeSCIMError(w, err)
+			return
+		}
+		writeSCIMList(w, r, items)
+	case http.MethodPost:
+		var req control.SCIMUserInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeSCIMError(w, &control.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "invalid json body"})
+			return
+		}
+		item, err := s.scimDirectory.CreateUser(req)
+		if err != nil {
+			writeSCIMError(w, err)
+			return
+		}
+		s.noteSCIMUserChange("created", item)
+		writeSCIM(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSCIMUser(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /scim/v2/Users/{id}
+	if len(parts) != 4 || parts[0] != "scim" || parts[1] != "v2" || parts[2] != "Users" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !s.requireSCIMToken(w, r) {
+		return
+	}
+	id := parts[3]
+	var (
+		item control.SCIMUser
+		err  error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		var ok bool
+		item, ok = s.scimDirectory.GetUser(id)
+		if !ok {
+			writeSCIMError(w, &control.SCIMError{Status: http.StatusNotFound, Detail: "user not found"})
+			return
+		}
+		writeSCIM(w, http.StatusOK, item)
+		return
+	case http.MethodPut:
+		var req control.SCIMUserInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeSCIMError(w, &control.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "invalid json body"})
+			return
+		}
+		item, err = s.scimDirectory.ReplaceUser(id, req)
+	case http.MethodPatch:
+		var ops []control.SCIMPatchOperation
+		if ops, err = decodeSCIMPatch(r); err == nil {
+			item, err = s.scimDirectory.PatchUser(id, ops)
+		}
+	case http.MethodDelete:
+		if item, err = s.scimDirectory.DeleteUser(id); err == nil {
+			item.Active = false
+			s.noteSCIMUserChange("deleted", item)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeSCIMError(w, err)
+		return
+	}
+	s.noteSCIMUserChange("updated", item)
+	writeSCIM(w, http.StatusOK, item)
+}
+
+func (s *Server) handleSCIMGroups(w http.ResponseWriter, r *http.Request) {
+	if !s.requireSCIMToken(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.scimDirectory.ListGroups(r.URL.Query().Get("filter"))
+		if err != nil {
+			writeSCIMError(w, err)
+			return
+		}
+		// Okta lists groups with excludedAttributes=members to keep pages
+		// small.
+		if strings.Contains(strings.ToLower(r.URL.Query().Get("excludedAttributes")), "members") {
+			for i := range items {
+				items[i].Members = []control.SCIMRef{}
+			}
+		}
+		writeSCIMList(w, r, items)
+	case http.MethodPost:
+		var req control.SCIMGroupInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeSCIMError(w, &control.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "invalid json body"})
+			return
+		}
+		item, err := s.scimDirectory.CreateGroup(req)
+		if err != nil {
+			writeSCIMError(w, err)
+			return
+		}
+		s.noteSCIMGroupChange("created", item)
+		writeSCIM(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSCIMGroup(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /scim/v2/Groups/{id}
+	if len(parts) != 4 || parts[0] != "scim" || parts[1] != "v2" || parts[2] != "Groups" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !s.requireSCIMToken(w, r) {
+		return
+	}
+	id := parts[3]
+	var (
+		item control.SCIMGroup
+		err  error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		var ok bool
+		item, ok = s.scimDirectory.GetGroup(id)
+		if !ok {
+			writeSCIMError(w, &control.SCIMError{Status: http.StatusNotFound, Detail: "group not found"})
+			return
+		}
+		writeSCIM(w, http.StatusOK, item)
+		return
+	case http.MethodPut:
+		var req control.SCIMGroupInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeSCIMError(w, &control.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "invalid json body"})
+			return
+		}
+		item, err = s.scimDirectory.ReplaceGroup(id, req)
+	case http.MethodPatch:
+		var ops []control.SCIMPatchOperation
+		if ops, err = decodeSCIMPatch(r); err == nil {
+			item, err = s.scimDirectory.PatchGroup(id, ops)
+		}
+	case http.MethodDelete:
+		if item, err = s.scimDirectory.DeleteGroup(id); err == nil {
+			s.noteSCIMGroupChange("deleted", item)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeSCIMError(w, err)
+		return
+	}
+	s.noteSCIMGroupChange("updated", item)
+	writeSCIM(w, http.StatusOK, item)
+}
+
+func (s *Server) handleSCIMGroupMappings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.scimDirectory.ListGroupMappings())
+	case http.MethodPost:
+		var req control.SCIMGroupRoleMappingInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if _, ok := s.rbac.GetRole(req.RoleID); !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "rbac role not found"})
+			return
+		}
+		item, err := s.scimDirectory.CreateGroupMapping(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "identity.scim.mapping.created",
+			Message: "scim group mapped to rbac role",
+			Fields: map[string]any{
+				"mapping_id": item.ID,
+				"group":      item.Group,
+				"role_id":    item.RoleID,
+				"scope":      item.Scope,
+			},
+		}, true)
+		s.syncSCIMBindings()
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSCIMGroupMappingAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/identity/scim/group-mappings/{id}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "identity" || parts[2] != "scim" || parts[3] != "group-mappings" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, err := s.scimDirectory.DeleteGroupMapping(parts[4])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "identity.scim.mapping.deleted",
+		Message: "scim group mapping removed",
+		Fields: map[string]any{
+			"mapping_id": item.ID,
+			"group":      item.Group,
+			"role_id":    item.RoleID,
+		},
+	}, true)
+	s.syncSCIMBindings()
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) noteSCIMUserChange(action string, item control.SCIMUser) {
+	s.recordEvent(control.Event{
+		Type:    "identity.scim.user." + action,
+		Message: "scim user " + action,
+		Fields: map[string]any{
+			"user_id":   item.ID,
+			"user_name": item.UserName,
+			"active":    item.Active,
+		},
+	}, true)
+	if !item.Active {
+		principals := []string{item.UserName}
+		for _, email := range item.Emails {
+			principals = append(principals, email.Value)
+		}
+		if revoked := s.identity.RevokeSessionsFor(principals...); revoked > 0 {
+			s.recordEvent(control.Event{
+				Type:    "identity.scim.sessions.revoked",
+				Message: "sso sessions revoked for deprovisioned user",
+				Fields: map[string]any{
+					"user_name": item.UserName,
+					"sessions":  revoked,
+				},
+			}, true)
+		}
+	}
+	s.syncSCIMBindings()
+}
+
+func (s *Server) noteSCIMGroupChange(action string, item control.SCIMGroup) {
+	s.recordEvent(control.Event{
+		Type:    "identity.scim.group." + action,
+		Message: "scim group " + action,
+		Fields: map[string]any{
+			"group_id":     item.ID,
+			"display_name": item.DisplayName,
+			"members":      len(item.Members),
+		},
+	}, true)
+	s.syncSCIMBindings()
+}
+
+// syncSCIMBindings reconciles SCIM-owned RBAC bindings with the directory
+// after every provisioning change.
+func (s *Server) syncSCIMBindings() {
+	added, removed := s.rbac.SyncManagedBindings(control.SCIMBindingSource, s.scimDirectory.DesiredBindings())
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "identity.scim.rbac.synced",
+		Message: "rbac bindings reconciled from scim groups",
+		Fields: map[string]any{
+			"added":   len(added),
+			"removed": len(removed),
+		},
+	}, true)
+}
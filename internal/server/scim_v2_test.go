@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestSCIMv2ProvisioningEndpoints(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/scim/v2/Users", "", ""); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected scim to be disabled without a token, got %d", rr.Code)
+	}
+	s.scimToken = "idp-secret"
+	if rr := do(http.MethodGet, "/scim/v2/Users", "wrong", ""); rr.Code != http.StatusUnauthorized || rr.Header().Get("Content-Type") != "application/scim+json" {
+		t.Fatalf("expected 401 scim error, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	role, err := s.rbac.CreateRole(control.RBACRoleInput{Name: "deployer", Permissions: []control.RBACPermission{{Resource: "runs", Action: "launch"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := do(http.MethodPost, "/v1/identity/scim/group-mappings", "", `{"group":"deployers","role_id":"`+role.ID+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create group mapping failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := do(http.MethodPost, "/scim/v2/Users", "idp-secret", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"alice@example.com","name":{"givenName":"Alice"},"emails":[{"value":"alice@example.com","type":"work","primary":true}],"active":true}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create scim user failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var user control.SCIMUser
+	if err := json.Unmarshal(rr.Body.Bytes(), &user); err != nil || user.ID == "" {
+		t.Fatalf("decode user failed: %v", err)
+	}
+	if rr := do(http.MethodPost, "/scim/v2/Users", "idp-secret", `{"userName":"alice@example.com"}`); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"scimType":"uniqueness"`) {
+		t.Fatalf("expected uniqueness conflict, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22alice@example.com%22`, "idp-secret", "")
+	var list struct {
+		TotalResults int                `json:"totalResults"`
+		Resources    []control.SCIMUser `json:"Resources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || list.TotalResults != 1 || list.Resources[0].ID != user.ID {
+		t.Fatalf("unexpected filtered list: %s", rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/scim/v2/Groups", "idp-secret", `{"displayName":"Deployers","members":[{"value":"`+user.ID+`"}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create scim group failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	check := func() bool {
+		return s.rbac.CheckAccess(control.RBACAccessCheckInput{Subject: "alice@example.com", Resource: "runs", Action: "launch"}).Allowed
+	}
+	if !check() {
+		t.Fatalf("expected group membership to provision rbac binding: %+v", s.rbac.ListBindings())
+	}
+
+	provider, _ := s.identity.CreateProvider(control.SSOProviderInput{Name: "okta", Protocol: "oidc", IssuerURL: "https://id.example.com", ClientID: "mc", RedirectURL: "https://mc.example.com/cb"})
+	start, _ := s.identity.StartLogin(control.SSOLoginStartInput{ProviderID: provider.ID, Email: "alice@example.com"})
+	if _, err := s.identity.CompleteLogin(control.SSOLoginCompleteInput{State: start.State, Code: "ok", Subject: "00u1", Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = do(http.MethodPatch, "/scim/v2/Users/"+user.ID, "idp-secret", `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"replace","value":{"active":false}}]}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"active":false`) {
+		t.Fatalf("deactivate user failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if check() {
+		t.Fatalf("expected deactivation to remove rbac binding")
+	}
+	if sessions := s.identity.ListSessions(); len(sessions) != 0 {
+		t.Fatalf("expected deactivation to revoke sso sessions: %+v", sessions)
+	}
+
+	if rr := do(http.MethodDelete, "/scim/v2/Users/"+user.ID, "idp-secret", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete user failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/scim/v2/Users/"+user.ID, "idp-secret", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected deleted user to be gone, got %d", rr.Code)
+	}
+}
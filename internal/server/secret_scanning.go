@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// scanForSecrets runs the secret scanner over content being accepted into
+// the control plane, records the result on the compliance scorecards, and
+// reports whether the policy blocks it.
+func (s *Server) scanForSecrets(in control.SecretScanInput) (control.SecretScanResult, error) {
+	result, err := s.secretScans.Scan(in)
+	if err != nil || result.Status == "skipped" {
+		return result, err
+	}
+	scan := s.compliance.RecordScan(control.SecretScanComplianceScan(result, in))
+	s.secretScans.LinkComplianceScan(result.ID, scan.ID)
+	result.ComplianceScanID = scan.ID
+	if len(result.Findings) == 0 {
+		return result, nil
+	}
+	rules := make([]string, 0, len(result.Findings))
+	for _, finding := range result.Findings {
+		rules = append(rules, finding.Rule)
+	}
+	eventType, message := "security.secret_scan.warned", "possible secrets found in "+result.Kind+" "+result.Target
+	if result.Blocked {
+		eventType, message = "security.secret_scan.blocked", "secret scan blocked "+result.Kind+" "+result.Target
+	}
+	s.recordEvent(control.Event{
+		Type:    eventType,
+		Message: message,
+		Fields: map[string]any{
+			"scan_id":            result.ID,
+			"kind":               result.Kind,
+			"target":             result.Target,
+			"findings":           len(result.Findings),
+			"rules":              rules,
+			"compliance_scan_id": scan.ID,
+		},
+	}, true)
+	return result, nil
+}
+
+// enforceSecretScan scans a file on disk and writes a 409 when the policy
+// blocks it. Unreadable files are left to the caller's own validation.
+func (s *Server) enforceSecretScan(w http.ResponseWriter, in control.SecretScanInput, path string) bool {
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return true
+		}
+		in.Content = string(content)
+	}
+	result, err := s.scanForSecrets(in)
+	if err != nil || !result.Blocked {
+		return true
+	}
+	writeJSON(w, http.StatusConflict, map[string]any{
+		"error":       "content contains possible secrets",
+		"secret_scan": result,
+	})
+	return false
+}
+
+func (s *Server) handleSecretScanPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.secretScans.Policy())
+	case http.MethodPost:
+		var req control.SecretScanPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.secretScans.SetPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "security.secret_scan.policy",
+			Message: "secret scanning policy updated",
+			Fields: map[string]any{
+				"mode":       item.Mode,
+				"kind_modes": item.KindModes,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSecretScans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		limit := 100
+		if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		writeJSON(w, http.StatusOK, s.secretScans.List(r.URL.Query().Get("kind"), limit))
+	case http.MethodPost:
+		var req control.SecretScanInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		result, err := s.scanForSecrets(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSecretScanAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/security/secret-scans/{id}
+	if len(parts) != 4 || parts[0] != "v1" || parts[1] != "security" || parts[2] != "secret-scans" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, ok := s.secretScans.Get(parts[3])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "secret scan not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
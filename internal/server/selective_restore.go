@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+// restoreKinds lists the entity kinds a restore can be narrowed to.
+var restoreKinds = []string{
+	control.ConfigAsCodeTemplates,
+	control.ConfigAsCodeRunbooks,
+	control.ConfigAsCodeRules,
+	control.ConfigAsCodeSchedules,
+	"webhooks",
+	"rbac_roles",
+	"rbac_bindings",
+	"secret_integrations",
+	"runs",
+	"events",
+}
+
+// restoreSelection narrows a restore to some entity kinds and, optionally,
+// one tenant. Tenant ownership comes from the "tenant" label on templates,
+// schedules, and runs and the "tenant" field on events; kinds without tenant
+// ownership are skipped by a tenant restore.
+type restoreSelection struct {
+	Kinds  map[string]bool `json:"-"`
+	Tenant string          `json:"tenant,omitempty"`
+}
+
+func parseRestoreSelection(include []string, tenant string) (restoreSelection, error) {
+	sel := restoreSelection{Kinds: map[string]bool{}, Tenant: strings.TrimSpace(tenant)}
+	for _, kind := range include {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" {
+			continue
+		}
+		known := false
+		for _, k := range restoreKinds {
+			if k == kind {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return restoreSelection{}, errors.New("unknown restore kind: " + kind)
+		}
+		sel.Kinds[kind] = true
+	}
+	return sel, nil
+}
+
+func (sel restoreSelection) active() bool {
+	return len(sel.Kinds) > 0 || sel.Tenant != ""
+}
+
+func (sel restoreSelection) wants(kind string) bool {
+	return len(sel.Kinds) == 0 || sel.Kinds[kind]
+}
+
+func (sel restoreSelection) ownedLabels(labels map[string]string) bool {
+	return sel.Tenant == "" || labels["tenant"] == sel.Tenant
+}
+
+func (sel restoreSelection) ownedEvent(e control.Event) bool {
+	if sel.Tenant == "" {
+		return true
+	}
+	tenant, _ := e.Fields["tenant"].(string)
+	return tenant == sel.Tenant
+}
+
+func (sel restoreSelection) kinds() []string {
+	out := make([]string, 0, len(restoreKinds))
+	for _, kind := range restoreKinds {
+		if sel.wants(kind) {
+			out = append(out, kind)
+		}
+	}
+	return out
+}
+
+// selectRestore narrows snap to the selection. The result always carries a
+// control-plane section so applyBackupSnapshot leaves unselected run history
+// and events alone. A tenant restore swaps only that tenant's runs and events
+// and keeps everyone else's current history.
+func (s *Server) selectRestore(baseDir string, snap backupSnapshot, sel restoreSelection) (backupSnapshot, error) {
+	if !sel.active() {
+		return snap, nil
+	}
+	src := snap.ControlPlane
+	if src == nil {
+		src = &controlPlaneSnapshot{}
+	}
+	cp := controlPlaneSnapshot{}
+	if sel.wants(control.ConfigAsCodeTemplates) {
+		cp.Templates = filterLabeled(src.Templates, func(t control.Template) map[string]string { return t.Labels }, sel.ownedLabels)
+	}
+	if sel.wants(control.ConfigAsCodeSchedules) {
+		cp.Schedules = filterLabeled(src.Schedules, func(t control.Schedule) map[string]string { return t.Labels }, sel.ownedLabels)
+	}
+	if sel.Tenant == "" {
+		if sel.wants(control.ConfigAsCodeRunbooks) {
+			cp.Runbooks = src.Runbooks
+		}
+		if sel.wants(control.ConfigAsCodeRules) {
+			cp.Rules = src.Rules
+		}
+		if sel.wants("webhooks") {
+			cp.Webhooks = src.Webhooks
+		}
+		if sel.wants("rbac_roles") {
+			cp.RBACRoles = src.RBACRoles
+		}
+		if sel.wants("rbac_bindings") {
+			cp.RBACBindings = src.RBACBindings
+		}
+		if sel.wants("secret_integrations") {
+			cp.SecretIntegrations = src.SecretIntegrations
+		}
+	}
+	out := backupSnapshot{Version: snap.Version, CreatedAt: snap.CreatedAt, ControlPlane: &cp}
+	if sel.wants("runs") && snap.Runs != nil {
+		out.Runs = append([]state.RunRecord{}, snap.Runs...)
+		if sel.Tenant != "" {
+			current, err := state.New(baseDir).ListRuns(100000)
+			if err != nil {
+				return backupSnapshot{}, err
+			}
+			out.Runs = filterLabeled(current, func(r state.RunRecord) map[string]string { return r.Labels }, func(labels map[string]string) bool {
+				return !sel.ownedLabels(labels)
+			})
+			out.Runs = append(out.Runs, filterLabeled(snap.Runs, func(r state.RunRecord) map[string]string { return r.Labels }, sel.ownedLabels)...)
+			sort.Slice(out.Runs, func(i, j int) bool { return out.Runs[i].StartedAt.Before(out.Runs[j].StartedAt) })
+		}
+	}
+	if sel.wants("events") && snap.Events != nil {
+		out.Events = append([]control.Event{}, snap.Events...)
+		if sel.Tenant != "" {
+			out.Events = out.Events[:0]
+			for _, e := range s.events.List() {
+				if !sel.ownedEvent(e) {
+					out.Events = append(out.Events, e)
+				}
+			}
+			for _, e := range snap.Events {
+				if sel.ownedEvent(e) {
+					out.Events = append(out.Events, e)
+				}
+			}
+			sort.SliceStable(out.Events, func(i, j int) bool { return out.Events[i].Time.Before(out.Events[j].Time) })
+		}
+	}
+	return out, nil
+}
+
+// replayToPointInTime rolls snap forward to until by appending the runs and
+// events recorded after the backup was taken, up to and including until.
+// Sections the backup did not capture are left out.
+func (s *Server) replayToPointInTime(baseDir string, snap *backupSnapshot, until time.Time) (map[string]int, error) {
+	if snap.CreatedAt.After(until) {
+		return nil, errors.New("backup was taken after point_in_time")
+	}
+	replayed := map[string]int{"runs": 0, "events": 0}
+	inWindow := func(t time.Time) bool { return t.After(snap.CreatedAt) && !t.After(until) }
+	if snap.Runs != nil {
+		seen := map[string]bool{}
+		for _, run := range snap.Runs {
+			seen[run.ID] = true
+		}
+		current, err := state.New(baseDir).ListRuns(100000)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range current {
+			if !seen[run.ID] && inWindow(run.StartedAt) {
+				snap.Runs = append(snap.Runs, run)
+				replayed["runs"]++
+			}
+		}
+	}
+	if snap.Events != nil {
+		for _, e := range s.events.List() {
+			if inWindow(e.Time) {
+				snap.Events = append(snap.Events, e)
+				replayed["events"]++
+			}
+		}
+	}
+	return replayed, nil
+}
+
+// restoreKindDiff reports, for one entity kind, which IDs a restore would
+// create or overwrite and how many are already identical.
+type restoreKindDiff struct {
+	Create    []string `json:"create"`
+	Overwrite []string `json:"overwrite"`
+	Unchanged int      `json:"unchanged"`
+}
+
+func diffRestoreByID[T any](live, restored []T, id func(T) string) restoreKindDiff {
+	current := map[string][]byte{}
+	for _, item := range live {
+		b, _ := json.Marshal(item)
+		current[id(item)] = b
+	}
+	out := restoreKindDiff{Create: []string{}, Overwrite: []string{}}
+	for _, item := range restored {
+		b, _ := json.Marshal(item)
+		have, ok := current[id(item)]
+		switch {
+		case !ok:
+			out.Create = append(out.Create, id(item))
+		case string(have) != string(b):
+			out.Overwrite = append(out.Overwrite, id(item))
+		default:
+			out.Unchanged++
+		}
+	}
+	sort.Strings(out.Create)
+	sort.Strings(out.Overwrite)
+	return out
+}
+
+// diffRestore describes what applying snap would change without touching
+// any store. Run history and the event log are replaced wholesale, so they
+// are summarised as current versus restored counts.
+func (s *Server) diffRestore(baseDir string, snap backupSnapshot, sel restoreSelection) (map[string]any, error) {
+	out := map[string]any{}
+	if snap.ControlPlane != nil {
+		live := s.buildControlPlaneSnapshot()
+		cp := snap.ControlPlane
+		diffs := map[string]restoreKindDiff{
+			control.ConfigAsCodeTemplates: diffRestoreByID(live.Templates, cp.Templates, func(v control.Template) string { return v.ID }),
+			control.ConfigAsCodeRunbooks:  diffRestoreByID(live.Runbooks, cp.Runbooks, func(v control.Runbook) string { return v.ID }),
+			control.ConfigAsCodeRules:     diffRestoreByID(live.Rules, cp.Rules, func(v control.Rule) string { return v.ID }),
+			control.ConfigAsCodeSchedules: diffRestoreByID(live.Schedules, cp.Schedules, func(v control.Schedule) string { return v.ID }),
+			"webhooks":                    diffRestoreByID(live.Webhooks, cp.Webhooks, func(v control.WebhookSubscription) string { return v.ID }),
+			"rbac_roles":                  diffRestoreByID(live.RBACRoles, cp.RBACRoles, func(v control.RBACRole) string { return v.ID }),
+			"rbac_bindings":               diffRestoreByID(live.RBACBindings, cp.RBACBindings, func(v control.RBACBinding) string { return v.ID }),
+			"secret_integrations":         diffRestoreByID(live.SecretIntegrations, cp.SecretIntegrations, func(v control.SecretsIntegration) string { return v.ID }),
+		}
+		for kind, diff := range diffs {
+			if sel.wants(kind) {
+				out[kind] = diff
+			}
+		}
+	}
+	if snap.ControlPlane == nil || snap.Runs != nil {
+		current, err := state.New(baseDir).ListRuns(100000)
+		if err != nil {
+			return nil, err
+		}
+		out["runs"] = map[string]int{"current": len(current), "restored": len(snap.Runs)}
+	}
+	if snap.ControlPlane == nil || snap.Events != nil {
+		out["events"] = map[string]int{"current": s.events.Len(), "restored": len(snap.Events)}
+	}
+	return out, nil
+}
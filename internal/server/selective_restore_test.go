@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestSelectiveRestoreDryRunTenantAndPointInTime(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	grantControlAdmin(t, s, "restore-admin")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "restore-admin")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	st := state.New(tmp)
+
+	acme := s.templates.Create(control.Template{Name: "acme web", ConfigPath: "acme.yaml", Labels: map[string]string{"tenant": "acme"}})
+	globex := s.templates.Create(control.Template{Name: "globex web", ConfigPath: "globex.yaml", Labels: map[string]string{"tenant": "globex"}})
+	if err := st.SaveRun(state.RunRecord{ID: "run-before", StartedAt: time.Now().UTC().Add(-time.Second), Status: state.RunSucceeded}); err != nil {
+		t.Fatal(err)
+	}
+	s.events.Append(control.Event{Type: "test.acme", Fields: map[string]any{"tenant": "acme"}})
+
+	rr := do(http.MethodPost, "/v1/control/backup", `{"include_runs":true,"include_events":true,"include_control_plane":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("backup failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var backup struct {
+		Object struct {
+			Key string `json:"key"`
+		} `json:"object"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &backup)
+
+	time.Sleep(5 * time.Millisecond)
+	for _, tpl := range []control.Template{acme, globex} {
+		tpl.Name += " (edited)"
+		if _, err := s.templates.Restore(tpl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.SaveRun(state.RunRecord{ID: "run-after", StartedAt: time.Now().UTC(), Status: state.RunSucceeded}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/restore", `{"key":"`+backup.Object.Key+`","include":["widgets"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown kind to be rejected, got code=%d", rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/v1/control/restore", `{"key":"`+backup.Object.Key+`","include":["templates"],"tenant":"acme","dry_run":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("dry run failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var dry struct {
+		Status string `json:"status"`
+		Diff   struct {
+			Templates restoreKindDiff `json:"templates"`
+		} `json:"diff"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &dry)
+	if dry.Status != "dry_run" || len(dry.Diff.Templates.Overwrite) != 1 || dry.Diff.Templates.Overwrite[0] != acme.ID {
+		t.Fatalf("expected dry run to report only acme template overwrite, got %s", rr.Body.String())
+	}
+	if got, _ := s.templates.Get(acme.ID); got.Name != "acme web (edited)" {
+		t.Fatalf("expected dry run to leave templates untouched, got %+v", got)
+	}
+
+	rr = do(http.MethodPost, "/v1/control/restore", `{"key":"`+backup.Object.Key+`","include":["templates"],"tenant":"acme"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("selective restore failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if got, _ := s.templates.Get(acme.ID); got.Name != "acme web" {
+		t.Fatalf("expected acme template restored, got %+v", got)
+	}
+	if got, _ := s.templates.Get(globex.ID); got.Name != "globex web (edited)" {
+		t.Fatalf("expected other tenant template untouched, got %+v", got)
+	}
+	if runs, _ := st.ListRuns(10); len(runs) != 2 {
+		t.Fatalf("expected template-only restore to keep run history, got %+v", runs)
+	}
+
+	// Point-in-time: the backup plus runs recorded after it, up to now.
+	if err := st.ReplaceRuns([]state.RunRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	pit := time.Now().UTC().Format(time.RFC3339Nano)
+	rr = do(http.MethodPost, "/v1/control/restore", `{"prefix":"backups","point_in_time":"`+pit+`","include":["runs"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("point-in-time restore failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	runs, err := st.ListRuns(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != "run-before" {
+		t.Fatalf("expected only backed-up run once current history is cleared, got %+v", runs)
+	}
+	if err := st.SaveRun(state.RunRecord{ID: "run-later", StartedAt: time.Now().UTC(), Status: state.RunSucceeded}); err != nil {
+		t.Fatal(err)
+	}
+	rr = do(http.MethodPost, "/v1/control/restore", `{"prefix":"backups","point_in_time":"`+time.Now().UTC().Format(time.RFC3339Nano)+`","include":["runs"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("point-in-time restore failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var restored struct {
+		Replayed map[string]int `json:"replayed"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &restored)
+	if runs, _ := st.ListRuns(10); len(runs) != 2 || restored.Replayed["runs"] != 1 {
+		t.Fatalf("expected backup plus replayed run, got runs=%+v body=%s", runs, rr.Body.String())
+	}
+
+	past := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339Nano)
+	if rr := do(http.MethodPost, "/v1/control/restore", `{"key":"`+backup.Object.Key+`","point_in_time":"`+past+`"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected point_in_time before backup to be rejected, got code=%d", rr.Code)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/masterchef/masterchef/internal/control"
 	"github.com/masterchef/masterchef/internal/features"
+	"github.com/masterchef/masterchef/internal/provider"
 	"github.com/masterchef/masterchef/internal/state"
 	"github.com/masterchef/masterchef/internal/storage"
 )
@@ -35,6 +38,7 @@ type Server struct {
 	scheduler              *control.Scheduler
 	templates              *control.TemplateStore
 	wizards                *control.WorkflowWizardCatalog
+	wizardSessions         *control.WorkflowWizardSessionStore
 	tasks                  *control.TaskFrameworkStore
 	workflows              *control.WorkflowStore
 	runbooks               *control.RunbookStore
@@ -44,6 +48,7 @@ type Server struct {
 	adhocCommands          *control.AdHocCommandStore
 	convergeTriggers       *control.ConvergeTriggerStore
 	exportedResources      *control.ExportedResourceStore
+	configAsCode           *control.ConfigAsCodeStore
 	canaries               *control.CanaryStore
 	rules                  *control.RuleEngine
 	webhooks               *control.WebhookDispatcher
@@ -54,12 +59,22 @@ type Server struct {
 	ticketIntegrations     *control.TicketIntegrationStore
 	checklists             *control.ChecklistStore
 	views                  *control.SavedViewStore
+	trash                  *control.TrashStore
+	entityVersions         *control.EntityVersionHistory
+	stewardship            *control.StewardshipStore
+	annotations            *control.AnnotationStore
+	fleetHealthScoring     *control.FleetHealthScoringStore
+	hostMaintenance        *control.HostMaintenanceStore
 	accessibility          *control.AccessibilityStore
 	progressiveDisclosure  *control.ProgressiveDisclosureStore
 	shortcuts              *control.UIShortcutCatalog
 	dashboardWidgets       *control.DashboardWidgetStore
+	homePreferences        *control.HomePreferenceStore
 	bulk                   *control.BulkManager
 	actionDocs             *control.ActionDocCatalog
+	messages               *control.MessageCatalog
+	docsExamples           *control.DocsExampleVerifier
+	docsBundles            *control.DocumentationBundleStore
 	objectModel            *control.ObjectModelRegistry
 	moduleScaffold         *control.ModuleScaffoldCatalog
 	migrations             *control.MigrationStore
@@ -70,17 +85,24 @@ type Server struct {
 	workspaceTemplates     *control.WorkspaceTemplateCatalog
 	channels               *control.ChannelManager
 	dependencyUpdates      *control.DependencyUpdateStore
+	dependencyExecutor     *control.DependencyUpdateExecutor
 	flakes                 *control.FlakeQuarantineStore
 	scenarioTests          *control.ScenarioTestStore
+	scenarioExecutor       *control.ScenarioExecutor
+	testImpact             *control.TestImpactSelector
 	providerConformance    *control.ProviderConformanceStore
+	conformanceRunner      *control.ProviderConformanceRunner
 	providerFixtureHarness *control.ProviderFixtureHarnessStore
 	ephemeralTestEnv       *control.EphemeralEnvironmentStore
 	chaosExperiments       *control.ChaosExperimentStore
+	chaosInjector          *control.ChaosInjector
 	leakDetection          *control.LeakDetectionStore
+	leakSampler            *control.LeakSampler
 	performanceGates       *control.PerformanceGateStore
 	loadSoak               *control.LoadSoakStore
 	readinessScorecards    *control.ReadinessScorecardStore
 	mutationTests          *control.MutationStore
+	mutationExecutor       *control.MutationExecutor
 	propertyHarness        *control.PropertyHarnessStore
 	modulePolicyHarness    *control.ModulePolicyHarnessStore
 	styleAnalyzer          *control.StyleAnalyzer
@@ -88,19 +110,32 @@ type Server struct {
 	providerSandbox        *control.ProviderSandboxStore
 	providerProtocols      *control.ProviderProtocolStore
 	healthProbes           *control.HealthProbeStore
+	healthProbeRunner      *control.HealthProbeRunner
 	canaryUpgrades         *control.CanaryUpgradeStore
+	canaryAnalyses         *control.CanaryAnalysisStore
 	upgradeOrchestration   *control.UpgradeOrchestrationStore
+	upgradeDrillLaunch     upgradeDrillLauncher
+	responseCache          *control.ResponseCache
 	failoverDrills         *control.RegionalFailoverDrillStore
 	performanceDiagnostics *control.PerformanceDiagnosticsStore
+	profiler               *control.ContinuousProfiler
 	topologyPlacement      *control.TopologyPlacementStore
 	federation             *control.FederationStore
+	federationForwarder    *control.FederationForwarder
+	federationToken        string
+	follower               *followerState
 	schedulerPartitions    *control.SchedulerPartitionStore
+	partitionDispatch      *control.PartitionDispatcher
 	workerAutoscaling      *control.WorkerAutoscalingStore
 	costScheduling         *control.CostSchedulingStore
 	artifactDistribution   *control.ArtifactDistributionStore
+	artifactReplication    *control.ArtifactReplicationStore
+	backupSchedules        *control.BackupScheduleStore
+	runDigestSchedules     *control.RunDigestScheduleStore
 	workspaceIsolation     *control.WorkspaceIsolationStore
 	tenantCrypto           *control.TenantCryptoStore
 	delegatedAdmin         *control.DelegatedAdminStore
+	delegatedKeys          *control.DelegatedAPIKeyStore
 	tenantLimits           *control.TenantLimitStore
 	schemaMigs             *control.SchemaMigrationManager
 	openSchemas            *control.OpenSchemaStore
@@ -108,6 +143,9 @@ type Server struct {
 	roleEnv                *control.RoleEnvironmentStore
 	encryptedVars          *control.EncryptedVariableStore
 	facts                  *control.FactCache
+	grainModules           *control.GrainModuleStore
+	agentBeacons           *control.AgentBeaconStore
+	factMine               *control.FactMineStore
 	varSources             *control.VariableSourceRegistry
 	discoveryInventory     *control.DiscoveryInventoryStore
 	inventoryDrift         *control.InventoryDriftStore
@@ -117,6 +155,7 @@ type Server struct {
 	nodeClassification     *control.NodeClassificationStore
 	plugins                *control.PluginExtensionStore
 	eventBus               *control.EventBus
+	eventSchemas           *control.EventSchemaRegistry
 	nodes                  *control.NodeLifecycleStore
 	gitopsPreviews         *control.GitOpsPreviewStore
 	gitopsPromotions       *control.GitOpsPromotionStore
@@ -127,14 +166,18 @@ type Server struct {
 	fileSync               *control.FileSyncStore
 	agentCheckins          *control.AgentCheckinStore
 	agentDispatch          *control.AgentDispatchStore
+	agentTransports        *control.AgentTransportHub
 	proxyMinions           *control.ProxyMinionStore
 	networkTransports      *control.NetworkTransportCatalog
+	deviceModules          *control.DeviceModuleRegistry
 	portableRunners        *control.PortableRunnerCatalog
 	nativeSchedulers       *control.NativeSchedulerCatalog
 	adaptiveConcurrency    *control.AdaptiveConcurrencyStore
 	disruptionBudgets      *control.DisruptionBudgetStore
 	executionEnvs          *control.ExecutionEnvironmentStore
 	executionCreds         *control.ExecutionCredentialStore
+	isolatedRunner         *control.IsolatedRunner
+	cloudCredentials       *control.CloudCredentialBroker
 	packageManagers        *control.PackageManagerAbstractionStore
 	systemdUnits           *control.SystemdUnitStore
 	rebootOrchestration    *control.RebootOrchestrationStore
@@ -152,12 +195,17 @@ type Server struct {
 	encryptedSecrets       *control.EncryptedSecretStore
 	delegationTokens       *control.DelegationTokenStore
 	accessApprovals        *control.AccessApprovalStore
+	breakGlassSweep        context.CancelFunc
 	jitGrants              *control.JITAccessGrantStore
 	compliance             *control.ComplianceStore
+	secretScans            *control.SecretScanStore
 	rbac                   *control.RBACStore
 	abac                   *control.ABACStore
+	admissionPolicies      *control.AdmissionPolicyStore
 	identity               *control.IdentityStore
 	scim                   *control.SCIMStore
+	scimDirectory          *control.SCIMDirectory
+	scimToken              string
 	oidcWorkload           *control.OIDCWorkloadStore
 	mtls                   *control.MTLSStore
 	secretIntegrations     *control.SecretsIntegrationStore
@@ -165,7 +213,12 @@ type Server struct {
 	packageRegistry        *control.PackageRegistryStore
 	cosignVerification     *control.CosignVerificationStore
 	contentChannels        *control.ContentChannelStore
+	contentMirror          *control.ContentMirror
+	artifactScans          *control.ArtifactScanStore
+	provenanceAttestations *control.ProvenanceAttestationStore
 	agentPKI               *control.AgentPKIStore
+	acmeChallenges         *control.ACMEChallengeStore
+	serverCerts            *control.ServerCertificateStore
 	agentCatalogs          *control.AgentCatalogStore
 	agentAttestation       *control.AgentAttestationStore
 	driftPolicies          *control.DriftPolicyStore
@@ -179,6 +232,14 @@ type Server struct {
 	runCancel              context.CancelFunc
 	metricsMu              sync.Mutex
 	metrics                map[string]int64
+	compressionMu          sync.RWMutex
+	compression            responseCompressionSettings
+	bodyLimitsMu           sync.RWMutex
+	bodyLimits             requestBodyLimits
+	logger                 *slog.Logger
+	logLevel               *slog.LevelVar
+	logFormat              string
+	shutdownDrain          time.Duration
 
 	backlogSamples    []backlogSample
 	backlogWarnActive bool
@@ -191,8 +252,15 @@ type backlogSample struct {
 }
 
 func New(addr, baseDir string) *Server {
+	exportedResources := control.NewExportedResourceStore(5000)
 	runner := control.NewRunner(baseDir)
-	queue := control.NewQueue(512)
+	runner.SetExportedResources(exportedResources)
+	queueBuffer := readIntEnv("MC_QUEUE_BUFFER", 512)
+	queue := control.NewQueue(queueBuffer)
+	_, _ = queue.SetShardPolicy(control.QueueShardPolicy{
+		DefaultCapacity: readIntEnv("MC_QUEUE_SHARD_CAPACITY", 0),
+		MaxOverflow:     readIntEnv("MC_QUEUE_MAX_OVERFLOW", 10*queueBuffer),
+	})
 	queueBackends := control.NewQueueBackendStore()
 	backlogThreshold := readIntEnv("MC_QUEUE_BACKLOG_SLO_THRESHOLD", 100)
 	queueBacklogSLO := control.NewQueueBacklogSLOStore(backlogThreshold, 5000)
@@ -201,8 +269,19 @@ func New(addr, baseDir string) *Server {
 	stepSnapshots := control.NewStepSnapshotStore(20_000)
 	executionLocks := control.NewExecutionLockStore()
 	checkpoints := control.NewExecutionCheckpointStore()
+	chaosExperiments := control.NewChaosExperimentStore()
+	chaosInjector := control.NewChaosInjector(chaosExperiments, queue)
+	executionEnvs := control.NewExecutionEnvironmentStore()
+	executionCreds := control.NewExecutionCredentialStore()
+	isolatedRunner := control.NewIsolatedRunner(baseDir, runner, executionEnvs, executionCreds)
+	changeRecords := control.NewChangeRecordStore()
+	roleEnv := control.NewRoleEnvironmentStore(baseDir)
+	guardrailExec := control.NewGuardrailExecutor(isolatedRunner, roleEnv, func(id string) bool {
+		rec, err := changeRecords.RecheckApproval(id)
+		return err == nil && rec.Status == control.ChangeRecordApproved
+	})
 	runCtx, runCancel := context.WithCancel(context.Background())
-	queue.StartWorker(runCtx, runner)
+	queue.StartWorker(runCtx, chaosInjector.WrapExecutor(guardrailExec))
 	scheduler := control.NewScheduler(queue)
 	templates := control.NewTemplateStore()
 	wizards := control.NewWorkflowWizardCatalog()
@@ -214,14 +293,13 @@ func New(addr, baseDir string) *Server {
 	commands := control.NewCommandIngestStore(5000)
 	adhocCommands := control.NewAdHocCommandStore(5000)
 	convergeTriggers := control.NewConvergeTriggerStore(5000)
-	exportedResources := control.NewExportedResourceStore(5000)
 	canaries := control.NewCanaryStore(queue)
 	rules := control.NewRuleEngine()
 	webhooks := control.NewWebhookDispatcher(5000)
+	webhooks.SetChaosInjector(chaosInjector)
 	alerts := control.NewAlertInbox()
 	notifications := control.NewNotificationRouter(5000)
 	reportProcessors := control.NewReportProcessorStore()
-	changeRecords := control.NewChangeRecordStore()
 	ticketIntegrations := control.NewTicketIntegrationStore()
 	checklists := control.NewChecklistStore()
 	views := control.NewSavedViewStore()
@@ -231,6 +309,8 @@ func New(addr, baseDir string) *Server {
 	dashboardWidgets := control.NewDashboardWidgetStore()
 	bulk := control.NewBulkManager(15 * time.Minute)
 	actionDocs := control.NewActionDocCatalog()
+	messages := control.NewMessageCatalog()
+	docsExamples := control.NewDocsExampleVerifier(actionDocs, "")
 	objectModel := control.NewObjectModelRegistry()
 	moduleScaffold := control.NewModuleScaffoldCatalog()
 	migrations := control.NewMigrationStore()
@@ -246,11 +326,11 @@ func New(addr, baseDir string) *Server {
 	providerConformance := control.NewProviderConformanceStore()
 	providerFixtureHarness := control.NewProviderFixtureHarnessStore(3000)
 	ephemeralTestEnv := control.NewEphemeralEnvironmentStore()
-	chaosExperiments := control.NewChaosExperimentStore()
 	leakDetection := control.NewLeakDetectionStore()
 	performanceGates := control.NewPerformanceGateStore()
 	loadSoak := control.NewLoadSoakStore()
 	readinessScorecards := control.NewReadinessScorecardStore()
+	conformanceRunner := control.NewProviderConformanceRunner(providerConformance, provider.NewBuiltinRegistry(), readinessScorecards, "")
 	mutationTests := control.NewMutationStore()
 	propertyHarness := control.NewPropertyHarnessStore()
 	modulePolicyHarness := control.NewModulePolicyHarnessStore()
@@ -265,20 +345,24 @@ func New(addr, baseDir string) *Server {
 	performanceDiagnostics := control.NewPerformanceDiagnosticsStore()
 	topologyPlacement := control.NewTopologyPlacementStore()
 	federation := control.NewFederationStore()
+	federationForwarder := control.NewFederationForwarder(federation, os.Getenv("MC_FEDERATION_REGION"))
 	schedulerPartitions := control.NewSchedulerPartitionStore()
+	partitionDispatch := control.NewPartitionDispatcher(queue, schedulerPartitions)
 	workerAutoscaling := control.NewWorkerAutoscalingStore()
 	costScheduling := control.NewCostSchedulingStore()
 	artifactDistribution := control.NewArtifactDistributionStore()
 	workspaceIsolation := control.NewWorkspaceIsolationStore()
-	tenantCrypto := control.NewTenantCryptoStore()
+	tenantCrypto := control.NewTenantCryptoStore(baseDir)
+	tenantCrypto.SetMasterSecret(os.Getenv("MC_TENANT_KEY_SECRET"))
 	delegatedAdmin := control.NewDelegatedAdminStore()
 	tenantLimits := control.NewTenantLimitStore()
 	schemaMigs := control.NewSchemaMigrationManager(1)
 	openSchemas := control.NewOpenSchemaStore()
 	dataBags := control.NewDataBagStore()
-	roleEnv := control.NewRoleEnvironmentStore(baseDir)
 	encryptedVars := control.NewEncryptedVariableStore(baseDir)
 	facts := control.NewFactCache(5 * time.Minute)
+	runner.SetFactCache(facts)
+	factMine := control.NewFactMineStore()
 	varSources := control.NewVariableSourceRegistry(baseDir)
 	discoveryInventory := control.NewDiscoveryInventoryStore()
 	inventoryDrift := control.NewInventoryDriftStore()
@@ -288,6 +372,8 @@ func New(addr, baseDir string) *Server {
 	nodeClassification := control.NewNodeClassificationStore()
 	plugins := control.NewPluginExtensionStore()
 	eventBus := control.NewEventBus()
+	eventSchemas := control.NewEventSchemaRegistry(readIntEnv("MC_EVENT_SCHEMA_VIOLATION_LIMIT", 1000))
+	eventBus.SetSchemaLookup(eventSchemas.SchemaRef)
 	nodes := control.NewNodeLifecycleStore()
 	gitopsPreviews := control.NewGitOpsPreviewStore()
 	gitopsPromotions := control.NewGitOpsPromotionStore()
@@ -304,8 +390,6 @@ func New(addr, baseDir string) *Server {
 	nativeSchedulers := control.NewNativeSchedulerCatalog()
 	adaptiveConcurrency := control.NewAdaptiveConcurrencyStore()
 	disruptionBudgets := control.NewDisruptionBudgetStore()
-	executionEnvs := control.NewExecutionEnvironmentStore()
-	executionCreds := control.NewExecutionCredentialStore()
 	packageManagers := control.NewPackageManagerAbstractionStore()
 	systemdUnits := control.NewSystemdUnitStore()
 	rebootOrchestration := control.NewRebootOrchestrationStore()
@@ -321,29 +405,49 @@ func New(addr, baseDir string) *Server {
 	signatureAdmission := control.NewSignatureAdmissionStore()
 	runtimeSecrets := control.NewRuntimeSecretStore()
 	encryptedSecrets := control.NewEncryptedSecretStore()
+	workflows.SetSecretStore(encryptedSecrets)
 	delegationTokens := control.NewDelegationTokenStore()
 	accessApprovals := control.NewAccessApprovalStore()
 	jitGrants := control.NewJITAccessGrantStore()
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("MC_JIT_ENFORCEMENT"))) {
+	case "true", control.JITEnforcementEnforce:
+		_, _ = jitGrants.SetEnforcementPolicy(control.JITEnforcementPolicy{Mode: control.JITEnforcementEnforce})
+	case "false", control.JITEnforcementOff:
+		_, _ = jitGrants.SetEnforcementPolicy(control.JITEnforcementPolicy{Mode: control.JITEnforcementOff})
+	}
 	compliance := control.NewComplianceStore()
 	rbac := control.NewRBACStore()
 	abac := control.NewABACStore()
+	admissionPolicies := control.NewAdmissionPolicyStore()
 	identity := control.NewIdentityStore()
 	scim := control.NewSCIMStore()
+	scimDirectory := control.NewSCIMDirectory()
 	oidcWorkload := control.NewOIDCWorkloadStore()
 	mtls := control.NewMTLSStore()
 	secretIntegrations := control.NewSecretsIntegrationStore()
+	cloudCredentials := control.NewCloudCredentialBroker(oidcWorkload, secretIntegrations)
+	isolatedRunner.SetCloudCredentialBroker(cloudCredentials)
 	packagePinning := control.NewPackagePinStore()
 	packageRegistry := control.NewPackageRegistryStore()
 	cosignVerification := control.NewCosignVerificationStore()
 	contentChannels := control.NewContentChannelStore()
 	agentPKI := control.NewAgentPKIStore()
+	if publicURL := strings.TrimRight(strings.TrimSpace(os.Getenv("MC_PUBLIC_URL")), "/"); publicURL != "" {
+		_, _ = agentPKI.SetRevocationConfig(control.AgentRevocationConfig{DistributionURL: publicURL + "/v1/agents/pki/crl"})
+	}
+	mtls.SetRevocationChecker(agentPKI)
+	hopRelay.SetRevocationChecker(agentPKI)
+	acmeChallenges := control.NewACMEChallengeStore()
+	serverCerts := control.NewServerCertificateStore(filepath.Join(baseDir, ".masterchef", "tls"), acmeChallenges)
 	agentCatalogs := control.NewAgentCatalogStore()
 	agentAttestation := control.NewAgentAttestationStore()
 	driftPolicies := control.NewDriftPolicyStore()
 	policyBundles := control.NewPolicyBundleStore()
 	policyPull := control.NewPolicyPullStore()
 	multiMaster := control.NewMultiMasterStore()
+	multiMaster.SetLocalNode(localMasterNodeID())
 	edgeRelay := control.NewEdgeRelayStore()
+	edgeRelay.SetRevocationChecker(agentPKI)
 	offline := control.NewOfflineStore()
 	objectStore, err := storage.NewObjectStoreFromEnv(baseDir)
 	if err != nil {
@@ -353,7 +457,55 @@ func New(addr, baseDir string) *Server {
 			objectStore = fallback
 		}
 	}
-	events := control.NewEventStore(20_000)
+	if objectStore != nil {
+		runner.SetArtifactStore(objectStore)
+	}
+	profiler := control.NewContinuousProfiler(func(key string, data []byte) error {
+		if objectStore == nil {
+			return errors.New("object store unavailable")
+		}
+		_, err := objectStore.Put(key, data, "application/octet-stream")
+		return err
+	})
+	contentMirror := control.NewContentMirror(contentChannels, packageRegistry, func(data []byte) (string, error) {
+		return storePackageTarball(objectStore, data)
+	})
+	mutationExecutor := control.NewMutationExecutor(mutationTests, modulePolicyHarness, func(key string, data []byte) error {
+		if objectStore == nil {
+			return errors.New("object store unavailable")
+		}
+		_, err := objectStore.Put(key, data, "application/json")
+		return err
+	}, baseDir)
+	scenarioExecutor := control.NewScenarioExecutor(scenarioTests, "")
+	dependencyExecutor := control.NewDependencyUpdateExecutor(dependencyUpdates, packageRegistry, scenarioTests, scenarioExecutor, func(artifact control.PackageArtifact) ([]byte, error) {
+		if objectStore == nil {
+			return nil, errors.New("object store unavailable")
+		}
+		data, _, err := objectStore.Get(artifact.ObjectKey)
+		return data, err
+	}, "")
+	docsBundles := control.NewDocumentationBundleStore(func(key string, data []byte, contentType string) error {
+		if objectStore == nil {
+			return errors.New("object store unavailable")
+		}
+		_, err := objectStore.Put(key, data, contentType)
+		return err
+	})
+	readinessScorecards.SetBlockerCheck(func(_, service string) []string {
+		return append(mutationTests.ReadinessBlockers(strings.TrimPrefix(service, "provider/")), docsExamples.ReadinessBlockers()...)
+	})
+	provenanceAttestations := control.NewProvenanceAttestationStore(packageRegistry)
+	artifactScans := control.NewArtifactScanStore(packageRegistry, imageBaking, control.NewOSVFeed(os.Getenv("MC_OSV_URL"), nil))
+	events := control.NewEventStore(readIntEnv("MC_EVENT_STORE_LIMIT", 20_000))
+	events.SetSpillDir(filepath.Join(baseDir, ".masterchef", "event-spill"))
+	if shardBy := strings.TrimSpace(os.Getenv("MC_EVENT_SHARD_BY")); shardBy != "" {
+		_, _ = events.SetShardPolicy(control.EventShardPolicy{
+			ShardBy:         shardBy,
+			DefaultCapacity: readIntEnv("MC_EVENT_SHARD_CAPACITY", 0),
+			Spill:           strings.EqualFold(strings.TrimSpace(os.Getenv("MC_EVENT_SPILL")), "true"),
+		})
+	}
 
 	mux := http.NewServeMux()
 	s := &Server{
@@ -369,6 +521,7 @@ func New(addr, baseDir string) *Server {
 		scheduler:              scheduler,
 		templates:              templates,
 		wizards:                wizards,
+		wizardSessions:         control.NewWorkflowWizardSessionStore(wizards, baseDir),
 		tasks:                  tasks,
 		workflows:              workflows,
 		runbooks:               runbooks,
@@ -378,6 +531,7 @@ func New(addr, baseDir string) *Server {
 		adhocCommands:          adhocCommands,
 		convergeTriggers:       convergeTriggers,
 		exportedResources:      exportedResources,
+		configAsCode:           control.NewConfigAsCodeStore(),
 		canaries:               canaries,
 		rules:                  rules,
 		webhooks:               webhooks,
@@ -388,12 +542,22 @@ func New(addr, baseDir string) *Server {
 		ticketIntegrations:     ticketIntegrations,
 		checklists:             checklists,
 		views:                  views,
+		trash:                  control.NewTrashStore(),
+		entityVersions:         control.NewEntityVersionHistory(100),
+		stewardship:            control.NewStewardshipStore(),
+		annotations:            control.NewAnnotationStore(),
+		fleetHealthScoring:     control.NewFleetHealthScoringStore(0),
+		hostMaintenance:        control.NewHostMaintenanceStore(),
 		accessibility:          accessibility,
 		progressiveDisclosure:  progressiveDisclosure,
 		shortcuts:              shortcuts,
 		dashboardWidgets:       dashboardWidgets,
+		homePreferences:        control.NewHomePreferenceStore(baseDir),
 		bulk:                   bulk,
 		actionDocs:             actionDocs,
+		messages:               messages,
+		docsExamples:           docsExamples,
+		docsBundles:            docsBundles,
 		objectModel:            objectModel,
 		moduleScaffold:         moduleScaffold,
 		migrations:             migrations,
@@ -404,17 +568,23 @@ func New(addr, baseDir string) *Server {
 		workspaceTemplates:     workspaceTemplates,
 		channels:               channels,
 		dependencyUpdates:      dependencyUpdates,
+		dependencyExecutor:     dependencyExecutor,
 		flakes:                 flakes,
 		scenarioTests:          scenarioTests,
+		scenarioExecutor:       scenarioExecutor,
+		testImpact:             control.NewTestImpactSelector(objectModel, scenarioTests, modulePolicyHarness, mutationTests, providerConformance, baseDir),
 		providerConformance:    providerConformance,
+		conformanceRunner:      conformanceRunner,
 		providerFixtureHarness: providerFixtureHarness,
 		ephemeralTestEnv:       ephemeralTestEnv,
 		chaosExperiments:       chaosExperiments,
+		chaosInjector:          chaosInjector,
 		leakDetection:          leakDetection,
 		performanceGates:       performanceGates,
 		loadSoak:               loadSoak,
 		readinessScorecards:    readinessScorecards,
 		mutationTests:          mutationTests,
+		mutationExecutor:       mutationExecutor,
 		propertyHarness:        propertyHarness,
 		modulePolicyHarness:    modulePolicyHarness,
 		styleAnalyzer:          styleAnalyzer,
@@ -423,18 +593,30 @@ func New(addr, baseDir string) *Server {
 		providerProtocols:      providerProtocols,
 		healthProbes:           healthProbes,
 		canaryUpgrades:         canaryUpgrades,
+		canaryAnalyses:         control.NewCanaryAnalysisStore(),
 		upgradeOrchestration:   upgradeOrchestration,
+		upgradeDrillLaunch:     launchShadowServerProcess,
+		responseCache:          control.NewResponseCache(readIntEnv("MC_RESPONSE_CACHE_MAX_ENTRIES", 256)),
 		failoverDrills:         failoverDrills,
 		performanceDiagnostics: performanceDiagnostics,
+		profiler:               profiler,
 		topologyPlacement:      topologyPlacement,
 		federation:             federation,
+		federationForwarder:    federationForwarder,
+		federationToken:        strings.TrimSpace(os.Getenv("MC_FEDERATION_TOKEN")),
+		follower:               newFollowerState(os.Getenv("MC_FOLLOWER_OF")),
 		schedulerPartitions:    schedulerPartitions,
+		partitionDispatch:      partitionDispatch,
 		workerAutoscaling:      workerAutoscaling,
 		costScheduling:         costScheduling,
 		artifactDistribution:   artifactDistribution,
+		artifactReplication:    control.NewArtifactReplicationStore(),
+		backupSchedules:        control.NewBackupScheduleStore(),
+		runDigestSchedules:     control.NewRunDigestScheduleStore(500),
 		workspaceIsolation:     workspaceIsolation,
 		tenantCrypto:           tenantCrypto,
 		delegatedAdmin:         delegatedAdmin,
+		delegatedKeys:          control.NewDelegatedAPIKeyStore(delegatedAdmin),
 		tenantLimits:           tenantLimits,
 		schemaMigs:             schemaMigs,
 		openSchemas:            openSchemas,
@@ -442,6 +624,9 @@ func New(addr, baseDir string) *Server {
 		roleEnv:                roleEnv,
 		encryptedVars:          encryptedVars,
 		facts:                  facts,
+		grainModules:           control.NewGrainModuleStore(),
+		agentBeacons:           control.NewAgentBeaconStore(),
+		factMine:               factMine,
 		varSources:             varSources,
 		discoveryInventory:     discoveryInventory,
 		inventoryDrift:         inventoryDrift,
@@ -451,6 +636,7 @@ func New(addr, baseDir string) *Server {
 		nodeClassification:     nodeClassification,
 		plugins:                plugins,
 		eventBus:               eventBus,
+		eventSchemas:           eventSchemas,
 		nodes:                  nodes,
 		gitopsPreviews:         gitopsPreviews,
 		gitopsPromotions:       gitopsPromotions,
@@ -461,14 +647,18 @@ func New(addr, baseDir string) *Server {
 		fileSync:               fileSync,
 		agentCheckins:          agentCheckins,
 		agentDispatch:          agentDispatch,
+		agentTransports:        control.NewAgentTransportHub(),
 		proxyMinions:           proxyMinions,
 		networkTransports:      networkTransports,
+		deviceModules:          control.NewDeviceModuleRegistry(),
 		portableRunners:        portableRunners,
 		nativeSchedulers:       nativeSchedulers,
 		adaptiveConcurrency:    adaptiveConcurrency,
 		disruptionBudgets:      disruptionBudgets,
 		executionEnvs:          executionEnvs,
 		executionCreds:         executionCreds,
+		isolatedRunner:         isolatedRunner,
+		cloudCredentials:       cloudCredentials,
 		packageManagers:        packageManagers,
 		systemdUnits:           systemdUnits,
 		rebootOrchestration:    rebootOrchestration,
@@ -488,10 +678,14 @@ func New(addr, baseDir string) *Server {
 		accessApprovals:        accessApprovals,
 		jitGrants:              jitGrants,
 		compliance:             compliance,
+		secretScans:            control.NewSecretScanStore(),
 		rbac:                   rbac,
 		abac:                   abac,
+		admissionPolicies:      admissionPolicies,
 		identity:               identity,
 		scim:                   scim,
+		scimDirectory:          scimDirectory,
+		scimToken:              strings.TrimSpace(os.Getenv("MC_SCIM_TOKEN")),
 		oidcWorkload:           oidcWorkload,
 		mtls:                   mtls,
 		secretIntegrations:     secretIntegrations,
@@ -499,7 +693,12 @@ func New(addr, baseDir string) *Server {
 		packageRegistry:        packageRegistry,
 		cosignVerification:     cosignVerification,
 		contentChannels:        contentChannels,
+		contentMirror:          contentMirror,
+		artifactScans:          artifactScans,
+		provenanceAttestations: provenanceAttestations,
 		agentPKI:               agentPKI,
+		acmeChallenges:         acmeChallenges,
+		serverCerts:            serverCerts,
 		agentCatalogs:          agentCatalogs,
 		agentAttestation:       agentAttestation,
 		driftPolicies:          driftPolicies,
@@ -511,16 +710,98 @@ func New(addr, baseDir string) *Server {
 		objectStore:            objectStore,
 		events:                 events,
 		metrics:                map[string]int64{},
+		compression:            loadResponseCompressionSettings(),
+		bodyLimits:             loadRequestBodyLimits(),
+		shutdownDrain:          time.Duration(readIntEnv("MC_SHUTDOWN_DRAIN_SECONDS", 30)) * time.Second,
 		runCancel:              runCancel,
 	}
+	events.OnAppend(func(e control.Event) { eventSchemas.Observe(e) })
+	s.logger, s.logLevel, s.logFormat = newServerLogger(os.Stderr)
 	s.httpServer = &http.Server{
 		Addr:              addr,
 		Handler:           s.wrapHTTP(mux),
 		ReadHeaderTimeout: 5 * time.Second,
-	}
+		ErrorLog:          slog.NewLogLogger(s.logger.Handler(), slog.LevelError),
+	}
+	chaosInjector.SetGuard(s.chaosSLOGuard)
+	chaosInjector.OnFinish(s.noteChaosExperimentFinished)
+	profiler.OnCapture(s.noteProfileCaptured)
+	profiler.Start()
+	s.leakSampler = control.NewLeakSampler(leakDetection, "control-plane", s.leakStoreSizes, s.noteLeakReport)
+	s.leakSampler.Start()
+	federationForwarder.OnUpdate(s.noteForwardedJobUpdated)
+	federationForwarder.Start(time.Duration(readIntEnv("MC_FEDERATION_SYNC_SECONDS", 10)) * time.Second)
+	contentMirror.OnFinish(s.noteContentSyncFinished)
+	artifactDeployments.SetAdmissionCheck(s.admitArtifactProvenance)
+	accessApprovals.SetApproverResolver(s.approverIdentity)
+	changeRecords.SetApprovalGate(s.changeRecordApprovalGate)
+	gitopsPromotions.SetApprovalGate(s.promotionApprovalGate)
+	encProviders.SetExecGuard(func(command string, args []string) error {
+		return s.guardExecCommand("enc exec provider", "MC_ENC_EXEC_COMMANDS", command, args)
+	})
+	healthProbes.SetExecGuard(func(command string, args []string) error {
+		return s.guardExecCommand("health probe", "MC_HEALTH_PROBE_COMMANDS", command, args)
+	})
+	s.agentBeacons.SetExecGuard(func(command string, args []string) error {
+		return s.guardExecCommand("agent beacon", "MC_BEACON_CHECK_COMMANDS", command, args)
+	})
+	s.grainModules.SetExecGuard(func(command string, args []string) error {
+		return s.guardExecCommand("grain module", "MC_GRAIN_MODULE_INTERPRETERS", command, args)
+	})
+	executionLocks.OnGrant(func(lock control.ExecutionLock) {
+		s.recordExecutionLockEvent("execution.lock.granted", "queued execution lock request granted", lock)
+	})
+	contentMirror.Start(time.Duration(readIntEnv("MC_CONTENT_SYNC_CHECK_SECONDS", 30)) * time.Second)
+	if seed := strings.TrimSpace(os.Getenv("MC_CONTROL_SNAPSHOT_SEED")); seed != "" {
+		if err := s.importControlSnapshotFile(seed); err != nil {
+			s.logger.Error("control snapshot seed failed", "path", seed, "error", err)
+		}
+	}
+	sweepCtx, sweepCancel := context.WithCancel(context.Background())
+	s.breakGlassSweep = sweepCancel
+	go s.sweepBreakGlass(sweepCtx, time.Duration(readIntEnv("MC_BREAK_GLASS_SWEEP_SECONDS", 15))*time.Second)
+	go s.sweepFlakeQuarantine(sweepCtx, time.Duration(readIntEnv("MC_FLAKE_REEVALUATE_SECONDS", 300))*time.Second)
+	go s.sweepExecutionLocks(sweepCtx, time.Duration(readIntEnv("MC_EXECUTION_LOCK_SWEEP_SECONDS", 15))*time.Second)
+	scheduler.SetHostMaintenanceCheck(s.hostMaintenance.InMaintenance)
+	scheduler.OnMaintenanceSkip(s.noteMaintenanceSkip)
+	exportedResources.SetHostExclusion(s.hostMaintenance.InMaintenance)
+	go s.sweepHostMaintenance(sweepCtx, time.Duration(readIntEnv("MC_HOST_MAINTENANCE_SWEEP_SECONDS", 15))*time.Second)
+	go s.sweepTicketPushes(sweepCtx, time.Duration(readIntEnv("MC_TICKET_PUSH_RETRY_SECONDS", 5))*time.Second)
+	go s.sweepArtifactReplication(sweepCtx, time.Duration(readIntEnv("MC_ARTIFACT_REPLICATION_SECONDS", 10))*time.Second)
+	go s.sweepBackupSchedules(sweepCtx, time.Duration(readIntEnv("MC_BACKUP_SCHEDULE_SWEEP_SECONDS", 30))*time.Second)
+	go s.sweepRunDigestSchedules(sweepCtx, time.Duration(readIntEnv("MC_RUN_DIGEST_SWEEP_SECONDS", 60))*time.Second)
+	go s.sweepAgentBeacons(sweepCtx, time.Duration(readIntEnv("MC_AGENT_BEACON_SWEEP_SECONDS", 5))*time.Second)
+	go s.sweepMultiMasterGossip(sweepCtx, time.Duration(readIntEnv("MC_MULTI_MASTER_GOSSIP_SECONDS", 15))*time.Second)
+	go s.sweepPolicyPromotions(sweepCtx, time.Duration(readIntEnv("MC_POLICY_PROMOTION_SWEEP_SECONDS", 300))*time.Second)
+	go s.sweepCommandRetries(sweepCtx, time.Duration(readIntEnv("MC_COMMAND_RETRY_SWEEP_SECONDS", 5))*time.Second)
+	go s.sweepNotificationEscalations(sweepCtx, time.Duration(readIntEnv("MC_NOTIFICATION_ESCALATION_SWEEP_SECONDS", 10))*time.Second)
+	go s.sweepAgentCRL(sweepCtx, time.Duration(readIntEnv("MC_AGENT_CRL_PUBLISH_SECONDS", 3600))*time.Second)
+	if s.follower.enabled() {
+		go s.sweepFollowerSync(sweepCtx, time.Duration(readIntEnv("MC_FOLLOWER_SYNC_SECONDS", 10))*time.Second)
+	}
+	s.healthProbeRunner = control.NewHealthProbeRunner(healthProbes, func(_ control.HealthProbeTarget, check control.HealthProbeCheck) {
+		s.noteHealthProbeCheck(check)
+	})
 
+	for _, loadErr := range roleEnv.LoadErrors() {
+		s.recordEvent(control.Event{
+			Type:    "policy.role_env.load_failed",
+			Message: loadErr.Kind + " definition failed to load",
+			Fields: map[string]any{
+				"severity": "high",
+				"kind":     loadErr.Kind,
+				"file":     loadErr.File,
+				"name":     loadErr.Name,
+				"error":    loadErr.Error,
+			},
+		}, true)
+	}
+	guardrailExec.SetViolationHandler(func(job control.Job, report control.GuardrailReport) {
+		s.recordGuardrailViolation("apply", job.ConfigPath, job.ID, report)
+	})
 	queue.Subscribe(func(job control.Job) {
 		if job.Status == control.JobSucceeded || job.Status == control.JobFailed || job.Status == control.JobCanceled {
+			s.responseCache.Invalidate(responseCacheTagRuns)
 			if released, ok := s.executionLocks.Release(control.ExecutionLockReleaseInput{JobID: job.ID}); ok {
 				s.recordEvent(control.Event{
 					Type:    "execution.lock.released",
@@ -559,6 +840,8 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/tasks/definitions/", s.handleTaskDefinitionByID)
 	mux.HandleFunc("/v1/wizards", s.handleWorkflowWizards)
 	mux.HandleFunc("/v1/wizards/", s.handleWorkflowWizardAction)
+	mux.HandleFunc("/v1/wizards/sessions", s.handleWorkflowWizardSessions)
+	mux.HandleFunc("/v1/wizards/sessions/", s.handleWorkflowWizardSessionAction)
 	mux.HandleFunc("/v1/tasks/plans", s.handleTaskPlans)
 	mux.HandleFunc("/v1/tasks/plans/", s.handleTaskPlanAction)
 	mux.HandleFunc("/v1/tasks/executions", s.handleTaskExecutions)
@@ -576,12 +859,23 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/docs/actions/", s.handleActionDocByID)
 	mux.HandleFunc("/v1/model/objects", s.handleObjectModel)
 	mux.HandleFunc("/v1/model/objects/resolve", s.handleObjectModelResolve)
+	mux.HandleFunc("/v1/model/dependencies", s.handleObjectDependencies(baseDir))
+	mux.HandleFunc("/v1/model/dependencies/impact", s.handleObjectDependencyImpact(baseDir))
 	mux.HandleFunc("/v1/docs/inline", s.handleInlineDocs)
 	mux.HandleFunc("/v1/docs/generate", s.handleDocsGenerate)
+	mux.HandleFunc("/v1/docs/bundles", s.handleDocsBundles)
+	mux.HandleFunc("/v1/docs/bundles/", s.handleDocsBundleFile)
 	mux.HandleFunc("/v1/docs/examples/verify", s.handleDocsExampleVerify)
+	mux.HandleFunc("/v1/i18n/locales", s.handleLocales)
+	mux.HandleFunc("/v1/i18n/completeness", s.handleLocaleCompleteness)
 	mux.HandleFunc("/v1/docs/api/version-diff", s.handleDocsAPIVersionDiff)
 	mux.HandleFunc("/v1/lint/style/rules", s.handleStyleAnalyzerRules)
 	mux.HandleFunc("/v1/lint/style/analyze", s.handleStyleAnalyzerAnalyze)
+	mux.HandleFunc("/v1/lint/style/content-rules", s.handleStyleContentRules)
+	mux.HandleFunc("/v1/lint/style/content-rules/", s.handleStyleContentRuleAction)
+	mux.HandleFunc("/v1/lint/style/check", s.handleStyleContentCheck(baseDir))
+	mux.HandleFunc("/v1/lint/style/waivers", s.handleStyleWaivers)
+	mux.HandleFunc("/v1/lint/style/waivers/", s.handleStyleWaiverAction)
 	mux.HandleFunc("/v1/format/canonicalize", s.handleCanonicalize)
 	mux.HandleFunc("/v1/release/readiness", s.handleReleaseReadiness)
 	mux.HandleFunc("/v1/release/readiness/scorecards", s.handleReadinessScorecards)
@@ -592,6 +886,7 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/release/dependency-bot/policy", s.handleDependencyUpdatePolicy)
 	mux.HandleFunc("/v1/release/dependency-bot/updates", s.handleDependencyUpdates)
 	mux.HandleFunc("/v1/release/dependency-bot/updates/", s.handleDependencyUpdateAction)
+	mux.HandleFunc("/v1/release/dependency-bot/executions", s.handleDependencyUpdateExecutions)
 	mux.HandleFunc("/v1/release/performance-gates/policy", s.handlePerformanceGatePolicy)
 	mux.HandleFunc("/v1/release/performance-gates/evaluate", s.handlePerformanceGateEvaluate)
 	mux.HandleFunc("/v1/release/performance-gates/evaluations", s.handlePerformanceGateEvaluations)
@@ -599,10 +894,12 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/release/tests/flake-observations", s.handleFlakeObservations)
 	mux.HandleFunc("/v1/release/tests/flake-cases", s.handleFlakeCases)
 	mux.HandleFunc("/v1/release/tests/flake-cases/", s.handleFlakeCaseAction)
-	mux.HandleFunc("/v1/release/tests/impact-analysis", s.handleTestImpactAnalysis)
+	mux.HandleFunc("/v1/release/tests/flake-reevaluate", s.handleFlakeReevaluate)
+	mux.HandleFunc("/v1/release/tests/impact-analysis", s.handleTestImpactAnalysis(baseDir))
 	mux.HandleFunc("/v1/release/tests/scenarios", s.handleTestScenarios)
 	mux.HandleFunc("/v1/release/tests/scenario-runs", s.handleTestScenarioRuns)
 	mux.HandleFunc("/v1/release/tests/scenario-runs/", s.handleTestScenarioRunAction)
+	mux.HandleFunc("/v1/release/tests/scenario-executions", s.handleTestScenarioExecutions)
 	mux.HandleFunc("/v1/release/tests/scenario-baselines", s.handleTestScenarioBaselines)
 	mux.HandleFunc("/v1/release/tests/scenario-baselines/", s.handleTestScenarioBaselineAction)
 	mux.HandleFunc("/v1/release/tests/environments", s.handleTestEnvironments)
@@ -614,6 +911,7 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/release/tests/mutation/suites", s.handleMutationSuites)
 	mux.HandleFunc("/v1/release/tests/mutation/runs", s.handleMutationRuns)
 	mux.HandleFunc("/v1/release/tests/mutation/runs/", s.handleMutationRunAction)
+	mux.HandleFunc("/v1/release/tests/mutation/executions", s.handleMutationExecutions)
 	mux.HandleFunc("/v1/release/tests/property-harness/cases", s.handlePropertyHarnessCases)
 	mux.HandleFunc("/v1/release/tests/property-harness/runs", s.handlePropertyHarnessRuns)
 	mux.HandleFunc("/v1/release/tests/property-harness/runs/", s.handlePropertyHarnessRunAction)
@@ -623,6 +921,7 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/providers/conformance/suites", s.handleProviderConformanceSuites)
 	mux.HandleFunc("/v1/providers/conformance/runs", s.handleProviderConformanceRuns)
 	mux.HandleFunc("/v1/providers/conformance/runs/", s.handleProviderConformanceRunAction)
+	mux.HandleFunc("/v1/providers/conformance/execute", s.handleProviderConformanceExecute)
 	mux.HandleFunc("/v1/providers/conformance/fixtures", s.handleProviderConformanceFixtures)
 	mux.HandleFunc("/v1/providers/conformance/fixtures/", s.handleProviderConformanceFixtureAction)
 	mux.HandleFunc("/v1/providers/conformance/harness/runs", s.handleProviderConformanceHarnessRuns)
@@ -650,11 +949,16 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/policy/pull/results", s.handlePolicyPullResults)
 	mux.HandleFunc("/v1/policy/bundles", s.handlePolicyBundles)
 	mux.HandleFunc("/v1/policy/bundles/", s.handlePolicyBundleAction)
+	mux.HandleFunc("/v1/policy/admission/policies", s.handleAdmissionPolicies)
+	mux.HandleFunc("/v1/policy/admission/policies/", s.handleAdmissionPolicyAction)
+	mux.HandleFunc("/v1/policy/admission/test", s.handleAdmissionPolicyTest)
+	mux.HandleFunc("/v1/policy/admission/evaluate", s.handleAdmissionEvaluate)
 	mux.HandleFunc("/v1/query", s.handleQuery(baseDir))
 	mux.HandleFunc("/v1/search", s.handleSearch(baseDir))
 	mux.HandleFunc("/v1/inventory/groups", s.handleInventoryGroups(baseDir))
 	mux.HandleFunc("/v1/inventory/export/bundle", s.handleInventoryExportBundle)
 	mux.HandleFunc("/v1/inventory/import/cmdb", s.handleInventoryCMDBImport)
+	mux.HandleFunc("/v1/inventory/import/cmdb/stream", s.handleInventoryCMDBImportStream)
 	mux.HandleFunc("/v1/inventory/import/bundle", s.handleInventoryImportBundle)
 	mux.HandleFunc("/v1/inventory/import/assist", s.handleInventoryImportAssistant)
 	mux.HandleFunc("/v1/inventory/import/brownfield-bootstrap", s.handleInventoryBrownfieldBootstrap)
@@ -669,6 +973,11 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/inventory/node-classifiers/", s.handleENCProviderAction)
 	mux.HandleFunc("/v1/compat/grains", s.handleCompatGrains)
 	mux.HandleFunc("/v1/compat/grains/query", s.handleCompatGrainsQuery)
+	mux.HandleFunc("/v1/compat/grains/modules", s.handleGrainModules)
+	mux.HandleFunc("/v1/compat/grains/modules/", s.handleGrainModuleByName)
+	mux.HandleFunc("/v1/compat/grains/agent/due", s.handleGrainModulesDue)
+	mux.HandleFunc("/v1/compat/grains/agent/results", s.handleGrainModuleResults)
+	mux.HandleFunc("/v1/compat/grains/agent/run", s.handleGrainModulesRun)
 	mux.HandleFunc("/v1/compat/shims", s.handleCompatibilityShims)
 	mux.HandleFunc("/v1/compat/shims/", s.handleCompatibilityShimAction)
 	mux.HandleFunc("/v1/compat/shims/resolve", s.handleCompatibilityShimsResolve)
@@ -680,14 +989,25 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/inventory/runtime-hosts/", s.handleRuntimeHostAction)
 	mux.HandleFunc("/v1/inventory/enroll", s.handleRuntimeEnrollAlias)
 	mux.HandleFunc("/v1/fleet/health", s.handleFleetHealth(baseDir))
+	mux.HandleFunc("/v1/fleet/health/scoring", s.handleFleetHealthScoring)
+	mux.HandleFunc("/v1/fleet/health/scores", s.handleFleetHealthScores(baseDir))
+	mux.HandleFunc("/v1/fleet/health/history", s.handleFleetHealthHistory)
 	mux.HandleFunc("/v1/agents/checkins", s.handleAgentCheckins)
 	mux.HandleFunc("/v1/agents/dispatch-mode", s.handleAgentDispatchMode)
 	mux.HandleFunc("/v1/agents/dispatch-environments", s.handleAgentDispatchEnvironments)
 	mux.HandleFunc("/v1/agents/dispatch-environments/", s.handleAgentDispatchEnvironmentAction)
 	mux.HandleFunc("/v1/agents/dispatch", s.handleAgentDispatch(baseDir))
+	mux.HandleFunc("/v1/agents/transports", s.handleAgentTransports)
+	mux.HandleFunc("/v1/agents/transports/policies", s.handleAgentTransportPolicies)
+	mux.HandleFunc("/v1/agents/transports/messages", s.handleAgentTransportMessages)
+	mux.HandleFunc("/v1/agents/transports/messages/", s.handleAgentTransportMessageAction)
+	mux.HandleFunc("/v1/agents/transports/poll", s.handleAgentTransportPoll)
+	mux.HandleFunc("/v1/agents/transports/ack", s.handleAgentTransportAck)
+	mux.HandleFunc("/v1/agents/transports/ws", s.handleAgentTransportWebSocket)
 	mux.HandleFunc("/v1/agents/proxy-minions", s.handleProxyMinions)
 	mux.HandleFunc("/v1/agents/proxy-minions/", s.handleProxyMinionAction)
 	mux.HandleFunc("/v1/agents/proxy-minions/dispatch", s.handleProxyMinionDispatch(baseDir))
+	mux.HandleFunc("/v1/agents/proxy-minions/modules", s.handleDeviceModules)
 	mux.HandleFunc("/v1/execution/network-transports", s.handleNetworkTransports)
 	mux.HandleFunc("/v1/execution/network-transports/validate", s.handleNetworkTransportValidate)
 	mux.HandleFunc("/v1/execution/portable-runners", s.handlePortableRunners)
@@ -716,9 +1036,15 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/execution/checkpoints/resume", s.handleExecutionCheckpointResume(baseDir))
 	mux.HandleFunc("/v1/execution/checkpoints/", s.handleExecutionCheckpointByID)
 	mux.HandleFunc("/v1/execution/snapshots", s.handleStepSnapshots)
-	mux.HandleFunc("/v1/execution/snapshots/", s.handleStepSnapshotByID)
+	mux.HandleFunc("/v1/execution/snapshots/", s.handleStepSnapshotByID(baseDir))
 	mux.HandleFunc("/v1/execution/environments", s.handleExecutionEnvironments)
 	mux.HandleFunc("/v1/execution/environments/", s.handleExecutionEnvironmentAction)
+	mux.HandleFunc("/v1/execution/isolated-runs", s.handleIsolatedRuns)
+	mux.HandleFunc("/v1/execution/isolated-runs/", s.handleIsolatedRunAction)
+	mux.HandleFunc("/v1/execution/cloud-credentials/bindings", s.handleCloudCredentialBindings)
+	mux.HandleFunc("/v1/execution/cloud-credentials/bindings/", s.handleCloudCredentialBindingAction)
+	mux.HandleFunc("/v1/execution/cloud-credentials/leases", s.handleCloudCredentialLeases)
+	mux.HandleFunc("/v1/execution/cloud-credentials/leases/", s.handleCloudCredentialLeaseAction)
 	mux.HandleFunc("/v1/execution/admission-policy", s.handleExecutionAdmissionPolicy)
 	mux.HandleFunc("/v1/execution/admit-check", s.handleExecutionAdmissionCheck)
 	mux.HandleFunc("/v1/execution/credentials", s.handleExecutionCredentials)
@@ -735,6 +1061,7 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/security/crypto/fips/validate", s.handleFIPSValidate)
 	mux.HandleFunc("/v1/security/host-profiles", s.handleHostSecurityProfiles)
 	mux.HandleFunc("/v1/security/host-profiles/evaluate", s.handleHostSecurityEvaluate)
+	mux.HandleFunc("/v1/security/host-profiles/", s.handleHostSecurityProfileActions(baseDir))
 	mux.HandleFunc("/v1/security/signatures/keyrings", s.handleSignatureKeyrings)
 	mux.HandleFunc("/v1/security/signatures/keyrings/", s.handleSignatureKeyringAction)
 	mux.HandleFunc("/v1/security/signatures/admission-policy", s.handleSignatureAdmissionPolicy)
@@ -750,10 +1077,13 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/access/delegation-tokens/", s.handleDelegationTokenAction)
 	mux.HandleFunc("/v1/access/approval-policies", s.handleApprovalPolicies)
 	mux.HandleFunc("/v1/access/approval-policies/", s.handleApprovalPolicyAction)
+	mux.HandleFunc("/v1/access/approval-policies/evaluate", s.handleApprovalEvaluation)
 	mux.HandleFunc("/v1/access/break-glass/requests", s.handleBreakGlassRequests)
 	mux.HandleFunc("/v1/access/break-glass/requests/", s.handleBreakGlassRequestAction)
+	mux.HandleFunc("/v1/access/break-glass/elevated", s.handleBreakGlassElevation)
 	mux.HandleFunc("/v1/access/jit-grants", s.handleJITAccessGrants)
 	mux.HandleFunc("/v1/access/jit-grants/validate", s.handleJITAccessGrantValidate)
+	mux.HandleFunc("/v1/access/jit-grants/enforcement", s.handleJITEnforcement)
 	mux.HandleFunc("/v1/access/jit-grants/", s.handleJITAccessGrantAction)
 	mux.HandleFunc("/v1/access/rbac/roles", s.handleRBACRoles)
 	mux.HandleFunc("/v1/access/rbac/roles/", s.handleRBACRoleAction)
@@ -765,17 +1095,29 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/identity/sso/providers/", s.handleSSOProviderAction)
 	mux.HandleFunc("/v1/identity/sso/login/start", s.handleSSOLoginStart)
 	mux.HandleFunc("/v1/identity/sso/login/callback", s.handleSSOLoginCallback)
+	mux.HandleFunc("/v1/identity/sso/saml/", s.handleSSOSAML)
 	mux.HandleFunc("/v1/identity/sso/sessions", s.handleSSOSessions)
 	mux.HandleFunc("/v1/identity/sso/sessions/", s.handleSSOSessionAction)
+	mux.HandleFunc("/v1/identity/sso/session-policy", s.handleSSOSessionPolicy)
 	mux.HandleFunc("/v1/identity/scim/roles", s.handleSCIMRoles)
 	mux.HandleFunc("/v1/identity/scim/roles/", s.handleSCIMRoleAction)
 	mux.HandleFunc("/v1/identity/scim/teams", s.handleSCIMTeams)
 	mux.HandleFunc("/v1/identity/scim/teams/", s.handleSCIMTeamAction)
+	mux.HandleFunc("/v1/identity/scim/group-mappings", s.handleSCIMGroupMappings)
+	mux.HandleFunc("/v1/identity/scim/group-mappings/", s.handleSCIMGroupMappingAction)
+	mux.HandleFunc("/scim/v2/ServiceProviderConfig", s.handleSCIMServiceProviderConfig)
+	mux.HandleFunc("/scim/v2/Users", s.handleSCIMUsers)
+	mux.HandleFunc("/scim/v2/Users/", s.handleSCIMUser)
+	mux.HandleFunc("/scim/v2/Groups", s.handleSCIMGroups)
+	mux.HandleFunc("/scim/v2/Groups/", s.handleSCIMGroup)
 	mux.HandleFunc("/v1/identity/oidc/workload/providers", s.handleOIDCWorkloadProviders)
 	mux.HandleFunc("/v1/identity/oidc/workload/providers/", s.handleOIDCWorkloadProviderAction)
 	mux.HandleFunc("/v1/identity/oidc/workload/exchange", s.handleOIDCWorkloadExchange)
 	mux.HandleFunc("/v1/identity/oidc/workload/credentials", s.handleOIDCWorkloadCredentials)
 	mux.HandleFunc("/v1/identity/oidc/workload/credentials/", s.handleOIDCWorkloadCredentialAction)
+	mux.HandleFunc("/v1/security/secret-scanning/policy", s.handleSecretScanPolicy)
+	mux.HandleFunc("/v1/security/secret-scans", s.handleSecretScans)
+	mux.HandleFunc("/v1/security/secret-scans/", s.handleSecretScanAction)
 	mux.HandleFunc("/v1/security/mtls/authorities", s.handleMTLSAuthorities)
 	mux.HandleFunc("/v1/security/mtls/policies", s.handleMTLSPolicies)
 	mux.HandleFunc("/v1/security/mtls/handshake-check", s.handleMTLSHandshakeCheck)
@@ -784,13 +1126,18 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/secrets/traces", s.handleSecretUsageTraces)
 	mux.HandleFunc("/v1/packages/artifacts", s.handlePackageArtifacts)
 	mux.HandleFunc("/v1/packages/artifacts/", s.handlePackageArtifactAction)
+	mux.HandleFunc("/v1/packages/versions", s.handlePackageVersions)
+	mux.HandleFunc("/v1/packages/resolve", s.handlePackageResolve)
 	mux.HandleFunc("/v1/packages/signing-policy", s.handlePackageSigningPolicy)
 	mux.HandleFunc("/v1/packages/verify", s.handlePackageVerify)
 	mux.HandleFunc("/v1/packages/cosign/trust-roots", s.handleCosignTrustRoots)
 	mux.HandleFunc("/v1/packages/cosign/trust-roots/", s.handleCosignTrustRootAction)
 	mux.HandleFunc("/v1/packages/cosign/policy", s.handleCosignPolicy)
 	mux.HandleFunc("/v1/packages/cosign/verify", s.handleCosignVerify)
+	mux.HandleFunc("/v1/packages/attestations", s.handleProvenanceAttestations)
+	mux.HandleFunc("/v1/packages/attestations/", s.handleProvenanceAttestationAction)
 	mux.HandleFunc("/v1/packages/certification-policy", s.handlePackageCertificationPolicy)
+	mux.HandleFunc("/v1/packages/vulnerability-policy", s.handleVulnerabilityGatePolicy)
 	mux.HandleFunc("/v1/packages/certify", s.handlePackageCertify)
 	mux.HandleFunc("/v1/packages/certifications", s.handlePackageCertifications)
 	mux.HandleFunc("/v1/packages/publication/check", s.handlePackagePublicationCheck)
@@ -809,13 +1156,26 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/packages/pinning/policies", s.handlePackagePinPolicies)
 	mux.HandleFunc("/v1/packages/pinning/evaluate", s.handlePackagePinEvaluate)
 	mux.HandleFunc("/v1/agents/cert-policy", s.handleAgentCertPolicy)
+	mux.HandleFunc("/v1/agents/ca-backend", s.handleAgentCABackend)
+	mux.HandleFunc("/v1/agents/pki/ca", s.handleAgentCACertificate)
+	mux.HandleFunc("/v1/agents/pki/crl", s.handleAgentCRL)
+	mux.HandleFunc("/v1/agents/pki/crl/publish", s.handleAgentCRLPublish)
+	mux.HandleFunc("/v1/agents/pki/revocation", s.handleAgentRevocationConfig)
+	mux.HandleFunc("/v1/agents/pki/status/", s.handleAgentRevocationStatus)
+	mux.HandleFunc("/v1/server-certificates", s.handleServerCertificates)
+	mux.HandleFunc("/v1/server-certificates/acme", s.handleServerCertificateACME)
+	mux.HandleFunc("/v1/server-certificates/", s.handleServerCertificateAction)
+	mux.HandleFunc("/.well-known/acme-challenge/", s.handleACMEChallenge)
 	mux.HandleFunc("/v1/agents/catalogs", s.handleAgentCatalogs(baseDir))
 	mux.HandleFunc("/v1/agents/catalogs/replay", s.handleAgentCatalogReplay(baseDir))
 	mux.HandleFunc("/v1/agents/catalogs/replays", s.handleAgentCatalogReplays)
 	mux.HandleFunc("/v1/agents/catalogs/", s.handleAgentCatalogAction)
 	mux.HandleFunc("/v1/agents/attestation/policy", s.handleAgentAttestationPolicy)
+	mux.HandleFunc("/v1/agents/attestation/keys", s.handleAgentAttestationKeys)
+	mux.HandleFunc("/v1/agents/attestation/status", s.handleAgentAttestationStatus)
 	mux.HandleFunc("/v1/agents/attestations", s.handleAgentAttestations)
 	mux.HandleFunc("/v1/agents/attestations/check", s.handleAgentAttestationCheck)
+	mux.HandleFunc("/v1/agents/attestations/challenge", s.handleAgentAttestationChallenge)
 	mux.HandleFunc("/v1/agents/attestations/", s.handleAgentAttestationAction)
 	mux.HandleFunc("/v1/agents/csrs", s.handleAgentCSRs)
 	mux.HandleFunc("/v1/agents/csrs/", s.handleAgentCSRAction)
@@ -857,6 +1217,7 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/gitops/plan-artifacts/verify", s.handleGitOpsPlanArtifactVerify(baseDir))
 	mux.HandleFunc("/v1/data-bags", s.handleDataBags)
 	mux.HandleFunc("/v1/data-bags/search", s.handleDataBagSearch)
+	mux.HandleFunc("/v1/data-bags/keys", s.handleDataBagKeys)
 	mux.HandleFunc("/v1/data-bags/", s.handleDataBagItem)
 	mux.HandleFunc("/v1/roles", s.handleRoles)
 	mux.HandleFunc("/v1/roles/", s.handleRoleAction)
@@ -878,7 +1239,12 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/facts/cache", s.handleFactCache)
 	mux.HandleFunc("/v1/facts/cache/", s.handleFactCacheNode)
 	mux.HandleFunc("/v1/facts/mine/query", s.handleFactMineQuery)
+	mux.HandleFunc("/v1/facts/mine", s.handleFactMine)
+	mux.HandleFunc("/v1/facts/mine/functions", s.handleFactMineFunctions)
+	mux.HandleFunc("/v1/facts/mine/functions/", s.handleFactMineFunctionAction)
+	mux.HandleFunc("/v1/facts/mine/publish", s.handleFactMinePublish)
 	mux.HandleFunc("/v1/incidents/view", s.handleIncidentView(baseDir))
+	mux.HandleFunc("/v1/incidents/export", s.handleIncidentExport(baseDir))
 	mux.HandleFunc("/v1/fleet/nodes", s.handleFleetNodes(baseDir))
 	mux.HandleFunc("/v1/drift/insights", s.handleDriftInsights(baseDir))
 	mux.HandleFunc("/v1/drift/history", s.handleDriftHistory(baseDir))
@@ -896,6 +1262,10 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/activity/audit-timeline", s.handleAuditTimeline)
 	mux.HandleFunc("/v1/metrics", s.handleMetrics)
 	mux.HandleFunc("/v1/events/ingest", s.handleEventIngest)
+	mux.HandleFunc("/v1/events/ingest/stream", s.handleEventIngestStream)
+	mux.HandleFunc("/v1/events/replay", s.handleEventReplay)
+	mux.HandleFunc("/v1/events/schemas", s.handleEventSchemas)
+	mux.HandleFunc("/v1/events/schemas/", s.handleEventSchemaAction)
 	mux.HandleFunc("/v1/event-stream/ingest", s.handleEventIngest)
 	mux.HandleFunc("/v1/event-stream/webhooks/ingest", s.handleEventIngest)
 	mux.HandleFunc("/v1/converge/triggers", s.handleConvergeTriggers(baseDir))
@@ -903,9 +1273,13 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/resources/exported", s.handleExportedResources)
 	mux.HandleFunc("/v1/resources/collect", s.handleResourceCollect)
 	mux.HandleFunc("/v1/alerts/inbox", s.handleAlertInbox)
+	mux.HandleFunc("/v1/alerts/", s.handleAlertAction)
 	mux.HandleFunc("/v1/notifications/targets", s.handleNotificationTargets)
 	mux.HandleFunc("/v1/notifications/targets/", s.handleNotificationTargetAction)
 	mux.HandleFunc("/v1/notifications/deliveries", s.handleNotificationDeliveries)
+	mux.HandleFunc("/v1/notifications/policies", s.handleNotificationPolicies)
+	mux.HandleFunc("/v1/notifications/policies/", s.handleNotificationPolicyAction)
+	mux.HandleFunc("/v1/notifications/escalations", s.handleNotificationEscalations)
 	mux.HandleFunc("/v1/reports/processors", s.handleReportProcessors)
 	mux.HandleFunc("/v1/reports/processors/", s.handleReportProcessorAction)
 	mux.HandleFunc("/v1/reports/process", s.handleReportProcessorDispatch)
@@ -914,11 +1288,15 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/change-records/ticket-integrations", s.handleTicketIntegrations)
 	mux.HandleFunc("/v1/change-records/ticket-integrations/", s.handleTicketIntegrationAction)
 	mux.HandleFunc("/v1/change-records/tickets/sync", s.handleTicketSync)
+	mux.HandleFunc("/v1/change-records/tickets/callback/", s.handleTicketCallback)
 	mux.HandleFunc("/v1/bulk/preview", s.handleBulkPreview)
 	mux.HandleFunc("/v1/bulk/execute", s.handleBulkExecute)
 	mux.HandleFunc("/v1/views", s.handleViews)
 	mux.HandleFunc("/v1/views/", s.handleViewAction)
 	mux.HandleFunc("/v1/views/home", s.handlePersonaHome(baseDir))
+	mux.HandleFunc("/v1/views/home/preferences", s.handleHomePreferences(baseDir))
+	mux.HandleFunc("/v1/views/home/preferences/cards", s.handleHomePreferenceCards(baseDir))
+	mux.HandleFunc("/v1/views/home/mandatory", s.handleHomeMandatoryCards(baseDir))
 	mux.HandleFunc("/v1/views/workloads", s.handleWorkloadViews)
 	mux.HandleFunc("/v1/ui/accessibility/profiles", s.handleAccessibilityProfiles)
 	mux.HandleFunc("/v1/ui/accessibility/active", s.handleAccessibilityActive)
@@ -944,31 +1322,56 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/workspace-templates", s.handleWorkspaceTemplates(baseDir))
 	mux.HandleFunc("/v1/workspace-templates/", s.handleWorkspaceTemplateAction(baseDir))
 	mux.HandleFunc("/v1/commands/ingest", s.handleCommandIngest(baseDir))
+	mux.HandleFunc("/v1/commands/ingest/batch", s.handleCommandIngestBatch(baseDir))
+	mux.HandleFunc("/v1/commands/ingest/policy", s.handleCommandIngestPolicy)
 	mux.HandleFunc("/v1/commands/dead-letters", s.handleCommandDeadLetters)
+	mux.HandleFunc("/v1/commands/dead-letters/", s.handleCommandDeadLetterAction)
+	mux.HandleFunc("/v1/commands/retry-policy", s.handleCommandRetryPolicy)
 	mux.HandleFunc("/v1/commands/adhoc", s.handleAdHocCommands)
 	mux.HandleFunc("/v1/commands/adhoc/policy", s.handleAdHocPolicy)
 	mux.HandleFunc("/v1/object-store/objects", s.handleObjectStoreObjects)
 	mux.HandleFunc("/v1/control/backup", s.handleBackup(baseDir))
 	mux.HandleFunc("/v1/control/backups", s.handleBackups)
+	mux.HandleFunc("/v1/control/backup-schedules", s.handleBackupSchedules)
+	mux.HandleFunc("/v1/control/backup-schedules/", s.handleBackupScheduleAction)
+	mux.HandleFunc("/v1/control/backup-sets", s.handleBackupSets)
+	mux.HandleFunc("/v1/control/backup-sets/", s.handleBackupSetAction)
 	mux.HandleFunc("/v1/control/restore", s.handleRestore(baseDir))
+	mux.HandleFunc("/v1/control/snapshot", s.handleControlSnapshot(baseDir))
+	mux.HandleFunc("/v1/control/snapshot/import", s.handleControlSnapshotImport(baseDir))
+	mux.HandleFunc("/v1/control/follower/", s.handleFollower)
 	mux.HandleFunc("/v1/control/drill", s.handleDRDrill(baseDir))
 	mux.HandleFunc("/v1/webhooks", s.handleWebhooks)
 	mux.HandleFunc("/v1/webhooks/", s.handleWebhookAction)
 	mux.HandleFunc("/v1/webhooks/deliveries", s.handleWebhookDeliveries)
 	mux.HandleFunc("/v1/rules", s.handleRules)
 	mux.HandleFunc("/v1/rules/", s.handleRuleAction)
+	mux.HandleFunc("/v1/trash", s.handleTrash)
+	mux.HandleFunc("/v1/trash/policy", s.handleTrashPolicy)
+	mux.HandleFunc("/v1/trash/purge", s.handleTrashPurge)
+	mux.HandleFunc("/v1/trash/", s.handleTrashAction)
 	mux.HandleFunc("/v1/compat/beacon-reactor/rules", s.handleBeaconReactorRules)
 	mux.HandleFunc("/v1/compat/beacon-reactor/rules/", s.handleBeaconReactorRuleAction)
 	mux.HandleFunc("/v1/compat/beacon-reactor/emit", s.handleBeaconReactorEmit)
+	mux.HandleFunc("/v1/compat/beacons", s.handleAgentBeacons)
+	mux.HandleFunc("/v1/compat/beacons/", s.handleAgentBeaconAction)
 	mux.HandleFunc("/v1/runs", s.handleRuns(baseDir))
 	mux.HandleFunc("/v1/runs/digest", s.handleRunDigest(baseDir))
+	mux.HandleFunc("/v1/runs/digest/schedules", s.handleRunDigestSchedules)
+	mux.HandleFunc("/v1/runs/digest/schedules/", s.handleRunDigestScheduleAction)
+	mux.HandleFunc("/v1/runs/digest/reports", s.handleRunDigestReports)
+	mux.HandleFunc("/v1/runs/digest/reports/", s.handleRunDigestReports)
 	mux.HandleFunc("/v1/runs/compare", s.handleRunCompare(baseDir))
 	mux.HandleFunc("/v1/runs/", s.handleRunAction(baseDir))
 	mux.HandleFunc("/v1/jobs", s.handleJobs(baseDir))
 	mux.HandleFunc("/v1/jobs/", s.handleJobByID)
 	mux.HandleFunc("/v1/control/emergency-stop", s.handleEmergencyStop)
+	mux.HandleFunc("/v1/control/emergency-stop/scopes", s.handleEmergencyStopScopes)
+	mux.HandleFunc("/v1/control/emergency-stop/scopes/", s.handleEmergencyStopScopeAction)
 	mux.HandleFunc("/v1/control/freeze", s.handleFreeze)
 	mux.HandleFunc("/v1/control/maintenance", s.handleMaintenance)
+	mux.HandleFunc("/v1/control/host-maintenance", s.handleHostMaintenance)
+	mux.HandleFunc("/v1/control/host-maintenance/", s.handleHostMaintenanceAction)
 	mux.HandleFunc("/v1/control/handoff", s.handleHandoff)
 	mux.HandleFunc("/v1/control/topology-advisor", s.handleTopologyAdvisor(baseDir))
 	mux.HandleFunc("/v1/control/deployment-profiles", s.handleDeploymentProfiles)
@@ -983,15 +1386,29 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/control/health-probes", s.handleHealthProbes)
 	mux.HandleFunc("/v1/control/health-probes/checks", s.handleHealthProbeChecks)
 	mux.HandleFunc("/v1/control/health-probes/evaluate", s.handleHealthProbeGateEvaluate)
+	mux.HandleFunc("/v1/control/health-probes/", s.handleHealthProbeAction)
 	mux.HandleFunc("/v1/control/channels", s.handleChannels)
 	mux.HandleFunc("/v1/control/canary-upgrades", s.handleCanaryUpgrades)
 	mux.HandleFunc("/v1/control/canary-upgrades/", s.handleCanaryUpgradeAction)
+	mux.HandleFunc("/v1/control/canary-analysis", s.handleCanaryAnalyses(baseDir))
+	mux.HandleFunc("/v1/control/canary-analysis/", s.handleCanaryAnalysisAction)
 	mux.HandleFunc("/v1/control/upgrade-orchestration/plans", s.handleUpgradeOrchestrationPlans)
 	mux.HandleFunc("/v1/control/upgrade-orchestration/plans/", s.handleUpgradeOrchestrationPlanAction)
+	mux.HandleFunc("/v1/control/upgrade-orchestration/drills", s.handleUpgradeDrills)
+	mux.HandleFunc("/v1/control/upgrade-orchestration/drills/", s.handleUpgradeDrillAction)
+	mux.HandleFunc("/v1/control/response-cache", s.handleResponseCache)
+	mux.HandleFunc("/v1/control/response-cache/invalidate", s.handleResponseCacheInvalidate)
+	mux.HandleFunc("/v1/control/response-compression", s.handleResponseCompression)
+	mux.HandleFunc("/v1/control/request-limits", s.handleRequestLimits)
+	mux.HandleFunc("/v1/control/log-level", s.handleLogLevel)
 	mux.HandleFunc("/v1/control/failover-drills", s.handleRegionalFailoverDrills)
 	mux.HandleFunc("/v1/control/failover-drills/scorecards", s.handleRegionalFailoverScorecards)
 	mux.HandleFunc("/v1/control/performance/profiles", s.handlePerformanceProfiles)
 	mux.HandleFunc("/v1/control/performance/diagnostics", s.handlePerformanceDiagnostics)
+	mux.HandleFunc("/v1/control/performance/profiler", s.handleContinuousProfiler)
+	mux.HandleFunc("/v1/control/performance/profiler/captures", s.handleProfileCaptures)
+	mux.HandleFunc("/v1/control/performance/profiler/captures/", s.handleProfileCaptureAction)
+	mux.HandleFunc("/v1/control/debug/pprof/", s.handlePprof)
 	mux.HandleFunc("/v1/control/topology-placement/policies", s.handleTopologyPlacementPolicies)
 	mux.HandleFunc("/v1/control/topology-placement/decide", s.handleTopologyPlacementDecision)
 	mux.HandleFunc("/v1/control/scale-profiles", s.handleScaleProfiles)
@@ -1000,20 +1417,40 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/control/leak-detection/policy", s.handleLeakDetectionPolicy)
 	mux.HandleFunc("/v1/control/leak-detection/snapshots", s.handleLeakDetectionSnapshots)
 	mux.HandleFunc("/v1/control/leak-detection/reports", s.handleLeakDetectionReports)
+	mux.HandleFunc("/v1/control/leak-detection/capture", s.handleLeakDetectionCapture)
+	mux.HandleFunc("/v1/control/shards", s.handleShards)
+	mux.HandleFunc("/v1/control/shards/events/policy", s.handleEventShardPolicy)
+	mux.HandleFunc("/v1/control/shards/queue/policy", s.handleQueueShardPolicy)
+	mux.HandleFunc("/v1/control/shards/events/", s.handleEventShardSpilled)
 	mux.HandleFunc("/v1/control/federation/peers", s.handleFederationPeers)
 	mux.HandleFunc("/v1/control/federation/peers/", s.handleFederationPeerAction)
 	mux.HandleFunc("/v1/control/federation/health", s.handleFederationHealth)
+	mux.HandleFunc("/v1/control/federation/jobs", s.handleFederationJobs)
+	mux.HandleFunc("/v1/control/federation/jobs/", s.handleFederationJobAction)
+	mux.HandleFunc("/v1/control/federation/forwarded", s.handleForwardedJobs)
+	mux.HandleFunc("/v1/control/federation/forwarded/", s.handleForwardedJobAction)
+	mux.HandleFunc("/v1/control/federation/runs", s.handleFederationRuns)
 	mux.HandleFunc("/v1/control/scheduler/partitions", s.handleSchedulerPartitions)
 	mux.HandleFunc("/v1/control/scheduler/partitions/", s.handleSchedulerPartitionAction)
 	mux.HandleFunc("/v1/control/scheduler/partition-decision", s.handleSchedulerPartitionDecision)
+	mux.HandleFunc("/v1/control/scheduler/partition-lag", s.handleSchedulerPartitionLag)
+	mux.HandleFunc("/v1/control/scheduler/rebalance", s.handleSchedulerRebalance)
+	mux.HandleFunc("/v1/control/scheduler/workers", s.handleSchedulerWorkers)
+	mux.HandleFunc("/v1/control/scheduler/workers/", s.handleSchedulerWorkerAction)
 	mux.HandleFunc("/v1/control/autoscaling/policy", s.handleWorkerAutoscalingPolicy)
 	mux.HandleFunc("/v1/control/autoscaling/recommend", s.handleWorkerAutoscalingRecommend)
 	mux.HandleFunc("/v1/control/cost-scheduling/policies", s.handleCostSchedulingPolicies)
 	mux.HandleFunc("/v1/control/cost-scheduling/admit", s.handleCostSchedulingAdmit)
 	mux.HandleFunc("/v1/control/artifact-distribution/policies", s.handleArtifactDistributionPolicies)
 	mux.HandleFunc("/v1/control/artifact-distribution/plan", s.handleArtifactDistributionPlan)
+	mux.HandleFunc("/v1/control/artifact-replication/targets", s.handleArtifactReplicationTargets)
+	mux.HandleFunc("/v1/control/artifact-replication/jobs", s.handleArtifactReplications)
+	mux.HandleFunc("/v1/control/artifact-replication/run", s.handleArtifactReplicationRun)
+	mux.HandleFunc("/v1/control/artifact-replication/lag", s.handleArtifactReplicationLag)
 	mux.HandleFunc("/v1/control/workspaces/isolation-policies", s.handleWorkspaceIsolationPolicies)
 	mux.HandleFunc("/v1/control/workspaces/isolation/evaluate", s.handleWorkspaceIsolationEvaluate)
+	mux.HandleFunc("/v1/control/workspaces/migrate", s.handleWorkspaceMigrate)
+	mux.HandleFunc("/v1/workspaces/", s.handleWorkspacePartitions)
 	mux.HandleFunc("/v1/control/tenancy/policies", s.handleTenantPolicies)
 	mux.HandleFunc("/v1/control/tenancy/admit-check", s.handleTenantAdmissionCheck)
 	mux.HandleFunc("/v1/security/tenant-keys", s.handleTenantCryptoKeys)
@@ -1021,9 +1458,12 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/security/tenant-keys/boundary-check", s.handleTenantCryptoBoundaryCheck)
 	mux.HandleFunc("/v1/control/delegated-admin/grants", s.handleDelegatedAdminGrants)
 	mux.HandleFunc("/v1/control/delegated-admin/authorize", s.handleDelegatedAdminAuthorize)
+	mux.HandleFunc("/v1/control/delegated-admin/keys", s.handleDelegatedAdminKeys)
+	mux.HandleFunc("/v1/control/delegated-admin/keys/", s.handleDelegatedAdminKeys)
 	mux.HandleFunc("/v1/control/multi-master/nodes", s.handleMultiMasterNodes)
 	mux.HandleFunc("/v1/control/multi-master/nodes/", s.handleMultiMasterNodeAction)
 	mux.HandleFunc("/v1/control/multi-master/cache", s.handleMultiMasterCache)
+	mux.HandleFunc("/v1/control/multi-master/replication/", s.handleMultiMasterReplication)
 	mux.HandleFunc("/v1/control/schema-migrations", s.handleSchemaMigrations)
 	mux.HandleFunc("/v1/schema/models", s.handleOpenSchemas)
 	mux.HandleFunc("/v1/schema/models/", s.handleOpenSchemaByID)
@@ -1033,6 +1473,8 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/control/blast-radius-map", s.handleBlastRadiusMap(baseDir))
 	mux.HandleFunc("/v1/control/disruption-budgets", s.handleDisruptionBudgets)
 	mux.HandleFunc("/v1/control/disruption-budgets/evaluate", s.handleDisruptionBudgetEvaluate)
+	mux.HandleFunc("/v1/control/disruption-budgets/claims", s.handleDisruptionClaims)
+	mux.HandleFunc("/v1/control/disruption-budgets/claims/", s.handleDisruptionClaimAction)
 	mux.HandleFunc("/v1/control/queue", s.handleQueueControl)
 	mux.HandleFunc("/v1/control/queue/backends", s.handleQueueBackends)
 	mux.HandleFunc("/v1/control/queue/backends/", s.handleQueueBackendAction)
@@ -1040,10 +1482,15 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/control/queue/backends/admit", s.handleQueueBackendAdmit)
 	mux.HandleFunc("/v1/control/queue/backlog-slo/policy", s.handleQueueBacklogSLOPolicy)
 	mux.HandleFunc("/v1/control/queue/backlog-slo/status", s.handleQueueBacklogSLOStatus)
+	mux.HandleFunc("/v1/control/queue/shedding/policy", s.handleQueueSheddingPolicy)
+	mux.HandleFunc("/v1/control/queue/shedding/status", s.handleQueueSheddingStatus)
+	mux.HandleFunc("/v1/control/queue/dispatch-windows", s.handleDispatchWindows)
+	mux.HandleFunc("/v1/control/queue/dispatch-windows/", s.handleDispatchWindowByName)
 	mux.HandleFunc("/v1/control/workers/lifecycle", s.handleWorkerLifecycle)
 	mux.HandleFunc("/v1/control/execution-locks", s.handleExecutionLocks)
 	mux.HandleFunc("/v1/control/execution-locks/release", s.handleExecutionLockRelease)
 	mux.HandleFunc("/v1/control/execution-locks/cleanup", s.handleExecutionLockCleanup)
+	mux.HandleFunc("/v1/control/execution-locks/wait-graph", s.handleExecutionLockWaitGraph)
 	mux.HandleFunc("/v1/control/run-leases", s.handleRunLeases)
 	mux.HandleFunc("/v1/control/run-leases/heartbeat", s.handleRunLeaseHeartbeat)
 	mux.HandleFunc("/v1/control/run-leases/release", s.handleRunLeaseRelease)
@@ -1058,15 +1505,24 @@ func New(addr, baseDir string) *Server {
 	mux.HandleFunc("/v1/runbooks", s.handleRunbooks(baseDir))
 	mux.HandleFunc("/v1/runbooks/catalog", s.handleRunbookCatalog)
 	mux.HandleFunc("/v1/runbooks/", s.handleRunbookAction(baseDir))
+	mux.HandleFunc("/v1/stewardship/policy", s.handleStewardshipPolicy)
+	mux.HandleFunc("/v1/stewardship/report", s.handleStewardshipReport)
 	mux.HandleFunc("/v1/workflows/", s.handleWorkflowAction)
 	mux.HandleFunc("/v1/workflow-runs", s.handleWorkflowRuns)
 	mux.HandleFunc("/v1/workflow-runs/", s.handleWorkflowRunByID)
+	mux.HandleFunc("/v1/orchestrations/", s.handleOrchestrations)
 	mux.HandleFunc("/v1/canaries", s.handleCanaries(baseDir))
 	mux.HandleFunc("/v1/canaries/", s.handleCanaryAction)
 	mux.HandleFunc("/v1/associations", s.handleAssociations(baseDir))
 	mux.HandleFunc("/v1/associations/", s.handleAssociationAction)
 	mux.HandleFunc("/v1/schedules", s.handleSchedules(baseDir))
 	mux.HandleFunc("/v1/schedules/", s.handleScheduleAction)
+	mux.HandleFunc("/v1/config-as-code", s.handleConfigAsCode)
+	mux.HandleFunc("/v1/config-as-code/sync", s.handleConfigAsCodeSync)
+	mux.HandleFunc("/v1/config-as-code/import", s.handleConfigAsCodeImport)
+	mux.HandleFunc("/v1/config-as-code/diff", s.handleConfigAsCodeDiff)
+	s.loadConfigAsCodeFromEnv()
+	s.restoreQueueState()
 	return s
 }
 
@@ -1074,18 +1530,46 @@ func (s *Server) ListenAndServe() error {
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown stops schedulers, drains the job queue, and then stops the HTTP
+// server. See drainQueue for how unfinished jobs are carried over.
 func (s *Server) Shutdown(ctx context.Context) error {
-	if s.runCancel != nil {
-		s.runCancel()
-	}
 	if s.scheduler != nil {
 		s.scheduler.Shutdown()
 	}
 	if s.canaries != nil {
 		s.canaries.Shutdown()
 	}
+	if s.healthProbeRunner != nil {
+		s.healthProbeRunner.Shutdown()
+	}
+	if s.chaosInjector != nil {
+		s.chaosInjector.Shutdown()
+	}
+	if s.profiler != nil {
+		s.profiler.Shutdown()
+	}
+	if s.leakSampler != nil {
+		s.leakSampler.Shutdown()
+	}
+	if s.federationForwarder != nil {
+		s.federationForwarder.Shutdown()
+	}
+	if s.contentMirror != nil {
+		s.contentMirror.Shutdown()
+	}
+	if s.agentTransports != nil {
+		s.agentTransports.Shutdown()
+	}
+	if s.breakGlassSweep != nil {
+		s.breakGlassSweep()
+	}
 	if s.queue != nil {
-		s.queue.Wait()
+		s.drainQueue(ctx)
+	} else if s.runCancel != nil {
+		s.runCancel()
+	}
+	if s.events != nil {
+		s.events.Close()
 	}
 	return s.httpServer.Shutdown(ctx)
 }
@@ -1175,11 +1659,16 @@ func (s *Server) handleEventIngest(w http.ResponseWriter, r *http.Request) {
 	if req.Message == "" {
 		req.Message = "external event"
 	}
-	s.recordEvent(control.Event{
+	evt := control.Event{
 		Type:    req.Type,
 		Message: req.Message,
 		Fields:  req.Fields,
-	}, true)
+	}
+	if res, rejected := s.enforceEventSchema(evt); rejected {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "event does not match its schema", "validation": res})
+		return
+	}
+	s.recordEvent(evt, true)
 	writeJSON(w, http.StatusAccepted, map[string]string{"status": "ingested"})
 }
 
@@ -1286,11 +1775,14 @@ func (s *Server) handleAlertInbox(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleNotificationTargets(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Name    string `json:"name"`
-		Kind    string `json:"kind"`
-		URL     string `json:"url"`
-		Route   string `json:"route"`
-		Enabled bool   `json:"enabled"`
+		Name            string                          `json:"name"`
+		Kind            string                          `json:"kind"`
+		URL             string                          `json:"url"`
+		Route           string                          `json:"route"`
+		Enabled         bool                            `json:"enabled"`
+		PayloadTemplate string                          `json:"payload_template"`
+		ContentType     string                          `json:"content_type"`
+		QuietHours      *control.NotificationQuietHours `json:"quiet_hours"`
 	}
 	switch r.Method {
 	case http.MethodGet:
@@ -1302,11 +1794,14 @@ func (s *Server) handleNotificationTargets(w http.ResponseWriter, r *http.Reques
 			return
 		}
 		target, err := s.notifications.Register(control.NotificationTarget{
-			Name:    req.Name,
-			Kind:    req.Kind,
-			URL:     req.URL,
-			Route:   req.Route,
-			Enabled: true,
+			Name:            req.Name,
+			Kind:            req.Kind,
+			URL:             req.URL,
+			Route:           req.Route,
+			Enabled:         true,
+			PayloadTemplate: req.PayloadTemplate,
+			ContentType:     req.ContentType,
+			QuietHours:      req.QuietHours,
 		})
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
@@ -1322,8 +1817,24 @@ func (s *Server) handleNotificationTargets(w http.ResponseWriter, r *http.Reques
 }
 
 func (s *Server) handleNotificationTargetAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/notifications/targets/{id}/enable|disable
+	// /v1/notifications/targets/{id} or /v1/notifications/targets/{id}/enable|disable|preview
 	parts := splitPath(r.URL.Path)
+	if len(parts) == 4 {
+		switch r.Method {
+		case http.MethodGet:
+			target, err := s.notifications.Get(parts[3])
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, target)
+		case http.MethodPatch:
+			s.patchNotificationTarget(w, r, parts[3])
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
 	if len(parts) < 5 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid notification target action path"})
 		return
@@ -1349,6 +1860,34 @@ func (s *Server) handleNotificationTargetAction(w http.ResponseWriter, r *http.R
 			return
 		}
 		writeJSON(w, http.StatusOK, target)
+	case "preview":
+		target, err := s.notifications.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		alert := control.AlertItem{
+			ID:       "alert-preview",
+			Route:    target.Route,
+			Severity: "high",
+			Message:  "notification payload preview",
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+		}
+		body, err := control.RenderNotificationPayload(target, alert)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"target_id":    target.ID,
+			"content_type": target.ContentType,
+			"body":         string(body),
+		})
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown notification target action"})
 	}
@@ -1398,6 +1937,7 @@ func (s *Server) handleChangeRecords(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.ticketIntegrations.EnqueuePush(rec.ID)
 		writeJSON(w, http.StatusCreated, rec)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -1450,9 +1990,10 @@ func (s *Server) handleChangeRecordAction(w http.ResponseWriter, r *http.Request
 			rec, err = s.changeRecords.Reject(id, req.Actor, req.Comment)
 		}
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			writeJSON(w, approvalErrorCode(err), map[string]string{"error": err.Error()})
 			return
 		}
+		s.ticketIntegrations.EnqueuePush(rec.ID)
 		writeJSON(w, http.StatusOK, rec)
 	case "attach-job":
 		var req struct {
@@ -1467,6 +2008,7 @@ func (s *Server) handleChangeRecordAction(w http.ResponseWriter, r *http.Request
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.ticketIntegrations.EnqueuePush(rec.ID)
 		writeJSON(w, http.StatusOK, rec)
 	case "complete":
 		rec, err := s.changeRecords.MarkCompleted(id)
@@ -1474,6 +2016,7 @@ func (s *Server) handleChangeRecordAction(w http.ResponseWriter, r *http.Request
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.ticketIntegrations.EnqueuePush(rec.ID)
 		writeJSON(w, http.StatusOK, rec)
 	case "fail":
 		var req struct {
@@ -1488,6 +2031,7 @@ func (s *Server) handleChangeRecordAction(w http.ResponseWriter, r *http.Request
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.ticketIntegrations.EnqueuePush(rec.ID)
 		writeJSON(w, http.StatusOK, rec)
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown change record action"})
@@ -1550,11 +2094,15 @@ func (s *Server) handleViewAction(w http.ResponseWriter, r *http.Request) {
 			}
 			writeJSON(w, http.StatusOK, view)
 		case http.MethodDelete:
-			if err := s.views.Delete(id); err != nil {
+			view, err := s.views.Get(id)
+			if err == nil {
+				err = s.views.Delete(id)
+			}
+			if err != nil {
 				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 				return
 			}
-			writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+			writeJSON(w, http.StatusOK, s.trashEntity(r, control.TrashKindView, view.ID, view.Name, view))
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -1717,64 +2265,21 @@ func (s *Server) handleSolutionPackAction(baseDir string) http.HandlerFunc {
 }
 
 func (s *Server) handleCommandIngest(baseDir string) http.HandlerFunc {
-	type reqBody struct {
-		Action         string `json:"action"`
-		ConfigPath     string `json:"config_path"`
-		Priority       string `json:"priority"`
-		IdempotencyKey string `json:"idempotency_key"`
-		Checksum       string `json:"checksum"`
-		Force          bool   `json:"force"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		var req reqBody
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
-			return
-		}
-
-		env := control.CommandEnvelope{
-			Action:         req.Action,
-			ConfigPath:     req.ConfigPath,
-			Priority:       req.Priority,
-			IdempotencyKey: req.IdempotencyKey,
-			Checksum:       req.Checksum,
-		}
-
-		if strings.TrimSpace(req.Checksum) == "" {
-			dlq := s.commands.RecordDeadLetter(env, "checksum is required")
-			writeJSON(w, http.StatusUnprocessableEntity, dlq)
-			return
-		}
-
-		expected := control.ComputeCommandChecksum(req.Action, req.ConfigPath, req.Priority, req.IdempotencyKey)
-		if !strings.EqualFold(strings.TrimSpace(req.Checksum), expected) {
-			dlq := s.commands.RecordDeadLetter(env, "checksum mismatch")
-			writeJSON(w, http.StatusUnprocessableEntity, dlq)
-			return
-		}
-		if strings.ToLower(strings.TrimSpace(req.Action)) != "apply" {
-			dlq := s.commands.RecordDeadLetter(env, "unsupported action")
-			writeJSON(w, http.StatusBadRequest, dlq)
-			return
-		}
-		if strings.TrimSpace(req.ConfigPath) == "" {
-			dlq := s.commands.RecordDeadLetter(env, "config_path is required")
-			writeJSON(w, http.StatusBadRequest, dlq)
+		var req commandIngestReq
+		if err := s.decodeCommandBody(r, &req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
 
-		configPath := req.ConfigPath
-		if !filepath.IsAbs(configPath) {
-			configPath = filepath.Join(baseDir, configPath)
-		}
-		if _, err := os.Stat(configPath); err != nil {
-			dlq := s.commands.RecordDeadLetter(env, "config_path not found")
-			writeJSON(w, http.StatusBadRequest, dlq)
+		env, configPath, reason, code := checkCommandEnvelope(baseDir, req)
+		if reason != "" {
+			dlq := s.commands.RecordDeadLetter(env, reason)
+			writeJSON(w, code, dlq)
 			return
 		}
 
@@ -1807,7 +2312,14 @@ func (s *Server) handleCommandDeadLetters(w http.ResponseWriter, r *http.Request
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	writeJSON(w, http.StatusOK, s.commands.DeadLetters())
+	q := r.URL.Query()
+	writeJSON(w, http.StatusOK, s.commands.FilterDeadLetters(control.CommandDeadLetterFilter{
+		Status:       q.Get("status"),
+		FailureClass: q.Get("failure_class"),
+		Reason:       q.Get("reason"),
+		Action:       q.Get("action"),
+		ConfigPath:   q.Get("config_path"),
+	}))
 }
 
 func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
@@ -1842,6 +2354,7 @@ func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		s.recordEntityVersion(r, control.VersionedKindRule, rule.ID, rule.Version, "created", rule)
 		s.events.Append(control.Event{
 			Type:    "rule.created",
 			Message: "event rule created",
@@ -1850,14 +2363,14 @@ func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
 				"source_prefix": rule.SourcePrefix,
 			},
 		})
-		writeJSON(w, http.StatusCreated, rule)
+		writeVersioned(w, http.StatusCreated, rule.Version, rule)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
 func (s *Server) handleRuleAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/rules/{id} or /v1/rules/{id}/enable|disable
+	// /v1/rules/{id}, /v1/rules/{id}/enable|disable, or /v1/rules/{id}/versions[/{version}]
 	parts := splitPath(r.URL.Path)
 	if len(parts) < 3 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid rule path"})
@@ -1865,38 +2378,51 @@ func (s *Server) handleRuleAction(w http.ResponseWriter, r *http.Request) {
 	}
 	id := parts[2]
 	if len(parts) == 3 {
-		if r.Method != http.MethodGet {
+		switch r.Method {
+		case http.MethodGet:
+			rule, err := s.rules.Get(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeVersioned(w, http.StatusOK, rule.Version, rule)
+		case http.MethodPut, http.MethodPatch:
+			s.updateRule(w, r, id)
+		case http.MethodDelete:
+			rule, err := s.rules.Delete(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			s.recordEntityVersion(r, control.VersionedKindRule, rule.ID, rule.Version, "deleted", rule)
+			writeJSON(w, http.StatusOK, s.trashEntity(r, control.TrashKindRule, rule.ID, rule.Name, rule))
+		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
 		}
-		rule, err := s.rules.Get(id)
-		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
-			return
-		}
-		writeJSON(w, http.StatusOK, rule)
+		return
+	}
+	action := parts[3]
+	if action == "versions" {
+		s.handleEntityVersions(w, r, control.VersionedKindRule, id, parts[4:])
 		return
 	}
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	action := parts[3]
 	switch action {
-	case "enable":
-		rule, err := s.rules.SetEnabled(id, true)
-		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case "enable", "disable":
+		expected, ok := ifMatchVersion(w, r, false)
+		if !ok {
 			return
 		}
-		writeJSON(w, http.StatusOK, rule)
-	case "disable":
-		rule, err := s.rules.SetEnabled(id, false)
+		rule, err := s.rules.SetEnabledVersion(id, action == "enable", expected)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			writeEntityUpdateError(w, err)
 			return
 		}
-		writeJSON(w, http.StatusOK, rule)
+		s.recordEntityVersion(r, control.VersionedKindRule, rule.ID, rule.Version, action+"d", rule)
+		writeVersioned(w, http.StatusOK, rule.Version, rule)
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown rule action"})
 	}
@@ -1904,11 +2430,15 @@ func (s *Server) handleRuleAction(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
 	type createReq struct {
-		Name        string `json:"name"`
-		URL         string `json:"url"`
-		EventPrefix string `json:"event_prefix"`
-		Secret      string `json:"secret"`
-		Enabled     bool   `json:"enabled"`
+		Name            string `json:"name"`
+		URL             string `json:"url"`
+		EventPrefix     string `json:"event_prefix"`
+		Secret          string `json:"secret"`
+		Enabled         bool   `json:"enabled"`
+		PayloadTemplate string `json:"payload_template"`
+		ContentType     string `json:"content_type"`
+		Owner           string `json:"owner"`
+		Team            string `json:"team"`
 	}
 	switch r.Method {
 	case http.MethodGet:
@@ -1919,12 +2449,20 @@ func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 			return
 		}
+		owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+		if !ok {
+			return
+		}
 		webhook, err := s.webhooks.Register(control.WebhookSubscription{
-			Name:        req.Name,
-			URL:         req.URL,
-			EventPrefix: req.EventPrefix,
-			Secret:      req.Secret,
-			Enabled:     req.Enabled,
+			Name:            req.Name,
+			URL:             req.URL,
+			EventPrefix:     req.EventPrefix,
+			Secret:          req.Secret,
+			Enabled:         req.Enabled,
+			PayloadTemplate: req.PayloadTemplate,
+			ContentType:     req.ContentType,
+			Owner:           owner,
+			Team:            team,
 		})
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
@@ -1937,7 +2475,7 @@ func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleWebhookAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/webhooks/{id} or /v1/webhooks/{id}/enable|disable
+	// /v1/webhooks/{id} or /v1/webhooks/{id}/enable|disable|preview
 	parts := splitPath(r.URL.Path)
 	if len(parts) < 3 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid webhook path"})
@@ -1945,16 +2483,19 @@ func (s *Server) handleWebhookAction(w http.ResponseWriter, r *http.Request) {
 	}
 	id := parts[2]
 	if len(parts) == 3 {
-		if r.Method != http.MethodGet {
+		switch r.Method {
+		case http.MethodGet:
+			wh, err := s.webhooks.Get(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, wh)
+		case http.MethodPatch:
+			s.patchWebhook(w, r, id)
+		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
 		}
-		wh, err := s.webhooks.Get(id)
-		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
-			return
-		}
-		writeJSON(w, http.StatusOK, wh)
 		return
 	}
 	if r.Method != http.MethodPost {
@@ -1977,6 +2518,35 @@ func (s *Server) handleWebhookAction(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		writeJSON(w, http.StatusOK, wh)
+	case "preview":
+		wh, err := s.webhooks.Get(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		var event control.Event
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+		}
+		if strings.TrimSpace(event.Type) == "" {
+			event.Type = wh.EventPrefix + "preview"
+		}
+		if event.Time.IsZero() {
+			event.Time = time.Now().UTC()
+		}
+		body, err := control.RenderWebhookPayload(wh, event)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"webhook_id":   wh.ID,
+			"content_type": wh.ContentType,
+			"body":         string(body),
+		})
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown webhook action"})
 	}
@@ -2002,13 +2572,17 @@ func (s *Server) handleRuns(baseDir string) http.HandlerFunc {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		keep, ok := s.labelFilter(w, r, "runs")
+		if !ok {
+			return
+		}
 		st := state.New(baseDir)
 		runs, err := st.ListRuns(200)
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, runs)
+		writeJSON(w, http.StatusOK, filterLabeled(runs, func(run state.RunRecord) map[string]string { return run.Labels }, keep))
 	}
 }
 
@@ -2041,44 +2615,8 @@ func (s *Server) handleRunDigest(baseDir string) http.HandlerFunc {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
-		filtered := make([]state.RunRecord, 0, len(runs))
-		for _, run := range runs {
-			ref := run.StartedAt
-			if ref.IsZero() {
-				ref = run.EndedAt
-			}
-			if ref.IsZero() || ref.Before(windowStart) {
-				continue
-			}
-			filtered = append(filtered, run)
-		}
-		sort.Slice(filtered, func(i, j int) bool {
-			return filtered[i].StartedAt.After(filtered[j].StartedAt)
-		})
-
-		total := len(filtered)
-		succeeded := 0
-		failed := 0
-		changedResources := 0
-		failedRunIDs := make([]string, 0)
-		for _, run := range filtered {
-			switch run.Status {
-			case state.RunSucceeded:
-				succeeded++
-			case state.RunFailed:
-				failed++
-				failedRunIDs = append(failedRunIDs, run.ID)
-			}
-			for _, res := range run.Results {
-				if res.Changed {
-					changedResources++
-				}
-			}
-		}
-		failRate := 0.0
-		if total > 0 {
-			failRate = float64(failed) / float64(total)
-		}
+		totals, failedRunIDs := summarizeRunWindow(runs, windowStart, time.Time{}, nil)
+		failRate := totals.FailRate
 
 		queueStatus := s.queue.ControlStatus()
 		emergency := s.queue.EmergencyStatus()
@@ -2114,11 +2652,11 @@ func (s *Server) handleRunDigest(baseDir string) http.HandlerFunc {
 			"window_hours":        hours,
 			"window_start":        windowStart,
 			"window_end":          now,
-			"total_runs":          total,
-			"succeeded_runs":      succeeded,
-			"failed_runs":         failed,
+			"total_runs":          totals.TotalRuns,
+			"succeeded_runs":      totals.SucceededRuns,
+			"failed_runs":         totals.FailedRuns,
 			"fail_rate":           failRate,
-			"changed_resources":   changedResources,
+			"changed_resources":   totals.ChangedResources,
 			"recent_failures":     failedRunIDs,
 			"latent_risk_score":   riskScore,
 			"latent_risk_level":   riskLevel,
@@ -2142,6 +2680,12 @@ func (s *Server) handleRunAction(baseDir string) http.HandlerFunc {
 		runID := parts[2]
 		action := parts[3]
 		switch action {
+		case "annotations":
+			if _, err := state.New(baseDir).GetRun(runID); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			s.handleAnnotations(w, r, control.AnnotationTargetRun, runID, parts[4:])
 		case "timeline":
 			if r.Method != http.MethodGet {
 				w.WriteHeader(http.StatusMethodNotAllowed)
@@ -2344,6 +2888,24 @@ func (s *Server) handleRunAction(baseDir string) http.HandlerFunc {
 				"run_id": runID,
 				"object": obj,
 			})
+		case "artifacts":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			run, err := state.New(baseDir).GetRun(runID)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			items := run.Artifacts
+			if items == nil {
+				items = []state.RunArtifact{}
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"run_id": runID,
+				"items":  items,
+			})
 		case "triage-bundle":
 			if r.Method != http.MethodPost {
 				w.WriteHeader(http.StatusMethodNotAllowed)
@@ -2368,6 +2930,13 @@ func (s *Server) handleRunAction(baseDir string) http.HandlerFunc {
 				}
 				hostSet[result.Host] = struct{}{}
 			}
+			collected := 0
+			for _, artifact := range run.Artifacts {
+				hostSet[artifact.Host] = struct{}{}
+				if artifact.Status == "collected" {
+					collected++
+				}
+			}
 			hosts := make([]string, 0, len(hostSet))
 			for host := range hostSet {
 				hosts = append(hosts, host)
@@ -2385,6 +2954,7 @@ func (s *Server) handleRunAction(baseDir string) http.HandlerFunc {
 					"provider_output": "captured in run results",
 					"diffs":           "captured in checker/check report surfaces",
 					"facts":           "available through query API/entity integrations",
+					"host_outputs":    run.Artifacts,
 				},
 				"generated_at": time.Now().UTC(),
 			}, "", "  ")
@@ -2403,6 +2973,7 @@ func (s *Server) handleRunAction(baseDir string) http.HandlerFunc {
 				"object":            obj,
 				"correlated_events": len(correlated),
 				"host_count":        len(hosts),
+				"host_artifacts":    collected,
 			})
 		default:
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown run action"})
@@ -2426,11 +2997,16 @@ func (s *Server) handleObjectStoreObjects(w http.ResponseWriter, r *http.Request
 			limit = n
 		}
 	}
+	if storage.IsWorkspaceKey(prefix) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "workspace partitions are only reachable through /v1/workspaces/{tenant}/{workspace}/objects"})
+		return
+	}
 	items, err := s.objectStore.List(prefix, limit)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
+	items = slices.DeleteFunc(items, func(item storage.ObjectInfo) bool { return storage.IsWorkspaceKey(item.Key) })
 	writeJSON(w, http.StatusOK, items)
 }
 
@@ -2462,6 +3038,10 @@ func (s *Server) handleReleaseReadiness(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		report := control.EvaluateReadiness(req.Signals, req.Thresholds)
+		if blockers := append(s.mutationTests.ReadinessBlockers(""), s.docsExamples.ReadinessBlockers()...); len(blockers) > 0 {
+			report.Blockers = append(report.Blockers, blockers...)
+			report.Pass = false
+		}
 		if !report.Pass {
 			writeJSON(w, http.StatusConflict, report)
 			return
@@ -2478,7 +3058,9 @@ func (s *Server) handleAPIContract(w http.ResponseWriter, r *http.Request) {
 	}
 	switch r.Method {
 	case http.MethodGet:
-		writeJSON(w, http.StatusOK, currentAPISpec())
+		s.serveCachedJSON(w, r, 0, []string{responseCacheTagStatic}, func() (int, any) {
+			return http.StatusOK, currentAPISpec()
+		})
 	case http.MethodPost:
 		var req reqBody
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -2550,6 +3132,13 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/wizards",
 			"GET /v1/wizards/{id}",
 			"POST /v1/wizards/{id}/launch",
+			"POST /v1/wizards/{id}/sessions",
+			"GET /v1/wizards/sessions",
+			"GET /v1/wizards/sessions/{id}",
+			"DELETE /v1/wizards/sessions/{id}",
+			"POST /v1/wizards/sessions/{id}/steps/{step_id}",
+			"POST /v1/wizards/sessions/{id}/back",
+			"POST /v1/wizards/sessions/{id}/materialize",
 			"GET /v1/tasks/plans",
 			"POST /v1/tasks/plans",
 			"GET /v1/tasks/plans/{id}",
@@ -2576,11 +3165,26 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/offline/mirrors/sync",
 			"GET /v1/docs/generate",
 			"POST /v1/docs/generate",
+			"GET /v1/docs/bundles",
+			"GET /v1/docs/bundles/{channel}/{version}/{path}",
+			"GET /v1/docs/examples/verify",
 			"POST /v1/docs/examples/verify",
+			"GET /v1/i18n/locales",
+			"POST /v1/i18n/locales",
+			"GET /v1/i18n/completeness",
 			"GET /v1/docs/api/version-diff",
 			"POST /v1/docs/api/version-diff",
 			"GET /v1/lint/style/rules",
 			"POST /v1/lint/style/analyze",
+			"GET /v1/lint/style/content-rules",
+			"POST /v1/lint/style/content-rules",
+			"DELETE /v1/lint/style/content-rules/{id}",
+			"POST /v1/lint/style/check",
+			"GET /v1/lint/style/waivers",
+			"POST /v1/lint/style/waivers",
+			"GET /v1/lint/style/waivers/{id}",
+			"POST /v1/lint/style/waivers/{id}/approve",
+			"POST /v1/lint/style/waivers/{id}/reject",
 			"POST /v1/format/canonicalize",
 			"GET /v1/policy/pull/sources",
 			"POST /v1/policy/pull/sources",
@@ -2592,9 +3196,19 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/policy/bundles/{id}",
 			"POST /v1/policy/bundles/{id}/promote",
 			"GET /v1/policy/bundles/{id}/promotions",
+			"GET /v1/policy/admission/policies",
+			"POST /v1/policy/admission/policies",
+			"GET /v1/policy/admission/policies/{id}",
+			"PUT /v1/policy/admission/policies/{id}",
+			"DELETE /v1/policy/admission/policies/{id}",
+			"GET /v1/policy/admission/policies/{id}/versions",
+			"GET /v1/policy/admission/policies/{id}/versions/{version}",
+			"POST /v1/policy/admission/test",
+			"POST /v1/policy/admission/evaluate",
 			"GET /v1/inventory/groups",
 			"POST /v1/inventory/export/bundle",
 			"POST /v1/inventory/import/cmdb",
+			"POST /v1/inventory/import/cmdb/stream",
 			"POST /v1/inventory/import/bundle",
 			"POST /v1/inventory/import/assist",
 			"POST /v1/inventory/import/brownfield-bootstrap",
@@ -2613,6 +3227,14 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/inventory/node-classifiers/classify",
 			"GET /v1/compat/grains",
 			"POST /v1/compat/grains/query",
+			"GET /v1/compat/grains/modules",
+			"POST /v1/compat/grains/modules",
+			"GET /v1/compat/grains/modules/{name}",
+			"DELETE /v1/compat/grains/modules/{name}",
+			"GET /v1/compat/grains/agent/due",
+			"GET /v1/compat/grains/agent/results",
+			"POST /v1/compat/grains/agent/results",
+			"POST /v1/compat/grains/agent/run",
 			"GET /v1/compat/shims",
 			"POST /v1/compat/shims",
 			"GET /v1/compat/shims/{id}",
@@ -2625,6 +3247,11 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/inventory/discovery-sources/sync",
 			"POST /v1/inventory/cloud-sync",
 			"GET /v1/fleet/health",
+			"GET /v1/fleet/health/scoring",
+			"POST /v1/fleet/health/scoring",
+			"DELETE /v1/fleet/health/scoring",
+			"GET /v1/fleet/health/scores",
+			"GET /v1/fleet/health/history",
 			"GET /v1/inventory/runtime-hosts",
 			"POST /v1/inventory/runtime-hosts",
 			"POST /v1/inventory/enroll",
@@ -2643,9 +3270,22 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/agents/dispatch-environments/{environment}",
 			"GET /v1/agents/dispatch",
 			"POST /v1/agents/dispatch",
+			"GET /v1/agents/transports",
+			"GET /v1/agents/transports/policies",
+			"POST /v1/agents/transports/policies",
+			"GET /v1/agents/transports/messages",
+			"POST /v1/agents/transports/messages",
+			"GET /v1/agents/transports/messages/{id}",
+			"GET /v1/agents/transports/poll",
+			"POST /v1/agents/transports/ack",
+			"GET /v1/agents/transports/ws",
 			"GET /v1/agents/proxy-minions",
 			"POST /v1/agents/proxy-minions",
 			"GET /v1/agents/proxy-minions/{id}",
+			"POST /v1/agents/proxy-minions/{id}/apply",
+			"GET /v1/agents/proxy-minions/{id}/facts",
+			"POST /v1/agents/proxy-minions/{id}/facts",
+			"GET /v1/agents/proxy-minions/modules",
 			"GET /v1/agents/proxy-minions/dispatch",
 			"POST /v1/agents/proxy-minions/dispatch",
 			"GET /v1/execution/network-transports",
@@ -2673,6 +3313,11 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/execution/image-baking/pipelines",
 			"GET /v1/execution/image-baking/pipelines/{id}",
 			"POST /v1/execution/image-baking/pipelines/{id}/plan",
+			"GET /v1/execution/image-baking/pipelines/{id}/sbom",
+			"POST /v1/execution/image-baking/pipelines/{id}/sbom",
+			"GET /v1/execution/image-baking/pipelines/{id}/scans",
+			"POST /v1/execution/image-baking/pipelines/{id}/scans",
+			"POST /v1/execution/image-baking/pipelines/{id}/attestations",
 			"GET /v1/execution/artifacts/deployments",
 			"POST /v1/execution/artifacts/deployments",
 			"GET /v1/execution/artifacts/deployments/{id}",
@@ -2689,9 +3334,19 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/execution/snapshots",
 			"POST /v1/execution/snapshots",
 			"GET /v1/execution/snapshots/{id}",
+			"GET /v1/execution/snapshots/diff",
+			"POST /v1/execution/snapshots/{id}/restore",
 			"GET /v1/execution/environments",
 			"POST /v1/execution/environments",
 			"GET /v1/execution/environments/{id}",
+			"GET /v1/execution/isolated-runs",
+			"GET /v1/execution/isolated-runs/{job_id}",
+			"GET /v1/execution/cloud-credentials/bindings",
+			"POST /v1/execution/cloud-credentials/bindings",
+			"GET /v1/execution/cloud-credentials/bindings/{id}",
+			"GET /v1/execution/cloud-credentials/leases",
+			"GET /v1/execution/cloud-credentials/leases/{id}",
+			"POST /v1/execution/cloud-credentials/leases/{id}/revoke",
 			"GET /v1/execution/admission-policy",
 			"POST /v1/execution/admission-policy",
 			"POST /v1/execution/admit-check",
@@ -2718,6 +3373,11 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/security/host-profiles",
 			"POST /v1/security/host-profiles",
 			"POST /v1/security/host-profiles/evaluate",
+			"POST /v1/security/host-profiles/{id}/check",
+			"GET /v1/security/host-profiles/evaluations",
+			"GET /v1/security/host-profiles/evaluations/{id}",
+			"POST /v1/security/host-profiles/evaluations/{id}/remediation/plan",
+			"POST /v1/security/host-profiles/evaluations/{id}/remediation/runbook",
 			"GET /v1/security/signatures/keyrings",
 			"POST /v1/security/signatures/keyrings",
 			"GET /v1/security/signatures/keyrings/{id}",
@@ -2743,15 +3403,20 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/access/approval-policies",
 			"POST /v1/access/approval-policies",
 			"GET /v1/access/approval-policies/{id}",
+			"GET /v1/access/approval-policies/evaluate",
 			"GET /v1/access/break-glass/requests",
 			"POST /v1/access/break-glass/requests",
 			"GET /v1/access/break-glass/requests/{id}",
 			"POST /v1/access/break-glass/requests/{id}/approve",
 			"POST /v1/access/break-glass/requests/{id}/reject",
 			"POST /v1/access/break-glass/requests/{id}/revoke",
+			"POST /v1/access/break-glass/requests/{id}/postmortem",
+			"GET /v1/access/break-glass/elevated",
 			"GET /v1/access/jit-grants",
 			"POST /v1/access/jit-grants",
 			"POST /v1/access/jit-grants/validate",
+			"GET /v1/access/jit-grants/enforcement",
+			"POST /v1/access/jit-grants/enforcement",
 			"GET /v1/access/jit-grants/{id}",
 			"POST /v1/access/jit-grants/{id}/revoke",
 			"GET /v1/access/rbac/roles",
@@ -2770,20 +3435,49 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/identity/sso/providers/{id}/disable",
 			"POST /v1/identity/sso/login/start",
 			"POST /v1/identity/sso/login/callback",
+			"GET /v1/identity/sso/saml/{provider_id}/metadata",
+			"POST /v1/identity/sso/saml/{provider_id}/acs",
 			"GET /v1/identity/sso/sessions",
 			"GET /v1/identity/sso/sessions/{id}",
+			"DELETE /v1/identity/sso/sessions/{id}",
+			"GET /v1/identity/sso/sessions/current",
+			"POST /v1/identity/sso/sessions/refresh",
+			"POST /v1/identity/sso/sessions/revoke",
+			"GET /v1/identity/sso/session-policy",
+			"POST /v1/identity/sso/session-policy",
 			"GET /v1/identity/scim/roles",
 			"POST /v1/identity/scim/roles",
 			"GET /v1/identity/scim/roles/{id}",
 			"GET /v1/identity/scim/teams",
 			"POST /v1/identity/scim/teams",
 			"GET /v1/identity/scim/teams/{id}",
+			"GET /v1/identity/scim/group-mappings",
+			"POST /v1/identity/scim/group-mappings",
+			"DELETE /v1/identity/scim/group-mappings/{id}",
+			"GET /scim/v2/ServiceProviderConfig",
+			"GET /scim/v2/Users",
+			"POST /scim/v2/Users",
+			"GET /scim/v2/Users/{id}",
+			"PUT /scim/v2/Users/{id}",
+			"PATCH /scim/v2/Users/{id}",
+			"DELETE /scim/v2/Users/{id}",
+			"GET /scim/v2/Groups",
+			"POST /scim/v2/Groups",
+			"GET /scim/v2/Groups/{id}",
+			"PUT /scim/v2/Groups/{id}",
+			"PATCH /scim/v2/Groups/{id}",
+			"DELETE /scim/v2/Groups/{id}",
 			"GET /v1/identity/oidc/workload/providers",
 			"POST /v1/identity/oidc/workload/providers",
 			"GET /v1/identity/oidc/workload/providers/{id}",
 			"POST /v1/identity/oidc/workload/exchange",
 			"GET /v1/identity/oidc/workload/credentials",
 			"GET /v1/identity/oidc/workload/credentials/{id}",
+			"GET /v1/security/secret-scanning/policy",
+			"POST /v1/security/secret-scanning/policy",
+			"GET /v1/security/secret-scans",
+			"POST /v1/security/secret-scans",
+			"GET /v1/security/secret-scans/{id}",
 			"GET /v1/security/mtls/authorities",
 			"POST /v1/security/mtls/authorities",
 			"GET /v1/security/mtls/policies",
@@ -2796,9 +3490,26 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/packages/artifacts",
 			"POST /v1/packages/artifacts",
 			"GET /v1/packages/artifacts/{id}",
+			"GET /v1/packages/artifacts/{id}/download",
+			"GET /v1/packages/artifacts/{id}/sbom",
+			"POST /v1/packages/artifacts/{id}/sbom",
+			"GET /v1/packages/artifacts/{id}/scans",
+			"POST /v1/packages/artifacts/{id}/scans",
+			"GET /v1/packages/vulnerability-policy",
+			"POST /v1/packages/vulnerability-policy",
+			"GET /v1/packages/versions",
+			"POST /v1/packages/resolve",
 			"GET /v1/packages/signing-policy",
 			"POST /v1/packages/signing-policy",
 			"POST /v1/packages/verify",
+			"GET /v1/packages/attestations",
+			"POST /v1/packages/attestations",
+			"GET /v1/packages/attestations/{id}",
+			"GET /v1/packages/attestations/keys",
+			"POST /v1/packages/attestations/keys",
+			"GET /v1/packages/attestations/policy",
+			"POST /v1/packages/attestations/policy",
+			"POST /v1/packages/attestations/verify",
 			"GET /v1/packages/cosign/trust-roots",
 			"POST /v1/packages/cosign/trust-roots",
 			"GET /v1/packages/cosign/trust-roots/{id}",
@@ -2823,6 +3534,8 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/packages/content-channels/remotes",
 			"GET /v1/packages/content-channels/remotes/{id}",
 			"POST /v1/packages/content-channels/remotes/{id}/rotate-token",
+			"POST /v1/packages/content-channels/remotes/{id}/sync",
+			"GET /v1/packages/content-channels/remotes/{id}/syncs",
 			"GET /v1/packages/scaffold/templates",
 			"POST /v1/packages/scaffold/generate",
 			"POST /v1/packages/interface-compat/analyze",
@@ -2831,6 +3544,21 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/packages/pinning/evaluate",
 			"GET /v1/agents/cert-policy",
 			"POST /v1/agents/cert-policy",
+			"GET /v1/agents/ca-backend",
+			"POST /v1/agents/ca-backend",
+			"GET /v1/agents/pki/ca",
+			"GET /v1/agents/pki/crl",
+			"POST /v1/agents/pki/crl/publish",
+			"GET /v1/agents/pki/revocation",
+			"POST /v1/agents/pki/revocation",
+			"GET /v1/agents/pki/status/{serial}",
+			"GET /v1/server-certificates",
+			"POST /v1/server-certificates",
+			"GET /v1/server-certificates/acme",
+			"POST /v1/server-certificates/acme",
+			"GET /v1/server-certificates/{id}",
+			"POST /v1/server-certificates/{id}/renew",
+			"GET /.well-known/acme-challenge/{token}",
 			"GET /v1/agents/catalogs",
 			"POST /v1/agents/catalogs",
 			"GET /v1/agents/catalogs/{id}",
@@ -2838,10 +3566,14 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/agents/catalogs/replays",
 			"GET /v1/agents/attestation/policy",
 			"POST /v1/agents/attestation/policy",
+			"GET /v1/agents/attestation/keys",
+			"POST /v1/agents/attestation/keys",
+			"GET /v1/agents/attestation/status",
 			"GET /v1/agents/attestations",
 			"POST /v1/agents/attestations",
 			"GET /v1/agents/attestations/{id}",
 			"POST /v1/agents/attestations/check",
+			"POST /v1/agents/attestations/challenge",
 			"GET /v1/agents/csrs",
 			"POST /v1/agents/csrs",
 			"POST /v1/agents/csrs/{id}/approve",
@@ -2891,6 +3623,8 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/gitops/promotions",
 			"GET /v1/gitops/promotions/{id}",
 			"POST /v1/gitops/promotions/{id}/advance",
+			"POST /v1/gitops/promotions/{id}/approve",
+			"POST /v1/gitops/promotions/{id}/reject",
 			"GET /v1/gitops/pr-comments",
 			"POST /v1/gitops/pr-comments",
 			"GET /v1/gitops/approval-gates",
@@ -2901,6 +3635,7 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/gitops/plan-artifacts/sign",
 			"POST /v1/gitops/plan-artifacts/verify",
 			"GET /v1/incidents/view",
+			"POST /v1/incidents/export",
 			"GET /v1/fleet/nodes",
 			"GET /v1/drift/insights",
 			"GET /v1/drift/history",
@@ -2918,9 +3653,13 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/metrics",
 			"GET /v1/features/summary",
 			"GET /v1/docs/actions",
+			"POST /v1/docs/actions",
 			"GET /v1/docs/actions/{id}",
 			"GET /v1/model/objects",
 			"GET /v1/model/objects/resolve",
+			"GET /v1/model/dependencies",
+			"POST /v1/model/dependencies",
+			"GET /v1/model/dependencies/impact",
 			"GET /v1/docs/inline",
 			"POST /v1/plans/explain",
 			"POST /v1/plans/graph",
@@ -2934,13 +3673,31 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/policy/enforcement-modes",
 			"GET /v1/policy/enforcement-modes/{policy_ref}",
 			"POST /v1/policy/enforcement-modes/evaluate",
+			"POST /v1/policy/enforcement-modes/violations",
+			"POST /v1/policy/enforcement-modes/promote",
+			"GET /v1/policy/enforcement-modes/{policy_ref}/timeline",
 			"GET /v1/alerts/inbox",
 			"POST /v1/alerts/inbox",
+			"GET /v1/alerts/{id}",
+			"GET /v1/alerts/{id}/annotations",
+			"POST /v1/alerts/{id}/annotations",
+			"GET /v1/alerts/{id}/annotations/{annotation_id}",
+			"DELETE /v1/alerts/{id}/annotations/{annotation_id}",
 			"GET /v1/notifications/targets",
 			"POST /v1/notifications/targets",
+			"GET /v1/notifications/targets/{id}",
+			"PATCH /v1/notifications/targets/{id}",
 			"POST /v1/notifications/targets/{id}/enable",
 			"POST /v1/notifications/targets/{id}/disable",
+			"POST /v1/notifications/targets/{id}/preview",
 			"GET /v1/notifications/deliveries",
+			"GET /v1/notifications/policies",
+			"POST /v1/notifications/policies",
+			"POST /v1/notifications/policies/simulate",
+			"GET /v1/notifications/policies/{id}",
+			"PUT /v1/notifications/policies/{id}",
+			"DELETE /v1/notifications/policies/{id}",
+			"GET /v1/notifications/escalations",
 			"GET /v1/reports/processors",
 			"POST /v1/reports/processors",
 			"GET /v1/reports/processors/{id}",
@@ -2962,6 +3719,7 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/change-records/ticket-integrations/{id}/disable",
 			"GET /v1/change-records/tickets/sync",
 			"POST /v1/change-records/tickets/sync",
+			"POST /v1/change-records/tickets/callback/{integration_id}",
 			"POST /v1/bulk/preview",
 			"POST /v1/bulk/execute",
 			"GET /v1/views",
@@ -2971,6 +3729,12 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/views/{id}/pin",
 			"POST /v1/views/{id}/share",
 			"GET /v1/views/home",
+			"GET /v1/views/home/preferences",
+			"PUT /v1/views/home/preferences",
+			"DELETE /v1/views/home/preferences",
+			"POST /v1/views/home/preferences/cards",
+			"GET /v1/views/home/mandatory",
+			"PUT /v1/views/home/mandatory",
 			"GET /v1/views/workloads",
 			"GET /v1/ui/accessibility/profiles",
 			"POST /v1/ui/accessibility/profiles",
@@ -2988,6 +3752,7 @@ func currentAPISpec() control.APISpec {
 			"DELETE /v1/ui/dashboard/widgets/{id}",
 			"POST /v1/ui/dashboard/widgets/{id}/pin",
 			"POST /v1/ui/dashboard/widgets/{id}/refresh",
+			"GET /v1/ui/dashboard/widgets/{id}/data",
 			"POST /v1/migrations/assess",
 			"GET /v1/migrations/reports",
 			"GET /v1/migrations/reports/{id}",
@@ -3020,6 +3785,7 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/release/dependency-bot/updates",
 			"GET /v1/release/dependency-bot/updates/{id}",
 			"POST /v1/release/dependency-bot/updates/{id}/evaluate",
+			"POST /v1/release/dependency-bot/executions",
 			"GET /v1/release/performance-gates/policy",
 			"POST /v1/release/performance-gates/policy",
 			"POST /v1/release/performance-gates/evaluate",
@@ -3031,6 +3797,7 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/release/tests/flake-cases/{id}",
 			"POST /v1/release/tests/flake-cases/{id}/quarantine",
 			"POST /v1/release/tests/flake-cases/{id}/unquarantine",
+			"POST /v1/release/tests/flake-reevaluate",
 			"POST /v1/release/tests/impact-analysis",
 			"GET /v1/release/tests/scenarios",
 			"POST /v1/release/tests/scenarios",
@@ -3038,9 +3805,11 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/release/tests/scenario-runs",
 			"GET /v1/release/tests/scenario-runs/{id}",
 			"POST /v1/release/tests/scenario-runs/{id}/compare-baseline",
+			"POST /v1/release/tests/scenario-executions",
 			"GET /v1/release/tests/scenario-baselines",
 			"POST /v1/release/tests/scenario-baselines",
 			"GET /v1/release/tests/scenario-baselines/{id}",
+			"POST /v1/release/tests/scenario-baselines/{id}",
 			"GET /v1/release/tests/environments",
 			"POST /v1/release/tests/environments",
 			"GET /v1/release/tests/environments/{id}",
@@ -3059,6 +3828,8 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/release/tests/mutation/runs",
 			"POST /v1/release/tests/mutation/runs",
 			"GET /v1/release/tests/mutation/runs/{id}",
+			"GET /v1/release/tests/mutation/runs/{id}/diagnostics",
+			"POST /v1/release/tests/mutation/executions",
 			"GET /v1/release/tests/property-harness/cases",
 			"POST /v1/release/tests/property-harness/cases",
 			"GET /v1/release/tests/property-harness/runs",
@@ -3074,6 +3845,7 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/providers/conformance/runs",
 			"POST /v1/providers/conformance/runs",
 			"GET /v1/providers/conformance/runs/{id}",
+			"POST /v1/providers/conformance/execute",
 			"GET /v1/providers/conformance/fixtures",
 			"POST /v1/providers/conformance/fixtures",
 			"GET /v1/providers/conformance/fixtures/{id}",
@@ -3096,6 +3868,8 @@ func currentAPISpec() control.APISpec {
 			"PUT /v1/data-bags/{bag}/{item}",
 			"DELETE /v1/data-bags/{bag}/{item}",
 			"POST /v1/data-bags/search",
+			"GET /v1/data-bags/keys",
+			"POST /v1/data-bags/keys",
 			"GET /v1/roles",
 			"POST /v1/roles",
 			"GET /v1/roles/{name}",
@@ -3105,6 +3879,8 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/environments",
 			"GET /v1/environments/{name}",
 			"DELETE /v1/environments/{name}",
+			"POST /v1/environments/{name}/constraints/check",
+			"POST /v1/environments/{name}/guardrails/check",
 			"GET /v1/vars/encrypted/keys",
 			"POST /v1/vars/encrypted/keys",
 			"GET /v1/vars/encrypted/files",
@@ -3132,7 +3908,20 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/facts/cache/{node}",
 			"DELETE /v1/facts/cache/{node}",
 			"POST /v1/facts/mine/query",
+			"GET /v1/facts/mine",
+			"GET /v1/facts/mine/functions",
+			"POST /v1/facts/mine/functions",
+			"DELETE /v1/facts/mine/functions/{name}",
+			"POST /v1/facts/mine/publish",
 			"POST /v1/events/ingest",
+			"POST /v1/events/ingest/stream",
+			"POST /v1/events/replay",
+			"GET /v1/events/schemas",
+			"POST /v1/events/schemas",
+			"GET /v1/events/schemas/{type}",
+			"POST /v1/events/schemas/{type}/compatibility",
+			"POST /v1/events/schemas/validate",
+			"GET /v1/events/schemas/violations",
 			"POST /v1/event-stream/ingest",
 			"POST /v1/event-stream/webhooks/ingest",
 			"GET /v1/converge/triggers",
@@ -3142,7 +3931,15 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/resources/exported",
 			"POST /v1/resources/collect",
 			"POST /v1/commands/ingest",
+			"POST /v1/commands/ingest/batch",
+			"GET /v1/commands/ingest/policy",
+			"POST /v1/commands/ingest/policy",
 			"GET /v1/commands/dead-letters",
+			"GET /v1/commands/dead-letters/{id}",
+			"POST /v1/commands/dead-letters/{id}/replay",
+			"POST /v1/commands/dead-letters/replay",
+			"GET /v1/commands/retry-policy",
+			"POST /v1/commands/retry-policy",
 			"GET /v1/commands/adhoc",
 			"POST /v1/commands/adhoc",
 			"GET /v1/commands/adhoc/policy",
@@ -3150,14 +3947,36 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/object-store/objects",
 			"POST /v1/control/backup",
 			"GET /v1/control/backups",
+			"GET /v1/control/backup-schedules",
+			"POST /v1/control/backup-schedules",
+			"GET /v1/control/backup-schedules/{id}",
+			"DELETE /v1/control/backup-schedules/{id}",
+			"POST /v1/control/backup-schedules/{id}/run",
+			"POST /v1/control/backup-schedules/{id}/enable",
+			"POST /v1/control/backup-schedules/{id}/disable",
+			"GET /v1/control/backup-sets",
+			"GET /v1/control/backup-sets/{id}",
+			"POST /v1/control/backup-sets/{id}/verify",
 			"POST /v1/control/restore",
+			"GET /v1/control/snapshot",
+			"POST /v1/control/snapshot/import",
+			"GET /v1/control/follower/status",
+			"POST /v1/control/follower/sync",
 			"POST /v1/control/drill",
 			"POST /v1/control/emergency-stop",
 			"GET /v1/control/emergency-stop",
+			"GET /v1/control/emergency-stop/scopes",
+			"POST /v1/control/emergency-stop/scopes",
+			"DELETE /v1/control/emergency-stop/scopes/{id}",
 			"POST /v1/control/freeze",
 			"GET /v1/control/freeze",
 			"POST /v1/control/maintenance",
 			"GET /v1/control/maintenance",
+			"GET /v1/control/host-maintenance",
+			"POST /v1/control/host-maintenance",
+			"GET /v1/control/host-maintenance/{id}",
+			"POST /v1/control/host-maintenance/{id}/end",
+			"GET /v1/control/host-maintenance/deferred",
 			"GET /v1/control/handoff",
 			"GET /v1/control/topology-advisor",
 			"GET /v1/control/deployment-profiles",
@@ -3177,22 +3996,46 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/control/health-probes",
 			"POST /v1/control/health-probes/checks",
 			"POST /v1/control/health-probes/evaluate",
+			"GET /v1/control/health-probes/{id}",
+			"POST /v1/control/health-probes/{id}/run",
 			"POST /v1/control/channels",
 			"GET /v1/control/channels",
 			"GET /v1/control/canary-upgrades",
 			"POST /v1/control/canary-upgrades",
 			"GET /v1/control/canary-upgrades/{id}",
+			"GET /v1/control/canary-analysis",
+			"POST /v1/control/canary-analysis",
+			"GET /v1/control/canary-analysis/{id}",
 			"GET /v1/control/upgrade-orchestration/plans",
 			"POST /v1/control/upgrade-orchestration/plans",
 			"GET /v1/control/upgrade-orchestration/plans/{id}",
 			"POST /v1/control/upgrade-orchestration/plans/{id}/advance",
 			"POST /v1/control/upgrade-orchestration/plans/{id}/abort",
+			"POST /v1/control/upgrade-orchestration/plans/{id}/drill",
+			"GET /v1/control/upgrade-orchestration/drills",
+			"GET /v1/control/upgrade-orchestration/drills/{id}",
+			"GET /v1/control/response-cache",
+			"POST /v1/control/response-cache/invalidate",
+			"GET /v1/control/response-compression",
+			"POST /v1/control/response-compression",
+			"GET /v1/control/request-limits",
+			"POST /v1/control/request-limits",
+			"GET /v1/control/log-level",
+			"POST /v1/control/log-level",
 			"GET /v1/control/failover-drills",
 			"POST /v1/control/failover-drills",
 			"GET /v1/control/failover-drills/scorecards",
 			"GET /v1/control/performance/profiles",
 			"POST /v1/control/performance/profiles",
+			"GET /v1/control/performance/diagnostics",
 			"POST /v1/control/performance/diagnostics",
+			"GET /v1/control/performance/profiler",
+			"POST /v1/control/performance/profiler",
+			"GET /v1/control/performance/profiler/captures",
+			"POST /v1/control/performance/profiler/captures",
+			"GET /v1/control/performance/profiler/captures/{id}",
+			"GET /v1/control/performance/profiler/captures/{id}/{kind}",
+			"GET /v1/control/debug/pprof/{profile}",
 			"GET /v1/control/topology-placement/policies",
 			"POST /v1/control/topology-placement/policies",
 			"POST /v1/control/topology-placement/decide",
@@ -3207,15 +4050,39 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/control/leak-detection/policy",
 			"POST /v1/control/leak-detection/snapshots",
 			"GET /v1/control/leak-detection/reports",
+			"POST /v1/control/leak-detection/capture",
+			"GET /v1/control/shards",
+			"GET /v1/control/shards/events/policy",
+			"POST /v1/control/shards/events/policy",
+			"GET /v1/control/shards/queue/policy",
+			"POST /v1/control/shards/queue/policy",
+			"GET /v1/control/shards/events/{shard}/spilled",
 			"GET /v1/control/federation/peers",
 			"POST /v1/control/federation/peers",
 			"GET /v1/control/federation/peers/{id}",
 			"POST /v1/control/federation/peers/{id}/health",
 			"GET /v1/control/federation/health",
+			"GET /v1/control/federation/jobs",
+			"POST /v1/control/federation/jobs",
+			"GET /v1/control/federation/jobs/{id}",
+			"GET /v1/control/federation/forwarded",
+			"GET /v1/control/federation/forwarded/{id}",
+			"POST /v1/control/federation/forwarded/sync",
+			"GET /v1/control/federation/runs",
 			"GET /v1/control/scheduler/partitions",
 			"POST /v1/control/scheduler/partitions",
 			"GET /v1/control/scheduler/partitions/{id}",
+			"DELETE /v1/control/scheduler/partitions/{id}",
 			"POST /v1/control/scheduler/partition-decision",
+			"GET /v1/control/scheduler/partition-lag",
+			"POST /v1/control/scheduler/rebalance",
+			"GET /v1/control/scheduler/workers",
+			"POST /v1/control/scheduler/workers",
+			"GET /v1/control/scheduler/workers/{id}",
+			"DELETE /v1/control/scheduler/workers/{id}",
+			"POST /v1/control/scheduler/workers/{id}/heartbeat",
+			"POST /v1/control/scheduler/workers/{id}/claim",
+			"POST /v1/control/scheduler/workers/{id}/complete",
 			"GET /v1/control/autoscaling/policy",
 			"POST /v1/control/autoscaling/policy",
 			"POST /v1/control/autoscaling/recommend",
@@ -3225,9 +4092,25 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/control/artifact-distribution/policies",
 			"POST /v1/control/artifact-distribution/policies",
 			"POST /v1/control/artifact-distribution/plan",
+			"GET /v1/control/artifact-replication/targets",
+			"POST /v1/control/artifact-replication/targets",
+			"DELETE /v1/control/artifact-replication/targets",
+			"GET /v1/control/artifact-replication/jobs",
+			"POST /v1/control/artifact-replication/jobs",
+			"POST /v1/control/artifact-replication/run",
+			"GET /v1/control/artifact-replication/lag",
 			"GET /v1/control/workspaces/isolation-policies",
 			"POST /v1/control/workspaces/isolation-policies",
 			"POST /v1/control/workspaces/isolation/evaluate",
+			"POST /v1/control/workspaces/migrate",
+			"GET /v1/workspaces/{tenant}/{workspace}/objects",
+			"GET /v1/workspaces/{tenant}/{workspace}/objects/{key}",
+			"PUT /v1/workspaces/{tenant}/{workspace}/objects/{key}",
+			"DELETE /v1/workspaces/{tenant}/{workspace}/objects/{key}",
+			"GET /v1/workspaces/{tenant}/{workspace}/runs",
+			"GET /v1/workspaces/{tenant}/{workspace}/runs/{id}",
+			"GET /v1/workspaces/{tenant}/{workspace}/sessions",
+			"GET /v1/workspaces/{tenant}/{workspace}/sessions/{id}",
 			"GET /v1/control/tenancy/policies",
 			"POST /v1/control/tenancy/policies",
 			"POST /v1/control/tenancy/admit-check",
@@ -3238,12 +4121,27 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/control/delegated-admin/grants",
 			"POST /v1/control/delegated-admin/grants",
 			"POST /v1/control/delegated-admin/authorize",
+			"GET /v1/control/delegated-admin/keys",
+			"POST /v1/control/delegated-admin/keys",
+			"GET /v1/control/delegated-admin/keys/{id}",
+			"POST /v1/control/delegated-admin/keys/{id}/rotate",
+			"POST /v1/control/delegated-admin/keys/{id}/revoke",
 			"GET /v1/control/multi-master/nodes",
 			"POST /v1/control/multi-master/nodes",
 			"GET /v1/control/multi-master/nodes/{id}",
 			"POST /v1/control/multi-master/nodes/{id}/heartbeat",
 			"GET /v1/control/multi-master/cache",
 			"POST /v1/control/multi-master/cache",
+			"GET /v1/control/multi-master/replication/status",
+			"POST /v1/control/multi-master/replication/policies",
+			"POST /v1/control/multi-master/replication/writes",
+			"GET /v1/control/multi-master/replication/gossip",
+			"POST /v1/control/multi-master/replication/gossip",
+			"POST /v1/control/multi-master/replication/invalidations",
+			"GET /v1/control/multi-master/replication/conflicts",
+			"GET /v1/control/multi-master/replication/digest",
+			"POST /v1/control/multi-master/replication/reconcile",
+			"POST /v1/control/multi-master/replication/sync",
 			"POST /v1/control/schema-migrations",
 			"GET /v1/control/schema-migrations",
 			"GET /v1/schema/models",
@@ -3256,6 +4154,9 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/control/disruption-budgets",
 			"POST /v1/control/disruption-budgets",
 			"POST /v1/control/disruption-budgets/evaluate",
+			"GET /v1/control/disruption-budgets/claims",
+			"POST /v1/control/disruption-budgets/claims",
+			"POST /v1/control/disruption-budgets/claims/{id}/release",
 			"POST /v1/control/queue",
 			"GET /v1/control/queue",
 			"GET /v1/control/queue/backends",
@@ -3267,12 +4168,19 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/control/queue/backlog-slo/policy",
 			"POST /v1/control/queue/backlog-slo/policy",
 			"GET /v1/control/queue/backlog-slo/status",
+			"GET /v1/control/queue/shedding/policy",
+			"POST /v1/control/queue/shedding/policy",
+			"GET /v1/control/queue/shedding/status",
+			"GET /v1/control/queue/dispatch-windows",
+			"POST /v1/control/queue/dispatch-windows",
+			"DELETE /v1/control/queue/dispatch-windows/{name}",
 			"POST /v1/control/workers/lifecycle",
 			"GET /v1/control/workers/lifecycle",
 			"GET /v1/control/execution-locks",
 			"POST /v1/control/execution-locks",
 			"POST /v1/control/execution-locks/release",
 			"POST /v1/control/execution-locks/cleanup",
+			"GET /v1/control/execution-locks/wait-graph",
 			"GET /v1/control/run-leases",
 			"POST /v1/control/run-leases",
 			"POST /v1/control/run-leases/heartbeat",
@@ -3285,19 +4193,46 @@ func currentAPISpec() control.APISpec {
 			"GET /v1/control/recover-stuck/status",
 			"GET /v1/runs",
 			"GET /v1/runs/digest",
+			"GET /v1/runs/digest/schedules",
+			"POST /v1/runs/digest/schedules",
+			"GET /v1/runs/digest/schedules/{id}",
+			"DELETE /v1/runs/digest/schedules/{id}",
+			"POST /v1/runs/digest/schedules/{id}/run",
+			"POST /v1/runs/digest/schedules/{id}/enable",
+			"POST /v1/runs/digest/schedules/{id}/disable",
+			"GET /v1/runs/digest/reports",
+			"GET /v1/runs/digest/reports/{id}",
 			"GET /v1/runs/compare",
 			"GET /v1/runs/{id}/timeline",
+			"GET /v1/runs/{id}/annotations",
+			"POST /v1/runs/{id}/annotations",
+			"GET /v1/runs/{id}/annotations/{annotation_id}",
+			"DELETE /v1/runs/{id}/annotations/{annotation_id}",
 			"GET /v1/runs/{id}/correlations",
 			"POST /v1/runs/{id}/retry",
 			"POST /v1/runs/{id}/rollback",
 			"POST /v1/runs/{id}/export",
 			"POST /v1/runs/{id}/triage-bundle",
+			"GET /v1/runs/{id}/artifacts",
 			"GET /v1/jobs",
 			"POST /v1/jobs",
 			"GET /v1/jobs/{id}",
 			"DELETE /v1/jobs/{id}",
+			"GET /v1/jobs/{id}/annotations",
+			"POST /v1/jobs/{id}/annotations",
+			"GET /v1/jobs/{id}/annotations/{annotation_id}",
+			"DELETE /v1/jobs/{id}/annotations/{annotation_id}",
+			"POST /v1/jobs/{id}/placement",
+			"GET /v1/jobs/{id}/concurrency",
+			"GET /v1/jobs/upcoming",
+			"POST /v1/jobs/{id}/reschedule",
 			"GET /v1/templates",
 			"POST /v1/templates",
+			"GET /v1/templates/{id}",
+			"PUT /v1/templates/{id}",
+			"PATCH /v1/templates/{id}",
+			"GET /v1/templates/{id}/versions",
+			"GET /v1/templates/{id}/versions/{version}",
 			"POST /v1/templates/{id}/launch",
 			"POST /v1/templates/{id}/render",
 			"DELETE /v1/templates/{id}/delete",
@@ -3308,11 +4243,17 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/runbooks/{id}/approve",
 			"POST /v1/runbooks/{id}/deprecate",
 			"POST /v1/runbooks/{id}/launch",
+			"GET /v1/stewardship/policy",
+			"POST /v1/stewardship/policy",
+			"GET /v1/stewardship/report",
 			"GET /v1/workflows",
 			"POST /v1/workflows",
 			"POST /v1/workflows/{id}/launch",
 			"GET /v1/workflow-runs",
 			"GET /v1/workflow-runs/{id}",
+			"POST /v1/orchestrations/plan",
+			"POST /v1/orchestrations/launch",
+			"GET /v1/orchestrations/runs",
 			"GET /v1/canaries",
 			"POST /v1/canaries",
 			"GET /v1/canaries/{id}",
@@ -3330,22 +4271,50 @@ func currentAPISpec() control.APISpec {
 			"POST /v1/schedules",
 			"POST /v1/schedules/{id}/enable",
 			"POST /v1/schedules/{id}/disable",
+			"GET /v1/schedules/{id}",
+			"PATCH /v1/schedules/{id}",
+			"DELETE /v1/schedules/{id}",
+			"GET /v1/config-as-code",
+			"POST /v1/config-as-code",
+			"POST /v1/config-as-code/sync",
+			"POST /v1/config-as-code/import",
+			"GET /v1/config-as-code/diff",
 			"GET /v1/rules",
 			"POST /v1/rules",
 			"GET /v1/rules/{id}",
+			"PUT /v1/rules/{id}",
+			"PATCH /v1/rules/{id}",
+			"GET /v1/rules/{id}/versions",
+			"GET /v1/rules/{id}/versions/{version}",
 			"POST /v1/rules/{id}/enable",
 			"POST /v1/rules/{id}/disable",
+			"DELETE /v1/rules/{id}",
+			"GET /v1/trash",
+			"GET /v1/trash/{id}",
+			"DELETE /v1/trash/{id}",
+			"POST /v1/trash/{id}/restore",
+			"POST /v1/trash/purge",
+			"GET /v1/trash/policy",
+			"POST /v1/trash/policy",
 			"GET /v1/compat/beacon-reactor/rules",
 			"POST /v1/compat/beacon-reactor/rules",
 			"GET /v1/compat/beacon-reactor/rules/{id}",
 			"POST /v1/compat/beacon-reactor/rules/{id}/enable",
 			"POST /v1/compat/beacon-reactor/rules/{id}/disable",
 			"POST /v1/compat/beacon-reactor/emit",
+			"GET /v1/compat/beacons",
+			"POST /v1/compat/beacons",
+			"GET /v1/compat/beacons/{id}",
+			"DELETE /v1/compat/beacons/{id}",
+			"GET /v1/compat/beacons/states",
+			"POST /v1/compat/beacons/evaluate",
 			"GET /v1/webhooks",
 			"POST /v1/webhooks",
 			"GET /v1/webhooks/{id}",
+			"PATCH /v1/webhooks/{id}",
 			"POST /v1/webhooks/{id}/enable",
 			"POST /v1/webhooks/{id}/disable",
+			"POST /v1/webhooks/{id}/preview",
 			"GET /v1/webhooks/deliveries",
 		},
 		Deprecations: []control.APIDeprecation{
@@ -3361,16 +4330,32 @@ func currentAPISpec() control.APISpec {
 
 func (s *Server) handleJobs(baseDir string) http.HandlerFunc {
 	type createReq struct {
-		ConfigPath     string `json:"config_path"`
-		Priority       string `json:"priority"`
-		LockKey        string `json:"lock_key,omitempty"`
-		LockTTLSeconds int    `json:"lock_ttl_seconds,omitempty"`
-		LockOwner      string `json:"lock_owner,omitempty"`
+		ConfigPath       string            `json:"config_path"`
+		Priority         string            `json:"priority"`
+		Environment      string            `json:"environment,omitempty"`
+		Region           string            `json:"region,omitempty"`
+		LockKey          string            `json:"lock_key,omitempty"`
+		LockTTLSeconds   int               `json:"lock_ttl_seconds,omitempty"`
+		LockOwner        string            `json:"lock_owner,omitempty"`
+		LockMode         string            `json:"lock_mode,omitempty"`
+		ExecutionEnv     string            `json:"execution_env,omitempty"`
+		CloudCredentials []string          `json:"cloud_credentials,omitempty"`
+		ChangeRecordID   string            `json:"change_record_id,omitempty"`
+		Labels           map[string]string `json:"labels,omitempty"`
+		ConcurrencyGroup string            `json:"concurrency_group,omitempty"`
+		CancelSuperseded bool              `json:"cancel_superseded,omitempty"`
+		RunAt            time.Time         `json:"run_at,omitempty"`
+		NotBefore        time.Time         `json:"not_before,omitempty"`
+		Timezone         string            `json:"timezone,omitempty"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			writeJSON(w, http.StatusOK, s.queue.List())
+			keep, ok := s.labelFilter(w, r, "jobs")
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, filterLabeled(s.queue.List(), func(j control.Job) map[string]string { return j.Labels }, keep))
 		case http.MethodPost:
 			var req createReq
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -3381,6 +4366,28 @@ func (s *Server) handleJobs(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
 				return
 			}
+			labels, err := control.NormalizeLabels(req.Labels)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			if !req.RunAt.IsZero() && !req.NotBefore.IsZero() && !req.RunAt.Equal(req.NotBefore) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "run_at and not_before disagree; set one"})
+				return
+			}
+			if req.NotBefore.IsZero() {
+				req.NotBefore = req.RunAt
+			}
+			if peer, ok, err := s.federationForwarder.Route(req.Region); ok {
+				// The job targets a region served by a peer control plane,
+				// which resolves config_path against its own workspace.
+				if err != nil {
+					writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+					return
+				}
+				s.forwardJob(w, r, peer, req.ConfigPath, req.Priority)
+				return
+			}
 			if !filepath.IsAbs(req.ConfigPath) {
 				req.ConfigPath = filepath.Join(baseDir, req.ConfigPath)
 			}
@@ -3388,6 +4395,19 @@ func (s *Server) handleJobs(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("config_path not found: %v", err)})
 				return
 			}
+			if !s.enforceConfigLint(w, "job", req.ConfigPath) {
+				return
+			}
+			if req.ChangeRecordID != "" {
+				if _, err := s.changeRecords.Get(req.ChangeRecordID); err != nil {
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+					return
+				}
+			}
+			if !s.enforceEnvironmentGuardrails(w, req.Environment, req.ConfigPath, req.ChangeRecordID) {
+				return
+			}
+			_ = s.objectModel.IndexConfig(req.ConfigPath)
 			key := r.Header.Get("Idempotency-Key")
 			force := strings.ToLower(r.Header.Get("X-Force-Apply")) == "true"
 			priority := req.Priority
@@ -3402,9 +4422,40 @@ func (s *Server) handleJobs(baseDir string) http.HandlerFunc {
 			if strings.TrimSpace(lockOwner) == "" {
 				lockOwner = r.Header.Get("X-Execution-Lock-Owner")
 			}
-			job, err := s.enqueueJobWithOptionalLock(req.ConfigPath, key, force, priority, lockKey, req.LockTTLSeconds, lockOwner)
+			isolated := false
+			if env := strings.TrimSpace(req.ExecutionEnv); env != "" {
+				item, ok := s.executionEnvs.Get(env)
+				if !ok {
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": "execution environment not found"})
+					return
+				}
+				if result := s.executionEnvs.EvaluateAdmission(item); !result.Allowed {
+					writeJSON(w, http.StatusConflict, map[string]any{"error": "execution environment rejected by admission policy", "admission": result})
+					return
+				}
+				isolated = item.Isolation.Mode != "none"
+			}
+			if len(req.CloudCredentials) > 0 {
+				// Credentials only reach the run through an isolated
+				// environment's process env.
+				if !isolated {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "cloud_credentials require an execution_env with container or chroot isolation"})
+					return
+				}
+				for _, ref := range req.CloudCredentials {
+					if _, ok := s.cloudCredentials.GetBinding(ref); !ok {
+						writeJSON(w, http.StatusNotFound, map[string]string{"error": "cloud credential binding " + ref + " not found"})
+						return
+					}
+				}
+			}
+			_, tenant := requestIdentity(r)
+			placement := control.JobPlacement{Tenant: tenant, Environment: req.Environment, Region: req.Region, ExecutionEnv: req.ExecutionEnv, CloudCredentials: req.CloudCredentials, ChangeRecordID: req.ChangeRecordID, Labels: labels, ConcurrencyGroup: req.ConcurrencyGroup, CancelSuperseded: req.CancelSuperseded, NotBefore: req.NotBefore, Timezone: req.Timezone}
+			placement.Partition = s.partitionDispatch.Route(placement, req.ConfigPath)
+			s.placeJobTopology(&placement)
+			job, err := s.enqueueJobWithOptionalLock(placement, req.ConfigPath, key, force, priority, control.ExecutionLockAcquireInput{Key: lockKey, Holder: lockOwner, Mode: req.LockMode, TTLSeconds: req.LockTTLSeconds})
 			if err != nil {
-				writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+				writeJSON(w, enqueueErrorCode(err), map[string]string{"error": err.Error()})
 				return
 			}
 			writeJSON(w, http.StatusAccepted, job)
@@ -3415,6 +4466,44 @@ func (s *Server) handleJobs(baseDir string) http.HandlerFunc {
 }
 
 func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
+	if parts := splitPath(r.URL.Path); len(parts) >= 4 && parts[3] == "annotations" {
+		if _, ok := s.queue.Get(parts[2]); !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+			return
+		}
+		s.handleAnnotations(w, r, control.AnnotationTargetJob, parts[2], parts[4:])
+		return
+	}
+	if parts := splitPath(r.URL.Path); len(parts) == 4 && parts[3] == "placement" {
+		s.handleJobPlacementOverride(w, r, parts[2])
+		return
+	}
+	if parts := splitPath(r.URL.Path); len(parts) == 3 && parts[2] == "upcoming" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		items := s.queue.Upcoming()
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(items), "items": items})
+		return
+	}
+	if parts := splitPath(r.URL.Path); len(parts) == 4 && parts[3] == "reschedule" {
+		s.handleJobReschedule(w, r, parts[2])
+		return
+	}
+	if parts := splitPath(r.URL.Path); len(parts) == 4 && parts[3] == "concurrency" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		status, err := s.queue.ConcurrencyStatus(parts[2])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
 	id := filepath.Base(r.URL.Path)
 	if id == "" || id == "jobs" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing job id"})
@@ -3441,19 +4530,26 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSchedules(baseDir string) http.HandlerFunc {
 	type createReq struct {
-		ConfigPath      string `json:"config_path"`
-		IntervalSeconds int    `json:"interval_seconds"`
-		JitterSeconds   int    `json:"jitter_seconds"`
-		Priority        string `json:"priority"`
-		ExecutionCost   int    `json:"execution_cost"`
-		Host            string `json:"host"`
-		Cluster         string `json:"cluster"`
-		Environment     string `json:"environment"`
+		ConfigPath      string            `json:"config_path"`
+		IntervalSeconds int               `json:"interval_seconds"`
+		JitterSeconds   int               `json:"jitter_seconds"`
+		Priority        string            `json:"priority"`
+		ExecutionCost   int               `json:"execution_cost"`
+		Host            string            `json:"host"`
+		Cluster         string            `json:"cluster"`
+		Environment     string            `json:"environment"`
+		Labels          map[string]string `json:"labels"`
+		Owner           string            `json:"owner"`
+		Team            string            `json:"team"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			writeJSON(w, http.StatusOK, s.scheduler.List())
+			keep, ok := s.labelFilter(w, r, "schedules")
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, filterLabeled(s.scheduler.List(), func(sc control.Schedule) map[string]string { return sc.Labels }, keep))
 		case http.MethodPost:
 			var req createReq
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -3464,6 +4560,15 @@ func (s *Server) handleSchedules(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
 				return
 			}
+			labels, err := control.NormalizeLabels(req.Labels)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+			if !ok {
+				return
+			}
 			if req.IntervalSeconds <= 0 {
 				req.IntervalSeconds = 60
 			}
@@ -3481,6 +4586,9 @@ func (s *Server) handleSchedules(baseDir string) http.HandlerFunc {
 				Host:          req.Host,
 				Cluster:       req.Cluster,
 				Environment:   req.Environment,
+				Labels:        labels,
+				Owner:         owner,
+				Team:          team,
 				Interval:      time.Duration(req.IntervalSeconds) * time.Second,
 				Jitter:        time.Duration(req.JitterSeconds) * time.Second,
 			})
@@ -3499,11 +4607,18 @@ func (s *Server) handleTemplates(baseDir string) http.HandlerFunc {
 		StrictMode  bool                           `json:"strict_mode,omitempty"`
 		Defaults    map[string]string              `json:"defaults"`
 		Survey      map[string]control.SurveyField `json:"survey"`
+		Labels      map[string]string              `json:"labels"`
+		Owner       string                         `json:"owner"`
+		Team        string                         `json:"team"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			writeJSON(w, http.StatusOK, s.templates.List())
+			keep, ok := s.labelFilter(w, r, "templates")
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, filterLabeled(s.templates.List(), func(t control.Template) map[string]string { return t.Labels }, keep))
 		case http.MethodPost:
 			var req createReq
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -3521,6 +4636,24 @@ func (s *Server) handleTemplates(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("config_path not found: %v", err)})
 				return
 			}
+			if !s.enforceConfigLint(w, "template", req.ConfigPath) {
+				return
+			}
+			labels, err := control.NormalizeLabels(req.Labels)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+			if !ok {
+				return
+			}
+			if !validateTemplateSurvey(w, req.Survey, req.Defaults) {
+				return
+			}
+			if !s.enforceSecretScan(w, control.SecretScanInput{Kind: "template", Target: req.Name, Team: team}, req.ConfigPath) {
+				return
+			}
 			t := s.templates.Create(control.Template{
 				Name:        req.Name,
 				Description: req.Description,
@@ -3528,7 +4661,12 @@ func (s *Server) handleTemplates(baseDir string) http.HandlerFunc {
 				StrictMode:  req.StrictMode,
 				Defaults:    req.Defaults,
 				Survey:      req.Survey,
+				Labels:      labels,
+				Owner:       owner,
+				Team:        team,
 			})
+			_ = s.objectModel.RegisterTemplate(t.ID, t.ConfigPath)
+			s.recordEntityVersion(r, control.VersionedKindTemplate, t.ID, t.Version, "created", t)
 			s.events.Append(control.Event{
 				Type:    "template.created",
 				Message: "template created",
@@ -3537,7 +4675,7 @@ func (s *Server) handleTemplates(baseDir string) http.HandlerFunc {
 					"name":        t.Name,
 				},
 			})
-			writeJSON(w, http.StatusCreated, t)
+			writeVersioned(w, http.StatusCreated, t.Version, t)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -3545,8 +4683,12 @@ func (s *Server) handleTemplates(baseDir string) http.HandlerFunc {
 }
 
 func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/templates/{id}/launch
+	// /v1/templates/{id}, /v1/templates/{id}/launch, /v1/templates/{id}/versions[/{version}]
 	parts := splitPath(r.URL.Path)
+	if len(parts) == 3 && parts[2] != "" {
+		s.handleTemplateEntity(w, r, parts[2])
+		return
+	}
 	if len(parts) < 4 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid template action path"})
 		return
@@ -3555,6 +4697,8 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 	action := parts[3]
 
 	switch action {
+	case "versions":
+		s.handleEntityVersions(w, r, control.VersionedKindTemplate, id, parts[4:])
 	case "launch":
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -3580,8 +4724,8 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		mergedVars := control.MergeTemplateVariables(t.Defaults, launch.Answers)
-		rendered, missing, renderErr := control.RenderTemplateFile(t.ConfigPath, mergedVars, t.StrictMode)
+		mergedVars := control.RedactSurveyAnswers(t.Survey, control.MergeTemplateVariables(t.Defaults, launch.Answers))
+		rendered, missing, renderErr := control.RenderTemplateFileWithMine(t.ConfigPath, mergedVars, t.StrictMode, s.factMine.TemplateLookup())
 		if renderErr != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": renderErr.Error()})
 			return
@@ -3592,11 +4736,16 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 		if priority == "" {
 			priority = r.Header.Get("X-Queue-Priority")
 		}
-		job, err := s.queue.Enqueue(t.ConfigPath, key, force, priority)
+		job, err := s.queue.EnqueuePlaced(control.JobPlacement{Labels: t.Labels}, t.ConfigPath, key, force, priority)
 		if err != nil {
-			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			writeJSON(w, enqueueErrorCode(err), map[string]string{"error": err.Error()})
+			return
+		}
+		secretRefs, ok := s.sealSurveyAnswers(w, "template-launch/"+job.ID, t.Survey, launch.Answers)
+		if !ok {
 			return
 		}
+		_ = s.templates.RecordLaunch(t.ID)
 		s.events.Append(control.Event{
 			Type:    "template.launched",
 			Message: "template launch enqueued",
@@ -3608,10 +4757,11 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusAccepted, map[string]any{
 			"template":           t,
 			"job":                job,
-			"answers":            launch.Answers,
+			"answers":            control.RedactSurveyAnswers(t.Survey, launch.Answers),
 			"resolved_variables": mergedVars,
 			"missing_variables":  missing,
 			"rendered_preview":   rendered,
+			"secret_refs":        secretRefs,
 		})
 	case "render":
 		if r.Method != http.MethodPost {
@@ -3637,8 +4787,8 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		mergedVars := control.MergeTemplateVariables(t.Defaults, req.Answers)
-		rendered, missing, err := control.RenderTemplateFile(t.ConfigPath, mergedVars, t.StrictMode)
+		mergedVars := control.RedactSurveyAnswers(t.Survey, control.MergeTemplateVariables(t.Defaults, req.Answers))
+		rendered, missing, err := control.RenderTemplateFileWithMine(t.ConfigPath, mergedVars, t.StrictMode, s.factMine.TemplateLookup())
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -3655,11 +4805,14 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		t, _ := s.templates.Get(id)
 		if err := s.templates.Delete(id); err != nil {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		s.objectModel.UnregisterTemplate(id)
+		s.recordEntityVersion(r, control.VersionedKindTemplate, t.ID, t.Version, "deleted", t)
+		writeJSON(w, http.StatusOK, s.trashEntity(r, control.TrashKindTemplate, t.ID, t.Name, t))
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown template action"})
 	}
@@ -3667,9 +4820,11 @@ func (s *Server) handleTemplateAction(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
 	type createReq struct {
-		Name        string                 `json:"name"`
-		Description string                 `json:"description"`
-		Steps       []control.WorkflowStep `json:"steps"`
+		Name        string                         `json:"name"`
+		Description string                         `json:"description"`
+		Inputs      map[string]control.SurveyField `json:"inputs"`
+		Defaults    map[string]string              `json:"defaults"`
+		Steps       []control.WorkflowStep         `json:"steps"`
 	}
 	switch r.Method {
 	case http.MethodGet:
@@ -3683,6 +4838,8 @@ func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
 		wf, err := s.workflows.Create(control.WorkflowTemplate{
 			Name:        req.Name,
 			Description: req.Description,
+			Inputs:      req.Inputs,
+			Defaults:    req.Defaults,
 			Steps:       req.Steps,
 		})
 		if err != nil {
@@ -3712,6 +4869,7 @@ func (s *Server) handleRunbooks(baseDir string) http.HandlerFunc {
 		ConfigPath  string   `json:"config_path"`
 		RiskLevel   string   `json:"risk_level"` // low|medium|high
 		Owner       string   `json:"owner"`
+		Team        string   `json:"team"`
 		Tags        []string `json:"tags"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -3736,6 +4894,10 @@ func (s *Server) handleRunbooks(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 				return
 			}
+			owner, team, ok := s.resolveOwnership(w, r, req.Owner, req.Team)
+			if !ok {
+				return
+			}
 			targetType := strings.ToLower(strings.TrimSpace(req.TargetType))
 			if targetType == "config" {
 				if !filepath.IsAbs(req.ConfigPath) {
@@ -3745,6 +4907,9 @@ func (s *Server) handleRunbooks(baseDir string) http.HandlerFunc {
 					writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("config_path not found: %v", err)})
 					return
 				}
+				if !s.enforceConfigLint(w, "runbook", req.ConfigPath) {
+					return
+				}
 			} else if targetType == "template" {
 				if _, ok := s.templates.Get(req.TargetID); !ok {
 					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "target template not found"})
@@ -3763,7 +4928,8 @@ func (s *Server) handleRunbooks(baseDir string) http.HandlerFunc {
 				TargetID:    req.TargetID,
 				ConfigPath:  req.ConfigPath,
 				RiskLevel:   req.RiskLevel,
-				Owner:       req.Owner,
+				Owner:       owner,
+				Team:        team,
 				Tags:        req.Tags,
 			})
 			if err != nil {
@@ -3792,9 +4958,10 @@ func (s *Server) handleRunbooks(baseDir string) http.HandlerFunc {
 
 func (s *Server) handleRunbookAction(baseDir string) http.HandlerFunc {
 	type launchReq struct {
-		Priority string            `json:"priority"`
-		Answers  map[string]string `json:"answers"`
-		Force    bool              `json:"force"`
+		Priority       string            `json:"priority"`
+		Answers        map[string]string `json:"answers"`
+		Force          bool              `json:"force"`
+		ChangeRecordID string            `json:"change_record_id"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		// /v1/runbooks/{id} or /v1/runbooks/{id}/approve|deprecate|launch
@@ -3854,6 +5021,31 @@ func (s *Server) handleRunbookAction(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusConflict, map[string]string{"error": "runbook must be approved before launch"})
 				return
 			}
+			if req.ChangeRecordID != "" {
+				rec, err := s.changeRecords.Get(req.ChangeRecordID)
+				if err != nil {
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+					return
+				}
+				if rec.Status == control.ChangeRecordRejected {
+					grant, elevated := s.breakGlassElevated(r)
+					if !elevated {
+						writeJSON(w, http.StatusConflict, map[string]string{"error": "change record was rejected"})
+						return
+					}
+					s.recordEvent(control.Event{
+						Type:    "access.break_glass.bypass",
+						Message: "break-glass elevated mode bypassed rejected change record",
+						Fields: map[string]any{
+							"request_id":       grant.ID,
+							"requested_by":     grant.RequestedBy,
+							"change_record_id": rec.ID,
+							"runbook_id":       runbook.ID,
+							"severity":         "medium",
+						},
+					}, true)
+				}
+			}
 			force := req.Force || strings.ToLower(r.Header.Get("X-Force-Apply")) == "true"
 			priority := req.Priority
 			if priority == "" {
@@ -3876,22 +5068,36 @@ func (s *Server) handleRunbookAction(baseDir string) http.HandlerFunc {
 					writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
 					return
 				}
-				writeJSON(w, http.StatusAccepted, map[string]any{
-					"runbook":  runbook,
-					"template": tpl,
-					"job":      job,
-					"answers":  req.Answers,
-				})
+				secretRefs, ok := s.sealSurveyAnswers(w, "runbook-launch/"+job.ID, tpl.Survey, req.Answers)
+				if !ok {
+					return
+				}
+				_ = s.templates.RecordLaunch(tpl.ID)
+				resp := map[string]any{
+					"runbook":     runbook,
+					"template":    tpl,
+					"job":         job,
+					"answers":     control.RedactSurveyAnswers(tpl.Survey, req.Answers),
+					"secret_refs": secretRefs,
+				}
+				if rec, ok := s.linkRunbookChangeRecord(runbook, req.ChangeRecordID, job.ID); ok {
+					resp["change_record"] = rec
+				}
+				writeJSON(w, http.StatusAccepted, resp)
 			case control.RunbookTargetWorkflow:
 				run, err := s.workflows.Launch(runbook.TargetID, priority, force)
 				if err != nil {
 					writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 					return
 				}
-				writeJSON(w, http.StatusAccepted, map[string]any{
+				resp := map[string]any{
 					"runbook":      runbook,
 					"workflow_run": run,
-				})
+				}
+				if rec, ok := s.linkRunbookChangeRecord(runbook, req.ChangeRecordID, run.ID); ok {
+					resp["change_record"] = rec
+				}
+				writeJSON(w, http.StatusAccepted, resp)
 			case control.RunbookTargetConfig:
 				configPath := runbook.ConfigPath
 				if !filepath.IsAbs(configPath) {
@@ -3907,14 +5113,19 @@ func (s *Server) handleRunbookAction(baseDir string) http.HandlerFunc {
 					writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
 					return
 				}
-				writeJSON(w, http.StatusAccepted, map[string]any{
+				resp := map[string]any{
 					"runbook": runbook,
 					"job":     job,
-				})
+				}
+				if rec, ok := s.linkRunbookChangeRecord(runbook, req.ChangeRecordID, job.ID); ok {
+					resp["change_record"] = rec
+				}
+				writeJSON(w, http.StatusAccepted, resp)
 			default:
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported runbook target type"})
 				return
 			}
+			_ = s.runbooks.RecordLaunch(runbook.ID)
 			s.events.Append(control.Event{
 				Type:    "runbook.launched",
 				Message: "runbook launch triggered",
@@ -3922,6 +5133,7 @@ func (s *Server) handleRunbookAction(baseDir string) http.HandlerFunc {
 					"runbook_id":  runbook.ID,
 					"target_type": runbook.TargetType,
 					"risk_level":  runbook.RiskLevel,
+					"evidence":    runbook.Evidence,
 				},
 			})
 		default:
@@ -3950,8 +5162,9 @@ func (s *Server) handleWorkflowAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type launchReq struct {
-		Priority string `json:"priority"`
-		Force    bool   `json:"force"`
+		Priority string            `json:"priority"`
+		Force    bool              `json:"force"`
+		Inputs   map[string]string `json:"inputs"`
 	}
 	var req launchReq
 	if r.ContentLength > 0 {
@@ -3961,17 +5174,23 @@ func (s *Server) handleWorkflowAction(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	force := req.Force || strings.ToLower(r.Header.Get("X-Force-Apply")) == "true"
-	run, err := s.workflows.Launch(id, req.Priority, force)
+	run, err := s.workflows.LaunchWithInputs(id, req.Priority, force, req.Inputs)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	inputNames := make([]string, 0, len(run.Inputs))
+	for name := range run.Inputs {
+		inputNames = append(inputNames, name)
+	}
+	sort.Strings(inputNames)
 	s.events.Append(control.Event{
 		Type:    "workflow.launched",
 		Message: "workflow launch started",
 		Fields: map[string]any{
 			"workflow_id": id,
 			"run_id":      run.ID,
+			"inputs":      inputNames,
 		},
 	})
 	writeJSON(w, http.StatusAccepted, run)
@@ -4014,6 +5233,9 @@ func (s *Server) handleAssociations(baseDir string) http.HandlerFunc {
 		IntervalSeconds int    `json:"interval_seconds"`
 		JitterSeconds   int    `json:"jitter_seconds"`
 		Enabled         bool   `json:"enabled"`
+		Environment     string `json:"environment,omitempty"`
+		PolicyBundle    string `json:"policy_bundle,omitempty"`
+		PolicyBundleID  string `json:"policy_bundle_id,omitempty"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -4057,8 +5279,23 @@ func (s *Server) handleAssociations(baseDir string) http.HandlerFunc {
 				writeJSON(w, http.StatusConflict, map[string]string{"error": selection.Reason})
 				return
 			}
-
-			assoc, err := s.assocs.Create(control.AssociationCreate{
+			env := strings.TrimSpace(req.Environment)
+			if env == "" && strings.EqualFold(strings.TrimSpace(req.TargetKind), "environment") {
+				env = req.TargetName
+			}
+			bundleSelection, requested, err := s.resolveEnvironmentPolicyBundle(env, req.PolicyBundleID, req.PolicyBundle)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			if requested && bundleSelection.Selected == nil {
+				writeJSON(w, http.StatusConflict, map[string]any{
+					"error":     "no policy bundle revision satisfies environment version constraints",
+					"selection": bundleSelection,
+				})
+				return
+			}
+			create := control.AssociationCreate{
 				ConfigPath: req.ConfigPath,
 				TargetKind: req.TargetKind,
 				TargetName: req.TargetName,
@@ -4067,7 +5304,13 @@ func (s *Server) handleAssociations(baseDir string) http.HandlerFunc {
 				Interval:   time.Duration(req.IntervalSeconds) * time.Second,
 				Jitter:     time.Duration(req.JitterSeconds) * time.Second,
 				Enabled:    req.Enabled,
-			})
+			}
+			if bundleSelection.Selected != nil {
+				create.PolicyBundleID = bundleSelection.Selected.ID
+				create.PolicyBundleVersion = bundleSelection.Selected.Version
+			}
+
+			assoc, err := s.assocs.Create(create)
 			if err != nil {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 				return
@@ -4233,6 +5476,7 @@ func (s *Server) handleEmergencyStop(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
 		Enabled bool   `json:"enabled"`
 		Reason  string `json:"reason"`
+		SetBy   string `json:"set_by"`
 	}
 	switch r.Method {
 	case http.MethodGet:
@@ -4243,13 +5487,14 @@ func (s *Server) handleEmergencyStop(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 			return
 		}
-		st := s.queue.SetEmergencyStop(req.Enabled, req.Reason)
+		st := s.queue.SetEmergencyStopBy(req.Enabled, req.Reason, emergencyStopActor(r, req.SetBy))
 		s.events.Append(control.Event{
 			Type:    "control.emergency_stop",
 			Message: "emergency stop toggled",
 			Fields: map[string]any{
 				"active": st.Active,
 				"reason": st.Reason,
+				"set_by": st.SetBy,
 			},
 		})
 		writeJSON(w, http.StatusOK, st)
@@ -4390,6 +5635,22 @@ func (s *Server) handleHandoff(w http.ResponseWriter, r *http.Request) {
 		risks = append(risks, "Emergency stop is active; all new applies are blocked.")
 		blocked = append(blocked, "new applies blocked by emergency stop")
 	}
+	emergencyScopes := make([]map[string]any, 0, len(emergency.Scopes))
+	for _, scope := range emergency.Scopes {
+		setBy := scope.SetBy
+		if setBy == "" {
+			setBy = "unknown"
+		}
+		risks = append(risks, "Scoped emergency stop "+scope.ID+" is active for "+scope.Describe()+" (set by "+setBy+").")
+		blocked = append(blocked, "new applies for "+scope.Describe()+" blocked by scoped emergency stop")
+		emergencyScopes = append(emergencyScopes, map[string]any{
+			"id":     scope.ID,
+			"scope":  scope.Describe(),
+			"reason": scope.Reason,
+			"set_by": scope.SetBy,
+			"since":  scope.Since,
+		})
+	}
 	if freeze.Active {
 		risks = append(risks, "Change freeze is active until "+freeze.Until.Format(time.RFC3339)+".")
 		blocked = append(blocked, "new applies blocked by change freeze")
@@ -4435,6 +5696,7 @@ func (s *Server) handleHandoff(w http.ResponseWriter, r *http.Request) {
 		"generated_at":          time.Now().UTC(),
 		"queue":                 queueStatus,
 		"emergency_stop":        emergency,
+		"emergency_scopes":      emergencyScopes,
 		"freeze":                freeze,
 		"maintenance":           maintenance,
 		"canary_health":         canary,
@@ -4456,7 +5718,11 @@ func (s *Server) handleChecklists(w http.ResponseWriter, r *http.Request) {
 	}
 	switch r.Method {
 	case http.MethodGet:
-		writeJSON(w, http.StatusOK, s.checklists.List())
+		items := s.checklists.List()
+		for i := range items {
+			items[i] = s.localizeChecklist(r, items[i])
+		}
+		writeJSON(w, http.StatusOK, items)
 	case http.MethodPost:
 		var req reqBody
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -4478,7 +5744,7 @@ func (s *Server) handleChecklists(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusCreated, item)
+		writeJSON(w, http.StatusCreated, s.localizeChecklist(r, item))
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
@@ -4502,7 +5768,7 @@ func (s *Server) handleChecklistAction(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, item)
+		writeJSON(w, http.StatusOK, s.localizeChecklist(r, item))
 		return
 	}
 	if len(parts) < 5 || r.Method != http.MethodPost {
@@ -4524,7 +5790,7 @@ func (s *Server) handleChecklistAction(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, item)
+		writeJSON(w, http.StatusOK, s.localizeChecklist(r, item))
 	case "gate":
 		var req struct {
 			Phase string `json:"phase"`
@@ -4541,6 +5807,7 @@ func (s *Server) handleChecklistAction(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		result.Blockers = s.localizeChecklistItems(r, result.Blockers)
 		if !result.Allowed {
 			writeJSON(w, http.StatusConflict, result)
 			return
@@ -5053,8 +6320,31 @@ func (s *Server) recordStuckRecoveryEvent(mode string, maxAgeSeconds int, jobIDs
 }
 
 func (s *Server) handleScheduleAction(w http.ResponseWriter, r *http.Request) {
-	// /v1/schedules/{id}/enable|disable
+	// /v1/schedules/{id}[/enable|disable]
 	parts := splitPath(r.URL.Path)
+	if len(parts) == 3 {
+		switch r.Method {
+		case http.MethodGet:
+			sc, ok := s.scheduler.Get(parts[2])
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, sc)
+		case http.MethodPatch:
+			s.patchSchedule(w, r, parts[2])
+		case http.MethodDelete:
+			sc, ok := s.scheduler.Delete(parts[2])
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, s.trashEntity(r, control.TrashKindSchedule, sc.ID, sc.ConfigPath, sc))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
 	if len(parts) < 4 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid schedule action path"})
 		return
@@ -5103,6 +6393,9 @@ func writeJSON(w http.ResponseWriter, code int, body any) {
 
 func (s *Server) recordEvent(e control.Event, evaluateRules bool) {
 	s.events.Append(e)
+	if s.responseCache != nil && workloadFromEvent(e) != "" {
+		s.responseCache.Invalidate(responseCacheTagWorkloads)
+	}
 	if s.eventBus != nil {
 		_ = s.eventBus.Publish(e)
 	}
@@ -5196,6 +6489,8 @@ func (s *Server) wrapHTTP(next http.Handler) http.Handler {
 		start := time.Now().UTC()
 		reqID := randomID()
 		w.Header().Set("X-Request-ID", reqID)
+		keyAuth, keyPresented := s.resolveDelegatedKey(r)
+		principal, tenant := requestIdentity(r)
 
 		s.metricsMu.Lock()
 		s.metrics["requests_total"]++
@@ -5203,29 +6498,112 @@ func (s *Server) wrapHTTP(next http.Handler) http.Handler {
 		s.metrics["requests."+r.URL.Path]++
 		s.metricsMu.Unlock()
 
+		// Log lines carry request_id, which matches the "id" field of the
+		// http.request/http.response events below.
+		logger := s.logger.With("request_id", reqID)
+		requestFields := map[string]any{
+			"id":     reqID,
+			"method": r.Method,
+			"path":   r.URL.Path,
+		}
+		if principal != "" {
+			logger = logger.With("principal", principal)
+			requestFields["principal"] = principal
+		}
+		if tenant != "" {
+			logger = logger.With("tenant", tenant)
+			requestFields["tenant"] = tenant
+		}
+		var keyFields map[string]any
+		if keyPresented {
+			keyFields = delegatedKeyAuditFields(keyAuth)
+			for k, v := range keyFields {
+				requestFields[k] = v
+			}
+			logger = logger.With("api_key_id", keyAuth.KeyID, "delegator", keyAuth.Delegator)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), requestLoggerKey{}, logger))
+		r = s.withRequestLocale(w, r)
+
 		s.events.Append(control.Event{
 			Type:    "http.request",
 			Message: "request received",
-			Fields: map[string]any{
-				"id":     reqID,
-				"method": r.Method,
-				"path":   r.URL.Path,
-			},
+			Fields:  requestFields,
 		})
 
-		next.ServeHTTP(w, r)
+		rec := &statusRecorder{ResponseWriter: w}
+		if s.rejectRevokedClientCert(rec, r) && s.enforceDelegatedKey(rec, r, keyAuth, keyPresented) && s.allowFollowerRequest(rec, r) && s.limitRequestBody(rec, r) && s.requireJITGrant(rec, r) && s.admitRequest(rec, r) {
+			var out http.ResponseWriter = rec
+			cw := s.newCompressResponseWriter(rec, r)
+			if cw != nil {
+				out = cw
+			}
+			if lw := s.newLocalizeResponseWriter(out, r); lw != nil {
+				next.ServeHTTP(lw, r)
+				lw.Close()
+			} else {
+				next.ServeHTTP(out, r)
+			}
+			if cw != nil {
+				_ = cw.Close()
+				s.recordResponseCompression(cw)
+			}
+			s.syncConfigAsCodeAfter(r)
+		}
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		end := time.Now().UTC()
+
+		level := slog.LevelInfo
+		if rec.status >= 500 {
+			level = slog.LevelError
+		} else if rec.status >= 400 {
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(r.Context(), level, "request completed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Float64("duration_ms", float64(end.Sub(start).Microseconds())/1000),
+		)
 
+		responseFields := map[string]any{
+			"id":         reqID,
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     rec.status,
+			"started_at": start,
+			"ended_at":   end,
+		}
+		for k, v := range keyFields {
+			responseFields[k] = v
+		}
 		s.events.Append(control.Event{
 			Type:    "http.response",
 			Message: "request completed",
-			Fields: map[string]any{
-				"id":         reqID,
+			Fields:  responseFields,
+		})
+		if keyPresented && keyAuth.Allowed {
+			used := map[string]any{
+				"request_id": reqID,
 				"method":     r.Method,
 				"path":       r.URL.Path,
-				"started_at": start,
-				"ended_at":   time.Now().UTC(),
-			},
-		})
+				"status":     rec.status,
+				"principal":  keyAuth.Principal,
+				"tenant":     keyAuth.Tenant,
+				"route":      keyAuth.MatchedRoute,
+			}
+			for k, v := range keyFields {
+				used[k] = v
+			}
+			s.events.Append(control.Event{
+				Type:    "access.delegated_key.used",
+				Message: "delegated api key " + keyAuth.KeyID + " acted for " + keyAuth.Delegator,
+				Fields:  used,
+			})
+		}
 	})
 }
 
@@ -5298,7 +6676,8 @@ func (s *Server) observeQueueBacklog() {
 	s.backlogWarnActive = !s.backlogSatActive && predictive && st.Pending >= warnThreshold
 	recovered := st.Pending <= recoveryThreshold && (prevSat || prevWarn) && !s.backlogSatActive && !s.backlogWarnActive
 
-	if s.backlogSatActive && !prevSat {
+	saturationStarted := s.backlogSatActive && !prevSat
+	if saturationStarted {
 		emit = &control.Event{
 			Type:    "queue.saturation",
 			Message: "queue backlog exceeded saturation SLO threshold",
@@ -5357,11 +6736,16 @@ func (s *Server) observeQueueBacklog() {
 
 	s.metrics["queue.saturation.active"] = boolToInt64(s.backlogSatActive)
 	s.metrics["queue.saturation.warning"] = boolToInt64(s.backlogWarnActive)
+	saturated := s.backlogSatActive
 	s.metricsMu.Unlock()
 
 	if emit != nil {
 		s.events.Append(*emit)
 	}
+	s.updateQueueShedding(now, saturated, st.Pending, recoveryThreshold)
+	if saturationStarted && s.profiler != nil {
+		_, _, _ = s.profiler.Trigger(control.ProfileTriggerBacklogSaturation, "queue backlog of "+strconv.Itoa(st.Pending)+" reached saturation threshold "+strconv.Itoa(threshold))
+	}
 }
 
 func readIntEnv(name string, defaultValue int) int {
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleServerCertificateACME(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.serverCerts.ACME())
+	case http.MethodPost:
+		var req control.ServerACMEConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.serverCerts.SetACME(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "server.certificates.acme.updated",
+			Message: "server certificate acme directory updated",
+			Fields:  map[string]any{"directory_url": item.DirectoryURL},
+		}, true)
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleServerCertificates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.serverCerts.List())
+	case http.MethodPost:
+		var req control.ServerCertificateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		item, err := s.serverCerts.Request(req)
+		s.writeServerCertificateResult(w, item, err, http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleServerCertificateAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/server-certificates/{id}[/renew]
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != "v1" || parts[1] != "server-certificates" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(parts) == 3 {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		item, ok := s.serverCerts.Get(parts[2])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "server certificate not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+		return
+	}
+	if parts[3] != "renew" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown server certificate action"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := s.serverCerts.Get(parts[2]); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "server certificate not found"})
+		return
+	}
+	item, err := s.serverCerts.Renew(parts[2])
+	s.writeServerCertificateResult(w, item, err, http.StatusOK)
+}
+
+func (s *Server) writeServerCertificateResult(w http.ResponseWriter, item control.ServerCertificate, err error, okStatus int) {
+	if err != nil && item.ID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	fields := map[string]any{
+		"certificate_id": item.ID,
+		"domains":        item.Domains,
+		"status":         item.Status,
+	}
+	if err != nil {
+		fields["error"] = item.Error
+		s.recordEvent(control.Event{
+			Type:    "server.certificates.failed",
+			Message: "acme server certificate order failed",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "certificate": item})
+		return
+	}
+	fields["serial"] = item.Serial
+	fields["not_after"] = item.NotAfter
+	s.recordEvent(control.Event{
+		Type:    "server.certificates.issued",
+		Message: "acme server certificate issued",
+		Fields:  fields,
+	}, true)
+	writeJSON(w, okStatus, item)
+}
+
+// handleACMEChallenge answers http-01 validation requests for orders the
+// server certificate store has in flight.
+func (s *Server) handleACMEChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, "/.well-known/acme-challenge/")
+	keyAuth, ok := s.acmeChallenges.Get(token)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(keyAuth))
+}
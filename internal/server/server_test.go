@@ -2,8 +2,10 @@ package server
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -499,6 +501,73 @@ resources:
 	}
 }
 
+func TestScopedEmergencyStopAPI(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/control/emergency-stop/scopes", "", `{"reason":"no scope"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unscoped stop to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/control/emergency-stop/scopes", "oncall-sre", `{"environment":"prod","selector":"team=payments","reason":"db incident"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("scoped stop failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var scope control.EmergencyStopScope
+	if err := json.Unmarshal(rr.Body.Bytes(), &scope); err != nil || scope.SetBy != "oncall-sre" {
+		t.Fatalf("expected scope set by request principal, got %+v err=%v", scope, err)
+	}
+
+	if rr := do(http.MethodPost, "/v1/jobs", "", `{"config_path":"c.yaml","environment":"prod","labels":{"team":"payments"}}`); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), scope.ID) {
+		t.Fatalf("expected prod payments job to be halted: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", "", `{"config_path":"c.yaml","environment":"staging","labels":{"team":"payments"}}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected staging job to be accepted: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/v1/control/handoff", "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handoff failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var handoff struct {
+		EmergencyScopes []map[string]any `json:"emergency_scopes"`
+		Blocked         []string         `json:"blocked_actions"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &handoff); err != nil {
+		t.Fatal(err)
+	}
+	if len(handoff.EmergencyScopes) != 1 || handoff.EmergencyScopes[0]["set_by"] != "oncall-sre" || handoff.EmergencyScopes[0]["scope"] != "environment=prod selector=team=payments" {
+		t.Fatalf("expected active scope with its owner in handoff, got %+v", handoff.EmergencyScopes)
+	}
+	if len(handoff.Blocked) == 0 || !strings.Contains(strings.Join(handoff.Blocked, "\n"), "blocked by scoped emergency stop") {
+		t.Fatalf("expected scoped stop in blocked actions, got %+v", handoff.Blocked)
+	}
+
+	if rr := do(http.MethodDelete, "/v1/control/emergency-stop/scopes/"+scope.ID, "lead", ""); rr.Code != http.StatusOK {
+		t.Fatalf("clear scope failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, "/v1/control/emergency-stop/scopes/"+scope.ID, "lead", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected second clear to 404: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", "", `{"config_path":"c.yaml","environment":"prod","labels":{"team":"payments"}}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected job accepted after clearing scope: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
 func TestControlChecklistEndpoints(t *testing.T) {
 	tmp := t.TempDir()
 	cfg := filepath.Join(tmp, "c.yaml")
@@ -2112,6 +2181,87 @@ resources:
 	if !strings.Contains(rr.Body.String(), `"ready":true`) {
 		t.Fatalf("expected ready launch response: %s", rr.Body.String())
 	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/wizards/rollout/sessions", bytes.NewReader([]byte(`{"owner":"sre-alice"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("wizard session start failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var session struct {
+		ID            string `json:"id"`
+		CurrentStepID string `json:"current_step_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &session); err != nil || session.CurrentStepID != "preflight" {
+		t.Fatalf("unexpected wizard session: err=%v body=%s", err, rr.Body.String())
+	}
+	submit := func(step, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/wizards/sessions/"+session.ID+"/steps/"+step, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := submit("start-deployment", `{"inputs":{}}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected out-of-order step to conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := submit("preflight", `{"inputs":{"config_path":"missing.yaml"}}`); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "loadable config file") {
+		t.Fatalf("expected config validation error: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := submit("preflight", `{"inputs":{"config_path":"c.yaml"}}`); rr.Code != http.StatusOK {
+		t.Fatalf("preflight step failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := submit("start-deployment", `{"inputs":{"strategy":"yolo","target_environment":"prod"}}`); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"strategy":"must be one of canary, blue-green, rolling"`) {
+		t.Fatalf("expected strategy validation error: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// A restarted server resumes the draft from its current step.
+	resumed := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = resumed.Shutdown(context.Background())
+	})
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/wizards/sessions/"+session.ID, nil)
+	resumed.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"current_step_id":"start-deployment"`) || !strings.Contains(rr.Body.String(), `"strategy":"yolo"`) {
+		t.Fatalf("expected resumable draft session: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := submit("start-deployment", `{"inputs":{"strategy":"canary"}}`); rr.Code != http.StatusOK {
+		t.Fatalf("start-deployment step failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := submit("gate-promotion", `{"inputs":{"verify_interval_seconds":"3600"}}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"ready"`) {
+		t.Fatalf("gate-promotion step failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/wizards/sessions/"+session.ID+"/materialize", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("wizard materialize failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var completed struct {
+		Status    string `json:"status"`
+		Artifacts []struct {
+			Kind string `json:"kind"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"artifacts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &completed); err != nil || completed.Status != "completed" || len(completed.Artifacts) != 3 {
+		t.Fatalf("unexpected materialized session: err=%v body=%s", err, rr.Body.String())
+	}
+	if completed.Artifacts[0].Name != "rollout-prod" || completed.Artifacts[1].Kind != "schedule" || completed.Artifacts[2].Name != "Rollout to prod (canary)" {
+		t.Fatalf("unexpected materialized artifacts: %+v", completed.Artifacts)
+	}
+	rb, err := s.runbooks.Get(completed.Artifacts[2].ID)
+	if err != nil || rb.TargetID != completed.Artifacts[0].ID || rb.Owner != "sre-alice" {
+		t.Fatalf("expected runbook targeting the wizard template: err=%v runbook=%+v", err, rb)
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/wizards/sessions/"+session.ID+"/materialize", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected second materialize to conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 }
 
 func TestFleetNodesEndpointPaginationAndRenderModes(t *testing.T) {
@@ -2325,6 +2475,21 @@ resources:
 	if !strings.Contains(rr.Body.String(), `"incident-view"`) || !strings.Contains(rr.Body.String(), `"active_profile"`) {
 		t.Fatalf("expected incident shortcut and active profile in response: %s", rr.Body.String())
 	}
+	if !strings.Contains(rr.Body.String(), `"aria_keyshortcuts":"Shift+I"`) || !strings.Contains(rr.Body.String(), `"focus_order":1`) || !strings.Contains(rr.Body.String(), `"token_set":"high-contrast"`) {
+		t.Fatalf("expected keyboard and contrast variant hints for active profile: %s", rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/ui/shortcuts?accessibility_profile=default", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"focus_order"`) || !strings.Contains(rr.Body.String(), `"interaction":"pointer"`) {
+		t.Fatalf("expected profile override to drop keyboard hints: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/ui/shortcuts?accessibility_profile=missing", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown accessibility profile to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodGet, "/v1/ui/progressive-disclosure", nil)
@@ -2365,6 +2530,9 @@ resources:
 	if !strings.Contains(rr.Body.String(), `"workflow":"rollout"`) || !strings.Contains(rr.Body.String(), `"no_mouse_coverage_percent"`) {
 		t.Fatalf("expected rollout navigation workflow with coverage details: %s", rr.Body.String())
 	}
+	if !strings.Contains(rr.Body.String(), `"keyboard_flow":[{"shortcut_id":"command-palette","title":"Open Command Palette","keystroke":"CmdOrCtrl+K"}`) {
+		t.Fatalf("expected keyboard-only flow for rollout workflow: %s", rr.Body.String())
+	}
 
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/views", bytes.NewReader([]byte(`{
@@ -2417,6 +2585,9 @@ resources:
 	if !strings.Contains(rr.Body.String(), widget.ID) {
 		t.Fatalf("expected created dashboard widget in list: %s", rr.Body.String())
 	}
+	if !strings.Contains(rr.Body.String(), `"animate_refresh":false`) || !strings.Contains(rr.Body.String(), `"live_region":"polite"`) || !strings.Contains(rr.Body.String(), `"motion.duration":"0ms"`) {
+		t.Fatalf("expected reduced motion and screen reader widget hints: %s", rr.Body.String())
+	}
 
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/ui/dashboard/widgets/"+widget.ID+"/refresh", nil)
@@ -2427,6 +2598,55 @@ resources:
 	if !strings.Contains(rr.Body.String(), `"last_refreshed_at"`) {
 		t.Fatalf("expected refresh timestamp in widget response: %s", rr.Body.String())
 	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/ui/dashboard/widgets/"+widget.ID+"/data", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("dashboard widget data failed: code=%d cache=%q body=%s", rr.Code, rr.Header().Get("X-Cache"), rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"provider":"query"`) || !strings.Contains(rr.Body.String(), `"entity":"runs"`) || !strings.Contains(rr.Body.String(), `"matched_count":0`) {
+		t.Fatalf("expected saved view query results in widget data: %s", rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/ui/dashboard/widgets/"+widget.ID+"/data", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Cache") != "HIT" || !strings.Contains(rr.Body.String(), `"cached":true`) {
+		t.Fatalf("expected cached widget data: code=%d cache=%q body=%s", rr.Code, rr.Header().Get("X-Cache"), rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/ui/dashboard/widgets", bytes.NewReader([]byte(`{"provider":"metric_series","title":"Applies"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected metric_series widget without event_type to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	s.recordEvent(control.Event{Type: "widget.test", Message: "feed entry"}, false)
+	for _, tc := range []struct {
+		body string
+		want string
+	}{
+		{`{"provider":"event_feed","title":"Feed","event_type":"widget.","limit":5}`, `"message":"feed entry"`},
+		{`{"provider":"metric_series","title":"Series","event_type":"widget.","window_seconds":600,"bucket_seconds":60}`, `"total":1`},
+		{`{"provider":"scorecard","title":"Scorecards","environment":"prod"}`, `"count":0`},
+	} {
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/v1/ui/dashboard/widgets", bytes.NewReader([]byte(tc.body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("provider widget create failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var created struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &created)
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/v1/ui/dashboard/widgets/"+created.ID+"/data?refresh=true", nil)
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), tc.want) {
+			t.Fatalf("provider widget data failed for %s: code=%d body=%s", tc.body, rr.Code, rr.Body.String())
+		}
+	}
 }
 
 func TestPlanExplainEndpoint(t *testing.T) {
@@ -2508,6 +2728,59 @@ resources:
 	}
 }
 
+func TestPlanExplainReportsFactConditionSkips(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: node-a
+      transport: local
+resources:
+  - id: apt-update
+    type: command
+    host: node-a
+    when: facts.os.family == debian
+    command: "apt-get update"
+  - id: motd
+    type: command
+    host: node-a
+    command: "echo hi"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	s.facts.Upsert("node-a", map[string]any{"os": map[string]any{"family": "rhel"}}, 0)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/plans/explain", bytes.NewReader([]byte(`{"config_path":"c.yaml"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	var resp struct {
+		Summary map[string]any `json:"summary"`
+		Steps   []struct {
+			ResourceID string `json:"resource_id"`
+			Skipped    bool   `json:"skipped"`
+			Reason     string `json:"reason"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("plan explain failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if resp.Summary["skipped_steps"] != float64(1) {
+		t.Fatalf("expected one skipped step in summary, got %+v", resp.Summary)
+	}
+	for _, step := range resp.Steps {
+		if step.ResourceID == "apt-update" && (!step.Skipped || !strings.Contains(step.Reason, "facts.os.family=rhel")) {
+			t.Fatalf("expected apt-update skipped on observed facts, got %+v", step)
+		}
+		if step.ResourceID == "motd" && step.Skipped {
+			t.Fatalf("expected unconditioned step to stay scheduled, got %+v", step)
+		}
+	}
+}
+
 func TestBlastRadiusMapEndpoint(t *testing.T) {
 	tmp := t.TempDir()
 	cfg := filepath.Join(tmp, "c.yaml")
@@ -2918,6 +3191,9 @@ resources:
 		Results: []state.ResourceRun{
 			{ResourceID: "f1", Host: "localhost", Type: "file", Changed: true},
 		},
+		Artifacts: []state.RunArtifact{
+			{ResourceID: "f1", Host: "localhost", Name: "report", Path: "/var/tmp/report.txt", ObjectKey: "runs/run-export-1/artifacts/localhost/f1/report", SizeBytes: 4, Status: "collected"},
+		},
 	}); err != nil {
 		t.Fatalf("save run failed: %v", err)
 	}
@@ -2942,6 +3218,16 @@ resources:
 	if rr.Code != http.StatusOK {
 		t.Fatalf("triage bundle export failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
+	if !strings.Contains(rr.Body.String(), `"host_artifacts":1`) {
+		t.Fatalf("expected host artifact counted in triage bundle, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/runs/run-export-1/artifacts", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "runs/run-export-1/artifacts/localhost/f1/report") {
+		t.Fatalf("run artifacts failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodGet, "/v1/runs/run-export-1/timeline?minutes_before=10&minutes_after=10", nil)
@@ -4726,3 +5012,685 @@ resources:
 		t.Fatalf("expected error advice in conflict response: %s", rr.Body.String())
 	}
 }
+
+func TestWorkflowLaunchValidatesTypedInputs(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: f1
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "wf-inputs.txt")+`
+    content: "ok"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/templates", `{"name":"scale","config_path":"c.yaml","survey":{"replicas":{"type":"int","required":true}}}`)
+	var tpl control.Template
+	if err := json.Unmarshal(rr.Body.Bytes(), &tpl); err != nil || tpl.ID == "" {
+		t.Fatalf("template create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/workflows", `{"name":"scale-out","inputs":{"replicas":{"type":"int","required":true}},"steps":[{"template_id":"`+tpl.ID+`","answers":{"replicas":"{{ replicas }}"}}]}`)
+	var wf control.WorkflowTemplate
+	if err := json.Unmarshal(rr.Body.Bytes(), &wf); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("workflow create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/workflows/"+wf.ID+"/launch", `{"inputs":{"replicas":"lots"}}`); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid workflow inputs") {
+		t.Fatalf("expected mistyped input rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/workflows/"+wf.ID+"/launch", `{"inputs":{"replicas":"4"}}`)
+	var run control.WorkflowRun
+	if err := json.Unmarshal(rr.Body.Bytes(), &run); err != nil || rr.Code != http.StatusAccepted {
+		t.Fatalf("workflow launch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/workflow-runs/"+run.ID, "")
+	var detail control.WorkflowRun
+	_ = json.Unmarshal(rr.Body.Bytes(), &detail)
+	if detail.Inputs["replicas"] != "4" || len(detail.Steps) != 1 || detail.Steps[0].Answers["replicas"] != "4" {
+		t.Fatalf("expected inputs and resolved answers on run detail, got %s", rr.Body.String())
+	}
+}
+
+func TestSecretSurveyAnswersAreSealedAndRedacted(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: f1
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "survey-secret.txt")+`
+    content: "token={{ api_token }}"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/templates", `{"name":"bad","config_path":"c.yaml","survey":{"api_token":{"type":"secret"}},"defaults":{"api_token":"plain"}}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected secret default rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/templates", `{"name":"rotate","config_path":"c.yaml","survey":{"api_token":{"type":"secret","required":true,"pattern":"tok-[0-9]+"}}}`)
+	var tpl control.Template
+	if err := json.Unmarshal(rr.Body.Bytes(), &tpl); err != nil || tpl.ID == "" {
+		t.Fatalf("template create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/templates/"+tpl.ID+"/launch", `{"answers":{"api_token":"nope"}}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected pattern mismatch rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/templates/"+tpl.ID+"/launch", `{"answers":{"api_token":"tok-12345"}}`)
+	if rr.Code != http.StatusAccepted || strings.Contains(rr.Body.String(), "tok-12345") || !strings.Contains(rr.Body.String(), `token=\u003credacted\u003e`) {
+		t.Fatalf("expected redacted template launch, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var launch struct {
+		SecretRefs []string `json:"secret_refs"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &launch)
+	if len(launch.SecretRefs) != 1 {
+		t.Fatalf("expected sealed secret ref, got %s", rr.Body.String())
+	}
+	if got, err := s.encryptedSecrets.Resolve(launch.SecretRefs[0]); err != nil || got.Value != "tok-12345" {
+		t.Fatalf("expected secret answer stored encrypted, got %+v err=%v", got, err)
+	}
+
+	rr = do(http.MethodPost, "/v1/workflows", `{"name":"rotate-all","inputs":{"token":{"type":"secret","required":true}},"steps":[{"template_id":"`+tpl.ID+`","answers":{"api_token":"{{ token }}"}}]}`)
+	var wf control.WorkflowTemplate
+	if err := json.Unmarshal(rr.Body.Bytes(), &wf); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("workflow create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/workflows/"+wf.ID+"/launch", `{"inputs":{"token":"tok-999"}}`)
+	var run control.WorkflowRun
+	if err := json.Unmarshal(rr.Body.Bytes(), &run); err != nil || rr.Code != http.StatusAccepted {
+		t.Fatalf("workflow launch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/workflow-runs/"+run.ID, "")
+	if strings.Contains(rr.Body.String(), "tok-999") || len(run.SecretRefs) != 2 {
+		t.Fatalf("expected workflow run secrets redacted and sealed, got %s", rr.Body.String())
+	}
+	for _, event := range s.events.List() {
+		if raw, _ := json.Marshal(event.Fields); strings.Contains(string(raw), "tok-") {
+			t.Fatalf("secret leaked into event %+v", event)
+		}
+	}
+}
+
+func TestJobConcurrencyGroupEndpoint(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "site.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	s.queue.Pause()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var first, second control.Job
+	rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"site.yaml"}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &first); err != nil || rr.Code != http.StatusAccepted {
+		t.Fatalf("enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/jobs", `{"config_path":"site.yaml","cancel_superseded":true}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &second); err != nil || rr.Code != http.StatusAccepted {
+		t.Fatalf("enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(first.ConcurrencyGroup, "#hosts:") || first.ConcurrencyGroup != second.ConcurrencyGroup {
+		t.Fatalf("expected both jobs in the default group, got %q and %q", first.ConcurrencyGroup, second.ConcurrencyGroup)
+	}
+	if cur, _ := s.queue.Get(first.ID); cur.Status != control.JobCanceled {
+		t.Fatalf("expected superseded job canceled, got %+v", cur)
+	}
+	rr = do(http.MethodGet, "/v1/jobs/"+second.ID+"/concurrency", "")
+	var status control.JobConcurrencyStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil || rr.Code != http.StatusOK || status.Position != 1 || status.Group != second.ConcurrencyGroup {
+		t.Fatalf("unexpected concurrency status: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/jobs/job-missing/concurrency", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown job, got %d", rr.Code)
+	}
+}
+
+func TestDelayedJobsListRescheduleAndCancel(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "site.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	runAt := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+	var job control.Job
+	rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"site.yaml","run_at":"`+runAt+`"}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil || rr.Code != http.StatusAccepted || job.Status != control.JobScheduled {
+		t.Fatalf("expected scheduled job, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/jobs", `{"config_path":"site.yaml","run_at":"`+runAt+`","not_before":"2030-01-01T00:00:00Z"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected conflicting run_at/not_before rejected, got %d", rr.Code)
+	}
+	rr = do(http.MethodGet, "/v1/jobs/upcoming", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), job.ID) || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("expected job in upcoming list: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	later := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Second)
+	rr = do(http.MethodPost, "/v1/jobs/"+job.ID+"/reschedule", `{"run_at":"`+later.Format(time.RFC3339)+`"}`)
+	var moved control.Job
+	if err := json.Unmarshal(rr.Body.Bytes(), &moved); err != nil || rr.Code != http.StatusOK || !moved.NotBefore.Equal(later) {
+		t.Fatalf("reschedule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/jobs/"+job.ID+"/reschedule", `{}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected missing run_at rejected, got %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/v1/jobs/"+job.ID, ""); rr.Code != http.StatusOK {
+		t.Fatalf("cancel before dispatch failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/jobs/"+job.ID+"/reschedule", `{"run_at":"`+runAt+`"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected canceled job not reschedulable, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/jobs/upcoming", ""); !strings.Contains(rr.Body.String(), `"count":0`) {
+		t.Fatalf("expected no upcoming jobs after cancel, got %s", rr.Body.String())
+	}
+}
+
+func TestQueueDispatchWindowsDeferJobs(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "site.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	hour := time.Now().UTC().Hour()
+	start := fmt.Sprintf("%02d:00", (hour+2)%24)
+	end := fmt.Sprintf("%02d:00", (hour+4)%24)
+	rr := do(http.MethodPost, "/v1/control/queue/dispatch-windows", `{"name":"overnight-bulk","priority":"low","start":"`+start+`","end":"`+end+`","timezone":"UTC","target_local":true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create window failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/queue/dispatch-windows", `{"name":"bad","priority":"low","start":"22:00","end":"06:00","timezone":"Mars/Olympus"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown timezone rejected, got %d", rr.Code)
+	}
+
+	var job control.Job
+	rr = do(http.MethodPost, "/v1/jobs", `{"config_path":"site.yaml","priority":"low"}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil || rr.Code != http.StatusAccepted {
+		t.Fatalf("enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if job.Status != control.JobScheduled || job.DeferredBy != "overnight-bulk" || job.NotBefore.Hour() != (hour+2)%24 {
+		t.Fatalf("expected low-priority job deferred to window opening, got %+v", job)
+	}
+	if rr := do(http.MethodGet, "/v1/jobs/upcoming", ""); !strings.Contains(rr.Body.String(), job.ID) {
+		t.Fatalf("expected deferred job listed as upcoming: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/control/queue/dispatch-windows", ""); !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("expected one window listed: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, "/v1/control/queue/dispatch-windows/overnight-bulk", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete window failed: %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/v1/control/queue/dispatch-windows/overnight-bulk", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected missing window 404, got %d", rr.Code)
+	}
+}
+
+func TestCommandIngestBatchModesAndGzip(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(path string, body []byte, gzipped bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	command := func(key string) string {
+		sum := control.ComputeCommandChecksum("apply", "c.yaml", "low", key)
+		return `{"action":"apply","config_path":"c.yaml","priority":"low","idempotency_key":"` + key + `","checksum":"` + sum + `"}`
+	}
+	bad := `{"action":"apply","config_path":"c.yaml","checksum":"bad"}`
+	type batchResp struct {
+		Mode         string             `json:"mode"`
+		Accepted     int                `json:"accepted"`
+		DeadLettered int                `json:"dead_lettered"`
+		Rejected     int                `json:"rejected"`
+		Items        []commandBatchItem `json:"items"`
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`{"commands":[` + command("edge-1") + `,` + bad + `]}`))
+	_ = zw.Close()
+	rr := do("/v1/commands/ingest/batch", gz.Bytes(), true)
+	var out batchResp
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || rr.Code != http.StatusAccepted {
+		t.Fatalf("individual gzip batch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if out.Mode != control.CommandBatchIndividual || out.Accepted != 1 || out.DeadLettered != 1 || out.Items[0].Job == nil || out.Items[1].Error != "checksum mismatch" {
+		t.Fatalf("unexpected individual batch result: %+v", out)
+	}
+
+	rr = do("/v1/commands/ingest/batch", []byte(`{"mode":"atomic","commands":[`+command("edge-2")+`,`+bad+`]}`), false)
+	out = batchResp{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected atomic batch rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if out.Accepted != 0 || out.Rejected != 1 || out.Items[0].Status != "rejected" || out.Items[0].Job != nil {
+		t.Fatalf("expected valid command rejected with the batch: %+v", out)
+	}
+
+	if rr := do("/v1/commands/ingest/policy", []byte(`{"max_items":1,"default_mode":"atomic"}`), false); rr.Code != http.StatusOK {
+		t.Fatalf("policy update failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/commands/ingest/batch", []byte(`{"commands":[`+command("edge-3")+`,`+command("edge-4")+`]}`), false); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected batch over max_items rejected, got %d", rr.Code)
+	}
+	if rr := do("/v1/commands/ingest/batch", []byte("not gzip"), true); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected corrupt gzip body rejected, got %d", rr.Code)
+	}
+}
+
+func TestCommandDeadLetterReplayAndAutoRetry(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	sum := func(key string) string {
+		return control.ComputeCommandChecksum("apply", "c.yaml", "low", key)
+	}
+
+	var dl control.CommandEnvelope
+	rr := do("/v1/commands/ingest", `{"action":"apply","config_path":"c.yaml","priority":"low","idempotency_key":"k1","checksum":"stale"}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &dl); err != nil || dl.FailureClass != control.CommandFailureValidation {
+		t.Fatalf("expected validation dead letter: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/commands/dead-letters/"+dl.ID+"/replay", ""); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"attempts":2`) {
+		t.Fatalf("expected replay without fix to fail again: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do("/v1/commands/dead-letters/"+dl.ID+"/replay", `{"checksum":"`+sum("k1")+`"}`)
+	if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"status":"replayed"`) {
+		t.Fatalf("expected replay with corrected checksum accepted: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("/v1/commands/dead-letters/"+dl.ID+"/replay", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected second replay rejected, got %d", rr.Code)
+	}
+
+	if rr := do("/v1/commands/retry-policy", `{"enabled":true,"max_attempts":3,"backoff_seconds":60,"classes":["freeze"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("retry policy update failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	s.queue.SetFreezeUntil(time.Now().Add(time.Hour), "release window")
+	for _, key := range []string{"k2", "k3"} {
+		if rr := do("/v1/commands/ingest", `{"action":"apply","config_path":"c.yaml","priority":"low","idempotency_key":"`+key+`","checksum":"`+sum(key)+`"}`); rr.Code != http.StatusConflict {
+			t.Fatalf("expected frozen queue to dead-letter command: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	listReq := httptest.NewRequest(http.MethodGet, "/v1/commands/dead-letters?failure_class=freeze", nil)
+	listRR := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(listRR, listReq)
+	var frozen []control.CommandEnvelope
+	if err := json.Unmarshal(listRR.Body.Bytes(), &frozen); err != nil || len(frozen) != 2 || frozen[0].NextRetryAt.IsZero() {
+		t.Fatalf("expected two freeze dead letters scheduled for retry: %s", listRR.Body.String())
+	}
+
+	s.queue.ClearFreeze()
+	if got := s.retryDueDeadLetters(time.Now().UTC()); len(got) != 0 {
+		t.Fatalf("expected no retries before backoff elapses, got %d", len(got))
+	}
+	got := s.retryDueDeadLetters(time.Now().UTC().Add(2 * time.Minute))
+	if len(got) != 2 || got[0].Job == nil || got[1].Error != "" {
+		t.Fatalf("expected both frozen commands retried after backoff: %+v", got)
+	}
+
+	rr = do("/v1/commands/dead-letters/replay", `{"failure_class":"freeze"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":0`) {
+		t.Fatalf("expected nothing left to bulk replay: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestEventSchemaRegistryEndpointsAndEnforcement(t *testing.T) {
+	s := New(":0", t.TempDir())
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/events/schemas", `{"event_type":"edge.sync","mode":"enforce","schema":{"type":"object","required":["site"],"properties":{"site":{"type":"string"},"items":{"type":"integer","minimum":0}}}}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"version":1`) {
+		t.Fatalf("register schema failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/events/ingest", `{"type":"edge.sync","fields":{"items":3}}`); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "fields.site: required field is missing") {
+		t.Fatalf("expected enforced schema to reject event: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/events/ingest", `{"type":"edge.sync","fields":{"site":"pdx","items":3}}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected valid event ingested: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/events/schemas/edge.sync/compatibility", `{"schema":{"type":"object","required":["site","region"]}}`)
+	if !strings.Contains(rr.Body.String(), `"compatible":false`) || !strings.Contains(rr.Body.String(), "fields.region: newly required") {
+		t.Fatalf("expected compatibility problems reported: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/events/schemas", `{"event_type":"edge.sync","schema":{"type":"object","required":["site","region"]}}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected incompatible version rejected, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/events/schemas/edge.sync", ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"latest"`) {
+		t.Fatalf("expected schema versions: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Internally emitted events are never dropped, but mismatches are kept.
+	if rr := do(http.MethodPost, "/v1/events/schemas", `{"event_type":"command.ingest_policy.updated","schema":{"type":"object","required":["owner"]}}`); rr.Code != http.StatusCreated {
+		t.Fatalf("register warn schema failed: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/commands/ingest/policy", `{"max_items":5}`); rr.Code != http.StatusOK {
+		t.Fatalf("policy update failed: %s", rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/events/schemas/violations", "")
+	if !strings.Contains(rr.Body.String(), `"command.ingest_policy.updated":1`) {
+		t.Fatalf("expected internal event violation recorded: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/events/schemas/validate", `{"type":"edge.sync","fields":{"site":1}}`); !strings.Contains(rr.Body.String(), `"valid":false`) {
+		t.Fatalf("expected validate endpoint to report mismatch: %s", rr.Body.String())
+	}
+}
+
+func TestEventReplayDryRunAndLive(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	var replayed int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Masterchef-Replay") == "true" {
+			atomic.AddInt32(&replayed, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/events/replay", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	start := time.Now().UTC().Add(-time.Second).Format(time.RFC3339Nano)
+	for _, sev := range []string{"high", "low", "high"} {
+		s.events.Append(control.Event{Type: "external.alert.disk", Message: "disk", Fields: map[string]any{"sev": sev}})
+	}
+	rule, err := s.rules.Create(control.Rule{
+		Name:         "disk-remediation",
+		SourcePrefix: "external.alert",
+		Conditions:   []control.RuleCondition{{Field: "fields.sev", Comparator: "eq", Value: "high"}},
+		Actions:      []control.RuleAction{{Type: "enqueue_apply", ConfigPath: "c.yaml"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.rules.SetEnabled(rule.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	hook, err := s.webhooks.Register(control.WebhookSubscription{Name: "draft", URL: receiver.URL, EventPrefix: "external.alert", Enabled: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type replayResp struct {
+		Mode            string `json:"mode"`
+		EventsScanned   int    `json:"events_scanned"`
+		RuleMatches     int    `json:"rule_matches"`
+		ActionsExecuted int    `json:"actions_executed"`
+		WebhookResults  int    `json:"webhook_results"`
+		Items           []struct {
+			Matches []struct {
+				Actions []struct {
+					Status string `json:"status"`
+				} `json:"actions"`
+			} `json:"matches"`
+		} `json:"items"`
+	}
+	var out replayResp
+	rr := do(`{"since":"` + start + `","type_prefix":"external.alert","rule_ids":["` + rule.ID + `"],"include_disabled_rules":true,"targets":["rules","webhooks"],"webhook_ids":["` + hook.ID + `"]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("dry-run replay failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if out.Mode != "dry_run" || out.EventsScanned != 3 || out.RuleMatches != 2 || out.WebhookResults != 3 || out.Items[0].Matches[0].Actions[0].Status != "planned" {
+		t.Fatalf("unexpected dry-run replay: %+v", out)
+	}
+	if atomic.LoadInt32(&replayed) != 0 || len(s.queue.List()) != 0 {
+		t.Fatalf("expected dry run to deliver and enqueue nothing")
+	}
+	if cur, _ := s.rules.Get(rule.ID); cur.TriggerCount != 0 {
+		t.Fatalf("expected replay not to count triggers, got %d", cur.TriggerCount)
+	}
+
+	out = replayResp{}
+	rr = do(`{"since":"` + start + `","type_prefix":"external.alert","mode":"live","rule_ids":["` + rule.ID + `"],"include_disabled_rules":true,"targets":["rules","webhooks"],"webhook_ids":["` + hook.ID + `"]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || out.ActionsExecuted != 2 || atomic.LoadInt32(&replayed) != 3 {
+		t.Fatalf("unexpected live replay: code=%d body=%s replayed=%d", rr.Code, rr.Body.String(), replayed)
+	}
+	if rr := do(`{"targets":["webhooks"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected webhook replay without ids rejected, got %d", rr.Code)
+	}
+	if rr := do(`{"rule_ids":["rule-missing"]}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown rule rejected, got %d", rr.Code)
+	}
+}
+
+func TestNotificationPoliciesAPI(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	createTarget := func(body string) string {
+		t.Helper()
+		rr := do(http.MethodPost, "/v1/notifications/targets", body)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("target create failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var target control.NotificationTarget
+		_ = json.Unmarshal(rr.Body.Bytes(), &target)
+		return target.ID
+	}
+	team := createTarget(`{"name":"team","kind":"chatops","url":"` + receiver.URL + `","route":"digest","enabled":true}`)
+	now := time.Now().UTC()
+	quiet := `{"start":"` + now.Add(-time.Hour).Format("15:04") + `","end":"` + now.Add(2*time.Hour).Format("15:04") + `","bypass_severities":["critical"]}`
+	manager := createTarget(`{"name":"manager","kind":"incident","url":"` + receiver.URL + `","route":"digest","enabled":true,"quiet_hours":` + quiet + `}`)
+	if rr := do(http.MethodPost, "/v1/notifications/targets", `{"name":"bad","kind":"chatops","url":"`+receiver.URL+`","route":"*","quiet_hours":{"start":"25:00","end":"07:00"}}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid quiet hours to be rejected, got code=%d", rr.Code)
+	}
+
+	if rr := do(http.MethodPost, "/v1/notifications/policies", `{"name":"bad","targets":["notify-999"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown target to be rejected, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/notifications/policies", `{"name":"db","enabled":true,"priority":10,"event_types":["external.alert.db"],"targets":["`+team+`"],"escalation":[{"after_seconds":60,"targets":["`+manager+`"]}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("policy create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var policy control.NotificationPolicy
+	_ = json.Unmarshal(rr.Body.Bytes(), &policy)
+
+	rr = do(http.MethodPost, "/v1/notifications/policies/simulate", `{"event_type":"external.alert.db","severity":"high"}`)
+	var plan control.NotificationRoutePlan
+	_ = json.Unmarshal(rr.Body.Bytes(), &plan)
+	if rr.Code != http.StatusOK || plan.PolicyID != policy.ID || len(plan.Deliveries) != 2 || !plan.Deliveries[1].Suppressed {
+		t.Fatalf("unexpected simulation: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr = do(http.MethodPost, "/v1/events/ingest", `{"type":"external.alert.db","message":"replica lag","fields":{"sev":"high"}}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("event ingest failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/notifications/deliveries", "")
+	if !strings.Contains(rr.Body.String(), `"policy_id":"`+policy.ID+`"`) || !strings.Contains(rr.Body.String(), `"phase":"business_hours"`) {
+		t.Fatalf("expected policy delivery in log: %s", rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/notifications/escalations", "")
+	var escalations struct {
+		Count int                              `json:"count"`
+		Items []control.NotificationEscalation `json:"items"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &escalations)
+	if escalations.Count != 1 {
+		t.Fatalf("expected one pending escalation: %s", rr.Body.String())
+	}
+	dels := s.runNotificationEscalations(escalations.Items[0].NextStepAt)
+	if len(dels) != 1 || dels[0].TargetID != manager || dels[0].Status != "suppressed" {
+		t.Fatalf("expected escalation held back by quiet hours, got %+v", dels)
+	}
+
+	if rr = do(http.MethodPut, "/v1/notifications/policies/"+policy.ID, `{"name":"db","enabled":false,"targets":["`+team+`"]}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"enabled":false`) {
+		t.Fatalf("policy update failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodDelete, "/v1/notifications/policies/"+policy.ID, ""); rr.Code != http.StatusOK {
+		t.Fatalf("policy delete failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v1/notifications/policies/"+policy.ID, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected deleted policy to be gone, got code=%d", rr.Code)
+	}
+}
+
+func TestApprovalPolicyEnforcement(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := do(http.MethodPost, "/v1/identity/scim/teams", `{"external_id":"grp-dba","name":"dba","members":["alice","bob","carol"]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("scim team create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr := do(http.MethodPost, "/v1/access/approval-policies", `{"name":"prod","stages":[{"name":"dba","required_approvals":2,"teams":["dba"]}],"applies_to":["change_record","gitops_promotion"],"scopes":["prod/","production"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("approval policy create failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/v1/change-records", `{"summary":"resize db","config_path":"prod/db.yaml","requested_by":"alice"}`)
+	var rec control.ChangeRecord
+	_ = json.Unmarshal(rr.Body.Bytes(), &rec)
+	if rr = do(http.MethodPost, "/v1/change-records/"+rec.ID+"/approve", `{"actor":"alice"}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected self-approval to be forbidden, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/change-records/"+rec.ID+"/approve", `{"actor":"mallory"}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected non-member approval to be forbidden, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/change-records/"+rec.ID+"/approve", `{"actor":"bob"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"proposed"`) {
+		t.Fatalf("expected one of two approvals to leave record proposed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/access/approval-policies/evaluate?kind=change_record&id="+rec.ID, "")
+	var eval control.ApprovalEvaluation
+	_ = json.Unmarshal(rr.Body.Bytes(), &eval)
+	if rr.Code != http.StatusOK || !eval.Required || eval.Satisfied {
+		t.Fatalf("unexpected evaluation: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/change-records/"+rec.ID+"/approve", `{"actor":"carol"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"approved"`) {
+		t.Fatalf("expected quorum to approve record: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	rr = do(http.MethodPost, "/v1/gitops/promotions", `{"name":"api","stages":["staging","production"],"artifact_digest":"`+digest+`","actor":"alice"}`)
+	var promotion control.GitOpsPromotion
+	_ = json.Unmarshal(rr.Body.Bytes(), &promotion)
+	advance := `{"artifact_digest":"` + digest + `","actor":"alice"}`
+	if rr = do(http.MethodPost, "/v1/gitops/promotions/"+promotion.ID+"/advance", advance); rr.Code != http.StatusConflict {
+		t.Fatalf("expected unapproved promotion to be held, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, actor := range []string{"bob", "carol"} {
+		if rr = do(http.MethodPost, "/v1/gitops/promotions/"+promotion.ID+"/approve", `{"actor":"`+actor+`"}`); rr.Code != http.StatusOK {
+			t.Fatalf("promotion approve failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+	}
+	if !strings.Contains(rr.Body.String(), `"satisfied":true`) {
+		t.Fatalf("expected promotion approvals to be satisfied: %s", rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/gitops/promotions/"+promotion.ID+"/advance", advance); rr.Code != http.StatusOK {
+		t.Fatalf("expected approved promotion to advance, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func (s *Server) handleShards(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"events": map[string]any{
+			"policy": s.events.ShardPolicy(),
+			"shards": s.events.ShardStats(),
+		},
+		"queue": map[string]any{
+			"policy": s.queue.ShardPolicy(),
+			"shards": s.queue.ShardStats(),
+		},
+	})
+}
+
+func (s *Server) handleEventShardPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.events.ShardPolicy())
+	case http.MethodPost:
+		var req control.EventShardPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.events.SetShardPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.shards.events.updated",
+			Message: "event shard policy updated",
+			Fields: map[string]any{
+				"shard_by":         policy.ShardBy,
+				"default_capacity": policy.DefaultCapacity,
+				"spill":            policy.Spill,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleQueueShardPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.queue.ShardPolicy())
+	case http.MethodPost:
+		var req control.QueueShardPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.queue.SetShardPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "control.shards.queue.updated",
+			Message: "queue shard policy updated",
+			Fields: map[string]any{
+				"default_capacity": policy.DefaultCapacity,
+				"max_overflow":     policy.MaxOverflow,
+			},
+		}, true)
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleEventShardSpilled(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/shards/events/{shard}/spilled
+	if len(parts) != 6 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "shards" || parts[3] != "events" || parts[5] != "spilled" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid event shard path"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	items, err := s.events.Spilled(parts[4], parseIntQuery(r, "limit", 200))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"shard": parts[4], "items": items})
+}
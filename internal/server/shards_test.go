@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueueShardPolicyLimitsTenantJobs(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: f1
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "x.txt")+`
+    content: "x"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/control/shards/queue/policy", bytes.NewReader([]byte(`{"capacities":{"acme":1}}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set queue shard policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Pause the queue so jobs stay pending in their shard.
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/queue", bytes.NewReader([]byte(`{"action":"pause"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("pause queue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	enqueue := func(tenant string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/jobs", bytes.NewReader([]byte(`{"config_path":"c.yaml"}`)))
+		req.Header.Set("X-Masterchef-Tenant", tenant)
+		req.Header.Set("X-Force-Apply", "true")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := enqueue("acme"); rr.Code != http.StatusAccepted {
+		t.Fatalf("first acme enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := enqueue("acme"); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "shard acme") {
+		t.Fatalf("expected acme shard to be full, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := enqueue("globex"); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected globex job to be admitted: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/control/shards", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get shards failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Queue struct {
+			Shards []struct {
+				Shard    string `json:"shard"`
+				Pending  int    `json:"pending"`
+				Rejected int    `json:"rejected"`
+			} `json:"shards"`
+		} `json:"queue"`
+		Events struct {
+			Shards []struct {
+				Shard string `json:"shard"`
+			} `json:"shards"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode shards failed: %v", err)
+	}
+	found := false
+	for _, sh := range resp.Queue.Shards {
+		if sh.Shard == "acme" {
+			found = sh.Pending == 1 && sh.Rejected == 1
+		}
+	}
+	if !found || len(resp.Events.Shards) == 0 {
+		t.Fatalf("unexpected shard metrics: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/shards/events/policy", bytes.NewReader([]byte(`{"shard_by":"tenant","spill":true}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set event shard policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/control/shards/events/acme/spilled", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("read spilled events failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
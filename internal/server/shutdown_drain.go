@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// DrainTimeout is how long Shutdown waits for running jobs before
+// checkpointing them.
+func (s *Server) DrainTimeout() time.Duration {
+	return s.shutdownDrain
+}
+
+func queueStatePath(baseDir string) string {
+	return filepath.Join(baseDir, ".masterchef", "queue-state.json")
+}
+
+// drainQueue stops intake, gives running jobs until the drain deadline
+// (MC_SHUTDOWN_DRAIN_SECONDS, bounded by ctx) to finish, checkpoints jobs
+// that are still running, and persists unfinished work for the next start.
+func (s *Server) drainQueue(ctx context.Context) {
+	started := time.Now().UTC()
+	s.queue.BeginDrain()
+	drainCtx, cancel := context.WithTimeout(ctx, s.shutdownDrain)
+	defer cancel()
+	_, waitErr := s.queue.WaitIdle(drainCtx)
+	if s.runCancel != nil {
+		s.runCancel()
+	}
+	select {
+	case <-s.queue.Done():
+	case <-drainCtx.Done():
+	}
+
+	unfinished := s.queue.Unfinished()
+	state := control.QueueState{SavedAt: time.Now().UTC(), Jobs: unfinished}
+	interrupted := 0
+	for _, job := range unfinished {
+		if job.Status == control.JobRunning {
+			interrupted++
+			_, _ = s.checkpoints.Record(control.ExecutionCheckpointInput{
+				JobID:      job.ID,
+				ConfigPath: job.ConfigPath,
+				Status:     "interrupted",
+				Metadata:   map[string]string{"reason": "shutdown drain deadline exceeded"},
+			})
+		}
+		state.Checkpoints = append(state.Checkpoints, s.checkpoints.List("", job.ID, 1000)...)
+	}
+	path := queueStatePath(s.baseDir)
+	fields := map[string]any{
+		"pending":     len(unfinished) - interrupted,
+		"interrupted": interrupted,
+		"timed_out":   waitErr != nil,
+		"duration_ms": time.Since(started).Milliseconds(),
+	}
+	if len(unfinished) == 0 {
+		_ = os.Remove(path)
+	} else if err := control.SaveQueueState(path, state); err != nil {
+		fields["error"] = err.Error()
+		s.logger.Error("persist queue state failed", "path", path, "error", err)
+	} else {
+		fields["state_path"] = path
+	}
+	s.logger.Info("queue drained for shutdown", "pending", fields["pending"], "interrupted", interrupted, "timed_out", waitErr != nil)
+	s.events.Append(control.Event{
+		Type:    "control.shutdown.drained",
+		Message: "queue drained for shutdown",
+		Fields:  fields,
+	})
+}
+
+// restoreQueueState re-queues work persisted by the previous shutdown. The
+// state file is removed once loaded so jobs are restored at most once.
+func (s *Server) restoreQueueState() {
+	path := queueStatePath(s.baseDir)
+	state, ok, err := control.LoadQueueState(path)
+	if err != nil {
+		s.logger.Error("load queue state failed", "path", path, "error", err)
+		s.events.Append(control.Event{
+			Type:    "control.queue.restore.error",
+			Message: "saved queue state could not be loaded",
+			Fields:  map[string]any{"path": path, "error": err.Error()},
+		})
+		return
+	}
+	if !ok {
+		return
+	}
+	_ = os.Remove(path)
+	for _, cp := range state.Checkpoints {
+		_, _ = s.checkpoints.Restore(cp)
+	}
+	restored, failed := 0, 0
+	for _, job := range state.Jobs {
+		if _, err := s.queue.Restore(job); err != nil {
+			failed++
+			continue
+		}
+		restored++
+	}
+	s.logger.Info("queue state restored", "jobs", restored, "failed", failed)
+	s.events.Append(control.Event{
+		Type:    "control.queue.restored",
+		Message: "unfinished jobs restored from previous shutdown",
+		Fields: map[string]any{
+			"restored":    restored,
+			"failed":      failed,
+			"checkpoints": len(state.Checkpoints),
+			"saved_at":    state.SavedAt,
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestShutdownPersistsAndRestoresQueue(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	s.shutdownDrain = 200 * time.Millisecond
+	s.queue.Pause()
+	// The idle worker may already be waiting for a job; let it take one so
+	// it observes the pause before the job under test is queued.
+	warm, err := s.queue.Enqueue(filepath.Join(tmp, "warm.yaml"), "", false, "")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		cur, _ := s.queue.Get(warm.ID)
+		if cur.Status != "pending" && cur.Status != "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for warm-up job")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, err := s.queue.Enqueue(filepath.Join(tmp, "c.yaml"), "drain-key", false, "high")
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if _, err := s.queue.Enqueue(filepath.Join(tmp, "c.yaml"), "", true, ""); err == nil {
+		t.Fatalf("expected queue to reject jobs after drain")
+	}
+	if _, err := os.Stat(queueStatePath(tmp)); err != nil {
+		t.Fatalf("expected queue state to be persisted: %v", err)
+	}
+
+	restarted := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = restarted.Shutdown(context.Background())
+	})
+	restored, ok := restarted.queue.Get(job.ID)
+	if !ok || restored.ConfigPath != job.ConfigPath || restored.Priority != "high" {
+		t.Fatalf("expected job to be restored after restart: %+v", restored)
+	}
+	if _, err := os.Stat(queueStatePath(tmp)); !os.IsNotExist(err) {
+		t.Fatalf("expected queue state file to be consumed on restore")
+	}
+	found := false
+	for _, e := range restarted.events.List() {
+		if e.Type == "control.queue.restored" && e.Fields["restored"] == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected control.queue.restored event")
+	}
+}
@@ -2,12 +2,17 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/masterchef/masterchef/internal/config"
 	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/planner"
 )
 
 func (s *Server) handleStepSnapshots(w http.ResponseWriter, r *http.Request) {
@@ -43,20 +48,248 @@ func (s *Server) handleStepSnapshots(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleStepSnapshotByID(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleStepSnapshotByID(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := splitPath(r.URL.Path)
+		// /v1/execution/snapshots/{id}[/restore]
+		if len(parts) < 4 || strings.TrimSpace(parts[3]) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "snapshot id is required"})
+			return
+		}
+		if len(parts) == 4 && parts[3] == "diff" {
+			s.handleStepSnapshotDiff(w, r)
+			return
+		}
+		if len(parts) == 5 && parts[4] == "restore" {
+			s.handleStepSnapshotRestore(baseDir, parts[3], w, r)
+			return
+		}
+		if len(parts) != 4 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown snapshot action"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		item, ok := s.stepSnapshots.Get(parts[3])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "snapshot not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	}
+}
+
+func (s *Server) handleStepSnapshotDiff(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	id := strings.TrimSpace(path.Base(r.URL.Path))
-	if id == "" || id == "snapshots" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "snapshot id is required"})
+	fromID := strings.TrimSpace(r.URL.Query().Get("from"))
+	toID := strings.TrimSpace(r.URL.Query().Get("to"))
+	if fromID == "" || toID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from and to snapshot ids are required"})
+		return
+	}
+	from, ok := s.stepSnapshots.Get(fromID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "snapshot " + fromID + " not found"})
+		return
+	}
+	to, ok := s.stepSnapshots.Get(toID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "snapshot " + toID + " not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, control.DiffStepSnapshots(from, to))
+}
+
+// handleStepSnapshotRestore seeds a new job from a step snapshot. A
+// succeeded or skipped step resumes after that step and a failed one
+// retries it. The resume point is recorded as an execution checkpoint and
+// run through the checkpoint resume flow, so only the remaining steps are
+// applied. Outputs of the steps already done are carried into the
+// checkpoint metadata and re-recorded as snapshots of the new job.
+func (s *Server) handleStepSnapshotRestore(baseDir, id string, w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		ConfigPath     string `json:"config_path,omitempty"`
+		Priority       string `json:"priority,omitempty"`
+		IdempotencyKey string `json:"idempotency_key,omitempty"`
+		Force          bool   `json:"force,omitempty"`
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req reqBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
 		return
 	}
-	item, ok := s.stepSnapshots.Get(id)
+	snapshot, ok := s.stepSnapshots.Get(id)
 	if !ok {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "snapshot not found"})
 		return
 	}
-	writeJSON(w, http.StatusOK, item)
+	configPath := strings.TrimSpace(req.ConfigPath)
+	if configPath == "" {
+		// Jobs seeded by an earlier restore run a trimmed resume config, so
+		// go back to the pipeline config their checkpoint was taken from.
+		if checkpoint, ok := s.checkpoints.Get(snapshot.Metadata["restored_checkpoint"]); ok {
+			configPath = checkpoint.ConfigPath
+		}
+	}
+	if configPath == "" && snapshot.JobID != "" {
+		if job, ok := s.queue.Get(snapshot.JobID); ok {
+			configPath = job.ConfigPath
+		}
+	}
+	if configPath == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required when the snapshot job is unknown"})
+		return
+	}
+	if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(baseDir, configPath)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	plan, err := planner.Build(cfg)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	orders := map[string]int{}
+	maxOrder := 0
+	for _, step := range plan.Steps {
+		orders[step.Resource.ID] = step.Order
+		if step.Order > maxOrder {
+			maxOrder = step.Order
+		}
+	}
+	order, ok := orders[snapshot.StepID]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "step " + snapshot.StepID + " is not in the plan for " + configPath})
+		return
+	}
+	completedThrough := order
+	if snapshot.Status != "succeeded" && snapshot.Status != "skipped" {
+		completedThrough = order - 1
+	}
+	if completedThrough >= maxOrder {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "snapshot is at the end of the plan; no remaining steps to restore"})
+		return
+	}
+
+	prior, err := s.stepSnapshots.PriorSteps(snapshot.SnapshotID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	carried := make([]control.StepSnapshot, 0, len(prior))
+	outputs := map[string]string{}
+	for _, item := range prior {
+		stepOrder, ok := orders[item.StepID]
+		if !ok || stepOrder > completedThrough || (item.Status != "succeeded" && item.Status != "skipped") {
+			continue
+		}
+		carried = append(carried, item)
+		for k, v := range item.Metadata {
+			outputs[item.StepID+"."+k] = v
+		}
+	}
+	// The checkpoint names the last completed step; with none completed the
+	// step is left empty so resume does not look its order back up.
+	lastStepID := ""
+	for _, step := range plan.Steps {
+		if step.Order == completedThrough {
+			lastStepID = step.Resource.ID
+		}
+	}
+	metadata := map[string]string{}
+	for k, v := range outputs {
+		metadata[k] = v
+	}
+	metadata["snapshot_id"] = snapshot.SnapshotID
+	metadata["snapshot_step_id"] = snapshot.StepID
+	checkpoint, err := s.checkpoints.Record(control.ExecutionCheckpointInput{
+		RunID:      snapshot.RunID,
+		JobID:      snapshot.JobID,
+		ConfigPath: configPath,
+		StepID:     lastStepID,
+		StepOrder:  completedThrough,
+		Status:     "restored",
+		Metadata:   metadata,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	resumePath, remaining, err := buildResumeConfigFromCheckpoint(baseDir, configPath, checkpoint)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	key := strings.TrimSpace(req.IdempotencyKey)
+	if key == "" {
+		key = "restore-" + snapshot.SnapshotID + "-" + time.Now().UTC().Format("20060102T150405")
+	}
+	job, err := s.queue.Enqueue(resumePath, key, req.Force, req.Priority)
+	if err != nil {
+		writeJSON(w, enqueueErrorCode(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	seeded := make([]control.StepSnapshot, 0, len(carried))
+	for _, item := range carried {
+		meta := map[string]string{}
+		for k, v := range item.Metadata {
+			meta[k] = v
+		}
+		meta["restored_from"] = item.SnapshotID
+		meta["restored_checkpoint"] = checkpoint.ID
+		in := control.StepSnapshotInput{
+			JobID:        job.ID,
+			StepID:       item.StepID,
+			ResourceType: item.ResourceType,
+			Host:         item.Host,
+			Status:       item.Status,
+			StartedAt:    item.StartedAt.Format(time.RFC3339Nano),
+			StdoutHash:   item.StdoutHash,
+			StderrHash:   item.StderrHash,
+			Metadata:     meta,
+		}
+		if !item.EndedAt.IsZero() {
+			in.EndedAt = item.EndedAt.Format(time.RFC3339Nano)
+		}
+		copied, err := s.stepSnapshots.Record(in)
+		if err != nil {
+			continue
+		}
+		seeded = append(seeded, copied)
+	}
+	s.recordEvent(control.Event{
+		Type:    "execution.snapshot.restored",
+		Message: "new job seeded from step snapshot",
+		Fields: map[string]any{
+			"snapshot_id":     snapshot.SnapshotID,
+			"step_id":         snapshot.StepID,
+			"checkpoint_id":   checkpoint.ID,
+			"job_id":          job.ID,
+			"remaining_steps": remaining,
+			"seeded_steps":    len(seeded),
+		},
+	}, true)
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"snapshot":           snapshot,
+		"checkpoint":         checkpoint,
+		"resume_config_path": resumePath,
+		"remaining_steps":    remaining,
+		"prior_outputs":      outputs,
+		"seeded_snapshots":   seeded,
+		"job":                job,
+	})
 }
@@ -8,7 +8,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestStepSnapshotEndpoints(t *testing.T) {
@@ -61,3 +64,123 @@ func TestStepSnapshotEndpoints(t *testing.T) {
 		t.Fatalf("get snapshot by id failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestStepSnapshotDiffAndRestore(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "pipeline.yaml"), []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: a
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "a.txt")+`
+    content: "a"
+  - id: b
+    type: file
+    host: localhost
+    depends_on: [a]
+    path: `+filepath.Join(tmp, "b.txt")+`
+    content: "b"
+  - id: c
+    type: command
+    host: localhost
+    depends_on: [b]
+    command: "echo c"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	record := func(body string) string {
+		t.Helper()
+		rr := do(http.MethodPost, "/v1/execution/snapshots", body)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("record snapshot failed: code=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var item struct {
+			SnapshotID string `json:"snapshot_id"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil {
+			t.Fatal(err)
+		}
+		return item.SnapshotID
+	}
+	record(`{"run_id":"run-7","step_id":"a","status":"succeeded","metadata":{"checksum":"abc"}}`)
+	failed := record(`{"run_id":"run-7","step_id":"b","status":"failed","stderr_hash":"sha256:err"}`)
+	retried := record(`{"run_id":"run-7","step_id":"b","status":"succeeded"}`)
+	last := record(`{"run_id":"run-7","step_id":"c","status":"succeeded"}`)
+
+	rr := do(http.MethodGet, "/v1/execution/snapshots/diff?from="+failed+"&to="+retried, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("diff snapshots failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var diff control.StepSnapshotDiff
+	if err := json.Unmarshal(rr.Body.Bytes(), &diff); err != nil {
+		t.Fatal(err)
+	}
+	if !diff.SameStep || len(diff.Changes) != 2 {
+		t.Fatalf("expected status and stderr changes, got %+v", diff.Changes)
+	}
+	if rr := do(http.MethodGet, "/v1/execution/snapshots/diff?from="+failed, ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected diff without to to fail: code=%d", rr.Code)
+	}
+
+	if rr := do(http.MethodPost, "/v1/execution/snapshots/"+failed+"/restore", `{}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected restore without a known job or config to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/execution/snapshots/"+last+"/restore", `{"config_path":"pipeline.yaml"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected restore past the last step to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/execution/snapshots/"+failed+"/restore", `{"config_path":"pipeline.yaml"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("restore snapshot failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var restored struct {
+		Checkpoint       control.ExecutionCheckpoint `json:"checkpoint"`
+		RemainingSteps   int                         `json:"remaining_steps"`
+		PriorOutputs     map[string]string           `json:"prior_outputs"`
+		SeededSnapshots  []control.StepSnapshot      `json:"seeded_snapshots"`
+		ResumeConfigPath string                      `json:"resume_config_path"`
+		Job              control.Job                 `json:"job"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.RemainingSteps != 2 || restored.Checkpoint.StepID != "a" || restored.Checkpoint.StepOrder != 1 {
+		t.Fatalf("expected the failed step to be retried, got %+v", restored)
+	}
+	if restored.PriorOutputs["a.checksum"] != "abc" || restored.Checkpoint.Metadata["snapshot_id"] != failed {
+		t.Fatalf("expected prior outputs on the checkpoint, got %+v", restored)
+	}
+	if len(restored.SeededSnapshots) != 1 || restored.SeededSnapshots[0].JobID != restored.Job.ID || restored.SeededSnapshots[0].Metadata["restored_from"] == "" {
+		t.Fatalf("expected step a to be seeded into the new job, got %+v", restored.SeededSnapshots)
+	}
+	content, err := os.ReadFile(restored.ResumeConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), `"id": "a"`) || !strings.Contains(string(content), `"id": "b"`) {
+		t.Fatalf("expected resume config to start at step b: %s", content)
+	}
+
+	// A restore of a succeeded step picks up after it, using the config of
+	// the job the snapshot belongs to.
+	rr = do(http.MethodPost, "/v1/execution/snapshots/"+restored.SeededSnapshots[0].SnapshotID+"/restore", `{}`)
+	if rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"remaining_steps":2`) {
+		t.Fatalf("restore from seeded job snapshot failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if events := s.events.Query(control.EventQuery{TypePrefix: "execution.snapshot.restored"}); len(events) != 2 {
+		t.Fatalf("expected two restore events, got %d", len(events))
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// resolveOwnership defaults an entity's owner to the requesting principal
+// and enforces the stewardship policy's ownership requirement.
+func (s *Server) resolveOwnership(w http.ResponseWriter, r *http.Request, owner, team string) (string, string, bool) {
+	owner = strings.TrimSpace(owner)
+	team = strings.TrimSpace(team)
+	if owner == "" && team == "" {
+		owner, _ = requestIdentity(r)
+	}
+	if err := s.stewardship.CheckOwnership(owner, team); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return "", "", false
+	}
+	return owner, team, true
+}
+
+func (s *Server) handleStewardshipPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.stewardship.Policy())
+	case http.MethodPost:
+		var req control.StewardshipPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		item, err := s.stewardship.SetPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleStewardshipReport serves GET /v1/stewardship/report with optional
+// stale_after_days, kind, and reason filters.
+func (s *Server) handleStewardshipReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	days := s.stewardship.Policy().StaleAfterDays
+	if raw := strings.TrimSpace(r.URL.Query().Get("stale_after_days")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "stale_after_days must be a positive integer"})
+			return
+		}
+		days = n
+	}
+	report := control.BuildStewardshipReport(control.StewardshipReportInput{
+		Templates:     s.templates.List(),
+		Runbooks:      s.runbooks.List(),
+		Schedules:     s.scheduler.List(),
+		Webhooks:      s.webhooks.List(),
+		DepartedOwner: s.departedOwner(),
+		StaleAfter:    time.Duration(days) * 24 * time.Hour,
+	})
+	kind := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("kind")))
+	reason := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("reason")))
+	if kind != "" || reason != "" {
+		filtered := make([]control.StewardshipFinding, 0, len(report.Findings))
+		for _, f := range report.Findings {
+			if (kind == "" || f.Kind == kind) && (reason == "" || f.Reason == reason) {
+				filtered = append(filtered, f)
+			}
+		}
+		report.Findings = filtered
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// departedOwner reports owners that are deactivated or absent in the SCIM
+// directory; owners naming a provisioned group are kept. Without
+// provisioned users no owner is considered departed.
+func (s *Server) departedOwner() func(string) bool {
+	users, err := s.scimDirectory.ListUsers("")
+	if err != nil || len(users) == 0 {
+		return nil
+	}
+	active := map[string]bool{}
+	for _, u := range users {
+		names := []string{u.UserName}
+		for _, email := range u.Emails {
+			names = append(names, email.Value)
+		}
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" {
+				active[name] = active[name] || u.Active
+			}
+		}
+	}
+	if groups, err := s.scimDirectory.ListGroups(""); err == nil {
+		for _, g := range groups {
+			active[strings.ToLower(strings.TrimSpace(g.DisplayName))] = true
+		}
+	}
+	return func(owner string) bool {
+		return !active[strings.ToLower(strings.TrimSpace(owner))]
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestOwnershipEnforcementAndStewardshipReport(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v1/stewardship/policy", "", `{"require_owner":true,"stale_after_days":30}`); rr.Code != http.StatusOK {
+		t.Fatalf("set policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	for path, body := range map[string]string{
+		"/v1/templates": `{"name":"web","config_path":"c.yaml"}`,
+		"/v1/runbooks":  `{"name":"web","target_type":"config","config_path":"c.yaml"}`,
+		"/v1/schedules": `{"config_path":"c.yaml","interval_seconds":3600}`,
+		"/v1/webhooks":  `{"name":"ops","url":"https://example.test/hook","event_prefix":"job."}`,
+	} {
+		if rr := do(http.MethodPost, path, "", body); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "owner or team is required") {
+			t.Fatalf("expected %s to require an owner: code=%d body=%s", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := do(http.MethodPost, "/v1/templates", "alice@example.com", `{"name":"web","config_path":"c.yaml"}`)
+	var tpl control.Template
+	if err := json.Unmarshal(rr.Body.Bytes(), &tpl); err != nil || rr.Code != http.StatusCreated || tpl.Owner != "alice@example.com" {
+		t.Fatalf("expected owner to default to principal: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/templates", "", `{"name":"db","config_path":"c.yaml","team":"payments"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create team-owned template failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/webhooks", "", `{"name":"ops","url":"https://example.test/hook","event_prefix":"job.","owner":"bob@example.com"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("create webhook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPatch, "/v1/templates/"+tpl.ID, "", `{"owner":null}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected patch clearing the owner to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodPost, "/v1/templates/"+tpl.ID+"/launch", "", ""); rr.Code != http.StatusAccepted {
+		t.Fatalf("launch failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	active, inactive := true, false
+	if _, err := s.scimDirectory.CreateUser(control.SCIMUserInput{UserName: "alice@example.com", Active: &active}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.scimDirectory.CreateUser(control.SCIMUserInput{UserName: "bob@example.com", Active: &inactive}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = do(http.MethodGet, "/v1/stewardship/report", "", "")
+	var report control.StewardshipReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("report failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if report.Scanned != 3 || report.StaleAfterDays != 30 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Counts[control.StewardshipDepartedOwner] != 1 || report.Counts[control.StewardshipNeverLaunched] != 1 {
+		t.Fatalf("unexpected finding counts %+v", report.Counts)
+	}
+	rr = do(http.MethodGet, "/v1/stewardship/report?reason=departed_owner", "", "")
+	if !strings.Contains(rr.Body.String(), `"id":"wh-1"`) || strings.Contains(rr.Body.String(), `"kind":"template"`) {
+		t.Fatalf("expected only the departed webhook owner: %s", rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v1/stewardship/report?stale_after_days=0", "", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid stale window to be rejected: code=%d", rr.Code)
+	}
+}
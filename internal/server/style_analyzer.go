@@ -3,7 +3,10 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"path/filepath"
+	"strings"
 
+	"github.com/masterchef/masterchef/internal/config"
 	"github.com/masterchef/masterchef/internal/control"
 )
 
@@ -38,3 +41,222 @@ func (s *Server) handleStyleAnalyzerAnalyze(w http.ResponseWriter, r *http.Reque
 	}
 	writeJSON(w, code, report)
 }
+
+func (s *Server) handleStyleContentRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.styleAnalyzer.ListContentRules())
+	case http.MethodPost:
+		var req control.StyleContentRuleInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		rule, err := s.styleAnalyzer.CreateContentRule(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "policy.lint.rule_created",
+			Message: "config lint rule created",
+			Fields: map[string]any{
+				"rule_id":  rule.ID,
+				"name":     rule.Name,
+				"type":     rule.Type,
+				"severity": rule.Severity,
+			},
+		}, true)
+		writeJSON(w, http.StatusCreated, rule)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleStyleContentRuleAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/lint/style/content-rules/{id}
+	if len(parts) != 5 || parts[3] != "content-rules" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.styleAnalyzer.DeleteContentRule(parts[4]) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "lint rule not found"})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "policy.lint.rule_deleted",
+		Message: "config lint rule deleted",
+		Fields:  map[string]any{"rule_id": parts[4]},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (s *Server) handleStyleContentCheck(baseDir string) http.HandlerFunc {
+	type checkReq struct {
+		ConfigPath string `json:"config_path"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req checkReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if strings.TrimSpace(req.ConfigPath) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "config_path is required"})
+			return
+		}
+		path := req.ConfigPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		cfg, err := config.Load(path)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.styleAnalyzer.EnforceConfig("check", path, cfg))
+	}
+}
+
+func (s *Server) handleStyleWaivers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.styleAnalyzer.ListWaivers())
+	case http.MethodPost:
+		var req control.StyleWaiverInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		waiver, err := s.styleAnalyzer.RequestWaiver(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.recordEvent(control.Event{
+			Type:    "policy.lint.waiver_requested",
+			Message: "config lint waiver requested",
+			Fields:  styleWaiverFields(waiver),
+		}, true)
+		writeJSON(w, http.StatusCreated, waiver)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleStyleWaiverAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/lint/style/waivers/{id} or /v1/lint/style/waivers/{id}/approve|reject
+	if len(parts) < 5 || parts[3] != "waivers" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := parts[4]
+	switch {
+	case len(parts) == 5 && r.Method == http.MethodGet:
+		waiver, ok := s.styleAnalyzer.GetWaiver(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "lint waiver not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, waiver)
+	case len(parts) == 6 && (parts[5] == "approve" || parts[5] == "reject") && r.Method == http.MethodPost:
+		var req control.StyleWaiverDecision
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		approve := parts[5] == "approve"
+		waiver, err := s.styleAnalyzer.DecideWaiver(id, approve, req)
+		if err != nil {
+			code := http.StatusBadRequest
+			if err.Error() == "lint waiver not found" {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		eventType := "policy.lint.waiver_rejected"
+		if approve {
+			eventType = "policy.lint.waiver_approved"
+		}
+		s.recordEvent(control.Event{
+			Type:    eventType,
+			Message: "config lint waiver " + string(waiver.Status),
+			Fields:  styleWaiverFields(waiver),
+		}, true)
+		writeJSON(w, http.StatusOK, waiver)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// enforceConfigLint runs the organization's content rules against the
+// config behind a job, template, or runbook being created. Unwaived error
+// violations answer 409 with the report and are recorded for the audit
+// timeline. Configs that fail to load are left to the normal run path.
+func (s *Server) enforceConfigLint(w http.ResponseWriter, kind, configPath string) bool {
+	if !s.styleAnalyzer.HasContentRules() {
+		return true
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return true
+	}
+	report := s.styleAnalyzer.EnforceConfig(kind, configPath, cfg)
+	if len(report.Violations) == 0 {
+		return true
+	}
+	waived := 0
+	for _, v := range report.Violations {
+		if v.Waived {
+			waived++
+		}
+	}
+	fields := map[string]any{
+		"kind":        kind,
+		"config_path": configPath,
+		"violations":  len(report.Violations),
+		"blocking":    report.Blocking,
+		"waived":      waived,
+	}
+	if !report.Allowed {
+		s.recordEvent(control.Event{
+			Type:    "policy.lint.blocked",
+			Message: "config lint rules blocked " + kind + " creation",
+			Fields:  fields,
+		}, true)
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error": "config violates lint rules",
+			"lint":  report,
+		})
+		return false
+	}
+	s.recordEvent(control.Event{
+		Type:    "policy.lint.violations",
+		Message: "config lint rules reported non-blocking violations",
+		Fields:  fields,
+	}, true)
+	return true
+}
+
+func styleWaiverFields(waiver control.StyleWaiver) map[string]any {
+	return map[string]any{
+		"waiver_id":    waiver.ID,
+		"rule_id":      waiver.RuleID,
+		"config_path":  waiver.ConfigPath,
+		"resource_id":  waiver.ResourceID,
+		"requested_by": waiver.RequestedBy,
+		"decided_by":   waiver.DecidedBy,
+		"status":       waiver.Status,
+	}
+}
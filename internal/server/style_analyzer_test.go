@@ -3,10 +3,12 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -52,3 +54,84 @@ func TestStyleAnalyzerEndpoints(t *testing.T) {
 		t.Fatalf("expected style conflict (409): code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestStyleContentRulesBlockJobsTemplatesAndRunbooks(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "marker.txt")+`
+    content: "ok"
+    mode: "0666"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	rr := post("/v1/lint/style/content-rules", `{"name":"no world-writable files","type":"world_writable_mode","severity":"error"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create lint rule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var rule struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &rule)
+
+	for path, body := range map[string]string{
+		"/v1/jobs":      `{"config_path":"c.yaml"}`,
+		"/v1/templates": `{"name":"deploy","config_path":"c.yaml"}`,
+		"/v1/runbooks":  `{"name":"deploy","target_type":"config","config_path":"c.yaml"}`,
+	} {
+		rr = post(path, body)
+		if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "world-writable") {
+			t.Fatalf("expected lint rules to block %s: code=%d body=%s", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr = post("/v1/lint/style/waivers", `{"rule_id":"`+rule.ID+`","resource_id":"marker","reason":"shared scratch file","requested_by":"dev"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("request waiver failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var waiver struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &waiver)
+	if rr = post("/v1/lint/style/waivers/"+waiver.ID+"/approve", `{"actor":"dev"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected self-approval to fail: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = post("/v1/lint/style/waivers/"+waiver.ID+"/approve", `{"actor":"security-lead"}`); rr.Code != http.StatusOK {
+		t.Fatalf("approve waiver failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = post("/v1/lint/style/check", `{"config_path":"c.yaml"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"waived":true`) {
+		t.Fatalf("expected waived violation in check report: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = post("/v1/jobs", `{"config_path":"c.yaml"}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected waived job to be enqueued: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/activity/audit-timeline?category=resource", nil))
+	for _, typ := range []string{"policy.lint.blocked", "policy.lint.waiver_approved", "policy.lint.violations"} {
+		if !strings.Contains(rr.Body.String(), `"type":"`+typ+`"`) {
+			t.Fatalf("expected %s in audit timeline: %s", typ, rr.Body.String())
+		}
+	}
+}
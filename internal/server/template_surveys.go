@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// validateTemplateSurvey rejects malformed survey schemas and plaintext
+// defaults for secret fields before a template is saved.
+func validateTemplateSurvey(w http.ResponseWriter, survey map[string]control.SurveyField, defaults map[string]string) bool {
+	if err := control.ValidateSurveySchema(survey); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return false
+	}
+	if err := control.CheckSurveyDefaults(survey, defaults); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// sealSurveyAnswers stores secret answers for a launch under scope and
+// returns the secret names to report instead of the values.
+func (s *Server) sealSurveyAnswers(w http.ResponseWriter, scope string, survey map[string]control.SurveyField, answers map[string]string) ([]string, bool) {
+	refs, err := control.SealSurveySecrets(s.encryptedSecrets, scope, survey, answers)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	return refs, true
+}
@@ -3,31 +3,68 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
+	"github.com/masterchef/masterchef/internal/control"
 	"github.com/masterchef/masterchef/internal/testimpact"
 )
 
-func (s *Server) handleTestImpactAnalysis(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+func (s *Server) handleTestImpactAnalysis(baseDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			ChangedFiles       []string                       `json:"changed_files"`
+			ChangedObjects     []control.ObjectDependencyNode `json:"changed_objects,omitempty"`
+			AlwaysInclude      []string                       `json:"always_include,omitempty"`
+			MaxTargetedPackage int                            `json:"max_targeted_packages,omitempty"`
+			MinConfidence      float64                        `json:"min_confidence,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if len(req.ChangedFiles) == 0 && len(req.ChangedObjects) == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "changed_files is required"})
+			return
+		}
+		// Changed config files and shared objects also select the configs
+		// whose scenario tests need to run, via the object model graph.
+		configs, templates, err := s.changeImpact(baseDir, req.ChangedFiles, req.ChangedObjects)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		// The same graph walk selects the scenarios and harness, mutation,
+		// and conformance suites CI needs to run for the change.
+		changes := make([]control.ObjectDependencyNode, 0, len(req.ChangedFiles)+len(req.ChangedObjects))
+		for _, path := range req.ChangedFiles {
+			if strings.TrimSpace(path) != "" {
+				changes = append(changes, control.ObjectDependencyNode{Kind: control.ObjectKindConfig, Name: resolveObjectPath(baseDir, path)})
+			}
+		}
+		for _, obj := range req.ChangedObjects {
+			if obj.Kind == control.ObjectKindConfig || obj.Kind == control.ObjectKindModule {
+				obj.Name = resolveObjectPath(baseDir, obj.Name)
+			}
+			changes = append(changes, obj)
+		}
+		tests, err := s.testImpact.Select(changes, req.MinConfidence)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		report := testimpact.AnalyzeWithOptions(req.ChangedFiles, testimpact.AnalyzeOptions{
+			AlwaysInclude:      req.AlwaysInclude,
+			MaxTargetedPackage: req.MaxTargetedPackage,
+		})
+		writeJSON(w, http.StatusOK, struct {
+			testimpact.Report
+			AffectedConfigs   []string                    `json:"affected_configs"`
+			AffectedTemplates []string                    `json:"affected_templates"`
+			Tests             control.TestImpactSelection `json:"tests"`
+		}{report, configs, templates, tests})
 	}
-	var req struct {
-		ChangedFiles       []string `json:"changed_files"`
-		AlwaysInclude      []string `json:"always_include,omitempty"`
-		MaxTargetedPackage int      `json:"max_targeted_packages,omitempty"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
-		return
-	}
-	if len(req.ChangedFiles) == 0 {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "changed_files is required"})
-		return
-	}
-	report := testimpact.AnalyzeWithOptions(req.ChangedFiles, testimpact.AnalyzeOptions{
-		AlwaysInclude:      req.AlwaysInclude,
-		MaxTargetedPackage: req.MaxTargetedPackage,
-	})
-	writeJSON(w, http.StatusOK, report)
 }
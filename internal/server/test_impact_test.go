@@ -107,3 +107,127 @@ func TestTestImpactAnalysisEndpointValidation(t *testing.T) {
 		t.Fatalf("expected validation error for empty changed files: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestObjectDependencyImpactScopesTestsAndPreviews(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		"base.yaml": `version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: motd
+    type: file
+    host: localhost
+    path: ` + filepath.Join(tmp, "motd.txt") + `
+    content: "{{ data_bag.motd.default }}"
+`,
+		"web.yaml": `version: v0
+includes:
+  - base.yaml
+`,
+		"db.yaml": `version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: db-marker
+    type: file
+    host: localhost
+    path: ` + filepath.Join(tmp, "db.txt") + `
+    content: "db\n"
+`,
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/templates", bytes.NewReader([]byte(`{"name":"web","config_path":"web.yaml"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create template failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/model/dependencies", bytes.NewReader([]byte(`{"config_paths":["db.yaml"]}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"kind":"module"`) {
+		t.Fatalf("index dependencies failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/model/dependencies/impact?kind=data_bag&name=motd", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("impact query failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var impact struct {
+		AffectedConfigs   []string `json:"affected_configs"`
+		AffectedTemplates []string `json:"affected_templates"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &impact); err != nil {
+		t.Fatal(err)
+	}
+	if len(impact.AffectedConfigs) != 1 || impact.AffectedConfigs[0] != filepath.Join(tmp, "web.yaml") || len(impact.AffectedTemplates) != 1 {
+		t.Fatalf("expected data bag to affect web config and template only, got %+v", impact)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/release/tests/scenarios", bytes.NewReader([]byte(`{"id":"web-smoke","name":"Web smoke","fleet_size":2,"services":1,"config_paths":["web.yaml"]}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upsert scenario failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/release/tests/impact-analysis", bytes.NewReader([]byte(`{"changed_files":["base.yaml"]}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"affected_configs":["`+filepath.Join(tmp, "web.yaml")+`"]`) {
+		t.Fatalf("expected test impact to include affected configs: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var selection struct {
+		Tests struct {
+			Targets []struct {
+				Kind       string  `json:"kind"`
+				ID         string  `json:"id"`
+				Confidence float64 `json:"confidence"`
+			} `json:"targets"`
+			FullRunRecommended bool `json:"full_run_recommended"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &selection); err != nil {
+		t.Fatal(err)
+	}
+	if len(selection.Tests.Targets) == 0 || selection.Tests.Targets[0].ID != "web-smoke" || selection.Tests.Targets[0].Confidence != 0.85 || selection.Tests.FullRunRecommended {
+		t.Fatalf("expected impacted scenario in minimal test set, got %+v", selection.Tests)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/release/tests/impact-analysis", bytes.NewReader([]byte(`{"changed_files":["db.yaml"],"min_confidence":0.9}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"full_run_recommended":true`) {
+		t.Fatalf("expected uncovered config to recommend a full run: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/gitops/previews", bytes.NewReader([]byte(`{"branch":"feature/motd","config_path":"db.yaml","changed_objects":[{"kind":"data_bag","name":"motd"}]}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected out-of-scope preview to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/gitops/previews", bytes.NewReader([]byte(`{"branch":"feature/motd","config_path":"web.yaml","changed_objects":[{"kind":"data_bag","name":"motd"}]}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"change_scope"`) {
+		t.Fatalf("expected scoped preview to be created: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
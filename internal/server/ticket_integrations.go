@@ -1,8 +1,14 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -97,3 +103,186 @@ func (s *Server) handleTicketSync(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, result)
 }
+
+func (s *Server) handleTicketCallback(w http.ResponseWriter, r *http.Request) {
+	// /v1/change-records/tickets/callback/{integration_id}
+	parts := splitPath(r.URL.Path)
+	if len(parts) != 5 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	result, err := s.ticketIntegrations.ApplyCallback(parts[4], body, r.Header.Get("X-Masterchef-Signature"))
+	if err != nil {
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "signature"):
+			code = http.StatusUnauthorized
+		case strings.Contains(err.Error(), "not found"), strings.Contains(err.Error(), "no change record linked"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "disabled"):
+			code = http.StatusConflict
+		}
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	var rec control.ChangeRecord
+	switch result.Decision {
+	case "approve":
+		rec, err = s.changeRecords.Approve(result.ChangeRecordID, result.Actor, result.Comment)
+	case "reject":
+		rec, err = s.changeRecords.Reject(result.ChangeRecordID, result.Actor, result.Comment)
+	case "complete":
+		rec, err = s.changeRecords.MarkCompleted(result.ChangeRecordID)
+	case "fail":
+		rec, err = s.changeRecords.MarkFailed(result.ChangeRecordID, result.Comment)
+	default:
+		rec, err = s.changeRecords.Get(result.ChangeRecordID)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "change_record.ticket.callback",
+		Message: "change record updated from ticket callback",
+		Fields: map[string]any{
+			"change_record_id": rec.ID,
+			"integration_id":   result.IntegrationID,
+			"ticket_id":        result.Link.TicketID,
+			"ticket_status":    result.TicketStatus,
+			"decision":         result.Decision,
+		},
+	}, true)
+	// Mirror the new status to other auto_create integrations; the source
+	// integration's link already matches so it is skipped.
+	s.ticketIntegrations.EnqueuePush(rec.ID)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"callback":      result,
+		"change_record": rec,
+	})
+}
+
+// pushChangeRecordTickets pushes the change record to auto_create ticket
+// integrations and records newly created ticket references on the record.
+// It returns an error when any integration failed so the push is retried.
+func (s *Server) pushChangeRecordTickets(rec control.ChangeRecord) error {
+	results := s.ticketIntegrations.PushChangeRecord(rec)
+	var failures []string
+	for _, res := range results {
+		switch res.Status {
+		case "synced":
+			if res.Operation == "create" && strings.TrimSpace(rec.TicketID) == "" {
+				if updated, err := s.changeRecords.SetTicket(rec.ID, res.Provider, res.Link.TicketID, res.Link.TicketURL); err == nil {
+					rec = updated
+				}
+			}
+			s.events.Append(control.Event{
+				Type:    "change_record.ticket." + res.Operation + "d",
+				Message: "change record synced to ticket system",
+				Fields: map[string]any{
+					"change_record_id": rec.ID,
+					"integration_id":   res.IntegrationID,
+					"ticket_id":        res.Link.TicketID,
+					"ticket_status":    res.Link.Status,
+				},
+			})
+		case "failed":
+			failures = append(failures, res.IntegrationID+": "+res.Error)
+			s.events.Append(control.Event{
+				Type:    "change_record.ticket.sync_failed",
+				Message: "change record ticket sync failed",
+				Fields: map[string]any{
+					"change_record_id": rec.ID,
+					"integration_id":   res.IntegrationID,
+					"operation":        res.Operation,
+					"error":            res.Error,
+				},
+			})
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// processTicketPushes drains due ticket pushes, always pushing the change
+// record's current state.
+func (s *Server) processTicketPushes() {
+	for {
+		push, ok := s.ticketIntegrations.NextPush(time.Now().UTC())
+		if !ok {
+			return
+		}
+		rec, err := s.changeRecords.Get(push.ChangeRecordID)
+		if err != nil {
+			continue
+		}
+		push, exhausted := s.ticketIntegrations.CompletePush(push, s.pushChangeRecordTickets(rec))
+		if exhausted {
+			s.recordEvent(control.Event{
+				Type:    "change_record.ticket.sync_abandoned",
+				Message: "change record ticket sync gave up after " + strconv.Itoa(push.Attempts) + " attempts",
+				Fields: map[string]any{
+					"severity":         "high",
+					"change_record_id": push.ChangeRecordID,
+					"attempts":         push.Attempts,
+					"error":            push.LastError,
+				},
+			}, true)
+		}
+	}
+}
+
+// sweepTicketPushes runs queued ticket pushes as they are enqueued and
+// retries failed ones on each tick once their backoff has elapsed.
+func (s *Server) sweepTicketPushes(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.ticketIntegrations.PushReady():
+			s.processTicketPushes()
+		case <-ticker.C:
+			s.processTicketPushes()
+		}
+	}
+}
+
+// linkRunbookChangeRecord attaches a runbook launch to a change record. When
+// no record is supplied and an auto_create ticket integration is enabled, a
+// change record is opened for the launch so a ticket is created for it.
+func (s *Server) linkRunbookChangeRecord(runbook control.Runbook, changeRecordID, jobID string) (control.ChangeRecord, bool) {
+	changeRecordID = strings.TrimSpace(changeRecordID)
+	if changeRecordID == "" {
+		if !s.ticketIntegrations.HasAutoCreate() {
+			return control.ChangeRecord{}, false
+		}
+		rec, err := s.changeRecords.Create(control.ChangeRecord{
+			Summary:     "runbook launch: " + runbook.Name,
+			ConfigPath:  runbook.ConfigPath,
+			RequestedBy: runbook.Owner,
+		})
+		if err != nil {
+			return control.ChangeRecord{}, false
+		}
+		changeRecordID = rec.ID
+	}
+	rec, err := s.changeRecords.AttachJob(changeRecordID, jobID)
+	if err != nil {
+		return control.ChangeRecord{}, false
+	}
+	s.ticketIntegrations.EnqueuePush(rec.ID)
+	return rec, true
+}
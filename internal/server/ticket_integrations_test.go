@@ -3,12 +3,18 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestTicketIntegrationEndpoints(t *testing.T) {
@@ -46,7 +52,7 @@ func TestTicketIntegrationEndpoints(t *testing.T) {
 		t.Fatalf("expected change record id in response: %s", rr.Body.String())
 	}
 
-	integrationBody := []byte(`{"name":"jira-prod","provider":"jira","base_url":"https://tickets.example.com","project_key":"OPS","enabled":true}`)
+	integrationBody := []byte(`{"name":"jira-prod","provider":"jira","base_url":"https://tickets.example.com","project_key":"OPS","callback_secret":"shh","enabled":true}`)
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/v1/change-records/ticket-integrations", bytes.NewReader(integrationBody))
 	s.httpServer.Handler.ServeHTTP(rr, req)
@@ -62,3 +68,155 @@ func TestTicketIntegrationEndpoints(t *testing.T) {
 		t.Fatalf("sync ticket integration failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestTicketIntegrationAutoCreateAndCallback(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := filepath.Join(tmp, "c.yaml")
+	features := filepath.Join(tmp, "features.md")
+	if err := os.WriteFile(cfg, []byte(`version: v0
+inventory:
+  hosts:
+    - name: localhost
+      transport: local
+resources:
+  - id: marker
+    type: file
+    host: localhost
+    path: `+filepath.Join(tmp, "x-ticket-runbook.txt")+`
+    content: "ok"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(features, []byte(`# Features
+- foo
+## Competitor Feature Traceability Matrix (Strict 1:1)
+### Chef -> Masterchef
+| ID | Chef Feature | Masterchef 1:1 Mapping |
+|---|---|---|
+| CHEF-1 | X | foo |
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var issues atomic.Int64
+	jira := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/issue" {
+			n := issues.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "1000" + strconv.FormatInt(n, 10), "key": "OPS-" + strconv.FormatInt(n, 10)})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer jira.Close()
+
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	integrationBody := []byte(`{"name":"jira-auto","provider":"jira","base_url":"` + jira.URL + `","project_key":"OPS","auto_create":true,"callback_secret":"shh","enabled":true}`)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/change-records/ticket-integrations", bytes.NewReader(integrationBody))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create ticket integration failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var integration struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &integration)
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/change-records", bytes.NewReader([]byte(`{"summary":"deploy api","requested_by":"sre"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create change record failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var change struct {
+		ID           string `json:"id"`
+		Status       string `json:"status"`
+		TicketSystem string `json:"ticket_system"`
+		TicketID     string `json:"ticket_id"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &change); err != nil {
+		t.Fatalf("decode change record failed: %v", err)
+	}
+	// The ticket is created by the background push worker.
+	deadline := time.Now().Add(5 * time.Second)
+	for change.TicketID == "" && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		rr = httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/change-records/"+change.ID, nil))
+		if err := json.Unmarshal(rr.Body.Bytes(), &change); err != nil {
+			t.Fatalf("decode change record failed: %v", err)
+		}
+	}
+	if change.TicketSystem != "jira" || change.TicketID != "OPS-1" {
+		t.Fatalf("expected auto-created jira ticket on change record, got %s", rr.Body.String())
+	}
+
+	callback := []byte(`{"issue":{"key":"OPS-1","fields":{"status":{"name":"Approved"}}},"user":{"name":"cab-lead"}}`)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/change-records/tickets/callback/"+integration.ID, bytes.NewReader(callback))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unsigned ticket callback to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	mac := hmac.New(sha256.New, []byte("shh"))
+	_, _ = mac.Write(callback)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/change-records/tickets/callback/"+integration.ID, bytes.NewReader(callback))
+	req.Header.Set("X-Masterchef-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ticket callback failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/change-records/"+change.ID, nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if err := json.Unmarshal(rr.Body.Bytes(), &change); err != nil {
+		t.Fatalf("decode change record failed: %v", err)
+	}
+	if change.Status != "approved" {
+		t.Fatalf("expected callback to approve change record, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/runbooks", bytes.NewReader([]byte(`{"name":"api-deploy","target_type":"config","config_path":"c.yaml","risk_level":"high","owner":"api-team"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create runbook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var runbook struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &runbook)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/runbooks/"+runbook.ID+"/approve", nil)
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("approve runbook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/runbooks/"+runbook.ID+"/launch", bytes.NewReader([]byte(`{"change_record_id":"`+change.ID+`"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("launch runbook failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var launch struct {
+		Job struct {
+			ID string `json:"id"`
+		} `json:"job"`
+		ChangeRecord struct {
+			ID          string `json:"id"`
+			Status      string `json:"status"`
+			LinkedJobID string `json:"linked_job_id"`
+		} `json:"change_record"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &launch); err != nil {
+		t.Fatalf("decode launch failed: %v", err)
+	}
+	if launch.ChangeRecord.ID != change.ID || launch.ChangeRecord.LinkedJobID != launch.Job.ID || launch.ChangeRecord.Status != "executing" {
+		t.Fatalf("expected launch to link change record, got %s", rr.Body.String())
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -33,6 +34,8 @@ func (s *Server) handleTopologyPlacementPolicies(w http.ResponseWriter, r *http.
 				"cluster":        item.Cluster,
 				"failure_domain": item.FailureDomain,
 				"max_parallel":   item.MaxParallel,
+				"data_residency": item.DataResidency,
+				"max_latency_ms": item.MaxLatencyMS,
 			},
 		}, true)
 		writeJSON(w, http.StatusOK, item)
@@ -54,3 +57,99 @@ func (s *Server) handleTopologyPlacementDecision(w http.ResponseWriter, r *http.
 	decision := s.topologyPlacement.Decide(req)
 	writeJSON(w, http.StatusOK, decision)
 }
+
+// placeJobTopology routes a new job to partition workers that satisfy the
+// topology placement policy matching its environment and region. Jobs no
+// policy matches keep their partition route.
+func (s *Server) placeJobTopology(placement *control.JobPlacement) {
+	if strings.TrimSpace(placement.Environment) == "" {
+		return
+	}
+	decision := s.topologyPlacement.Decide(control.TopologyPlacementDecisionInput{
+		Environment: placement.Environment,
+		Region:      placement.Region,
+	})
+	if decision.PolicyID == "" {
+		return
+	}
+	policy, ok := s.topologyPlacement.Get(decision.PolicyID)
+	if !ok {
+		return
+	}
+	placement.Partition, placement.Topology = s.partitionDispatch.PlaceTopology(policy, placement.Environment, placement.Partition)
+}
+
+// handleJobPlacementOverride serves POST /v1/jobs/{id}/placement, letting an
+// operator pin a pending partitioned job to a worker or partition regardless
+// of its topology placement policy.
+func (s *Server) handleJobPlacementOverride(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Worker    string `json:"worker"`
+		Partition string `json:"partition"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	current, ok := s.queue.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "reason is required"})
+		return
+	}
+	partition := strings.ToLower(strings.TrimSpace(req.Partition))
+	req.Worker = strings.TrimSpace(req.Worker)
+	if req.Worker != "" {
+		worker, ok := s.partitionDispatch.Get(req.Worker)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "worker not registered"})
+			return
+		}
+		switch {
+		case partition != "":
+			if !containsString(worker.Partitions, partition) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "worker does not serve partition " + partition})
+				return
+			}
+		case containsString(worker.Partitions, current.Partition):
+			partition = current.Partition
+		default:
+			partition = worker.Partitions[0]
+		}
+	}
+	if partition == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "worker or partition is required"})
+		return
+	}
+	by, _ := requestIdentity(r)
+	job, err := s.queue.OverridePlacement(id, partition, control.JobPlacementOverride{
+		Worker: req.Worker,
+		Reason: req.Reason,
+		By:     by,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "job.placement.overridden",
+		Message: "job " + job.ID + " placement overridden",
+		Fields: map[string]any{
+			"job_id":    job.ID,
+			"partition": job.Partition,
+			"worker":    req.Worker,
+			"reason":    req.Reason,
+			"by":        by,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, job)
+}
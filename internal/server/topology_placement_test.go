@@ -3,11 +3,14 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func TestTopologyPlacementEndpoints(t *testing.T) {
@@ -45,3 +48,62 @@ func TestTopologyPlacementEndpoints(t *testing.T) {
 		t.Fatalf("topology placement decision failed: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestJobsRoutedByTopologyPlacementWithOverride(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	if rr := post("/v1/control/topology-placement/policies", `{"environment":"prod","region":"eu-west-1","data_residency":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("create policy failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/control/scheduler/workers", `{"id":"eu-1","partitions":["eu"],"region":"eu-west-1","latency_ms":30}`); rr.Code != http.StatusCreated {
+		t.Fatalf("register eu worker failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/control/scheduler/workers", `{"id":"us-1","partitions":["us"],"region":"us-east-1"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("register us worker failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr := post("/v1/jobs", `{"config_path":"c.yaml","environment":"prod","region":"eu-west-1"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("enqueue failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var job control.Job
+	_ = json.Unmarshal(rr.Body.Bytes(), &job)
+	if job.Partition != "eu" || job.Placement == nil || job.Placement.PreferredWorker != "eu-1" || len(job.Placement.Explanation) == 0 {
+		t.Fatalf("expected job placed on eu worker with explanation, got %s", rr.Body.String())
+	}
+	if rr := post("/v1/control/scheduler/workers/us-1/claim", ``); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected us worker to get nothing, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := post("/v1/jobs/"+job.ID+"/placement", `{"worker":"us-1"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected override without reason to fail, got code=%d", rr.Code)
+	}
+	if rr := post("/v1/jobs/"+job.ID+"/placement", `{"worker":"ghost","reason":"x"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown worker override to 404, got code=%d", rr.Code)
+	}
+	rr = post("/v1/jobs/"+job.ID+"/placement", `{"worker":"us-1","reason":"eu region degraded"}`)
+	_ = json.Unmarshal(rr.Body.Bytes(), &job)
+	if rr.Code != http.StatusOK || job.Partition != "us" || job.Placement.Override == nil || job.Placement.Override.Worker != "us-1" {
+		t.Fatalf("override failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = post("/v1/control/scheduler/workers/us-1/claim", ``)
+	_ = json.Unmarshal(rr.Body.Bytes(), &job)
+	if rr.Code != http.StatusOK || job.Worker != "us-1" {
+		t.Fatalf("expected overridden job claimed by us-1, got code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/v1/jobs/"+job.ID+"/placement", `{"partition":"eu","reason":"move back"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected running job override to conflict, got code=%d", rr.Code)
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+// trashEntity soft-deletes an entity that was just removed from its store so
+// it can be restored from /v1/trash until its retention window ends.
+func (s *Server) trashEntity(r *http.Request, kind, entityID, name string, entity any) map[string]string {
+	principal, _ := requestIdentity(r)
+	item, err := s.trash.Put(kind, entityID, name, entity, principal)
+	if err != nil {
+		s.requestLogger(r.Context()).Warn("trash snapshot failed", "kind", kind, "entity_id", entityID, "error", err)
+		return map[string]string{"status": "deleted"}
+	}
+	s.recordEvent(control.Event{
+		Type:    "trash.entity.deleted",
+		Message: kind + " moved to trash",
+		Fields: map[string]any{
+			"trash_id":   item.ID,
+			"kind":       item.Kind,
+			"entity_id":  item.EntityID,
+			"deleted_by": item.DeletedBy,
+			"expires_at": item.ExpiresAt,
+		},
+	}, true)
+	return map[string]string{"status": "deleted", "trash_id": item.ID}
+}
+
+// restoreTrashItem puts a trashed entity back under its original ID. It
+// refuses when an entity with that ID exists again.
+func (s *Server) restoreTrashItem(item control.TrashItem) (any, error) {
+	conflict := errors.New(item.Kind + " " + item.EntityID + " already exists")
+	switch item.Kind {
+	case control.TrashKindView:
+		var view control.SavedView
+		if err := json.Unmarshal(item.Entity, &view); err != nil {
+			return nil, err
+		}
+		if _, err := s.views.Get(view.ID); err == nil {
+			return nil, conflict
+		}
+		return s.views.Restore(view)
+	case control.TrashKindTemplate:
+		var t control.Template
+		if err := json.Unmarshal(item.Entity, &t); err != nil {
+			return nil, err
+		}
+		if _, ok := s.templates.Get(t.ID); ok {
+			return nil, conflict
+		}
+		restored, err := s.templates.Restore(t)
+		if err != nil {
+			return nil, err
+		}
+		_ = s.objectModel.RegisterTemplate(restored.ID, restored.ConfigPath)
+		return restored, nil
+	case control.TrashKindSchedule:
+		var sc control.Schedule
+		if err := json.Unmarshal(item.Entity, &sc); err != nil {
+			return nil, err
+		}
+		if _, ok := s.scheduler.Get(sc.ID); ok {
+			return nil, conflict
+		}
+		return s.scheduler.Restore(sc)
+	case control.TrashKindRule:
+		var rule control.Rule
+		if err := json.Unmarshal(item.Entity, &rule); err != nil {
+			return nil, err
+		}
+		if _, err := s.rules.Get(rule.ID); err == nil {
+			return nil, conflict
+		}
+		return s.rules.Restore(rule)
+	default:
+		return nil, errors.New("unsupported trash kind " + item.Kind)
+	}
+}
+
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.trash.PurgeExpired(time.Now().UTC())
+	items := s.trash.List(r.URL.Query().Get("kind"))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":  items,
+		"count":  len(items),
+		"policy": s.trash.Policy(),
+	})
+}
+
+func (s *Server) handleTrashPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.trash.Policy())
+	case http.MethodPost:
+		var req control.TrashPolicy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		policy, err := s.trash.SetPolicy(req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, policy)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTrashPurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		All  bool   `json:"all"`
+		Kind string `json:"kind"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	purged := s.trash.PurgeExpired(time.Now().UTC())
+	if req.All || strings.TrimSpace(req.Kind) != "" {
+		purged = append(purged, s.trash.PurgeMatching(req.Kind)...)
+	}
+	s.recordEvent(control.Event{
+		Type:    "trash.purged",
+		Message: "trash purged",
+		Fields:  map[string]any{"count": len(purged), "kind": req.Kind, "all": req.All},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]any{"purged": purged, "count": len(purged)})
+}
+
+func (s *Server) handleTrashAction(w http.ResponseWriter, r *http.Request) {
+	// /v1/trash/{id}[/restore]
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 3 || strings.TrimSpace(parts[2]) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "trash item id is required"})
+		return
+	}
+	id := parts[2]
+	if len(parts) == 3 {
+		switch r.Method {
+		case http.MethodGet:
+			item, err := s.trash.Get(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, item)
+		case http.MethodDelete:
+			if err := s.trash.Purge(id); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "purged"})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if len(parts) != 4 || parts[3] != "restore" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown trash action"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, err := s.trash.Get(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	restored, err := s.restoreTrashItem(item)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	if _, err := s.trash.Take(id); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "trash.entity.restored",
+		Message: item.Kind + " restored from trash",
+		Fields: map[string]any{
+			"trash_id":  item.ID,
+			"kind":      item.Kind,
+			"entity_id": item.EntityID,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, map[string]any{"kind": item.Kind, "entity": restored})
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+func TestTrashSoftDeleteRestoreAndPurge(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\ninventory:\n  hosts:\n    - name: localhost\n      transport: local\nresources: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Principal", "alice")
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	created := func(path, body string) string {
+		rr := do(http.MethodPost, path, body)
+		if rr.Code >= 300 {
+			t.Fatalf("create %s failed: code=%d body=%s", path, rr.Code, rr.Body.String())
+		}
+		var out struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &out)
+		return out.ID
+	}
+	viewID := created("/v1/views", `{"name":"Failed runs","entity":"runs","query":"status=failed"}`)
+	templateID := created("/v1/templates", `{"name":"web","config_path":"c.yaml"}`)
+	scheduleID := created("/v1/schedules", `{"config_path":"c.yaml","interval_seconds":3600}`)
+	ruleID := created("/v1/rules", `{"name":"on-drift","source_prefix":"drift.","actions":[{"type":"enqueue_apply","config_path":"c.yaml"}]}`)
+
+	trashIDs := map[string]string{}
+	for kind, path := range map[string]string{
+		"view":     "/v1/views/" + viewID,
+		"template": "/v1/templates/" + templateID + "/delete",
+		"schedule": "/v1/schedules/" + scheduleID,
+		"rule":     "/v1/rules/" + ruleID,
+	} {
+		rr := do(http.MethodDelete, path, "")
+		var out map[string]string
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || rr.Code != http.StatusOK || out["trash_id"] == "" {
+			t.Fatalf("delete %s failed: code=%d body=%s", kind, rr.Code, rr.Body.String())
+		}
+		trashIDs[kind] = out["trash_id"]
+	}
+	if _, ok := s.scheduler.Get(scheduleID); ok {
+		t.Fatalf("expected schedule to be removed")
+	}
+
+	rr := do(http.MethodGet, "/v1/trash", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":4`) || !strings.Contains(rr.Body.String(), `"deleted_by":"alice"`) {
+		t.Fatalf("unexpected trash listing: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v1/trash?kind=template", "")
+	if !strings.Contains(rr.Body.String(), `"count":1`) || !strings.Contains(rr.Body.String(), `"entity_id":"`+templateID+`"`) {
+		t.Fatalf("expected kind filter: %s", rr.Body.String())
+	}
+
+	for _, kind := range []string{"view", "template", "schedule"} {
+		if rr := do(http.MethodPost, "/v1/trash/"+trashIDs[kind]+"/restore", ""); rr.Code != http.StatusOK {
+			t.Fatalf("restore %s failed: code=%d body=%s", kind, rr.Code, rr.Body.String())
+		}
+	}
+	if _, err := s.views.Get(viewID); err != nil {
+		t.Fatalf("expected view restored: %v", err)
+	}
+	if _, ok := s.templates.Get(templateID); !ok {
+		t.Fatalf("expected template restored")
+	}
+	if sc, ok := s.scheduler.Get(scheduleID); !ok || !sc.Enabled {
+		t.Fatalf("expected enabled schedule restored: %+v", sc)
+	}
+	if next := created("/v1/views", `{"name":"Another","entity":"runs"}`); next == viewID {
+		t.Fatalf("expected restored view id to stay reserved")
+	}
+
+	// An entity recreated under the same ID blocks the restore.
+	if _, err := s.rules.Restore(control.Rule{ID: ruleID, Name: "imported", SourcePrefix: "drift.", Actions: []control.RuleAction{{Type: "enqueue_apply", ConfigPath: "c.yaml"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if rr := do(http.MethodPost, "/v1/trash/"+trashIDs["rule"]+"/restore", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected restore conflict: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodPost, "/v1/trash/policy", `{"retention_hours":0,"max_items":10}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid policy rejection: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/trash/policy", `{"retention_hours":24,"max_items":50}`); rr.Code != http.StatusOK {
+		t.Fatalf("policy update failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodPost, "/v1/trash/purge", `{"all":true}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":1`) {
+		t.Fatalf("purge failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/trash/"+trashIDs["rule"]+"/restore", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected purged item to be gone: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"net/http"
 	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
 )
 
 func (s *Server) handleUIShortcuts(w http.ResponseWriter, r *http.Request) {
@@ -10,30 +12,37 @@ func (s *Server) handleUIShortcuts(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	profile, ok := s.requestAccessibilityProfile(w, r)
+	if !ok {
+		return
+	}
+	hints := profile.VariantHints()
 	query := strings.TrimSpace(r.URL.Query().Get("q"))
 	items := s.shortcuts.Search(query)
 	includeGlobalOnly := strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("global_only")), "true")
 	if includeGlobalOnly {
-		filtered := make([]any, 0, len(items))
+		filtered := make([]control.UIShortcut, 0, len(items))
 		for _, item := range items {
 			if item.Global {
 				filtered = append(filtered, item)
 			}
 		}
 		writeJSON(w, http.StatusOK, map[string]any{
-			"items":          filtered,
+			"items":          control.AdaptShortcuts(filtered, hints),
 			"count":          len(filtered),
 			"query":          query,
 			"global_only":    true,
-			"active_profile": s.accessibility.ActiveProfile(),
+			"active_profile": profile,
+			"variant":        hints,
 		})
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
-		"items":          items,
+		"items":          control.AdaptShortcuts(items, hints),
 		"count":          len(items),
 		"query":          query,
 		"global_only":    false,
-		"active_profile": s.accessibility.ActiveProfile(),
+		"active_profile": profile,
+		"variant":        hints,
 	})
 }
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/control"
+)
+
+type upgradeDrillLaunchSpec struct {
+	Binary  string
+	Addr    string
+	BaseDir string
+	// SeedPath is a control-plane snapshot the shadow imports at startup.
+	SeedPath string
+}
+
+// upgradeDrillLauncher starts a shadow server and returns its base URL and
+// a stop function. Tests swap in an in-process launcher.
+type upgradeDrillLauncher func(ctx context.Context, spec upgradeDrillLaunchSpec) (string, func(), error)
+
+// Read paths whose responses depend on per-process activity rather than
+// shared state, so they never match between primary and shadow.
+var upgradeDrillExcludedPrefixes = []string{
+	"/v1/events",
+	"/v1/activity",
+	"/v1/metrics",
+	"/metrics",
+	"/v1/control/upgrade-orchestration",
+	// Schedules are left out of the shadow's seed so it never runs jobs.
+	"/v1/schedules",
+}
+
+func (s *Server) handleUpgradeDrills(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.upgradeOrchestration.ListDrills(r.URL.Query().Get("plan_id")))
+}
+
+func (s *Server) handleUpgradeDrillAction(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	// /v1/control/upgrade-orchestration/drills/{id}
+	if len(parts) != 5 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "upgrade-orchestration" || parts[3] != "drills" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	item, ok := s.upgradeOrchestration.GetDrill(parts[4])
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "upgrade drill not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) handleUpgradeDrillRun(w http.ResponseWriter, r *http.Request, planID string) {
+	if !s.requireControlAdmin(w, r) {
+		return
+	}
+	var req control.UpgradeDrillInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	binary, err := upgradeDrillBinary(req.Binary)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Binary = binary
+	plan, ok := s.upgradeOrchestration.GetPlan(planID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "upgrade plan not found"})
+		return
+	}
+	if plan.Component != "control-plane" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "upgrade drills apply to control-plane plans"})
+		return
+	}
+	card, err := s.upgradeOrchestration.RecordDrill(s.runUpgradeDrill(r.Context(), plan.ID, req))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	s.recordEvent(control.Event{
+		Type:    "control.upgrade.orchestration.drill.completed",
+		Message: "blue/green upgrade drill completed",
+		Fields: map[string]any{
+			"plan_id":             card.PlanID,
+			"drill_id":            card.ID,
+			"verdict":             card.Verdict,
+			"compatibility_score": card.CompatibilityScore,
+			"sampled":             card.Sampled,
+		},
+	}, true)
+	writeJSON(w, http.StatusOK, card)
+}
+
+// runUpgradeDrill starts the candidate binary against a copy of baseDir,
+// seeds it with the live control-plane snapshot minus schedules, and
+// replays read traffic against both servers. in.Binary must already have
+// been checked by upgradeDrillBinary.
+func (s *Server) runUpgradeDrill(ctx context.Context, planID string, in control.UpgradeDrillInput) control.UpgradeDrillScorecard {
+	card := control.UpgradeDrillScorecard{
+		PlanID:      planID,
+		MinScore:    in.MinScore,
+		StartedAt:   time.Now().UTC(),
+		Comparisons: []control.UpgradeDrillComparison{},
+	}
+	fail := func(err error) control.UpgradeDrillScorecard {
+		card.Status = "failed"
+		card.Error = err.Error()
+		card.CompletedAt = time.Now().UTC()
+		return card
+	}
+	timeout := time.Duration(in.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	card.Binary = in.Binary
+	snapshotDir, err := os.MkdirTemp("", "masterchef-upgrade-drill-")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(snapshotDir)
+	if err := copyUpgradeDrillTree(s.baseDir, snapshotDir); err != nil {
+		return fail(errors.New("snapshot base dir: " + err.Error()))
+	}
+	snap, err := s.buildBackupSnapshot(s.baseDir, false, false, true)
+	if err != nil {
+		return fail(err)
+	}
+	snap.ControlPlane.Schedules = nil
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return fail(err)
+	}
+	// The seed lives outside the shadow's base dir so the shadow cannot
+	// serve it back.
+	seedPath := snapshotDir + "-seed.json"
+	if err := os.WriteFile(seedPath, payload, 0o600); err != nil {
+		return fail(err)
+	}
+	defer os.Remove(seedPath)
+	addr, err := upgradeDrillAddr(in.Port)
+	if err != nil {
+		return fail(err)
+	}
+	card.ShadowAddr = addr
+	baseURL, stop, err := s.upgradeDrillLaunch(ctx, upgradeDrillLaunchSpec{Binary: card.Binary, Addr: addr, BaseDir: snapshotDir, SeedPath: seedPath})
+	if err != nil {
+		return fail(errors.New("start shadow server: " + err.Error()))
+	}
+	defer stop()
+
+	paths := in.Paths
+	if len(paths) == 0 {
+		paths = s.sampleUpgradeDrillPaths(in.SampleSize)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, path := range paths {
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		shadowStatus, shadowBody, err := upgradeDrillRequest(ctx, client, http.MethodGet, baseURL+path, nil)
+		if err != nil {
+			card.Comparisons = append(card.Comparisons, control.UpgradeDrillComparison{
+				Path:          path,
+				PrimaryStatus: rr.Code,
+				Error:         err.Error(),
+			})
+			continue
+		}
+		card.Comparisons = append(card.Comparisons, control.CompareUpgradeDrillResponses(path, rr.Code, rr.Body.Bytes(), shadowStatus, shadowBody, in.IgnoreFields))
+	}
+	card.Status = "completed"
+	card.CompletedAt = time.Now().UTC()
+	return card
+}
+
+// upgradeDrillBinary resolves the candidate binary for a drill. Without a
+// request it is the running executable; any other binary must be listed
+// in MC_UPGRADE_DRILL_BINARIES (comma separated absolute paths).
+func upgradeDrillBinary(requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		return os.Executable()
+	}
+	for _, allowed := range strings.Split(os.Getenv("MC_UPGRADE_DRILL_BINARIES"), ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed != "" && filepath.IsAbs(allowed) && filepath.Clean(allowed) == filepath.Clean(requested) {
+			return filepath.Clean(allowed), nil
+		}
+	}
+	return "", errors.New("binary is not listed in MC_UPGRADE_DRILL_BINARIES")
+}
+
+// importControlSnapshotFile seeds the control plane from a snapshot file.
+// Drill shadows are seeded this way rather than through the import
+// endpoint, which needs an admin the fresh shadow does not have.
+func (s *Server) importControlSnapshotFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var snap backupSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil || snap.ControlPlane == nil {
+		return errInvalidBackupSnapshotPayload
+	}
+	_, err = s.applyBackupSnapshot(s.baseDir, snap)
+	return err
+}
+
+// sampleUpgradeDrillPaths picks the most recent distinct GET paths from the
+// request log.
+func (s *Server) sampleUpgradeDrillPaths(limit int) []string {
+	if limit <= 0 {
+		limit = 20
+	}
+	events := s.events.List()
+	seen := map[string]struct{}{}
+	out := make([]string, 0, limit)
+	for i := len(events) - 1; i >= 0 && len(out) < limit; i-- {
+		e := events[i]
+		if e.Type != "http.request" || e.Fields["method"] != http.MethodGet {
+			continue
+		}
+		path, _ := e.Fields["path"].(string)
+		if path == "" || upgradeDrillExcluded(path) {
+			continue
+		}
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		out = append(out, path)
+	}
+	return out
+}
+
+func upgradeDrillExcluded(path string) bool {
+	for _, prefix := range upgradeDrillExcludedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func upgradeDrillRequest(ctx context.Context, client *http.Client, method, url string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
+
+func upgradeDrillAddr(port int) (string, error) {
+	if port > 0 {
+		return "127.0.0.1:" + strconv.Itoa(port), nil
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr, nil
+}
+
+// copyUpgradeDrillTree copies regular files under src into dst, skipping
+// VCS metadata.
+func copyUpgradeDrillTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" && rel != "." {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, info.Mode().Perm())
+	})
+}
+
+// launchShadowServerProcess runs "<binary> serve -addr <addr>" in the
+// snapshot directory, seeded through MC_CONTROL_SNAPSHOT_SEED, and waits
+// for /healthz.
+func launchShadowServerProcess(ctx context.Context, spec upgradeDrillLaunchSpec) (string, func(), error) {
+	cmd := exec.Command(spec.Binary, "serve", "-addr", spec.Addr)
+	cmd.Dir = spec.BaseDir
+	cmd.Env = make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		// The shadow must not write back to the primary's config-as-code dir.
+		if strings.HasPrefix(kv, "MC_CONFIG_AS_CODE_") {
+			continue
+		}
+		cmd.Env = append(cmd.Env, kv)
+	}
+	cmd.Env = append(cmd.Env, "MC_CONTROL_SNAPSHOT_SEED="+spec.SeedPath)
+	if err := cmd.Start(); err != nil {
+		return "", nil, err
+	}
+	stop := func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+	baseURL := "http://" + spec.Addr
+	client := &http.Client{Timeout: time.Second}
+	for {
+		if status, _, err := upgradeDrillRequest(ctx, client, http.MethodGet, baseURL+"/healthz", nil); err == nil && status == http.StatusOK {
+			return baseURL, stop, nil
+		}
+		select {
+		case <-ctx.Done():
+			stop()
+			return "", nil, errors.New("shadow server did not become healthy: " + ctx.Err().Error())
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
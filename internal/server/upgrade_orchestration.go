@@ -44,7 +44,7 @@ func (s *Server) handleUpgradeOrchestrationPlans(w http.ResponseWriter, r *http.
 
 func (s *Server) handleUpgradeOrchestrationPlanAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/control/upgrade-orchestration/plans/{id}[/advance|abort]
+	// /v1/control/upgrade-orchestration/plans/{id}[/advance|abort|drill]
 	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "control" || parts[2] != "upgrade-orchestration" || parts[3] != "plans" {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -69,6 +69,8 @@ func (s *Server) handleUpgradeOrchestrationPlanAction(w http.ResponseWriter, r *
 		return
 	}
 	switch action {
+	case "drill":
+		s.handleUpgradeDrillRun(w, r, id)
 	case "advance":
 		var req control.UpgradeOrchestrationAdvanceInput
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -59,3 +59,124 @@ func TestUpgradeOrchestrationEndpoints(t *testing.T) {
 		t.Fatalf("expected blocked wave conflict response: code=%d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestUpgradeOrchestrationDrill(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "c.yaml"), []byte("version: v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	var shadowDirs []string
+	var shadowSchedules int
+	s.upgradeDrillLaunch = func(_ context.Context, spec upgradeDrillLaunchSpec) (string, func(), error) {
+		shadowDirs = append(shadowDirs, spec.BaseDir)
+		shadow := New(":0", spec.BaseDir)
+		if err := shadow.importControlSnapshotFile(spec.SeedPath); err != nil {
+			_ = shadow.Shutdown(context.Background())
+			return "", nil, err
+		}
+		shadowSchedules = len(shadow.scheduler.List())
+		ts := httptest.NewServer(shadow.httpServer.Handler)
+		return ts.URL, func() {
+			ts.Close()
+			_ = shadow.Shutdown(context.Background())
+		}, nil
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/templates", bytes.NewReader([]byte(`{"name":"base","config_path":"c.yaml"}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create template failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/templates", nil))
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/schedules", bytes.NewReader([]byte(`{"config_path":"c.yaml","interval_seconds":3600}`)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create schedule failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	planBody := []byte(`{"component":"control-plane","from_channel":"stable","to_channel":"candidate","total_nodes":2}`)
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/control/upgrade-orchestration/plans", bytes.NewReader(planBody))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create upgrade plan failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var plan struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &plan)
+
+	drill := func(principal, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/control/upgrade-orchestration/plans/"+plan.ID+"/drill", bytes.NewReader([]byte(body)))
+		if principal != "" {
+			req.Header.Set("X-Masterchef-Principal", principal)
+		}
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	candidate := filepath.Join(tmp, "masterchef-candidate")
+	t.Setenv("MC_UPGRADE_DRILL_BINARIES", candidate)
+	if rr := drill("", `{"binary":"`+candidate+`"}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected drill to require a principal: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	grantControlAdmin(t, s, "release-admin")
+	if rr := drill("release-admin", `{"binary":"/bin/sh"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected unlisted binary to be rejected: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = drill("release-admin", `{"binary":"`+candidate+`"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upgrade drill failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var card struct {
+		ID          string `json:"id"`
+		Status      string `json:"status"`
+		Verdict     string `json:"verdict"`
+		Sampled     int    `json:"sampled"`
+		Comparisons []struct {
+			Path        string   `json:"path"`
+			Match       bool     `json:"match"`
+			Differences []string `json:"differences"`
+		} `json:"comparisons"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &card)
+	if card.Status != "completed" || card.Verdict != "compatible" || card.Sampled == 0 {
+		t.Fatalf("unexpected drill scorecard: %s", rr.Body.String())
+	}
+	found := false
+	for _, cmp := range card.Comparisons {
+		if cmp.Path == "/v1/templates" {
+			found = cmp.Match
+		}
+	}
+	if !found {
+		t.Fatalf("expected sampled /v1/templates to match on shadow: %s", rr.Body.String())
+	}
+	if shadowSchedules != 0 {
+		t.Fatalf("expected schedules to be left out of the shadow seed, got %d", shadowSchedules)
+	}
+	if len(shadowDirs) != 1 || shadowDirs[0] == tmp {
+		t.Fatalf("expected shadow to run against a snapshot dir: %v", shadowDirs)
+	}
+	if _, err := os.Stat(shadowDirs[0]); !os.IsNotExist(err) {
+		t.Fatalf("expected snapshot dir to be removed after drill")
+	}
+
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/upgrade-orchestration/drills/"+card.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get drill failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/control/upgrade-orchestration/plans/"+plan.ID, nil))
+	if !bytes.Contains(rr.Body.Bytes(), []byte(`"last_drill_verdict":"compatible"`)) {
+		t.Fatalf("expected plan to carry drill verdict: %s", rr.Body.String())
+	}
+}
@@ -12,6 +12,7 @@ func (s *Server) handleVariableSourceResolve(w http.ResponseWriter, r *http.Requ
 		Sources            []control.VariableSourceSpec `json:"sources"`
 		Layers             []control.VariableLayer      `json:"layers"`
 		HardFail           bool                         `json:"hard_fail"`
+		Environment        string                       `json:"environment,omitempty"`
 		IncludeRole        string                       `json:"include_role,omitempty"`
 		IncludeEnvironment string                       `json:"include_environment,omitempty"`
 		IncludeDataBags    []string                     `json:"include_data_bags,omitempty"`
@@ -38,7 +39,11 @@ func (s *Server) handleVariableSourceResolve(w http.ResponseWriter, r *http.Requ
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
-	sourceLayers, err := s.varSources.ResolveLayers(r.Context(), req.Sources)
+	environment := req.Environment
+	if environment == "" {
+		environment = req.IncludeEnvironment
+	}
+	sourceLayers, sourceStatus, err := s.varSources.ResolveSources(r.Context(), req.Sources, environment)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
@@ -51,8 +56,9 @@ func (s *Server) handleVariableSourceResolve(w http.ResponseWriter, r *http.Requ
 	})
 	if err != nil {
 		writeJSON(w, http.StatusConflict, map[string]any{
-			"error":  err.Error(),
-			"result": result,
+			"error":   err.Error(),
+			"result":  result,
+			"sources": sourceStatus,
 		})
 		return
 	}
@@ -61,5 +67,7 @@ func (s *Server) handleVariableSourceResolve(w http.ResponseWriter, r *http.Requ
 		"result":        result,
 		"resolved_from": len(sourceLayers),
 		"total_layers":  len(layers),
+		"sources":       sourceStatus,
+		"winners":       control.VariableWinners(result),
 	})
 }
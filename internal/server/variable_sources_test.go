@@ -65,4 +65,27 @@ func TestVariableSourceResolveEndpoint(t *testing.T) {
 	if !strings.Contains(resp, `"region":"us-east-1"`) {
 		t.Fatalf("expected env source value in merged output: %s", resp)
 	}
+	if !strings.Contains(resp, `"service.replicas":"http"`) || !strings.Contains(resp, `"service.name":"file"`) || !strings.Contains(resp, `"status":"fetched"`) {
+		t.Fatalf("expected per-key winners and source status: %s", resp)
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmp, "vars"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "vars", "prod.env"), []byte("REPLICAS=9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	body = `{
+		"environment":"prod",
+		"sources":[
+			{"name":"http","type":"http","config":{"url":"` + httpSource.URL + `"}},
+			{"name":"dotenv","type":"env_file","ttl_seconds":30,"config":{"target":"service"}}
+		]
+	}`
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/vars/sources/resolve", bytes.NewReader([]byte(body)))
+	s.httpServer.Handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"service.replicas":"dotenv"`) || !strings.Contains(rr.Body.String(), `"environment":"prod"`) {
+		t.Fatalf("expected env file for prod to win replicas: code=%d body=%s", rr.Code, rr.Body.String())
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/masterchef/masterchef/internal/control"
 )
@@ -38,7 +39,7 @@ func (s *Server) handleWorkflowWizards(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleWorkflowWizardAction(w http.ResponseWriter, r *http.Request) {
 	parts := splitPath(r.URL.Path)
-	// /v1/wizards/{id}[/launch]
+	// /v1/wizards/{id}[/launch|sessions]
 	if len(parts) < 3 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid wizard path"})
 		return
@@ -90,5 +91,201 @@ func (s *Server) handleWorkflowWizardAction(w http.ResponseWriter, r *http.Reque
 		writeJSON(w, code, result)
 		return
 	}
+	if len(parts) == 4 && parts[3] == "sessions" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Owner  string            `json:"owner"`
+			Inputs map[string]string `json:"inputs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		if req.Owner == "" {
+			req.Owner, _ = requestIdentity(r)
+		}
+		item, err := s.wizardSessions.Start(id, req.Owner, req.Inputs)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, item)
+		return
+	}
 	writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid wizard action"})
 }
+
+func (s *Server) handleWorkflowWizardSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	items := s.wizardSessions.List(r.URL.Query().Get("owner"), r.URL.Query().Get("status"))
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "count": len(items)})
+}
+
+func (s *Server) handleWorkflowWizardSessionAction(w http.ResponseWriter, r *http.Request) {
+	// /v1/wizards/sessions/{id}[/steps/{step_id}|back|materialize]
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 4 || strings.TrimSpace(parts[3]) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "wizard session id is required"})
+		return
+	}
+	id := parts[3]
+	if len(parts) == 4 {
+		switch r.Method {
+		case http.MethodGet:
+			item, err := s.wizardSessions.Get(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			wizard, _ := s.wizards.Get(item.WizardID)
+			var current *control.WorkflowWizardStep
+			for i := range wizard.Steps {
+				if wizard.Steps[i].ID == item.CurrentStepID {
+					current = &wizard.Steps[i]
+				}
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"session":      item,
+				"current_step": current,
+				"total_steps":  len(wizard.Steps),
+			})
+		case http.MethodDelete:
+			if err := s.wizardSessions.Delete(id); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case parts[4] == "steps" && len(parts) == 6:
+		var req struct {
+			Inputs map[string]string `json:"inputs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+			return
+		}
+		result, err := s.wizardSessions.SubmitStep(id, parts[5], req.Inputs)
+		if err != nil {
+			code := http.StatusConflict
+			if strings.Contains(err.Error(), "not found") {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		code := http.StatusOK
+		if !result.Valid {
+			code = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, code, result)
+	case parts[4] == "back" && len(parts) == 5:
+		item, err := s.wizardSessions.Back(id)
+		if err != nil {
+			code := http.StatusConflict
+			if strings.Contains(err.Error(), "not found") {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case parts[4] == "materialize" && len(parts) == 5:
+		item, err := s.materializeWizardSession(id)
+		if err != nil {
+			code := http.StatusConflict
+			if strings.Contains(err.Error(), "not found") {
+				code = http.StatusNotFound
+			}
+			writeJSON(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, item)
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid wizard session action"})
+	}
+}
+
+// materializeWizardSession creates the templates, schedules, and runbooks a
+// ready session describes. Runbooks target the template created by the same
+// session when there is one, and the config otherwise.
+func (s *Server) materializeWizardSession(id string) (control.WorkflowWizardSession, error) {
+	session, err := s.wizardSessions.Get(id)
+	if err != nil {
+		return control.WorkflowWizardSession{}, err
+	}
+	plan, err := s.wizardSessions.MaterializationPlan(id)
+	if err != nil {
+		return control.WorkflowWizardSession{}, err
+	}
+	description := "Created by " + session.WizardID + " wizard session " + session.ID
+	artifacts := make([]control.WorkflowWizardArtifact, 0, len(plan))
+	templateID := ""
+	for _, item := range plan {
+		switch item.Kind {
+		case "template":
+			t := s.templates.Create(control.Template{
+				Name:        item.Name,
+				Description: description,
+				ConfigPath:  item.ConfigPath,
+			})
+			_ = s.objectModel.RegisterTemplate(t.ID, t.ConfigPath)
+			templateID = t.ID
+			artifacts = append(artifacts, control.WorkflowWizardArtifact{Kind: item.Kind, ID: t.ID, Name: t.Name})
+		case "schedule":
+			sc := s.scheduler.CreateWithOptions(control.ScheduleOptions{
+				ConfigPath:  item.ConfigPath,
+				Environment: item.Environment,
+				Interval:    time.Duration(item.IntervalSeconds) * time.Second,
+			})
+			artifacts = append(artifacts, control.WorkflowWizardArtifact{Kind: item.Kind, ID: sc.ID, Name: item.Name})
+		case "runbook":
+			in := control.Runbook{
+				Name:        item.Name,
+				Description: description,
+				TargetType:  control.RunbookTargetConfig,
+				ConfigPath:  item.ConfigPath,
+				RiskLevel:   item.RiskLevel,
+				Owner:       session.Owner,
+				Tags:        []string{"wizard:" + session.WizardID},
+			}
+			if templateID != "" {
+				in.TargetType, in.TargetID, in.ConfigPath = control.RunbookTargetTemplate, templateID, ""
+			}
+			rb, err := s.runbooks.Create(in)
+			if err != nil {
+				return control.WorkflowWizardSession{}, err
+			}
+			artifacts = append(artifacts, control.WorkflowWizardArtifact{Kind: item.Kind, ID: rb.ID, Name: rb.Name})
+		}
+	}
+	out, err := s.wizardSessions.Complete(id, artifacts)
+	if err != nil {
+		return control.WorkflowWizardSession{}, err
+	}
+	s.recordEvent(control.Event{
+		Type:    "wizard.session.materialized",
+		Message: "workflow wizard session materialized",
+		Fields: map[string]any{
+			"session_id": out.ID,
+			"wizard_id":  out.WizardID,
+			"owner":      out.Owner,
+			"artifacts":  len(out.Artifacts),
+		},
+	}, true)
+	return out, nil
+}
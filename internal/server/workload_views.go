@@ -44,12 +44,14 @@ func (s *Server) handleWorkloadViews(w http.ResponseWriter, r *http.Request) {
 			since = parsed
 		}
 	}
-	items := s.computeWorkloadViews(limit, since)
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items": items,
-		"count": len(items),
-		"since": since,
-		"limit": limit,
+	s.serveCachedJSON(w, r, aggregateResponseCacheTTL, []string{responseCacheTagWorkloads}, func() (int, any) {
+		items := s.computeWorkloadViews(limit, since)
+		return http.StatusOK, map[string]any{
+			"items": items,
+			"count": len(items),
+			"since": since,
+			"limit": limit,
+		}
 	})
 }
 
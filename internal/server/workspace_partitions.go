@@ -0,0 +1,363 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/masterchef/masterchef/internal/control"
+	"github.com/masterchef/masterchef/internal/state"
+	"github.com/masterchef/masterchef/internal/storage"
+)
+
+// workspacePartition is one tenant workspace's slice of server storage.
+type workspacePartition struct {
+	tenant    string
+	workspace string
+	baseDir   string
+	objects   *storage.ScopedObjectStore
+}
+
+func (s *Server) workspacePartition(tenant, workspace string) (workspacePartition, error) {
+	prefix, err := storage.WorkspacePrefix(tenant, workspace)
+	if err != nil {
+		return workspacePartition{}, err
+	}
+	baseDir, err := storage.WorkspaceBaseDir(s.baseDir, tenant, workspace)
+	if err != nil {
+		return workspacePartition{}, err
+	}
+	out := workspacePartition{
+		tenant:    strings.ToLower(strings.TrimSpace(tenant)),
+		workspace: strings.ToLower(strings.TrimSpace(workspace)),
+		baseDir:   baseDir,
+	}
+	if s.objectStore != nil {
+		objects, err := storage.NewScopedObjectStore(s.objectStore, prefix)
+		if err != nil {
+			return workspacePartition{}, err
+		}
+		out.objects = objects
+	}
+	return out, nil
+}
+
+// authorizeWorkspaceAccess enforces workspace boundaries for the partition
+// API. Callers identify their own workspace with X-Masterchef-Tenant and
+// X-Masterchef-Workspace; anything else is denied unless the caller's
+// isolation policy allows reading sibling workspaces of the same tenant.
+// Writes never cross workspaces.
+func (s *Server) authorizeWorkspaceAccess(r *http.Request, target workspacePartition, write bool) error {
+	_, tenant := requestIdentity(r)
+	tenant = strings.ToLower(tenant)
+	workspace := strings.ToLower(strings.TrimSpace(r.Header.Get("X-Masterchef-Workspace")))
+	switch {
+	case tenant == "" || workspace == "":
+		return errors.New("workspace identity is required (X-Masterchef-Tenant and X-Masterchef-Workspace)")
+	case tenant != target.tenant:
+		return errors.New("cross-tenant access denied")
+	case workspace == target.workspace:
+		return nil
+	case write:
+		return errors.New("cross-workspace writes are denied")
+	case s.workspaceIsolation.AllowsCrossWorkspaceRead(tenant, workspace):
+		return nil
+	default:
+		return errors.New("cross-workspace access denied by default")
+	}
+}
+
+// handleWorkspacePartitions serves workspace-scoped data:
+//
+//	GET    /v1/workspaces/{tenant}/{workspace}/objects         list (prefix, limit)
+//	GET    /v1/workspaces/{tenant}/{workspace}/objects/{key}   read an object
+//	PUT    /v1/workspaces/{tenant}/{workspace}/objects/{key}   write an object
+//	DELETE /v1/workspaces/{tenant}/{workspace}/objects/{key}   delete an object
+//	GET    /v1/workspaces/{tenant}/{workspace}/runs[/{id}]     run history
+//	GET    /v1/workspaces/{tenant}/{workspace}/sessions[/{id}] session recordings
+func (s *Server) handleWorkspacePartitions(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 5 || parts[0] != "v1" || parts[1] != "workspaces" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	part, err := s.workspacePartition(parts[2], parts[3])
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if err := s.authorizeWorkspaceAccess(r, part, write); err != nil {
+		_, callerTenant := requestIdentity(r)
+		s.recordEvent(control.Event{
+			Type:    "control.workspace_isolation.access_denied",
+			Message: "workspace partition access denied",
+			Fields: map[string]any{
+				"tenant":           part.tenant,
+				"workspace":        part.workspace,
+				"caller_tenant":    callerTenant,
+				"caller_workspace": r.Header.Get("X-Masterchef-Workspace"),
+				"method":           r.Method,
+				"reason":           err.Error(),
+			},
+		}, true)
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+		return
+	}
+
+	limit := 100
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	switch parts[4] {
+	case "objects":
+		if part.objects == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "object store unavailable"})
+			return
+		}
+		if len(parts) == 5 {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			items, err := part.objects.List(r.URL.Query().Get("prefix"), limit)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"prefix": part.objects.Prefix(), "count": len(items), "items": items})
+			return
+		}
+		key := strings.Join(parts[5:], "/")
+		switch r.Method {
+		case http.MethodGet:
+			data, info, err := part.objects.Get(key)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "object not found"})
+				return
+			}
+			w.Header().Set("Content-Type", info.ContentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+				return
+			}
+			info, err := part.objects.Put(key, data, r.Header.Get("Content-Type"))
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, info)
+		case http.MethodDelete:
+			if err := part.objects.Delete(key); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "object not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "deleted", "key": key})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case "runs":
+		if r.Method != http.MethodGet || len(parts) > 6 {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		st := state.New(part.baseDir)
+		if len(parts) == 6 {
+			run, err := st.GetRun(parts[5])
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "run not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, run)
+			return
+		}
+		runs, err := st.ListRuns(limit)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, runs)
+	case "sessions":
+		if r.Method != http.MethodGet || len(parts) > 6 {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		sessions := control.NewSessionRecordingStore(part.baseDir)
+		if len(parts) == 6 {
+			item, err := sessions.Get(parts[5])
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "session recording not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, item)
+			return
+		}
+		writeJSON(w, http.StatusOK, sessions.List(limit, r.URL.Query().Get("host"), r.URL.Query().Get("transport")))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+type workspaceMigrationRequest struct {
+	Tenant       string   `json:"tenant"`
+	Workspace    string   `json:"workspace"`
+	ObjectPrefix string   `json:"object_prefix,omitempty"`
+	RunIDs       []string `json:"run_ids,omitempty"`
+	RunSelector  string   `json:"run_selector,omitempty"`
+	SessionHosts []string `json:"session_hosts,omitempty"`
+	DryRun       bool     `json:"dry_run,omitempty"`
+}
+
+type workspaceMigrationResult struct {
+	Tenant    string   `json:"tenant"`
+	Workspace string   `json:"workspace"`
+	DryRun    bool     `json:"dry_run"`
+	Objects   []string `json:"objects"`
+	Runs      []string `json:"runs"`
+	Sessions  []string `json:"sessions"`
+}
+
+// handleWorkspaceMigrate moves existing shared data into a workspace
+// partition: objects under object_prefix, runs by id or label selector, and
+// session recordings by host. dry_run reports what would move.
+func (s *Server) handleWorkspaceMigrate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req workspaceMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	part, err := s.workspacePartition(req.Tenant, req.Workspace)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	out, err := s.migrateIntoWorkspace(part, req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !req.DryRun {
+		s.recordEvent(control.Event{
+			Type:    "control.workspace.migrated",
+			Message: "shared data migrated into workspace partition",
+			Fields: map[string]any{
+				"tenant":    part.tenant,
+				"workspace": part.workspace,
+				"objects":   len(out.Objects),
+				"runs":      len(out.Runs),
+				"sessions":  len(out.Sessions),
+			},
+		}, true)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) migrateIntoWorkspace(part workspacePartition, req workspaceMigrationRequest) (workspaceMigrationResult, error) {
+	out := workspaceMigrationResult{
+		Tenant:    part.tenant,
+		Workspace: part.workspace,
+		DryRun:    req.DryRun,
+		Objects:   []string{},
+		Runs:      []string{},
+		Sessions:  []string{},
+	}
+	if prefix := strings.TrimSpace(req.ObjectPrefix); prefix != "" {
+		if storage.IsWorkspaceKey(prefix) {
+			return out, errors.New("object_prefix must name shared objects, not a workspace partition")
+		}
+		if part.objects == nil {
+			return out, errors.New("object store unavailable")
+		}
+		items, err := s.objectStore.List(prefix, 100000)
+		if err != nil {
+			return out, err
+		}
+		for _, item := range items {
+			if storage.IsWorkspaceKey(item.Key) {
+				continue
+			}
+			out.Objects = append(out.Objects, item.Key)
+			if req.DryRun {
+				continue
+			}
+			data, info, err := s.objectStore.Get(item.Key)
+			if err != nil {
+				return out, err
+			}
+			if _, err := part.objects.Put(item.Key, data, info.ContentType); err != nil {
+				return out, err
+			}
+			if err := s.objectStore.Delete(item.Key); err != nil {
+				return out, err
+			}
+		}
+	}
+
+	if len(req.RunIDs) > 0 || strings.TrimSpace(req.RunSelector) != "" {
+		selector, err := control.ParseLabelSelector(req.RunSelector)
+		if err != nil {
+			return out, err
+		}
+		wanted := map[string]bool{}
+		for _, id := range req.RunIDs {
+			if id = strings.TrimSpace(id); id != "" {
+				wanted[id] = true
+			}
+		}
+		shared := state.New(s.baseDir)
+		runs, err := shared.ListRuns(0)
+		if err != nil {
+			return out, err
+		}
+		dst := state.New(part.baseDir)
+		for _, run := range runs {
+			if len(wanted) > 0 && !wanted[run.ID] {
+				continue
+			}
+			if strings.TrimSpace(req.RunSelector) != "" && !selector.Matches(run.Labels) {
+				continue
+			}
+			out.Runs = append(out.Runs, run.ID)
+			if req.DryRun {
+				continue
+			}
+			if err := dst.SaveRun(run); err != nil {
+				return out, err
+			}
+			if err := shared.DeleteRun(run.ID); err != nil {
+				return out, err
+			}
+		}
+		s.responseCache.Invalidate(responseCacheTagRuns)
+	}
+
+	if len(req.SessionHosts) > 0 {
+		dst := control.NewSessionRecordingStore(part.baseDir)
+		for _, host := range req.SessionHosts {
+			for _, item := range s.sessionRecordings.List(1_000_000, host, "") {
+				out.Sessions = append(out.Sessions, item.ID)
+				if req.DryRun {
+					continue
+				}
+				if err := s.sessionRecordings.MoveTo(item.ID, dst); err != nil && !os.IsNotExist(err) {
+					return out, err
+				}
+			}
+		}
+	}
+	return out, nil
+}
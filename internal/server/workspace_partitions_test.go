@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masterchef/masterchef/internal/state"
+)
+
+func TestWorkspacePartitionsEnforceBoundaries(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body, tenant, workspace string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if tenant != "" {
+			req.Header.Set("X-Masterchef-Tenant", tenant)
+		}
+		if workspace != "" {
+			req.Header.Set("X-Masterchef-Workspace", workspace)
+		}
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPut, "/v1/workspaces/acme/payments/objects/reports/r1.json", `{"ok":true}`, "acme", "payments"); rr.Code != http.StatusOK {
+		t.Fatalf("scoped put failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/objects/reports/r1.json", "", "acme", "payments"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"ok":true`) {
+		t.Fatalf("scoped get failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/objects", "", "", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected anonymous access denied, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/objects", "", "globex", "payments"); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "cross-tenant") {
+		t.Fatalf("expected cross-tenant access denied: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/objects", "", "acme", "search"); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected cross-workspace read denied by default, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/object-store/objects?prefix=workspaces/acme", "", "", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected shared listing of workspace prefix to be denied, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v1/object-store/objects", "", "", ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "workspaces/") {
+		t.Fatalf("expected shared listing to hide workspace objects: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	policy := `{"tenant":"acme","workspace":"search","environment":"prod","network_segment":"seg-a","compute_pool":"pool-a","allow_cross_workspace_read":true}`
+	if rr := do(http.MethodPost, "/v1/control/workspaces/isolation-policies", policy, "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("policy upsert failed: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/objects", "", "acme", "search"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"key":"reports/r1.json"`) {
+		t.Fatalf("expected policy-allowed cross-workspace read: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, "/v1/workspaces/acme/payments/objects/reports/r1.json", "", "acme", "search"); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected cross-workspace write denied, got %d", rr.Code)
+	}
+}
+
+func TestWorkspaceMigrateMovesSharedData(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(":0", tmp)
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-Masterchef-Tenant", "acme")
+		req.Header.Set("X-Masterchef-Workspace", "payments")
+		rr := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	shared := state.New(tmp)
+	for _, run := range []state.RunRecord{
+		{ID: "run-pay", StartedAt: time.Now().UTC(), Status: state.RunSucceeded, Labels: map[string]string{"team": "payments"}},
+		{ID: "run-other", StartedAt: time.Now().UTC(), Status: state.RunSucceeded, Labels: map[string]string{"team": "search"}},
+	} {
+		if err := shared.SaveRun(run); err != nil {
+			t.Fatalf("seed run: %v", err)
+		}
+	}
+	sessions := filepath.Join(tmp, ".masterchef", "sessions")
+	if err := os.MkdirAll(sessions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessions, "sess-1.json"), []byte(`{"timestamp":"2026-01-01T00:00:00Z","host":"pay-1","transport":"ssh","resource_id":"cmd-1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.objectStore.Put("exports/pay.json", []byte(`{"x":1}`), "application/json"); err != nil {
+		t.Fatalf("seed object: %v", err)
+	}
+
+	body := `{"tenant":"acme","workspace":"payments","object_prefix":"exports/","run_selector":"team=payments","session_hosts":["pay-1"]`
+	rr := do(http.MethodPost, "/v1/control/workspaces/migrate", body+`,"dry_run":true}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"runs":["run-pay"]`) {
+		t.Fatalf("dry run failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if _, err := shared.GetRun("run-pay"); err != nil {
+		t.Fatalf("dry run must not move data: %v", err)
+	}
+
+	rr = do(http.MethodPost, "/v1/control/workspaces/migrate", body+`}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"sessions":["sess-1"]`) || !strings.Contains(rr.Body.String(), `"objects":["exports/pay.json"]`) {
+		t.Fatalf("migrate failed: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if _, err := shared.GetRun("run-pay"); err == nil {
+		t.Fatalf("expected migrated run removed from shared state")
+	}
+	if _, err := shared.GetRun("run-other"); err != nil {
+		t.Fatalf("expected unselected run to stay shared: %v", err)
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/runs/run-pay", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected migrated run in workspace: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/sessions/sess-1", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected migrated session in workspace: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/workspaces/acme/payments/objects/exports/pay.json", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected migrated object in workspace: code=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/execution/session-recordings", ""); strings.Contains(rr.Body.String(), "sess-1") {
+		t.Fatalf("expected migrated session gone from shared recordings: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v1/control/workspaces/migrate", `{"tenant":"acme","workspace":"payments","object_prefix":"workspaces/acme"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected migrating from a partition to be rejected, got %d", rr.Code)
+	}
+}
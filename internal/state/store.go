@@ -30,11 +30,28 @@ type ResourceRun struct {
 }
 
 type RunRecord struct {
-	ID        string        `json:"id"`
-	StartedAt time.Time     `json:"started_at"`
-	EndedAt   time.Time     `json:"ended_at"`
-	Status    RunStatus     `json:"status"`
-	Results   []ResourceRun `json:"results"`
+	ID        string            `json:"id"`
+	StartedAt time.Time         `json:"started_at"`
+	EndedAt   time.Time         `json:"ended_at"`
+	Status    RunStatus         `json:"status"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Results   []ResourceRun     `json:"results"`
+	Artifacts []RunArtifact     `json:"artifacts,omitempty"`
+}
+
+// RunArtifact links a host-side output file uploaded after the run.
+// ResourceID is empty for run-level artifacts.
+type RunArtifact struct {
+	ResourceID string `json:"resource_id,omitempty"`
+	Host       string `json:"host"`
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	ObjectKey  string `json:"object_key,omitempty"`
+	SizeBytes  int64  `json:"size_bytes"`
+	SHA256     string `json:"sha256,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"`
+	Status     string `json:"status"` // collected, missing, failed
+	Error      string `json:"error,omitempty"`
 }
 
 func New(baseDir string) *Store {
@@ -120,3 +137,15 @@ func (s *Store) ReplaceRuns(runs []RunRecord) error {
 	}
 	return nil
 }
+
+// DeleteRun removes a run record. Missing records are not an error so
+// callers moving runs between stores can retry safely.
+func (s *Store) DeleteRun(id string) error {
+	if id == "" {
+		return fmt.Errorf("run id is required")
+	}
+	if err := os.Remove(filepath.Join(s.baseDir, "runs", id+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete run record: %w", err)
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ type ObjectStore interface {
 	Put(key string, data []byte, contentType string) (ObjectInfo, error)
 	Get(key string) ([]byte, ObjectInfo, error)
 	List(prefix string, limit int) ([]ObjectInfo, error)
+	Delete(key string) error
 }
 
 type LocalFSStore struct {
@@ -105,6 +106,14 @@ func (s *LocalFSStore) Get(key string) ([]byte, ObjectInfo, error) {
 	}, nil
 }
 
+func (s *LocalFSStore) Delete(key string) error {
+	_, path, err := s.resolvePath(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
 func (s *LocalFSStore) List(prefix string, limit int) ([]ObjectInfo, error) {
 	prefix = sanitizeKey(prefix)
 	if limit <= 0 {
@@ -35,4 +35,10 @@ func TestLocalFSStorePutGetList(t *testing.T) {
 	if len(items) != 1 {
 		t.Fatalf("expected one listed object, got %d", len(items))
 	}
+	if err := store.Delete("runs/run-1.json"); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if _, _, err := store.Get("runs/run-1.json"); err == nil {
+		t.Fatalf("expected deleted object to be gone")
+	}
 }
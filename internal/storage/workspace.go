@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// WorkspaceKeyRoot is the object-store prefix every workspace partition lives
+// under. Keys below it are only reachable through a ScopedObjectStore.
+const WorkspaceKeyRoot = "workspaces"
+
+var workspaceSegmentPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,62}$`)
+
+// WorkspacePrefix returns the partition prefix for a tenant's workspace, e.g.
+// "workspaces/acme/payments". Both segments are lowercased and must be plain
+// path-safe names.
+func WorkspacePrefix(tenant, workspace string) (string, error) {
+	tenant = strings.ToLower(strings.TrimSpace(tenant))
+	workspace = strings.ToLower(strings.TrimSpace(workspace))
+	if tenant == "" || workspace == "" {
+		return "", errors.New("tenant and workspace are required")
+	}
+	if !workspaceSegmentPattern.MatchString(tenant) || strings.Contains(tenant, "..") {
+		return "", errors.New("invalid tenant name")
+	}
+	if !workspaceSegmentPattern.MatchString(workspace) || strings.Contains(workspace, "..") {
+		return "", errors.New("invalid workspace name")
+	}
+	return WorkspaceKeyRoot + "/" + tenant + "/" + workspace, nil
+}
+
+// WorkspaceBaseDir returns the state root for a workspace. Stores built on it
+// (run history, session recordings, filebuckets) keep their usual layout
+// below it, so nothing written for one workspace lands in another's paths.
+func WorkspaceBaseDir(baseDir, tenant, workspace string) (string, error) {
+	prefix, err := WorkspacePrefix(tenant, workspace)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, ".masterchef", filepath.FromSlash(prefix)), nil
+}
+
+// IsWorkspaceKey reports whether key falls inside any workspace partition.
+func IsWorkspaceKey(key string) bool {
+	key = sanitizeKey(key)
+	return key == WorkspaceKeyRoot || strings.HasPrefix(key, WorkspaceKeyRoot+"/")
+}
+
+// ScopedObjectStore confines an ObjectStore to one prefix. Callers address
+// objects by keys relative to the prefix and cannot name anything outside it.
+type ScopedObjectStore struct {
+	inner  ObjectStore
+	prefix string
+}
+
+func NewScopedObjectStore(inner ObjectStore, prefix string) (*ScopedObjectStore, error) {
+	if inner == nil {
+		return nil, errors.New("object store is required")
+	}
+	prefix = sanitizeKey(prefix)
+	if prefix == "" || strings.Contains(prefix, "..") {
+		return nil, errors.New("invalid scope prefix")
+	}
+	return &ScopedObjectStore{inner: inner, prefix: prefix}, nil
+}
+
+func (s *ScopedObjectStore) Prefix() string {
+	return s.prefix
+}
+
+func (s *ScopedObjectStore) Put(key string, data []byte, contentType string) (ObjectInfo, error) {
+	full, err := s.scopedKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := s.inner.Put(full, data, contentType)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info.Key = s.relative(info.Key)
+	return info, nil
+}
+
+func (s *ScopedObjectStore) Get(key string) ([]byte, ObjectInfo, error) {
+	full, err := s.scopedKey(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	data, info, err := s.inner.Get(full)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	info.Key = s.relative(info.Key)
+	return data, info, nil
+}
+
+func (s *ScopedObjectStore) Delete(key string) error {
+	full, err := s.scopedKey(key)
+	if err != nil {
+		return err
+	}
+	return s.inner.Delete(full)
+}
+
+func (s *ScopedObjectStore) List(prefix string, limit int) ([]ObjectInfo, error) {
+	full := s.prefix + "/"
+	if p := sanitizeKey(prefix); p != "" {
+		if strings.Contains(p, "..") {
+			return nil, errors.New("invalid object key")
+		}
+		full += p
+	}
+	items, err := s.inner.List(full, limit)
+	if err != nil {
+		return nil, err
+	}
+	// The inner store matches raw string prefixes, so "ws/a" would also
+	// match "ws/ab/..."; keep only keys inside this scope.
+	out := items[:0]
+	for _, item := range items {
+		if !strings.HasPrefix(item.Key, s.prefix+"/") {
+			continue
+		}
+		item.Key = s.relative(item.Key)
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func (s *ScopedObjectStore) scopedKey(key string) (string, error) {
+	key = sanitizeKey(key)
+	if key == "" {
+		return "", errors.New("object key is required")
+	}
+	if strings.Contains(key, "..") {
+		return "", errors.New("invalid object key")
+	}
+	return s.prefix + "/" + key, nil
+}
+
+func (s *ScopedObjectStore) relative(key string) string {
+	return strings.TrimPrefix(key, s.prefix+"/")
+}
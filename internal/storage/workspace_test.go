@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspacePrefixValidation(t *testing.T) {
+	prefix, err := WorkspacePrefix(" Acme ", "Payments")
+	if err != nil || prefix != "workspaces/acme/payments" {
+		t.Fatalf("unexpected prefix %q (%v)", prefix, err)
+	}
+	for _, tc := range [][2]string{{"", "payments"}, {"acme", ""}, {"acme", "../web"}, {"a/b", "web"}, {"acme", ".."}} {
+		if _, err := WorkspacePrefix(tc[0], tc[1]); err == nil {
+			t.Fatalf("expected %q/%q to be rejected", tc[0], tc[1])
+		}
+	}
+	dir, err := WorkspaceBaseDir("/srv", "acme", "web")
+	if err != nil || dir != filepath.Join("/srv", ".masterchef", "workspaces", "acme", "web") {
+		t.Fatalf("unexpected base dir %q (%v)", dir, err)
+	}
+	if !IsWorkspaceKey("/workspaces/acme/web/a.json") || IsWorkspaceKey("workspaces-old/a.json") {
+		t.Fatalf("unexpected workspace key classification")
+	}
+}
+
+func TestScopedObjectStoreConfinesKeys(t *testing.T) {
+	inner, err := NewLocalFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected store init error: %v", err)
+	}
+	a, _ := NewScopedObjectStore(inner, "workspaces/acme/a")
+	ab, _ := NewScopedObjectStore(inner, "workspaces/acme/ab")
+
+	obj, err := a.Put("reports/r1.json", []byte(`{"a":1}`), "application/json")
+	if err != nil || obj.Key != "reports/r1.json" {
+		t.Fatalf("unexpected scoped put %+v (%v)", obj, err)
+	}
+	if _, err := ab.Put("reports/r2.json", []byte(`{"b":1}`), "application/json"); err != nil {
+		t.Fatalf("unexpected scoped put error: %v", err)
+	}
+	if _, _, err := inner.Get("workspaces/acme/a/reports/r1.json"); err != nil {
+		t.Fatalf("expected object under scope prefix: %v", err)
+	}
+
+	items, err := a.List("", 10)
+	if err != nil || len(items) != 1 || items[0].Key != "reports/r1.json" {
+		t.Fatalf("expected only scope-a objects, got %+v (%v)", items, err)
+	}
+	if _, _, err := a.Get("reports/r2.json"); err == nil {
+		t.Fatalf("expected sibling scope object to be unreachable")
+	}
+	for _, key := range []string{"../ab/reports/r2.json", "reports/../../ab/reports/r2.json"} {
+		if _, _, err := a.Get(key); err == nil {
+			t.Fatalf("expected traversal key %q to be rejected", key)
+		}
+	}
+}